@@ -0,0 +1,81 @@
+// Package openapi embeds the OpenAPI 3 specs of the services' HTTP APIs,
+// written spec first, and serves them with a Swagger UI, so clients and
+// their SDKs are generated from the document the service itself serves
+package openapi
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// specs holds the specs, one per service, named after it
+//
+//go:embed *.yaml
+var specs embed.FS
+
+// swaggerUIVersion is the version of the Swagger UI assets the docs page
+// loads
+const swaggerUIVersion = "5.17.14"
+
+// Spec returns the spec of service, e.g. user-service, as JSON
+func Spec(service string) ([]byte, error) {
+	definition, err := specs.ReadFile(service + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI spec of %s: %w", service, err)
+	}
+
+	var spec map[string]interface{}
+	if err := yaml.Unmarshal(definition, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec of %s: %w", service, err)
+	}
+	encoded, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI spec of %s: %w", service, err)
+	}
+	return encoded, nil
+}
+
+// Register serves the spec of service on /openapi.json and a Swagger UI of
+// it on /docs
+func Register(router gin.IRoutes, service string) error {
+	spec, err := Spec(service)
+	if err != nil {
+		return err
+	}
+
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+	})
+	router.GET("/docs", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		swaggerUI.Execute(c.Writer, map[string]string{"Title": service, "Version": swaggerUIVersion, "Spec": "/openapi.json"})
+	})
+	return nil
+}
+
+// swaggerUI is the docs page, loading the Swagger UI assets from a CDN
+var swaggerUI = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>{{.Title}} API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "{{.Spec}}", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`))
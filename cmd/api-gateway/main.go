@@ -1,20 +1,17 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"log"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/spf13/viper"
 
 	"github.com/kaanevranportfolio/Commercium/internal/api-gateway/config"
 	"github.com/kaanevranportfolio/Commercium/internal/api-gateway/server"
+	"github.com/kaanevranportfolio/Commercium/pkg/diagnostics"
+	"github.com/kaanevranportfolio/Commercium/pkg/lifecycle"
 	"github.com/kaanevranportfolio/Commercium/pkg/logger"
 	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
 	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
@@ -36,28 +33,31 @@ func main() {
 	}
 	defer logger.Sync()
 
+	// Parts of the gateway are stopped in the reverse order they are set
+	// up, once it is signalled to stop
+	shutdown := lifecycle.New(cfg.Server.ShutdownTimeout, logger)
+
 	logger.Info("Starting API Gateway", 
 		"version", cfg.Version,
 		"environment", cfg.Environment,
 		"port", cfg.Server.Port,
 	)
 
+	logger.Info("Effective configuration", "config", cfg.Redacted())
+
 	// Initialize tracing
 	tracerProvider, err := tracing.NewTracerProvider(cfg.Tracing, serviceName)
 	if err != nil {
 		logger.Fatal("Failed to initialize tracing", "error", err)
 	}
-	defer func() {
-		if err := tracerProvider.Shutdown(context.Background()); err != nil {
-			logger.Error("Failed to shutdown tracer provider", "error", err)
-		}
-	}()
+	shutdown.Register("tracer", tracerProvider.Shutdown)
 
 	// Initialize metrics
 	metricsRegistry, err := metrics.NewRegistry(cfg.Metrics, serviceName)
 	if err != nil {
 		logger.Fatal("Failed to initialize metrics", "error", err)
 	}
+	shutdown.Register("metrics", metricsRegistry.Shutdown)
 
 	// Set Gin mode
 	if cfg.Environment == "production" {
@@ -69,6 +69,20 @@ func main() {
 	if err != nil {
 		logger.Fatal("Failed to create server", "error", err)
 	}
+	// Publish clickstream events still buffered
+	shutdown.Register("clickstream", lifecycle.Stop(srv.Close))
+
+	// Diagnostics on their internal port, when configured
+	if err := diagnostics.Serve(cfg, shutdown, logger); err != nil {
+		logger.Fatal("Failed to serve diagnostics", "error", err)
+	}
+
+	// Keep in step with the remote configuration shared by all replicas
+	if cfg.Remote.Provider != "" {
+		go config.Watch(shutdown.Context(), cfg, srv.Reload, func(err error) {
+			logger.Error("Remote configuration not applied", "error", err)
+		})
+	}
 
 	// Create HTTP server
 	httpServer := &http.Server{
@@ -87,37 +101,11 @@ func main() {
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	logger.Info("Shutting down server...")
+	shutdown.Register("http server", httpServer.Shutdown)
 
-	// Create a deadline for shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// Attempt graceful shutdown
-	if err := httpServer.Shutdown(ctx); err != nil {
-		logger.Error("Server forced to shutdown", "error", err)
-	} else {
-		logger.Info("Server shutdown complete")
+	if err := shutdown.Wait(); err != nil {
+		logger.Error("API Gateway stopped uncleanly", "error", err)
 	}
-}
 
-func init() {
-	// Set up Viper to read configuration
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("./configs")
-	viper.AddConfigPath(".")
-	
-	// Enable reading from environment variables
-	viper.AutomaticEnv()
-	
-	// Set default values
-	viper.SetDefault("server.port", 8080)
-	viper.SetDefault("environment", "development")
-	viper.SetDefault("logger.level", "info")
+	logger.Info("API Gateway stopped")
 }
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/anonymize"
+)
+
+func (a *app) newAnonymizeCommand() *cobra.Command {
+	var (
+		source    string
+		key       string
+		password  string
+		batchSize int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "anonymize",
+		Short: "Replace the environment's data with an anonymized copy of another database",
+		Long: `Replace the data of the environment's database with an anonymized copy of
+another, usually production's: emails, names, addresses, phone numbers and
+free text are scrambled, while IDs are kept so references between rows hold.
+
+The source is read in a single read-only transaction. It is given with
+--source, or the PG* environment variables of libpq when --source is empty,
+and must be migrated to the same version as the environment's database.
+The environment's database is emptied first; production's never is.
+
+Copies with the same --key scramble values the same, so datasets copied at
+different times can be compared. Every user's password is replaced with
+--password, or users can't log in with one when it isn't given.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, db, err := a.connect()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			if cfg.Environment == "production" {
+				return fmt.Errorf("production's database is never replaced with an anonymized copy")
+			}
+
+			var passwordHash string
+			if password != "" {
+				hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+				if err != nil {
+					return fmt.Errorf("failed to hash password: %w", err)
+				}
+				passwordHash = string(hash)
+			}
+
+			migrationsTable := cfg.Database.MigrationsTable
+			if migrationsTable == "" {
+				migrationsTable = "schema_migrations"
+			}
+			anonymizer, err := anonymize.New(db, anonymize.DefaultRules, anonymize.Options{
+				Key:          []byte(key),
+				PasswordHash: passwordHash,
+				Excluded:     []string{migrationsTable},
+				BatchSize:    batchSize,
+			}, a.log)
+			if err != nil {
+				return err
+			}
+
+			ctx := cmd.Context()
+			conn, err := pgx.Connect(ctx, source)
+			if err != nil {
+				return fmt.Errorf("failed to connect to source database: %w", err)
+			}
+			defer conn.Close(ctx)
+
+			tx, err := conn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+			if err != nil {
+				return fmt.Errorf("failed to begin source transaction: %w", err)
+			}
+			defer tx.Rollback(ctx)
+
+			tables, err := anonymizer.Run(ctx, tx)
+			if err != nil {
+				return fmt.Errorf("failed to copy source database: %w", err)
+			}
+			return printJSON(cmd, tables)
+		},
+	}
+	cmd.Flags().StringVar(&source, "source", "", "connection string of the database copied, e.g. postgres://user@host/db")
+	cmd.Flags().StringVar(&key, "key", "", "key scrambling values, random when not given")
+	cmd.Flags().StringVar(&password, "password", "", "password every user is given")
+	cmd.Flags().IntVar(&batchSize, "batch-size", 1000, "rows copied at a time")
+	return cmd
+}
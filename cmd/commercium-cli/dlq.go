@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/kafka"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+)
+
+func (a *app) newDLQCommand() *cobra.Command {
+	dlq := &cobra.Command{
+		Use:   "dlq",
+		Short: "Inspect and redrive the Kafka dead-letter topics",
+	}
+
+	var (
+		topic     string
+		partition int
+		from      int64
+		limit     int
+		offset    int64
+		offsets   []int64
+	)
+
+	topics := &cobra.Command{
+		Use:   "topics",
+		Short: "List the dead-letter topics and how many messages they hold",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.withDeadLetterQueue(cmd.Context(), false, func(ctx context.Context, queue *kafka.DeadLetterQueue) error {
+				topics, err := queue.Topics(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to list dead-letter topics: %w", err)
+				}
+				return printJSON(cmd, topics)
+			})
+		},
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List the messages of a dead-letter partition",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.withDeadLetterQueue(cmd.Context(), false, func(ctx context.Context, queue *kafka.DeadLetterQueue) error {
+				deadLetters, err := queue.List(ctx, topic, partition, from, limit)
+				if err != nil {
+					return fmt.Errorf("failed to list dead letters: %w", err)
+				}
+				return printJSON(cmd, deadLetters)
+			})
+		},
+	}
+	list.Flags().Int64Var(&from, "offset", 0, "offset to list from")
+	list.Flags().IntVar(&limit, "limit", 20, "maximum number of messages listed")
+
+	show := &cobra.Command{
+		Use:   "show",
+		Short: "Show a message of a dead-letter partition",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.withDeadLetterQueue(cmd.Context(), false, func(ctx context.Context, queue *kafka.DeadLetterQueue) error {
+				deadLetter, err := queue.Get(ctx, topic, partition, offset)
+				if err != nil {
+					return fmt.Errorf("failed to get dead letter: %w", err)
+				}
+				return printJSON(cmd, deadLetter)
+			})
+		},
+	}
+	show.Flags().Int64Var(&offset, "offset", -1, "offset of the message")
+	show.MarkFlagRequired("offset")
+
+	redrive := &cobra.Command{
+		Use:   "redrive",
+		Short: "Publish messages of a dead-letter partition back to their original topic",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.withDeadLetterQueue(cmd.Context(), true, func(ctx context.Context, queue *kafka.DeadLetterQueue) error {
+				redriven, err := queue.Redrive(ctx, topic, partition, offsets)
+				fmt.Fprintf(cmd.OutOrStdout(), "Redrove %d of %d messages\n", redriven, len(offsets))
+				if err != nil {
+					return fmt.Errorf("failed to redrive dead letters: %w", err)
+				}
+				return nil
+			})
+		},
+	}
+	redrive.Flags().Int64SliceVar(&offsets, "offsets", nil, "offsets of the messages to redrive")
+	redrive.MarkFlagRequired("offsets")
+
+	for _, cmd := range []*cobra.Command{list, show, redrive} {
+		cmd.Flags().StringVar(&topic, "topic", "", "dead-letter topic")
+		cmd.Flags().IntVar(&partition, "partition", 0, "partition of the topic")
+		cmd.MarkFlagRequired("topic")
+	}
+
+	dlq.AddCommand(topics, list, show, redrive)
+	return dlq
+}
+
+// withDeadLetterQueue runs fn with the dead-letter queue. When redrive is
+// set, the queue can redrive messages, and the run's metrics are pushed as
+// those of a batch job.
+func (a *app) withDeadLetterQueue(ctx context.Context, redrive bool, fn func(ctx context.Context, queue *kafka.DeadLetterQueue) error) error {
+	cfg, err := a.load(config.ModuleKafka)
+	if err != nil {
+		return err
+	}
+
+	var (
+		producer *kafka.Producer
+		job      *metrics.Job
+	)
+	if redrive {
+		registry := a.newRegistry(cfg)
+		producer, err = kafka.NewProducer(cfg.Kafka, registry, serviceName, a.log)
+		if err != nil {
+			return fmt.Errorf("failed to initialize Kafka producer: %w", err)
+		}
+		defer producer.Close()
+		job = a.startJob(cfg, registry, "dlq_redrive")
+	}
+
+	queue, err := kafka.NewDeadLetterQueue(cfg.Kafka, producer, a.log)
+	if err != nil {
+		return fmt.Errorf("failed to initialize dead-letter queue: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	err = fn(ctx, queue)
+	a.finishJob(job, err)
+	return err
+}
@@ -0,0 +1,159 @@
+// Command commercium-cli administers a running Commercium environment: the
+// one its configuration points to, as loaded by the services (CONFIG_PATH,
+// the environment's overlay and environment variables).
+//
+//	commercium-cli users create-admin --email admin@example.com --username admin
+//	commercium-cli users grant-role seller@example.com seller
+//	commercium-cli tokens issue admin@example.com
+//	commercium-cli migrate status
+//	commercium-cli seed run --sets admin
+//	commercium-cli dlq redrive --topic orders.events.dlq --offsets 42,43
+//	commercium-cli anonymize --source postgres://readonly@production-db/commercium
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+)
+
+const serviceName = "commercium-cli"
+
+// Set at build time
+var (
+	version   = "dev"
+	buildTime = "unknown"
+)
+
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// app holds what the commands share: the environment they run against and
+// the logger, once loaded
+type app struct {
+	configPath  string
+	environment string
+
+	log *logger.Logger
+}
+
+func newRootCommand() *cobra.Command {
+	a := &app{}
+
+	root := &cobra.Command{
+		Use:          "commercium-cli",
+		Short:        "Administer a Commercium environment",
+		Version:      fmt.Sprintf("%s (built %s)", version, buildTime),
+		SilenceUsage: true,
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			if a.log != nil {
+				a.log.Sync()
+			}
+		},
+	}
+	root.PersistentFlags().StringVar(&a.configPath, "config", "", "configuration file of the environment, instead of CONFIG_PATH")
+	root.PersistentFlags().StringVar(&a.environment, "env", "", "environment to run against, instead of the configured one")
+
+	root.AddCommand(
+		a.newUsersCommand(),
+		a.newTokensCommand(),
+		a.newMigrateCommand(),
+		a.newSeedCommand(),
+		a.newDLQCommand(),
+		a.newAnonymizeCommand(),
+	)
+	return root
+}
+
+// load loads the configuration of the modules a command needs, and the
+// logger
+func (a *app) load(modules ...config.Module) (*config.Config, error) {
+	// The configuration is read the way the services read it, so the
+	// flags override it through the environment
+	if a.configPath != "" {
+		os.Setenv("CONFIG_PATH", a.configPath)
+	}
+	if a.environment != "" {
+		os.Setenv("ENVIRONMENT", a.environment)
+	}
+
+	cfg, err := config.Load(modules...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if a.log == nil {
+		a.log, err = logger.New(cfg.Logger, serviceName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize logger: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// connect loads the configuration of modules, along with the database's,
+// and connects to the database
+func (a *app) connect(modules ...config.Module) (*config.Config, *database.DB, error) {
+	cfg, err := a.load(append(modules, config.ModuleDatabase)...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := database.New(cfg.Database, a.log)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return cfg, db, nil
+}
+
+// startJob starts pushing the metrics of registry for a run of command, see
+// metrics.Job. Runs go on without pushing when the Pushgateway can't be
+// reached.
+func (a *app) startJob(cfg *config.Config, registry metrics.Registry, command string) *metrics.Job {
+	job, err := metrics.StartJob(cfg.Metrics, registry, serviceName+"_"+command)
+	if err != nil {
+		a.log.Warn("Failed to push job metrics", "error", err)
+	}
+	return job
+}
+
+// finishJob pushes the outcome of a run, err being the error it failed with
+func (a *app) finishJob(job *metrics.Job, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := job.Finish(ctx, err); err != nil {
+		a.log.Warn("Failed to push job outcome", "error", err)
+	}
+}
+
+// newRegistry creates the registry of a run's metrics, which are pushed to
+// the Pushgateway as the run ends before Prometheus could scrape it
+func (a *app) newRegistry(cfg *config.Config) metrics.Registry {
+	registry, err := metrics.NewRegistry(cfg.Metrics, serviceName)
+	if err != nil {
+		a.log.Warn("Failed to initialize metrics", "error", err)
+	}
+	return registry
+}
+
+// printJSON writes v to the output of cmd as indented JSON
+func printJSON(cmd *cobra.Command, v interface{}) error {
+	encoder := json.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kaanevranportfolio/Commercium/migrations"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+)
+
+func (a *app) newMigrateCommand() *cobra.Command {
+	migrate := &cobra.Command{
+		Use:   "migrate",
+		Short: "Run and inspect the database migrations",
+	}
+
+	status := &cobra.Command{
+		Use:   "status",
+		Short: "List the migrations and whether they were applied",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.withMigrator(func(cfg *config.Config, db *database.DB, migrator *database.Migrator) error {
+				statuses, dirty, err := migrator.Status()
+				if err != nil {
+					return fmt.Errorf("failed to get migration status: %w", err)
+				}
+				for _, status := range statuses {
+					state := "pending"
+					if status.Applied {
+						state = "applied"
+					}
+					fmt.Fprintf(cmd.OutOrStdout(), "%06d  %-8s %s\n", status.Version, state, status.Name)
+				}
+				if dirty {
+					fmt.Fprintln(cmd.OutOrStdout(), "The database is dirty: the last migration failed halfway. Repair it, then force its version.")
+				}
+				return nil
+			})
+		},
+	}
+
+	versionCmd := &cobra.Command{
+		Use:   "version",
+		Short: "Show the current migration version",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.withMigrator(func(cfg *config.Config, db *database.DB, migrator *database.Migrator) error {
+				version, dirty, err := migrator.Version()
+				if err != nil {
+					return fmt.Errorf("failed to get migration version: %w", err)
+				}
+				if dirty {
+					fmt.Fprintf(cmd.OutOrStdout(), "%d (dirty)\n", version)
+				} else {
+					fmt.Fprintln(cmd.OutOrStdout(), version)
+				}
+				return nil
+			})
+		},
+	}
+
+	var tenants bool
+	up := &cobra.Command{
+		Use:   "up",
+		Short: "Apply all pending migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.withMigrator(func(cfg *config.Config, db *database.DB, migrator *database.Migrator) error {
+				registry := a.newRegistry(cfg)
+				db.Instrument(registry, serviceName)
+				job := a.startJob(cfg, registry, "migrate_up")
+
+				err := migrator.Up()
+				if err != nil {
+					err = fmt.Errorf("failed to run database migrations: %w", err)
+				} else if tenants {
+					ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Minute)
+					defer cancel()
+					source := database.NewMigrationSource(cfg.Database, migrations.FS)
+					if err = db.MigrateTenants(ctx, source); err != nil {
+						err = fmt.Errorf("failed to run tenant database migrations: %w", err)
+					}
+				}
+				a.finishJob(job, err)
+				return err
+			})
+		},
+	}
+	up.Flags().BoolVar(&tenants, "tenants", false, "also migrate every tenant schema")
+
+	down := &cobra.Command{
+		Use:   "down N",
+		Short: "Roll back the last N migrations",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("N must be a positive number, not %q", args[0])
+			}
+			return a.withMigrator(func(cfg *config.Config, db *database.DB, migrator *database.Migrator) error {
+				if err := migrator.Steps(-n); err != nil {
+					return fmt.Errorf("failed to roll back migrations: %w", err)
+				}
+				return nil
+			})
+		},
+	}
+
+	force := &cobra.Command{
+		Use:   "force VERSION",
+		Short: "Set the migration version without migrating, to repair a dirty database",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			version, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("VERSION is not a number: %q", args[0])
+			}
+			return a.withMigrator(func(cfg *config.Config, db *database.DB, migrator *database.Migrator) error {
+				if err := migrator.Force(version); err != nil {
+					return fmt.Errorf("failed to force migration version: %w", err)
+				}
+				return nil
+			})
+		},
+	}
+
+	migrate.AddCommand(status, versionCmd, up, down, force)
+	return migrate
+}
+
+// withMigrator runs fn with a migrator of the built-in migrations, closed
+// once it returns
+func (a *app) withMigrator(fn func(cfg *config.Config, db *database.DB, migrator *database.Migrator) error) error {
+	cfg, db, err := a.connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	source := database.NewMigrationSource(cfg.Database, migrations.FS)
+	migrator, err := database.NewSourceMigrator(db.DB, source, a.log)
+	if err != nil {
+		return fmt.Errorf("failed to create migrator: %w", err)
+	}
+	defer migrator.Close()
+
+	return fn(cfg, db, migrator)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/seed"
+)
+
+func (a *app) newSeedCommand() *cobra.Command {
+	seedCmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Fill the database with seed data",
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List the seed sets and the environments they may be run in",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := a.load(config.ModuleSeed)
+			if err != nil {
+				return err
+			}
+			for _, set := range seed.NewRunner(nil, cfg.Seed, a.log).Sets() {
+				fmt.Fprintf(cmd.OutOrStdout(), "%-14s %s (%s)\n", set.Name, set.Description, strings.Join(set.Environments, ", "))
+			}
+			return nil
+		},
+	}
+
+	var sets []string
+	run := &cobra.Command{
+		Use:   "run",
+		Short: "Run the seed sets of the environment",
+		Long: `Run the seed sets of the environment, or those named with --sets.
+Sets may only be run in the environments they are meant for, e.g. the demo
+catalog is never seeded in production.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, db, err := a.connect(config.ModuleSeed)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			registry := a.newRegistry(cfg)
+			db.Instrument(registry, serviceName)
+			job := a.startJob(cfg, registry, "seed_run")
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), time.Minute)
+			defer cancel()
+
+			err = seed.NewRunner(db, cfg.Seed, a.log).Run(ctx, cfg.Environment, sets...)
+			if err != nil {
+				err = fmt.Errorf("failed to seed database: %w", err)
+			}
+			a.finishJob(job, err)
+			return err
+		},
+	}
+	run.Flags().StringSliceVar(&sets, "sets", nil, "seed sets to run instead of all sets of the environment")
+
+	seedCmd.AddCommand(list, run)
+	return seedCmd
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/tenant"
+)
+
+// testToken is an access token issued for testing. Unlike a login, no
+// refresh token comes with it: the user service only redeems those it
+// issued itself.
+type testToken struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresIn   int64     `json:"expires_in"`
+	UserID      uuid.UUID `json:"user_id"`
+	Role        string    `json:"role"`
+}
+
+func (a *app) newTokensCommand() *cobra.Command {
+	tokens := &cobra.Command{
+		Use:   "tokens",
+		Short: "Issue tokens for testing",
+	}
+
+	var (
+		expiration time.Duration
+		tenantID   string
+	)
+	issue := &cobra.Command{
+		Use:   "issue EMAIL",
+		Short: "Issue an access token of a user",
+		Long: `Issue an access token of a user, signed with the environment's JWT key,
+e.g. to call the API as that user from scripts. With --tenant, the user is
+one of that tenant, and the token only valid for it. Tokens aren't issued in
+production, nor for deactivated users.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			email := args[0]
+
+			cfg, db, err := a.connect(config.ModuleAuth)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			if cfg.Environment == "production" {
+				return fmt.Errorf("test tokens aren't issued in production")
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+			defer cancel()
+			if tenantID != "" {
+				if err := tenant.Validate(tenantID); err != nil {
+					return err
+				}
+				ctx = tenant.WithTenant(ctx, tenantID)
+			}
+
+			var user struct {
+				ID       uuid.UUID `db:"id"`
+				Username string    `db:"username"`
+				Role     string    `db:"role"`
+				IsActive bool      `db:"is_active"`
+			}
+			err = db.GetContext(ctx, &user, `SELECT id, username, COALESCE(role, 'customer') AS role, is_active FROM users WHERE email = $1`, email)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("user %s not found", email)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get user %s: %w", email, err)
+			}
+			if !user.IsActive {
+				return fmt.Errorf("user %s is deactivated", email)
+			}
+
+			jwtConfig := cfg.Auth.JWT
+			if expiration > 0 {
+				jwtConfig.Expiration = expiration
+			}
+			pair, err := auth.NewJWTService(&jwtConfig).GenerateTokenPair(user.ID, email, user.Username, user.Role, tenantID)
+			if err != nil {
+				return fmt.Errorf("failed to issue token: %w", err)
+			}
+
+			a.log.Info("Test token issued", "user_id", user.ID, "email", email, "expires_in", pair.ExpiresIn)
+			return printJSON(cmd, testToken{
+				AccessToken: pair.AccessToken,
+				TokenType:   pair.TokenType,
+				ExpiresIn:   pair.ExpiresIn,
+				UserID:      user.ID,
+				Role:        user.Role,
+			})
+		},
+	}
+	issue.Flags().DurationVar(&expiration, "expiration", 0, "lifetime of the token, instead of the configured one")
+	issue.Flags().StringVar(&tenantID, "tenant", "", "tenant the user is one of")
+
+	tokens.AddCommand(issue)
+	return tokens
+}
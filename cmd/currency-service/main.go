@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kaanevranportfolio/Commercium/internal/currency/handlers"
+	"github.com/kaanevranportfolio/Commercium/internal/currency/rates"
+	"github.com/kaanevranportfolio/Commercium/internal/currency/repository"
+	"github.com/kaanevranportfolio/Commercium/internal/currency/service"
+	"github.com/kaanevranportfolio/Commercium/migrations"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/diagnostics"
+	"github.com/kaanevranportfolio/Commercium/pkg/health"
+	"github.com/kaanevranportfolio/Commercium/pkg/i18n"
+	"github.com/kaanevranportfolio/Commercium/pkg/lifecycle"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+	"github.com/kaanevranportfolio/Commercium/pkg/scheduler"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+const serviceName = "currency-service"
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load(config.ModuleServer, config.ModuleDatabase, config.ModuleAuth)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load configuration: %v", err))
+	}
+
+	// Initialize logger
+	log, err := logger.New(cfg.Logger, serviceName)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
+	}
+	defer log.Sync()
+
+	// Parts of the service are stopped in the reverse order they are set
+	// up, once it is signalled to stop
+	shutdown := lifecycle.New(cfg.Server.ShutdownTimeout, log)
+
+	log.Info("Starting Currency Service",
+		"version", cfg.Version,
+		"environment", cfg.Environment,
+		"port", cfg.Server.Port,
+	)
+
+	log.Info("Effective configuration", "config", cfg.Redacted())
+
+	// Initialize tracing
+	tracerProvider, err := tracing.NewTracerProvider(cfg.Tracing, serviceName)
+	if err != nil {
+		log.Error("Failed to initialize tracing", "error", err)
+	} else {
+		shutdown.Register("tracer", tracerProvider.Shutdown)
+	}
+
+	// Initialize metrics
+	metricsRegistry, err := metrics.NewRegistry(cfg.Metrics, serviceName)
+	if err != nil {
+		log.Error("Failed to initialize metrics", "error", err)
+	} else {
+		shutdown.Register("metrics", metricsRegistry.Shutdown)
+	}
+
+	// Initialize database
+	db, err := database.New(cfg.Database, log)
+	if err != nil {
+		log.Fatal("Failed to connect to database", "error", err)
+	}
+	shutdown.Register("database", lifecycle.Close(db.Close))
+	db.Instrument(metricsRegistry, serviceName)
+
+	// Run database migrations, unless they are run with the migrate command
+	if !cfg.Database.SkipMigrations {
+		if err := db.Migrate(database.NewMigrationSource(cfg.Database, migrations.FS)); err != nil {
+			log.Fatal("Failed to run database migrations", "error", err)
+		}
+	}
+
+	// Initialize JWT service
+	jwtService := auth.NewJWTService(&cfg.Auth.JWT)
+
+	// Initialize exchange rate provider
+	rateProvider, err := rates.NewProvider(cfg.Services.Currency)
+	if err != nil {
+		log.Fatal("Failed to initialize exchange rate provider", "error", err)
+	}
+
+	// Initialize repositories
+	currencyRepo := repository.NewCurrencyRepository(db, log)
+
+	// Initialize services
+	currencyService := service.NewCurrencyService(currencyRepo, rateProvider, cfg, log)
+
+	// Initialize handlers
+	currencyHandler := handlers.NewCurrencyHandler(currencyService, jwtService, log)
+
+	// Start background workers
+	workerCtx := shutdown.Context()
+
+	// Refresh exchange rates on a schedule, and at startup so a fresh
+	// deployment has rates right away
+	jobs := scheduler.New(serviceName, nil, scheduler.NewHistory(db, log), metricsRegistry, log)
+	jobs.Schedule("exchange_rate_refresh", scheduler.Every(cfg.Services.Currency.Rates.RefreshInterval), func(ctx context.Context) error {
+		_, err := currencyService.RefreshRates(ctx)
+		return err
+	}, scheduler.AtStart())
+	go jobs.Run(workerCtx)
+
+	// Setup Gin router
+	if cfg.Environment == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router := gin.New()
+	// Client IPs are only taken from X-Forwarded-For behind trusted proxies
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatal("Failed to set trusted proxies", "error", err)
+	}
+
+	// Add middleware
+	router.Use(gin.Logger())
+	router.Use(logger.Recovery())
+	// Requests run in a span, with a logger scoped to them
+	router.Use(tracing.Middleware(serviceName), logger.Middleware(log))
+	// Responses are in the language clients accept
+	router.Use(i18n.Middleware())
+	if metricsRegistry != nil {
+		router.Use(metricsRegistry.HTTPMiddleware(serviceName))
+	}
+	// Handlers failing with c.Error are answered with problem details,
+	// registered after the middleware reading the status of responses
+	router.Use(apperrors.Middleware())
+
+	// Health checks
+	checks := health.NewRegistry(serviceName, cfg.Version)
+	checks.Register("database", health.Func(db.HealthCheck))
+	checks.Routes(router)
+
+	// Setup currency routes
+	currencyHandler.SetupRoutes(router)
+
+	// Setup metrics endpoint
+	router.GET("/metrics", func(c *gin.Context) {
+		if metricsRegistry != nil {
+			metricsRegistry.Handler().ServeHTTP(c.Writer, c.Request)
+		} else {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "metrics not available"})
+		}
+	})
+
+	// Setup SLO status endpoint
+	router.GET("/slo", func(c *gin.Context) {
+		if metricsRegistry != nil {
+			metricsRegistry.SLOHandler().ServeHTTP(c.Writer, c.Request)
+		} else {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "metrics not available"})
+		}
+	})
+
+	// Effective configuration, for operators
+	router.GET("/debug/config", jwtService.Middleware(), auth.RequireRole("admin"), config.DebugHandler(cfg))
+
+	// Profiles, goroutine dumps and build info, for operators
+	if err := diagnostics.Setup(cfg, router, shutdown, log, jwtService.Middleware(), auth.RequireRole("admin")); err != nil {
+		log.Fatal("Failed to serve diagnostics", "error", err)
+	}
+
+	// Start HTTP server
+	srv := &http.Server{
+		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+
+	// Start server in a goroutine
+	go func() {
+		log.Info("Currency service starting", "address", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Failed to start server", "error", err)
+		}
+	}()
+
+	shutdown.Register("http server", srv.Shutdown)
+
+	if err := shutdown.Wait(); err != nil {
+		log.Error("Currency Service stopped uncleanly", "error", err)
+	}
+
+	log.Info("Currency Service stopped")
+}
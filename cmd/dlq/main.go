@@ -0,0 +1,211 @@
+// Command dlq inspects the Kafka dead-letter topics consumer groups park
+// failed messages in, and redrives messages back to the topic they failed on.
+//
+//	dlq topics
+//	dlq list -topic orders.events.dlq [-partition 0] [-offset 0] [-limit 20]
+//	dlq show -topic orders.events.dlq -partition 0 -offset 42
+//	dlq redrive -topic orders.events.dlq -partition 0 -offsets 42,43
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/kafka"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+)
+
+const serviceName = "dlq"
+
+const usage = `Usage: dlq <command> [flags]
+
+Commands:
+  topics    list the dead-letter topics and how many messages they hold
+  list      list the messages of a dead-letter partition
+  show      show a message of a dead-letter partition
+  redrive   publish messages of a dead-letter partition back to their original topic
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Load configuration
+	cfg, err := config.Load(config.ModuleKafka)
+	if err != nil {
+		fail("Failed to load configuration: %v", err)
+	}
+
+	// Initialize logger
+	log, err := logger.New(cfg.Logger, serviceName)
+	if err != nil {
+		fail("Failed to initialize logger: %v", err)
+	}
+	defer log.Sync()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "topics":
+		queue := newDeadLetterQueue(cfg, nil, log)
+		topics, err := queue.Topics(ctx)
+		if err != nil {
+			fail("Failed to list dead-letter topics: %v", err)
+		}
+		printJSON(topics)
+
+	case "list":
+		flags := flag.NewFlagSet("list", flag.ExitOnError)
+		topic := flags.String("topic", "", "dead-letter topic")
+		partition := flags.Int("partition", 0, "partition of the topic")
+		offset := flags.Int64("offset", 0, "offset to list from")
+		limit := flags.Int("limit", 20, "maximum number of messages listed")
+		flags.Parse(args)
+		requireTopic(flags, *topic)
+
+		queue := newDeadLetterQueue(cfg, nil, log)
+		deadLetters, err := queue.List(ctx, *topic, *partition, *offset, *limit)
+		if err != nil {
+			fail("Failed to list dead letters: %v", err)
+		}
+		printJSON(deadLetters)
+
+	case "show":
+		flags := flag.NewFlagSet("show", flag.ExitOnError)
+		topic := flags.String("topic", "", "dead-letter topic")
+		partition := flags.Int("partition", 0, "partition of the topic")
+		offset := flags.Int64("offset", -1, "offset of the message")
+		flags.Parse(args)
+		requireTopic(flags, *topic)
+
+		queue := newDeadLetterQueue(cfg, nil, log)
+		deadLetter, err := queue.Get(ctx, *topic, *partition, *offset)
+		if err != nil {
+			fail("Failed to get dead letter: %v", err)
+		}
+		printJSON(deadLetter)
+
+	case "redrive":
+		flags := flag.NewFlagSet("redrive", flag.ExitOnError)
+		topic := flags.String("topic", "", "dead-letter topic")
+		partition := flags.Int("partition", 0, "partition of the topic")
+		offsetList := flags.String("offsets", "", "comma-separated offsets of the messages to redrive")
+		flags.Parse(args)
+		requireTopic(flags, *topic)
+
+		offsets, err := parseOffsets(*offsetList)
+		if err != nil {
+			fail("Invalid offsets: %v", err)
+		}
+
+		// The run ends before Prometheus could scrape it, so its metrics
+		// are pushed to the Pushgateway
+		registry, err := metrics.NewRegistry(cfg.Metrics, serviceName)
+		if err != nil {
+			log.Warn("Failed to initialize metrics", "error", err)
+		}
+
+		producer, err := kafka.NewProducer(cfg.Kafka, registry, serviceName, log)
+		if err != nil {
+			fail("Failed to initialize Kafka producer: %v", err)
+		}
+		defer producer.Close()
+		job := startJob(cfg, registry, command, log)
+
+		queue := newDeadLetterQueue(cfg, producer, log)
+		redriven, err := queue.Redrive(ctx, *topic, *partition, offsets)
+		fmt.Printf("Redrove %d of %d messages\n", redriven, len(offsets))
+		if err != nil {
+			producer.Close()
+			finishJob(job, err, log)
+			fail("Failed to redrive dead letters: %v", err)
+		}
+		finishJob(job, nil, log)
+
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// newDeadLetterQueue creates the dead-letter queue, redriving with producer
+func newDeadLetterQueue(cfg *config.Config, producer *kafka.Producer, log *logger.Logger) *kafka.DeadLetterQueue {
+	queue, err := kafka.NewDeadLetterQueue(cfg.Kafka, producer, log)
+	if err != nil {
+		fail("Failed to initialize dead-letter queue: %v", err)
+	}
+	return queue
+}
+
+// requireTopic exits with the usage of a command when no topic was given
+func requireTopic(flags *flag.FlagSet, topic string) {
+	if topic == "" {
+		fmt.Fprintln(os.Stderr, "-topic is required")
+		flags.Usage()
+		os.Exit(2)
+	}
+}
+
+// parseOffsets parses a comma-separated list of offsets
+func parseOffsets(list string) ([]int64, error) {
+	if list == "" {
+		return nil, fmt.Errorf("no offsets given")
+	}
+
+	var offsets []int64
+	for _, field := range strings.Split(list, ",") {
+		offset, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("%q is not an offset", field)
+		}
+		offsets = append(offsets, offset)
+	}
+	return offsets, nil
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		fail("Failed to encode output: %v", err)
+	}
+}
+
+// startJob starts pushing the metrics of registry for a run of command, see
+// metrics.Job. Runs go on without pushing when the Pushgateway can't be
+// reached.
+func startJob(cfg *config.Config, registry metrics.Registry, command string, log *logger.Logger) *metrics.Job {
+	job, err := metrics.StartJob(cfg.Metrics, registry, serviceName+"_"+command)
+	if err != nil {
+		log.Warn("Failed to push job metrics", "error", err)
+	}
+	return job
+}
+
+// finishJob pushes the outcome of a run, err being the error it failed with
+func finishJob(job *metrics.Job, err error, log *logger.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := job.Finish(ctx, err); err != nil {
+		log.Warn("Failed to push job outcome", "error", err)
+	}
+}
+
+// fail prints an error and exits
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
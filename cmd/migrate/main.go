@@ -0,0 +1,187 @@
+// Command migrate runs the database migrations on demand, for deployments
+// that don't let every replica migrate when it starts (see
+// database.skip_migrations).
+//
+//	migrate status
+//	migrate version
+//	migrate up [-tenants]
+//	migrate down N
+//	migrate force VERSION
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/migrations"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+)
+
+const serviceName = "migrate"
+
+const usage = `Usage: migrate <command> [arguments]
+
+Commands:
+  status          list the migrations and whether they were applied
+  version         show the current migration version
+  up              apply all pending migrations; -tenants also migrates every tenant schema
+  down N          roll back the last N migrations
+  force VERSION   set the migration version without migrating, to repair a dirty database
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Load configuration
+	cfg, err := config.Load(config.ModuleDatabase)
+	if err != nil {
+		fail("Failed to load configuration: %v", err)
+	}
+
+	// Initialize logger
+	log, err := logger.New(cfg.Logger, serviceName)
+	if err != nil {
+		fail("Failed to initialize logger: %v", err)
+	}
+	defer log.Sync()
+
+	db, err := database.New(cfg.Database, log)
+	if err != nil {
+		fail("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	source := database.NewMigrationSource(cfg.Database, migrations.FS)
+	migrator, err := database.NewSourceMigrator(db.DB, source, log)
+	if err != nil {
+		fail("Failed to create migrator: %v", err)
+	}
+	defer migrator.Close()
+
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "status":
+		statuses, dirty, err := migrator.Status()
+		if err != nil {
+			fail("Failed to get migration status: %v", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%06d  %-8s %s\n", status.Version, state, status.Name)
+		}
+		if dirty {
+			fmt.Println("The database is dirty: the last migration failed halfway. Repair it, then force its version.")
+		}
+
+	case "version":
+		version, dirty, err := migrator.Version()
+		if err != nil {
+			fail("Failed to get migration version: %v", err)
+		}
+		if dirty {
+			fmt.Printf("%d (dirty)\n", version)
+		} else {
+			fmt.Println(version)
+		}
+
+	case "up":
+		flags := flag.NewFlagSet("up", flag.ExitOnError)
+		tenants := flags.Bool("tenants", false, "also migrate every tenant schema")
+		flags.Parse(args)
+
+		// The run ends before Prometheus could scrape it, so its metrics
+		// are pushed to the Pushgateway
+		registry, err := metrics.NewRegistry(cfg.Metrics, serviceName)
+		if err != nil {
+			log.Warn("Failed to initialize metrics", "error", err)
+		}
+		db.Instrument(registry, serviceName)
+		job := startJob(cfg, registry, command, log)
+
+		if err := migrator.Up(); err != nil {
+			finishJob(job, err, log)
+			fail("Failed to run database migrations: %v", err)
+		}
+		if *tenants {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+			defer cancel()
+			if err := db.MigrateTenants(ctx, source); err != nil {
+				finishJob(job, err, log)
+				fail("Failed to run tenant database migrations: %v", err)
+			}
+		}
+		finishJob(job, nil, log)
+
+	case "down":
+		n := parseArg(args, "N")
+		if n <= 0 {
+			fail("N must be positive")
+		}
+		if err := migrator.Steps(-n); err != nil {
+			fail("Failed to roll back migrations: %v", err)
+		}
+
+	case "force":
+		version := parseArg(args, "VERSION")
+		if err := migrator.Force(version); err != nil {
+			fail("Failed to force migration version: %v", err)
+		}
+
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// parseArg parses the single integer argument of a command
+func parseArg(args []string, name string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "%s is required\n", name)
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	value, err := strconv.Atoi(args[0])
+	if err != nil {
+		fail("%s is not a number: %q", name, args[0])
+	}
+	return value
+}
+
+// startJob starts pushing the metrics of registry for a run of command, see
+// metrics.Job. Runs go on without pushing when the Pushgateway can't be
+// reached.
+func startJob(cfg *config.Config, registry metrics.Registry, command string, log *logger.Logger) *metrics.Job {
+	job, err := metrics.StartJob(cfg.Metrics, registry, serviceName+"_"+command)
+	if err != nil {
+		log.Warn("Failed to push job metrics", "error", err)
+	}
+	return job
+}
+
+// finishJob pushes the outcome of a run, err being the error it failed with
+func finishJob(job *metrics.Job, err error, log *logger.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := job.Finish(ctx, err); err != nil {
+		log.Warn("Failed to push job outcome", "error", err)
+	}
+}
+
+// fail prints an error and exits
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/clients"
+	"github.com/kaanevranportfolio/Commercium/internal/order/handlers"
+	"github.com/kaanevranportfolio/Commercium/internal/order/repository"
+	"github.com/kaanevranportfolio/Commercium/internal/order/service"
+	"github.com/kaanevranportfolio/Commercium/internal/order/tax"
+	"github.com/kaanevranportfolio/Commercium/migrations"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/diagnostics"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+	"github.com/kaanevranportfolio/Commercium/pkg/health"
+	"github.com/kaanevranportfolio/Commercium/pkg/i18n"
+	"github.com/kaanevranportfolio/Commercium/pkg/kafka"
+	"github.com/kaanevranportfolio/Commercium/pkg/lifecycle"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+	"github.com/kaanevranportfolio/Commercium/pkg/resilience"
+	"github.com/kaanevranportfolio/Commercium/pkg/retry"
+	"github.com/kaanevranportfolio/Commercium/pkg/scheduler"
+	"github.com/kaanevranportfolio/Commercium/pkg/schemaregistry"
+	"github.com/kaanevranportfolio/Commercium/pkg/storage"
+	"github.com/kaanevranportfolio/Commercium/pkg/tenant"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+	eventspb "github.com/kaanevranportfolio/Commercium/proto/events"
+)
+
+const serviceName = "order-service"
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load(config.ModuleServer, config.ModuleDatabase, config.ModuleAuth, config.ModuleKafka, config.ModuleRedis, config.ModuleStorage, config.ModuleTenancy)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load configuration: %v", err))
+	}
+
+	// Initialize logger
+	log, err := logger.New(cfg.Logger, serviceName)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
+	}
+	defer log.Sync()
+
+	// Parts of the service are stopped in the reverse order they are set
+	// up, once it is signalled to stop
+	shutdown := lifecycle.New(cfg.Server.ShutdownTimeout, log)
+
+	log.Info("Starting Order Service",
+		"version", cfg.Version,
+		"environment", cfg.Environment,
+		"port", cfg.Server.Port,
+	)
+
+	log.Info("Effective configuration", "config", cfg.Redacted())
+
+	// Initialize tracing
+	tracerProvider, err := tracing.NewTracerProvider(cfg.Tracing, serviceName)
+	if err != nil {
+		log.Error("Failed to initialize tracing", "error", err)
+	} else {
+		shutdown.Register("tracer", tracerProvider.Shutdown)
+	}
+
+	// Initialize metrics
+	metricsRegistry, err := metrics.NewRegistry(cfg.Metrics, serviceName)
+	if err != nil {
+		log.Error("Failed to initialize metrics", "error", err)
+	} else {
+		shutdown.Register("metrics", metricsRegistry.Shutdown)
+	}
+
+	// Initialize database
+	db, err := database.New(cfg.Database, log)
+	if err != nil {
+		log.Fatal("Failed to connect to database", "error", err)
+	}
+	shutdown.Register("database", lifecycle.Close(db.Close))
+	db.Instrument(metricsRegistry, serviceName)
+
+	// Run database migrations, unless they are run with the migrate command
+	if !cfg.Database.SkipMigrations {
+		migrationSource := database.NewMigrationSource(cfg.Database, migrations.FS)
+		if err := db.Migrate(migrationSource); err != nil {
+			log.Fatal("Failed to run database migrations", "error", err)
+		}
+
+		// Each tenant's schema is migrated along with the shared one
+		if cfg.Tenancy.Enabled {
+			if err := db.MigrateTenants(context.Background(), migrationSource); err != nil {
+				log.Fatal("Failed to run tenant database migrations", "error", err)
+			}
+		}
+	}
+
+	// Initialize Kafka producer for order events
+	var publisher service.EventPublisher
+	producer, err := kafka.NewProducer(cfg.Kafka, metricsRegistry, serviceName, log)
+	if err != nil {
+		log.Error("Failed to initialize Kafka producer, order events disabled", "error", err)
+	} else {
+		shutdown.Register("kafka producer", lifecycle.Close(producer.Close))
+
+		// Events that don't match their schema are refused. Schema changes
+		// consumers can't read fail here, before anything is published.
+		schemas := events.NewSchemaSet([]*events.Schema{eventspb.OrderEventSchema}, schemaregistry.NewRegistry(cfg.Kafka.SchemaRegistry), log)
+		if err := schemas.Register(context.Background()); err != nil {
+			log.Fatal("Failed to register event schemas", "error", err)
+		}
+		producer.ValidateWith(schemas)
+		publisher = producer
+	}
+
+	// Initialize JWT service
+	jwtService := auth.NewJWTService(&cfg.Auth.JWT)
+
+	// Downstream services are called with the resilience policy configured
+	// for each
+	dependency := func(name string) *resilience.Dependency {
+		return resilience.New(name, cfg.Services.Resilience.Policy(name, cfg.Services.Timeout), metricsRegistry)
+	}
+
+	// Initialize clients for downstream services
+	paymentClient := clients.NewPaymentClient(cfg.Services.PaymentURL, dependency("payment-service"))
+	inventoryClient := clients.NewInventoryClient(cfg.Services.InventoryURL, dependency("inventory-service"))
+
+	// Amounts are shown in the customer's display currency when the currency service is available
+	var currencyClient clients.CurrencyClient
+	if cfg.Services.CurrencyURL != "" {
+		currencyClient = clients.NewCurrencyClient(cfg.Services.CurrencyURL, dependency("currency-service"))
+	}
+
+	// Checkout charges the customer's prices when the pricing service is available
+	var pricingClient clients.PricingClient
+	if cfg.Services.PricingURL != "" {
+		pricingClient = clients.NewPricingClient(cfg.Services.PricingURL, dependency("pricing-service"))
+	}
+
+	// Initialize tax provider
+	taxProvider, err := tax.NewProvider(cfg.Services.Order.Tax)
+	if err != nil {
+		log.Fatal("Failed to initialize tax provider", "error", err)
+	}
+
+	// Initialize object storage for invoice PDFs
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatal("Failed to initialize storage", "error", err)
+	}
+
+	// Initialize repositories
+	orderRepo := repository.NewOrderRepository(db, log)
+
+	// Initialize services
+	orderService := service.NewOrderService(orderRepo, paymentClient, inventoryClient, currencyClient, pricingClient, taxProvider, store, publisher, cfg, log)
+
+	// Start background workers
+	workerCtx := shutdown.Context()
+
+	// Roll back stuck checkouts
+	recoveryWorker := service.NewRecoveryWorker(orderService, cfg.Services.Order.Saga.RecoveryInterval, cfg.Services.Order.Saga.BatchSize, log)
+	go recoveryWorker.Run(workerCtx)
+
+	// Release the stock of abandoned checkouts on a schedule, on one replica
+	// at a time when Redis is available
+	var locker scheduler.Locker
+	redis, err := database.NewRedis(cfg.Redis, log)
+	if err != nil {
+		log.Error("Failed to connect to Redis, scheduled jobs run unlocked", "error", err)
+	} else {
+		shutdown.Register("redis", lifecycle.Close(redis.Close))
+		redis.Instrument(metricsRegistry, serviceName)
+		locker = redis
+	}
+
+	reservationCfg := cfg.Services.Order.Reservations
+	reservationJob := service.NewReservationJob(orderService, metricsRegistry, serviceName, reservationCfg.BatchSize, log)
+	jobs := scheduler.New(serviceName, locker, scheduler.NewHistory(db, log), metricsRegistry, log)
+	jobs.Schedule("abandoned_checkouts", scheduler.Every(reservationCfg.ExpiryInterval), reservationJob.Run)
+	go jobs.Run(workerCtx)
+
+	// Keep the order read model up to date from order and payment events
+	projectionCfg := cfg.Services.Order.Projection
+	consumers, err := kafka.NewConsumerGroup(cfg.Kafka, projectionCfg.ConsumerGroup, producer, log)
+	if err != nil {
+		log.Error("Failed to initialize Kafka consumer, read model updates from events disabled", "error", err)
+	} else {
+		// Fail fast if producers registered order or payment events this
+		// build can't read
+		consumerSchemas := events.NewSchemaSet([]*events.Schema{eventspb.OrderEventSchema, eventspb.PaymentEventSchema},
+			schemaregistry.NewRegistry(cfg.Kafka.SchemaRegistry), log)
+		if err := consumerSchemas.CheckCompatibility(context.Background()); err != nil {
+			log.Fatal("Consumed event schemas are incompatible", "error", err)
+		}
+
+		consumers.Register(cfg.Kafka.Topics.OrderEvents, service.ProjectionHandler(orderService))
+		consumers.Register(cfg.Kafka.Topics.PaymentEvents, service.ProjectionHandler(orderService))
+		retryPolicy := retry.FromConfig(projectionCfg.Retry, kafka.DefaultRetryPolicy(cfg.Kafka))
+		consumers.SetRetryPolicy(cfg.Kafka.Topics.OrderEvents, retryPolicy)
+		consumers.SetRetryPolicy(cfg.Kafka.Topics.PaymentEvents, retryPolicy)
+		go consumers.Run()
+		shutdown.Register("kafka consumers", consumers.Shutdown)
+	}
+
+	// Catch the read model up with orders whose events were missed
+	projectionWorker := service.NewProjectionWorker(orderService, projectionCfg.Interval, projectionCfg.BatchSize, log)
+	go projectionWorker.Run(workerCtx)
+
+	// Initialize handlers
+	orderHandler := handlers.NewOrderHandler(orderService, jwtService, log)
+
+	// Setup Gin router
+	if cfg.Environment == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router := gin.New()
+	// Client IPs are only taken from X-Forwarded-For behind trusted proxies
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatal("Failed to set trusted proxies", "error", err)
+	}
+
+	// Add middleware
+	router.Use(gin.Logger())
+	router.Use(logger.Recovery())
+	// Requests run in a span, with a logger scoped to them
+	router.Use(tracing.Middleware(serviceName), logger.Middleware(log))
+	// Responses are in the language clients accept
+	router.Use(i18n.Middleware())
+	if metricsRegistry != nil {
+		router.Use(metricsRegistry.HTTPMiddleware(serviceName))
+	}
+	// Handlers failing with c.Error are answered with problem details,
+	// registered after the middleware reading the status of responses
+	router.Use(apperrors.Middleware())
+	router.Use(tenant.Middleware(cfg.Tenancy, jwtService, db))
+
+	// Events are lost while Kafka is unreachable, so the service is only
+	// ready when it can publish them
+	checks := health.NewRegistry(serviceName, cfg.Version)
+	checks.Register("database", health.Func(db.HealthCheck))
+	if producer != nil {
+		checks.Register("kafka", health.Func(producer.HealthCheck))
+	} else {
+		checks.Register("kafka", health.Unavailable("kafka producer failed to initialize"))
+	}
+	checks.Routes(router)
+
+	// Setup order routes
+	orderHandler.SetupRoutes(router)
+
+	// Files kept on local disk are downloaded from this service
+	if localStore, ok := store.(*storage.LocalStore); ok {
+		router.GET("/files/*key", gin.WrapH(localStore.Handler("/files")))
+	}
+
+	// Setup metrics endpoint
+	router.GET("/metrics", func(c *gin.Context) {
+		if metricsRegistry != nil {
+			metricsRegistry.Handler().ServeHTTP(c.Writer, c.Request)
+		} else {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "metrics not available"})
+		}
+	})
+
+	// Setup SLO status endpoint
+	router.GET("/slo", func(c *gin.Context) {
+		if metricsRegistry != nil {
+			metricsRegistry.SLOHandler().ServeHTTP(c.Writer, c.Request)
+		} else {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "metrics not available"})
+		}
+	})
+
+	// Effective configuration, for operators
+	router.GET("/debug/config", jwtService.Middleware(), auth.RequireRole("admin"), config.DebugHandler(cfg))
+
+	// Profiles, goroutine dumps and build info, for operators
+	if err := diagnostics.Setup(cfg, router, shutdown, log, jwtService.Middleware(), auth.RequireRole("admin")); err != nil {
+		log.Fatal("Failed to serve diagnostics", "error", err)
+	}
+
+	// Start HTTP server
+	srv := &http.Server{
+		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+
+	// Start server in a goroutine
+	go func() {
+		log.Info("Order service starting", "address", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Failed to start server", "error", err)
+		}
+	}()
+
+	shutdown.Register("http server", srv.Shutdown)
+
+	if err := shutdown.Wait(); err != nil {
+		log.Error("Order Service stopped uncleanly", "error", err)
+	}
+
+	log.Info("Order Service stopped")
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kaanevranportfolio/Commercium/internal/review/handlers"
+	"github.com/kaanevranportfolio/Commercium/internal/review/repository"
+	"github.com/kaanevranportfolio/Commercium/internal/review/service"
+	"github.com/kaanevranportfolio/Commercium/migrations"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/cache"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/diagnostics"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+	"github.com/kaanevranportfolio/Commercium/pkg/health"
+	"github.com/kaanevranportfolio/Commercium/pkg/i18n"
+	"github.com/kaanevranportfolio/Commercium/pkg/kafka"
+	"github.com/kaanevranportfolio/Commercium/pkg/lifecycle"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+	"github.com/kaanevranportfolio/Commercium/pkg/schemaregistry"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+	eventspb "github.com/kaanevranportfolio/Commercium/proto/events"
+)
+
+const serviceName = "review-service"
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load(config.ModuleServer, config.ModuleDatabase, config.ModuleAuth, config.ModuleKafka, config.ModuleRedis)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load configuration: %v", err))
+	}
+
+	// Initialize logger
+	log, err := logger.New(cfg.Logger, serviceName)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
+	}
+	defer log.Sync()
+
+	// Parts of the service are stopped in the reverse order they are set
+	// up, once it is signalled to stop
+	shutdown := lifecycle.New(cfg.Server.ShutdownTimeout, log)
+
+	log.Info("Starting Review Service",
+		"version", cfg.Version,
+		"environment", cfg.Environment,
+		"port", cfg.Server.Port,
+	)
+
+	log.Info("Effective configuration", "config", cfg.Redacted())
+
+	// Initialize tracing
+	tracerProvider, err := tracing.NewTracerProvider(cfg.Tracing, serviceName)
+	if err != nil {
+		log.Error("Failed to initialize tracing", "error", err)
+	} else {
+		shutdown.Register("tracer", tracerProvider.Shutdown)
+	}
+
+	// Initialize metrics
+	metricsRegistry, err := metrics.NewRegistry(cfg.Metrics, serviceName)
+	if err != nil {
+		log.Error("Failed to initialize metrics", "error", err)
+	} else {
+		shutdown.Register("metrics", metricsRegistry.Shutdown)
+	}
+
+	// Initialize database
+	db, err := database.New(cfg.Database, log)
+	if err != nil {
+		log.Fatal("Failed to connect to database", "error", err)
+	}
+	shutdown.Register("database", lifecycle.Close(db.Close))
+	db.Instrument(metricsRegistry, serviceName)
+
+	// Run database migrations, unless they are run with the migrate command
+	if !cfg.Database.SkipMigrations {
+		if err := db.Migrate(database.NewMigrationSource(cfg.Database, migrations.FS)); err != nil {
+			log.Fatal("Failed to run database migrations", "error", err)
+		}
+	}
+
+	// Initialize Kafka producer for review events
+	var publisher service.EventPublisher
+	producer, err := kafka.NewProducer(cfg.Kafka, metricsRegistry, serviceName, log)
+	if err != nil {
+		log.Error("Failed to initialize Kafka producer, review events disabled", "error", err)
+	} else {
+		shutdown.Register("kafka producer", lifecycle.Close(producer.Close))
+
+		// Events that don't match their schema are refused. Schema changes
+		// consumers can't read fail here, before anything is published.
+		schemas := events.NewSchemaSet([]*events.Schema{eventspb.ReviewEventSchema}, schemaregistry.NewRegistry(cfg.Kafka.SchemaRegistry), log)
+		if err := schemas.Register(context.Background()); err != nil {
+			log.Fatal("Failed to register event schemas", "error", err)
+		}
+		producer.ValidateWith(schemas)
+		publisher = producer
+	}
+
+	// Initialize the cache ratings are read through. Without Redis, they are
+	// read from the database each time.
+	var ratings *cache.Cache
+	redis, err := database.NewRedis(cfg.Redis, log)
+	if err != nil {
+		log.Error("Failed to connect to Redis, ratings are not cached", "error", err)
+	} else {
+		shutdown.Register("redis", lifecycle.Close(redis.Close))
+		redis.Instrument(metricsRegistry, serviceName)
+		ratings = cache.New(redis, "product_ratings", cfg.Services.Review.RatingCache.TTL, log).
+			KeepLocal(cfg.Services.Review.RatingCache.LocalSize, cfg.Services.Review.RatingCache.LocalTTL).
+			Instrument(metricsRegistry, serviceName)
+	}
+
+	// Initialize JWT service
+	jwtService := auth.NewJWTService(&cfg.Auth.JWT)
+
+	// Initialize repositories
+	reviewRepo := repository.NewReviewRepository(db, log)
+
+	// Initialize services
+	reviewService := service.NewReviewService(reviewRepo, publisher, ratings, cfg, log)
+
+	// Drop the cached ratings of the products the catalog changes. Events
+	// that can't be handled are moved to a dead-letter topic.
+	var consumers *kafka.ConsumerGroup
+	if ratings != nil {
+		consumers, err = kafka.NewConsumerGroup(cfg.Kafka, cfg.Services.Review.ConsumerGroup, producer, log)
+		if err != nil {
+			log.Error("Failed to initialize Kafka consumer, ratings are only dropped when reviews change", "error", err)
+		} else {
+			consumers.Register(cfg.Kafka.Topics.ProductEvents, service.ProductEventHandler(ratings))
+			go consumers.Run()
+			shutdown.Register("kafka consumers", consumers.Shutdown)
+		}
+	}
+
+	// Initialize handlers
+	reviewHandler := handlers.NewReviewHandler(reviewService, jwtService, log)
+
+	// Setup Gin router
+	if cfg.Environment == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router := gin.New()
+	// Client IPs are only taken from X-Forwarded-For behind trusted proxies
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatal("Failed to set trusted proxies", "error", err)
+	}
+
+	// Add middleware
+	router.Use(gin.Logger())
+	router.Use(logger.Recovery())
+	// Requests run in a span, with a logger scoped to them
+	router.Use(tracing.Middleware(serviceName), logger.Middleware(log))
+	// Responses are in the language clients accept
+	router.Use(i18n.Middleware())
+	if metricsRegistry != nil {
+		router.Use(metricsRegistry.HTTPMiddleware(serviceName))
+	}
+	// Handlers failing with c.Error are answered with problem details,
+	// registered after the middleware reading the status of responses
+	router.Use(apperrors.Middleware())
+
+	// Events are lost while Kafka is unreachable, so the service is only
+	// ready when it can publish them
+	checks := health.NewRegistry(serviceName, cfg.Version)
+	checks.Register("database", health.Func(db.HealthCheck))
+	if redis != nil {
+		checks.Register("redis", health.Func(redis.HealthCheck), health.Optional())
+	}
+	if consumers != nil {
+		checks.Register("kafka_consumers", health.Func(consumers.HealthCheck), health.Optional())
+	}
+	if producer != nil {
+		checks.Register("kafka", health.Func(producer.HealthCheck))
+	} else {
+		checks.Register("kafka", health.Unavailable("kafka producer failed to initialize"))
+	}
+	checks.Routes(router)
+
+	// Setup review routes
+	reviewHandler.SetupRoutes(router)
+
+	// Setup metrics endpoint
+	router.GET("/metrics", func(c *gin.Context) {
+		if metricsRegistry != nil {
+			metricsRegistry.Handler().ServeHTTP(c.Writer, c.Request)
+		} else {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "metrics not available"})
+		}
+	})
+
+	// Setup SLO status endpoint
+	router.GET("/slo", func(c *gin.Context) {
+		if metricsRegistry != nil {
+			metricsRegistry.SLOHandler().ServeHTTP(c.Writer, c.Request)
+		} else {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "metrics not available"})
+		}
+	})
+
+	// Effective configuration, for operators
+	router.GET("/debug/config", jwtService.Middleware(), auth.RequireRole("admin"), config.DebugHandler(cfg))
+
+	// Profiles, goroutine dumps and build info, for operators
+	if err := diagnostics.Setup(cfg, router, shutdown, log, jwtService.Middleware(), auth.RequireRole("admin")); err != nil {
+		log.Fatal("Failed to serve diagnostics", "error", err)
+	}
+
+	// Start HTTP server
+	srv := &http.Server{
+		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+
+	// Start server in a goroutine
+	go func() {
+		log.Info("Review service starting", "address", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Failed to start server", "error", err)
+		}
+	}()
+
+	shutdown.Register("http server", srv.Shutdown)
+
+	if err := shutdown.Wait(); err != nil {
+		log.Error("Review Service stopped uncleanly", "error", err)
+	}
+
+	log.Info("Review Service stopped")
+}
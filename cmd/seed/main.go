@@ -0,0 +1,145 @@
+// Command seed fills the database with the seed sets of an environment,
+// e.g. to prepare a test database in CI.
+//
+//	seed list
+//	seed run [-env test] [-sets admin,fixtures] [-migrate]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/migrations"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+	"github.com/kaanevranportfolio/Commercium/pkg/seed"
+)
+
+const serviceName = "seed"
+
+const usage = `Usage: seed <command> [flags]
+
+Commands:
+  list   list the seed sets and the environments they may be run in
+  run    run the seed sets of an environment
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Load configuration
+	cfg, err := config.Load(config.ModuleDatabase, config.ModuleSeed)
+	if err != nil {
+		fail("Failed to load configuration: %v", err)
+	}
+
+	// Initialize logger
+	log, err := logger.New(cfg.Logger, serviceName)
+	if err != nil {
+		fail("Failed to initialize logger: %v", err)
+	}
+	defer log.Sync()
+
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "list":
+		for _, set := range seed.NewRunner(nil, cfg.Seed, log).Sets() {
+			fmt.Printf("%-14s %s (%s)\n", set.Name, set.Description, strings.Join(set.Environments, ", "))
+		}
+
+	case "run":
+		flags := flag.NewFlagSet("run", flag.ExitOnError)
+		environment := flags.String("env", cfg.Environment, "environment whose seed sets are run")
+		sets := flags.String("sets", "", "comma-separated seed sets to run instead of all sets of the environment")
+		migrate := flags.Bool("migrate", false, "run the database migrations first")
+		migrationsPath := flags.String("migrations", cfg.Database.MigrationsPath, "directory of the database migrations, instead of those built in")
+		flags.Parse(args)
+
+		db, err := database.New(cfg.Database, log)
+		if err != nil {
+			fail("Failed to connect to database: %v", err)
+		}
+		defer db.Close()
+
+		// The run ends before Prometheus could scrape it, so its metrics
+		// are pushed to the Pushgateway
+		registry, err := metrics.NewRegistry(cfg.Metrics, serviceName)
+		if err != nil {
+			log.Warn("Failed to initialize metrics", "error", err)
+		}
+		db.Instrument(registry, serviceName)
+		job := startJob(cfg, registry, command, log)
+
+		if *migrate {
+			source := database.NewMigrationSource(cfg.Database, migrations.FS)
+			source.Path = *migrationsPath
+			migrator, err := database.NewSourceMigrator(db.DB, source, log)
+			if err != nil {
+				finishJob(job, err, log)
+				fail("Failed to create migrator: %v", err)
+			}
+			err = migrator.Up()
+			migrator.Close()
+			if err != nil {
+				finishJob(job, err, log)
+				fail("Failed to run database migrations: %v", err)
+			}
+		}
+
+		var names []string
+		if *sets != "" {
+			for _, name := range strings.Split(*sets, ",") {
+				names = append(names, strings.TrimSpace(name))
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		if err := seed.NewRunner(db, cfg.Seed, log).Run(ctx, *environment, names...); err != nil {
+			finishJob(job, err, log)
+			db.Close()
+			fail("Failed to seed database: %v", err)
+		}
+		finishJob(job, nil, log)
+
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// startJob starts pushing the metrics of registry for a run of command, see
+// metrics.Job. Runs go on without pushing when the Pushgateway can't be
+// reached.
+func startJob(cfg *config.Config, registry metrics.Registry, command string, log *logger.Logger) *metrics.Job {
+	job, err := metrics.StartJob(cfg.Metrics, registry, serviceName+"_"+command)
+	if err != nil {
+		log.Warn("Failed to push job metrics", "error", err)
+	}
+	return job
+}
+
+// finishJob pushes the outcome of a run, err being the error it failed with
+func finishJob(job *metrics.Job, err error, log *logger.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := job.Finish(ctx, err); err != nil {
+		log.Warn("Failed to push job outcome", "error", err)
+	}
+}
+
+// fail prints an error and exits
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
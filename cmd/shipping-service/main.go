@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/carriers"
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/handlers"
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/repository"
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/service"
+	"github.com/kaanevranportfolio/Commercium/migrations"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/diagnostics"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+	"github.com/kaanevranportfolio/Commercium/pkg/health"
+	"github.com/kaanevranportfolio/Commercium/pkg/i18n"
+	"github.com/kaanevranportfolio/Commercium/pkg/kafka"
+	"github.com/kaanevranportfolio/Commercium/pkg/lifecycle"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+	"github.com/kaanevranportfolio/Commercium/pkg/resilience"
+	"github.com/kaanevranportfolio/Commercium/pkg/schemaregistry"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+	eventspb "github.com/kaanevranportfolio/Commercium/proto/events"
+)
+
+const serviceName = "shipping-service"
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load(config.ModuleServer, config.ModuleDatabase, config.ModuleAuth, config.ModuleKafka)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load configuration: %v", err))
+	}
+
+	// Initialize logger
+	log, err := logger.New(cfg.Logger, serviceName)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
+	}
+	defer log.Sync()
+
+	// Parts of the service are stopped in the reverse order they are set
+	// up, once it is signalled to stop
+	shutdown := lifecycle.New(cfg.Server.ShutdownTimeout, log)
+
+	log.Info("Starting Shipping Service",
+		"version", cfg.Version,
+		"environment", cfg.Environment,
+		"port", cfg.Server.Port,
+	)
+
+	log.Info("Effective configuration", "config", cfg.Redacted())
+
+	// Initialize tracing
+	tracerProvider, err := tracing.NewTracerProvider(cfg.Tracing, serviceName)
+	if err != nil {
+		log.Error("Failed to initialize tracing", "error", err)
+	} else {
+		shutdown.Register("tracer", tracerProvider.Shutdown)
+	}
+
+	// Initialize metrics
+	metricsRegistry, err := metrics.NewRegistry(cfg.Metrics, serviceName)
+	if err != nil {
+		log.Error("Failed to initialize metrics", "error", err)
+	} else {
+		shutdown.Register("metrics", metricsRegistry.Shutdown)
+	}
+
+	// Initialize database
+	db, err := database.New(cfg.Database, log)
+	if err != nil {
+		log.Fatal("Failed to connect to database", "error", err)
+	}
+	shutdown.Register("database", lifecycle.Close(db.Close))
+	db.Instrument(metricsRegistry, serviceName)
+
+	// Run database migrations, unless they are run with the migrate command
+	if !cfg.Database.SkipMigrations {
+		if err := db.Migrate(database.NewMigrationSource(cfg.Database, migrations.FS)); err != nil {
+			log.Fatal("Failed to run database migrations", "error", err)
+		}
+	}
+
+	// Initialize Kafka producer for shipping events
+	var publisher service.EventPublisher
+	producer, err := kafka.NewProducer(cfg.Kafka, metricsRegistry, serviceName, log)
+	if err != nil {
+		log.Error("Failed to initialize Kafka producer, shipping events disabled", "error", err)
+	} else {
+		shutdown.Register("kafka producer", lifecycle.Close(producer.Close))
+
+		// Events that don't match their schema are refused. Schema changes
+		// consumers can't read fail here, before anything is published.
+		schemas := events.NewSchemaSet([]*events.Schema{eventspb.ShipmentEventSchema, eventspb.OrderEventSchema}, schemaregistry.NewRegistry(cfg.Kafka.SchemaRegistry), log)
+		if err := schemas.Register(context.Background()); err != nil {
+			log.Fatal("Failed to register event schemas", "error", err)
+		}
+		producer.ValidateWith(schemas)
+		publisher = producer
+	}
+
+	// Initialize JWT service
+	jwtService := auth.NewJWTService(&cfg.Auth.JWT)
+
+	// Initialize carriers
+	shippingCfg := cfg.Services.Shipping
+	// Carriers are called with the resilience policy configured for each
+	dependency := func(name string) *resilience.Dependency {
+		return resilience.New(name, cfg.Services.Resilience.Policy(name, shippingCfg.Timeout), metricsRegistry)
+	}
+	registry := carriers.NewRegistry()
+	if shippingCfg.Carriers.FlatRate.Enabled {
+		flatRate, err := carriers.NewFlatRateCarrier(shippingCfg.Carriers.FlatRate)
+		if err != nil {
+			log.Fatal("Failed to initialize flat rate carrier", "error", err)
+		}
+		registry.Register(flatRate)
+	}
+	if shippingCfg.Carriers.EasyPost.Enabled {
+		easyPost, err := carriers.NewEasyPostCarrier(shippingCfg.Carriers.EasyPost, dependency("easypost"))
+		if err != nil {
+			log.Fatal("Failed to initialize EasyPost carrier", "error", err)
+		}
+		registry.Register(easyPost)
+	}
+
+	// Initialize repositories
+	shippingRepo := repository.NewShippingRepository(db, log)
+
+	// Initialize services
+	shippingService := service.NewShippingService(shippingRepo, registry, publisher, cfg, log)
+
+	// Initialize handlers
+	shippingHandler := handlers.NewShippingHandler(shippingService, jwtService, log)
+
+	// Start background workers
+	workerCtx := shutdown.Context()
+
+	// Poll carriers for tracking updates
+	trackingWorker := service.NewTrackingWorker(shippingService, shippingCfg.Tracking.PollInterval, shippingCfg.Tracking.BatchSize, log)
+	go trackingWorker.Run(workerCtx)
+
+	// Periodically delete expired quotes
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-workerCtx.Done():
+				return
+			case <-ticker.C:
+				if _, err := shippingService.PurgeExpiredQuotes(workerCtx); err != nil {
+					log.Error("Failed to purge expired shipping quotes", "error", err)
+				}
+			}
+		}
+	}()
+
+	// Setup Gin router
+	if cfg.Environment == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router := gin.New()
+	// Client IPs are only taken from X-Forwarded-For behind trusted proxies
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatal("Failed to set trusted proxies", "error", err)
+	}
+
+	// Add middleware
+	router.Use(gin.Logger())
+	router.Use(logger.Recovery())
+	// Requests run in a span, with a logger scoped to them
+	router.Use(tracing.Middleware(serviceName), logger.Middleware(log))
+	// Responses are in the language clients accept
+	router.Use(i18n.Middleware())
+	if metricsRegistry != nil {
+		router.Use(metricsRegistry.HTTPMiddleware(serviceName))
+	}
+	// Handlers failing with c.Error are answered with problem details,
+	// registered after the middleware reading the status of responses
+	router.Use(apperrors.Middleware())
+
+	// Events are lost while Kafka is unreachable, so the service is only
+	// ready when it can publish them
+	checks := health.NewRegistry(serviceName, cfg.Version)
+	checks.Register("database", health.Func(db.HealthCheck))
+	if producer != nil {
+		checks.Register("kafka", health.Func(producer.HealthCheck))
+	} else {
+		checks.Register("kafka", health.Unavailable("kafka producer failed to initialize"))
+	}
+	checks.Routes(router)
+
+	// Setup shipping routes
+	shippingHandler.SetupRoutes(router)
+
+	// Setup metrics endpoint
+	router.GET("/metrics", func(c *gin.Context) {
+		if metricsRegistry != nil {
+			metricsRegistry.Handler().ServeHTTP(c.Writer, c.Request)
+		} else {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "metrics not available"})
+		}
+	})
+
+	// Setup SLO status endpoint
+	router.GET("/slo", func(c *gin.Context) {
+		if metricsRegistry != nil {
+			metricsRegistry.SLOHandler().ServeHTTP(c.Writer, c.Request)
+		} else {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "metrics not available"})
+		}
+	})
+
+	// Effective configuration, for operators
+	router.GET("/debug/config", jwtService.Middleware(), auth.RequireRole("admin"), config.DebugHandler(cfg))
+
+	// Profiles, goroutine dumps and build info, for operators
+	if err := diagnostics.Setup(cfg, router, shutdown, log, jwtService.Middleware(), auth.RequireRole("admin")); err != nil {
+		log.Fatal("Failed to serve diagnostics", "error", err)
+	}
+
+	// Start HTTP server
+	srv := &http.Server{
+		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+
+	// Start server in a goroutine
+	go func() {
+		log.Info("Shipping service starting", "address", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Failed to start server", "error", err)
+		}
+	}()
+
+	shutdown.Register("http server", srv.Shutdown)
+
+	if err := shutdown.Wait(); err != nil {
+		log.Error("Shipping Service stopped uncleanly", "error", err)
+	}
+
+	log.Info("Shipping Service stopped")
+}
@@ -3,28 +3,39 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
 
+	"github.com/kaanevranportfolio/Commercium/api/openapi"
 	"github.com/kaanevranportfolio/Commercium/internal/user/handlers"
 	"github.com/kaanevranportfolio/Commercium/internal/user/repository"
 	"github.com/kaanevranportfolio/Commercium/internal/user/service"
+	"github.com/kaanevranportfolio/Commercium/migrations"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
 	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/cache"
 	"github.com/kaanevranportfolio/Commercium/pkg/config"
 	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/diagnostics"
+	"github.com/kaanevranportfolio/Commercium/pkg/health"
+	"github.com/kaanevranportfolio/Commercium/pkg/i18n"
+	"github.com/kaanevranportfolio/Commercium/pkg/lifecycle"
 	"github.com/kaanevranportfolio/Commercium/pkg/logger"
 	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+	"github.com/kaanevranportfolio/Commercium/pkg/rabbitmq"
+	"github.com/kaanevranportfolio/Commercium/pkg/ratelimit"
+	"github.com/kaanevranportfolio/Commercium/pkg/scheduler"
+	"github.com/kaanevranportfolio/Commercium/pkg/seed"
+	"github.com/kaanevranportfolio/Commercium/pkg/tenant"
 	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
 )
 
 func main() {
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := config.Load(config.ModuleServer, config.ModuleDatabase, config.ModuleAuth, config.ModuleRabbitMQ, config.ModuleRedis, config.ModuleSeed, config.ModuleTenancy)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to load configuration: %v", err))
 	}
@@ -36,28 +47,39 @@ func main() {
 	}
 	defer log.Sync()
 
+	// Parts of the service are stopped in the reverse order they are set
+	// up, once it is signalled to stop
+	shutdown := lifecycle.New(cfg.Server.ShutdownTimeout, log)
+
+	// Security events are written to the audit log, apart from the lines above
+	auditLog, err := logger.NewAuditLogger(cfg.Logger.Audit, "user-service")
+	if err != nil {
+		log.Fatal("Failed to initialize audit log", "error", err)
+	}
+	shutdown.Register("audit log", lifecycle.Close(auditLog.Close))
+
 	log.Info("Starting User Service", 
 		"version", cfg.Version,
 		"environment", cfg.Environment,
 		"port", cfg.Server.Port,
 	)
 
+	log.Info("Effective configuration", "config", cfg.Redacted())
+
 	// Initialize tracing
 	tracerProvider, err := tracing.NewTracerProvider(cfg.Tracing, "user-service")
 	if err != nil {
 		log.Error("Failed to initialize tracing", "error", err)
 	} else {
-		defer func() {
-			if err := tracerProvider.Shutdown(context.Background()); err != nil {
-				log.Error("Failed to shutdown tracer", "error", err)
-			}
-		}()
+		shutdown.Register("tracer", tracerProvider.Shutdown)
 	}
 
 	// Initialize metrics
 	metricsRegistry, err := metrics.NewRegistry(cfg.Metrics, "user-service")
 	if err != nil {
 		log.Error("Failed to initialize metrics", "error", err)
+	} else {
+		shutdown.Register("metrics", metricsRegistry.Shutdown)
 	}
 	
 	// Initialize database
@@ -65,17 +87,29 @@ func main() {
 	if err != nil {
 		log.Fatal("Failed to connect to database", "error", err)
 	}
-	defer db.Close()
+	shutdown.Register("database", lifecycle.Close(db.Close))
+	db.Instrument(metricsRegistry, "user-service")
 
-	// Run database migrations
-	migrator, err := database.NewMigrator(db.DB, "./migrations", log)
-	if err != nil {
-		log.Fatal("Failed to create migrator", "error", err)
+	// Run database migrations, unless they are run with the migrate command
+	if !cfg.Database.SkipMigrations {
+		migrationSource := database.NewMigrationSource(cfg.Database, migrations.FS)
+		if err := db.Migrate(migrationSource); err != nil {
+			log.Fatal("Failed to run database migrations", "error", err)
+		}
+
+		// Users of tenants are kept in their tenant's schema
+		if cfg.Tenancy.Enabled {
+			if err := db.MigrateTenants(context.Background(), migrationSource); err != nil {
+				log.Fatal("Failed to run tenant database migrations", "error", err)
+			}
+		}
 	}
-	defer migrator.Close()
 
-	if err := migrator.Up(); err != nil {
-		log.Fatal("Failed to run database migrations", "error", err)
+	// Seed the admin user and demo data to work with in development
+	if cfg.Seed.OnStartup && cfg.Environment == "development" {
+		if err := seed.NewRunner(db, cfg.Seed, log).Run(context.Background(), cfg.Environment); err != nil {
+			log.Fatal("Failed to seed database", "error", err)
+		}
 	}
 
 	// Initialize Redis
@@ -83,7 +117,8 @@ func main() {
 	if err != nil {
 		log.Fatal("Failed to connect to Redis", "error", err)
 	}
-	defer redis.Close()
+	shutdown.Register("redis", lifecycle.Close(redis.Close))
+	redis.Instrument(metricsRegistry, "user-service")
 
 	// Initialize JWT service
 	jwtService := auth.NewJWTService(&cfg.Auth.JWT)
@@ -91,11 +126,44 @@ func main() {
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db, log)
 
+	// Initialize the queue verification and password reset emails are sent through
+	var emails service.EmailPublisher
+	publisher, err := rabbitmq.NewPublisher(cfg.RabbitMQ, log, cfg.RabbitMQ.Queues.EmailNotifications)
+	if err != nil {
+		log.Error("Failed to initialize RabbitMQ publisher, account emails disabled", "error", err)
+	} else {
+		shutdown.Register("rabbitmq publisher", lifecycle.Close(publisher.Close))
+		emails = publisher
+	}
+
+	// Initialize the cache profiles are read through
+	profileCache := cache.New(redis, service.ProfileCachePrefix, cfg.Services.User.ProfileCache.TTL, log).
+		KeepLocal(cfg.Services.User.ProfileCache.LocalSize, cfg.Services.User.ProfileCache.LocalTTL).
+		Instrument(metricsRegistry, "user-service")
+
 	// Initialize services  
-	userService := service.NewUserService(userRepo, jwtService, redis, cfg, log)
+	userService := service.NewUserService(userRepo, jwtService, redis, redis, emails, profileCache, auditLog, cfg, log)
+
+	// Delete tokens that can no longer be used on a schedule, on one
+	// replica at a time
+	jobs := scheduler.New("user-service", redis, scheduler.NewHistory(db, log), metricsRegistry, log)
+	if err := jobs.Register("token_cleanup", cfg.Services.User.TokenCleanupSchedule, userService.PurgeExpiredTokens); err != nil {
+		log.Fatal("Failed to schedule token cleanup", "error", err)
+	}
+	go jobs.Run(shutdown.Context())
 
 	// Initialize handlers
-	userHandler := handlers.NewUserHandler(userService, jwtService, log)
+	userHandler := handlers.NewUserHandler(userService, jwtService)
+	// Attempts at logging in and at changing or resetting passwords are
+	// limited per client, shared by every instance through Redis
+	if cfg.Services.User.RateLimit.Enabled {
+		limiter, err := ratelimit.New(cfg.Services.User.RateLimit, redis, "user-service")
+		if err != nil {
+			log.Fatal("Failed to initialize rate limiter", "error", err)
+		}
+		userHandler.LimitSensitive(ratelimit.Middleware(limiter, ratelimit.PerRoute(ratelimit.ByUser)))
+	}
+	grpcHandler := handlers.NewGRPCHandler(userService, log)
 
 	// Setup Gin router
 	if cfg.Environment == "production" {
@@ -103,48 +171,46 @@ func main() {
 	}
 
 	router := gin.New()
+	// Client IPs are only taken from X-Forwarded-For behind trusted proxies
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatal("Failed to set trusted proxies", "error", err)
+	}
 	
 	// Add middleware
 	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
-	
-	// Health checks
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status": "healthy",
-			"service": "user-service",
-			"timestamp": time.Now().Unix(),
-		})
-	})
+	router.Use(logger.Recovery())
+	// Requests run in a span, with a logger scoped to them
+	router.Use(tracing.Middleware("user-service"), logger.Middleware(log))
+	// Responses are in the language clients accept
+	router.Use(i18n.Middleware())
+	if metricsRegistry != nil {
+		router.Use(metricsRegistry.HTTPMiddleware("user-service"))
+	}
+	// Handlers failing with c.Error are answered with problem details,
+	// registered after the middleware reading the status of responses
+	router.Use(apperrors.Middleware())
+	// Users sign up and in at the tenant of the request
+	router.Use(tenant.Middleware(cfg.Tenancy, jwtService, db))
 	
-	router.GET("/readiness", func(c *gin.Context) {
-		// Check database connectivity
-		if err := db.HealthCheck(); err != nil {
-			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"status": "not ready",
-				"error": "database connection failed",
-			})
-			return
-		}
-		
-		// Check Redis connectivity
-		if err := redis.HealthCheck(); err != nil {
-			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"status": "not ready", 
-				"error": "redis connection failed",
-			})
-			return
-		}
-		
-		c.JSON(http.StatusOK, gin.H{
-			"status": "ready",
-			"service": "user-service",
-		})
-	})
+	// Account emails aren't queued while RabbitMQ is unreachable
+	checks := health.NewRegistry("user-service", cfg.Version)
+	checks.Register("database", health.Func(db.HealthCheck))
+	checks.Register("redis", health.Func(redis.HealthCheck))
+	if publisher != nil {
+		checks.Register("rabbitmq", health.Func(publisher.HealthCheck))
+	} else {
+		checks.Register("rabbitmq", health.Unavailable("rabbitmq publisher failed to initialize"))
+	}
+	checks.Routes(router)
 
 	// Setup user routes
 	userHandler.SetupRoutes(router)
 
+	// Spec of the user routes, with its Swagger UI on /docs
+	if err := openapi.Register(router, "user-service"); err != nil {
+		log.Fatal("Failed to load the OpenAPI spec", "error", err)
+	}
+
 	// Setup metrics endpoint
 	router.GET("/metrics", func(c *gin.Context) {
 		if metricsRegistry != nil {
@@ -154,6 +220,23 @@ func main() {
 		}
 	})
 
+	// Setup SLO status endpoint
+	router.GET("/slo", func(c *gin.Context) {
+		if metricsRegistry != nil {
+			metricsRegistry.SLOHandler().ServeHTTP(c.Writer, c.Request)
+		} else {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "metrics not available"})
+		}
+	})
+
+	// Effective configuration, for operators
+	router.GET("/debug/config", jwtService.Middleware(), auth.RequireRole("admin"), config.DebugHandler(cfg))
+
+	// Profiles, goroutine dumps and build info, for operators
+	if err := diagnostics.Setup(cfg, router, shutdown, log, jwtService.Middleware(), auth.RequireRole("admin")); err != nil {
+		log.Fatal("Failed to serve diagnostics", "error", err)
+	}
+
 	// Start HTTP server
 	srv := &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
@@ -171,19 +254,28 @@ func main() {
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	// Start internal gRPC server for other services
+	grpcServer := grpc.NewServer()
+	grpcHandler.Register(grpcServer)
 
-	log.Info("Shutting down User Service...")
+	grpcAddr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Services.User.GRPCPort)
+	listener, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		log.Fatal("Failed to listen for gRPC", "error", err, "address", grpcAddr)
+	}
+
+	go func() {
+		log.Info("User gRPC server starting", "address", grpcAddr)
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Fatal("Failed to start gRPC server", "error", err)
+		}
+	}()
+	shutdown.Register("grpc server", lifecycle.Graceful(grpcServer.GracefulStop, grpcServer.Stop))
 
-	// Give outstanding requests 30 seconds to complete
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	shutdown.Register("http server", srv.Shutdown)
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Error("Server forced to shutdown", "error", err)
+	if err := shutdown.Wait(); err != nil {
+		log.Error("User Service stopped uncleanly", "error", err)
 	}
 
 	log.Info("User Service stopped")
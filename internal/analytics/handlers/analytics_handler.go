@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kaanevranportfolio/Commercium/internal/analytics/models"
+	"github.com/kaanevranportfolio/Commercium/internal/analytics/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/validation"
+)
+
+// AnalyticsHandler handles HTTP requests for the admin analytics dashboard
+type AnalyticsHandler struct {
+	analyticsService service.AnalyticsService
+	jwtService       *auth.JWTService
+	logger           *logger.Logger
+}
+
+// NewAnalyticsHandler creates a new analytics handler
+func NewAnalyticsHandler(analyticsService service.AnalyticsService, jwtService *auth.JWTService, logger *logger.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: analyticsService,
+		jwtService:       jwtService,
+		logger:           logger,
+	}
+}
+
+// Orders reports orders placed per period (admin)
+func (h *AnalyticsHandler) Orders(c *gin.Context) {
+	var req models.ReportRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	report, err := h.analyticsService.Orders(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to report orders")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Revenue reports revenue per period and currency (admin)
+func (h *AnalyticsHandler) Revenue(c *gin.Context) {
+	var req models.ReportRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	report, err := h.analyticsService.Revenue(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to report revenue")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Conversion reports checkout conversion per period (admin)
+func (h *AnalyticsHandler) Conversion(c *gin.Context) {
+	var req models.ReportRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	report, err := h.analyticsService.Conversion(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to report conversion")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// NewUsers reports sign-ups per period (admin)
+func (h *AnalyticsHandler) NewUsers(c *gin.Context) {
+	var req models.ReportRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	report, err := h.analyticsService.NewUsers(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to report new users")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// TopProducts reports the best-selling products (admin)
+func (h *AnalyticsHandler) TopProducts(c *gin.Context) {
+	var req models.TopProductsRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	report, err := h.analyticsService.TopProducts(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to report top products")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Refresh brings the reports up to date without waiting for the next scheduled refresh (admin)
+func (h *AnalyticsHandler) Refresh(c *gin.Context) {
+	response, err := h.analyticsService.Refresh(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "Failed to refresh analytics")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// respondError logs a request failing on the side of the service, or of a
+// provider it depends on, and answers it with the problem details of err,
+// so middleware reading its status after it, such as idempotency's, sees
+// the failure
+func (h *AnalyticsHandler) respondError(c *gin.Context, err error, message string) {
+	if apperrors.HTTPStatus(err) >= http.StatusInternalServerError {
+		logger.FromContext(c.Request.Context()).Error(message, "error", err)
+	}
+	apperrors.Respond(c, err)
+}
+
+// SetupRoutes sets up the analytics routes
+func (h *AnalyticsHandler) SetupRoutes(r *gin.Engine) {
+	admin := r.Group("/api/v1/admin/analytics")
+	admin.Use(h.jwtService.Middleware(), auth.RequireRole("admin"))
+	{
+		admin.GET("/orders", h.Orders)
+		admin.GET("/revenue", h.Revenue)
+		admin.GET("/conversion", h.Conversion)
+		admin.GET("/new-users", h.NewUsers)
+		admin.GET("/top-products", h.TopProducts)
+		admin.POST("/refresh", h.Refresh)
+	}
+}
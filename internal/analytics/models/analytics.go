@@ -0,0 +1,132 @@
+package models
+
+import (
+	"time"
+)
+
+// Granularity is the size of the periods a report is broken down into
+type Granularity string
+
+const (
+	GranularityDay   Granularity = "day"
+	GranularityWeek  Granularity = "week"
+	GranularityMonth Granularity = "month"
+)
+
+// IsValid reports whether the granularity is a known granularity
+func (g Granularity) IsValid() bool {
+	switch g {
+	case GranularityDay, GranularityWeek, GranularityMonth:
+		return true
+	}
+	return false
+}
+
+// ReportRequest represents the query parameters of the time series reports.
+// From and To are inclusive UTC dates (YYYY-MM-DD).
+type ReportRequest struct {
+	From        string `form:"from"`
+	To          string `form:"to"`
+	Granularity string `form:"granularity" binding:"omitempty,oneof=day week month"`
+	Currency    string `form:"currency" binding:"omitempty,len=3"`
+}
+
+// TopProductsRequest represents the query parameters of the top products report
+type TopProductsRequest struct {
+	From     string `form:"from"`
+	To       string `form:"to"`
+	Currency string `form:"currency" binding:"omitempty,len=3"`
+	Sort     string `form:"sort" binding:"omitempty,oneof=units revenue"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// ReportRange describes the range a report covers. Periods are labelled
+// with their first day; weeks start on Monday. AsOf is when the underlying
+// data was last refreshed.
+type ReportRange struct {
+	From        string      `json:"from"`
+	To          string      `json:"to"`
+	Granularity Granularity `json:"granularity,omitempty"`
+	AsOf        time.Time   `json:"as_of"`
+}
+
+// OrdersPoint is the number of orders placed in a period
+type OrdersPoint struct {
+	Period     string `json:"period" db:"period"`
+	Orders     int64  `json:"orders" db:"orders"`
+	PaidOrders int64  `json:"paid_orders" db:"paid_orders"`
+}
+
+// OrdersReport holds orders placed per period
+type OrdersReport struct {
+	ReportRange
+	TotalOrders int64          `json:"total_orders"`
+	Series      []*OrdersPoint `json:"series"`
+}
+
+// RevenuePoint is the revenue of paid orders in a period and currency
+type RevenuePoint struct {
+	Period            string `json:"period" db:"period"`
+	Currency          string `json:"currency" db:"currency"`
+	PaidOrders        int64  `json:"paid_orders" db:"paid_orders"`
+	GrossRevenue      int64  `json:"gross_revenue" db:"gross_revenue"`
+	RefundedAmount    int64  `json:"refunded_amount" db:"refunded_amount"`
+	NetRevenue        int64  `json:"net_revenue" db:"-"`
+	AverageOrderValue int64  `json:"average_order_value" db:"-"`
+}
+
+// RevenueReport holds revenue per period, one series per currency
+type RevenueReport struct {
+	ReportRange
+	Series map[string][]*RevenuePoint `json:"series"`
+}
+
+// ConversionPoint is the share of orders placed in a period that were paid
+type ConversionPoint struct {
+	Period         string  `json:"period"`
+	Orders         int64   `json:"orders"`
+	PaidOrders     int64   `json:"paid_orders"`
+	ConversionRate float64 `json:"conversion_rate"`
+}
+
+// ConversionReport holds checkout conversion per period
+type ConversionReport struct {
+	ReportRange
+	ConversionRate float64            `json:"conversion_rate"`
+	Series         []*ConversionPoint `json:"series"`
+}
+
+// NewUsersPoint is the number of sign-ups in a period
+type NewUsersPoint struct {
+	Period   string `json:"period" db:"period"`
+	NewUsers int64  `json:"new_users" db:"new_users"`
+}
+
+// NewUsersReport holds sign-ups per period
+type NewUsersReport struct {
+	ReportRange
+	TotalNewUsers int64            `json:"total_new_users"`
+	Series        []*NewUsersPoint `json:"series"`
+}
+
+// TopProduct is a product's sales over a report's range
+type TopProduct struct {
+	ProductID string `json:"product_id" db:"product_id"`
+	SKU       string `json:"sku" db:"sku"`
+	Name      string `json:"name" db:"name"`
+	Currency  string `json:"currency" db:"currency"`
+	Units     int64  `json:"units" db:"units"`
+	Revenue   int64  `json:"revenue" db:"revenue"`
+}
+
+// TopProductsReport holds the best-selling products
+type TopProductsReport struct {
+	ReportRange
+	Sort     string        `json:"sort"`
+	Products []*TopProduct `json:"products"`
+}
+
+// RefreshResponse reports a refresh of the analytics read model
+type RefreshResponse struct {
+	RefreshedAt time.Time `json:"refreshed_at"`
+}
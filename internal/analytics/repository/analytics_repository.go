@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/internal/analytics/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// AnalyticsRepository defines the interface for analytics read model operations.
+// Ranges are inclusive UTC dates.
+type AnalyticsRepository interface {
+	OrdersSeries(ctx context.Context, from, to time.Time, granularity models.Granularity) ([]*models.OrdersPoint, error)
+	RevenueSeries(ctx context.Context, from, to time.Time, granularity models.Granularity, currency string) ([]*models.RevenuePoint, error)
+	NewUsersSeries(ctx context.Context, from, to time.Time, granularity models.Granularity) ([]*models.NewUsersPoint, error)
+	TopProducts(ctx context.Context, from, to time.Time, currency, sort string, limit int) ([]*models.TopProduct, error)
+
+	// Refresh rebuilds the read model from the orders and users tables
+	Refresh(ctx context.Context) (time.Time, error)
+	LastRefreshed(ctx context.Context) (time.Time, error)
+}
+
+// analyticsRepository implements the AnalyticsRepository interface
+type analyticsRepository struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewAnalyticsRepository creates a new analytics repository
+func NewAnalyticsRepository(db *database.DB, logger *logger.Logger) AnalyticsRepository {
+	return &analyticsRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// views are the materialized views of the read model
+var views = []string{
+	"analytics_daily_orders",
+	"analytics_daily_product_sales",
+	"analytics_daily_users",
+}
+
+// period labels a day with the first day of its period
+const period = `to_char(date_trunc($3, day::timestamp), 'YYYY-MM-DD')`
+
+// OrdersSeries retrieves the orders placed per period, in every currency
+func (r *analyticsRepository) OrdersSeries(ctx context.Context, from, to time.Time, granularity models.Granularity) ([]*models.OrdersPoint, error) {
+	points := []*models.OrdersPoint{}
+	query := `
+		SELECT ` + period + ` AS period, SUM(orders) AS orders, SUM(paid_orders) AS paid_orders
+		FROM analytics_daily_orders
+		WHERE day BETWEEN $1::date AND $2::date
+		GROUP BY 1
+		ORDER BY 1`
+
+	err := r.db.SelectContext(ctx, &points, query, from.Format(time.DateOnly), to.Format(time.DateOnly), granularity)
+	if err != nil {
+		r.logger.Error("Failed to query orders series", "error", err)
+		return nil, fmt.Errorf("failed to query orders: %w", err)
+	}
+
+	return points, nil
+}
+
+// RevenueSeries retrieves the revenue per period and currency, optionally of
+// one currency only
+func (r *analyticsRepository) RevenueSeries(ctx context.Context, from, to time.Time, granularity models.Granularity, currency string) ([]*models.RevenuePoint, error) {
+	points := []*models.RevenuePoint{}
+	query := `
+		SELECT ` + period + ` AS period, currency, SUM(paid_orders) AS paid_orders,
+		       SUM(gross_revenue) AS gross_revenue, SUM(refunded_amount) AS refunded_amount
+		FROM analytics_daily_orders
+		WHERE day BETWEEN $1::date AND $2::date AND ($4 = '' OR currency = $4)
+		GROUP BY 1, 2
+		ORDER BY 2, 1`
+
+	err := r.db.SelectContext(ctx, &points, query, from.Format(time.DateOnly), to.Format(time.DateOnly), granularity, currency)
+	if err != nil {
+		r.logger.Error("Failed to query revenue series", "error", err)
+		return nil, fmt.Errorf("failed to query revenue: %w", err)
+	}
+
+	return points, nil
+}
+
+// NewUsersSeries retrieves the sign-ups per period
+func (r *analyticsRepository) NewUsersSeries(ctx context.Context, from, to time.Time, granularity models.Granularity) ([]*models.NewUsersPoint, error) {
+	points := []*models.NewUsersPoint{}
+	query := `
+		SELECT ` + period + ` AS period, SUM(new_users) AS new_users
+		FROM analytics_daily_users
+		WHERE day BETWEEN $1::date AND $2::date
+		GROUP BY 1
+		ORDER BY 1`
+
+	err := r.db.SelectContext(ctx, &points, query, from.Format(time.DateOnly), to.Format(time.DateOnly), granularity)
+	if err != nil {
+		r.logger.Error("Failed to query new users series", "error", err)
+		return nil, fmt.Errorf("failed to query new users: %w", err)
+	}
+
+	return points, nil
+}
+
+// TopProducts retrieves the products with the most units sold or revenue
+// in the range. Sales in different currencies are ranked separately.
+func (r *analyticsRepository) TopProducts(ctx context.Context, from, to time.Time, currency, sort string, limit int) ([]*models.TopProduct, error) {
+	orderBy := "units DESC, revenue DESC"
+	if sort == "revenue" {
+		orderBy = "revenue DESC, units DESC"
+	}
+
+	products := []*models.TopProduct{}
+	query := `
+		SELECT product_id::text AS product_id, MAX(sku) AS sku, MAX(name) AS name, currency,
+		       SUM(units) AS units, SUM(revenue) AS revenue
+		FROM analytics_daily_product_sales
+		WHERE day BETWEEN $1::date AND $2::date AND ($3 = '' OR currency = $3)
+		GROUP BY product_id, currency
+		HAVING SUM(units) > 0
+		ORDER BY ` + orderBy + `, product_id
+		LIMIT $4`
+
+	err := r.db.SelectContext(ctx, &products, query, from.Format(time.DateOnly), to.Format(time.DateOnly), currency, limit)
+	if err != nil {
+		r.logger.Error("Failed to query top products", "error", err)
+		return nil, fmt.Errorf("failed to query top products: %w", err)
+	}
+
+	return products, nil
+}
+
+// Refresh refreshes the materialized views. Views are refreshed
+// concurrently, so reports keep being served from the previous data while
+// a refresh runs. The refresh is recorded with the time it started, which
+// the data is at least as recent as.
+func (r *analyticsRepository) Refresh(ctx context.Context) (time.Time, error) {
+	startedAt := time.Now()
+	for _, view := range views {
+		if _, err := r.db.ExecContext(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY `+view); err != nil {
+			r.logger.Error("Failed to refresh analytics view", "error", err, "view", view)
+			return time.Time{}, fmt.Errorf("failed to refresh %s: %w", view, err)
+		}
+	}
+
+	if _, err := r.db.ExecContext(ctx, `UPDATE analytics_refreshes SET refreshed_at = $1`, startedAt); err != nil {
+		r.logger.Error("Failed to record analytics refresh", "error", err)
+		return time.Time{}, fmt.Errorf("failed to record refresh: %w", err)
+	}
+
+	return startedAt, nil
+}
+
+// LastRefreshed returns when the views were last refreshed
+func (r *analyticsRepository) LastRefreshed(ctx context.Context) (time.Time, error) {
+	var refreshedAt time.Time
+	err := r.db.GetContext(ctx, &refreshedAt, `SELECT refreshed_at FROM analytics_refreshes`)
+	if err != nil {
+		r.logger.Error("Failed to get analytics refresh time", "error", err)
+		return time.Time{}, fmt.Errorf("failed to get refresh time: %w", err)
+	}
+
+	return refreshedAt, nil
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/internal/analytics/models"
+	"github.com/kaanevranportfolio/Commercium/internal/analytics/repository"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/money"
+)
+
+const (
+	// defaultRangeDays is the range reported when none is given
+	defaultRangeDays   = 30
+	defaultTopProducts = 10
+)
+
+// AnalyticsService defines the interface for the admin analytics reports
+type AnalyticsService interface {
+	Orders(ctx context.Context, req *models.ReportRequest) (*models.OrdersReport, error)
+	Revenue(ctx context.Context, req *models.ReportRequest) (*models.RevenueReport, error)
+	Conversion(ctx context.Context, req *models.ReportRequest) (*models.ConversionReport, error)
+	NewUsers(ctx context.Context, req *models.ReportRequest) (*models.NewUsersReport, error)
+	TopProducts(ctx context.Context, req *models.TopProductsRequest) (*models.TopProductsReport, error)
+
+	// Refresh brings the read model the reports are computed from up to date
+	Refresh(ctx context.Context) (*models.RefreshResponse, error)
+}
+
+// analyticsService implements the AnalyticsService interface
+type analyticsService struct {
+	repo   repository.AnalyticsRepository
+	config *config.Config
+	logger *logger.Logger
+}
+
+// NewAnalyticsService creates a new analytics service
+func NewAnalyticsService(repo repository.AnalyticsRepository, config *config.Config, logger *logger.Logger) AnalyticsService {
+	return &analyticsService{
+		repo:   repo,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Orders reports the orders placed per period, paid or not
+func (s *analyticsService) Orders(ctx context.Context, req *models.ReportRequest) (*models.OrdersReport, error) {
+	from, to, report, err := s.reportRange(ctx, req.From, req.To, req.Granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := s.repo.OrdersSeries(ctx, from, to, report.Granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	byPeriod := make(map[string]*models.OrdersPoint, len(points))
+	for _, point := range points {
+		byPeriod[point.Period] = point
+	}
+
+	response := &models.OrdersReport{ReportRange: *report, Series: []*models.OrdersPoint{}}
+	for _, period := range periods(from, to, report.Granularity) {
+		point, ok := byPeriod[period]
+		if !ok {
+			point = &models.OrdersPoint{Period: period}
+		}
+		response.TotalOrders += point.Orders
+		response.Series = append(response.Series, point)
+	}
+
+	return response, nil
+}
+
+// Revenue reports the revenue of paid orders per period. Amounts in
+// different currencies are never added up, so there is one series per
+// currency.
+func (s *analyticsService) Revenue(ctx context.Context, req *models.ReportRequest) (*models.RevenueReport, error) {
+	currency := strings.ToUpper(req.Currency)
+	if currency != "" && !money.IsCurrencyCode(currency) {
+		return nil, apperrors.Validation("invalid currency: %s", req.Currency)
+	}
+
+	from, to, report, err := s.reportRange(ctx, req.From, req.To, req.Granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := s.repo.RevenueSeries(ctx, from, to, report.Granularity, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	byCurrency := make(map[string]map[string]*models.RevenuePoint)
+	for _, point := range points {
+		if byCurrency[point.Currency] == nil {
+			byCurrency[point.Currency] = make(map[string]*models.RevenuePoint)
+		}
+		byCurrency[point.Currency][point.Period] = point
+	}
+	if currency != "" && byCurrency[currency] == nil {
+		byCurrency[currency] = make(map[string]*models.RevenuePoint)
+	}
+
+	response := &models.RevenueReport{ReportRange: *report, Series: make(map[string][]*models.RevenuePoint, len(byCurrency))}
+	for code, byPeriod := range byCurrency {
+		series := []*models.RevenuePoint{}
+		for _, period := range periods(from, to, report.Granularity) {
+			point, ok := byPeriod[period]
+			if !ok {
+				point = &models.RevenuePoint{Period: period, Currency: code}
+			}
+			point.NetRevenue = point.GrossRevenue - point.RefundedAmount
+			if point.PaidOrders > 0 {
+				point.AverageOrderValue = point.GrossRevenue / point.PaidOrders
+			}
+			series = append(series, point)
+		}
+		response.Series[code] = series
+	}
+
+	return response, nil
+}
+
+// Conversion reports checkout conversion per period: the share of placed
+// orders that were paid rather than left pending or cancelled
+func (s *analyticsService) Conversion(ctx context.Context, req *models.ReportRequest) (*models.ConversionReport, error) {
+	orders, err := s.Orders(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.ConversionReport{ReportRange: orders.ReportRange, Series: []*models.ConversionPoint{}}
+	var placed, paid int64
+	for _, point := range orders.Series {
+		placed += point.Orders
+		paid += point.PaidOrders
+		response.Series = append(response.Series, &models.ConversionPoint{
+			Period:         point.Period,
+			Orders:         point.Orders,
+			PaidOrders:     point.PaidOrders,
+			ConversionRate: rate(point.PaidOrders, point.Orders),
+		})
+	}
+	response.ConversionRate = rate(paid, placed)
+
+	return response, nil
+}
+
+// NewUsers reports the sign-ups per period
+func (s *analyticsService) NewUsers(ctx context.Context, req *models.ReportRequest) (*models.NewUsersReport, error) {
+	from, to, report, err := s.reportRange(ctx, req.From, req.To, req.Granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := s.repo.NewUsersSeries(ctx, from, to, report.Granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	byPeriod := make(map[string]*models.NewUsersPoint, len(points))
+	for _, point := range points {
+		byPeriod[point.Period] = point
+	}
+
+	response := &models.NewUsersReport{ReportRange: *report, Series: []*models.NewUsersPoint{}}
+	for _, period := range periods(from, to, report.Granularity) {
+		point, ok := byPeriod[period]
+		if !ok {
+			point = &models.NewUsersPoint{Period: period}
+		}
+		response.TotalNewUsers += point.NewUsers
+		response.Series = append(response.Series, point)
+	}
+
+	return response, nil
+}
+
+// TopProducts reports the best-selling products of the range, by units sold
+// unless sorted by revenue
+func (s *analyticsService) TopProducts(ctx context.Context, req *models.TopProductsRequest) (*models.TopProductsReport, error) {
+	currency := strings.ToUpper(req.Currency)
+	if currency != "" && !money.IsCurrencyCode(currency) {
+		return nil, apperrors.Validation("invalid currency: %s", req.Currency)
+	}
+
+	from, to, report, err := s.reportRange(ctx, req.From, req.To, "")
+	if err != nil {
+		return nil, err
+	}
+	report.Granularity = ""
+
+	sort := req.Sort
+	if sort == "" {
+		sort = "units"
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultTopProducts
+	}
+
+	products, err := s.repo.TopProducts(ctx, from, to, currency, sort, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TopProductsReport{ReportRange: *report, Sort: sort, Products: products}, nil
+}
+
+// Refresh refreshes the read model
+func (s *analyticsService) Refresh(ctx context.Context) (*models.RefreshResponse, error) {
+	started := time.Now()
+	refreshedAt, err := s.repo.Refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Analytics refreshed", "duration", time.Since(started))
+	return &models.RefreshResponse{RefreshedAt: refreshedAt}, nil
+}
+
+// reportRange validates a report's date range and granularity. The range
+// defaults to the last 30 days including today, and the granularity to days.
+func (s *analyticsService) reportRange(ctx context.Context, rawFrom, rawTo, rawGranularity string) (time.Time, time.Time, *models.ReportRange, error) {
+	granularity := models.GranularityDay
+	if rawGranularity != "" {
+		granularity = models.Granularity(rawGranularity)
+		if !granularity.IsValid() {
+			return time.Time{}, time.Time{}, nil, apperrors.Validation("invalid granularity: %s", rawGranularity)
+		}
+	}
+
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if rawTo != "" {
+		parsed, err := time.Parse(time.DateOnly, rawTo)
+		if err != nil {
+			return time.Time{}, time.Time{}, nil, apperrors.Validation("invalid to date: must be YYYY-MM-DD")
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, 1-defaultRangeDays)
+	if rawFrom != "" {
+		parsed, err := time.Parse(time.DateOnly, rawFrom)
+		if err != nil {
+			return time.Time{}, time.Time{}, nil, apperrors.Validation("invalid from date: must be YYYY-MM-DD")
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, nil, apperrors.Validation("invalid date range: from must not be after to")
+	}
+	if maxDays := s.config.Services.Analytics.MaxRangeDays; to.Sub(from) >= time.Duration(maxDays)*24*time.Hour {
+		return time.Time{}, time.Time{}, nil, apperrors.Validation("invalid date range: at most %d days", maxDays)
+	}
+
+	asOf, err := s.repo.LastRefreshed(ctx)
+	if err != nil {
+		return time.Time{}, time.Time{}, nil, err
+	}
+
+	return from, to, &models.ReportRange{
+		From:        from.Format(time.DateOnly),
+		To:          to.Format(time.DateOnly),
+		Granularity: granularity,
+		AsOf:        asOf,
+	}, nil
+}
+
+// periods returns the labels of the periods overlapping a range, matching
+// the labels of the read model: the first day of each period, weeks
+// starting on Monday
+func periods(from, to time.Time, granularity models.Granularity) []string {
+	start := from
+	switch granularity {
+	case models.GranularityWeek:
+		// Go counts weekdays from Sunday
+		start = from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+	case models.GranularityMonth:
+		start = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	labels := []string{}
+	for period := start; !period.After(to); {
+		labels = append(labels, period.Format(time.DateOnly))
+		switch granularity {
+		case models.GranularityWeek:
+			period = period.AddDate(0, 0, 7)
+		case models.GranularityMonth:
+			period = period.AddDate(0, 1, 0)
+		default:
+			period = period.AddDate(0, 0, 1)
+		}
+	}
+
+	return labels
+}
+
+// rate returns part/total rounded to four decimals, or zero without a total
+func rate(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*10000) / 10000
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/cdc"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// RefreshWorker periodically refreshes the analytics read model in the background
+type RefreshWorker struct {
+	analyticsService AnalyticsService
+	interval         time.Duration
+	minInterval      time.Duration
+	// changed holds a pending refresh triggered by a change
+	changed chan struct{}
+	logger  *logger.Logger
+}
+
+// NewRefreshWorker creates a new refresh worker. Refreshes triggered by
+// changes are at least minInterval apart.
+func NewRefreshWorker(analyticsService AnalyticsService, interval, minInterval time.Duration, logger *logger.Logger) *RefreshWorker {
+	return &RefreshWorker{
+		analyticsService: analyticsService,
+		interval:         interval,
+		minInterval:      minInterval,
+		changed:          make(chan struct{}, 1),
+		logger:           logger,
+	}
+}
+
+// Run refreshes the read model until ctx is cancelled. The first refresh
+// happens at startup so reports are current after a deployment.
+func (w *RefreshWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		refreshedAt := time.Now()
+		if _, err := w.analyticsService.Refresh(ctx); err != nil && ctx.Err() == nil {
+			w.logger.Error("Failed to refresh analytics", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.changed:
+			// Changes come in bursts; one refresh covers the whole burst
+			timer := time.NewTimer(time.Until(refreshedAt.Add(w.minInterval)))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			select {
+			case <-w.changed:
+			default:
+			}
+		}
+	}
+}
+
+// Trigger requests a refresh, e.g. because a table the read model is built
+// from changed
+func (w *RefreshWorker) Trigger() {
+	select {
+	case w.changed <- struct{}{}:
+	default:
+	}
+}
+
+// HandleChange triggers a refresh for a change event of a table the read
+// model is built from. The rows aren't needed: the views are rebuilt whole.
+func (w *RefreshWorker) HandleChange(ctx context.Context, change *cdc.Change, before, after *struct{}) error {
+	w.Trigger()
+	return nil
+}
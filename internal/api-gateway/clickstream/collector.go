@@ -0,0 +1,170 @@
+package clickstream
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+	"github.com/kaanevranportfolio/Commercium/pkg/kafka"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// eventSource is the source of clickstream events
+const eventSource = "api-gateway"
+
+// eventTypePrefix prefixes the event types of clickstream envelopes, so they
+// are told apart from other events
+const eventTypePrefix = "clickstream."
+
+// publishTimeout bounds how long a batch may take to reach Kafka
+const publishTimeout = 10 * time.Second
+
+// ErrBufferFull is returned when events arrive faster than they can be
+// published; clients should retry later
+var ErrBufferFull = errors.New("clickstream buffer is full")
+
+// Publisher publishes batches of messages to a topic
+type Publisher interface {
+	PublishBatch(ctx context.Context, topic string, messages []kafka.Message) error
+}
+
+// Collector buffers events in memory and publishes them to Kafka in batches,
+// whenever a batch is full or the flush interval elapses. Events are lost if
+// Kafka rejects a batch or the gateway stops abruptly; clickstream data is
+// not worth slowing down the storefront for.
+type Collector struct {
+	publisher Publisher
+	topic     string
+	config    config.ClickstreamConfig
+	logger    *logger.Logger
+
+	mu      sync.Mutex
+	pending []kafka.Message
+
+	flush chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// NewCollector creates a new collector. Run must be started for events to be published.
+func NewCollector(publisher Publisher, topic string, cfg config.ClickstreamConfig, logger *logger.Logger) *Collector {
+	return &Collector{
+		publisher: publisher,
+		topic:     topic,
+		config:    cfg,
+		logger:    logger,
+		pending:   make([]kafka.Message, 0, cfg.BatchSize),
+		flush:     make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Add samples the events and queues the remaining ones for publishing. A batch
+// is queued entirely or not at all: when it doesn't fit in the buffer,
+// ErrBufferFull is returned.
+func (c *Collector) Add(ctx context.Context, batch []*Event) (*IngestResponse, error) {
+	response := &IngestResponse{}
+	messages := make([]kafka.Message, 0, len(batch))
+	for _, event := range batch {
+		if !c.sampled(event) {
+			response.Sampled++
+			continue
+		}
+		envelope, err := events.New(ctx, eventSource, eventTypePrefix+event.Type, event.SessionID, EventSchemaVersion, event)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, kafka.Message{Key: event.SessionID, Value: envelope})
+	}
+
+	c.mu.Lock()
+	if len(c.pending)+len(messages) > c.config.BufferSize {
+		c.mu.Unlock()
+		return nil, ErrBufferFull
+	}
+	c.pending = append(c.pending, messages...)
+	full := len(c.pending) >= c.config.BatchSize
+	c.mu.Unlock()
+
+	if full {
+		select {
+		case c.flush <- struct{}{}:
+		default:
+		}
+	}
+
+	response.Accepted = len(messages)
+	return response, nil
+}
+
+// sampled reports whether an event is kept. Sampling is by session, so the
+// kept sessions are complete and can still be followed from view to cart.
+func (c *Collector) sampled(event *Event) bool {
+	rate, ok := c.config.SampleRates[event.Type]
+	if !ok || rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(event.SessionID))
+	return float64(hash.Sum32())/(1<<32) < rate
+}
+
+// Run publishes buffered events until Close is called
+func (c *Collector) Run() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			c.publish()
+			return
+		case <-ticker.C:
+			c.publish()
+		case <-c.flush:
+			c.publish()
+		}
+	}
+}
+
+// publish publishes the buffered events, in batches of at most BatchSize
+func (c *Collector) publish() {
+	for {
+		c.mu.Lock()
+		count := len(c.pending)
+		if count > c.config.BatchSize {
+			count = c.config.BatchSize
+		}
+		batch := c.pending[:count:count]
+		c.pending = c.pending[count:]
+		c.mu.Unlock()
+
+		if len(batch) == 0 {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		err := c.publisher.PublishBatch(ctx, c.topic, batch)
+		cancel()
+		if err != nil {
+			c.logger.Error("Failed to publish clickstream events, events dropped", "error", err, "count", len(batch))
+		}
+	}
+}
+
+// Close publishes the buffered events and stops the collector
+func (c *Collector) Close() {
+	close(c.stop)
+	<-c.done
+}
@@ -0,0 +1,39 @@
+package clickstream
+
+import (
+	"time"
+)
+
+// Event types accepted from the storefront
+const (
+	EventTypeProductView = "product_view"
+	EventTypeAddToCart   = "add_to_cart"
+)
+
+// EventSchemaVersion is the schema version of Event payloads
+const EventSchemaVersion = 1
+
+// Event is a storefront interaction. Events are keyed by session, so the
+// events of a session are published to the same partition in order.
+type Event struct {
+	Type      string `json:"type" binding:"required,oneof=product_view add_to_cart"`
+	SessionID string `json:"session_id" binding:"required,max=128"`
+	ProductID string `json:"product_id" binding:"required,max=64"`
+	// Quantity is the number of units added to the cart
+	Quantity int `json:"quantity,omitempty" binding:"omitempty,min=1,max=10000"`
+	// OccurredAt is when the client saw the event, if it reports it
+	OccurredAt *time.Time `json:"occurred_at,omitempty"`
+	ReceivedAt time.Time  `json:"received_at"`
+}
+
+// IngestRequest represents a batch of events sent by a client
+type IngestRequest struct {
+	Events []*Event `json:"events" binding:"required,min=1,dive"`
+}
+
+// IngestResponse reports how many events of a batch were queued for
+// publishing and how many were dropped by sampling
+type IngestResponse struct {
+	Accepted int `json:"accepted"`
+	Sampled  int `json:"sampled"`
+}
@@ -1,10 +1,39 @@
 package config
 
 import (
+	"context"
+
 	"github.com/kaanevranportfolio/Commercium/pkg/config"
 )
 
-// Load loads the API Gateway configuration
+// Load loads the API Gateway configuration. Kafka is only needed by the
+// clickstream and dead letter APIs, and Redis by rate limits shared by the
+// replicas, so they are only loaded, and validated, when those are
+// enabled. Auth guards the admin APIs.
 func Load() (*config.Config, error) {
-	return config.Load()
+	base := []config.Module{config.ModuleServer, config.ModuleTenancy, config.ModuleAuth}
+	cfg, err := config.Load(base...)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := base
+	gateway := cfg.Services.Gateway
+	if gateway.Clickstream.Enabled || gateway.DeadLetters.Enabled {
+		modules = append(modules, config.ModuleKafka)
+	}
+	if gateway.RateLimit.Enabled && gateway.RateLimit.Backend == "redis" {
+		modules = append(modules, config.ModuleRedis)
+	}
+	if len(modules) == len(base) {
+		return cfg, nil
+	}
+	return config.Load(modules...)
+}
+
+// Watch calls onChange with the configuration loaded again each time the
+// remote configuration shared by the gateway replicas changes, until ctx
+// is done, see config.WatchRemote
+func Watch(ctx context.Context, cfg *config.Config, onChange func(*config.Config), onError func(error)) {
+	config.WatchRemote(ctx, cfg, onChange, onError)
 }
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/kafka"
+	"github.com/kaanevranportfolio/Commercium/pkg/validation"
+)
+
+// RedriveRequest selects the messages of a dead-letter partition to redrive
+type RedriveRequest struct {
+	Offsets []int64 `json:"offsets" binding:"required,min=1,max=100"`
+}
+
+// setupDeadLetters creates the dead-letter queue the admin API inspects,
+// redriving messages with the gateway's producer
+func (s *Server) setupDeadLetters() error {
+	if s.producer == nil {
+		producer, err := kafka.NewProducer(s.config.Kafka, s.metrics, "api-gateway", s.logger)
+		if err != nil {
+			return err
+		}
+		s.producer = producer
+	}
+
+	deadLetters, err := kafka.NewDeadLetterQueue(s.config.Kafka, s.producer, s.logger)
+	if err != nil {
+		return err
+	}
+	s.deadLetters = deadLetters
+	return nil
+}
+
+// registerDeadLetterRoutes registers the admin API of the dead-letter queue
+func (s *Server) registerDeadLetterRoutes(v1 *gin.RouterGroup) {
+	admin := v1.Group("/admin/dead-letters")
+	admin.Use(auth.NewJWTService(&s.config.Auth.JWT).Middleware(), auth.RequireRole("admin"))
+	{
+		admin.GET("", s.listDeadLetterTopics)
+		admin.GET("/:topic/partitions/:partition", s.listDeadLetters)
+		admin.GET("/:topic/partitions/:partition/messages/:offset", s.getDeadLetter)
+		admin.POST("/:topic/partitions/:partition/redrive", s.redriveDeadLetters)
+	}
+}
+
+// listDeadLetterTopics lists the dead-letter topics and how many messages
+// they hold
+func (s *Server) listDeadLetterTopics(c *gin.Context) {
+	topics, err := s.deadLetters.Topics(c.Request.Context())
+	if err != nil {
+		s.respondDeadLetterError(c, err, "Failed to list dead-letter topics")
+		return
+	}
+
+	httpx.Items(c, topics)
+}
+
+// listDeadLetters lists the messages of a dead-letter partition from an
+// offset, with why they failed. next_offset continues the listing.
+func (s *Server) listDeadLetters(c *gin.Context) {
+	partition, err := strconv.Atoi(c.Param("partition"))
+	if err != nil || partition < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid partition"})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+		return
+	}
+
+	maxLimit := s.config.Services.Gateway.DeadLetters.MaxListLimit
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > maxLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit, must be between 1 and " + strconv.Itoa(maxLimit)})
+		return
+	}
+
+	deadLetters, err := s.deadLetters.List(c.Request.Context(), c.Param("topic"), partition, offset, limit)
+	if err != nil {
+		s.respondDeadLetterError(c, err, "Failed to list dead letters")
+		return
+	}
+
+	response := gin.H{"dead_letters": deadLetters}
+	if len(deadLetters) > 0 {
+		response["next_offset"] = deadLetters[len(deadLetters)-1].Offset + 1
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// getDeadLetter returns a message of a dead-letter partition
+func (s *Server) getDeadLetter(c *gin.Context) {
+	partition, err := strconv.Atoi(c.Param("partition"))
+	if err != nil || partition < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid partition"})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.Param("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+		return
+	}
+
+	deadLetter, err := s.deadLetters.Get(c.Request.Context(), c.Param("topic"), partition, offset)
+	if err != nil {
+		s.respondDeadLetterError(c, err, "Failed to get dead letter")
+		return
+	}
+
+	c.JSON(http.StatusOK, deadLetter)
+}
+
+// redriveDeadLetters publishes the selected messages of a dead-letter
+// partition back to the topics they failed on
+func (s *Server) redriveDeadLetters(c *gin.Context) {
+	partition, err := strconv.Atoi(c.Param("partition"))
+	if err != nil || partition < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid partition"})
+		return
+	}
+
+	var req RedriveRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	redriven, err := s.deadLetters.Redrive(c.Request.Context(), c.Param("topic"), partition, req.Offsets)
+	if err != nil {
+		s.logger.Error("Failed to redrive dead letters", "error", err, "topic", c.Param("topic"),
+			"partition", partition, "redriven", redriven, "admin_id", auth.UserIDFromContext(c))
+		status, message := deadLetterErrorStatus(err, "Failed to redrive dead letters")
+		c.JSON(status, gin.H{"error": message, "redriven": redriven})
+		return
+	}
+
+	s.logger.Info("Dead letters redriven", "topic", c.Param("topic"), "partition", partition,
+		"count", redriven, "admin_id", auth.UserIDFromContext(c))
+	c.JSON(http.StatusOK, gin.H{"redriven": redriven})
+}
+
+// respondDeadLetterError maps dead-letter queue errors to HTTP responses
+func (s *Server) respondDeadLetterError(c *gin.Context, err error, fallback string) {
+	status, message := deadLetterErrorStatus(err, fallback)
+	if status == http.StatusInternalServerError {
+		s.logger.Error(fallback, "error", err)
+	}
+	c.JSON(status, gin.H{"error": message})
+}
+
+// deadLetterErrorStatus returns the HTTP status and message of a dead-letter
+// queue error
+func deadLetterErrorStatus(err error, fallback string) (int, string) {
+	switch {
+	case errors.Is(err, kafka.ErrDeadLetterNotFound):
+		return http.StatusNotFound, err.Error()
+	case strings.Contains(err.Error(), "invalid"):
+		return http.StatusBadRequest, err.Error()
+	default:
+		return http.StatusInternalServerError, fallback
+	}
+}
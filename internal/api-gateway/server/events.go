@@ -0,0 +1,52 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kaanevranportfolio/Commercium/internal/api-gateway/clickstream"
+	"github.com/kaanevranportfolio/Commercium/pkg/validation"
+)
+
+// maxEventsBodyBytes bounds the size of an events request
+const maxEventsBodyBytes = 256 << 10
+
+// ingestEvents accepts a batch of storefront events and queues them for
+// Kafka. Validation is kept to the minimum needed to key and route events.
+// When the buffer is full the whole batch is refused with 503, so clients
+// can back off and resend it.
+func (s *Server) ingestEvents(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxEventsBodyBytes)
+
+	var req clickstream.IngestRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	if limit := s.config.Services.Gateway.Clickstream.MaxEventsPerRequest; len(req.Events) > limit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d events can be sent at once", limit)})
+		return
+	}
+
+	receivedAt := time.Now().UTC()
+	for _, event := range req.Events {
+		event.ReceivedAt = receivedAt
+	}
+
+	response, err := s.collector.Add(c.Request.Context(), req.Events)
+	if err != nil {
+		if errors.Is(err, clickstream.ErrBufferFull) {
+			c.Header("Retry-After", "1")
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Too many events, retry later"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept events"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, response)
+}
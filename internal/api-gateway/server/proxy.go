@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/health"
+	"github.com/kaanevranportfolio/Commercium/pkg/idempotency"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// serviceProxy proxies requests to a backend service. The URL of the
+// service may change while the gateway runs, see Reload.
+type serviceProxy struct {
+	upstream atomic.Pointer[upstream]
+}
+
+// upstream is a backend service URL and the reverse proxy to it
+type upstream struct {
+	target string
+	proxy  *httputil.ReverseProxy
+}
+
+// ServeHTTP proxies a request to the current URL of the service
+func (p *serviceProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.upstream.Load().proxy.ServeHTTP(w, r)
+}
+
+// newServiceProxy creates a proxy to a backend service, which Reload keeps
+// pointed at the service's URL.
+// Request headers, including Idempotency-Key, are forwarded unchanged.
+func (s *Server) newServiceProxy(service, target string) (*serviceProxy, error) {
+	reverseProxy, err := s.newReverseProxy(service, target)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := &serviceProxy{}
+	proxy.upstream.Store(&upstream{target: target, proxy: reverseProxy})
+	s.proxies[service] = proxy
+	s.checks.Register(service, proxy.healthCheck, health.Optional())
+	return proxy, nil
+}
+
+// healthCheck checks the health endpoint at the current URL of the service
+func (p *serviceProxy) healthCheck(ctx context.Context) error {
+	return health.URL(nil, strings.TrimSuffix(p.upstream.Load().target, "/")+"/health")(ctx)
+}
+
+// newReverseProxy creates a reverse proxy to a backend service at target
+func (s *Server) newReverseProxy(service, target string) (*httputil.ReverseProxy, error) {
+	targetURL, err := url.Parse(target)
+	if err != nil || targetURL.Scheme == "" || targetURL.Host == "" {
+		return nil, fmt.Errorf("invalid %s URL: %q", service, target)
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(targetURL)
+	// Services continue the trace of the request
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+		tracing.Inject(r.Context(), r.Header)
+	}
+	proxy.Transport = &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ResponseHeaderTimeout: s.upstreamTimeout(),
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConnsPerHost:   100,
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		s.logger.Error("Upstream request failed", "error", err, "service", service, "path", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"error":"Service unavailable"}`))
+	}
+
+	return proxy, nil
+}
+
+// Reload applies a configuration loaded again while the gateway runs,
+// e.g. after a remote configuration change: services moved to another URL
+// are proxied to there from then on. Routes are set up when the gateway
+// starts, so proxying to a service it started without takes a restart, as
+// do changes to other settings.
+func (s *Server) Reload(cfg *config.Config) {
+	targets := serviceURLs(cfg.Services)
+	for service, proxy := range s.proxies {
+		target := targets[service]
+		current := proxy.upstream.Load()
+		if target == "" || target == current.target {
+			continue
+		}
+
+		reverseProxy, err := s.newReverseProxy(service, target)
+		if err != nil {
+			s.logger.Error("Ignoring service URL change", "service", service, "error", err)
+			continue
+		}
+		proxy.upstream.Store(&upstream{target: target, proxy: reverseProxy})
+		current.proxy.Transport.(*http.Transport).CloseIdleConnections()
+
+		s.logger.Info("Service URL changed", "service", service, "url", target)
+	}
+}
+
+// serviceURLs returns the URLs of the backend services by the names their
+// proxies are created with
+func serviceURLs(services config.ServicesConfig) map[string]string {
+	return map[string]string{
+		"payment service":      services.PaymentURL,
+		"shipping service":     services.ShippingURL,
+		"review service":       services.ReviewURL,
+		"notification service": services.NotificationURL,
+		"currency service":     services.CurrencyURL,
+		"pricing service":      services.PricingURL,
+		"subscription service": services.SubscriptionURL,
+		"seller service":       services.SellerURL,
+		"analytics service":    services.AnalyticsURL,
+		"stock alert service":  services.StockAlertURL,
+	}
+}
+
+// upstreamTimeout returns how long to wait for a backend's response headers.
+// Payment calls wait on external providers, so the longer payment timeout applies.
+func (s *Server) upstreamTimeout() time.Duration {
+	timeout := s.config.Services.Timeout
+	if s.config.Services.Payment.Timeout > timeout {
+		timeout = s.config.Services.Payment.Timeout
+	}
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return timeout
+}
+
+// proxyHandler adapts a service proxy to a gin handler
+func proxyHandler(proxy *serviceProxy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		proxy.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// requireIdempotencyKey rejects requests without an Idempotency-Key header
+// before they reach a backend, so clients learn to send one on every retry
+func requireIdempotencyKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotency.HeaderKey)
+		if key == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": idempotency.HeaderKey + " header is required"})
+			c.Abort()
+			return
+		}
+		if len(key) > idempotency.MaxKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": idempotency.HeaderKey + " header is too long"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -1,30 +1,102 @@
 package server
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/kaanevranportfolio/Commercium/internal/api-gateway/clickstream"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
 	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/diagnostics"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+	"github.com/kaanevranportfolio/Commercium/pkg/health"
+	"github.com/kaanevranportfolio/Commercium/pkg/kafka"
 	"github.com/kaanevranportfolio/Commercium/pkg/logger"
 	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+	"github.com/kaanevranportfolio/Commercium/pkg/ratelimit"
+	"github.com/kaanevranportfolio/Commercium/pkg/schemaregistry"
+	"github.com/kaanevranportfolio/Commercium/pkg/tenant"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+	eventspb "github.com/kaanevranportfolio/Commercium/proto/events"
 )
 
 // Server represents the API Gateway server
 type Server struct {
 	config   *config.Config
 	logger   *logger.Logger
-	metrics  *metrics.Registry
+	metrics  metrics.Registry
 	router   *gin.Engine
+
+	// collector is nil when clickstream ingestion is disabled
+	collector *clickstream.Collector
+	producer  *kafka.Producer
+	// deadLetters is nil when the dead-letter admin API is disabled
+	deadLetters *kafka.DeadLetterQueue
+	// proxies are the proxies to backend services, by service name
+	proxies map[string]*serviceProxy
+	// checks are those of the services proxied to and of Kafka
+	checks *health.Registry
+	// limits limit the API requests of each client; empty when rate
+	// limiting is disabled
+	limits gin.HandlersChain
+	// redis keeps the rate limits shared by the gateway replicas; nil
+	// unless they are kept in Redis
+	redis *database.Redis
 }
 
 // New creates a new API Gateway server
-func New(cfg *config.Config, log *logger.Logger, metricsRegistry *metrics.Registry) (*Server, error) {
+func New(cfg *config.Config, log *logger.Logger, metricsRegistry metrics.Registry) (*Server, error) {
 	server := &Server{
 		config:  cfg,
 		logger:  log,
 		metrics: metricsRegistry,
 		router:  gin.New(),
+		proxies: make(map[string]*serviceProxy),
+		checks:  health.NewRegistry("api-gateway", cfg.Version),
+	}
+
+	// Client IPs, which requests are limited by, are only taken from
+	// X-Forwarded-For behind trusted proxies
+	if err := server.router.SetTrustedProxies(cfg.Services.Gateway.TrustedProxies); err != nil {
+		return nil, err
+	}
+
+	if cfg.Services.Gateway.Clickstream.Enabled {
+		// Clickstream events are only published once their schema is
+		// registered; the storefront works without them
+		schemas := events.NewSchemaSet([]*events.Schema{eventspb.ClickstreamEventSchema},
+			schemaregistry.NewRegistry(cfg.Kafka.SchemaRegistry), log)
+		producer, err := kafka.NewProducer(cfg.Kafka, metricsRegistry, "api-gateway", log)
+		if err == nil {
+			err = schemas.Register(context.Background())
+			if err != nil {
+				producer.Close()
+			}
+		}
+		if err != nil {
+			log.Error("Failed to initialize Kafka producer, clickstream ingestion disabled", "error", err)
+		} else {
+			producer.ValidateWith(schemas)
+			server.producer = producer
+			server.checks.Register("kafka", health.Func(producer.HealthCheck), health.Optional())
+			server.collector = clickstream.NewCollector(producer, cfg.Kafka.Topics.ClickstreamEvents, cfg.Services.Gateway.Clickstream, log)
+			go server.collector.Run()
+		}
+	}
+
+	if cfg.Services.Gateway.RateLimit.Enabled {
+		if err := server.setupRateLimit(); err != nil {
+			log.Error("Failed to initialize rate limiter, rate limiting disabled", "error", err)
+		}
+	}
+
+	if cfg.Services.Gateway.DeadLetters.Enabled {
+		if err := server.setupDeadLetters(); err != nil {
+			log.Error("Failed to initialize dead-letter queue, dead-letter admin API disabled", "error", err)
+		}
 	}
 
 	if err := server.setupRoutes(); err != nil {
@@ -34,6 +106,44 @@ func New(cfg *config.Config, log *logger.Logger, metricsRegistry *metrics.Regist
 	return server, nil
 }
 
+// setupRateLimit limits the API requests of each client by IP address
+func (s *Server) setupRateLimit() error {
+	rateLimit := s.config.Services.Gateway.RateLimit
+	if rateLimit.Backend == ratelimit.BackendRedis {
+		redis, err := database.NewRedis(s.config.Redis, s.logger)
+		if err != nil {
+			return err
+		}
+		s.redis = redis
+		s.checks.Register("redis", health.Func(redis.HealthCheck), health.Optional())
+	}
+
+	limiter, err := ratelimit.New(rateLimit, s.redis, "api-gateway")
+	if err != nil {
+		return err
+	}
+	s.limits = gin.HandlersChain{ratelimit.Middleware(limiter, ratelimit.ByClientIP)}
+	return nil
+}
+
+// Close publishes buffered clickstream events and releases the Kafka
+// producer and the Redis client
+func (s *Server) Close() {
+	if s.collector != nil {
+		s.collector.Close()
+	}
+	if s.producer != nil {
+		if err := s.producer.Close(); err != nil {
+			s.logger.Error("Failed to close Kafka producer", "error", err)
+		}
+	}
+	if s.redis != nil {
+		if err := s.redis.Close(); err != nil {
+			s.logger.Error("Failed to close Redis connection", "error", err)
+		}
+	}
+}
+
 // Handler returns the HTTP handler
 func (s *Server) Handler() http.Handler {
 	return s.router
@@ -43,22 +153,256 @@ func (s *Server) Handler() http.Handler {
 func (s *Server) setupRoutes() error {
 	// Middleware
 	s.router.Use(gin.Logger())
-	s.router.Use(gin.Recovery())
+	s.router.Use(logger.Recovery())
 	s.router.Use(s.metrics.HTTPMiddleware("api-gateway"))
+	// Requests run in a span, with a logger scoped to them. Baggage is set
+	// here, from the tenant and user of requests, rather than by clients.
+	s.router.Use(tracing.DropBaggage(), tracing.Middleware("api-gateway"), logger.Middleware(s.logger))
+	// Resolves the tenant, the one of the user's token for authenticated
+	// requests, and passes it on to services in the tenant header
+	s.router.Use(tenant.Middleware(s.config.Tenancy, auth.NewJWTService(&s.config.Auth.JWT), nil))
 
-	// Health check endpoint
-	s.router.GET("/health", s.healthCheck)
-	s.router.GET("/readiness", s.readinessCheck)
+	// Health checks. The gateway stays ready while services it proxies to
+	// are down, as requests to the others still succeed.
+	s.checks.Routes(s.router)
 
 	// Metrics endpoint
 	s.router.GET("/metrics", gin.WrapH(s.metrics.Handler()))
 
-	// API routes
-	v1 := s.router.Group("/api/v1")
+	// SLO status endpoint
+	s.router.GET("/slo", gin.WrapH(s.metrics.SLOHandler()))
+
+	// Effective configuration, for operators
+	s.router.GET("/debug/config", auth.NewJWTService(&s.config.Auth.JWT).Middleware(), auth.RequireRole("admin"),
+		config.DebugHandler(s.config))
+
+	// Profiles, goroutine dumps and build info, unless on an internal port
+	if s.config.Server.Debug.Port == 0 {
+		diagnostics.Routes(s.router, s.config.Version, auth.NewJWTService(&s.config.Auth.JWT).Middleware(),
+			auth.RequireRole("admin"))
+	}
+
+	// API routes, limited per client
+	v1 := s.router.Group("/api/v1", s.limits...)
 	{
 		v1.GET("/status", s.getStatus)
 	}
 
+	// Storefront events are handled by the gateway itself and go straight to Kafka
+	if s.collector != nil {
+		v1.POST("/events", s.ingestEvents)
+	}
+
+	// Dead-lettered messages are inspected and redriven by the gateway itself
+	if s.deadLetters != nil {
+		s.registerDeadLetterRoutes(v1)
+	}
+
+	// Account routes are proxied to the user service, which limits the
+	// attempts at its sensitive endpoints itself. Its internal routes aren't.
+	if s.config.Services.UserURL != "" {
+		userProxy, err := s.newServiceProxy("user service", s.config.Services.UserURL)
+		if err != nil {
+			return err
+		}
+		v1.POST("/auth/register", proxyHandler(userProxy))
+		v1.POST("/auth/login", proxyHandler(userProxy))
+		v1.POST("/auth/refresh", proxyHandler(userProxy))
+		v1.POST("/auth/forgot-password", proxyHandler(userProxy))
+		v1.POST("/auth/reset-password", proxyHandler(userProxy))
+		v1.GET("/auth/verify-email", proxyHandler(userProxy))
+		v1.GET("/users/profile", proxyHandler(userProxy))
+		v1.PUT("/users/profile", proxyHandler(userProxy))
+		v1.POST("/users/change-password", proxyHandler(userProxy))
+		v1.POST("/users/resend-verification", proxyHandler(userProxy))
+		v1.GET("/users/addresses", proxyHandler(userProxy))
+		v1.POST("/users/addresses", proxyHandler(userProxy))
+		v1.PUT("/users/addresses/:id", proxyHandler(userProxy))
+		v1.DELETE("/users/addresses/:id", proxyHandler(userProxy))
+	}
+
+	// Order routes are proxied to the order service, its internal routes
+	// aren't
+	if s.config.Services.OrderURL != "" {
+		orderProxy, err := s.newServiceProxy("order service", s.config.Services.OrderURL)
+		if err != nil {
+			return err
+		}
+		v1.GET("/orders", proxyHandler(orderProxy))
+		v1.GET("/orders/:id", proxyHandler(orderProxy))
+		v1.POST("/orders/:id/cancel", proxyHandler(orderProxy))
+		v1.GET("/orders/:id/refunds", proxyHandler(orderProxy))
+		v1.POST("/orders/:id/refunds", proxyHandler(orderProxy))
+		v1.GET("/orders/:id/invoice", proxyHandler(orderProxy))
+		v1.POST("/checkout", proxyHandler(orderProxy))
+		v1.POST("/checkout/totals", proxyHandler(orderProxy))
+		v1.GET("/admin/orders", proxyHandler(orderProxy))
+		v1.GET("/admin/tax-exemptions/:user_id", proxyHandler(orderProxy))
+		v1.PUT("/admin/tax-exemptions/:user_id", proxyHandler(orderProxy))
+		v1.DELETE("/admin/tax-exemptions/:user_id", proxyHandler(orderProxy))
+	}
+
+	// Payment routes are proxied to the payment service. Checkouts must be
+	// retry-safe, so they are only accepted with an idempotency key.
+	if s.config.Services.PaymentURL != "" {
+		paymentProxy, err := s.newServiceProxy("payment service", s.config.Services.PaymentURL)
+		if err != nil {
+			return err
+		}
+		v1.POST("/payments", requireIdempotencyKey(), proxyHandler(paymentProxy))
+		v1.GET("/payments/:id", proxyHandler(paymentProxy))
+		v1.GET("/admin/fraud/reviews", proxyHandler(paymentProxy))
+		v1.POST("/admin/fraud/reviews/:id", proxyHandler(paymentProxy))
+		v1.POST("/gift-cards/balance", proxyHandler(paymentProxy))
+		v1.POST("/admin/gift-cards", proxyHandler(paymentProxy))
+		v1.GET("/admin/gift-cards/:id", proxyHandler(paymentProxy))
+		v1.POST("/admin/gift-cards/:id/disable", proxyHandler(paymentProxy))
+	}
+
+	if s.config.Services.ShippingURL != "" {
+		shippingProxy, err := s.newServiceProxy("shipping service", s.config.Services.ShippingURL)
+		if err != nil {
+			return err
+		}
+		v1.POST("/shipping/rates", proxyHandler(shippingProxy))
+		v1.GET("/orders/:id/tracking", proxyHandler(shippingProxy))
+	}
+
+	if s.config.Services.ReviewURL != "" {
+		reviewProxy, err := s.newServiceProxy("review service", s.config.Services.ReviewURL)
+		if err != nil {
+			return err
+		}
+		v1.GET("/products/:id/reviews", proxyHandler(reviewProxy))
+		v1.POST("/products/:id/reviews", proxyHandler(reviewProxy))
+		v1.GET("/products/:id/rating", proxyHandler(reviewProxy))
+		v1.PUT("/reviews/:id", proxyHandler(reviewProxy))
+		v1.DELETE("/reviews/:id", proxyHandler(reviewProxy))
+		v1.POST("/reviews/:id/votes", proxyHandler(reviewProxy))
+		v1.DELETE("/reviews/:id/votes", proxyHandler(reviewProxy))
+		v1.GET("/admin/reviews", proxyHandler(reviewProxy))
+		v1.POST("/admin/reviews/:id/moderation", proxyHandler(reviewProxy))
+	}
+
+	if s.config.Services.NotificationURL != "" {
+		notificationProxy, err := s.newServiceProxy("notification service", s.config.Services.NotificationURL)
+		if err != nil {
+			return err
+		}
+		v1.GET("/admin/email-templates", proxyHandler(notificationProxy))
+		v1.POST("/admin/email-templates", proxyHandler(notificationProxy))
+		v1.GET("/admin/email-templates/:key/locales/:locale/versions", proxyHandler(notificationProxy))
+		v1.POST("/admin/email-templates/:key/locales/:locale/versions/:version/activate", proxyHandler(notificationProxy))
+		v1.POST("/admin/email-templates/:key/preview", proxyHandler(notificationProxy))
+		v1.POST("/admin/email-templates/:key/test-send", proxyHandler(notificationProxy))
+	}
+
+	if s.config.Services.CurrencyURL != "" {
+		currencyProxy, err := s.newServiceProxy("currency service", s.config.Services.CurrencyURL)
+		if err != nil {
+			return err
+		}
+		v1.GET("/currencies", proxyHandler(currencyProxy))
+		v1.GET("/currencies/preference", proxyHandler(currencyProxy))
+		v1.PUT("/currencies/preference", proxyHandler(currencyProxy))
+		v1.DELETE("/currencies/preference", proxyHandler(currencyProxy))
+		v1.GET("/exchange-rates", proxyHandler(currencyProxy))
+		v1.GET("/exchange-rates/convert", proxyHandler(currencyProxy))
+		v1.POST("/admin/exchange-rates/refresh", proxyHandler(currencyProxy))
+	}
+
+	if s.config.Services.PricingURL != "" {
+		pricingProxy, err := s.newServiceProxy("pricing service", s.config.Services.PricingURL)
+		if err != nil {
+			return err
+		}
+		v1.GET("/prices", proxyHandler(pricingProxy))
+		v1.GET("/admin/price-lists", proxyHandler(pricingProxy))
+		v1.POST("/admin/price-lists", proxyHandler(pricingProxy))
+		v1.GET("/admin/price-lists/:id", proxyHandler(pricingProxy))
+		v1.PUT("/admin/price-lists/:id", proxyHandler(pricingProxy))
+		v1.GET("/admin/price-lists/:id/prices", proxyHandler(pricingProxy))
+		v1.POST("/admin/price-lists/:id/prices", proxyHandler(pricingProxy))
+		v1.DELETE("/admin/price-lists/:id/prices/:priceId", proxyHandler(pricingProxy))
+		v1.GET("/admin/customer-groups/:userId", proxyHandler(pricingProxy))
+		v1.PUT("/admin/customer-groups/:userId", proxyHandler(pricingProxy))
+		v1.DELETE("/admin/customer-groups/:userId", proxyHandler(pricingProxy))
+	}
+
+	if s.config.Services.SubscriptionURL != "" {
+		subscriptionProxy, err := s.newServiceProxy("subscription service", s.config.Services.SubscriptionURL)
+		if err != nil {
+			return err
+		}
+		v1.GET("/subscription-plans", proxyHandler(subscriptionProxy))
+		v1.GET("/subscription-plans/:id", proxyHandler(subscriptionProxy))
+		v1.GET("/subscriptions", proxyHandler(subscriptionProxy))
+		v1.POST("/subscriptions", proxyHandler(subscriptionProxy))
+		v1.GET("/subscriptions/:id", proxyHandler(subscriptionProxy))
+		v1.GET("/subscriptions/:id/renewals", proxyHandler(subscriptionProxy))
+		v1.PUT("/subscriptions/:id/plan", proxyHandler(subscriptionProxy))
+		v1.POST("/subscriptions/:id/pause", proxyHandler(subscriptionProxy))
+		v1.POST("/subscriptions/:id/resume", proxyHandler(subscriptionProxy))
+		v1.POST("/subscriptions/:id/cancel", proxyHandler(subscriptionProxy))
+		v1.PUT("/subscriptions/:id/payment-method", proxyHandler(subscriptionProxy))
+		v1.GET("/admin/subscription-plans", proxyHandler(subscriptionProxy))
+		v1.POST("/admin/subscription-plans", proxyHandler(subscriptionProxy))
+		v1.PUT("/admin/subscription-plans/:id", proxyHandler(subscriptionProxy))
+	}
+
+	if s.config.Services.SellerURL != "" {
+		sellerProxy, err := s.newServiceProxy("seller service", s.config.Services.SellerURL)
+		if err != nil {
+			return err
+		}
+		v1.POST("/sellers", proxyHandler(sellerProxy))
+		v1.GET("/sellers/me", proxyHandler(sellerProxy))
+		v1.PUT("/seller/profile", proxyHandler(sellerProxy))
+		v1.GET("/seller/products", proxyHandler(sellerProxy))
+		v1.POST("/seller/products", proxyHandler(sellerProxy))
+		v1.GET("/seller/orders", proxyHandler(sellerProxy))
+		v1.GET("/seller/orders/:id", proxyHandler(sellerProxy))
+		v1.GET("/seller/statements", proxyHandler(sellerProxy))
+		v1.GET("/seller/statements/:id", proxyHandler(sellerProxy))
+		v1.GET("/admin/sellers", proxyHandler(sellerProxy))
+		v1.GET("/admin/sellers/:id", proxyHandler(sellerProxy))
+		v1.POST("/admin/sellers/:id/review", proxyHandler(sellerProxy))
+		v1.PUT("/admin/sellers/:id/commission", proxyHandler(sellerProxy))
+		v1.GET("/admin/sellers/:id/statements", proxyHandler(sellerProxy))
+		v1.POST("/admin/sellers/:id/statements", proxyHandler(sellerProxy))
+		v1.POST("/admin/seller-statements", proxyHandler(sellerProxy))
+		v1.GET("/admin/seller-statements/:id", proxyHandler(sellerProxy))
+		v1.POST("/admin/seller-statements/:id/paid", proxyHandler(sellerProxy))
+		// Background tasks, inspected through the seller service
+		v1.GET("/admin/tasks/queues", proxyHandler(sellerProxy))
+		v1.GET("/admin/tasks/queues/:queue/failed", proxyHandler(sellerProxy))
+		v1.POST("/admin/tasks/queues/:queue/tasks/:id/run", proxyHandler(sellerProxy))
+		v1.DELETE("/admin/tasks/queues/:queue/tasks/:id", proxyHandler(sellerProxy))
+	}
+
+	if s.config.Services.AnalyticsURL != "" {
+		analyticsProxy, err := s.newServiceProxy("analytics service", s.config.Services.AnalyticsURL)
+		if err != nil {
+			return err
+		}
+		v1.GET("/admin/analytics/orders", proxyHandler(analyticsProxy))
+		v1.GET("/admin/analytics/revenue", proxyHandler(analyticsProxy))
+		v1.GET("/admin/analytics/conversion", proxyHandler(analyticsProxy))
+		v1.GET("/admin/analytics/new-users", proxyHandler(analyticsProxy))
+		v1.GET("/admin/analytics/top-products", proxyHandler(analyticsProxy))
+		v1.POST("/admin/analytics/refresh", proxyHandler(analyticsProxy))
+	}
+
+	if s.config.Services.StockAlertURL != "" {
+		stockAlertProxy, err := s.newServiceProxy("stock alert service", s.config.Services.StockAlertURL)
+		if err != nil {
+			return err
+		}
+		v1.GET("/stock-alerts", proxyHandler(stockAlertProxy))
+		v1.POST("/stock-alerts", proxyHandler(stockAlertProxy))
+		v1.DELETE("/stock-alerts/:id", proxyHandler(stockAlertProxy))
+	}
+
 	// GraphQL endpoint (placeholder for now)
 	s.router.POST("/graphql", s.graphqlHandler)
 	s.router.GET("/playground", s.playgroundHandler)
@@ -66,25 +410,6 @@ func (s *Server) setupRoutes() error {
 	return nil
 }
 
-// healthCheck handles health check requests
-func (s *Server) healthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "healthy",
-		"service": "api-gateway",
-		"version": s.config.Version,
-	})
-}
-
-// readinessCheck handles readiness check requests
-func (s *Server) readinessCheck(c *gin.Context) {
-	// TODO: Add actual readiness checks (database connectivity, etc.)
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "ready",
-		"service": "api-gateway",
-		"version": s.config.Version,
-	})
-}
-
 // getStatus handles status requests
 func (s *Server) getStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+	"github.com/kaanevranportfolio/Commercium/pkg/ratelimit"
+)
+
+// TestRateLimitIgnoresSpoofedForwardedFor checks clients can't get round
+// the limits of their IP address by sending X-Forwarded-For, unless they are
+// a trusted proxy
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, tc := range []struct {
+		name    string
+		proxies []string
+		// codes are those of two requests from the same address forwarded
+		// for different clients
+		codes []int
+	}{
+		{"no trusted proxies", nil, []int{http.StatusOK, http.StatusTooManyRequests}},
+		{"untrusted proxy", []string{"10.0.0.0/8"}, []int{http.StatusOK, http.StatusTooManyRequests}},
+		{"trusted proxy", []string{"192.0.2.0/24"}, []int{http.StatusOK, http.StatusOK}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{Environment: "test", Version: "test"}
+			cfg.Services.Gateway.TrustedProxies = tc.proxies
+			cfg.Services.Gateway.RateLimit = config.RateLimitConfig{
+				Enabled:   true,
+				Algorithm: ratelimit.AlgorithmTokenBucket,
+				Backend:   ratelimit.BackendMemory,
+				Limit:     1,
+				Window:    time.Minute,
+			}
+			log, err := logger.New(config.LoggerConfig{Level: "error", Format: "json", Output: "stdout"}, "api-gateway")
+			require.NoError(t, err)
+			metricsRegistry, err := metrics.NewRegistry(cfg.Metrics, "api-gateway")
+			require.NoError(t, err)
+
+			server, err := New(cfg, log, metricsRegistry)
+			require.NoError(t, err)
+			defer server.Close()
+
+			for i, client := range []string{"198.51.100.1", "198.51.100.2"} {
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+				req.RemoteAddr = "192.0.2.10:40000"
+				req.Header.Set("X-Forwarded-For", client)
+				rec := httptest.NewRecorder()
+				server.Handler().ServeHTTP(rec, req)
+				assert.Equal(t, tc.codes[i], rec.Code, "request %d", i+1)
+			}
+		})
+	}
+}
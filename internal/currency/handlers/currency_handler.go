@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/currency/models"
+	"github.com/kaanevranportfolio/Commercium/internal/currency/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/validation"
+)
+
+// CurrencyHandler handles HTTP requests for currency operations
+type CurrencyHandler struct {
+	currencyService service.CurrencyService
+	jwtService      *auth.JWTService
+	logger          *logger.Logger
+}
+
+// NewCurrencyHandler creates a new currency handler
+func NewCurrencyHandler(currencyService service.CurrencyService, jwtService *auth.JWTService, logger *logger.Logger) *CurrencyHandler {
+	return &CurrencyHandler{
+		currencyService: currencyService,
+		jwtService:      jwtService,
+		logger:          logger,
+	}
+}
+
+// ListCurrencies lists the currencies prices can be displayed in
+func (h *CurrencyHandler) ListCurrencies(c *gin.Context) {
+	c.JSON(http.StatusOK, h.currencyService.ListCurrencies(c.Request.Context()))
+}
+
+// ListRates returns the current exchange rates
+func (h *CurrencyHandler) ListRates(c *gin.Context) {
+	rates, err := h.currencyService.ListRates(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "Failed to list exchange rates")
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// Convert converts an amount between two currencies
+func (h *CurrencyHandler) Convert(c *gin.Context) {
+	var req models.ConvertRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	conversion, err := h.currencyService.Convert(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to convert amount")
+		return
+	}
+
+	c.JSON(http.StatusOK, conversion)
+}
+
+// GetPreference returns the authenticated user's display currency
+func (h *CurrencyHandler) GetPreference(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	preference, err := h.currencyService.GetPreference(c.Request.Context(), userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get currency preference")
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
+
+// SetPreference changes the authenticated user's display currency
+func (h *CurrencyHandler) SetPreference(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.SetPreferenceRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	preference, err := h.currencyService.SetPreference(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to set currency preference")
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
+
+// DeletePreference resets the authenticated user's display currency
+func (h *CurrencyHandler) DeletePreference(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := h.currencyService.DeletePreference(c.Request.Context(), userID); err != nil {
+		h.respondError(c, err, "Failed to delete currency preference")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Currency preference removed"})
+}
+
+// RefreshRates fetches the latest exchange rates right away (admin)
+func (h *CurrencyHandler) RefreshRates(c *gin.Context) {
+	count, err := h.currencyService.RefreshRates(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to refresh exchange rates", "error", err)
+		h.respondError(c, err, "Failed to refresh exchange rates")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rates": count})
+}
+
+// DisplayAmounts converts amounts to a customer's display currency (internal)
+func (h *CurrencyHandler) DisplayAmounts(c *gin.Context) {
+	var req models.DisplayAmountsRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	response, err := h.currencyService.DisplayAmounts(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to convert amounts")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// respondError logs a request failing on the side of the service, or of a
+// provider it depends on, and answers it with the problem details of err,
+// so middleware reading its status after it, such as idempotency's, sees
+// the failure
+func (h *CurrencyHandler) respondError(c *gin.Context, err error, message string) {
+	if apperrors.HTTPStatus(err) >= http.StatusInternalServerError {
+		logger.FromContext(c.Request.Context()).Error(message, "error", err)
+	}
+	apperrors.Respond(c, err)
+}
+
+// SetupRoutes sets up the currency routes.
+// Internal routes are called by other services and must not be exposed through the gateway.
+func (h *CurrencyHandler) SetupRoutes(r *gin.Engine) {
+	currencies := r.Group("/api/v1/currencies")
+	{
+		currencies.GET("", h.ListCurrencies)
+		currencies.GET("/preference", h.jwtService.Middleware(), h.GetPreference)
+		currencies.PUT("/preference", h.jwtService.Middleware(), h.SetPreference)
+		currencies.DELETE("/preference", h.jwtService.Middleware(), h.DeletePreference)
+	}
+
+	rates := r.Group("/api/v1/exchange-rates")
+	{
+		rates.GET("", h.ListRates)
+		rates.GET("/convert", h.Convert)
+	}
+
+	admin := r.Group("/api/v1/admin/exchange-rates")
+	admin.Use(h.jwtService.Middleware(), auth.RequireRole("admin"))
+	{
+		admin.POST("/refresh", h.RefreshRates)
+	}
+
+	internal := r.Group("/internal/v1")
+	{
+		internal.POST("/display-amounts", h.DisplayAmounts)
+	}
+}
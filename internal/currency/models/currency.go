@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExchangeRate is the price of one unit of the base currency in the quote
+// currency. Rates are decimal strings so they keep their exact value.
+type ExchangeRate struct {
+	BaseCurrency  string    `json:"base_currency" db:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency" db:"quote_currency"`
+	Rate          string    `json:"rate" db:"rate"`
+	Source        string    `json:"source" db:"source"`
+	FetchedAt     time.Time `json:"fetched_at" db:"fetched_at"`
+}
+
+// CurrenciesResponse lists the currencies prices can be displayed in
+type CurrenciesResponse struct {
+	BaseCurrency string   `json:"base_currency"`
+	Supported    []string `json:"supported"`
+}
+
+// RatesResponse holds the current rates of the supported currencies
+type RatesResponse struct {
+	BaseCurrency string            `json:"base_currency"`
+	Rates        map[string]string `json:"rates"`
+	FetchedAt    *time.Time        `json:"fetched_at,omitempty"`
+}
+
+// ConvertRequest represents the query parameters of the conversion endpoint
+type ConvertRequest struct {
+	Amount int64  `form:"amount"`
+	From   string `form:"from" binding:"required,len=3"`
+	To     string `form:"to" binding:"required,len=3"`
+}
+
+// Conversion is an amount converted between two currencies. Amounts are in
+// minor units of their currency.
+type Conversion struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Rate      string    `json:"rate"`
+	Amount    int64     `json:"amount"`
+	Converted int64     `json:"converted"`
+	Formatted string    `json:"formatted"`
+	RatesAsOf time.Time `json:"rates_as_of"`
+}
+
+// CurrencyPreference is the currency a customer wants prices displayed in
+type CurrencyPreference struct {
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Currency  string    `json:"currency" db:"currency"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SetPreferenceRequest represents the request to change the display currency
+type SetPreferenceRequest struct {
+	Currency string `json:"currency" binding:"required,len=3"`
+}
+
+// Money is an amount in minor units of a currency
+type Money struct {
+	Currency string `json:"currency" binding:"required,len=3"`
+	Amount   int64  `json:"amount"`
+}
+
+// DisplayAmountsRequest asks for amounts converted to a customer's display
+// currency. Currency overrides the customer's preference when set.
+type DisplayAmountsRequest struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Currency string    `json:"currency" binding:"omitempty,len=3"`
+	Amounts  []Money   `json:"amounts" binding:"max=500,dive"`
+}
+
+// DisplayAmount is an amount converted to the display currency
+type DisplayAmount struct {
+	Money
+	Converted int64  `json:"converted"`
+	Rate      string `json:"rate"`
+}
+
+// DisplayAmountsResponse holds the converted amounts in request order. It
+// has no currency when the customer has no preference and none was requested.
+type DisplayAmountsResponse struct {
+	Currency  string           `json:"currency,omitempty"`
+	Amounts   []*DisplayAmount `json:"amounts"`
+	RatesAsOf *time.Time       `json:"rates_as_of,omitempty"`
+}
@@ -0,0 +1,96 @@
+package rates
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/money"
+)
+
+// defaultECBURL is the daily reference rate feed of the European Central Bank
+const defaultECBURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// ecbProvider fetches the euro reference rates the European Central Bank
+// publishes every working day around 16:00 CET
+type ecbProvider struct {
+	url        string
+	httpClient *http.Client
+}
+
+// ecbEnvelope is the subset of the ECB feed we use
+type ecbEnvelope struct {
+	Cube struct {
+		Cube struct {
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// NewECBProvider creates a provider for the ECB reference rates
+func NewECBProvider(url string, timeout time.Duration) Provider {
+	if url == "" {
+		url = defaultECBURL
+	}
+
+	return &ecbProvider{
+		url:        url,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name returns the provider name
+func (p *ecbProvider) Name() string {
+	return ProviderECB
+}
+
+// Fetch downloads the latest reference rates. They are quoted against EUR.
+func (p *ecbProvider) Fetch(ctx context.Context) (*Snapshot, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ecb request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ecb request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("ecb request failed: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode ecb rates: %w", err)
+	}
+
+	day := envelope.Cube.Cube
+	snapshot := &Snapshot{
+		Base:  "EUR",
+		Rates: make(map[string]*big.Rat, len(day.Rates)),
+	}
+	for _, r := range day.Rates {
+		rate, err := money.ParseRate(r.Rate)
+		if err != nil {
+			return nil, fmt.Errorf("ecb rate for %s: %w", r.Currency, err)
+		}
+		snapshot.Rates[strings.ToUpper(r.Currency)] = rate
+	}
+
+	if len(snapshot.Rates) == 0 {
+		return nil, fmt.Errorf("ecb feed contains no rates")
+	}
+
+	return snapshot, nil
+}
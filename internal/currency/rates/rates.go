@@ -0,0 +1,69 @@
+// Package rates fetches exchange rates from rate providers
+package rates
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// Rate provider names
+const (
+	ProviderECB    = "ecb"
+	ProviderStatic = "static"
+)
+
+// Snapshot is a set of rates published together. Each rate is the
+// price of one unit of Base in the keyed currency.
+type Snapshot struct {
+	Base  string
+	Rates map[string]*big.Rat
+}
+
+// Provider fetches the latest exchange rates
+type Provider interface {
+	Name() string
+	Fetch(ctx context.Context) (*Snapshot, error)
+}
+
+// NewProvider creates the rate provider selected in the configuration
+func NewProvider(cfg config.CurrencyServiceConfig) (Provider, error) {
+	switch cfg.Rates.Provider {
+	case ProviderECB:
+		return NewECBProvider(cfg.Rates.ECBURL, cfg.Rates.Timeout), nil
+	case ProviderStatic:
+		return NewStaticProvider(cfg.BaseCurrency, cfg.Rates.StaticRates)
+	default:
+		return nil, fmt.Errorf("exchange rate provider not supported: %s", cfg.Rates.Provider)
+	}
+}
+
+// Rebase converts a snapshot to rates against another base currency, which
+// must be the snapshot's base or one of its currencies
+func Rebase(snapshot *Snapshot, base string) (*Snapshot, error) {
+	if snapshot.Base == base {
+		return snapshot, nil
+	}
+
+	baseRate, ok := snapshot.Rates[base]
+	if !ok {
+		return nil, fmt.Errorf("rates from %s don't include base currency %s", snapshot.Base, base)
+	}
+
+	rebased := &Snapshot{
+		Base:  base,
+		Rates: make(map[string]*big.Rat, len(snapshot.Rates)),
+	}
+	// The old base becomes one of the quoted currencies
+	rebased.Rates[snapshot.Base] = new(big.Rat).Inv(baseRate)
+	for currency, rate := range snapshot.Rates {
+		if currency == base {
+			continue
+		}
+		rebased.Rates[currency] = new(big.Rat).Quo(rate, baseRate)
+	}
+
+	return rebased, nil
+}
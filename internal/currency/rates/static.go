@@ -0,0 +1,52 @@
+package rates
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/money"
+)
+
+// staticProvider serves fixed rates from the configuration, for development
+// and tests
+type staticProvider struct {
+	base  string
+	rates map[string]*big.Rat
+}
+
+// NewStaticProvider creates a provider for the configured rates against base
+func NewStaticProvider(base string, configured map[string]string) (Provider, error) {
+	rates := make(map[string]*big.Rat, len(configured))
+	for currency, value := range configured {
+		rate, err := money.ParseRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("static rate for %s: %w", currency, err)
+		}
+		rates[strings.ToUpper(currency)] = rate
+	}
+
+	return &staticProvider{
+		base:  strings.ToUpper(base),
+		rates: rates,
+	}, nil
+}
+
+// Name returns the provider name
+func (p *staticProvider) Name() string {
+	return ProviderStatic
+}
+
+// Fetch returns the configured rates
+func (p *staticProvider) Fetch(ctx context.Context) (*Snapshot, error) {
+	rates := make(map[string]*big.Rat, len(p.rates))
+	for currency, rate := range p.rates {
+		rates[currency] = new(big.Rat).Set(rate)
+	}
+
+	return &Snapshot{
+		Base:  p.base,
+		Rates: rates,
+	}, nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kaanevranportfolio/Commercium/internal/currency/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// CurrencyRepository defines the interface for currency data operations
+type CurrencyRepository interface {
+	// Exchange rate operations
+	ListRates(ctx context.Context, baseCurrency string) ([]*models.ExchangeRate, error)
+	SaveRates(ctx context.Context, rates []*models.ExchangeRate) error
+
+	// Preference operations
+	GetPreference(ctx context.Context, userID uuid.UUID) (*models.CurrencyPreference, error)
+	UpsertPreference(ctx context.Context, preference *models.CurrencyPreference) error
+	DeletePreference(ctx context.Context, userID uuid.UUID) error
+}
+
+// currencyRepository implements the CurrencyRepository interface
+type currencyRepository struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewCurrencyRepository creates a new currency repository
+func NewCurrencyRepository(db *database.DB, logger *logger.Logger) CurrencyRepository {
+	return &currencyRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ListRates retrieves the latest rates against a base currency
+func (r *currencyRepository) ListRates(ctx context.Context, baseCurrency string) ([]*models.ExchangeRate, error) {
+	rates := []*models.ExchangeRate{}
+	query := `
+		SELECT base_currency, quote_currency, rate, source, fetched_at
+		FROM exchange_rates
+		WHERE base_currency = $1
+		ORDER BY quote_currency`
+
+	err := r.db.SelectContext(ctx, &rates, query, baseCurrency)
+	if err != nil {
+		r.logger.Error("Failed to list exchange rates", "error", err, "base_currency", baseCurrency)
+		return nil, fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+
+	return rates, nil
+}
+
+// SaveRates stores a set of fetched rates, replacing the previous rate of each
+// currency pair
+func (r *currencyRepository) SaveRates(ctx context.Context, rates []*models.ExchangeRate) error {
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		for _, rate := range rates {
+			_, err := tx.NamedExecContext(ctx, `
+				INSERT INTO exchange_rates (base_currency, quote_currency, rate, source, fetched_at)
+				VALUES (:base_currency, :quote_currency, :rate, :source, :fetched_at)
+				ON CONFLICT (base_currency, quote_currency) DO UPDATE
+				SET rate = EXCLUDED.rate,
+				    source = EXCLUDED.source,
+				    fetched_at = EXCLUDED.fetched_at`, rate)
+			if err != nil {
+				r.logger.Error("Failed to save exchange rate", "error", err, "quote_currency", rate.QuoteCurrency)
+				return fmt.Errorf("failed to save exchange rate: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetPreference retrieves a customer's display currency
+func (r *currencyRepository) GetPreference(ctx context.Context, userID uuid.UUID) (*models.CurrencyPreference, error) {
+	preference := &models.CurrencyPreference{}
+	query := `
+		SELECT user_id, currency, created_at, updated_at
+		FROM currency_preferences
+		WHERE user_id = $1`
+
+	err := r.db.GetContext(ctx, preference, query, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("currency preference not found")
+		}
+		r.logger.Error("Failed to get currency preference", "error", err, "user_id", userID)
+		return nil, fmt.Errorf("failed to get currency preference: %w", err)
+	}
+
+	return preference, nil
+}
+
+// UpsertPreference sets a customer's display currency
+func (r *currencyRepository) UpsertPreference(ctx context.Context, preference *models.CurrencyPreference) error {
+	query := `
+		INSERT INTO currency_preferences (user_id, currency)
+		VALUES (:user_id, :currency)
+		ON CONFLICT (user_id) DO UPDATE SET currency = EXCLUDED.currency
+		RETURNING created_at, updated_at`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to prepare upsert currency preference statement", "error", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, preference).Scan(&preference.CreatedAt, &preference.UpdatedAt)
+	if err != nil {
+		if database.IsForeignKeyViolation(err) {
+			return apperrors.NotFound("user not found")
+		}
+		r.logger.Error("Failed to upsert currency preference", "error", err, "user_id", preference.UserID)
+		return fmt.Errorf("failed to save currency preference: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePreference removes a customer's display currency
+func (r *currencyRepository) DeletePreference(ctx context.Context, userID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM currency_preferences WHERE user_id = $1`, userID)
+	if err != nil {
+		r.logger.Error("Failed to delete currency preference", "error", err, "user_id", userID)
+		return fmt.Errorf("failed to delete currency preference: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete currency preference: %w", err)
+	}
+	if rows == 0 {
+		return apperrors.NotFound("currency preference not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/currency/models"
+	"github.com/kaanevranportfolio/Commercium/internal/currency/rates"
+	"github.com/kaanevranportfolio/Commercium/internal/currency/repository"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/money"
+)
+
+// CurrencyService defines the interface for currency business logic
+type CurrencyService interface {
+	ListCurrencies(ctx context.Context) *models.CurrenciesResponse
+	ListRates(ctx context.Context) (*models.RatesResponse, error)
+	Convert(ctx context.Context, req *models.ConvertRequest) (*models.Conversion, error)
+	RefreshRates(ctx context.Context) (int, error)
+
+	// Display currency preferences
+	GetPreference(ctx context.Context, userID uuid.UUID) (*models.CurrencyPreference, error)
+	SetPreference(ctx context.Context, userID uuid.UUID, req *models.SetPreferenceRequest) (*models.CurrencyPreference, error)
+	DeletePreference(ctx context.Context, userID uuid.UUID) error
+
+	// DisplayAmounts converts amounts to a customer's display currency for other services
+	DisplayAmounts(ctx context.Context, req *models.DisplayAmountsRequest) (*models.DisplayAmountsResponse, error)
+}
+
+// currencyService implements the CurrencyService interface
+type currencyService struct {
+	repo      repository.CurrencyRepository
+	provider  rates.Provider
+	base      string
+	supported []string
+	config    *config.Config
+	logger    *logger.Logger
+}
+
+// NewCurrencyService creates a new currency service
+func NewCurrencyService(repo repository.CurrencyRepository, provider rates.Provider, config *config.Config, logger *logger.Logger) CurrencyService {
+	supported := make([]string, 0, len(config.Services.Currency.Supported))
+	for _, currency := range config.Services.Currency.Supported {
+		supported = append(supported, strings.ToUpper(currency))
+	}
+
+	return &currencyService{
+		repo:      repo,
+		provider:  provider,
+		base:      strings.ToUpper(config.Services.Currency.BaseCurrency),
+		supported: supported,
+		config:    config,
+		logger:    logger,
+	}
+}
+
+// rateTable holds the rates of every known currency against the base currency
+type rateTable struct {
+	rates     map[string]*big.Rat
+	fetchedAt time.Time
+}
+
+// ListCurrencies returns the currencies prices can be displayed in
+func (s *currencyService) ListCurrencies(ctx context.Context) *models.CurrenciesResponse {
+	return &models.CurrenciesResponse{
+		BaseCurrency: s.base,
+		Supported:    s.supported,
+	}
+}
+
+// ListRates returns the current rates of the supported currencies
+func (s *currencyService) ListRates(ctx context.Context) (*models.RatesResponse, error) {
+	stored, err := s.repo.ListRates(ctx, s.base)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.RatesResponse{
+		BaseCurrency: s.base,
+		Rates:        make(map[string]string, len(stored)),
+	}
+	for _, rate := range stored {
+		if !s.isSupported(rate.QuoteCurrency) {
+			continue
+		}
+		response.Rates[rate.QuoteCurrency] = rate.Rate
+		if response.FetchedAt == nil || rate.FetchedAt.Before(*response.FetchedAt) {
+			fetchedAt := rate.FetchedAt
+			response.FetchedAt = &fetchedAt
+		}
+	}
+
+	return response, nil
+}
+
+// Convert converts an amount between two currencies at the current rates
+func (s *currencyService) Convert(ctx context.Context, req *models.ConvertRequest) (*models.Conversion, error) {
+	from := strings.ToUpper(req.From)
+	to := strings.ToUpper(req.To)
+
+	table, err := s.loadRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rate, err := table.rate(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	converted := money.Convert(req.Amount, from, to, rate)
+	return &models.Conversion{
+		From:      from,
+		To:        to,
+		Rate:      money.FormatRate(rate),
+		Amount:    req.Amount,
+		Converted: converted,
+		Formatted: money.Format(converted, to),
+		RatesAsOf: table.fetchedAt,
+	}, nil
+}
+
+// RefreshRates fetches the latest rates from the provider and stores them.
+// It returns the number of rates stored.
+func (s *currencyService) RefreshRates(ctx context.Context) (int, error) {
+	snapshot, err := s.provider.Fetch(ctx)
+	if err != nil {
+		return 0, apperrors.Upstream("exchange rate provider is unavailable").Wrap(err)
+	}
+
+	snapshot, err = rates.Rebase(snapshot, s.base)
+	if err != nil {
+		return 0, err
+	}
+
+	fetchedAt := time.Now()
+	stored := make([]*models.ExchangeRate, 0, len(snapshot.Rates))
+	for currency, rate := range snapshot.Rates {
+		stored = append(stored, &models.ExchangeRate{
+			BaseCurrency:  s.base,
+			QuoteCurrency: currency,
+			Rate:          money.FormatRate(rate),
+			Source:        s.provider.Name(),
+			FetchedAt:     fetchedAt,
+		})
+	}
+
+	if err := s.repo.SaveRates(ctx, stored); err != nil {
+		return 0, err
+	}
+
+	for _, currency := range s.supported {
+		if _, ok := snapshot.Rates[currency]; !ok && currency != s.base {
+			s.logger.Warn("Exchange rate provider has no rate for supported currency",
+				"currency", currency, "provider", s.provider.Name())
+		}
+	}
+
+	s.logger.Info("Exchange rates refreshed", "provider", s.provider.Name(), "rates", len(stored))
+	return len(stored), nil
+}
+
+// GetPreference returns a customer's display currency
+func (s *currencyService) GetPreference(ctx context.Context, userID uuid.UUID) (*models.CurrencyPreference, error) {
+	return s.repo.GetPreference(ctx, userID)
+}
+
+// SetPreference changes a customer's display currency
+func (s *currencyService) SetPreference(ctx context.Context, userID uuid.UUID, req *models.SetPreferenceRequest) (*models.CurrencyPreference, error) {
+	currency := strings.ToUpper(req.Currency)
+	if !money.IsCurrencyCode(currency) || !s.isSupported(currency) {
+		return nil, apperrors.Validation("invalid currency: %s is not supported", req.Currency)
+	}
+
+	preference := &models.CurrencyPreference{
+		UserID:   userID,
+		Currency: currency,
+	}
+	if err := s.repo.UpsertPreference(ctx, preference); err != nil {
+		return nil, err
+	}
+
+	return preference, nil
+}
+
+// DeletePreference resets a customer's display currency
+func (s *currencyService) DeletePreference(ctx context.Context, userID uuid.UUID) error {
+	return s.repo.DeletePreference(ctx, userID)
+}
+
+// DisplayAmounts converts amounts to the requested display currency, or to
+// the customer's preferred one. Without either the amounts are returned
+// unconverted.
+func (s *currencyService) DisplayAmounts(ctx context.Context, req *models.DisplayAmountsRequest) (*models.DisplayAmountsResponse, error) {
+	currency := strings.ToUpper(req.Currency)
+	if currency == "" && req.UserID != uuid.Nil {
+		preference, err := s.repo.GetPreference(ctx, req.UserID)
+		if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+			return nil, err
+		}
+		if preference != nil {
+			currency = preference.Currency
+		}
+	}
+
+	response := &models.DisplayAmountsResponse{
+		Currency: currency,
+		Amounts:  make([]*models.DisplayAmount, 0, len(req.Amounts)),
+	}
+	if currency == "" {
+		return response, nil
+	}
+
+	if !s.isSupported(currency) {
+		return nil, apperrors.Validation("invalid currency: %s is not supported", currency)
+	}
+
+	table, err := s.loadRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	response.RatesAsOf = &table.fetchedAt
+
+	for _, amount := range req.Amounts {
+		from := strings.ToUpper(amount.Currency)
+		rate, err := table.rate(from, currency)
+		if err != nil {
+			return nil, err
+		}
+
+		response.Amounts = append(response.Amounts, &models.DisplayAmount{
+			Money:     models.Money{Currency: from, Amount: amount.Amount},
+			Converted: money.Convert(amount.Amount, from, currency, rate),
+			Rate:      money.FormatRate(rate),
+		})
+	}
+
+	return response, nil
+}
+
+// loadRates reads the stored rates and refuses to use them once they are
+// older than the configured maximum age
+func (s *currencyService) loadRates(ctx context.Context) (*rateTable, error) {
+	stored, err := s.repo.ListRates(ctx, s.base)
+	if err != nil {
+		return nil, err
+	}
+	if len(stored) == 0 {
+		return nil, apperrors.Unavailable("exchange rates are not available yet")
+	}
+
+	table := &rateTable{
+		rates: map[string]*big.Rat{s.base: big.NewRat(1, 1)},
+	}
+	for _, rate := range stored {
+		parsed, err := money.ParseRate(rate.Rate)
+		if err != nil {
+			return nil, err
+		}
+		table.rates[rate.QuoteCurrency] = parsed
+		if table.fetchedAt.IsZero() || rate.FetchedAt.Before(table.fetchedAt) {
+			table.fetchedAt = rate.FetchedAt
+		}
+	}
+
+	if time.Since(table.fetchedAt) > s.config.Services.Currency.Rates.MaxAge {
+		return nil, apperrors.Unavailable("exchange rates are stale, last fetched at %s", table.fetchedAt.Format(time.RFC3339))
+	}
+
+	return table, nil
+}
+
+// rate returns the rate from one currency to another
+func (t *rateTable) rate(from, to string) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+
+	baseToFrom, ok := t.rates[from]
+	if !ok {
+		return nil, apperrors.Validation("currency not supported: %s", from)
+	}
+	baseToTo, ok := t.rates[to]
+	if !ok {
+		return nil, apperrors.Validation("currency not supported: %s", to)
+	}
+
+	return money.CrossRate(baseToFrom, baseToTo), nil
+}
+
+// isSupported reports whether prices can be displayed in a currency. Any
+// currency with a rate is allowed when no list is configured.
+func (s *currencyService) isSupported(currency string) bool {
+	if currency == s.base || len(s.supported) == 0 {
+		return true
+	}
+	for _, supported := range s.supported {
+		if supported == currency {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kaanevranportfolio/Commercium/internal/notification/models"
+	"github.com/kaanevranportfolio/Commercium/internal/notification/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/validation"
+)
+
+// NotificationHandler handles HTTP requests for notification operations
+type NotificationHandler struct {
+	notificationService service.NotificationService
+	jwtService          *auth.JWTService
+	logger              *logger.Logger
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(notificationService service.NotificationService, jwtService *auth.JWTService, logger *logger.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		jwtService:          jwtService,
+		logger:              logger,
+	}
+}
+
+// CreateTemplate adds a version of an email template (admin)
+func (h *NotificationHandler) CreateTemplate(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+
+	var req models.CreateTemplateRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	template, err := h.notificationService.CreateTemplate(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to create email template")
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// ListTemplates lists the email templates (admin)
+func (h *NotificationHandler) ListTemplates(c *gin.Context) {
+	templates, err := h.notificationService.ListTemplates(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "Failed to list email templates")
+		return
+	}
+
+	httpx.Items(c, templates)
+}
+
+// ListTemplateVersions lists the versions of an email template in one locale (admin)
+func (h *NotificationHandler) ListTemplateVersions(c *gin.Context) {
+	versions, err := h.notificationService.ListTemplateVersions(c.Request.Context(), c.Param("key"), c.Param("locale"))
+	if err != nil {
+		h.respondError(c, err, "Failed to list email template versions")
+		return
+	}
+
+	httpx.Items(c, versions)
+}
+
+// ActivateTemplate makes a version of an email template the one used for sending (admin)
+func (h *NotificationHandler) ActivateTemplate(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template version"})
+		return
+	}
+
+	template, err := h.notificationService.ActivateTemplate(c.Request.Context(), c.Param("key"), c.Param("locale"), version)
+	if err != nil {
+		h.respondError(c, err, "Failed to activate email template")
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// Preview renders an email template with sample data (admin)
+func (h *NotificationHandler) Preview(c *gin.Context) {
+	var req models.PreviewRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	rendered, err := h.notificationService.Preview(c.Request.Context(), c.Param("key"), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to render email template")
+		return
+	}
+
+	c.JSON(http.StatusOK, rendered)
+}
+
+// TestSend sends an email template rendered with sample data to a test recipient (admin)
+func (h *NotificationHandler) TestSend(c *gin.Context) {
+	var req models.TestSendRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	rendered, err := h.notificationService.TestSend(c.Request.Context(), c.Param("key"), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to send test email")
+		return
+	}
+
+	c.JSON(http.StatusOK, rendered)
+}
+
+// SendEmail sends a transactional email (internal)
+func (h *NotificationHandler) SendEmail(c *gin.Context) {
+	var req models.SendEmailRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	rendered, err := h.notificationService.SendEmail(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to send transactional email", "error", err, "template", req.Template)
+		h.respondError(c, err, "Failed to send email")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"template": rendered.Key,
+		"locale":   rendered.Locale,
+		"version":  rendered.Version,
+	})
+}
+
+// respondError logs a request failing on the side of the service, or of a
+// provider it depends on, and answers it with the problem details of err,
+// so middleware reading its status after it, such as idempotency's, sees
+// the failure
+func (h *NotificationHandler) respondError(c *gin.Context, err error, message string) {
+	if apperrors.HTTPStatus(err) >= http.StatusInternalServerError {
+		logger.FromContext(c.Request.Context()).Error(message, "error", err)
+	}
+	apperrors.Respond(c, err)
+}
+
+// SetupRoutes sets up the notification routes.
+// Internal routes are called by other services and must not be exposed through the gateway.
+func (h *NotificationHandler) SetupRoutes(r *gin.Engine) {
+	admin := r.Group("/api/v1/admin/email-templates")
+	admin.Use(h.jwtService.Middleware(), auth.RequireRole("admin"))
+	{
+		admin.GET("", h.ListTemplates)
+		admin.POST("", h.CreateTemplate)
+		admin.GET("/:key/locales/:locale/versions", h.ListTemplateVersions)
+		admin.POST("/:key/locales/:locale/versions/:version/activate", h.ActivateTemplate)
+		admin.POST("/:key/preview", h.Preview)
+		admin.POST("/:key/test-send", h.TestSend)
+	}
+
+	internal := r.Group("/internal/v1")
+	{
+		internal.POST("/emails", h.SendEmail)
+	}
+}
@@ -0,0 +1,202 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// Message is an email with HTML and plain text alternatives
+type Message struct {
+	To       string
+	Subject  string
+	HTMLBody string
+	TextBody string
+}
+
+// Mailer delivers email messages
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New creates the mailer selected by the email driver setting
+func New(cfg config.EmailConfig, log *logger.Logger) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTPMailer(cfg)
+	case "log", "":
+		return NewLogMailer(log), nil
+	default:
+		return nil, fmt.Errorf("email driver %q is not supported", cfg.Driver)
+	}
+}
+
+// SMTPMailer sends messages through an SMTP relay
+type SMTPMailer struct {
+	addr    string
+	host    string
+	auth    smtp.Auth
+	from    mail.Address
+	timeout time.Duration
+}
+
+// NewSMTPMailer creates a mailer for the configured SMTP relay
+func NewSMTPMailer(cfg config.EmailConfig) (*SMTPMailer, error) {
+	if cfg.SMTPHost == "" || cfg.SMTPPort == 0 {
+		return nil, fmt.Errorf("smtp host and port are required")
+	}
+	if _, err := mail.ParseAddress(cfg.FromAddress); err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+
+	m := &SMTPMailer{
+		addr:    net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort)),
+		host:    cfg.SMTPHost,
+		from:    mail.Address{Name: cfg.FromName, Address: cfg.FromAddress},
+		timeout: cfg.Timeout,
+	}
+	if m.timeout == 0 {
+		m.timeout = 10 * time.Second
+	}
+	if cfg.SMTPUser != "" {
+		m.auth = smtp.PlainAuth("", cfg.SMTPUser, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+
+	return m, nil
+}
+
+// Send delivers a message, upgrading the connection with STARTTLS when the relay offers it
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return apperrors.Validation("invalid recipient: %s", err)
+	}
+
+	data, err := m.build(to, msg)
+	if err != nil {
+		return err
+	}
+
+	dialer := &net.Dialer{Timeout: m.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("smtp failed: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(m.timeout))
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp failed: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("smtp failed: %w", err)
+		}
+	}
+	if m.auth != nil {
+		if err := client.Auth(m.auth); err != nil {
+			return fmt.Errorf("smtp failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("smtp failed: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("smtp failed: %w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp failed: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("smtp failed: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp failed: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// build formats a multipart/alternative message with quoted-printable parts
+func (m *SMTPMailer) build(to *mail.Address, msg *Message) ([]byte, error) {
+	boundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", m.from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+
+	// The last part is the preferred one
+	parts := []struct{ contentType, body string }{
+		{"text/plain", msg.TextBody},
+		{"text/html", msg.HTMLBody},
+	}
+	for _, part := range parts {
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		fmt.Fprintf(&buf, "Content-Type: %s; charset=utf-8\r\n", part.contentType)
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+		qp := quotedprintable.NewWriter(&buf)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, fmt.Errorf("failed to encode message: %w", err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encode message: %w", err)
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes(), nil
+}
+
+// randomBoundary returns a MIME boundary that won't occur in the encoded parts
+func randomBoundary() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate boundary: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// LogMailer logs messages instead of sending them, for development
+type LogMailer struct {
+	logger *logger.Logger
+}
+
+// NewLogMailer creates a mailer that only logs messages
+func NewLogMailer(log *logger.Logger) *LogMailer {
+	return &LogMailer{logger: log}
+}
+
+// Send logs the message
+func (m *LogMailer) Send(ctx context.Context, msg *Message) error {
+	m.logger.Info("Email not sent, log driver in use", "to", msg.To, "subject", msg.Subject, "text", msg.TextBody)
+	return nil
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailTemplate is one version of a transactional email in one locale.
+// Subject and text body are Go text templates, the HTML body is an
+// html/template so data is escaped.
+type EmailTemplate struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	Key         string     `json:"key" db:"key"`
+	Locale      string     `json:"locale" db:"locale"`
+	Version     int        `json:"version" db:"version"`
+	Subject     string     `json:"subject" db:"subject"`
+	HTMLBody    string     `json:"html_body" db:"html_body"`
+	TextBody    string     `json:"text_body" db:"text_body"`
+	Description *string    `json:"description,omitempty" db:"description"`
+	Active      bool       `json:"active" db:"active"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	ActivatedAt *time.Time `json:"activated_at,omitempty" db:"activated_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// TemplateSummary describes a template in one locale
+type TemplateSummary struct {
+	Key           string    `json:"key" db:"key"`
+	Locale        string    `json:"locale" db:"locale"`
+	LatestVersion int       `json:"latest_version" db:"latest_version"`
+	ActiveVersion *int      `json:"active_version,omitempty" db:"active_version"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateTemplateRequest represents a request to add a version of a template
+type CreateTemplateRequest struct {
+	Key         string `json:"key" binding:"required,max=100"`
+	Locale      string `json:"locale" binding:"required,max=10"`
+	Subject     string `json:"subject" binding:"required,max=1000"`
+	HTMLBody    string `json:"html_body" binding:"required"`
+	TextBody    string `json:"text_body" binding:"required"`
+	Description string `json:"description,omitempty" binding:"max=500"`
+	// Activate makes the new version the one used for sending
+	Activate bool `json:"activate"`
+}
+
+// PreviewRequest represents a request to render a template with sample data.
+// Without a version the active version is rendered.
+type PreviewRequest struct {
+	Locale  string                 `json:"locale" binding:"required,max=10"`
+	Version int                    `json:"version,omitempty" binding:"omitempty,min=1"`
+	Data    map[string]interface{} `json:"data"`
+}
+
+// TestSendRequest represents a request to send a rendered template to a test recipient
+type TestSendRequest struct {
+	PreviewRequest
+	To string `json:"to" binding:"required,email"`
+}
+
+// SendEmailRequest represents a request from another service to send a
+// transactional email with the active version of a template
+type SendEmailRequest struct {
+	Template string                 `json:"template" binding:"required,max=100"`
+	Locale   string                 `json:"locale,omitempty" binding:"max=10"`
+	To       string                 `json:"to" binding:"required,email"`
+	Data     map[string]interface{} `json:"data"`
+}
+
+// RenderedEmail is a template rendered with data
+type RenderedEmail struct {
+	Key      string `json:"key"`
+	Locale   string `json:"locale"`
+	Version  int    `json:"version"`
+	Subject  string `json:"subject"`
+	HTMLBody string `json:"html_body"`
+	TextBody string `json:"text_body"`
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kaanevranportfolio/Commercium/internal/notification/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// TemplateRepository defines the interface for email template data operations
+type TemplateRepository interface {
+	CreateTemplate(ctx context.Context, template *models.EmailTemplate) error
+	GetTemplateVersion(ctx context.Context, key, locale string, version int) (*models.EmailTemplate, error)
+	GetActiveTemplate(ctx context.Context, key string, locales []string) (*models.EmailTemplate, error)
+	ListTemplates(ctx context.Context) ([]*models.TemplateSummary, error)
+	ListTemplateVersions(ctx context.Context, key, locale string) ([]*models.EmailTemplate, error)
+	ActivateTemplate(ctx context.Context, key, locale string, version int) (*models.EmailTemplate, error)
+}
+
+// templateRepository implements the TemplateRepository interface
+type templateRepository struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewTemplateRepository creates a new email template repository
+func NewTemplateRepository(db *database.DB, logger *logger.Logger) TemplateRepository {
+	return &templateRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const templateColumns = `id, key, locale, version, subject, html_body, text_body, description, active,
+		       created_by, activated_at, created_at`
+
+// CreateTemplate stores a template as the next version of its key and locale,
+// activating it in the same transaction when requested
+func (r *templateRepository) CreateTemplate(ctx context.Context, template *models.EmailTemplate) error {
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		if template.Active {
+			if err := deactivateTemplate(ctx, tx, template.Key, template.Locale); err != nil {
+				return err
+			}
+		}
+
+		query := `
+			INSERT INTO email_templates (id, key, locale, version, subject, html_body, text_body, description,
+			                             active, created_by, activated_at)
+			SELECT :id, :key, :locale, COALESCE(MAX(version), 0) + 1, :subject, :html_body, :text_body, :description,
+			       :active, :created_by, :activated_at
+			FROM email_templates
+			WHERE key = :key AND locale = :locale
+			RETURNING version, created_at`
+
+		stmt, err := tx.PrepareNamedContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer stmt.Close()
+
+		if err := stmt.QueryRowxContext(ctx, template).Scan(&template.Version, &template.CreatedAt); err != nil {
+			// Two versions saved at the same time get the same number
+			if database.IsUniqueViolation(err) {
+				return apperrors.Conflict("template version already exists, retry the request")
+			}
+			r.logger.Error("Failed to create email template", "error", err, "key", template.Key, "locale", template.Locale)
+			return fmt.Errorf("failed to create email template: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// GetTemplateVersion retrieves one version of a template
+func (r *templateRepository) GetTemplateVersion(ctx context.Context, key, locale string, version int) (*models.EmailTemplate, error) {
+	template := &models.EmailTemplate{}
+	query := `
+		SELECT ` + templateColumns + `
+		FROM email_templates
+		WHERE key = $1 AND locale = $2 AND version = $3`
+
+	err := r.db.GetContext(ctx, template, query, key, locale, version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("email template not found")
+		}
+		r.logger.Error("Failed to get email template", "error", err, "key", key, "locale", locale, "version", version)
+		return nil, fmt.Errorf("failed to get email template: %w", err)
+	}
+
+	return template, nil
+}
+
+// GetActiveTemplate retrieves the active version of a template in the first
+// of the given locales that has one
+func (r *templateRepository) GetActiveTemplate(ctx context.Context, key string, locales []string) (*models.EmailTemplate, error) {
+	template := &models.EmailTemplate{}
+	query := `
+		SELECT ` + templateColumns + `
+		FROM email_templates
+		WHERE key = $1 AND locale = ANY($2) AND active
+		ORDER BY array_position($2, locale::text)
+		LIMIT 1`
+
+	err := r.db.GetContext(ctx, template, query, key, locales)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("email template not found")
+		}
+		r.logger.Error("Failed to get active email template", "error", err, "key", key)
+		return nil, fmt.Errorf("failed to get email template: %w", err)
+	}
+
+	return template, nil
+}
+
+// ListTemplates lists every template and locale with its latest and active version
+func (r *templateRepository) ListTemplates(ctx context.Context) ([]*models.TemplateSummary, error) {
+	summaries := []*models.TemplateSummary{}
+	query := `
+		SELECT key, locale, MAX(version) AS latest_version,
+		       MAX(version) FILTER (WHERE active) AS active_version,
+		       MAX(created_at) AS updated_at
+		FROM email_templates
+		GROUP BY key, locale
+		ORDER BY key, locale`
+
+	err := r.db.SelectContext(ctx, &summaries, query)
+	if err != nil {
+		r.logger.Error("Failed to list email templates", "error", err)
+		return nil, fmt.Errorf("failed to list email templates: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// ListTemplateVersions lists the versions of a template in one locale, newest first
+func (r *templateRepository) ListTemplateVersions(ctx context.Context, key, locale string) ([]*models.EmailTemplate, error) {
+	templates := []*models.EmailTemplate{}
+	query := `
+		SELECT ` + templateColumns + `
+		FROM email_templates
+		WHERE key = $1 AND locale = $2
+		ORDER BY version DESC`
+
+	err := r.db.SelectContext(ctx, &templates, query, key, locale)
+	if err != nil {
+		r.logger.Error("Failed to list email template versions", "error", err, "key", key, "locale", locale)
+		return nil, fmt.Errorf("failed to list email template versions: %w", err)
+	}
+
+	return templates, nil
+}
+
+// ActivateTemplate makes a version the one used for sending, deactivating the previous one
+func (r *templateRepository) ActivateTemplate(ctx context.Context, key, locale string, version int) (*models.EmailTemplate, error) {
+	template := &models.EmailTemplate{}
+	err := r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		if err := deactivateTemplate(ctx, tx, key, locale); err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE email_templates
+			SET active = TRUE, activated_at = NOW()
+			WHERE key = $1 AND locale = $2 AND version = $3
+			RETURNING ` + templateColumns
+
+		if err := tx.GetContext(ctx, template, query, key, locale, version); err != nil {
+			if err == sql.ErrNoRows {
+				return apperrors.NotFound("email template not found")
+			}
+			r.logger.Error("Failed to activate email template", "error", err, "key", key, "locale", locale, "version", version)
+			return fmt.Errorf("failed to activate email template: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// deactivateTemplate clears the active version of a template in one locale
+func deactivateTemplate(ctx context.Context, tx *sqlx.Tx, key, locale string) error {
+	query := `UPDATE email_templates SET active = FALSE WHERE key = $1 AND locale = $2 AND active`
+	if _, err := tx.ExecContext(ctx, query, key, locale); err != nil {
+		return fmt.Errorf("failed to deactivate email template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kaanevranportfolio/Commercium/internal/notification/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/rabbitmq"
+)
+
+// EmailQueueHandler returns a RabbitMQ handler sending the emails other
+// services queue. Emails whose template is missing or invalid are
+// dead-lettered without retrying; they can be redriven once it is fixed.
+func EmailQueueHandler(notificationService NotificationService) func(ctx context.Context, req *models.SendEmailRequest) error {
+	return func(ctx context.Context, req *models.SendEmailRequest) error {
+		_, err := notificationService.SendEmail(ctx, req)
+		if err != nil && (errors.Is(err, apperrors.ErrNotFound) || errors.Is(err, apperrors.ErrValidation)) {
+			return rabbitmq.Permanent(err)
+		}
+		return err
+	}
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/notification/mailer"
+	"github.com/kaanevranportfolio/Commercium/internal/notification/models"
+	"github.com/kaanevranportfolio/Commercium/internal/notification/repository"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/i18n"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+var (
+	// templateKeyPattern matches keys like order_confirmation or password.reset
+	templateKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+	// localePattern matches a language with an optional region, e.g. en or pt-BR
+	localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z]{2})?$`)
+)
+
+// NotificationService defines the interface for notification business logic
+type NotificationService interface {
+	// Template management
+	CreateTemplate(ctx context.Context, userID uuid.UUID, req *models.CreateTemplateRequest) (*models.EmailTemplate, error)
+	ListTemplates(ctx context.Context) ([]*models.TemplateSummary, error)
+	ListTemplateVersions(ctx context.Context, key, locale string) ([]*models.EmailTemplate, error)
+	ActivateTemplate(ctx context.Context, key, locale string, version int) (*models.EmailTemplate, error)
+	Preview(ctx context.Context, key string, req *models.PreviewRequest) (*models.RenderedEmail, error)
+	TestSend(ctx context.Context, key string, req *models.TestSendRequest) (*models.RenderedEmail, error)
+
+	// SendEmail is called by other services to send transactional email
+	SendEmail(ctx context.Context, req *models.SendEmailRequest) (*models.RenderedEmail, error)
+}
+
+// notificationService implements the NotificationService interface
+type notificationService struct {
+	repo   repository.TemplateRepository
+	mailer mailer.Mailer
+	config *config.Config
+	logger *logger.Logger
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(
+	repo repository.TemplateRepository,
+	mailer mailer.Mailer,
+	config *config.Config,
+	logger *logger.Logger,
+) NotificationService {
+	return &notificationService{
+		repo:   repo,
+		mailer: mailer,
+		config: config,
+		logger: logger,
+	}
+}
+
+// CreateTemplate stores a new version of a template. Templates that don't
+// parse are rejected, so every stored version can be rendered.
+func (s *notificationService) CreateTemplate(ctx context.Context, userID uuid.UUID, req *models.CreateTemplateRequest) (*models.EmailTemplate, error) {
+	if !templateKeyPattern.MatchString(req.Key) {
+		return nil, apperrors.Validation("invalid template key: use lowercase letters, digits, '_', '.' and '-'")
+	}
+
+	locale, err := normalizeLocale(req.Locale)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &models.EmailTemplate{
+		ID:          uuid.New(),
+		Key:         req.Key,
+		Locale:      locale,
+		Subject:     req.Subject,
+		HTMLBody:    req.HTMLBody,
+		TextBody:    req.TextBody,
+		Description: optionalString(strings.TrimSpace(req.Description)),
+		Active:      req.Activate,
+		CreatedBy:   &userID,
+	}
+	if _, err := compileTemplate(template); err != nil {
+		return nil, err
+	}
+
+	if template.Active {
+		now := time.Now()
+		template.ActivatedAt = &now
+	}
+
+	if err := s.repo.CreateTemplate(ctx, template); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Email template version created", "key", template.Key, "locale", template.Locale, "version", template.Version, "active", template.Active)
+	return template, nil
+}
+
+// ListTemplates lists every template and locale
+func (s *notificationService) ListTemplates(ctx context.Context) ([]*models.TemplateSummary, error) {
+	return s.repo.ListTemplates(ctx)
+}
+
+// ListTemplateVersions lists the versions of a template in one locale
+func (s *notificationService) ListTemplateVersions(ctx context.Context, key, locale string) ([]*models.EmailTemplate, error) {
+	locale, err := normalizeLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := s.repo.ListTemplateVersions(ctx, key, locale)
+	if err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, apperrors.NotFound("email template not found")
+	}
+
+	return templates, nil
+}
+
+// ActivateTemplate makes a version the one used for sending. Activating an
+// older version rolls the template back.
+func (s *notificationService) ActivateTemplate(ctx context.Context, key, locale string, version int) (*models.EmailTemplate, error) {
+	locale, err := normalizeLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := s.repo.ActivateTemplate(ctx, key, locale, version)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Email template version activated", "key", key, "locale", locale, "version", version)
+	return template, nil
+}
+
+// Preview renders a template with sample data without sending it
+func (s *notificationService) Preview(ctx context.Context, key string, req *models.PreviewRequest) (*models.RenderedEmail, error) {
+	template, err := s.findTemplate(ctx, key, req.Locale, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	return renderTemplate(template, req.Data)
+}
+
+// TestSend renders a template with sample data and sends it to a test
+// recipient. The subject is marked so test sends aren't mistaken for real ones.
+func (s *notificationService) TestSend(ctx context.Context, key string, req *models.TestSendRequest) (*models.RenderedEmail, error) {
+	rendered, err := s.Preview(ctx, key, &req.PreviewRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered.Subject = "[TEST] " + rendered.Subject
+	if err := s.send(ctx, req.To, rendered); err != nil {
+		return nil, err
+	}
+
+	return rendered, nil
+}
+
+// SendEmail renders the active version of a template in the recipient's
+// locale, falling back to the language, the default locale and then English,
+// and sends it
+func (s *notificationService) SendEmail(ctx context.Context, req *models.SendEmailRequest) (*models.RenderedEmail, error) {
+	locale := req.Locale
+	if locale == "" {
+		locale = s.config.Services.Notification.DefaultLocale
+	}
+
+	template, err := s.findTemplate(ctx, req.Template, locale, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := renderTemplate(template, req.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.send(ctx, req.To, rendered); err != nil {
+		return nil, err
+	}
+
+	return rendered, nil
+}
+
+// findTemplate returns a specific version of a template in exactly the given
+// locale, or the active version in the best matching locale
+func (s *notificationService) findTemplate(ctx context.Context, key, locale string, version int) (*models.EmailTemplate, error) {
+	locale, err := normalizeLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	if version > 0 {
+		return s.repo.GetTemplateVersion(ctx, key, locale, version)
+	}
+
+	return s.repo.GetActiveTemplate(ctx, key, s.localeFallbacks(locale))
+}
+
+// send delivers a rendered email
+func (s *notificationService) send(ctx context.Context, to string, rendered *models.RenderedEmail) error {
+	err := s.mailer.Send(ctx, &mailer.Message{
+		To:       to,
+		Subject:  rendered.Subject,
+		HTMLBody: rendered.HTMLBody,
+		TextBody: rendered.TextBody,
+	})
+	if err != nil {
+		s.logger.Error("Failed to send email", "error", err, "template", rendered.Key, "locale", rendered.Locale, "version", rendered.Version)
+		if errors.Is(err, apperrors.ErrValidation) {
+			return err
+		}
+		return apperrors.Upstream("email provider is unavailable").Wrap(err)
+	}
+
+	s.logger.Info("Email sent", "template", rendered.Key, "locale", rendered.Locale, "version", rendered.Version)
+	return nil
+}
+
+// localeFallbacks lists the locales to try for a locale, most specific first:
+// pt-BR, then pt, then the default locale, then English
+func (s *notificationService) localeFallbacks(locale string) []string {
+	locales := []string{locale}
+	if language, _, found := strings.Cut(locale, "-"); found {
+		locales = append(locales, language)
+	}
+
+	fallbacks := []string{i18n.Locale(i18n.Fallback)}
+	if defaultLocale, err := normalizeLocale(s.config.Services.Notification.DefaultLocale); err == nil {
+		fallbacks = append([]string{defaultLocale}, fallbacks...)
+	}
+	for _, fallback := range fallbacks {
+		if !slices.Contains(locales, fallback) {
+			locales = append(locales, fallback)
+		}
+	}
+
+	return locales
+}
+
+// normalizeLocale accepts locales like en, pt_BR or pt-br and returns them as pt-BR
+func normalizeLocale(locale string) (string, error) {
+	language, region, found := strings.Cut(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	normalized := strings.ToLower(language)
+	if found {
+		normalized += "-" + strings.ToUpper(region)
+	}
+
+	if !localePattern.MatchString(normalized) {
+		return "", apperrors.Validation("invalid locale: %s", locale)
+	}
+	return normalized, nil
+}
+
+// optionalString returns nil for empty strings, for nullable columns
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
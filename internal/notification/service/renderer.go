@@ -0,0 +1,110 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/internal/notification/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/money"
+)
+
+// templateFuncs are available in every part of a template
+var templateFuncs = map[string]interface{}{
+	// money formats an amount in minor units, e.g. {{money .total .currency}} gives "12.34 USD"
+	"money": func(amount interface{}, currency string) (string, error) {
+		var minor int64
+		switch v := amount.(type) {
+		case int:
+			minor = int64(v)
+		case int64:
+			minor = v
+		case float64:
+			// Numbers decoded from JSON requests
+			minor = int64(v)
+		default:
+			return "", fmt.Errorf("money: unsupported amount %T", amount)
+		}
+
+		return money.Format(minor, currency), nil
+	},
+	// date formats an RFC 3339 timestamp as a calendar date
+	"date": func(value interface{}) (string, error) {
+		switch v := value.(type) {
+		case time.Time:
+			return v.Format("2 January 2006"), nil
+		case string:
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return "", fmt.Errorf("date: %w", err)
+			}
+			return t.Format("2 January 2006"), nil
+		default:
+			return "", fmt.Errorf("date: unsupported value %T", value)
+		}
+	},
+}
+
+// compiledTemplate holds the parsed parts of an email template
+type compiledTemplate struct {
+	subject *texttemplate.Template
+	html    *htmltemplate.Template
+	text    *texttemplate.Template
+}
+
+// compileTemplate parses every part of a template. Referencing data that
+// isn't provided is an error when rendering, so broken emails aren't sent.
+func compileTemplate(template *models.EmailTemplate) (*compiledTemplate, error) {
+	subject, err := texttemplate.New("subject").Funcs(templateFuncs).Option("missingkey=error").Parse(template.Subject)
+	if err != nil {
+		return nil, apperrors.Validation("invalid subject template: %s", err)
+	}
+
+	html, err := htmltemplate.New("html").Funcs(templateFuncs).Option("missingkey=error").Parse(template.HTMLBody)
+	if err != nil {
+		return nil, apperrors.Validation("invalid html template: %s", err)
+	}
+
+	text, err := texttemplate.New("text").Funcs(templateFuncs).Option("missingkey=error").Parse(template.TextBody)
+	if err != nil {
+		return nil, apperrors.Validation("invalid text template: %s", err)
+	}
+
+	return &compiledTemplate{subject: subject, html: html, text: text}, nil
+}
+
+// renderTemplate renders a template with the given data
+func renderTemplate(template *models.EmailTemplate, data map[string]interface{}) (*models.RenderedEmail, error) {
+	compiled, err := compileTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+
+	var subject, html, text bytes.Buffer
+	if err := compiled.subject.Execute(&subject, data); err != nil {
+		return nil, apperrors.Validation("invalid template data: %s", err)
+	}
+	if err := compiled.html.Execute(&html, data); err != nil {
+		return nil, apperrors.Validation("invalid template data: %s", err)
+	}
+	if err := compiled.text.Execute(&text, data); err != nil {
+		return nil, apperrors.Validation("invalid template data: %s", err)
+	}
+
+	// Keeping the subject on one line also rules out header injection
+	return &models.RenderedEmail{
+		Key:      template.Key,
+		Locale:   template.Locale,
+		Version:  template.Version,
+		Subject:  strings.Join(strings.Fields(subject.String()), " "),
+		HTMLBody: html.String(),
+		TextBody: text.String(),
+	}, nil
+}
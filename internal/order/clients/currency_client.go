@@ -0,0 +1,106 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/resilience"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// Money is an amount in minor units of a currency
+type Money struct {
+	Currency string `json:"currency"`
+	Amount   int64  `json:"amount"`
+}
+
+// DisplayAmountsRequest asks the currency service to convert amounts to the
+// customer's display currency. Currency overrides the customer's preference.
+type DisplayAmountsRequest struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Currency string    `json:"currency,omitempty"`
+	Amounts  []Money   `json:"amounts"`
+}
+
+// DisplayAmount is an amount converted to the display currency
+type DisplayAmount struct {
+	Money
+	Converted int64  `json:"converted"`
+	Rate      string `json:"rate"`
+}
+
+// DisplayAmountsResponse holds the converted amounts in request order. Currency
+// is empty when the customer has no display currency.
+type DisplayAmountsResponse struct {
+	Currency  string           `json:"currency"`
+	Amounts   []*DisplayAmount `json:"amounts"`
+	RatesAsOf *time.Time       `json:"rates_as_of"`
+}
+
+// CurrencyClient defines the currency operations the order service depends on
+type CurrencyClient interface {
+	DisplayAmounts(ctx context.Context, req *DisplayAmountsRequest) (*DisplayAmountsResponse, error)
+}
+
+// httpCurrencyClient calls the currency service over its internal HTTP API
+type httpCurrencyClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewCurrencyClient creates a new currency service client, calling it through
+// dependency
+func NewCurrencyClient(baseURL string, dependency *resilience.Dependency) CurrencyClient {
+	// Services called continue the trace, with its baggage
+	return &httpCurrencyClient{
+		baseURL:    baseURL,
+		httpClient: dependency.HTTPClient(tracing.NewTransport(nil)),
+	}
+}
+
+// DisplayAmounts converts amounts to the customer's display currency. An
+// unsupported currency is reported as an invalid request.
+func (c *httpCurrencyClient) DisplayAmounts(ctx context.Context, req *DisplayAmountsRequest) (*DisplayAmountsResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal display amounts request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/internal/v1/display-amounts", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create display amounts request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call currency service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		return nil, apperrors.Validation("invalid display currency: %s", errResp.Error)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("currency service returned status %d", resp.StatusCode)
+	}
+
+	result := &DisplayAmountsResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, fmt.Errorf("failed to decode display amounts response: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,116 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/resilience"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// StockItem identifies a quantity of a product
+type StockItem struct {
+	ProductID uuid.UUID `json:"product_id"`
+	SKU       string    `json:"sku"`
+	Quantity  int       `json:"quantity"`
+}
+
+// ErrInsufficientStock is returned when stock can't be reserved because not
+// enough of an item is available
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ReserveStockRequest holds stock for an order until it is released or shipped.
+// Reserving the same order again has no further effect.
+type ReserveStockRequest struct {
+	OrderID uuid.UUID    `json:"order_id"`
+	Items   []*StockItem `json:"items"`
+}
+
+// ReleaseStockRequest returns reserved stock of an order to the available pool
+type ReleaseStockRequest struct {
+	OrderID uuid.UUID    `json:"order_id"`
+	Items   []*StockItem `json:"items"`
+}
+
+// InventoryClient defines the inventory operations the order service depends on
+type InventoryClient interface {
+	Reserve(ctx context.Context, req *ReserveStockRequest) error
+	Release(ctx context.Context, req *ReleaseStockRequest) error
+}
+
+// httpInventoryClient calls the inventory service over its internal HTTP API
+type httpInventoryClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewInventoryClient creates a new inventory service client, calling it through
+// dependency
+func NewInventoryClient(baseURL string, dependency *resilience.Dependency) InventoryClient {
+	// Services called continue the trace, with its baggage
+	return &httpInventoryClient{
+		baseURL:    baseURL,
+		httpClient: dependency.HTTPClient(tracing.NewTransport(nil)),
+	}
+}
+
+// Reserve reserves stock for an order, all items or none
+func (c *httpInventoryClient) Reserve(ctx context.Context, req *ReserveStockRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal reserve request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/internal/v1/reservations", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create reserve request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to call inventory service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return ErrInsufficientStock
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("inventory service returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Release releases the stock reserved for an order
+func (c *httpInventoryClient) Release(ctx context.Context, req *ReleaseStockRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal release request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/internal/v1/reservations/release", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create release request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to call inventory service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("inventory service returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
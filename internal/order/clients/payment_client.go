@@ -0,0 +1,163 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/resilience"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// ErrPaymentNotFound is returned when the order has no payment to refund
+var ErrPaymentNotFound = errors.New("payment not found")
+
+// AuthorizePaymentRequest asks the payment service to authorize the total of
+// an order placed at checkout
+type AuthorizePaymentRequest struct {
+	UserID            uuid.UUID `json:"user_id"`
+	OrderID           uuid.UUID `json:"order_id"`
+	PaymentMethod     string    `json:"payment_method,omitempty"`
+	GiftCardCodes     []string  `json:"gift_card_codes,omitempty"`
+	PaymentMethodType string    `json:"payment_method_type,omitempty"`
+	Provider          string    `json:"provider,omitempty"`
+	DeviceID          string    `json:"device_id,omitempty"`
+	ClientIP          string    `json:"client_ip,omitempty"`
+	IdempotencyKey    string    `json:"-"`
+}
+
+// Payment is the part of a payment the order service uses
+type Payment struct {
+	ID     uuid.UUID `json:"id"`
+	Status string    `json:"status"`
+	Amount int64     `json:"amount"`
+}
+
+// DeclinedError is returned when the payment service refused to authorize a
+// payment, e.g. because the card was declined or the payment method is invalid
+type DeclinedError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *DeclinedError) Error() string {
+	return fmt.Sprintf("payment declined: %s", e.Message)
+}
+
+// RefundPaymentRequest asks the payment service to return money for an order
+type RefundPaymentRequest struct {
+	OrderID        uuid.UUID `json:"order_id"`
+	RefundID       uuid.UUID `json:"refund_id"`
+	Amount         int64     `json:"amount"`
+	Currency       string    `json:"currency"`
+	Reason         string    `json:"reason,omitempty"`
+	IdempotencyKey string    `json:"-"`
+}
+
+// RefundPaymentResponse is returned by the payment service once the provider accepted the refund
+type RefundPaymentResponse struct {
+	ProviderRefundID string `json:"provider_refund_id"`
+	Status           string `json:"status"`
+}
+
+// PaymentClient defines the payment operations the order service depends on
+type PaymentClient interface {
+	Authorize(ctx context.Context, req *AuthorizePaymentRequest) (*Payment, error)
+	Refund(ctx context.Context, req *RefundPaymentRequest) (*RefundPaymentResponse, error)
+}
+
+// httpPaymentClient calls the payment service over its internal HTTP API
+type httpPaymentClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewPaymentClient creates a new payment service client, calling it through
+// dependency
+func NewPaymentClient(baseURL string, dependency *resilience.Dependency) PaymentClient {
+	// Services called continue the trace, with its baggage
+	return &httpPaymentClient{
+		baseURL:    baseURL,
+		httpClient: dependency.HTTPClient(tracing.NewTransport(nil)),
+	}
+}
+
+// Authorize authorizes payment of an order on the customer's behalf
+func (c *httpPaymentClient) Authorize(ctx context.Context, req *AuthorizePaymentRequest) (*Payment, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal authorize request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/internal/v1/payments/authorize", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create authorize request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Idempotency-Key", req.IdempotencyKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call payment service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&errResp)
+		return nil, &DeclinedError{StatusCode: resp.StatusCode, Message: errResp.Error}
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("payment service returned status %d", resp.StatusCode)
+	}
+
+	result := &Payment{}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, fmt.Errorf("failed to decode authorize response: %w", err)
+	}
+
+	return result, nil
+}
+
+// Refund requests a refund of the order's payment. The payment service voids
+// authorizations that were never captured and refunds captured payments.
+func (c *httpPaymentClient) Refund(ctx context.Context, req *RefundPaymentRequest) (*RefundPaymentResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal refund request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/internal/v1/refunds", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refund request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Idempotency-Key", req.IdempotencyKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call payment service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrPaymentNotFound
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("payment service returned status %d", resp.StatusCode)
+	}
+
+	result := &RefundPaymentResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, fmt.Errorf("failed to decode refund response: %w", err)
+	}
+
+	return result, nil
+}
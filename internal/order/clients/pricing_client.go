@@ -0,0 +1,88 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/resilience"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// ResolvePricesRequest asks the pricing service for the prices a customer pays
+type ResolvePricesRequest struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Currency string    `json:"currency"`
+	SKUs     []string  `json:"skus"`
+}
+
+// ResolvedPrice is the price a customer pays for a SKU
+type ResolvedPrice struct {
+	SKU       string `json:"sku"`
+	UnitPrice int64  `json:"unit_price"`
+	ListPrice int64  `json:"list_price"`
+	OnSale    bool   `json:"on_sale"`
+}
+
+// ResolvePricesResponse holds the resolved prices and the SKUs without a price
+type ResolvePricesResponse struct {
+	Currency string           `json:"currency"`
+	Prices   []*ResolvedPrice `json:"prices"`
+	Missing  []string         `json:"missing"`
+}
+
+// PricingClient defines the pricing operations the order service depends on
+type PricingClient interface {
+	ResolvePrices(ctx context.Context, req *ResolvePricesRequest) (*ResolvePricesResponse, error)
+}
+
+// httpPricingClient calls the pricing service over its internal HTTP API
+type httpPricingClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewPricingClient creates a new pricing service client, calling it through
+// dependency
+func NewPricingClient(baseURL string, dependency *resilience.Dependency) PricingClient {
+	// Services called continue the trace, with its baggage
+	return &httpPricingClient{
+		baseURL:    baseURL,
+		httpClient: dependency.HTTPClient(tracing.NewTransport(nil)),
+	}
+}
+
+// ResolvePrices returns the current prices of SKUs for a customer
+func (c *httpPricingClient) ResolvePrices(ctx context.Context, req *ResolvePricesRequest) (*ResolvePricesResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resolve prices request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/internal/v1/prices/resolve", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resolve prices request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call pricing service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("pricing service returned status %d", resp.StatusCode)
+	}
+
+	result := &ResolvePricesResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, fmt.Errorf("failed to decode resolve prices response: %w", err)
+	}
+
+	return result, nil
+}
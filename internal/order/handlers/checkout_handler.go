@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/validation"
+)
+
+// CalculateTotals returns the subtotal, discount, shipping, tax and total of a cart
+func (h *OrderHandler) CalculateTotals(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.CheckoutTotalsRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	totals, err := h.orderService.CalculateTotals(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to calculate totals")
+		return
+	}
+
+	c.JSON(http.StatusOK, totals)
+}
+
+// PlaceOrder checks out the caller's cart: stock is reserved, the order is
+// created and its payment authorized, or nothing is
+func (h *OrderHandler) PlaceOrder(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.PlaceOrderRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+	req.IPAddress = c.ClientIP()
+
+	order, err := h.orderService.PlaceOrder(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to place order")
+		return
+	}
+
+	c.JSON(http.StatusCreated, order)
+}
+
+// GetTaxExemption returns a customer's tax exemption (admin)
+func (h *OrderHandler) GetTaxExemption(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	exemption, err := h.orderService.GetTaxExemption(c.Request.Context(), userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get tax exemption")
+		return
+	}
+
+	c.JSON(http.StatusOK, exemption)
+}
+
+// SetTaxExemption grants or renews a customer's tax exemption (admin)
+func (h *OrderHandler) SetTaxExemption(c *gin.Context) {
+	adminID := auth.UserIDFromContext(c)
+	if adminID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.TaxExemptionRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	exemption, err := h.orderService.SetTaxExemption(c.Request.Context(), adminID, userID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to set tax exemption")
+		return
+	}
+
+	c.JSON(http.StatusOK, exemption)
+}
+
+// DeleteTaxExemption revokes a customer's tax exemption (admin)
+func (h *OrderHandler) DeleteTaxExemption(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.orderService.DeleteTaxExemption(c.Request.Context(), userID); err != nil {
+		h.respondError(c, err, "Failed to delete tax exemption")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tax exemption revoked"})
+}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/internal/order/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/validation"
+)
+
+// OrderHandler handles HTTP requests for order operations
+type OrderHandler struct {
+	orderService service.OrderService
+	jwtService   *auth.JWTService
+	logger       *logger.Logger
+}
+
+// NewOrderHandler creates a new order handler
+func NewOrderHandler(orderService service.OrderService, jwtService *auth.JWTService, logger *logger.Logger) *OrderHandler {
+	return &OrderHandler{
+		orderService: orderService,
+		jwtService:   jwtService,
+		logger:       logger,
+	}
+}
+
+// ListOrders returns the authenticated user's order history
+func (h *OrderHandler) ListOrders(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.ListOrdersRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	orders, err := h.orderService.ListOrders(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to list orders")
+		return
+	}
+
+	httpx.List(c, orders)
+}
+
+// SearchOrders searches all customers' orders (admin)
+func (h *OrderHandler) SearchOrders(c *gin.Context) {
+	var req models.SearchOrdersRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	orders, err := h.orderService.SearchOrders(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to search orders")
+		return
+	}
+
+	httpx.List(c, orders)
+}
+
+// GetOrder returns the full detail of one of the authenticated user's orders
+func (h *OrderHandler) GetOrder(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var req models.GetOrderRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	order, err := h.orderService.ViewOrder(c.Request.Context(), userID, orderID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to get order")
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// CancelOrder cancels one of the authenticated user's orders before it ships
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var req models.CancelOrderRequest
+	if !validation.BindOptionalJSON(c, &req) {
+		return
+	}
+
+	order, err := h.orderService.CancelOrder(c.Request.Context(), userID, orderID, &req)
+	if err != nil {
+		h.logger.Error("Order cancellation failed", "error", err, "user_id", userID, "order_id", orderID)
+		apperrors.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Order cancelled successfully",
+		"order":   order,
+	})
+}
+
+// CreateRefund refunds line items of one of the authenticated user's shipped orders
+func (h *OrderHandler) CreateRefund(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var req models.CreateRefundRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	refund, err := h.orderService.RefundItems(c.Request.Context(), userID, orderID, &req)
+	if err != nil {
+		h.logger.Error("Refund failed", "error", err, "user_id", userID, "order_id", orderID)
+		apperrors.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Refund processed successfully",
+		"refund":  refund,
+	})
+}
+
+// ListRefunds lists the refunds of one of the authenticated user's orders
+func (h *OrderHandler) ListRefunds(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	refunds, err := h.orderService.ListRefunds(c.Request.Context(), userID, orderID)
+	if err != nil {
+		h.respondError(c, err, "Failed to list refunds")
+		return
+	}
+
+	httpx.Items(c, refunds)
+}
+
+// GetInvoice returns a download link for the invoice of one of the authenticated user's orders
+func (h *OrderHandler) GetInvoice(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	invoice, err := h.orderService.GetInvoice(c.Request.Context(), userID, orderID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get invoice")
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+// CreateOrder places an order on behalf of another service (internal)
+func (h *OrderHandler) CreateOrder(c *gin.Context) {
+	var req models.CreateOrderRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	order, err := h.orderService.CreateOrder(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to create order")
+		return
+	}
+
+	c.JSON(http.StatusCreated, order)
+}
+
+// respondError logs a request failing on the side of the service, or of a
+// provider it depends on, and answers it with the problem details of err,
+// so middleware reading its status after it, such as idempotency's, sees
+// the failure
+func (h *OrderHandler) respondError(c *gin.Context, err error, message string) {
+	if apperrors.HTTPStatus(err) >= http.StatusInternalServerError {
+		logger.FromContext(c.Request.Context()).Error(message, "error", err)
+	}
+	apperrors.Respond(c, err)
+}
+
+// SetupRoutes sets up the order routes.
+// Internal routes are called by other services and must not be exposed through the gateway.
+func (h *OrderHandler) SetupRoutes(r *gin.Engine) {
+	orders := r.Group("/api/v1/orders")
+	orders.Use(h.jwtService.Middleware())
+	{
+		orders.GET("", h.ListOrders)
+		orders.GET("/:id", h.GetOrder)
+		orders.POST("/:id/cancel", h.CancelOrder)
+		orders.POST("/:id/refunds", h.CreateRefund)
+		orders.GET("/:id/refunds", h.ListRefunds)
+		orders.GET("/:id/invoice", h.GetInvoice)
+	}
+
+	checkout := r.Group("/api/v1/checkout")
+	checkout.Use(h.jwtService.Middleware())
+	{
+		checkout.POST("", h.PlaceOrder)
+		checkout.POST("/totals", h.CalculateTotals)
+	}
+
+	adminOrders := r.Group("/api/v1/admin/orders")
+	adminOrders.Use(h.jwtService.Middleware(), auth.RequireRole("admin"))
+	{
+		adminOrders.GET("", h.SearchOrders)
+	}
+
+	admin := r.Group("/api/v1/admin/tax-exemptions")
+	admin.Use(h.jwtService.Middleware(), auth.RequireRole("admin"))
+	{
+		admin.GET("/:user_id", h.GetTaxExemption)
+		admin.PUT("/:user_id", h.SetTaxExemption)
+		admin.DELETE("/:user_id", h.DeleteTaxExemption)
+	}
+
+	internal := r.Group("/internal/v1")
+	{
+		internal.POST("/orders", h.CreateOrder)
+	}
+}
@@ -0,0 +1,167 @@
+// Package invoice renders order invoices as PDF documents
+package invoice
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/money"
+)
+
+// Layout of an invoice page, in points
+const (
+	margin       = 50.0
+	lineHeight   = 14.0
+	rowHeight    = 16.0
+	detailsX     = 340.0
+	qtyRight     = 370.0
+	unitRight    = 460.0
+	amountRight  = pageWidth - margin
+	footerY      = 40.0
+	tableBottom  = 90.0
+	totalsHeight = 6 * rowHeight
+	// maxDescription keeps item names clear of the quantity column
+	maxDescription = 48
+)
+
+// Render lays out the invoice of an order for the legal entity issuing it
+func Render(entity config.LegalEntityConfig, invoice *models.Invoice, order *models.Order) []byte {
+	doc := NewDocument()
+	doc.AddPage()
+
+	// Issuer
+	y := pageHeight - margin - 10
+	doc.Text(margin, y, FontBold, 16, entity.Name)
+	y -= lineHeight + 4
+	for _, line := range entity.AddressLines {
+		doc.Text(margin, y, FontRegular, 9, line)
+		y -= lineHeight
+	}
+	if entity.TaxID != "" {
+		doc.Text(margin, y, FontRegular, 9, "Tax ID: "+entity.TaxID)
+	}
+
+	// Invoice details
+	y = pageHeight - margin - 10
+	doc.Text(detailsX, y, FontBold, 20, "INVOICE")
+	y -= lineHeight + 8
+	for _, detail := range [][2]string{
+		{"Invoice number", invoice.InvoiceNumber},
+		{"Invoice date", invoice.IssuedAt.Format("2 January 2006")},
+		{"Order number", order.OrderNumber},
+		{"Order date", order.PlacedAt.Format("2 January 2006")},
+	} {
+		doc.Text(detailsX, y, FontRegular, 9, detail[0])
+		doc.Text(detailsX+85, y, FontBold, 9, detail[1])
+		y -= lineHeight
+	}
+
+	// Customer
+	y = pageHeight - 200
+	address := order.BillingAddress
+	if address == nil {
+		address = order.ShippingAddress
+	}
+	if address != nil {
+		doc.Text(margin, y, FontBold, 10, "Bill to")
+		y -= lineHeight
+		for _, line := range addressLines(address) {
+			doc.Text(margin, y, FontRegular, 9, line)
+			y -= lineHeight
+		}
+	}
+
+	// Line items
+	y = pageHeight - 320
+	y = tableHeader(doc, y)
+	for _, item := range order.Items {
+		if y < tableBottom {
+			doc.AddPage()
+			y = tableHeader(doc, pageHeight-margin-10)
+		}
+		doc.Text(margin, y, FontRegular, 9, truncate(item.Name, maxDescription))
+		doc.TextRight(qtyRight, y, 9, fmt.Sprint(item.Quantity))
+		doc.TextRight(unitRight, y, 9, money.FormatAmount(item.UnitPrice, order.Currency))
+		doc.TextRight(amountRight, y, 9, money.FormatAmount(item.TotalPrice, order.Currency))
+		y -= rowHeight
+	}
+
+	// Totals stay together on one page
+	if y-totalsHeight < tableBottom {
+		doc.AddPage()
+		y = pageHeight - margin - 10
+	}
+	doc.Line(unitRight-90, y+rowHeight-4, amountRight, y+rowHeight-4)
+
+	type total struct {
+		label  string
+		amount int64
+	}
+	totals := []total{{"Subtotal", order.SubtotalAmount}}
+	if order.DiscountAmount > 0 {
+		totals = append(totals, total{"Discount", -order.DiscountAmount})
+	}
+	totals = append(totals, total{"Shipping", order.ShippingAmount}, total{"Tax", order.TaxAmount})
+	for _, t := range totals {
+		doc.Text(unitRight-90, y, FontRegular, 9, t.label)
+		doc.TextRight(amountRight, y, 9, money.FormatAmount(t.amount, order.Currency))
+		y -= rowHeight
+	}
+	doc.Text(unitRight-90, y, FontBold, 11, "Total "+strings.ToUpper(order.Currency))
+	doc.TextRight(amountRight, y, 11, money.FormatAmount(order.TotalAmount, order.Currency))
+
+	// Footers go on last, when the page count is known
+	pages := doc.PageCount()
+	for i := 0; i < pages; i++ {
+		doc.SelectPage(i)
+		doc.Line(margin, footerY+14, amountRight, footerY+14)
+		if entity.Footer != "" {
+			doc.Text(margin, footerY, FontRegular, 8, entity.Footer)
+		}
+		doc.TextRight(amountRight, footerY, 8, fmt.Sprintf("Page %d of %d", i+1, pages))
+	}
+
+	return doc.Bytes()
+}
+
+// tableHeader draws the column headings of the line items and returns the
+// baseline of the first row
+func tableHeader(doc *Document, y float64) float64 {
+	doc.Text(margin, y, FontBold, 9, "Description")
+	doc.Text(qtyRight-20, y, FontBold, 9, "Qty")
+	doc.Text(unitRight-50, y, FontBold, 9, "Unit price")
+	doc.Text(amountRight-40, y, FontBold, 9, "Amount")
+	doc.Line(margin, y-5, amountRight, y-5)
+	return y - rowHeight - 4
+}
+
+// addressLines formats an address for printing
+func addressLines(address *models.Address) []string {
+	lines := []string{strings.TrimSpace(address.FirstName + " " + address.LastName)}
+	if address.Company != nil && *address.Company != "" {
+		lines = append(lines, *address.Company)
+	}
+	lines = append(lines, address.AddressLine1)
+	if address.AddressLine2 != nil && *address.AddressLine2 != "" {
+		lines = append(lines, *address.AddressLine2)
+	}
+
+	city := address.PostalCode + " " + address.City
+	if address.State != nil && *address.State != "" {
+		city += ", " + *address.State
+	}
+	lines = append(lines, strings.TrimSpace(city), address.Country)
+
+	return lines
+}
+
+// truncate shortens text to at most max characters
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-3]) + "..."
+}
@@ -0,0 +1,188 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+)
+
+// Font selects one of the standard PDF fonts, which viewers provide without
+// embedding
+type Font string
+
+// Fonts available in documents
+const (
+	FontRegular Font = "F1"
+	FontBold    Font = "F2"
+	FontMono    Font = "F3"
+)
+
+var baseFonts = []struct {
+	font Font
+	name string
+}{
+	{FontRegular, "Helvetica"},
+	{FontBold, "Helvetica-Bold"},
+	{FontMono, "Courier"},
+}
+
+// monoCharWidth is the advance width of every Courier glyph per point of font size
+const monoCharWidth = 0.6
+
+// Document is a minimal PDF writer for text-based documents such as invoices.
+// Coordinates are in points from the bottom-left corner of the page.
+type Document struct {
+	pages   []*bytes.Buffer
+	current *bytes.Buffer
+}
+
+// NewDocument creates an empty document
+func NewDocument() *Document {
+	return &Document{}
+}
+
+// AddPage starts a new page and makes it the current one
+func (d *Document) AddPage() {
+	d.current = &bytes.Buffer{}
+	d.pages = append(d.pages, d.current)
+}
+
+// PageCount returns the number of pages
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// SelectPage makes an existing page the current one, e.g. to add page footers
+// once the page count is known
+func (d *Document) SelectPage(index int) {
+	d.current = d.pages[index]
+}
+
+// Text draws text with its baseline starting at x, y
+func (d *Document) Text(x, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(d.current, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escapeText(text))
+}
+
+// TextRight draws monospaced text so that it ends at x. Amounts are drawn
+// this way so their digits line up.
+func (d *Document) TextRight(x, y float64, size float64, text string) {
+	width := float64(len(encodeWinAnsi(text))) * size * monoCharWidth
+	d.Text(x-width, y, FontMono, size, text)
+}
+
+// Line draws a thin line between two points
+func (d *Document) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(d.current, "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+// Bytes serializes the document
+func (d *Document) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+
+	beginObject := func() int {
+		offsets = append(offsets, out.Len())
+		id := len(offsets)
+		fmt.Fprintf(&out, "%d 0 obj\n", id)
+		return id
+	}
+	endObject := func() {
+		out.WriteString("endobj\n")
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Object numbers: 1 catalog, 2 page tree, then fonts, then a page and
+	// content stream pair per page
+	firstPage := 3 + len(baseFonts)
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+
+	beginObject()
+	out.WriteString("<< /Type /Catalog /Pages 2 0 R >>\n")
+	endObject()
+
+	beginObject()
+	fmt.Fprintf(&out, "<< /Type /Pages /Kids [%s] /Count %d >>\n", strings.Join(kids, " "), len(d.pages))
+	endObject()
+
+	var fonts strings.Builder
+	for _, f := range baseFonts {
+		id := beginObject()
+		fmt.Fprintf(&out, "<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>\n", f.name)
+		endObject()
+		fmt.Fprintf(&fonts, "/%s %d 0 R ", f.font, id)
+	}
+
+	for _, content := range d.pages {
+		pageID := beginObject()
+		fmt.Fprintf(&out, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << %s>> >> /Contents %d 0 R >>\n",
+			pageWidth, pageHeight, fonts.String(), pageID+1)
+		endObject()
+
+		beginObject()
+		fmt.Fprintf(&out, "<< /Length %d >>\nstream\n", content.Len())
+		out.Write(content.Bytes())
+		out.WriteString("endstream\n")
+		endObject()
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// winAnsiSpecials maps the characters of the 0x80-0x9F range of WinAnsiEncoding
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// encodeWinAnsi converts text to the encoding of the standard fonts.
+// Characters the fonts can't show are replaced with '?'.
+func encodeWinAnsi(text string) []byte {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r < 0x80 && r >= 0x20:
+			out = append(out, byte(r))
+		case r >= 0xA0 && r <= 0xFF:
+			out = append(out, byte(r))
+		default:
+			if b, ok := winAnsiSpecials[r]; ok {
+				out = append(out, b)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+// escapeText encodes text for a PDF string literal
+func escapeText(text string) string {
+	var b strings.Builder
+	for _, c := range encodeWinAnsi(text) {
+		switch c {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,476 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: order_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=order_repository.go -destination=../mocks/mock_order_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	uuid "github.com/google/uuid"
+	models "github.com/kaanevranportfolio/Commercium/internal/order/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOrderRepository is a mock of OrderRepository interface.
+type MockOrderRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockOrderRepositoryMockRecorder is the mock recorder for MockOrderRepository.
+type MockOrderRepositoryMockRecorder struct {
+	mock *MockOrderRepository
+}
+
+// NewMockOrderRepository creates a new mock instance.
+func NewMockOrderRepository(ctrl *gomock.Controller) *MockOrderRepository {
+	mock := &MockOrderRepository{ctrl: ctrl}
+	mock.recorder = &MockOrderRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderRepository) EXPECT() *MockOrderRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AdvanceSaga mocks base method.
+func (m *MockOrderRepository) AdvanceSaga(ctx context.Context, id uuid.UUID, step models.SagaStep) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvanceSaga", ctx, id, step)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AdvanceSaga indicates an expected call of AdvanceSaga.
+func (mr *MockOrderRepositoryMockRecorder) AdvanceSaga(ctx, id, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceSaga", reflect.TypeOf((*MockOrderRepository)(nil).AdvanceSaga), ctx, id, step)
+}
+
+// Cancel mocks base method.
+func (m *MockOrderRepository) Cancel(ctx context.Context, orderID uuid.UUID, reason *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", ctx, orderID, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockOrderRepositoryMockRecorder) Cancel(ctx, orderID, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockOrderRepository)(nil).Cancel), ctx, orderID, reason)
+}
+
+// ClaimExpiredReservations mocks base method.
+func (m *MockOrderRepository) ClaimExpiredReservations(ctx context.Context, lease time.Duration, limit int) ([]*models.StockReservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimExpiredReservations", ctx, lease, limit)
+	ret0, _ := ret[0].([]*models.StockReservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimExpiredReservations indicates an expected call of ClaimExpiredReservations.
+func (mr *MockOrderRepositoryMockRecorder) ClaimExpiredReservations(ctx, lease, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimExpiredReservations", reflect.TypeOf((*MockOrderRepository)(nil).ClaimExpiredReservations), ctx, lease, limit)
+}
+
+// ClaimStuckSagas mocks base method.
+func (m *MockOrderRepository) ClaimStuckSagas(ctx context.Context, stuckAfter time.Duration, limit int) ([]*models.CheckoutSaga, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimStuckSagas", ctx, stuckAfter, limit)
+	ret0, _ := ret[0].([]*models.CheckoutSaga)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimStuckSagas indicates an expected call of ClaimStuckSagas.
+func (mr *MockOrderRepositoryMockRecorder) ClaimStuckSagas(ctx, stuckAfter, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimStuckSagas", reflect.TypeOf((*MockOrderRepository)(nil).ClaimStuckSagas), ctx, stuckAfter, limit)
+}
+
+// CompleteRefund mocks base method.
+func (m *MockOrderRepository) CompleteRefund(ctx context.Context, refundID uuid.UUID, providerRefundID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteRefund", ctx, refundID, providerRefundID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteRefund indicates an expected call of CompleteRefund.
+func (mr *MockOrderRepositoryMockRecorder) CompleteRefund(ctx, refundID, providerRefundID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteRefund", reflect.TypeOf((*MockOrderRepository)(nil).CompleteRefund), ctx, refundID, providerRefundID)
+}
+
+// CompleteSaga mocks base method.
+func (m *MockOrderRepository) CompleteSaga(ctx context.Context, id, paymentID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteSaga", ctx, id, paymentID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteSaga indicates an expected call of CompleteSaga.
+func (mr *MockOrderRepositoryMockRecorder) CompleteSaga(ctx, id, paymentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteSaga", reflect.TypeOf((*MockOrderRepository)(nil).CompleteSaga), ctx, id, paymentID)
+}
+
+// Create mocks base method.
+func (m *MockOrderRepository) Create(ctx context.Context, order *models.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, order)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockOrderRepositoryMockRecorder) Create(ctx, order any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOrderRepository)(nil).Create), ctx, order)
+}
+
+// CreateInvoice mocks base method.
+func (m *MockOrderRepository) CreateInvoice(ctx context.Context, invoice *models.Invoice, format func(int64) string) (*models.Invoice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInvoice", ctx, invoice, format)
+	ret0, _ := ret[0].(*models.Invoice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInvoice indicates an expected call of CreateInvoice.
+func (mr *MockOrderRepositoryMockRecorder) CreateInvoice(ctx, invoice, format any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvoice", reflect.TypeOf((*MockOrderRepository)(nil).CreateInvoice), ctx, invoice, format)
+}
+
+// CreateRefund mocks base method.
+func (m *MockOrderRepository) CreateRefund(ctx context.Context, refund *models.Refund) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefund", ctx, refund)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRefund indicates an expected call of CreateRefund.
+func (mr *MockOrderRepositoryMockRecorder) CreateRefund(ctx, refund any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefund", reflect.TypeOf((*MockOrderRepository)(nil).CreateRefund), ctx, refund)
+}
+
+// CreateReservation mocks base method.
+func (m *MockOrderRepository) CreateReservation(ctx context.Context, reservation *models.StockReservation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReservation", ctx, reservation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateReservation indicates an expected call of CreateReservation.
+func (mr *MockOrderRepositoryMockRecorder) CreateReservation(ctx, reservation any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReservation", reflect.TypeOf((*MockOrderRepository)(nil).CreateReservation), ctx, reservation)
+}
+
+// CreateSaga mocks base method.
+func (m *MockOrderRepository) CreateSaga(ctx context.Context, saga *models.CheckoutSaga) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSaga", ctx, saga)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSaga indicates an expected call of CreateSaga.
+func (mr *MockOrderRepositoryMockRecorder) CreateSaga(ctx, saga any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSaga", reflect.TypeOf((*MockOrderRepository)(nil).CreateSaga), ctx, saga)
+}
+
+// DeleteTaxExemption mocks base method.
+func (m *MockOrderRepository) DeleteTaxExemption(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTaxExemption", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTaxExemption indicates an expected call of DeleteTaxExemption.
+func (mr *MockOrderRepositoryMockRecorder) DeleteTaxExemption(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTaxExemption", reflect.TypeOf((*MockOrderRepository)(nil).DeleteTaxExemption), ctx, userID)
+}
+
+// ExpireReservation mocks base method.
+func (m *MockOrderRepository) ExpireReservation(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireReservation", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExpireReservation indicates an expected call of ExpireReservation.
+func (mr *MockOrderRepositoryMockRecorder) ExpireReservation(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireReservation", reflect.TypeOf((*MockOrderRepository)(nil).ExpireReservation), ctx, id)
+}
+
+// FailRefund mocks base method.
+func (m *MockOrderRepository) FailRefund(ctx context.Context, refund *models.Refund, failureReason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailRefund", ctx, refund, failureReason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailRefund indicates an expected call of FailRefund.
+func (mr *MockOrderRepositoryMockRecorder) FailRefund(ctx, refund, failureReason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailRefund", reflect.TypeOf((*MockOrderRepository)(nil).FailRefund), ctx, refund, failureReason)
+}
+
+// FinishCompensation mocks base method.
+func (m *MockOrderRepository) FinishCompensation(ctx context.Context, id uuid.UUID, status models.SagaStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishCompensation", ctx, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FinishCompensation indicates an expected call of FinishCompensation.
+func (mr *MockOrderRepositoryMockRecorder) FinishCompensation(ctx, id, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishCompensation", reflect.TypeOf((*MockOrderRepository)(nil).FinishCompensation), ctx, id, status)
+}
+
+// GetByID mocks base method.
+func (m *MockOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockOrderRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockOrderRepository)(nil).GetByID), ctx, id)
+}
+
+// GetInvoiceByOrderID mocks base method.
+func (m *MockOrderRepository) GetInvoiceByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Invoice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvoiceByOrderID", ctx, orderID)
+	ret0, _ := ret[0].(*models.Invoice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvoiceByOrderID indicates an expected call of GetInvoiceByOrderID.
+func (mr *MockOrderRepositoryMockRecorder) GetInvoiceByOrderID(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvoiceByOrderID", reflect.TypeOf((*MockOrderRepository)(nil).GetInvoiceByOrderID), ctx, orderID)
+}
+
+// GetItems mocks base method.
+func (m *MockOrderRepository) GetItems(ctx context.Context, orderID uuid.UUID) ([]*models.OrderItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetItems", ctx, orderID)
+	ret0, _ := ret[0].([]*models.OrderItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetItems indicates an expected call of GetItems.
+func (mr *MockOrderRepositoryMockRecorder) GetItems(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItems", reflect.TypeOf((*MockOrderRepository)(nil).GetItems), ctx, orderID)
+}
+
+// GetReservationStats mocks base method.
+func (m *MockOrderRepository) GetReservationStats(ctx context.Context, since time.Time) (*models.ReservationStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReservationStats", ctx, since)
+	ret0, _ := ret[0].(*models.ReservationStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReservationStats indicates an expected call of GetReservationStats.
+func (mr *MockOrderRepositoryMockRecorder) GetReservationStats(ctx, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReservationStats", reflect.TypeOf((*MockOrderRepository)(nil).GetReservationStats), ctx, since)
+}
+
+// GetSaga mocks base method.
+func (m *MockOrderRepository) GetSaga(ctx context.Context, id uuid.UUID) (*models.CheckoutSaga, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSaga", ctx, id)
+	ret0, _ := ret[0].(*models.CheckoutSaga)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSaga indicates an expected call of GetSaga.
+func (mr *MockOrderRepositoryMockRecorder) GetSaga(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSaga", reflect.TypeOf((*MockOrderRepository)(nil).GetSaga), ctx, id)
+}
+
+// GetTaxExemption mocks base method.
+func (m *MockOrderRepository) GetTaxExemption(ctx context.Context, userID uuid.UUID) (*models.TaxExemption, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaxExemption", ctx, userID)
+	ret0, _ := ret[0].(*models.TaxExemption)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaxExemption indicates an expected call of GetTaxExemption.
+func (mr *MockOrderRepositoryMockRecorder) GetTaxExemption(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaxExemption", reflect.TypeOf((*MockOrderRepository)(nil).GetTaxExemption), ctx, userID)
+}
+
+// ListRefunds mocks base method.
+func (m *MockOrderRepository) ListRefunds(ctx context.Context, orderID uuid.UUID) ([]*models.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRefunds", ctx, orderID)
+	ret0, _ := ret[0].([]*models.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRefunds indicates an expected call of ListRefunds.
+func (mr *MockOrderRepositoryMockRecorder) ListRefunds(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRefunds", reflect.TypeOf((*MockOrderRepository)(nil).ListRefunds), ctx, orderID)
+}
+
+// MarkInvoiceIssued mocks base method.
+func (m *MockOrderRepository) MarkInvoiceIssued(ctx context.Context, invoiceID uuid.UUID, storageKey string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkInvoiceIssued", ctx, invoiceID, storageKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkInvoiceIssued indicates an expected call of MarkInvoiceIssued.
+func (mr *MockOrderRepositoryMockRecorder) MarkInvoiceIssued(ctx, invoiceID, storageKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkInvoiceIssued", reflect.TypeOf((*MockOrderRepository)(nil).MarkInvoiceIssued), ctx, invoiceID, storageKey)
+}
+
+// ProjectOrders mocks base method.
+func (m *MockOrderRepository) ProjectOrders(ctx context.Context, orderIDs []uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProjectOrders", ctx, orderIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProjectOrders indicates an expected call of ProjectOrders.
+func (mr *MockOrderRepositoryMockRecorder) ProjectOrders(ctx, orderIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProjectOrders", reflect.TypeOf((*MockOrderRepository)(nil).ProjectOrders), ctx, orderIDs)
+}
+
+// ProjectStaleOrders mocks base method.
+func (m *MockOrderRepository) ProjectStaleOrders(ctx context.Context, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProjectStaleOrders", ctx, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProjectStaleOrders indicates an expected call of ProjectStaleOrders.
+func (mr *MockOrderRepositoryMockRecorder) ProjectStaleOrders(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProjectStaleOrders", reflect.TypeOf((*MockOrderRepository)(nil).ProjectStaleOrders), ctx, limit)
+}
+
+// PurchaseReservation mocks base method.
+func (m *MockOrderRepository) PurchaseReservation(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurchaseReservation", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurchaseReservation indicates an expected call of PurchaseReservation.
+func (mr *MockOrderRepositoryMockRecorder) PurchaseReservation(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurchaseReservation", reflect.TypeOf((*MockOrderRepository)(nil).PurchaseReservation), ctx, id)
+}
+
+// ReleaseReservation mocks base method.
+func (m *MockOrderRepository) ReleaseReservation(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseReservation", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseReservation indicates an expected call of ReleaseReservation.
+func (mr *MockOrderRepositoryMockRecorder) ReleaseReservation(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseReservation", reflect.TypeOf((*MockOrderRepository)(nil).ReleaseReservation), ctx, id)
+}
+
+// SearchSummaries mocks base method.
+func (m *MockOrderRepository) SearchSummaries(ctx context.Context, filter *models.OrderFilter) ([]*models.OrderReadModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchSummaries", ctx, filter)
+	ret0, _ := ret[0].([]*models.OrderReadModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchSummaries indicates an expected call of SearchSummaries.
+func (mr *MockOrderRepositoryMockRecorder) SearchSummaries(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchSummaries", reflect.TypeOf((*MockOrderRepository)(nil).SearchSummaries), ctx, filter)
+}
+
+// StartCompensation mocks base method.
+func (m *MockOrderRepository) StartCompensation(ctx context.Context, id uuid.UUID, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartCompensation", ctx, id, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StartCompensation indicates an expected call of StartCompensation.
+func (mr *MockOrderRepositoryMockRecorder) StartCompensation(ctx, id, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartCompensation", reflect.TypeOf((*MockOrderRepository)(nil).StartCompensation), ctx, id, reason)
+}
+
+// UpsertTaxExemption mocks base method.
+func (m *MockOrderRepository) UpsertTaxExemption(ctx context.Context, exemption *models.TaxExemption) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertTaxExemption", ctx, exemption)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertTaxExemption indicates an expected call of UpsertTaxExemption.
+func (mr *MockOrderRepositoryMockRecorder) UpsertTaxExemption(ctx, exemption any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertTaxExemption", reflect.TypeOf((*MockOrderRepository)(nil).UpsertTaxExemption), ctx, exemption)
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CheckoutItem is a line item in a checkout totals request.
+// Prices are in minor units of the request currency. UnitPrice is ignored
+// when the pricing service is configured.
+type CheckoutItem struct {
+	ProductID uuid.UUID `json:"product_id" binding:"required"`
+	SKU       string    `json:"sku" binding:"required,max=100"`
+	Quantity  int       `json:"quantity" binding:"required,min=1,max=1000"`
+	UnitPrice int64     `json:"unit_price" binding:"min=0"`
+	// TaxCode selects reduced rates or exemptions for the product, e.g. "books"
+	TaxCode string `json:"tax_code,omitempty" binding:"max=50"`
+}
+
+// CheckoutTotalsRequest asks for the totals of a cart before it is ordered
+type CheckoutTotalsRequest struct {
+	Currency        string         `json:"currency" binding:"required,len=3"`
+	Items           []CheckoutItem `json:"items" binding:"required,min=1,max=100,dive"`
+	ShippingAddress *Address       `json:"shipping_address" binding:"required"`
+	ShippingAmount  int64          `json:"shipping_amount" binding:"min=0"`
+	DiscountAmount  int64          `json:"discount_amount" binding:"min=0"`
+}
+
+// CheckoutLineTotal is a line item with its share of the discount and its tax
+type CheckoutLineTotal struct {
+	ProductID      uuid.UUID `json:"product_id"`
+	SKU            string    `json:"sku"`
+	Quantity       int       `json:"quantity"`
+	UnitPrice      int64     `json:"unit_price"`
+	ListPrice      int64     `json:"list_price,omitempty"`
+	TotalPrice     int64     `json:"total_price"`
+	DiscountAmount int64     `json:"discount_amount"`
+	TaxAmount      int64     `json:"tax_amount"`
+}
+
+// CheckoutTotals are the amounts an order will be placed with. When
+// PricesIncludeTax is set, TaxAmount is contained in the subtotal and
+// shipping instead of being added to the total.
+type CheckoutTotals struct {
+	Currency         string               `json:"currency"`
+	SubtotalAmount   int64                `json:"subtotal_amount"`
+	DiscountAmount   int64                `json:"discount_amount"`
+	ShippingAmount   int64                `json:"shipping_amount"`
+	TaxAmount        int64                `json:"tax_amount"`
+	TotalAmount      int64                `json:"total_amount"`
+	PricesIncludeTax bool                 `json:"prices_include_tax"`
+	TaxExempt        bool                 `json:"tax_exempt"`
+	Lines            []*CheckoutLineTotal `json:"lines"`
+}
+
+// TaxExemption exempts a customer from tax on the strength of an exemption certificate
+type TaxExemption struct {
+	UserID            uuid.UUID  `json:"user_id" db:"user_id"`
+	CertificateNumber string     `json:"certificate_number" db:"certificate_number"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedBy         uuid.UUID  `json:"created_by" db:"created_by"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// IsActive reports whether the exemption applies at the given time
+func (e *TaxExemption) IsActive(at time.Time) bool {
+	return e.ExpiresAt == nil || at.Before(*e.ExpiresAt)
+}
+
+// TaxExemptionRequest grants or renews a customer's tax exemption
+type TaxExemptionRequest struct {
+	CertificateNumber string     `json:"certificate_number" binding:"required,max=100"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Order event types published to the order events topic
+const (
+	EventOrderCreated   = "order.created"
+	EventOrderCancelled = "order.cancelled"
+	EventOrderRefunded  = "order.refunded"
+	// EventReservationExpired is published when the stock of an abandoned checkout is released
+	EventReservationExpired = "order.reservation_expired"
+	// EventOrderDelivered is published by the shipping service
+	EventOrderDelivered = "order.delivered"
+)
+
+// OrderEventSchemaVersion is the schema version of OrderEvent payloads
+const OrderEventSchemaVersion = 1
+
+// OrderEvent is published whenever the state of an order changes.
+// Downstream consumers (notifications, analytics) key off Type.
+type OrderEvent struct {
+	Type       string     `json:"type"`
+	OrderID    uuid.UUID  `json:"order_id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Status     string     `json:"status"`
+	Amount     int64      `json:"amount,omitempty"`
+	Currency   string     `json:"currency,omitempty"`
+	RefundID   *uuid.UUID `json:"refund_id,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+	OccurredAt time.Time  `json:"occurred_at"`
+}
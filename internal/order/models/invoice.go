@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InvoiceStatus represents the state of an invoice document
+type InvoiceStatus string
+
+// Invoice statuses
+const (
+	// InvoiceStatusPending means the number is allocated but the PDF is not stored yet
+	InvoiceStatusPending InvoiceStatus = "pending"
+	InvoiceStatusIssued  InvoiceStatus = "issued"
+)
+
+// Invoice represents the invoice issued for a completed order
+type Invoice struct {
+	ID             uuid.UUID     `json:"id" db:"id"`
+	OrderID        uuid.UUID     `json:"order_id" db:"order_id"`
+	LegalEntity    string        `json:"legal_entity" db:"legal_entity"`
+	SequenceNumber int64         `json:"sequence_number" db:"sequence_number"`
+	InvoiceNumber  string        `json:"invoice_number" db:"invoice_number"`
+	Currency       string        `json:"currency" db:"currency"`
+	TotalAmount    int64         `json:"total_amount" db:"total_amount"`
+	Status         InvoiceStatus `json:"status" db:"status"`
+	StorageKey     *string       `json:"-" db:"storage_key"`
+	IssuedAt       time.Time     `json:"issued_at" db:"issued_at"`
+	CreatedAt      time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// InvoiceResponse is the invoice download link returned to the customer
+type InvoiceResponse struct {
+	InvoiceNumber string    `json:"invoice_number"`
+	IssuedAt      time.Time `json:"issued_at"`
+	URL           string    `json:"url"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
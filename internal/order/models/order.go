@@ -0,0 +1,237 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+)
+
+// OrderStatus represents the lifecycle state of an order
+type OrderStatus string
+
+// Order statuses
+const (
+	OrderStatusPending    OrderStatus = "pending"
+	OrderStatusConfirmed  OrderStatus = "confirmed"
+	OrderStatusProcessing OrderStatus = "processing"
+	OrderStatusShipped    OrderStatus = "shipped"
+	OrderStatusDelivered  OrderStatus = "delivered"
+	OrderStatusCancelled  OrderStatus = "cancelled"
+	OrderStatusRefunded   OrderStatus = "refunded"
+)
+
+// IsValid reports whether the status is a known order status
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case OrderStatusPending, OrderStatusConfirmed, OrderStatusProcessing, OrderStatusShipped,
+		OrderStatusDelivered, OrderStatusCancelled, OrderStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// Address is a snapshot of a shipping or billing address stored with the order
+type Address struct {
+	FirstName    string  `json:"first_name"`
+	LastName     string  `json:"last_name"`
+	Company      *string `json:"company,omitempty"`
+	AddressLine1 string  `json:"address_line1"`
+	AddressLine2 *string `json:"address_line2,omitempty"`
+	City         string  `json:"city"`
+	State        *string `json:"state,omitempty"`
+	PostalCode   string  `json:"postal_code"`
+	Country      string  `json:"country"`
+	Phone        *string `json:"phone,omitempty"`
+}
+
+// Value implements driver.Valuer so addresses can be stored as JSONB
+func (a Address) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+// Scan implements sql.Scanner so addresses can be read from JSONB
+func (a *Address) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return fmt.Errorf("cannot scan %T into Address", src)
+	}
+}
+
+// Order represents a customer order
+type Order struct {
+	ID              uuid.UUID   `json:"id" db:"id"`
+	OrderNumber     string      `json:"order_number" db:"order_number"`
+	UserID          uuid.UUID   `json:"user_id" db:"user_id"`
+	Status          OrderStatus `json:"status" db:"status"`
+	Currency        string      `json:"currency" db:"currency"`
+	SubtotalAmount  int64       `json:"subtotal_amount" db:"subtotal_amount"`
+	TaxAmount       int64       `json:"tax_amount" db:"tax_amount"`
+	ShippingAmount  int64       `json:"shipping_amount" db:"shipping_amount"`
+	DiscountAmount  int64       `json:"discount_amount" db:"discount_amount"`
+	TotalAmount     int64       `json:"total_amount" db:"total_amount"`
+	RefundedAmount  int64       `json:"refunded_amount" db:"refunded_amount"`
+	ShippingAddress *Address    `json:"shipping_address,omitempty" db:"shipping_address"`
+	BillingAddress  *Address    `json:"billing_address,omitempty" db:"billing_address"`
+	Notes           *string     `json:"notes,omitempty" db:"notes"`
+	CancelledAt     *time.Time  `json:"cancelled_at,omitempty" db:"cancelled_at"`
+	CancelReason    *string     `json:"cancellation_reason,omitempty" db:"cancellation_reason"`
+	PlacedAt        time.Time   `json:"placed_at" db:"placed_at"`
+	CreatedAt       time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at" db:"updated_at"`
+
+	Items   []*OrderItem    `json:"items,omitempty" db:"-"`
+	Display *DisplayAmounts `json:"display,omitempty" db:"-"`
+}
+
+// DisplayAmounts are the amounts of an order converted to the customer's
+// display currency. They are indicative; the order is charged in its own
+// currency. Each amount is converted and rounded separately.
+type DisplayAmounts struct {
+	Currency       string     `json:"currency"`
+	Rate           string     `json:"rate"`
+	SubtotalAmount int64      `json:"subtotal_amount"`
+	TaxAmount      int64      `json:"tax_amount"`
+	ShippingAmount int64      `json:"shipping_amount"`
+	DiscountAmount int64      `json:"discount_amount"`
+	TotalAmount    int64      `json:"total_amount"`
+	RefundedAmount int64      `json:"refunded_amount"`
+	RatesAsOf      *time.Time `json:"rates_as_of,omitempty"`
+}
+
+// DisplayTotal is an order total converted to the customer's display currency
+type DisplayTotal struct {
+	Currency    string `json:"currency"`
+	TotalAmount int64  `json:"total_amount"`
+}
+
+// CanCancel reports whether the order can still be cancelled free of charge.
+// Once an order has shipped it can only be refunded.
+func (o *Order) CanCancel() bool {
+	switch o.Status {
+	case OrderStatusPending, OrderStatusConfirmed, OrderStatusProcessing:
+		return true
+	}
+	return false
+}
+
+// CanRefund reports whether line items of the order can be refunded
+func (o *Order) CanRefund() bool {
+	switch o.Status {
+	case OrderStatusShipped, OrderStatusDelivered:
+		return true
+	}
+	return false
+}
+
+// CreateOrderRequest is sent by other services, e.g. subscriptions, to place
+// an order at the given prices. ID is chosen by the caller so that a retried
+// request returns the order placed by the first one.
+type CreateOrderRequest struct {
+	ID              uuid.UUID         `json:"id" binding:"required"`
+	UserID          uuid.UUID         `json:"user_id" binding:"required"`
+	Currency        string            `json:"currency" binding:"required,len=3"`
+	Items           []CreateOrderItem `json:"items" binding:"required,min=1,max=100,dive"`
+	ShippingAddress *Address          `json:"shipping_address" binding:"required"`
+	ShippingAmount  int64             `json:"shipping_amount" binding:"min=0"`
+	DiscountAmount  int64             `json:"discount_amount" binding:"min=0"`
+	Notes           *string           `json:"notes,omitempty" binding:"omitempty,max=1000"`
+}
+
+// CreateOrderItem is a line item of an order placed by another service
+type CreateOrderItem struct {
+	CheckoutItem
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+// OrderItem represents a single line item of an order
+type OrderItem struct {
+	ID               uuid.UUID `json:"id" db:"id"`
+	OrderID          uuid.UUID `json:"order_id" db:"order_id"`
+	ProductID        uuid.UUID `json:"product_id" db:"product_id"`
+	SKU              string    `json:"sku" db:"sku"`
+	Name             string    `json:"name" db:"name"`
+	ImageURL         *string   `json:"image_url,omitempty" db:"image_url"`
+	Quantity         int       `json:"quantity" db:"quantity"`
+	RefundedQuantity int       `json:"refunded_quantity" db:"refunded_quantity"`
+	UnitPrice        int64     `json:"unit_price" db:"unit_price"`
+	TotalPrice       int64     `json:"total_price" db:"total_price"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// RefundableQuantity returns how many units of the item have not been refunded yet
+func (i *OrderItem) RefundableQuantity() int {
+	return i.Quantity - i.RefundedQuantity
+}
+
+// OrderFilter holds the criteria used to list and search orders
+type OrderFilter struct {
+	// UserID restricts the orders to one customer's
+	UserID *uuid.UUID
+	// Query matches an order number, or the start of a customer's email or name
+	Query    string
+	Statuses []OrderStatus
+	From     *time.Time
+	To       *time.Time
+	Cursor   *OrderCursor
+	Limit    int
+}
+
+// OrderCursor identifies the position after which the next page starts
+type OrderCursor struct {
+	PlacedAt time.Time `json:"p"`
+	ID       uuid.UUID `json:"i"`
+}
+
+// ListOrdersRequest represents the query parameters of the order history endpoint
+type ListOrdersRequest struct {
+	Status string `form:"status"`
+	From   string `form:"from"`
+	To     string `form:"to"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	// Currency overrides the customer's display currency
+	Currency string `form:"currency" binding:"omitempty,len=3"`
+}
+
+// GetOrderRequest represents the query parameters of the order detail endpoint
+type GetOrderRequest struct {
+	// Currency overrides the customer's display currency
+	Currency string `form:"currency" binding:"omitempty,len=3"`
+}
+
+// OrderItemSummary is the compact line item representation embedded in order lists
+type OrderItemSummary struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Name      string    `json:"name"`
+	ImageURL  *string   `json:"image_url,omitempty"`
+	Quantity  int       `json:"quantity"`
+}
+
+// OrderSummary represents an order in the order history list
+type OrderSummary struct {
+	ID          uuid.UUID           `json:"id"`
+	OrderNumber string              `json:"order_number"`
+	Status      OrderStatus         `json:"status"`
+	Currency    string              `json:"currency"`
+	TotalAmount int64               `json:"total_amount"`
+	ItemCount   int                 `json:"item_count"`
+	Items       []*OrderItemSummary `json:"items"`
+	Display     *DisplayTotal       `json:"display,omitempty"`
+	// PaymentStatus is the status of the order's latest payment
+	PaymentStatus *string   `json:"payment_status,omitempty"`
+	PlacedAt      time.Time `json:"placed_at"`
+}
+
+// OrderListResponse represents a page of the order history
+type OrderListResponse = httpx.ListResponse[*OrderSummary]
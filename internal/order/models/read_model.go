@@ -0,0 +1,107 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+)
+
+// OrderReadModel is an order's row in the order read model: the order with
+// its customer and latest payment, as of SourceUpdatedAt
+type OrderReadModel struct {
+	OrderID         uuid.UUID    `db:"order_id"`
+	OrderNumber     string       `db:"order_number"`
+	UserID          uuid.UUID    `db:"user_id"`
+	CustomerEmail   string       `db:"customer_email"`
+	CustomerName    *string      `db:"customer_name"`
+	Status          OrderStatus  `db:"status"`
+	Currency        string       `db:"currency"`
+	TotalAmount     int64        `db:"total_amount"`
+	RefundedAmount  int64        `db:"refunded_amount"`
+	ItemCount       int          `db:"item_count"`
+	Items           SummaryItems `db:"items"`
+	PaymentStatus   *string      `db:"payment_status"`
+	PaymentProvider *string      `db:"payment_provider"`
+	PlacedAt        time.Time    `db:"placed_at"`
+	SourceUpdatedAt time.Time    `db:"source_updated_at"`
+	ProjectedAt     time.Time    `db:"projected_at"`
+}
+
+// SummaryItems are the line items of an order summary, stored as JSONB
+type SummaryItems []*OrderItemSummary
+
+// Scan implements sql.Scanner so items can be read from JSONB
+func (i *SummaryItems) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, i)
+	case string:
+		return json.Unmarshal([]byte(v), i)
+	default:
+		return fmt.Errorf("cannot scan %T into SummaryItems", src)
+	}
+}
+
+// Value implements driver.Valuer so items can be stored as JSONB
+func (i SummaryItems) Value() (driver.Value, error) {
+	data, err := json.Marshal(i)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// ToSummary converts the row to the customer's view of the order
+func (m *OrderReadModel) ToSummary() *OrderSummary {
+	return &OrderSummary{
+		ID:            m.OrderID,
+		OrderNumber:   m.OrderNumber,
+		Status:        m.Status,
+		Currency:      m.Currency,
+		TotalAmount:   m.TotalAmount,
+		ItemCount:     m.ItemCount,
+		Items:         m.Items,
+		PaymentStatus: m.PaymentStatus,
+		PlacedAt:      m.PlacedAt,
+	}
+}
+
+// ToAdminSummary converts the row to the back office view of the order
+func (m *OrderReadModel) ToAdminSummary() *AdminOrderSummary {
+	return &AdminOrderSummary{
+		OrderSummary:    *m.ToSummary(),
+		UserID:          m.UserID,
+		CustomerEmail:   m.CustomerEmail,
+		CustomerName:    m.CustomerName,
+		RefundedAmount:  m.RefundedAmount,
+		PaymentProvider: m.PaymentProvider,
+	}
+}
+
+// AdminOrderSummary represents an order in back office order searches
+type AdminOrderSummary struct {
+	OrderSummary
+	UserID          uuid.UUID `json:"user_id"`
+	CustomerEmail   string    `json:"customer_email"`
+	CustomerName    *string   `json:"customer_name,omitempty"`
+	RefundedAmount  int64     `json:"refunded_amount"`
+	PaymentProvider *string   `json:"payment_provider,omitempty"`
+}
+
+// SearchOrdersRequest represents the query parameters of the back office order search
+type SearchOrdersRequest struct {
+	ListOrdersRequest
+	// Query matches an order number, or the start of a customer's email or name
+	Query  string `form:"q" binding:"max=255"`
+	UserID string `form:"user_id" binding:"omitempty,uuid"`
+}
+
+// AdminOrderListResponse represents a page of back office order search results
+type AdminOrderListResponse = httpx.ListResponse[*AdminOrderSummary]
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefundStatus represents the state of a refund with the payment provider
+type RefundStatus string
+
+// Refund statuses
+const (
+	RefundStatusPending   RefundStatus = "pending"
+	RefundStatusSucceeded RefundStatus = "succeeded"
+	RefundStatusFailed    RefundStatus = "failed"
+)
+
+// Refund represents money returned to the customer for an order
+type Refund struct {
+	ID               uuid.UUID    `json:"id" db:"id"`
+	OrderID          uuid.UUID    `json:"order_id" db:"order_id"`
+	Amount           int64        `json:"amount" db:"amount"`
+	Currency         string       `json:"currency" db:"currency"`
+	Reason           *string      `json:"reason,omitempty" db:"reason"`
+	Status           RefundStatus `json:"status" db:"status"`
+	ProviderRefundID *string      `json:"provider_refund_id,omitempty" db:"provider_refund_id"`
+	FailureReason    *string      `json:"failure_reason,omitempty" db:"failure_reason"`
+	CreatedAt        time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at" db:"updated_at"`
+
+	Items []*RefundItem `json:"items,omitempty" db:"-"`
+}
+
+// RefundItem represents the refunded quantity of a single order line item
+type RefundItem struct {
+	RefundID    uuid.UUID `json:"refund_id" db:"refund_id"`
+	OrderItemID uuid.UUID `json:"order_item_id" db:"order_item_id"`
+	Quantity    int       `json:"quantity" db:"quantity"`
+	Amount      int64     `json:"amount" db:"amount"`
+}
+
+// CancelOrderRequest represents the request to cancel an order
+type CancelOrderRequest struct {
+	Reason string `json:"reason" binding:"omitempty,max=500"`
+}
+
+// RefundItemRequest identifies a line item and the quantity to refund
+type RefundItemRequest struct {
+	OrderItemID uuid.UUID `json:"order_item_id" binding:"required"`
+	Quantity    int       `json:"quantity" binding:"required,min=1"`
+}
+
+// CreateRefundRequest represents the request to refund line items of a shipped order
+type CreateRefundRequest struct {
+	Reason string               `json:"reason" binding:"omitempty,max=500"`
+	Items  []*RefundItemRequest `json:"items" binding:"required,min=1,dive"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReservationStatus represents the state of a stock reservation
+type ReservationStatus string
+
+const (
+	// ReservationStatusHeld reservations hold stock for a running checkout
+	ReservationStatusHeld ReservationStatus = "held"
+	// ReservationStatusPurchased reservations became an order
+	ReservationStatusPurchased ReservationStatus = "purchased"
+	// ReservationStatusReleased reservations were given back by a rolled back checkout
+	ReservationStatusReleased ReservationStatus = "released"
+	// ReservationStatusExpiring reservations expired and are being released
+	ReservationStatusExpiring ReservationStatus = "expiring"
+	// ReservationStatusExpired reservations were given back after their checkout was abandoned
+	ReservationStatusExpired ReservationStatus = "expired"
+)
+
+// StockReservation is the stock a checkout holds in inventory. Its ID is the
+// ID of the checkout and of the order it places.
+type StockReservation struct {
+	ID         uuid.UUID         `json:"id" db:"id"`
+	UserID     uuid.UUID         `json:"user_id" db:"user_id"`
+	Items      ReservedItems     `json:"items" db:"items"`
+	Status     ReservationStatus `json:"status" db:"status"`
+	ExpiresAt  time.Time         `json:"expires_at" db:"expires_at"`
+	ResolvedAt *time.Time        `json:"resolved_at,omitempty" db:"resolved_at"`
+	CreatedAt  time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// ReservationStats counts the outcomes of the reservations resolved in a period
+type ReservationStats struct {
+	Purchased int `db:"purchased"`
+	Released  int `db:"released"`
+	Expired   int `db:"expired"`
+}
+
+// ConversionRate is the share of resolved reservations that became an order
+func (s *ReservationStats) ConversionRate() float64 {
+	total := s.Purchased + s.Released + s.Expired
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Purchased) / float64(total)
+}
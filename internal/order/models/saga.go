@@ -0,0 +1,111 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SagaStatus represents the state of a checkout saga
+type SagaStatus string
+
+const (
+	// SagaStatusRunning sagas are executing their steps
+	SagaStatusRunning   SagaStatus = "running"
+	SagaStatusCompleted SagaStatus = "completed"
+	// SagaStatusCompensating sagas are undoing the steps they started
+	SagaStatusCompensating SagaStatus = "compensating"
+	SagaStatusCompensated  SagaStatus = "compensated"
+	// SagaStatusFailed sagas could not be undone and need manual attention
+	SagaStatusFailed SagaStatus = "failed"
+)
+
+// IsFinished reports whether the saga has nothing left to do
+func (s SagaStatus) IsFinished() bool {
+	switch s {
+	case SagaStatusCompleted, SagaStatusCompensated, SagaStatusFailed:
+		return true
+	}
+	return false
+}
+
+// SagaStep is a step of the checkout saga
+type SagaStep string
+
+const (
+	SagaStepReserveInventory SagaStep = "reserve_inventory"
+	SagaStepCreateOrder      SagaStep = "create_order"
+	SagaStepAuthorizePayment SagaStep = "authorize_payment"
+)
+
+// SagaSteps are the steps of a checkout in the order they run
+var SagaSteps = []SagaStep{SagaStepReserveInventory, SagaStepCreateOrder, SagaStepAuthorizePayment}
+
+// CheckoutSaga tracks a checkout across the services it spans. Step is the
+// step last started, which may or may not have taken effect; undoing a saga
+// undoes that step and every step before it. The saga ID is the ID of the
+// order it places.
+type CheckoutSaga struct {
+	ID            uuid.UUID     `json:"id" db:"id"`
+	UserID        uuid.UUID     `json:"user_id" db:"user_id"`
+	Items         ReservedItems `json:"items" db:"items"`
+	Status        SagaStatus    `json:"status" db:"status"`
+	Step          SagaStep      `json:"step" db:"step"`
+	PaymentID     *uuid.UUID    `json:"payment_id,omitempty" db:"payment_id"`
+	FailureReason *string       `json:"failure_reason,omitempty" db:"failure_reason"`
+	Attempts      int           `json:"attempts" db:"attempts"`
+	CreatedAt     time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// ReservedItems are the items a saga reserves stock for
+type ReservedItems []CheckoutItem
+
+// Value implements driver.Valuer so items can be stored as JSONB
+func (i ReservedItems) Value() (driver.Value, error) {
+	data, err := json.Marshal(i)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner so items can be read from JSONB
+func (i *ReservedItems) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, i)
+	case string:
+		return json.Unmarshal([]byte(v), i)
+	default:
+		return fmt.Errorf("cannot scan %T into ReservedItems", src)
+	}
+}
+
+// PlaceOrderRequest places an order for the customer's cart and authorizes
+// its payment. CheckoutID is chosen by the client and becomes the order ID,
+// so a retried request returns the outcome of the first one.
+type PlaceOrderRequest struct {
+	CheckoutID      uuid.UUID         `json:"checkout_id" binding:"required"`
+	Currency        string            `json:"currency" binding:"required,len=3"`
+	Items           []CreateOrderItem `json:"items" binding:"required,min=1,max=100,dive"`
+	ShippingAddress *Address          `json:"shipping_address" binding:"required"`
+	ShippingAmount  int64             `json:"shipping_amount" binding:"min=0"`
+	DiscountAmount  int64             `json:"discount_amount" binding:"min=0"`
+	Notes           *string           `json:"notes,omitempty" binding:"omitempty,max=1000"`
+
+	PaymentMethod     string   `json:"payment_method" binding:"required_without=GiftCardCodes"`
+	GiftCardCodes     []string `json:"gift_card_codes,omitempty" binding:"omitempty,max=5,dive,required,max=32"`
+	PaymentMethodType string   `json:"payment_method_type,omitempty"`
+	Provider          string   `json:"provider,omitempty"`
+	// DeviceID is the client's device fingerprint, used for fraud screening
+	DeviceID string `json:"device_id,omitempty" binding:"max=255"`
+
+	// IPAddress is set from the request by the handler
+	IPAddress string `json:"-"`
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+)
+
+const invoiceColumns = `id, order_id, legal_entity, sequence_number, invoice_number, currency, total_amount,
+		       status, storage_key, issued_at, created_at, updated_at`
+
+// GetInvoiceByOrderID retrieves the invoice of an order
+func (r *orderRepository) GetInvoiceByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Invoice, error) {
+	invoice := &models.Invoice{}
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices
+		WHERE order_id = $1`
+
+	err := r.db.GetContext(ctx, invoice, query, orderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("invoice not found")
+		}
+		r.logger.Error("Failed to get invoice", "error", err, "order_id", orderID)
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+
+	return invoice, nil
+}
+
+// CreateInvoice allocates the next number of the invoice's legal entity and
+// stores the invoice as pending. The number is formatted by format. If the
+// order already has an invoice, that invoice is returned instead and no
+// number is used up.
+func (r *orderRepository) CreateInvoice(ctx context.Context, invoice *models.Invoice, format func(int64) string) (*models.Invoice, error) {
+	var existing *models.Invoice
+
+	// Invoicing an order again returns its invoice, so the transaction is
+	// safe to run again after a deadlock on the sequence
+	err := r.db.TransactionRetry(ctx, func(tx *sqlx.Tx) error {
+		// Serialize invoicing of the same order so only one number is allocated
+		if _, err := tx.ExecContext(ctx, `SELECT 1 FROM orders WHERE id = $1 FOR UPDATE`, invoice.OrderID); err != nil {
+			return fmt.Errorf("failed to lock order: %w", err)
+		}
+
+		current := &models.Invoice{}
+		err := tx.GetContext(ctx, current, `SELECT `+invoiceColumns+` FROM invoices WHERE order_id = $1`, invoice.OrderID)
+		if err == nil {
+			existing = current
+			return nil
+		}
+		if err != sql.ErrNoRows {
+			return fmt.Errorf("failed to get invoice: %w", err)
+		}
+
+		// The row lock on the sequence keeps concurrent invoices of the entity in order
+		err = tx.QueryRowxContext(ctx, `
+			INSERT INTO invoice_sequences (legal_entity, last_number) VALUES ($1, 1)
+			ON CONFLICT (legal_entity) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+			RETURNING last_number`, invoice.LegalEntity).Scan(&invoice.SequenceNumber)
+		if err != nil {
+			return fmt.Errorf("failed to allocate invoice number: %w", err)
+		}
+		invoice.InvoiceNumber = format(invoice.SequenceNumber)
+
+		stmt, err := tx.PrepareNamedContext(ctx, `
+			INSERT INTO invoices (id, order_id, legal_entity, sequence_number, invoice_number, currency,
+			                      total_amount, status)
+			VALUES (:id, :order_id, :legal_entity, :sequence_number, :invoice_number, :currency,
+			        :total_amount, :status)
+			RETURNING issued_at, created_at, updated_at`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer stmt.Close()
+
+		if err := stmt.QueryRowxContext(ctx, invoice).Scan(&invoice.IssuedAt, &invoice.CreatedAt, &invoice.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to create invoice: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("Failed to create invoice", "error", err, "order_id", invoice.OrderID)
+		return nil, err
+	}
+
+	if existing != nil {
+		return existing, nil
+	}
+	return invoice, nil
+}
+
+// MarkInvoiceIssued records where the invoice PDF is stored
+func (r *orderRepository) MarkInvoiceIssued(ctx context.Context, invoiceID uuid.UUID, storageKey string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE invoices SET status = $2, storage_key = $3 WHERE id = $1`,
+		invoiceID, models.InvoiceStatusIssued, storageKey)
+	if err != nil {
+		r.logger.Error("Failed to mark invoice as issued", "error", err, "invoice_id", invoiceID)
+		return fmt.Errorf("failed to mark invoice as issued: %w", err)
+	}
+
+	return nil
+}
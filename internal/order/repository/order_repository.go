@@ -0,0 +1,363 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+//go:generate go run go.uber.org/mock/mockgen -source=order_repository.go -destination=../mocks/mock_order_repository.go -package=mocks
+
+// OrderRepository defines the interface for order data operations
+type OrderRepository interface {
+	Create(ctx context.Context, order *models.Order) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error)
+
+	// Item operations
+	GetItems(ctx context.Context, orderID uuid.UUID) ([]*models.OrderItem, error)
+
+	// Cancellation and refund operations
+	Cancel(ctx context.Context, orderID uuid.UUID, reason *string) error
+	CreateRefund(ctx context.Context, refund *models.Refund) error
+	CompleteRefund(ctx context.Context, refundID uuid.UUID, providerRefundID string) error
+	FailRefund(ctx context.Context, refund *models.Refund, failureReason string) error
+	ListRefunds(ctx context.Context, orderID uuid.UUID) ([]*models.Refund, error)
+
+	// Tax exemption operations
+	GetTaxExemption(ctx context.Context, userID uuid.UUID) (*models.TaxExemption, error)
+	UpsertTaxExemption(ctx context.Context, exemption *models.TaxExemption) error
+	DeleteTaxExemption(ctx context.Context, userID uuid.UUID) error
+
+	// Invoice operations
+	GetInvoiceByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Invoice, error)
+	CreateInvoice(ctx context.Context, invoice *models.Invoice, format func(int64) string) (*models.Invoice, error)
+	MarkInvoiceIssued(ctx context.Context, invoiceID uuid.UUID, storageKey string) error
+
+	// Checkout saga operations
+	CreateSaga(ctx context.Context, saga *models.CheckoutSaga) error
+	GetSaga(ctx context.Context, id uuid.UUID) (*models.CheckoutSaga, error)
+	AdvanceSaga(ctx context.Context, id uuid.UUID, step models.SagaStep) error
+	CompleteSaga(ctx context.Context, id uuid.UUID, paymentID uuid.UUID) error
+	StartCompensation(ctx context.Context, id uuid.UUID, reason string) error
+	FinishCompensation(ctx context.Context, id uuid.UUID, status models.SagaStatus) error
+	ClaimStuckSagas(ctx context.Context, stuckAfter time.Duration, limit int) ([]*models.CheckoutSaga, error)
+
+	// Stock reservation operations
+	CreateReservation(ctx context.Context, reservation *models.StockReservation) error
+	PurchaseReservation(ctx context.Context, id uuid.UUID) error
+	ReleaseReservation(ctx context.Context, id uuid.UUID) error
+	ExpireReservation(ctx context.Context, id uuid.UUID) error
+	ClaimExpiredReservations(ctx context.Context, lease time.Duration, limit int) ([]*models.StockReservation, error)
+	GetReservationStats(ctx context.Context, since time.Time) (*models.ReservationStats, error)
+
+	// Read model operations
+	ProjectOrders(ctx context.Context, orderIDs []uuid.UUID) error
+	ProjectStaleOrders(ctx context.Context, limit int) (int, error)
+	SearchSummaries(ctx context.Context, filter *models.OrderFilter) ([]*models.OrderReadModel, error)
+}
+
+// orderRepository implements the OrderRepository interface
+type orderRepository struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewOrderRepository creates a new order repository
+func NewOrderRepository(db *database.DB, logger *logger.Logger) OrderRepository {
+	return &orderRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const orderColumns = `id, order_number, user_id, status, currency, subtotal_amount, tax_amount,
+		       shipping_amount, discount_amount, total_amount, refunded_amount, shipping_address,
+		       billing_address, notes, cancelled_at, cancellation_reason, placed_at, created_at, updated_at`
+
+const orderItemColumns = `id, order_id, product_id, sku, name, image_url, quantity, refunded_quantity,
+		       unit_price, total_price, created_at`
+
+// Create stores a new order with its line items
+func (r *orderRepository) Create(ctx context.Context, order *models.Order) error {
+	ctx = database.WithOperation(ctx, "orders.create")
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		query := `
+			INSERT INTO orders (id, order_number, user_id, status, currency, subtotal_amount, tax_amount,
+			                    shipping_amount, discount_amount, total_amount, shipping_address,
+			                    billing_address, notes)
+			VALUES (:id, :order_number, :user_id, :status, :currency, :subtotal_amount, :tax_amount,
+			        :shipping_amount, :discount_amount, :total_amount, :shipping_address,
+			        :billing_address, :notes)
+			RETURNING placed_at, created_at, updated_at`
+
+		stmt, err := tx.PrepareNamedContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer stmt.Close()
+
+		err = stmt.QueryRowxContext(ctx, order).Scan(&order.PlacedAt, &order.CreatedAt, &order.UpdatedAt)
+		if err != nil {
+			if pgErr, ok := database.PgError(err); ok {
+				switch pgErr.Code {
+				case database.UniqueViolation:
+					return apperrors.Conflict("order already exists")
+				case database.ForeignKeyViolation:
+					return apperrors.NotFound("user not found")
+				}
+			}
+			r.logger.Error("Failed to create order", "error", err, "id", order.ID)
+			return fmt.Errorf("failed to create order: %w", err)
+		}
+
+		itemQuery := `
+			INSERT INTO order_items (id, order_id, product_id, sku, name, image_url, quantity,
+			                         unit_price, total_price)
+			VALUES (:id, :order_id, :product_id, :sku, :name, :image_url, :quantity,
+			        :unit_price, :total_price)
+			RETURNING created_at`
+
+		itemStmt, err := tx.PrepareNamedContext(ctx, itemQuery)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer itemStmt.Close()
+
+		for _, item := range order.Items {
+			if err := itemStmt.QueryRowxContext(ctx, item).Scan(&item.CreatedAt); err != nil {
+				r.logger.Error("Failed to create order item", "error", err, "order_id", order.ID, "sku", item.SKU)
+				return fmt.Errorf("failed to create order item: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetByID retrieves an order by ID
+func (r *orderRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Order, error) {
+	ctx = database.WithOperation(ctx, "orders.get_by_id")
+	order := &models.Order{}
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders
+		WHERE id = $1`
+
+	err := r.db.GetContext(ctx, order, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("order not found")
+		}
+		r.logger.Error("Failed to get order by ID", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	return order, nil
+}
+
+// GetItems retrieves all line items of an order
+func (r *orderRepository) GetItems(ctx context.Context, orderID uuid.UUID) ([]*models.OrderItem, error) {
+	ctx = database.WithOperation(ctx, "orders.get_items")
+	items := []*models.OrderItem{}
+	query := `
+		SELECT ` + orderItemColumns + `
+		FROM order_items
+		WHERE order_id = $1
+		ORDER BY created_at, id`
+
+	err := r.db.SelectContext(ctx, &items, query, orderID)
+	if err != nil {
+		r.logger.Error("Failed to get order items", "error", err, "order_id", orderID)
+		return nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+
+	return items, nil
+}
+
+// Cancel marks an order as cancelled if it has not shipped yet
+func (r *orderRepository) Cancel(ctx context.Context, orderID uuid.UUID, reason *string) error {
+	ctx = database.WithOperation(ctx, "orders.cancel")
+	query := `
+		UPDATE orders
+		SET status = $2, cancelled_at = NOW(), cancellation_reason = $3
+		WHERE id = $1 AND status IN ($4, $5, $6)`
+
+	result, err := r.db.ExecContext(ctx, query, orderID, models.OrderStatusCancelled, reason,
+		models.OrderStatusPending, models.OrderStatusConfirmed, models.OrderStatusProcessing)
+	if err != nil {
+		r.logger.Error("Failed to cancel order", "error", err, "order_id", orderID)
+		return fmt.Errorf("failed to cancel order: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperrors.Conflict("order cannot be cancelled in its current state")
+	}
+
+	return nil
+}
+
+// CreateRefund stores a pending refund and reserves the refunded quantities of its items
+func (r *orderRepository) CreateRefund(ctx context.Context, refund *models.Refund) error {
+	ctx = database.WithOperation(ctx, "orders.create_refund")
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		query := `
+			INSERT INTO order_refunds (id, order_id, amount, currency, reason, status)
+			VALUES (:id, :order_id, :amount, :currency, :reason, :status)
+			RETURNING created_at, updated_at`
+
+		stmt, err := tx.PrepareNamedContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer stmt.Close()
+
+		if err := stmt.QueryRowxContext(ctx, refund).Scan(&refund.CreatedAt, &refund.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to create refund: %w", err)
+		}
+
+		for _, item := range refund.Items {
+			item.RefundID = refund.ID
+
+			// The check guards against concurrent refunds of the same units
+			result, err := tx.ExecContext(ctx, `
+				UPDATE order_items SET refunded_quantity = refunded_quantity + $1
+				WHERE id = $2 AND order_id = $3 AND refunded_quantity + $1 <= quantity`,
+				item.Quantity, item.OrderItemID, refund.OrderID)
+			if err != nil {
+				return fmt.Errorf("failed to update refunded quantity: %w", err)
+			}
+
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("failed to get rows affected: %w", err)
+			}
+			if rowsAffected == 0 {
+				return apperrors.Validation("refund quantity exceeds refundable quantity for item %s", item.OrderItemID)
+			}
+
+			_, err = tx.NamedExecContext(ctx, `
+				INSERT INTO order_refund_items (refund_id, order_item_id, quantity, amount)
+				VALUES (:refund_id, :order_item_id, :quantity, :amount)`, item)
+			if err != nil {
+				return fmt.Errorf("failed to create refund item: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// CompleteRefund marks a refund as succeeded and updates the order totals.
+// The order moves to refunded once every item has been fully refunded.
+func (r *orderRepository) CompleteRefund(ctx context.Context, refundID uuid.UUID, providerRefundID string) error {
+	ctx = database.WithOperation(ctx, "orders.complete_refund")
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		var orderID uuid.UUID
+		var amount int64
+		err := tx.QueryRowxContext(ctx, `
+			UPDATE order_refunds SET status = $2, provider_refund_id = $3
+			WHERE id = $1 AND status = $4
+			RETURNING order_id, amount`,
+			refundID, models.RefundStatusSucceeded, providerRefundID, models.RefundStatusPending).Scan(&orderID, &amount)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperrors.NotFound("pending refund not found")
+			}
+			return fmt.Errorf("failed to complete refund: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE orders
+			SET refunded_amount = refunded_amount + $2,
+			    status = CASE
+			        WHEN NOT EXISTS (
+			            SELECT 1 FROM order_items WHERE order_id = $1 AND refunded_quantity < quantity
+			        ) THEN $3 ELSE status END
+			WHERE id = $1`,
+			orderID, amount, models.OrderStatusRefunded)
+		if err != nil {
+			return fmt.Errorf("failed to update order refund totals: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// FailRefund marks a refund as failed and releases the quantities it had reserved
+func (r *orderRepository) FailRefund(ctx context.Context, refund *models.Refund, failureReason string) error {
+	ctx = database.WithOperation(ctx, "orders.fail_refund")
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE order_refunds SET status = $2, failure_reason = $3 WHERE id = $1`,
+			refund.ID, models.RefundStatusFailed, failureReason)
+		if err != nil {
+			return fmt.Errorf("failed to mark refund as failed: %w", err)
+		}
+
+		for _, item := range refund.Items {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE order_items SET refunded_quantity = refunded_quantity - $1 WHERE id = $2`,
+				item.Quantity, item.OrderItemID)
+			if err != nil {
+				return fmt.Errorf("failed to release refunded quantity: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// ListRefunds retrieves all refunds of an order with their items
+func (r *orderRepository) ListRefunds(ctx context.Context, orderID uuid.UUID) ([]*models.Refund, error) {
+	ctx = database.WithOperation(ctx, "orders.list_refunds")
+	refunds := []*models.Refund{}
+	query := `
+		SELECT id, order_id, amount, currency, reason, status, provider_refund_id, failure_reason,
+		       created_at, updated_at
+		FROM order_refunds
+		WHERE order_id = $1
+		ORDER BY created_at DESC`
+
+	err := r.db.SelectContext(ctx, &refunds, query, orderID)
+	if err != nil {
+		r.logger.Error("Failed to list refunds", "error", err, "order_id", orderID)
+		return nil, fmt.Errorf("failed to list refunds: %w", err)
+	}
+
+	items := []*models.RefundItem{}
+	err = r.db.SelectContext(ctx, &items, `
+		SELECT ri.refund_id, ri.order_item_id, ri.quantity, ri.amount
+		FROM order_refund_items ri
+		JOIN order_refunds rf ON rf.id = ri.refund_id
+		WHERE rf.order_id = $1`, orderID)
+	if err != nil {
+		r.logger.Error("Failed to list refund items", "error", err, "order_id", orderID)
+		return nil, fmt.Errorf("failed to list refund items: %w", err)
+	}
+
+	byRefund := make(map[uuid.UUID]*models.Refund, len(refunds))
+	for _, refund := range refunds {
+		byRefund[refund.ID] = refund
+	}
+	for _, item := range items {
+		if refund, ok := byRefund[item.RefundID]; ok {
+			refund.Items = append(refund.Items, item)
+		}
+	}
+
+	return refunds, nil
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+)
+
+const summaryColumns = `order_id, order_number, user_id, customer_email, customer_name, status, currency,
+		       total_amount, refunded_amount, item_count, items, payment_status, payment_provider,
+		       placed_at, source_updated_at, projected_at`
+
+// projectOrders rebuilds the read model rows of the orders selected by the
+// condition appended to it. Rows are only replaced by newer states, so
+// projections racing each other can't leave an older one behind.
+const projectOrders = `
+	INSERT INTO order_summaries (order_id, order_number, user_id, customer_email, customer_name, status,
+	                             currency, total_amount, refunded_amount, item_count, items,
+	                             payment_status, payment_provider, placed_at, source_updated_at)
+	SELECT o.id, o.order_number, o.user_id, u.email,
+	       NULLIF(TRIM(CONCAT_WS(' ', u.first_name, u.last_name)), ''),
+	       o.status, o.currency, o.total_amount, o.refunded_amount,
+	       COALESCE(i.item_count, 0), COALESCE(i.items, '[]'::jsonb),
+	       p.status, p.provider, o.placed_at, GREATEST(o.updated_at, p.updated_at)
+	FROM orders o
+	JOIN users u ON u.id = o.user_id
+	LEFT JOIN LATERAL (
+		SELECT SUM(quantity) AS item_count,
+		       jsonb_agg(jsonb_build_object('product_id', product_id, 'name', name, 'image_url', image_url,
+		                                    'quantity', quantity) ORDER BY created_at, id) AS items
+		FROM order_items
+		WHERE order_id = o.id
+	) i ON true
+	LEFT JOIN LATERAL (
+		SELECT status, provider, MAX(updated_at) OVER () AS updated_at
+		FROM payments
+		WHERE order_id = o.id
+		ORDER BY created_at DESC
+		LIMIT 1
+	) p ON true
+	WHERE `
+
+const projectOrdersConflict = `
+	ON CONFLICT (order_id) DO UPDATE
+	SET order_number = EXCLUDED.order_number, user_id = EXCLUDED.user_id,
+	    customer_email = EXCLUDED.customer_email, customer_name = EXCLUDED.customer_name,
+	    status = EXCLUDED.status, currency = EXCLUDED.currency, total_amount = EXCLUDED.total_amount,
+	    refunded_amount = EXCLUDED.refunded_amount, item_count = EXCLUDED.item_count, items = EXCLUDED.items,
+	    payment_status = EXCLUDED.payment_status, payment_provider = EXCLUDED.payment_provider,
+	    placed_at = EXCLUDED.placed_at, source_updated_at = EXCLUDED.source_updated_at, projected_at = NOW()
+	WHERE order_summaries.source_updated_at <= EXCLUDED.source_updated_at`
+
+// ProjectOrders rebuilds the read model rows of orders from their current
+// state. Unknown orders are skipped.
+func (r *orderRepository) ProjectOrders(ctx context.Context, orderIDs []uuid.UUID) error {
+	query := projectOrders + `o.id = ANY($1)` + projectOrdersConflict
+
+	if _, err := r.db.ExecContext(ctx, query, orderIDs); err != nil {
+		r.logger.Error("Failed to project orders", "error", err, "orders", len(orderIDs))
+		return fmt.Errorf("failed to project orders: %w", err)
+	}
+
+	return nil
+}
+
+// ProjectStaleOrders rebuilds up to limit read model rows that are missing or
+// behind their order or its payments, and returns how many it rebuilt
+func (r *orderRepository) ProjectStaleOrders(ctx context.Context, limit int) (int, error) {
+	query := projectOrders + `o.id IN (
+		SELECT o.id
+		FROM orders o
+		LEFT JOIN order_summaries s ON s.order_id = o.id
+		WHERE s.order_id IS NULL
+		   OR o.updated_at > s.source_updated_at
+		   OR EXISTS (SELECT 1 FROM payments p WHERE p.order_id = o.id AND p.updated_at > s.source_updated_at)
+		LIMIT $1
+	)` + projectOrdersConflict
+
+	result, err := r.db.ExecContext(ctx, query, limit)
+	if err != nil {
+		r.logger.Error("Failed to project stale orders", "error", err)
+		return 0, fmt.Errorf("failed to project stale orders: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// SearchSummaries retrieves a page of orders from the read model, newest first
+func (r *orderRepository) SearchSummaries(ctx context.Context, filter *models.OrderFilter) ([]*models.OrderReadModel, error) {
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+
+	if filter.Query != "" {
+		args = append(args, strings.ToUpper(filter.Query), escapeLike(strings.ToLower(filter.Query))+"%")
+		conditions = append(conditions, fmt.Sprintf(
+			"(order_number = $%d OR LOWER(customer_email) LIKE $%d OR LOWER(customer_name) LIKE $%d)",
+			len(args)-1, len(args), len(args)))
+	}
+
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
+		}
+		args = append(args, statuses)
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("placed_at >= $%d", len(args)))
+	}
+
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("placed_at < $%d", len(args)))
+	}
+
+	if filter.Cursor != nil {
+		args = append(args, filter.Cursor.PlacedAt, filter.Cursor.ID)
+		conditions = append(conditions, fmt.Sprintf("(placed_at, order_id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	args = append(args, filter.Limit)
+	query := `
+		SELECT ` + summaryColumns + `
+		FROM order_summaries
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY placed_at DESC, order_id DESC
+		LIMIT $` + fmt.Sprint(len(args))
+
+	summaries := []*models.OrderReadModel{}
+	err := r.db.SelectContext(ctx, &summaries, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to search order summaries", "error", err)
+		return nil, fmt.Errorf("failed to search order summaries: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
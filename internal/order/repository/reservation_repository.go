@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+)
+
+const reservationColumns = `id, user_id, items, status, expires_at, resolved_at, created_at, updated_at`
+
+// CreateReservation stores a stock reservation about to be made
+func (r *orderRepository) CreateReservation(ctx context.Context, reservation *models.StockReservation) error {
+	query := `
+		INSERT INTO stock_reservations (id, user_id, items, status, expires_at)
+		VALUES (:id, :user_id, :items, :status, :expires_at)
+		RETURNING created_at, updated_at`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, reservation).Scan(&reservation.CreatedAt, &reservation.UpdatedAt)
+	if err != nil {
+		if pgErr, ok := database.PgError(err); ok {
+			switch pgErr.Code {
+			case database.UniqueViolation:
+				return apperrors.Conflict("reservation already exists")
+			case database.ForeignKeyViolation:
+				return apperrors.NotFound("user not found")
+			}
+		}
+		r.logger.Error("Failed to create stock reservation", "error", err, "reservation_id", reservation.ID)
+		return fmt.Errorf("failed to create stock reservation: %w", err)
+	}
+
+	return nil
+}
+
+// PurchaseReservation records that a held reservation became an order. It
+// fails if the reservation expired in the meantime.
+func (r *orderRepository) PurchaseReservation(ctx context.Context, id uuid.UUID) error {
+	resolved, err := r.resolveReservation(ctx, id, models.ReservationStatusHeld, models.ReservationStatusPurchased)
+	if err != nil {
+		return err
+	}
+
+	if !resolved {
+		return apperrors.Conflict("checkout cannot be completed, its stock reservation expired")
+	}
+
+	return nil
+}
+
+// ReleaseReservation records that a held reservation was given back.
+// Reservations no longer held are left as they are.
+func (r *orderRepository) ReleaseReservation(ctx context.Context, id uuid.UUID) error {
+	_, err := r.resolveReservation(ctx, id, models.ReservationStatusHeld, models.ReservationStatusReleased)
+	return err
+}
+
+// ExpireReservation records that an expiring reservation was given back
+func (r *orderRepository) ExpireReservation(ctx context.Context, id uuid.UUID) error {
+	_, err := r.resolveReservation(ctx, id, models.ReservationStatusExpiring, models.ReservationStatusExpired)
+	return err
+}
+
+// resolveReservation moves a reservation from one status to its outcome and
+// reports whether it was in that status
+func (r *orderRepository) resolveReservation(ctx context.Context, id uuid.UUID, from, to models.ReservationStatus) (bool, error) {
+	query := `UPDATE stock_reservations SET status = $2, resolved_at = NOW() WHERE id = $1 AND status = $3`
+
+	result, err := r.db.ExecContext(ctx, query, id, to, from)
+	if err != nil {
+		r.logger.Error("Failed to resolve stock reservation", "error", err, "reservation_id", id, "status", to)
+		return false, fmt.Errorf("failed to resolve stock reservation: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ClaimExpiredReservations claims up to limit held reservations past their
+// expiry, along with expiring ones whose release stalled for lease. Claimed
+// reservations can no longer be purchased.
+func (r *orderRepository) ClaimExpiredReservations(ctx context.Context, lease time.Duration, limit int) ([]*models.StockReservation, error) {
+	reservations := []*models.StockReservation{}
+	query := `
+		UPDATE stock_reservations
+		SET status = $1, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM stock_reservations
+			WHERE (status = $2 AND expires_at < NOW())
+			   OR (status = $1 AND updated_at < $3)
+			ORDER BY expires_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + reservationColumns
+
+	err := r.db.SelectContext(ctx, &reservations, query, models.ReservationStatusExpiring, models.ReservationStatusHeld,
+		time.Now().Add(-lease), limit)
+	if err != nil {
+		r.logger.Error("Failed to claim expired stock reservations", "error", err)
+		return nil, fmt.Errorf("failed to claim expired stock reservations: %w", err)
+	}
+
+	return reservations, nil
+}
+
+// GetReservationStats counts the outcomes of the reservations resolved since a time
+func (r *orderRepository) GetReservationStats(ctx context.Context, since time.Time) (*models.ReservationStats, error) {
+	stats := &models.ReservationStats{}
+	query := `
+		SELECT COUNT(*) FILTER (WHERE status = $2) AS purchased,
+		       COUNT(*) FILTER (WHERE status = $3) AS released,
+		       COUNT(*) FILTER (WHERE status = $4) AS expired
+		FROM stock_reservations
+		WHERE resolved_at >= $1`
+
+	err := r.db.GetContext(ctx, stats, query, since, models.ReservationStatusPurchased,
+		models.ReservationStatusReleased, models.ReservationStatusExpired)
+	if err != nil {
+		r.logger.Error("Failed to get stock reservation stats", "error", err)
+		return nil, fmt.Errorf("failed to get stock reservation stats: %w", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+)
+
+const sagaColumns = `id, user_id, items, status, step, payment_id, failure_reason, attempts, created_at, updated_at`
+
+// CreateSaga stores a new checkout saga
+func (r *orderRepository) CreateSaga(ctx context.Context, saga *models.CheckoutSaga) error {
+	query := `
+		INSERT INTO checkout_sagas (id, user_id, items, status, step)
+		VALUES (:id, :user_id, :items, :status, :step)
+		RETURNING attempts, created_at, updated_at`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, saga).Scan(&saga.Attempts, &saga.CreatedAt, &saga.UpdatedAt)
+	if err != nil {
+		if pgErr, ok := database.PgError(err); ok {
+			switch pgErr.Code {
+			case database.UniqueViolation:
+				return apperrors.Conflict("checkout already exists")
+			case database.ForeignKeyViolation:
+				return apperrors.NotFound("user not found")
+			}
+		}
+		r.logger.Error("Failed to create checkout saga", "error", err, "saga_id", saga.ID)
+		return fmt.Errorf("failed to create checkout saga: %w", err)
+	}
+
+	return nil
+}
+
+// GetSaga retrieves a checkout saga by ID
+func (r *orderRepository) GetSaga(ctx context.Context, id uuid.UUID) (*models.CheckoutSaga, error) {
+	saga := &models.CheckoutSaga{}
+	query := `SELECT ` + sagaColumns + ` FROM checkout_sagas WHERE id = $1`
+
+	err := r.db.GetContext(ctx, saga, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("checkout not found")
+		}
+		r.logger.Error("Failed to get checkout saga", "error", err, "saga_id", id)
+		return nil, fmt.Errorf("failed to get checkout saga: %w", err)
+	}
+
+	return saga, nil
+}
+
+// AdvanceSaga records that a running saga starts its next step. It fails if
+// the saga stopped running in the meantime, e.g. because the recovery sweep
+// took it over.
+func (r *orderRepository) AdvanceSaga(ctx context.Context, id uuid.UUID, step models.SagaStep) error {
+	query := `UPDATE checkout_sagas SET step = $2 WHERE id = $1 AND status = $3`
+
+	return r.updateRunningSaga(ctx, id, query, id, step, models.SagaStatusRunning)
+}
+
+// CompleteSaga marks a running saga completed
+func (r *orderRepository) CompleteSaga(ctx context.Context, id uuid.UUID, paymentID uuid.UUID) error {
+	query := `UPDATE checkout_sagas SET status = $2, payment_id = $3 WHERE id = $1 AND status = $4`
+
+	return r.updateRunningSaga(ctx, id, query, id, models.SagaStatusCompleted, paymentID, models.SagaStatusRunning)
+}
+
+func (r *orderRepository) updateRunningSaga(ctx context.Context, id uuid.UUID, query string, args ...interface{}) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to update checkout saga", "error", err, "saga_id", id)
+		return fmt.Errorf("failed to update checkout saga: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperrors.Conflict("checkout cannot be continued, it is no longer running")
+	}
+
+	return nil
+}
+
+// StartCompensation marks an unfinished saga as being undone. The first
+// failure reason recorded is kept.
+func (r *orderRepository) StartCompensation(ctx context.Context, id uuid.UUID, reason string) error {
+	query := `
+		UPDATE checkout_sagas
+		SET status = $2, failure_reason = COALESCE(failure_reason, $3)
+		WHERE id = $1 AND status IN ($4, $2)`
+
+	_, err := r.db.ExecContext(ctx, query, id, models.SagaStatusCompensating, reason, models.SagaStatusRunning)
+	if err != nil {
+		r.logger.Error("Failed to start checkout saga compensation", "error", err, "saga_id", id)
+		return fmt.Errorf("failed to start checkout saga compensation: %w", err)
+	}
+
+	return nil
+}
+
+// FinishCompensation records the outcome of undoing a saga: compensated, or
+// failed when it could not be undone
+func (r *orderRepository) FinishCompensation(ctx context.Context, id uuid.UUID, status models.SagaStatus) error {
+	query := `UPDATE checkout_sagas SET status = $2 WHERE id = $1 AND status = $3`
+
+	_, err := r.db.ExecContext(ctx, query, id, status, models.SagaStatusCompensating)
+	if err != nil {
+		r.logger.Error("Failed to finish checkout saga compensation", "error", err, "saga_id", id)
+		return fmt.Errorf("failed to finish checkout saga compensation: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimStuckSagas claims up to limit unfinished sagas that haven't moved for
+// stuckAfter. Claiming counts an attempt and touches updated_at, so other
+// instances leave the sagas alone until they are stuck again.
+func (r *orderRepository) ClaimStuckSagas(ctx context.Context, stuckAfter time.Duration, limit int) ([]*models.CheckoutSaga, error) {
+	sagas := []*models.CheckoutSaga{}
+	query := `
+		UPDATE checkout_sagas
+		SET attempts = attempts + 1, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM checkout_sagas
+			WHERE status IN ($1, $2) AND updated_at < $3
+			ORDER BY updated_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + sagaColumns
+
+	err := r.db.SelectContext(ctx, &sagas, query, models.SagaStatusRunning, models.SagaStatusCompensating,
+		time.Now().Add(-stuckAfter), limit)
+	if err != nil {
+		r.logger.Error("Failed to claim stuck checkout sagas", "error", err)
+		return nil, fmt.Errorf("failed to claim stuck checkout sagas: %w", err)
+	}
+
+	return sagas, nil
+}
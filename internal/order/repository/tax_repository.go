@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+)
+
+// GetTaxExemption retrieves the tax exemption of a customer
+func (r *orderRepository) GetTaxExemption(ctx context.Context, userID uuid.UUID) (*models.TaxExemption, error) {
+	exemption := &models.TaxExemption{}
+	query := `
+		SELECT user_id, certificate_number, expires_at, created_by, created_at, updated_at
+		FROM tax_exemptions
+		WHERE user_id = $1`
+
+	err := r.db.GetContext(ctx, exemption, query, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("tax exemption not found")
+		}
+		r.logger.Error("Failed to get tax exemption", "error", err, "user_id", userID)
+		return nil, fmt.Errorf("failed to get tax exemption: %w", err)
+	}
+
+	return exemption, nil
+}
+
+// UpsertTaxExemption grants a tax exemption, replacing the customer's previous one
+func (r *orderRepository) UpsertTaxExemption(ctx context.Context, exemption *models.TaxExemption) error {
+	query := `
+		INSERT INTO tax_exemptions (user_id, certificate_number, expires_at, created_by)
+		VALUES (:user_id, :certificate_number, :expires_at, :created_by)
+		ON CONFLICT (user_id) DO UPDATE
+		SET certificate_number = EXCLUDED.certificate_number,
+		    expires_at = EXCLUDED.expires_at,
+		    created_by = EXCLUDED.created_by
+		RETURNING created_at, updated_at`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to prepare upsert tax exemption statement", "error", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, exemption).Scan(&exemption.CreatedAt, &exemption.UpdatedAt)
+	if err != nil {
+		if database.IsForeignKeyViolation(err) {
+			return apperrors.NotFound("user not found")
+		}
+		r.logger.Error("Failed to upsert tax exemption", "error", err, "user_id", exemption.UserID)
+		return fmt.Errorf("failed to save tax exemption: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteTaxExemption revokes a customer's tax exemption
+func (r *orderRepository) DeleteTaxExemption(ctx context.Context, userID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM tax_exemptions WHERE user_id = $1`, userID)
+	if err != nil {
+		r.logger.Error("Failed to delete tax exemption", "error", err, "user_id", userID)
+		return fmt.Errorf("failed to delete tax exemption: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete tax exemption: %w", err)
+	}
+	if rows == 0 {
+		return apperrors.NotFound("tax exemption not found")
+	}
+
+	return nil
+}
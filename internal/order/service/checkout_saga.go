@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/clients"
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+)
+
+// reasonCheckoutTimedOut is recorded on checkouts rolled back by the recovery sweep
+const reasonCheckoutTimedOut = "checkout timed out"
+
+// PlaceOrder checks out a cart as a saga: it reserves stock, creates the
+// order and authorizes its payment, recording each step before starting it.
+// If a step fails, the steps started so far are undone, last first, and the
+// step's error is returned. Undos that fail are retried by the recovery sweep.
+// A repeated request for the same checkout returns the order once the
+// checkout completed.
+func (s *orderService) PlaceOrder(ctx context.Context, userID uuid.UUID, req *models.PlaceOrderRequest) (*models.Order, error) {
+	items := make([]models.CheckoutItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, item.CheckoutItem)
+	}
+	priced, _, err := s.priceItems(ctx, userID, strings.ToUpper(req.Currency), items)
+	if err != nil {
+		return nil, err
+	}
+
+	saga := &models.CheckoutSaga{
+		ID:     req.CheckoutID,
+		UserID: userID,
+		Items:  priced,
+		Status: models.SagaStatusRunning,
+		Step:   models.SagaStepReserveInventory,
+	}
+	if err := s.repo.CreateSaga(ctx, saga); err != nil {
+		if errors.Is(err, apperrors.ErrConflict) {
+			return s.repeatedCheckout(ctx, userID, req.CheckoutID)
+		}
+		return nil, err
+	}
+
+	payment, err := s.runCheckout(ctx, saga, req)
+	if err != nil {
+		// The customer may give up on the request, but the undo must go on
+		if undoErr := s.compensate(context.WithoutCancel(ctx), saga, err.Error()); undoErr != nil {
+			s.logger.Error("Failed to roll back checkout, leaving it to the recovery sweep",
+				"error", undoErr, "saga_id", saga.ID, "step", saga.Step)
+		}
+		return nil, err
+	}
+
+	if err := s.repo.CompleteSaga(ctx, saga.ID, payment.ID); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Checkout completed", "saga_id", saga.ID, "user_id", userID, "payment_id", payment.ID)
+	return s.GetOrder(ctx, userID, saga.ID)
+}
+
+// repeatedCheckout answers a checkout request made before
+func (s *orderService) repeatedCheckout(ctx context.Context, userID uuid.UUID, checkoutID uuid.UUID) (*models.Order, error) {
+	saga, err := s.repo.GetSaga(ctx, checkoutID)
+	if err != nil {
+		return nil, err
+	}
+	if saga.UserID != userID {
+		return nil, apperrors.Conflict("checkout already exists")
+	}
+
+	switch saga.Status {
+	case models.SagaStatusCompleted:
+		return s.GetOrder(ctx, userID, saga.ID)
+	case models.SagaStatusRunning, models.SagaStatusCompensating:
+		return nil, apperrors.Conflict("checkout is already in progress")
+	default:
+		reason := ""
+		if saga.FailureReason != nil {
+			reason = *saga.FailureReason
+		}
+		return nil, apperrors.Conflict("checkout already failed: %s", reason)
+	}
+}
+
+// runCheckout runs the steps of a new saga and returns the authorized payment
+func (s *orderService) runCheckout(ctx context.Context, saga *models.CheckoutSaga, req *models.PlaceOrderRequest) (*clients.Payment, error) {
+	if err := s.reserveStock(ctx, saga); err != nil {
+		return nil, err
+	}
+
+	if err := s.advanceSaga(ctx, saga, models.SagaStepCreateOrder); err != nil {
+		return nil, err
+	}
+	orderReq := &models.CreateOrderRequest{
+		ID:              saga.ID,
+		UserID:          saga.UserID,
+		Currency:        req.Currency,
+		Items:           make([]models.CreateOrderItem, 0, len(req.Items)),
+		ShippingAddress: req.ShippingAddress,
+		ShippingAmount:  req.ShippingAmount,
+		DiscountAmount:  req.DiscountAmount,
+		Notes:           req.Notes,
+	}
+	for i, item := range saga.Items {
+		orderReq.Items = append(orderReq.Items, models.CreateOrderItem{CheckoutItem: item, Name: req.Items[i].Name})
+	}
+	if _, err := s.CreateOrder(ctx, orderReq); err != nil {
+		return nil, err
+	}
+
+	if err := s.advanceSaga(ctx, saga, models.SagaStepAuthorizePayment); err != nil {
+		return nil, err
+	}
+	payment, err := s.payments.Authorize(ctx, &clients.AuthorizePaymentRequest{
+		UserID:            saga.UserID,
+		OrderID:           saga.ID,
+		PaymentMethod:     req.PaymentMethod,
+		GiftCardCodes:     req.GiftCardCodes,
+		PaymentMethodType: req.PaymentMethodType,
+		Provider:          req.Provider,
+		DeviceID:          req.DeviceID,
+		ClientIP:          req.IPAddress,
+		IdempotencyKey:    "checkout-" + saga.ID.String(),
+	})
+	if err != nil {
+		var declinedErr *clients.DeclinedError
+		if errors.As(err, &declinedErr) {
+			return nil, apperrors.PaymentRequired("payment was not authorized: %s", declinedErr.Message).Wrap(err)
+		}
+		return nil, err
+	}
+
+	// The stock now belongs to the order, unless it expired while checking out
+	if err := s.repo.PurchaseReservation(ctx, saga.ID); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// advanceSaga records that the saga starts step
+func (s *orderService) advanceSaga(ctx context.Context, saga *models.CheckoutSaga, step models.SagaStep) error {
+	if err := s.repo.AdvanceSaga(ctx, saga.ID, step); err != nil {
+		return err
+	}
+	saga.Step = step
+	return nil
+}
+
+// RecoverCheckouts rolls back a batch of checkouts that stopped moving, e.g.
+// because the instance running them died, and retries rollbacks that failed.
+// Stuck checkouts are rolled back rather than finished, since the customer
+// was told the checkout failed or gave up waiting for it. Returns how many
+// checkouts were claimed.
+func (s *orderService) RecoverCheckouts(ctx context.Context) (int, error) {
+	cfg := s.config.Services.Order.Saga
+	sagas, err := s.repo.ClaimStuckSagas(ctx, cfg.Timeout, cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, saga := range sagas {
+		err := s.compensate(ctx, saga, reasonCheckoutTimedOut)
+		if err == nil {
+			continue
+		}
+		if saga.Attempts < cfg.RetryAttempts {
+			s.logger.Warn("Failed to roll back checkout, will retry", "error", err, "saga_id", saga.ID, "attempts", saga.Attempts)
+			continue
+		}
+
+		s.logger.Error("Giving up rolling back checkout, it needs manual attention",
+			"error", err, "saga_id", saga.ID, "step", saga.Step, "attempts", saga.Attempts)
+		if err := s.repo.FinishCompensation(ctx, saga.ID, models.SagaStatusFailed); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(sagas), nil
+}
+
+// compensate undoes the steps a saga started, last first. Every undo is safe
+// to repeat, so a compensation that stopped part-way can simply run again.
+func (s *orderService) compensate(ctx context.Context, saga *models.CheckoutSaga, reason string) error {
+	if err := s.repo.StartCompensation(ctx, saga.ID, reason); err != nil {
+		return err
+	}
+
+	for i := len(models.SagaSteps) - 1; i >= 0; i-- {
+		step := models.SagaSteps[i]
+		if !stepStarted(saga, step) {
+			continue
+		}
+		if err := s.undoStep(ctx, saga, step, reason); err != nil {
+			return fmt.Errorf("failed to undo %s: %w", step, err)
+		}
+	}
+
+	if err := s.repo.FinishCompensation(ctx, saga.ID, models.SagaStatusCompensated); err != nil {
+		return err
+	}
+
+	s.logger.Info("Checkout rolled back", "saga_id", saga.ID, "step", saga.Step, "reason", reason)
+	return nil
+}
+
+// stepStarted reports whether the saga got as far as step
+func stepStarted(saga *models.CheckoutSaga, step models.SagaStep) bool {
+	for _, started := range models.SagaSteps {
+		if started == step {
+			return true
+		}
+		if started == saga.Step {
+			return false
+		}
+	}
+	return false
+}
+
+// undoStep undoes one step of a saga. Steps that never took effect are
+// recognized and skipped.
+func (s *orderService) undoStep(ctx context.Context, saga *models.CheckoutSaga, step models.SagaStep, reason string) error {
+	switch step {
+	case models.SagaStepAuthorizePayment:
+		order, err := s.repo.GetByID(ctx, saga.ID)
+		if err != nil {
+			if errors.Is(err, apperrors.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		// Refunding an authorized payment voids it
+		_, err = s.payments.Refund(ctx, &clients.RefundPaymentRequest{
+			OrderID:        saga.ID,
+			RefundID:       uuid.NewSHA1(saga.ID, []byte(step)),
+			Amount:         order.TotalAmount,
+			Currency:       order.Currency,
+			Reason:         reason,
+			IdempotencyKey: "checkout-void-" + saga.ID.String(),
+		})
+		if errors.Is(err, clients.ErrPaymentNotFound) {
+			return nil
+		}
+		return err
+	case models.SagaStepCreateOrder:
+		order, err := s.repo.GetByID(ctx, saga.ID)
+		if err != nil {
+			if errors.Is(err, apperrors.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+		if order.Status == models.OrderStatusCancelled {
+			return nil
+		}
+
+		if err := s.repo.Cancel(ctx, order.ID, &reason); err != nil {
+			return err
+		}
+		s.publishEvent(ctx, &models.OrderEvent{
+			Type:       models.EventOrderCancelled,
+			OrderID:    order.ID,
+			UserID:     order.UserID,
+			Status:     string(models.OrderStatusCancelled),
+			Reason:     reason,
+			OccurredAt: time.Now().UTC(),
+		})
+		return nil
+	case models.SagaStepReserveInventory:
+		return s.releaseReservation(ctx, saga)
+	default:
+		return fmt.Errorf("unknown checkout step %s", step)
+	}
+}
+
+// stockItems returns the stock held for the items of a checkout
+func stockItems(items models.ReservedItems) []*clients.StockItem {
+	stock := make([]*clients.StockItem, 0, len(items))
+	for _, item := range items {
+		stock = append(stock, &clients.StockItem{
+			ProductID: item.ProductID,
+			SKU:       item.SKU,
+			Quantity:  item.Quantity,
+		})
+	}
+	return stock
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/clients"
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/internal/order/tax"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+)
+
+// CalculateTotals prices a cart: it looks up the customer's prices, spreads
+// the order discount over the lines, calculates tax for the shipping address
+// and adds everything up.
+// Tax-exempt customers pay no tax; where prices include tax, the included
+// tax is deducted for them instead.
+func (s *orderService) CalculateTotals(ctx context.Context, userID uuid.UUID, req *models.CheckoutTotalsRequest) (*models.CheckoutTotals, error) {
+	items, listPrices, err := s.priceItems(ctx, userID, strings.ToUpper(req.Currency), req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	priced := *req
+	priced.Items = items
+	return s.calculateTotals(ctx, userID, &priced, listPrices)
+}
+
+// CreateOrder places a pending order at the prices of the request, with tax
+// calculated as at checkout. A request repeated with the same order ID
+// returns the order placed by the first one.
+func (s *orderService) CreateOrder(ctx context.Context, req *models.CreateOrderRequest) (*models.Order, error) {
+	existing, err := s.repo.GetByID(ctx, req.ID)
+	if err == nil {
+		if existing.UserID != req.UserID {
+			return nil, apperrors.Conflict("order already exists")
+		}
+		if existing.Items, err = s.repo.GetItems(ctx, existing.ID); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+	if !errors.Is(err, apperrors.ErrNotFound) {
+		return nil, err
+	}
+
+	checkout := &models.CheckoutTotalsRequest{
+		Currency:        req.Currency,
+		Items:           make([]models.CheckoutItem, 0, len(req.Items)),
+		ShippingAddress: req.ShippingAddress,
+		ShippingAmount:  req.ShippingAmount,
+		DiscountAmount:  req.DiscountAmount,
+	}
+	for _, item := range req.Items {
+		checkout.Items = append(checkout.Items, item.CheckoutItem)
+	}
+
+	totals, err := s.calculateTotals(ctx, req.UserID, checkout, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	order := &models.Order{
+		ID:              req.ID,
+		OrderNumber:     newOrderNumber(req.ID, time.Now()),
+		UserID:          req.UserID,
+		Status:          models.OrderStatusPending,
+		Currency:        totals.Currency,
+		SubtotalAmount:  totals.SubtotalAmount,
+		TaxAmount:       totals.TaxAmount,
+		ShippingAmount:  totals.ShippingAmount,
+		DiscountAmount:  totals.DiscountAmount,
+		TotalAmount:     totals.TotalAmount,
+		ShippingAddress: req.ShippingAddress,
+		Notes:           req.Notes,
+		Items:           make([]*models.OrderItem, 0, len(totals.Lines)),
+	}
+	for i, line := range totals.Lines {
+		order.Items = append(order.Items, &models.OrderItem{
+			ID:         uuid.New(),
+			OrderID:    order.ID,
+			ProductID:  line.ProductID,
+			SKU:        line.SKU,
+			Name:       req.Items[i].Name,
+			Quantity:   line.Quantity,
+			UnitPrice:  line.UnitPrice,
+			TotalPrice: line.TotalPrice,
+		})
+	}
+
+	if err := s.repo.Create(ctx, order); err != nil {
+		return nil, err
+	}
+
+	s.publishEvent(ctx, &models.OrderEvent{
+		Type:       models.EventOrderCreated,
+		OrderID:    order.ID,
+		UserID:     order.UserID,
+		Status:     string(order.Status),
+		Amount:     order.TotalAmount,
+		Currency:   order.Currency,
+		OccurredAt: order.PlacedAt,
+	})
+
+	s.logger.Info("Order created", "order_id", order.ID, "order_number", order.OrderNumber, "user_id", order.UserID)
+	return order, nil
+}
+
+// calculateTotals adds up a cart whose unit prices are final. listPrices
+// holds the list prices of lines on sale.
+func (s *orderService) calculateTotals(ctx context.Context, userID uuid.UUID, req *models.CheckoutTotalsRequest, listPrices map[string]int64) (*models.CheckoutTotals, error) {
+	address := req.ShippingAddress
+	if len(address.Country) != 2 {
+		return nil, apperrors.Validation("invalid shipping address: country must be a two-letter code")
+	}
+
+	totals := &models.CheckoutTotals{
+		Currency:       strings.ToUpper(req.Currency),
+		DiscountAmount: req.DiscountAmount,
+		ShippingAmount: req.ShippingAmount,
+		Lines:          make([]*models.CheckoutLineTotal, 0, len(req.Items)),
+	}
+	for _, item := range req.Items {
+		line := &models.CheckoutLineTotal{
+			ProductID:  item.ProductID,
+			SKU:        item.SKU,
+			Quantity:   item.Quantity,
+			UnitPrice:  item.UnitPrice,
+			ListPrice:  listPrices[item.SKU],
+			TotalPrice: item.UnitPrice * int64(item.Quantity),
+		}
+		totals.SubtotalAmount += line.TotalPrice
+		totals.Lines = append(totals.Lines, line)
+	}
+
+	if req.DiscountAmount > totals.SubtotalAmount {
+		return nil, apperrors.Validation("invalid discount: exceeds the subtotal")
+	}
+	allocateDiscount(totals.Lines, req.DiscountAmount, totals.SubtotalAmount)
+
+	exempt, err := s.isTaxExempt(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	taxReq := &tax.Request{
+		Currency:       totals.Currency,
+		To:             taxAddress(address),
+		Lines:          make([]*tax.Line, 0, len(req.Items)),
+		ShippingAmount: req.ShippingAmount,
+	}
+	for i, item := range req.Items {
+		taxReq.Lines = append(taxReq.Lines, &tax.Line{
+			ID:        strconv.Itoa(i),
+			TaxCode:   item.TaxCode,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Discount:  totals.Lines[i].DiscountAmount,
+		})
+	}
+
+	result, err := s.taxes.Calculate(ctx, taxReq)
+	if err != nil {
+		var providerErr *tax.ProviderError
+		if errors.As(err, &providerErr) {
+			return nil, apperrors.Upstream("tax provider rejected the request: %s", providerErr.Message).Wrap(err)
+		}
+		return nil, apperrors.Upstream("tax calculation is unavailable").Wrap(err)
+	}
+
+	totals.PricesIncludeTax = result.Inclusive
+	totals.TaxExempt = exempt
+	totals.TotalAmount = totals.SubtotalAmount - totals.DiscountAmount + totals.ShippingAmount
+
+	if exempt {
+		// Exempt customers pay the net price where prices include tax
+		if result.Inclusive {
+			totals.TotalAmount -= result.TotalTax
+		}
+	} else {
+		totals.TaxAmount = result.TotalTax
+		if !result.Inclusive {
+			totals.TotalAmount += result.TotalTax
+		}
+		for i, line := range result.Lines {
+			if i < len(totals.Lines) {
+				totals.Lines[i].TaxAmount = line.TaxAmount
+			}
+		}
+	}
+
+	return totals, nil
+}
+
+// priceItems replaces the unit prices of the request with the customer's
+// prices from the pricing service. It also returns the list prices of SKUs
+// on sale. Without a pricing service the request prices are used as they are.
+func (s *orderService) priceItems(ctx context.Context, userID uuid.UUID, currency string, items []models.CheckoutItem) ([]models.CheckoutItem, map[string]int64, error) {
+	if s.pricing == nil {
+		return items, nil, nil
+	}
+
+	skus := make([]string, 0, len(items))
+	for _, item := range items {
+		skus = append(skus, item.SKU)
+	}
+
+	resolved, err := s.pricing.ResolvePrices(ctx, &clients.ResolvePricesRequest{
+		UserID:   userID,
+		Currency: currency,
+		SKUs:     skus,
+	})
+	if err != nil {
+		return nil, nil, apperrors.Upstream("pricing is unavailable").Wrap(err)
+	}
+	if len(resolved.Missing) > 0 {
+		return nil, nil, apperrors.Validation("invalid item: no %s price for %s", currency, strings.Join(resolved.Missing, ", "))
+	}
+
+	unitPrices := make(map[string]int64, len(resolved.Prices))
+	listPrices := make(map[string]int64)
+	for _, price := range resolved.Prices {
+		unitPrices[price.SKU] = price.UnitPrice
+		if price.OnSale {
+			listPrices[price.SKU] = price.ListPrice
+		}
+	}
+
+	priced := make([]models.CheckoutItem, len(items))
+	for i, item := range items {
+		item.UnitPrice = unitPrices[item.SKU]
+		priced[i] = item
+	}
+
+	return priced, listPrices, nil
+}
+
+// GetTaxExemption returns a customer's tax exemption
+func (s *orderService) GetTaxExemption(ctx context.Context, userID uuid.UUID) (*models.TaxExemption, error) {
+	return s.repo.GetTaxExemption(ctx, userID)
+}
+
+// SetTaxExemption grants or renews a customer's tax exemption
+func (s *orderService) SetTaxExemption(ctx context.Context, adminID uuid.UUID, userID uuid.UUID, req *models.TaxExemptionRequest) (*models.TaxExemption, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, apperrors.Validation("invalid expiry: must be in the future")
+	}
+
+	exemption := &models.TaxExemption{
+		UserID:            userID,
+		CertificateNumber: strings.TrimSpace(req.CertificateNumber),
+		ExpiresAt:         req.ExpiresAt,
+		CreatedBy:         adminID,
+	}
+
+	if err := s.repo.UpsertTaxExemption(ctx, exemption); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Tax exemption granted", "user_id", userID, "admin_id", adminID)
+	return exemption, nil
+}
+
+// DeleteTaxExemption revokes a customer's tax exemption
+func (s *orderService) DeleteTaxExemption(ctx context.Context, userID uuid.UUID) error {
+	if err := s.repo.DeleteTaxExemption(ctx, userID); err != nil {
+		return err
+	}
+
+	s.logger.Info("Tax exemption revoked", "user_id", userID)
+	return nil
+}
+
+// isTaxExempt reports whether a customer holds an unexpired tax exemption
+func (s *orderService) isTaxExempt(ctx context.Context, userID uuid.UUID) (bool, error) {
+	exemption, err := s.repo.GetTaxExemption(ctx, userID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return exemption.IsActive(time.Now()), nil
+}
+
+// newOrderNumber derives a customer-facing order number from the order ID
+func newOrderNumber(id uuid.UUID, placedAt time.Time) string {
+	return fmt.Sprintf("ORD-%s-%s", placedAt.UTC().Format("20060102"), strings.ToUpper(strings.ReplaceAll(id.String(), "-", "")[:8]))
+}
+
+// allocateDiscount spreads an order discount over the lines in proportion to
+// their price. The rounding remainder goes to the most expensive line.
+func allocateDiscount(lines []*models.CheckoutLineTotal, discount, subtotal int64) {
+	if discount == 0 || subtotal == 0 {
+		return
+	}
+
+	largest := 0
+	allocated := int64(0)
+	for i, line := range lines {
+		line.DiscountAmount = discount * line.TotalPrice / subtotal
+		allocated += line.DiscountAmount
+		if line.TotalPrice > lines[largest].TotalPrice {
+			largest = i
+		}
+	}
+	lines[largest].DiscountAmount += discount - allocated
+}
+
+// taxAddress converts a shipping address to a tax destination
+func taxAddress(address *models.Address) tax.Address {
+	to := tax.Address{
+		Country:    strings.ToUpper(address.Country),
+		City:       address.City,
+		PostalCode: address.PostalCode,
+	}
+	if address.State != nil {
+		to.State = strings.ToUpper(*address.State)
+	}
+	return to
+}
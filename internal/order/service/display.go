@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/clients"
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+)
+
+// ViewOrder returns one of the user's orders with its amounts converted to the
+// requested or preferred display currency
+func (s *orderService) ViewOrder(ctx context.Context, userID uuid.UUID, orderID uuid.UUID, req *models.GetOrderRequest) (*models.Order, error) {
+	order, err := s.GetOrder(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := []int64{
+		order.SubtotalAmount, order.TaxAmount, order.ShippingAmount,
+		order.DiscountAmount, order.TotalAmount, order.RefundedAmount,
+	}
+	display, err := s.displayAmounts(ctx, userID, req.Currency, order.Currency, amounts)
+	if err != nil {
+		return nil, err
+	}
+
+	if display != nil && display.Currency != order.Currency {
+		order.Display = &models.DisplayAmounts{
+			Currency:       display.Currency,
+			Rate:           display.Amounts[0].Rate,
+			SubtotalAmount: display.Amounts[0].Converted,
+			TaxAmount:      display.Amounts[1].Converted,
+			ShippingAmount: display.Amounts[2].Converted,
+			DiscountAmount: display.Amounts[3].Converted,
+			TotalAmount:    display.Amounts[4].Converted,
+			RefundedAmount: display.Amounts[5].Converted,
+			RatesAsOf:      display.RatesAsOf,
+		}
+	}
+
+	return order, nil
+}
+
+// addDisplayTotals converts the totals of listed orders to the display currency
+func (s *orderService) addDisplayTotals(ctx context.Context, userID uuid.UUID, currency string, orders []*models.OrderSummary) error {
+	if s.currency == nil || len(orders) == 0 {
+		return nil
+	}
+
+	req := &clients.DisplayAmountsRequest{
+		UserID:   userID,
+		Currency: currency,
+		Amounts:  make([]clients.Money, len(orders)),
+	}
+	for i, order := range orders {
+		req.Amounts[i] = clients.Money{Currency: order.Currency, Amount: order.TotalAmount}
+	}
+
+	display, err := s.convert(ctx, req)
+	if err != nil || display == nil {
+		return err
+	}
+
+	for i, order := range orders {
+		order.Display = &models.DisplayTotal{
+			Currency:    display.Currency,
+			TotalAmount: display.Amounts[i].Converted,
+		}
+	}
+
+	return nil
+}
+
+// displayAmounts converts amounts of one currency to the display currency.
+// It returns nil when there is nothing to display.
+func (s *orderService) displayAmounts(ctx context.Context, userID uuid.UUID, currency, from string, amounts []int64) (*clients.DisplayAmountsResponse, error) {
+	if s.currency == nil {
+		return nil, nil
+	}
+
+	req := &clients.DisplayAmountsRequest{
+		UserID:   userID,
+		Currency: currency,
+		Amounts:  make([]clients.Money, len(amounts)),
+	}
+	for i, amount := range amounts {
+		req.Amounts[i] = clients.Money{Currency: from, Amount: amount}
+	}
+
+	return s.convert(ctx, req)
+}
+
+// convert calls the currency service. Only an invalid requested currency is
+// an error; otherwise orders are shown without display amounts when
+// conversion isn't possible.
+func (s *orderService) convert(ctx context.Context, req *clients.DisplayAmountsRequest) (*clients.DisplayAmountsResponse, error) {
+	display, err := s.currency.DisplayAmounts(ctx, req)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrValidation) && req.Currency != "" {
+			return nil, err
+		}
+		s.logger.Error("Failed to convert order amounts", "error", err, "user_id", req.UserID)
+		return nil, nil
+	}
+
+	if display.Currency == "" || len(display.Amounts) != len(req.Amounts) {
+		return nil, nil
+	}
+	return display, nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/invoice"
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// GetInvoice returns a download link for the invoice of a completed order.
+// The invoice is issued on first request: it takes the next number of the
+// legal entity selling to the shipping country and its PDF is stored.
+func (s *orderService) GetInvoice(ctx context.Context, userID uuid.UUID, orderID uuid.UUID) (*models.InvoiceResponse, error) {
+	order, err := s.GetOrder(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	inv, err := s.repo.GetInvoiceByOrderID(ctx, orderID)
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		return nil, err
+	}
+
+	// Orders refunded after delivery keep the invoice issued for them
+	if inv == nil {
+		if order.Status != models.OrderStatusDelivered {
+			return nil, apperrors.Conflict("invoice cannot be issued: order is not completed")
+		}
+
+		inv, err = s.issueInvoice(ctx, order)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// An earlier attempt allocated the number but didn't store the PDF
+	if inv.Status == models.InvoiceStatusPending {
+		if err := s.storeInvoice(ctx, inv, order); err != nil {
+			return nil, err
+		}
+	}
+
+	ttl := s.config.Services.Order.Invoices.URLTTL
+	url, err := s.store.SignedURL(ctx, *inv.StorageKey, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign invoice url: %w", err)
+	}
+
+	return &models.InvoiceResponse{
+		InvoiceNumber: inv.InvoiceNumber,
+		IssuedAt:      inv.IssuedAt,
+		URL:           url,
+		ExpiresAt:     time.Now().Add(ttl),
+	}, nil
+}
+
+// issueInvoice allocates the invoice number of an order
+func (s *orderService) issueInvoice(ctx context.Context, order *models.Order) (*models.Invoice, error) {
+	entity, err := s.legalEntity(order)
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &models.Invoice{
+		ID:          uuid.New(),
+		OrderID:     order.ID,
+		LegalEntity: entity.Code,
+		Currency:    order.Currency,
+		TotalAmount: order.TotalAmount,
+		Status:      models.InvoiceStatusPending,
+	}
+
+	inv, err = s.repo.CreateInvoice(ctx, inv, func(n int64) string {
+		return fmt.Sprintf("%s%06d", entity.NumberPrefix, n)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Invoice issued", "order_id", order.ID, "invoice_number", inv.InvoiceNumber)
+	return inv, nil
+}
+
+// storeInvoice renders the invoice PDF, uploads it and marks the invoice issued
+func (s *orderService) storeInvoice(ctx context.Context, inv *models.Invoice, order *models.Order) error {
+	entity, ok := s.findLegalEntity(inv.LegalEntity)
+	if !ok {
+		return fmt.Errorf("legal entity %s is not configured", inv.LegalEntity)
+	}
+
+	key := fmt.Sprintf("invoices/%s/%s.pdf", strings.ToLower(entity.Code), inv.InvoiceNumber)
+	pdf := invoice.Render(entity, inv, order)
+
+	if err := s.store.Put(ctx, key, "application/pdf", pdf); err != nil {
+		return fmt.Errorf("failed to store invoice: %w", err)
+	}
+
+	if err := s.repo.MarkInvoiceIssued(ctx, inv.ID, key); err != nil {
+		return err
+	}
+
+	inv.Status = models.InvoiceStatusIssued
+	inv.StorageKey = &key
+	return nil
+}
+
+// legalEntity selects the legal entity invoicing an order by its shipping country
+func (s *orderService) legalEntity(order *models.Order) (config.LegalEntityConfig, error) {
+	invoices := s.config.Services.Order.Invoices
+
+	if order.ShippingAddress != nil {
+		for _, entity := range invoices.LegalEntities {
+			for _, country := range entity.Countries {
+				if strings.EqualFold(country, order.ShippingAddress.Country) {
+					return entity, nil
+				}
+			}
+		}
+	}
+
+	entity, ok := s.findLegalEntity(invoices.DefaultEntity)
+	if !ok {
+		return config.LegalEntityConfig{}, fmt.Errorf("no legal entity configured for invoicing")
+	}
+	return entity, nil
+}
+
+// findLegalEntity looks up a configured legal entity by its code
+func (s *orderService) findLegalEntity(code string) (config.LegalEntityConfig, bool) {
+	for _, entity := range s.config.Services.Order.Invoices.LegalEntities {
+		if entity.Code == code {
+			return entity, true
+		}
+	}
+	return config.LegalEntityConfig{}, false
+}
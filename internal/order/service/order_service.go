@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/clients"
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/internal/order/repository"
+	"github.com/kaanevranportfolio/Commercium/internal/order/tax"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/storage"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// OrderService defines the interface for order business logic
+type OrderService interface {
+	ListOrders(ctx context.Context, userID uuid.UUID, req *models.ListOrdersRequest) (*models.OrderListResponse, error)
+	GetOrder(ctx context.Context, userID uuid.UUID, orderID uuid.UUID) (*models.Order, error)
+	ViewOrder(ctx context.Context, userID uuid.UUID, orderID uuid.UUID, req *models.GetOrderRequest) (*models.Order, error)
+	// SearchOrders searches all customers' orders (admin)
+	SearchOrders(ctx context.Context, req *models.SearchOrdersRequest) (*models.AdminOrderListResponse, error)
+
+	// Cancellation and refunds
+	CancelOrder(ctx context.Context, userID uuid.UUID, orderID uuid.UUID, req *models.CancelOrderRequest) (*models.Order, error)
+	RefundItems(ctx context.Context, userID uuid.UUID, orderID uuid.UUID, req *models.CreateRefundRequest) (*models.Refund, error)
+	ListRefunds(ctx context.Context, userID uuid.UUID, orderID uuid.UUID) ([]*models.Refund, error)
+
+	// Checkout
+	CalculateTotals(ctx context.Context, userID uuid.UUID, req *models.CheckoutTotalsRequest) (*models.CheckoutTotals, error)
+	// CreateOrder places an order for other services, e.g. subscription renewals
+	CreateOrder(ctx context.Context, req *models.CreateOrderRequest) (*models.Order, error)
+	// PlaceOrder places a customer's order and authorizes its payment
+	PlaceOrder(ctx context.Context, userID uuid.UUID, req *models.PlaceOrderRequest) (*models.Order, error)
+	// RecoverCheckouts rolls back a batch of stuck checkouts and returns how many were claimed
+	RecoverCheckouts(ctx context.Context) (int, error)
+	// ExpireReservations releases a batch of abandoned stock reservations and returns how many were claimed
+	ExpireReservations(ctx context.Context) (int, error)
+	GetReservationStats(ctx context.Context) (*models.ReservationStats, error)
+
+	// Tax exemptions (admin)
+	GetTaxExemption(ctx context.Context, userID uuid.UUID) (*models.TaxExemption, error)
+	SetTaxExemption(ctx context.Context, adminID uuid.UUID, userID uuid.UUID, req *models.TaxExemptionRequest) (*models.TaxExemption, error)
+	DeleteTaxExemption(ctx context.Context, userID uuid.UUID) error
+
+	// Invoices
+	GetInvoice(ctx context.Context, userID uuid.UUID, orderID uuid.UUID) (*models.InvoiceResponse, error)
+
+	// Read model
+	ProjectOrder(ctx context.Context, orderID uuid.UUID) error
+	// ProjectStaleOrders catches up a batch of read model rows and returns how many it updated
+	ProjectStaleOrders(ctx context.Context) (int, error)
+}
+
+// EventPublisher publishes domain events to the message broker
+type EventPublisher interface {
+	Publish(ctx context.Context, topic, key string, event interface{}) error
+}
+
+// eventSource is the source of the events the service publishes
+const eventSource = "order-service"
+
+// orderService implements the OrderService interface
+type orderService struct {
+	repo      repository.OrderRepository
+	payments  clients.PaymentClient
+	inventory clients.InventoryClient
+	currency  clients.CurrencyClient
+	pricing   clients.PricingClient
+	taxes     tax.Provider
+	store     storage.Store
+	publisher EventPublisher
+	config    *config.Config
+	logger    *logger.Logger
+}
+
+// NewOrderService creates a new order service.
+// store holds invoice PDFs. currency may be nil, in which case amounts are
+// only shown in the order currency. pricing may be nil, in which case checkout
+// uses the unit prices of the request. publisher may be nil, in which case
+// order events are not published.
+func NewOrderService(
+	repo repository.OrderRepository,
+	payments clients.PaymentClient,
+	inventory clients.InventoryClient,
+	currency clients.CurrencyClient,
+	pricing clients.PricingClient,
+	taxes tax.Provider,
+	store storage.Store,
+	publisher EventPublisher,
+	config *config.Config,
+	logger *logger.Logger,
+) OrderService {
+	return &orderService{
+		repo:      repo,
+		payments:  payments,
+		inventory: inventory,
+		currency:  currency,
+		pricing:   pricing,
+		taxes:     taxes,
+		store:     store,
+		publisher: publisher,
+		config:    config,
+		logger:    logger,
+	}
+}
+
+// ListOrders returns a page of the user's order history. Orders are read
+// from the read model, which may lag a moment behind the orders themselves.
+func (s *orderService) ListOrders(ctx context.Context, userID uuid.UUID, req *models.ListOrdersRequest) (*models.OrderListResponse, error) {
+	filter, err := s.buildFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	filter.UserID = &userID
+	pageSize := filter.Limit
+
+	summaries, nextCursor, err := s.searchSummaries(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	orders := make([]*models.OrderSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		orders = append(orders, summary.ToSummary())
+	}
+	response := httpx.NewPage(orders, nextCursor, pageSize)
+
+	if err := s.addDisplayTotals(ctx, userID, req.Currency, response.Data); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GetOrder returns the full detail of one of the user's orders
+func (s *orderService) GetOrder(ctx context.Context, userID uuid.UUID, orderID uuid.UUID) (*models.Order, error) {
+	order, err := s.repo.GetByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	// Don't reveal the existence of other users' orders
+	if order.UserID != userID {
+		return nil, apperrors.NotFound("order not found")
+	}
+
+	order.Items, err = s.repo.GetItems(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+
+	return order, nil
+}
+
+// buildFilter validates the list request and converts it to a repository filter
+func (s *orderService) buildFilter(req *models.ListOrdersRequest) (*models.OrderFilter, error) {
+	filter := &models.OrderFilter{Limit: req.Limit}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+
+	if req.Status != "" {
+		for _, raw := range strings.Split(req.Status, ",") {
+			status := models.OrderStatus(strings.TrimSpace(raw))
+			if !status.IsValid() {
+				return nil, apperrors.Validation("invalid status filter: %s", raw)
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	if req.From != "" {
+		from, err := parseDate(req.From)
+		if err != nil {
+			return nil, apperrors.Validation("invalid from date: %s", err)
+		}
+		filter.From = &from
+	}
+
+	if req.To != "" {
+		to, err := parseDate(req.To)
+		if err != nil {
+			return nil, apperrors.Validation("invalid to date: %s", err)
+		}
+		// A bare date includes the whole day
+		if len(req.To) == len(time.DateOnly) {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = &to
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, apperrors.Validation("invalid date range: from must be before to")
+	}
+
+	if req.Cursor != "" {
+		cursor, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return nil, apperrors.Validation("invalid cursor")
+		}
+		filter.Cursor = cursor
+	}
+
+	return filter, nil
+}
+
+// parseDate accepts either an RFC 3339 timestamp or a YYYY-MM-DD date
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// encodeCursor serializes a cursor into an opaque URL-safe token
+func encodeCursor(cursor *models.OrderCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor parses a token produced by encodeCursor
+func decodeCursor(token string) (*models.OrderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := &models.OrderCursor{}
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return nil, err
+	}
+	if cursor.ID == uuid.Nil || cursor.PlacedAt.IsZero() {
+		return nil, fmt.Errorf("incomplete cursor")
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,130 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/mocks"
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/internal/order/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// newOrderService returns an order service on a mock of its repository.
+// Calls the test didn't expect fail it.
+func newOrderService(t *testing.T) (service.OrderService, *mocks.MockOrderRepository) {
+	t.Helper()
+
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "error",
+		Format: "json",
+		Output: "stdout",
+	}, "order-service-test")
+	require.NoError(t, err)
+
+	repo := mocks.NewMockOrderRepository(gomock.NewController(t))
+	return service.NewOrderService(repo, nil, nil, nil, nil, nil, nil, nil, &config.Config{}, log), repo
+}
+
+// TestOrderLookups covers how the lookups of one of a user's orders fail:
+// the repository's not found passes through as is, other errors are
+// wrapped, and orders of other users aren't found either
+func TestOrderLookups(t *testing.T) {
+	errDatabase := errors.New("connection reset")
+	userID := uuid.New()
+
+	lookups := []struct {
+		name string
+		// lookup looks up the order as user, expecting what it reads
+		// besides the order on a success
+		lookup func(ctx context.Context, svc service.OrderService, repo *mocks.MockOrderRepository, user, order uuid.UUID) error
+	}{
+		{
+			name: "GetOrder",
+			lookup: func(ctx context.Context, svc service.OrderService, repo *mocks.MockOrderRepository, user, order uuid.UUID) error {
+				repo.EXPECT().GetItems(gomock.Any(), order).Return([]*models.OrderItem{}, nil).MaxTimes(1)
+				_, err := svc.GetOrder(ctx, user, order)
+				return err
+			},
+		},
+		{
+			name: "ListRefunds",
+			lookup: func(ctx context.Context, svc service.OrderService, repo *mocks.MockOrderRepository, user, order uuid.UUID) error {
+				repo.EXPECT().ListRefunds(gomock.Any(), order).Return([]*models.Refund{}, nil).MaxTimes(1)
+				_, err := svc.ListRefunds(ctx, user, order)
+				return err
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		// found is what the repository returns for the order
+		found      func(orderID uuid.UUID) (*models.Order, error)
+		wantErr    error
+		wantStatus int
+		wrapped    bool
+	}{
+		{
+			name:  "own order",
+			found: func(orderID uuid.UUID) (*models.Order, error) { return &models.Order{ID: orderID, UserID: userID}, nil },
+		},
+		{
+			name:       "missing order",
+			found:      func(uuid.UUID) (*models.Order, error) { return nil, apperrors.NotFound("order not found") },
+			wantErr:    apperrors.ErrNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "order of another user",
+			found: func(orderID uuid.UUID) (*models.Order, error) {
+				return &models.Order{ID: orderID, UserID: uuid.New()}, nil
+			},
+			wantErr:    apperrors.ErrNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "repository failure",
+			found:      func(uuid.UUID) (*models.Order, error) { return nil, errDatabase },
+			wantErr:    errDatabase,
+			wantStatus: http.StatusInternalServerError,
+			wrapped:    true,
+		},
+	}
+
+	for _, lookup := range lookups {
+		for _, tt := range tests {
+			t.Run(lookup.name+"/"+tt.name, func(t *testing.T) {
+				svc, repo := newOrderService(t)
+				orderID := uuid.New()
+				order, repoErr := tt.found(orderID)
+				repo.EXPECT().GetByID(gomock.Any(), orderID).Return(order, repoErr)
+
+				err := lookup.lookup(context.Background(), svc, repo, userID, orderID)
+				if tt.wantErr == nil {
+					require.NoError(t, err)
+					return
+				}
+
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, tt.wantStatus, apperrors.HTTPStatus(err))
+				if repoErr != nil && !tt.wrapped {
+					// The repository's error is returned as is
+					assert.Same(t, repoErr, err)
+				}
+				if tt.wrapped {
+					assert.Contains(t, err.Error(), "failed to get order")
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+	"github.com/kaanevranportfolio/Commercium/pkg/kafka"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// ProjectionHandler returns a Kafka handler updating the read model row of
+// the order an order or payment event is about
+func ProjectionHandler(orderService OrderService) kafka.Handler {
+	return func(ctx context.Context, key, value []byte) error {
+		envelope, err := events.Parse(value)
+		if err != nil {
+			return kafka.Permanent(err)
+		}
+		var event struct {
+			OrderID uuid.UUID `json:"order_id"`
+		}
+		if err := envelope.Decode(&event); err != nil {
+			return kafka.Permanent(err)
+		}
+		if event.OrderID == uuid.Nil {
+			return nil
+		}
+
+		return orderService.ProjectOrder(ctx, event.OrderID)
+	}
+}
+
+// ProjectionWorker periodically catches the read model up with orders whose
+// events were missed
+type ProjectionWorker struct {
+	orderService OrderService
+	interval     time.Duration
+	batchSize    int
+	logger       *logger.Logger
+}
+
+// NewProjectionWorker creates a new projection worker
+func NewProjectionWorker(orderService OrderService, interval time.Duration, batchSize int, logger *logger.Logger) *ProjectionWorker {
+	return &ProjectionWorker{
+		orderService: orderService,
+		interval:     interval,
+		batchSize:    batchSize,
+		logger:       logger,
+	}
+}
+
+// Run catches up at startup and then on every tick until ctx is cancelled.
+// Each run works through all stale rows batch by batch.
+func (w *ProjectionWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			projected, err := w.orderService.ProjectStaleOrders(ctx)
+			if err != nil {
+				w.logger.Error("Failed to project stale orders", "error", err)
+				break
+			}
+			if projected < w.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
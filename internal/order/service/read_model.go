@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+)
+
+// SearchOrders searches all customers' orders for the back office. Like
+// order histories, searches are served from the read model.
+func (s *orderService) SearchOrders(ctx context.Context, req *models.SearchOrdersRequest) (*models.AdminOrderListResponse, error) {
+	filter, err := s.buildFilter(&req.ListOrdersRequest)
+	if err != nil {
+		return nil, err
+	}
+	filter.Query = strings.TrimSpace(req.Query)
+	if req.UserID != "" {
+		userID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			return nil, apperrors.Validation("invalid user ID")
+		}
+		filter.UserID = &userID
+	}
+	pageSize := filter.Limit
+
+	summaries, nextCursor, err := s.searchSummaries(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search orders: %w", err)
+	}
+
+	orders := make([]*models.AdminOrderSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		orders = append(orders, summary.ToAdminSummary())
+	}
+
+	return httpx.NewPage(orders, nextCursor, pageSize), nil
+}
+
+// searchSummaries reads a page of orders from the read model and returns it
+// with the cursor of the next page, which is empty on the last page
+func (s *orderService) searchSummaries(ctx context.Context, filter *models.OrderFilter) ([]*models.OrderReadModel, string, error) {
+	// Fetch one extra row to find out whether another page exists
+	pageSize := filter.Limit
+	filter.Limit = pageSize + 1
+
+	summaries, err := s.repo.SearchSummaries(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(summaries) <= pageSize {
+		return summaries, "", nil
+	}
+
+	summaries = summaries[:pageSize]
+	last := summaries[len(summaries)-1]
+	nextCursor, err := encodeCursor(&models.OrderCursor{PlacedAt: last.PlacedAt, ID: last.OrderID})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	return summaries, nextCursor, nil
+}
+
+// ProjectOrder brings an order's row in the read model up to date. It is
+// called for every order and payment event, and rebuilds the row from the
+// current state rather than applying the event, so duplicate or out of order
+// events do no harm.
+func (s *orderService) ProjectOrder(ctx context.Context, orderID uuid.UUID) error {
+	return s.repo.ProjectOrders(ctx, []uuid.UUID{orderID})
+}
+
+// ProjectStaleOrders brings a batch of read model rows up to date whose
+// events were missed, e.g. while Kafka was unavailable, and returns how many
+// it updated
+func (s *orderService) ProjectStaleOrders(ctx context.Context) (int, error) {
+	projected, err := s.repo.ProjectStaleOrders(ctx, s.config.Services.Order.Projection.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	if projected > 0 {
+		s.logger.Info("Stale order summaries projected", "count", projected)
+	}
+	return projected, nil
+}
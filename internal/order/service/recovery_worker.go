@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// RecoveryWorker periodically rolls back stuck checkouts in the background
+type RecoveryWorker struct {
+	orderService OrderService
+	interval     time.Duration
+	batchSize    int
+	logger       *logger.Logger
+}
+
+// NewRecoveryWorker creates a new recovery worker
+func NewRecoveryWorker(orderService OrderService, interval time.Duration, batchSize int, logger *logger.Logger) *RecoveryWorker {
+	return &RecoveryWorker{
+		orderService: orderService,
+		interval:     interval,
+		batchSize:    batchSize,
+		logger:       logger,
+	}
+}
+
+// Run recovers checkouts until ctx is cancelled. Each tick works through all
+// stuck checkouts batch by batch.
+func (w *RecoveryWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				claimed, err := w.orderService.RecoverCheckouts(ctx)
+				if err != nil {
+					w.logger.Error("Failed to recover stuck checkouts", "error", err)
+					break
+				}
+				if claimed < w.batchSize {
+					break
+				}
+			}
+		}
+	}
+}
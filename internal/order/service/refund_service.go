@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/clients"
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+)
+
+// CancelOrder cancels an order that has not shipped yet. Reserved stock is
+// released and the full payment is returned to the customer.
+func (s *orderService) CancelOrder(ctx context.Context, userID uuid.UUID, orderID uuid.UUID, req *models.CancelOrderRequest) (*models.Order, error) {
+	order, err := s.GetOrder(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !order.CanCancel() {
+		if order.CanRefund() {
+			return nil, apperrors.Conflict("order has already shipped and cannot be cancelled, request a refund instead")
+		}
+		return nil, apperrors.Conflict("order cannot be cancelled in its current state")
+	}
+
+	var reason *string
+	if req.Reason != "" {
+		reason = &req.Reason
+	}
+
+	if err := s.repo.Cancel(ctx, orderID, reason); err != nil {
+		return nil, err
+	}
+
+	s.releaseStock(ctx, order)
+
+	var refund *models.Refund
+	if order.TotalAmount-order.RefundedAmount > 0 {
+		refund = &models.Refund{
+			ID:       uuid.New(),
+			OrderID:  order.ID,
+			Amount:   order.TotalAmount - order.RefundedAmount,
+			Currency: order.Currency,
+			Reason:   reason,
+			Status:   models.RefundStatusPending,
+		}
+
+		// A failed refund leaves the order cancelled; the failed refund is
+		// visible on the order and can be retried by support.
+		if err := s.repo.CreateRefund(ctx, refund); err != nil {
+			s.logger.Error("Failed to create refund for cancelled order", "error", err, "order_id", orderID)
+		} else if err := s.executeRefund(ctx, refund); err != nil {
+			s.logger.Error("Failed to refund cancelled order", "error", err, "order_id", orderID)
+		}
+	}
+
+	event := &models.OrderEvent{
+		Type:       models.EventOrderCancelled,
+		OrderID:    order.ID,
+		UserID:     order.UserID,
+		Status:     string(models.OrderStatusCancelled),
+		Reason:     req.Reason,
+		OccurredAt: time.Now().UTC(),
+	}
+	if refund != nil && refund.Status == models.RefundStatusSucceeded {
+		event.Amount = refund.Amount
+		event.Currency = refund.Currency
+		event.RefundID = &refund.ID
+	}
+	s.publishEvent(ctx, event)
+
+	s.logger.Info("Order cancelled", "order_id", orderID, "user_id", userID)
+	return s.GetOrder(ctx, userID, orderID)
+}
+
+// RefundItems refunds line items of a shipped or delivered order
+func (s *orderService) RefundItems(ctx context.Context, userID uuid.UUID, orderID uuid.UUID, req *models.CreateRefundRequest) (*models.Refund, error) {
+	order, err := s.GetOrder(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !order.CanRefund() {
+		if order.CanCancel() {
+			return nil, apperrors.Conflict("order has not shipped yet, cancel it instead")
+		}
+		return nil, apperrors.Conflict("order cannot be refunded in its current state")
+	}
+
+	items := make(map[uuid.UUID]*models.OrderItem, len(order.Items))
+	for _, item := range order.Items {
+		items[item.ID] = item
+	}
+
+	refund := &models.Refund{
+		ID:       uuid.New(),
+		OrderID:  order.ID,
+		Currency: order.Currency,
+		Status:   models.RefundStatusPending,
+	}
+	if req.Reason != "" {
+		refund.Reason = &req.Reason
+	}
+
+	requested := make(map[uuid.UUID]int, len(req.Items))
+	for _, itemReq := range req.Items {
+		item, ok := items[itemReq.OrderItemID]
+		if !ok {
+			return nil, apperrors.Validation("invalid refund item: %s is not part of the order", itemReq.OrderItemID)
+		}
+
+		requested[item.ID] += itemReq.Quantity
+		if requested[item.ID] > item.RefundableQuantity() {
+			return nil, apperrors.Validation("invalid refund quantity for item %s: only %d refundable", item.ID, item.RefundableQuantity())
+		}
+
+		amount := item.UnitPrice * int64(itemReq.Quantity)
+		refund.Amount += amount
+		refund.Items = append(refund.Items, &models.RefundItem{
+			OrderItemID: item.ID,
+			Quantity:    itemReq.Quantity,
+			Amount:      amount,
+		})
+	}
+
+	if err := s.repo.CreateRefund(ctx, refund); err != nil {
+		return nil, fmt.Errorf("failed to create refund: %w", err)
+	}
+
+	if err := s.executeRefund(ctx, refund); err != nil {
+		return nil, apperrors.Upstream("payment provider rejected the refund").Wrap(err)
+	}
+
+	s.publishEvent(ctx, &models.OrderEvent{
+		Type:       models.EventOrderRefunded,
+		OrderID:    order.ID,
+		UserID:     order.UserID,
+		Status:     string(order.Status),
+		Amount:     refund.Amount,
+		Currency:   refund.Currency,
+		RefundID:   &refund.ID,
+		Reason:     req.Reason,
+		OccurredAt: time.Now().UTC(),
+	})
+
+	s.logger.Info("Order items refunded", "order_id", orderID, "refund_id", refund.ID, "amount", refund.Amount)
+	return refund, nil
+}
+
+// ListRefunds returns all refunds of one of the user's orders
+func (s *orderService) ListRefunds(ctx context.Context, userID uuid.UUID, orderID uuid.UUID) ([]*models.Refund, error) {
+	order, err := s.repo.GetByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if order.UserID != userID {
+		return nil, apperrors.NotFound("order not found")
+	}
+
+	return s.repo.ListRefunds(ctx, orderID)
+}
+
+// executeRefund asks the payment provider to return the amount of a pending
+// refund and records the outcome
+func (s *orderService) executeRefund(ctx context.Context, refund *models.Refund) error {
+	reason := ""
+	if refund.Reason != nil {
+		reason = *refund.Reason
+	}
+
+	resp, err := s.payments.Refund(ctx, &clients.RefundPaymentRequest{
+		OrderID:        refund.OrderID,
+		RefundID:       refund.ID,
+		Amount:         refund.Amount,
+		Currency:       refund.Currency,
+		Reason:         reason,
+		IdempotencyKey: refund.ID.String(),
+	})
+	if err != nil {
+		refund.Status = models.RefundStatusFailed
+		if failErr := s.repo.FailRefund(ctx, refund, err.Error()); failErr != nil {
+			s.logger.Error("Failed to record refund failure", "error", failErr, "refund_id", refund.ID)
+		}
+		return err
+	}
+
+	if err := s.repo.CompleteRefund(ctx, refund.ID, resp.ProviderRefundID); err != nil {
+		// The provider has already refunded the money, so this must be reconciled manually
+		s.logger.Error("Failed to record completed refund", "error", err,
+			"refund_id", refund.ID, "provider_refund_id", resp.ProviderRefundID)
+		return fmt.Errorf("failed to record refund: %w", err)
+	}
+
+	refund.Status = models.RefundStatusSucceeded
+	refund.ProviderRefundID = &resp.ProviderRefundID
+	return nil
+}
+
+// releaseStock returns the stock reserved for an order's items to inventory
+func (s *orderService) releaseStock(ctx context.Context, order *models.Order) {
+	req := &clients.ReleaseStockRequest{OrderID: order.ID}
+	for _, item := range order.Items {
+		req.Items = append(req.Items, &clients.StockItem{
+			ProductID: item.ProductID,
+			SKU:       item.SKU,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	// Unreleased reservations expire on their own, so a failure here is not fatal
+	if err := s.inventory.Release(ctx, req); err != nil {
+		s.logger.Warn("Failed to release stock", "error", err, "order_id", order.ID)
+	}
+}
+
+// publishEvent publishes an order event, logging instead of failing on errors
+func (s *orderService) publishEvent(ctx context.Context, event *models.OrderEvent) {
+	if s.publisher == nil {
+		return
+	}
+
+	envelope, err := events.New(ctx, eventSource, event.Type, event.OrderID.String(), models.OrderEventSchemaVersion, event)
+	if err != nil {
+		s.logger.Warn("Failed to publish order event", "error", err, "type", event.Type, "order_id", event.OrderID)
+		return
+	}
+
+	if err := s.publisher.Publish(ctx, s.config.Kafka.Topics.OrderEvents, event.OrderID.String(), envelope); err != nil {
+		s.logger.Warn("Failed to publish order event", "error", err, "type", event.Type, "order_id", event.OrderID)
+	}
+}
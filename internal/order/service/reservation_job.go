@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+)
+
+// ReservationJob releases the stock of abandoned checkouts and records how
+// many reservations turn into orders
+type ReservationJob struct {
+	orderService OrderService
+	metrics      metrics.Registry
+	serviceName  string
+	batchSize    int
+	logger       *logger.Logger
+}
+
+// NewReservationJob creates a new reservation job. metrics may be nil.
+func NewReservationJob(orderService OrderService, metrics metrics.Registry, serviceName string, batchSize int, logger *logger.Logger) *ReservationJob {
+	return &ReservationJob{
+		orderService: orderService,
+		metrics:      metrics,
+		serviceName:  serviceName,
+		batchSize:    batchSize,
+		logger:       logger,
+	}
+}
+
+// Run works through all expired reservations batch by batch, then updates
+// the conversion metrics
+func (j *ReservationJob) Run(ctx context.Context) error {
+	for ctx.Err() == nil {
+		claimed, err := j.orderService.ExpireReservations(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to expire stock reservations: %w", err)
+		}
+		if claimed < j.batchSize {
+			break
+		}
+	}
+	j.recordStats(ctx)
+	return nil
+}
+
+// recordStats publishes the outcomes of recently resolved reservations
+func (j *ReservationJob) recordStats(ctx context.Context) {
+	if j.metrics == nil {
+		return
+	}
+
+	stats, err := j.orderService.GetReservationStats(ctx)
+	if err != nil {
+		j.logger.Error("Failed to get stock reservation stats", "error", err)
+		return
+	}
+
+	j.metrics.SetReservations(string(models.ReservationStatusPurchased), j.serviceName, float64(stats.Purchased))
+	j.metrics.SetReservations(string(models.ReservationStatusReleased), j.serviceName, float64(stats.Released))
+	j.metrics.SetReservations(string(models.ReservationStatusExpired), j.serviceName, float64(stats.Expired))
+	j.metrics.SetReservationConversionRate(j.serviceName, stats.ConversionRate())
+}
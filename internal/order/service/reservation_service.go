@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/clients"
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+)
+
+// reasonReservationExpired is recorded on reservations released by the expiry worker
+const reasonReservationExpired = "stock reservation expired"
+
+// reserveStock reserves the stock of a checkout. The reservation is recorded
+// first so that it expires even if the call fails after taking effect.
+func (s *orderService) reserveStock(ctx context.Context, saga *models.CheckoutSaga) error {
+	reservation := &models.StockReservation{
+		ID:        saga.ID,
+		UserID:    saga.UserID,
+		Items:     saga.Items,
+		Status:    models.ReservationStatusHeld,
+		ExpiresAt: time.Now().Add(s.config.Services.Order.Reservations.TTL),
+	}
+	if err := s.repo.CreateReservation(ctx, reservation); err != nil {
+		return err
+	}
+
+	err := s.inventory.Reserve(ctx, &clients.ReserveStockRequest{OrderID: saga.ID, Items: stockItems(saga.Items)})
+	if errors.Is(err, clients.ErrInsufficientStock) {
+		return apperrors.Conflict("some items are out of stock").Wrap(err)
+	}
+	return err
+}
+
+// releaseReservation gives back the stock reserved by a checkout
+func (s *orderService) releaseReservation(ctx context.Context, saga *models.CheckoutSaga) error {
+	err := s.inventory.Release(ctx, &clients.ReleaseStockRequest{OrderID: saga.ID, Items: stockItems(saga.Items)})
+	if err != nil {
+		return err
+	}
+
+	return s.repo.ReleaseReservation(ctx, saga.ID)
+}
+
+// ExpireReservations releases a batch of stock reservations whose checkouts
+// were abandoned and returns how many were claimed. Releases that fail are
+// retried once the claim lapses. A checkout still running when its
+// reservation expires fails to complete and is rolled back.
+func (s *orderService) ExpireReservations(ctx context.Context) (int, error) {
+	cfg := s.config.Services.Order.Reservations
+	reservations, err := s.repo.ClaimExpiredReservations(ctx, cfg.ExpiryInterval, cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, reservation := range reservations {
+		err := s.inventory.Release(ctx, &clients.ReleaseStockRequest{
+			OrderID: reservation.ID,
+			Items:   stockItems(reservation.Items),
+		})
+		if err != nil {
+			s.logger.Warn("Failed to release expired stock reservation, will retry", "error", err, "reservation_id", reservation.ID)
+			continue
+		}
+
+		if err := s.repo.ExpireReservation(ctx, reservation.ID); err != nil {
+			return 0, err
+		}
+
+		s.publishEvent(ctx, &models.OrderEvent{
+			Type:       models.EventReservationExpired,
+			OrderID:    reservation.ID,
+			UserID:     reservation.UserID,
+			Status:     string(models.ReservationStatusExpired),
+			Reason:     reasonReservationExpired,
+			OccurredAt: time.Now().UTC(),
+		})
+		s.logger.Info("Stock reservation expired", "reservation_id", reservation.ID, "user_id", reservation.UserID,
+			"expired_at", reservation.ExpiresAt)
+	}
+
+	return len(reservations), nil
+}
+
+// GetReservationStats counts the outcomes of the reservations resolved within
+// the metrics window
+func (s *orderService) GetReservationStats(ctx context.Context) (*models.ReservationStats, error) {
+	window := s.config.Services.Order.Reservations.MetricsWindow
+	return s.repo.GetReservationStats(ctx, time.Now().Add(-window))
+}
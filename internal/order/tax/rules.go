@@ -0,0 +1,149 @@
+package tax
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// ratePrecision is the number of rate units per 100%, so rates with up to
+// four decimals such as 8.875% are exact
+const ratePrecision = 1_000_000
+
+// rule is a tax rate with its rates in millionths
+type rule struct {
+	rate          int64
+	inclusive     bool
+	taxShipping   bool
+	categoryRates map[string]int64
+}
+
+// rateFor returns the rate applying to a product tax code
+func (r *rule) rateFor(taxCode string) int64 {
+	if rate, ok := r.categoryRates[strings.ToLower(taxCode)]; ok {
+		return rate
+	}
+	return r.rate
+}
+
+// ruleProvider calculates taxes from the configured rate table.
+// Destinations without a rule are not taxed.
+type ruleProvider struct {
+	rules map[string]*rule
+}
+
+// NewRuleProvider creates a provider for a table of country and state rates
+func NewRuleProvider(rules []config.TaxRuleConfig) (Provider, error) {
+	provider := &ruleProvider{rules: make(map[string]*rule, len(rules))}
+
+	for _, cfg := range rules {
+		if len(cfg.Country) != 2 {
+			return nil, fmt.Errorf("invalid tax rule: country %q is not a two-letter code", cfg.Country)
+		}
+
+		key := ruleKey(cfg.Country, cfg.State)
+		if _, exists := provider.rules[key]; exists {
+			return nil, fmt.Errorf("invalid tax rule: duplicate rule for %s", key)
+		}
+
+		rate, err := toRate(cfg.Rate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tax rule for %s: %w", key, err)
+		}
+
+		r := &rule{
+			rate:          rate,
+			inclusive:     cfg.Inclusive,
+			taxShipping:   cfg.TaxShipping,
+			categoryRates: make(map[string]int64, len(cfg.CategoryRates)),
+		}
+		for category, percent := range cfg.CategoryRates {
+			rate, err := toRate(percent)
+			if err != nil {
+				return nil, fmt.Errorf("invalid tax rule for %s: %w", key, err)
+			}
+			r.categoryRates[strings.ToLower(category)] = rate
+		}
+
+		provider.rules[key] = r
+	}
+
+	return provider, nil
+}
+
+// Name returns the provider name
+func (p *ruleProvider) Name() string {
+	return ProviderRules
+}
+
+// Calculate applies the rule of the destination to each line, and to
+// shipping where shipping is taxable. Tax is rounded per line.
+func (p *ruleProvider) Calculate(ctx context.Context, req *Request) (*Result, error) {
+	result := &Result{Lines: make([]*LineTax, 0, len(req.Lines))}
+
+	r := p.match(req.To)
+	if r == nil {
+		for _, line := range req.Lines {
+			result.Lines = append(result.Lines, &LineTax{ID: line.ID})
+		}
+		return result, nil
+	}
+
+	result.Inclusive = r.inclusive
+	for _, line := range req.Lines {
+		amount := taxOn(line.Amount(), r.rateFor(line.TaxCode), r.inclusive)
+		result.Lines = append(result.Lines, &LineTax{ID: line.ID, TaxAmount: amount})
+		result.TotalTax += amount
+	}
+
+	if r.taxShipping {
+		result.ShippingTax = taxOn(req.ShippingAmount, r.rate, r.inclusive)
+		result.TotalTax += result.ShippingTax
+	}
+
+	return result, nil
+}
+
+// match returns the state rule of the address, falling back to its country rule
+func (p *ruleProvider) match(to Address) *rule {
+	if to.State != "" {
+		if r, ok := p.rules[ruleKey(to.Country, to.State)]; ok {
+			return r
+		}
+	}
+	return p.rules[ruleKey(to.Country, "")]
+}
+
+// ruleKey identifies a rule by country and optional state, e.g. "US-CA"
+func ruleKey(country, state string) string {
+	key := strings.ToUpper(country)
+	if state != "" {
+		key += "-" + strings.ToUpper(state)
+	}
+	return key
+}
+
+// toRate converts a percentage to millionths
+func toRate(percent float64) (int64, error) {
+	if percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("rate %v is outside 0-100%%", percent)
+	}
+	return int64(math.Round(percent * ratePrecision / 100)), nil
+}
+
+// taxOn returns the tax on amount, rounded half up. For inclusive prices the
+// tax is the part of amount above its net price.
+func taxOn(amount, rate int64, inclusive bool) int64 {
+	if amount <= 0 || rate == 0 {
+		return 0
+	}
+
+	divisor := int64(ratePrecision)
+	if inclusive {
+		divisor += rate
+	}
+	return (2*amount*rate + divisor) / (2 * divisor)
+}
@@ -0,0 +1,93 @@
+package tax
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// Tax provider names
+const (
+	ProviderRules  = "rules"
+	ProviderTaxJar = "taxjar"
+)
+
+// Address is the destination that determines which taxes apply
+type Address struct {
+	Country    string
+	State      string
+	City       string
+	PostalCode string
+}
+
+// Line is a priced line item. Amounts are in minor currency units.
+type Line struct {
+	ID        string
+	TaxCode   string
+	Quantity  int
+	UnitPrice int64
+	// Discount is the part of the order discount allocated to the line
+	Discount int64
+}
+
+// Amount returns the price of the line after its discount
+func (l *Line) Amount() int64 {
+	return l.UnitPrice*int64(l.Quantity) - l.Discount
+}
+
+// Request holds the data needed to calculate the tax of an order
+type Request struct {
+	Currency       string
+	To             Address
+	Lines          []*Line
+	ShippingAmount int64
+}
+
+// LineTax is the tax charged on a line item
+type LineTax struct {
+	ID        string
+	TaxAmount int64
+}
+
+// Result is the tax of an order. When Inclusive is set, the amounts are
+// contained in the prices rather than added on top of them.
+type Result struct {
+	Lines       []*LineTax
+	ShippingTax int64
+	TotalTax    int64
+	Inclusive   bool
+}
+
+// Provider calculates taxes, either from a local rate table or through an
+// external service such as TaxJar or Avalara
+type Provider interface {
+	Name() string
+	Calculate(ctx context.Context, req *Request) (*Result, error)
+}
+
+// ProviderError is returned when an external tax service rejects a request
+type ProviderError struct {
+	Provider string
+	Code     string
+	Message  string
+}
+
+func (e *ProviderError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s: %s (%s)", e.Provider, e.Message, e.Code)
+	}
+	return fmt.Sprintf("%s: %s", e.Provider, e.Message)
+}
+
+// NewProvider creates the tax provider selected in the configuration
+func NewProvider(cfg config.TaxConfig) (Provider, error) {
+	switch cfg.Provider {
+	case ProviderRules:
+		return NewRuleProvider(cfg.Rules)
+	case ProviderTaxJar:
+		return NewTaxJarProvider(cfg.TaxJar, cfg.Timeout)
+	default:
+		return nil, fmt.Errorf("tax provider not supported: %s", cfg.Provider)
+	}
+}
@@ -0,0 +1,207 @@
+package tax
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// taxJarProvider calculates US and international sales tax with the TaxJar
+// API. TaxJar prices are always exclusive of tax.
+type taxJarProvider struct {
+	apiURL     string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// taxJarLineItem is a line item of a TaxJar tax request
+type taxJarLineItem struct {
+	ID             string      `json:"id"`
+	Quantity       int         `json:"quantity"`
+	UnitPrice      json.Number `json:"unit_price"`
+	Discount       json.Number `json:"discount"`
+	ProductTaxCode string      `json:"product_tax_code,omitempty"`
+}
+
+// taxJarRequest is the body of POST /v2/taxes
+type taxJarRequest struct {
+	ToCountry string           `json:"to_country"`
+	ToState   string           `json:"to_state,omitempty"`
+	ToCity    string           `json:"to_city,omitempty"`
+	ToZip     string           `json:"to_zip,omitempty"`
+	Shipping  json.Number      `json:"shipping"`
+	LineItems []taxJarLineItem `json:"line_items"`
+}
+
+// taxJarResponse is the subset of the tax response we use
+type taxJarResponse struct {
+	Tax struct {
+		AmountToCollect json.Number `json:"amount_to_collect"`
+		Breakdown       *struct {
+			Shipping *struct {
+				TaxCollectable json.Number `json:"tax_collectable"`
+			} `json:"shipping"`
+			LineItems []struct {
+				ID             string      `json:"id"`
+				TaxCollectable json.Number `json:"tax_collectable"`
+			} `json:"line_items"`
+		} `json:"breakdown"`
+	} `json:"tax"`
+}
+
+// taxJarErrorResponse is the error body returned by the TaxJar API
+type taxJarErrorResponse struct {
+	Error  string `json:"error"`
+	Detail string `json:"detail"`
+}
+
+// NewTaxJarProvider creates a new TaxJar provider
+func NewTaxJarProvider(cfg config.TaxJarConfig, timeout time.Duration) (Provider, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("taxjar api key is required")
+	}
+
+	return &taxJarProvider{
+		apiURL:     strings.TrimRight(cfg.APIURL, "/"),
+		apiKey:     cfg.APIKey,
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Name returns the provider name
+func (p *taxJarProvider) Name() string {
+	return ProviderTaxJar
+}
+
+// Calculate asks TaxJar for the tax to collect on an order
+func (p *taxJarProvider) Calculate(ctx context.Context, req *Request) (*Result, error) {
+	body := &taxJarRequest{
+		ToCountry: req.To.Country,
+		ToState:   req.To.State,
+		ToCity:    req.To.City,
+		ToZip:     req.To.PostalCode,
+		Shipping:  formatDecimal(req.ShippingAmount),
+		LineItems: make([]taxJarLineItem, 0, len(req.Lines)),
+	}
+	for _, line := range req.Lines {
+		body.LineItems = append(body.LineItems, taxJarLineItem{
+			ID:             line.ID,
+			Quantity:       line.Quantity,
+			UnitPrice:      formatDecimal(line.UnitPrice),
+			Discount:       formatDecimal(line.Discount),
+			ProductTaxCode: line.TaxCode,
+		})
+	}
+
+	resp := &taxJarResponse{}
+	if err := p.post(ctx, "/v2/taxes", body, resp); err != nil {
+		return nil, err
+	}
+
+	total, err := parseDecimal(resp.Tax.AmountToCollect)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{TotalTax: total, Lines: make([]*LineTax, 0, len(req.Lines))}
+	byID := make(map[string]int64)
+	if breakdown := resp.Tax.Breakdown; breakdown != nil {
+		for _, item := range breakdown.LineItems {
+			amount, err := parseDecimal(item.TaxCollectable)
+			if err != nil {
+				return nil, err
+			}
+			byID[item.ID] = amount
+		}
+		if breakdown.Shipping != nil {
+			if result.ShippingTax, err = parseDecimal(breakdown.Shipping.TaxCollectable); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, line := range req.Lines {
+		result.Lines = append(result.Lines, &LineTax{ID: line.ID, TaxAmount: byID[line.ID]})
+	}
+
+	return result, nil
+}
+
+// post sends a JSON request to the TaxJar API and decodes the response into out
+func (p *taxJarProvider) post(ctx context.Context, path string, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode taxjar request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create taxjar request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call taxjar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		errResp := &taxJarErrorResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(errResp); err != nil {
+			return fmt.Errorf("taxjar returned status %d", resp.StatusCode)
+		}
+		return &ProviderError{
+			Provider: ProviderTaxJar,
+			Code:     errResp.Error,
+			Message:  errResp.Detail,
+		}
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("failed to decode taxjar response: %w", err)
+	}
+
+	return nil
+}
+
+// formatDecimal converts an amount in minor units to a decimal such as 7.58
+func formatDecimal(amount int64) json.Number {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return json.Number(fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100))
+}
+
+// parseDecimal converts a decimal such as 7.58 to minor units, rounding half
+// up beyond the second decimal
+func parseDecimal(value json.Number) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	whole, fraction, _ := strings.Cut(value.String(), ".")
+	fraction = (fraction + "000")[:3]
+
+	major, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", value, err)
+	}
+	minor, err := strconv.ParseInt(fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", value, err)
+	}
+
+	return major*100 + (minor+5)/10, nil
+}
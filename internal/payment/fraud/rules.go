@@ -0,0 +1,73 @@
+package fraud
+
+import (
+	"fmt"
+	"strings"
+)
+
+// velocityRule flags users making many payment attempts or collecting declines in a short time
+type velocityRule struct {
+	maxAttempts int
+	maxFailures int
+}
+
+func (r *velocityRule) Evaluate(signals *Signals) (int, string) {
+	score := 0
+	var reasons []string
+
+	if r.maxAttempts > 0 && signals.RecentAttempts > r.maxAttempts {
+		score += 30
+		reasons = append(reasons, fmt.Sprintf("%d payment attempts in velocity window", signals.RecentAttempts))
+	}
+
+	// Repeated declines are typical of card testing
+	if r.maxFailures > 0 && signals.RecentFailures >= r.maxFailures {
+		score += 40
+		reasons = append(reasons, fmt.Sprintf("%d declined payments in velocity window", signals.RecentFailures))
+	}
+
+	return score, strings.Join(reasons, "; ")
+}
+
+// countryMismatchRule flags orders billed in one country and shipped to another
+type countryMismatchRule struct{}
+
+func (r *countryMismatchRule) Evaluate(signals *Signals) (int, string) {
+	if signals.BillingCountry == "" || signals.ShippingCountry == "" {
+		return 0, ""
+	}
+
+	if strings.EqualFold(signals.BillingCountry, signals.ShippingCountry) {
+		return 0, ""
+	}
+
+	return 25, fmt.Sprintf("billing country %s differs from shipping country %s",
+		strings.ToUpper(signals.BillingCountry), strings.ToUpper(signals.ShippingCountry))
+}
+
+// deviceRule flags checkouts without a device fingerprint and devices or IP
+// addresses shared by many accounts
+type deviceRule struct {
+	maxAccountsPerDevice int
+	maxAccountsPerIP     int
+}
+
+func (r *deviceRule) Evaluate(signals *Signals) (int, string) {
+	score := 0
+	var reasons []string
+
+	if signals.DeviceID == "" {
+		score += 10
+		reasons = append(reasons, "missing device fingerprint")
+	} else if r.maxAccountsPerDevice > 0 && signals.AccountsOnDevice > r.maxAccountsPerDevice {
+		score += 35
+		reasons = append(reasons, fmt.Sprintf("device used by %d accounts", signals.AccountsOnDevice))
+	}
+
+	if signals.IPAddress != "" && r.maxAccountsPerIP > 0 && signals.AccountsOnIP > r.maxAccountsPerIP {
+		score += 25
+		reasons = append(reasons, fmt.Sprintf("IP address used by %d accounts", signals.AccountsOnIP))
+	}
+
+	return score, strings.Join(reasons, "; ")
+}
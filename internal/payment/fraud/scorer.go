@@ -0,0 +1,96 @@
+package fraud
+
+import (
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// Decision is the outcome of a fraud assessment
+type Decision string
+
+const (
+	// DecisionPass lets the checkout proceed
+	DecisionPass Decision = "pass"
+	// DecisionReview authorizes the payment but holds it for manual review before capture
+	DecisionReview Decision = "review"
+	// DecisionDecline rejects the checkout without contacting the provider
+	DecisionDecline Decision = "decline"
+)
+
+// Signals describes a checkout attempt and the customer's recent activity.
+// Counts cover the configured velocity window and include the current attempt.
+type Signals struct {
+	Amount   int64
+	Currency string
+
+	BillingCountry  string
+	ShippingCountry string
+
+	IPAddress string
+	DeviceID  string
+
+	// RecentAttempts and RecentFailures count the user's payment attempts
+	RecentAttempts int
+	RecentFailures int
+	// AccountsOnDevice and AccountsOnIP count distinct users seen on the device or IP address
+	AccountsOnDevice int
+	AccountsOnIP     int
+}
+
+// Assessment is a fraud score with the decision it leads to
+type Assessment struct {
+	Score    int
+	Decision Decision
+	Reasons  []string
+}
+
+// Rule scores one aspect of a checkout. A rule returns 0 if it doesn't apply.
+type Rule interface {
+	Evaluate(signals *Signals) (score int, reason string)
+}
+
+// Scorer combines the scores of a set of rules into a decision
+type Scorer struct {
+	rules            []Rule
+	reviewThreshold  int
+	declineThreshold int
+}
+
+// NewScorer creates a scorer with the default rules
+func NewScorer(cfg config.FraudConfig) *Scorer {
+	return &Scorer{
+		rules: []Rule{
+			&velocityRule{maxAttempts: cfg.MaxAttempts, maxFailures: cfg.MaxFailures},
+			&countryMismatchRule{},
+			&deviceRule{maxAccountsPerDevice: cfg.MaxAccountsPerDevice, maxAccountsPerIP: cfg.MaxAccountsPerIP},
+		},
+		reviewThreshold:  cfg.ReviewThreshold,
+		declineThreshold: cfg.DeclineThreshold,
+	}
+}
+
+// Evaluate scores a checkout. Scores are capped at 100.
+func (s *Scorer) Evaluate(signals *Signals) *Assessment {
+	assessment := &Assessment{Decision: DecisionPass, Reasons: []string{}}
+
+	for _, rule := range s.rules {
+		score, reason := rule.Evaluate(signals)
+		if score <= 0 {
+			continue
+		}
+		assessment.Score += score
+		assessment.Reasons = append(assessment.Reasons, reason)
+	}
+
+	if assessment.Score > 100 {
+		assessment.Score = 100
+	}
+
+	switch {
+	case assessment.Score >= s.declineThreshold:
+		assessment.Decision = DecisionDecline
+	case assessment.Score >= s.reviewThreshold:
+		assessment.Decision = DecisionReview
+	}
+
+	return assessment
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/payment/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/validation"
+)
+
+// ListFraudReviews returns the fraud review queue (admin)
+func (h *PaymentHandler) ListFraudReviews(c *gin.Context) {
+	var req models.ListFraudReviewsRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	reviews, err := h.paymentService.ListFraudReviews(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to list fraud reviews", "error", err)
+		h.respondError(c, err, "Failed to list fraud reviews")
+		return
+	}
+
+	httpx.Items(c, reviews)
+}
+
+// ReviewFraudAssessment approves or rejects a checkout held for fraud review (admin)
+func (h *PaymentHandler) ReviewFraudAssessment(c *gin.Context) {
+	reviewerID := auth.UserIDFromContext(c)
+	if reviewerID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	assessmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assessment ID"})
+		return
+	}
+
+	var req models.FraudReviewRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	assessment, err := h.paymentService.ReviewFraudAssessment(c.Request.Context(), reviewerID, assessmentID, &req)
+	if err != nil {
+		h.logger.Error("Fraud review failed", "error", err, "assessment_id", assessmentID, "reviewer_id", reviewerID)
+		h.respondError(c, err, "Failed to review fraud assessment")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Fraud review completed",
+		"assessment": assessment,
+	})
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/payment/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/validation"
+)
+
+// IssueGiftCard issues a gift card and returns its code (admin)
+func (h *PaymentHandler) IssueGiftCard(c *gin.Context) {
+	issuerID := auth.UserIDFromContext(c)
+	if issuerID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.IssueGiftCardRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	issued, err := h.paymentService.IssueGiftCard(c.Request.Context(), issuerID, &req)
+	if err != nil {
+		h.logger.Error("Failed to issue gift card", "error", err, "issuer_id", issuerID)
+		h.respondError(c, err, "Failed to issue gift card")
+		return
+	}
+
+	c.JSON(http.StatusCreated, issued)
+}
+
+// GetGiftCard returns a gift card with its ledger (admin)
+func (h *PaymentHandler) GetGiftCard(c *gin.Context) {
+	giftCardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid gift card ID"})
+		return
+	}
+
+	card, err := h.paymentService.GetGiftCard(c.Request.Context(), giftCardID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get gift card")
+		return
+	}
+
+	c.JSON(http.StatusOK, card)
+}
+
+// DisableGiftCard stops a gift card from being redeemed (admin)
+func (h *PaymentHandler) DisableGiftCard(c *gin.Context) {
+	giftCardID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid gift card ID"})
+		return
+	}
+
+	card, err := h.paymentService.DisableGiftCard(c.Request.Context(), giftCardID)
+	if err != nil {
+		h.logger.Error("Failed to disable gift card", "error", err, "gift_card_id", giftCardID)
+		h.respondError(c, err, "Failed to disable gift card")
+		return
+	}
+
+	c.JSON(http.StatusOK, card)
+}
+
+// CheckGiftCardBalance returns the balance of a gift card code
+func (h *PaymentHandler) CheckGiftCardBalance(c *gin.Context) {
+	var req models.GiftCardBalanceRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	balance, err := h.paymentService.CheckGiftCardBalance(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to check gift card balance")
+		return
+	}
+
+	c.JSON(http.StatusOK, balance)
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/payment/models"
+	"github.com/kaanevranportfolio/Commercium/internal/payment/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/idempotency"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/validation"
+)
+
+const (
+	// checkoutKeyTTL is how long a checkout response is replayed for retries
+	checkoutKeyTTL = 24 * time.Hour
+	// checkoutKeyLease must outlast a provider call, after which a retry may take over
+	checkoutKeyLease = time.Minute
+)
+
+// PaymentHandler handles HTTP requests for payment operations
+type PaymentHandler struct {
+	paymentService   service.PaymentService
+	jwtService       *auth.JWTService
+	idempotencyStore *idempotency.Store
+	logger           *logger.Logger
+}
+
+// NewPaymentHandler creates a new payment handler
+func NewPaymentHandler(
+	paymentService service.PaymentService,
+	jwtService *auth.JWTService,
+	idempotencyStore *idempotency.Store,
+	logger *logger.Logger,
+) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService:   paymentService,
+		jwtService:       jwtService,
+		idempotencyStore: idempotencyStore,
+		logger:           logger,
+	}
+}
+
+// Authorize authorizes payment for one of the authenticated user's orders.
+// Requests must carry an Idempotency-Key header so retried checkouts never
+// charge twice.
+func (h *PaymentHandler) Authorize(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.AuthorizePaymentRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+	req.IPAddress = c.ClientIP()
+
+	payment, err := h.paymentService.Authorize(c.Request.Context(), userID, &req, c.GetHeader(idempotency.HeaderKey))
+	if err != nil {
+		h.logger.Error("Payment authorization failed", "error", err, "user_id", userID, "order_id", req.OrderID)
+		h.respondError(c, err, "Failed to authorize payment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Payment authorized successfully",
+		"payment": payment,
+	})
+}
+
+// GetPayment returns one of the authenticated user's payments
+func (h *PaymentHandler) GetPayment(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return
+	}
+
+	payment, err := h.paymentService.GetPayment(c.Request.Context(), userID, paymentID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get payment")
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// Capture captures an authorized payment (internal)
+func (h *PaymentHandler) Capture(c *gin.Context) {
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return
+	}
+
+	var req models.CaptureRequest
+	if !validation.BindOptionalJSON(c, &req) {
+		return
+	}
+
+	payment, err := h.paymentService.Capture(c.Request.Context(), paymentID, &req)
+	if err != nil {
+		h.logger.Error("Payment capture failed", "error", err, "payment_id", paymentID)
+		h.respondError(c, err, "Failed to capture payment")
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// Void voids an authorized payment (internal)
+func (h *PaymentHandler) Void(c *gin.Context) {
+	paymentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return
+	}
+
+	payment, err := h.paymentService.Void(c.Request.Context(), paymentID)
+	if err != nil {
+		h.logger.Error("Payment void failed", "error", err, "payment_id", paymentID)
+		h.respondError(c, err, "Failed to void payment")
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// Refund returns money for an order on behalf of the order service (internal)
+func (h *PaymentHandler) Refund(c *gin.Context) {
+	var req models.RefundRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	response, err := h.paymentService.RefundOrder(c.Request.Context(), &req, c.GetHeader(idempotency.HeaderKey))
+	if err != nil {
+		h.logger.Error("Refund failed", "error", err, "order_id", req.OrderID, "refund_id", req.RefundID)
+		h.respondError(c, err, "Failed to refund payment")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AuthorizeCheckout authorizes payment of an order placed at checkout by the
+// order service (internal). Retries carry the same Idempotency-Key and return
+// the payment of the first request.
+func (h *PaymentHandler) AuthorizeCheckout(c *gin.Context) {
+	var req models.CheckoutAuthorizeRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+	req.IPAddress = req.ClientIP
+
+	payment, err := h.paymentService.Authorize(c.Request.Context(), req.UserID, &req.AuthorizePaymentRequest, c.GetHeader(idempotency.HeaderKey))
+	if err != nil {
+		h.logger.Error("Checkout payment authorization failed", "error", err, "user_id", req.UserID, "order_id", req.OrderID)
+		h.respondError(c, err, "Failed to authorize payment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
+
+// ChargeRecurring charges a subscription renewal (internal)
+func (h *PaymentHandler) ChargeRecurring(c *gin.Context) {
+	var req models.RecurringChargeRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	payment, err := h.paymentService.ChargeRecurring(c.Request.Context(), &req, c.GetHeader(idempotency.HeaderKey))
+	if err != nil {
+		h.logger.Error("Recurring charge failed", "error", err, "order_id", req.OrderID, "user_id", req.UserID)
+		h.respondError(c, err, "Failed to charge payment")
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// Webhook receives provider callbacks. Events are verified and queued before
+// they are acknowledged; processing happens asynchronously.
+func (h *PaymentHandler) Webhook(c *gin.Context) {
+	provider := c.Param("provider")
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := h.paymentService.HandleWebhook(c.Request.Context(), provider, c.Request.Header, body); err != nil {
+		h.logger.Error("Webhook processing failed", "error", err, "provider", provider)
+		// Failures other than unknown providers and invalid deliveries make
+		// the provider retry the delivery
+		apperrors.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"received": true})
+}
+
+// respondError logs a request failing on the side of the service, or of a
+// provider it depends on, and answers it with the problem details of err,
+// so middleware reading its status after it, such as idempotency's, sees
+// the failure
+func (h *PaymentHandler) respondError(c *gin.Context, err error, message string) {
+	if apperrors.HTTPStatus(err) >= http.StatusInternalServerError {
+		logger.FromContext(c.Request.Context()).Error(message, "error", err)
+	}
+	apperrors.Respond(c, err)
+}
+
+// SetupRoutes sets up the payment routes.
+// Internal routes are called by other services and must not be exposed through the gateway.
+func (h *PaymentHandler) SetupRoutes(r *gin.Engine) {
+	payments := r.Group("/api/v1/payments")
+	payments.Use(h.jwtService.Middleware())
+	{
+		payments.POST("", idempotency.Middleware(h.idempotencyStore, idempotency.Options{
+			TTL:      checkoutKeyTTL,
+			Lease:    checkoutKeyLease,
+			Required: true,
+			Scope: func(c *gin.Context) string {
+				return auth.UserIDFromContext(c).String()
+			},
+		}), h.Authorize)
+		payments.GET("/:id", h.GetPayment)
+	}
+
+	giftCards := r.Group("/api/v1/gift-cards")
+	giftCards.Use(h.jwtService.Middleware())
+	{
+		giftCards.POST("/balance", h.CheckGiftCardBalance)
+	}
+
+	admin := r.Group("/api/v1/admin/fraud")
+	admin.Use(h.jwtService.Middleware(), auth.RequireRole("admin"))
+	{
+		admin.GET("/reviews", h.ListFraudReviews)
+		admin.POST("/reviews/:id", h.ReviewFraudAssessment)
+	}
+
+	adminGiftCards := r.Group("/api/v1/admin/gift-cards")
+	adminGiftCards.Use(h.jwtService.Middleware(), auth.RequireRole("admin"))
+	{
+		adminGiftCards.POST("", h.IssueGiftCard)
+		adminGiftCards.GET("/:id", h.GetGiftCard)
+		adminGiftCards.POST("/:id/disable", h.DisableGiftCard)
+	}
+
+	internal := r.Group("/internal/v1")
+	{
+		internal.POST("/payments/authorize", h.AuthorizeCheckout)
+		internal.POST("/payments/:id/capture", h.Capture)
+		internal.POST("/payments/:id/void", h.Void)
+		internal.POST("/refunds", h.Refund)
+		internal.POST("/payments/recurring", h.ChargeRecurring)
+	}
+
+	r.POST("/webhooks/:provider", h.Webhook)
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Payment event types published to the payment events topic
+const (
+	EventPaymentAuthorized = "payment.authorized"
+	EventPaymentCaptured   = "payment.captured"
+	EventPaymentVoided     = "payment.voided"
+	EventPaymentRefunded   = "payment.refunded"
+	EventPaymentFailed     = "payment.failed"
+)
+
+// PaymentEventSchemaVersion is the schema version of PaymentEvent payloads
+const PaymentEventSchemaVersion = 1
+
+// PaymentEvent is published whenever the state of a payment changes
+type PaymentEvent struct {
+	Type       string    `json:"type"`
+	PaymentID  uuid.UUID `json:"payment_id"`
+	OrderID    uuid.UUID `json:"order_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Provider   string    `json:"provider"`
+	Status     string    `json:"status"`
+	Amount     int64     `json:"amount"`
+	Currency   string    `json:"currency"`
+	Reason     string    `json:"reason,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+)
+
+// FraudReviewStatus represents the state of a manual fraud review
+type FraudReviewStatus string
+
+const (
+	FraudReviewStatusPending  FraudReviewStatus = "pending"
+	FraudReviewStatusApproved FraudReviewStatus = "approved"
+	FraudReviewStatusRejected FraudReviewStatus = "rejected"
+)
+
+// FraudAssessment records the fraud score of a checkout and, for checkouts
+// held for review, the outcome of the review
+type FraudAssessment struct {
+	ID              uuid.UUID            `json:"id" db:"id"`
+	PaymentID       uuid.UUID            `json:"payment_id" db:"payment_id"`
+	OrderID         uuid.UUID            `json:"order_id" db:"order_id"`
+	UserID          uuid.UUID            `json:"user_id" db:"user_id"`
+	Score           int                  `json:"score" db:"score"`
+	Decision        string               `json:"decision" db:"decision"`
+	Reasons         database.StringArray `json:"reasons" db:"reasons"`
+	IPAddress       *string              `json:"ip_address,omitempty" db:"ip_address"`
+	DeviceID        *string              `json:"device_id,omitempty" db:"device_id"`
+	BillingCountry  *string              `json:"billing_country,omitempty" db:"billing_country"`
+	ShippingCountry *string              `json:"shipping_country,omitempty" db:"shipping_country"`
+	ReviewStatus    *FraudReviewStatus   `json:"review_status,omitempty" db:"review_status"`
+	ReviewedBy      *uuid.UUID           `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewNotes     *string              `json:"review_notes,omitempty" db:"review_notes"`
+	ReviewedAt      *time.Time           `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	CreatedAt       time.Time            `json:"created_at" db:"created_at"`
+}
+
+// FraudVelocity holds recent activity counts used as fraud signals
+type FraudVelocity struct {
+	Attempts         int `db:"attempts"`
+	Failures         int `db:"failures"`
+	AccountsOnDevice int `db:"accounts_on_device"`
+	AccountsOnIP     int `db:"accounts_on_ip"`
+}
+
+// FraudReviewRequest represents an admin's decision on a held checkout
+type FraudReviewRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=approve reject"`
+	Notes    string `json:"notes,omitempty" binding:"max=1000"`
+}
+
+// ListFraudReviewsRequest represents query parameters for the review queue
+type ListFraudReviewsRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending approved rejected"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
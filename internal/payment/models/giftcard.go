@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GiftCardProvider is the provider recorded on payments fully covered by gift cards
+const GiftCardProvider = "gift_card"
+
+// GiftCardStatus represents whether a gift card can be redeemed
+type GiftCardStatus string
+
+const (
+	GiftCardStatusActive   GiftCardStatus = "active"
+	GiftCardStatusDisabled GiftCardStatus = "disabled"
+)
+
+// GiftCardEntryType represents the reason for a gift card balance change
+type GiftCardEntryType string
+
+const (
+	GiftCardEntryIssue    GiftCardEntryType = "issue"
+	GiftCardEntryRedeem   GiftCardEntryType = "redeem"
+	GiftCardEntryReversal GiftCardEntryType = "reversal"
+	GiftCardEntryRefund   GiftCardEntryType = "refund"
+)
+
+// GiftCard represents a prepaid balance redeemable at checkout.
+// All amounts are in minor currency units.
+type GiftCard struct {
+	ID             uuid.UUID      `json:"id" db:"id"`
+	CodeHash       string         `json:"-" db:"code_hash"`
+	LastFour       string         `json:"last_four" db:"last_four"`
+	Currency       string         `json:"currency" db:"currency"`
+	InitialAmount  int64          `json:"initial_amount" db:"initial_amount"`
+	Balance        int64          `json:"balance" db:"balance"`
+	Status         GiftCardStatus `json:"status" db:"status"`
+	ExpiresAt      *time.Time     `json:"expires_at,omitempty" db:"expires_at"`
+	RecipientEmail *string        `json:"recipient_email,omitempty" db:"recipient_email"`
+	Note           *string        `json:"note,omitempty" db:"note"`
+	IssuedBy       uuid.UUID      `json:"issued_by" db:"issued_by"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+
+	Ledger []*GiftCardLedgerEntry `json:"ledger,omitempty" db:"-"`
+}
+
+// IsExpired reports whether the gift card expired before the given time
+func (g *GiftCard) IsExpired(now time.Time) bool {
+	return g.ExpiresAt != nil && !now.Before(*g.ExpiresAt)
+}
+
+// GiftCardLedgerEntry records a single change of a gift card balance.
+// Credits are positive, debits negative.
+type GiftCardLedgerEntry struct {
+	ID           uuid.UUID         `json:"id" db:"id"`
+	GiftCardID   uuid.UUID         `json:"gift_card_id" db:"gift_card_id"`
+	Type         GiftCardEntryType `json:"type" db:"type"`
+	Amount       int64             `json:"amount" db:"amount"`
+	BalanceAfter int64             `json:"balance_after" db:"balance_after"`
+	PaymentID    *uuid.UUID        `json:"payment_id,omitempty" db:"payment_id"`
+	OrderID      *uuid.UUID        `json:"order_id,omitempty" db:"order_id"`
+	Reference    *string           `json:"-" db:"reference"`
+	CreatedBy    *uuid.UUID        `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt    time.Time         `json:"created_at" db:"created_at"`
+}
+
+// IssueGiftCardRequest represents a request to issue a gift card
+type IssueGiftCardRequest struct {
+	Amount         int64      `json:"amount" binding:"required,gt=0"`
+	Currency       string     `json:"currency" binding:"required,len=3"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	RecipientEmail string     `json:"recipient_email,omitempty" binding:"omitempty,email,max=255"`
+	Note           string     `json:"note,omitempty" binding:"max=500"`
+}
+
+// IssuedGiftCard is returned once when a gift card is issued. The code can't
+// be retrieved again.
+type IssuedGiftCard struct {
+	Code     string    `json:"code"`
+	GiftCard *GiftCard `json:"gift_card"`
+}
+
+// GiftCardBalanceRequest represents a balance check of a gift card code
+type GiftCardBalanceRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+// GiftCardBalance is the customer-facing view of a gift card
+type GiftCardBalance struct {
+	LastFour  string         `json:"last_four"`
+	Currency  string         `json:"currency"`
+	Balance   int64          `json:"balance"`
+	Status    GiftCardStatus `json:"status"`
+	Expired   bool           `json:"expired"`
+	ExpiresAt *time.Time     `json:"expires_at,omitempty"`
+}
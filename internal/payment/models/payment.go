@@ -0,0 +1,161 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentStatus represents the lifecycle state of a payment
+type PaymentStatus string
+
+const (
+	PaymentStatusPending           PaymentStatus = "pending"
+	PaymentStatusAuthorized        PaymentStatus = "authorized"
+	PaymentStatusCaptured          PaymentStatus = "captured"
+	PaymentStatusPartiallyRefunded PaymentStatus = "partially_refunded"
+	PaymentStatusRefunded          PaymentStatus = "refunded"
+	PaymentStatusVoided            PaymentStatus = "voided"
+	PaymentStatusFailed            PaymentStatus = "failed"
+)
+
+// TransactionType represents the provider operation recorded by a transaction
+type TransactionType string
+
+const (
+	TransactionTypeAuthorize TransactionType = "authorize"
+	TransactionTypeCapture   TransactionType = "capture"
+	TransactionTypeRefund    TransactionType = "refund"
+	TransactionTypeVoid      TransactionType = "void"
+)
+
+// TransactionStatus represents the outcome of a provider operation
+type TransactionStatus string
+
+const (
+	TransactionStatusSucceeded TransactionStatus = "succeeded"
+	TransactionStatusFailed    TransactionStatus = "failed"
+)
+
+// Payment represents a payment intent for an order.
+// All amounts are in minor currency units. GiftCardAmount is the part of
+// Amount paid with gift cards; the provider is charged the rest.
+type Payment struct {
+	ID                     uuid.UUID     `json:"id" db:"id"`
+	OrderID                uuid.UUID     `json:"order_id" db:"order_id"`
+	UserID                 uuid.UUID     `json:"user_id" db:"user_id"`
+	Provider               string        `json:"provider" db:"provider"`
+	ProviderPaymentID      *string       `json:"provider_payment_id,omitempty" db:"provider_payment_id"`
+	Status                 PaymentStatus `json:"status" db:"status"`
+	Currency               string        `json:"currency" db:"currency"`
+	Amount                 int64         `json:"amount" db:"amount"`
+	CapturedAmount         int64         `json:"captured_amount" db:"captured_amount"`
+	RefundedAmount         int64         `json:"refunded_amount" db:"refunded_amount"`
+	GiftCardAmount         int64         `json:"gift_card_amount" db:"gift_card_amount"`
+	GiftCardRefundedAmount int64         `json:"gift_card_refunded_amount" db:"gift_card_refunded_amount"`
+	FailureReason          *string       `json:"failure_reason,omitempty" db:"failure_reason"`
+	IdempotencyKey         *string       `json:"-" db:"idempotency_key"`
+	CreatedAt              time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt              time.Time     `json:"updated_at" db:"updated_at"`
+
+	Transactions []*Transaction `json:"transactions,omitempty" db:"-"`
+}
+
+// ProviderAmount returns the part of the payment charged through the provider
+func (p *Payment) ProviderAmount() int64 {
+	return p.Amount - p.GiftCardAmount
+}
+
+// RefundableAmount returns the captured amount that has not been refunded yet
+func (p *Payment) RefundableAmount() int64 {
+	return p.CapturedAmount - p.RefundedAmount
+}
+
+// GiftCardRefundableAmount returns the gift card amount that has not been refunded yet
+func (p *Payment) GiftCardRefundableAmount() int64 {
+	return p.GiftCardAmount - p.GiftCardRefundedAmount
+}
+
+// Transaction records a single operation performed against the payment provider
+type Transaction struct {
+	ID                    uuid.UUID         `json:"id" db:"id"`
+	PaymentID             uuid.UUID         `json:"payment_id" db:"payment_id"`
+	Type                  TransactionType   `json:"type" db:"type"`
+	Status                TransactionStatus `json:"status" db:"status"`
+	Amount                int64             `json:"amount" db:"amount"`
+	ProviderTransactionID *string           `json:"provider_transaction_id,omitempty" db:"provider_transaction_id"`
+	IdempotencyKey        *string           `json:"-" db:"idempotency_key"`
+	ErrorMessage          *string           `json:"error_message,omitempty" db:"error_message"`
+	CreatedAt             time.Time         `json:"created_at" db:"created_at"`
+}
+
+// PayableOrder holds the order fields needed to authorize a payment
+type PayableOrder struct {
+	ID          uuid.UUID `db:"id"`
+	UserID      uuid.UUID `db:"user_id"`
+	Status      string    `db:"status"`
+	Currency    string    `db:"currency"`
+	TotalAmount int64     `db:"total_amount"`
+
+	BillingCountry  *string `db:"billing_country"`
+	ShippingCountry *string `db:"shipping_country"`
+}
+
+// AuthorizePaymentRequest represents a request to authorize payment for an order.
+// PaymentMethod is the provider's token for the customer's payment method
+// (a Stripe PaymentMethod ID, an approved PayPal order ID). The provider is
+// chosen from PaymentMethodType unless Provider is given explicitly.
+// GiftCardCodes are redeemed first, in order; PaymentMethod may be omitted
+// when they cover the order total.
+type AuthorizePaymentRequest struct {
+	OrderID           uuid.UUID `json:"order_id" binding:"required"`
+	PaymentMethod     string    `json:"payment_method" binding:"required_without=GiftCardCodes"`
+	GiftCardCodes     []string  `json:"gift_card_codes,omitempty" binding:"omitempty,max=5,dive,required,max=32"`
+	PaymentMethodType string    `json:"payment_method_type,omitempty"`
+	Provider          string    `json:"provider,omitempty"`
+	// DeviceID is the client's device fingerprint, used for fraud screening
+	DeviceID string `json:"device_id,omitempty" binding:"max=255"`
+
+	// IPAddress is set from the request by the handler
+	IPAddress string `json:"-"`
+}
+
+// CheckoutAuthorizeRequest is sent by the order service to authorize payment
+// of an order it placed during checkout, on behalf of the customer
+type CheckoutAuthorizeRequest struct {
+	AuthorizePaymentRequest
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+	// ClientIP is the IP address the customer checked out from
+	ClientIP string `json:"client_ip,omitempty"`
+}
+
+// RecurringChargeRequest is sent by the subscription service to charge a
+// renewal order to the customer's saved payment method
+type RecurringChargeRequest struct {
+	UserID            uuid.UUID `json:"user_id" binding:"required"`
+	OrderID           uuid.UUID `json:"order_id" binding:"required"`
+	PaymentMethod     string    `json:"payment_method" binding:"required"`
+	PaymentMethodType string    `json:"payment_method_type,omitempty"`
+	Provider          string    `json:"provider,omitempty"`
+}
+
+// CaptureRequest represents a request to capture an authorized payment.
+// A zero amount captures the full authorized amount.
+type CaptureRequest struct {
+	Amount int64 `json:"amount" binding:"min=0"`
+}
+
+// RefundRequest is sent by the order service to return money for an order
+type RefundRequest struct {
+	OrderID  uuid.UUID `json:"order_id" binding:"required"`
+	RefundID uuid.UUID `json:"refund_id" binding:"required"`
+	Amount   int64     `json:"amount" binding:"required,gt=0"`
+	Currency string    `json:"currency" binding:"required,len=3"`
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// RefundResponse is returned to the order service once the provider accepted the refund
+type RefundResponse struct {
+	ProviderRefundID string `json:"provider_refund_id"`
+	Status           string `json:"status"`
+}
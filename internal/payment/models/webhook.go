@@ -0,0 +1,82 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookEventStatus represents the processing state of a stored webhook event
+type WebhookEventStatus string
+
+const (
+	WebhookEventStatusPending    WebhookEventStatus = "pending"
+	WebhookEventStatusProcessing WebhookEventStatus = "processing"
+	WebhookEventStatusProcessed  WebhookEventStatus = "processed"
+	WebhookEventStatusIgnored    WebhookEventStatus = "ignored"
+	WebhookEventStatusFailed     WebhookEventStatus = "failed"
+)
+
+// WebhookEvent is a verified provider callback waiting to be, or already, processed
+type WebhookEvent struct {
+	ID                uuid.UUID          `json:"id" db:"id"`
+	Provider          string             `json:"provider" db:"provider"`
+	EventID           string             `json:"event_id" db:"event_id"`
+	ProviderEventType string             `json:"provider_event_type" db:"provider_event_type"`
+	EventType         *string            `json:"event_type,omitempty" db:"event_type"`
+	ProviderPaymentID *string            `json:"provider_payment_id,omitempty" db:"provider_payment_id"`
+	RelatedPaymentID  *string            `json:"related_payment_id,omitempty" db:"related_payment_id"`
+	Amount            int64              `json:"amount" db:"amount"`
+	Currency          *string            `json:"currency,omitempty" db:"currency"`
+	Payload           RawJSON            `json:"payload" db:"payload"`
+	Status            WebhookEventStatus `json:"status" db:"status"`
+	Attempts          int                `json:"attempts" db:"attempts"`
+	LastError         *string            `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt     time.Time          `json:"next_attempt_at" db:"next_attempt_at"`
+	ReceivedAt        time.Time          `json:"received_at" db:"received_at"`
+	ProcessedAt       *time.Time         `json:"processed_at,omitempty" db:"processed_at"`
+}
+
+// RawJSON is a JSON document stored verbatim in a JSONB column
+type RawJSON json.RawMessage
+
+// Value implements the driver.Valuer interface. The document is sent as text,
+// since []byte parameters would be encoded as bytea.
+func (j RawJSON) Value() (driver.Value, error) {
+	if j == nil {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+// Scan implements the sql.Scanner interface
+func (j *RawJSON) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append((*j)[:0], v...)
+	case string:
+		*j = RawJSON(v)
+	default:
+		return fmt.Errorf("cannot scan %T into RawJSON", value)
+	}
+	return nil
+}
+
+// MarshalJSON returns the document as is
+func (j RawJSON) MarshalJSON() ([]byte, error) {
+	if j == nil {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+// UnmarshalJSON stores a copy of the document
+func (j *RawJSON) UnmarshalJSON(data []byte) error {
+	*j = append((*j)[:0], data...)
+	return nil
+}
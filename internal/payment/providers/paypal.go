@@ -0,0 +1,415 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/resilience"
+)
+
+const paypalProviderName = "paypal"
+
+// paypalZeroDecimalCurrencies are the currencies PayPal accepts without a fractional part
+var paypalZeroDecimalCurrencies = map[string]bool{
+	"HUF": true,
+	"JPY": true,
+	"TWD": true,
+}
+
+// paypalProvider implements PaymentProvider against the PayPal Orders v2 API.
+// The buyer approves a PayPal order on the client side; the approved order ID
+// is passed as the payment method and authorized here. Authorizations are then
+// captured, voided or, once captured, refunded through the Payments v2 API.
+type paypalProvider struct {
+	apiURL       string
+	clientID     string
+	clientSecret string
+	webhookID    string
+	httpClient   *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	tokenExpiry time.Time
+}
+
+// paypalAmount is PayPal's money representation, in major units
+type paypalAmount struct {
+	CurrencyCode string `json:"currency_code"`
+	Value        string `json:"value"`
+}
+
+// paypalOrder is the subset of the Order object we use
+type paypalOrder struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	PurchaseUnits []struct {
+		Amount   paypalAmount `json:"amount"`
+		Payments struct {
+			Authorizations []paypalPayment `json:"authorizations"`
+		} `json:"payments"`
+	} `json:"purchase_units"`
+}
+
+// paypalPayment is the subset of the Authorization, Capture and Refund objects we use
+type paypalPayment struct {
+	ID     string        `json:"id"`
+	Status string        `json:"status"`
+	Amount *paypalAmount `json:"amount"`
+}
+
+// paypalErrorResponse is the error envelope returned by the PayPal API
+type paypalErrorResponse struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Details []struct {
+		Issue       string `json:"issue"`
+		Description string `json:"description"`
+	} `json:"details"`
+}
+
+// paypalWebhookEvent is the envelope of PayPal webhook notifications
+type paypalWebhookEvent struct {
+	ID         string    `json:"id"`
+	EventType  string    `json:"event_type"`
+	CreateTime time.Time `json:"create_time"`
+	Resource   struct {
+		ID                string        `json:"id"`
+		Status            string        `json:"status"`
+		Amount            *paypalAmount `json:"amount"`
+		SupplementaryData struct {
+			RelatedIDs struct {
+				AuthorizationID string `json:"authorization_id"`
+			} `json:"related_ids"`
+		} `json:"supplementary_data"`
+		SellerPayableBreakdown struct {
+			TotalRefundedAmount *paypalAmount `json:"total_refunded_amount"`
+		} `json:"seller_payable_breakdown"`
+		Links []struct {
+			Href string `json:"href"`
+			Rel  string `json:"rel"`
+		} `json:"links"`
+	} `json:"resource"`
+}
+
+// NewPayPalProvider creates a new PayPal payment provider, calling PayPal
+// through dependency
+func NewPayPalProvider(cfg config.PayPalConfig, dependency *resilience.Dependency) (PaymentProvider, error) {
+	if cfg.ClientID == "" || cfg.ClientSecret == "" {
+		return nil, fmt.Errorf("paypal client id and secret are required")
+	}
+
+	return &paypalProvider{
+		apiURL:       strings.TrimRight(cfg.APIURL, "/"),
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		webhookID:    cfg.WebhookID,
+		httpClient:   dependency.HTTPClient(nil, "PayPal-Request-Id"),
+	}, nil
+}
+
+// Name returns the provider name
+func (p *paypalProvider) Name() string {
+	return paypalProviderName
+}
+
+// Authorize authorizes a PayPal order the buyer has approved. The order amount
+// is checked against the expected amount so a tampered order can't be used.
+func (p *paypalProvider) Authorize(ctx context.Context, req *AuthorizeRequest) (*Result, error) {
+	order := &paypalOrder{}
+	if err := p.do(ctx, http.MethodGet, "/v2/checkout/orders/"+url.PathEscape(req.PaymentMethod), nil, "", order); err != nil {
+		return nil, err
+	}
+
+	if order.Status != "APPROVED" {
+		return nil, &ProviderError{Provider: paypalProviderName, Code: order.Status, Message: "order has not been approved by the buyer"}
+	}
+
+	expected := paypalAmount{CurrencyCode: strings.ToUpper(req.Currency), Value: formatPayPalAmount(req.Amount, req.Currency)}
+	if len(order.PurchaseUnits) != 1 || order.PurchaseUnits[0].Amount != expected {
+		return nil, &ProviderError{Provider: paypalProviderName, Message: "order amount does not match the payment amount"}
+	}
+
+	if err := p.do(ctx, http.MethodPost, "/v2/checkout/orders/"+url.PathEscape(order.ID)+"/authorize", struct{}{}, req.IdempotencyKey, order); err != nil {
+		return nil, err
+	}
+
+	if len(order.PurchaseUnits) == 0 || len(order.PurchaseUnits[0].Payments.Authorizations) == 0 {
+		return nil, fmt.Errorf("paypal returned no authorization for order %s", order.ID)
+	}
+
+	authorization := order.PurchaseUnits[0].Payments.Authorizations[0]
+	if authorization.Status != "CREATED" && authorization.Status != "PENDING" {
+		return &Result{ID: authorization.ID, Status: StatusFailed, Amount: req.Amount}, &ProviderError{
+			Provider: paypalProviderName,
+			Code:     authorization.Status,
+			Message:  "payment was not authorized",
+			Declined: authorization.Status == "DENIED",
+		}
+	}
+
+	return &Result{ID: authorization.ID, Status: StatusAuthorized, Amount: req.Amount}, nil
+}
+
+// Capture captures an authorization. The returned ID identifies the capture,
+// which is what later refunds refer to.
+func (p *paypalProvider) Capture(ctx context.Context, paymentID string, amount int64, currency string, idempotencyKey string) (*Result, error) {
+	body := map[string]interface{}{"final_capture": true}
+	if amount > 0 {
+		body["amount"] = paypalAmount{CurrencyCode: strings.ToUpper(currency), Value: formatPayPalAmount(amount, currency)}
+	}
+
+	capture := &paypalPayment{}
+	if err := p.do(ctx, http.MethodPost, "/v2/payments/authorizations/"+url.PathEscape(paymentID)+"/capture", body, idempotencyKey, capture); err != nil {
+		return nil, err
+	}
+
+	status := StatusCaptured
+	switch capture.Status {
+	case "PENDING":
+		status = StatusPending
+	case "DECLINED", "FAILED":
+		return nil, &ProviderError{Provider: paypalProviderName, Code: capture.Status, Message: "capture was declined"}
+	}
+
+	return &Result{ID: capture.ID, Status: status, Amount: amount}, nil
+}
+
+// Refund refunds part or all of a capture
+func (p *paypalProvider) Refund(ctx context.Context, paymentID string, amount int64, currency string, idempotencyKey string) (*Result, error) {
+	body := map[string]interface{}{
+		"amount": paypalAmount{CurrencyCode: strings.ToUpper(currency), Value: formatPayPalAmount(amount, currency)},
+	}
+
+	refund := &paypalPayment{}
+	if err := p.do(ctx, http.MethodPost, "/v2/payments/captures/"+url.PathEscape(paymentID)+"/refund", body, idempotencyKey, refund); err != nil {
+		return nil, err
+	}
+
+	status := StatusPending
+	switch refund.Status {
+	case "COMPLETED":
+		status = StatusRefunded
+	case "FAILED", "CANCELLED":
+		return nil, &ProviderError{Provider: paypalProviderName, Code: refund.Status, Message: "refund was not completed"}
+	}
+
+	return &Result{ID: refund.ID, Status: status, Amount: amount}, nil
+}
+
+// Void voids an authorization, releasing the hold on the buyer's funds
+func (p *paypalProvider) Void(ctx context.Context, paymentID string, idempotencyKey string) (*Result, error) {
+	if err := p.do(ctx, http.MethodPost, "/v2/payments/authorizations/"+url.PathEscape(paymentID)+"/void", nil, idempotencyKey, nil); err != nil {
+		return nil, err
+	}
+
+	return &Result{ID: paymentID, Status: StatusVoided}, nil
+}
+
+// ParseWebhook verifies a webhook notification with PayPal and translates it
+func (p *paypalProvider) ParseWebhook(ctx context.Context, header http.Header, body []byte) (*WebhookEvent, error) {
+	if p.webhookID == "" {
+		return nil, fmt.Errorf("paypal webhook id is not configured")
+	}
+
+	verification := map[string]interface{}{
+		"auth_algo":         header.Get("PAYPAL-AUTH-ALGO"),
+		"cert_url":          header.Get("PAYPAL-CERT-URL"),
+		"transmission_id":   header.Get("PAYPAL-TRANSMISSION-ID"),
+		"transmission_sig":  header.Get("PAYPAL-TRANSMISSION-SIG"),
+		"transmission_time": header.Get("PAYPAL-TRANSMISSION-TIME"),
+		"webhook_id":        p.webhookID,
+		"webhook_event":     json.RawMessage(body),
+	}
+
+	var result struct {
+		VerificationStatus string `json:"verification_status"`
+	}
+	if err := p.do(ctx, http.MethodPost, "/v1/notifications/verify-webhook-signature", verification, "", &result); err != nil {
+		return nil, fmt.Errorf("failed to verify paypal webhook: %w", err)
+	}
+	if result.VerificationStatus != "SUCCESS" {
+		return nil, apperrors.Validation("invalid webhook signature")
+	}
+
+	raw := &paypalWebhookEvent{}
+	if err := json.Unmarshal(body, raw); err != nil {
+		return nil, apperrors.Validation("invalid webhook payload").Wrap(err)
+	}
+
+	event := &WebhookEvent{
+		ID:           raw.ID,
+		ProviderType: raw.EventType,
+		PaymentID:    raw.Resource.ID,
+		OccurredAt:   raw.CreateTime,
+	}
+	if raw.Resource.Amount != nil {
+		event.Currency = raw.Resource.Amount.CurrencyCode
+		event.Amount, _ = parsePayPalAmount(raw.Resource.Amount.Value, raw.Resource.Amount.CurrencyCode)
+	}
+
+	switch raw.EventType {
+	case "PAYMENT.CAPTURE.COMPLETED":
+		event.Type = WebhookPaymentCaptured
+		event.RelatedPaymentID = raw.Resource.SupplementaryData.RelatedIDs.AuthorizationID
+	case "PAYMENT.CAPTURE.DENIED":
+		event.Type = WebhookPaymentFailed
+		event.RelatedPaymentID = raw.Resource.SupplementaryData.RelatedIDs.AuthorizationID
+	case "PAYMENT.AUTHORIZATION.VOIDED":
+		event.Type = WebhookPaymentVoided
+	case "PAYMENT.CAPTURE.REFUNDED":
+		// The resource is the refund; its "up" link points at the refunded capture.
+		// Report the capture's refunded total, like other providers do.
+		event.Type = WebhookPaymentRefunded
+		for _, link := range raw.Resource.Links {
+			if link.Rel == "up" {
+				event.PaymentID = link.Href[strings.LastIndex(link.Href, "/")+1:]
+			}
+		}
+		if total := raw.Resource.SellerPayableBreakdown.TotalRefundedAmount; total != nil {
+			event.Amount, _ = parsePayPalAmount(total.Value, total.CurrencyCode)
+		}
+	}
+
+	return event, nil
+}
+
+// do sends a JSON request to the PayPal API and decodes the response into out, if any
+func (p *paypalProvider) do(ctx context.Context, method, path string, body interface{}, requestID string, out interface{}) error {
+	token, err := p.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal paypal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.apiURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create paypal request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Prefer", "return=representation")
+	if requestID != "" {
+		req.Header.Set("PayPal-Request-Id", requestID)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call paypal: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		errResp := &paypalErrorResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(errResp); err != nil {
+			return fmt.Errorf("paypal returned status %d", resp.StatusCode)
+		}
+
+		perr := &ProviderError{Provider: paypalProviderName, Code: errResp.Name, Message: errResp.Message}
+		if len(errResp.Details) > 0 {
+			perr.Code = errResp.Details[0].Issue
+			perr.Message = errResp.Details[0].Description
+			perr.Declined = errResp.Details[0].Issue == "INSTRUMENT_DECLINED"
+		}
+		return perr
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode paypal response: %w", err)
+	}
+
+	return nil
+}
+
+// token returns a cached OAuth2 access token, fetching a new one when it is about to expire
+func (p *paypalProvider) token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.accessToken != "" && time.Now().Before(p.tokenExpiry) {
+		return p.accessToken, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL+"/v1/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create paypal token request: %w", err)
+	}
+	req.SetBasicAuth(p.clientID, p.clientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get paypal access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("paypal token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode paypal token response: %w", err)
+	}
+
+	// Refresh a minute early so in-flight requests don't use an expired token
+	p.accessToken = result.AccessToken
+	p.tokenExpiry = time.Now().Add(time.Duration(result.ExpiresIn)*time.Second - time.Minute)
+
+	return p.accessToken, nil
+}
+
+// formatPayPalAmount converts minor units to PayPal's decimal string
+func formatPayPalAmount(amount int64, currency string) string {
+	if paypalZeroDecimalCurrencies[strings.ToUpper(currency)] {
+		return strconv.FormatInt(amount, 10)
+	}
+	return fmt.Sprintf("%d.%02d", amount/100, amount%100)
+}
+
+// parsePayPalAmount converts PayPal's decimal string to minor units
+func parsePayPalAmount(value, currency string) (int64, error) {
+	if paypalZeroDecimalCurrencies[strings.ToUpper(currency)] {
+		return strconv.ParseInt(value, 10, 64)
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	fraction = (fraction + "00")[:2]
+
+	major, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", value, err)
+	}
+	minor, err := strconv.ParseInt(fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", value, err)
+	}
+
+	return major*100 + minor, nil
+}
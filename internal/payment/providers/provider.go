@@ -0,0 +1,112 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+)
+
+// Result statuses reported by providers
+const (
+	StatusAuthorized = "authorized"
+	StatusCaptured   = "captured"
+	StatusRefunded   = "refunded"
+	StatusVoided     = "voided"
+	StatusPending    = "pending"
+	StatusFailed     = "failed"
+)
+
+// AuthorizeRequest holds the data needed to place a hold on the customer's funds
+type AuthorizeRequest struct {
+	Amount         int64
+	Currency       string
+	PaymentMethod  string
+	Description    string
+	IdempotencyKey string
+	Metadata       map[string]string
+}
+
+// Result is the outcome of a provider operation
+type Result struct {
+	// ID is the provider's identifier of the payment or refund
+	ID     string
+	Status string
+	Amount int64
+}
+
+// PaymentProvider abstracts a payment gateway.
+// Amounts are in minor currency units.
+type PaymentProvider interface {
+	Name() string
+	Authorize(ctx context.Context, req *AuthorizeRequest) (*Result, error)
+	Capture(ctx context.Context, paymentID string, amount int64, currency string, idempotencyKey string) (*Result, error)
+	Refund(ctx context.Context, paymentID string, amount int64, currency string, idempotencyKey string) (*Result, error)
+	Void(ctx context.Context, paymentID string, idempotencyKey string) (*Result, error)
+}
+
+// ProviderError is returned when a provider rejects an operation
+type ProviderError struct {
+	Provider string
+	Code     string
+	Message  string
+	// Declined is set when the customer's payment method was declined
+	Declined bool
+}
+
+func (e *ProviderError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s: %s (%s)", e.Provider, e.Message, e.Code)
+	}
+	return fmt.Sprintf("%s: %s", e.Provider, e.Message)
+}
+
+// Registry holds the configured payment providers by name
+type Registry struct {
+	providers       map[string]PaymentProvider
+	defaultProvider string
+	methodProviders map[string]string
+}
+
+// NewRegistry creates a new provider registry.
+// methodProviders maps payment method types to provider names and may be nil.
+func NewRegistry(defaultProvider string, methodProviders map[string]string) *Registry {
+	return &Registry{
+		providers:       make(map[string]PaymentProvider),
+		defaultProvider: defaultProvider,
+		methodProviders: methodProviders,
+	}
+}
+
+// Register adds a provider to the registry
+func (r *Registry) Register(provider PaymentProvider) {
+	r.providers[provider.Name()] = provider
+}
+
+// Get returns the named provider, or the default provider if name is empty
+func (r *Registry) Get(name string) (PaymentProvider, error) {
+	if name == "" {
+		name = r.defaultProvider
+	}
+
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, apperrors.Validation("payment provider not supported: %s", name)
+	}
+
+	return provider, nil
+}
+
+// Select picks the provider for a checkout. An explicitly requested provider wins,
+// then the provider configured for the payment method type, then the default.
+func (r *Registry) Select(name, methodType string) (PaymentProvider, error) {
+	if name == "" && methodType != "" {
+		mapped, ok := r.methodProviders[methodType]
+		if !ok {
+			return nil, apperrors.Validation("payment method not supported: %s", methodType)
+		}
+		name = mapped
+	}
+
+	return r.Get(name)
+}
@@ -0,0 +1,319 @@
+package providers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/resilience"
+)
+
+const stripeProviderName = "stripe"
+
+// stripeProvider implements PaymentProvider against the Stripe REST API.
+// Payments are modelled as PaymentIntents with manual capture so that
+// authorization and capture are separate steps.
+type stripeProvider struct {
+	apiURL             string
+	secretKey          string
+	webhookSecret      string
+	signatureTolerance time.Duration
+	httpClient         *http.Client
+}
+
+// stripePaymentIntent is the subset of the PaymentIntent object we use
+type stripePaymentIntent struct {
+	ID               string `json:"id"`
+	Status           string `json:"status"`
+	Amount           int64  `json:"amount"`
+	AmountReceived   int64  `json:"amount_received"`
+	LastPaymentError *struct {
+		Code        string `json:"code"`
+		DeclineCode string `json:"decline_code"`
+		Message     string `json:"message"`
+	} `json:"last_payment_error"`
+}
+
+// stripeRefund is the subset of the Refund object we use
+type stripeRefund struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Amount int64  `json:"amount"`
+}
+
+// stripeEvent is the envelope of Stripe webhook events
+type stripeEvent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object struct {
+			ID             string `json:"id"`
+			Object         string `json:"object"`
+			Currency       string `json:"currency"`
+			AmountReceived int64  `json:"amount_received"`
+			AmountRefunded int64  `json:"amount_refunded"`
+			PaymentIntent  string `json:"payment_intent"`
+		} `json:"object"`
+	} `json:"data"`
+}
+
+// stripeErrorResponse is the error envelope returned by the Stripe API
+type stripeErrorResponse struct {
+	Error struct {
+		Type        string `json:"type"`
+		Code        string `json:"code"`
+		DeclineCode string `json:"decline_code"`
+		Message     string `json:"message"`
+	} `json:"error"`
+}
+
+// NewStripeProvider creates a new Stripe payment provider.
+// Stripe is called through dependency, and webhooks older than
+// signatureTolerance are rejected to prevent replays.
+func NewStripeProvider(cfg config.StripeConfig, dependency *resilience.Dependency, signatureTolerance time.Duration) (PaymentProvider, error) {
+	if cfg.SecretKey == "" {
+		return nil, fmt.Errorf("stripe secret key is required")
+	}
+
+	return &stripeProvider{
+		apiURL:             strings.TrimRight(cfg.APIURL, "/"),
+		secretKey:          cfg.SecretKey,
+		webhookSecret:      cfg.WebhookSecret,
+		signatureTolerance: signatureTolerance,
+		httpClient:         dependency.HTTPClient(nil),
+	}, nil
+}
+
+// Name returns the provider name
+func (p *stripeProvider) Name() string {
+	return stripeProviderName
+}
+
+// Authorize creates and confirms a PaymentIntent with manual capture
+func (p *stripeProvider) Authorize(ctx context.Context, req *AuthorizeRequest) (*Result, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(req.Amount, 10))
+	form.Set("currency", strings.ToLower(req.Currency))
+	form.Set("payment_method", req.PaymentMethod)
+	form.Set("capture_method", "manual")
+	form.Set("confirm", "true")
+	if req.Description != "" {
+		form.Set("description", req.Description)
+	}
+	for key, value := range req.Metadata {
+		form.Set("metadata["+key+"]", value)
+	}
+
+	intent := &stripePaymentIntent{}
+	if err := p.post(ctx, "/v1/payment_intents", form, req.IdempotencyKey, intent); err != nil {
+		return nil, err
+	}
+
+	if intent.Status != "requires_capture" && intent.Status != "succeeded" {
+		perr := &ProviderError{Provider: stripeProviderName, Message: "payment was not authorized: " + intent.Status}
+		if intent.LastPaymentError != nil {
+			perr.Code = intent.LastPaymentError.Code
+			perr.Message = intent.LastPaymentError.Message
+			perr.Declined = intent.LastPaymentError.DeclineCode != ""
+		}
+		return &Result{ID: intent.ID, Status: StatusFailed, Amount: intent.Amount}, perr
+	}
+
+	return &Result{ID: intent.ID, Status: mapIntentStatus(intent.Status), Amount: intent.Amount}, nil
+}
+
+// Capture captures an authorized PaymentIntent. Any uncaptured remainder is released.
+func (p *stripeProvider) Capture(ctx context.Context, paymentID string, amount int64, currency string, idempotencyKey string) (*Result, error) {
+	form := url.Values{}
+	if amount > 0 {
+		form.Set("amount_to_capture", strconv.FormatInt(amount, 10))
+	}
+
+	intent := &stripePaymentIntent{}
+	if err := p.post(ctx, "/v1/payment_intents/"+url.PathEscape(paymentID)+"/capture", form, idempotencyKey, intent); err != nil {
+		return nil, err
+	}
+
+	return &Result{ID: intent.ID, Status: mapIntentStatus(intent.Status), Amount: intent.AmountReceived}, nil
+}
+
+// Refund refunds part or all of a captured PaymentIntent
+func (p *stripeProvider) Refund(ctx context.Context, paymentID string, amount int64, currency string, idempotencyKey string) (*Result, error) {
+	form := url.Values{}
+	form.Set("payment_intent", paymentID)
+	form.Set("amount", strconv.FormatInt(amount, 10))
+
+	refund := &stripeRefund{}
+	if err := p.post(ctx, "/v1/refunds", form, idempotencyKey, refund); err != nil {
+		return nil, err
+	}
+
+	status := StatusPending
+	switch refund.Status {
+	case "succeeded":
+		status = StatusRefunded
+	case "failed", "canceled":
+		return nil, &ProviderError{Provider: stripeProviderName, Message: "refund " + refund.Status}
+	}
+
+	return &Result{ID: refund.ID, Status: status, Amount: refund.Amount}, nil
+}
+
+// Void cancels an uncaptured PaymentIntent, releasing the hold on the customer's funds
+func (p *stripeProvider) Void(ctx context.Context, paymentID string, idempotencyKey string) (*Result, error) {
+	intent := &stripePaymentIntent{}
+	if err := p.post(ctx, "/v1/payment_intents/"+url.PathEscape(paymentID)+"/cancel", url.Values{}, idempotencyKey, intent); err != nil {
+		return nil, err
+	}
+
+	return &Result{ID: intent.ID, Status: mapIntentStatus(intent.Status), Amount: intent.Amount}, nil
+}
+
+// ParseWebhook verifies the Stripe-Signature header and translates the event
+func (p *stripeProvider) ParseWebhook(ctx context.Context, header http.Header, body []byte) (*WebhookEvent, error) {
+	if p.webhookSecret == "" {
+		return nil, fmt.Errorf("stripe webhook secret is not configured")
+	}
+
+	if err := p.verifySignature(header.Get("Stripe-Signature"), body); err != nil {
+		return nil, err
+	}
+
+	raw := &stripeEvent{}
+	if err := json.Unmarshal(body, raw); err != nil {
+		return nil, apperrors.Validation("invalid webhook payload").Wrap(err)
+	}
+
+	object := raw.Data.Object
+	event := &WebhookEvent{
+		ID:           raw.ID,
+		ProviderType: raw.Type,
+		PaymentID:    object.ID,
+		Currency:     strings.ToUpper(object.Currency),
+		OccurredAt:   time.Unix(raw.Created, 0).UTC(),
+	}
+
+	switch raw.Type {
+	case "payment_intent.succeeded":
+		event.Type = WebhookPaymentCaptured
+		event.Amount = object.AmountReceived
+	case "payment_intent.canceled":
+		event.Type = WebhookPaymentVoided
+	case "payment_intent.payment_failed":
+		event.Type = WebhookPaymentFailed
+	case "charge.refunded":
+		// The object is the charge; refunds are tracked on its payment intent
+		event.Type = WebhookPaymentRefunded
+		event.PaymentID = object.PaymentIntent
+		event.Amount = object.AmountRefunded
+	}
+
+	return event, nil
+}
+
+// verifySignature checks a Stripe-Signature header of the form t=<unix>,v1=<hex hmac>[,v1=...]
+func (p *stripeProvider) verifySignature(signatureHeader string, body []byte) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(signatureHeader, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	if timestamp == "" || len(signatures) == 0 {
+		return apperrors.Validation("invalid webhook signature: malformed header")
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return apperrors.Validation("invalid webhook signature: malformed timestamp")
+	}
+	if p.signatureTolerance > 0 && time.Since(time.Unix(unix, 0)) > p.signatureTolerance {
+		return apperrors.Validation("invalid webhook signature: timestamp outside tolerance")
+	}
+
+	mac := hmac.New(sha256.New, []byte(p.webhookSecret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+
+	return apperrors.Validation("invalid webhook signature")
+}
+
+// post sends a form-encoded request to the Stripe API and decodes the response into out
+func (p *stripeProvider) post(ctx context.Context, path string, form url.Values, idempotencyKey string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create stripe request: %w", err)
+	}
+	req.SetBasicAuth(p.secretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call stripe: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		errResp := &stripeErrorResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(errResp); err != nil {
+			return fmt.Errorf("stripe returned status %d", resp.StatusCode)
+		}
+		return &ProviderError{
+			Provider: stripeProviderName,
+			Code:     errResp.Error.Code,
+			Message:  errResp.Error.Message,
+			Declined: errResp.Error.Type == "card_error",
+		}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode stripe response: %w", err)
+	}
+
+	return nil
+}
+
+// mapIntentStatus converts a PaymentIntent status to a provider result status
+func mapIntentStatus(status string) string {
+	switch status {
+	case "requires_capture":
+		return StatusAuthorized
+	case "succeeded":
+		return StatusCaptured
+	case "canceled":
+		return StatusVoided
+	case "processing":
+		return StatusPending
+	default:
+		return StatusFailed
+	}
+}
@@ -0,0 +1,40 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Webhook event types, normalized across providers
+const (
+	WebhookPaymentCaptured = "payment.captured"
+	WebhookPaymentVoided   = "payment.voided"
+	WebhookPaymentFailed   = "payment.failed"
+	WebhookPaymentRefunded = "payment.refunded"
+)
+
+// WebhookEvent is a provider callback translated to provider-neutral terms
+type WebhookEvent struct {
+	// ID is the provider's event identifier
+	ID string
+	// Type is one of the Webhook* constants, or empty if the event is not relevant
+	Type string
+	// ProviderType is the provider's own event type
+	ProviderType string
+	// PaymentID is the provider identifier of the object the event refers to
+	PaymentID string
+	// RelatedPaymentID is a second identifier the payment may be stored under,
+	// e.g. the authorization of a PayPal capture
+	RelatedPaymentID string
+	// Amount is the captured amount for captures and the total refunded so far for refunds
+	Amount     int64
+	Currency   string
+	OccurredAt time.Time
+}
+
+// WebhookParser is implemented by providers that send webhooks.
+// ParseWebhook must verify the request's authenticity before parsing it.
+type WebhookParser interface {
+	ParseWebhook(ctx context.Context, header http.Header, body []byte) (*WebhookEvent, error)
+}
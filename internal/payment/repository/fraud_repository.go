@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/payment/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+)
+
+const fraudAssessmentColumns = `id, payment_id, order_id, user_id, score, decision, reasons, ip_address, device_id,
+		       billing_country, shipping_country, review_status, reviewed_by, review_notes, reviewed_at, created_at`
+
+// GetFraudVelocity counts the user's payment attempts and the accounts seen on
+// the device and IP address since the given time. The user itself is counted
+// once among the accounts even before its first assessment is stored.
+func (r *paymentRepository) GetFraudVelocity(ctx context.Context, userID uuid.UUID, deviceID, ipAddress string, since time.Time) (*models.FraudVelocity, error) {
+	velocity := &models.FraudVelocity{}
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM payments WHERE user_id = $1 AND created_at >= $4) AS attempts,
+			(SELECT COUNT(*) FROM payments WHERE user_id = $1 AND created_at >= $4 AND status = $5) AS failures,
+			(SELECT COUNT(*) FROM (
+				SELECT user_id FROM fraud_assessments WHERE device_id = $2 AND created_at >= $4
+				UNION SELECT $1
+			) AS device_users) AS accounts_on_device,
+			(SELECT COUNT(*) FROM (
+				SELECT user_id FROM fraud_assessments WHERE ip_address = $3 AND created_at >= $4
+				UNION SELECT $1
+			) AS ip_users) AS accounts_on_ip`
+
+	err := r.db.GetContext(ctx, velocity, query, userID, deviceID, ipAddress, since, models.PaymentStatusFailed)
+	if err != nil {
+		r.logger.Error("Failed to get fraud velocity", "error", err, "user_id", userID)
+		return nil, fmt.Errorf("failed to get fraud velocity: %w", err)
+	}
+
+	return velocity, nil
+}
+
+// CreateFraudAssessment stores the fraud assessment of a checkout
+func (r *paymentRepository) CreateFraudAssessment(ctx context.Context, assessment *models.FraudAssessment) error {
+	query := `
+		INSERT INTO fraud_assessments (id, payment_id, order_id, user_id, score, decision, reasons, ip_address,
+		                               device_id, billing_country, shipping_country, review_status)
+		VALUES (:id, :payment_id, :order_id, :user_id, :score, :decision, :reasons, :ip_address,
+		        :device_id, :billing_country, :shipping_country, :review_status)
+		RETURNING created_at`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to prepare create fraud assessment statement", "error", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, assessment).Scan(&assessment.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to create fraud assessment", "error", err, "payment_id", assessment.PaymentID)
+		return fmt.Errorf("failed to create fraud assessment: %w", err)
+	}
+
+	return nil
+}
+
+// GetFraudAssessment retrieves a fraud assessment by ID
+func (r *paymentRepository) GetFraudAssessment(ctx context.Context, id uuid.UUID) (*models.FraudAssessment, error) {
+	assessment := &models.FraudAssessment{}
+	query := `
+		SELECT ` + fraudAssessmentColumns + `
+		FROM fraud_assessments
+		WHERE id = $1`
+
+	err := r.db.GetContext(ctx, assessment, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("fraud assessment not found")
+		}
+		r.logger.Error("Failed to get fraud assessment", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to get fraud assessment: %w", err)
+	}
+
+	return assessment, nil
+}
+
+// GetFraudAssessmentByPaymentID retrieves the fraud assessment of a payment
+func (r *paymentRepository) GetFraudAssessmentByPaymentID(ctx context.Context, paymentID uuid.UUID) (*models.FraudAssessment, error) {
+	assessment := &models.FraudAssessment{}
+	query := `
+		SELECT ` + fraudAssessmentColumns + `
+		FROM fraud_assessments
+		WHERE payment_id = $1`
+
+	err := r.db.GetContext(ctx, assessment, query, paymentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("fraud assessment not found")
+		}
+		r.logger.Error("Failed to get fraud assessment by payment ID", "error", err, "payment_id", paymentID)
+		return nil, fmt.Errorf("failed to get fraud assessment: %w", err)
+	}
+
+	return assessment, nil
+}
+
+// ListFraudReviews lists assessments with the given review status, oldest first
+func (r *paymentRepository) ListFraudReviews(ctx context.Context, status models.FraudReviewStatus, limit int) ([]*models.FraudAssessment, error) {
+	assessments := []*models.FraudAssessment{}
+	query := `
+		SELECT ` + fraudAssessmentColumns + `
+		FROM fraud_assessments
+		WHERE review_status = $1
+		ORDER BY created_at ASC
+		LIMIT $2`
+
+	err := r.db.SelectContext(ctx, &assessments, query, status, limit)
+	if err != nil {
+		r.logger.Error("Failed to list fraud reviews", "error", err, "status", status)
+		return nil, fmt.Errorf("failed to list fraud reviews: %w", err)
+	}
+
+	return assessments, nil
+}
+
+// CompleteFraudReview records the outcome of a review. Only pending reviews
+// can be completed, so two reviewers can't decide the same checkout.
+func (r *paymentRepository) CompleteFraudReview(ctx context.Context, assessment *models.FraudAssessment) error {
+	query := `
+		UPDATE fraud_assessments
+		SET review_status = $2, reviewed_by = $3, review_notes = $4, reviewed_at = NOW()
+		WHERE id = $1 AND review_status = $5
+		RETURNING reviewed_at`
+
+	err := r.db.QueryRowxContext(ctx, query,
+		assessment.ID, assessment.ReviewStatus, assessment.ReviewedBy, assessment.ReviewNotes,
+		models.FraudReviewStatusPending,
+	).Scan(&assessment.ReviewedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.Conflict("fraud review already completed")
+		}
+		r.logger.Error("Failed to complete fraud review", "error", err, "id", assessment.ID)
+		return fmt.Errorf("failed to complete fraud review: %w", err)
+	}
+
+	return nil
+}
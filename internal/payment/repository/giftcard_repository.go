@@ -0,0 +1,385 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kaanevranportfolio/Commercium/internal/payment/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+)
+
+const giftCardColumns = `id, code_hash, last_four, currency, initial_amount, balance, status, expires_at,
+		       recipient_email, note, issued_by, created_at, updated_at`
+
+const giftCardLedgerColumns = `id, gift_card_id, type, amount, balance_after, payment_id, order_id, reference,
+		       created_by, created_at`
+
+// giftCardDue is the amount of a payment still owed back to one of its gift cards
+type giftCardDue struct {
+	GiftCardID uuid.UUID `db:"gift_card_id"`
+	Amount     int64     `db:"amount"`
+}
+
+// CreateGiftCard stores a newly issued gift card together with its issue entry
+func (r *paymentRepository) CreateGiftCard(ctx context.Context, card *models.GiftCard) error {
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		query := `
+			INSERT INTO gift_cards (id, code_hash, last_four, currency, initial_amount, balance, status,
+			                        expires_at, recipient_email, note, issued_by)
+			VALUES (:id, :code_hash, :last_four, :currency, :initial_amount, :balance, :status,
+			        :expires_at, :recipient_email, :note, :issued_by)
+			RETURNING created_at, updated_at`
+
+		stmt, err := tx.PrepareNamedContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer stmt.Close()
+
+		if err := stmt.QueryRowxContext(ctx, card).Scan(&card.CreatedAt, &card.UpdatedAt); err != nil {
+			if database.IsUniqueViolation(err) {
+				return apperrors.Conflict("gift card code already exists")
+			}
+			r.logger.Error("Failed to create gift card", "error", err)
+			return fmt.Errorf("failed to create gift card: %w", err)
+		}
+
+		entry := &models.GiftCardLedgerEntry{
+			ID:           uuid.New(),
+			GiftCardID:   card.ID,
+			Type:         models.GiftCardEntryIssue,
+			Amount:       card.InitialAmount,
+			BalanceAfter: card.Balance,
+			CreatedBy:    &card.IssuedBy,
+		}
+		if err := insertGiftCardEntry(ctx, tx, entry); err != nil {
+			return err
+		}
+
+		card.Ledger = []*models.GiftCardLedgerEntry{entry}
+		return nil
+	})
+}
+
+// GetGiftCard retrieves a gift card by ID
+func (r *paymentRepository) GetGiftCard(ctx context.Context, id uuid.UUID) (*models.GiftCard, error) {
+	card := &models.GiftCard{}
+	query := `
+		SELECT ` + giftCardColumns + `
+		FROM gift_cards
+		WHERE id = $1`
+
+	err := r.db.GetContext(ctx, card, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("gift card not found")
+		}
+		r.logger.Error("Failed to get gift card by ID", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to get gift card: %w", err)
+	}
+
+	return card, nil
+}
+
+// GetGiftCardByCodeHash retrieves a gift card by the hash of its code
+func (r *paymentRepository) GetGiftCardByCodeHash(ctx context.Context, codeHash string) (*models.GiftCard, error) {
+	card := &models.GiftCard{}
+	query := `
+		SELECT ` + giftCardColumns + `
+		FROM gift_cards
+		WHERE code_hash = $1`
+
+	err := r.db.GetContext(ctx, card, query, codeHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("gift card not found")
+		}
+		r.logger.Error("Failed to get gift card by code", "error", err)
+		return nil, fmt.Errorf("failed to get gift card: %w", err)
+	}
+
+	return card, nil
+}
+
+// DisableGiftCard stops a gift card from being redeemed
+func (r *paymentRepository) DisableGiftCard(ctx context.Context, id uuid.UUID) (*models.GiftCard, error) {
+	card := &models.GiftCard{}
+	query := `
+		UPDATE gift_cards SET status = $2
+		WHERE id = $1
+		RETURNING ` + giftCardColumns
+
+	err := r.db.GetContext(ctx, card, query, id, models.GiftCardStatusDisabled)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("gift card not found")
+		}
+		r.logger.Error("Failed to disable gift card", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to disable gift card: %w", err)
+	}
+
+	return card, nil
+}
+
+// ListGiftCardLedger lists the balance changes of a gift card, oldest first
+func (r *paymentRepository) ListGiftCardLedger(ctx context.Context, giftCardID uuid.UUID) ([]*models.GiftCardLedgerEntry, error) {
+	entries := []*models.GiftCardLedgerEntry{}
+	query := `
+		SELECT ` + giftCardLedgerColumns + `
+		FROM gift_card_ledger
+		WHERE gift_card_id = $1
+		ORDER BY created_at ASC`
+
+	err := r.db.SelectContext(ctx, &entries, query, giftCardID)
+	if err != nil {
+		r.logger.Error("Failed to list gift card ledger", "error", err, "gift_card_id", giftCardID)
+		return nil, fmt.Errorf("failed to list gift card ledger: %w", err)
+	}
+
+	return entries, nil
+}
+
+// CreateWithGiftCards creates a payment and debits the gift cards redeemed for
+// it in one transaction, so a stored payment always has its redemptions. The
+// checks guard against cards being spent, disabled or expiring concurrently.
+func (r *paymentRepository) CreateWithGiftCards(ctx context.Context, payment *models.Payment, redemptions []*models.GiftCardLedgerEntry) error {
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		stmt, err := tx.PrepareNamedContext(ctx, createPaymentQuery)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer stmt.Close()
+
+		if err := stmt.QueryRowxContext(ctx, payment).Scan(&payment.CreatedAt, &payment.UpdatedAt); err != nil {
+			if conflictErr := paymentConflictError(err); conflictErr != nil {
+				return conflictErr
+			}
+			r.logger.Error("Failed to create payment", "error", err, "order_id", payment.OrderID)
+			return fmt.Errorf("failed to create payment: %w", err)
+		}
+
+		for _, entry := range redemptions {
+			err := tx.QueryRowxContext(ctx, `
+				UPDATE gift_cards SET balance = balance + $2
+				WHERE id = $1 AND balance + $2 >= 0 AND status = $3 AND currency = $4
+				  AND (expires_at IS NULL OR expires_at > NOW())
+				RETURNING balance`,
+				entry.GiftCardID, entry.Amount, models.GiftCardStatusActive, payment.Currency).Scan(&entry.BalanceAfter)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					return apperrors.Conflict("gift card cannot be redeemed: balance changed during checkout")
+				}
+				return fmt.Errorf("failed to debit gift card: %w", err)
+			}
+
+			entry.PaymentID = &payment.ID
+			entry.OrderID = &payment.OrderID
+			if err := insertGiftCardEntry(ctx, tx, entry); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ReverseGiftCardRedemptions credits back everything a payment still owes its
+// gift cards. Reversing a payment twice has no further effect.
+func (r *paymentRepository) ReverseGiftCardRedemptions(ctx context.Context, paymentID uuid.UUID) ([]*models.GiftCardLedgerEntry, error) {
+	entries := []*models.GiftCardLedgerEntry{}
+	err := r.db.TransactionRetry(ctx, func(tx *sqlx.Tx) error {
+		entries = entries[:0]
+
+		var orderID uuid.UUID
+		// Locking the payment serializes reversals and refunds of its gift cards
+		err := tx.QueryRowxContext(ctx, `SELECT order_id FROM payments WHERE id = $1 FOR UPDATE`, paymentID).Scan(&orderID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperrors.NotFound("payment not found")
+			}
+			return fmt.Errorf("failed to lock payment: %w", err)
+		}
+
+		dues, err := listGiftCardDues(ctx, tx, paymentID)
+		if err != nil {
+			return err
+		}
+
+		for _, due := range dues {
+			entry := &models.GiftCardLedgerEntry{
+				ID:         uuid.New(),
+				GiftCardID: due.GiftCardID,
+				Type:       models.GiftCardEntryReversal,
+				Amount:     due.Amount,
+				PaymentID:  &paymentID,
+				OrderID:    &orderID,
+			}
+			if err := creditGiftCard(ctx, tx, entry); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("Failed to reverse gift card redemptions", "error", err, "payment_id", paymentID)
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// RefundGiftCards credits part of a captured payment back to the gift cards it
+// was paid with, card by card. The reference makes retries return the entries
+// of the first attempt.
+func (r *paymentRepository) RefundGiftCards(ctx context.Context, paymentID uuid.UUID, amount int64, reference string) ([]*models.GiftCardLedgerEntry, error) {
+	entries := []*models.GiftCardLedgerEntry{}
+	err := r.db.TransactionRetry(ctx, func(tx *sqlx.Tx) error {
+		entries = entries[:0]
+		if err := tx.SelectContext(ctx, &entries, `
+			SELECT `+giftCardLedgerColumns+`
+			FROM gift_card_ledger
+			WHERE payment_id = $1 AND type = $2 AND reference = $3`,
+			paymentID, models.GiftCardEntryRefund, reference); err != nil {
+			return fmt.Errorf("failed to get gift card refunds: %w", err)
+		}
+		if len(entries) > 0 {
+			return nil
+		}
+
+		// The check guards against concurrent refunds exceeding the redeemed amount
+		var orderID uuid.UUID
+		err := tx.QueryRowxContext(ctx, `
+			UPDATE payments
+			SET gift_card_refunded_amount = gift_card_refunded_amount + $2,
+			    status = CASE WHEN refunded_amount + gift_card_refunded_amount + $2 = captured_amount + gift_card_amount
+			                  THEN $3 ELSE $4 END
+			WHERE id = $1 AND gift_card_refunded_amount + $2 <= gift_card_amount AND status IN ($5, $4)
+			RETURNING order_id`,
+			paymentID, amount, models.PaymentStatusRefunded, models.PaymentStatusPartiallyRefunded,
+			models.PaymentStatusCaptured).Scan(&orderID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperrors.Validation("refund amount exceeds refundable amount")
+			}
+			return fmt.Errorf("failed to reserve gift card refund: %w", err)
+		}
+
+		dues, err := listGiftCardDues(ctx, tx, paymentID)
+		if err != nil {
+			return err
+		}
+
+		remaining := amount
+		for _, due := range dues {
+			if remaining == 0 {
+				break
+			}
+
+			credit := min(due.Amount, remaining)
+			entry := &models.GiftCardLedgerEntry{
+				ID:         uuid.New(),
+				GiftCardID: due.GiftCardID,
+				Type:       models.GiftCardEntryRefund,
+				Amount:     credit,
+				PaymentID:  &paymentID,
+				OrderID:    &orderID,
+				Reference:  &reference,
+			}
+			if err := creditGiftCard(ctx, tx, entry); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+			remaining -= credit
+		}
+
+		if remaining > 0 {
+			return apperrors.Validation("refund amount exceeds refundable amount")
+		}
+
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("Failed to refund gift cards", "error", err, "payment_id", paymentID)
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// ListGiftCardRefunds retrieves the gift card credits made by a refund
+func (r *paymentRepository) ListGiftCardRefunds(ctx context.Context, reference string) ([]*models.GiftCardLedgerEntry, error) {
+	entries := []*models.GiftCardLedgerEntry{}
+	query := `
+		SELECT ` + giftCardLedgerColumns + `
+		FROM gift_card_ledger
+		WHERE type = $1 AND reference = $2`
+
+	err := r.db.SelectContext(ctx, &entries, query, models.GiftCardEntryRefund, reference)
+	if err != nil {
+		r.logger.Error("Failed to list gift card refunds", "error", err)
+		return nil, fmt.Errorf("failed to list gift card refunds: %w", err)
+	}
+
+	return entries, nil
+}
+
+// listGiftCardDues returns what a payment still owes each of its gift cards
+func listGiftCardDues(ctx context.Context, tx *sqlx.Tx, paymentID uuid.UUID) ([]*giftCardDue, error) {
+	dues := []*giftCardDue{}
+	err := tx.SelectContext(ctx, &dues, `
+		SELECT gift_card_id, -SUM(amount) AS amount
+		FROM gift_card_ledger
+		WHERE payment_id = $1
+		GROUP BY gift_card_id
+		HAVING SUM(amount) < 0
+		ORDER BY MIN(created_at), gift_card_id`,
+		paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gift card redemptions: %w", err)
+	}
+
+	return dues, nil
+}
+
+// creditGiftCard adds a ledger entry's amount to the card balance and records the entry
+func creditGiftCard(ctx context.Context, tx *sqlx.Tx, entry *models.GiftCardLedgerEntry) error {
+	err := tx.QueryRowxContext(ctx, `
+		UPDATE gift_cards SET balance = balance + $2
+		WHERE id = $1
+		RETURNING balance`,
+		entry.GiftCardID, entry.Amount).Scan(&entry.BalanceAfter)
+	if err != nil {
+		return fmt.Errorf("failed to credit gift card: %w", err)
+	}
+
+	return insertGiftCardEntry(ctx, tx, entry)
+}
+
+// insertGiftCardEntry records a gift card ledger entry
+func insertGiftCardEntry(ctx context.Context, tx *sqlx.Tx, entry *models.GiftCardLedgerEntry) error {
+	query := `
+		INSERT INTO gift_card_ledger (id, gift_card_id, type, amount, balance_after, payment_id, order_id,
+		                              reference, created_by)
+		VALUES (:id, :gift_card_id, :type, :amount, :balance_after, :payment_id, :order_id,
+		        :reference, :created_by)
+		RETURNING created_at`
+
+	stmt, err := tx.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	if err := stmt.QueryRowxContext(ctx, entry).Scan(&entry.CreatedAt); err != nil {
+		return fmt.Errorf("failed to record gift card entry: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,474 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/payment/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// PaymentRepository defines the interface for payment data operations
+type PaymentRepository interface {
+	GetPayableOrder(ctx context.Context, orderID uuid.UUID) (*models.PayableOrder, error)
+
+	// Payment operations
+	Create(ctx context.Context, payment *models.Payment) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Payment, error)
+	GetActiveByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Payment, error)
+	GetByIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (*models.Payment, error)
+	GetByProviderPaymentID(ctx context.Context, provider string, providerPaymentIDs []string) (*models.Payment, error)
+	Update(ctx context.Context, payment *models.Payment) error
+	ReserveRefund(ctx context.Context, paymentID uuid.UUID, amount int64) error
+	ReleaseRefund(ctx context.Context, paymentID uuid.UUID, amount int64) error
+
+	// Transaction operations
+	CreateTransaction(ctx context.Context, txn *models.Transaction) error
+	GetTransactionByIdempotencyKey(ctx context.Context, txnType models.TransactionType, key string) (*models.Transaction, error)
+	ListTransactions(ctx context.Context, paymentID uuid.UUID) ([]*models.Transaction, error)
+
+	// Webhook event operations
+	CreateWebhookEvent(ctx context.Context, event *models.WebhookEvent) (bool, error)
+	ClaimWebhookEvents(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookEvent, error)
+	CompleteWebhookEvent(ctx context.Context, id uuid.UUID, status models.WebhookEventStatus) error
+	FailWebhookEvent(ctx context.Context, id uuid.UUID, lastError string, nextAttemptAt *time.Time) error
+
+	// Fraud operations
+	GetFraudVelocity(ctx context.Context, userID uuid.UUID, deviceID, ipAddress string, since time.Time) (*models.FraudVelocity, error)
+	CreateFraudAssessment(ctx context.Context, assessment *models.FraudAssessment) error
+	GetFraudAssessment(ctx context.Context, id uuid.UUID) (*models.FraudAssessment, error)
+	GetFraudAssessmentByPaymentID(ctx context.Context, paymentID uuid.UUID) (*models.FraudAssessment, error)
+	ListFraudReviews(ctx context.Context, status models.FraudReviewStatus, limit int) ([]*models.FraudAssessment, error)
+	CompleteFraudReview(ctx context.Context, assessment *models.FraudAssessment) error
+
+	// Gift card operations
+	CreateGiftCard(ctx context.Context, card *models.GiftCard) error
+	GetGiftCard(ctx context.Context, id uuid.UUID) (*models.GiftCard, error)
+	GetGiftCardByCodeHash(ctx context.Context, codeHash string) (*models.GiftCard, error)
+	DisableGiftCard(ctx context.Context, id uuid.UUID) (*models.GiftCard, error)
+	ListGiftCardLedger(ctx context.Context, giftCardID uuid.UUID) ([]*models.GiftCardLedgerEntry, error)
+	CreateWithGiftCards(ctx context.Context, payment *models.Payment, redemptions []*models.GiftCardLedgerEntry) error
+	ReverseGiftCardRedemptions(ctx context.Context, paymentID uuid.UUID) ([]*models.GiftCardLedgerEntry, error)
+	RefundGiftCards(ctx context.Context, paymentID uuid.UUID, amount int64, reference string) ([]*models.GiftCardLedgerEntry, error)
+	ListGiftCardRefunds(ctx context.Context, reference string) ([]*models.GiftCardLedgerEntry, error)
+}
+
+// paymentRepository implements the PaymentRepository interface
+type paymentRepository struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewPaymentRepository creates a new payment repository
+func NewPaymentRepository(db *database.DB, logger *logger.Logger) PaymentRepository {
+	return &paymentRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const paymentColumns = `id, order_id, user_id, provider, provider_payment_id, status, currency, amount,
+		       captured_amount, refunded_amount, gift_card_amount, gift_card_refunded_amount,
+		       failure_reason, idempotency_key, created_at, updated_at`
+
+// activePaymentIndex enforces at most one active payment per order
+const activePaymentIndex = "idx_payments_order_active"
+
+// GetPayableOrder retrieves the order fields needed to authorize a payment
+func (r *paymentRepository) GetPayableOrder(ctx context.Context, orderID uuid.UUID) (*models.PayableOrder, error) {
+	order := &models.PayableOrder{}
+	query := `
+		SELECT id, user_id, status, currency, total_amount,
+		       billing_address->>'country' AS billing_country,
+		       shipping_address->>'country' AS shipping_country
+		FROM orders
+		WHERE id = $1`
+
+	err := r.db.GetContext(ctx, order, query, orderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("order not found")
+		}
+		r.logger.Error("Failed to get order for payment", "error", err, "order_id", orderID)
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	return order, nil
+}
+
+// createPaymentQuery inserts a payment
+const createPaymentQuery = `
+		INSERT INTO payments (id, order_id, user_id, provider, status, currency, amount, gift_card_amount, idempotency_key)
+		VALUES (:id, :order_id, :user_id, :provider, :status, :currency, :amount, :gift_card_amount, :idempotency_key)
+		RETURNING created_at, updated_at`
+
+// Create creates a new payment
+func (r *paymentRepository) Create(ctx context.Context, payment *models.Payment) error {
+	stmt, err := r.db.PrepareNamedContext(ctx, createPaymentQuery)
+	if err != nil {
+		r.logger.Error("Failed to prepare create payment statement", "error", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, payment).Scan(&payment.CreatedAt, &payment.UpdatedAt)
+	if err != nil {
+		if conflictErr := paymentConflictError(err); conflictErr != nil {
+			return conflictErr
+		}
+		r.logger.Error("Failed to create payment", "error", err, "order_id", payment.OrderID)
+		return fmt.Errorf("failed to create payment: %w", err)
+	}
+
+	return nil
+}
+
+// paymentConflictError maps unique violations of createPaymentQuery, and
+// returns nil for any other error
+func paymentConflictError(err error) error {
+	if pgErr, ok := database.PgError(err); ok && pgErr.Code == database.UniqueViolation {
+		if pgErr.ConstraintName == activePaymentIndex {
+			return apperrors.Conflict("order already has an active payment")
+		}
+		return apperrors.Conflict("payment with this idempotency key already exists")
+	}
+	return nil
+}
+
+// GetByID retrieves a payment by ID
+func (r *paymentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Payment, error) {
+	payment := &models.Payment{}
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE id = $1`
+
+	err := r.db.GetContext(ctx, payment, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("payment not found")
+		}
+		r.logger.Error("Failed to get payment by ID", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+
+	return payment, nil
+}
+
+// GetByIdempotencyKey retrieves the payment a user created with the given idempotency key
+func (r *paymentRepository) GetByIdempotencyKey(ctx context.Context, userID uuid.UUID, key string) (*models.Payment, error) {
+	payment := &models.Payment{}
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE user_id = $1 AND idempotency_key = $2`
+
+	err := r.db.GetContext(ctx, payment, query, userID, key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("payment not found")
+		}
+		r.logger.Error("Failed to get payment by idempotency key", "error", err, "user_id", userID)
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+
+	return payment, nil
+}
+
+// GetActiveByOrderID retrieves the order's latest payment that still holds or has taken funds
+func (r *paymentRepository) GetActiveByOrderID(ctx context.Context, orderID uuid.UUID) (*models.Payment, error) {
+	payment := &models.Payment{}
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE order_id = $1 AND status IN ($2, $3, $4, $5)
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	err := r.db.GetContext(ctx, payment, query, orderID,
+		models.PaymentStatusPending, models.PaymentStatusAuthorized,
+		models.PaymentStatusCaptured, models.PaymentStatusPartiallyRefunded)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("payment not found")
+		}
+		r.logger.Error("Failed to get payment by order ID", "error", err, "order_id", orderID)
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+
+	return payment, nil
+}
+
+// GetByProviderPaymentID retrieves a payment stored under any of the given provider identifiers
+func (r *paymentRepository) GetByProviderPaymentID(ctx context.Context, provider string, providerPaymentIDs []string) (*models.Payment, error) {
+	payment := &models.Payment{}
+	query := `
+		SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE provider = $1 AND provider_payment_id = ANY($2)
+		LIMIT 1`
+
+	err := r.db.GetContext(ctx, payment, query, provider, providerPaymentIDs)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("payment not found")
+		}
+		r.logger.Error("Failed to get payment by provider ID", "error", err, "provider", provider)
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+
+	return payment, nil
+}
+
+// Update updates the provider state of a payment
+func (r *paymentRepository) Update(ctx context.Context, payment *models.Payment) error {
+	query := `
+		UPDATE payments
+		SET provider_payment_id = :provider_payment_id, status = :status,
+		    captured_amount = :captured_amount, failure_reason = :failure_reason
+		WHERE id = :id`
+
+	result, err := r.db.NamedExecContext(ctx, query, payment)
+	if err != nil {
+		r.logger.Error("Failed to update payment", "error", err, "payment_id", payment.ID)
+		return fmt.Errorf("failed to update payment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperrors.NotFound("payment not found")
+	}
+
+	return nil
+}
+
+// ReserveRefund adds amount to the refunded total before the provider is called.
+// The check guards against concurrent refunds exceeding the captured amount.
+func (r *paymentRepository) ReserveRefund(ctx context.Context, paymentID uuid.UUID, amount int64) error {
+	query := `
+		UPDATE payments
+		SET refunded_amount = refunded_amount + $2,
+		    status = CASE WHEN refunded_amount + $2 + gift_card_refunded_amount = captured_amount + gift_card_amount
+		                  THEN $3 ELSE $4 END
+		WHERE id = $1 AND refunded_amount + $2 <= captured_amount`
+
+	result, err := r.db.ExecContext(ctx, query, paymentID, amount,
+		models.PaymentStatusRefunded, models.PaymentStatusPartiallyRefunded)
+	if err != nil {
+		r.logger.Error("Failed to reserve refund", "error", err, "payment_id", paymentID)
+		return fmt.Errorf("failed to reserve refund: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperrors.Validation("refund amount exceeds refundable amount")
+	}
+
+	return nil
+}
+
+// ReleaseRefund reverts a reservation made by ReserveRefund after the provider rejected the refund
+func (r *paymentRepository) ReleaseRefund(ctx context.Context, paymentID uuid.UUID, amount int64) error {
+	query := `
+		UPDATE payments
+		SET refunded_amount = refunded_amount - $2,
+		    status = CASE WHEN refunded_amount - $2 + gift_card_refunded_amount = 0 THEN $3 ELSE $4 END
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, paymentID, amount,
+		models.PaymentStatusCaptured, models.PaymentStatusPartiallyRefunded)
+	if err != nil {
+		r.logger.Error("Failed to release refund", "error", err, "payment_id", paymentID)
+		return fmt.Errorf("failed to release refund: %w", err)
+	}
+
+	return nil
+}
+
+// CreateTransaction records an operation performed against the provider
+func (r *paymentRepository) CreateTransaction(ctx context.Context, txn *models.Transaction) error {
+	query := `
+		INSERT INTO payment_transactions (id, payment_id, type, status, amount,
+		                                  provider_transaction_id, idempotency_key, error_message)
+		VALUES (:id, :payment_id, :type, :status, :amount,
+		        :provider_transaction_id, :idempotency_key, :error_message)
+		RETURNING created_at`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to prepare create transaction statement", "error", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, txn).Scan(&txn.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to create payment transaction", "error", err, "payment_id", txn.PaymentID)
+		return fmt.Errorf("failed to create payment transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetTransactionByIdempotencyKey retrieves a succeeded transaction by its idempotency key
+func (r *paymentRepository) GetTransactionByIdempotencyKey(ctx context.Context, txnType models.TransactionType, key string) (*models.Transaction, error) {
+	txn := &models.Transaction{}
+	query := `
+		SELECT id, payment_id, type, status, amount, provider_transaction_id, idempotency_key,
+		       error_message, created_at
+		FROM payment_transactions
+		WHERE type = $1 AND idempotency_key = $2 AND status = $3`
+
+	err := r.db.GetContext(ctx, txn, query, txnType, key, models.TransactionStatusSucceeded)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("transaction not found")
+		}
+		r.logger.Error("Failed to get transaction by idempotency key", "error", err, "type", txnType)
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	return txn, nil
+}
+
+// ListTransactions retrieves all transactions of a payment, oldest first
+func (r *paymentRepository) ListTransactions(ctx context.Context, paymentID uuid.UUID) ([]*models.Transaction, error) {
+	txns := []*models.Transaction{}
+	query := `
+		SELECT id, payment_id, type, status, amount, provider_transaction_id, idempotency_key,
+		       error_message, created_at
+		FROM payment_transactions
+		WHERE payment_id = $1
+		ORDER BY created_at`
+
+	err := r.db.SelectContext(ctx, &txns, query, paymentID)
+	if err != nil {
+		r.logger.Error("Failed to list payment transactions", "error", err, "payment_id", paymentID)
+		return nil, fmt.Errorf("failed to list payment transactions: %w", err)
+	}
+
+	return txns, nil
+}
+
+// WebhookEventsChannel is notified when a webhook event is queued
+const WebhookEventsChannel = "payment_webhook_events"
+
+const webhookEventColumns = `id, provider, event_id, provider_event_type, event_type, provider_payment_id,
+		       related_payment_id, amount, currency, payload, status, attempts, last_error,
+		       next_attempt_at, received_at, processed_at`
+
+// CreateWebhookEvent stores a verified webhook event. It returns false without
+// error if the provider already delivered an event with the same ID.
+func (r *paymentRepository) CreateWebhookEvent(ctx context.Context, event *models.WebhookEvent) (bool, error) {
+	query := `
+		INSERT INTO payment_webhook_events (id, provider, event_id, provider_event_type, event_type,
+		                                    provider_payment_id, related_payment_id, amount, currency,
+		                                    payload, status)
+		VALUES (:id, :provider, :event_id, :provider_event_type, :event_type,
+		        :provider_payment_id, :related_payment_id, :amount, :currency,
+		        :payload, :status)
+		ON CONFLICT (provider, event_id) DO NOTHING
+		RETURNING received_at, next_attempt_at`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to prepare create webhook event statement", "error", err)
+		return false, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, event).Scan(&event.ReceivedAt, &event.NextAttemptAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		r.logger.Error("Failed to create webhook event", "error", err, "provider", event.Provider, "event_id", event.EventID)
+		return false, fmt.Errorf("failed to create webhook event: %w", err)
+	}
+
+	// Workers poll for events anyway, so they are only woken up early
+	if err := r.db.Notify(ctx, WebhookEventsChannel, event.ID.String()); err != nil {
+		r.logger.Warn("Failed to notify webhook workers", "error", err, "event_id", event.EventID)
+	}
+
+	return true, nil
+}
+
+// ClaimWebhookEvents leases up to limit events that are due for processing.
+// Claimed events become due again once the lease expires, so events held by a
+// crashed worker are picked up by another one.
+func (r *paymentRepository) ClaimWebhookEvents(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookEvent, error) {
+	events := []*models.WebhookEvent{}
+	query := `
+		UPDATE payment_webhook_events
+		SET status = $1, attempts = attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		WHERE id IN (
+			SELECT id FROM payment_webhook_events
+			WHERE status IN ($3, $1) AND next_attempt_at <= NOW()
+			ORDER BY received_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookEventColumns
+
+	err := r.db.SelectContext(ctx, &events, query,
+		models.WebhookEventStatusProcessing, lease.Seconds(), models.WebhookEventStatusPending, limit)
+	if err != nil {
+		r.logger.Error("Failed to claim webhook events", "error", err)
+		return nil, fmt.Errorf("failed to claim webhook events: %w", err)
+	}
+
+	return events, nil
+}
+
+// CompleteWebhookEvent marks an event as finished with the given final status
+func (r *paymentRepository) CompleteWebhookEvent(ctx context.Context, id uuid.UUID, status models.WebhookEventStatus) error {
+	query := `
+		UPDATE payment_webhook_events
+		SET status = $2, processed_at = NOW(), last_error = NULL
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id, status)
+	if err != nil {
+		r.logger.Error("Failed to complete webhook event", "error", err, "id", id)
+		return fmt.Errorf("failed to complete webhook event: %w", err)
+	}
+
+	return nil
+}
+
+// FailWebhookEvent records a processing failure. The event is retried at
+// nextAttemptAt, or given up on when nextAttemptAt is nil.
+func (r *paymentRepository) FailWebhookEvent(ctx context.Context, id uuid.UUID, lastError string, nextAttemptAt *time.Time) error {
+	status := models.WebhookEventStatusFailed
+	if nextAttemptAt != nil {
+		status = models.WebhookEventStatusPending
+	}
+
+	query := `
+		UPDATE payment_webhook_events
+		SET status = $2, last_error = $3, next_attempt_at = COALESCE($4, next_attempt_at)
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, id, status, lastError, nextAttemptAt)
+	if err != nil {
+		r.logger.Error("Failed to record webhook event failure", "error", err, "id", id)
+		return fmt.Errorf("failed to record webhook event failure: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/payment/fraud"
+	"github.com/kaanevranportfolio/Commercium/internal/payment/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+)
+
+const defaultFraudReviewLimit = 50
+
+// assessCheckout scores a checkout before the payment is created.
+// It returns nil if fraud screening is disabled.
+func (s *paymentService) assessCheckout(ctx context.Context, userID uuid.UUID, order *models.PayableOrder, req *models.AuthorizePaymentRequest) (*fraud.Signals, *fraud.Assessment, error) {
+	cfg := s.config.Services.Payment.Fraud
+	if !cfg.Enabled {
+		return nil, nil, nil
+	}
+
+	since := time.Now().Add(-cfg.VelocityWindow)
+	velocity, err := s.repo.GetFraudVelocity(ctx, userID, req.DeviceID, req.IPAddress, since)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signals := &fraud.Signals{
+		Amount:    order.TotalAmount,
+		Currency:  order.Currency,
+		IPAddress: req.IPAddress,
+		DeviceID:  req.DeviceID,
+		// The attempt being assessed is not stored yet
+		RecentAttempts:   velocity.Attempts + 1,
+		RecentFailures:   velocity.Failures,
+		AccountsOnDevice: velocity.AccountsOnDevice,
+		AccountsOnIP:     velocity.AccountsOnIP,
+	}
+	if order.BillingCountry != nil {
+		signals.BillingCountry = *order.BillingCountry
+	}
+	if order.ShippingCountry != nil {
+		signals.ShippingCountry = *order.ShippingCountry
+	}
+
+	return signals, s.fraud.Evaluate(signals), nil
+}
+
+// recordAssessment stores the assessment of a newly created payment. Checkouts
+// held for review enter the review queue. A payment whose assessment can't be
+// stored is failed, since a held checkout must not slip past review.
+func (s *paymentService) recordAssessment(ctx context.Context, payment *models.Payment, signals *fraud.Signals, assessment *fraud.Assessment) error {
+	record := &models.FraudAssessment{
+		ID:              uuid.New(),
+		PaymentID:       payment.ID,
+		OrderID:         payment.OrderID,
+		UserID:          payment.UserID,
+		Score:           assessment.Score,
+		Decision:        string(assessment.Decision),
+		Reasons:         assessment.Reasons,
+		IPAddress:       optionalString(signals.IPAddress),
+		DeviceID:        optionalString(signals.DeviceID),
+		BillingCountry:  optionalString(signals.BillingCountry),
+		ShippingCountry: optionalString(signals.ShippingCountry),
+	}
+	if assessment.Decision == fraud.DecisionReview {
+		status := models.FraudReviewStatusPending
+		record.ReviewStatus = &status
+	}
+
+	if err := s.repo.CreateFraudAssessment(ctx, record); err != nil {
+		s.failPayment(ctx, payment, "fraud screening unavailable")
+		return err
+	}
+
+	if assessment.Decision != fraud.DecisionPass {
+		s.logger.Warn("Checkout flagged by fraud screening",
+			"payment_id", payment.ID,
+			"order_id", payment.OrderID,
+			"score", assessment.Score,
+			"decision", assessment.Decision,
+			"reasons", strings.Join(assessment.Reasons, "; "),
+		)
+	}
+
+	return nil
+}
+
+// failPayment marks a payment that was never sent to the provider as failed
+// and credits back its gift cards
+func (s *paymentService) failPayment(ctx context.Context, payment *models.Payment, reason string) {
+	payment.Status = models.PaymentStatusFailed
+	payment.FailureReason = &reason
+	if err := s.repo.Update(ctx, payment); err != nil {
+		s.logger.Error("Failed to update payment", "error", err, "payment_id", payment.ID)
+		return
+	}
+	s.reverseGiftCards(ctx, payment)
+
+	s.publishEvent(ctx, models.EventPaymentFailed, payment, payment.Amount, reason)
+}
+
+// checkFraudHold returns an error if the payment is held for fraud review or was rejected
+func (s *paymentService) checkFraudHold(ctx context.Context, payment *models.Payment) error {
+	assessment, err := s.repo.GetFraudAssessmentByPaymentID(ctx, payment.ID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if assessment.ReviewStatus == nil {
+		return nil
+	}
+
+	switch *assessment.ReviewStatus {
+	case models.FraudReviewStatusPending:
+		return apperrors.Conflict("payment cannot be captured while held for fraud review")
+	case models.FraudReviewStatusRejected:
+		return apperrors.Conflict("payment cannot be captured after fraud review rejected it")
+	}
+
+	return nil
+}
+
+// ListFraudReviews returns the fraud review queue, oldest first
+func (s *paymentService) ListFraudReviews(ctx context.Context, req *models.ListFraudReviewsRequest) ([]*models.FraudAssessment, error) {
+	status := models.FraudReviewStatusPending
+	if req.Status != "" {
+		status = models.FraudReviewStatus(req.Status)
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultFraudReviewLimit
+	}
+
+	return s.repo.ListFraudReviews(ctx, status, limit)
+}
+
+// ReviewFraudAssessment approves or rejects a checkout held for review.
+// Approval releases the payment for capture; rejection voids it.
+func (s *paymentService) ReviewFraudAssessment(ctx context.Context, reviewerID uuid.UUID, assessmentID uuid.UUID, req *models.FraudReviewRequest) (*models.FraudAssessment, error) {
+	assessment, err := s.repo.GetFraudAssessment(ctx, assessmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if assessment.ReviewStatus == nil {
+		return nil, apperrors.Conflict("fraud assessment cannot be reviewed: checkout was not held for review")
+	}
+	if *assessment.ReviewStatus != models.FraudReviewStatusPending {
+		return nil, apperrors.Conflict("fraud review already completed")
+	}
+
+	status := models.FraudReviewStatusApproved
+	if req.Decision == "reject" {
+		status = models.FraudReviewStatusRejected
+
+		payment, err := s.repo.GetByID(ctx, assessment.PaymentID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Release the hold on the customer's funds before closing the review
+		if payment.Status == models.PaymentStatusAuthorized {
+			if _, err := s.void(ctx, payment, "fraud-review-"+assessment.ID.String(), "rejected by fraud review"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	assessment.ReviewStatus = &status
+	assessment.ReviewedBy = &reviewerID
+	assessment.ReviewNotes = optionalString(req.Notes)
+	if err := s.repo.CompleteFraudReview(ctx, assessment); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Fraud review completed", "assessment_id", assessment.ID, "payment_id", assessment.PaymentID, "status", status, "reviewer_id", reviewerID)
+	return assessment, nil
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/payment/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+)
+
+const (
+	// giftCardCodeAlphabet leaves out characters that are easily confused (0/O, 1/I)
+	giftCardCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	// giftCardCodeLength gives 80 bits of randomness with the 32 character alphabet
+	giftCardCodeLength = 16
+	// giftCardIssueAttempts bounds retries after a generated code collides
+	giftCardIssueAttempts = 3
+)
+
+// IssueGiftCard issues a gift card with a newly generated code. The code is
+// only returned here; the gift card stores a hash of it.
+func (s *paymentService) IssueGiftCard(ctx context.Context, issuerID uuid.UUID, req *models.IssueGiftCardRequest) (*models.IssuedGiftCard, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, apperrors.Validation("invalid expiry: must be in the future")
+	}
+
+	card := &models.GiftCard{
+		Currency:       strings.ToUpper(req.Currency),
+		InitialAmount:  req.Amount,
+		Balance:        req.Amount,
+		Status:         models.GiftCardStatusActive,
+		ExpiresAt:      req.ExpiresAt,
+		RecipientEmail: optionalString(req.RecipientEmail),
+		Note:           optionalString(req.Note),
+		IssuedBy:       issuerID,
+	}
+
+	for attempt := 1; ; attempt++ {
+		code, err := generateGiftCardCode()
+		if err != nil {
+			return nil, err
+		}
+
+		normalized := normalizeGiftCardCode(code)
+		card.ID = uuid.New()
+		card.CodeHash = hashGiftCardCode(normalized)
+		card.LastFour = normalized[len(normalized)-4:]
+
+		err = s.repo.CreateGiftCard(ctx, card)
+		if err == nil {
+			s.logger.Info("Gift card issued", "gift_card_id", card.ID, "amount", card.InitialAmount, "currency", card.Currency, "issued_by", issuerID)
+			return &models.IssuedGiftCard{Code: code, GiftCard: card}, nil
+		}
+		if !errors.Is(err, apperrors.ErrConflict) || attempt == giftCardIssueAttempts {
+			return nil, err
+		}
+	}
+}
+
+// GetGiftCard returns a gift card with its ledger
+func (s *paymentService) GetGiftCard(ctx context.Context, id uuid.UUID) (*models.GiftCard, error) {
+	card, err := s.repo.GetGiftCard(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	card.Ledger, err = s.repo.ListGiftCardLedger(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return card, nil
+}
+
+// DisableGiftCard stops a gift card from being redeemed. Its balance is kept,
+// and refunds of earlier redemptions are still credited to it.
+func (s *paymentService) DisableGiftCard(ctx context.Context, id uuid.UUID) (*models.GiftCard, error) {
+	card, err := s.repo.DisableGiftCard(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Gift card disabled", "gift_card_id", card.ID, "balance", card.Balance)
+	return card, nil
+}
+
+// CheckGiftCardBalance returns the balance of the gift card with the given code
+func (s *paymentService) CheckGiftCardBalance(ctx context.Context, req *models.GiftCardBalanceRequest) (*models.GiftCardBalance, error) {
+	card, err := s.repo.GetGiftCardByCodeHash(ctx, hashGiftCardCode(normalizeGiftCardCode(req.Code)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.GiftCardBalance{
+		LastFour:  card.LastFour,
+		Currency:  card.Currency,
+		Balance:   card.Balance,
+		Status:    card.Status,
+		Expired:   card.IsExpired(time.Now()),
+		ExpiresAt: card.ExpiresAt,
+	}, nil
+}
+
+// planGiftCardRedemptions works out how much of the order each gift card pays,
+// in the order the codes were given. Cards that aren't needed to cover the
+// total are left untouched. The debits are only applied when the payment is
+// created.
+func (s *paymentService) planGiftCardRedemptions(ctx context.Context, order *models.PayableOrder, codes []string) ([]*models.GiftCardLedgerEntry, int64, error) {
+	redemptions := []*models.GiftCardLedgerEntry{}
+	seen := make(map[string]bool, len(codes))
+	now := time.Now()
+
+	var total int64
+	for _, code := range codes {
+		codeHash := hashGiftCardCode(normalizeGiftCardCode(code))
+		if seen[codeHash] {
+			return nil, 0, apperrors.Validation("invalid gift card codes: the same code was given twice")
+		}
+		seen[codeHash] = true
+
+		card, err := s.repo.GetGiftCardByCodeHash(ctx, codeHash)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		switch {
+		case card.Status != models.GiftCardStatusActive:
+			return nil, 0, apperrors.Conflict("gift card cannot be redeemed: card ending in %s is disabled", card.LastFour)
+		case card.IsExpired(now):
+			return nil, 0, apperrors.Conflict("gift card cannot be redeemed: card ending in %s has expired", card.LastFour)
+		case card.Currency != order.Currency:
+			return nil, 0, apperrors.Validation("invalid gift card: card ending in %s was issued in %s", card.LastFour, card.Currency)
+		}
+
+		amount := min(card.Balance, order.TotalAmount-total)
+		if amount == 0 {
+			continue
+		}
+
+		redemptions = append(redemptions, &models.GiftCardLedgerEntry{
+			ID:         uuid.New(),
+			GiftCardID: card.ID,
+			Type:       models.GiftCardEntryRedeem,
+			Amount:     -amount,
+		})
+		total += amount
+	}
+
+	return redemptions, total, nil
+}
+
+// reverseGiftCards credits back the gift cards of a payment that was never
+// captured. Failures are logged, since the payment already changed state;
+// reversing again later has no effect on cards already credited.
+func (s *paymentService) reverseGiftCards(ctx context.Context, payment *models.Payment) {
+	if payment.GiftCardAmount == 0 {
+		return
+	}
+
+	entries, err := s.repo.ReverseGiftCardRedemptions(ctx, payment.ID)
+	if err != nil {
+		s.logger.Error("Failed to reverse gift card redemptions", "error", err, "payment_id", payment.ID)
+		return
+	}
+
+	if len(entries) > 0 {
+		s.logger.Info("Gift card redemptions reversed", "payment_id", payment.ID, "cards", len(entries))
+	}
+}
+
+// refundGiftCards returns part of a captured payment to the gift cards it was paid with
+func (s *paymentService) refundGiftCards(ctx context.Context, payment *models.Payment, amount int64, idempotencyKey, reason string) ([]*models.GiftCardLedgerEntry, error) {
+	entries, err := s.repo.RefundGiftCards(ctx, payment.ID, amount, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	payment.GiftCardRefundedAmount += amount
+	s.publishEvent(ctx, models.EventPaymentRefunded, payment, amount, reason)
+
+	s.logger.Info("Payment refunded to gift cards", "payment_id", payment.ID, "order_id", payment.OrderID, "amount", amount)
+	return entries, nil
+}
+
+// generateGiftCardCode returns a random code formatted in groups of four, e.g. ABCD-EFGH-JKLM-NPQR
+func generateGiftCardCode() (string, error) {
+	buf := make([]byte, giftCardCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate gift card code: %w", err)
+	}
+
+	var code strings.Builder
+	for i, b := range buf {
+		if i > 0 && i%4 == 0 {
+			code.WriteByte('-')
+		}
+		// The alphabet has 32 characters, so every byte maps uniformly
+		code.WriteByte(giftCardCodeAlphabet[int(b)%len(giftCardCodeAlphabet)])
+	}
+
+	return code.String(), nil
+}
+
+// normalizeGiftCardCode uppercases a code and drops separators customers may type
+func normalizeGiftCardCode(code string) string {
+	var normalized strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		if r == '-' || r == ' ' {
+			continue
+		}
+		normalized.WriteRune(r)
+	}
+	return normalized.String()
+}
+
+// hashGiftCardCode returns the hash under which a normalized code is stored
+func hashGiftCardCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,647 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/payment/fraud"
+	"github.com/kaanevranportfolio/Commercium/internal/payment/models"
+	"github.com/kaanevranportfolio/Commercium/internal/payment/providers"
+	"github.com/kaanevranportfolio/Commercium/internal/payment/repository"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// Order statuses the payment service acts on
+const (
+	orderStatusPending   = "pending"
+	orderStatusCancelled = "cancelled"
+)
+
+// PaymentService defines the interface for payment business logic
+type PaymentService interface {
+	Authorize(ctx context.Context, userID uuid.UUID, req *models.AuthorizePaymentRequest, idempotencyKey string) (*models.Payment, error)
+	GetPayment(ctx context.Context, userID uuid.UUID, paymentID uuid.UUID) (*models.Payment, error)
+
+	// Internal operations called by other services
+	Capture(ctx context.Context, paymentID uuid.UUID, req *models.CaptureRequest) (*models.Payment, error)
+	Void(ctx context.Context, paymentID uuid.UUID) (*models.Payment, error)
+	RefundOrder(ctx context.Context, req *models.RefundRequest, idempotencyKey string) (*models.RefundResponse, error)
+	ChargeRecurring(ctx context.Context, req *models.RecurringChargeRequest, idempotencyKey string) (*models.Payment, error)
+
+	// Provider callbacks
+	HandleWebhook(ctx context.Context, providerName string, header http.Header, body []byte) error
+	ProcessWebhookEvents(ctx context.Context) (int, error)
+
+	// Fraud review queue
+	ListFraudReviews(ctx context.Context, req *models.ListFraudReviewsRequest) ([]*models.FraudAssessment, error)
+	ReviewFraudAssessment(ctx context.Context, reviewerID uuid.UUID, assessmentID uuid.UUID, req *models.FraudReviewRequest) (*models.FraudAssessment, error)
+
+	// Gift cards
+	IssueGiftCard(ctx context.Context, issuerID uuid.UUID, req *models.IssueGiftCardRequest) (*models.IssuedGiftCard, error)
+	GetGiftCard(ctx context.Context, id uuid.UUID) (*models.GiftCard, error)
+	DisableGiftCard(ctx context.Context, id uuid.UUID) (*models.GiftCard, error)
+	CheckGiftCardBalance(ctx context.Context, req *models.GiftCardBalanceRequest) (*models.GiftCardBalance, error)
+}
+
+// EventPublisher publishes domain events to the message broker
+type EventPublisher interface {
+	Publish(ctx context.Context, topic, key string, event interface{}) error
+}
+
+// eventSource is the source of the events the service publishes
+const eventSource = "payment-service"
+
+// paymentService implements the PaymentService interface
+type paymentService struct {
+	repo      repository.PaymentRepository
+	providers *providers.Registry
+	fraud     *fraud.Scorer
+	publisher EventPublisher
+	config    *config.Config
+	logger    *logger.Logger
+}
+
+// NewPaymentService creates a new payment service.
+// publisher may be nil, in which case payment events are not published.
+func NewPaymentService(
+	repo repository.PaymentRepository,
+	providers *providers.Registry,
+	publisher EventPublisher,
+	config *config.Config,
+	logger *logger.Logger,
+) PaymentService {
+	return &paymentService{
+		repo:      repo,
+		providers: providers,
+		fraud:     fraud.NewScorer(config.Services.Payment.Fraud),
+		publisher: publisher,
+		config:    config,
+		logger:    logger,
+	}
+}
+
+// Authorize places a hold on the customer's funds for the order total.
+// Gift cards are debited first and the provider authorizes the rest; orders
+// fully covered by gift cards never reach a provider. Checkouts retried with
+// the same idempotency key return the payment created by the first attempt
+// instead of charging the customer again.
+func (s *paymentService) Authorize(ctx context.Context, userID uuid.UUID, req *models.AuthorizePaymentRequest, idempotencyKey string) (*models.Payment, error) {
+	if idempotencyKey != "" {
+		if existing, err := s.repo.GetByIdempotencyKey(ctx, userID, idempotencyKey); err == nil {
+			return s.resumeAuthorization(ctx, existing, req)
+		}
+	}
+
+	order, err := s.getPayableOrder(ctx, userID, req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.GetActiveByOrderID(ctx, order.ID); err == nil {
+		return nil, apperrors.Conflict("order already has an active payment")
+	}
+
+	redemptions, giftCardAmount, err := s.planGiftCardRedemptions(ctx, order, req.GiftCardCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	var provider providers.PaymentProvider
+	providerName := models.GiftCardProvider
+	status := models.PaymentStatusAuthorized
+	if giftCardAmount < order.TotalAmount {
+		if req.PaymentMethod == "" {
+			return nil, apperrors.Validation("invalid payment: gift cards don't cover the order total, a payment method is required")
+		}
+
+		provider, err = s.providers.Select(req.Provider, req.PaymentMethodType)
+		if err != nil {
+			return nil, err
+		}
+		providerName = provider.Name()
+		status = models.PaymentStatusPending
+	}
+
+	signals, assessment, err := s.assessCheckout(ctx, userID, order, req)
+	if err != nil {
+		return nil, err
+	}
+
+	payment := &models.Payment{
+		ID:             uuid.New(),
+		OrderID:        order.ID,
+		UserID:         userID,
+		Provider:       providerName,
+		Status:         status,
+		Currency:       order.Currency,
+		Amount:         order.TotalAmount,
+		GiftCardAmount: giftCardAmount,
+		IdempotencyKey: optionalString(idempotencyKey),
+	}
+
+	if len(redemptions) > 0 {
+		err = s.repo.CreateWithGiftCards(ctx, payment, redemptions)
+	} else {
+		err = s.repo.Create(ctx, payment)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if assessment != nil {
+		if err := s.recordAssessment(ctx, payment, signals, assessment); err != nil {
+			return nil, err
+		}
+
+		// Declined checkouts never reach the provider. Checkouts held for
+		// review are authorized, but can't be captured until approved.
+		if assessment.Decision == fraud.DecisionDecline {
+			s.failPayment(ctx, payment, "declined by fraud screening")
+			return nil, apperrors.PaymentRequired("payment declined by fraud screening")
+		}
+	}
+
+	if provider == nil {
+		s.publishEvent(ctx, models.EventPaymentAuthorized, payment, payment.Amount, "")
+
+		s.logger.Info("Payment authorized with gift cards", "payment_id", payment.ID, "order_id", payment.OrderID, "amount", payment.GiftCardAmount)
+		return payment, nil
+	}
+
+	return s.executeAuthorization(ctx, provider, payment, req.PaymentMethod)
+}
+
+// resumeAuthorization handles a retried checkout. A payment still pending was
+// interrupted before the provider's answer was recorded, so the provider is
+// asked again with the same idempotency key, which can't authorize twice.
+func (s *paymentService) resumeAuthorization(ctx context.Context, payment *models.Payment, req *models.AuthorizePaymentRequest) (*models.Payment, error) {
+	if payment.OrderID != req.OrderID {
+		return nil, apperrors.Validation("invalid idempotency key: already used for a different order")
+	}
+
+	switch payment.Status {
+	case models.PaymentStatusPending:
+		if _, err := s.getPayableOrder(ctx, payment.UserID, payment.OrderID); err != nil {
+			return nil, err
+		}
+
+		provider, err := s.providers.Get(payment.Provider)
+		if err != nil {
+			return nil, err
+		}
+
+		s.logger.Info("Resuming interrupted payment authorization", "payment_id", payment.ID, "order_id", payment.OrderID)
+		return s.executeAuthorization(ctx, provider, payment, req.PaymentMethod)
+	case models.PaymentStatusFailed:
+		reason := "declined"
+		if payment.FailureReason != nil {
+			reason = *payment.FailureReason
+		}
+		return nil, apperrors.PaymentRequired("payment authorization failed: %s", reason)
+	}
+
+	return payment, nil
+}
+
+// getPayableOrder returns the user's order if it is awaiting payment
+func (s *paymentService) getPayableOrder(ctx context.Context, userID uuid.UUID, orderID uuid.UUID) (*models.PayableOrder, error) {
+	order, err := s.repo.GetPayableOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't reveal the existence of other users' orders
+	if order.UserID != userID {
+		return nil, apperrors.NotFound("order not found")
+	}
+
+	if order.Status != orderStatusPending {
+		return nil, apperrors.Conflict("order cannot be paid in its current state")
+	}
+
+	return order, nil
+}
+
+// executeAuthorization asks the provider to authorize the part of a pending
+// payment not covered by gift cards and records the outcome. The payment ID is
+// the provider idempotency key, so repeating the call for the same payment
+// never places a second hold.
+func (s *paymentService) executeAuthorization(ctx context.Context, provider providers.PaymentProvider, payment *models.Payment, paymentMethod string) (*models.Payment, error) {
+	idempotencyKey := payment.ID.String()
+	result, authErr := provider.Authorize(ctx, &providers.AuthorizeRequest{
+		Amount:         payment.ProviderAmount(),
+		Currency:       payment.Currency,
+		PaymentMethod:  paymentMethod,
+		Description:    "Order " + payment.OrderID.String(),
+		IdempotencyKey: idempotencyKey,
+		Metadata: map[string]string{
+			"order_id":   payment.OrderID.String(),
+			"payment_id": payment.ID.String(),
+		},
+	})
+	if result != nil {
+		payment.ProviderPaymentID = &result.ID
+	}
+
+	if authErr != nil {
+		reason := authErr.Error()
+		payment.Status = models.PaymentStatusFailed
+		payment.FailureReason = &reason
+	} else if result.Status == providers.StatusCaptured {
+		payment.Status = models.PaymentStatusCaptured
+		payment.CapturedAmount = result.Amount
+	} else {
+		payment.Status = models.PaymentStatusAuthorized
+	}
+
+	if err := s.repo.Update(ctx, payment); err != nil {
+		return nil, fmt.Errorf("failed to update payment: %w", err)
+	}
+	s.recordTransaction(ctx, payment, models.TransactionTypeAuthorize, payment.ProviderAmount(), result, idempotencyKey, authErr)
+
+	if authErr != nil {
+		s.reverseGiftCards(ctx, payment)
+		s.publishEvent(ctx, models.EventPaymentFailed, payment, payment.Amount, authErr.Error())
+		return nil, fmt.Errorf("payment authorization failed: %w", providerFailure(authErr))
+	}
+
+	s.publishEvent(ctx, models.EventPaymentAuthorized, payment, payment.Amount, "")
+
+	s.logger.Info("Payment authorized", "payment_id", payment.ID, "order_id", payment.OrderID, "provider", payment.Provider)
+	return payment, nil
+}
+
+// GetPayment returns one of the user's payments with its transaction history
+func (s *paymentService) GetPayment(ctx context.Context, userID uuid.UUID, paymentID uuid.UUID) (*models.Payment, error) {
+	payment, err := s.repo.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.UserID != userID {
+		return nil, apperrors.NotFound("payment not found")
+	}
+
+	payment.Transactions, err = s.repo.ListTransactions(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment transactions: %w", err)
+	}
+
+	return payment, nil
+}
+
+// Capture takes the authorized funds. A zero amount captures the full
+// authorization. Amounts refer to the provider's part of the payment, since
+// gift cards were already debited at checkout.
+func (s *paymentService) Capture(ctx context.Context, paymentID uuid.UUID, req *models.CaptureRequest) (*models.Payment, error) {
+	payment, err := s.repo.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Status != models.PaymentStatusAuthorized || (payment.ProviderPaymentID == nil && payment.ProviderAmount() > 0) {
+		return nil, apperrors.Conflict("payment cannot be captured in its current state")
+	}
+
+	if err := s.checkFraudHold(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	if payment.ProviderAmount() == 0 {
+		payment.Status = models.PaymentStatusCaptured
+		if err := s.repo.Update(ctx, payment); err != nil {
+			return nil, fmt.Errorf("failed to update payment: %w", err)
+		}
+
+		s.publishEvent(ctx, models.EventPaymentCaptured, payment, payment.GiftCardAmount, "")
+
+		s.logger.Info("Payment captured", "payment_id", payment.ID, "amount", payment.GiftCardAmount)
+		return payment, nil
+	}
+
+	amount := req.Amount
+	if amount == 0 {
+		amount = payment.ProviderAmount()
+	}
+	if amount > payment.ProviderAmount() {
+		return nil, apperrors.Validation("invalid capture amount: exceeds authorized amount")
+	}
+
+	provider, err := s.providers.Get(payment.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	idempotencyKey := "capture-" + payment.ID.String()
+	result, err := provider.Capture(ctx, *payment.ProviderPaymentID, amount, payment.Currency, idempotencyKey)
+	s.recordTransaction(ctx, payment, models.TransactionTypeCapture, amount, result, idempotencyKey, err)
+	if err != nil {
+		return nil, fmt.Errorf("capture failed: %w", providerFailure(err))
+	}
+
+	// Some providers (PayPal) identify the captured funds separately from the
+	// authorization; refunds refer to the capture from now on
+	payment.ProviderPaymentID = &result.ID
+	payment.Status = models.PaymentStatusCaptured
+	payment.CapturedAmount = amount
+	if err := s.repo.Update(ctx, payment); err != nil {
+		return nil, fmt.Errorf("failed to update payment: %w", err)
+	}
+
+	s.publishEvent(ctx, models.EventPaymentCaptured, payment, amount, "")
+
+	s.logger.Info("Payment captured", "payment_id", payment.ID, "amount", amount)
+	return payment, nil
+}
+
+// ChargeRecurring authorizes and immediately captures a renewal order with
+// the customer's saved payment method. An authorization that can't be
+// captured is voided, so the renewal can be retried with a new key.
+func (s *paymentService) ChargeRecurring(ctx context.Context, req *models.RecurringChargeRequest, idempotencyKey string) (*models.Payment, error) {
+	payment, err := s.Authorize(ctx, req.UserID, &models.AuthorizePaymentRequest{
+		OrderID:           req.OrderID,
+		PaymentMethod:     req.PaymentMethod,
+		PaymentMethodType: req.PaymentMethodType,
+		Provider:          req.Provider,
+	}, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Status == models.PaymentStatusCaptured {
+		return payment, nil
+	}
+
+	captured, err := s.Capture(ctx, payment.ID, &models.CaptureRequest{})
+	if err != nil {
+		if _, voidErr := s.void(ctx, payment, "void-"+payment.ID.String(), "recurring capture failed"); voidErr != nil {
+			s.logger.Error("Failed to void uncaptured recurring payment", "error", voidErr, "payment_id", payment.ID)
+		}
+		return nil, err
+	}
+
+	return captured, nil
+}
+
+// Void releases the hold on the customer's funds of an uncaptured payment
+func (s *paymentService) Void(ctx context.Context, paymentID uuid.UUID) (*models.Payment, error) {
+	payment, err := s.repo.GetByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.void(ctx, payment, "void-"+payment.ID.String(), ""); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// RefundOrder returns money for an order. Uncaptured authorizations are voided,
+// captured payments are refunded: to the provider first, and whatever exceeds
+// the provider's refundable amount to the gift cards the order was paid with.
+// Retries with the same idempotency key return the original result without
+// calling the provider again.
+func (s *paymentService) RefundOrder(ctx context.Context, req *models.RefundRequest, idempotencyKey string) (*models.RefundResponse, error) {
+	if idempotencyKey == "" {
+		idempotencyKey = req.RefundID.String()
+	}
+
+	for _, txnType := range []models.TransactionType{models.TransactionTypeRefund, models.TransactionTypeVoid} {
+		if txn, err := s.repo.GetTransactionByIdempotencyKey(ctx, txnType, idempotencyKey); err == nil {
+			// A retry may find the provider part done but not the gift card part;
+			// crediting the gift cards again with the same key has no effect
+			if txnType == models.TransactionTypeRefund && req.Amount > txn.Amount {
+				if _, err := s.repo.RefundGiftCards(ctx, txn.PaymentID, req.Amount-txn.Amount, idempotencyKey); err != nil {
+					return nil, err
+				}
+			}
+			return refundResponse(txn), nil
+		}
+	}
+
+	if entries, err := s.repo.ListGiftCardRefunds(ctx, idempotencyKey); err == nil && len(entries) > 0 {
+		return giftCardRefundResponse(entries), nil
+	}
+
+	payment, err := s.repo.GetActiveByOrderID(ctx, req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Currency != req.Currency {
+		return nil, apperrors.Validation("invalid currency: payment was made in %s", payment.Currency)
+	}
+
+	switch payment.Status {
+	case models.PaymentStatusAuthorized:
+		// Nothing was taken yet, so only releasing the whole hold makes sense
+		if req.Amount != payment.Amount {
+			return nil, apperrors.Conflict("payment cannot be partially refunded before capture")
+		}
+		txn, err := s.void(ctx, payment, idempotencyKey, req.Reason)
+		if err != nil {
+			return nil, err
+		}
+		return refundResponse(txn), nil
+	case models.PaymentStatusCaptured, models.PaymentStatusPartiallyRefunded:
+	default:
+		return nil, apperrors.Conflict("payment cannot be refunded in its current state")
+	}
+
+	providerAmount := min(req.Amount, payment.RefundableAmount())
+	giftCardAmount := req.Amount - providerAmount
+	if giftCardAmount > payment.GiftCardRefundableAmount() {
+		return nil, apperrors.Validation("refund amount exceeds refundable amount")
+	}
+
+	var response *models.RefundResponse
+	if providerAmount > 0 {
+		result, err := s.refund(ctx, payment, providerAmount, idempotencyKey, req.Reason)
+		if err != nil {
+			return nil, err
+		}
+		response = &models.RefundResponse{ProviderRefundID: result.ID, Status: result.Status}
+	}
+
+	if giftCardAmount > 0 {
+		entries, err := s.refundGiftCards(ctx, payment, giftCardAmount, idempotencyKey, req.Reason)
+		if err != nil {
+			return nil, err
+		}
+		if response == nil {
+			response = giftCardRefundResponse(entries)
+		}
+	}
+
+	return response, nil
+}
+
+// refund returns part of a captured payment. The amount is reserved before the
+// provider is called so concurrent refunds can't exceed the captured amount.
+func (s *paymentService) refund(ctx context.Context, payment *models.Payment, amount int64, idempotencyKey, reason string) (*providers.Result, error) {
+	provider, err := s.providers.Get(payment.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReserveRefund(ctx, payment.ID, amount); err != nil {
+		return nil, err
+	}
+
+	result, refundErr := provider.Refund(ctx, *payment.ProviderPaymentID, amount, payment.Currency, idempotencyKey)
+	s.recordTransaction(ctx, payment, models.TransactionTypeRefund, amount, result, idempotencyKey, refundErr)
+	if refundErr != nil {
+		if err := s.repo.ReleaseRefund(ctx, payment.ID, amount); err != nil {
+			s.logger.Error("Failed to release refund reservation", "error", err, "payment_id", payment.ID)
+		}
+		return nil, fmt.Errorf("refund failed: %w", providerFailure(refundErr))
+	}
+
+	payment.RefundedAmount += amount
+	s.publishEvent(ctx, models.EventPaymentRefunded, payment, amount, reason)
+
+	s.logger.Info("Payment refunded", "payment_id", payment.ID, "order_id", payment.OrderID, "amount", amount)
+	return result, nil
+}
+
+// void cancels an authorized payment, credits back its gift cards and records
+// the void transaction. Payments covered by gift cards alone have no provider
+// authorization to cancel.
+func (s *paymentService) void(ctx context.Context, payment *models.Payment, idempotencyKey, reason string) (*models.Transaction, error) {
+	if payment.Status != models.PaymentStatusAuthorized || (payment.ProviderPaymentID == nil && payment.ProviderAmount() > 0) {
+		return nil, apperrors.Conflict("payment cannot be voided in its current state")
+	}
+
+	var result *providers.Result
+	var voidErr error
+	if payment.ProviderAmount() > 0 {
+		provider, err := s.providers.Get(payment.Provider)
+		if err != nil {
+			return nil, err
+		}
+
+		result, voidErr = provider.Void(ctx, *payment.ProviderPaymentID, idempotencyKey)
+	}
+	txn := s.recordTransaction(ctx, payment, models.TransactionTypeVoid, payment.Amount, result, idempotencyKey, voidErr)
+	if voidErr != nil {
+		return nil, fmt.Errorf("void failed: %w", providerFailure(voidErr))
+	}
+
+	payment.Status = models.PaymentStatusVoided
+	if err := s.repo.Update(ctx, payment); err != nil {
+		return nil, fmt.Errorf("failed to update payment: %w", err)
+	}
+	s.reverseGiftCards(ctx, payment)
+
+	s.publishEvent(ctx, models.EventPaymentVoided, payment, payment.Amount, reason)
+
+	s.logger.Info("Payment voided", "payment_id", payment.ID, "order_id", payment.OrderID)
+	return txn, nil
+}
+
+// providerFailure returns the error of a provider call that failed: declines
+// are the customer's to resolve with another payment method, rejections and
+// outages the provider's
+func providerFailure(err error) error {
+	var providerErr *providers.ProviderError
+	if !errors.As(err, &providerErr) {
+		return apperrors.Upstream("payment provider is unavailable").Wrap(err)
+	}
+	if providerErr.Declined {
+		return apperrors.PaymentRequired("%s", providerErr.Message).Wrap(err)
+	}
+	return apperrors.Upstream("payment provider rejected the request").Wrap(err)
+}
+
+// recordTransaction stores the outcome of a provider call. Failures to record
+// are logged rather than returned, since the provider call already happened.
+func (s *paymentService) recordTransaction(
+	ctx context.Context,
+	payment *models.Payment,
+	txnType models.TransactionType,
+	amount int64,
+	result *providers.Result,
+	idempotencyKey string,
+	providerErr error,
+) *models.Transaction {
+	txn := &models.Transaction{
+		ID:             uuid.New(),
+		PaymentID:      payment.ID,
+		Type:           txnType,
+		Status:         models.TransactionStatusSucceeded,
+		Amount:         amount,
+		IdempotencyKey: &idempotencyKey,
+	}
+	if result != nil {
+		txn.ProviderTransactionID = &result.ID
+	}
+	if providerErr != nil {
+		message := providerErr.Error()
+		txn.Status = models.TransactionStatusFailed
+		txn.ErrorMessage = &message
+	}
+
+	if err := s.repo.CreateTransaction(ctx, txn); err != nil {
+		s.logger.Error("Failed to record payment transaction", "error", err, "payment_id", payment.ID, "type", txnType)
+	}
+
+	return txn
+}
+
+// publishEvent publishes a payment event, logging rather than failing the
+// request when the broker is unavailable
+func (s *paymentService) publishEvent(ctx context.Context, eventType string, payment *models.Payment, amount int64, reason string) {
+	if s.publisher == nil {
+		return
+	}
+
+	event := &models.PaymentEvent{
+		Type:       eventType,
+		PaymentID:  payment.ID,
+		OrderID:    payment.OrderID,
+		UserID:     payment.UserID,
+		Provider:   payment.Provider,
+		Status:     string(payment.Status),
+		Amount:     amount,
+		Currency:   payment.Currency,
+		Reason:     reason,
+		OccurredAt: time.Now().UTC(),
+	}
+
+	envelope, err := events.New(ctx, eventSource, eventType, payment.ID.String(), models.PaymentEventSchemaVersion, event)
+	if err != nil {
+		s.logger.Error("Failed to publish payment event", "error", err, "type", eventType, "payment_id", payment.ID)
+		return
+	}
+
+	if err := s.publisher.Publish(ctx, s.config.Kafka.Topics.PaymentEvents, payment.OrderID.String(), envelope); err != nil {
+		s.logger.Error("Failed to publish payment event", "error", err, "type", eventType, "payment_id", payment.ID)
+	}
+}
+
+// refundResponse builds the response returned to the order service from a recorded transaction
+func refundResponse(txn *models.Transaction) *models.RefundResponse {
+	response := &models.RefundResponse{Status: providers.StatusRefunded}
+	if txn.Type == models.TransactionTypeVoid {
+		response.Status = providers.StatusVoided
+	}
+	if txn.ProviderTransactionID != nil {
+		response.ProviderRefundID = *txn.ProviderTransactionID
+	}
+	return response
+}
+
+// giftCardRefundResponse builds the response for a refund made entirely to
+// gift cards, identified by its first ledger entry
+func giftCardRefundResponse(entries []*models.GiftCardLedgerEntry) *models.RefundResponse {
+	return &models.RefundResponse{ProviderRefundID: entries[0].ID.String(), Status: providers.StatusRefunded}
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/payment/models"
+	"github.com/kaanevranportfolio/Commercium/internal/payment/providers"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+)
+
+const (
+	// webhookLease is how long a claimed event is reserved for one worker
+	webhookLease = 2 * time.Minute
+
+	webhookRetryBaseDelay = 10 * time.Second
+	webhookRetryMaxDelay  = time.Hour
+)
+
+// HandleWebhook verifies a provider callback and queues it for processing.
+// Redeliveries of an event that was already received are acknowledged without
+// being queued again.
+func (s *paymentService) HandleWebhook(ctx context.Context, providerName string, header http.Header, body []byte) error {
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return apperrors.NotFound("unknown provider").Wrap(err)
+	}
+
+	parser, ok := provider.(providers.WebhookParser)
+	if !ok {
+		return apperrors.NotFound("payment provider %s does not send webhooks", providerName)
+	}
+
+	parsed, err := parser.ParseWebhook(ctx, header, body)
+	if err != nil {
+		return err
+	}
+
+	if parsed.ID == "" {
+		return apperrors.Validation("invalid webhook payload: missing event id")
+	}
+
+	event := &models.WebhookEvent{
+		ID:                uuid.New(),
+		Provider:          provider.Name(),
+		EventID:           parsed.ID,
+		ProviderEventType: parsed.ProviderType,
+		EventType:         optionalString(parsed.Type),
+		ProviderPaymentID: optionalString(parsed.PaymentID),
+		RelatedPaymentID:  optionalString(parsed.RelatedPaymentID),
+		Amount:            parsed.Amount,
+		Currency:          optionalString(parsed.Currency),
+		Payload:           models.RawJSON(body),
+		Status:            models.WebhookEventStatusPending,
+	}
+	if parsed.Type == "" {
+		event.Status = models.WebhookEventStatusIgnored
+	}
+
+	created, err := s.repo.CreateWebhookEvent(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	if !created {
+		s.logger.Info("Duplicate webhook event acknowledged", "provider", providerName, "event_id", parsed.ID)
+	}
+
+	return nil
+}
+
+// ProcessWebhookEvents processes one batch of queued webhook events and
+// returns the number of events claimed. Failed events are retried with
+// exponential backoff until the configured number of attempts is used up.
+func (s *paymentService) ProcessWebhookEvents(ctx context.Context) (int, error) {
+	cfg := s.config.Services.Payment.Webhooks
+
+	events, err := s.repo.ClaimWebhookEvents(ctx, cfg.BatchSize, webhookLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		status, err := s.processWebhookEvent(ctx, event)
+		if err == nil {
+			if err := s.repo.CompleteWebhookEvent(ctx, event.ID, status); err != nil {
+				s.logger.Error("Failed to complete webhook event", "error", err, "event_id", event.EventID)
+			}
+			continue
+		}
+
+		var nextAttemptAt *time.Time
+		if event.Attempts < cfg.MaxAttempts {
+			next := time.Now().Add(webhookRetryDelay(event.Attempts))
+			nextAttemptAt = &next
+		}
+
+		s.logger.Error("Failed to process webhook event",
+			"error", err,
+			"provider", event.Provider,
+			"event_id", event.EventID,
+			"attempts", event.Attempts,
+			"will_retry", nextAttemptAt != nil,
+		)
+
+		if err := s.repo.FailWebhookEvent(ctx, event.ID, err.Error(), nextAttemptAt); err != nil {
+			s.logger.Error("Failed to record webhook event failure", "error", err, "event_id", event.EventID)
+		}
+	}
+
+	return len(events), nil
+}
+
+// processWebhookEvent applies an event to its payment and reconciles the payment with its order
+func (s *paymentService) processWebhookEvent(ctx context.Context, event *models.WebhookEvent) (models.WebhookEventStatus, error) {
+	if event.EventType == nil || event.ProviderPaymentID == nil {
+		return models.WebhookEventStatusIgnored, nil
+	}
+
+	ids := []string{*event.ProviderPaymentID}
+	if event.RelatedPaymentID != nil {
+		ids = append(ids, *event.RelatedPaymentID)
+	}
+
+	payment, err := s.repo.GetByProviderPaymentID(ctx, event.Provider, ids)
+	if err != nil {
+		// Payments created outside this service (e.g. in the provider dashboard) are not ours to track
+		s.logger.Warn("Webhook event for unknown payment", "provider", event.Provider, "event_id", event.EventID)
+		return models.WebhookEventStatusIgnored, nil
+	}
+
+	if err := s.applyWebhookEvent(ctx, payment, event); err != nil {
+		return "", err
+	}
+
+	if err := s.reconcileWithOrder(ctx, payment); err != nil {
+		return "", err
+	}
+
+	return models.WebhookEventStatusProcessed, nil
+}
+
+// applyWebhookEvent moves a payment to the state reported by the provider.
+// Events describing a state we already recorded are no-ops.
+func (s *paymentService) applyWebhookEvent(ctx context.Context, payment *models.Payment, event *models.WebhookEvent) error {
+	var eventType string
+
+	switch *event.EventType {
+	case providers.WebhookPaymentCaptured:
+		if payment.Status != models.PaymentStatusAuthorized {
+			return nil
+		}
+		payment.Status = models.PaymentStatusCaptured
+		payment.CapturedAmount = event.Amount
+		payment.ProviderPaymentID = event.ProviderPaymentID
+		eventType = models.EventPaymentCaptured
+	case providers.WebhookPaymentVoided:
+		if payment.Status != models.PaymentStatusAuthorized {
+			return nil
+		}
+		payment.Status = models.PaymentStatusVoided
+		eventType = models.EventPaymentVoided
+	case providers.WebhookPaymentFailed:
+		if payment.Status != models.PaymentStatusPending && payment.Status != models.PaymentStatusAuthorized {
+			return nil
+		}
+		reason := "declined by provider: " + event.ProviderEventType
+		payment.Status = models.PaymentStatusFailed
+		payment.FailureReason = &reason
+		eventType = models.EventPaymentFailed
+	case providers.WebhookPaymentRefunded:
+		return s.applyExternalRefund(ctx, payment, event)
+	default:
+		return nil
+	}
+
+	if err := s.repo.Update(ctx, payment); err != nil {
+		return fmt.Errorf("failed to update payment: %w", err)
+	}
+	if payment.Status == models.PaymentStatusVoided || payment.Status == models.PaymentStatusFailed {
+		s.reverseGiftCards(ctx, payment)
+	}
+
+	s.publishEvent(ctx, eventType, payment, event.Amount, "")
+
+	s.logger.Info("Payment updated from webhook", "payment_id", payment.ID, "status", payment.Status, "event_id", event.EventID)
+	return nil
+}
+
+// applyExternalRefund records refunds issued outside this service, e.g. from
+// the provider dashboard. Refunds we issued ourselves are already counted,
+// since the refunded total is reserved before the provider is called.
+func (s *paymentService) applyExternalRefund(ctx context.Context, payment *models.Payment, event *models.WebhookEvent) error {
+	delta := event.Amount - payment.RefundedAmount
+	if delta <= 0 {
+		return nil
+	}
+
+	if err := s.repo.ReserveRefund(ctx, payment.ID, delta); err != nil {
+		return err
+	}
+	payment.RefundedAmount += delta
+
+	idempotencyKey := "webhook-" + event.EventID
+	result := &providers.Result{ID: *event.ProviderPaymentID, Status: providers.StatusRefunded, Amount: delta}
+	s.recordTransaction(ctx, payment, models.TransactionTypeRefund, delta, result, idempotencyKey, nil)
+
+	s.publishEvent(ctx, models.EventPaymentRefunded, payment, delta, "refunded at provider")
+
+	s.logger.Warn("Recorded refund issued outside the payment service", "payment_id", payment.ID, "amount", delta, "event_id", event.EventID)
+	return nil
+}
+
+// reconcileWithOrder makes sure no money is held for an order that no longer
+// wants it, and flags captures that don't match the order total
+func (s *paymentService) reconcileWithOrder(ctx context.Context, payment *models.Payment) error {
+	order, err := s.repo.GetPayableOrder(ctx, payment.OrderID)
+	if err != nil {
+		return err
+	}
+
+	idempotencyKey := "reconcile-" + payment.ID.String()
+
+	if order.Status == orderStatusCancelled {
+		switch payment.Status {
+		case models.PaymentStatusAuthorized:
+			s.logger.Warn("Voiding authorization of cancelled order", "payment_id", payment.ID, "order_id", order.ID)
+			_, err := s.void(ctx, payment, idempotencyKey, "order cancelled")
+			return err
+		case models.PaymentStatusCaptured, models.PaymentStatusPartiallyRefunded:
+			if payment.RefundableAmount() > 0 {
+				s.logger.Warn("Refunding capture of cancelled order", "payment_id", payment.ID, "order_id", order.ID)
+				if _, err := s.refund(ctx, payment, payment.RefundableAmount(), idempotencyKey, "order cancelled"); err != nil {
+					return err
+				}
+			}
+			if payment.GiftCardRefundableAmount() > 0 {
+				s.logger.Warn("Refunding gift cards of cancelled order", "payment_id", payment.ID, "order_id", order.ID)
+				if _, err := s.refundGiftCards(ctx, payment, payment.GiftCardRefundableAmount(), idempotencyKey, "order cancelled"); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if payment.Status == models.PaymentStatusCaptured && payment.CapturedAmount+payment.GiftCardAmount != order.TotalAmount {
+		s.logger.Warn("Captured amount does not match order total",
+			"payment_id", payment.ID,
+			"order_id", order.ID,
+			"captured_amount", payment.CapturedAmount,
+			"gift_card_amount", payment.GiftCardAmount,
+			"order_total", order.TotalAmount,
+		)
+	}
+
+	return nil
+}
+
+// webhookRetryDelay returns the exponential backoff delay after the given number of attempts
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := webhookRetryBaseDelay
+	for i := 1; i < attempts && delay < webhookRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > webhookRetryMaxDelay {
+		delay = webhookRetryMaxDelay
+	}
+	return delay
+}
+
+// optionalString returns nil for empty strings, for nullable columns
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
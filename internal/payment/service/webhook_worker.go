@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// WebhookWorker periodically processes queued webhook events in the background
+type WebhookWorker struct {
+	paymentService PaymentService
+	interval       time.Duration
+	batchSize      int
+	wake           chan struct{}
+	logger         *logger.Logger
+}
+
+// NewWebhookWorker creates a new webhook worker
+func NewWebhookWorker(paymentService PaymentService, interval time.Duration, batchSize int, logger *logger.Logger) *WebhookWorker {
+	return &WebhookWorker{
+		paymentService: paymentService,
+		interval:       interval,
+		batchSize:      batchSize,
+		wake:           make(chan struct{}, 1),
+		logger:         logger,
+	}
+}
+
+// Wake makes the worker process events now rather than on its next tick,
+// e.g. when an event was queued
+func (w *WebhookWorker) Wake() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run processes events until ctx is cancelled. Each tick, or wake up,
+// drains the queue batch by batch so a backlog is worked off without
+// waiting for more ticks.
+func (w *WebhookWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+
+		for ctx.Err() == nil {
+			processed, err := w.paymentService.ProcessWebhookEvents(ctx)
+			if err != nil {
+				w.logger.Error("Failed to process webhook events", "error", err)
+				break
+			}
+			if processed < w.batchSize {
+				break
+			}
+		}
+	}
+}
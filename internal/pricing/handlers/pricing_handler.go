@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/pricing/models"
+	"github.com/kaanevranportfolio/Commercium/internal/pricing/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/validation"
+)
+
+// PricingHandler handles HTTP requests for pricing operations
+type PricingHandler struct {
+	pricingService service.PricingService
+	jwtService     *auth.JWTService
+	logger         *logger.Logger
+}
+
+// NewPricingHandler creates a new pricing handler
+func NewPricingHandler(pricingService service.PricingService, jwtService *auth.JWTService, logger *logger.Logger) *PricingHandler {
+	return &PricingHandler{
+		pricingService: pricingService,
+		jwtService:     jwtService,
+		logger:         logger,
+	}
+}
+
+// GetPrices returns the current prices of SKUs for anonymous customers
+func (h *PricingHandler) GetPrices(c *gin.Context) {
+	var req models.PublicPricesRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	prices, err := h.pricingService.ResolvePrices(c.Request.Context(), &models.ResolvePricesRequest{
+		Currency: req.Currency,
+		SKUs:     req.SKUs,
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to get prices")
+		return
+	}
+
+	c.JSON(http.StatusOK, prices)
+}
+
+// CreatePriceList creates a price list (admin)
+func (h *PricingHandler) CreatePriceList(c *gin.Context) {
+	var req models.CreatePriceListRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	list, err := h.pricingService.CreatePriceList(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to create price list")
+		return
+	}
+
+	c.JSON(http.StatusCreated, list)
+}
+
+// ListPriceLists lists every price list (admin)
+func (h *PricingHandler) ListPriceLists(c *gin.Context) {
+	lists, err := h.pricingService.ListPriceLists(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "Failed to list price lists")
+		return
+	}
+
+	httpx.Items(c, lists)
+}
+
+// GetPriceList returns a price list (admin)
+func (h *PricingHandler) GetPriceList(c *gin.Context) {
+	listID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price list ID"})
+		return
+	}
+
+	list, err := h.pricingService.GetPriceList(c.Request.Context(), listID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get price list")
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// UpdatePriceList changes a price list (admin)
+func (h *PricingHandler) UpdatePriceList(c *gin.Context) {
+	listID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price list ID"})
+		return
+	}
+
+	var req models.UpdatePriceListRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	list, err := h.pricingService.UpdatePriceList(c.Request.Context(), listID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to update price list")
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// ListPrices lists the prices of a price list (admin)
+func (h *PricingHandler) ListPrices(c *gin.Context) {
+	listID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price list ID"})
+		return
+	}
+
+	var req models.ListPricesRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	prices, err := h.pricingService.ListPrices(c.Request.Context(), listID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to list prices")
+		return
+	}
+
+	httpx.Items(c, prices)
+}
+
+// BulkUpdatePrices adds or schedules many prices at once (admin)
+func (h *PricingHandler) BulkUpdatePrices(c *gin.Context) {
+	adminID := auth.UserIDFromContext(c)
+
+	listID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price list ID"})
+		return
+	}
+
+	var req models.BulkPricesRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	response, err := h.pricingService.BulkUpdatePrices(c.Request.Context(), adminID, listID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to update prices")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// DeletePrice cancels a scheduled price or ends a sale early (admin)
+func (h *PricingHandler) DeletePrice(c *gin.Context) {
+	listID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price list ID"})
+		return
+	}
+
+	priceID, err := uuid.Parse(c.Param("priceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price ID"})
+		return
+	}
+
+	if err := h.pricingService.DeletePrice(c.Request.Context(), listID, priceID); err != nil {
+		h.respondError(c, err, "Failed to delete price")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Price deleted"})
+}
+
+// GetCustomerGroup returns a customer's customer group (admin)
+func (h *PricingHandler) GetCustomerGroup(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	group, err := h.pricingService.GetCustomerGroup(c.Request.Context(), userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get customer group")
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// SetCustomerGroup assigns a customer to a customer group (admin)
+func (h *PricingHandler) SetCustomerGroup(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.SetCustomerGroupRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	group, err := h.pricingService.SetCustomerGroup(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to set customer group")
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// DeleteCustomerGroup removes a customer from their customer group (admin)
+func (h *PricingHandler) DeleteCustomerGroup(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.pricingService.DeleteCustomerGroup(c.Request.Context(), userID); err != nil {
+		h.respondError(c, err, "Failed to delete customer group")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Customer group removed"})
+}
+
+// ResolvePrices returns the prices a customer pays (internal)
+func (h *PricingHandler) ResolvePrices(c *gin.Context) {
+	var req models.ResolvePricesRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	prices, err := h.pricingService.ResolvePrices(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to resolve prices")
+		return
+	}
+
+	c.JSON(http.StatusOK, prices)
+}
+
+// respondError logs a request failing on the side of the service, or of a
+// provider it depends on, and answers it with the problem details of err,
+// so middleware reading its status after it, such as idempotency's, sees
+// the failure
+func (h *PricingHandler) respondError(c *gin.Context, err error, message string) {
+	if apperrors.HTTPStatus(err) >= http.StatusInternalServerError {
+		logger.FromContext(c.Request.Context()).Error(message, "error", err)
+	}
+	apperrors.Respond(c, err)
+}
+
+// SetupRoutes sets up the pricing routes.
+// Internal routes are called by other services and must not be exposed through the gateway.
+func (h *PricingHandler) SetupRoutes(r *gin.Engine) {
+	r.GET("/api/v1/prices", h.GetPrices)
+
+	admin := r.Group("/api/v1/admin")
+	admin.Use(h.jwtService.Middleware(), auth.RequireRole("admin"))
+	{
+		admin.GET("/price-lists", h.ListPriceLists)
+		admin.POST("/price-lists", h.CreatePriceList)
+		admin.GET("/price-lists/:id", h.GetPriceList)
+		admin.PUT("/price-lists/:id", h.UpdatePriceList)
+		admin.GET("/price-lists/:id/prices", h.ListPrices)
+		admin.POST("/price-lists/:id/prices", h.BulkUpdatePrices)
+		admin.DELETE("/price-lists/:id/prices/:priceId", h.DeletePrice)
+
+		admin.GET("/customer-groups/:userId", h.GetCustomerGroup)
+		admin.PUT("/customer-groups/:userId", h.SetCustomerGroup)
+		admin.DELETE("/customer-groups/:userId", h.DeleteCustomerGroup)
+	}
+
+	internal := r.Group("/internal/v1")
+	{
+		internal.POST("/prices/resolve", h.ResolvePrices)
+	}
+}
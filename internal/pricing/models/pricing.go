@@ -0,0 +1,150 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PriceKind distinguishes regular prices from time-bounded sale prices
+type PriceKind string
+
+const (
+	PriceKindRegular PriceKind = "regular"
+	PriceKindSale    PriceKind = "sale"
+)
+
+// IsValid reports whether the kind is a known price kind
+func (k PriceKind) IsValid() bool {
+	switch k {
+	case PriceKindRegular, PriceKindSale:
+		return true
+	}
+	return false
+}
+
+// PriceList is a set of prices in one currency, optionally restricted to a
+// customer group
+type PriceList struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	Name          string    `json:"name" db:"name"`
+	Currency      string    `json:"currency" db:"currency"`
+	CustomerGroup *string   `json:"customer_group,omitempty" db:"customer_group"`
+	Priority      int       `json:"priority" db:"priority"`
+	IsActive      bool      `json:"is_active" db:"is_active"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Price is the price of a SKU in a price list. A regular price applies from
+// StartsAt until a later regular price starts; a sale price applies between
+// StartsAt and EndsAt.
+type Price struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	PriceListID uuid.UUID  `json:"price_list_id" db:"price_list_id"`
+	SKU         string     `json:"sku" db:"sku"`
+	Kind        PriceKind  `json:"kind" db:"kind"`
+	Amount      int64      `json:"amount" db:"amount"`
+	StartsAt    time.Time  `json:"starts_at" db:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at,omitempty" db:"ends_at"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CandidatePrice is a price together with the list it belongs to, as
+// considered during price resolution
+type CandidatePrice struct {
+	Price
+	CustomerGroup *string `db:"customer_group"`
+	Priority      int     `db:"priority"`
+}
+
+// CreatePriceListRequest represents a request to create a price list
+type CreatePriceListRequest struct {
+	Name          string  `json:"name" binding:"required,max=100"`
+	Currency      string  `json:"currency" binding:"required,len=3"`
+	CustomerGroup *string `json:"customer_group,omitempty" binding:"omitempty,min=1,max=50"`
+	Priority      int     `json:"priority"`
+}
+
+// UpdatePriceListRequest represents a request to change a price list
+type UpdatePriceListRequest struct {
+	Name     *string `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	Priority *int    `json:"priority,omitempty"`
+	IsActive *bool   `json:"is_active,omitempty"`
+}
+
+// PriceEntry is one price of a bulk price update. StartsAt defaults to now;
+// a later StartsAt schedules the change.
+type PriceEntry struct {
+	SKU      string     `json:"sku" binding:"required,max=100"`
+	Kind     PriceKind  `json:"kind,omitempty"`
+	Amount   int64      `json:"amount" binding:"min=0"`
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
+
+// BulkPricesRequest adds many prices to a price list at once
+type BulkPricesRequest struct {
+	Prices []PriceEntry `json:"prices" binding:"required,min=1,dive"`
+}
+
+// BulkPricesResponse reports the prices added by a bulk update
+type BulkPricesResponse struct {
+	Created int      `json:"created"`
+	Prices  []*Price `json:"prices"`
+}
+
+// ListPricesRequest filters the prices of a price list
+type ListPricesRequest struct {
+	SKU string `form:"sku" binding:"omitempty,max=100"`
+	// Upcoming lists only prices that have not started yet
+	Upcoming bool `form:"upcoming"`
+}
+
+// CustomerGroup assigns a customer to a customer group
+type CustomerGroup struct {
+	UserID        uuid.UUID `json:"user_id" db:"user_id"`
+	CustomerGroup string    `json:"customer_group" db:"customer_group"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SetCustomerGroupRequest assigns a customer to a customer group
+type SetCustomerGroupRequest struct {
+	CustomerGroup string `json:"customer_group" binding:"required,max=50"`
+}
+
+// ResolvePricesRequest asks for the prices a customer pays for SKUs. At
+// defaults to now and can be set to preview scheduled changes.
+type ResolvePricesRequest struct {
+	UserID   uuid.UUID  `json:"user_id"`
+	Currency string     `json:"currency" binding:"required,len=3"`
+	SKUs     []string   `json:"skus" binding:"required,min=1,max=500,dive,required,max=100"`
+	At       *time.Time `json:"at,omitempty"`
+}
+
+// ResolvedPrice is the price a customer pays for a SKU. ListPrice is the
+// regular price; UnitPrice is lower while a sale is on.
+type ResolvedPrice struct {
+	SKU         string     `json:"sku"`
+	UnitPrice   int64      `json:"unit_price"`
+	ListPrice   int64      `json:"list_price"`
+	OnSale      bool       `json:"on_sale"`
+	SaleEndsAt  *time.Time `json:"sale_ends_at,omitempty"`
+	PriceListID uuid.UUID  `json:"price_list_id"`
+}
+
+// ResolvePricesResponse holds the resolved prices. SKUs without a price in
+// any applicable list are reported in Missing.
+type ResolvePricesResponse struct {
+	Currency string           `json:"currency"`
+	Prices   []*ResolvedPrice `json:"prices"`
+	Missing  []string         `json:"missing,omitempty"`
+}
+
+// PublicPricesRequest asks for the current prices of SKUs
+type PublicPricesRequest struct {
+	Currency string   `form:"currency" binding:"required,len=3"`
+	SKUs     []string `form:"sku" binding:"required,min=1,max=100"`
+}
@@ -0,0 +1,328 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kaanevranportfolio/Commercium/internal/pricing/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// PricingRepository defines the interface for pricing data operations
+type PricingRepository interface {
+	// Price list operations
+	CreatePriceList(ctx context.Context, list *models.PriceList) error
+	GetPriceList(ctx context.Context, id uuid.UUID) (*models.PriceList, error)
+	ListPriceLists(ctx context.Context) ([]*models.PriceList, error)
+	UpdatePriceList(ctx context.Context, list *models.PriceList) error
+
+	// Price operations
+	CreatePrices(ctx context.Context, prices []*models.Price) error
+	GetPrice(ctx context.Context, priceListID, id uuid.UUID) (*models.Price, error)
+	ListPrices(ctx context.Context, priceListID uuid.UUID, req *models.ListPricesRequest) ([]*models.Price, error)
+	DeletePrice(ctx context.Context, id uuid.UUID) error
+	// CandidatePrices returns the prices in effect at a time in the active
+	// lists of a currency that apply to a customer group
+	CandidatePrices(ctx context.Context, currency string, customerGroup *string, skus []string, at time.Time) ([]*models.CandidatePrice, error)
+
+	// Customer group operations
+	GetCustomerGroup(ctx context.Context, userID uuid.UUID) (*models.CustomerGroup, error)
+	UpsertCustomerGroup(ctx context.Context, group *models.CustomerGroup) error
+	DeleteCustomerGroup(ctx context.Context, userID uuid.UUID) error
+}
+
+// pricingRepository implements the PricingRepository interface
+type pricingRepository struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewPricingRepository creates a new pricing repository
+func NewPricingRepository(db *database.DB, logger *logger.Logger) PricingRepository {
+	return &pricingRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const priceListColumns = `id, name, currency, customer_group, priority, is_active, created_at, updated_at`
+
+const priceColumns = `id, price_list_id, sku, kind, amount, starts_at, ends_at, created_by, created_at`
+
+// CreatePriceList stores a new price list
+func (r *pricingRepository) CreatePriceList(ctx context.Context, list *models.PriceList) error {
+	query := `
+		INSERT INTO price_lists (id, name, currency, customer_group, priority, is_active)
+		VALUES (:id, :name, :currency, :customer_group, :priority, :is_active)
+		RETURNING created_at, updated_at`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to prepare create price list statement", "error", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, list).Scan(&list.CreatedAt, &list.UpdatedAt)
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return apperrors.Conflict("price list already exists: %s", list.Name)
+		}
+		r.logger.Error("Failed to create price list", "error", err, "name", list.Name)
+		return fmt.Errorf("failed to create price list: %w", err)
+	}
+
+	return nil
+}
+
+// GetPriceList retrieves a price list by ID
+func (r *pricingRepository) GetPriceList(ctx context.Context, id uuid.UUID) (*models.PriceList, error) {
+	list := &models.PriceList{}
+	query := `SELECT ` + priceListColumns + ` FROM price_lists WHERE id = $1`
+
+	err := r.db.GetContext(ctx, list, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("price list not found")
+		}
+		r.logger.Error("Failed to get price list", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to get price list: %w", err)
+	}
+
+	return list, nil
+}
+
+// ListPriceLists retrieves all price lists
+func (r *pricingRepository) ListPriceLists(ctx context.Context) ([]*models.PriceList, error) {
+	lists := []*models.PriceList{}
+	query := `
+		SELECT ` + priceListColumns + `
+		FROM price_lists
+		ORDER BY currency, customer_group NULLS FIRST, priority DESC, name`
+
+	err := r.db.SelectContext(ctx, &lists, query)
+	if err != nil {
+		r.logger.Error("Failed to list price lists", "error", err)
+		return nil, fmt.Errorf("failed to list price lists: %w", err)
+	}
+
+	return lists, nil
+}
+
+// UpdatePriceList updates the name, priority and status of a price list
+func (r *pricingRepository) UpdatePriceList(ctx context.Context, list *models.PriceList) error {
+	query := `
+		UPDATE price_lists
+		SET name = $2, priority = $3, is_active = $4
+		WHERE id = $1
+		RETURNING updated_at`
+
+	err := r.db.QueryRowxContext(ctx, query, list.ID, list.Name, list.Priority, list.IsActive).Scan(&list.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.NotFound("price list not found")
+		}
+		if database.IsUniqueViolation(err) {
+			return apperrors.Conflict("price list already exists: %s", list.Name)
+		}
+		r.logger.Error("Failed to update price list", "error", err, "id", list.ID)
+		return fmt.Errorf("failed to update price list: %w", err)
+	}
+
+	return nil
+}
+
+// CreatePrices stores a batch of prices in one transaction, so a bulk
+// update is applied completely or not at all
+func (r *pricingRepository) CreatePrices(ctx context.Context, prices []*models.Price) error {
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		query := `
+			INSERT INTO prices (id, price_list_id, sku, kind, amount, starts_at, ends_at, created_by)
+			VALUES (:id, :price_list_id, :sku, :kind, :amount, :starts_at, :ends_at, :created_by)
+			RETURNING created_at`
+
+		stmt, err := tx.PrepareNamedContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, price := range prices {
+			if err := stmt.QueryRowxContext(ctx, price).Scan(&price.CreatedAt); err != nil {
+				if database.IsForeignKeyViolation(err) {
+					return apperrors.NotFound("price list not found")
+				}
+				r.logger.Error("Failed to create price", "error", err, "sku", price.SKU)
+				return fmt.Errorf("failed to create price: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetPrice retrieves a price of a price list
+func (r *pricingRepository) GetPrice(ctx context.Context, priceListID, id uuid.UUID) (*models.Price, error) {
+	price := &models.Price{}
+	query := `SELECT ` + priceColumns + ` FROM prices WHERE id = $1 AND price_list_id = $2`
+
+	err := r.db.GetContext(ctx, price, query, id, priceListID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("price not found")
+		}
+		r.logger.Error("Failed to get price", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to get price: %w", err)
+	}
+
+	return price, nil
+}
+
+// ListPrices retrieves the prices of a price list, newest first
+func (r *pricingRepository) ListPrices(ctx context.Context, priceListID uuid.UUID, req *models.ListPricesRequest) ([]*models.Price, error) {
+	conditions := []string{"price_list_id = $1"}
+	args := []interface{}{priceListID}
+
+	if req.SKU != "" {
+		args = append(args, req.SKU)
+		conditions = append(conditions, fmt.Sprintf("sku = $%d", len(args)))
+	}
+
+	if req.Upcoming {
+		conditions = append(conditions, "starts_at > NOW()")
+	}
+
+	prices := []*models.Price{}
+	query := `
+		SELECT ` + priceColumns + `
+		FROM prices
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY sku, starts_at DESC
+		LIMIT 1000`
+
+	err := r.db.SelectContext(ctx, &prices, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to list prices", "error", err, "price_list_id", priceListID)
+		return nil, fmt.Errorf("failed to list prices: %w", err)
+	}
+
+	return prices, nil
+}
+
+// DeletePrice removes a price
+func (r *pricingRepository) DeletePrice(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM prices WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("Failed to delete price", "error", err, "id", id)
+		return fmt.Errorf("failed to delete price: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete price: %w", err)
+	}
+	if rows == 0 {
+		return apperrors.NotFound("price not found")
+	}
+
+	return nil
+}
+
+// CandidatePrices returns the prices in effect at a time in the active lists
+// of a currency that apply to everyone or to the customer group
+func (r *pricingRepository) CandidatePrices(ctx context.Context, currency string, customerGroup *string, skus []string, at time.Time) ([]*models.CandidatePrice, error) {
+	prices := []*models.CandidatePrice{}
+	query := `
+		SELECT p.id, p.price_list_id, p.sku, p.kind, p.amount, p.starts_at, p.ends_at,
+		       p.created_by, p.created_at, l.customer_group, l.priority
+		FROM prices p
+		JOIN price_lists l ON l.id = p.price_list_id
+		WHERE l.is_active AND l.currency = $1
+		  AND (l.customer_group IS NULL OR l.customer_group = $2)
+		  AND p.sku = ANY($3)
+		  AND p.starts_at <= $4
+		  AND (p.ends_at IS NULL OR p.ends_at > $4)
+		ORDER BY p.sku, p.starts_at DESC`
+
+	err := r.db.SelectContext(ctx, &prices, query, currency, customerGroup, skus, at)
+	if err != nil {
+		r.logger.Error("Failed to load candidate prices", "error", err, "currency", currency)
+		return nil, fmt.Errorf("failed to load prices: %w", err)
+	}
+
+	return prices, nil
+}
+
+// GetCustomerGroup retrieves the customer group of a customer
+func (r *pricingRepository) GetCustomerGroup(ctx context.Context, userID uuid.UUID) (*models.CustomerGroup, error) {
+	group := &models.CustomerGroup{}
+	query := `
+		SELECT user_id, customer_group, created_at, updated_at
+		FROM customer_groups
+		WHERE user_id = $1`
+
+	err := r.db.GetContext(ctx, group, query, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("customer group not found")
+		}
+		r.logger.Error("Failed to get customer group", "error", err, "user_id", userID)
+		return nil, fmt.Errorf("failed to get customer group: %w", err)
+	}
+
+	return group, nil
+}
+
+// UpsertCustomerGroup assigns a customer to a customer group
+func (r *pricingRepository) UpsertCustomerGroup(ctx context.Context, group *models.CustomerGroup) error {
+	query := `
+		INSERT INTO customer_groups (user_id, customer_group)
+		VALUES (:user_id, :customer_group)
+		ON CONFLICT (user_id) DO UPDATE SET customer_group = EXCLUDED.customer_group
+		RETURNING created_at, updated_at`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to prepare upsert customer group statement", "error", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, group).Scan(&group.CreatedAt, &group.UpdatedAt)
+	if err != nil {
+		if database.IsForeignKeyViolation(err) {
+			return apperrors.NotFound("user not found")
+		}
+		r.logger.Error("Failed to upsert customer group", "error", err, "user_id", group.UserID)
+		return fmt.Errorf("failed to save customer group: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteCustomerGroup removes a customer from their customer group
+func (r *pricingRepository) DeleteCustomerGroup(ctx context.Context, userID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM customer_groups WHERE user_id = $1`, userID)
+	if err != nil {
+		r.logger.Error("Failed to delete customer group", "error", err, "user_id", userID)
+		return fmt.Errorf("failed to delete customer group: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete customer group: %w", err)
+	}
+	if rows == 0 {
+		return apperrors.NotFound("customer group not found")
+	}
+
+	return nil
+}
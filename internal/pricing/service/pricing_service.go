@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/pricing/models"
+	"github.com/kaanevranportfolio/Commercium/internal/pricing/repository"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/money"
+)
+
+// PricingService defines the interface for pricing business logic
+type PricingService interface {
+	// Price lists (admin)
+	CreatePriceList(ctx context.Context, req *models.CreatePriceListRequest) (*models.PriceList, error)
+	GetPriceList(ctx context.Context, id uuid.UUID) (*models.PriceList, error)
+	ListPriceLists(ctx context.Context) ([]*models.PriceList, error)
+	UpdatePriceList(ctx context.Context, id uuid.UUID, req *models.UpdatePriceListRequest) (*models.PriceList, error)
+
+	// Prices (admin)
+	BulkUpdatePrices(ctx context.Context, adminID uuid.UUID, priceListID uuid.UUID, req *models.BulkPricesRequest) (*models.BulkPricesResponse, error)
+	ListPrices(ctx context.Context, priceListID uuid.UUID, req *models.ListPricesRequest) ([]*models.Price, error)
+	DeletePrice(ctx context.Context, priceListID uuid.UUID, priceID uuid.UUID) error
+
+	// Customer groups (admin)
+	GetCustomerGroup(ctx context.Context, userID uuid.UUID) (*models.CustomerGroup, error)
+	SetCustomerGroup(ctx context.Context, userID uuid.UUID, req *models.SetCustomerGroupRequest) (*models.CustomerGroup, error)
+	DeleteCustomerGroup(ctx context.Context, userID uuid.UUID) error
+
+	// ResolvePrices returns the prices a customer pays, for cart and checkout
+	ResolvePrices(ctx context.Context, req *models.ResolvePricesRequest) (*models.ResolvePricesResponse, error)
+}
+
+// pricingService implements the PricingService interface
+type pricingService struct {
+	repo   repository.PricingRepository
+	config *config.Config
+	logger *logger.Logger
+}
+
+// NewPricingService creates a new pricing service
+func NewPricingService(repo repository.PricingRepository, config *config.Config, logger *logger.Logger) PricingService {
+	return &pricingService{
+		repo:   repo,
+		config: config,
+		logger: logger,
+	}
+}
+
+// CreatePriceList creates a new price list
+func (s *pricingService) CreatePriceList(ctx context.Context, req *models.CreatePriceListRequest) (*models.PriceList, error) {
+	currency := strings.ToUpper(req.Currency)
+	if !money.IsCurrencyCode(currency) {
+		return nil, apperrors.Validation("invalid currency: %s", req.Currency)
+	}
+
+	list := &models.PriceList{
+		ID:            uuid.New(),
+		Name:          strings.TrimSpace(req.Name),
+		Currency:      currency,
+		CustomerGroup: normalizeGroup(req.CustomerGroup),
+		Priority:      req.Priority,
+		IsActive:      true,
+	}
+
+	if err := s.repo.CreatePriceList(ctx, list); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Price list created", "price_list_id", list.ID, "name", list.Name, "currency", list.Currency)
+	return list, nil
+}
+
+// GetPriceList returns a price list
+func (s *pricingService) GetPriceList(ctx context.Context, id uuid.UUID) (*models.PriceList, error) {
+	return s.repo.GetPriceList(ctx, id)
+}
+
+// ListPriceLists returns every price list
+func (s *pricingService) ListPriceLists(ctx context.Context) ([]*models.PriceList, error) {
+	return s.repo.ListPriceLists(ctx)
+}
+
+// UpdatePriceList renames, reprioritises, activates or deactivates a price list
+func (s *pricingService) UpdatePriceList(ctx context.Context, id uuid.UUID, req *models.UpdatePriceListRequest) (*models.PriceList, error) {
+	list, err := s.repo.GetPriceList(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		list.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Priority != nil {
+		list.Priority = *req.Priority
+	}
+	if req.IsActive != nil {
+		list.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.UpdatePriceList(ctx, list); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Price list updated", "price_list_id", list.ID, "is_active", list.IsActive)
+	return list, nil
+}
+
+// BulkUpdatePrices adds prices to a price list. Every entry is validated
+// before any is stored, and the batch is stored in one transaction.
+func (s *pricingService) BulkUpdatePrices(ctx context.Context, adminID uuid.UUID, priceListID uuid.UUID, req *models.BulkPricesRequest) (*models.BulkPricesResponse, error) {
+	if limit := s.config.Services.Pricing.MaxBulkPrices; len(req.Prices) > limit {
+		return nil, apperrors.Validation("invalid bulk update: at most %d prices per request", limit)
+	}
+
+	if _, err := s.repo.GetPriceList(ctx, priceListID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	prices := make([]*models.Price, 0, len(req.Prices))
+	for _, entry := range req.Prices {
+		price, err := newPrice(entry, priceListID, now)
+		if err != nil {
+			return nil, err
+		}
+		price.CreatedBy = &adminID
+		prices = append(prices, price)
+	}
+
+	if err := s.repo.CreatePrices(ctx, prices); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Prices updated", "price_list_id", priceListID, "prices", len(prices), "admin_id", adminID)
+	return &models.BulkPricesResponse{
+		Created: len(prices),
+		Prices:  prices,
+	}, nil
+}
+
+// ListPrices returns the prices of a price list
+func (s *pricingService) ListPrices(ctx context.Context, priceListID uuid.UUID, req *models.ListPricesRequest) ([]*models.Price, error) {
+	if _, err := s.repo.GetPriceList(ctx, priceListID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPrices(ctx, priceListID, req)
+}
+
+// DeletePrice cancels a scheduled price change or ends a sale early. Regular
+// prices in effect are kept as history; add a new price to change them.
+func (s *pricingService) DeletePrice(ctx context.Context, priceListID uuid.UUID, priceID uuid.UUID) error {
+	price, err := s.repo.GetPrice(ctx, priceListID, priceID)
+	if err != nil {
+		return err
+	}
+
+	if price.Kind == models.PriceKindRegular && !price.StartsAt.After(time.Now()) {
+		return apperrors.Conflict("price is in effect and cannot be deleted, add a new price instead")
+	}
+
+	if err := s.repo.DeletePrice(ctx, priceID); err != nil {
+		return err
+	}
+
+	s.logger.Info("Price deleted", "price_id", priceID, "price_list_id", priceListID, "sku", price.SKU)
+	return nil
+}
+
+// GetCustomerGroup returns a customer's customer group
+func (s *pricingService) GetCustomerGroup(ctx context.Context, userID uuid.UUID) (*models.CustomerGroup, error) {
+	return s.repo.GetCustomerGroup(ctx, userID)
+}
+
+// SetCustomerGroup assigns a customer to a customer group
+func (s *pricingService) SetCustomerGroup(ctx context.Context, userID uuid.UUID, req *models.SetCustomerGroupRequest) (*models.CustomerGroup, error) {
+	name := normalizeGroup(&req.CustomerGroup)
+	if name == nil {
+		return nil, apperrors.Validation("invalid customer group: must not be empty")
+	}
+
+	group := &models.CustomerGroup{
+		UserID:        userID,
+		CustomerGroup: *name,
+	}
+	if err := s.repo.UpsertCustomerGroup(ctx, group); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Customer group assigned", "user_id", userID, "customer_group", group.CustomerGroup)
+	return group, nil
+}
+
+// DeleteCustomerGroup removes a customer from their customer group
+func (s *pricingService) DeleteCustomerGroup(ctx context.Context, userID uuid.UUID) error {
+	return s.repo.DeleteCustomerGroup(ctx, userID)
+}
+
+// ResolvePrices returns the price a customer pays for each SKU.
+//
+// The list price comes from the applicable list with the highest
+// precedence: lists of the customer's group before lists for everyone, then
+// higher priority, then the latest regular price. A sale price in any
+// applicable list lowers the unit price below the list price.
+func (s *pricingService) ResolvePrices(ctx context.Context, req *models.ResolvePricesRequest) (*models.ResolvePricesResponse, error) {
+	currency := strings.ToUpper(req.Currency)
+	at := time.Now()
+	if req.At != nil {
+		at = *req.At
+	}
+
+	var customerGroup *string
+	if req.UserID != uuid.Nil {
+		group, err := s.repo.GetCustomerGroup(ctx, req.UserID)
+		if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+			return nil, err
+		}
+		if group != nil {
+			customerGroup = &group.CustomerGroup
+		}
+	}
+
+	candidates, err := s.repo.CandidatePrices(ctx, currency, customerGroup, req.SKUs, at)
+	if err != nil {
+		return nil, err
+	}
+
+	regular := make(map[string]*models.CandidatePrice)
+	sale := make(map[string]*models.CandidatePrice)
+	for _, candidate := range candidates {
+		switch candidate.Kind {
+		case models.PriceKindRegular:
+			if current, ok := regular[candidate.SKU]; !ok || takesPrecedence(candidate, current) {
+				regular[candidate.SKU] = candidate
+			}
+		case models.PriceKindSale:
+			if current, ok := sale[candidate.SKU]; !ok || candidate.Amount < current.Amount {
+				sale[candidate.SKU] = candidate
+			}
+		}
+	}
+
+	response := &models.ResolvePricesResponse{
+		Currency: currency,
+		Prices:   make([]*models.ResolvedPrice, 0, len(req.SKUs)),
+	}
+	for _, sku := range req.SKUs {
+		list, ok := regular[sku]
+		if !ok {
+			response.Missing = append(response.Missing, sku)
+			continue
+		}
+
+		resolved := &models.ResolvedPrice{
+			SKU:         sku,
+			UnitPrice:   list.Amount,
+			ListPrice:   list.Amount,
+			PriceListID: list.PriceListID,
+		}
+		if discounted, ok := sale[sku]; ok && discounted.Amount < list.Amount {
+			resolved.UnitPrice = discounted.Amount
+			resolved.OnSale = true
+			resolved.SaleEndsAt = discounted.EndsAt
+			resolved.PriceListID = discounted.PriceListID
+		}
+		response.Prices = append(response.Prices, resolved)
+	}
+
+	return response, nil
+}
+
+// newPrice validates a price entry of a bulk update
+func newPrice(entry models.PriceEntry, priceListID uuid.UUID, now time.Time) (*models.Price, error) {
+	price := &models.Price{
+		ID:          uuid.New(),
+		PriceListID: priceListID,
+		SKU:         strings.TrimSpace(entry.SKU),
+		Kind:        entry.Kind,
+		Amount:      entry.Amount,
+		StartsAt:    now,
+		EndsAt:      entry.EndsAt,
+	}
+	if price.Kind == "" {
+		price.Kind = models.PriceKindRegular
+	}
+	if !price.Kind.IsValid() {
+		return nil, apperrors.Validation("invalid price kind for SKU %s: %s", entry.SKU, entry.Kind)
+	}
+	if price.SKU == "" {
+		return nil, apperrors.Validation("invalid price: SKU must not be empty")
+	}
+
+	if entry.StartsAt != nil {
+		if entry.StartsAt.Before(now) {
+			return nil, apperrors.Validation("invalid price for SKU %s: start is in the past", price.SKU)
+		}
+		price.StartsAt = *entry.StartsAt
+	}
+
+	switch price.Kind {
+	case models.PriceKindRegular:
+		if price.EndsAt != nil {
+			return nil, apperrors.Validation("invalid price for SKU %s: regular prices have no end, schedule a new price instead", price.SKU)
+		}
+	case models.PriceKindSale:
+		if price.EndsAt == nil || !price.EndsAt.After(price.StartsAt) {
+			return nil, apperrors.Validation("invalid price for SKU %s: sale prices must end after they start", price.SKU)
+		}
+	}
+
+	return price, nil
+}
+
+// takesPrecedence reports whether regular price a overrides regular price b
+func takesPrecedence(a, b *models.CandidatePrice) bool {
+	if (a.CustomerGroup != nil) != (b.CustomerGroup != nil) {
+		return a.CustomerGroup != nil
+	}
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return a.StartsAt.After(b.StartsAt)
+}
+
+// normalizeGroup trims and lowercases a customer group name. Empty names
+// mean no group.
+func normalizeGroup(group *string) *string {
+	if group == nil {
+		return nil
+	}
+	name := strings.ToLower(strings.TrimSpace(*group))
+	if name == "" {
+		return nil
+	}
+	return &name
+}
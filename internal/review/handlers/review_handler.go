@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/review/models"
+	"github.com/kaanevranportfolio/Commercium/internal/review/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/validation"
+)
+
+// ReviewHandler handles HTTP requests for review operations
+type ReviewHandler struct {
+	reviewService service.ReviewService
+	jwtService    *auth.JWTService
+	logger        *logger.Logger
+}
+
+// NewReviewHandler creates a new review handler
+func NewReviewHandler(reviewService service.ReviewService, jwtService *auth.JWTService, logger *logger.Logger) *ReviewHandler {
+	return &ReviewHandler{
+		reviewService: reviewService,
+		jwtService:    jwtService,
+		logger:        logger,
+	}
+}
+
+// ListProductReviews returns a page of a product's approved reviews
+func (h *ReviewHandler) ListProductReviews(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req models.ListReviewsRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	response, err := h.reviewService.ListProductReviews(c.Request.Context(), productID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to list reviews")
+		return
+	}
+
+	httpx.List(c, response)
+}
+
+// GetProductRating returns the rating summary of a product
+func (h *ReviewHandler) GetProductRating(c *gin.Context) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	rating, err := h.reviewService.GetProductRating(c.Request.Context(), productID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get product rating")
+		return
+	}
+
+	c.JSON(http.StatusOK, rating)
+}
+
+// SubmitReview stores the current user's review of a product
+func (h *ReviewHandler) SubmitReview(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req models.SubmitReviewRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	review, err := h.reviewService.SubmitReview(c.Request.Context(), userID, productID, &req)
+	if err != nil {
+		h.logger.Error("Failed to submit review", "error", err, "user_id", userID, "product_id", productID)
+		h.respondError(c, err, "Failed to submit review")
+		return
+	}
+
+	c.JSON(http.StatusCreated, review)
+}
+
+// UpdateReview replaces the content of one of the current user's reviews
+func (h *ReviewHandler) UpdateReview(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	reviewID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	var req models.SubmitReviewRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	review, err := h.reviewService.UpdateReview(c.Request.Context(), userID, reviewID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to update review")
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// DeleteReview deletes one of the current user's reviews
+func (h *ReviewHandler) DeleteReview(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	reviewID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	if err := h.reviewService.DeleteReview(c.Request.Context(), userID, reviewID); err != nil {
+		h.respondError(c, err, "Failed to delete review")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Vote records whether the current user found a review helpful
+func (h *ReviewHandler) Vote(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	reviewID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	var req models.VoteRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	review, err := h.reviewService.Vote(c.Request.Context(), userID, reviewID, *req.Helpful)
+	if err != nil {
+		h.respondError(c, err, "Failed to record vote")
+		return
+	}
+
+	c.JSON(http.StatusOK, review.ToPublic())
+}
+
+// RemoveVote withdraws the current user's vote on a review
+func (h *ReviewHandler) RemoveVote(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	reviewID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	review, err := h.reviewService.RemoveVote(c.Request.Context(), userID, reviewID)
+	if err != nil {
+		h.respondError(c, err, "Failed to remove vote")
+		return
+	}
+
+	c.JSON(http.StatusOK, review.ToPublic())
+}
+
+// ListForModeration returns the moderation queue (admin)
+func (h *ReviewHandler) ListForModeration(c *gin.Context) {
+	var req models.ListModerationRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	reviews, err := h.reviewService.ListForModeration(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to list reviews")
+		return
+	}
+
+	httpx.Items(c, reviews)
+}
+
+// Moderate approves or rejects a review (admin)
+func (h *ReviewHandler) Moderate(c *gin.Context) {
+	moderatorID := auth.UserIDFromContext(c)
+
+	reviewID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	var req models.ModerationRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	review, err := h.reviewService.Moderate(c.Request.Context(), moderatorID, reviewID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to moderate review")
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// respondError logs a request failing on the side of the service, or of a
+// provider it depends on, and answers it with the problem details of err,
+// so middleware reading its status after it, such as idempotency's, sees
+// the failure
+func (h *ReviewHandler) respondError(c *gin.Context, err error, message string) {
+	if apperrors.HTTPStatus(err) >= http.StatusInternalServerError {
+		logger.FromContext(c.Request.Context()).Error(message, "error", err)
+	}
+	apperrors.Respond(c, err)
+}
+
+// SetupRoutes sets up the review routes
+func (h *ReviewHandler) SetupRoutes(r *gin.Engine) {
+	products := r.Group("/api/v1/products")
+	{
+		products.GET("/:id/reviews", h.ListProductReviews)
+		products.GET("/:id/rating", h.GetProductRating)
+		products.POST("/:id/reviews", h.jwtService.Middleware(), h.SubmitReview)
+	}
+
+	reviews := r.Group("/api/v1/reviews")
+	reviews.Use(h.jwtService.Middleware())
+	{
+		reviews.PUT("/:id", h.UpdateReview)
+		reviews.DELETE("/:id", h.DeleteReview)
+		reviews.POST("/:id/votes", h.Vote)
+		reviews.DELETE("/:id/votes", h.RemoveVote)
+	}
+
+	admin := r.Group("/api/v1/admin/reviews")
+	admin.Use(h.jwtService.Middleware(), auth.RequireRole("admin"))
+	{
+		admin.GET("", h.ListForModeration)
+		admin.POST("/:id/moderation", h.Moderate)
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Review event types published to the review events topic
+const (
+	EventReviewSubmitted = "review.submitted"
+	EventReviewApproved  = "review.approved"
+	EventReviewRejected  = "review.rejected"
+)
+
+// ReviewEventSchemaVersion is the schema version of ReviewEvent payloads
+const ReviewEventSchemaVersion = 1
+
+// ReviewEvent is published when a review is submitted or moderated
+type ReviewEvent struct {
+	Type             string    `json:"type"`
+	ReviewID         uuid.UUID `json:"review_id"`
+	ProductID        uuid.UUID `json:"product_id"`
+	UserID           uuid.UUID `json:"user_id"`
+	Rating           int       `json:"rating"`
+	VerifiedPurchase bool      `json:"verified_purchase"`
+	Status           string    `json:"status"`
+	OccurredAt       time.Time `json:"occurred_at"`
+}
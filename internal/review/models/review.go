@@ -0,0 +1,177 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+)
+
+// ReviewStatus represents the moderation state of a review
+type ReviewStatus string
+
+const (
+	ReviewStatusPending  ReviewStatus = "pending"
+	ReviewStatusApproved ReviewStatus = "approved"
+	ReviewStatusRejected ReviewStatus = "rejected"
+)
+
+// IsValid reports whether the status is a known review status
+func (s ReviewStatus) IsValid() bool {
+	switch s {
+	case ReviewStatusPending, ReviewStatusApproved, ReviewStatusRejected:
+		return true
+	}
+	return false
+}
+
+// ReviewSort is the order in which a product's reviews are listed
+type ReviewSort string
+
+const (
+	ReviewSortNewest      ReviewSort = "newest"
+	ReviewSortOldest      ReviewSort = "oldest"
+	ReviewSortHighest     ReviewSort = "highest_rated"
+	ReviewSortLowest      ReviewSort = "lowest_rated"
+	ReviewSortMostHelpful ReviewSort = "most_helpful"
+)
+
+// IsValid reports whether the sort order is supported
+func (s ReviewSort) IsValid() bool {
+	switch s {
+	case ReviewSortNewest, ReviewSortOldest, ReviewSortHighest, ReviewSortLowest, ReviewSortMostHelpful:
+		return true
+	}
+	return false
+}
+
+// Review represents a customer's review of a product
+type Review struct {
+	ID               uuid.UUID    `json:"id" db:"id"`
+	ProductID        uuid.UUID    `json:"product_id" db:"product_id"`
+	UserID           uuid.UUID    `json:"user_id" db:"user_id"`
+	OrderID          *uuid.UUID   `json:"-" db:"order_id"`
+	Rating           int          `json:"rating" db:"rating"`
+	Title            string       `json:"title" db:"title"`
+	Body             string       `json:"body" db:"body"`
+	VerifiedPurchase bool         `json:"verified_purchase" db:"verified_purchase"`
+	Status           ReviewStatus `json:"status" db:"status"`
+	ModeratedBy      *uuid.UUID   `json:"moderated_by,omitempty" db:"moderated_by"`
+	ModerationNotes  *string      `json:"moderation_notes,omitempty" db:"moderation_notes"`
+	ModeratedAt      *time.Time   `json:"moderated_at,omitempty" db:"moderated_at"`
+	HelpfulCount     int          `json:"helpful_count" db:"helpful_count"`
+	UnhelpfulCount   int          `json:"unhelpful_count" db:"unhelpful_count"`
+	CreatedAt        time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at" db:"updated_at"`
+
+	// Author is the reviewer's display name
+	Author string `json:"author" db:"author"`
+}
+
+// PublicReview is the view of an approved review shown on product pages
+type PublicReview struct {
+	ID               uuid.UUID `json:"id"`
+	Rating           int       `json:"rating"`
+	Title            string    `json:"title"`
+	Body             string    `json:"body"`
+	Author           string    `json:"author"`
+	VerifiedPurchase bool      `json:"verified_purchase"`
+	HelpfulCount     int       `json:"helpful_count"`
+	UnhelpfulCount   int       `json:"unhelpful_count"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ToPublic converts a Review to PublicReview
+func (r *Review) ToPublic() *PublicReview {
+	return &PublicReview{
+		ID:               r.ID,
+		Rating:           r.Rating,
+		Title:            r.Title,
+		Body:             r.Body,
+		Author:           r.Author,
+		VerifiedPurchase: r.VerifiedPurchase,
+		HelpfulCount:     r.HelpfulCount,
+		UnhelpfulCount:   r.UnhelpfulCount,
+		CreatedAt:        r.CreatedAt,
+	}
+}
+
+// ProductRating aggregates the approved reviews of a product
+type ProductRating struct {
+	ProductID   uuid.UUID `json:"product_id" db:"product_id"`
+	ReviewCount int       `json:"review_count" db:"review_count"`
+	RatingTotal int       `json:"-" db:"rating_total"`
+	Rating1     int       `json:"-" db:"rating_1"`
+	Rating2     int       `json:"-" db:"rating_2"`
+	Rating3     int       `json:"-" db:"rating_3"`
+	Rating4     int       `json:"-" db:"rating_4"`
+	Rating5     int       `json:"-" db:"rating_5"`
+
+	AverageRating float64     `json:"average_rating" db:"-"`
+	Distribution  map[int]int `json:"distribution" db:"-"`
+}
+
+// Summarize fills in the average rating, rounded to two decimals, and the
+// number of reviews per star rating
+func (p *ProductRating) Summarize() {
+	if p.ReviewCount > 0 {
+		p.AverageRating = math.Round(float64(p.RatingTotal)/float64(p.ReviewCount)*100) / 100
+	}
+	p.Distribution = map[int]int{1: p.Rating1, 2: p.Rating2, 3: p.Rating3, 4: p.Rating4, 5: p.Rating5}
+}
+
+// ReviewFilter holds the criteria used to list a product's reviews
+type ReviewFilter struct {
+	Sort         ReviewSort
+	Rating       int
+	VerifiedOnly bool
+	Cursor       *ReviewCursor
+	Limit        int
+}
+
+// ReviewCursor identifies the position after which the next page starts.
+// Value is the rating or helpful count the page is sorted by.
+type ReviewCursor struct {
+	Sort      ReviewSort `json:"s"`
+	Value     int        `json:"v"`
+	CreatedAt time.Time  `json:"c"`
+	ID        uuid.UUID  `json:"i"`
+}
+
+// ListReviewsRequest represents the query parameters of the product reviews endpoint
+type ListReviewsRequest struct {
+	Sort     string `form:"sort"`
+	Rating   int    `form:"rating" binding:"omitempty,min=1,max=5"`
+	Verified bool   `form:"verified"`
+	Cursor   string `form:"cursor"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// ReviewListResponse represents a page of a product's reviews
+type ReviewListResponse = httpx.ListResponse[*PublicReview]
+
+// SubmitReviewRequest represents a request to review a product
+type SubmitReviewRequest struct {
+	Rating int    `json:"rating" binding:"required,min=1,max=5"`
+	Title  string `json:"title" binding:"required,max=200"`
+	Body   string `json:"body" binding:"required,max=5000"`
+}
+
+// VoteRequest represents a helpfulness vote on a review
+type VoteRequest struct {
+	Helpful *bool `json:"helpful" binding:"required"`
+}
+
+// ListModerationRequest represents the query parameters of the moderation queue
+type ListModerationRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending approved rejected"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// ModerationRequest represents a moderator's decision on a review
+type ModerationRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=approve reject"`
+	Notes    string `json:"notes,omitempty" binding:"max=1000"`
+}
@@ -0,0 +1,444 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kaanevranportfolio/Commercium/internal/review/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// ReviewRepository defines the interface for review data operations
+type ReviewRepository interface {
+	FindPurchaseOrder(ctx context.Context, userID, productID uuid.UUID) (*uuid.UUID, error)
+
+	// Review operations
+	CreateReview(ctx context.Context, review *models.Review) error
+	GetReview(ctx context.Context, id uuid.UUID) (*models.Review, error)
+	UpdateReview(ctx context.Context, review *models.Review) error
+	DeleteReview(ctx context.Context, id uuid.UUID) error
+	ModerateReview(ctx context.Context, review *models.Review) error
+	ListProductReviews(ctx context.Context, productID uuid.UUID, filter *models.ReviewFilter) ([]*models.Review, error)
+	ListReviewsByStatus(ctx context.Context, status models.ReviewStatus, limit int) ([]*models.Review, error)
+
+	// Rating operations
+	GetProductRating(ctx context.Context, productID uuid.UUID) (*models.ProductRating, error)
+
+	// Vote operations
+	SetVote(ctx context.Context, reviewID, userID uuid.UUID, helpful bool) (*models.Review, error)
+	DeleteVote(ctx context.Context, reviewID, userID uuid.UUID) (*models.Review, error)
+}
+
+// reviewRepository implements the ReviewRepository interface
+type reviewRepository struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewReviewRepository creates a new review repository
+func NewReviewRepository(db *database.DB, logger *logger.Logger) ReviewRepository {
+	return &reviewRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const reviewColumns = `r.id, r.product_id, r.user_id, r.order_id, r.rating, r.title, r.body, r.verified_purchase,
+		       r.status, r.moderated_by, r.moderation_notes, r.moderated_at, r.helpful_count,
+		       r.unhelpful_count, r.created_at, r.updated_at, u.username AS author`
+
+// reviewState is the part of a stored review that the rating aggregate depends on
+type reviewState struct {
+	ProductID uuid.UUID           `db:"product_id"`
+	Rating    int                 `db:"rating"`
+	Status    models.ReviewStatus `db:"status"`
+}
+
+// FindPurchaseOrder returns the most recent order in which the user bought the
+// product, or nil when there is none. Orders count once they are confirmed.
+func (r *reviewRepository) FindPurchaseOrder(ctx context.Context, userID, productID uuid.UUID) (*uuid.UUID, error) {
+	var orderID uuid.UUID
+	query := `
+		SELECT o.id
+		FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		WHERE o.user_id = $1 AND oi.product_id = $2
+		  AND o.status IN ('confirmed', 'processing', 'shipped', 'delivered')
+		ORDER BY o.placed_at DESC
+		LIMIT 1`
+
+	err := r.db.GetContext(ctx, &orderID, query, userID, productID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to look up purchase", "error", err, "user_id", userID, "product_id", productID)
+		return nil, fmt.Errorf("failed to look up purchase: %w", err)
+	}
+
+	return &orderID, nil
+}
+
+// CreateReview stores a new review. Reviews approved on submission are added
+// to the product's rating in the same transaction.
+func (r *reviewRepository) CreateReview(ctx context.Context, review *models.Review) error {
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		query := `
+			INSERT INTO product_reviews (id, product_id, user_id, order_id, rating, title, body,
+			                             verified_purchase, status, moderated_at)
+			VALUES (:id, :product_id, :user_id, :order_id, :rating, :title, :body,
+			        :verified_purchase, :status, :moderated_at)
+			RETURNING created_at, updated_at`
+
+		stmt, err := tx.PrepareNamedContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer stmt.Close()
+
+		if err := stmt.QueryRowxContext(ctx, review).Scan(&review.CreatedAt, &review.UpdatedAt); err != nil {
+			if database.IsUniqueViolation(err) {
+				return apperrors.Conflict("review already exists for this product")
+			}
+			r.logger.Error("Failed to create review", "error", err, "product_id", review.ProductID)
+			return fmt.Errorf("failed to create review: %w", err)
+		}
+
+		if review.Status == models.ReviewStatusApproved {
+			return r.adjustRating(ctx, tx, review.ProductID, review.Rating, 1)
+		}
+		return nil
+	})
+}
+
+// GetReview retrieves a review by ID
+func (r *reviewRepository) GetReview(ctx context.Context, id uuid.UUID) (*models.Review, error) {
+	review := &models.Review{}
+	query := `
+		SELECT ` + reviewColumns + `
+		FROM product_reviews r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.id = $1`
+
+	err := r.db.GetContext(ctx, review, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("review not found")
+		}
+		r.logger.Error("Failed to get review", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+
+	return review, nil
+}
+
+// UpdateReview stores the author's changes to a review together with its new
+// status, keeping the product's rating in step
+func (r *reviewRepository) UpdateReview(ctx context.Context, review *models.Review) error {
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		previous, err := r.lockReview(ctx, tx, review.ID)
+		if err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE product_reviews
+			SET rating = $2, title = $3, body = $4, status = $5,
+			    moderated_by = NULL, moderation_notes = NULL, moderated_at = $6
+			WHERE id = $1
+			RETURNING updated_at`
+
+		err = tx.QueryRowxContext(ctx, query, review.ID, review.Rating, review.Title, review.Body,
+			review.Status, review.ModeratedAt).Scan(&review.UpdatedAt)
+		if err != nil {
+			r.logger.Error("Failed to update review", "error", err, "id", review.ID)
+			return fmt.Errorf("failed to update review: %w", err)
+		}
+
+		return r.applyRatingChange(ctx, tx, previous, review.Status, review.Rating)
+	})
+}
+
+// DeleteReview deletes a review and its votes, removing it from the product's rating
+func (r *reviewRepository) DeleteReview(ctx context.Context, id uuid.UUID) error {
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		previous, err := r.lockReview(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM product_reviews WHERE id = $1`, id); err != nil {
+			r.logger.Error("Failed to delete review", "error", err, "id", id)
+			return fmt.Errorf("failed to delete review: %w", err)
+		}
+
+		return r.applyRatingChange(ctx, tx, previous, "", 0)
+	})
+}
+
+// ModerateReview records a moderation decision, adding the review to or
+// removing it from the product's rating when its approval changes
+func (r *reviewRepository) ModerateReview(ctx context.Context, review *models.Review) error {
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		previous, err := r.lockReview(ctx, tx, review.ID)
+		if err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE product_reviews
+			SET status = $2, moderated_by = $3, moderation_notes = $4, moderated_at = $5
+			WHERE id = $1
+			RETURNING updated_at`
+
+		err = tx.QueryRowxContext(ctx, query, review.ID, review.Status, review.ModeratedBy,
+			review.ModerationNotes, review.ModeratedAt).Scan(&review.UpdatedAt)
+		if err != nil {
+			r.logger.Error("Failed to moderate review", "error", err, "id", review.ID)
+			return fmt.Errorf("failed to moderate review: %w", err)
+		}
+
+		return r.applyRatingChange(ctx, tx, previous, review.Status, previous.Rating)
+	})
+}
+
+// ListProductReviews retrieves a page of a product's approved reviews in the
+// requested order
+func (r *reviewRepository) ListProductReviews(ctx context.Context, productID uuid.UUID, filter *models.ReviewFilter) ([]*models.Review, error) {
+	conditions := []string{"r.product_id = $1", "r.status = 'approved'"}
+	args := []interface{}{productID}
+
+	if filter.Rating > 0 {
+		args = append(args, filter.Rating)
+		conditions = append(conditions, fmt.Sprintf("r.rating = $%d", len(args)))
+	}
+
+	if filter.VerifiedOnly {
+		conditions = append(conditions, "r.verified_purchase")
+	}
+
+	// Every order ends with (created_at, id) descending, except oldest first,
+	// so ties on rating or helpfulness page deterministically
+	var orderBy string
+	switch filter.Sort {
+	case models.ReviewSortOldest:
+		orderBy = "r.created_at, r.id"
+	case models.ReviewSortHighest:
+		orderBy = "r.rating DESC, r.created_at DESC, r.id DESC"
+	case models.ReviewSortLowest:
+		orderBy = "r.rating, r.created_at DESC, r.id DESC"
+	case models.ReviewSortMostHelpful:
+		orderBy = "r.helpful_count DESC, r.created_at DESC, r.id DESC"
+	default:
+		orderBy = "r.created_at DESC, r.id DESC"
+	}
+
+	if cursor := filter.Cursor; cursor != nil {
+		var condition string
+		switch filter.Sort {
+		case models.ReviewSortOldest:
+			condition, args = database.KeysetCondition(args, database.Ascending, []string{"r.created_at", "r.id"},
+				cursor.CreatedAt, cursor.ID)
+		case models.ReviewSortHighest, models.ReviewSortMostHelpful:
+			column := "r.rating"
+			if filter.Sort == models.ReviewSortMostHelpful {
+				column = "r.helpful_count"
+			}
+			condition, args = database.KeysetCondition(args, database.Descending, []string{column, "r.created_at", "r.id"},
+				cursor.Value, cursor.CreatedAt, cursor.ID)
+		case models.ReviewSortLowest:
+			// Rating ascends while ties descend, which a single row
+			// comparison can't express
+			args = append(args, cursor.Value, cursor.CreatedAt, cursor.ID)
+			condition = fmt.Sprintf("(r.rating > $%d OR (r.rating = $%d AND (r.created_at, r.id) < ($%d, $%d)))",
+				len(args)-2, len(args)-2, len(args)-1, len(args))
+		default:
+			condition, args = database.KeysetCondition(args, database.Descending, []string{"r.created_at", "r.id"},
+				cursor.CreatedAt, cursor.ID)
+		}
+		conditions = append(conditions, condition)
+	}
+
+	args = append(args, filter.Limit)
+	query := `
+		SELECT ` + reviewColumns + `
+		FROM product_reviews r
+		JOIN users u ON u.id = r.user_id
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY ` + orderBy + `
+		LIMIT $` + fmt.Sprint(len(args))
+
+	reviews := []*models.Review{}
+	err := r.db.SelectContext(ctx, &reviews, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to list product reviews", "error", err, "product_id", productID)
+		return nil, fmt.Errorf("failed to list product reviews: %w", err)
+	}
+
+	return reviews, nil
+}
+
+// ListReviewsByStatus retrieves reviews in a moderation state, oldest first
+func (r *reviewRepository) ListReviewsByStatus(ctx context.Context, status models.ReviewStatus, limit int) ([]*models.Review, error) {
+	reviews := []*models.Review{}
+	query := `
+		SELECT ` + reviewColumns + `
+		FROM product_reviews r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.status = $1
+		ORDER BY r.created_at, r.id
+		LIMIT $2`
+
+	err := r.db.SelectContext(ctx, &reviews, query, status, limit)
+	if err != nil {
+		r.logger.Error("Failed to list reviews", "error", err, "status", status)
+		return nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+
+	return reviews, nil
+}
+
+// GetProductRating retrieves the rating aggregate of a product. Products
+// without approved reviews get an empty aggregate.
+func (r *reviewRepository) GetProductRating(ctx context.Context, productID uuid.UUID) (*models.ProductRating, error) {
+	rating := &models.ProductRating{}
+	query := `
+		SELECT product_id, review_count, rating_total, rating_1, rating_2, rating_3, rating_4, rating_5
+		FROM product_ratings
+		WHERE product_id = $1`
+
+	err := r.db.GetContext(ctx, rating, query, productID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &models.ProductRating{ProductID: productID}, nil
+		}
+		r.logger.Error("Failed to get product rating", "error", err, "product_id", productID)
+		return nil, fmt.Errorf("failed to get product rating: %w", err)
+	}
+
+	return rating, nil
+}
+
+// SetVote records or changes a customer's helpfulness vote and returns the
+// review with its recounted votes
+func (r *reviewRepository) SetVote(ctx context.Context, reviewID, userID uuid.UUID, helpful bool) (*models.Review, error) {
+	err := r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		query := `
+			INSERT INTO review_votes (review_id, user_id, helpful)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (review_id, user_id) DO UPDATE SET helpful = EXCLUDED.helpful`
+
+		if _, err := tx.ExecContext(ctx, query, reviewID, userID, helpful); err != nil {
+			r.logger.Error("Failed to record review vote", "error", err, "review_id", reviewID)
+			return fmt.Errorf("failed to record review vote: %w", err)
+		}
+
+		return r.recountVotes(ctx, tx, reviewID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetReview(ctx, reviewID)
+}
+
+// DeleteVote withdraws a customer's helpfulness vote and returns the review
+// with its recounted votes
+func (r *reviewRepository) DeleteVote(ctx context.Context, reviewID, userID uuid.UUID) (*models.Review, error) {
+	err := r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, `DELETE FROM review_votes WHERE review_id = $1 AND user_id = $2`, reviewID, userID)
+		if err != nil {
+			r.logger.Error("Failed to delete review vote", "error", err, "review_id", reviewID)
+			return fmt.Errorf("failed to delete review vote: %w", err)
+		}
+
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return apperrors.NotFound("vote not found")
+		}
+
+		return r.recountVotes(ctx, tx, reviewID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetReview(ctx, reviewID)
+}
+
+// lockReview locks a review for the rest of the transaction and returns the
+// state its contribution to the product's rating was based on
+func (r *reviewRepository) lockReview(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (*reviewState, error) {
+	state := &reviewState{}
+	query := `SELECT product_id, rating, status FROM product_reviews WHERE id = $1 FOR UPDATE`
+
+	if err := tx.GetContext(ctx, state, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("review not found")
+		}
+		r.logger.Error("Failed to lock review", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to lock review: %w", err)
+	}
+
+	return state, nil
+}
+
+// applyRatingChange moves a review's contribution to the product's rating from
+// its previous state to the new one. An empty status means the review is gone.
+func (r *reviewRepository) applyRatingChange(ctx context.Context, tx *sqlx.Tx, previous *reviewState, status models.ReviewStatus, rating int) error {
+	if previous.Status == models.ReviewStatusApproved {
+		if err := r.adjustRating(ctx, tx, previous.ProductID, previous.Rating, -1); err != nil {
+			return err
+		}
+	}
+	if status == models.ReviewStatusApproved {
+		return r.adjustRating(ctx, tx, previous.ProductID, rating, 1)
+	}
+	return nil
+}
+
+// adjustRating adds (delta 1) or removes (delta -1) one rating from a product's aggregate
+func (r *reviewRepository) adjustRating(ctx context.Context, tx *sqlx.Tx, productID uuid.UUID, rating, delta int) error {
+	if rating < 1 || rating > 5 {
+		return fmt.Errorf("invalid rating: %d", rating)
+	}
+
+	// The star column is picked from a validated rating, never from input
+	column := fmt.Sprintf("rating_%d", rating)
+	query := `
+		INSERT INTO product_ratings (product_id, review_count, rating_total, ` + column + `)
+		VALUES ($1, $2, $3, $2)
+		ON CONFLICT (product_id) DO UPDATE
+		SET review_count = product_ratings.review_count + EXCLUDED.review_count,
+		    rating_total = product_ratings.rating_total + EXCLUDED.rating_total,
+		    ` + column + ` = product_ratings.` + column + ` + EXCLUDED.` + column
+
+	if _, err := tx.ExecContext(ctx, query, productID, delta, delta*rating); err != nil {
+		r.logger.Error("Failed to update product rating", "error", err, "product_id", productID)
+		return fmt.Errorf("failed to update product rating: %w", err)
+	}
+
+	return nil
+}
+
+// recountVotes recomputes a review's vote counts from its votes
+func (r *reviewRepository) recountVotes(ctx context.Context, tx *sqlx.Tx, reviewID uuid.UUID) error {
+	query := `
+		UPDATE product_reviews
+		SET helpful_count = (SELECT COUNT(*) FROM review_votes WHERE review_id = $1 AND helpful),
+		    unhelpful_count = (SELECT COUNT(*) FROM review_votes WHERE review_id = $1 AND NOT helpful)
+		WHERE id = $1`
+
+	if _, err := tx.ExecContext(ctx, query, reviewID); err != nil {
+		r.logger.Error("Failed to count review votes", "error", err, "review_id", reviewID)
+		return fmt.Errorf("failed to count review votes: %w", err)
+	}
+
+	return nil
+}
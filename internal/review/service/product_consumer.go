@@ -0,0 +1,23 @@
+package service
+
+import (
+	"github.com/kaanevranportfolio/Commercium/pkg/cache"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+	"github.com/kaanevranportfolio/Commercium/pkg/kafka"
+)
+
+// ProductEventHandler returns a Kafka handler dropping the cached rating of
+// the product each product event is about, the subject of its envelope, so
+// ratings of products the catalog changed are summarized again
+func ProductEventHandler(ratings *cache.Cache) kafka.Handler {
+	return ratings.InvalidationHandler(func(value []byte) ([]string, error) {
+		envelope, err := events.Parse(value)
+		if err != nil {
+			return nil, kafka.Permanent(err)
+		}
+		if envelope.Subject == "" {
+			return nil, nil
+		}
+		return []string{envelope.Subject}, nil
+	})
+}
@@ -0,0 +1,438 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/review/models"
+	"github.com/kaanevranportfolio/Commercium/internal/review/repository"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/cache"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// ReviewService defines the interface for review business logic
+type ReviewService interface {
+	// Public operations
+	ListProductReviews(ctx context.Context, productID uuid.UUID, req *models.ListReviewsRequest) (*models.ReviewListResponse, error)
+	GetProductRating(ctx context.Context, productID uuid.UUID) (*models.ProductRating, error)
+
+	// Customer operations
+	SubmitReview(ctx context.Context, userID, productID uuid.UUID, req *models.SubmitReviewRequest) (*models.Review, error)
+	UpdateReview(ctx context.Context, userID, reviewID uuid.UUID, req *models.SubmitReviewRequest) (*models.Review, error)
+	DeleteReview(ctx context.Context, userID, reviewID uuid.UUID) error
+	Vote(ctx context.Context, userID, reviewID uuid.UUID, helpful bool) (*models.Review, error)
+	RemoveVote(ctx context.Context, userID, reviewID uuid.UUID) (*models.Review, error)
+
+	// Moderation operations
+	ListForModeration(ctx context.Context, req *models.ListModerationRequest) ([]*models.Review, error)
+	Moderate(ctx context.Context, moderatorID, reviewID uuid.UUID, req *models.ModerationRequest) (*models.Review, error)
+}
+
+// EventPublisher publishes domain events to the message broker
+type EventPublisher interface {
+	Publish(ctx context.Context, topic, key string, event interface{}) error
+}
+
+// eventSource is the source of the events the service publishes
+const eventSource = "review-service"
+
+// reviewService implements the ReviewService interface
+type reviewService struct {
+	repo      repository.ReviewRepository
+	publisher EventPublisher
+	ratings   *cache.Cache
+	config    *config.Config
+	logger    *logger.Logger
+}
+
+// NewReviewService creates a new review service.
+// publisher may be nil, in which case review events are not published, and
+// ratings may be nil, in which case ratings are read from the database each
+// time.
+func NewReviewService(
+	repo repository.ReviewRepository,
+	publisher EventPublisher,
+	ratings *cache.Cache,
+	config *config.Config,
+	logger *logger.Logger,
+) ReviewService {
+	return &reviewService{
+		repo:      repo,
+		publisher: publisher,
+		ratings:   ratings,
+		config:    config,
+		logger:    logger,
+	}
+}
+
+// ListProductReviews returns a page of a product's approved reviews
+func (s *reviewService) ListProductReviews(ctx context.Context, productID uuid.UUID, req *models.ListReviewsRequest) (*models.ReviewListResponse, error) {
+	filter, err := buildFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra row to find out whether another page exists
+	pageSize := filter.Limit
+	filter.Limit = pageSize + 1
+
+	reviews, err := s.repo.ListProductReviews(ctx, productID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(reviews) > pageSize
+	if hasMore {
+		reviews = reviews[:pageSize]
+	}
+
+	public := make([]*models.PublicReview, 0, len(reviews))
+	for _, review := range reviews {
+		public = append(public, review.ToPublic())
+	}
+	response := httpx.NewPage(public, "", pageSize)
+
+	if hasMore {
+		last := reviews[len(reviews)-1]
+		cursor := &models.ReviewCursor{Sort: filter.Sort, CreatedAt: last.CreatedAt, ID: last.ID}
+		switch filter.Sort {
+		case models.ReviewSortHighest, models.ReviewSortLowest:
+			cursor.Value = last.Rating
+		case models.ReviewSortMostHelpful:
+			cursor.Value = last.HelpfulCount
+		}
+
+		response.Pagination.NextCursor, err = database.EncodeCursor(cursor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode cursor: %w", err)
+		}
+	}
+
+	return response, nil
+}
+
+// GetProductRating returns the rating summary of a product, read through
+// the rating cache
+func (s *reviewService) GetProductRating(ctx context.Context, productID uuid.UUID) (*models.ProductRating, error) {
+	if s.ratings == nil {
+		return s.loadProductRating(ctx, productID)
+	}
+
+	rating := &models.ProductRating{}
+	err := s.ratings.Fetch(ctx, productID.String(), rating, func(ctx context.Context) (interface{}, error) {
+		return s.loadProductRating(ctx, productID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rating, nil
+}
+
+// loadProductRating summarizes the rating of a product from its reviews
+func (s *reviewService) loadProductRating(ctx context.Context, productID uuid.UUID) (*models.ProductRating, error) {
+	rating, err := s.repo.GetProductRating(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	rating.Summarize()
+	return rating, nil
+}
+
+// SubmitReview stores a customer's review of a product. Reviews are marked as
+// verified when the customer bought the product, and wait for moderation
+// unless verified reviews are configured to be approved right away.
+func (s *reviewService) SubmitReview(ctx context.Context, userID, productID uuid.UUID, req *models.SubmitReviewRequest) (*models.Review, error) {
+	orderID, err := s.repo.FindPurchaseOrder(ctx, userID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	review := &models.Review{
+		ID:               uuid.New(),
+		ProductID:        productID,
+		UserID:           userID,
+		OrderID:          orderID,
+		Rating:           req.Rating,
+		Title:            strings.TrimSpace(req.Title),
+		Body:             strings.TrimSpace(req.Body),
+		VerifiedPurchase: orderID != nil,
+	}
+	s.setInitialStatus(review)
+
+	if err := s.repo.CreateReview(ctx, review); err != nil {
+		return nil, err
+	}
+
+	s.publishEvent(ctx, models.EventReviewSubmitted, review)
+	if review.Status == models.ReviewStatusApproved {
+		s.publishEvent(ctx, models.EventReviewApproved, review)
+		s.dropRating(ctx, productID)
+	}
+
+	s.logger.Info("Review submitted", "review_id", review.ID, "product_id", productID, "user_id", userID, "verified", review.VerifiedPurchase)
+	return s.repo.GetReview(ctx, review.ID)
+}
+
+// UpdateReview replaces the content of one of the customer's reviews. The
+// edited review goes through moderation again.
+func (s *reviewService) UpdateReview(ctx context.Context, userID, reviewID uuid.UUID, req *models.SubmitReviewRequest) (*models.Review, error) {
+	review, err := s.getOwnReview(ctx, userID, reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	review.Rating = req.Rating
+	review.Title = strings.TrimSpace(req.Title)
+	review.Body = strings.TrimSpace(req.Body)
+	review.ModeratedBy = nil
+	review.ModerationNotes = nil
+	s.setInitialStatus(review)
+
+	if err := s.repo.UpdateReview(ctx, review); err != nil {
+		return nil, err
+	}
+	// The edited review may have counted towards the rating before
+	s.dropRating(ctx, review.ProductID)
+
+	s.publishEvent(ctx, models.EventReviewSubmitted, review)
+	if review.Status == models.ReviewStatusApproved {
+		s.publishEvent(ctx, models.EventReviewApproved, review)
+	}
+
+	s.logger.Info("Review updated", "review_id", review.ID, "user_id", userID)
+	return review, nil
+}
+
+// DeleteReview deletes one of the customer's reviews
+func (s *reviewService) DeleteReview(ctx context.Context, userID, reviewID uuid.UUID) error {
+	review, err := s.getOwnReview(ctx, userID, reviewID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteReview(ctx, reviewID); err != nil {
+		return err
+	}
+	s.dropRating(ctx, review.ProductID)
+
+	s.logger.Info("Review deleted", "review_id", reviewID, "user_id", userID)
+	return nil
+}
+
+// Vote records whether the customer found an approved review helpful.
+// Voting again replaces the earlier vote.
+func (s *reviewService) Vote(ctx context.Context, userID, reviewID uuid.UUID, helpful bool) (*models.Review, error) {
+	review, err := s.repo.GetReview(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reviews that aren't published can't be voted on, and aren't revealed
+	if review.Status != models.ReviewStatusApproved {
+		return nil, apperrors.NotFound("review not found")
+	}
+	if review.UserID == userID {
+		return nil, apperrors.Conflict("review cannot be voted on by its author")
+	}
+
+	return s.repo.SetVote(ctx, reviewID, userID, helpful)
+}
+
+// RemoveVote withdraws the customer's vote on a review
+func (s *reviewService) RemoveVote(ctx context.Context, userID, reviewID uuid.UUID) (*models.Review, error) {
+	return s.repo.DeleteVote(ctx, reviewID, userID)
+}
+
+// ListForModeration returns reviews in a moderation state, pending by default,
+// oldest first
+func (s *reviewService) ListForModeration(ctx context.Context, req *models.ListModerationRequest) ([]*models.Review, error) {
+	status := models.ReviewStatusPending
+	if req.Status != "" {
+		status = models.ReviewStatus(req.Status)
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	return s.repo.ListReviewsByStatus(ctx, status, limit)
+}
+
+// Moderate approves or rejects a review. Approved reviews can later be
+// rejected, which removes them from the product's rating.
+func (s *reviewService) Moderate(ctx context.Context, moderatorID, reviewID uuid.UUID, req *models.ModerationRequest) (*models.Review, error) {
+	review, err := s.repo.GetReview(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := models.ReviewStatusRejected
+	eventType := models.EventReviewRejected
+	if req.Decision == "approve" {
+		status = models.ReviewStatusApproved
+		eventType = models.EventReviewApproved
+	}
+
+	if review.Status == status {
+		return nil, apperrors.Conflict("review is already %s", status)
+	}
+
+	now := time.Now()
+	review.Status = status
+	review.ModeratedBy = &moderatorID
+	review.ModerationNotes = optionalString(strings.TrimSpace(req.Notes))
+	review.ModeratedAt = &now
+
+	if err := s.repo.ModerateReview(ctx, review); err != nil {
+		return nil, err
+	}
+	s.dropRating(ctx, review.ProductID)
+
+	s.publishEvent(ctx, eventType, review)
+
+	s.logger.Info("Review moderated", "review_id", review.ID, "status", status, "moderator_id", moderatorID)
+	return review, nil
+}
+
+// dropRating drops the cached rating of a product once its reviews
+// changed; a rating that can't be dropped expires on its own
+func (s *reviewService) dropRating(ctx context.Context, productID uuid.UUID) {
+	if s.ratings == nil {
+		return
+	}
+	if err := s.ratings.Invalidate(ctx, productID.String()); err != nil {
+		s.logger.Warn("Failed to invalidate cached rating", "error", err, "product_id", productID)
+	}
+}
+
+// setInitialStatus puts a new or edited review into moderation, or approves
+// it right away when it is a verified purchase and auto-approval is on
+func (s *reviewService) setInitialStatus(review *models.Review) {
+	review.Status = models.ReviewStatusPending
+	review.ModeratedAt = nil
+
+	if review.VerifiedPurchase && s.config.Services.Review.AutoApproveVerified {
+		now := time.Now()
+		review.Status = models.ReviewStatusApproved
+		review.ModeratedAt = &now
+	}
+}
+
+// getOwnReview returns one of the customer's reviews
+func (s *reviewService) getOwnReview(ctx context.Context, userID, reviewID uuid.UUID) (*models.Review, error) {
+	review, err := s.repo.GetReview(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't reveal the existence of other users' unpublished reviews
+	if review.UserID != userID {
+		return nil, apperrors.NotFound("review not found")
+	}
+
+	return review, nil
+}
+
+// publishEvent publishes a review event, logging rather than failing the
+// request when the broker is unavailable
+func (s *reviewService) publishEvent(ctx context.Context, eventType string, review *models.Review) {
+	if s.publisher == nil {
+		return
+	}
+
+	event := &models.ReviewEvent{
+		Type:             eventType,
+		ReviewID:         review.ID,
+		ProductID:        review.ProductID,
+		UserID:           review.UserID,
+		Rating:           review.Rating,
+		VerifiedPurchase: review.VerifiedPurchase,
+		Status:           string(review.Status),
+		OccurredAt:       time.Now().UTC(),
+	}
+
+	envelope, err := events.New(ctx, eventSource, eventType, review.ID.String(), models.ReviewEventSchemaVersion, event)
+	if err != nil {
+		s.logger.Error("Failed to publish review event", "error", err, "type", eventType, "review_id", review.ID)
+		return
+	}
+
+	if err := s.publisher.Publish(ctx, s.config.Kafka.Topics.ReviewEvents, review.ProductID.String(), envelope); err != nil {
+		s.logger.Error("Failed to publish review event", "error", err, "type", eventType, "review_id", review.ID)
+	}
+}
+
+// buildFilter validates the list request and converts it to a repository filter
+func buildFilter(req *models.ListReviewsRequest) (*models.ReviewFilter, error) {
+	filter := &models.ReviewFilter{
+		Sort:         models.ReviewSortNewest,
+		Rating:       req.Rating,
+		VerifiedOnly: req.Verified,
+		Limit:        req.Limit,
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+
+	if req.Sort != "" {
+		filter.Sort = models.ReviewSort(req.Sort)
+		if !filter.Sort.IsValid() {
+			return nil, apperrors.Validation("invalid sort: %s", req.Sort)
+		}
+	}
+
+	if req.Cursor != "" {
+		cursor, err := decodeCursor(req.Cursor)
+		// A cursor only continues the listing it was issued for
+		if err != nil || cursor.Sort != filter.Sort {
+			return nil, apperrors.Validation("invalid cursor")
+		}
+		filter.Cursor = cursor
+	}
+
+	return filter, nil
+}
+
+// decodeCursor parses a token produced by database.EncodeCursor
+func decodeCursor(token string) (*models.ReviewCursor, error) {
+	cursor, err := database.DecodeCursor[*models.ReviewCursor](token)
+	if err != nil {
+		return nil, err
+	}
+	if cursor == nil || cursor.ID == uuid.Nil || cursor.CreatedAt.IsZero() {
+		return nil, fmt.Errorf("incomplete cursor")
+	}
+
+	return cursor, nil
+}
+
+// optionalString returns nil for empty strings, for nullable columns
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
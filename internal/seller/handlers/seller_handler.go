@@ -0,0 +1,409 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/seller/models"
+	"github.com/kaanevranportfolio/Commercium/internal/seller/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/validation"
+)
+
+// SellerHandler handles HTTP requests for marketplace seller operations
+type SellerHandler struct {
+	sellerService service.SellerService
+	jwtService    *auth.JWTService
+	logger        *logger.Logger
+}
+
+// NewSellerHandler creates a new seller handler
+func NewSellerHandler(sellerService service.SellerService, jwtService *auth.JWTService, logger *logger.Logger) *SellerHandler {
+	return &SellerHandler{
+		sellerService: sellerService,
+		jwtService:    jwtService,
+		logger:        logger,
+	}
+}
+
+// Apply submits an application to become a seller
+func (h *SellerHandler) Apply(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+
+	var req models.ApplyRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	seller, err := h.sellerService.Apply(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to submit seller application")
+		return
+	}
+
+	c.JSON(http.StatusCreated, seller)
+}
+
+// GetOwnSeller returns the caller's seller account and application status
+func (h *SellerHandler) GetOwnSeller(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+
+	seller, err := h.sellerService.GetOwnSeller(c.Request.Context(), userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get seller account")
+		return
+	}
+
+	c.JSON(http.StatusOK, seller)
+}
+
+// UpdateProfile changes the caller's store profile
+func (h *SellerHandler) UpdateProfile(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+
+	var req models.UpdateProfileRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	seller, err := h.sellerService.UpdateProfile(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to update seller profile")
+		return
+	}
+
+	c.JSON(http.StatusOK, seller)
+}
+
+// ListProducts lists the products of the caller's store
+func (h *SellerHandler) ListProducts(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+
+	products, err := h.sellerService.ListProducts(c.Request.Context(), userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to list products")
+		return
+	}
+
+	httpx.Items(c, products)
+}
+
+// RegisterProduct registers a product as sold by the caller's store
+func (h *SellerHandler) RegisterProduct(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+
+	var req models.RegisterProductRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	product, err := h.sellerService.RegisterProduct(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to register product")
+		return
+	}
+
+	c.JSON(http.StatusCreated, product)
+}
+
+// ListOrders lists the orders containing the caller's products
+func (h *SellerHandler) ListOrders(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+
+	var req models.ListSellerOrdersRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	orders, err := h.sellerService.ListOrders(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to list orders")
+		return
+	}
+
+	httpx.List(c, orders)
+}
+
+// GetOrder returns the caller's items of an order
+func (h *SellerHandler) GetOrder(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	order, err := h.sellerService.GetOrder(c.Request.Context(), userID, orderID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get order")
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// ListOwnStatements lists the caller's payout statements
+func (h *SellerHandler) ListOwnStatements(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+
+	statements, err := h.sellerService.ListOwnStatements(c.Request.Context(), userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to list statements")
+		return
+	}
+
+	httpx.Items(c, statements)
+}
+
+// GetOwnStatement returns one of the caller's payout statements
+func (h *SellerHandler) GetOwnStatement(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+
+	statementID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid statement ID"})
+		return
+	}
+
+	statement, err := h.sellerService.GetOwnStatement(c.Request.Context(), userID, statementID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get statement")
+		return
+	}
+
+	c.JSON(http.StatusOK, statement)
+}
+
+// ListSellers lists sellers by status (admin)
+func (h *SellerHandler) ListSellers(c *gin.Context) {
+	var req models.ListSellersRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	sellers, err := h.sellerService.ListSellers(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to list sellers")
+		return
+	}
+
+	httpx.Items(c, sellers)
+}
+
+// GetSeller returns a seller account (admin)
+func (h *SellerHandler) GetSeller(c *gin.Context) {
+	sellerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid seller ID"})
+		return
+	}
+
+	seller, err := h.sellerService.GetSeller(c.Request.Context(), sellerID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get seller")
+		return
+	}
+
+	c.JSON(http.StatusOK, seller)
+}
+
+// ReviewSeller approves, rejects or suspends a seller (admin)
+func (h *SellerHandler) ReviewSeller(c *gin.Context) {
+	adminID := auth.UserIDFromContext(c)
+
+	sellerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid seller ID"})
+		return
+	}
+
+	var req models.ReviewSellerRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	seller, err := h.sellerService.ReviewSeller(c.Request.Context(), adminID, sellerID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to review seller")
+		return
+	}
+
+	c.JSON(http.StatusOK, seller)
+}
+
+// SetCommission sets a seller's commission rate (admin)
+func (h *SellerHandler) SetCommission(c *gin.Context) {
+	sellerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid seller ID"})
+		return
+	}
+
+	var req models.SetCommissionRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	seller, err := h.sellerService.SetCommission(c.Request.Context(), sellerID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to set commission rate")
+		return
+	}
+
+	c.JSON(http.StatusOK, seller)
+}
+
+// ListStatements lists a seller's payout statements (admin)
+func (h *SellerHandler) ListStatements(c *gin.Context) {
+	sellerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid seller ID"})
+		return
+	}
+
+	statements, err := h.sellerService.ListStatements(c.Request.Context(), sellerID)
+	if err != nil {
+		h.respondError(c, err, "Failed to list statements")
+		return
+	}
+
+	httpx.Items(c, statements)
+}
+
+// GenerateStatements settles a seller's sales into payout statements (admin)
+func (h *SellerHandler) GenerateStatements(c *gin.Context) {
+	sellerID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid seller ID"})
+		return
+	}
+
+	var req models.GenerateStatementsRequest
+	if !validation.BindOptionalJSON(c, &req) {
+		return
+	}
+
+	response, err := h.sellerService.GenerateStatements(c.Request.Context(), sellerID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to generate statements")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// GenerateAllStatements settles every active seller's sales, or queues
+// their settlement (admin)
+func (h *SellerHandler) GenerateAllStatements(c *gin.Context) {
+	var req models.GenerateStatementsRequest
+	if !validation.BindOptionalJSON(c, &req) {
+		return
+	}
+
+	response, err := h.sellerService.GenerateAllStatements(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to generate statements")
+		return
+	}
+
+	// Statements queued are generated in the background
+	if response.Queued > 0 {
+		c.JSON(http.StatusAccepted, response)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetStatement returns a payout statement (admin)
+func (h *SellerHandler) GetStatement(c *gin.Context) {
+	statementID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid statement ID"})
+		return
+	}
+
+	statement, err := h.sellerService.GetStatement(c.Request.Context(), statementID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get statement")
+		return
+	}
+
+	c.JSON(http.StatusOK, statement)
+}
+
+// MarkStatementPaid records the payout of a statement (admin)
+func (h *SellerHandler) MarkStatementPaid(c *gin.Context) {
+	statementID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid statement ID"})
+		return
+	}
+
+	var req models.MarkPaidRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	statement, err := h.sellerService.MarkStatementPaid(c.Request.Context(), statementID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to mark statement paid")
+		return
+	}
+
+	c.JSON(http.StatusOK, statement)
+}
+
+// respondError logs a request failing on the side of the service, or of a
+// provider it depends on, and answers it with the problem details of err,
+// so middleware reading its status after it, such as idempotency's, sees
+// the failure
+func (h *SellerHandler) respondError(c *gin.Context, err error, message string) {
+	if apperrors.HTTPStatus(err) >= http.StatusInternalServerError {
+		logger.FromContext(c.Request.Context()).Error(message, "error", err)
+	}
+	apperrors.Respond(c, err)
+}
+
+// SetupRoutes sets up the seller routes. Any user can apply to sell; the
+// seller routes need the seller role, which approval grants.
+func (h *SellerHandler) SetupRoutes(r *gin.Engine) {
+	sellers := r.Group("/api/v1/sellers")
+	sellers.Use(h.jwtService.Middleware())
+	{
+		sellers.POST("", h.Apply)
+		sellers.GET("/me", h.GetOwnSeller)
+	}
+
+	seller := r.Group("/api/v1/seller")
+	seller.Use(h.jwtService.Middleware(), auth.RequireRole("seller"))
+	{
+		seller.PUT("/profile", h.UpdateProfile)
+		seller.GET("/products", h.ListProducts)
+		seller.POST("/products", h.RegisterProduct)
+		seller.GET("/orders", h.ListOrders)
+		seller.GET("/orders/:id", h.GetOrder)
+		seller.GET("/statements", h.ListOwnStatements)
+		seller.GET("/statements/:id", h.GetOwnStatement)
+	}
+
+	admin := r.Group("/api/v1/admin")
+	admin.Use(h.jwtService.Middleware(), auth.RequireRole("admin"))
+	{
+		admin.GET("/sellers", h.ListSellers)
+		admin.GET("/sellers/:id", h.GetSeller)
+		admin.POST("/sellers/:id/review", h.ReviewSeller)
+		admin.PUT("/sellers/:id/commission", h.SetCommission)
+		admin.GET("/sellers/:id/statements", h.ListStatements)
+		admin.POST("/sellers/:id/statements", h.GenerateStatements)
+
+		admin.POST("/seller-statements", h.GenerateAllStatements)
+		admin.GET("/seller-statements/:id", h.GetStatement)
+		admin.POST("/seller-statements/:id/paid", h.MarkStatementPaid)
+	}
+}
@@ -0,0 +1,77 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/mock/gomock"
+
+	"github.com/kaanevranportfolio/Commercium/internal/seller/handlers"
+	"github.com/kaanevranportfolio/Commercium/internal/seller/mocks"
+	"github.com/kaanevranportfolio/Commercium/internal/seller/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// BenchmarkListProducts measures listing the products of a store over
+// HTTP, past the service: the token validated, the role checked and the
+// products encoded
+func BenchmarkListProducts(b *testing.B) {
+	gin.SetMode(gin.ReleaseMode)
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "error",
+		Format: "json",
+		Output: "stdout",
+	}, "seller-handler-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	jwtService := auth.NewJWTService(&config.JWTConfig{
+		SecretKey:         "benchmark-secret-key-of-32-bytes!",
+		Issuer:            "commercium",
+		Expiration:        15 * time.Minute,
+		RefreshExpiration: 7 * 24 * time.Hour,
+	})
+	userID := uuid.New()
+	pair, err := jwtService.GenerateTokenPair(userID, "seller@example.com", "seller", "seller", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// A store of a hundred products
+	sellerID := uuid.New()
+	products := make([]*models.SellerProduct, 100)
+	for i := range products {
+		products[i] = &models.SellerProduct{
+			ProductID: uuid.New(),
+			SellerID:  sellerID,
+			SKU:       fmt.Sprintf("SKU-%05d", i),
+			CreatedAt: time.Now(),
+		}
+	}
+
+	sellerService := mocks.NewMockSellerService(gomock.NewController(b))
+	sellerService.EXPECT().ListProducts(gomock.Any(), userID).Return(products, nil).AnyTimes()
+
+	router := gin.New()
+	handlers.NewSellerHandler(sellerService, jwtService, log).SetupRoutes(router)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/seller/products", nil)
+		req.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			b.Fatalf("unexpected status %d: %s", w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: seller_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=seller_service.go -destination=../mocks/mock_seller_service.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	uuid "github.com/google/uuid"
+	models "github.com/kaanevranportfolio/Commercium/internal/seller/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSellerService is a mock of SellerService interface.
+type MockSellerService struct {
+	ctrl     *gomock.Controller
+	recorder *MockSellerServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockSellerServiceMockRecorder is the mock recorder for MockSellerService.
+type MockSellerServiceMockRecorder struct {
+	mock *MockSellerService
+}
+
+// NewMockSellerService creates a new mock instance.
+func NewMockSellerService(ctrl *gomock.Controller) *MockSellerService {
+	mock := &MockSellerService{ctrl: ctrl}
+	mock.recorder = &MockSellerServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSellerService) EXPECT() *MockSellerServiceMockRecorder {
+	return m.recorder
+}
+
+// Apply mocks base method.
+func (m *MockSellerService) Apply(ctx context.Context, userID uuid.UUID, req *models.ApplyRequest) (*models.Seller, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Apply", ctx, userID, req)
+	ret0, _ := ret[0].(*models.Seller)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Apply indicates an expected call of Apply.
+func (mr *MockSellerServiceMockRecorder) Apply(ctx, userID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Apply", reflect.TypeOf((*MockSellerService)(nil).Apply), ctx, userID, req)
+}
+
+// GenerateAllStatements mocks base method.
+func (m *MockSellerService) GenerateAllStatements(ctx context.Context, req *models.GenerateStatementsRequest) (*models.GenerateStatementsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateAllStatements", ctx, req)
+	ret0, _ := ret[0].(*models.GenerateStatementsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateAllStatements indicates an expected call of GenerateAllStatements.
+func (mr *MockSellerServiceMockRecorder) GenerateAllStatements(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateAllStatements", reflect.TypeOf((*MockSellerService)(nil).GenerateAllStatements), ctx, req)
+}
+
+// GenerateStatements mocks base method.
+func (m *MockSellerService) GenerateStatements(ctx context.Context, sellerID uuid.UUID, req *models.GenerateStatementsRequest) (*models.GenerateStatementsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateStatements", ctx, sellerID, req)
+	ret0, _ := ret[0].(*models.GenerateStatementsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateStatements indicates an expected call of GenerateStatements.
+func (mr *MockSellerServiceMockRecorder) GenerateStatements(ctx, sellerID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateStatements", reflect.TypeOf((*MockSellerService)(nil).GenerateStatements), ctx, sellerID, req)
+}
+
+// GetOrder mocks base method.
+func (m *MockSellerService) GetOrder(ctx context.Context, userID, orderID uuid.UUID) (*models.SellerOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrder", ctx, userID, orderID)
+	ret0, _ := ret[0].(*models.SellerOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrder indicates an expected call of GetOrder.
+func (mr *MockSellerServiceMockRecorder) GetOrder(ctx, userID, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockSellerService)(nil).GetOrder), ctx, userID, orderID)
+}
+
+// GetOwnSeller mocks base method.
+func (m *MockSellerService) GetOwnSeller(ctx context.Context, userID uuid.UUID) (*models.Seller, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOwnSeller", ctx, userID)
+	ret0, _ := ret[0].(*models.Seller)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOwnSeller indicates an expected call of GetOwnSeller.
+func (mr *MockSellerServiceMockRecorder) GetOwnSeller(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnSeller", reflect.TypeOf((*MockSellerService)(nil).GetOwnSeller), ctx, userID)
+}
+
+// GetOwnStatement mocks base method.
+func (m *MockSellerService) GetOwnStatement(ctx context.Context, userID, statementID uuid.UUID) (*models.Statement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOwnStatement", ctx, userID, statementID)
+	ret0, _ := ret[0].(*models.Statement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOwnStatement indicates an expected call of GetOwnStatement.
+func (mr *MockSellerServiceMockRecorder) GetOwnStatement(ctx, userID, statementID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnStatement", reflect.TypeOf((*MockSellerService)(nil).GetOwnStatement), ctx, userID, statementID)
+}
+
+// GetSeller mocks base method.
+func (m *MockSellerService) GetSeller(ctx context.Context, sellerID uuid.UUID) (*models.Seller, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSeller", ctx, sellerID)
+	ret0, _ := ret[0].(*models.Seller)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSeller indicates an expected call of GetSeller.
+func (mr *MockSellerServiceMockRecorder) GetSeller(ctx, sellerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSeller", reflect.TypeOf((*MockSellerService)(nil).GetSeller), ctx, sellerID)
+}
+
+// GetStatement mocks base method.
+func (m *MockSellerService) GetStatement(ctx context.Context, statementID uuid.UUID) (*models.Statement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatement", ctx, statementID)
+	ret0, _ := ret[0].(*models.Statement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatement indicates an expected call of GetStatement.
+func (mr *MockSellerServiceMockRecorder) GetStatement(ctx, statementID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatement", reflect.TypeOf((*MockSellerService)(nil).GetStatement), ctx, statementID)
+}
+
+// ListOrders mocks base method.
+func (m *MockSellerService) ListOrders(ctx context.Context, userID uuid.UUID, req *models.ListSellerOrdersRequest) (*models.SellerOrderListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrders", ctx, userID, req)
+	ret0, _ := ret[0].(*models.SellerOrderListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrders indicates an expected call of ListOrders.
+func (mr *MockSellerServiceMockRecorder) ListOrders(ctx, userID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrders", reflect.TypeOf((*MockSellerService)(nil).ListOrders), ctx, userID, req)
+}
+
+// ListOwnStatements mocks base method.
+func (m *MockSellerService) ListOwnStatements(ctx context.Context, userID uuid.UUID) ([]*models.Statement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOwnStatements", ctx, userID)
+	ret0, _ := ret[0].([]*models.Statement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOwnStatements indicates an expected call of ListOwnStatements.
+func (mr *MockSellerServiceMockRecorder) ListOwnStatements(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOwnStatements", reflect.TypeOf((*MockSellerService)(nil).ListOwnStatements), ctx, userID)
+}
+
+// ListProducts mocks base method.
+func (m *MockSellerService) ListProducts(ctx context.Context, userID uuid.UUID) ([]*models.SellerProduct, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListProducts", ctx, userID)
+	ret0, _ := ret[0].([]*models.SellerProduct)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListProducts indicates an expected call of ListProducts.
+func (mr *MockSellerServiceMockRecorder) ListProducts(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProducts", reflect.TypeOf((*MockSellerService)(nil).ListProducts), ctx, userID)
+}
+
+// ListSellers mocks base method.
+func (m *MockSellerService) ListSellers(ctx context.Context, req *models.ListSellersRequest) ([]*models.Seller, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSellers", ctx, req)
+	ret0, _ := ret[0].([]*models.Seller)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSellers indicates an expected call of ListSellers.
+func (mr *MockSellerServiceMockRecorder) ListSellers(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSellers", reflect.TypeOf((*MockSellerService)(nil).ListSellers), ctx, req)
+}
+
+// ListStatements mocks base method.
+func (m *MockSellerService) ListStatements(ctx context.Context, sellerID uuid.UUID) ([]*models.Statement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStatements", ctx, sellerID)
+	ret0, _ := ret[0].([]*models.Statement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStatements indicates an expected call of ListStatements.
+func (mr *MockSellerServiceMockRecorder) ListStatements(ctx, sellerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStatements", reflect.TypeOf((*MockSellerService)(nil).ListStatements), ctx, sellerID)
+}
+
+// MarkStatementPaid mocks base method.
+func (m *MockSellerService) MarkStatementPaid(ctx context.Context, statementID uuid.UUID, req *models.MarkPaidRequest) (*models.Statement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkStatementPaid", ctx, statementID, req)
+	ret0, _ := ret[0].(*models.Statement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkStatementPaid indicates an expected call of MarkStatementPaid.
+func (mr *MockSellerServiceMockRecorder) MarkStatementPaid(ctx, statementID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkStatementPaid", reflect.TypeOf((*MockSellerService)(nil).MarkStatementPaid), ctx, statementID, req)
+}
+
+// RegisterProduct mocks base method.
+func (m *MockSellerService) RegisterProduct(ctx context.Context, userID uuid.UUID, req *models.RegisterProductRequest) (*models.SellerProduct, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterProduct", ctx, userID, req)
+	ret0, _ := ret[0].(*models.SellerProduct)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterProduct indicates an expected call of RegisterProduct.
+func (mr *MockSellerServiceMockRecorder) RegisterProduct(ctx, userID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterProduct", reflect.TypeOf((*MockSellerService)(nil).RegisterProduct), ctx, userID, req)
+}
+
+// ReviewSeller mocks base method.
+func (m *MockSellerService) ReviewSeller(ctx context.Context, adminID, sellerID uuid.UUID, req *models.ReviewSellerRequest) (*models.Seller, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReviewSeller", ctx, adminID, sellerID, req)
+	ret0, _ := ret[0].(*models.Seller)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReviewSeller indicates an expected call of ReviewSeller.
+func (mr *MockSellerServiceMockRecorder) ReviewSeller(ctx, adminID, sellerID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReviewSeller", reflect.TypeOf((*MockSellerService)(nil).ReviewSeller), ctx, adminID, sellerID, req)
+}
+
+// SetCommission mocks base method.
+func (m *MockSellerService) SetCommission(ctx context.Context, sellerID uuid.UUID, req *models.SetCommissionRequest) (*models.Seller, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCommission", ctx, sellerID, req)
+	ret0, _ := ret[0].(*models.Seller)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetCommission indicates an expected call of SetCommission.
+func (mr *MockSellerServiceMockRecorder) SetCommission(ctx, sellerID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCommission", reflect.TypeOf((*MockSellerService)(nil).SetCommission), ctx, sellerID, req)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockSellerService) UpdateProfile(ctx context.Context, userID uuid.UUID, req *models.UpdateProfileRequest) (*models.Seller, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", ctx, userID, req)
+	ret0, _ := ret[0].(*models.Seller)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockSellerServiceMockRecorder) UpdateProfile(ctx, userID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockSellerService)(nil).UpdateProfile), ctx, userID, req)
+}
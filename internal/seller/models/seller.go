@@ -0,0 +1,236 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+)
+
+// SellerStatus represents the state of a seller account
+type SellerStatus string
+
+const (
+	SellerStatusPending   SellerStatus = "pending"
+	SellerStatusActive    SellerStatus = "active"
+	SellerStatusRejected  SellerStatus = "rejected"
+	SellerStatusSuspended SellerStatus = "suspended"
+)
+
+// IsValid reports whether the status is a known seller status
+func (s SellerStatus) IsValid() bool {
+	switch s {
+	case SellerStatusPending, SellerStatusActive, SellerStatusRejected, SellerStatusSuspended:
+		return true
+	}
+	return false
+}
+
+// StatementStatus represents the state of a payout statement
+type StatementStatus string
+
+const (
+	StatementStatusPending StatementStatus = "pending"
+	StatementStatusPaid    StatementStatus = "paid"
+)
+
+// Seller is a user's seller account and store profile. CommissionRateBps,
+// when set, overrides the default commission rate, in basis points.
+type Seller struct {
+	ID                uuid.UUID    `json:"id" db:"id"`
+	UserID            uuid.UUID    `json:"user_id" db:"user_id"`
+	StoreName         string       `json:"store_name" db:"store_name"`
+	Description       *string      `json:"description,omitempty" db:"description"`
+	ContactEmail      string       `json:"contact_email" db:"contact_email"`
+	Phone             *string      `json:"phone,omitempty" db:"phone"`
+	Country           string       `json:"country" db:"country"`
+	TaxID             *string      `json:"tax_id,omitempty" db:"tax_id"`
+	PayoutReference   *string      `json:"payout_reference,omitempty" db:"payout_reference"`
+	CommissionRateBps *int         `json:"commission_rate_bps,omitempty" db:"commission_rate_bps"`
+	Status            SellerStatus `json:"status" db:"status"`
+	StatusReason      *string      `json:"status_reason,omitempty" db:"status_reason"`
+	ReviewedBy        *uuid.UUID   `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ApprovedAt        *time.Time   `json:"approved_at,omitempty" db:"approved_at"`
+	CreatedAt         time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// SellerProduct assigns a product to the seller selling it
+type SellerProduct struct {
+	ProductID uuid.UUID `json:"product_id" db:"product_id"`
+	SellerID  uuid.UUID `json:"seller_id" db:"seller_id"`
+	SKU       string    `json:"sku" db:"sku"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// SellerOrder is an order as seen by a seller: only the seller's own items
+// are included, and Subtotal is the sum of those items
+type SellerOrder struct {
+	ID              uuid.UUID          `json:"id" db:"id"`
+	OrderNumber     string             `json:"order_number" db:"order_number"`
+	Status          string             `json:"status" db:"status"`
+	Currency        string             `json:"currency" db:"currency"`
+	ShippingAddress json.RawMessage    `json:"shipping_address,omitempty" db:"shipping_address"`
+	PlacedAt        time.Time          `json:"placed_at" db:"placed_at"`
+	Subtotal        int64              `json:"subtotal" db:"-"`
+	Items           []*SellerOrderItem `json:"items" db:"-"`
+}
+
+// SellerOrderItem is one of a seller's items in an order
+type SellerOrderItem struct {
+	ID               uuid.UUID `json:"id" db:"id"`
+	OrderID          uuid.UUID `json:"order_id" db:"order_id"`
+	ProductID        uuid.UUID `json:"product_id" db:"product_id"`
+	SKU              string    `json:"sku" db:"sku"`
+	Name             string    `json:"name" db:"name"`
+	Quantity         int       `json:"quantity" db:"quantity"`
+	RefundedQuantity int       `json:"refunded_quantity" db:"refunded_quantity"`
+	UnitPrice        int64     `json:"unit_price" db:"unit_price"`
+	TotalPrice       int64     `json:"total_price" db:"total_price"`
+}
+
+// SellerOrderFilter holds the criteria used to list a seller's orders
+type SellerOrderFilter struct {
+	Statuses []string
+	Cursor   *OrderCursor
+	Limit    int
+}
+
+// OrderCursor identifies the position after which the next page starts
+type OrderCursor struct {
+	PlacedAt time.Time `json:"p"`
+	ID       uuid.UUID `json:"i"`
+}
+
+// Statement is a payout statement: the seller's settled sales in one
+// currency, the commission kept and the amount paid out
+type Statement struct {
+	ID               uuid.UUID        `json:"id" db:"id"`
+	SellerID         uuid.UUID        `json:"seller_id" db:"seller_id"`
+	Currency         string           `json:"currency" db:"currency"`
+	PeriodEnd        time.Time        `json:"period_end" db:"period_end"`
+	GrossAmount      int64            `json:"gross_amount" db:"gross_amount"`
+	CommissionAmount int64            `json:"commission_amount" db:"commission_amount"`
+	PayoutAmount     int64            `json:"payout_amount" db:"payout_amount"`
+	Status           StatementStatus  `json:"status" db:"status"`
+	PayoutReference  *string          `json:"payout_reference,omitempty" db:"payout_reference"`
+	PaidAt           *time.Time       `json:"paid_at,omitempty" db:"paid_at"`
+	CreatedAt        time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at" db:"updated_at"`
+	Lines            []*StatementLine `json:"lines,omitempty" db:"-"`
+}
+
+// StatementLine is an order item settled by a statement
+type StatementLine struct {
+	ID                uuid.UUID `json:"id" db:"id"`
+	StatementID       uuid.UUID `json:"statement_id" db:"statement_id"`
+	OrderItemID       uuid.UUID `json:"order_item_id" db:"order_item_id"`
+	OrderID           uuid.UUID `json:"order_id" db:"order_id"`
+	OrderNumber       string    `json:"order_number" db:"order_number"`
+	ProductID         uuid.UUID `json:"product_id" db:"product_id"`
+	SKU               string    `json:"sku" db:"sku"`
+	Quantity          int       `json:"quantity" db:"quantity"`
+	UnitPrice         int64     `json:"unit_price" db:"unit_price"`
+	GrossAmount       int64     `json:"gross_amount" db:"gross_amount"`
+	CommissionRateBps int       `json:"commission_rate_bps" db:"commission_rate_bps"`
+	CommissionAmount  int64     `json:"commission_amount" db:"commission_amount"`
+	PayoutAmount      int64     `json:"payout_amount" db:"payout_amount"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+}
+
+// UnsettledItem is a delivered order item of a seller not yet on a statement
+type UnsettledItem struct {
+	OrderItemID      uuid.UUID `db:"order_item_id"`
+	OrderID          uuid.UUID `db:"order_id"`
+	OrderNumber      string    `db:"order_number"`
+	Currency         string    `db:"currency"`
+	ProductID        uuid.UUID `db:"product_id"`
+	SKU              string    `db:"sku"`
+	Quantity         int       `db:"quantity"`
+	RefundedQuantity int       `db:"refunded_quantity"`
+	UnitPrice        int64     `db:"unit_price"`
+}
+
+// ApplyRequest represents a user's application to become a seller
+type ApplyRequest struct {
+	StoreName       string  `json:"store_name" binding:"required,max=100"`
+	Description     *string `json:"description,omitempty" binding:"omitempty,max=2000"`
+	ContactEmail    string  `json:"contact_email" binding:"required,email,max=255"`
+	Phone           *string `json:"phone,omitempty" binding:"omitempty,max=20,phone"`
+	Country         string  `json:"country" binding:"required,country"`
+	TaxID           *string `json:"tax_id,omitempty" binding:"omitempty,max=50"`
+	PayoutReference *string `json:"payout_reference,omitempty" binding:"omitempty,max=255"`
+}
+
+// UpdateProfileRequest represents a seller's changes to their profile
+type UpdateProfileRequest struct {
+	StoreName       *string `json:"store_name,omitempty" binding:"omitempty,min=1,max=100"`
+	Description     *string `json:"description,omitempty" binding:"omitempty,max=2000"`
+	ContactEmail    *string `json:"contact_email,omitempty" binding:"omitempty,email,max=255"`
+	Phone           *string `json:"phone,omitempty" binding:"omitempty,max=20,phone"`
+	TaxID           *string `json:"tax_id,omitempty" binding:"omitempty,max=50"`
+	PayoutReference *string `json:"payout_reference,omitempty" binding:"omitempty,max=255"`
+}
+
+// ListSellersRequest represents the query parameters of the admin seller list
+type ListSellersRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending active rejected suspended"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// ReviewSellerRequest represents an admin's decision on a seller account.
+// Approving activates the account; it also reinstates a suspended seller.
+type ReviewSellerRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=approve reject suspend"`
+	Reason   string `json:"reason,omitempty" binding:"max=1000"`
+}
+
+// SetCommissionRequest sets or, with a null rate, clears a seller's own
+// commission rate
+type SetCommissionRequest struct {
+	CommissionRateBps *int `json:"commission_rate_bps" binding:"omitempty,min=0,max=10000"`
+}
+
+// RegisterProductRequest registers a product as sold by the seller
+type RegisterProductRequest struct {
+	ProductID uuid.UUID `json:"product_id" binding:"required"`
+	SKU       string    `json:"sku" binding:"required,max=100"`
+}
+
+// ListSellerOrdersRequest represents the query parameters of the seller order list
+type ListSellerOrdersRequest struct {
+	Status string `form:"status"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// SellerOrderListResponse represents a page of a seller's orders
+type SellerOrderListResponse = httpx.ListResponse[*SellerOrder]
+
+// GenerateStatementsRequest settles sales of orders placed before PeriodEnd,
+// which defaults to now
+type GenerateStatementsRequest struct {
+	PeriodEnd *time.Time `json:"period_end,omitempty"`
+}
+
+// GenerateStatementsResponse reports the statements generated, or for
+// every seller, how many sellers' statements are generated in the background
+type GenerateStatementsResponse struct {
+	Created    int          `json:"created"`
+	Queued     int          `json:"queued,omitempty"`
+	Statements []*Statement `json:"statements"`
+}
+
+// GenerateStatementsTask is the background task generating a seller's
+// statements up to PeriodEnd
+type GenerateStatementsTask struct {
+	SellerID  uuid.UUID `json:"seller_id"`
+	PeriodEnd time.Time `json:"period_end"`
+}
+
+// MarkPaidRequest records the payout of a statement
+type MarkPaidRequest struct {
+	PayoutReference string `json:"payout_reference" binding:"required,max=255"`
+}
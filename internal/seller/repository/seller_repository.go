@@ -0,0 +1,534 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kaanevranportfolio/Commercium/internal/seller/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// SellerRepository defines the interface for seller data operations
+type SellerRepository interface {
+	// Seller operations
+	CreateSeller(ctx context.Context, seller *models.Seller) error
+	GetSeller(ctx context.Context, id uuid.UUID) (*models.Seller, error)
+	GetSellerByUserID(ctx context.Context, userID uuid.UUID) (*models.Seller, error)
+	ListSellers(ctx context.Context, status models.SellerStatus, limit int) ([]*models.Seller, error)
+	UpdateSeller(ctx context.Context, seller *models.Seller) error
+	// ReviewSeller saves a seller's new status, and gives the user the
+	// seller role when the account is active
+	ReviewSeller(ctx context.Context, seller *models.Seller) error
+
+	// Product operations
+	RegisterProduct(ctx context.Context, product *models.SellerProduct) error
+	ListProducts(ctx context.Context, sellerID uuid.UUID) ([]*models.SellerProduct, error)
+
+	// Order operations
+	ListOrders(ctx context.Context, sellerID uuid.UUID, filter *models.SellerOrderFilter) ([]*models.SellerOrder, error)
+	GetOrder(ctx context.Context, sellerID, orderID uuid.UUID) (*models.SellerOrder, error)
+	GetOrderItems(ctx context.Context, sellerID uuid.UUID, orderIDs []uuid.UUID) (map[uuid.UUID][]*models.SellerOrderItem, error)
+
+	// Statement operations
+	UnsettledItems(ctx context.Context, sellerID uuid.UUID, periodEnd time.Time) ([]*models.UnsettledItem, error)
+	SellersWithUnsettledItems(ctx context.Context, periodEnd time.Time) ([]uuid.UUID, error)
+	CreateStatement(ctx context.Context, statement *models.Statement) error
+	GetStatement(ctx context.Context, id uuid.UUID) (*models.Statement, error)
+	ListStatements(ctx context.Context, sellerID uuid.UUID) ([]*models.Statement, error)
+	MarkStatementPaid(ctx context.Context, statement *models.Statement) error
+}
+
+// sellerRepository implements the SellerRepository interface
+type sellerRepository struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewSellerRepository creates a new seller repository
+func NewSellerRepository(db *database.DB, logger *logger.Logger) SellerRepository {
+	return &sellerRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const sellerColumns = `id, user_id, store_name, description, contact_email, phone, country, tax_id,
+	payout_reference, commission_rate_bps, status, status_reason, reviewed_by, approved_at,
+	created_at, updated_at`
+
+const statementColumns = `id, seller_id, currency, period_end, gross_amount, commission_amount,
+	payout_amount, status, payout_reference, paid_at, created_at, updated_at`
+
+// sellerItems joins order items to the sellers of their products. A sale is
+// only attributed to a seller if the product was registered before the
+// order was placed.
+const sellerItems = `
+	order_items oi
+	JOIN orders o ON o.id = oi.order_id
+	JOIN seller_products sp ON sp.product_id = oi.product_id AND sp.created_at <= o.placed_at`
+
+// CreateSeller stores a new seller application
+func (r *sellerRepository) CreateSeller(ctx context.Context, seller *models.Seller) error {
+	query := `
+		INSERT INTO sellers (id, user_id, store_name, description, contact_email, phone, country, tax_id,
+		                     payout_reference, status)
+		VALUES (:id, :user_id, :store_name, :description, :contact_email, :phone, :country, :tax_id,
+		        :payout_reference, :status)
+		RETURNING created_at, updated_at`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to prepare create seller statement", "error", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, seller).Scan(&seller.CreatedAt, &seller.UpdatedAt)
+	if err != nil {
+		if pgErr, ok := database.PgError(err); ok {
+			switch pgErr.Code {
+			case database.ForeignKeyViolation:
+				return apperrors.NotFound("user not found")
+			case database.UniqueViolation:
+				return duplicateSellerError(pgErr, seller)
+			}
+		}
+		r.logger.Error("Failed to create seller", "error", err, "user_id", seller.UserID)
+		return fmt.Errorf("failed to create seller: %w", err)
+	}
+
+	return nil
+}
+
+// GetSeller retrieves a seller by ID
+func (r *sellerRepository) GetSeller(ctx context.Context, id uuid.UUID) (*models.Seller, error) {
+	seller := &models.Seller{}
+	query := `SELECT ` + sellerColumns + ` FROM sellers WHERE id = $1`
+
+	err := r.db.GetContext(ctx, seller, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("seller not found")
+		}
+		r.logger.Error("Failed to get seller", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to get seller: %w", err)
+	}
+
+	return seller, nil
+}
+
+// GetSellerByUserID retrieves the seller account of a user
+func (r *sellerRepository) GetSellerByUserID(ctx context.Context, userID uuid.UUID) (*models.Seller, error) {
+	seller := &models.Seller{}
+	query := `SELECT ` + sellerColumns + ` FROM sellers WHERE user_id = $1`
+
+	err := r.db.GetContext(ctx, seller, query, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("seller not found")
+		}
+		r.logger.Error("Failed to get seller", "error", err, "user_id", userID)
+		return nil, fmt.Errorf("failed to get seller: %w", err)
+	}
+
+	return seller, nil
+}
+
+// ListSellers retrieves sellers with a status, oldest first
+func (r *sellerRepository) ListSellers(ctx context.Context, status models.SellerStatus, limit int) ([]*models.Seller, error) {
+	sellers := []*models.Seller{}
+	query := `
+		SELECT ` + sellerColumns + `
+		FROM sellers
+		WHERE status = $1
+		ORDER BY created_at, id
+		LIMIT $2`
+
+	err := r.db.SelectContext(ctx, &sellers, query, status, limit)
+	if err != nil {
+		r.logger.Error("Failed to list sellers", "error", err, "status", status)
+		return nil, fmt.Errorf("failed to list sellers: %w", err)
+	}
+
+	return sellers, nil
+}
+
+// UpdateSeller updates a seller's profile and commission rate
+func (r *sellerRepository) UpdateSeller(ctx context.Context, seller *models.Seller) error {
+	query := `
+		UPDATE sellers
+		SET store_name = :store_name, description = :description, contact_email = :contact_email,
+		    phone = :phone, tax_id = :tax_id, payout_reference = :payout_reference,
+		    commission_rate_bps = :commission_rate_bps
+		WHERE id = :id
+		RETURNING updated_at`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to prepare update seller statement", "error", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, seller).Scan(&seller.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.NotFound("seller not found")
+		}
+		if pgErr, ok := database.PgError(err); ok && pgErr.Code == database.UniqueViolation {
+			return duplicateSellerError(pgErr, seller)
+		}
+		r.logger.Error("Failed to update seller", "error", err, "id", seller.ID)
+		return fmt.Errorf("failed to update seller: %w", err)
+	}
+
+	return nil
+}
+
+// ReviewSeller saves a seller's status in the same transaction as the role
+// change, so an active seller always has the seller role. Admins keep their
+// role; sellers keep theirs while suspended and are refused by the service.
+func (r *sellerRepository) ReviewSeller(ctx context.Context, seller *models.Seller) error {
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		query := `
+			UPDATE sellers
+			SET status = $2, status_reason = $3, reviewed_by = $4, approved_at = $5
+			WHERE id = $1
+			RETURNING updated_at`
+
+		err := tx.QueryRowxContext(ctx, query, seller.ID, seller.Status, seller.StatusReason, seller.ReviewedBy,
+			seller.ApprovedAt).Scan(&seller.UpdatedAt)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperrors.NotFound("seller not found")
+			}
+			r.logger.Error("Failed to review seller", "error", err, "id", seller.ID)
+			return fmt.Errorf("failed to review seller: %w", err)
+		}
+
+		if seller.Status == models.SellerStatusActive {
+			_, err = tx.ExecContext(ctx, `UPDATE users SET role = 'seller' WHERE id = $1 AND role = 'customer'`, seller.UserID)
+			if err != nil {
+				r.logger.Error("Failed to grant seller role", "error", err, "user_id", seller.UserID)
+				return fmt.Errorf("failed to grant seller role: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// RegisterProduct assigns a product to a seller
+func (r *sellerRepository) RegisterProduct(ctx context.Context, product *models.SellerProduct) error {
+	query := `
+		INSERT INTO seller_products (product_id, seller_id, sku)
+		VALUES (:product_id, :seller_id, :sku)
+		RETURNING created_at`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to prepare register product statement", "error", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, product).Scan(&product.CreatedAt)
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return apperrors.Conflict("product already registered: %s", product.ProductID)
+		}
+		r.logger.Error("Failed to register product", "error", err, "product_id", product.ProductID)
+		return fmt.Errorf("failed to register product: %w", err)
+	}
+
+	return nil
+}
+
+// ListProducts retrieves the products of a seller, newest first
+func (r *sellerRepository) ListProducts(ctx context.Context, sellerID uuid.UUID) ([]*models.SellerProduct, error) {
+	products := []*models.SellerProduct{}
+	query := `
+		SELECT product_id, seller_id, sku, created_at
+		FROM seller_products
+		WHERE seller_id = $1
+		ORDER BY created_at DESC`
+
+	err := r.db.SelectContext(ctx, &products, query, sellerID)
+	if err != nil {
+		r.logger.Error("Failed to list seller products", "error", err, "seller_id", sellerID)
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+
+	return products, nil
+}
+
+// ListOrders retrieves the orders containing a seller's items, newest first
+func (r *sellerRepository) ListOrders(ctx context.Context, sellerID uuid.UUID, filter *models.SellerOrderFilter) ([]*models.SellerOrder, error) {
+	conditions := []string{`EXISTS (SELECT 1 FROM ` + sellerItems + ` WHERE oi.order_id = orders.id AND sp.seller_id = $1)`}
+	args := []interface{}{sellerID}
+
+	if len(filter.Statuses) > 0 {
+		args = append(args, filter.Statuses)
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+
+	if filter.Cursor != nil {
+		var condition string
+		condition, args = database.KeysetCondition(args, database.Descending, []string{"placed_at", "id"},
+			filter.Cursor.PlacedAt, filter.Cursor.ID)
+		conditions = append(conditions, condition)
+	}
+
+	args = append(args, filter.Limit)
+	query := `
+		SELECT id, order_number, status, currency, shipping_address, placed_at
+		FROM orders
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY placed_at DESC, id DESC
+		LIMIT $` + fmt.Sprint(len(args))
+
+	orders := []*models.SellerOrder{}
+	err := r.db.SelectContext(ctx, &orders, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to list seller orders", "error", err, "seller_id", sellerID)
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// GetOrder retrieves an order containing a seller's items
+func (r *sellerRepository) GetOrder(ctx context.Context, sellerID, orderID uuid.UUID) (*models.SellerOrder, error) {
+	order := &models.SellerOrder{}
+	query := `
+		SELECT id, order_number, status, currency, shipping_address, placed_at
+		FROM orders
+		WHERE id = $2
+		  AND EXISTS (SELECT 1 FROM ` + sellerItems + ` WHERE oi.order_id = orders.id AND sp.seller_id = $1)`
+
+	err := r.db.GetContext(ctx, order, query, sellerID, orderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("order not found")
+		}
+		r.logger.Error("Failed to get seller order", "error", err, "seller_id", sellerID, "order_id", orderID)
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	return order, nil
+}
+
+// GetOrderItems retrieves a seller's items of several orders, grouped by order
+func (r *sellerRepository) GetOrderItems(ctx context.Context, sellerID uuid.UUID, orderIDs []uuid.UUID) (map[uuid.UUID][]*models.SellerOrderItem, error) {
+	grouped := make(map[uuid.UUID][]*models.SellerOrderItem, len(orderIDs))
+	if len(orderIDs) == 0 {
+		return grouped, nil
+	}
+
+	items := []*models.SellerOrderItem{}
+	query := `
+		SELECT oi.id, oi.order_id, oi.product_id, oi.sku, oi.name, oi.quantity, oi.refunded_quantity,
+		       oi.unit_price, oi.total_price
+		FROM ` + sellerItems + `
+		WHERE sp.seller_id = $1 AND oi.order_id = ANY($2)
+		ORDER BY oi.created_at, oi.id`
+
+	err := r.db.SelectContext(ctx, &items, query, sellerID, orderIDs)
+	if err != nil {
+		r.logger.Error("Failed to get seller order items", "error", err, "seller_id", sellerID)
+		return nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+
+	for _, item := range items {
+		grouped[item.OrderID] = append(grouped[item.OrderID], item)
+	}
+
+	return grouped, nil
+}
+
+// UnsettledItems retrieves a seller's items of orders delivered and placed
+// before the period end that no statement settled yet. Fully refunded items
+// are left out.
+func (r *sellerRepository) UnsettledItems(ctx context.Context, sellerID uuid.UUID, periodEnd time.Time) ([]*models.UnsettledItem, error) {
+	items := []*models.UnsettledItem{}
+	query := `
+		SELECT oi.id AS order_item_id, oi.order_id, o.order_number, o.currency, oi.product_id, oi.sku,
+		       oi.quantity, oi.refunded_quantity, oi.unit_price
+		FROM ` + sellerItems + `
+		WHERE sp.seller_id = $1
+		  AND o.status = 'delivered'
+		  AND o.placed_at < $2
+		  AND oi.quantity > oi.refunded_quantity
+		  AND NOT EXISTS (SELECT 1 FROM seller_statement_lines l WHERE l.order_item_id = oi.id)
+		ORDER BY o.placed_at, oi.id`
+
+	err := r.db.SelectContext(ctx, &items, query, sellerID, periodEnd)
+	if err != nil {
+		r.logger.Error("Failed to get unsettled items", "error", err, "seller_id", sellerID)
+		return nil, fmt.Errorf("failed to get unsettled items: %w", err)
+	}
+
+	return items, nil
+}
+
+// SellersWithUnsettledItems retrieves the active sellers that have items to
+// settle up to the period end
+func (r *sellerRepository) SellersWithUnsettledItems(ctx context.Context, periodEnd time.Time) ([]uuid.UUID, error) {
+	ids := []uuid.UUID{}
+	query := `
+		SELECT DISTINCT sp.seller_id
+		FROM ` + sellerItems + `
+		JOIN sellers s ON s.id = sp.seller_id
+		WHERE s.status = 'active'
+		  AND o.status = 'delivered'
+		  AND o.placed_at < $1
+		  AND oi.quantity > oi.refunded_quantity
+		  AND NOT EXISTS (SELECT 1 FROM seller_statement_lines l WHERE l.order_item_id = oi.id)`
+
+	err := r.db.SelectContext(ctx, &ids, query, periodEnd)
+	if err != nil {
+		r.logger.Error("Failed to list sellers with unsettled items", "error", err)
+		return nil, fmt.Errorf("failed to list sellers: %w", err)
+	}
+
+	return ids, nil
+}
+
+// CreateStatement stores a statement with its lines in one transaction. An
+// order item settled concurrently by another statement fails the whole
+// statement.
+func (r *sellerRepository) CreateStatement(ctx context.Context, statement *models.Statement) error {
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		query := `
+			INSERT INTO seller_statements (id, seller_id, currency, period_end, gross_amount, commission_amount,
+			                               payout_amount, status)
+			VALUES (:id, :seller_id, :currency, :period_end, :gross_amount, :commission_amount,
+			        :payout_amount, :status)
+			RETURNING created_at, updated_at`
+
+		stmt, err := tx.PrepareNamedContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer stmt.Close()
+
+		if err := stmt.QueryRowxContext(ctx, statement).Scan(&statement.CreatedAt, &statement.UpdatedAt); err != nil {
+			r.logger.Error("Failed to create statement", "error", err, "seller_id", statement.SellerID)
+			return fmt.Errorf("failed to create statement: %w", err)
+		}
+
+		lineQuery := `
+			INSERT INTO seller_statement_lines (id, statement_id, order_item_id, order_id, order_number, product_id,
+			                                    sku, quantity, unit_price, gross_amount, commission_rate_bps,
+			                                    commission_amount, payout_amount)
+			VALUES (:id, :statement_id, :order_item_id, :order_id, :order_number, :product_id,
+			        :sku, :quantity, :unit_price, :gross_amount, :commission_rate_bps,
+			        :commission_amount, :payout_amount)
+			RETURNING created_at`
+
+		lineStmt, err := tx.PrepareNamedContext(ctx, lineQuery)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer lineStmt.Close()
+
+		for _, line := range statement.Lines {
+			if err := lineStmt.QueryRowxContext(ctx, line).Scan(&line.CreatedAt); err != nil {
+				if database.IsUniqueViolation(err) {
+					return apperrors.Conflict("order item already settled: %s", line.OrderItemID)
+				}
+				r.logger.Error("Failed to create statement line", "error", err, "order_item_id", line.OrderItemID)
+				return fmt.Errorf("failed to create statement line: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetStatement retrieves a statement with its lines
+func (r *sellerRepository) GetStatement(ctx context.Context, id uuid.UUID) (*models.Statement, error) {
+	statement := &models.Statement{}
+	query := `SELECT ` + statementColumns + ` FROM seller_statements WHERE id = $1`
+
+	err := r.db.GetContext(ctx, statement, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("statement not found")
+		}
+		r.logger.Error("Failed to get statement", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to get statement: %w", err)
+	}
+
+	statement.Lines = []*models.StatementLine{}
+	err = r.db.SelectContext(ctx, &statement.Lines, `
+		SELECT id, statement_id, order_item_id, order_id, order_number, product_id, sku, quantity, unit_price,
+		       gross_amount, commission_rate_bps, commission_amount, payout_amount, created_at
+		FROM seller_statement_lines
+		WHERE statement_id = $1
+		ORDER BY order_number, id`, id)
+	if err != nil {
+		r.logger.Error("Failed to get statement lines", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to get statement lines: %w", err)
+	}
+
+	return statement, nil
+}
+
+// ListStatements retrieves a seller's statements without their lines, newest first
+func (r *sellerRepository) ListStatements(ctx context.Context, sellerID uuid.UUID) ([]*models.Statement, error) {
+	statements := []*models.Statement{}
+	query := `
+		SELECT ` + statementColumns + `
+		FROM seller_statements
+		WHERE seller_id = $1
+		ORDER BY created_at DESC
+		LIMIT 500`
+
+	err := r.db.SelectContext(ctx, &statements, query, sellerID)
+	if err != nil {
+		r.logger.Error("Failed to list statements", "error", err, "seller_id", sellerID)
+		return nil, fmt.Errorf("failed to list statements: %w", err)
+	}
+
+	return statements, nil
+}
+
+// MarkStatementPaid records the payout of a pending statement
+func (r *sellerRepository) MarkStatementPaid(ctx context.Context, statement *models.Statement) error {
+	query := `
+		UPDATE seller_statements
+		SET status = 'paid', payout_reference = $2, paid_at = $3
+		WHERE id = $1 AND status = 'pending'
+		RETURNING updated_at`
+
+	err := r.db.QueryRowxContext(ctx, query, statement.ID, statement.PayoutReference, statement.PaidAt).
+		Scan(&statement.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.Conflict("statement already paid")
+		}
+		r.logger.Error("Failed to mark statement paid", "error", err, "id", statement.ID)
+		return fmt.Errorf("failed to mark statement paid: %w", err)
+	}
+
+	statement.Status = models.StatementStatusPaid
+	return nil
+}
+
+// duplicateSellerError tells which unique constraint a seller violated
+func duplicateSellerError(pgErr *pgconn.PgError, seller *models.Seller) error {
+	if strings.Contains(pgErr.ConstraintName, "store_name") {
+		return apperrors.Conflict("store name already taken: %s", seller.StoreName)
+	}
+	return apperrors.Conflict("seller account already exists")
+}
@@ -0,0 +1,667 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/seller/models"
+	"github.com/kaanevranportfolio/Commercium/internal/seller/repository"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/taskqueue"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// orderStatuses are the order statuses sellers can filter their orders by
+var orderStatuses = map[string]bool{
+	"pending": true, "confirmed": true, "processing": true, "shipped": true,
+	"delivered": true, "cancelled": true, "refunded": true,
+}
+
+//go:generate go run go.uber.org/mock/mockgen -source=seller_service.go -destination=../mocks/mock_seller_service.go -package=mocks
+
+// SellerService defines the interface for marketplace seller business logic
+type SellerService interface {
+	// Onboarding
+	Apply(ctx context.Context, userID uuid.UUID, req *models.ApplyRequest) (*models.Seller, error)
+	GetOwnSeller(ctx context.Context, userID uuid.UUID) (*models.Seller, error)
+	UpdateProfile(ctx context.Context, userID uuid.UUID, req *models.UpdateProfileRequest) (*models.Seller, error)
+
+	// Seller operations
+	RegisterProduct(ctx context.Context, userID uuid.UUID, req *models.RegisterProductRequest) (*models.SellerProduct, error)
+	ListProducts(ctx context.Context, userID uuid.UUID) ([]*models.SellerProduct, error)
+	ListOrders(ctx context.Context, userID uuid.UUID, req *models.ListSellerOrdersRequest) (*models.SellerOrderListResponse, error)
+	GetOrder(ctx context.Context, userID, orderID uuid.UUID) (*models.SellerOrder, error)
+	ListOwnStatements(ctx context.Context, userID uuid.UUID) ([]*models.Statement, error)
+	GetOwnStatement(ctx context.Context, userID, statementID uuid.UUID) (*models.Statement, error)
+
+	// Admin operations
+	ListSellers(ctx context.Context, req *models.ListSellersRequest) ([]*models.Seller, error)
+	GetSeller(ctx context.Context, sellerID uuid.UUID) (*models.Seller, error)
+	ReviewSeller(ctx context.Context, adminID, sellerID uuid.UUID, req *models.ReviewSellerRequest) (*models.Seller, error)
+	SetCommission(ctx context.Context, sellerID uuid.UUID, req *models.SetCommissionRequest) (*models.Seller, error)
+	ListStatements(ctx context.Context, sellerID uuid.UUID) ([]*models.Statement, error)
+	GetStatement(ctx context.Context, statementID uuid.UUID) (*models.Statement, error)
+	GenerateStatements(ctx context.Context, sellerID uuid.UUID, req *models.GenerateStatementsRequest) (*models.GenerateStatementsResponse, error)
+	GenerateAllStatements(ctx context.Context, req *models.GenerateStatementsRequest) (*models.GenerateStatementsResponse, error)
+	MarkStatementPaid(ctx context.Context, statementID uuid.UUID, req *models.MarkPaidRequest) (*models.Statement, error)
+}
+
+// sellerService implements the SellerService interface
+type sellerService struct {
+	repo   repository.SellerRepository
+	tasks  *taskqueue.Client
+	config *config.Config
+	logger *logger.Logger
+}
+
+// NewSellerService creates a new seller service. The statements of every
+// seller are generated in the background with tasks, or within the request
+// when tasks is nil.
+func NewSellerService(repo repository.SellerRepository, tasks *taskqueue.Client, config *config.Config, logger *logger.Logger) SellerService {
+	return &sellerService{
+		repo:   repo,
+		tasks:  tasks,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Apply submits a user's application to sell on the marketplace. The
+// application waits for an admin to review it.
+func (s *sellerService) Apply(ctx context.Context, userID uuid.UUID, req *models.ApplyRequest) (*models.Seller, error) {
+	seller := &models.Seller{
+		ID:              uuid.New(),
+		UserID:          userID,
+		StoreName:       strings.TrimSpace(req.StoreName),
+		Description:     req.Description,
+		ContactEmail:    strings.ToLower(strings.TrimSpace(req.ContactEmail)),
+		Phone:           req.Phone,
+		Country:         strings.ToUpper(req.Country),
+		TaxID:           req.TaxID,
+		PayoutReference: req.PayoutReference,
+		Status:          models.SellerStatusPending,
+	}
+	if seller.StoreName == "" {
+		return nil, apperrors.Validation("invalid store name: must not be blank")
+	}
+
+	if err := s.repo.CreateSeller(ctx, seller); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Seller application submitted", "seller_id", seller.ID, "user_id", userID)
+	return seller, nil
+}
+
+// GetOwnSeller returns the user's seller account
+func (s *sellerService) GetOwnSeller(ctx context.Context, userID uuid.UUID) (*models.Seller, error) {
+	return s.repo.GetSellerByUserID(ctx, userID)
+}
+
+// UpdateProfile changes the user's store profile and payout details
+func (s *sellerService) UpdateProfile(ctx context.Context, userID uuid.UUID, req *models.UpdateProfileRequest) (*models.Seller, error) {
+	seller, err := s.repo.GetSellerByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if seller.Status == models.SellerStatusRejected || seller.Status == models.SellerStatusSuspended {
+		return nil, apperrors.Conflict("seller profile cannot be changed while %s", seller.Status)
+	}
+
+	if req.StoreName != nil {
+		name := strings.TrimSpace(*req.StoreName)
+		if name == "" {
+			return nil, apperrors.Validation("invalid store name: must not be blank")
+		}
+		seller.StoreName = name
+	}
+	if req.Description != nil {
+		seller.Description = optionalString(strings.TrimSpace(*req.Description))
+	}
+	if req.ContactEmail != nil {
+		seller.ContactEmail = strings.ToLower(strings.TrimSpace(*req.ContactEmail))
+	}
+	if req.Phone != nil {
+		seller.Phone = optionalString(*req.Phone)
+	}
+	if req.TaxID != nil {
+		seller.TaxID = optionalString(*req.TaxID)
+	}
+	if req.PayoutReference != nil {
+		seller.PayoutReference = optionalString(*req.PayoutReference)
+	}
+
+	if err := s.repo.UpdateSeller(ctx, seller); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Seller profile updated", "seller_id", seller.ID)
+	return seller, nil
+}
+
+// RegisterProduct registers a product as sold by the user's store. Sales of
+// the product are attributed to the seller from now on; a product belongs
+// to one seller only.
+func (s *sellerService) RegisterProduct(ctx context.Context, userID uuid.UUID, req *models.RegisterProductRequest) (*models.SellerProduct, error) {
+	seller, err := s.getActiveSeller(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	product := &models.SellerProduct{
+		ProductID: req.ProductID,
+		SellerID:  seller.ID,
+		SKU:       strings.TrimSpace(req.SKU),
+	}
+	if err := s.repo.RegisterProduct(ctx, product); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Seller product registered", "seller_id", seller.ID, "product_id", product.ProductID)
+	return product, nil
+}
+
+// ListProducts returns the products of the user's store
+func (s *sellerService) ListProducts(ctx context.Context, userID uuid.UUID) ([]*models.SellerProduct, error) {
+	seller, err := s.repo.GetSellerByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListProducts(ctx, seller.ID)
+}
+
+// ListOrders returns a page of the orders containing the seller's products.
+// Each order only shows the seller's own items.
+func (s *sellerService) ListOrders(ctx context.Context, userID uuid.UUID, req *models.ListSellerOrdersRequest) (*models.SellerOrderListResponse, error) {
+	seller, err := s.repo.GetSellerByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := buildOrderFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra row to find out whether another page exists
+	pageSize := filter.Limit
+	filter.Limit = pageSize + 1
+
+	orders, err := s.repo.ListOrders(ctx, seller.ID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(orders) > pageSize
+	if hasMore {
+		orders = orders[:pageSize]
+	}
+
+	orderIDs := make([]uuid.UUID, len(orders))
+	for i, order := range orders {
+		orderIDs[i] = order.ID
+	}
+
+	items, err := s.repo.GetOrderItems(ctx, seller.ID, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	response := httpx.NewPage(orders, "", pageSize)
+
+	for _, order := range orders {
+		setItems(order, items[order.ID])
+		// Addresses are only shown in the order detail
+		order.ShippingAddress = nil
+	}
+
+	if hasMore {
+		last := orders[len(orders)-1]
+		response.Pagination.NextCursor, err = database.EncodeCursor(&models.OrderCursor{PlacedAt: last.PlacedAt, ID: last.ID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode cursor: %w", err)
+		}
+	}
+
+	return response, nil
+}
+
+// GetOrder returns an order containing the seller's products, with the
+// shipping address the seller ships the items to
+func (s *sellerService) GetOrder(ctx context.Context, userID, orderID uuid.UUID) (*models.SellerOrder, error) {
+	seller, err := s.repo.GetSellerByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.repo.GetOrder(ctx, seller.ID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.GetOrderItems(ctx, seller.ID, []uuid.UUID{order.ID})
+	if err != nil {
+		return nil, err
+	}
+	setItems(order, items[order.ID])
+
+	return order, nil
+}
+
+// ListOwnStatements returns the user's payout statements
+func (s *sellerService) ListOwnStatements(ctx context.Context, userID uuid.UUID) ([]*models.Statement, error) {
+	seller, err := s.repo.GetSellerByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListStatements(ctx, seller.ID)
+}
+
+// GetOwnStatement returns one of the user's payout statements with its lines
+func (s *sellerService) GetOwnStatement(ctx context.Context, userID, statementID uuid.UUID) (*models.Statement, error) {
+	seller, err := s.repo.GetSellerByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	statement, err := s.repo.GetStatement(ctx, statementID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't reveal the existence of other sellers' statements
+	if statement.SellerID != seller.ID {
+		return nil, apperrors.NotFound("statement not found")
+	}
+
+	return statement, nil
+}
+
+// ListSellers returns sellers by status, pending applications by default
+func (s *sellerService) ListSellers(ctx context.Context, req *models.ListSellersRequest) ([]*models.Seller, error) {
+	status := models.SellerStatusPending
+	if req.Status != "" {
+		status = models.SellerStatus(req.Status)
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	return s.repo.ListSellers(ctx, status, limit)
+}
+
+// GetSeller returns a seller account
+func (s *sellerService) GetSeller(ctx context.Context, sellerID uuid.UUID) (*models.Seller, error) {
+	return s.repo.GetSeller(ctx, sellerID)
+}
+
+// ReviewSeller approves, rejects or suspends a seller. Approval gives the
+// user the seller role and also reinstates a suspended seller; only pending
+// applications can be rejected, and only active sellers suspended.
+func (s *sellerService) ReviewSeller(ctx context.Context, adminID, sellerID uuid.UUID, req *models.ReviewSellerRequest) (*models.Seller, error) {
+	seller, err := s.repo.GetSeller(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var status models.SellerStatus
+	switch req.Decision {
+	case "approve":
+		status = models.SellerStatusActive
+	case "reject":
+		status = models.SellerStatusRejected
+		if seller.Status != models.SellerStatusPending && seller.Status != models.SellerStatusRejected {
+			return nil, apperrors.Conflict("seller cannot be rejected while %s", seller.Status)
+		}
+	case "suspend":
+		status = models.SellerStatusSuspended
+		if seller.Status != models.SellerStatusActive && seller.Status != models.SellerStatusSuspended {
+			return nil, apperrors.Conflict("seller cannot be suspended while %s", seller.Status)
+		}
+	}
+
+	if seller.Status == status {
+		return nil, apperrors.Conflict("seller is already %s", status)
+	}
+
+	seller.Status = status
+	seller.StatusReason = optionalString(strings.TrimSpace(req.Reason))
+	seller.ReviewedBy = &adminID
+	if status == models.SellerStatusActive && seller.ApprovedAt == nil {
+		now := time.Now()
+		seller.ApprovedAt = &now
+	}
+
+	if err := s.repo.ReviewSeller(ctx, seller); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Seller reviewed", "seller_id", seller.ID, "status", status, "admin_id", adminID)
+	return seller, nil
+}
+
+// SetCommission sets a seller's own commission rate, or clears it so the
+// default rate applies. The rate applies to sales settled from now on.
+func (s *sellerService) SetCommission(ctx context.Context, sellerID uuid.UUID, req *models.SetCommissionRequest) (*models.Seller, error) {
+	seller, err := s.repo.GetSeller(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	seller.CommissionRateBps = req.CommissionRateBps
+	if err := s.repo.UpdateSeller(ctx, seller); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Seller commission rate set", "seller_id", seller.ID, "commission_rate_bps", seller.CommissionRateBps)
+	return seller, nil
+}
+
+// ListStatements returns a seller's payout statements
+func (s *sellerService) ListStatements(ctx context.Context, sellerID uuid.UUID) ([]*models.Statement, error) {
+	if _, err := s.repo.GetSeller(ctx, sellerID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListStatements(ctx, sellerID)
+}
+
+// GetStatement returns a payout statement with its lines
+func (s *sellerService) GetStatement(ctx context.Context, statementID uuid.UUID) (*models.Statement, error) {
+	return s.repo.GetStatement(ctx, statementID)
+}
+
+// GenerateStatements settles a seller's unsettled sales up to the period end
+// into one statement per currency
+func (s *sellerService) GenerateStatements(ctx context.Context, sellerID uuid.UUID, req *models.GenerateStatementsRequest) (*models.GenerateStatementsResponse, error) {
+	periodEnd, err := statementPeriodEnd(req)
+	if err != nil {
+		return nil, err
+	}
+
+	seller, err := s.repo.GetSeller(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	statements, err := s.settle(ctx, seller, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.GenerateStatementsResponse{Created: len(statements), Statements: statements}, nil
+}
+
+// GenerateAllStatements settles the unsettled sales of every active seller
+// up to the period end. A seller whose statements fail is logged and
+// skipped, so one seller doesn't hold up the payouts of the others. With a
+// task queue, each seller's statements are generated by a task of their
+// own, retried while it fails, and the sellers queued are reported instead.
+func (s *sellerService) GenerateAllStatements(ctx context.Context, req *models.GenerateStatementsRequest) (*models.GenerateStatementsResponse, error) {
+	periodEnd, err := statementPeriodEnd(req)
+	if err != nil {
+		return nil, err
+	}
+
+	sellerIDs, err := s.repo.SellersWithUnsettledItems(ctx, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.GenerateStatementsResponse{Statements: []*models.Statement{}}
+	if s.tasks != nil {
+		response.Queued = s.queueStatements(ctx, sellerIDs, periodEnd)
+		return response, nil
+	}
+
+	for _, sellerID := range sellerIDs {
+		seller, err := s.repo.GetSeller(ctx, sellerID)
+		if err != nil {
+			s.logger.Error("Failed to generate seller statements", "error", err, "seller_id", sellerID)
+			continue
+		}
+
+		statements, err := s.settle(ctx, seller, periodEnd)
+		if err != nil {
+			s.logger.Error("Failed to generate seller statements", "error", err, "seller_id", sellerID)
+			continue
+		}
+		response.Statements = append(response.Statements, statements...)
+	}
+
+	response.Created = len(response.Statements)
+	return response, nil
+}
+
+// queueStatements enqueues the tasks generating the statements of sellers
+// up to periodEnd and returns how many were queued. A seller's task is
+// queued once for a period while it waits to run.
+func (s *sellerService) queueStatements(ctx context.Context, sellerIDs []uuid.UUID, periodEnd time.Time) int {
+	queued := 0
+	for _, sellerID := range sellerIDs {
+		task := models.GenerateStatementsTask{SellerID: sellerID, PeriodEnd: periodEnd}
+		id := taskqueue.ID(fmt.Sprintf("statements:%s:%d", sellerID, periodEnd.Unix()))
+		err := generateStatementsTask.Enqueue(ctx, s.tasks, task, id)
+		if err != nil && !errors.Is(err, taskqueue.ErrDuplicate) {
+			s.logger.Error("Failed to queue seller statements", "error", err, "seller_id", sellerID)
+			continue
+		}
+		queued++
+	}
+
+	s.logger.Info("Seller statements queued", "count", queued, "period_end", periodEnd)
+	return queued
+}
+
+// MarkStatementPaid records that a statement was paid out
+func (s *sellerService) MarkStatementPaid(ctx context.Context, statementID uuid.UUID, req *models.MarkPaidRequest) (*models.Statement, error) {
+	statement, err := s.repo.GetStatement(ctx, statementID)
+	if err != nil {
+		return nil, err
+	}
+
+	if statement.Status == models.StatementStatusPaid {
+		return nil, apperrors.Conflict("statement already paid")
+	}
+
+	now := time.Now()
+	reference := strings.TrimSpace(req.PayoutReference)
+	statement.PayoutReference = &reference
+	statement.PaidAt = &now
+
+	if err := s.repo.MarkStatementPaid(ctx, statement); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Seller statement paid", "statement_id", statement.ID, "seller_id", statement.SellerID,
+		"payout_amount", statement.PayoutAmount, "currency", statement.Currency)
+	return statement, nil
+}
+
+// settle creates the statements of a seller's unsettled sales, one per
+// currency. Commission is charged at the seller's current rate on the
+// unrefunded quantity of each item.
+func (s *sellerService) settle(ctx context.Context, seller *models.Seller, periodEnd time.Time) ([]*models.Statement, error) {
+	items, err := s.repo.UnsettledItems(ctx, seller.ID, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	rate := s.commissionRate(seller)
+	byCurrency := make(map[string]*models.Statement)
+	statements := []*models.Statement{}
+	for _, item := range items {
+		statement, ok := byCurrency[item.Currency]
+		if !ok {
+			statement = &models.Statement{
+				ID:        uuid.New(),
+				SellerID:  seller.ID,
+				Currency:  item.Currency,
+				PeriodEnd: periodEnd,
+				Status:    models.StatementStatusPending,
+			}
+			byCurrency[item.Currency] = statement
+			statements = append(statements, statement)
+		}
+
+		quantity := item.Quantity - item.RefundedQuantity
+		gross := item.UnitPrice * int64(quantity)
+		commission := commissionAmount(gross, rate)
+		statement.Lines = append(statement.Lines, &models.StatementLine{
+			ID:                uuid.New(),
+			StatementID:       statement.ID,
+			OrderItemID:       item.OrderItemID,
+			OrderID:           item.OrderID,
+			OrderNumber:       item.OrderNumber,
+			ProductID:         item.ProductID,
+			SKU:               item.SKU,
+			Quantity:          quantity,
+			UnitPrice:         item.UnitPrice,
+			GrossAmount:       gross,
+			CommissionRateBps: rate,
+			CommissionAmount:  commission,
+			PayoutAmount:      gross - commission,
+		})
+		statement.GrossAmount += gross
+		statement.CommissionAmount += commission
+		statement.PayoutAmount += gross - commission
+	}
+
+	for _, statement := range statements {
+		if err := s.repo.CreateStatement(ctx, statement); err != nil {
+			return nil, err
+		}
+
+		s.logger.Info("Seller statement generated", "statement_id", statement.ID, "seller_id", seller.ID,
+			"currency", statement.Currency, "payout_amount", statement.PayoutAmount, "lines", len(statement.Lines))
+	}
+
+	return statements, nil
+}
+
+// commissionRate returns the seller's commission rate in basis points
+func (s *sellerService) commissionRate(seller *models.Seller) int {
+	if seller.CommissionRateBps != nil {
+		return *seller.CommissionRateBps
+	}
+	return s.config.Services.Seller.DefaultCommissionBps
+}
+
+// getActiveSeller returns the user's seller account if it may sell
+func (s *sellerService) getActiveSeller(ctx context.Context, userID uuid.UUID) (*models.Seller, error) {
+	seller, err := s.repo.GetSellerByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if seller.Status != models.SellerStatusActive {
+		return nil, apperrors.Conflict("seller account cannot be used while %s", seller.Status)
+	}
+
+	return seller, nil
+}
+
+// commissionAmount returns the commission on an amount at a rate in basis
+// points, rounded half up to the minor unit
+func commissionAmount(amount int64, rateBps int) int64 {
+	return (amount*int64(rateBps) + 5000) / 10000
+}
+
+// setItems attaches the seller's items to an order and totals them
+func setItems(order *models.SellerOrder, items []*models.SellerOrderItem) {
+	order.Items = items
+	if order.Items == nil {
+		order.Items = []*models.SellerOrderItem{}
+	}
+
+	order.Subtotal = 0
+	for _, item := range order.Items {
+		order.Subtotal += item.TotalPrice
+	}
+}
+
+// statementPeriodEnd returns the requested period end, defaulting to now
+func statementPeriodEnd(req *models.GenerateStatementsRequest) (time.Time, error) {
+	now := time.Now()
+	if req.PeriodEnd == nil {
+		return now, nil
+	}
+	if req.PeriodEnd.After(now) {
+		return time.Time{}, apperrors.Validation("invalid period end: must not be in the future")
+	}
+	return *req.PeriodEnd, nil
+}
+
+// buildOrderFilter validates the order list request and converts it to a repository filter
+func buildOrderFilter(req *models.ListSellerOrdersRequest) (*models.SellerOrderFilter, error) {
+	filter := &models.SellerOrderFilter{Limit: req.Limit}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+
+	if req.Status != "" {
+		for _, raw := range strings.Split(req.Status, ",") {
+			status := strings.TrimSpace(raw)
+			if !orderStatuses[status] {
+				return nil, apperrors.Validation("invalid status filter: %s", raw)
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	if req.Cursor != "" {
+		cursor, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return nil, apperrors.Validation("invalid cursor")
+		}
+		filter.Cursor = cursor
+	}
+
+	return filter, nil
+}
+
+// decodeCursor parses a token produced by database.EncodeCursor
+func decodeCursor(token string) (*models.OrderCursor, error) {
+	cursor, err := database.DecodeCursor[*models.OrderCursor](token)
+	if err != nil {
+		return nil, err
+	}
+	if cursor == nil || cursor.ID == uuid.Nil || cursor.PlacedAt.IsZero() {
+		return nil, fmt.Errorf("incomplete cursor")
+	}
+
+	return cursor, nil
+}
+
+// optionalString returns nil for empty strings, for nullable columns
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
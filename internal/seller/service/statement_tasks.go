@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/internal/seller/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/taskqueue"
+)
+
+// generateStatementsTask generates a seller's statements in the background
+var generateStatementsTask = taskqueue.NewTask[models.GenerateStatementsTask]("seller:generate_statements",
+	taskqueue.Timeout(10*time.Minute))
+
+// HandleTasks runs the background tasks of the seller service on server
+func HandleTasks(server *taskqueue.Server, sellerService SellerService) {
+	taskqueue.Handle(server, generateStatementsTask, func(ctx context.Context, task models.GenerateStatementsTask) error {
+		_, err := sellerService.GenerateStatements(ctx, task.SellerID, &models.GenerateStatementsRequest{PeriodEnd: &task.PeriodEnd})
+		// A seller that is gone won't come back on retry
+		if err != nil && errors.Is(err, apperrors.ErrNotFound) {
+			return taskqueue.Permanent(err)
+		}
+		return err
+	})
+}
@@ -0,0 +1,143 @@
+package carriers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+)
+
+// RateRequest holds the data needed to price a parcel
+type RateRequest struct {
+	From   models.Address
+	To     models.Address
+	Parcel models.Parcel
+}
+
+// Rate is a price offered by a carrier for one of its services.
+// Amounts are in minor currency units.
+type Rate struct {
+	Carrier       string
+	Service       string
+	Amount        int64
+	Currency      string
+	EstimatedDays int
+}
+
+// LabelRequest holds the data needed to buy a shipping label
+type LabelRequest struct {
+	From    models.Address
+	To      models.Address
+	Parcel  models.Parcel
+	Service string
+	// Reference is printed on the label, e.g. the order number
+	Reference      string
+	IdempotencyKey string
+}
+
+// Label is a purchased shipping label
+type Label struct {
+	TrackingNumber string
+	LabelURL       string
+	// ShipmentID is the carrier's identifier of the shipment
+	ShipmentID string
+	Amount     int64
+	Currency   string
+}
+
+// Carrier abstracts a shipping carrier or aggregator
+type Carrier interface {
+	Name() string
+	Rates(ctx context.Context, req *RateRequest) ([]*Rate, error)
+	PurchaseLabel(ctx context.Context, req *LabelRequest) (*Label, error)
+}
+
+// TrackRequest identifies a shipment to look up with its carrier
+type TrackRequest struct {
+	TrackingNumber string
+	Service        string
+	// ShipmentID is the carrier's identifier of the shipment
+	ShipmentID string
+}
+
+// TrackingEvent is a scan or status change reported by a carrier
+type TrackingEvent struct {
+	Status      models.ShipmentStatus
+	Description string
+	Location    string
+	OccurredAt  time.Time
+}
+
+// TrackingUpdate is the current status and full tracking history of a shipment
+type TrackingUpdate struct {
+	TrackingNumber string
+	Status         models.ShipmentStatus
+	Events         []*TrackingEvent
+}
+
+// Tracker is implemented by carriers whose tracking status can be polled
+type Tracker interface {
+	Track(ctx context.Context, req *TrackRequest) (*TrackingUpdate, error)
+}
+
+// WebhookParser is implemented by carriers that push tracking updates.
+// ParseTrackingWebhook must verify the request's authenticity before parsing
+// it, and returns a nil update for events that are not about tracking.
+type WebhookParser interface {
+	ParseTrackingWebhook(ctx context.Context, header http.Header, body []byte) (*TrackingUpdate, error)
+}
+
+// CarrierError is returned when a carrier rejects a request
+type CarrierError struct {
+	Carrier string
+	Code    string
+	Message string
+}
+
+func (e *CarrierError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s: %s (%s)", e.Carrier, e.Message, e.Code)
+	}
+	return fmt.Sprintf("%s: %s", e.Carrier, e.Message)
+}
+
+// Registry holds the configured carriers by name
+type Registry struct {
+	carriers map[string]Carrier
+}
+
+// NewRegistry creates a new carrier registry
+func NewRegistry() *Registry {
+	return &Registry{
+		carriers: make(map[string]Carrier),
+	}
+}
+
+// Register adds a carrier to the registry
+func (r *Registry) Register(carrier Carrier) {
+	r.carriers[carrier.Name()] = carrier
+}
+
+// Get returns the named carrier
+func (r *Registry) Get(name string) (Carrier, error) {
+	carrier, ok := r.carriers[name]
+	if !ok {
+		return nil, apperrors.Validation("shipping carrier not supported: %s", name)
+	}
+
+	return carrier, nil
+}
+
+// All returns the registered carriers sorted by name
+func (r *Registry) All() []Carrier {
+	all := make([]Carrier, 0, len(r.carriers))
+	for _, carrier := range r.carriers {
+		all = append(all, carrier)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name() < all[j].Name() })
+	return all
+}
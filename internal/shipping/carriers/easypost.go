@@ -0,0 +1,454 @@
+package carriers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/resilience"
+)
+
+const (
+	easyPostCarrierName = "easypost"
+
+	easyPostSignatureHeader = "X-Hmac-Signature"
+	easyPostSignaturePrefix = "hmac-sha256-hex="
+
+	gramsPerOunce = 28.349523125
+	cmPerInch     = 2.54
+)
+
+// easyPostCarrier implements Carrier, Tracker and WebhookParser against the
+// EasyPost API, which quotes and buys labels from the underlying carriers
+// (USPS, UPS, FedEx, ...). Services are named "<carrier>:<service>", e.g.
+// "USPS:Priority".
+type easyPostCarrier struct {
+	apiURL        string
+	apiKey        string
+	webhookSecret string
+	httpClient    *http.Client
+}
+
+// easyPostAddress is the EasyPost address object
+type easyPostAddress struct {
+	Name    string `json:"name,omitempty"`
+	Company string `json:"company,omitempty"`
+	Street1 string `json:"street1"`
+	Street2 string `json:"street2,omitempty"`
+	City    string `json:"city"`
+	State   string `json:"state,omitempty"`
+	Zip     string `json:"zip"`
+	Country string `json:"country"`
+	Phone   string `json:"phone,omitempty"`
+}
+
+// easyPostParcel is the EasyPost parcel object; weight in ounces, dimensions in inches
+type easyPostParcel struct {
+	Weight float64 `json:"weight"`
+	Length float64 `json:"length,omitempty"`
+	Width  float64 `json:"width,omitempty"`
+	Height float64 `json:"height,omitempty"`
+}
+
+// easyPostRate is the subset of the Rate object we use
+type easyPostRate struct {
+	ID           string `json:"id"`
+	Carrier      string `json:"carrier"`
+	Service      string `json:"service"`
+	Rate         string `json:"rate"`
+	Currency     string `json:"currency"`
+	DeliveryDays *int   `json:"delivery_days"`
+}
+
+// easyPostShipment is the subset of the Shipment object we use
+type easyPostShipment struct {
+	ID           string          `json:"id"`
+	TrackingCode string          `json:"tracking_code"`
+	Rates        []*easyPostRate `json:"rates"`
+	SelectedRate *easyPostRate   `json:"selected_rate"`
+	PostageLabel *struct {
+		LabelURL string `json:"label_url"`
+	} `json:"postage_label"`
+}
+
+// easyPostTracker is the subset of the Tracker object we use
+type easyPostTracker struct {
+	TrackingCode    string `json:"tracking_code"`
+	Status          string `json:"status"`
+	TrackingDetails []struct {
+		Message          string    `json:"message"`
+		Status           string    `json:"status"`
+		Datetime         time.Time `json:"datetime"`
+		TrackingLocation *struct {
+			City    string `json:"city"`
+			State   string `json:"state"`
+			Country string `json:"country"`
+		} `json:"tracking_location"`
+	} `json:"tracking_details"`
+}
+
+// easyPostEvent is the webhook envelope sent by EasyPost
+type easyPostEvent struct {
+	ID          string          `json:"id"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+// easyPostErrorResponse is the error envelope returned by the EasyPost API
+type easyPostErrorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewEasyPostCarrier creates a new EasyPost carrier, calling EasyPost through
+// dependency
+func NewEasyPostCarrier(cfg config.EasyPostConfig, dependency *resilience.Dependency) (Carrier, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("easypost api key is required")
+	}
+
+	return &easyPostCarrier{
+		apiURL:        strings.TrimRight(cfg.APIURL, "/"),
+		apiKey:        cfg.APIKey,
+		webhookSecret: cfg.WebhookSecret,
+		httpClient:    dependency.HTTPClient(nil),
+	}, nil
+}
+
+// Name returns the carrier name
+func (c *easyPostCarrier) Name() string {
+	return easyPostCarrierName
+}
+
+// Rates creates an EasyPost shipment and returns the rates quoted for it
+func (c *easyPostCarrier) Rates(ctx context.Context, req *RateRequest) ([]*Rate, error) {
+	shipment, err := c.createShipment(ctx, req.From, req.To, req.Parcel, "")
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make([]*Rate, 0, len(shipment.Rates))
+	for _, raw := range shipment.Rates {
+		rate, err := c.convertRate(raw)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+// PurchaseLabel creates a shipment and buys the rate of the requested service.
+// Rates change over time, so the label is bought from a fresh quote.
+func (c *easyPostCarrier) PurchaseLabel(ctx context.Context, req *LabelRequest) (*Label, error) {
+	shipment, err := c.createShipment(ctx, req.From, req.To, req.Parcel, req.Reference)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected *easyPostRate
+	for _, rate := range shipment.Rates {
+		if rate.Carrier+":"+rate.Service == req.Service {
+			selected = rate
+			break
+		}
+	}
+	if selected == nil {
+		return nil, apperrors.Validation("shipping service not supported: %s is not available for this parcel", req.Service)
+	}
+
+	body := map[string]interface{}{"rate": map[string]string{"id": selected.ID}}
+	bought := &easyPostShipment{}
+	if err := c.post(ctx, "/v2/shipments/"+url.PathEscape(shipment.ID)+"/buy", body, bought); err != nil {
+		return nil, err
+	}
+
+	if bought.SelectedRate == nil {
+		bought.SelectedRate = selected
+	}
+	rate, err := c.convertRate(bought.SelectedRate)
+	if err != nil {
+		return nil, err
+	}
+
+	label := &Label{
+		TrackingNumber: bought.TrackingCode,
+		ShipmentID:     bought.ID,
+		Amount:         rate.Amount,
+		Currency:       rate.Currency,
+	}
+	if bought.PostageLabel != nil {
+		label.LabelURL = bought.PostageLabel.LabelURL
+	}
+
+	return label, nil
+}
+
+// Track returns the tracking history of a shipment. EasyPost returns the
+// existing tracker when one was already created for the tracking code.
+func (c *easyPostCarrier) Track(ctx context.Context, req *TrackRequest) (*TrackingUpdate, error) {
+	underlying, _, _ := strings.Cut(req.Service, ":")
+	body := map[string]interface{}{
+		"tracker": map[string]string{
+			"tracking_code": req.TrackingNumber,
+			"carrier":       underlying,
+		},
+	}
+
+	tracker := &easyPostTracker{}
+	if err := c.post(ctx, "/v2/trackers", body, tracker); err != nil {
+		return nil, err
+	}
+
+	return convertTracker(tracker), nil
+}
+
+// ParseTrackingWebhook verifies and parses an EasyPost event. Only
+// tracker.updated events carry tracking updates.
+func (c *easyPostCarrier) ParseTrackingWebhook(ctx context.Context, header http.Header, body []byte) (*TrackingUpdate, error) {
+	if c.webhookSecret == "" {
+		return nil, fmt.Errorf("easypost webhook secret is not configured")
+	}
+
+	if err := c.verifySignature(header.Get(easyPostSignatureHeader), body); err != nil {
+		return nil, err
+	}
+
+	event := &easyPostEvent{}
+	if err := json.Unmarshal(body, event); err != nil {
+		return nil, apperrors.Validation("invalid webhook payload").Wrap(err)
+	}
+
+	if event.Description != "tracker.updated" {
+		return nil, nil
+	}
+
+	tracker := &easyPostTracker{}
+	if err := json.Unmarshal(event.Result, tracker); err != nil {
+		return nil, apperrors.Validation("invalid webhook payload").Wrap(err)
+	}
+
+	return convertTracker(tracker), nil
+}
+
+// verifySignature checks an X-Hmac-Signature header of the form hmac-sha256-hex=<hex hmac of the body>
+func (c *easyPostCarrier) verifySignature(signatureHeader string, body []byte) error {
+	signature, ok := strings.CutPrefix(signatureHeader, easyPostSignaturePrefix)
+	if !ok {
+		return apperrors.Validation("invalid webhook signature: malformed header")
+	}
+
+	decoded, err := hex.DecodeString(signature)
+	if err != nil {
+		return apperrors.Validation("invalid webhook signature: malformed header")
+	}
+
+	mac := hmac.New(sha256.New, []byte(c.webhookSecret))
+	mac.Write(body)
+	if !hmac.Equal(decoded, mac.Sum(nil)) {
+		return apperrors.Validation("invalid webhook signature: signature mismatch")
+	}
+
+	return nil
+}
+
+// createShipment creates an EasyPost shipment, which quotes all available rates
+func (c *easyPostCarrier) createShipment(ctx context.Context, from, to models.Address, parcel models.Parcel, reference string) (*easyPostShipment, error) {
+	body := map[string]interface{}{
+		"shipment": map[string]interface{}{
+			"from_address": toEasyPostAddress(from),
+			"to_address":   toEasyPostAddress(to),
+			"parcel": easyPostParcel{
+				Weight: roundUp(float64(parcel.WeightGrams)/gramsPerOunce, 1),
+				Length: roundUp(parcel.LengthCm/cmPerInch, 1),
+				Width:  roundUp(parcel.WidthCm/cmPerInch, 1),
+				Height: roundUp(parcel.HeightCm/cmPerInch, 1),
+			},
+			"reference": reference,
+		},
+	}
+
+	shipment := &easyPostShipment{}
+	if err := c.post(ctx, "/v2/shipments", body, shipment); err != nil {
+		return nil, err
+	}
+
+	return shipment, nil
+}
+
+// convertRate converts an EasyPost rate to a carrier-neutral rate
+func (c *easyPostCarrier) convertRate(raw *easyPostRate) (*Rate, error) {
+	amount, err := parseDecimalAmount(raw.Rate)
+	if err != nil {
+		return nil, err
+	}
+
+	rate := &Rate{
+		Carrier:  easyPostCarrierName,
+		Service:  raw.Carrier + ":" + raw.Service,
+		Amount:   amount,
+		Currency: strings.ToUpper(raw.Currency),
+	}
+	if raw.DeliveryDays != nil {
+		rate.EstimatedDays = *raw.DeliveryDays
+	}
+
+	return rate, nil
+}
+
+// post sends a JSON request to the EasyPost API and decodes the response into out
+func (c *easyPostCarrier) post(ctx context.Context, path string, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode easypost request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create easypost request: %w", err)
+	}
+	req.SetBasicAuth(c.apiKey, "")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call easypost: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		errResp := &easyPostErrorResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(errResp); err != nil {
+			return fmt.Errorf("easypost returned status %d", resp.StatusCode)
+		}
+		return &CarrierError{
+			Carrier: easyPostCarrierName,
+			Code:    errResp.Error.Code,
+			Message: errResp.Error.Message,
+		}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode easypost response: %w", err)
+	}
+
+	return nil
+}
+
+// convertTracker converts an EasyPost tracker to a carrier-neutral update.
+// Details with statuses we don't track are left out.
+func convertTracker(tracker *easyPostTracker) *TrackingUpdate {
+	update := &TrackingUpdate{
+		TrackingNumber: tracker.TrackingCode,
+		Status:         easyPostTrackingStatus(tracker.Status),
+		Events:         make([]*TrackingEvent, 0, len(tracker.TrackingDetails)),
+	}
+
+	for _, detail := range tracker.TrackingDetails {
+		status := easyPostTrackingStatus(detail.Status)
+		if status == "" {
+			continue
+		}
+
+		event := &TrackingEvent{
+			Status:      status,
+			Description: detail.Message,
+			OccurredAt:  detail.Datetime,
+		}
+		if loc := detail.TrackingLocation; loc != nil {
+			parts := []string{}
+			for _, part := range []string{loc.City, loc.State, loc.Country} {
+				if part != "" {
+					parts = append(parts, part)
+				}
+			}
+			event.Location = strings.Join(parts, ", ")
+		}
+		update.Events = append(update.Events, event)
+	}
+
+	return update
+}
+
+// easyPostTrackingStatus maps an EasyPost tracking status to a shipment
+// status, or returns an empty status for unknown ones
+func easyPostTrackingStatus(status string) models.ShipmentStatus {
+	switch status {
+	case "pre_transit":
+		return models.ShipmentStatusLabelPurchased
+	case "in_transit", "available_for_pickup":
+		return models.ShipmentStatusInTransit
+	case "out_for_delivery":
+		return models.ShipmentStatusOutForDelivery
+	case "delivered":
+		return models.ShipmentStatusDelivered
+	case "return_to_sender", "failure", "cancelled", "error":
+		return models.ShipmentStatusException
+	default:
+		return ""
+	}
+}
+
+// toEasyPostAddress converts an address to the EasyPost address object
+func toEasyPostAddress(address models.Address) easyPostAddress {
+	converted := easyPostAddress{
+		Name:    strings.TrimSpace(address.FirstName + " " + address.LastName),
+		Street1: address.AddressLine1,
+		City:    address.City,
+		Zip:     address.PostalCode,
+		Country: strings.ToUpper(address.Country),
+	}
+	if address.Company != nil {
+		converted.Company = *address.Company
+	}
+	if address.AddressLine2 != nil {
+		converted.Street2 = *address.AddressLine2
+	}
+	if address.State != nil {
+		converted.State = *address.State
+	}
+	if address.Phone != nil {
+		converted.Phone = *address.Phone
+	}
+	return converted
+}
+
+// parseDecimalAmount converts a decimal string such as "7.58" to minor units
+func parseDecimalAmount(value string) (int64, error) {
+	whole, fraction, _ := strings.Cut(value, ".")
+	fraction = (fraction + "00")[:2]
+
+	major, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", value, err)
+	}
+	minor, err := strconv.ParseInt(fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", value, err)
+	}
+
+	return major*100 + minor, nil
+}
+
+// roundUp rounds a positive value up to the given number of decimals, so
+// converted weights never understate the parcel
+func roundUp(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Ceil(value*factor) / factor
+}
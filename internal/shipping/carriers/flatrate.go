@@ -0,0 +1,81 @@
+package carriers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+const (
+	flatRateCarrierName = "flat_rate"
+	flatRateService     = "standard"
+)
+
+// flatRateCarrier prices parcels from a fixed table, for stores that ship
+// with their own courier or don't need live carrier rates. Labels carry a
+// generated tracking number and are printed by the warehouse.
+type flatRateCarrier struct {
+	cfg config.FlatRateConfig
+}
+
+// NewFlatRateCarrier creates a new flat-rate carrier
+func NewFlatRateCarrier(cfg config.FlatRateConfig) (Carrier, error) {
+	if len(cfg.Currency) != 3 {
+		return nil, fmt.Errorf("flat rate currency is invalid: %q", cfg.Currency)
+	}
+
+	return &flatRateCarrier{cfg: cfg}, nil
+}
+
+// Name returns the carrier name
+func (c *flatRateCarrier) Name() string {
+	return flatRateCarrierName
+}
+
+// Rates returns the single flat-rate service for the destination
+func (c *flatRateCarrier) Rates(ctx context.Context, req *RateRequest) ([]*Rate, error) {
+	return []*Rate{c.rate(req)}, nil
+}
+
+// PurchaseLabel issues a tracking number for a flat-rate shipment
+func (c *flatRateCarrier) PurchaseLabel(ctx context.Context, req *LabelRequest) (*Label, error) {
+	if req.Service != flatRateService {
+		return nil, apperrors.Validation("shipping service not supported: %s", req.Service)
+	}
+
+	rate := c.rate(&RateRequest{From: req.From, To: req.To, Parcel: req.Parcel})
+	trackingNumber := "FR" + strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", "")[:16])
+
+	return &Label{
+		TrackingNumber: trackingNumber,
+		ShipmentID:     trackingNumber,
+		Amount:         rate.Amount,
+		Currency:       rate.Currency,
+	}, nil
+}
+
+// rate prices a parcel: a base amount by destination plus a charge per started kilogram
+func (c *flatRateCarrier) rate(req *RateRequest) *Rate {
+	amount := c.cfg.InternationalAmount
+	days := c.cfg.InternationalDays
+	if strings.EqualFold(req.From.Country, req.To.Country) {
+		amount = c.cfg.DomesticAmount
+		days = c.cfg.DomesticDays
+	}
+
+	kilograms := (int64(req.Parcel.WeightGrams) + 999) / 1000
+	amount += kilograms * c.cfg.PerKgAmount
+
+	return &Rate{
+		Carrier:       flatRateCarrierName,
+		Service:       flatRateService,
+		Amount:        amount,
+		Currency:      strings.ToUpper(c.cfg.Currency),
+		EstimatedDays: days,
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/models"
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/idempotency"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/validation"
+)
+
+// ShippingHandler handles HTTP requests for shipping operations
+type ShippingHandler struct {
+	shippingService service.ShippingService
+	jwtService      *auth.JWTService
+	logger          *logger.Logger
+}
+
+// NewShippingHandler creates a new shipping handler
+func NewShippingHandler(shippingService service.ShippingService, jwtService *auth.JWTService, logger *logger.Logger) *ShippingHandler {
+	return &ShippingHandler{
+		shippingService: shippingService,
+		jwtService:      jwtService,
+		logger:          logger,
+	}
+}
+
+// QuoteRates returns shipping rates for a destination at checkout
+func (h *ShippingHandler) QuoteRates(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.RateRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	response, err := h.shippingService.QuoteRates(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.logger.Error("Failed to quote shipping rates", "error", err, "user_id", userID)
+		h.respondError(c, err, "Failed to quote shipping rates")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetQuote returns an unexpired shipping quote (internal)
+func (h *ShippingHandler) GetQuote(c *gin.Context) {
+	quoteID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quote ID"})
+		return
+	}
+
+	quote, err := h.shippingService.GetQuote(c.Request.Context(), quoteID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get shipping quote")
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// PurchaseLabel buys a shipping label when an order is fulfilled (internal)
+func (h *ShippingHandler) PurchaseLabel(c *gin.Context) {
+	var req models.PurchaseLabelRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	shipment, err := h.shippingService.PurchaseLabel(c.Request.Context(), &req, c.GetHeader(idempotency.HeaderKey))
+	if err != nil {
+		h.logger.Error("Label purchase failed", "error", err, "order_id", req.OrderID, "carrier", req.Carrier)
+		h.respondError(c, err, "Failed to purchase shipping label")
+		return
+	}
+
+	c.JSON(http.StatusCreated, shipment)
+}
+
+// ListShipments lists the shipments of an order (internal)
+func (h *ShippingHandler) ListShipments(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	shipments, err := h.shippingService.ListShipments(c.Request.Context(), orderID)
+	if err != nil {
+		h.respondError(c, err, "Failed to list shipments")
+		return
+	}
+
+	httpx.Items(c, shipments)
+}
+
+// GetOrderTracking returns the shipments of an order with their tracking history
+func (h *ShippingHandler) GetOrderTracking(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	tracking, err := h.shippingService.GetOrderTracking(c.Request.Context(), userID, orderID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get order tracking")
+		return
+	}
+
+	c.JSON(http.StatusOK, tracking)
+}
+
+// RecordTrackingEvents records tracking events pushed for a shipment (internal)
+func (h *ShippingHandler) RecordTrackingEvents(c *gin.Context) {
+	shipmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipment ID"})
+		return
+	}
+
+	var req models.RecordTrackingEventsRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	shipment, err := h.shippingService.RecordTrackingEvents(c.Request.Context(), shipmentID, &req)
+	if err != nil {
+		h.logger.Error("Failed to record tracking events", "error", err, "shipment_id", shipmentID)
+		h.respondError(c, err, "Failed to record tracking events")
+		return
+	}
+
+	c.JSON(http.StatusOK, shipment)
+}
+
+// TrackingWebhook receives tracking updates pushed by carriers
+func (h *ShippingHandler) TrackingWebhook(c *gin.Context) {
+	carrier := c.Param("carrier")
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := h.shippingService.HandleTrackingWebhook(c.Request.Context(), carrier, c.Request.Header, body); err != nil {
+		h.logger.Error("Tracking webhook processing failed", "error", err, "carrier", carrier)
+		// Failures other than unknown carriers and invalid deliveries make
+		// the carrier retry the delivery
+		apperrors.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"received": true})
+}
+
+// respondError logs a request failing on the side of the service, or of a
+// provider it depends on, and answers it with the problem details of err,
+// so middleware reading its status after it, such as idempotency's, sees
+// the failure
+func (h *ShippingHandler) respondError(c *gin.Context, err error, message string) {
+	if apperrors.HTTPStatus(err) >= http.StatusInternalServerError {
+		logger.FromContext(c.Request.Context()).Error(message, "error", err)
+	}
+	apperrors.Respond(c, err)
+}
+
+// SetupRoutes sets up the shipping routes.
+// Internal routes are called by other services and must not be exposed through the gateway.
+func (h *ShippingHandler) SetupRoutes(r *gin.Engine) {
+	shipping := r.Group("/api/v1/shipping")
+	shipping.Use(h.jwtService.Middleware())
+	{
+		shipping.POST("/rates", h.QuoteRates)
+	}
+
+	orders := r.Group("/api/v1/orders")
+	orders.Use(h.jwtService.Middleware())
+	{
+		orders.GET("/:id/tracking", h.GetOrderTracking)
+	}
+
+	r.POST("/webhooks/carriers/:carrier", h.TrackingWebhook)
+
+	internal := r.Group("/internal/v1")
+	{
+		internal.GET("/shipping/quotes/:id", h.GetQuote)
+		internal.POST("/shipments", h.PurchaseLabel)
+		internal.POST("/shipments/:id/events", h.RecordTrackingEvents)
+		internal.GET("/orders/:id/shipments", h.ListShipments)
+	}
+}
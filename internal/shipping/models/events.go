@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Shipping event types published to the shipping events topic
+const (
+	EventShipmentCreated = "shipment.created"
+	EventShipmentUpdated = "shipment.updated"
+)
+
+// EventOrderDelivered is published to the order events topic once every
+// shipment of an order is delivered. Loyalty points and review-request
+// emails are driven by it.
+const EventOrderDelivered = "order.delivered"
+
+// Schema versions of the payloads of the events published by the shipping
+// service. OrderDeliveredEvent follows the order service's OrderEvent.
+const (
+	ShipmentEventSchemaVersion       = 1
+	OrderDeliveredEventSchemaVersion = 1
+)
+
+// ShipmentEvent is published whenever the state of a shipment changes
+type ShipmentEvent struct {
+	Type           string    `json:"type"`
+	ShipmentID     uuid.UUID `json:"shipment_id"`
+	OrderID        uuid.UUID `json:"order_id"`
+	Carrier        string    `json:"carrier"`
+	Service        string    `json:"service"`
+	TrackingNumber string    `json:"tracking_number"`
+	Status         string    `json:"status"`
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+// OrderDeliveredEvent has the shape of the order service's order events
+type OrderDeliveredEvent struct {
+	Type       string    `json:"type"`
+	OrderID    uuid.UUID `json:"order_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Status     string    `json:"status"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
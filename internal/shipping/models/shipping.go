@@ -0,0 +1,184 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ShipmentStatus represents the delivery state of a shipment
+type ShipmentStatus string
+
+// Shipment statuses
+const (
+	ShipmentStatusLabelPurchased ShipmentStatus = "label_purchased"
+	ShipmentStatusInTransit      ShipmentStatus = "in_transit"
+	ShipmentStatusOutForDelivery ShipmentStatus = "out_for_delivery"
+	ShipmentStatusDelivered      ShipmentStatus = "delivered"
+	ShipmentStatusException      ShipmentStatus = "exception"
+)
+
+// Address is a ship-to or ship-from address.
+// Its JSON form matches the address snapshots stored with orders.
+type Address struct {
+	FirstName    string  `json:"first_name" binding:"required,max=100"`
+	LastName     string  `json:"last_name" binding:"required,max=100"`
+	Company      *string `json:"company,omitempty" binding:"omitempty,max=100"`
+	AddressLine1 string  `json:"address_line1" binding:"required,max=255"`
+	AddressLine2 *string `json:"address_line2,omitempty" binding:"omitempty,max=255"`
+	City         string  `json:"city" binding:"required,max=100"`
+	State        *string `json:"state,omitempty" binding:"omitempty,max=100"`
+	PostalCode   string  `json:"postal_code" binding:"required,max=20"`
+	Country      string  `json:"country" binding:"required,len=2"`
+	Phone        *string `json:"phone,omitempty" binding:"omitempty,max=30"`
+}
+
+// Value implements driver.Valuer so addresses can be stored as JSONB
+func (a Address) Value() (driver.Value, error) {
+	data, err := json.Marshal(a)
+	return string(data), err
+}
+
+// Scan implements sql.Scanner so addresses can be read from JSONB
+func (a *Address) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return fmt.Errorf("cannot scan %T into Address", src)
+	}
+}
+
+// Parcel describes the package to ship. Dimensions are optional; carriers
+// that price by dimensional weight quote more accurately with them.
+type Parcel struct {
+	WeightGrams int     `json:"weight_grams" binding:"required,gt=0,max=150000"`
+	LengthCm    float64 `json:"length_cm,omitempty" binding:"omitempty,gt=0"`
+	WidthCm     float64 `json:"width_cm,omitempty" binding:"omitempty,gt=0"`
+	HeightCm    float64 `json:"height_cm,omitempty" binding:"omitempty,gt=0"`
+}
+
+// Value implements driver.Valuer so parcels can be stored as JSONB
+func (p Parcel) Value() (driver.Value, error) {
+	data, err := json.Marshal(p)
+	return string(data), err
+}
+
+// Scan implements sql.Scanner so parcels can be read from JSONB
+func (p *Parcel) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	default:
+		return fmt.Errorf("cannot scan %T into Parcel", src)
+	}
+}
+
+// Quote is a shipping rate offered to a customer at checkout.
+// Amounts are in minor currency units.
+type Quote struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	UserID        uuid.UUID `json:"-" db:"user_id"`
+	Carrier       string    `json:"carrier" db:"carrier"`
+	Service       string    `json:"service" db:"service"`
+	Amount        int64     `json:"amount" db:"amount"`
+	Currency      string    `json:"currency" db:"currency"`
+	EstimatedDays *int      `json:"estimated_days,omitempty" db:"estimated_days"`
+	Destination   Address   `json:"-" db:"destination"`
+	Parcel        Parcel    `json:"-" db:"parcel"`
+	ExpiresAt     time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt     time.Time `json:"-" db:"created_at"`
+}
+
+// Shipment is a parcel of an order with a purchased shipping label
+type Shipment struct {
+	ID                uuid.UUID      `json:"id" db:"id"`
+	OrderID           uuid.UUID      `json:"order_id" db:"order_id"`
+	Carrier           string         `json:"carrier" db:"carrier"`
+	Service           string         `json:"service" db:"service"`
+	Status            ShipmentStatus `json:"status" db:"status"`
+	TrackingNumber    string         `json:"tracking_number" db:"tracking_number"`
+	LabelURL          *string        `json:"label_url,omitempty" db:"label_url"`
+	CarrierShipmentID *string        `json:"-" db:"carrier_shipment_id"`
+	RateAmount        int64          `json:"rate_amount" db:"rate_amount"`
+	Currency          string         `json:"currency" db:"currency"`
+	WeightGrams       int            `json:"weight_grams" db:"weight_grams"`
+	IdempotencyKey    string         `json:"-" db:"idempotency_key"`
+	DeliveredAt       *time.Time     `json:"delivered_at,omitempty" db:"delivered_at"`
+	LastPolledAt      *time.Time     `json:"-" db:"last_polled_at"`
+	CreatedAt         time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at" db:"updated_at"`
+
+	// Events is the tracking history, oldest first. Only loaded for tracking requests.
+	Events []*TrackingEvent `json:"events,omitempty" db:"-"`
+}
+
+// TrackingEvent is a scan or status change reported for a shipment
+type TrackingEvent struct {
+	ID          uuid.UUID      `json:"id" db:"id"`
+	ShipmentID  uuid.UUID      `json:"-" db:"shipment_id"`
+	Status      ShipmentStatus `json:"status" db:"status"`
+	Description string         `json:"description" db:"description"`
+	Location    *string        `json:"location,omitempty" db:"location"`
+	OccurredAt  time.Time      `json:"occurred_at" db:"occurred_at"`
+	CreatedAt   time.Time      `json:"-" db:"created_at"`
+}
+
+// ShippableOrder holds the order fields needed to ship it
+type ShippableOrder struct {
+	ID              uuid.UUID `db:"id"`
+	UserID          uuid.UUID `db:"user_id"`
+	Status          string    `db:"status"`
+	ShippingAddress *Address  `db:"shipping_address"`
+}
+
+// RateRequest represents a request for shipping rates at checkout
+type RateRequest struct {
+	Destination Address `json:"destination" binding:"required"`
+	Parcel      Parcel  `json:"parcel" binding:"required"`
+}
+
+// RateResponse lists the quotes for a destination, cheapest first
+type RateResponse struct {
+	Quotes []*Quote `json:"quotes"`
+}
+
+// PurchaseLabelRequest is sent when an order is fulfilled to buy a label for one of its parcels
+type PurchaseLabelRequest struct {
+	OrderID uuid.UUID `json:"order_id" binding:"required"`
+	Carrier string    `json:"carrier" binding:"required"`
+	Service string    `json:"service" binding:"required"`
+	Parcel  Parcel    `json:"parcel" binding:"required"`
+}
+
+// TrackingResponse lists the shipments of an order with their tracking history
+type TrackingResponse struct {
+	OrderID     uuid.UUID   `json:"order_id"`
+	OrderStatus string      `json:"order_status"`
+	Shipments   []*Shipment `json:"shipments"`
+}
+
+// TrackingEventRequest is a tracking event pushed by the warehouse or a
+// courier without an API, e.g. for flat-rate shipments
+type TrackingEventRequest struct {
+	Status      ShipmentStatus `json:"status" binding:"required,oneof=in_transit out_for_delivery delivered exception"`
+	Description string         `json:"description" binding:"max=500"`
+	Location    *string        `json:"location,omitempty" binding:"omitempty,max=255"`
+	OccurredAt  time.Time      `json:"occurred_at" binding:"required"`
+}
+
+// RecordTrackingEventsRequest records tracking events of a shipment
+type RecordTrackingEventsRequest struct {
+	Events []TrackingEventRequest `json:"events" binding:"required,min=1,max=100,dive"`
+}
@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// ShippingRepository defines the interface for shipping data operations
+type ShippingRepository interface {
+	GetShippableOrder(ctx context.Context, orderID uuid.UUID) (*models.ShippableOrder, error)
+
+	// Quote operations
+	CreateQuotes(ctx context.Context, quotes []*models.Quote) error
+	GetQuote(ctx context.Context, id uuid.UUID) (*models.Quote, error)
+	DeleteExpiredQuotes(ctx context.Context) (int64, error)
+
+	// Shipment operations
+	CreateShipment(ctx context.Context, shipment *models.Shipment) error
+	GetShipment(ctx context.Context, id uuid.UUID) (*models.Shipment, error)
+	GetShipmentByIdempotencyKey(ctx context.Context, key string) (*models.Shipment, error)
+	GetShipmentByTrackingNumber(ctx context.Context, carrier, trackingNumber string) (*models.Shipment, error)
+	ListShipmentsByOrderID(ctx context.Context, orderID uuid.UUID) ([]*models.Shipment, error)
+
+	// Tracking operations
+	ClaimShipmentsToPoll(ctx context.Context, carriers []string, refreshInterval time.Duration, limit int) ([]*models.Shipment, error)
+	ApplyTrackingUpdate(ctx context.Context, shipment *models.Shipment, events []*models.TrackingEvent) error
+	ListTrackingEventsByOrderID(ctx context.Context, orderID uuid.UUID) ([]*models.TrackingEvent, error)
+	MarkOrderDelivered(ctx context.Context, orderID uuid.UUID) (bool, error)
+}
+
+// shippingRepository implements the ShippingRepository interface
+type shippingRepository struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewShippingRepository creates a new shipping repository
+func NewShippingRepository(db *database.DB, logger *logger.Logger) ShippingRepository {
+	return &shippingRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const shipmentColumns = `id, order_id, carrier, service, status, tracking_number, label_url, carrier_shipment_id,
+		       rate_amount, currency, weight_grams, idempotency_key, created_at, updated_at`
+
+// GetShippableOrder retrieves the order fields needed to ship an order
+func (r *shippingRepository) GetShippableOrder(ctx context.Context, orderID uuid.UUID) (*models.ShippableOrder, error) {
+	order := &models.ShippableOrder{}
+	query := `
+		SELECT id, user_id, status, shipping_address
+		FROM orders
+		WHERE id = $1`
+
+	err := r.db.GetContext(ctx, order, query, orderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("order not found")
+		}
+		r.logger.Error("Failed to get order for shipping", "error", err, "order_id", orderID)
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	return order, nil
+}
+
+// CreateQuotes stores the quotes offered for one rate request
+func (r *shippingRepository) CreateQuotes(ctx context.Context, quotes []*models.Quote) error {
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		query := `
+			INSERT INTO shipping_quotes (id, user_id, carrier, service, amount, currency, estimated_days,
+			                             destination, parcel, expires_at)
+			VALUES (:id, :user_id, :carrier, :service, :amount, :currency, :estimated_days,
+			        :destination, :parcel, :expires_at)
+			RETURNING created_at`
+
+		stmt, err := tx.PrepareNamedContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, quote := range quotes {
+			if err := stmt.QueryRowxContext(ctx, quote).Scan(&quote.CreatedAt); err != nil {
+				r.logger.Error("Failed to create shipping quote", "error", err, "carrier", quote.Carrier)
+				return fmt.Errorf("failed to create shipping quote: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetQuote retrieves a shipping quote by ID
+func (r *shippingRepository) GetQuote(ctx context.Context, id uuid.UUID) (*models.Quote, error) {
+	quote := &models.Quote{}
+	query := `
+		SELECT id, user_id, carrier, service, amount, currency, estimated_days, destination, parcel,
+		       expires_at, created_at
+		FROM shipping_quotes
+		WHERE id = $1`
+
+	err := r.db.GetContext(ctx, quote, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("shipping quote not found")
+		}
+		r.logger.Error("Failed to get shipping quote", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to get shipping quote: %w", err)
+	}
+
+	return quote, nil
+}
+
+// DeleteExpiredQuotes deletes expired quotes and returns how many were removed
+func (r *shippingRepository) DeleteExpiredQuotes(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM shipping_quotes WHERE expires_at < NOW()`)
+	if err != nil {
+		r.logger.Error("Failed to delete expired shipping quotes", "error", err)
+		return 0, fmt.Errorf("failed to delete expired shipping quotes: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// CreateShipment stores a shipment with a purchased label
+func (r *shippingRepository) CreateShipment(ctx context.Context, shipment *models.Shipment) error {
+	query := `
+		INSERT INTO shipments (id, order_id, carrier, service, status, tracking_number, label_url,
+		                       carrier_shipment_id, rate_amount, currency, weight_grams, idempotency_key)
+		VALUES (:id, :order_id, :carrier, :service, :status, :tracking_number, :label_url,
+		        :carrier_shipment_id, :rate_amount, :currency, :weight_grams, :idempotency_key)
+		RETURNING created_at, updated_at`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to prepare create shipment statement", "error", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, shipment).Scan(&shipment.CreatedAt, &shipment.UpdatedAt)
+	if err != nil {
+		r.logger.Error("Failed to create shipment", "error", err, "order_id", shipment.OrderID)
+		return fmt.Errorf("failed to create shipment: %w", err)
+	}
+
+	return nil
+}
+
+// GetShipment retrieves a shipment by ID
+func (r *shippingRepository) GetShipment(ctx context.Context, id uuid.UUID) (*models.Shipment, error) {
+	shipment := &models.Shipment{}
+	query := `
+		SELECT ` + shipmentColumns + `
+		FROM shipments
+		WHERE id = $1`
+
+	err := r.db.GetContext(ctx, shipment, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("shipment not found")
+		}
+		r.logger.Error("Failed to get shipment", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to get shipment: %w", err)
+	}
+
+	return shipment, nil
+}
+
+// GetShipmentByIdempotencyKey retrieves the shipment created for a label purchase request
+func (r *shippingRepository) GetShipmentByIdempotencyKey(ctx context.Context, key string) (*models.Shipment, error) {
+	shipment := &models.Shipment{}
+	query := `
+		SELECT ` + shipmentColumns + `
+		FROM shipments
+		WHERE idempotency_key = $1`
+
+	err := r.db.GetContext(ctx, shipment, query, key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("shipment not found")
+		}
+		r.logger.Error("Failed to get shipment by idempotency key", "error", err)
+		return nil, fmt.Errorf("failed to get shipment: %w", err)
+	}
+
+	return shipment, nil
+}
+
+// GetShipmentByTrackingNumber retrieves a shipment by its carrier's tracking number
+func (r *shippingRepository) GetShipmentByTrackingNumber(ctx context.Context, carrier, trackingNumber string) (*models.Shipment, error) {
+	shipment := &models.Shipment{}
+	query := `
+		SELECT ` + shipmentColumns + `
+		FROM shipments
+		WHERE carrier = $1 AND tracking_number = $2`
+
+	err := r.db.GetContext(ctx, shipment, query, carrier, trackingNumber)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("shipment not found")
+		}
+		r.logger.Error("Failed to get shipment by tracking number", "error", err, "carrier", carrier)
+		return nil, fmt.Errorf("failed to get shipment: %w", err)
+	}
+
+	return shipment, nil
+}
+
+// ListShipmentsByOrderID lists the shipments of an order, oldest first
+func (r *shippingRepository) ListShipmentsByOrderID(ctx context.Context, orderID uuid.UUID) ([]*models.Shipment, error) {
+	shipments := []*models.Shipment{}
+	query := `
+		SELECT ` + shipmentColumns + `
+		FROM shipments
+		WHERE order_id = $1
+		ORDER BY created_at ASC`
+
+	err := r.db.SelectContext(ctx, &shipments, query, orderID)
+	if err != nil {
+		r.logger.Error("Failed to list shipments", "error", err, "order_id", orderID)
+		return nil, fmt.Errorf("failed to list shipments: %w", err)
+	}
+
+	return shipments, nil
+}
+
+// ClaimShipmentsToPoll selects up to limit undelivered shipments of the given
+// carriers that were not polled within refreshInterval, least recently polled
+// first, and marks them as polled so concurrent pollers skip them
+func (r *shippingRepository) ClaimShipmentsToPoll(ctx context.Context, carriers []string, refreshInterval time.Duration, limit int) ([]*models.Shipment, error) {
+	shipments := []*models.Shipment{}
+	query := `
+		UPDATE shipments
+		SET last_polled_at = NOW()
+		WHERE id IN (
+			SELECT id FROM shipments
+			WHERE status <> $1 AND carrier = ANY($2)
+			  AND (last_polled_at IS NULL OR last_polled_at <= NOW() - $3 * INTERVAL '1 second')
+			ORDER BY last_polled_at NULLS FIRST
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + shipmentColumns
+
+	err := r.db.SelectContext(ctx, &shipments, query,
+		models.ShipmentStatusDelivered, carriers, refreshInterval.Seconds(), limit)
+	if err != nil {
+		r.logger.Error("Failed to claim shipments to poll", "error", err)
+		return nil, fmt.Errorf("failed to claim shipments to poll: %w", err)
+	}
+
+	return shipments, nil
+}
+
+// ApplyTrackingUpdate stores new tracking events of a shipment together with
+// its status. Events that were already stored are skipped, and the status of
+// delivered shipments is final.
+func (r *shippingRepository) ApplyTrackingUpdate(ctx context.Context, shipment *models.Shipment, events []*models.TrackingEvent) error {
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		query := `
+			INSERT INTO shipment_tracking_events (id, shipment_id, status, description, location, occurred_at)
+			VALUES (:id, :shipment_id, :status, :description, :location, :occurred_at)
+			ON CONFLICT (shipment_id, occurred_at, status) DO NOTHING`
+
+		for _, event := range events {
+			if _, err := tx.NamedExecContext(ctx, query, event); err != nil {
+				r.logger.Error("Failed to store tracking event", "error", err, "shipment_id", shipment.ID)
+				return fmt.Errorf("failed to store tracking event: %w", err)
+			}
+		}
+
+		query = `
+			UPDATE shipments
+			SET status = $2, delivered_at = $3
+			WHERE id = $1 AND status <> $4
+			RETURNING updated_at`
+
+		err := tx.QueryRowxContext(ctx, query, shipment.ID, shipment.Status, shipment.DeliveredAt, models.ShipmentStatusDelivered).
+			Scan(&shipment.UpdatedAt)
+		if err != nil && err != sql.ErrNoRows {
+			r.logger.Error("Failed to update shipment status", "error", err, "shipment_id", shipment.ID)
+			return fmt.Errorf("failed to update shipment status: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// ListTrackingEventsByOrderID lists the tracking events of all shipments of an order, oldest first
+func (r *shippingRepository) ListTrackingEventsByOrderID(ctx context.Context, orderID uuid.UUID) ([]*models.TrackingEvent, error) {
+	events := []*models.TrackingEvent{}
+	query := `
+		SELECT e.id, e.shipment_id, e.status, e.description, e.location, e.occurred_at, e.created_at
+		FROM shipment_tracking_events e
+		JOIN shipments s ON s.id = e.shipment_id
+		WHERE s.order_id = $1
+		ORDER BY e.occurred_at ASC, e.created_at ASC`
+
+	err := r.db.SelectContext(ctx, &events, query, orderID)
+	if err != nil {
+		r.logger.Error("Failed to list tracking events", "error", err, "order_id", orderID)
+		return nil, fmt.Errorf("failed to list tracking events: %w", err)
+	}
+
+	return events, nil
+}
+
+// MarkOrderDelivered moves an order to delivered once all of its shipments
+// are delivered. It reports whether the order changed, so the transition is
+// only announced once.
+func (r *shippingRepository) MarkOrderDelivered(ctx context.Context, orderID uuid.UUID) (bool, error) {
+	query := `
+		UPDATE orders
+		SET status = 'delivered'
+		WHERE id = $1
+		  AND status IN ('confirmed', 'processing', 'shipped')
+		  AND EXISTS (SELECT 1 FROM shipments WHERE order_id = $1)
+		  AND NOT EXISTS (SELECT 1 FROM shipments WHERE order_id = $1 AND status <> $2)`
+
+	result, err := r.db.ExecContext(ctx, query, orderID, models.ShipmentStatusDelivered)
+	if err != nil {
+		r.logger.Error("Failed to mark order delivered", "error", err, "order_id", orderID)
+		return false, fmt.Errorf("failed to mark order delivered: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark order delivered: %w", err)
+	}
+
+	return rows > 0, nil
+}
@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/carriers"
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/models"
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/repository"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// Order statuses in which parcels may be shipped. Orders that already shipped
+// can still get labels for further parcels.
+var shippableOrderStatuses = map[string]bool{
+	"confirmed":  true,
+	"processing": true,
+	"shipped":    true,
+}
+
+// ShippingService defines the interface for shipping business logic
+type ShippingService interface {
+	QuoteRates(ctx context.Context, userID uuid.UUID, req *models.RateRequest) (*models.RateResponse, error)
+
+	// Internal operations called by other services
+	GetQuote(ctx context.Context, quoteID uuid.UUID) (*models.Quote, error)
+	PurchaseLabel(ctx context.Context, req *models.PurchaseLabelRequest, idempotencyKey string) (*models.Shipment, error)
+	ListShipments(ctx context.Context, orderID uuid.UUID) ([]*models.Shipment, error)
+
+	// Tracking
+	GetOrderTracking(ctx context.Context, userID uuid.UUID, orderID uuid.UUID) (*models.TrackingResponse, error)
+	RecordTrackingEvents(ctx context.Context, shipmentID uuid.UUID, req *models.RecordTrackingEventsRequest) (*models.Shipment, error)
+	HandleTrackingWebhook(ctx context.Context, carrier string, header http.Header, body []byte) error
+	PollTracking(ctx context.Context) (int, error)
+
+	PurgeExpiredQuotes(ctx context.Context) (int64, error)
+}
+
+// EventPublisher publishes domain events to the message broker
+type EventPublisher interface {
+	Publish(ctx context.Context, topic, key string, event interface{}) error
+}
+
+// eventSource is the source of the events the service publishes
+const eventSource = "shipping-service"
+
+// shippingService implements the ShippingService interface
+type shippingService struct {
+	repo      repository.ShippingRepository
+	carriers  *carriers.Registry
+	publisher EventPublisher
+	config    *config.Config
+	logger    *logger.Logger
+}
+
+// NewShippingService creates a new shipping service.
+// publisher may be nil, in which case shipping events are not published.
+func NewShippingService(
+	repo repository.ShippingRepository,
+	carriers *carriers.Registry,
+	publisher EventPublisher,
+	config *config.Config,
+	logger *logger.Logger,
+) ShippingService {
+	return &shippingService{
+		repo:      repo,
+		carriers:  carriers,
+		publisher: publisher,
+		config:    config,
+		logger:    logger,
+	}
+}
+
+// QuoteRates asks every carrier for rates to the destination and stores them
+// as quotes, cheapest first. Carriers that fail are left out of the result.
+func (s *shippingService) QuoteRates(ctx context.Context, userID uuid.UUID, req *models.RateRequest) (*models.RateResponse, error) {
+	rateReq := &carriers.RateRequest{
+		From:   s.origin(),
+		To:     req.Destination,
+		Parcel: req.Parcel,
+	}
+
+	all := s.carriers.All()
+	results := make([][]*carriers.Rate, len(all))
+	var wg sync.WaitGroup
+	for i, carrier := range all {
+		wg.Add(1)
+		go func(i int, carrier carriers.Carrier) {
+			defer wg.Done()
+
+			rates, err := carrier.Rates(ctx, rateReq)
+			if err != nil {
+				s.logger.Error("Failed to get shipping rates", "error", err, "carrier", carrier.Name())
+				return
+			}
+			results[i] = rates
+		}(i, carrier)
+	}
+	wg.Wait()
+
+	expiresAt := time.Now().Add(s.config.Services.Shipping.QuoteTTL)
+	quotes := []*models.Quote{}
+	for _, rates := range results {
+		for _, rate := range rates {
+			quote := &models.Quote{
+				ID:          uuid.New(),
+				UserID:      userID,
+				Carrier:     rate.Carrier,
+				Service:     rate.Service,
+				Amount:      rate.Amount,
+				Currency:    rate.Currency,
+				Destination: req.Destination,
+				Parcel:      req.Parcel,
+				ExpiresAt:   expiresAt,
+			}
+			if rate.EstimatedDays > 0 {
+				days := rate.EstimatedDays
+				quote.EstimatedDays = &days
+			}
+			quotes = append(quotes, quote)
+		}
+	}
+
+	if len(quotes) == 0 {
+		return nil, apperrors.Validation("shipping not supported: no rates available for this destination")
+	}
+
+	sort.SliceStable(quotes, func(i, j int) bool { return quotes[i].Amount < quotes[j].Amount })
+
+	if err := s.repo.CreateQuotes(ctx, quotes); err != nil {
+		return nil, err
+	}
+
+	return &models.RateResponse{Quotes: quotes}, nil
+}
+
+// GetQuote returns a quote that has not expired yet
+func (s *shippingService) GetQuote(ctx context.Context, quoteID uuid.UUID) (*models.Quote, error) {
+	quote, err := s.repo.GetQuote(ctx, quoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(quote.ExpiresAt) {
+		return nil, apperrors.NotFound("shipping quote not found: quote expired")
+	}
+
+	return quote, nil
+}
+
+// PurchaseLabel buys a shipping label for a parcel of an order. Retries with
+// the same idempotency key return the shipment created by the first request.
+// Without a key, an order gets a single label.
+func (s *shippingService) PurchaseLabel(ctx context.Context, req *models.PurchaseLabelRequest, idempotencyKey string) (*models.Shipment, error) {
+	if idempotencyKey == "" {
+		idempotencyKey = req.OrderID.String()
+	}
+
+	if shipment, err := s.repo.GetShipmentByIdempotencyKey(ctx, idempotencyKey); err == nil {
+		if shipment.OrderID != req.OrderID {
+			return nil, apperrors.Validation("invalid idempotency key: already used for a different order")
+		}
+		return shipment, nil
+	}
+
+	order, err := s.repo.GetShippableOrder(ctx, req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !shippableOrderStatuses[order.Status] {
+		return nil, apperrors.Conflict("order cannot be shipped in its current state")
+	}
+
+	if order.ShippingAddress == nil {
+		return nil, apperrors.Validation("invalid order: no shipping address")
+	}
+
+	carrier, err := s.carriers.Get(req.Carrier)
+	if err != nil {
+		return nil, err
+	}
+
+	label, err := carrier.PurchaseLabel(ctx, &carriers.LabelRequest{
+		From:           s.origin(),
+		To:             *order.ShippingAddress,
+		Parcel:         req.Parcel,
+		Service:        req.Service,
+		Reference:      order.ID.String(),
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		var carrierErr *carriers.CarrierError
+		switch {
+		case errors.As(err, &carrierErr):
+			return nil, apperrors.Upstream("shipping carrier rejected the request: %s", carrierErr.Message).Wrap(err)
+		case errors.Is(err, apperrors.ErrValidation):
+			return nil, err
+		default:
+			return nil, apperrors.Upstream("shipping carrier is unavailable").Wrap(err)
+		}
+	}
+
+	shipment := &models.Shipment{
+		ID:                uuid.New(),
+		OrderID:           order.ID,
+		Carrier:           carrier.Name(),
+		Service:           req.Service,
+		Status:            models.ShipmentStatusLabelPurchased,
+		TrackingNumber:    label.TrackingNumber,
+		CarrierShipmentID: optionalString(label.ShipmentID),
+		LabelURL:          optionalString(label.LabelURL),
+		RateAmount:        label.Amount,
+		Currency:          label.Currency,
+		WeightGrams:       req.Parcel.WeightGrams,
+		IdempotencyKey:    idempotencyKey,
+	}
+
+	if err := s.repo.CreateShipment(ctx, shipment); err != nil {
+		// The label is paid for; log everything needed to recover it by hand
+		s.logger.Error("Failed to store purchased label",
+			"error", err,
+			"order_id", order.ID,
+			"carrier", carrier.Name(),
+			"tracking_number", label.TrackingNumber,
+			"carrier_shipment_id", label.ShipmentID,
+		)
+		return nil, err
+	}
+
+	s.publishEvent(ctx, models.EventShipmentCreated, shipment)
+
+	s.logger.Info("Shipping label purchased", "shipment_id", shipment.ID, "order_id", order.ID, "carrier", carrier.Name(), "service", req.Service)
+	return shipment, nil
+}
+
+// ListShipments lists the shipments of an order
+func (s *shippingService) ListShipments(ctx context.Context, orderID uuid.UUID) ([]*models.Shipment, error) {
+	return s.repo.ListShipmentsByOrderID(ctx, orderID)
+}
+
+// PurgeExpiredQuotes deletes quotes that can no longer be used
+func (s *shippingService) PurgeExpiredQuotes(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpiredQuotes(ctx)
+}
+
+// origin returns the configured ship-from address
+func (s *shippingService) origin() models.Address {
+	cfg := s.config.Services.Shipping.Origin
+	return models.Address{
+		FirstName:    cfg.Name,
+		Company:      optionalString(cfg.Company),
+		AddressLine1: cfg.AddressLine1,
+		AddressLine2: optionalString(cfg.AddressLine2),
+		City:         cfg.City,
+		State:        optionalString(cfg.State),
+		PostalCode:   cfg.PostalCode,
+		Country:      strings.ToUpper(cfg.Country),
+		Phone:        optionalString(cfg.Phone),
+	}
+}
+
+// publishEvent publishes a shipment event, logging rather than failing the
+// request when the broker is unavailable
+func (s *shippingService) publishEvent(ctx context.Context, eventType string, shipment *models.Shipment) {
+	if s.publisher == nil {
+		return
+	}
+
+	event := &models.ShipmentEvent{
+		Type:           eventType,
+		ShipmentID:     shipment.ID,
+		OrderID:        shipment.OrderID,
+		Carrier:        shipment.Carrier,
+		Service:        shipment.Service,
+		TrackingNumber: shipment.TrackingNumber,
+		Status:         string(shipment.Status),
+		OccurredAt:     time.Now().UTC(),
+	}
+
+	envelope, err := events.New(ctx, eventSource, eventType, shipment.ID.String(), models.ShipmentEventSchemaVersion, event)
+	if err != nil {
+		s.logger.Error("Failed to publish shipment event", "error", err, "type", eventType, "shipment_id", shipment.ID)
+		return
+	}
+
+	if err := s.publisher.Publish(ctx, s.config.Kafka.Topics.ShippingEvents, shipment.OrderID.String(), envelope); err != nil {
+		s.logger.Error("Failed to publish shipment event", "error", err, "type", eventType, "shipment_id", shipment.ID)
+	}
+}
+
+// optionalString returns nil for empty strings, for nullable columns
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/carriers"
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+)
+
+// GetOrderTracking returns the shipments of a customer's order with their tracking history
+func (s *shippingService) GetOrderTracking(ctx context.Context, userID uuid.UUID, orderID uuid.UUID) (*models.TrackingResponse, error) {
+	order, err := s.repo.GetShippableOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't reveal other customers' orders
+	if order.UserID != userID {
+		return nil, apperrors.NotFound("order not found")
+	}
+
+	shipments, err := s.repo.ListShipmentsByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.repo.ListTrackingEventsByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	byShipment := make(map[uuid.UUID]*models.Shipment, len(shipments))
+	for _, shipment := range shipments {
+		shipment.Events = []*models.TrackingEvent{}
+		byShipment[shipment.ID] = shipment
+	}
+	for _, event := range events {
+		if shipment, ok := byShipment[event.ShipmentID]; ok {
+			shipment.Events = append(shipment.Events, event)
+		}
+	}
+
+	return &models.TrackingResponse{
+		OrderID:     order.ID,
+		OrderStatus: order.Status,
+		Shipments:   shipments,
+	}, nil
+}
+
+// RecordTrackingEvents records tracking events pushed for a shipment, e.g. by
+// the warehouse for carriers that can't be tracked through an API. The
+// shipment takes the status of the most recent event.
+func (s *shippingService) RecordTrackingEvents(ctx context.Context, shipmentID uuid.UUID, req *models.RecordTrackingEventsRequest) (*models.Shipment, error) {
+	shipment, err := s.repo.GetShipment(ctx, shipmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*models.TrackingEvent, 0, len(req.Events))
+	for _, event := range req.Events {
+		events = append(events, &models.TrackingEvent{
+			Status:      event.Status,
+			Description: event.Description,
+			Location:    event.Location,
+			OccurredAt:  event.OccurredAt.UTC(),
+		})
+	}
+
+	if err := s.applyTrackingUpdate(ctx, shipment, "", events); err != nil {
+		return nil, err
+	}
+
+	return shipment, nil
+}
+
+// HandleTrackingWebhook verifies and applies a tracking update pushed by a
+// carrier. Updates for unknown tracking numbers are acknowledged and dropped.
+func (s *shippingService) HandleTrackingWebhook(ctx context.Context, carrierName string, header http.Header, body []byte) error {
+	carrier, err := s.carriers.Get(carrierName)
+	if err != nil {
+		return apperrors.NotFound("unknown carrier").Wrap(err)
+	}
+
+	parser, ok := carrier.(carriers.WebhookParser)
+	if !ok {
+		return apperrors.NotFound("tracking webhooks not supported by carrier %s", carrierName)
+	}
+
+	update, err := parser.ParseTrackingWebhook(ctx, header, body)
+	if err != nil {
+		return err
+	}
+	if update == nil {
+		return nil
+	}
+
+	shipment, err := s.repo.GetShipmentByTrackingNumber(ctx, carrier.Name(), update.TrackingNumber)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			s.logger.Warn("Tracking update for unknown shipment", "carrier", carrierName, "tracking_number", update.TrackingNumber)
+			return nil
+		}
+		return err
+	}
+
+	return s.applyCarrierUpdate(ctx, shipment, update)
+}
+
+// PollTracking polls carriers for one batch of undelivered shipments and
+// returns the number of shipments polled. Carriers without a tracking API
+// are skipped; their shipments are updated through RecordTrackingEvents.
+func (s *shippingService) PollTracking(ctx context.Context) (int, error) {
+	trackers := make(map[string]carriers.Tracker)
+	names := []string{}
+	for _, carrier := range s.carriers.All() {
+		if tracker, ok := carrier.(carriers.Tracker); ok {
+			trackers[carrier.Name()] = tracker
+			names = append(names, carrier.Name())
+		}
+	}
+	if len(names) == 0 {
+		return 0, nil
+	}
+
+	cfg := s.config.Services.Shipping.Tracking
+	shipments, err := s.repo.ClaimShipmentsToPoll(ctx, names, cfg.RefreshInterval, cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, shipment := range shipments {
+		req := &carriers.TrackRequest{
+			TrackingNumber: shipment.TrackingNumber,
+			Service:        shipment.Service,
+		}
+		if shipment.CarrierShipmentID != nil {
+			req.ShipmentID = *shipment.CarrierShipmentID
+		}
+
+		update, err := trackers[shipment.Carrier].Track(ctx, req)
+		if err != nil {
+			s.logger.Error("Failed to track shipment", "error", err, "shipment_id", shipment.ID, "carrier", shipment.Carrier)
+			continue
+		}
+
+		if err := s.applyCarrierUpdate(ctx, shipment, update); err != nil {
+			s.logger.Error("Failed to apply tracking update", "error", err, "shipment_id", shipment.ID)
+		}
+	}
+
+	return len(shipments), nil
+}
+
+// applyCarrierUpdate applies a tracking update received from a carrier
+func (s *shippingService) applyCarrierUpdate(ctx context.Context, shipment *models.Shipment, update *carriers.TrackingUpdate) error {
+	events := make([]*models.TrackingEvent, 0, len(update.Events))
+	for _, event := range update.Events {
+		events = append(events, &models.TrackingEvent{
+			Status:      event.Status,
+			Description: event.Description,
+			Location:    optionalString(event.Location),
+			OccurredAt:  event.OccurredAt.UTC(),
+		})
+	}
+
+	return s.applyTrackingUpdate(ctx, shipment, update.Status, events)
+}
+
+// applyTrackingUpdate stores tracking events and moves the shipment to status,
+// or to the status of its most recent event when status is empty. Delivery is
+// final: later events are recorded but don't change the status again.
+func (s *shippingService) applyTrackingUpdate(ctx context.Context, shipment *models.Shipment, status models.ShipmentStatus, events []*models.TrackingEvent) error {
+	sort.SliceStable(events, func(i, j int) bool { return events[i].OccurredAt.Before(events[j].OccurredAt) })
+
+	if status == "" && len(events) > 0 {
+		status = events[len(events)-1].Status
+	}
+	if status == "" || shipment.Status == models.ShipmentStatusDelivered {
+		status = shipment.Status
+	}
+
+	previous := shipment.Status
+	shipment.Status = status
+	if status == models.ShipmentStatusDelivered && shipment.DeliveredAt == nil {
+		deliveredAt := time.Now().UTC()
+		for _, event := range events {
+			if event.Status == models.ShipmentStatusDelivered {
+				deliveredAt = event.OccurredAt
+			}
+		}
+		shipment.DeliveredAt = &deliveredAt
+	}
+
+	for _, event := range events {
+		event.ID = uuid.New()
+		event.ShipmentID = shipment.ID
+	}
+
+	if err := s.repo.ApplyTrackingUpdate(ctx, shipment, events); err != nil {
+		return err
+	}
+
+	if status != previous {
+		s.publishEvent(ctx, models.EventShipmentUpdated, shipment)
+		s.logger.Info("Shipment status changed", "shipment_id", shipment.ID, "order_id", shipment.OrderID, "from", previous, "to", status)
+	}
+
+	// Checked on every delivered update, so an order whose transition failed
+	// earlier is completed by the next update of any of its shipments
+	if status == models.ShipmentStatusDelivered {
+		return s.completeOrderDelivery(ctx, shipment.OrderID)
+	}
+
+	return nil
+}
+
+// completeOrderDelivery marks an order delivered once all of its shipments
+// are, and announces it with an order.delivered event
+func (s *shippingService) completeOrderDelivery(ctx context.Context, orderID uuid.UUID) error {
+	delivered, err := s.repo.MarkOrderDelivered(ctx, orderID)
+	if err != nil || !delivered {
+		return err
+	}
+
+	s.logger.Info("Order delivered", "order_id", orderID)
+
+	if s.publisher == nil {
+		return nil
+	}
+
+	order, err := s.repo.GetShippableOrder(ctx, orderID)
+	if err != nil {
+		s.logger.Error("Failed to load delivered order", "error", err, "order_id", orderID)
+		return nil
+	}
+
+	event := &models.OrderDeliveredEvent{
+		Type:       models.EventOrderDelivered,
+		OrderID:    order.ID,
+		UserID:     order.UserID,
+		Status:     order.Status,
+		OccurredAt: time.Now().UTC(),
+	}
+
+	envelope, err := events.New(ctx, eventSource, event.Type, orderID.String(), models.OrderDeliveredEventSchemaVersion, event)
+	if err != nil {
+		s.logger.Error("Failed to publish order event", "error", err, "type", event.Type, "order_id", orderID)
+		return nil
+	}
+
+	if err := s.publisher.Publish(ctx, s.config.Kafka.Topics.OrderEvents, orderID.String(), envelope); err != nil {
+		s.logger.Error("Failed to publish order event", "error", err, "type", event.Type, "order_id", orderID)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// TrackingWorker periodically polls carriers for tracking updates in the background
+type TrackingWorker struct {
+	shippingService ShippingService
+	interval        time.Duration
+	batchSize       int
+	logger          *logger.Logger
+}
+
+// NewTrackingWorker creates a new tracking worker
+func NewTrackingWorker(shippingService ShippingService, interval time.Duration, batchSize int, logger *logger.Logger) *TrackingWorker {
+	return &TrackingWorker{
+		shippingService: shippingService,
+		interval:        interval,
+		batchSize:       batchSize,
+		logger:          logger,
+	}
+}
+
+// Run polls shipments until ctx is cancelled. Each tick works through all
+// shipments due for a refresh batch by batch.
+func (w *TrackingWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				polled, err := w.shippingService.PollTracking(ctx)
+				if err != nil {
+					w.logger.Error("Failed to poll shipment tracking", "error", err)
+					break
+				}
+				if polled < w.batchSize {
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/resilience"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// SendEmailRequest asks the notification service to send a templated email
+type SendEmailRequest struct {
+	Template string                 `json:"template"`
+	Locale   string                 `json:"locale,omitempty"`
+	To       string                 `json:"to"`
+	Data     map[string]interface{} `json:"data"`
+}
+
+// NotificationClient defines the notification operations the stock alert service depends on
+type NotificationClient interface {
+	SendEmail(ctx context.Context, req *SendEmailRequest) error
+}
+
+// httpNotificationClient calls the notification service over its internal HTTP API
+type httpNotificationClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewNotificationClient creates a new notification service client, calling it through
+// dependency
+func NewNotificationClient(baseURL string, dependency *resilience.Dependency) NotificationClient {
+	// Services called continue the trace, with its baggage
+	return &httpNotificationClient{
+		baseURL:    baseURL,
+		httpClient: dependency.HTTPClient(tracing.NewTransport(nil)),
+	}
+}
+
+// SendEmail queues an email with the active version of a template
+func (c *httpNotificationClient) SendEmail(ctx context.Context, req *SendEmailRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal send email request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/internal/v1/emails", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create send email request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to call notification service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("notification service returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
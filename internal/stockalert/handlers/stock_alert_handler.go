@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/stockalert/models"
+	"github.com/kaanevranportfolio/Commercium/internal/stockalert/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/validation"
+)
+
+// StockAlertHandler handles HTTP requests for back-in-stock alerts
+type StockAlertHandler struct {
+	stockAlertService service.StockAlertService
+	jwtService        *auth.JWTService
+	logger            *logger.Logger
+}
+
+// NewStockAlertHandler creates a new stock alert handler
+func NewStockAlertHandler(stockAlertService service.StockAlertService, jwtService *auth.JWTService, logger *logger.Logger) *StockAlertHandler {
+	return &StockAlertHandler{
+		stockAlertService: stockAlertService,
+		jwtService:        jwtService,
+		logger:            logger,
+	}
+}
+
+// CreateAlert subscribes the caller to a SKU's restock
+func (h *StockAlertHandler) CreateAlert(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+
+	var req models.CreateAlertRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	alert, err := h.stockAlertService.CreateAlert(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to create stock alert")
+		return
+	}
+
+	c.JSON(http.StatusCreated, alert)
+}
+
+// ListAlerts returns the caller's alerts
+func (h *StockAlertHandler) ListAlerts(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+
+	var req models.ListAlertsRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	alerts, err := h.stockAlertService.ListAlerts(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to list stock alerts")
+		return
+	}
+
+	httpx.Items(c, alerts)
+}
+
+// CancelAlert cancels one of the caller's alerts
+func (h *StockAlertHandler) CancelAlert(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+
+	alertID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid stock alert ID"})
+		return
+	}
+
+	alert, err := h.stockAlertService.CancelAlert(c.Request.Context(), userID, alertID)
+	if err != nil {
+		h.respondError(c, err, "Failed to cancel stock alert")
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// respondError logs a request failing on the side of the service, or of a
+// provider it depends on, and answers it with the problem details of err,
+// so middleware reading its status after it, such as idempotency's, sees
+// the failure
+func (h *StockAlertHandler) respondError(c *gin.Context, err error, message string) {
+	if apperrors.HTTPStatus(err) >= http.StatusInternalServerError {
+		logger.FromContext(c.Request.Context()).Error(message, "error", err)
+	}
+	apperrors.Respond(c, err)
+}
+
+// SetupRoutes sets up the stock alert routes
+func (h *StockAlertHandler) SetupRoutes(r *gin.Engine) {
+	alerts := r.Group("/api/v1/stock-alerts")
+	alerts.Use(h.jwtService.Middleware())
+	{
+		alerts.POST("", h.CreateAlert)
+		alerts.GET("", h.ListAlerts)
+		alerts.DELETE("/:id", h.CancelAlert)
+	}
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Inventory event types consumed from the inventory events topic
+const (
+	EventStockChanged = "inventory.stock_changed"
+)
+
+// InventoryEvent is published by the inventory service when the available
+// stock of a SKU changes
+type InventoryEvent struct {
+	Type              string    `json:"type"`
+	ProductID         uuid.UUID `json:"product_id"`
+	SKU               string    `json:"sku"`
+	Available         int       `json:"available"`
+	PreviousAvailable int       `json:"previous_available"`
+	OccurredAt        time.Time `json:"occurred_at"`
+}
+
+// IsRestock reports whether the event brings a SKU back in stock
+func (e *InventoryEvent) IsRestock() bool {
+	return e.Type == EventStockChanged && e.PreviousAvailable <= 0 && e.Available > 0
+}
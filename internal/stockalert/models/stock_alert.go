@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AlertStatus represents the state of a back-in-stock alert
+type AlertStatus string
+
+const (
+	AlertStatusActive   AlertStatus = "active"
+	AlertStatusNotified AlertStatus = "notified"
+	AlertStatusExpired  AlertStatus = "expired"
+	AlertStatusCanceled AlertStatus = "canceled"
+)
+
+// IsValid reports whether the status is a known alert status
+func (s AlertStatus) IsValid() bool {
+	switch s {
+	case AlertStatusActive, AlertStatusNotified, AlertStatusExpired, AlertStatusCanceled:
+		return true
+	}
+	return false
+}
+
+// StockAlert is a customer's request to be emailed when an out-of-stock SKU
+// is available again. An alert fires once; alerts that haven't fired by
+// ExpiresAt expire.
+type StockAlert struct {
+	ID         uuid.UUID   `json:"id" db:"id"`
+	UserID     uuid.UUID   `json:"user_id" db:"user_id"`
+	ProductID  uuid.UUID   `json:"product_id" db:"product_id"`
+	SKU        string      `json:"sku" db:"sku"`
+	Status     AlertStatus `json:"status" db:"status"`
+	ExpiresAt  time.Time   `json:"expires_at" db:"expires_at"`
+	NotifiedAt *time.Time  `json:"notified_at,omitempty" db:"notified_at"`
+	CreatedAt  time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// AlertRecipient is an alert claimed for notification, with the contact
+// details of its customer
+type AlertRecipient struct {
+	AlertID   uuid.UUID `db:"id"`
+	ProductID uuid.UUID `db:"product_id"`
+	SKU       string    `db:"sku"`
+	Email     string    `db:"email"`
+	FirstName *string   `db:"first_name"`
+}
+
+// CreateAlertRequest represents a request to be alerted when a SKU is restocked
+type CreateAlertRequest struct {
+	ProductID uuid.UUID `json:"product_id" binding:"required"`
+	SKU       string    `json:"sku" binding:"required,max=100"`
+}
+
+// ListAlertsRequest represents the query parameters for listing a customer's alerts
+type ListAlertsRequest struct {
+	Status string `form:"status"`
+}
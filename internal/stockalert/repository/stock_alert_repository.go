@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/stockalert/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// maxListedAlerts bounds the number of alerts listed for a customer
+const maxListedAlerts = 100
+
+// StockAlertRepository defines the interface for stock alert data operations
+type StockAlertRepository interface {
+	CreateAlert(ctx context.Context, alert *models.StockAlert) error
+	GetAlert(ctx context.Context, id uuid.UUID) (*models.StockAlert, error)
+	ListAlerts(ctx context.Context, userID uuid.UUID, status models.AlertStatus) ([]*models.StockAlert, error)
+	CountActiveAlerts(ctx context.Context, userID uuid.UUID) (int, error)
+	CancelAlert(ctx context.Context, alert *models.StockAlert) error
+
+	// ClaimAlerts marks up to limit active alerts of a SKU as notified and
+	// returns them with their customers' contact details. Concurrent claims
+	// never return the same alert.
+	ClaimAlerts(ctx context.Context, sku string, limit int) ([]*models.AlertRecipient, error)
+	// ReleaseAlert makes a claimed alert active again, e.g. when its email
+	// could not be sent
+	ReleaseAlert(ctx context.Context, id uuid.UUID) error
+	// ExpireAlerts expires up to limit active alerts past their expiry
+	ExpireAlerts(ctx context.Context, limit int) (int, error)
+}
+
+// stockAlertRepository implements the StockAlertRepository interface
+type stockAlertRepository struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewStockAlertRepository creates a new stock alert repository
+func NewStockAlertRepository(db *database.DB, logger *logger.Logger) StockAlertRepository {
+	return &stockAlertRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const alertColumns = `id, user_id, product_id, sku, status, expires_at, notified_at, created_at, updated_at`
+
+// CreateAlert stores a new alert
+func (r *stockAlertRepository) CreateAlert(ctx context.Context, alert *models.StockAlert) error {
+	query := `
+		INSERT INTO stock_alerts (id, user_id, product_id, sku, status, expires_at)
+		VALUES (:id, :user_id, :product_id, :sku, :status, :expires_at)
+		RETURNING created_at, updated_at`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to prepare create stock alert statement", "error", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, alert).Scan(&alert.CreatedAt, &alert.UpdatedAt)
+	if err != nil {
+		if pgErr, ok := database.PgError(err); ok {
+			switch pgErr.Code {
+			case database.ForeignKeyViolation:
+				return apperrors.NotFound("user not found")
+			case database.UniqueViolation:
+				return apperrors.Conflict("stock alert for SKU %s already exists", alert.SKU)
+			}
+		}
+		r.logger.Error("Failed to create stock alert", "error", err, "user_id", alert.UserID, "sku", alert.SKU)
+		return fmt.Errorf("failed to create stock alert: %w", err)
+	}
+
+	return nil
+}
+
+// GetAlert retrieves an alert by ID
+func (r *stockAlertRepository) GetAlert(ctx context.Context, id uuid.UUID) (*models.StockAlert, error) {
+	alert := &models.StockAlert{}
+	query := `SELECT ` + alertColumns + ` FROM stock_alerts WHERE id = $1`
+
+	err := r.db.GetContext(ctx, alert, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("stock alert not found")
+		}
+		r.logger.Error("Failed to get stock alert", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to get stock alert: %w", err)
+	}
+
+	return alert, nil
+}
+
+// ListAlerts retrieves a customer's most recent alerts, optionally of one status only
+func (r *stockAlertRepository) ListAlerts(ctx context.Context, userID uuid.UUID, status models.AlertStatus) ([]*models.StockAlert, error) {
+	alerts := []*models.StockAlert{}
+	query := `
+		SELECT ` + alertColumns + `
+		FROM stock_alerts
+		WHERE user_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3`
+
+	err := r.db.SelectContext(ctx, &alerts, query, userID, status, maxListedAlerts)
+	if err != nil {
+		r.logger.Error("Failed to list stock alerts", "error", err, "user_id", userID)
+		return nil, fmt.Errorf("failed to list stock alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// CountActiveAlerts counts a customer's active alerts
+func (r *stockAlertRepository) CountActiveAlerts(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM stock_alerts WHERE user_id = $1 AND status = 'active'`
+
+	if err := r.db.GetContext(ctx, &count, query, userID); err != nil {
+		r.logger.Error("Failed to count stock alerts", "error", err, "user_id", userID)
+		return 0, fmt.Errorf("failed to count stock alerts: %w", err)
+	}
+
+	return count, nil
+}
+
+// CancelAlert cancels an active alert
+func (r *stockAlertRepository) CancelAlert(ctx context.Context, alert *models.StockAlert) error {
+	query := `
+		UPDATE stock_alerts SET status = 'canceled'
+		WHERE id = $1 AND status = 'active'
+		RETURNING status, updated_at`
+
+	err := r.db.QueryRowxContext(ctx, query, alert.ID).Scan(&alert.Status, &alert.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.Conflict("stock alert cannot be canceled once it is no longer active")
+		}
+		r.logger.Error("Failed to cancel stock alert", "error", err, "id", alert.ID)
+		return fmt.Errorf("failed to cancel stock alert: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimAlerts claims the oldest active alerts of a SKU first
+func (r *stockAlertRepository) ClaimAlerts(ctx context.Context, sku string, limit int) ([]*models.AlertRecipient, error) {
+	recipients := []*models.AlertRecipient{}
+	query := `
+		UPDATE stock_alerts sa
+		SET status = 'notified', notified_at = NOW()
+		FROM users u
+		WHERE u.id = sa.user_id
+		  AND sa.id IN (
+		      SELECT id FROM stock_alerts
+		      WHERE sku = $1 AND status = 'active' AND expires_at > NOW()
+		      ORDER BY created_at, id
+		      LIMIT $2
+		      FOR UPDATE SKIP LOCKED)
+		RETURNING sa.id, sa.product_id, sa.sku, u.email, u.first_name`
+
+	err := r.db.SelectContext(ctx, &recipients, query, sku, limit)
+	if err != nil {
+		r.logger.Error("Failed to claim stock alerts", "error", err, "sku", sku)
+		return nil, fmt.Errorf("failed to claim stock alerts: %w", err)
+	}
+
+	return recipients, nil
+}
+
+// ReleaseAlert makes a claimed alert active again
+func (r *stockAlertRepository) ReleaseAlert(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE stock_alerts SET status = 'active', notified_at = NULL WHERE id = $1 AND status = 'notified'`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		r.logger.Error("Failed to release stock alert", "error", err, "id", id)
+		return fmt.Errorf("failed to release stock alert: %w", err)
+	}
+
+	return nil
+}
+
+// ExpireAlerts expires active alerts past their expiry
+func (r *stockAlertRepository) ExpireAlerts(ctx context.Context, limit int) (int, error) {
+	query := `
+		UPDATE stock_alerts SET status = 'expired'
+		WHERE id IN (
+		    SELECT id FROM stock_alerts
+		    WHERE status = 'active' AND expires_at <= NOW()
+		    ORDER BY expires_at
+		    LIMIT $1
+		    FOR UPDATE SKIP LOCKED)`
+
+	result, err := r.db.ExecContext(ctx, query, limit)
+	if err != nil {
+		r.logger.Error("Failed to expire stock alerts", "error", err)
+		return 0, fmt.Errorf("failed to expire stock alerts: %w", err)
+	}
+
+	expired, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire stock alerts: %w", err)
+	}
+
+	return int(expired), nil
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// ExpiryWorker periodically expires stale stock alerts in the background
+type ExpiryWorker struct {
+	stockAlertService StockAlertService
+	interval          time.Duration
+	batchSize         int
+	logger            *logger.Logger
+}
+
+// NewExpiryWorker creates a new expiry worker
+func NewExpiryWorker(stockAlertService StockAlertService, interval time.Duration, batchSize int, logger *logger.Logger) *ExpiryWorker {
+	return &ExpiryWorker{
+		stockAlertService: stockAlertService,
+		interval:          interval,
+		batchSize:         batchSize,
+		logger:            logger,
+	}
+}
+
+// Run expires alerts until ctx is cancelled. Each tick works through all
+// stale alerts batch by batch.
+func (w *ExpiryWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				expired, err := w.stockAlertService.ExpireAlerts(ctx)
+				if err != nil {
+					w.logger.Error("Failed to expire stock alerts", "error", err)
+					break
+				}
+				if expired < w.batchSize {
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+
+	"github.com/kaanevranportfolio/Commercium/internal/stockalert/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+)
+
+// InventoryEventHandler returns a Kafka handler passing inventory events to
+// the stock alert service
+func InventoryEventHandler(stockAlertService StockAlertService) func(ctx context.Context, envelope *events.Envelope, event *models.InventoryEvent) error {
+	return func(ctx context.Context, envelope *events.Envelope, event *models.InventoryEvent) error {
+		// The envelope's type is authoritative over the one in the payload
+		event.Type = envelope.Type
+		return stockAlertService.HandleInventoryEvent(ctx, event)
+	}
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/stockalert/clients"
+	"github.com/kaanevranportfolio/Commercium/internal/stockalert/models"
+	"github.com/kaanevranportfolio/Commercium/internal/stockalert/repository"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/workerpool"
+)
+
+// templateBackInStock is the notification template of back-in-stock emails
+const templateBackInStock = "back_in_stock"
+
+// StockAlertService defines the interface for back-in-stock alert business logic
+type StockAlertService interface {
+	CreateAlert(ctx context.Context, userID uuid.UUID, req *models.CreateAlertRequest) (*models.StockAlert, error)
+	ListAlerts(ctx context.Context, userID uuid.UUID, req *models.ListAlertsRequest) ([]*models.StockAlert, error)
+	CancelAlert(ctx context.Context, userID, alertID uuid.UUID) (*models.StockAlert, error)
+
+	// HandleInventoryEvent notifies the customers waiting for a SKU when an
+	// inventory event brings it back in stock
+	HandleInventoryEvent(ctx context.Context, event *models.InventoryEvent) error
+	// ExpireAlerts expires a batch of stale alerts and returns how many expired
+	ExpireAlerts(ctx context.Context) (int, error)
+}
+
+// stockAlertService implements the StockAlertService interface
+type stockAlertService struct {
+	repo          repository.StockAlertRepository
+	notifications clients.NotificationClient
+	emails        *workerpool.Pool
+	config        *config.Config
+	logger        *logger.Logger
+}
+
+// NewStockAlertService creates a new stock alert service. Back-in-stock
+// emails are sent on the emails pool.
+func NewStockAlertService(
+	repo repository.StockAlertRepository,
+	notifications clients.NotificationClient,
+	emails *workerpool.Pool,
+	config *config.Config,
+	logger *logger.Logger,
+) StockAlertService {
+	return &stockAlertService{
+		repo:          repo,
+		notifications: notifications,
+		emails:        emails,
+		config:        config,
+		logger:        logger,
+	}
+}
+
+// CreateAlert subscribes a customer to a SKU. The alert expires after the
+// configured time if the SKU isn't restocked by then.
+func (s *stockAlertService) CreateAlert(ctx context.Context, userID uuid.UUID, req *models.CreateAlertRequest) (*models.StockAlert, error) {
+	sku := strings.TrimSpace(req.SKU)
+	if sku == "" {
+		return nil, apperrors.Validation("invalid SKU: must not be blank")
+	}
+
+	cfg := s.config.Services.StockAlert
+	active, err := s.repo.CountActiveAlerts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if active >= cfg.MaxAlertsPerUser {
+		return nil, apperrors.Conflict("stock alert cannot be created: at most %d alerts can be active", cfg.MaxAlertsPerUser)
+	}
+
+	alert := &models.StockAlert{
+		ID:        uuid.New(),
+		UserID:    userID,
+		ProductID: req.ProductID,
+		SKU:       sku,
+		Status:    models.AlertStatusActive,
+		ExpiresAt: time.Now().Add(cfg.AlertTTL),
+	}
+	if err := s.repo.CreateAlert(ctx, alert); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Stock alert created", "alert_id", alert.ID, "user_id", userID, "sku", sku)
+	return alert, nil
+}
+
+// ListAlerts returns a customer's alerts, most recent first
+func (s *stockAlertService) ListAlerts(ctx context.Context, userID uuid.UUID, req *models.ListAlertsRequest) ([]*models.StockAlert, error) {
+	status := models.AlertStatus(req.Status)
+	if status != "" && !status.IsValid() {
+		return nil, apperrors.Validation("invalid status: %s", req.Status)
+	}
+
+	return s.repo.ListAlerts(ctx, userID, status)
+}
+
+// CancelAlert cancels one of the customer's active alerts
+func (s *stockAlertService) CancelAlert(ctx context.Context, userID, alertID uuid.UUID) (*models.StockAlert, error) {
+	alert, err := s.repo.GetAlert(ctx, alertID)
+	if err != nil {
+		return nil, err
+	}
+	if alert.UserID != userID {
+		return nil, apperrors.NotFound("stock alert not found")
+	}
+
+	if err := s.repo.CancelAlert(ctx, alert); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Stock alert canceled", "alert_id", alert.ID, "user_id", userID)
+	return alert, nil
+}
+
+// HandleInventoryEvent fans a restock out to the SKU's alerts, batch by
+// batch, sending their emails concurrently on the email pool. Each alert is
+// claimed before its email is sent, so a customer is emailed once even if
+// the event is delivered twice. Alerts whose email fails are released once
+// all alerts were tried, and fire on the next restock instead.
+func (s *stockAlertService) HandleInventoryEvent(ctx context.Context, event *models.InventoryEvent) error {
+	if !event.IsRestock() || event.SKU == "" {
+		return nil
+	}
+
+	var (
+		mu       sync.Mutex
+		failed   []uuid.UUID
+		notified int
+	)
+	defer func() {
+		for _, alertID := range failed {
+			if err := s.repo.ReleaseAlert(ctx, alertID); err != nil {
+				s.logger.Error("Failed to release stock alert", "error", err, "alert_id", alertID)
+			}
+		}
+	}()
+
+	// Alerts are released once every email was tried
+	sends := s.emails.Group()
+	defer sends.Wait()
+
+	batchSize := s.config.Services.StockAlert.BatchSize
+	for {
+		recipients, err := s.repo.ClaimAlerts(ctx, event.SKU, batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, recipient := range recipients {
+			err := sends.Go(ctx, func(ctx context.Context) error {
+				err := s.sendBackInStockEmail(ctx, recipient)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					failed = append(failed, recipient.AlertID)
+					return fmt.Errorf("failed to send back-in-stock email for alert %s: %w", recipient.AlertID, err)
+				}
+				notified++
+				return nil
+			})
+			if err != nil {
+				s.logger.Error("Failed to send back-in-stock email", "error", err, "alert_id", recipient.AlertID)
+				mu.Lock()
+				failed = append(failed, recipient.AlertID)
+				mu.Unlock()
+			}
+		}
+
+		if len(recipients) < batchSize {
+			break
+		}
+	}
+
+	sends.Wait()
+	s.logger.Info("Back-in-stock alerts sent", "sku", event.SKU, "count", notified, "failed", len(failed))
+	return nil
+}
+
+// sendBackInStockEmail emails a customer that the SKU they wait for is available
+func (s *stockAlertService) sendBackInStockEmail(ctx context.Context, recipient *models.AlertRecipient) error {
+	data := map[string]interface{}{
+		"product_id": recipient.ProductID.String(),
+		"sku":        recipient.SKU,
+	}
+	if recipient.FirstName != nil {
+		data["first_name"] = *recipient.FirstName
+	}
+
+	return s.notifications.SendEmail(ctx, &clients.SendEmailRequest{
+		Template: templateBackInStock,
+		To:       recipient.Email,
+		Data:     data,
+	})
+}
+
+// ExpireAlerts expires a batch of alerts past their expiry
+func (s *stockAlertService) ExpireAlerts(ctx context.Context) (int, error) {
+	expired, err := s.repo.ExpireAlerts(ctx, s.config.Services.StockAlert.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	if expired > 0 {
+		s.logger.Info("Stock alerts expired", "count", expired)
+	}
+	return expired, nil
+}
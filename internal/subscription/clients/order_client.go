@@ -0,0 +1,96 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/subscription/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/resilience"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// OrderItem is a line item of a renewal order
+type OrderItem struct {
+	ProductID uuid.UUID `json:"product_id"`
+	SKU       string    `json:"sku"`
+	Name      string    `json:"name"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice int64     `json:"unit_price"`
+}
+
+// CreateOrderRequest asks the order service to place a renewal order.
+// Repeating a request with the same ID returns the order placed first.
+type CreateOrderRequest struct {
+	ID              uuid.UUID       `json:"id"`
+	UserID          uuid.UUID       `json:"user_id"`
+	Currency        string          `json:"currency"`
+	Items           []*OrderItem    `json:"items"`
+	ShippingAddress *models.Address `json:"shipping_address"`
+	DiscountAmount  int64           `json:"discount_amount"`
+	Notes           *string         `json:"notes,omitempty"`
+}
+
+// Order is the part of an order the subscription service uses
+type Order struct {
+	ID          uuid.UUID `json:"id"`
+	OrderNumber string    `json:"order_number"`
+	Currency    string    `json:"currency"`
+	TotalAmount int64     `json:"total_amount"`
+}
+
+// OrderClient defines the order operations the subscription service depends on
+type OrderClient interface {
+	CreateOrder(ctx context.Context, req *CreateOrderRequest) (*Order, error)
+}
+
+// httpOrderClient calls the order service over its internal HTTP API
+type httpOrderClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewOrderClient creates a new order service client, calling it through
+// dependency
+func NewOrderClient(baseURL string, dependency *resilience.Dependency) OrderClient {
+	// Services called continue the trace, with its baggage
+	return &httpOrderClient{
+		baseURL:    baseURL,
+		httpClient: dependency.HTTPClient(tracing.NewTransport(nil)),
+	}
+}
+
+// CreateOrder places an order at the given prices
+func (c *httpOrderClient) CreateOrder(ctx context.Context, req *CreateOrderRequest) (*Order, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal create order request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/internal/v1/orders", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create order request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call order service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("order service returned status %d", resp.StatusCode)
+	}
+
+	result := &Order{}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, fmt.Errorf("failed to decode create order response: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,104 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/resilience"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// ChargeRequest asks the payment service to charge a renewal order to a
+// saved payment method
+type ChargeRequest struct {
+	UserID            uuid.UUID `json:"user_id"`
+	OrderID           uuid.UUID `json:"order_id"`
+	PaymentMethod     string    `json:"payment_method"`
+	PaymentMethodType string    `json:"payment_method_type,omitempty"`
+	Provider          string    `json:"provider,omitempty"`
+	IdempotencyKey    string    `json:"-"`
+}
+
+// Payment is the part of a payment the subscription service uses
+type Payment struct {
+	ID     uuid.UUID `json:"id"`
+	Status string    `json:"status"`
+	Amount int64     `json:"amount"`
+}
+
+// DeclinedError is returned when the payment service refused a charge,
+// e.g. because the card was declined or the payment method is invalid.
+// Unlike other errors, retrying the same charge right away won't help.
+type DeclinedError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *DeclinedError) Error() string {
+	return fmt.Sprintf("payment declined: %s", e.Message)
+}
+
+// PaymentClient defines the payment operations the subscription service depends on
+type PaymentClient interface {
+	Charge(ctx context.Context, req *ChargeRequest) (*Payment, error)
+}
+
+// httpPaymentClient calls the payment service over its internal HTTP API
+type httpPaymentClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewPaymentClient creates a new payment service client, calling it through
+// dependency
+func NewPaymentClient(baseURL string, dependency *resilience.Dependency) PaymentClient {
+	// Services called continue the trace, with its baggage
+	return &httpPaymentClient{
+		baseURL:    baseURL,
+		httpClient: dependency.HTTPClient(tracing.NewTransport(nil)),
+	}
+}
+
+// Charge authorizes and captures the total of an order
+func (c *httpPaymentClient) Charge(ctx context.Context, req *ChargeRequest) (*Payment, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal charge request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/internal/v1/payments/recurring", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create charge request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Idempotency-Key", req.IdempotencyKey)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call payment service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&errResp)
+		return nil, &DeclinedError{StatusCode: resp.StatusCode, Message: errResp.Error}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("payment service returned status %d", resp.StatusCode)
+	}
+
+	result := &Payment{}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, fmt.Errorf("failed to decode charge response: %w", err)
+	}
+
+	return result, nil
+}
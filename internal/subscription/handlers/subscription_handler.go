@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/subscription/models"
+	"github.com/kaanevranportfolio/Commercium/internal/subscription/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/validation"
+)
+
+// SubscriptionHandler handles HTTP requests for subscription operations
+type SubscriptionHandler struct {
+	subscriptionService service.SubscriptionService
+	jwtService          *auth.JWTService
+	logger              *logger.Logger
+}
+
+// NewSubscriptionHandler creates a new subscription handler
+func NewSubscriptionHandler(subscriptionService service.SubscriptionService, jwtService *auth.JWTService, logger *logger.Logger) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		subscriptionService: subscriptionService,
+		jwtService:          jwtService,
+		logger:              logger,
+	}
+}
+
+// ListPlans lists the plans customers can subscribe to
+func (h *SubscriptionHandler) ListPlans(c *gin.Context) {
+	plans, err := h.subscriptionService.ListPlans(c.Request.Context(), false)
+	if err != nil {
+		h.respondError(c, err, "Failed to list plans")
+		return
+	}
+
+	httpx.Items(c, plans)
+}
+
+// GetPlan returns a plan
+func (h *SubscriptionHandler) GetPlan(c *gin.Context) {
+	planID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid plan ID"})
+		return
+	}
+
+	plan, err := h.subscriptionService.GetPlan(c.Request.Context(), planID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get plan")
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// AdminListPlans lists every plan, including retired ones (admin)
+func (h *SubscriptionHandler) AdminListPlans(c *gin.Context) {
+	var req models.ListPlansRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+
+	plans, err := h.subscriptionService.ListPlans(c.Request.Context(), req.IncludeInactive)
+	if err != nil {
+		h.respondError(c, err, "Failed to list plans")
+		return
+	}
+
+	httpx.Items(c, plans)
+}
+
+// CreatePlan creates a subscription plan (admin)
+func (h *SubscriptionHandler) CreatePlan(c *gin.Context) {
+	var req models.CreatePlanRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	plan, err := h.subscriptionService.CreatePlan(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to create plan")
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+// UpdatePlan renames or retires a plan (admin)
+func (h *SubscriptionHandler) UpdatePlan(c *gin.Context) {
+	planID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid plan ID"})
+		return
+	}
+
+	var req models.UpdatePlanRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	plan, err := h.subscriptionService.UpdatePlan(c.Request.Context(), planID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to update plan")
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// Subscribe subscribes the customer to a plan
+func (h *SubscriptionHandler) Subscribe(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.SubscribeRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	sub, err := h.subscriptionService.Subscribe(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.logger.Error("Failed to subscribe", "error", err, "user_id", userID, "plan_id", req.PlanID)
+		h.respondError(c, err, "Failed to subscribe")
+		return
+	}
+
+	c.JSON(http.StatusCreated, sub)
+}
+
+// ListSubscriptions lists the customer's subscriptions
+func (h *SubscriptionHandler) ListSubscriptions(c *gin.Context) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	subs, err := h.subscriptionService.ListSubscriptions(c.Request.Context(), userID)
+	if err != nil {
+		h.respondError(c, err, "Failed to list subscriptions")
+		return
+	}
+
+	httpx.Items(c, subs)
+}
+
+// GetSubscription returns one of the customer's subscriptions
+func (h *SubscriptionHandler) GetSubscription(c *gin.Context) {
+	userID, subID, ok := h.subscriptionParams(c)
+	if !ok {
+		return
+	}
+
+	sub, err := h.subscriptionService.GetSubscription(c.Request.Context(), userID, subID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get subscription")
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// ListRenewals lists the billing history of a subscription
+func (h *SubscriptionHandler) ListRenewals(c *gin.Context) {
+	userID, subID, ok := h.subscriptionParams(c)
+	if !ok {
+		return
+	}
+
+	renewals, err := h.subscriptionService.ListRenewals(c.Request.Context(), userID, subID)
+	if err != nil {
+		h.respondError(c, err, "Failed to list renewals")
+		return
+	}
+
+	httpx.Items(c, renewals)
+}
+
+// ChangePlan moves a subscription to another plan or quantity
+func (h *SubscriptionHandler) ChangePlan(c *gin.Context) {
+	userID, subID, ok := h.subscriptionParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.ChangePlanRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	sub, err := h.subscriptionService.ChangePlan(c.Request.Context(), userID, subID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to change plan")
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// Pause pauses a subscription
+func (h *SubscriptionHandler) Pause(c *gin.Context) {
+	userID, subID, ok := h.subscriptionParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.PauseRequest
+	if !validation.BindOptionalJSON(c, &req) {
+		return
+	}
+
+	sub, err := h.subscriptionService.Pause(c.Request.Context(), userID, subID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to pause subscription")
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// Resume resumes a paused subscription or keeps one scheduled for cancellation
+func (h *SubscriptionHandler) Resume(c *gin.Context) {
+	userID, subID, ok := h.subscriptionParams(c)
+	if !ok {
+		return
+	}
+
+	sub, err := h.subscriptionService.Resume(c.Request.Context(), userID, subID)
+	if err != nil {
+		h.respondError(c, err, "Failed to resume subscription")
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// Cancel cancels a subscription now or at the end of the paid period
+func (h *SubscriptionHandler) Cancel(c *gin.Context) {
+	userID, subID, ok := h.subscriptionParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.CancelRequest
+	if !validation.BindOptionalJSON(c, &req) {
+		return
+	}
+
+	sub, err := h.subscriptionService.Cancel(c.Request.Context(), userID, subID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to cancel subscription")
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// UpdatePaymentMethod changes the payment method renewals are charged to
+func (h *SubscriptionHandler) UpdatePaymentMethod(c *gin.Context) {
+	userID, subID, ok := h.subscriptionParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdatePaymentMethodRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	sub, err := h.subscriptionService.UpdatePaymentMethod(c.Request.Context(), userID, subID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to update payment method")
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// subscriptionParams reads the authenticated customer and the subscription
+// ID, writing an error response if either is missing
+func (h *SubscriptionHandler) subscriptionParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID := auth.UserIDFromContext(c)
+	if userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	subID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, subID, true
+}
+
+// respondError logs a request failing on the side of the service, or of a
+// provider it depends on, and answers it with the problem details of err,
+// so middleware reading its status after it, such as idempotency's, sees
+// the failure
+func (h *SubscriptionHandler) respondError(c *gin.Context, err error, message string) {
+	if apperrors.HTTPStatus(err) >= http.StatusInternalServerError {
+		logger.FromContext(c.Request.Context()).Error(message, "error", err)
+	}
+	apperrors.Respond(c, err)
+}
+
+// SetupRoutes sets up the subscription routes
+func (h *SubscriptionHandler) SetupRoutes(r *gin.Engine) {
+	plans := r.Group("/api/v1/subscription-plans")
+	{
+		plans.GET("", h.ListPlans)
+		plans.GET("/:id", h.GetPlan)
+	}
+
+	subscriptions := r.Group("/api/v1/subscriptions")
+	subscriptions.Use(h.jwtService.Middleware())
+	{
+		subscriptions.GET("", h.ListSubscriptions)
+		subscriptions.POST("", h.Subscribe)
+		subscriptions.GET("/:id", h.GetSubscription)
+		subscriptions.GET("/:id/renewals", h.ListRenewals)
+		subscriptions.PUT("/:id/plan", h.ChangePlan)
+		subscriptions.POST("/:id/pause", h.Pause)
+		subscriptions.POST("/:id/resume", h.Resume)
+		subscriptions.POST("/:id/cancel", h.Cancel)
+		subscriptions.PUT("/:id/payment-method", h.UpdatePaymentMethod)
+	}
+
+	admin := r.Group("/api/v1/admin")
+	admin.Use(h.jwtService.Middleware(), auth.RequireRole("admin"))
+	{
+		admin.GET("/subscription-plans", h.AdminListPlans)
+		admin.POST("/subscription-plans", h.CreatePlan)
+		admin.PUT("/subscription-plans/:id", h.UpdatePlan)
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Subscription event types published to the subscription events topic
+const (
+	EventSubscriptionCreated       = "subscription.created"
+	EventSubscriptionRenewed       = "subscription.renewed"
+	EventSubscriptionPaymentFailed = "subscription.payment_failed"
+	EventSubscriptionPlanChanged   = "subscription.plan_changed"
+	EventSubscriptionPaused        = "subscription.paused"
+	EventSubscriptionResumed       = "subscription.resumed"
+	EventSubscriptionCanceled      = "subscription.canceled"
+)
+
+// SubscriptionEventSchemaVersion is the schema version of SubscriptionEvent payloads
+const SubscriptionEventSchemaVersion = 1
+
+// SubscriptionEvent is published whenever the state of a subscription changes
+type SubscriptionEvent struct {
+	Type           string     `json:"type"`
+	SubscriptionID uuid.UUID  `json:"subscription_id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	PlanID         uuid.UUID  `json:"plan_id"`
+	Status         string     `json:"status"`
+	OrderID        *uuid.UUID `json:"order_id,omitempty"`
+	Amount         int64      `json:"amount,omitempty"`
+	Currency       string     `json:"currency,omitempty"`
+	Reason         string     `json:"reason,omitempty"`
+	OccurredAt     time.Time  `json:"occurred_at"`
+}
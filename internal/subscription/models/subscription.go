@@ -0,0 +1,240 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IntervalUnit is the unit of a plan's billing interval
+type IntervalUnit string
+
+// Billing interval units
+const (
+	IntervalDay   IntervalUnit = "day"
+	IntervalWeek  IntervalUnit = "week"
+	IntervalMonth IntervalUnit = "month"
+	IntervalYear  IntervalUnit = "year"
+)
+
+// IsValid reports whether the unit is a known interval unit
+func (u IntervalUnit) IsValid() bool {
+	switch u {
+	case IntervalDay, IntervalWeek, IntervalMonth, IntervalYear:
+		return true
+	}
+	return false
+}
+
+// SubscriptionStatus represents the billing state of a subscription
+type SubscriptionStatus string
+
+// Subscription statuses
+const (
+	SubscriptionStatusTrialing SubscriptionStatus = "trialing"
+	SubscriptionStatusActive   SubscriptionStatus = "active"
+	SubscriptionStatusPastDue  SubscriptionStatus = "past_due"
+	SubscriptionStatusPaused   SubscriptionStatus = "paused"
+	SubscriptionStatusCanceled SubscriptionStatus = "canceled"
+)
+
+// RenewalStatus represents the payment state of a renewal
+type RenewalStatus string
+
+// Renewal statuses
+const (
+	RenewalStatusPending RenewalStatus = "pending"
+	RenewalStatusPaid    RenewalStatus = "paid"
+	RenewalStatusFailed  RenewalStatus = "failed"
+)
+
+// Plan bills a quantity of one product every interval. Plans are never
+// repriced; a new plan is created instead and customers are moved to it.
+type Plan struct {
+	ID            uuid.UUID    `json:"id" db:"id"`
+	Code          string       `json:"code" db:"code"`
+	Name          string       `json:"name" db:"name"`
+	ProductID     uuid.UUID    `json:"product_id" db:"product_id"`
+	SKU           string       `json:"sku" db:"sku"`
+	Currency      string       `json:"currency" db:"currency"`
+	Amount        int64        `json:"amount" db:"amount"`
+	IntervalUnit  IntervalUnit `json:"interval_unit" db:"interval_unit"`
+	IntervalCount int          `json:"interval_count" db:"interval_count"`
+	TrialDays     int          `json:"trial_days" db:"trial_days"`
+	IsActive      bool         `json:"is_active" db:"is_active"`
+	CreatedAt     time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// PeriodEnd returns the end of a billing period starting at start
+func (p *Plan) PeriodEnd(start time.Time) time.Time {
+	switch p.IntervalUnit {
+	case IntervalDay:
+		return start.AddDate(0, 0, p.IntervalCount)
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7*p.IntervalCount)
+	case IntervalYear:
+		return start.AddDate(p.IntervalCount, 0, 0)
+	default:
+		return start.AddDate(0, p.IntervalCount, 0)
+	}
+}
+
+// SameInterval reports whether two plans bill on the same schedule
+func (p *Plan) SameInterval(other *Plan) bool {
+	return p.IntervalUnit == other.IntervalUnit && p.IntervalCount == other.IntervalCount
+}
+
+// Address is the address renewal orders are shipped to.
+// Its JSON form matches the address snapshots stored with orders.
+type Address struct {
+	FirstName    string  `json:"first_name" binding:"required,max=100"`
+	LastName     string  `json:"last_name" binding:"required,max=100"`
+	Company      *string `json:"company,omitempty" binding:"omitempty,max=100"`
+	AddressLine1 string  `json:"address_line1" binding:"required,max=255"`
+	AddressLine2 *string `json:"address_line2,omitempty" binding:"omitempty,max=255"`
+	City         string  `json:"city" binding:"required,max=100"`
+	State        *string `json:"state,omitempty" binding:"omitempty,max=100"`
+	PostalCode   string  `json:"postal_code" binding:"required,max=20"`
+	Country      string  `json:"country" binding:"required,country"`
+	Phone        *string `json:"phone,omitempty" binding:"omitempty,max=30,phone"`
+}
+
+// Value implements driver.Valuer so addresses can be stored as JSONB
+func (a Address) Value() (driver.Value, error) {
+	data, err := json.Marshal(a)
+	return string(data), err
+}
+
+// Scan implements sql.Scanner so addresses can be read from JSONB
+func (a *Address) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return fmt.Errorf("cannot scan %T into Address", src)
+	}
+}
+
+// Subscription is a customer's subscription to a plan. Trialing and active
+// subscriptions renew at CurrentPeriodEnd; past due subscriptions retry the
+// failed renewal at NextAttemptAt; paused subscriptions resume at ResumeAt.
+type Subscription struct {
+	ID                 uuid.UUID          `json:"id" db:"id"`
+	UserID             uuid.UUID          `json:"user_id" db:"user_id"`
+	PlanID             uuid.UUID          `json:"plan_id" db:"plan_id"`
+	Status             SubscriptionStatus `json:"status" db:"status"`
+	Quantity           int                `json:"quantity" db:"quantity"`
+	PaymentMethod      string             `json:"-" db:"payment_method"`
+	PaymentMethodType  *string            `json:"payment_method_type,omitempty" db:"payment_method_type"`
+	PaymentProvider    *string            `json:"payment_provider,omitempty" db:"payment_provider"`
+	ShippingAddress    Address            `json:"shipping_address" db:"shipping_address"`
+	CurrentPeriodStart time.Time          `json:"current_period_start" db:"current_period_start"`
+	CurrentPeriodEnd   time.Time          `json:"current_period_end" db:"current_period_end"`
+	CancelAtPeriodEnd  bool               `json:"cancel_at_period_end" db:"cancel_at_period_end"`
+	PausedAt           *time.Time         `json:"paused_at,omitempty" db:"paused_at"`
+	ResumeAt           *time.Time         `json:"resume_at,omitempty" db:"resume_at"`
+	CanceledAt         *time.Time         `json:"canceled_at,omitempty" db:"canceled_at"`
+	CancellationReason *string            `json:"cancellation_reason,omitempty" db:"cancellation_reason"`
+	ProrationBalance   int64              `json:"proration_balance" db:"proration_balance"`
+	FailedAttempts     int                `json:"failed_attempts" db:"failed_attempts"`
+	NextAttemptAt      *time.Time         `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	ClaimedUntil       *time.Time         `json:"-" db:"claimed_until"`
+	CreatedAt          time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time          `json:"updated_at" db:"updated_at"`
+
+	Plan *Plan `json:"plan,omitempty" db:"-"`
+}
+
+// Renewal is the charge for one billing period of a subscription
+type Renewal struct {
+	ID              uuid.UUID     `json:"id" db:"id"`
+	SubscriptionID  uuid.UUID     `json:"subscription_id" db:"subscription_id"`
+	PeriodStart     time.Time     `json:"period_start" db:"period_start"`
+	PeriodEnd       time.Time     `json:"period_end" db:"period_end"`
+	OrderID         uuid.UUID     `json:"order_id" db:"order_id"`
+	Amount          int64         `json:"amount" db:"amount"`
+	ProrationAmount int64         `json:"proration_amount" db:"proration_amount"`
+	Status          RenewalStatus `json:"status" db:"status"`
+	Attempts        int           `json:"attempts" db:"attempts"`
+	LastError       *string       `json:"last_error,omitempty" db:"last_error"`
+	PaidAt          *time.Time    `json:"paid_at,omitempty" db:"paid_at"`
+	CreatedAt       time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// Contact holds what renewal emails are addressed with
+type Contact struct {
+	Email     string  `db:"email"`
+	FirstName *string `db:"first_name"`
+}
+
+// CreatePlanRequest represents a request to create a subscription plan
+type CreatePlanRequest struct {
+	Code          string       `json:"code" binding:"required,max=50"`
+	Name          string       `json:"name" binding:"required,max=100"`
+	ProductID     uuid.UUID    `json:"product_id" binding:"required"`
+	SKU           string       `json:"sku" binding:"required,max=100"`
+	Currency      string       `json:"currency" binding:"required,len=3"`
+	Amount        int64        `json:"amount" binding:"min=0"`
+	IntervalUnit  IntervalUnit `json:"interval_unit" binding:"required"`
+	IntervalCount int          `json:"interval_count" binding:"omitempty,min=1,max=36"`
+	TrialDays     int          `json:"trial_days" binding:"min=0,max=365"`
+}
+
+// UpdatePlanRequest represents a request to rename or retire a plan
+type UpdatePlanRequest struct {
+	Name     *string `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	IsActive *bool   `json:"is_active,omitempty"`
+}
+
+// SubscribeRequest represents a request to subscribe to a plan.
+// PaymentMethod is the provider's token for a reusable payment method.
+type SubscribeRequest struct {
+	PlanID            uuid.UUID `json:"plan_id" binding:"required"`
+	Quantity          int       `json:"quantity" binding:"omitempty,min=1,max=100"`
+	PaymentMethod     string    `json:"payment_method" binding:"required,max=255"`
+	PaymentMethodType string    `json:"payment_method_type,omitempty" binding:"max=50"`
+	Provider          string    `json:"provider,omitempty" binding:"max=50"`
+	ShippingAddress   Address   `json:"shipping_address" binding:"required"`
+}
+
+// ChangePlanRequest represents a request to move a subscription to another
+// plan or quantity. The difference is prorated over the rest of the period.
+type ChangePlanRequest struct {
+	PlanID   uuid.UUID `json:"plan_id" binding:"required"`
+	Quantity int       `json:"quantity" binding:"omitempty,min=1,max=100"`
+}
+
+// PauseRequest represents a request to pause a subscription. Without
+// ResumeAt the subscription stays paused until it is resumed.
+type PauseRequest struct {
+	ResumeAt *time.Time `json:"resume_at,omitempty"`
+}
+
+// CancelRequest represents a request to cancel a subscription
+type CancelRequest struct {
+	// AtPeriodEnd keeps the subscription until the paid period ends
+	AtPeriodEnd bool   `json:"at_period_end"`
+	Reason      string `json:"reason,omitempty" binding:"max=500"`
+}
+
+// UpdatePaymentMethodRequest represents a request to change the payment
+// method renewals are charged to
+type UpdatePaymentMethodRequest struct {
+	PaymentMethod     string `json:"payment_method" binding:"required,max=255"`
+	PaymentMethodType string `json:"payment_method_type,omitempty" binding:"max=50"`
+	Provider          string `json:"provider,omitempty" binding:"max=50"`
+}
+
+// ListPlansRequest filters the plans listed to admins
+type ListPlansRequest struct {
+	IncludeInactive bool `form:"include_inactive"`
+}
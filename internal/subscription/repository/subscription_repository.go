@@ -0,0 +1,409 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kaanevranportfolio/Commercium/internal/subscription/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// SubscriptionRepository defines the interface for subscription data operations
+type SubscriptionRepository interface {
+	// Plan operations
+	CreatePlan(ctx context.Context, plan *models.Plan) error
+	GetPlan(ctx context.Context, id uuid.UUID) (*models.Plan, error)
+	ListPlans(ctx context.Context, includeInactive bool) ([]*models.Plan, error)
+	UpdatePlan(ctx context.Context, plan *models.Plan) error
+
+	// Subscription operations
+	CreateSubscription(ctx context.Context, sub *models.Subscription) error
+	GetSubscription(ctx context.Context, id uuid.UUID) (*models.Subscription, error)
+	ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]*models.Subscription, error)
+	UpdateSubscription(ctx context.Context, sub *models.Subscription) error
+	// ClaimDueSubscriptions selects up to limit subscriptions due for a
+	// renewal, retry or resumption and claims them for lease
+	ClaimDueSubscriptions(ctx context.Context, lease time.Duration, limit int) ([]*models.Subscription, error)
+
+	// Renewal operations
+	GetRenewal(ctx context.Context, subscriptionID uuid.UUID, periodStart time.Time) (*models.Renewal, error)
+	// CreateRenewal stores a renewal and moves its proration amount off the
+	// subscription's balance
+	CreateRenewal(ctx context.Context, renewal *models.Renewal) error
+	ListRenewals(ctx context.Context, subscriptionID uuid.UUID) ([]*models.Renewal, error)
+	// SaveRenewal stores the outcome of a renewal attempt together with the
+	// subscription it moved forward or put past due
+	SaveRenewal(ctx context.Context, sub *models.Subscription, renewal *models.Renewal) error
+
+	// GetContact returns the email address of a customer
+	GetContact(ctx context.Context, userID uuid.UUID) (*models.Contact, error)
+}
+
+// subscriptionRepository implements the SubscriptionRepository interface
+type subscriptionRepository struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewSubscriptionRepository creates a new subscription repository
+func NewSubscriptionRepository(db *database.DB, logger *logger.Logger) SubscriptionRepository {
+	return &subscriptionRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const planColumns = `id, code, name, product_id, sku, currency, amount, interval_unit, interval_count,
+	trial_days, is_active, created_at, updated_at`
+
+const subscriptionColumns = `id, user_id, plan_id, status, quantity, payment_method, payment_method_type,
+	payment_provider, shipping_address, current_period_start, current_period_end, cancel_at_period_end,
+	paused_at, resume_at, canceled_at, cancellation_reason, proration_balance, failed_attempts,
+	next_attempt_at, claimed_until, created_at, updated_at`
+
+const renewalColumns = `id, subscription_id, period_start, period_end, order_id, amount, proration_amount,
+	status, attempts, last_error, paid_at, created_at, updated_at`
+
+// CreatePlan stores a new subscription plan
+func (r *subscriptionRepository) CreatePlan(ctx context.Context, plan *models.Plan) error {
+	query := `
+		INSERT INTO subscription_plans (id, code, name, product_id, sku, currency, amount,
+		                                interval_unit, interval_count, trial_days, is_active)
+		VALUES (:id, :code, :name, :product_id, :sku, :currency, :amount,
+		        :interval_unit, :interval_count, :trial_days, :is_active)
+		RETURNING created_at, updated_at`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to prepare create plan statement", "error", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, plan).Scan(&plan.CreatedAt, &plan.UpdatedAt)
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return apperrors.Conflict("plan already exists: %s", plan.Code)
+		}
+		r.logger.Error("Failed to create plan", "error", err, "code", plan.Code)
+		return fmt.Errorf("failed to create plan: %w", err)
+	}
+
+	return nil
+}
+
+// GetPlan retrieves a plan by ID
+func (r *subscriptionRepository) GetPlan(ctx context.Context, id uuid.UUID) (*models.Plan, error) {
+	plan := &models.Plan{}
+	query := `SELECT ` + planColumns + ` FROM subscription_plans WHERE id = $1`
+
+	err := r.db.GetContext(ctx, plan, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("plan not found")
+		}
+		r.logger.Error("Failed to get plan", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	return plan, nil
+}
+
+// ListPlans retrieves the active plans, or all plans if includeInactive is set
+func (r *subscriptionRepository) ListPlans(ctx context.Context, includeInactive bool) ([]*models.Plan, error) {
+	plans := []*models.Plan{}
+	query := `
+		SELECT ` + planColumns + `
+		FROM subscription_plans
+		WHERE is_active OR $1
+		ORDER BY currency, amount, code`
+
+	err := r.db.SelectContext(ctx, &plans, query, includeInactive)
+	if err != nil {
+		r.logger.Error("Failed to list plans", "error", err)
+		return nil, fmt.Errorf("failed to list plans: %w", err)
+	}
+
+	return plans, nil
+}
+
+// UpdatePlan updates the name and status of a plan
+func (r *subscriptionRepository) UpdatePlan(ctx context.Context, plan *models.Plan) error {
+	query := `
+		UPDATE subscription_plans
+		SET name = $2, is_active = $3
+		WHERE id = $1
+		RETURNING updated_at`
+
+	err := r.db.QueryRowxContext(ctx, query, plan.ID, plan.Name, plan.IsActive).Scan(&plan.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.NotFound("plan not found")
+		}
+		r.logger.Error("Failed to update plan", "error", err, "id", plan.ID)
+		return fmt.Errorf("failed to update plan: %w", err)
+	}
+
+	return nil
+}
+
+// CreateSubscription stores a new subscription
+func (r *subscriptionRepository) CreateSubscription(ctx context.Context, sub *models.Subscription) error {
+	query := `
+		INSERT INTO subscriptions (id, user_id, plan_id, status, quantity, payment_method, payment_method_type,
+		                           payment_provider, shipping_address, current_period_start, current_period_end,
+		                           claimed_until)
+		VALUES (:id, :user_id, :plan_id, :status, :quantity, :payment_method, :payment_method_type,
+		        :payment_provider, :shipping_address, :current_period_start, :current_period_end,
+		        :claimed_until)
+		RETURNING created_at, updated_at`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Failed to prepare create subscription statement", "error", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, sub).Scan(&sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		if database.IsForeignKeyViolation(err) {
+			return apperrors.NotFound("user not found")
+		}
+		r.logger.Error("Failed to create subscription", "error", err, "user_id", sub.UserID)
+		return fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	return nil
+}
+
+// GetSubscription retrieves a subscription by ID
+func (r *subscriptionRepository) GetSubscription(ctx context.Context, id uuid.UUID) (*models.Subscription, error) {
+	sub := &models.Subscription{}
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id = $1`
+
+	err := r.db.GetContext(ctx, sub, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("subscription not found")
+		}
+		r.logger.Error("Failed to get subscription", "error", err, "id", id)
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+// ListSubscriptions retrieves the subscriptions of a customer, newest first
+func (r *subscriptionRepository) ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]*models.Subscription, error) {
+	subs := []*models.Subscription{}
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+
+	err := r.db.SelectContext(ctx, &subs, query, userID)
+	if err != nil {
+		r.logger.Error("Failed to list subscriptions", "error", err, "user_id", userID)
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	return subs, nil
+}
+
+const updateSubscriptionQuery = `
+	UPDATE subscriptions
+	SET plan_id = :plan_id, status = :status, quantity = :quantity, payment_method = :payment_method,
+	    payment_method_type = :payment_method_type, payment_provider = :payment_provider,
+	    current_period_start = :current_period_start, current_period_end = :current_period_end,
+	    cancel_at_period_end = :cancel_at_period_end, paused_at = :paused_at, resume_at = :resume_at,
+	    canceled_at = :canceled_at, cancellation_reason = :cancellation_reason,
+	    proration_balance = :proration_balance, failed_attempts = :failed_attempts,
+	    next_attempt_at = :next_attempt_at, claimed_until = :claimed_until
+	WHERE id = :id
+	RETURNING updated_at`
+
+// UpdateSubscription updates the plan, status and billing state of a subscription
+func (r *subscriptionRepository) UpdateSubscription(ctx context.Context, sub *models.Subscription) error {
+	stmt, err := r.db.PrepareNamedContext(ctx, updateSubscriptionQuery)
+	if err != nil {
+		r.logger.Error("Failed to prepare update subscription statement", "error", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowxContext(ctx, sub).Scan(&sub.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.NotFound("subscription not found")
+		}
+		r.logger.Error("Failed to update subscription", "error", err, "id", sub.ID)
+		return fmt.Errorf("failed to update subscription: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDueSubscriptions selects subscriptions whose period ended, whose
+// failed renewal is due for a retry or whose pause is over, oldest first,
+// and claims them so concurrent workers skip them. A claim that isn't
+// released because the worker died expires after lease.
+func (r *subscriptionRepository) ClaimDueSubscriptions(ctx context.Context, lease time.Duration, limit int) ([]*models.Subscription, error) {
+	subs := []*models.Subscription{}
+	query := `
+		UPDATE subscriptions
+		SET claimed_until = NOW() + $1 * INTERVAL '1 second'
+		WHERE id IN (
+			SELECT id FROM subscriptions
+			WHERE ((status IN ('trialing', 'active') AND current_period_end <= NOW())
+			    OR (status = 'past_due' AND next_attempt_at <= NOW())
+			    OR (status = 'paused' AND resume_at <= NOW()))
+			  AND (claimed_until IS NULL OR claimed_until <= NOW())
+			ORDER BY current_period_end
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + subscriptionColumns
+
+	err := r.db.SelectContext(ctx, &subs, query, lease.Seconds(), limit)
+	if err != nil {
+		r.logger.Error("Failed to claim due subscriptions", "error", err)
+		return nil, fmt.Errorf("failed to claim due subscriptions: %w", err)
+	}
+
+	return subs, nil
+}
+
+// GetRenewal retrieves the renewal of a subscription for the period starting at periodStart
+func (r *subscriptionRepository) GetRenewal(ctx context.Context, subscriptionID uuid.UUID, periodStart time.Time) (*models.Renewal, error) {
+	renewal := &models.Renewal{}
+	query := `SELECT ` + renewalColumns + ` FROM subscription_renewals WHERE subscription_id = $1 AND period_start = $2`
+
+	err := r.db.GetContext(ctx, renewal, query, subscriptionID, periodStart)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("renewal not found")
+		}
+		r.logger.Error("Failed to get renewal", "error", err, "subscription_id", subscriptionID)
+		return nil, fmt.Errorf("failed to get renewal: %w", err)
+	}
+
+	return renewal, nil
+}
+
+// CreateRenewal stores a new renewal and deducts its proration amount from
+// the subscription's proration balance in one transaction
+func (r *subscriptionRepository) CreateRenewal(ctx context.Context, renewal *models.Renewal) error {
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		query := `
+			INSERT INTO subscription_renewals (id, subscription_id, period_start, period_end, order_id,
+			                                   amount, proration_amount, status)
+			VALUES (:id, :subscription_id, :period_start, :period_end, :order_id,
+			        :amount, :proration_amount, :status)
+			RETURNING created_at, updated_at`
+
+		stmt, err := tx.PrepareNamedContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer stmt.Close()
+
+		err = stmt.QueryRowxContext(ctx, renewal).Scan(&renewal.CreatedAt, &renewal.UpdatedAt)
+		if err != nil {
+			if database.IsUniqueViolation(err) {
+				return apperrors.Conflict("renewal already exists")
+			}
+			r.logger.Error("Failed to create renewal", "error", err, "subscription_id", renewal.SubscriptionID)
+			return fmt.Errorf("failed to create renewal: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE subscriptions SET proration_balance = proration_balance - $2 WHERE id = $1`,
+			renewal.SubscriptionID, renewal.ProrationAmount)
+		if err != nil {
+			r.logger.Error("Failed to update proration balance", "error", err, "subscription_id", renewal.SubscriptionID)
+			return fmt.Errorf("failed to update proration balance: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// ListRenewals retrieves the renewals of a subscription, newest first
+func (r *subscriptionRepository) ListRenewals(ctx context.Context, subscriptionID uuid.UUID) ([]*models.Renewal, error) {
+	renewals := []*models.Renewal{}
+	query := `
+		SELECT ` + renewalColumns + `
+		FROM subscription_renewals
+		WHERE subscription_id = $1
+		ORDER BY period_start DESC`
+
+	err := r.db.SelectContext(ctx, &renewals, query, subscriptionID)
+	if err != nil {
+		r.logger.Error("Failed to list renewals", "error", err, "subscription_id", subscriptionID)
+		return nil, fmt.Errorf("failed to list renewals: %w", err)
+	}
+
+	return renewals, nil
+}
+
+// SaveRenewal updates a renewal and its subscription in one transaction
+func (r *subscriptionRepository) SaveRenewal(ctx context.Context, sub *models.Subscription, renewal *models.Renewal) error {
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+		query := `
+			UPDATE subscription_renewals
+			SET status = $2, attempts = $3, last_error = $4, paid_at = $5
+			WHERE id = $1
+			RETURNING updated_at`
+
+		err := tx.QueryRowxContext(ctx, query, renewal.ID, renewal.Status, renewal.Attempts, renewal.LastError, renewal.PaidAt).
+			Scan(&renewal.UpdatedAt)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperrors.NotFound("renewal not found")
+			}
+			r.logger.Error("Failed to update renewal", "error", err, "id", renewal.ID)
+			return fmt.Errorf("failed to update renewal: %w", err)
+		}
+
+		stmt, err := tx.PrepareNamedContext(ctx, updateSubscriptionQuery)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer stmt.Close()
+
+		if err := stmt.QueryRowxContext(ctx, sub).Scan(&sub.UpdatedAt); err != nil {
+			if err == sql.ErrNoRows {
+				return apperrors.NotFound("subscription not found")
+			}
+			r.logger.Error("Failed to update subscription", "error", err, "id", sub.ID)
+			return fmt.Errorf("failed to update subscription: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// GetContact retrieves the email address and first name of a customer
+func (r *subscriptionRepository) GetContact(ctx context.Context, userID uuid.UUID) (*models.Contact, error) {
+	contact := &models.Contact{}
+	query := `SELECT email, first_name FROM users WHERE id = $1`
+
+	err := r.db.GetContext(ctx, contact, query, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.NotFound("user not found")
+		}
+		r.logger.Error("Failed to get contact", "error", err, "user_id", userID)
+		return nil, fmt.Errorf("failed to get contact: %w", err)
+	}
+
+	return contact, nil
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/subscription/clients"
+	"github.com/kaanevranportfolio/Commercium/internal/subscription/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/money"
+)
+
+// claimLease is how long a claimed subscription is kept from other renewal
+// workers. It must cover creating the renewal order and charging it.
+const claimLease = 10 * time.Minute
+
+// Dunning email templates, sent as a failed renewal escalates
+const (
+	templatePaymentFailed  = "subscription_payment_failed"
+	templatePaymentRetry   = "subscription_payment_reminder"
+	templateFinalNotice    = "subscription_payment_final_notice"
+	templateCanceledUnpaid = "subscription_canceled_unpaid"
+)
+
+// ProcessRenewals claims one batch of due subscriptions and renews them.
+// Subscriptions scheduled for cancellation are canceled instead, and paused
+// subscriptions whose pause is over are resumed with a new period.
+func (s *subscriptionService) ProcessRenewals(ctx context.Context) (int, error) {
+	subs, err := s.repo.ClaimDueSubscriptions(ctx, claimLease, s.config.Services.Subscription.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, sub := range subs {
+		if err := s.processDue(ctx, sub); err != nil {
+			var declined *clients.DeclinedError
+			if !errors.As(err, &declined) {
+				s.logger.Error("Failed to renew subscription", "error", err, "subscription_id", sub.ID)
+			}
+		}
+	}
+
+	return len(subs), nil
+}
+
+// processDue brings a claimed subscription up to date
+func (s *subscriptionService) processDue(ctx context.Context, sub *models.Subscription) error {
+	plan, err := s.repo.GetPlan(ctx, sub.PlanID)
+	if err != nil {
+		return err
+	}
+
+	// Periods are kept to the second so they match the stored renewals
+	now := time.Now().UTC().Truncate(time.Second)
+	switch {
+	case sub.Status == models.SubscriptionStatusPaused:
+		// The unused part of the paused period was credited, so the
+		// subscription starts a new period when it resumes. The period is
+		// saved first so that a retried renewal charges the same period.
+		sub.Status = models.SubscriptionStatusActive
+		sub.CurrentPeriodEnd = now
+		sub.PausedAt = nil
+		sub.ResumeAt = nil
+		if err := s.repo.UpdateSubscription(ctx, sub); err != nil {
+			return err
+		}
+
+		s.logger.Info("Subscription resumed", "subscription_id", sub.ID)
+		s.publishEvent(ctx, models.EventSubscriptionResumed, sub, plan, nil)
+
+	case sub.CancelAtPeriodEnd:
+		s.cancel(sub, now)
+		sub.ClaimedUntil = nil
+		if err := s.repo.UpdateSubscription(ctx, sub); err != nil {
+			return err
+		}
+
+		s.logger.Info("Subscription canceled at period end", "subscription_id", sub.ID)
+		s.publishEvent(ctx, models.EventSubscriptionCanceled, sub, plan, nil)
+		return nil
+	}
+
+	return s.renew(ctx, sub, plan, true)
+}
+
+// renew charges the period following the current one. The renewal order is
+// placed with an ID fixed when the renewal is first attempted, so retries
+// charge the same order. A declined charge is recorded and returned as a
+// *clients.DeclinedError: with dunning the renewal is retried on the
+// configured schedule, without it the subscription is canceled. Other errors
+// leave the subscription untouched to be retried once its claim expires.
+func (s *subscriptionService) renew(ctx context.Context, sub *models.Subscription, plan *models.Plan, dunning bool) error {
+	renewal, err := s.getOrCreateRenewal(ctx, sub, plan)
+	if err != nil {
+		return err
+	}
+
+	// A renewal paid by an attempt that failed to move the subscription on
+	// only needs to be completed
+	if renewal.Status != models.RenewalStatusPaid {
+		if err := s.placeRenewalOrder(ctx, sub, plan, renewal); err != nil {
+			return err
+		}
+
+		_, err := s.payments.Charge(ctx, &clients.ChargeRequest{
+			UserID:            sub.UserID,
+			OrderID:           renewal.OrderID,
+			PaymentMethod:     sub.PaymentMethod,
+			PaymentMethodType: stringValue(sub.PaymentMethodType),
+			Provider:          stringValue(sub.PaymentProvider),
+			IdempotencyKey:    fmt.Sprintf("renewal-%s-%d", renewal.ID, renewal.Attempts+1),
+		})
+		if err != nil {
+			var declined *clients.DeclinedError
+			if !errors.As(err, &declined) {
+				return err
+			}
+
+			if err := s.recordFailedRenewal(ctx, sub, plan, renewal, declined, dunning); err != nil {
+				return err
+			}
+			return declined
+		}
+
+		now := time.Now().UTC()
+		renewal.Status = models.RenewalStatusPaid
+		renewal.Attempts++
+		renewal.LastError = nil
+		renewal.PaidAt = &now
+	}
+
+	sub.Status = models.SubscriptionStatusActive
+	sub.CurrentPeriodStart = renewal.PeriodStart
+	sub.CurrentPeriodEnd = renewal.PeriodEnd
+	sub.FailedAttempts = 0
+	sub.NextAttemptAt = nil
+	sub.ClaimedUntil = nil
+
+	if err := s.repo.SaveRenewal(ctx, sub, renewal); err != nil {
+		return err
+	}
+
+	s.logger.Info("Subscription renewed", "subscription_id", sub.ID, "order_id", renewal.OrderID,
+		"period_end", renewal.PeriodEnd)
+	s.publishEvent(ctx, models.EventSubscriptionRenewed, sub, plan, renewal)
+
+	return nil
+}
+
+// getOrCreateRenewal returns the renewal of the period after the current one.
+// A new renewal takes the proration balance, though a credit only up to the
+// plan amount; the rest of the credit carries over to later renewals.
+func (s *subscriptionService) getOrCreateRenewal(ctx context.Context, sub *models.Subscription, plan *models.Plan) (*models.Renewal, error) {
+	renewal, err := s.repo.GetRenewal(ctx, sub.ID, sub.CurrentPeriodEnd)
+	if err == nil {
+		return renewal, nil
+	}
+	if !errors.Is(err, apperrors.ErrNotFound) {
+		return nil, err
+	}
+
+	amount := plan.Amount * int64(sub.Quantity)
+	proration := sub.ProrationBalance
+	if proration < -amount {
+		proration = -amount
+	}
+
+	renewal = &models.Renewal{
+		ID:              uuid.New(),
+		SubscriptionID:  sub.ID,
+		PeriodStart:     sub.CurrentPeriodEnd,
+		PeriodEnd:       plan.PeriodEnd(sub.CurrentPeriodEnd),
+		OrderID:         uuid.New(),
+		Amount:          amount,
+		ProrationAmount: proration,
+		Status:          models.RenewalStatusPending,
+	}
+	if err := s.repo.CreateRenewal(ctx, renewal); err != nil {
+		return nil, err
+	}
+
+	sub.ProrationBalance -= proration
+	return renewal, nil
+}
+
+// placeRenewalOrder places the order of a renewal. A prorated charge is
+// added as a line of its own; a credit becomes an order discount.
+func (s *subscriptionService) placeRenewalOrder(ctx context.Context, sub *models.Subscription, plan *models.Plan, renewal *models.Renewal) error {
+	notes := fmt.Sprintf("Subscription %s, %s to %s", plan.Name,
+		renewal.PeriodStart.Format("2006-01-02"), renewal.PeriodEnd.Format("2006-01-02"))
+	req := &clients.CreateOrderRequest{
+		ID:       renewal.OrderID,
+		UserID:   sub.UserID,
+		Currency: plan.Currency,
+		Items: []*clients.OrderItem{{
+			ProductID: plan.ProductID,
+			SKU:       plan.SKU,
+			Name:      plan.Name,
+			Quantity:  sub.Quantity,
+			UnitPrice: plan.Amount,
+		}},
+		ShippingAddress: &sub.ShippingAddress,
+		Notes:           &notes,
+	}
+	if renewal.ProrationAmount > 0 {
+		req.Items = append(req.Items, &clients.OrderItem{
+			ProductID: plan.ProductID,
+			SKU:       plan.SKU,
+			Name:      plan.Name + " (plan change)",
+			Quantity:  1,
+			UnitPrice: renewal.ProrationAmount,
+		})
+	} else {
+		req.DiscountAmount = -renewal.ProrationAmount
+	}
+
+	if _, err := s.orders.CreateOrder(ctx, req); err != nil {
+		return fmt.Errorf("failed to place renewal order: %w", err)
+	}
+
+	return nil
+}
+
+// recordFailedRenewal records a declined renewal charge. With dunning the
+// subscription goes past due and the charge is retried after the next delay
+// of the retry schedule, escalating the emails sent to the customer; once
+// the schedule is exhausted, or without dunning, the subscription is
+// canceled.
+func (s *subscriptionService) recordFailedRenewal(ctx context.Context, sub *models.Subscription, plan *models.Plan, renewal *models.Renewal, declined *clients.DeclinedError, dunning bool) error {
+	now := time.Now().UTC()
+	reason := declined.Error()
+	if len(reason) > 500 {
+		reason = reason[:500]
+	}
+
+	renewal.Status = models.RenewalStatusFailed
+	renewal.Attempts++
+	renewal.LastError = &reason
+
+	schedule := s.config.Services.Subscription.Dunning.RetrySchedule
+	sub.FailedAttempts++
+	sub.ClaimedUntil = nil
+	if dunning && sub.FailedAttempts <= len(schedule) {
+		nextAttempt := now.Add(schedule[sub.FailedAttempts-1])
+		sub.Status = models.SubscriptionStatusPastDue
+		sub.NextAttemptAt = &nextAttempt
+	} else {
+		s.cancel(sub, now)
+		unpaid := "payment failed"
+		sub.CancellationReason = &unpaid
+	}
+
+	if err := s.repo.SaveRenewal(ctx, sub, renewal); err != nil {
+		return err
+	}
+
+	s.logger.Warn("Subscription renewal payment failed", "subscription_id", sub.ID, "order_id", renewal.OrderID,
+		"attempt", sub.FailedAttempts, "status", sub.Status, "reason", declined.Message)
+	s.publishEvent(ctx, models.EventSubscriptionPaymentFailed, sub, plan, renewal)
+	if sub.Status == models.SubscriptionStatusCanceled {
+		s.publishEvent(ctx, models.EventSubscriptionCanceled, sub, plan, renewal)
+	}
+
+	// A declined first charge is reported to the customer directly
+	if dunning {
+		s.sendDunningEmail(ctx, sub, plan, renewal, len(schedule))
+	}
+
+	return nil
+}
+
+// sendDunningEmail tells the customer that a renewal payment failed.
+// Emails are best effort; failures are logged.
+func (s *subscriptionService) sendDunningEmail(ctx context.Context, sub *models.Subscription, plan *models.Plan, renewal *models.Renewal, maxRetries int) {
+	if s.notifications == nil {
+		return
+	}
+
+	contact, err := s.repo.GetContact(ctx, sub.UserID)
+	if err != nil {
+		s.logger.Error("Failed to get contact for dunning email", "error", err, "subscription_id", sub.ID)
+		return
+	}
+
+	data := map[string]interface{}{
+		"subscription_id": sub.ID.String(),
+		"plan_name":       plan.Name,
+		"amount":          money.Format(renewal.Amount+renewal.ProrationAmount, plan.Currency),
+		"attempt":         sub.FailedAttempts,
+		"max_attempts":    maxRetries + 1,
+	}
+	if contact.FirstName != nil {
+		data["first_name"] = *contact.FirstName
+	}
+	if sub.NextAttemptAt != nil {
+		data["next_attempt_at"] = sub.NextAttemptAt.Format("2006-01-02")
+	}
+
+	err = s.notifications.SendEmail(ctx, &clients.SendEmailRequest{
+		Template: dunningTemplate(sub, maxRetries),
+		To:       contact.Email,
+		Data:     data,
+	})
+	if err != nil {
+		s.logger.Error("Failed to send dunning email", "error", err, "subscription_id", sub.ID)
+	}
+}
+
+// dunningTemplate picks the email for a failed renewal: a notice on the
+// first failure, reminders on further failures, a final notice before the
+// last retry and a cancellation notice once retries are exhausted
+func dunningTemplate(sub *models.Subscription, maxRetries int) string {
+	switch {
+	case sub.Status == models.SubscriptionStatusCanceled:
+		return templateCanceledUnpaid
+	case sub.FailedAttempts >= maxRetries:
+		return templateFinalNotice
+	case sub.FailedAttempts == 1:
+		return templatePaymentFailed
+	default:
+		return templatePaymentRetry
+	}
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// RenewalWorker periodically renews due subscriptions in the background
+type RenewalWorker struct {
+	subscriptionService SubscriptionService
+	interval            time.Duration
+	batchSize           int
+	logger              *logger.Logger
+}
+
+// NewRenewalWorker creates a new renewal worker
+func NewRenewalWorker(subscriptionService SubscriptionService, interval time.Duration, batchSize int, logger *logger.Logger) *RenewalWorker {
+	return &RenewalWorker{
+		subscriptionService: subscriptionService,
+		interval:            interval,
+		batchSize:           batchSize,
+		logger:              logger,
+	}
+}
+
+// Run renews subscriptions until ctx is cancelled. Each tick works through
+// all due subscriptions batch by batch.
+func (w *RenewalWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				processed, err := w.subscriptionService.ProcessRenewals(ctx)
+				if err != nil {
+					w.logger.Error("Failed to process subscription renewals", "error", err)
+					break
+				}
+				if processed < w.batchSize {
+					break
+				}
+			}
+		}
+	}
+}
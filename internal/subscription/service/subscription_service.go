@@ -0,0 +1,520 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/internal/subscription/clients"
+	"github.com/kaanevranportfolio/Commercium/internal/subscription/models"
+	"github.com/kaanevranportfolio/Commercium/internal/subscription/repository"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/money"
+)
+
+// SubscriptionService defines the interface for subscription business logic
+type SubscriptionService interface {
+	// Plans (admin)
+	CreatePlan(ctx context.Context, req *models.CreatePlanRequest) (*models.Plan, error)
+	GetPlan(ctx context.Context, id uuid.UUID) (*models.Plan, error)
+	ListPlans(ctx context.Context, includeInactive bool) ([]*models.Plan, error)
+	UpdatePlan(ctx context.Context, id uuid.UUID, req *models.UpdatePlanRequest) (*models.Plan, error)
+
+	// Customer subscriptions
+	Subscribe(ctx context.Context, userID uuid.UUID, req *models.SubscribeRequest) (*models.Subscription, error)
+	GetSubscription(ctx context.Context, userID, id uuid.UUID) (*models.Subscription, error)
+	ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]*models.Subscription, error)
+	ListRenewals(ctx context.Context, userID, id uuid.UUID) ([]*models.Renewal, error)
+	ChangePlan(ctx context.Context, userID, id uuid.UUID, req *models.ChangePlanRequest) (*models.Subscription, error)
+	Pause(ctx context.Context, userID, id uuid.UUID, req *models.PauseRequest) (*models.Subscription, error)
+	Resume(ctx context.Context, userID, id uuid.UUID) (*models.Subscription, error)
+	Cancel(ctx context.Context, userID, id uuid.UUID, req *models.CancelRequest) (*models.Subscription, error)
+	UpdatePaymentMethod(ctx context.Context, userID, id uuid.UUID, req *models.UpdatePaymentMethodRequest) (*models.Subscription, error)
+
+	// ProcessRenewals renews one batch of due subscriptions and returns the
+	// number of subscriptions processed
+	ProcessRenewals(ctx context.Context) (int, error)
+}
+
+// EventPublisher publishes domain events to the message broker
+type EventPublisher interface {
+	Publish(ctx context.Context, topic, key string, event interface{}) error
+}
+
+// eventSource is the source of the events the service publishes
+const eventSource = "subscription-service"
+
+// subscriptionService implements the SubscriptionService interface
+type subscriptionService struct {
+	repo          repository.SubscriptionRepository
+	orders        clients.OrderClient
+	payments      clients.PaymentClient
+	notifications clients.NotificationClient
+	publisher     EventPublisher
+	config        *config.Config
+	logger        *logger.Logger
+}
+
+// NewSubscriptionService creates a new subscription service.
+// notifications and publisher may be nil, in which case dunning emails and
+// subscription events are not sent.
+func NewSubscriptionService(
+	repo repository.SubscriptionRepository,
+	orders clients.OrderClient,
+	payments clients.PaymentClient,
+	notifications clients.NotificationClient,
+	publisher EventPublisher,
+	config *config.Config,
+	logger *logger.Logger,
+) SubscriptionService {
+	return &subscriptionService{
+		repo:          repo,
+		orders:        orders,
+		payments:      payments,
+		notifications: notifications,
+		publisher:     publisher,
+		config:        config,
+		logger:        logger,
+	}
+}
+
+// CreatePlan creates a new subscription plan
+func (s *subscriptionService) CreatePlan(ctx context.Context, req *models.CreatePlanRequest) (*models.Plan, error) {
+	currency := strings.ToUpper(req.Currency)
+	if !money.IsCurrencyCode(currency) {
+		return nil, apperrors.Validation("invalid currency: %s", req.Currency)
+	}
+	if !req.IntervalUnit.IsValid() {
+		return nil, apperrors.Validation("invalid interval unit: %s", req.IntervalUnit)
+	}
+
+	intervalCount := req.IntervalCount
+	if intervalCount == 0 {
+		intervalCount = 1
+	}
+
+	plan := &models.Plan{
+		ID:            uuid.New(),
+		Code:          strings.TrimSpace(req.Code),
+		Name:          strings.TrimSpace(req.Name),
+		ProductID:     req.ProductID,
+		SKU:           req.SKU,
+		Currency:      currency,
+		Amount:        req.Amount,
+		IntervalUnit:  req.IntervalUnit,
+		IntervalCount: intervalCount,
+		TrialDays:     req.TrialDays,
+		IsActive:      true,
+	}
+
+	if err := s.repo.CreatePlan(ctx, plan); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Subscription plan created", "plan_id", plan.ID, "code", plan.Code)
+	return plan, nil
+}
+
+// GetPlan returns a plan
+func (s *subscriptionService) GetPlan(ctx context.Context, id uuid.UUID) (*models.Plan, error) {
+	return s.repo.GetPlan(ctx, id)
+}
+
+// ListPlans returns the plans customers can subscribe to, or every plan if
+// includeInactive is set
+func (s *subscriptionService) ListPlans(ctx context.Context, includeInactive bool) ([]*models.Plan, error) {
+	return s.repo.ListPlans(ctx, includeInactive)
+}
+
+// UpdatePlan renames a plan or stops offering it. Existing subscribers of a
+// retired plan keep renewing on it.
+func (s *subscriptionService) UpdatePlan(ctx context.Context, id uuid.UUID, req *models.UpdatePlanRequest) (*models.Plan, error) {
+	plan, err := s.repo.GetPlan(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		plan.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.IsActive != nil {
+		plan.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.UpdatePlan(ctx, plan); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// Subscribe subscribes a customer to a plan. Plans with a trial start
+// trialing and are first charged when the trial ends; otherwise the first
+// period is charged right away and the subscription is canceled if the
+// payment is declined. If the payment service can't be reached, the first
+// charge is left to the renewal worker.
+func (s *subscriptionService) Subscribe(ctx context.Context, userID uuid.UUID, req *models.SubscribeRequest) (*models.Subscription, error) {
+	plan, err := s.repo.GetPlan(ctx, req.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.IsActive {
+		return nil, apperrors.Validation("invalid plan: %s is no longer offered", plan.Code)
+	}
+
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	sub := &models.Subscription{
+		ID:                 uuid.New(),
+		UserID:             userID,
+		PlanID:             plan.ID,
+		Status:             models.SubscriptionStatusActive,
+		Quantity:           quantity,
+		PaymentMethod:      req.PaymentMethod,
+		PaymentMethodType:  optionalString(req.PaymentMethodType),
+		PaymentProvider:    optionalString(req.Provider),
+		ShippingAddress:    req.ShippingAddress,
+		CurrentPeriodStart: now,
+		// The first period is due right away
+		CurrentPeriodEnd: now,
+		Plan:             plan,
+	}
+	if plan.TrialDays > 0 {
+		sub.Status = models.SubscriptionStatusTrialing
+		sub.CurrentPeriodEnd = now.AddDate(0, 0, plan.TrialDays)
+	} else {
+		// Keep the renewal worker off the subscription while it's charged here
+		claimedUntil := now.Add(claimLease)
+		sub.ClaimedUntil = &claimedUntil
+	}
+
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Subscription created", "subscription_id", sub.ID, "user_id", userID, "plan_id", plan.ID, "status", sub.Status)
+	s.publishEvent(ctx, models.EventSubscriptionCreated, sub, plan, nil)
+
+	if sub.Status == models.SubscriptionStatusTrialing {
+		return sub, nil
+	}
+
+	if err := s.renew(ctx, sub, plan, false); err != nil {
+		var declined *clients.DeclinedError
+		if errors.As(err, &declined) {
+			return nil, apperrors.PaymentRequired("initial payment failed: %s", declined.Message)
+		}
+		s.logger.Error("Failed to charge first subscription period, leaving it to the renewal worker",
+			"error", err, "subscription_id", sub.ID)
+	}
+
+	return sub, nil
+}
+
+// GetSubscription returns a subscription of a customer with its plan
+func (s *subscriptionService) GetSubscription(ctx context.Context, userID, id uuid.UUID) (*models.Subscription, error) {
+	sub, err := s.getOwnSubscription(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if sub.Plan, err = s.repo.GetPlan(ctx, sub.PlanID); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// ListSubscriptions returns the subscriptions of a customer
+func (s *subscriptionService) ListSubscriptions(ctx context.Context, userID uuid.UUID) ([]*models.Subscription, error) {
+	return s.repo.ListSubscriptions(ctx, userID)
+}
+
+// ListRenewals returns the billing history of a subscription
+func (s *subscriptionService) ListRenewals(ctx context.Context, userID, id uuid.UUID) ([]*models.Renewal, error) {
+	if _, err := s.getOwnSubscription(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListRenewals(ctx, id)
+}
+
+// ChangePlan moves a subscription to another plan or quantity right away.
+// The price difference for the rest of the current period is prorated and
+// settled with the next renewal: upgrades are charged, downgrades credited.
+// Plans must share the currency and billing interval.
+func (s *subscriptionService) ChangePlan(ctx context.Context, userID, id uuid.UUID, req *models.ChangePlanRequest) (*models.Subscription, error) {
+	sub, err := s.getOwnSubscription(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Status != models.SubscriptionStatusActive && sub.Status != models.SubscriptionStatusTrialing {
+		return nil, apperrors.Conflict("subscription cannot be changed while %s", sub.Status)
+	}
+
+	current, err := s.repo.GetPlan(ctx, sub.PlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := s.repo.GetPlan(ctx, req.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.IsActive && plan.ID != current.ID {
+		return nil, apperrors.Validation("invalid plan: %s is no longer offered", plan.Code)
+	}
+	if plan.Currency != current.Currency {
+		return nil, apperrors.Validation("invalid plan: currency must be %s", current.Currency)
+	}
+	if !plan.SameInterval(current) {
+		return nil, apperrors.Validation("invalid plan: billing interval must match the current plan")
+	}
+
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = sub.Quantity
+	}
+
+	// Trials are free, so there's nothing to prorate
+	if sub.Status == models.SubscriptionStatusActive {
+		now := time.Now()
+		difference := plan.Amount*int64(quantity) - current.Amount*int64(sub.Quantity)
+		sub.ProrationBalance += prorate(difference, sub.CurrentPeriodEnd.Sub(now), sub.CurrentPeriodEnd.Sub(sub.CurrentPeriodStart))
+	}
+
+	sub.PlanID = plan.ID
+	sub.Quantity = quantity
+	if err := s.repo.UpdateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	sub.Plan = plan
+	s.logger.Info("Subscription plan changed", "subscription_id", sub.ID, "from_plan_id", current.ID, "to_plan_id", plan.ID,
+		"quantity", quantity, "proration_balance", sub.ProrationBalance)
+	s.publishEvent(ctx, models.EventSubscriptionPlanChanged, sub, plan, nil)
+
+	return sub, nil
+}
+
+// Pause pauses an active subscription. The unused part of the current period
+// is credited to the renewal made when the subscription resumes, at ResumeAt
+// or when the customer resumes it.
+func (s *subscriptionService) Pause(ctx context.Context, userID, id uuid.UUID, req *models.PauseRequest) (*models.Subscription, error) {
+	sub, err := s.getOwnSubscription(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Status != models.SubscriptionStatusActive {
+		return nil, apperrors.Conflict("subscription cannot be paused while %s", sub.Status)
+	}
+	if sub.CancelAtPeriodEnd {
+		return nil, apperrors.Conflict("subscription cannot be paused, it is scheduled for cancellation")
+	}
+
+	now := time.Now().UTC()
+	if req.ResumeAt != nil && !req.ResumeAt.After(now) {
+		return nil, apperrors.Validation("invalid resume date: must be in the future")
+	}
+
+	plan, err := s.repo.GetPlan(ctx, sub.PlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	unused := prorate(plan.Amount*int64(sub.Quantity), sub.CurrentPeriodEnd.Sub(now), sub.CurrentPeriodEnd.Sub(sub.CurrentPeriodStart))
+	sub.ProrationBalance -= unused
+	sub.Status = models.SubscriptionStatusPaused
+	sub.PausedAt = &now
+	sub.ResumeAt = req.ResumeAt
+
+	if err := s.repo.UpdateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Subscription paused", "subscription_id", sub.ID, "resume_at", sub.ResumeAt, "credit", unused)
+	s.publishEvent(ctx, models.EventSubscriptionPaused, sub, plan, nil)
+
+	return sub, nil
+}
+
+// Resume resumes a paused subscription, which starts a new billing period
+// charged by the next run of the renewal worker. For a subscription
+// scheduled for cancellation, Resume keeps the subscription instead.
+func (s *subscriptionService) Resume(ctx context.Context, userID, id uuid.UUID) (*models.Subscription, error) {
+	sub, err := s.getOwnSubscription(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case sub.Status == models.SubscriptionStatusPaused:
+		now := time.Now().UTC()
+		sub.ResumeAt = &now
+	case sub.CancelAtPeriodEnd && sub.Status != models.SubscriptionStatusCanceled:
+		sub.CancelAtPeriodEnd = false
+		sub.CancellationReason = nil
+	default:
+		return nil, apperrors.Conflict("subscription cannot be resumed while %s", sub.Status)
+	}
+
+	if err := s.repo.UpdateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	if sub.Status != models.SubscriptionStatusPaused {
+		s.publishEvent(ctx, models.EventSubscriptionResumed, sub, nil, nil)
+	}
+
+	return sub, nil
+}
+
+// Cancel cancels a subscription right away or at the end of the paid
+// period. The current period is not refunded.
+func (s *subscriptionService) Cancel(ctx context.Context, userID, id uuid.UUID, req *models.CancelRequest) (*models.Subscription, error) {
+	sub, err := s.getOwnSubscription(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Status == models.SubscriptionStatusCanceled {
+		return nil, apperrors.Conflict("subscription already canceled")
+	}
+
+	sub.CancellationReason = optionalString(strings.TrimSpace(req.Reason))
+
+	atPeriodEnd := req.AtPeriodEnd &&
+		(sub.Status == models.SubscriptionStatusActive || sub.Status == models.SubscriptionStatusTrialing)
+	if atPeriodEnd {
+		sub.CancelAtPeriodEnd = true
+	} else {
+		s.cancel(sub, time.Now().UTC())
+	}
+
+	if err := s.repo.UpdateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Subscription canceled", "subscription_id", sub.ID, "at_period_end", atPeriodEnd)
+	if !atPeriodEnd {
+		s.publishEvent(ctx, models.EventSubscriptionCanceled, sub, nil, nil)
+	}
+
+	return sub, nil
+}
+
+// UpdatePaymentMethod changes the payment method renewals are charged to.
+// A past due renewal is retried with the new method right away.
+func (s *subscriptionService) UpdatePaymentMethod(ctx context.Context, userID, id uuid.UUID, req *models.UpdatePaymentMethodRequest) (*models.Subscription, error) {
+	sub, err := s.getOwnSubscription(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Status == models.SubscriptionStatusCanceled {
+		return nil, apperrors.Conflict("subscription cannot be changed once canceled")
+	}
+
+	sub.PaymentMethod = req.PaymentMethod
+	sub.PaymentMethodType = optionalString(req.PaymentMethodType)
+	sub.PaymentProvider = optionalString(req.Provider)
+	if sub.Status == models.SubscriptionStatusPastDue {
+		now := time.Now().UTC()
+		sub.NextAttemptAt = &now
+	}
+
+	if err := s.repo.UpdateSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// getOwnSubscription returns a subscription of the customer. Other
+// customers' subscriptions are reported as not found.
+func (s *subscriptionService) getOwnSubscription(ctx context.Context, userID, id uuid.UUID) (*models.Subscription, error) {
+	sub, err := s.repo.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.UserID != userID {
+		return nil, apperrors.NotFound("subscription not found")
+	}
+
+	return sub, nil
+}
+
+// cancel ends a subscription at now
+func (s *subscriptionService) cancel(sub *models.Subscription, now time.Time) {
+	sub.Status = models.SubscriptionStatusCanceled
+	sub.CanceledAt = &now
+	sub.CancelAtPeriodEnd = false
+	sub.NextAttemptAt = nil
+	sub.ResumeAt = nil
+}
+
+// publishEvent publishes a subscription event. plan and renewal are optional.
+func (s *subscriptionService) publishEvent(ctx context.Context, eventType string, sub *models.Subscription, plan *models.Plan, renewal *models.Renewal) {
+	if s.publisher == nil {
+		return
+	}
+
+	event := &models.SubscriptionEvent{
+		Type:           eventType,
+		SubscriptionID: sub.ID,
+		UserID:         sub.UserID,
+		PlanID:         sub.PlanID,
+		Status:         string(sub.Status),
+		OccurredAt:     time.Now().UTC(),
+	}
+	if sub.CancellationReason != nil {
+		event.Reason = *sub.CancellationReason
+	}
+	if plan != nil {
+		event.Currency = plan.Currency
+	}
+	if renewal != nil {
+		event.OrderID = &renewal.OrderID
+		event.Amount = renewal.Amount + renewal.ProrationAmount
+	}
+
+	envelope, err := events.New(ctx, eventSource, eventType, sub.ID.String(), models.SubscriptionEventSchemaVersion, event)
+	if err != nil {
+		s.logger.Error("Failed to publish subscription event", "error", err, "type", eventType, "subscription_id", sub.ID)
+		return
+	}
+
+	if err := s.publisher.Publish(ctx, s.config.Kafka.Topics.SubscriptionEvents, sub.ID.String(), envelope); err != nil {
+		s.logger.Error("Failed to publish subscription event", "error", err, "type", eventType, "subscription_id", sub.ID)
+	}
+}
+
+// prorate returns the share of amount for remaining out of period, rounded
+// towards zero. Nothing is prorated once the period is over.
+func prorate(amount int64, remaining, period time.Duration) int64 {
+	if remaining <= 0 || period <= 0 {
+		return 0
+	}
+	if remaining > period {
+		remaining = period
+	}
+
+	share := new(big.Int).Mul(big.NewInt(amount), big.NewInt(int64(remaining)))
+	return share.Quo(share, big.NewInt(int64(period))).Int64()
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/kaanevranportfolio/Commercium/internal/user/models"
+	"github.com/kaanevranportfolio/Commercium/internal/user/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	userpb "github.com/kaanevranportfolio/Commercium/proto/user"
+)
+
+// GRPCHandler serves the internal gRPC API other services fetch user data from
+type GRPCHandler struct {
+	userpb.UnimplementedUserServiceServer
+
+	userService service.UserService
+	logger      *logger.Logger
+}
+
+// NewGRPCHandler creates a new gRPC handler
+func NewGRPCHandler(userService service.UserService, logger *logger.Logger) *GRPCHandler {
+	return &GRPCHandler{
+		userService: userService,
+		logger:      logger,
+	}
+}
+
+// Register registers the handler with a gRPC server
+func (h *GRPCHandler) Register(server *grpc.Server) {
+	userpb.RegisterUserServiceServer(server, h)
+}
+
+// GetUser returns a user by ID
+func (h *GRPCHandler) GetUser(ctx context.Context, req *userpb.GetUserRequest) (*userpb.GetUserResponse, error) {
+	userID, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	user, err := h.userService.GetProfile(ctx, userID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		h.logger.Error("Failed to get user", "error", err, "user_id", userID)
+		return nil, status.Error(codes.Internal, "failed to get user")
+	}
+
+	return &userpb.GetUserResponse{User: toProtoUser(user)}, nil
+}
+
+// BatchGetUsers returns several users by ID at once
+func (h *GRPCHandler) BatchGetUsers(ctx context.Context, req *userpb.BatchGetUsersRequest) (*userpb.BatchGetUsersResponse, error) {
+	if len(req.GetUserIds()) == 0 || len(req.GetUserIds()) > models.MaxBatchGetUsers {
+		return nil, status.Errorf(codes.InvalidArgument, "between 1 and %d user IDs are required", models.MaxBatchGetUsers)
+	}
+
+	userIDs := make([]uuid.UUID, len(req.GetUserIds()))
+	for i, id := range req.GetUserIds() {
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid user ID %q", id)
+		}
+		userIDs[i] = userID
+	}
+
+	users, err := h.userService.GetProfiles(ctx, userIDs)
+	if err != nil {
+		h.logger.Error("Failed to get users", "error", err, "users", len(userIDs))
+		return nil, status.Error(codes.Internal, "failed to get users")
+	}
+
+	resp := &userpb.BatchGetUsersResponse{Users: make(map[string]*userpb.User, len(users))}
+	for userID, user := range users {
+		resp.Users[userID.String()] = toProtoUser(user)
+	}
+
+	return resp, nil
+}
+
+// ValidateCredentials returns the user a username or email and password pair belongs to
+func (h *GRPCHandler) ValidateCredentials(ctx context.Context, req *userpb.ValidateCredentialsRequest) (*userpb.ValidateCredentialsResponse, error) {
+	if req.GetUsername() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "username and password are required")
+	}
+
+	user, err := h.userService.ValidateCredentials(ctx, &models.LoginRequest{
+		Username: req.GetUsername(),
+		Password: req.GetPassword(),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, apperrors.ErrUnauthorized):
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		case errors.Is(err, apperrors.ErrForbidden):
+			return nil, status.Error(codes.PermissionDenied, "account is deactivated")
+		case errors.Is(err, apperrors.ErrRateLimited):
+			return nil, status.Error(codes.ResourceExhausted, "account locked after too many failed logins")
+		}
+		h.logger.Error("Failed to validate credentials", "error", err)
+		return nil, status.Error(codes.Internal, "failed to validate credentials")
+	}
+
+	return &userpb.ValidateCredentialsResponse{User: toProtoUser(user)}, nil
+}
+
+// GetAddresses returns the addresses of a user
+func (h *GRPCHandler) GetAddresses(ctx context.Context, req *userpb.GetAddressesRequest) (*userpb.GetAddressesResponse, error) {
+	userID, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user ID")
+	}
+
+	addresses, err := h.userService.GetAddresses(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to get addresses", "error", err, "user_id", userID)
+		return nil, status.Error(codes.Internal, "failed to get addresses")
+	}
+
+	resp := &userpb.GetAddressesResponse{Addresses: make([]*userpb.Address, 0, len(addresses))}
+	for _, address := range addresses {
+		if req.Type != nil && address.Type != req.GetType() {
+			continue
+		}
+		resp.Addresses = append(resp.Addresses, toProtoAddress(address))
+	}
+
+	return resp, nil
+}
+
+// toProtoUser converts a user to its gRPC message
+func toProtoUser(user *models.UserResponse) *userpb.User {
+	msg := &userpb.User{
+		Id:         user.ID.String(),
+		Username:   user.Username,
+		Email:      user.Email,
+		FirstName:  user.FirstName,
+		LastName:   user.LastName,
+		Phone:      user.Phone,
+		IsActive:   user.IsActive,
+		IsVerified: user.IsVerified,
+		Role:       user.Role,
+		CreatedAt:  timestamppb.New(user.CreatedAt),
+		UpdatedAt:  timestamppb.New(user.UpdatedAt),
+	}
+	if user.LastLoginAt != nil {
+		msg.LastLoginAt = timestamppb.New(*user.LastLoginAt)
+	}
+	return msg
+}
+
+// toProtoAddress converts an address to its gRPC message
+func toProtoAddress(address *models.UserAddress) *userpb.Address {
+	return &userpb.Address{
+		Id:           address.ID.String(),
+		UserId:       address.UserID.String(),
+		Type:         address.Type,
+		FirstName:    address.FirstName,
+		LastName:     address.LastName,
+		Company:      address.Company,
+		AddressLine1: address.AddressLine1,
+		AddressLine2: address.AddressLine2,
+		City:         address.City,
+		State:        address.State,
+		PostalCode:   address.PostalCode,
+		Country:      address.Country,
+		Phone:        address.Phone,
+		IsDefault:    address.IsDefault,
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -9,48 +10,55 @@ import (
 
 	"github.com/kaanevranportfolio/Commercium/internal/user/models"
 	"github.com/kaanevranportfolio/Commercium/internal/user/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
 	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
 	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/validation"
 )
 
-// UserHandler handles HTTP requests for user operations
+// UserHandler handles HTTP requests for user operations. Failures are
+// logged with the logger scoped to the request, see logger.Middleware, and
+// answered with their problem details, see apperrors.Respond.
 type UserHandler struct {
 	userService service.UserService
 	jwtService  *auth.JWTService
-	logger      *logger.Logger
+	// limits limit the attempts of each client at sensitive endpoints
+	limits gin.HandlersChain
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(userService service.UserService, jwtService *auth.JWTService, logger *logger.Logger) *UserHandler {
+func NewUserHandler(userService service.UserService, jwtService *auth.JWTService) *UserHandler {
 	return &UserHandler{
 		userService: userService,
 		jwtService:  jwtService,
-		logger:      logger,
 	}
 }
 
+// LimitSensitive puts limit in front of the endpoints attackers guess
+// credentials or flood inboxes with: registering, logging in and changing
+// or resetting passwords
+func (h *UserHandler) LimitSensitive(limit gin.HandlerFunc) *UserHandler {
+	h.limits = append(h.limits, limit)
+	return h
+}
+
+// limited returns handler behind the limits of sensitive endpoints
+func (h *UserHandler) limited(handler gin.HandlerFunc) gin.HandlersChain {
+	return append(append(gin.HandlersChain{}, h.limits...), handler)
+}
+
 // Register handles user registration
 func (h *UserHandler) Register(c *gin.Context) {
 	var req models.CreateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data",
-			"details": err.Error(),
-		})
+	if !validation.BindJSON(c, &req) {
 		return
 	}
 
 	user, err := h.userService.Register(c.Request.Context(), &req)
 	if err != nil {
-		h.logger.Error("Registration failed", "error", err)
-		
-		// Check for specific errors
-		if strings.Contains(err.Error(), "already exists") {
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-			return
-		}
-		
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Registration failed"})
+		logger.FromContext(c.Request.Context()).Error("Registration failed", "error", err)
+		apperrors.Respond(c, err)
 		return
 	}
 
@@ -63,29 +71,14 @@ func (h *UserHandler) Register(c *gin.Context) {
 // Login handles user authentication
 func (h *UserHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data",
-			"details": err.Error(),
-		})
+	if !validation.BindJSON(c, &req) {
 		return
 	}
 
 	tokens, err := h.userService.Login(c.Request.Context(), &req)
 	if err != nil {
-		h.logger.Error("Login failed", "error", err)
-		
-		if strings.Contains(err.Error(), "credentials") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-			return
-		}
-		
-		if strings.Contains(err.Error(), "deactivated") {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Account is deactivated"})
-			return
-		}
-		
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Login failed"})
+		logger.FromContext(c.Request.Context()).Error("Login failed", "error", err)
+		apperrors.Respond(c, err)
 		return
 	}
 
@@ -98,17 +91,13 @@ func (h *UserHandler) RefreshToken(c *gin.Context) {
 		RefreshToken string `json:"refresh_token" binding:"required"`
 	}
 	
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data",
-			"details": err.Error(),
-		})
+	if !validation.BindJSON(c, &req) {
 		return
 	}
 
 	tokens, err := h.userService.RefreshToken(c.Request.Context(), req.RefreshToken)
 	if err != nil {
-		h.logger.Error("Token refresh failed", "error", err)
+		logger.FromContext(c.Request.Context()).Error("Token refresh failed", "error", err)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
 		return
 	}
@@ -126,14 +115,51 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 
 	user, err := h.userService.GetProfile(c.Request.Context(), userID)
 	if err != nil {
-		h.logger.Error("Failed to get user profile", "error", err, "user_id", userID)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get profile"})
+		logger.FromContext(c.Request.Context()).Error("Failed to get user profile", "error", err, "user_id", userID)
+		apperrors.Respond(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, user)
 }
 
+// BatchGetUsers returns several users by ID at once (internal)
+func (h *UserHandler) BatchGetUsers(c *gin.Context) {
+	var req models.BatchGetUsersRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	users, err := h.userService.GetProfiles(c.Request.Context(), req.UserIDs)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Error("Failed to get users", "error", err, "users", len(req.UserIDs))
+		apperrors.Respond(c, err)
+		return
+	}
+
+	response := models.BatchGetUsersResponse{Users: users, NotFound: []uuid.UUID{}}
+	for _, userID := range req.UserIDs {
+		if _, ok := users[userID]; !ok && !slices.Contains(response.NotFound, userID) {
+			response.NotFound = append(response.NotFound, userID)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// customMethod serves handler on the path of a custom method, such as
+// /users:batchGet. Gin routes it as users followed by a parameter named
+// after the method, which customMethod checks holds just the method.
+func customMethod(method string, handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Param(method) != ":"+method {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+			return
+		}
+		handler(c)
+	}
+}
+
 // UpdateProfile updates the user's profile
 func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	userID := h.getUserIDFromContext(c)
@@ -143,18 +169,14 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	}
 
 	var req models.UpdateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data",
-			"details": err.Error(),
-		})
+	if !validation.BindJSON(c, &req) {
 		return
 	}
 
 	user, err := h.userService.UpdateProfile(c.Request.Context(), userID, &req)
 	if err != nil {
-		h.logger.Error("Failed to update user profile", "error", err, "user_id", userID)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		logger.FromContext(c.Request.Context()).Error("Failed to update user profile", "error", err, "user_id", userID)
+		apperrors.Respond(c, err)
 		return
 	}
 
@@ -173,24 +195,14 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	}
 
 	var req models.ChangePasswordRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data",
-			"details": err.Error(),
-		})
+	if !validation.BindJSON(c, &req) {
 		return
 	}
 
 	err := h.userService.ChangePassword(c.Request.Context(), userID, &req)
 	if err != nil {
-		h.logger.Error("Password change failed", "error", err, "user_id", userID)
-		
-		if strings.Contains(err.Error(), "incorrect") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Current password is incorrect"})
-			return
-		}
-		
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		logger.FromContext(c.Request.Context()).Error("Password change failed", "error", err, "user_id", userID)
+		apperrors.Respond(c, err)
 		return
 	}
 
@@ -200,18 +212,14 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 // ForgotPassword handles forgot password requests
 func (h *UserHandler) ForgotPassword(c *gin.Context) {
 	var req models.ForgotPasswordRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data",
-			"details": err.Error(),
-		})
+	if !validation.BindJSON(c, &req) {
 		return
 	}
 
 	err := h.userService.ForgotPassword(c.Request.Context(), &req)
 	if err != nil {
-		h.logger.Error("Forgot password failed", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process request"})
+		logger.FromContext(c.Request.Context()).Error("Forgot password failed", "error", err)
+		apperrors.Respond(c, err)
 		return
 	}
 
@@ -224,24 +232,14 @@ func (h *UserHandler) ForgotPassword(c *gin.Context) {
 // ResetPassword handles password reset requests
 func (h *UserHandler) ResetPassword(c *gin.Context) {
 	var req models.ResetPasswordRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data",
-			"details": err.Error(),
-		})
+	if !validation.BindJSON(c, &req) {
 		return
 	}
 
 	err := h.userService.ResetPassword(c.Request.Context(), &req)
 	if err != nil {
-		h.logger.Error("Password reset failed", "error", err)
-		
-		if strings.Contains(err.Error(), "invalid or expired") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
-			return
-		}
-		
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		logger.FromContext(c.Request.Context()).Error("Password reset failed", "error", err)
+		apperrors.Respond(c, err)
 		return
 	}
 
@@ -258,14 +256,8 @@ func (h *UserHandler) VerifyEmail(c *gin.Context) {
 
 	err := h.userService.VerifyEmail(c.Request.Context(), token)
 	if err != nil {
-		h.logger.Error("Email verification failed", "error", err)
-		
-		if strings.Contains(err.Error(), "invalid or expired") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
-			return
-		}
-		
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		logger.FromContext(c.Request.Context()).Error("Email verification failed", "error", err)
+		apperrors.Respond(c, err)
 		return
 	}
 
@@ -282,14 +274,8 @@ func (h *UserHandler) ResendEmailVerification(c *gin.Context) {
 
 	err := h.userService.ResendEmailVerification(c.Request.Context(), userID)
 	if err != nil {
-		h.logger.Error("Failed to resend email verification", "error", err, "user_id", userID)
-		
-		if strings.Contains(err.Error(), "already verified") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Email is already verified"})
-			return
-		}
-		
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resend verification email"})
+		logger.FromContext(c.Request.Context()).Error("Failed to resend email verification", "error", err, "user_id", userID)
+		apperrors.Respond(c, err)
 		return
 	}
 
@@ -305,18 +291,14 @@ func (h *UserHandler) CreateAddress(c *gin.Context) {
 	}
 
 	var address models.UserAddress
-	if err := c.ShouldBindJSON(&address); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data",
-			"details": err.Error(),
-		})
+	if !validation.BindJSON(c, &address) {
 		return
 	}
 
 	createdAddress, err := h.userService.CreateAddress(c.Request.Context(), userID, &address)
 	if err != nil {
-		h.logger.Error("Failed to create address", "error", err, "user_id", userID)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create address"})
+		logger.FromContext(c.Request.Context()).Error("Failed to create address", "error", err, "user_id", userID)
+		apperrors.Respond(c, err)
 		return
 	}
 
@@ -336,12 +318,12 @@ func (h *UserHandler) GetAddresses(c *gin.Context) {
 
 	addresses, err := h.userService.GetAddresses(c.Request.Context(), userID)
 	if err != nil {
-		h.logger.Error("Failed to get addresses", "error", err, "user_id", userID)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get addresses"})
+		logger.FromContext(c.Request.Context()).Error("Failed to get addresses", "error", err, "user_id", userID)
+		apperrors.Respond(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"addresses": addresses})
+	httpx.Items(c, addresses)
 }
 
 // UpdateAddress updates a user address
@@ -360,24 +342,14 @@ func (h *UserHandler) UpdateAddress(c *gin.Context) {
 	}
 
 	var address models.UserAddress
-	if err := c.ShouldBindJSON(&address); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request data",
-			"details": err.Error(),
-		})
+	if !validation.BindJSON(c, &address) {
 		return
 	}
 
 	updatedAddress, err := h.userService.UpdateAddress(c.Request.Context(), userID, addressID, &address)
 	if err != nil {
-		h.logger.Error("Failed to update address", "error", err, "user_id", userID, "address_id", addressID)
-		
-		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "does not belong") {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
-			return
-		}
-		
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update address"})
+		logger.FromContext(c.Request.Context()).Error("Failed to update address", "error", err, "user_id", userID, "address_id", addressID)
+		apperrors.Respond(c, err)
 		return
 	}
 
@@ -404,14 +376,8 @@ func (h *UserHandler) DeleteAddress(c *gin.Context) {
 
 	err = h.userService.DeleteAddress(c.Request.Context(), userID, addressID)
 	if err != nil {
-		h.logger.Error("Failed to delete address", "error", err, "user_id", userID, "address_id", addressID)
-		
-		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "does not belong") {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
-			return
-		}
-		
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete address"})
+		logger.FromContext(c.Request.Context()).Error("Failed to delete address", "error", err, "user_id", userID, "address_id", addressID)
+		apperrors.Respond(c, err)
 		return
 	}
 
@@ -439,7 +405,7 @@ func (h *UserHandler) AuthMiddleware() gin.HandlerFunc {
 		token := parts[1]
 		claims, err := h.jwtService.ValidateAccessToken(token)
 		if err != nil {
-			h.logger.Error("Token validation failed", "error", err)
+			logger.FromContext(c.Request.Context()).Error("Token validation failed", "error", err)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
@@ -451,6 +417,9 @@ func (h *UserHandler) AuthMiddleware() gin.HandlerFunc {
 		c.Set("user_username", claims.Username)
 		c.Set("user_role", claims.Role)
 
+		// Lines logged for the request carry the user
+		c.Request = c.Request.WithContext(logger.ContextWithFields(c.Request.Context(), "user_id", claims.UserID.String()))
+
 		c.Next()
 	}
 }
@@ -470,16 +439,17 @@ func (h *UserHandler) getUserIDFromContext(c *gin.Context) uuid.UUID {
 	return id
 }
 
-// SetupRoutes sets up the user routes
+// SetupRoutes sets up the user routes.
+// Internal routes are called by other services and must not be exposed through the gateway.
 func (h *UserHandler) SetupRoutes(r *gin.Engine) {
 	// Public routes
 	auth := r.Group("/api/v1/auth")
 	{
-		auth.POST("/register", h.Register)
-		auth.POST("/login", h.Login)
+		auth.POST("/register", h.limited(h.Register)...)
+		auth.POST("/login", h.limited(h.Login)...)
 		auth.POST("/refresh", h.RefreshToken)
-		auth.POST("/forgot-password", h.ForgotPassword)
-		auth.POST("/reset-password", h.ResetPassword)
+		auth.POST("/forgot-password", h.limited(h.ForgotPassword)...)
+		auth.POST("/reset-password", h.limited(h.ResetPassword)...)
 		auth.GET("/verify-email", h.VerifyEmail)
 	}
 
@@ -489,8 +459,8 @@ func (h *UserHandler) SetupRoutes(r *gin.Engine) {
 	{
 		users.GET("/profile", h.GetProfile)
 		users.PUT("/profile", h.UpdateProfile)
-		users.POST("/change-password", h.ChangePassword)
-		users.POST("/resend-verification", h.ResendEmailVerification)
+		users.POST("/change-password", h.limited(h.ChangePassword)...)
+		users.POST("/resend-verification", h.limited(h.ResendEmailVerification)...)
 		
 		// Address management
 		users.POST("/addresses", h.CreateAddress)
@@ -498,12 +468,9 @@ func (h *UserHandler) SetupRoutes(r *gin.Engine) {
 		users.PUT("/addresses/:id", h.UpdateAddress)
 		users.DELETE("/addresses/:id", h.DeleteAddress)
 	}
-}
 
-// HealthCheck provides a health check endpoint
-func (h *UserHandler) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status": "healthy",
-		"service": "user-service",
-	})
+	internal := r.Group("/internal/v1")
+	{
+		internal.POST("/users:batchGet", customMethod("batchGet", h.BatchGetUsers))
+	}
 }
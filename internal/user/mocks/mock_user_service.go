@@ -0,0 +1,512 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: user_service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=user_service.go -destination=../mocks/mock_user_service.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+	uuid "github.com/google/uuid"
+	models "github.com/kaanevranportfolio/Commercium/internal/user/models"
+	auth "github.com/kaanevranportfolio/Commercium/pkg/auth"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockEmailPublisher is a mock of EmailPublisher interface.
+type MockEmailPublisher struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailPublisherMockRecorder
+	isgomock struct{}
+}
+
+// MockEmailPublisherMockRecorder is the mock recorder for MockEmailPublisher.
+type MockEmailPublisherMockRecorder struct {
+	mock *MockEmailPublisher
+}
+
+// NewMockEmailPublisher creates a new mock instance.
+func NewMockEmailPublisher(ctrl *gomock.Controller) *MockEmailPublisher {
+	mock := &MockEmailPublisher{ctrl: ctrl}
+	mock.recorder = &MockEmailPublisherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailPublisher) EXPECT() *MockEmailPublisherMockRecorder {
+	return m.recorder
+}
+
+// PublishPriority mocks base method.
+func (m *MockEmailPublisher) PublishPriority(ctx context.Context, queue string, priority uint8, value any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishPriority", ctx, queue, priority, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishPriority indicates an expected call of PublishPriority.
+func (mr *MockEmailPublisherMockRecorder) PublishPriority(ctx, queue, priority, value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishPriority", reflect.TypeOf((*MockEmailPublisher)(nil).PublishPriority), ctx, queue, priority, value)
+}
+
+// MockTokenIssuer is a mock of TokenIssuer interface.
+type MockTokenIssuer struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenIssuerMockRecorder
+	isgomock struct{}
+}
+
+// MockTokenIssuerMockRecorder is the mock recorder for MockTokenIssuer.
+type MockTokenIssuerMockRecorder struct {
+	mock *MockTokenIssuer
+}
+
+// NewMockTokenIssuer creates a new mock instance.
+func NewMockTokenIssuer(ctrl *gomock.Controller) *MockTokenIssuer {
+	mock := &MockTokenIssuer{ctrl: ctrl}
+	mock.recorder = &MockTokenIssuerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenIssuer) EXPECT() *MockTokenIssuerMockRecorder {
+	return m.recorder
+}
+
+// GenerateTokenPair mocks base method.
+func (m *MockTokenIssuer) GenerateTokenPair(userID uuid.UUID, email, username, role, tenantID string) (*auth.TokenPair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateTokenPair", userID, email, username, role, tenantID)
+	ret0, _ := ret[0].(*auth.TokenPair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateTokenPair indicates an expected call of GenerateTokenPair.
+func (mr *MockTokenIssuerMockRecorder) GenerateTokenPair(userID, email, username, role, tenantID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateTokenPair", reflect.TypeOf((*MockTokenIssuer)(nil).GenerateTokenPair), userID, email, username, role, tenantID)
+}
+
+// ValidateRefreshToken mocks base method.
+func (m *MockTokenIssuer) ValidateRefreshToken(tokenString string) (*jwt.RegisteredClaims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateRefreshToken", tokenString)
+	ret0, _ := ret[0].(*jwt.RegisteredClaims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateRefreshToken indicates an expected call of ValidateRefreshToken.
+func (mr *MockTokenIssuerMockRecorder) ValidateRefreshToken(tokenString any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateRefreshToken", reflect.TypeOf((*MockTokenIssuer)(nil).ValidateRefreshToken), tokenString)
+}
+
+// MockTokenStore is a mock of TokenStore interface.
+type MockTokenStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenStoreMockRecorder
+	isgomock struct{}
+}
+
+// MockTokenStoreMockRecorder is the mock recorder for MockTokenStore.
+type MockTokenStoreMockRecorder struct {
+	mock *MockTokenStore
+}
+
+// NewMockTokenStore creates a new mock instance.
+func NewMockTokenStore(ctrl *gomock.Controller) *MockTokenStore {
+	mock := &MockTokenStore{ctrl: ctrl}
+	mock.recorder = &MockTokenStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenStore) EXPECT() *MockTokenStoreMockRecorder {
+	return m.recorder
+}
+
+// GetString mocks base method.
+func (m *MockTokenStore) GetString(ctx context.Context, key string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetString", ctx, key)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetString indicates an expected call of GetString.
+func (mr *MockTokenStoreMockRecorder) GetString(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetString", reflect.TypeOf((*MockTokenStore)(nil).GetString), ctx, key)
+}
+
+// SetWithExpiration mocks base method.
+func (m *MockTokenStore) SetWithExpiration(ctx context.Context, key string, value any, expiration time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWithExpiration", ctx, key, value, expiration)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWithExpiration indicates an expected call of SetWithExpiration.
+func (mr *MockTokenStoreMockRecorder) SetWithExpiration(ctx, key, value, expiration any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWithExpiration", reflect.TypeOf((*MockTokenStore)(nil).SetWithExpiration), ctx, key, value, expiration)
+}
+
+// MockAttemptCounter is a mock of AttemptCounter interface.
+type MockAttemptCounter struct {
+	ctrl     *gomock.Controller
+	recorder *MockAttemptCounterMockRecorder
+	isgomock struct{}
+}
+
+// MockAttemptCounterMockRecorder is the mock recorder for MockAttemptCounter.
+type MockAttemptCounterMockRecorder struct {
+	mock *MockAttemptCounter
+}
+
+// NewMockAttemptCounter creates a new mock instance.
+func NewMockAttemptCounter(ctrl *gomock.Controller) *MockAttemptCounter {
+	mock := &MockAttemptCounter{ctrl: ctrl}
+	mock.recorder = &MockAttemptCounterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAttemptCounter) EXPECT() *MockAttemptCounterMockRecorder {
+	return m.recorder
+}
+
+// DeleteKeys mocks base method.
+func (m *MockAttemptCounter) DeleteKeys(ctx context.Context, keys ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range keys {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteKeys", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteKeys indicates an expected call of DeleteKeys.
+func (mr *MockAttemptCounterMockRecorder) DeleteKeys(ctx any, keys ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, keys...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteKeys", reflect.TypeOf((*MockAttemptCounter)(nil).DeleteKeys), varargs...)
+}
+
+// GetInt mocks base method.
+func (m *MockAttemptCounter) GetInt(ctx context.Context, key string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInt", ctx, key)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInt indicates an expected call of GetInt.
+func (mr *MockAttemptCounterMockRecorder) GetInt(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInt", reflect.TypeOf((*MockAttemptCounter)(nil).GetInt), ctx, key)
+}
+
+// IncrWithExpiration mocks base method.
+func (m *MockAttemptCounter) IncrWithExpiration(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrWithExpiration", ctx, key, expiration)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrWithExpiration indicates an expected call of IncrWithExpiration.
+func (mr *MockAttemptCounterMockRecorder) IncrWithExpiration(ctx, key, expiration any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrWithExpiration", reflect.TypeOf((*MockAttemptCounter)(nil).IncrWithExpiration), ctx, key, expiration)
+}
+
+// MockUserService is a mock of UserService interface.
+type MockUserService struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockUserServiceMockRecorder is the mock recorder for MockUserService.
+type MockUserServiceMockRecorder struct {
+	mock *MockUserService
+}
+
+// NewMockUserService creates a new mock instance.
+func NewMockUserService(ctrl *gomock.Controller) *MockUserService {
+	mock := &MockUserService{ctrl: ctrl}
+	mock.recorder = &MockUserServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
+	return m.recorder
+}
+
+// ChangePassword mocks base method.
+func (m *MockUserService) ChangePassword(ctx context.Context, userID uuid.UUID, req *models.ChangePasswordRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, userID, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockUserServiceMockRecorder) ChangePassword(ctx, userID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUserService)(nil).ChangePassword), ctx, userID, req)
+}
+
+// CreateAddress mocks base method.
+func (m *MockUserService) CreateAddress(ctx context.Context, userID uuid.UUID, address *models.UserAddress) (*models.UserAddress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAddress", ctx, userID, address)
+	ret0, _ := ret[0].(*models.UserAddress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAddress indicates an expected call of CreateAddress.
+func (mr *MockUserServiceMockRecorder) CreateAddress(ctx, userID, address any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAddress", reflect.TypeOf((*MockUserService)(nil).CreateAddress), ctx, userID, address)
+}
+
+// DeleteAddress mocks base method.
+func (m *MockUserService) DeleteAddress(ctx context.Context, userID, addressID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAddress", ctx, userID, addressID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAddress indicates an expected call of DeleteAddress.
+func (mr *MockUserServiceMockRecorder) DeleteAddress(ctx, userID, addressID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAddress", reflect.TypeOf((*MockUserService)(nil).DeleteAddress), ctx, userID, addressID)
+}
+
+// ForgotPassword mocks base method.
+func (m *MockUserService) ForgotPassword(ctx context.Context, req *models.ForgotPasswordRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgotPassword", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgotPassword indicates an expected call of ForgotPassword.
+func (mr *MockUserServiceMockRecorder) ForgotPassword(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgotPassword", reflect.TypeOf((*MockUserService)(nil).ForgotPassword), ctx, req)
+}
+
+// GetAddresses mocks base method.
+func (m *MockUserService) GetAddresses(ctx context.Context, userID uuid.UUID) ([]*models.UserAddress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAddresses", ctx, userID)
+	ret0, _ := ret[0].([]*models.UserAddress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAddresses indicates an expected call of GetAddresses.
+func (mr *MockUserServiceMockRecorder) GetAddresses(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAddresses", reflect.TypeOf((*MockUserService)(nil).GetAddresses), ctx, userID)
+}
+
+// GetProfile mocks base method.
+func (m *MockUserService) GetProfile(ctx context.Context, userID uuid.UUID) (*models.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfile", ctx, userID)
+	ret0, _ := ret[0].(*models.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfile indicates an expected call of GetProfile.
+func (mr *MockUserServiceMockRecorder) GetProfile(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockUserService)(nil).GetProfile), ctx, userID)
+}
+
+// GetProfiles mocks base method.
+func (m *MockUserService) GetProfiles(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*models.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfiles", ctx, userIDs)
+	ret0, _ := ret[0].(map[uuid.UUID]*models.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfiles indicates an expected call of GetProfiles.
+func (mr *MockUserServiceMockRecorder) GetProfiles(ctx, userIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfiles", reflect.TypeOf((*MockUserService)(nil).GetProfiles), ctx, userIDs)
+}
+
+// Login mocks base method.
+func (m *MockUserService) Login(ctx context.Context, req *models.LoginRequest) (*models.AuthTokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Login", ctx, req)
+	ret0, _ := ret[0].(*models.AuthTokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Login indicates an expected call of Login.
+func (mr *MockUserServiceMockRecorder) Login(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockUserService)(nil).Login), ctx, req)
+}
+
+// PurgeExpiredTokens mocks base method.
+func (m *MockUserService) PurgeExpiredTokens(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeExpiredTokens", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeExpiredTokens indicates an expected call of PurgeExpiredTokens.
+func (mr *MockUserServiceMockRecorder) PurgeExpiredTokens(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeExpiredTokens", reflect.TypeOf((*MockUserService)(nil).PurgeExpiredTokens), ctx)
+}
+
+// RefreshToken mocks base method.
+func (m *MockUserService) RefreshToken(ctx context.Context, refreshToken string) (*models.AuthTokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshToken", ctx, refreshToken)
+	ret0, _ := ret[0].(*models.AuthTokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshToken indicates an expected call of RefreshToken.
+func (mr *MockUserServiceMockRecorder) RefreshToken(ctx, refreshToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockUserService)(nil).RefreshToken), ctx, refreshToken)
+}
+
+// Register mocks base method.
+func (m *MockUserService) Register(ctx context.Context, req *models.CreateUserRequest) (*models.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Register", ctx, req)
+	ret0, _ := ret[0].(*models.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockUserServiceMockRecorder) Register(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockUserService)(nil).Register), ctx, req)
+}
+
+// ResendEmailVerification mocks base method.
+func (m *MockUserService) ResendEmailVerification(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResendEmailVerification", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResendEmailVerification indicates an expected call of ResendEmailVerification.
+func (mr *MockUserServiceMockRecorder) ResendEmailVerification(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendEmailVerification", reflect.TypeOf((*MockUserService)(nil).ResendEmailVerification), ctx, userID)
+}
+
+// ResetPassword mocks base method.
+func (m *MockUserService) ResetPassword(ctx context.Context, req *models.ResetPasswordRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUserServiceMockRecorder) ResetPassword(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUserService)(nil).ResetPassword), ctx, req)
+}
+
+// UpdateAddress mocks base method.
+func (m *MockUserService) UpdateAddress(ctx context.Context, userID, addressID uuid.UUID, address *models.UserAddress) (*models.UserAddress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAddress", ctx, userID, addressID, address)
+	ret0, _ := ret[0].(*models.UserAddress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAddress indicates an expected call of UpdateAddress.
+func (mr *MockUserServiceMockRecorder) UpdateAddress(ctx, userID, addressID, address any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAddress", reflect.TypeOf((*MockUserService)(nil).UpdateAddress), ctx, userID, addressID, address)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockUserService) UpdateProfile(ctx context.Context, userID uuid.UUID, req *models.UpdateUserRequest) (*models.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", ctx, userID, req)
+	ret0, _ := ret[0].(*models.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockUserServiceMockRecorder) UpdateProfile(ctx, userID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockUserService)(nil).UpdateProfile), ctx, userID, req)
+}
+
+// ValidateCredentials mocks base method.
+func (m *MockUserService) ValidateCredentials(ctx context.Context, req *models.LoginRequest) (*models.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateCredentials", ctx, req)
+	ret0, _ := ret[0].(*models.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateCredentials indicates an expected call of ValidateCredentials.
+func (mr *MockUserServiceMockRecorder) ValidateCredentials(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateCredentials", reflect.TypeOf((*MockUserService)(nil).ValidateCredentials), ctx, req)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockUserService) VerifyEmail(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockUserServiceMockRecorder) VerifyEmail(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockUserService)(nil).VerifyEmail), ctx, token)
+}
@@ -48,8 +48,8 @@ type UserAddress struct {
 	City         string    `json:"city" db:"city"`
 	State        *string   `json:"state,omitempty" db:"state"`
 	PostalCode   string    `json:"postal_code" db:"postal_code"`
-	Country      string    `json:"country" db:"country"`
-	Phone        *string   `json:"phone,omitempty" db:"phone"`
+	Country      string    `json:"country" db:"country" binding:"omitempty,country"`
+	Phone        *string   `json:"phone,omitempty" db:"phone" binding:"omitempty,phone"`
 	IsDefault    bool      `json:"is_default" db:"is_default"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
@@ -59,17 +59,17 @@ type UserAddress struct {
 type CreateUserRequest struct {
 	Username  string  `json:"username" binding:"required,min=3,max=50"`
 	Email     string  `json:"email" binding:"required,email"`
-	Password  string  `json:"password" binding:"required,min=8"`
+	Password  string  `json:"password" binding:"required,min=8,password"`
 	FirstName *string `json:"first_name,omitempty" binding:"omitempty,max=100"`
 	LastName  *string `json:"last_name,omitempty" binding:"omitempty,max=100"`
-	Phone     *string `json:"phone,omitempty" binding:"omitempty,max=20"`
+	Phone     *string `json:"phone,omitempty" binding:"omitempty,max=20,phone"`
 }
 
 // UpdateUserRequest represents the request to update a user
 type UpdateUserRequest struct {
 	FirstName *string `json:"first_name,omitempty" binding:"omitempty,max=100"`
 	LastName  *string `json:"last_name,omitempty" binding:"omitempty,max=100"`
-	Phone     *string `json:"phone,omitempty" binding:"omitempty,max=20"`
+	Phone     *string `json:"phone,omitempty" binding:"omitempty,max=20,phone"`
 }
 
 // LoginRequest represents a login request
@@ -81,7 +81,7 @@ type LoginRequest struct {
 // ChangePasswordRequest represents a password change request
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" binding:"required"`
-	NewPassword     string `json:"new_password" binding:"required,min=8"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,password"`
 }
 
 // ForgotPasswordRequest represents a forgot password request
@@ -92,7 +92,7 @@ type ForgotPasswordRequest struct {
 // ResetPasswordRequest represents a reset password request
 type ResetPasswordRequest struct {
 	Token       string `json:"token" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required,min=8"`
+	NewPassword string `json:"new_password" binding:"required,min=8,password"`
 }
 
 // UserResponse represents a user response (without sensitive data)
@@ -111,6 +111,22 @@ type UserResponse struct {
 	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
 }
 
+// MaxBatchGetUsers is how many users a batch get asks for at most
+const MaxBatchGetUsers = 100
+
+// BatchGetUsersRequest asks for several users at once, for internal
+// consumers that would otherwise look them up one by one
+type BatchGetUsersRequest struct {
+	UserIDs []uuid.UUID `json:"user_ids" binding:"required,min=1,max=100"`
+}
+
+// BatchGetUsersResponse holds the users of a batch get by ID. IDs of unknown
+// users are listed in NotFound.
+type BatchGetUsersResponse struct {
+	Users    map[uuid.UUID]*UserResponse `json:"users"`
+	NotFound []uuid.UUID                 `json:"not_found"`
+}
+
 // ToResponse converts a User to UserResponse
 func (u *User) ToResponse() *UserResponse {
 	return &UserResponse{
@@ -156,3 +172,12 @@ type AuthTokens struct {
 	TokenType    string `json:"token_type"`
 	ExpiresIn    int64  `json:"expires_in"`
 }
+
+// EmailMessage asks the notification service to send a templated email. It
+// is queued on the email notifications queue rather than sent synchronously.
+type EmailMessage struct {
+	Template string                 `json:"template"`
+	Locale   string                 `json:"locale,omitempty"`
+	To       string                 `json:"to"`
+	Data     map[string]interface{} `json:"data"`
+}
@@ -9,14 +9,18 @@ import (
 	"github.com/jmoiron/sqlx"
 
 	"github.com/kaanevranportfolio/Commercium/internal/user/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
 	"github.com/kaanevranportfolio/Commercium/pkg/database"
 	"github.com/kaanevranportfolio/Commercium/pkg/logger"
 )
 
+//go:generate go run go.uber.org/mock/mockgen -source=user_repository.go -destination=../mocks/mock_user_repository.go -package=mocks
+
 // UserRepository defines the interface for user data operations
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
 	GetByUsername(ctx context.Context, username string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
@@ -44,6 +48,7 @@ type UserRepository interface {
 	CreateEmailVerificationToken(ctx context.Context, token *models.EmailVerificationToken) error
 	GetEmailVerificationToken(ctx context.Context, token string) (*models.EmailVerificationToken, error)
 	MarkEmailVerificationTokenUsed(ctx context.Context, tokenID uuid.UUID) error
+	DeleteExpiredTokens(ctx context.Context) (int64, error)
 }
 
 // userRepository implements the UserRepository interface
@@ -96,7 +101,7 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 	err := r.db.GetContext(ctx, user, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, apperrors.NotFound("user not found")
 		}
 		r.logger.Error("Failed to get user by ID", "error", err, "id", id)
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -105,6 +110,33 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 	return user, nil
 }
 
+// GetByIDs retrieves users by ID, keyed by ID. Unknown IDs are left out.
+func (r *userRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.User, error) {
+	found := make(map[uuid.UUID]*models.User, len(ids))
+	if len(ids) == 0 {
+		return found, nil
+	}
+
+	users := []*models.User{}
+	query := `
+		SELECT id, username, email, password_hash, first_name, last_name, phone,
+		       is_active, is_verified, role, created_at, updated_at, last_login_at
+		FROM users
+		WHERE id = ANY($1)`
+
+	err := r.db.SelectContext(ctx, &users, query, ids)
+	if err != nil {
+		r.logger.Error("Failed to get users by ID", "error", err, "ids", len(ids))
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	for _, user := range users {
+		found[user.ID] = user
+	}
+
+	return found, nil
+}
+
 // GetByEmail retrieves a user by email
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	user := &models.User{}
@@ -117,7 +149,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 	err := r.db.GetContext(ctx, user, query, email)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, apperrors.NotFound("user not found")
 		}
 		r.logger.Error("Failed to get user by email", "error", err, "email", email)
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -138,7 +170,7 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*m
 	err := r.db.GetContext(ctx, user, query, username)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, apperrors.NotFound("user not found")
 		}
 		r.logger.Error("Failed to get user by username", "error", err, "username", username)
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -167,7 +199,7 @@ func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 	}
 	
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		return apperrors.NotFound("user not found")
 	}
 	
 	return nil
@@ -189,7 +221,7 @@ func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 	
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		return apperrors.NotFound("user not found")
 	}
 	
 	return nil
@@ -263,7 +295,7 @@ func (r *userRepository) GetProfile(ctx context.Context, userID uuid.UUID) (*mod
 	err := r.db.GetContext(ctx, profile, query, userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user profile not found")
+			return nil, apperrors.NotFound("user profile not found")
 		}
 		r.logger.Error("Failed to get user profile", "error", err, "user_id", userID)
 		return nil, fmt.Errorf("failed to get user profile: %w", err)
@@ -292,7 +324,7 @@ func (r *userRepository) UpdateProfile(ctx context.Context, profile *models.User
 	}
 	
 	if rowsAffected == 0 {
-		return fmt.Errorf("user profile not found")
+		return apperrors.NotFound("user profile not found")
 	}
 	
 	return nil
@@ -300,7 +332,7 @@ func (r *userRepository) UpdateProfile(ctx context.Context, profile *models.User
 
 // CreateAddress creates a user address
 func (r *userRepository) CreateAddress(ctx context.Context, address *models.UserAddress) error {
-	return r.db.Transaction(func(tx *sqlx.Tx) error {
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
 		// If this is being set as default, unset other default addresses
 		if address.IsDefault {
 			_, err := tx.ExecContext(ctx, 
@@ -373,7 +405,7 @@ func (r *userRepository) GetAddressByID(ctx context.Context, id uuid.UUID) (*mod
 	err := r.db.GetContext(ctx, address, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("address not found")
+			return nil, apperrors.NotFound("address not found")
 		}
 		r.logger.Error("Failed to get address by ID", "error", err, "id", id)
 		return nil, fmt.Errorf("failed to get address: %w", err)
@@ -384,7 +416,7 @@ func (r *userRepository) GetAddressByID(ctx context.Context, id uuid.UUID) (*mod
 
 // UpdateAddress updates a user address
 func (r *userRepository) UpdateAddress(ctx context.Context, address *models.UserAddress) error {
-	return r.db.Transaction(func(tx *sqlx.Tx) error {
+	return r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
 		// If this is being set as default, unset other default addresses
 		if address.IsDefault {
 			_, err := tx.ExecContext(ctx, 
@@ -414,7 +446,7 @@ func (r *userRepository) UpdateAddress(ctx context.Context, address *models.User
 		}
 		
 		if rowsAffected == 0 {
-			return fmt.Errorf("address not found")
+			return apperrors.NotFound("address not found")
 		}
 		
 		return nil
@@ -437,7 +469,7 @@ func (r *userRepository) DeleteAddress(ctx context.Context, id uuid.UUID) error
 	}
 	
 	if rowsAffected == 0 {
-		return fmt.Errorf("address not found")
+		return apperrors.NotFound("address not found")
 	}
 	
 	return nil
@@ -478,7 +510,7 @@ func (r *userRepository) GetPasswordResetToken(ctx context.Context, token string
 	err := r.db.GetContext(ctx, resetToken, query, token)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("invalid or expired token")
+			return nil, apperrors.NotFound("invalid or expired token")
 		}
 		r.logger.Error("Failed to get password reset token", "error", err)
 		return nil, fmt.Errorf("failed to get password reset token: %w", err)
@@ -535,7 +567,7 @@ func (r *userRepository) GetEmailVerificationToken(ctx context.Context, token st
 	err := r.db.GetContext(ctx, verificationToken, query, token)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("invalid or expired token")
+			return nil, apperrors.NotFound("invalid or expired token")
 		}
 		r.logger.Error("Failed to get email verification token", "error", err)
 		return nil, fmt.Errorf("failed to get email verification token: %w", err)
@@ -556,3 +588,24 @@ func (r *userRepository) MarkEmailVerificationTokenUsed(ctx context.Context, tok
 	
 	return nil
 }
+
+// DeleteExpiredTokens deletes the password reset and email verification
+// tokens that expired or were used, and returns how many were deleted
+func (r *userRepository) DeleteExpiredTokens(ctx context.Context) (int64, error) {
+	var deleted int64
+	for _, table := range []string{"password_reset_tokens", "email_verification_tokens"} {
+		result, err := r.db.ExecContext(ctx, `DELETE FROM `+table+` WHERE expires_at < NOW() OR used_at IS NOT NULL`)
+		if err != nil {
+			r.logger.Error("Failed to delete expired tokens", "error", err, "table", table)
+			return deleted, fmt.Errorf("failed to delete expired tokens: %w", err)
+		}
+
+		count, err := result.RowsAffected()
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete expired tokens: %w", err)
+		}
+		deleted += count
+	}
+
+	return deleted, nil
+}
@@ -5,31 +5,82 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"slices"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/kaanevranportfolio/Commercium/internal/user/models"
 	"github.com/kaanevranportfolio/Commercium/internal/user/repository"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
 	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/cache"
 	"github.com/kaanevranportfolio/Commercium/pkg/config"
-	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/i18n"
 	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/rabbitmq"
+	"github.com/kaanevranportfolio/Commercium/pkg/tenant"
 )
 
+// Notification templates of the emails the user service queues
+const (
+	templatePasswordReset     = "password_reset"
+	templateEmailVerification = "email_verification"
+)
+
+// emailPriorities are the queue priorities of the emails the user service
+// queues: a user waiting on a password reset goes ahead of bulk email
+var emailPriorities = map[string]uint8{
+	templatePasswordReset:     rabbitmq.PriorityHigh,
+	templateEmailVerification: rabbitmq.PriorityNormal,
+}
+
+//go:generate go run go.uber.org/mock/mockgen -source=user_service.go -destination=../mocks/mock_user_service.go -package=mocks
+
+// EmailPublisher queues emails for the notification service to send
+type EmailPublisher interface {
+	PublishPriority(ctx context.Context, queue string, priority uint8, value interface{}) error
+}
+
+// TokenIssuer issues the access and refresh tokens of users, and validates
+// the refresh tokens they bring back. *auth.JWTService is one.
+type TokenIssuer interface {
+	GenerateTokenPair(userID uuid.UUID, email, username, role, tenantID string) (*auth.TokenPair, error)
+	ValidateRefreshToken(tokenString string) (*jwt.RegisteredClaims, error)
+}
+
+// TokenStore keeps the refresh token each user was last issued, so older
+// ones can't be used. *database.Redis is one.
+type TokenStore interface {
+	SetWithExpiration(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	GetString(ctx context.Context, key string) (string, error)
+}
+
+// AttemptCounter counts the failed logins of each account, shared by every
+// instance, to lock accounts out. *database.Redis is one.
+type AttemptCounter interface {
+	GetInt(ctx context.Context, key string) (int64, error)
+	IncrWithExpiration(ctx context.Context, key string, expiration time.Duration) (int64, error)
+	DeleteKeys(ctx context.Context, keys ...string) error
+}
+
 // UserService defines the interface for user business logic
 type UserService interface {
 	Register(ctx context.Context, req *models.CreateUserRequest) (*models.UserResponse, error)
 	Login(ctx context.Context, req *models.LoginRequest) (*models.AuthTokens, error)
+	ValidateCredentials(ctx context.Context, req *models.LoginRequest) (*models.UserResponse, error)
 	RefreshToken(ctx context.Context, refreshToken string) (*models.AuthTokens, error)
 	GetProfile(ctx context.Context, userID uuid.UUID) (*models.UserResponse, error)
+	GetProfiles(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*models.UserResponse, error)
 	UpdateProfile(ctx context.Context, userID uuid.UUID, req *models.UpdateUserRequest) (*models.UserResponse, error)
 	ChangePassword(ctx context.Context, userID uuid.UUID, req *models.ChangePasswordRequest) error
 	ForgotPassword(ctx context.Context, req *models.ForgotPasswordRequest) error
 	ResetPassword(ctx context.Context, req *models.ResetPasswordRequest) error
 	VerifyEmail(ctx context.Context, token string) error
 	ResendEmailVerification(ctx context.Context, userID uuid.UUID) error
+	PurgeExpiredTokens(ctx context.Context) error
 	
 	// Address management
 	CreateAddress(ctx context.Context, userID uuid.UUID, address *models.UserAddress) (*models.UserAddress, error)
@@ -38,30 +89,57 @@ type UserService interface {
 	DeleteAddress(ctx context.Context, userID uuid.UUID, addressID uuid.UUID) error
 }
 
+// ProfileCachePrefix namespaces the keys of the profile cache, keyed by
+// user ID, so tools changing users outside the service can invalidate them
+const ProfileCachePrefix = "user_profiles"
+
 // userService implements the UserService interface
 type userService struct {
 	repo       repository.UserRepository
-	jwtService *auth.JWTService
-	redis      *database.Redis
+	jwtService TokenIssuer
+	redis      TokenStore
+	attempts   AttemptCounter
+	emails     EmailPublisher
+	profiles   *cache.Typed[*models.UserResponse]
+	auditLog   *logger.AuditLogger
 	config     *config.Config
 	logger     *logger.Logger
 }
 
-// NewUserService creates a new user service
+// NewUserService creates a new user service. attempts may be nil, in which
+// case accounts aren't locked out after failed logins, as when lockout is
+// disabled. emails may be nil, in which case tokens are generated but the
+// emails sending them aren't queued.
+// profiles may be nil, in which case profiles are read from the database
+// every time. auditLog may be nil, in which case security events such as
+// logins are only logged with the service's lines.
 func NewUserService(
 	repo repository.UserRepository,
-	jwtService *auth.JWTService,
-	redis *database.Redis,
+	jwtService TokenIssuer,
+	redis TokenStore,
+	attempts AttemptCounter,
+	emails EmailPublisher,
+	profiles *cache.Cache,
+	auditLog *logger.AuditLogger,
 	config *config.Config,
 	logger *logger.Logger,
 ) UserService {
-	return &userService{
+	s := &userService{
 		repo:       repo,
 		jwtService: jwtService,
 		redis:      redis,
+		emails:     emails,
+		auditLog:   auditLog,
 		config:     config,
 		logger:     logger,
 	}
+	if profiles != nil {
+		s.profiles = cache.NewTyped[*models.UserResponse](profiles)
+	}
+	if config.Services.User.Lockout.Enabled {
+		s.attempts = attempts
+	}
+	return s
 }
 
 // Register creates a new user account
@@ -69,12 +147,12 @@ func (s *userService) Register(ctx context.Context, req *models.CreateUserReques
 	// Check if user already exists
 	existingUser, err := s.repo.GetByEmail(ctx, req.Email)
 	if err == nil && existingUser != nil {
-		return nil, fmt.Errorf("user with email %s already exists", req.Email)
+		return nil, apperrors.Conflict("user with email %s already exists", req.Email)
 	}
 
 	existingUser, err = s.repo.GetByUsername(ctx, req.Username)
 	if err == nil && existingUser != nil {
-		return nil, fmt.Errorf("user with username %s already exists", req.Username)
+		return nil, apperrors.Conflict("user with username %s already exists", req.Username)
 	}
 
 	// Hash password
@@ -115,42 +193,28 @@ func (s *userService) Register(ctx context.Context, req *models.CreateUserReques
 	}
 
 	// Generate email verification token
-	err = s.generateEmailVerificationToken(ctx, user.ID)
+	err = s.generateEmailVerificationToken(ctx, user)
 	if err != nil {
 		s.logger.Warn("Failed to generate email verification token", "error", err, "user_id", user.ID)
 	}
 
+	s.audit(ctx, "user.registered", "user_id", user.ID)
 	s.logger.Info("User registered successfully", "user_id", user.ID, "email", user.Email)
 	return user.ToResponse(), nil
 }
 
 // Login authenticates a user and returns tokens
 func (s *userService) Login(ctx context.Context, req *models.LoginRequest) (*models.AuthTokens, error) {
-	// Get user by username or email
-	var user *models.User
-	var err error
-
-	// Try to find by email first, then by username
-	user, err = s.repo.GetByEmail(ctx, req.Username)
+	user, err := s.authenticate(ctx, req)
 	if err != nil {
-		user, err = s.repo.GetByUsername(ctx, req.Username)
-		if err != nil {
-			return nil, fmt.Errorf("invalid credentials")
-		}
+		s.audit(ctx, "user.login_failed", "username", req.Username, "reason", err)
+		return nil, err
 	}
 
-	// Check if user is active
-	if !user.IsActive {
-		return nil, fmt.Errorf("account is deactivated")
-	}
-
-	// Verify password
-	if !s.verifyPassword(req.Password, user.PasswordHash) {
-		return nil, fmt.Errorf("invalid credentials")
-	}
-
-	// Generate tokens
-	tokenPair, err := s.jwtService.GenerateTokenPair(user.ID, user.Email, user.Username, user.Role)
+	// Generate tokens, for the tenant of the request: the user was found
+	// in its schema
+	tenantID, _ := tenant.FromContext(ctx)
+	tokenPair, err := s.jwtService.GenerateTokenPair(user.ID, user.Email, user.Username, user.Role, tenantID)
 	if err != nil {
 		s.logger.Error("Failed to generate tokens", "error", err, "user_id", user.ID)
 		return nil, fmt.Errorf("failed to generate tokens: %w", err)
@@ -161,6 +225,7 @@ func (s *userService) Login(ctx context.Context, req *models.LoginRequest) (*mod
 	if err != nil {
 		s.logger.Warn("Failed to update last login", "error", err, "user_id", user.ID)
 	}
+	s.invalidateProfile(ctx, user.ID)
 
 	// Cache refresh token in Redis
 	refreshKey := fmt.Sprintf("refresh_token:%s", user.ID.String())
@@ -169,6 +234,7 @@ func (s *userService) Login(ctx context.Context, req *models.LoginRequest) (*mod
 		s.logger.Warn("Failed to cache refresh token", "error", err, "user_id", user.ID)
 	}
 
+	s.audit(ctx, "user.login", "user_id", user.ID)
 	s.logger.Info("User logged in successfully", "user_id", user.ID, "email", user.Email)
 	
 	return &models.AuthTokens{
@@ -179,24 +245,113 @@ func (s *userService) Login(ctx context.Context, req *models.LoginRequest) (*mod
 	}, nil
 }
 
+// ValidateCredentials checks a username or email and password pair for
+// other services and returns the user it belongs to, without logging in
+func (s *userService) ValidateCredentials(ctx context.Context, req *models.LoginRequest) (*models.UserResponse, error) {
+	user, err := s.authenticate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return user.ToResponse(), nil
+}
+
+// authenticate returns the active user a username or email and password pair belongs to
+func (s *userService) authenticate(ctx context.Context, req *models.LoginRequest) (*models.User, error) {
+	// Try to find by email first, then by username
+	user, err := s.repo.GetByEmail(ctx, req.Username)
+	if err != nil {
+		user, err = s.repo.GetByUsername(ctx, req.Username)
+		if err != nil {
+			return nil, apperrors.Unauthorized("invalid credentials")
+		}
+	}
+
+	// Check if user is active
+	if !user.IsActive {
+		return nil, apperrors.Forbidden("account is deactivated")
+	}
+
+	if s.lockedOut(ctx, user) {
+		return nil, apperrors.RateLimited("account locked after too many failed logins, try again later")
+	}
+
+	// Verify password
+	if !s.verifyPassword(req.Password, user.PasswordHash) {
+		s.recordFailedLogin(ctx, user)
+		return nil, apperrors.Unauthorized("invalid credentials")
+	}
+	s.resetFailedLogins(ctx, user)
+
+	return user, nil
+}
+
+// failedLoginsKey returns the key counting the failed logins of a user
+func failedLoginsKey(userID uuid.UUID) string {
+	return "failed_logins:" + userID.String()
+}
+
+// lockedOut reports whether a user failed to log in too many times to try
+// again yet. Failing to count lets the attempt through, which the rate
+// limit of the login route still bounds.
+func (s *userService) lockedOut(ctx context.Context, user *models.User) bool {
+	if s.attempts == nil {
+		return false
+	}
+	failures, err := s.attempts.GetInt(ctx, failedLoginsKey(user.ID))
+	if err != nil {
+		s.logger.Warn("Failed to read failed logins", "error", err, "user_id", user.ID)
+		return false
+	}
+	return failures >= int64(s.config.Services.User.Lockout.MaxAttempts)
+}
+
+// recordFailedLogin counts a failed login of a user. The count expires a
+// window after the last failure, unlocking the account if it was locked.
+func (s *userService) recordFailedLogin(ctx context.Context, user *models.User) {
+	if s.attempts == nil {
+		return
+	}
+	lockout := s.config.Services.User.Lockout
+	failures, err := s.attempts.IncrWithExpiration(ctx, failedLoginsKey(user.ID), lockout.Window)
+	if err != nil {
+		s.logger.Warn("Failed to count failed login", "error", err, "user_id", user.ID)
+		return
+	}
+	if failures == int64(lockout.MaxAttempts) {
+		s.audit(ctx, "user.locked_out", "user_id", user.ID, "failures", failures)
+		s.logger.Warn("Account locked after failed logins", "user_id", user.ID, "failures", failures)
+	}
+}
+
+// resetFailedLogins clears the failed logins of a user who logged in
+func (s *userService) resetFailedLogins(ctx context.Context, user *models.User) {
+	if s.attempts == nil {
+		return
+	}
+	if err := s.attempts.DeleteKeys(ctx, failedLoginsKey(user.ID)); err != nil {
+		s.logger.Warn("Failed to reset failed logins", "error", err, "user_id", user.ID)
+	}
+}
+
 // RefreshToken generates new tokens using a refresh token
 func (s *userService) RefreshToken(ctx context.Context, refreshToken string) (*models.AuthTokens, error) {
 	// Validate refresh token
 	claims, err := s.jwtService.ValidateRefreshToken(refreshToken)
 	if err != nil {
-		return nil, fmt.Errorf("invalid refresh token: %w", err)
+		return nil, apperrors.Unauthorized("invalid refresh token").Wrap(err)
 	}
 
 	userID, err := uuid.Parse(claims.Subject)
 	if err != nil {
-		return nil, fmt.Errorf("invalid user ID in token: %w", err)
+		return nil, apperrors.Unauthorized("invalid user ID in token").Wrap(err)
 	}
 
 	// Check if refresh token exists in Redis
 	refreshKey := fmt.Sprintf("refresh_token:%s", userID.String())
 	cachedToken, err := s.redis.GetString(ctx, refreshKey)
 	if err != nil || cachedToken != refreshToken {
-		return nil, fmt.Errorf("refresh token not found or expired")
+		return nil, apperrors.Unauthorized("refresh token not found or expired")
 	}
 
 	// Get user details
@@ -206,11 +361,13 @@ func (s *userService) RefreshToken(ctx context.Context, refreshToken string) (*m
 	}
 
 	if !user.IsActive {
-		return nil, fmt.Errorf("account is deactivated")
+		return nil, apperrors.Forbidden("account is deactivated")
 	}
 
-	// Generate new token pair
-	tokenPair, err := s.jwtService.GenerateTokenPair(user.ID, user.Email, user.Username, user.Role)
+	// Generate new token pair, for the tenant of the request: the user was
+	// found in its schema
+	tenantID, _ := tenant.FromContext(ctx)
+	tokenPair, err := s.jwtService.GenerateTokenPair(user.ID, user.Email, user.Username, user.Role, tenantID)
 	if err != nil {
 		s.logger.Error("Failed to generate tokens", "error", err, "user_id", user.ID)
 		return nil, fmt.Errorf("failed to generate tokens: %w", err)
@@ -232,6 +389,17 @@ func (s *userService) RefreshToken(ctx context.Context, refreshToken string) (*m
 
 // GetProfile retrieves a user's profile
 func (s *userService) GetProfile(ctx context.Context, userID uuid.UUID) (*models.UserResponse, error) {
+	if s.profiles == nil {
+		return s.loadProfile(ctx, userID)
+	}
+
+	return s.profiles.Fetch(ctx, userID.String(), func(ctx context.Context) (*models.UserResponse, error) {
+		return s.loadProfile(ctx, userID)
+	})
+}
+
+// loadProfile reads a user's profile from the database
+func (s *userService) loadProfile(ctx context.Context, userID uuid.UUID) (*models.UserResponse, error) {
 	user, err := s.repo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user profile: %w", err)
@@ -240,6 +408,52 @@ func (s *userService) GetProfile(ctx context.Context, userID uuid.UUID) (*models
 	return user.ToResponse(), nil
 }
 
+// GetProfiles retrieves the profiles of several users at once, keyed by
+// user ID; unknown users are left out. Cached profiles are used, and the
+// others read in a single query, without being cached: only Fetch stores a
+// value safely against a concurrent invalidation.
+func (s *userService) GetProfiles(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*models.UserResponse, error) {
+	profiles := make(map[uuid.UUID]*models.UserResponse, len(userIDs))
+	missing := make([]uuid.UUID, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if _, ok := profiles[userID]; ok || slices.Contains(missing, userID) {
+			continue
+		}
+		if s.profiles != nil {
+			profile, ok, err := s.profiles.Get(ctx, userID.String())
+			if err != nil {
+				s.logger.Warn("Failed to read cached profile", "error", err, "user_id", userID)
+			}
+			if ok {
+				profiles[userID] = profile
+				continue
+			}
+		}
+		missing = append(missing, userID)
+	}
+
+	users, err := s.repo.GetByIDs(ctx, missing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user profiles: %w", err)
+	}
+	for userID, user := range users {
+		profiles[userID] = user.ToResponse()
+	}
+
+	return profiles, nil
+}
+
+// invalidateProfile drops a user's cached profile after it changed. Failing
+// to leaves it stale until it expires, which doesn't fail the change.
+func (s *userService) invalidateProfile(ctx context.Context, userID uuid.UUID) {
+	if s.profiles == nil {
+		return
+	}
+	if err := s.profiles.Invalidate(ctx, userID.String()); err != nil {
+		s.logger.Warn("Failed to invalidate cached profile", "error", err, "user_id", userID)
+	}
+}
+
 // UpdateProfile updates a user's profile
 func (s *userService) UpdateProfile(ctx context.Context, userID uuid.UUID, req *models.UpdateUserRequest) (*models.UserResponse, error) {
 	user, err := s.repo.GetByID(ctx, userID)
@@ -263,6 +477,7 @@ func (s *userService) UpdateProfile(ctx context.Context, userID uuid.UUID, req *
 		s.logger.Error("Failed to update user", "error", err, "user_id", userID)
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
+	s.invalidateProfile(ctx, userID)
 
 	s.logger.Info("User profile updated", "user_id", userID)
 	return user.ToResponse(), nil
@@ -277,7 +492,8 @@ func (s *userService) ChangePassword(ctx context.Context, userID uuid.UUID, req
 
 	// Verify current password
 	if !s.verifyPassword(req.CurrentPassword, user.PasswordHash) {
-		return fmt.Errorf("current password is incorrect")
+		s.audit(ctx, "user.password_change_failed", "user_id", userID, "reason", "current password is incorrect")
+		return apperrors.Validation("current password is incorrect")
 	}
 
 	// Hash new password
@@ -292,7 +508,9 @@ func (s *userService) ChangePassword(ctx context.Context, userID uuid.UUID, req
 		s.logger.Error("Failed to update user password", "error", err, "user_id", userID)
 		return fmt.Errorf("failed to update password: %w", err)
 	}
+	s.invalidateProfile(ctx, userID)
 
+	s.audit(ctx, "user.password_changed", "user_id", userID)
 	s.logger.Info("User password changed", "user_id", userID)
 	return nil
 }
@@ -326,7 +544,15 @@ func (s *userService) ForgotPassword(ctx context.Context, req *models.ForgotPass
 		return fmt.Errorf("failed to create reset token: %w", err)
 	}
 
-	// TODO: Send email with reset link
+	err = s.queueEmail(ctx, user, templatePasswordReset, map[string]interface{}{
+		"token":      token,
+		"expires_at": resetToken.ExpiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to queue password reset email: %w", err)
+	}
+
+	s.audit(ctx, "user.password_reset_requested", "user_id", user.ID)
 	s.logger.Info("Password reset token generated", "user_id", user.ID, "email", user.Email)
 	return nil
 }
@@ -336,7 +562,7 @@ func (s *userService) ResetPassword(ctx context.Context, req *models.ResetPasswo
 	// Get and validate token
 	resetToken, err := s.repo.GetPasswordResetToken(ctx, req.Token)
 	if err != nil {
-		return fmt.Errorf("invalid or expired reset token")
+		return apperrors.Validation("invalid or expired reset token")
 	}
 
 	// Get user
@@ -358,6 +584,7 @@ func (s *userService) ResetPassword(ctx context.Context, req *models.ResetPasswo
 		s.logger.Error("Failed to reset user password", "error", err, "user_id", user.ID)
 		return fmt.Errorf("failed to reset password: %w", err)
 	}
+	s.invalidateProfile(ctx, user.ID)
 
 	// Mark token as used
 	err = s.repo.MarkPasswordResetTokenUsed(ctx, resetToken.ID)
@@ -365,6 +592,7 @@ func (s *userService) ResetPassword(ctx context.Context, req *models.ResetPasswo
 		s.logger.Warn("Failed to mark reset token as used", "error", err, "token_id", resetToken.ID)
 	}
 
+	s.audit(ctx, "user.password_reset", "user_id", user.ID)
 	s.logger.Info("Password reset successfully", "user_id", user.ID)
 	return nil
 }
@@ -374,7 +602,7 @@ func (s *userService) VerifyEmail(ctx context.Context, token string) error {
 	// Get and validate token
 	verificationToken, err := s.repo.GetEmailVerificationToken(ctx, token)
 	if err != nil {
-		return fmt.Errorf("invalid or expired verification token")
+		return apperrors.Validation("invalid or expired verification token")
 	}
 
 	// Get user
@@ -390,6 +618,7 @@ func (s *userService) VerifyEmail(ctx context.Context, token string) error {
 		s.logger.Error("Failed to verify user email", "error", err, "user_id", user.ID)
 		return fmt.Errorf("failed to verify email: %w", err)
 	}
+	s.invalidateProfile(ctx, user.ID)
 
 	// Mark token as used
 	err = s.repo.MarkEmailVerificationTokenUsed(ctx, verificationToken.ID)
@@ -397,6 +626,7 @@ func (s *userService) VerifyEmail(ctx context.Context, token string) error {
 		s.logger.Warn("Failed to mark verification token as used", "error", err, "token_id", verificationToken.ID)
 	}
 
+	s.audit(ctx, "user.email_verified", "user_id", user.ID)
 	s.logger.Info("Email verified successfully", "user_id", user.ID, "email", user.Email)
 	return nil
 }
@@ -409,10 +639,10 @@ func (s *userService) ResendEmailVerification(ctx context.Context, userID uuid.U
 	}
 
 	if user.IsVerified {
-		return fmt.Errorf("email is already verified")
+		return apperrors.Validation("email is already verified")
 	}
 
-	err = s.generateEmailVerificationToken(ctx, userID)
+	err = s.generateEmailVerificationToken(ctx, user)
 	if err != nil {
 		return fmt.Errorf("failed to generate verification token: %w", err)
 	}
@@ -454,7 +684,7 @@ func (s *userService) UpdateAddress(ctx context.Context, userID uuid.UUID, addre
 	}
 
 	if existingAddress.UserID != userID {
-		return nil, fmt.Errorf("address does not belong to user")
+		return nil, apperrors.NotFound("address not found")
 	}
 
 	// Update address
@@ -480,7 +710,7 @@ func (s *userService) DeleteAddress(ctx context.Context, userID uuid.UUID, addre
 	}
 
 	if address.UserID != userID {
-		return fmt.Errorf("address does not belong to user")
+		return apperrors.NotFound("address not found")
 	}
 
 	err = s.repo.DeleteAddress(ctx, addressID)
@@ -517,8 +747,9 @@ func (s *userService) generateSecureToken(length int) (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
-// generateEmailVerificationToken generates and stores an email verification token
-func (s *userService) generateEmailVerificationToken(ctx context.Context, userID uuid.UUID) error {
+// generateEmailVerificationToken generates and stores an email verification
+// token, and queues the email sending it to the user
+func (s *userService) generateEmailVerificationToken(ctx context.Context, user *models.User) error {
 	token, err := s.generateSecureToken(32)
 	if err != nil {
 		return fmt.Errorf("failed to generate token: %w", err)
@@ -526,7 +757,7 @@ func (s *userService) generateEmailVerificationToken(ctx context.Context, userID
 
 	verificationToken := &models.EmailVerificationToken{
 		ID:        uuid.New(),
-		UserID:    userID,
+		UserID:    user.ID,
 		Token:     token,
 		ExpiresAt: time.Now().Add(24 * time.Hour), // 24 hours expiration
 	}
@@ -536,7 +767,65 @@ func (s *userService) generateEmailVerificationToken(ctx context.Context, userID
 		return fmt.Errorf("failed to create verification token: %w", err)
 	}
 
-	// TODO: Send verification email
-	s.logger.Info("Email verification token generated", "user_id", userID)
+	err = s.queueEmail(ctx, user, templateEmailVerification, map[string]interface{}{
+		"token":      token,
+		"expires_at": verificationToken.ExpiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to queue verification email: %w", err)
+	}
+
+	s.logger.Info("Email verification token generated", "user_id", user.ID)
+	return nil
+}
+
+// PurgeExpiredTokens deletes the password reset and email verification
+// tokens that can no longer be used
+func (s *userService) PurgeExpiredTokens(ctx context.Context) error {
+	deleted, err := s.repo.DeleteExpiredTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		s.logger.Info("Expired tokens deleted", "count", deleted)
+	}
 	return nil
 }
+
+// queueEmail queues a templated email to a user on the email notifications
+// queue with the priority of its template, in the language of the request.
+// The notification service sends it, retrying independently of the request
+// that queued it.
+func (s *userService) queueEmail(ctx context.Context, user *models.User, template string, data map[string]interface{}) error {
+	if s.emails == nil {
+		s.logger.Warn("Email queue disabled, email not sent", "user_id", user.ID, "template", template)
+		return nil
+	}
+
+	data["username"] = user.Username
+	if user.FirstName != nil {
+		data["first_name"] = *user.FirstName
+	}
+
+	err := s.emails.PublishPriority(ctx, s.config.RabbitMQ.Queues.EmailNotifications, emailPriorities[template], &models.EmailMessage{
+		Template: template,
+		Locale:   i18n.Locale(i18n.FromContext(ctx)),
+		To:       user.Email,
+		Data:     data,
+	})
+	if err != nil {
+		s.logger.Error("Failed to queue email", "error", err, "user_id", user.ID, "template", template)
+		return err
+	}
+
+	return nil
+}
+
+// audit records a security event on the audit log. The request carries on
+// when it can't be recorded, as the change it records is already made.
+func (s *userService) audit(ctx context.Context, event string, fields ...interface{}) {
+	if err := s.auditLog.Record(ctx, event, fields...); err != nil {
+		s.logger.Error("Failed to record audit event", "error", err, "event", event)
+	}
+}
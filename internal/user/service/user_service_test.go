@@ -0,0 +1,560 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/kaanevranportfolio/Commercium/internal/user/mocks"
+	"github.com/kaanevranportfolio/Commercium/internal/user/models"
+	"github.com/kaanevranportfolio/Commercium/internal/user/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+const (
+	testPassword          = "Password123!"
+	testRefreshExpiration = time.Hour
+)
+
+// fixture is a user service on mocks of everything it depends on. Calls
+// the test didn't expect fail it.
+type fixture struct {
+	repo    *mocks.MockUserRepository
+	tokens  *mocks.MockTokenIssuer
+	store   *mocks.MockTokenStore
+	service service.UserService
+}
+
+func newFixture(t testing.TB) *fixture {
+	t.Helper()
+	return newLockoutFixture(t, config.LockoutConfig{}, nil)
+}
+
+// newLockoutFixture returns a fixture whose service counts failed logins
+// with attempts, locking accounts out as lockout sets
+func newLockoutFixture(t testing.TB, lockout config.LockoutConfig, attempts service.AttemptCounter) *fixture {
+	t.Helper()
+
+	log := testLogger(t)
+	cfg := &config.Config{}
+	cfg.Auth.JWT.RefreshExpiration = testRefreshExpiration
+	cfg.Services.User.Lockout = lockout
+
+	ctrl := gomock.NewController(t)
+	f := &fixture{
+		repo:   mocks.NewMockUserRepository(ctrl),
+		tokens: mocks.NewMockTokenIssuer(ctrl),
+		store:  mocks.NewMockTokenStore(ctrl),
+	}
+	f.service = service.NewUserService(f.repo, f.tokens, f.store, attempts, nil, nil, nil, cfg, log)
+	return f
+}
+
+// testLogger returns a logger of errors only
+func testLogger(t testing.TB) *logger.Logger {
+	t.Helper()
+
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "error",
+		Format: "json",
+		Output: "stdout",
+	}, "user-service-test")
+	require.NoError(t, err)
+	return log
+}
+
+// newUser returns an active user whose password is testPassword
+func newUser(t testing.TB) *models.User {
+	t.Helper()
+
+	// The minimum cost keeps the tests fast, and verifies all the same
+	return newUserWithCost(t, bcrypt.MinCost)
+}
+
+// newUserWithCost returns an active user whose password is testPassword,
+// hashed at cost
+func newUserWithCost(t testing.TB, cost int) *models.User {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), cost)
+	require.NoError(t, err)
+
+	return &models.User{
+		ID:           uuid.New(),
+		Username:     "testuser",
+		Email:        "test@example.com",
+		PasswordHash: string(hash),
+		IsActive:     true,
+		Role:         "customer",
+	}
+}
+
+func refreshKey(user *models.User) string {
+	return "refresh_token:" + user.ID.String()
+}
+
+// TestLogin covers logins by credentials, see TestLoginLockout for those
+// of accounts failing to log in repeatedly
+func TestLogin(t *testing.T) {
+	errSigning := errors.New("signing failed")
+	pair := &auth.TokenPair{
+		AccessToken:  "access-token",
+		RefreshToken: "refresh-token",
+		TokenType:    "Bearer",
+		ExpiresIn:    900,
+	}
+
+	tests := []struct {
+		name     string
+		username func(user *models.User) string
+		password string
+		// setup sets the expectations of the login on f, for user
+		setup   func(f *fixture, user *models.User)
+		wantErr error
+	}{
+		{
+			name:     "valid credentials by email",
+			username: func(user *models.User) string { return user.Email },
+			password: testPassword,
+			setup: func(f *fixture, user *models.User) {
+				f.repo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil)
+				f.tokens.EXPECT().GenerateTokenPair(user.ID, user.Email, user.Username, user.Role, "").Return(pair, nil)
+				f.repo.EXPECT().UpdateLastLogin(gomock.Any(), user.ID).Return(nil)
+				f.store.EXPECT().SetWithExpiration(gomock.Any(), refreshKey(user), pair.RefreshToken, testRefreshExpiration).Return(nil)
+			},
+		},
+		{
+			name:     "valid credentials by username",
+			username: func(user *models.User) string { return user.Username },
+			password: testPassword,
+			setup: func(f *fixture, user *models.User) {
+				f.repo.EXPECT().GetByEmail(gomock.Any(), user.Username).Return(nil, apperrors.NotFound("user not found"))
+				f.repo.EXPECT().GetByUsername(gomock.Any(), user.Username).Return(user, nil)
+				f.tokens.EXPECT().GenerateTokenPair(user.ID, user.Email, user.Username, user.Role, "").Return(pair, nil)
+				f.repo.EXPECT().UpdateLastLogin(gomock.Any(), user.ID).Return(nil)
+				f.store.EXPECT().SetWithExpiration(gomock.Any(), refreshKey(user), pair.RefreshToken, testRefreshExpiration).Return(nil)
+			},
+		},
+		{
+			name:     "failing to record the login doesn't fail it",
+			username: func(user *models.User) string { return user.Email },
+			password: testPassword,
+			setup: func(f *fixture, user *models.User) {
+				f.repo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil)
+				f.tokens.EXPECT().GenerateTokenPair(user.ID, user.Email, user.Username, user.Role, "").Return(pair, nil)
+				f.repo.EXPECT().UpdateLastLogin(gomock.Any(), user.ID).Return(errors.New("connection refused"))
+				f.store.EXPECT().SetWithExpiration(gomock.Any(), refreshKey(user), pair.RefreshToken, testRefreshExpiration).Return(errors.New("connection refused"))
+			},
+		},
+		{
+			name:     "unknown user",
+			username: func(user *models.User) string { return "nobody" },
+			password: testPassword,
+			setup: func(f *fixture, user *models.User) {
+				f.repo.EXPECT().GetByEmail(gomock.Any(), "nobody").Return(nil, apperrors.NotFound("user not found"))
+				f.repo.EXPECT().GetByUsername(gomock.Any(), "nobody").Return(nil, apperrors.NotFound("user not found"))
+			},
+			wantErr: apperrors.ErrUnauthorized,
+		},
+		{
+			name:     "wrong password",
+			username: func(user *models.User) string { return user.Email },
+			password: "WrongPassword123!",
+			setup: func(f *fixture, user *models.User) {
+				f.repo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil)
+			},
+			wantErr: apperrors.ErrUnauthorized,
+		},
+		{
+			name:     "deactivated account is refused",
+			username: func(user *models.User) string { return user.Email },
+			password: testPassword,
+			setup: func(f *fixture, user *models.User) {
+				user.IsActive = false
+				f.repo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil)
+			},
+			wantErr: apperrors.ErrForbidden,
+		},
+		{
+			name:     "token generation failure",
+			username: func(user *models.User) string { return user.Email },
+			password: testPassword,
+			setup: func(f *fixture, user *models.User) {
+				f.repo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil)
+				f.tokens.EXPECT().GenerateTokenPair(user.ID, user.Email, user.Username, user.Role, "").Return(nil, errSigning)
+			},
+			wantErr: errSigning,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			user := newUser(t)
+			tt.setup(f, user)
+
+			tokens, err := f.service.Login(context.Background(), &models.LoginRequest{
+				Username: tt.username(user),
+				Password: tt.password,
+			})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, tokens)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, pair.AccessToken, tokens.AccessToken)
+			assert.Equal(t, pair.RefreshToken, tokens.RefreshToken)
+			assert.Equal(t, pair.TokenType, tokens.TokenType)
+			assert.Equal(t, pair.ExpiresIn, tokens.ExpiresIn)
+		})
+	}
+}
+
+// TestLoginLockout covers locking accounts out after failed logins, with
+// the failures counted in Redis
+func TestLoginLockout(t *testing.T) {
+	const wrongPassword = "WrongPassword1!"
+	lockout := config.LockoutConfig{Enabled: true, MaxAttempts: 3, Window: 15 * time.Minute}
+
+	// attempt is a login with password, after advancing the clock of
+	// Redis by advance
+	type attempt struct {
+		password string
+		advance  time.Duration
+		wantErr  error
+	}
+	failed := attempt{password: wrongPassword, wantErr: apperrors.ErrUnauthorized}
+
+	tests := []struct {
+		name     string
+		attempts []attempt
+	}{
+		{
+			name:     "fewer failures than the limit",
+			attempts: []attempt{failed, failed, {password: testPassword}},
+		},
+		{
+			name: "locked after the limit of failures",
+			attempts: []attempt{
+				failed, failed, failed,
+				{password: testPassword, wantErr: apperrors.ErrRateLimited},
+				{password: wrongPassword, wantErr: apperrors.ErrRateLimited},
+			},
+		},
+		{
+			name: "locked until the window after the last failure",
+			attempts: []attempt{
+				failed, failed, failed,
+				{password: testPassword, advance: lockout.Window - time.Minute, wantErr: apperrors.ErrRateLimited},
+			},
+		},
+		{
+			name: "unlocked after the window",
+			attempts: []attempt{
+				failed, failed, failed,
+				{password: testPassword, advance: lockout.Window + time.Second},
+			},
+		},
+		{
+			name: "failures further apart than the window don't add up",
+			attempts: []attempt{
+				failed, failed,
+				{password: wrongPassword, advance: lockout.Window + time.Second, wantErr: apperrors.ErrUnauthorized},
+				failed,
+				{password: testPassword},
+			},
+		},
+		{
+			name: "logging in resets the failures",
+			attempts: []attempt{
+				failed, failed,
+				{password: testPassword},
+				failed, failed,
+				{password: testPassword},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := miniredis.RunT(t)
+			port, err := strconv.Atoi(server.Port())
+			require.NoError(t, err)
+			redis, err := database.NewRedis(config.RedisConfig{Host: server.Host(), Port: port}, testLogger(t))
+			require.NoError(t, err)
+			t.Cleanup(func() { redis.Close() })
+
+			f := newLockoutFixture(t, lockout, redis)
+			user := newUser(t)
+			pair := &auth.TokenPair{AccessToken: "access-token", RefreshToken: "refresh-token", TokenType: "Bearer"}
+			f.repo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil).AnyTimes()
+			f.tokens.EXPECT().GenerateTokenPair(user.ID, user.Email, user.Username, user.Role, "").Return(pair, nil).AnyTimes()
+			f.repo.EXPECT().UpdateLastLogin(gomock.Any(), user.ID).Return(nil).AnyTimes()
+			f.store.EXPECT().SetWithExpiration(gomock.Any(), refreshKey(user), pair.RefreshToken, testRefreshExpiration).Return(nil).AnyTimes()
+
+			for i, a := range tt.attempts {
+				server.FastForward(a.advance)
+				_, err := f.service.Login(context.Background(), &models.LoginRequest{
+					Username: user.Email,
+					Password: a.password,
+				})
+				if a.wantErr != nil {
+					require.ErrorIs(t, err, a.wantErr, "attempt %d", i+1)
+					continue
+				}
+				require.NoError(t, err, "attempt %d", i+1)
+			}
+		})
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	const presented = "presented-refresh-token"
+	pair := &auth.TokenPair{
+		AccessToken:  "new-access-token",
+		RefreshToken: "new-refresh-token",
+		TokenType:    "Bearer",
+		ExpiresIn:    900,
+	}
+
+	// valid expects presented to be validated as a refresh token of user
+	valid := func(f *fixture, user *models.User) {
+		f.tokens.EXPECT().ValidateRefreshToken(presented).
+			Return(&jwt.RegisteredClaims{Subject: user.ID.String()}, nil)
+	}
+
+	tests := []struct {
+		name    string
+		setup   func(f *fixture, user *models.User)
+		wantErr error
+	}{
+		{
+			name: "valid refresh token is rotated",
+			setup: func(f *fixture, user *models.User) {
+				valid(f, user)
+				f.store.EXPECT().GetString(gomock.Any(), refreshKey(user)).Return(presented, nil)
+				f.repo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+				f.tokens.EXPECT().GenerateTokenPair(user.ID, user.Email, user.Username, user.Role, "").Return(pair, nil)
+				f.store.EXPECT().SetWithExpiration(gomock.Any(), refreshKey(user), pair.RefreshToken, testRefreshExpiration).Return(nil)
+			},
+		},
+		{
+			name: "failing to store the new token doesn't fail the refresh",
+			setup: func(f *fixture, user *models.User) {
+				valid(f, user)
+				f.store.EXPECT().GetString(gomock.Any(), refreshKey(user)).Return(presented, nil)
+				f.repo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+				f.tokens.EXPECT().GenerateTokenPair(user.ID, user.Email, user.Username, user.Role, "").Return(pair, nil)
+				f.store.EXPECT().SetWithExpiration(gomock.Any(), refreshKey(user), pair.RefreshToken, testRefreshExpiration).Return(errors.New("connection refused"))
+			},
+		},
+		{
+			name: "invalid or expired token",
+			setup: func(f *fixture, user *models.User) {
+				f.tokens.EXPECT().ValidateRefreshToken(presented).Return(nil, jwt.ErrTokenExpired)
+			},
+			wantErr: apperrors.ErrUnauthorized,
+		},
+		{
+			name: "subject isn't a user ID",
+			setup: func(f *fixture, user *models.User) {
+				f.tokens.EXPECT().ValidateRefreshToken(presented).
+					Return(&jwt.RegisteredClaims{Subject: "not-a-uuid"}, nil)
+			},
+			wantErr: apperrors.ErrUnauthorized,
+		},
+		{
+			name: "token no longer stored",
+			setup: func(f *fixture, user *models.User) {
+				valid(f, user)
+				f.store.EXPECT().GetString(gomock.Any(), refreshKey(user)).Return("", errors.New("redis: nil"))
+			},
+			wantErr: apperrors.ErrUnauthorized,
+		},
+		{
+			name: "token superseded by a newer one",
+			setup: func(f *fixture, user *models.User) {
+				valid(f, user)
+				f.store.EXPECT().GetString(gomock.Any(), refreshKey(user)).Return("newer-refresh-token", nil)
+			},
+			wantErr: apperrors.ErrUnauthorized,
+		},
+		{
+			name: "user deleted since",
+			setup: func(f *fixture, user *models.User) {
+				valid(f, user)
+				f.store.EXPECT().GetString(gomock.Any(), refreshKey(user)).Return(presented, nil)
+				f.repo.EXPECT().GetByID(gomock.Any(), user.ID).Return(nil, apperrors.NotFound("user not found"))
+			},
+			wantErr: apperrors.ErrNotFound,
+		},
+		{
+			name: "account deactivated since",
+			setup: func(f *fixture, user *models.User) {
+				user.IsActive = false
+				valid(f, user)
+				f.store.EXPECT().GetString(gomock.Any(), refreshKey(user)).Return(presented, nil)
+				f.repo.EXPECT().GetByID(gomock.Any(), user.ID).Return(user, nil)
+			},
+			wantErr: apperrors.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			user := newUser(t)
+			tt.setup(f, user)
+
+			tokens, err := f.service.RefreshToken(context.Background(), presented)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, tokens)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, pair.AccessToken, tokens.AccessToken)
+			assert.Equal(t, pair.RefreshToken, tokens.RefreshToken)
+		})
+	}
+}
+
+func TestAddressOwnership(t *testing.T) {
+	userID := uuid.New()
+	addressID := uuid.New()
+
+	tests := []struct {
+		name string
+		// stored is the address of addressID, nil if there's none
+		stored  *models.UserAddress
+		wantErr error
+	}{
+		{
+			name:   "own address",
+			stored: &models.UserAddress{ID: addressID, UserID: userID},
+		},
+		{
+			name:    "address of another user",
+			stored:  &models.UserAddress{ID: addressID, UserID: uuid.New()},
+			wantErr: apperrors.ErrNotFound,
+		},
+		{
+			name:    "missing address",
+			wantErr: apperrors.ErrNotFound,
+		},
+	}
+
+	// expectStored sets the address of addressID the repository returns
+	expectStored := func(f *fixture, stored *models.UserAddress) {
+		if stored == nil {
+			f.repo.EXPECT().GetAddressByID(gomock.Any(), addressID).Return(nil, apperrors.NotFound("address not found"))
+			return
+		}
+		f.repo.EXPECT().GetAddressByID(gomock.Any(), addressID).Return(stored, nil)
+	}
+
+	for _, tt := range tests {
+		t.Run("update "+tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			expectStored(f, tt.stored)
+			if tt.wantErr == nil {
+				f.repo.EXPECT().UpdateAddress(gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			// The IDs of the request are ignored for the ones of the path
+			address, err := f.service.UpdateAddress(context.Background(), userID, addressID, &models.UserAddress{
+				ID:     uuid.New(),
+				UserID: uuid.New(),
+				City:   "Berlin",
+			})
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, addressID, address.ID)
+			assert.Equal(t, userID, address.UserID)
+			assert.Equal(t, "Berlin", address.City)
+		})
+
+		t.Run("delete "+tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			expectStored(f, tt.stored)
+			if tt.wantErr == nil {
+				f.repo.EXPECT().DeleteAddress(gomock.Any(), addressID).Return(nil)
+			}
+
+			err := f.service.DeleteAddress(context.Background(), userID, addressID)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestGetProfiles(t *testing.T) {
+	f := newFixture(t)
+	user := newUser(t)
+	unknown := uuid.New()
+
+	// Repeated IDs are read once, in a single query
+	f.repo.EXPECT().GetByIDs(gomock.Any(), []uuid.UUID{user.ID, unknown}).
+		Return(map[uuid.UUID]*models.User{user.ID: user}, nil)
+
+	profiles, err := f.service.GetProfiles(context.Background(), []uuid.UUID{user.ID, unknown, user.ID})
+	require.NoError(t, err)
+	require.Len(t, profiles, 1)
+	assert.Equal(t, user.ToResponse(), profiles[user.ID])
+	assert.NotContains(t, profiles, unknown)
+
+	t.Run("repository failure", func(t *testing.T) {
+		f := newFixture(t)
+		f.repo.EXPECT().GetByIDs(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+
+		_, err := f.service.GetProfiles(context.Background(), []uuid.UUID{uuid.New()})
+		require.Error(t, err)
+	})
+}
+
+// BenchmarkLogin measures a login on the service's own work, at the cost
+// passwords are hashed with in production: the password verified and the
+// tokens issued
+func BenchmarkLogin(b *testing.B) {
+	f := newFixture(b)
+	user := newUserWithCost(b, bcrypt.DefaultCost)
+	pair := &auth.TokenPair{AccessToken: "access-token", RefreshToken: "refresh-token"}
+
+	f.repo.EXPECT().GetByEmail(gomock.Any(), user.Email).Return(user, nil).AnyTimes()
+	f.tokens.EXPECT().GenerateTokenPair(user.ID, user.Email, user.Username, user.Role, "").Return(pair, nil).AnyTimes()
+	f.repo.EXPECT().UpdateLastLogin(gomock.Any(), user.ID).Return(nil).AnyTimes()
+	f.store.EXPECT().SetWithExpiration(gomock.Any(), refreshKey(user), pair.RefreshToken, testRefreshExpiration).Return(nil).AnyTimes()
+
+	req := &models.LoginRequest{Username: user.Email, Password: testPassword}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := f.service.Login(context.Background(), req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_orders_updated_at ON orders;
+
+-- Drop tables
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
@@ -0,0 +1,16 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_order_refunds_updated_at ON order_refunds;
+
+-- Drop tables
+DROP TABLE IF EXISTS order_refund_items;
+DROP TABLE IF EXISTS order_refunds;
+
+-- Drop columns
+ALTER TABLE order_items
+    DROP CONSTRAINT IF EXISTS chk_order_items_refunded_quantity,
+    DROP COLUMN IF EXISTS refunded_quantity;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS refunded_amount,
+    DROP COLUMN IF EXISTS cancellation_reason,
+    DROP COLUMN IF EXISTS cancelled_at;
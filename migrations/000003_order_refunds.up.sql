@@ -0,0 +1,37 @@
+-- Cancellation and refund tracking on orders
+ALTER TABLE orders
+    ADD COLUMN cancelled_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN cancellation_reason TEXT,
+    ADD COLUMN refunded_amount BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE order_items
+    ADD COLUMN refunded_quantity INTEGER NOT NULL DEFAULT 0,
+    ADD CONSTRAINT chk_order_items_refunded_quantity CHECK (refunded_quantity >= 0 AND refunded_quantity <= quantity);
+
+-- Order refunds table
+CREATE TABLE order_refunds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    reason TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, succeeded, failed
+    provider_refund_id VARCHAR(255),
+    failure_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_order_refunds_order_id ON order_refunds(order_id);
+
+-- Refunded line items
+CREATE TABLE order_refund_items (
+    refund_id UUID NOT NULL REFERENCES order_refunds(id) ON DELETE CASCADE,
+    order_item_id UUID NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    amount BIGINT NOT NULL,
+    PRIMARY KEY (refund_id, order_item_id)
+);
+
+CREATE TRIGGER update_order_refunds_updated_at BEFORE UPDATE ON order_refunds
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
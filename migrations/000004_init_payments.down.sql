@@ -0,0 +1,6 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_payments_updated_at ON payments;
+
+-- Drop tables
+DROP TABLE IF EXISTS payment_transactions;
+DROP TABLE IF EXISTS payments;
@@ -0,0 +1,40 @@
+-- Payments table (one payment intent per checkout attempt)
+CREATE TABLE payments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL REFERENCES orders(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    provider VARCHAR(20) NOT NULL, -- stripe
+    provider_payment_id VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, authorized, captured, partially_refunded, refunded, voided, failed
+    currency VARCHAR(3) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0), -- amounts are stored in minor units
+    captured_amount BIGINT NOT NULL DEFAULT 0,
+    refunded_amount BIGINT NOT NULL DEFAULT 0,
+    failure_reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_payments_order_id ON payments(order_id);
+CREATE INDEX idx_payments_user_id ON payments(user_id);
+CREATE UNIQUE INDEX idx_payments_provider_payment_id ON payments(provider, provider_payment_id);
+
+-- Payment transactions table (every call made to the provider)
+CREATE TABLE payment_transactions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL, -- authorize, capture, refund, void
+    status VARCHAR(20) NOT NULL, -- succeeded, failed
+    amount BIGINT NOT NULL DEFAULT 0,
+    provider_transaction_id VARCHAR(255),
+    idempotency_key VARCHAR(255),
+    error_message TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_payment_transactions_payment_id ON payment_transactions(payment_id);
+CREATE UNIQUE INDEX idx_payment_transactions_idempotency_key ON payment_transactions(type, idempotency_key)
+    WHERE idempotency_key IS NOT NULL AND status = 'succeeded';
+
+CREATE TRIGGER update_payments_updated_at BEFORE UPDATE ON payments
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
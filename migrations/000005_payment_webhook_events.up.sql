@@ -0,0 +1,25 @@
+-- Payment webhook events table. Every verified provider callback is stored
+-- here before it is acknowledged; the unique key deduplicates redeliveries and
+-- the payment service works through pending rows asynchronously.
+CREATE TABLE payment_webhook_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    provider VARCHAR(20) NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    provider_event_type VARCHAR(100) NOT NULL,
+    event_type VARCHAR(50), -- normalized type, NULL if the event is not relevant
+    provider_payment_id VARCHAR(255),
+    related_payment_id VARCHAR(255),
+    amount BIGINT NOT NULL DEFAULT 0,
+    currency VARCHAR(3),
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, processing, processed, ignored, failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    received_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    processed_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT uq_payment_webhook_events_provider_event UNIQUE (provider, event_id)
+);
+
+CREATE INDEX idx_payment_webhook_events_pending ON payment_webhook_events(next_attempt_at)
+    WHERE status IN ('pending', 'processing');
@@ -0,0 +1,9 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_payments_order_active;
+DROP INDEX IF EXISTS idx_payments_user_idempotency_key;
+
+-- Drop columns
+ALTER TABLE payments DROP COLUMN IF EXISTS idempotency_key;
+
+-- Drop tables
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,26 @@
+-- Idempotency keys table. Stores the response of every mutating request sent
+-- with an Idempotency-Key header so retries replay it instead of re-executing.
+CREATE TABLE idempotency_keys (
+    scope VARCHAR(255) NOT NULL, -- caller and route the key belongs to
+    key VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'in_progress', -- in_progress, completed
+    response_code INTEGER,
+    response_body BYTEA,
+    locked_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+-- Payments remember the key of the checkout that created them
+ALTER TABLE payments ADD COLUMN idempotency_key VARCHAR(255);
+
+CREATE UNIQUE INDEX idx_payments_user_idempotency_key ON payments(user_id, idempotency_key)
+    WHERE idempotency_key IS NOT NULL;
+
+-- At most one payment per order may hold or have taken funds
+CREATE UNIQUE INDEX idx_payments_order_active ON payments(order_id)
+    WHERE status IN ('pending', 'authorized', 'captured', 'partially_refunded');
@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_payments_user_id_created_at;
+
+-- Drop tables
+DROP TABLE IF EXISTS fraud_assessments;
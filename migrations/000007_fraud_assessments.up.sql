@@ -0,0 +1,31 @@
+-- Fraud assessments table. Every checkout is scored before the provider is
+-- called; assessments held for review form the admin review queue.
+CREATE TABLE fraud_assessments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    order_id UUID NOT NULL REFERENCES orders(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    score INTEGER NOT NULL,
+    decision VARCHAR(20) NOT NULL, -- pass, review, decline
+    reasons TEXT[] NOT NULL DEFAULT '{}',
+    ip_address VARCHAR(45),
+    device_id VARCHAR(255),
+    billing_country VARCHAR(2),
+    shipping_country VARCHAR(2),
+    review_status VARCHAR(20), -- pending, approved, rejected; NULL unless held for review
+    reviewed_by UUID REFERENCES users(id),
+    review_notes TEXT,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_fraud_assessments_payment_id ON fraud_assessments(payment_id);
+CREATE INDEX idx_fraud_assessments_review_queue ON fraud_assessments(review_status, created_at)
+    WHERE review_status IS NOT NULL;
+-- Velocity lookups by device and IP address
+CREATE INDEX idx_fraud_assessments_device_id ON fraud_assessments(device_id, created_at)
+    WHERE device_id IS NOT NULL;
+CREATE INDEX idx_fraud_assessments_ip_address ON fraud_assessments(ip_address, created_at)
+    WHERE ip_address IS NOT NULL;
+-- Velocity lookups of a user's recent payment attempts
+CREATE INDEX idx_payments_user_id_created_at ON payments(user_id, created_at);
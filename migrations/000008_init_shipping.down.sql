@@ -0,0 +1,6 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_shipments_updated_at ON shipments;
+
+-- Drop tables
+DROP TABLE IF EXISTS shipments;
+DROP TABLE IF EXISTS shipping_quotes;
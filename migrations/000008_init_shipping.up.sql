@@ -0,0 +1,43 @@
+-- Shipping quotes table. Rates quoted at checkout are kept until they expire
+-- so the charged shipping amount can be verified when the order is placed.
+CREATE TABLE shipping_quotes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    carrier VARCHAR(50) NOT NULL,
+    service VARCHAR(100) NOT NULL,
+    amount BIGINT NOT NULL, -- amounts are stored in minor units
+    currency VARCHAR(3) NOT NULL,
+    estimated_days INTEGER,
+    destination JSONB NOT NULL,
+    parcel JSONB NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_shipping_quotes_expires_at ON shipping_quotes(expires_at);
+
+-- Shipments table (one purchased label per parcel)
+CREATE TABLE shipments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL REFERENCES orders(id),
+    carrier VARCHAR(50) NOT NULL,
+    service VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'label_purchased', -- label_purchased, in_transit, delivered, exception
+    tracking_number VARCHAR(100) NOT NULL,
+    label_url VARCHAR(1000),
+    carrier_shipment_id VARCHAR(255),
+    rate_amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    weight_grams INTEGER NOT NULL CHECK (weight_grams > 0),
+    idempotency_key VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_shipments_order_id ON shipments(order_id);
+CREATE UNIQUE INDEX idx_shipments_idempotency_key ON shipments(idempotency_key);
+CREATE UNIQUE INDEX idx_shipments_carrier_tracking_number ON shipments(carrier, tracking_number);
+
+-- Trigger to automatically update updated_at
+CREATE TRIGGER update_shipments_updated_at BEFORE UPDATE ON shipments
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_shipments_tracking_poll;
+
+ALTER TABLE shipments DROP COLUMN IF EXISTS last_polled_at;
+ALTER TABLE shipments DROP COLUMN IF EXISTS delivered_at;
+
+DROP TABLE IF EXISTS shipment_tracking_events;
@@ -0,0 +1,20 @@
+-- Tracking history of shipments, as reported by carriers or pushed by the warehouse
+CREATE TABLE shipment_tracking_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    shipment_id UUID NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL, -- label_purchased, in_transit, out_for_delivery, delivered, exception
+    description VARCHAR(500) NOT NULL DEFAULT '',
+    location VARCHAR(255),
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Carriers resend the full history on every update; duplicates are ignored
+CREATE UNIQUE INDEX idx_shipment_tracking_events_unique ON shipment_tracking_events(shipment_id, occurred_at, status);
+
+ALTER TABLE shipments ADD COLUMN delivered_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE shipments ADD COLUMN last_polled_at TIMESTAMP WITH TIME ZONE;
+
+-- Shipments still on their way are polled for tracking updates
+CREATE INDEX idx_shipments_tracking_poll ON shipments(last_polled_at NULLS FIRST)
+    WHERE status <> 'delivered';
@@ -0,0 +1,5 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_tax_exemptions_updated_at ON tax_exemptions;
+
+-- Drop tables
+DROP TABLE IF EXISTS tax_exemptions;
@@ -0,0 +1,14 @@
+-- Customers exempt from sales tax (resellers, non-profits, government),
+-- backed by an exemption certificate
+CREATE TABLE tax_exemptions (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    certificate_number VARCHAR(100) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Trigger to automatically update updated_at
+CREATE TRIGGER update_tax_exemptions_updated_at BEFORE UPDATE ON tax_exemptions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
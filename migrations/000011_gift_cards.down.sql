@@ -0,0 +1,11 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_gift_cards_updated_at ON gift_cards;
+
+-- Drop columns
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS gift_card_refunded_amount,
+    DROP COLUMN IF EXISTS gift_card_amount;
+
+-- Drop tables
+DROP TABLE IF EXISTS gift_card_ledger;
+DROP TABLE IF EXISTS gift_cards;
@@ -0,0 +1,47 @@
+-- Gift cards table. Codes are only shown once at issuance; the table keeps a
+-- hash for lookups and the last four characters for display.
+CREATE TABLE gift_cards (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code_hash VARCHAR(64) NOT NULL,
+    last_four VARCHAR(4) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    initial_amount BIGINT NOT NULL CHECK (initial_amount > 0), -- amounts are stored in minor units
+    balance BIGINT NOT NULL CHECK (balance >= 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, disabled
+    expires_at TIMESTAMP WITH TIME ZONE,
+    recipient_email VARCHAR(255),
+    note TEXT,
+    issued_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_gift_cards_code_hash ON gift_cards(code_hash);
+
+-- Gift card ledger. Every balance change is recorded with the balance it left,
+-- so a card's balance always equals the sum of its entries.
+CREATE TABLE gift_card_ledger (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    gift_card_id UUID NOT NULL REFERENCES gift_cards(id),
+    type VARCHAR(20) NOT NULL, -- issue, redeem, reversal, refund
+    amount BIGINT NOT NULL, -- positive credits, negative debits
+    balance_after BIGINT NOT NULL,
+    payment_id UUID REFERENCES payments(id),
+    order_id UUID REFERENCES orders(id),
+    reference VARCHAR(255), -- idempotency key of the refund that credited the card
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_gift_card_ledger_gift_card_id ON gift_card_ledger(gift_card_id, created_at);
+CREATE INDEX idx_gift_card_ledger_payment_id ON gift_card_ledger(payment_id) WHERE payment_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_gift_card_ledger_reference ON gift_card_ledger(gift_card_id, reference)
+    WHERE reference IS NOT NULL;
+
+-- Part of a payment covered by gift cards; the provider is charged the rest
+ALTER TABLE payments
+    ADD COLUMN gift_card_amount BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN gift_card_refunded_amount BIGINT NOT NULL DEFAULT 0;
+
+CREATE TRIGGER update_gift_cards_updated_at BEFORE UPDATE ON gift_cards
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
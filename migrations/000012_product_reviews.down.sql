@@ -0,0 +1,8 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_product_ratings_updated_at ON product_ratings;
+DROP TRIGGER IF EXISTS update_product_reviews_updated_at ON product_reviews;
+
+-- Drop tables
+DROP TABLE IF EXISTS product_ratings;
+DROP TABLE IF EXISTS review_votes;
+DROP TABLE IF EXISTS product_reviews;
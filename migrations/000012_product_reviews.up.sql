@@ -0,0 +1,59 @@
+-- Product reviews table. Reviews are only shown once approved by a moderator.
+CREATE TABLE product_reviews (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    order_id UUID REFERENCES orders(id) ON DELETE SET NULL, -- order that verified the purchase
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    title VARCHAR(200) NOT NULL,
+    body TEXT NOT NULL,
+    verified_purchase BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, approved, rejected
+    moderated_by UUID REFERENCES users(id),
+    moderation_notes TEXT,
+    moderated_at TIMESTAMP WITH TIME ZONE,
+    helpful_count INTEGER NOT NULL DEFAULT 0,
+    unhelpful_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- One review per customer and product
+CREATE UNIQUE INDEX idx_product_reviews_product_user ON product_reviews(product_id, user_id);
+-- Listing a product's approved reviews in each sort order
+CREATE INDEX idx_product_reviews_product_created ON product_reviews(product_id, created_at DESC, id DESC)
+    WHERE status = 'approved';
+CREATE INDEX idx_product_reviews_product_rating ON product_reviews(product_id, rating, created_at DESC, id DESC)
+    WHERE status = 'approved';
+CREATE INDEX idx_product_reviews_product_helpful ON product_reviews(product_id, helpful_count DESC, created_at DESC, id DESC)
+    WHERE status = 'approved';
+-- Moderation queue
+CREATE INDEX idx_product_reviews_status_created ON product_reviews(status, created_at);
+
+-- Helpfulness votes, one per customer and review
+CREATE TABLE review_votes (
+    review_id UUID NOT NULL REFERENCES product_reviews(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    helpful BOOLEAN NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (review_id, user_id)
+);
+
+-- Rating aggregates of approved reviews, kept up to date with moderation
+CREATE TABLE product_ratings (
+    product_id UUID PRIMARY KEY,
+    review_count INTEGER NOT NULL DEFAULT 0,
+    rating_total INTEGER NOT NULL DEFAULT 0,
+    rating_1 INTEGER NOT NULL DEFAULT 0,
+    rating_2 INTEGER NOT NULL DEFAULT 0,
+    rating_3 INTEGER NOT NULL DEFAULT 0,
+    rating_4 INTEGER NOT NULL DEFAULT 0,
+    rating_5 INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TRIGGER update_product_reviews_updated_at BEFORE UPDATE ON product_reviews
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_product_ratings_updated_at BEFORE UPDATE ON product_ratings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
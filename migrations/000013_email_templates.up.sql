@@ -0,0 +1,20 @@
+-- Transactional email templates. Every edit creates a new version; one
+-- version per template and locale is active and used for sending.
+CREATE TABLE email_templates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    key VARCHAR(100) NOT NULL, -- e.g. order_confirmation
+    locale VARCHAR(10) NOT NULL, -- BCP 47 tag, e.g. en or pt-BR
+    version INTEGER NOT NULL CHECK (version > 0),
+    subject TEXT NOT NULL,
+    html_body TEXT NOT NULL,
+    text_body TEXT NOT NULL,
+    description TEXT,
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by UUID REFERENCES users(id),
+    activated_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_email_templates_key_locale_version ON email_templates(key, locale, version);
+-- At most one active version per template and locale
+CREATE UNIQUE INDEX idx_email_templates_active ON email_templates(key, locale) WHERE active;
@@ -0,0 +1,6 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_invoices_updated_at ON invoices;
+
+-- Drop tables
+DROP TABLE IF EXISTS invoices;
+DROP TABLE IF EXISTS invoice_sequences;
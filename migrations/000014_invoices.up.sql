@@ -0,0 +1,27 @@
+-- Last invoice number issued by each legal entity. Numbers are allocated in
+-- the same transaction as the invoice row, so the sequence has no gaps.
+CREATE TABLE invoice_sequences (
+    legal_entity VARCHAR(50) PRIMARY KEY,
+    last_number BIGINT NOT NULL DEFAULT 0
+);
+
+-- Invoices for completed orders, one per order
+CREATE TABLE invoices (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID UNIQUE NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    legal_entity VARCHAR(50) NOT NULL,
+    sequence_number BIGINT NOT NULL,
+    invoice_number VARCHAR(64) UNIQUE NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    total_amount BIGINT NOT NULL, -- minor units
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending until the PDF is stored, then issued
+    storage_key VARCHAR(500),
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (legal_entity, sequence_number)
+);
+
+-- Trigger to automatically update updated_at
+CREATE TRIGGER update_invoices_updated_at BEFORE UPDATE ON invoices
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,7 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_currency_preferences_updated_at ON currency_preferences;
+DROP TRIGGER IF EXISTS update_exchange_rates_updated_at ON exchange_rates;
+
+-- Drop tables
+DROP TABLE IF EXISTS currency_preferences;
+DROP TABLE IF EXISTS exchange_rates;
@@ -0,0 +1,26 @@
+-- Latest exchange rates against the base currency. A rate is the price of
+-- one unit of the base currency in the quote currency.
+CREATE TABLE exchange_rates (
+    base_currency VARCHAR(3) NOT NULL,
+    quote_currency VARCHAR(3) NOT NULL,
+    rate NUMERIC(24, 12) NOT NULL CHECK (rate > 0),
+    source VARCHAR(20) NOT NULL, -- ecb, static
+    fetched_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (base_currency, quote_currency)
+);
+
+-- Currency a customer wants prices displayed in
+CREATE TABLE currency_preferences (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    currency VARCHAR(3) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Triggers to automatically update updated_at
+CREATE TRIGGER update_exchange_rates_updated_at BEFORE UPDATE ON exchange_rates
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_currency_preferences_updated_at BEFORE UPDATE ON currency_preferences
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,8 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_customer_groups_updated_at ON customer_groups;
+DROP TRIGGER IF EXISTS update_price_lists_updated_at ON price_lists;
+
+-- Drop tables
+DROP TABLE IF EXISTS customer_groups;
+DROP TABLE IF EXISTS prices;
+DROP TABLE IF EXISTS price_lists;
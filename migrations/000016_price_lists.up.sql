@@ -0,0 +1,50 @@
+-- Price lists. A list without a customer group applies to every customer;
+-- group lists take precedence for members of the group, then lists with a
+-- higher priority.
+CREATE TABLE price_lists (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL UNIQUE,
+    currency VARCHAR(3) NOT NULL,
+    customer_group VARCHAR(50),
+    priority INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_price_lists_currency ON price_lists(currency) WHERE is_active;
+
+-- Prices of a list. Rows are never updated: a regular price applies from its
+-- start until a later regular price starts, so future rows are scheduled
+-- price changes. Sale prices apply between their start and end only.
+CREATE TABLE prices (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    price_list_id UUID NOT NULL REFERENCES price_lists(id) ON DELETE CASCADE,
+    sku VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT 'regular', -- regular, sale
+    amount BIGINT NOT NULL CHECK (amount >= 0),
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ends_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (ends_at IS NULL OR ends_at > starts_at),
+    CHECK ((kind = 'regular' AND ends_at IS NULL) OR (kind = 'sale' AND ends_at IS NOT NULL))
+);
+
+CREATE INDEX idx_prices_list_sku ON prices(price_list_id, sku, starts_at DESC);
+CREATE INDEX idx_prices_sku ON prices(sku);
+
+-- Customer group each customer belongs to, e.g. "wholesale"
+CREATE TABLE customer_groups (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    customer_group VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Triggers to automatically update updated_at
+CREATE TRIGGER update_price_lists_updated_at BEFORE UPDATE ON price_lists
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_customer_groups_updated_at BEFORE UPDATE ON customer_groups
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
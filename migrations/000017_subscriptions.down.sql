@@ -0,0 +1,9 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_subscription_renewals_updated_at ON subscription_renewals;
+DROP TRIGGER IF EXISTS update_subscriptions_updated_at ON subscriptions;
+DROP TRIGGER IF EXISTS update_subscription_plans_updated_at ON subscription_plans;
+
+-- Drop tables
+DROP TABLE IF EXISTS subscription_renewals;
+DROP TABLE IF EXISTS subscriptions;
+DROP TABLE IF EXISTS subscription_plans;
@@ -0,0 +1,79 @@
+-- Subscription plans. A plan bills a quantity of one product every interval;
+-- prices are never changed, plans are replaced by new ones instead.
+CREATE TABLE subscription_plans (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    product_id UUID NOT NULL,
+    sku VARCHAR(100) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount >= 0),
+    interval_unit VARCHAR(10) NOT NULL, -- day, week, month, year
+    interval_count INTEGER NOT NULL DEFAULT 1 CHECK (interval_count > 0),
+    trial_days INTEGER NOT NULL DEFAULT 0 CHECK (trial_days >= 0),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Customer subscriptions. proration_balance is added to the next renewal:
+-- positive for upgrades, negative for credits from downgrades and pauses.
+CREATE TABLE subscriptions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    plan_id UUID NOT NULL REFERENCES subscription_plans(id),
+    status VARCHAR(20) NOT NULL, -- trialing, active, past_due, paused, canceled
+    quantity INTEGER NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    payment_method VARCHAR(255) NOT NULL,
+    payment_method_type VARCHAR(50),
+    payment_provider VARCHAR(50),
+    shipping_address JSONB NOT NULL,
+    current_period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    current_period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    cancel_at_period_end BOOLEAN NOT NULL DEFAULT FALSE,
+    paused_at TIMESTAMP WITH TIME ZONE,
+    resume_at TIMESTAMP WITH TIME ZONE,
+    canceled_at TIMESTAMP WITH TIME ZONE,
+    cancellation_reason VARCHAR(500),
+    proration_balance BIGINT NOT NULL DEFAULT 0,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    -- claimed_until keeps other renewal workers off a subscription being renewed
+    claimed_until TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_subscriptions_user_id ON subscriptions(user_id);
+CREATE INDEX idx_subscriptions_period_end ON subscriptions(current_period_end) WHERE status IN ('trialing', 'active');
+CREATE INDEX idx_subscriptions_next_attempt ON subscriptions(next_attempt_at) WHERE status = 'past_due';
+CREATE INDEX idx_subscriptions_resume_at ON subscriptions(resume_at) WHERE status = 'paused';
+
+-- One renewal per billing period. The renewal order ID is chosen when the
+-- renewal is created so that retries charge the same order.
+CREATE TABLE subscription_renewals (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    order_id UUID NOT NULL,
+    amount BIGINT NOT NULL,
+    proration_amount BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, paid, failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error VARCHAR(500),
+    paid_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (subscription_id, period_start)
+);
+
+-- Triggers to automatically update updated_at
+CREATE TRIGGER update_subscription_plans_updated_at BEFORE UPDATE ON subscription_plans
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_subscriptions_updated_at BEFORE UPDATE ON subscriptions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_subscription_renewals_updated_at BEFORE UPDATE ON subscription_renewals
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,12 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_seller_statements_updated_at ON seller_statements;
+DROP TRIGGER IF EXISTS update_sellers_updated_at ON sellers;
+
+-- Sellers lose the seller role
+UPDATE users SET role = 'customer' WHERE role = 'seller';
+
+-- Drop tables
+DROP TABLE IF EXISTS seller_statement_lines;
+DROP TABLE IF EXISTS seller_statements;
+DROP TABLE IF EXISTS seller_products;
+DROP TABLE IF EXISTS sellers;
@@ -0,0 +1,81 @@
+-- Marketplace sellers. A user applies to sell; an admin approves the
+-- application, which gives the user the seller role.
+CREATE TABLE sellers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    store_name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT,
+    contact_email VARCHAR(255) NOT NULL,
+    phone VARCHAR(20),
+    country VARCHAR(2) NOT NULL, -- ISO country code
+    tax_id VARCHAR(50),
+    payout_reference VARCHAR(255), -- bank account or payout provider account
+    commission_rate_bps INTEGER CHECK (commission_rate_bps BETWEEN 0 AND 10000), -- overrides the default rate
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, active, rejected, suspended
+    status_reason TEXT,
+    reviewed_by UUID REFERENCES users(id),
+    approved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_sellers_status_created ON sellers(status, created_at);
+
+-- Products sold by a seller. Sales of a product are attributed to its seller
+-- from the time the product was registered.
+CREATE TABLE seller_products (
+    product_id UUID PRIMARY KEY,
+    seller_id UUID NOT NULL REFERENCES sellers(id) ON DELETE CASCADE,
+    sku VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_seller_products_seller_id ON seller_products(seller_id, created_at DESC);
+
+-- Payout statements. A statement settles the seller's delivered sales in one
+-- currency up to its period end that no earlier statement settled.
+CREATE TABLE seller_statements (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    seller_id UUID NOT NULL REFERENCES sellers(id) ON DELETE CASCADE,
+    currency VARCHAR(3) NOT NULL,
+    period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    gross_amount BIGINT NOT NULL, -- amounts are stored in minor units
+    commission_amount BIGINT NOT NULL,
+    payout_amount BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, paid
+    payout_reference VARCHAR(255),
+    paid_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_seller_statements_seller_id ON seller_statements(seller_id, created_at DESC);
+CREATE INDEX idx_seller_statements_status ON seller_statements(status) WHERE status = 'pending';
+
+-- Order items settled by a statement, with the commission charged on each.
+-- An order item is settled at most once.
+CREATE TABLE seller_statement_lines (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    statement_id UUID NOT NULL REFERENCES seller_statements(id) ON DELETE CASCADE,
+    order_item_id UUID NOT NULL UNIQUE REFERENCES order_items(id),
+    order_id UUID NOT NULL REFERENCES orders(id),
+    order_number VARCHAR(32) NOT NULL,
+    product_id UUID NOT NULL,
+    sku VARCHAR(100) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price BIGINT NOT NULL,
+    gross_amount BIGINT NOT NULL,
+    commission_rate_bps INTEGER NOT NULL,
+    commission_amount BIGINT NOT NULL,
+    payout_amount BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_seller_statement_lines_statement_id ON seller_statement_lines(statement_id);
+
+-- Triggers to automatically update updated_at
+CREATE TRIGGER update_sellers_updated_at BEFORE UPDATE ON sellers
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_seller_statements_updated_at BEFORE UPDATE ON seller_statements
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,7 @@
+-- Drop tables
+DROP TABLE IF EXISTS analytics_refreshes;
+
+-- Drop views
+DROP MATERIALIZED VIEW IF EXISTS analytics_daily_users;
+DROP MATERIALIZED VIEW IF EXISTS analytics_daily_product_sales;
+DROP MATERIALIZED VIEW IF EXISTS analytics_daily_orders;
@@ -0,0 +1,51 @@
+-- Read model for the admin analytics dashboard. The views aggregate per UTC
+-- day and are refreshed periodically by the analytics service; reports roll
+-- the days up to weeks and months.
+
+-- Orders and revenue per day and currency. Orders count as paid once they
+-- leave pending, unless they were cancelled.
+CREATE MATERIALIZED VIEW analytics_daily_orders AS
+SELECT (placed_at AT TIME ZONE 'UTC')::date AS day,
+       currency,
+       COUNT(*) AS orders,
+       COUNT(*) FILTER (WHERE status NOT IN ('pending', 'cancelled')) AS paid_orders,
+       COALESCE(SUM(total_amount) FILTER (WHERE status NOT IN ('pending', 'cancelled')), 0) AS gross_revenue,
+       COALESCE(SUM(refunded_amount) FILTER (WHERE status NOT IN ('pending', 'cancelled')), 0) AS refunded_amount
+FROM orders
+GROUP BY 1, 2;
+
+-- Unique indexes allow refreshing the views concurrently
+CREATE UNIQUE INDEX idx_analytics_daily_orders ON analytics_daily_orders(day, currency);
+
+-- Units sold and revenue per day, product and currency, net of refunded units
+CREATE MATERIALIZED VIEW analytics_daily_product_sales AS
+SELECT (o.placed_at AT TIME ZONE 'UTC')::date AS day,
+       oi.product_id,
+       o.currency,
+       MAX(oi.sku) AS sku,
+       MAX(oi.name) AS name,
+       SUM(oi.quantity - oi.refunded_quantity) AS units,
+       SUM(oi.unit_price * (oi.quantity - oi.refunded_quantity)) AS revenue
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+WHERE o.status NOT IN ('pending', 'cancelled')
+GROUP BY 1, 2, 3;
+
+CREATE UNIQUE INDEX idx_analytics_daily_product_sales ON analytics_daily_product_sales(day, product_id, currency);
+
+-- Sign-ups per day
+CREATE MATERIALIZED VIEW analytics_daily_users AS
+SELECT (created_at AT TIME ZONE 'UTC')::date AS day,
+       COUNT(*) AS new_users
+FROM users
+GROUP BY 1;
+
+CREATE UNIQUE INDEX idx_analytics_daily_users ON analytics_daily_users(day);
+
+-- When the views were last refreshed, reported with every report
+CREATE TABLE analytics_refreshes (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id), -- single row
+    refreshed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+INSERT INTO analytics_refreshes (refreshed_at) VALUES (NOW());
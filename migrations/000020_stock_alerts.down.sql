@@ -0,0 +1,5 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_stock_alerts_updated_at ON stock_alerts;
+
+-- Drop tables
+DROP TABLE IF EXISTS stock_alerts;
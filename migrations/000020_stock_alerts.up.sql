@@ -0,0 +1,23 @@
+-- Back-in-stock alerts. A customer subscribes to an out-of-stock SKU and is
+-- emailed once when it is restocked. Alerts that never fire expire.
+CREATE TABLE stock_alerts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL,
+    sku VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, notified, expired, canceled
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    notified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- At most one active alert per customer and SKU
+CREATE UNIQUE INDEX idx_stock_alerts_active_user_sku ON stock_alerts(user_id, sku) WHERE status = 'active';
+CREATE INDEX idx_stock_alerts_active_sku ON stock_alerts(sku, created_at) WHERE status = 'active';
+CREATE INDEX idx_stock_alerts_active_expires ON stock_alerts(expires_at) WHERE status = 'active';
+CREATE INDEX idx_stock_alerts_user_created ON stock_alerts(user_id, created_at DESC);
+
+-- Trigger to automatically update updated_at
+CREATE TRIGGER update_stock_alerts_updated_at BEFORE UPDATE ON stock_alerts
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
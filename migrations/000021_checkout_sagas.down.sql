@@ -0,0 +1,5 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_checkout_sagas_updated_at ON checkout_sagas;
+
+-- Drop tables
+DROP TABLE IF EXISTS checkout_sagas;
@@ -0,0 +1,24 @@
+-- Checkout sagas. Placing an order reserves stock, creates the order and
+-- authorizes payment; each saga records the step it is on so that a checkout
+-- that fails part-way, or whose instance dies, can be rolled back by undoing
+-- the steps it started. The saga ID is also the ID of the order it places.
+CREATE TABLE checkout_sagas (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    items JSONB NOT NULL, -- items stock is reserved for
+    status VARCHAR(20) NOT NULL DEFAULT 'running', -- running, completed, compensating, compensated, failed
+    step VARCHAR(30) NOT NULL, -- reserve_inventory, create_order, authorize_payment
+    payment_id UUID,
+    failure_reason TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_checkout_sagas_unfinished ON checkout_sagas(updated_at)
+    WHERE status IN ('running', 'compensating');
+CREATE INDEX idx_checkout_sagas_user_created ON checkout_sagas(user_id, created_at DESC);
+
+-- Trigger to automatically update updated_at
+CREATE TRIGGER update_checkout_sagas_updated_at BEFORE UPDATE ON checkout_sagas
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,29 @@
+-- Order read model. One denormalized row per order, combining the order with
+-- its customer and latest payment, so order lists and searches are served
+-- without touching the order, user and payment tables. Rows are rebuilt from
+-- those tables whenever order or payment events report a change;
+-- source_updated_at is the last change a row reflects.
+CREATE TABLE order_summaries (
+    order_id UUID PRIMARY KEY REFERENCES orders(id) ON DELETE CASCADE,
+    order_number VARCHAR(32) NOT NULL,
+    user_id UUID NOT NULL,
+    customer_email VARCHAR(255) NOT NULL,
+    customer_name VARCHAR(201),
+    status VARCHAR(20) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    total_amount BIGINT NOT NULL,
+    refunded_amount BIGINT NOT NULL,
+    item_count INTEGER NOT NULL,
+    items JSONB NOT NULL, -- product_id, name, image_url and quantity of each line
+    payment_status VARCHAR(20),
+    payment_provider VARCHAR(20),
+    placed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    source_updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    projected_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_order_summaries_user_placed ON order_summaries(user_id, placed_at DESC, order_id DESC);
+CREATE INDEX idx_order_summaries_placed ON order_summaries(placed_at DESC, order_id DESC);
+CREATE INDEX idx_order_summaries_order_number ON order_summaries(order_number);
+CREATE INDEX idx_order_summaries_customer_email ON order_summaries(LOWER(customer_email) text_pattern_ops);
+CREATE INDEX idx_order_summaries_customer_name ON order_summaries(LOWER(customer_name) text_pattern_ops);
@@ -0,0 +1,5 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS update_stock_reservations_updated_at ON stock_reservations;
+
+-- Drop tables
+DROP TABLE IF EXISTS stock_reservations;
@@ -0,0 +1,23 @@
+-- Stock reserved by checkouts. A reservation is held until its checkout
+-- places the order (purchased) or is rolled back (released); reservations of
+-- abandoned checkouts are released by the expiry worker once expires_at
+-- passes. The reservation ID is the ID of the checkout and order.
+CREATE TABLE stock_reservations (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    items JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'held', -- held, purchased, released, expiring, expired
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_stock_reservations_unresolved ON stock_reservations(expires_at)
+    WHERE status IN ('held', 'expiring');
+CREATE INDEX idx_stock_reservations_resolved ON stock_reservations(resolved_at)
+    WHERE resolved_at IS NOT NULL;
+
+-- Trigger to automatically update updated_at
+CREATE TRIGGER update_stock_reservations_updated_at BEFORE UPDATE ON stock_reservations
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
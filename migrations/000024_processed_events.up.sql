@@ -0,0 +1,14 @@
+-- Processed events table. The inbox of event consumers: records the events
+-- each consumer handled, so events delivered again are skipped.
+CREATE TABLE processed_events (
+    consumer VARCHAR(255) NOT NULL, -- consumer group and topic the event was handled for
+    event_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'in_progress', -- in_progress, completed
+    locked_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (consumer, event_id)
+);
+
+CREATE INDEX idx_processed_events_expires_at ON processed_events(expires_at);
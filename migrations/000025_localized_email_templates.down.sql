@@ -0,0 +1,3 @@
+-- Remove the default email templates
+DELETE FROM email_templates
+WHERE key IN ('email_verification', 'password_reset') AND locale IN ('en', 'de', 'tr') AND description = 'Default template';
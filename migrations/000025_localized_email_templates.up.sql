@@ -0,0 +1,26 @@
+-- Email templates of the user service in every language API messages are
+-- in. Templates an admin already activated in a locale are left as they are.
+INSERT INTO email_templates (key, locale, version, subject, html_body, text_body, description, active, activated_at)
+SELECT t.key, t.locale, COALESCE((SELECT MAX(version) FROM email_templates e WHERE e.key = t.key AND e.locale = t.locale), 0) + 1,
+       t.subject, t.html_body, t.text_body, 'Default template', TRUE, NOW()
+FROM (VALUES
+    ('email_verification', 'en', 'Verify your email address',
+     '<p>Hello {{.username}},</p><p>Verify your email address with this code: <strong>{{.token}}</strong></p>',
+     E'Hello {{.username}},\n\nVerify your email address with this code: {{.token}}\n'),
+    ('email_verification', 'de', 'Bestätigen Sie Ihre E-Mail-Adresse',
+     '<p>Hallo {{.username}},</p><p>Bestätigen Sie Ihre E-Mail-Adresse mit diesem Code: <strong>{{.token}}</strong></p>',
+     E'Hallo {{.username}},\n\nBestätigen Sie Ihre E-Mail-Adresse mit diesem Code: {{.token}}\n'),
+    ('email_verification', 'tr', 'E-posta adresinizi doğrulayın',
+     '<p>Merhaba {{.username}},</p><p>E-posta adresinizi şu kodla doğrulayın: <strong>{{.token}}</strong></p>',
+     E'Merhaba {{.username}},\n\nE-posta adresinizi şu kodla doğrulayın: {{.token}}\n'),
+    ('password_reset', 'en', 'Reset your password',
+     '<p>Hello {{.username}},</p><p>Reset your password with this code within the hour: <strong>{{.token}}</strong></p><p>If you didn''t ask to, ignore this email.</p>',
+     E'Hello {{.username}},\n\nReset your password with this code within the hour: {{.token}}\n\nIf you didn''t ask to, ignore this email.\n'),
+    ('password_reset', 'de', 'Setzen Sie Ihr Passwort zurück',
+     '<p>Hallo {{.username}},</p><p>Setzen Sie Ihr Passwort innerhalb einer Stunde mit diesem Code zurück: <strong>{{.token}}</strong></p><p>Falls Sie das nicht angefordert haben, ignorieren Sie diese E-Mail.</p>',
+     E'Hallo {{.username}},\n\nSetzen Sie Ihr Passwort innerhalb einer Stunde mit diesem Code zurück: {{.token}}\n\nFalls Sie das nicht angefordert haben, ignorieren Sie diese E-Mail.\n'),
+    ('password_reset', 'tr', 'Şifrenizi sıfırlayın',
+     '<p>Merhaba {{.username}},</p><p>Şifrenizi bir saat içinde şu kodla sıfırlayın: <strong>{{.token}}</strong></p><p>Bunu siz istemediyseniz bu e-postayı dikkate almayın.</p>',
+     E'Merhaba {{.username}},\n\nŞifrenizi bir saat içinde şu kodla sıfırlayın: {{.token}}\n\nBunu siz istemediyseniz bu e-postayı dikkate almayın.\n')
+) AS t(key, locale, subject, html_body, text_body)
+WHERE NOT EXISTS (SELECT 1 FROM email_templates e WHERE e.key = t.key AND e.locale = t.locale AND e.active);
@@ -0,0 +1,15 @@
+-- Job runs table. The history of the scheduled jobs of services; a run is
+-- recorded once per job and scheduled time, which is how the replicas of a
+-- service agree which of them runs it.
+CREATE TABLE job_runs (
+    id UUID PRIMARY KEY,
+    service VARCHAR(100) NOT NULL,
+    job VARCHAR(100) NOT NULL,
+    scheduled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL, -- running, succeeded, failed
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    error TEXT
+);
+
+CREATE UNIQUE INDEX idx_job_runs_service_job_scheduled_at ON job_runs(service, job, scheduled_at);
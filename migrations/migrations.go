@@ -0,0 +1,10 @@
+// Package migrations embeds the database migrations, so services migrate
+// their database without the migration files next to the binary
+package migrations
+
+import "embed"
+
+// FS holds the migration files
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,378 @@
+// Package anonymize copies a database, usually production's, into another,
+// e.g. staging's, with the personal data of its users scrambled, so bugs
+// can be reproduced on realistic data without exposing anyone's.
+//
+// Rows are copied as they are, but for the columns Rules name: IDs and
+// amounts, statuses and timestamps are kept, so every reference between
+// rows holds in the copy and it behaves as production did. Scrambled
+// values are derived from the originals with a key, so a value scrambles
+// the same wherever it appears and lookups across tables still match.
+package anonymize
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// defaultBatchSize is how many rows are copied at a time by default
+const defaultBatchSize = 1000
+
+// Source is the database copied from. It should be a read-only,
+// repeatable-read transaction, so every table is read from the same
+// snapshot and rows referenced across tables are all there.
+type Source interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+// Options of a copy
+type Options struct {
+	// Key derives the scrambled values. Copies with the same key scramble
+	// values the same; a random one is used when it is empty.
+	Key []byte
+	// PasswordHash replaces the password hash of every user, e.g. the
+	// bcrypt hash of a password the team shares. When empty, users can't
+	// log in with a password.
+	PasswordHash string
+	// Excluded are the tables of the target that are neither emptied nor
+	// copied, e.g. its migrations table
+	Excluded []string
+	// BatchSize is how many rows are copied at a time
+	BatchSize int
+}
+
+// Table is the outcome of copying a table
+type Table struct {
+	Name    string `json:"name"`
+	Rows    int64  `json:"rows"`
+	Skipped bool   `json:"skipped,omitempty"`
+}
+
+// Anonymizer copies databases into a target, anonymizing them
+type Anonymizer struct {
+	target    *database.DB
+	rules     Rules
+	options   Options
+	scrambler *scrambler
+	logger    *logger.Logger
+}
+
+// New creates an anonymizer copying into target by rules. The target must
+// be migrated to the same version as the databases copied into it.
+func New(target *database.DB, rules Rules, options Options, log *logger.Logger) (*Anonymizer, error) {
+	key := options.Key
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = defaultBatchSize
+	}
+
+	return &Anonymizer{
+		target:    target,
+		rules:     rules,
+		options:   options,
+		scrambler: &scrambler{key: key, passwordHash: options.PasswordHash},
+		logger:    log,
+	}, nil
+}
+
+// Run replaces the rows of the target's tables with those of source,
+// anonymized. Tables are copied in the order their foreign keys need, so
+// the target checks every reference as it is copied.
+func (a *Anonymizer) Run(ctx context.Context, source Source) ([]Table, error) {
+	targetColumns, err := a.targetColumns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sourceColumns, err := sourceColumns(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]string, 0, len(targetColumns))
+	for table, columns := range targetColumns {
+		if a.excluded(table) {
+			continue
+		}
+		if err := sameColumns(table, sourceColumns[table], columns); err != nil {
+			return nil, err
+		}
+		if err := a.checkRules(table, columns); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	for table := range sourceColumns {
+		if _, ok := targetColumns[table]; !ok && !a.excluded(table) {
+			return nil, fmt.Errorf("table %s of the source isn't in the target, migrate both to the same version", table)
+		}
+	}
+
+	tables, err = a.order(ctx, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.empty(ctx, tables); err != nil {
+		return nil, err
+	}
+
+	copied := make([]Table, 0, len(tables))
+	for _, table := range tables {
+		if a.rules.skipped(table) {
+			copied = append(copied, Table{Name: table, Skipped: true})
+			continue
+		}
+
+		rows, err := a.copyTable(ctx, source, table, targetColumns[table])
+		if err != nil {
+			return copied, err
+		}
+		a.logger.Info("Table copied", "table", table, "rows", rows)
+		copied = append(copied, Table{Name: table, Rows: rows})
+	}
+
+	if err := a.refreshViews(ctx); err != nil {
+		return copied, err
+	}
+	return copied, nil
+}
+
+// copyTable copies the rows of a table, anonymizing them, and returns how
+// many were copied
+func (a *Anonymizer) copyTable(ctx context.Context, source Source, table string, columns []string) (int64, error) {
+	identifiers := make([]string, len(columns))
+	for i, column := range columns {
+		identifiers[i] = pgx.Identifier{column}.Sanitize()
+	}
+	rows, err := source.Query(ctx, fmt.Sprintf("SELECT %s FROM %s",
+		strings.Join(identifiers, ", "), pgx.Identifier{table}.Sanitize()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	rules := a.rules.Columns[table]
+	var (
+		copied int64
+		batch  = make([][]interface{}, 0, a.options.BatchSize)
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n, err := a.target.CopyFrom(ctx, table, columns, batch)
+		copied += n
+		batch = batch[:0]
+		return err
+	}
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return copied, fmt.Errorf("failed to read row of %s: %w", table, err)
+		}
+		for i, column := range columns {
+			rule, ok := rules[column]
+			if !ok {
+				continue
+			}
+			if values[i], err = a.scrambler.apply(rule, values[i]); err != nil {
+				return copied, fmt.Errorf("failed to anonymize %s.%s: %w", table, column, err)
+			}
+		}
+
+		batch = append(batch, values)
+		if len(batch) == a.options.BatchSize {
+			if err := flush(); err != nil {
+				return copied, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return copied, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	return copied, flush()
+}
+
+// checkRules checks the rules of a table name columns it has, so a column
+// renamed isn't copied as is
+func (a *Anonymizer) checkRules(table string, columns []string) error {
+	for column := range a.rules.Columns[table] {
+		if !slices.Contains(columns, column) {
+			return fmt.Errorf("column %s.%s has a rule but isn't in the target", table, column)
+		}
+	}
+	return nil
+}
+
+// excluded reports whether a table is left as it is in the target
+func (a *Anonymizer) excluded(table string) bool {
+	return slices.Contains(a.options.Excluded, table)
+}
+
+// targetColumns returns the columns of the tables of the target's current
+// schema, by table
+func (a *Anonymizer) targetColumns(ctx context.Context) (map[string][]string, error) {
+	var rows []struct {
+		Table  string `db:"table_name"`
+		Column string `db:"column_name"`
+	}
+	err := a.target.SelectContext(ctx, &rows, columnsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the columns of the target: %w", err)
+	}
+
+	columns := make(map[string][]string)
+	for _, row := range rows {
+		columns[row.Table] = append(columns[row.Table], row.Column)
+	}
+	return columns, nil
+}
+
+// sourceColumns returns the columns of the tables of the source's current
+// schema, by table
+func sourceColumns(ctx context.Context, source Source) (map[string][]string, error) {
+	rows, err := source.Query(ctx, columnsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the columns of the source: %w", err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string][]string)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, fmt.Errorf("failed to list the columns of the source: %w", err)
+		}
+		columns[table] = append(columns[table], column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list the columns of the source: %w", err)
+	}
+	return columns, nil
+}
+
+// columnsQuery lists the columns of the tables of the current schema
+const columnsQuery = `
+	SELECT c.table_name, c.column_name
+	FROM information_schema.columns c
+	JOIN information_schema.tables t
+		ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+	WHERE c.table_schema = current_schema()
+		AND t.table_type = 'BASE TABLE'
+		AND c.is_generated = 'NEVER'
+	ORDER BY c.table_name, c.ordinal_position`
+
+// sameColumns checks the source has the columns of a table the target has
+func sameColumns(table string, source, target []string) error {
+	if source == nil {
+		return fmt.Errorf("table %s of the target isn't in the source, migrate both to the same version", table)
+	}
+	if strings.Join(source, ",") != strings.Join(target, ",") {
+		return fmt.Errorf("table %s has columns (%s) in the source but (%s) in the target, migrate both to the same version",
+			table, strings.Join(source, ", "), strings.Join(target, ", "))
+	}
+	return nil
+}
+
+// order sorts tables so every table comes after those it references
+func (a *Anonymizer) order(ctx context.Context, tables []string) ([]string, error) {
+	var references []struct {
+		Child  string `db:"child"`
+		Parent string `db:"parent"`
+	}
+	err := a.target.SelectContext(ctx, &references, `
+		SELECT child.relname AS child, parent.relname AS parent
+		FROM pg_constraint c
+		JOIN pg_class child ON child.oid = c.conrelid
+		JOIN pg_class parent ON parent.oid = c.confrelid
+		WHERE c.contype = 'f' AND c.connamespace = current_schema()::regnamespace`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the foreign keys of the target: %w", err)
+	}
+
+	// Tables referencing themselves are copied in one go, the rows they
+	// reference being checked at the end of the copy
+	parents := make(map[string]map[string]bool, len(tables))
+	for _, table := range tables {
+		parents[table] = make(map[string]bool)
+	}
+	for _, reference := range references {
+		if _, ok := parents[reference.Child]; ok && reference.Child != reference.Parent {
+			if _, ok := parents[reference.Parent]; ok {
+				parents[reference.Child][reference.Parent] = true
+			}
+		}
+	}
+
+	sort.Strings(tables)
+	ordered := make([]string, 0, len(tables))
+	for len(ordered) < len(tables) {
+		progressed := false
+		for _, table := range tables {
+			if parents[table] == nil || len(parents[table]) > 0 {
+				continue
+			}
+			ordered = append(ordered, table)
+			delete(parents, table)
+			for _, others := range parents {
+				delete(others, table)
+			}
+			progressed = true
+		}
+		if !progressed {
+			remaining := make([]string, 0, len(parents))
+			for table := range parents {
+				remaining = append(remaining, table)
+			}
+			sort.Strings(remaining)
+			return nil, fmt.Errorf("tables %s reference each other", strings.Join(remaining, ", "))
+		}
+	}
+	return ordered, nil
+}
+
+// empty deletes the rows of tables in the target
+func (a *Anonymizer) empty(ctx context.Context, tables []string) error {
+	if len(tables) == 0 {
+		return nil
+	}
+	identifiers := make([]string, len(tables))
+	for i, table := range tables {
+		identifiers[i] = pgx.Identifier{table}.Sanitize()
+	}
+	if _, err := a.target.ExecContext(ctx, "TRUNCATE "+strings.Join(identifiers, ", ")); err != nil {
+		return fmt.Errorf("failed to empty the target: %w", err)
+	}
+	return nil
+}
+
+// refreshViews refreshes the materialized views of the target, computed
+// from the rows before they were replaced
+func (a *Anonymizer) refreshViews(ctx context.Context) error {
+	var views []string
+	err := a.target.SelectContext(ctx, &views,
+		`SELECT matviewname FROM pg_matviews WHERE schemaname = current_schema() ORDER BY matviewname`)
+	if err != nil {
+		return fmt.Errorf("failed to list the materialized views of the target: %w", err)
+	}
+	for _, view := range views {
+		if _, err := a.target.ExecContext(ctx, "REFRESH MATERIALIZED VIEW "+pgx.Identifier{view}.Sanitize()); err != nil {
+			return fmt.Errorf("failed to refresh %s: %w", view, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package anonymize
+
+import "slices"
+
+// Rule is how the values of a column are anonymized
+type Rule string
+
+// Rules of columns. Scrambled values are derived from the original ones
+// with the key of the run, so a value scrambles the same in every column it
+// appears in, e.g. the email of a user in users and in order_summaries.
+const (
+	// Email replaces an email address with one at example.com
+	Email Rule = "email"
+	// Username replaces a username with user_ and a hash
+	Username Rule = "username"
+	// FirstName replaces a first name with one of a list
+	FirstName Rule = "first_name"
+	// LastName replaces a last name with one of a list
+	LastName Rule = "last_name"
+	// FullName replaces a first name and last name, separated by a space,
+	// as FirstName and LastName would
+	FullName Rule = "full_name"
+	// Street replaces the first line of an address with a number and a
+	// street of a list
+	Street Rule = "street"
+	// City replaces a city with one of a list
+	City Rule = "city"
+	// Characters replaces every letter and digit with another, keeping the
+	// length, case, spaces and punctuation, e.g. of phone numbers, postal
+	// codes and free text
+	Characters Rule = "characters"
+	// Address anonymizes a JSON address, as stored with orders, shipping
+	// quotes and subscriptions
+	Address Rule = "address"
+	// IPAddress replaces an IP address with one of the documentation ranges
+	IPAddress Rule = "ip_address"
+	// Password replaces a password hash with Options.PasswordHash
+	Password Rule = "password"
+	// Null empties a column
+	Null Rule = "null"
+)
+
+// addressRules are the rules of the fields of JSON addresses. State and
+// country are kept, being needed to compute taxes and shipping.
+var addressRules = map[string]Rule{
+	"first_name":    FirstName,
+	"last_name":     LastName,
+	"company":       Characters,
+	"address_line1": Street,
+	"address_line2": Characters,
+	"city":          City,
+	"postal_code":   Characters,
+	"phone":         Characters,
+}
+
+// Rules are how the tables of a database are anonymized. Columns without a
+// rule are copied as is; IDs are never changed, so references between rows
+// hold in the copy.
+type Rules struct {
+	// Columns are the rules of columns, by table and column
+	Columns map[string]map[string]Rule
+	// Skipped are the tables whose rows aren't copied, being of no use
+	// outside production and unsafe to keep: tokens, and payloads whose
+	// personal data can't be told apart
+	Skipped []string
+}
+
+// DefaultRules anonymize the personal data of the Commercium schema: that
+// of users, and of the addresses, messages and notes they entered. New
+// columns holding personal data need a rule here, which TestRulesCover
+// checks.
+var DefaultRules = Rules{
+	Columns: map[string]map[string]Rule{
+		"users": {
+			"username":      Username,
+			"email":         Email,
+			"first_name":    FirstName,
+			"last_name":     LastName,
+			"phone":         Characters,
+			"password_hash": Password,
+		},
+		"user_profiles": {
+			"avatar_url":    Null,
+			"date_of_birth": Null,
+			"gender":        Null,
+			"bio":           Characters,
+		},
+		"user_addresses": {
+			"first_name":    FirstName,
+			"last_name":     LastName,
+			"company":       Characters,
+			"address_line1": Street,
+			"address_line2": Characters,
+			"city":          City,
+			"postal_code":   Characters,
+			"phone":         Characters,
+		},
+		"orders": {
+			"shipping_address":    Address,
+			"billing_address":     Address,
+			"notes":               Characters,
+			"cancellation_reason": Characters,
+		},
+		"order_refunds": {
+			"reason": Characters,
+		},
+		"fraud_assessments": {
+			"ip_address":   IPAddress,
+			"review_notes": Characters,
+		},
+		"shipping_quotes": {
+			"destination": Address,
+		},
+		"shipments": {
+			"tracking_number": Characters,
+			"label_url":       Null,
+		},
+		"tax_exemptions": {
+			"certificate_number": Characters,
+		},
+		"gift_cards": {
+			"recipient_email": Email,
+			"note":            Characters,
+		},
+		"product_reviews": {
+			"title":            Characters,
+			"body":             Characters,
+			"moderation_notes": Characters,
+		},
+		"subscriptions": {
+			"shipping_address":    Address,
+			"cancellation_reason": Characters,
+		},
+		"sellers": {
+			"store_name":    Characters,
+			"contact_email": Email,
+			"phone":         Characters,
+			"tax_id":        Characters,
+			"status_reason": Characters,
+		},
+		"order_summaries": {
+			"customer_email": Email,
+			"customer_name":  FullName,
+		},
+	},
+	Skipped: []string{
+		"password_reset_tokens",
+		"email_verification_tokens",
+		"idempotency_keys",
+		"payment_webhook_events",
+	},
+}
+
+// skipped reports whether the rows of table aren't copied
+func (r Rules) skipped(table string) bool {
+	return slices.Contains(r.Skipped, table)
+}
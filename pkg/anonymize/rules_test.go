@@ -0,0 +1,69 @@
+package anonymize_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/anonymize"
+)
+
+// personal matches the names of columns holding personal data
+var personal = regexp.MustCompile(`(^|_)(email|phone|username|first_name|last_name|customer_name|address|address_line\d|city|postal_code|ip_address|tax_id|date_of_birth|password_hash)$`)
+
+var (
+	createTable = regexp.MustCompile(`(?i)^\s*CREATE TABLE (?:IF NOT EXISTS )?(\w+)`)
+	alterTable  = regexp.MustCompile(`(?i)^\s*ALTER TABLE (?:IF EXISTS )?(?:ONLY )?(\w+)`)
+	addColumn   = regexp.MustCompile(`(?i)\bADD COLUMN (?:IF NOT EXISTS )?(\w+)`)
+	column      = regexp.MustCompile(`^\s+(\w+)\s+[A-Z]`)
+)
+
+// TestRulesCover checks every column of the migrations whose name tells it
+// holds personal data has a rule, or is in a table that isn't copied, so
+// columns added later aren't copied as they are
+func TestRulesCover(t *testing.T) {
+	files, err := filepath.Glob("../../migrations/*.up.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		// table is the table created, altered the table altered, by the
+		// statement the line belongs to
+		var table, altered string
+		for _, line := range strings.Split(string(data), "\n") {
+			var owner, name string
+			if match := alterTable.FindStringSubmatch(line); match != nil {
+				altered = match[1]
+			}
+			switch {
+			case createTable.MatchString(line):
+				table = createTable.FindStringSubmatch(line)[1]
+			case altered != "" && addColumn.MatchString(line):
+				owner, name = altered, addColumn.FindStringSubmatch(line)[1]
+			case table != "" && column.MatchString(line):
+				owner, name = table, column.FindStringSubmatch(line)[1]
+			case strings.HasPrefix(line, ")"):
+				table = ""
+			}
+			if strings.Contains(line, ";") {
+				altered = ""
+			}
+
+			if name == "" || !personal.MatchString(name) || slices.Contains(anonymize.DefaultRules.Skipped, owner) {
+				continue
+			}
+
+			_, ok := anonymize.DefaultRules.Columns[owner][name]
+			assert.True(t, ok, "%s: %s.%s holds personal data but has no rule", filepath.Base(file), owner, name)
+		}
+	}
+}
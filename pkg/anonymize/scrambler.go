@@ -0,0 +1,179 @@
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"unicode"
+)
+
+var firstNames = []string{
+	"Alex", "Bailey", "Casey", "Dana", "Eli", "Frankie", "Gray", "Harper",
+	"Indy", "Jordan", "Kai", "Logan", "Morgan", "Noa", "Oakley", "Parker",
+	"Quinn", "Riley", "Sage", "Taylor", "Umi", "Val", "Wren", "Yael",
+}
+
+var lastNames = []string{
+	"Abbott", "Becker", "Carter", "Dietrich", "Evans", "Fischer", "Garcia",
+	"Hansen", "Ivanova", "Jensen", "Kowalski", "Larsen", "Meyer", "Novak",
+	"Olsen", "Petrov", "Quint", "Rossi", "Schmidt", "Torres", "Uhl", "Vogel",
+	"Weber", "Young",
+}
+
+var streets = []string{
+	"Main Street", "Oak Avenue", "Station Road", "Mill Lane", "Park Road",
+	"Church Street", "High Street", "Elm Street", "Lake View", "River Road",
+	"Market Square", "Garden Lane",
+}
+
+var cities = []string{
+	"Springfield", "Riverton", "Fairview", "Greenville", "Lakeside",
+	"Milltown", "Oakridge", "Brookfield", "Kingsport", "Westbury",
+	"Ashford", "Clayton",
+}
+
+// scrambler derives anonymized values from the original ones, keyed so
+// they can't be reversed by hashing guesses without the key
+type scrambler struct {
+	key          []byte
+	passwordHash string
+}
+
+// apply anonymizes value by rule. Nulls stay null.
+func (s *scrambler) apply(rule Rule, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch rule {
+	case Null:
+		return nil, nil
+	case Password:
+		return s.passwordHash, nil
+	case Address:
+		return s.address(value)
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("rule %s doesn't apply to %T values", rule, value)
+	}
+	return s.scramble(rule, text)
+}
+
+// scramble anonymizes a text value by rule
+func (s *scrambler) scramble(rule Rule, value string) (string, error) {
+	switch rule {
+	case Email:
+		sum := s.sum(Email, strings.ToLower(strings.TrimSpace(value)))
+		return "user-" + hex.EncodeToString(sum[:8]) + "@example.com", nil
+	case Username:
+		sum := s.sum(Username, value)
+		return "user_" + hex.EncodeToString(sum[:6]), nil
+	case FirstName:
+		return pick(firstNames, s.sum(FirstName, value)), nil
+	case LastName:
+		return pick(lastNames, s.sum(LastName, value)), nil
+	case FullName:
+		first, last, _ := strings.Cut(value, " ")
+		name := pick(firstNames, s.sum(FirstName, first))
+		if last != "" {
+			name += " " + pick(lastNames, s.sum(LastName, last))
+		}
+		return name, nil
+	case Street:
+		sum := s.sum(Street, value)
+		return fmt.Sprintf("%d %s", 1+binary.BigEndian.Uint16(sum[8:])%999, pick(streets, sum)), nil
+	case City:
+		return pick(cities, s.sum(City, value)), nil
+	case Characters:
+		return s.characters(value), nil
+	case IPAddress:
+		sum := s.sum(IPAddress, value)
+		if ip := net.ParseIP(value); ip != nil && ip.To4() == nil {
+			return fmt.Sprintf("2001:db8::%x", binary.BigEndian.Uint16(sum[:])), nil
+		}
+		return fmt.Sprintf("192.0.2.%d", 1+sum[0]%254), nil
+	default:
+		return "", fmt.Errorf("unknown rule %q", rule)
+	}
+}
+
+// address anonymizes the fields of a JSON address, as decoded from JSONB
+func (s *scrambler) address(value interface{}) (interface{}, error) {
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("rule %s doesn't apply to %T values", Address, value)
+	}
+
+	anonymized := make(map[string]interface{}, len(fields))
+	for name, field := range fields {
+		rule, ok := addressRules[name]
+		if !ok {
+			anonymized[name] = field
+			continue
+		}
+		var err error
+		if anonymized[name], err = s.apply(rule, field); err != nil {
+			return nil, fmt.Errorf("failed to anonymize %s: %w", name, err)
+		}
+	}
+	return anonymized, nil
+}
+
+// characters replaces the letters and digits of value with others, drawn
+// from a keystream of the value
+func (s *scrambler) characters(value string) string {
+	var (
+		b      strings.Builder
+		stream []byte
+		block  uint32
+	)
+	b.Grow(len(value))
+	for _, r := range value {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			b.WriteRune(r)
+			continue
+		}
+		if len(stream) == 0 {
+			var counter [4]byte
+			binary.BigEndian.PutUint32(counter[:], block)
+			sum := s.sum(Characters, value, string(counter[:]))
+			stream = sum[:]
+			block++
+		}
+		n := stream[0]
+		stream = stream[1:]
+
+		switch {
+		case unicode.IsDigit(r):
+			b.WriteByte('0' + n%10)
+		case unicode.IsUpper(r):
+			b.WriteByte('A' + n%26)
+		default:
+			b.WriteByte('a' + n%26)
+		}
+	}
+	return b.String()
+}
+
+// sum returns the keyed hash of a rule's value
+func (s *scrambler) sum(rule Rule, parts ...string) [sha256.Size]byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(rule))
+	for _, part := range parts {
+		mac.Write([]byte{0})
+		mac.Write([]byte(part))
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], mac.Sum(nil))
+	return sum
+}
+
+// pick returns the element of list a hash selects
+func pick(list []string, sum [sha256.Size]byte) string {
+	return list[binary.BigEndian.Uint32(sum[:4])%uint32(len(list))]
+}
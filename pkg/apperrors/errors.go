@@ -0,0 +1,196 @@
+// Package apperrors defines the errors repositories and services fail
+// with when a request can't be served as made, such as a missing record or
+// a taken email, so handlers map them to responses by kind instead of by
+// their messages
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// The kinds of errors, matched with errors.Is
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrValidation   = errors.New("validation failed")
+	ErrRateLimited  = errors.New("rate limited")
+	// ErrPaymentRequired is a payment declined by its provider
+	ErrPaymentRequired = errors.New("payment required")
+	// ErrUpstream is a provider or service a request depends on failing
+	ErrUpstream = errors.New("upstream failed")
+	// ErrUnavailable is data a request depends on not being available, such
+	// as stale exchange rates
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// Error is an error of a kind, with a message fit for the client that made
+// the request. The error it was caused by, if any, is kept for logs only.
+type Error struct {
+	kind    error
+	message string
+	cause   error
+	// Fields maps the invalid fields of a validation error to what is wrong
+	// with them
+	Fields map[string]string
+	// FieldErrors describes the invalid fields for clients to act on, in the
+	// order they were found
+	FieldErrors []FieldError
+}
+
+// FieldError is an invalid field of a request: its path in the request, a
+// code telling clients what is wrong with it, the parameter of the rule it
+// broke, if any, and a message for users
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// New returns an error of kind, one of the Err variables, with message
+func New(kind error, message string) *Error {
+	return &Error{kind: kind, message: message}
+}
+
+// NotFound returns an error for a record that doesn't exist, or that the
+// client mustn't know exists
+func NotFound(format string, args ...interface{}) *Error {
+	return New(ErrNotFound, fmt.Sprintf(format, args...))
+}
+
+// Conflict returns an error for a request conflicting with the state of a
+// record, such as a taken email
+func Conflict(format string, args ...interface{}) *Error {
+	return New(ErrConflict, fmt.Sprintf(format, args...))
+}
+
+// Unauthorized returns an error for a client that isn't authenticated,
+// such as one giving wrong credentials
+func Unauthorized(format string, args ...interface{}) *Error {
+	return New(ErrUnauthorized, fmt.Sprintf(format, args...))
+}
+
+// Forbidden returns an error for a client authenticated but not allowed
+// to make a request
+func Forbidden(format string, args ...interface{}) *Error {
+	return New(ErrForbidden, fmt.Sprintf(format, args...))
+}
+
+// Validation returns an error for a request whose data is invalid
+func Validation(format string, args ...interface{}) *Error {
+	return New(ErrValidation, fmt.Sprintf(format, args...))
+}
+
+// RateLimited returns an error for a client that made more requests than
+// it is allowed to
+func RateLimited(format string, args ...interface{}) *Error {
+	return New(ErrRateLimited, fmt.Sprintf(format, args...))
+}
+
+// PaymentRequired returns an error for a payment its provider declined
+func PaymentRequired(format string, args ...interface{}) *Error {
+	return New(ErrPaymentRequired, fmt.Sprintf(format, args...))
+}
+
+// Upstream returns an error for a request failing because a provider or
+// service it depends on did. Its message is all clients see; the failure
+// itself is the cause to Wrap.
+func Upstream(format string, args ...interface{}) *Error {
+	return New(ErrUpstream, fmt.Sprintf(format, args...))
+}
+
+// Unavailable returns an error for a request that can't be served for now,
+// such as one needing data that is stale
+func Unavailable(format string, args ...interface{}) *Error {
+	return New(ErrUnavailable, fmt.Sprintf(format, args...))
+}
+
+// WithField adds an invalid field, and what is wrong with it, to e
+func (e *Error) WithField(field, problem string) *Error {
+	if e.Fields == nil {
+		e.Fields = make(map[string]string)
+	}
+	e.Fields[field] = problem
+	return e
+}
+
+// WithFieldError adds an invalid field, described by fe, to e
+func (e *Error) WithFieldError(fe FieldError) *Error {
+	e.FieldErrors = append(e.FieldErrors, fe)
+	return e.WithField(fe.Field, fe.Message)
+}
+
+// Wrap sets the error e was caused by
+func (e *Error) Wrap(cause error) *Error {
+	e.cause = cause
+	return e
+}
+
+// Error implements error
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.message + ": " + e.cause.Error()
+	}
+	return e.message
+}
+
+// Message returns the message of e, without its cause
+func (e *Error) Message() string {
+	return e.message
+}
+
+// Unwrap returns the kind of e and its cause, for errors.Is and errors.As
+func (e *Error) Unwrap() []error {
+	if e.cause != nil {
+		return []error{e.kind, e.cause}
+	}
+	return []error{e.kind}
+}
+
+// HTTPStatus returns the status of the response to a request that failed
+// with err, 500 when err isn't of a kind
+func HTTPStatus(err error) int {
+	switch kindOf(err) {
+	case ErrNotFound:
+		return http.StatusNotFound
+	case ErrConflict:
+		return http.StatusConflict
+	case ErrUnauthorized:
+		return http.StatusUnauthorized
+	case ErrForbidden:
+		return http.StatusForbidden
+	case ErrValidation:
+		return http.StatusBadRequest
+	case ErrRateLimited:
+		return http.StatusTooManyRequests
+	case ErrPaymentRequired:
+		return http.StatusPaymentRequired
+	case ErrUpstream:
+		return http.StatusBadGateway
+	case ErrUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// kindOf returns the kind of err: that of the first Error in its chain,
+// so an error caused by one of another kind keeps its own, or the kind it
+// wraps, or nil
+func kindOf(err error) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.kind
+	}
+	for _, kind := range []error{ErrNotFound, ErrConflict, ErrUnauthorized, ErrForbidden, ErrValidation, ErrRateLimited,
+		ErrPaymentRequired, ErrUpstream, ErrUnavailable} {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	return nil
+}
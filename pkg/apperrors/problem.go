@@ -0,0 +1,98 @@
+package apperrors
+
+import (
+	"errors"
+	"net/http"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/i18n"
+)
+
+// ProblemContentType is the content type of problem details
+const ProblemContentType = "application/problem+json"
+
+// Problem is the body of an error response, the problem details of RFC
+// 7807
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Errors maps the invalid fields of a validation error to what is wrong
+	// with them
+	Errors map[string]string `json:"errors,omitempty"`
+	// FieldErrors describes the invalid fields with machine-readable codes
+	FieldErrors []FieldError `json:"field_errors,omitempty"`
+	// Error repeats the detail for clients reading the error member of the
+	// responses services gave before problem details
+	Error string `json:"error"`
+}
+
+// NewProblem returns the problem details of a request to instance, its
+// path, that failed with err. The detail of an error of a kind is its
+// message; that of any other is left out, as it may reveal internals.
+func NewProblem(err error, instance string) Problem {
+	status := HTTPStatus(err)
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Instance: instance,
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		problem.Detail = sentence(e.message)
+		problem.Errors = e.Fields
+		problem.FieldErrors = e.FieldErrors
+	} else if status != http.StatusInternalServerError {
+		problem.Detail = sentence(err.Error())
+	}
+	problem.Error = problem.Detail
+	if problem.Error == "" {
+		problem.Error = problem.Title
+	}
+	return problem
+}
+
+// Middleware returns Gin middleware that answers requests whose handlers
+// failed, adding an error with c.Error instead of writing a response, with
+// the problem details of the last error added
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		Respond(c, c.Errors.Last().Err)
+	}
+}
+
+// Respond answers the request of c, which failed with err, with its
+// problem details, titled in the language of the request
+func Respond(c *gin.Context, err error) {
+	problem := NewProblem(err, c.Request.URL.Path)
+	title := i18n.StatusText(i18n.Language(c), problem.Status)
+	if problem.Error == problem.Title {
+		problem.Error = title
+	}
+	problem.Title = title
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(problem.Status, problem)
+}
+
+// sentence returns message starting with a capital, as responses have
+// them, from an error message starting with a lowercase letter, as Go has
+// them
+func sentence(message string) string {
+	r, size := utf8.DecodeRuneInString(message)
+	if r == utf8.RuneError {
+		return message
+	}
+	return string(unicode.ToUpper(r)) + message[size:]
+}
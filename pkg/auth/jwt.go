@@ -18,6 +18,9 @@ type Claims struct {
 	Email    string    `json:"email"`
 	Username string    `json:"username"`
 	Role     string    `json:"role"`
+	// TenantID is the tenant the user signed in at, empty outside tenants.
+	// Requests with the token are only made for that tenant.
+	TenantID string `json:"tenant_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -41,14 +44,16 @@ func NewJWTService(config *config.JWTConfig) *JWTService {
 	}
 }
 
-// GenerateTokenPair generates access and refresh tokens
-func (j *JWTService) GenerateTokenPair(userID uuid.UUID, email, username, role string) (*TokenPair, error) {
+// GenerateTokenPair generates access and refresh tokens of a user of
+// tenantID, empty outside tenants
+func (j *JWTService) GenerateTokenPair(userID uuid.UUID, email, username, role, tenantID string) (*TokenPair, error) {
 	// Generate access token
 	accessClaims := &Claims{
 		UserID:   userID,
 		Email:    email,
 		Username: username,
 		Role:     role,
+		TenantID: tenantID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        uuid.New().String(),
 			Issuer:    j.config.Issuer,
@@ -147,7 +152,7 @@ func (j *JWTService) ValidateRefreshToken(tokenString string) (*jwt.RegisteredCl
 }
 
 // RefreshTokenPair generates a new token pair using a refresh token
-func (j *JWTService) RefreshTokenPair(refreshToken string, userID uuid.UUID, email, username, role string) (*TokenPair, error) {
+func (j *JWTService) RefreshTokenPair(refreshToken string, userID uuid.UUID, email, username, role, tenantID string) (*TokenPair, error) {
 	// Validate the refresh token
 	_, err := j.ValidateRefreshToken(refreshToken)
 	if err != nil {
@@ -155,7 +160,7 @@ func (j *JWTService) RefreshTokenPair(refreshToken string, userID uuid.UUID, ema
 	}
 
 	// Generate new token pair
-	return j.GenerateTokenPair(userID, email, username, role)
+	return j.GenerateTokenPair(userID, email, username, role, tenantID)
 }
 
 // GenerateSecureToken generates a cryptographically secure random token
@@ -0,0 +1,81 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+func newJWTService() *auth.JWTService {
+	return auth.NewJWTService(&config.JWTConfig{
+		SecretKey:         "benchmark-secret-key-of-32-bytes!",
+		Issuer:            "commercium",
+		Expiration:        15 * time.Minute,
+		RefreshExpiration: 7 * 24 * time.Hour,
+	})
+}
+
+func BenchmarkGenerateTokenPair(b *testing.B) {
+	jwtService := newJWTService()
+	userID := uuid.New()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := jwtService.GenerateTokenPair(userID, "test@example.com", "testuser", "customer", ""); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidateAccessToken(b *testing.B) {
+	jwtService := newJWTService()
+	pair, err := jwtService.GenerateTokenPair(uuid.New(), "test@example.com", "testuser", "customer", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := jwtService.ValidateAccessToken(pair.AccessToken); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkMiddleware measures what every authenticated request pays before
+// reaching its handler: the token validated, the role checked
+func BenchmarkMiddleware(b *testing.B) {
+	gin.SetMode(gin.ReleaseMode)
+	jwtService := newJWTService()
+	pair, err := jwtService.GenerateTokenPair(uuid.New(), "test@example.com", "testuser", "customer", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	router := gin.New()
+	router.GET("/profile", jwtService.Middleware(), auth.RequireRole("customer"), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+			req.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusNoContent {
+				b.Fatalf("unexpected status %d", w.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,356 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/kafka"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+)
+
+const (
+	// lockTTL bounds how long one instance may hold the right to load a key
+	lockTTL = 5 * time.Second
+	// lockPoll is how often instances waiting for another one's load check
+	// whether the value arrived
+	lockPoll = 50 * time.Millisecond
+)
+
+// Results of cache reads, as recorded in metrics
+const (
+	resultLocalHit = "local_hit"
+	resultHit      = "hit"
+	resultMiss     = "miss"
+)
+
+// setIfCurrent stores a loaded value unless the key was invalidated while it
+// was being loaded, which the key's version reveals. KEYS: value key, version
+// key. ARGV: version seen before loading, value, TTL in milliseconds.
+var setIfCurrent = database.NewScript("cache_set_if_current", `
+local version = redis.call('GET', KEYS[2]) or ''
+if version ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1`)
+
+// releaseLock releases the lock of a key only while the load holding it
+// still does, so a lock that expired during a slow load and was taken by
+// another instance is left alone. KEYS: lock key. ARGV: token of the load.
+var releaseLock = database.NewScript("cache_release_lock", `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+
+// Loader loads a value missing from the cache from its source
+type Loader func(ctx context.Context) (interface{}, error)
+
+// Cache is a read-through cache of JSON values in Redis. On a miss only one
+// caller loads a key: callers in the same process share the load, and
+// instances wait for the instance holding the key's lock instead of all
+// hitting the source at once. Entries expire after the TTL, but are meant to
+// be invalidated as soon as their source changes. Hot keys may also be kept
+// in process for a short while, see KeepLocal.
+type Cache struct {
+	redis  *database.Redis
+	prefix string
+	ttl    time.Duration
+	logger *logger.Logger
+
+	local       *local
+	metrics     metrics.Registry
+	serviceName string
+
+	mu    sync.Mutex
+	loads map[string]*load
+}
+
+// load is a load of a key in progress, shared by its callers
+type load struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+// New creates a new cache. Keys are namespaced with prefix.
+func New(redis *database.Redis, prefix string, ttl time.Duration, logger *logger.Logger) *Cache {
+	return &Cache{
+		redis:  redis,
+		prefix: prefix,
+		ttl:    ttl,
+		logger: logger,
+		loads:  make(map[string]*load),
+	}
+}
+
+// KeepLocal keeps up to size values in process for ttl in front of Redis.
+// Instances only drop the values they invalidate themselves, so other
+// instances may serve a stale value for up to ttl; keep it short.
+func (c *Cache) KeepLocal(size int, ttl time.Duration) *Cache {
+	if size > 0 && ttl > 0 {
+		c.local = newLocal(size, ttl)
+	}
+	return c
+}
+
+// Instrument records cache hits and misses in registry, labeled by the
+// cache's prefix
+func (c *Cache) Instrument(registry metrics.Registry, serviceName string) *Cache {
+	c.metrics = registry
+	c.serviceName = serviceName
+	return c
+}
+
+// Get decodes the cached value of key into dest, reporting whether it was
+// cached
+func (c *Cache) Get(ctx context.Context, key string, dest interface{}) (bool, error) {
+	value, ok := c.get(ctx, key)
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(value, dest); err != nil {
+		return false, fmt.Errorf("failed to decode cached value: %w", err)
+	}
+	return true, nil
+}
+
+// Set caches the value of key. Prefer Fetch, which doesn't store a value
+// read before a concurrent invalidation.
+func (c *Cache) Set(ctx context.Context, key string, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cached value: %w", err)
+	}
+
+	if err := c.redis.Set(ctx, c.valueKey(key), encoded, c.jitteredTTL()).Err(); err != nil {
+		c.logger.Error("Failed to write cache", "error", err, "key", key)
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if c.local != nil {
+		c.local.set(key, encoded)
+	}
+
+	return nil
+}
+
+// Fetch decodes the cached value of key into dest, loading and caching it
+// first on a miss. Redis errors are logged and the value is loaded from the
+// source, so the cache being down doesn't take reads down with it.
+func (c *Cache) Fetch(ctx context.Context, key string, dest interface{}, loader Loader) error {
+	if value, ok := c.get(ctx, key); ok {
+		return json.Unmarshal(value, dest)
+	}
+
+	c.mu.Lock()
+	current, ok := c.loads[key]
+	if !ok {
+		current = &load{done: make(chan struct{})}
+		c.loads[key] = current
+		go c.load(key, current, loader)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-current.done:
+	}
+	if current.err != nil {
+		return current.err
+	}
+	if c.local != nil {
+		c.local.set(key, current.value)
+	}
+
+	return json.Unmarshal(current.value, dest)
+}
+
+// get returns the cached value of key from the local tier or Redis,
+// recording the result
+func (c *Cache) get(ctx context.Context, key string) ([]byte, bool) {
+	if c.local != nil {
+		if value, ok := c.local.get(key); ok {
+			c.record(resultLocalHit)
+			return value, true
+		}
+	}
+
+	value, err := c.redis.Get(ctx, c.valueKey(key)).Bytes()
+	if err == nil {
+		c.record(resultHit)
+		if c.local != nil {
+			c.local.set(key, value)
+		}
+		return value, true
+	}
+	if err != redis.Nil {
+		c.logger.Error("Failed to read cache", "error", err, "key", key)
+	}
+
+	c.record(resultMiss)
+	return nil, false
+}
+
+// record records the result of a cache read
+func (c *Cache) record(result string) {
+	if c.metrics != nil {
+		c.metrics.IncCacheRequest(c.prefix, result, c.serviceName)
+	}
+}
+
+// load loads a key for all callers waiting for it. The load isn't tied to
+// the context of the caller that started it, which may give up before the
+// others.
+func (c *Cache) load(key string, current *load, loader Loader) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.loads, key)
+		c.mu.Unlock()
+		close(current.done)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), lockTTL)
+	defer cancel()
+
+	version, err := c.redis.Get(ctx, c.versionKey(key)).Result()
+	if err != nil && err != redis.Nil {
+		c.logger.Error("Failed to read cache version", "error", err, "key", key)
+	}
+
+	// Wait for another instance loading the key rather than loading it too
+	token := uuid.NewString()
+	locked, err := c.redis.SetNX(ctx, c.lockKey(key), token, lockTTL).Result()
+	if err == nil && !locked {
+		if value, ok := c.await(ctx, key); ok {
+			current.value = value
+			return
+		}
+	}
+	if locked {
+		defer c.unlock(key, token)
+	}
+
+	loaded, err := loader(ctx)
+	if err != nil {
+		current.err = err
+		return
+	}
+	current.value, current.err = json.Marshal(loaded)
+	if current.err != nil {
+		current.err = fmt.Errorf("failed to encode cached value: %w", current.err)
+		return
+	}
+
+	err = c.redis.RunScript(ctx, setIfCurrent, []string{c.valueKey(key), c.versionKey(key)},
+		version, current.value, c.jitteredTTL().Milliseconds()).Err()
+	if err != nil {
+		c.logger.Error("Failed to write cache", "error", err, "key", key)
+	}
+}
+
+// unlock releases the lock of key taken with token, if still held
+func (c *Cache) unlock(key, token string) {
+	ctx, cancel := context.WithTimeout(context.Background(), lockTTL)
+	defer cancel()
+
+	if err := c.redis.RunScript(ctx, releaseLock, []string{c.lockKey(key)}, token).Err(); err != nil {
+		c.logger.Error("Failed to release cache lock, it expires on its own", "error", err, "key", key)
+	}
+}
+
+// await polls for the value another instance is loading, until its lock is
+// released or expires
+func (c *Cache) await(ctx context.Context, key string) ([]byte, bool) {
+	ticker := time.NewTicker(lockPoll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-ticker.C:
+		}
+
+		value, err := c.redis.Get(ctx, c.valueKey(key)).Bytes()
+		if err == nil {
+			return value, true
+		}
+		if err != redis.Nil {
+			return nil, false
+		}
+		if held, err := c.redis.Exists(ctx, c.lockKey(key)); err != nil || !held {
+			return nil, false
+		}
+	}
+}
+
+// Invalidate removes keys from the cache. Loads of the keys in progress
+// don't store the values they read before the invalidation.
+func (c *Cache) Invalidate(ctx context.Context, keys ...string) error {
+	if c.local != nil {
+		c.local.delete(keys...)
+	}
+
+	pipe := c.redis.TxPipeline()
+	for _, key := range keys {
+		pipe.Incr(ctx, c.versionKey(key))
+		pipe.Expire(ctx, c.versionKey(key), c.ttl+lockTTL)
+		pipe.Del(ctx, c.valueKey(key))
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		c.logger.Error("Failed to invalidate cache", "error", err, "keys", keys)
+		return fmt.Errorf("failed to invalidate cache: %w", err)
+	}
+
+	return nil
+}
+
+// InvalidationHandler returns a Kafka handler invalidating the keys that
+// keys maps each message to, so entries are dropped as soon as the events of
+// their source report a change
+func (c *Cache) InvalidationHandler(keys func(value []byte) ([]string, error)) kafka.Handler {
+	return func(ctx context.Context, key, value []byte) error {
+		stale, err := keys(value)
+		if err != nil {
+			return err
+		}
+		if len(stale) == 0 {
+			return nil
+		}
+
+		return c.Invalidate(ctx, stale...)
+	}
+}
+
+// jitteredTTL spreads expiries by up to a tenth of the TTL, so entries
+// cached together don't all expire together
+func (c *Cache) jitteredTTL() time.Duration {
+	jitter := int64(c.ttl / 10)
+	if jitter <= 0 {
+		return c.ttl
+	}
+	return c.ttl + time.Duration(rand.Int63n(jitter))
+}
+
+func (c *Cache) valueKey(key string) string {
+	return c.prefix + ":" + key
+}
+
+func (c *Cache) versionKey(key string) string {
+	return c.prefix + ":version:" + key
+}
+
+func (c *Cache) lockKey(key string) string {
+	return c.prefix + ":lock:" + key
+}
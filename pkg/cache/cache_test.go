@@ -0,0 +1,162 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/cache"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+type product struct {
+	Name  string `json:"name"`
+	Price int64  `json:"price"`
+}
+
+// newCache returns a cache of products in a Redis of its own
+func newCache(t *testing.T) (*cache.Cache, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	port, err := strconv.Atoi(server.Port())
+	require.NoError(t, err)
+
+	log, err := logger.New(config.LoggerConfig{Level: "error", Format: "json", Output: "stdout"}, "cache-test")
+	require.NoError(t, err)
+	redis, err := database.NewRedis(config.RedisConfig{Host: server.Host(), Port: port}, log)
+	require.NoError(t, err)
+	t.Cleanup(func() { redis.Close() })
+
+	return cache.New(redis, "products", time.Minute, log), server
+}
+
+// countingLoader loads a product, counting its loads
+func countingLoader(loads *int32, value product) cache.Loader {
+	return func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(loads, 1)
+		time.Sleep(20 * time.Millisecond)
+		return value, nil
+	}
+}
+
+func TestFetchLoadsMissesOnce(t *testing.T) {
+	c, server := newCache(t)
+	var loads int32
+	loader := countingLoader(&loads, product{Name: "Mug", Price: 1200})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var got product
+			assert.NoError(t, c.Fetch(context.Background(), "1", &got, loader))
+			assert.Equal(t, "Mug", got.Name)
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, loads, "concurrent misses share one load")
+
+	var got product
+	require.NoError(t, c.Fetch(context.Background(), "1", &got, loader))
+	assert.EqualValues(t, 1, loads, "hits aren't loaded")
+	assert.False(t, server.Exists("products:lock:1"), "the lock is released after the load")
+}
+
+func TestFetchReportsLoadErrors(t *testing.T) {
+	c, server := newCache(t)
+	failure := errors.New("database is down")
+
+	var got product
+	err := c.Fetch(context.Background(), "1", &got, func(ctx context.Context) (interface{}, error) {
+		return nil, failure
+	})
+	assert.ErrorIs(t, err, failure)
+	assert.False(t, server.Exists("products:1"), "failed loads aren't cached")
+}
+
+func TestInvalidateDropsValue(t *testing.T) {
+	c, _ := newCache(t)
+	var loads int32
+
+	var got product
+	require.NoError(t, c.Fetch(context.Background(), "1", &got, countingLoader(&loads, product{Name: "Mug"})))
+	require.NoError(t, c.Invalidate(context.Background(), "1"))
+	require.NoError(t, c.Fetch(context.Background(), "1", &got, countingLoader(&loads, product{Name: "Cup"})))
+
+	assert.EqualValues(t, 2, loads)
+	assert.Equal(t, "Cup", got.Name)
+}
+
+func TestLoadRacingInvalidationIsNotStored(t *testing.T) {
+	c, server := newCache(t)
+
+	var got product
+	err := c.Fetch(context.Background(), "1", &got, func(ctx context.Context) (interface{}, error) {
+		// The product changes after it was read
+		value := product{Name: "Mug"}
+		require.NoError(t, c.Invalidate(ctx, "1"))
+		return value, nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Mug", got.Name, "callers still get what was loaded")
+	assert.False(t, server.Exists("products:1"), "the stale value isn't cached")
+}
+
+func TestLoadLeavesLockOfAnotherHolder(t *testing.T) {
+	c, server := newCache(t)
+
+	var got product
+	err := c.Fetch(context.Background(), "1", &got, func(ctx context.Context) (interface{}, error) {
+		// The lock expired during a slow load and another instance took it
+		server.FastForward(10 * time.Second)
+		require.NoError(t, server.Set("products:lock:1", "another-instance"))
+		return product{Name: "Mug"}, nil
+	})
+	require.NoError(t, err)
+
+	lock, err := server.Get("products:lock:1")
+	require.NoError(t, err)
+	assert.Equal(t, "another-instance", lock)
+}
+
+func TestFetchWaitsForAnotherInstance(t *testing.T) {
+	c, server := newCache(t)
+	require.NoError(t, server.Set("products:lock:1", "another-instance"))
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		server.Set("products:1", `{"name":"Mug","price":1200}`)
+		server.Del("products:lock:1")
+	}()
+
+	var loads int32
+	var got product
+	require.NoError(t, c.Fetch(context.Background(), "1", &got, countingLoader(&loads, product{Name: "Cup"})))
+	assert.EqualValues(t, 0, loads, "the value loaded by the lock's holder is used")
+	assert.Equal(t, "Mug", got.Name)
+}
+
+func TestInvalidationHandler(t *testing.T) {
+	c, server := newCache(t)
+	require.NoError(t, c.Set(context.Background(), "1", product{Name: "Mug"}))
+	require.NoError(t, c.Set(context.Background(), "2", product{Name: "Cup"}))
+
+	handle := c.InvalidationHandler(func(value []byte) ([]string, error) {
+		return []string{string(value)}, nil
+	})
+	require.NoError(t, handle(context.Background(), nil, []byte("1")))
+
+	assert.False(t, server.Exists("products:1"))
+	assert.True(t, server.Exists("products:2"))
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// local is an in-process LRU of encoded values in front of Redis. Its
+// entries live briefly, as other instances' invalidations don't reach it.
+type local struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// localEntry is a value held by the local tier
+type localEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func newLocal(size int, ttl time.Duration) *local {
+	return &local{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the value of key unless it is missing or expired
+func (l *local) get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*localEntry)
+	if time.Now().After(entry.expiresAt) {
+		l.order.Remove(element)
+		delete(l.entries, key)
+		return nil, false
+	}
+
+	l.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// set stores the value of key, evicting the least recently used entry when
+// the tier is full
+func (l *local) set(key string, value []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	expiresAt := time.Now().Add(l.ttl)
+	if element, ok := l.entries[key]; ok {
+		entry := element.Value.(*localEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		l.order.MoveToFront(element)
+		return
+	}
+
+	l.entries[key] = l.order.PushFront(&localEntry{key: key, value: value, expiresAt: expiresAt})
+	for l.order.Len() > l.size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*localEntry).key)
+	}
+}
+
+// delete removes keys from the tier
+func (l *local) delete(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := l.entries[key]; ok {
+			l.order.Remove(element)
+			delete(l.entries, key)
+		}
+	}
+}
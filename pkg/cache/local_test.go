@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalEvictsLeastRecentlyUsed(t *testing.T) {
+	l := newLocal(2, time.Minute)
+	l.set("1", []byte("one"))
+	l.set("2", []byte("two"))
+
+	// Reading 1 makes 2 the least recently used
+	_, ok := l.get("1")
+	assert.True(t, ok)
+	l.set("3", []byte("three"))
+
+	_, ok = l.get("2")
+	assert.False(t, ok, "the least recently used entry is evicted")
+	value, ok := l.get("1")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("one"), value)
+	_, ok = l.get("3")
+	assert.True(t, ok)
+}
+
+func TestLocalExpiresEntries(t *testing.T) {
+	l := newLocal(10, 20*time.Millisecond)
+	l.set("1", []byte("one"))
+
+	_, ok := l.get("1")
+	assert.True(t, ok)
+
+	time.Sleep(30 * time.Millisecond)
+	_, ok = l.get("1")
+	assert.False(t, ok)
+	assert.Empty(t, l.entries, "expired entries are dropped once read")
+}
+
+func TestLocalSetReplacesValue(t *testing.T) {
+	l := newLocal(1, time.Minute)
+	l.set("1", []byte("one"))
+	l.set("1", []byte("uno"))
+
+	value, ok := l.get("1")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("uno"), value)
+	assert.Equal(t, 1, l.order.Len())
+}
+
+func TestLocalDelete(t *testing.T) {
+	l := newLocal(10, time.Minute)
+	l.set("1", []byte("one"))
+	l.set("2", []byte("two"))
+
+	l.delete("1", "unknown")
+
+	_, ok := l.get("1")
+	assert.False(t, ok)
+	_, ok = l.get("2")
+	assert.True(t, ok)
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+)
+
+// Typed is a cache of values of type T, sparing callers the decoding into
+// interface{} destinations
+type Typed[T any] struct {
+	cache *Cache
+}
+
+// NewTyped returns a cache of values of type T stored in cache
+func NewTyped[T any](cache *Cache) *Typed[T] {
+	return &Typed[T]{cache: cache}
+}
+
+// Get returns the cached value of key, reporting whether it was cached
+func (t *Typed[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	var value T
+	ok, err := t.cache.Get(ctx, key, &value)
+	return value, ok, err
+}
+
+// Set caches the value of key
+func (t *Typed[T]) Set(ctx context.Context, key string, value T) error {
+	return t.cache.Set(ctx, key, value)
+}
+
+// Fetch returns the cached value of key, loading and caching it first on a
+// miss
+func (t *Typed[T]) Fetch(ctx context.Context, key string, loader func(ctx context.Context) (T, error)) (T, error) {
+	var value T
+	err := t.cache.Fetch(ctx, key, &value, func(ctx context.Context) (interface{}, error) {
+		return loader(ctx)
+	})
+	return value, err
+}
+
+// Invalidate removes keys from the cache
+func (t *Typed[T]) Invalidate(ctx context.Context, keys ...string) error {
+	return t.cache.Invalidate(ctx, keys...)
+}
@@ -0,0 +1,78 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/cache"
+)
+
+func TestTyped(t *testing.T) {
+	c, _ := newCache(t)
+	products := cache.NewTyped[*product](c)
+	ctx := context.Background()
+
+	_, ok, err := products.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	loads := 0
+	load := func(ctx context.Context) (*product, error) {
+		loads++
+		return &product{Name: "Mug", Price: 1200}, nil
+	}
+	got, err := products.Fetch(ctx, "1", load)
+	require.NoError(t, err)
+	assert.Equal(t, &product{Name: "Mug", Price: 1200}, got)
+
+	got, ok, err = products.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "Mug", got.Name)
+
+	require.NoError(t, products.Set(ctx, "1", &product{Name: "Cup"}))
+	got, err = products.Fetch(ctx, "1", load)
+	require.NoError(t, err)
+	assert.Equal(t, "Cup", got.Name, "set values are served")
+
+	require.NoError(t, products.Invalidate(ctx, "1"))
+	_, ok, err = products.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 1, loads)
+}
+
+func TestTypedFetchReportsLoadErrors(t *testing.T) {
+	c, _ := newCache(t)
+	products := cache.NewTyped[product](c)
+	failure := errors.New("database is down")
+
+	_, err := products.Fetch(context.Background(), "1", func(ctx context.Context) (product, error) {
+		return product{}, failure
+	})
+	assert.ErrorIs(t, err, failure)
+}
+
+func TestKeepLocalServesWithoutRedis(t *testing.T) {
+	c, server := newCache(t)
+	products := cache.NewTyped[product](c.KeepLocal(10, time.Minute))
+	ctx := context.Background()
+
+	require.NoError(t, products.Set(ctx, "1", product{Name: "Mug"}))
+	server.FlushAll()
+
+	got, ok, err := products.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.True(t, ok, "hot keys are kept in process")
+	assert.Equal(t, "Mug", got.Name)
+
+	require.NoError(t, products.Invalidate(ctx, "1"))
+	_, ok, err = products.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.False(t, ok, "invalidating drops the local copy too")
+}
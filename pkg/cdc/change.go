@@ -0,0 +1,156 @@
+// Package cdc consumes the change events Debezium captures from the
+// PostgreSQL write-ahead log. Each table has a topic carrying one message per
+// row insert, update or delete, with the row before and after the change, so
+// services can keep derived data in sync with tables they don't own without
+// the owning service publishing events for them.
+package cdc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/kafka"
+)
+
+// Operation is the kind of change made to a row
+type Operation string
+
+// Operations of change events
+const (
+	OperationCreate   Operation = "c"
+	OperationUpdate   Operation = "u"
+	OperationDelete   Operation = "d"
+	OperationTruncate Operation = "t"
+	// OperationRead is a row read while snapshotting a table, before its
+	// changes are streamed
+	OperationRead Operation = "r"
+)
+
+// Source describes where in the database a change was captured
+type Source struct {
+	Connector string `json:"connector"`
+	Name      string `json:"name"`
+	Database  string `json:"db"`
+	Schema    string `json:"schema"`
+	Table     string `json:"table"`
+	// LSN is the position of the change in the write-ahead log
+	LSN  int64 `json:"lsn"`
+	TxID int64 `json:"txId"`
+	// Snapshot is "true", "last" or "incremental" for rows read while
+	// snapshotting, and "false" otherwise
+	Snapshot string `json:"snapshot"`
+	TsMs     int64  `json:"ts_ms"`
+}
+
+// Change is a change event. Before is null for inserts and snapshot reads,
+// After is null for deletes; columns are encoded as Debezium's JSON
+// converter encodes them.
+type Change struct {
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	Source    Source          `json:"source"`
+	Operation Operation       `json:"op"`
+	TsMs      int64           `json:"ts_ms"`
+}
+
+// envelope is a change event serialized with its schema, as the JSON
+// converter does when schemas are enabled
+type envelope struct {
+	Schema  json.RawMessage `json:"schema"`
+	Payload *Change         `json:"payload"`
+}
+
+// Parse decodes a change event, with or without its schema
+func Parse(data []byte) (*Change, error) {
+	var withSchema envelope
+	if err := json.Unmarshal(data, &withSchema); err != nil {
+		return nil, fmt.Errorf("invalid change event: %w", err)
+	}
+
+	change := withSchema.Payload
+	if withSchema.Schema == nil || change == nil {
+		change = &Change{}
+		if err := json.Unmarshal(data, change); err != nil {
+			return nil, fmt.Errorf("invalid change event: %w", err)
+		}
+	}
+
+	switch change.Operation {
+	case OperationCreate, OperationUpdate, OperationDelete, OperationTruncate, OperationRead:
+	default:
+		return nil, fmt.Errorf("invalid change event: unknown operation %q", change.Operation)
+	}
+	return change, nil
+}
+
+// Table returns the schema-qualified name of the table the change was made to
+func (c *Change) Table() string {
+	return c.Source.Schema + "." + c.Source.Table
+}
+
+// Time returns when the change was committed
+func (c *Change) Time() time.Time {
+	return time.UnixMilli(c.Source.TsMs).UTC()
+}
+
+// DecodeBefore decodes the row before the change into v, and reports whether
+// there was one
+func (c *Change) DecodeBefore(v interface{}) (bool, error) {
+	return decodeRow(c.Before, v)
+}
+
+// DecodeAfter decodes the row after the change into v, and reports whether
+// there is one
+func (c *Change) DecodeAfter(v interface{}) (bool, error) {
+	return decodeRow(c.After, v)
+}
+
+// decodeRow decodes a row image, which is null when the row doesn't exist
+// on that side of the change
+func decodeRow(data json.RawMessage, v interface{}) (bool, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("invalid change event row: %w", err)
+	}
+	return true, nil
+}
+
+// Handle registers a handler for the change events of a table's topic, with
+// the rows before and after the change decoded into T; either is nil when the
+// row doesn't exist on that side of the change. The tombstones Debezium
+// follows deletes with, for log compaction, are skipped. Malformed change
+// events are dead-lettered right away.
+func Handle[T any](g *kafka.ConsumerGroup, topic string, handle func(ctx context.Context, change *Change, before, after *T) error) {
+	g.Register(topic, func(ctx context.Context, key, value []byte) error {
+		if len(value) == 0 {
+			return nil
+		}
+
+		change, err := Parse(value)
+		if err != nil {
+			return kafka.Permanent(err)
+		}
+
+		before, after := new(T), new(T)
+		hasBefore, err := change.DecodeBefore(before)
+		if err != nil {
+			return kafka.Permanent(err)
+		}
+		hasAfter, err := change.DecodeAfter(after)
+		if err != nil {
+			return kafka.Permanent(err)
+		}
+		if !hasBefore {
+			before = nil
+		}
+		if !hasAfter {
+			after = nil
+		}
+
+		return handle(ctx, change, before, after)
+	})
+}
@@ -0,0 +1,332 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	awsAlgorithm   = "AWS4-HMAC-SHA256"
+	awsDateFormat  = "20060102T150405Z"
+	awsScopeFormat = "20060102"
+	// awsContainerCredentialsHost serves the credentials of the task role
+	// to ECS tasks
+	awsContainerCredentialsHost = "http://169.254.170.2"
+)
+
+// awsCredentials sign requests to AWS
+type awsCredentials struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	SessionToken    string    `json:"Token"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// awsClient calls the JSON APIs of AWS services, signing requests with AWS
+// Signature Version 4. Credentials are the configured keys, those of the
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+// environment variables, or those of the ECS task role, in that order.
+type awsClient struct {
+	service    string
+	region     string
+	endpoint   string
+	config     AWSSecretsConfig
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	credentials *awsCredentials
+}
+
+func newAWSClient(service string, cfg AWSSecretsConfig) *awsClient {
+	region := cfg.Region
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+
+	return &awsClient{
+		service:    service,
+		region:     region,
+		endpoint:   fmt.Sprintf("https://%s.%s.amazonaws.com/", service, region),
+		config:     cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// call calls the target operation with the JSON encoding of input and
+// decodes the response into output
+func (c *awsClient) call(ctx context.Context, target string, input, output interface{}) error {
+	if c.region == "" {
+		return fmt.Errorf("aws region is required, set secrets.aws.region or AWS_REGION")
+	}
+
+	credentials, err := c.resolveCredentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", target, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", target, err)
+	}
+	c.sign(req, body, target, credentials, time.Now().UTC())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", target, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", target, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(respBody, &apiErr)
+		return fmt.Errorf("%s failed: status %d: %s %s", target, resp.StatusCode, apiErr.Type, apiErr.Message)
+	}
+
+	if err := json.Unmarshal(respBody, output); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", target, err)
+	}
+	return nil
+}
+
+// sign signs a request to the JSON API of the service
+func (c *awsClient) sign(req *http.Request, body []byte, target string, credentials *awsCredentials, now time.Time) {
+	payloadHash := awsSHA256Hex(body)
+	headers := map[string]string{
+		"content-type": "application/x-amz-json-1.1",
+		"host":         req.URL.Host,
+		"x-amz-date":   now.Format(awsDateFormat),
+		"x-amz-target": target,
+	}
+	if credentials.SessionToken != "" {
+		headers["x-amz-security-token"] = credentials.SessionToken
+	}
+
+	names := make([]string, 0, len(headers))
+	for name, value := range headers {
+		names = append(names, name)
+		if name != "host" {
+			req.Header.Set(name, value)
+		}
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodPost,
+		"/",
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := now.Format(awsScopeFormat) + "/" + c.region + "/" + c.service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		awsAlgorithm,
+		now.Format(awsDateFormat),
+		scope,
+		awsSHA256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := awsHMACSHA256([]byte("AWS4"+credentials.SecretAccessKey), now.Format(awsScopeFormat))
+	signingKey = awsHMACSHA256(signingKey, c.region)
+	signingKey = awsHMACSHA256(signingKey, c.service)
+	signingKey = awsHMACSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(awsHMACSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsAlgorithm, credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// resolveCredentials returns the credentials to sign requests with
+func (c *awsClient) resolveCredentials(ctx context.Context) (*awsCredentials, error) {
+	if c.config.AccessKeyID != "" {
+		return &awsCredentials{
+			AccessKeyID:     c.config.AccessKeyID,
+			SecretAccessKey: c.config.SecretAccessKey,
+			SessionToken:    c.config.SessionToken,
+		}, nil
+	}
+	if id := os.Getenv("AWS_ACCESS_KEY_ID"); id != "" {
+		return &awsCredentials{
+			AccessKeyID:     id,
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	}
+
+	uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI")
+	if uri == "" {
+		return nil, fmt.Errorf("no aws credentials: set secrets.aws.access_key_id, AWS_ACCESS_KEY_ID, or run with an ECS task role")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.credentials != nil && time.Until(c.credentials.Expiration) > time.Minute {
+		return c.credentials, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, awsContainerCredentialsHost+uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credentials request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ecs task role credentials: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get ecs task role credentials: status %d", resp.StatusCode)
+	}
+
+	var credentials awsCredentials
+	if err := json.NewDecoder(resp.Body).Decode(&credentials); err != nil {
+		return nil, fmt.Errorf("failed to decode ecs task role credentials: %w", err)
+	}
+	c.credentials = &credentials
+	return c.credentials, nil
+}
+
+// SecretsManagerProvider resolves references to secrets in AWS Secrets
+// Manager: "awssm:<secret-id>" is the secret string, and
+// "awssm:<secret-id>#<key>" the value of key in a secret string holding a
+// JSON object, as RDS credentials do
+type SecretsManagerProvider struct {
+	client *awsClient
+
+	// secrets are the secret strings read, as settings often take
+	// different keys of the same secret
+	mu      sync.Mutex
+	secrets map[string]string
+}
+
+// NewSecretsManagerProvider creates a new AWS Secrets Manager provider
+func NewSecretsManagerProvider(cfg AWSSecretsConfig) *SecretsManagerProvider {
+	return &SecretsManagerProvider{
+		client:  newAWSClient("secretsmanager", cfg),
+		secrets: make(map[string]string),
+	}
+}
+
+// Prefix returns the prefix of Secrets Manager references
+func (p *SecretsManagerProvider) Prefix() string {
+	return "awssm:"
+}
+
+// Secret returns a secret from Secrets Manager
+func (p *SecretsManagerProvider) Secret(ctx context.Context, name string) (string, error) {
+	id, key, hasKey := strings.Cut(name, "#")
+
+	secret, err := p.secretString(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if !hasKey {
+		return secret, nil
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &values); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object", id)
+	}
+	value, ok := values[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %s", id, key)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// secretString returns the secret string of a secret
+func (p *SecretsManagerProvider) secretString(ctx context.Context, id string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if secret, ok := p.secrets[id]; ok {
+		return secret, nil
+	}
+
+	var output struct {
+		SecretString string `json:"SecretString"`
+	}
+	err := p.client.call(ctx, "secretsmanager.GetSecretValue", map[string]string{"SecretId": id}, &output)
+	if err != nil {
+		return "", err
+	}
+
+	p.secrets[id] = output.SecretString
+	return output.SecretString, nil
+}
+
+// ParameterStoreProvider resolves references to parameters in AWS Systems
+// Manager Parameter Store: "ssm:<name>" is the value of the parameter,
+// decrypted when it is a SecureString
+type ParameterStoreProvider struct {
+	client *awsClient
+}
+
+// NewParameterStoreProvider creates a new AWS Parameter Store provider
+func NewParameterStoreProvider(cfg AWSSecretsConfig) *ParameterStoreProvider {
+	return &ParameterStoreProvider{client: newAWSClient("ssm", cfg)}
+}
+
+// Prefix returns the prefix of Parameter Store references
+func (p *ParameterStoreProvider) Prefix() string {
+	return "ssm:"
+}
+
+// Secret returns a parameter from Parameter Store
+func (p *ParameterStoreProvider) Secret(ctx context.Context, name string) (string, error) {
+	var output struct {
+		Parameter struct {
+			Value string `json:"Value"`
+		} `json:"Parameter"`
+	}
+	input := map[string]interface{}{"Name": name, "WithDecryption": true}
+	if err := p.client.call(ctx, "AmazonSSM.GetParameter", input, &output); err != nil {
+		return "", err
+	}
+	return output.Parameter.Value, nil
+}
+
+func awsSHA256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func awsHMACSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
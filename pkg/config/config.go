@@ -1,7 +1,12 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -22,6 +27,15 @@ type Config struct {
 	Metrics     MetricsConfig `mapstructure:"metrics"`
 	Tracing     TracingConfig `mapstructure:"tracing"`
 	Vault       VaultConfig   `mapstructure:"vault"`
+	Storage     StorageConfig `mapstructure:"storage"`
+	Tenancy     TenancyConfig `mapstructure:"tenancy"`
+	Seed        SeedConfig    `mapstructure:"seed"`
+	Secrets     SecretsConfig `mapstructure:"secrets"`
+	Remote      RemoteConfig  `mapstructure:"remote"`
+	Services    ServicesConfig `mapstructure:"services"`
+
+	// modules are the modules the configuration was loaded with
+	modules map[Module]bool
 }
 
 // ServerConfig holds server configuration
@@ -32,6 +46,47 @@ type ServerConfig struct {
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
 	IdleTimeout  time.Duration `mapstructure:"idle_timeout"`
 	TLS          TLSConfig     `mapstructure:"tls"`
+	// ShutdownTimeout is how long each part of the service, such as its HTTP
+	// server or consumers, gets to stop once signalled, finishing the
+	// requests and messages in flight
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	Debug           DebugConfig   `mapstructure:"debug"`
+	// TrustedProxies are the IP addresses and CIDR ranges of the proxies in
+	// front of the service, the API gateway's. The client IP is only taken
+	// from the X-Forwarded-For header of requests coming from them; with
+	// none, it is the address of the connection. They default to
+	// DefaultTrustedProxies, the private networks the gateway reaches
+	// services on; services clients reach directly set them to [].
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+}
+
+// DefaultTrustedProxies are the proxies services trust by default: those
+// of the private networks, and of the host, the API gateway reaches them
+// from. Behind it, every request comes from the gateway, so a limit by
+// client IP address would otherwise limit every client together.
+var DefaultTrustedProxies = []string{
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"127.0.0.0/8",
+	"fc00::/7",
+	"::1/128",
+}
+
+// DebugConfig holds the configuration of the runtime diagnostics, pprof
+// profiles, goroutine dumps and build info. They are served under /debug
+// of the service's port, to admins only, unless Port is set.
+type DebugConfig struct {
+	// Host and Port serve the diagnostics on a port of their own instead,
+	// without authentication, so Host must only be reachable by operators,
+	// e.g. 127.0.0.1 with kubectl port-forward
+	Host string `mapstructure:"host"`
+	Port int    `mapstructure:"port"`
+	// MutexProfileFraction and BlockProfileRate turn the mutex and block
+	// profiles on, see runtime.SetMutexProfileFraction and
+	// runtime.SetBlockProfileRate; they are off at 0
+	MutexProfileFraction int `mapstructure:"mutex_profile_fraction"`
+	BlockProfileRate     int `mapstructure:"block_profile_rate"`
 }
 
 // TLSConfig holds TLS configuration
@@ -53,12 +108,72 @@ type DatabaseConfig struct {
 	MaxIdleConns int           `mapstructure:"max_idle_conns"`
 	MaxLifetime  time.Duration `mapstructure:"max_lifetime"`
 	MaxIdleTime  time.Duration `mapstructure:"max_idle_time"`
+	// Queries taking SlowQueryThreshold or longer are logged
+	SlowQueryThreshold time.Duration `mapstructure:"slow_query_threshold"`
+	// Retry is the retry policy of operations run with DB.Retry on
+	// serialization failures, deadlocks and lost connections
+	Retry RetryPolicyConfig `mapstructure:"retry"`
+	// Migrations are read from MigrationsPath when it is set, e.g. while
+	// writing them, and from those built into the binary otherwise
+	MigrationsPath string `mapstructure:"migrations_path"`
+	// MigrationsTable in MigrationsSchema records the applied migrations,
+	// by default schema_migrations in the current schema. Services sharing
+	// a database but not their migrations need tables of their own.
+	MigrationsTable  string `mapstructure:"migrations_table"`
+	MigrationsSchema string `mapstructure:"migrations_schema"`
+	// SkipMigrations leaves migrating to the migrate command rather than
+	// every replica migrating when it starts
+	SkipMigrations bool `mapstructure:"skip_migrations"`
+	// SearchPath is the schema search path of connections, by default that
+	// of the database user, e.g. "test_1, public" to keep tests apart
+	SearchPath string `mapstructure:"search_path"`
+	// Vault issues short-lived credentials to log in with instead of User
+	// and Password when enabled
+	Vault DatabaseVaultConfig `mapstructure:"vault"`
+	// TenantPools bounds the connection pools opened for tenants
+	TenantPools TenantPoolsConfig `mapstructure:"tenant_pools"`
+}
+
+// TenantPoolsConfig bounds the connection pools of tenants. At most MaxPools
+// are kept open, the least recently used closed to open another, each of at
+// most MaxOpenConns connections, MaxIdleConns of them kept idle.
+type TenantPoolsConfig struct {
+	MaxPools     int `mapstructure:"max_pools"`
+	MaxOpenConns int `mapstructure:"max_open_conns"`
+	MaxIdleConns int `mapstructure:"max_idle_conns"`
 }
 
-// DSN returns the database connection string
+// DatabaseVaultConfig holds settings for fetching database credentials
+// from the database secrets engine mounted at Mount in Vault. Credentials
+// of Role are replaced RenewBefore their lease expires, or halfway through
+// shorter leases. Address, Token and Namespace left out are taken from the
+// vault section.
+type DatabaseVaultConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	Address     string        `mapstructure:"address"`
+	Token       string        `mapstructure:"token"`
+	Namespace   string        `mapstructure:"namespace"`
+	Mount       string        `mapstructure:"mount"`
+	Role        string        `mapstructure:"role"`
+	RenewBefore time.Duration `mapstructure:"renew_before"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+}
+
+// DSN returns the database connection string. Values are quoted so empty
+// ones, e.g. a blank password, don't swallow the next setting.
 func (d DatabaseConfig) DSN() string {
-	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		d.Host, d.Port, d.User, d.Password, d.Database, d.SSLMode)
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quoteDSNValue(d.Host), d.Port, quoteDSNValue(d.User), quoteDSNValue(d.Password),
+		quoteDSNValue(d.Database), quoteDSNValue(d.SSLMode))
+	if d.SearchPath != "" {
+		dsn += " search_path=" + quoteDSNValue(d.SearchPath)
+	}
+	return dsn
+}
+
+// quoteDSNValue quotes a connection string value
+func quoteDSNValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
 }
 
 // RedisConfig holds Redis configuration
@@ -87,17 +202,87 @@ type KafkaConfig struct {
 	ConsumerGroup string        `mapstructure:"consumer_group"`
 	BatchSize     int           `mapstructure:"batch_size"`
 	BatchTimeout  time.Duration `mapstructure:"batch_timeout"`
-	RetryMax      int           `mapstructure:"retry_max"`
-	Topics        TopicsConfig  `mapstructure:"topics"`
+	// Failed writes, and consumed messages that fail, are retried up to
+	// RetryMax times after a backoff growing from RetryBackoffMin to
+	// RetryBackoffMax
+	RetryMax        int           `mapstructure:"retry_max"`
+	RetryBackoffMin time.Duration `mapstructure:"retry_backoff_min"`
+	RetryBackoffMax time.Duration `mapstructure:"retry_backoff_max"`
+	// Consumed messages that keep failing are moved to the topic named
+	// after theirs with DeadLetterSuffix appended
+	DeadLetterSuffix string               `mapstructure:"dead_letter_suffix"`
+	SchemaRegistry   SchemaRegistryConfig `mapstructure:"schema_registry"`
+	Inbox            InboxConfig          `mapstructure:"inbox"`
+	CDC              CDCConfig            `mapstructure:"cdc"`
+	Topics           TopicsConfig         `mapstructure:"topics"`
+}
+
+// CDCConfig holds the configuration of the change events Debezium captures
+// from PostgreSQL. The changes of a table are published to the topic named
+// TopicPrefix.<schema>.<table>.
+type CDCConfig struct {
+	TopicPrefix string `mapstructure:"topic_prefix"`
+}
+
+// Topic returns the topic the changes of a schema-qualified table, e.g.
+// public.orders, are published to
+func (c CDCConfig) Topic(table string) string {
+	return c.TopicPrefix + "." + table
+}
+
+// RetryPolicyConfig holds the retry policy of a message handler. A failed
+// message is attempted MaxAttempts times in place with a backoff doubling
+// from BackoffMin to BackoffMax, of which the Jitter fraction is randomized.
+// A message that still fails is parked for each of DelayTiers in turn and
+// attempted again before it is dead-lettered. Settings that aren't
+// configured are taken from the retry settings of the broker.
+type RetryPolicyConfig struct {
+	MaxAttempts int             `mapstructure:"max_attempts"`
+	BackoffMin  time.Duration   `mapstructure:"backoff_min"`
+	BackoffMax  time.Duration   `mapstructure:"backoff_max"`
+	Jitter      float64         `mapstructure:"jitter"`
+	DelayTiers  []time.Duration `mapstructure:"delay_tiers"`
+}
+
+// WorkerPoolConfig holds the settings of a worker pool: Size tasks run at
+// once, each cancelled after TaskTimeout
+type WorkerPoolConfig struct {
+	Size        int           `mapstructure:"size"`
+	TaskTimeout time.Duration `mapstructure:"task_timeout"`
+}
+
+// InboxConfig holds the configuration of consumer inboxes, which record the
+// events consumers handled to skip events delivered again. Events are
+// remembered for TTL; an event whose handling stopped half-way is handled
+// again once its Lease ran out.
+type InboxConfig struct {
+	TTL           time.Duration `mapstructure:"ttl"`
+	Lease         time.Duration `mapstructure:"lease"`
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
+}
+
+// SchemaRegistryConfig holds the configuration of the schema registry event
+// payload schemas are registered with. Without a URL, payloads are still
+// validated against the schemas services are built with, but the schemas
+// aren't registered or checked for compatibility.
+type SchemaRegistryConfig struct {
+	URL      string        `mapstructure:"url"`
+	Username string        `mapstructure:"username"`
+	Password string        `mapstructure:"password"`
+	Timeout  time.Duration `mapstructure:"timeout"`
 }
 
 // TopicsConfig holds Kafka topics configuration
 type TopicsConfig struct {
-	UserEvents      string `mapstructure:"user_events"`
-	ProductEvents   string `mapstructure:"product_events"`
-	OrderEvents     string `mapstructure:"order_events"`
-	PaymentEvents   string `mapstructure:"payment_events"`
-	InventoryEvents string `mapstructure:"inventory_events"`
+	UserEvents         string `mapstructure:"user_events"`
+	ProductEvents      string `mapstructure:"product_events"`
+	OrderEvents        string `mapstructure:"order_events"`
+	PaymentEvents      string `mapstructure:"payment_events"`
+	InventoryEvents    string `mapstructure:"inventory_events"`
+	ShippingEvents     string `mapstructure:"shipping_events"`
+	ReviewEvents       string `mapstructure:"review_events"`
+	SubscriptionEvents string `mapstructure:"subscription_events"`
+	ClickstreamEvents  string `mapstructure:"clickstream_events"`
 }
 
 // RabbitMQConfig holds RabbitMQ configuration
@@ -107,7 +292,20 @@ type RabbitMQConfig struct {
 	ExchangeType string        `mapstructure:"exchange_type"`
 	RetryMax     int           `mapstructure:"retry_max"`
 	RetryDelay   time.Duration `mapstructure:"retry_delay"`
-	Queues       QueuesConfig  `mapstructure:"queues"`
+	// MaxPriority is the highest message priority queues honour. Queues
+	// must be deleted and declared again for a change to take effect.
+	MaxPriority uint8                   `mapstructure:"max_priority"`
+	Scheduler   RabbitMQSchedulerConfig `mapstructure:"scheduler"`
+	Queues      QueuesConfig            `mapstructure:"queues"`
+}
+
+// RabbitMQSchedulerConfig holds settings for delayed messages, which are
+// held in the Redis sorted set Key until due. Due messages are published
+// every PollInterval, BatchSize at a time.
+type RabbitMQSchedulerConfig struct {
+	Key          string        `mapstructure:"key"`
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	BatchSize    int           `mapstructure:"batch_size"`
 }
 
 // QueuesConfig holds RabbitMQ queues configuration
@@ -144,22 +342,141 @@ type OAuth2Config struct {
 
 // LoggerConfig holds logger configuration
 type LoggerConfig struct {
-	Level      string `mapstructure:"level"`
-	Format     string `mapstructure:"format"`
+	Level      string             `mapstructure:"level"`
+	Format     string             `mapstructure:"format"`
+	Output     string             `mapstructure:"output"`
+	Filename   string             `mapstructure:"filename"`
+	MaxSize    int                `mapstructure:"max_size"`
+	MaxBackups int                `mapstructure:"max_backups"`
+	MaxAge     int                `mapstructure:"max_age"`
+	Compress   bool               `mapstructure:"compress"`
+	Sampling   LogSamplingConfig  `mapstructure:"sampling"`
+	RateLimit  LogRateLimitConfig `mapstructure:"rate_limit"`
+	Redaction  LogRedactionConfig `mapstructure:"redaction"`
+	Export     LogExportConfig    `mapstructure:"export"`
+	Audit      AuditLogConfig     `mapstructure:"audit"`
+	// ErrorReporting sends error lines and panics to Sentry
+	ErrorReporting ErrorReportingConfig `mapstructure:"error_reporting"`
+}
+
+// ErrorReportingConfig holds settings for sending error lines and panics,
+// with their stack traces, to Sentry or a backend compatible with it, such
+// as GlitchTip; reporting is off when DSN is empty. Environment and Release
+// tag the events, defaulting to the environment and version of the
+// service. Up to BufferSize events wait to be sent, and further ones are
+// dropped while the backend is unreachable.
+type ErrorReportingConfig struct {
+	DSN         string        `mapstructure:"dsn"`
+	Environment string        `mapstructure:"environment"`
+	Release     string        `mapstructure:"release"`
+	BufferSize  int           `mapstructure:"buffer_size"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+}
+
+// AuditLogConfig holds settings for the audit log, where security-relevant
+// events are written apart from the application's lines. Output is stdout,
+// stderr or file, rotated like the application's log file. Each event
+// carries the hash of the one before it, an HMAC when SigningKey is set so
+// the chain can't be rebuilt by whoever edits the file.
+type AuditLogConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
 	Output     string `mapstructure:"output"`
 	Filename   string `mapstructure:"filename"`
 	MaxSize    int    `mapstructure:"max_size"`
 	MaxBackups int    `mapstructure:"max_backups"`
 	MaxAge     int    `mapstructure:"max_age"`
-	Compress   bool   `mapstructure:"compress"`
+	SigningKey string `mapstructure:"signing_key"`
+}
+
+// LogExportConfig holds settings for shipping log lines, besides writing
+// them, to an OTLP collector or Loki; shipping is off when Provider is
+// empty. Endpoint is the OTLP/HTTP address of the collector, e.g.
+// http://otel-collector:4318, or the address of Loki, e.g.
+// http://loki:3100. Lines are sent BatchSize at a time, at least every
+// FlushInterval; up to BufferSize lines wait to be sent, and further ones
+// are dropped while the backend is unreachable.
+type LogExportConfig struct {
+	Provider      string            `mapstructure:"provider"`
+	Endpoint      string            `mapstructure:"endpoint"`
+	Headers       map[string]string `mapstructure:"headers"`
+	BatchSize     int               `mapstructure:"batch_size"`
+	FlushInterval time.Duration     `mapstructure:"flush_interval"`
+	BufferSize    int               `mapstructure:"buffer_size"`
+	Timeout       time.Duration     `mapstructure:"timeout"`
+	Retry         RetryPolicyConfig `mapstructure:"retry"`
+}
+
+// LogSamplingConfig holds settings for sampling repeated log lines: of the
+// lines with the same level and message logged within Tick, the first
+// Initial are kept, then one in every Thereafter of their level. Levels
+// without a Thereafter aren't sampled.
+type LogSamplingConfig struct {
+	Tick       time.Duration  `mapstructure:"tick"`
+	Initial    int            `mapstructure:"initial"`
+	Thereafter map[string]int `mapstructure:"thereafter"`
+}
+
+// LogRedactionConfig holds settings for masking personal data in log
+// lines: fields named one of Fields, or ending in one, e.g. user_email,
+// are masked outright, and emails and card numbers are masked wherever
+// they appear in messages, strings and errors
+type LogRedactionConfig struct {
+	Fields []string `mapstructure:"fields"`
 }
 
-// MetricsConfig holds metrics configuration
+// LogRateLimitConfig caps the lines logged each second below error level,
+// so a noisy loop can't flood the logging pipeline. Lines over PerSecond
+// are dropped and reported as a count once the second is over; 0 leaves
+// lines uncapped.
+type LogRateLimitConfig struct {
+	PerSecond int `mapstructure:"per_second"`
+}
+
+// MetricsConfig holds metrics configuration. Provider is prometheus, which
+// scrapes metrics from Path, or otlp, which pushes them every Interval to
+// the OTLP/HTTP collector at Endpoint, e.g. http://otel-collector:4318.
+// Batch jobs push their metrics to the Pushgateway every Interval too.
 type MetricsConfig struct {
-	Enabled   bool   `mapstructure:"enabled"`
-	Path      string `mapstructure:"path"`
-	Namespace string `mapstructure:"namespace"`
-	Subsystem string `mapstructure:"subsystem"`
+	Enabled     bool              `mapstructure:"enabled"`
+	Provider    string            `mapstructure:"provider"`
+	Path        string            `mapstructure:"path"`
+	Namespace   string            `mapstructure:"namespace"`
+	Subsystem   string            `mapstructure:"subsystem"`
+	Endpoint    string            `mapstructure:"endpoint"`
+	Headers     map[string]string `mapstructure:"headers"`
+	Interval    time.Duration     `mapstructure:"interval"`
+	Pushgateway PushgatewayConfig `mapstructure:"pushgateway"`
+	// RuntimeInterval is how often the goroutine, memory and CPU gauges
+	// are updated
+	RuntimeInterval time.Duration `mapstructure:"runtime_interval"`
+	// MaxLabelValues is how many values each label of a metric takes, the
+	// values beyond it being recorded as overflow; negative leaves labels
+	// unlimited
+	MaxLabelValues int       `mapstructure:"max_label_values"`
+	SLO            SLOConfig `mapstructure:"slo"`
+}
+
+// SLOConfig holds the service level objectives every route of a service is
+// measured against: the share of requests that must not fail with a 5xx,
+// and the share that must be served within LatencyThreshold. Burn rates,
+// how fast each route spends its error budget, are computed over Windows,
+// the longest of which the remaining budget is reported for.
+type SLOConfig struct {
+	Enabled          bool            `mapstructure:"enabled"`
+	Availability     float64         `mapstructure:"availability"`
+	Latency          float64         `mapstructure:"latency"`
+	LatencyThreshold time.Duration   `mapstructure:"latency_threshold"`
+	Windows          []time.Duration `mapstructure:"windows"`
+}
+
+// PushgatewayConfig holds settings for pushing the metrics of batch jobs,
+// which may end before Prometheus scrapes them, to the Pushgateway at URL,
+// e.g. http://pushgateway:9091; jobs don't push metrics when URL is empty.
+// Their metrics are grouped by Instance, the hostname by default.
+type PushgatewayConfig struct {
+	URL      string        `mapstructure:"url"`
+	Instance string        `mapstructure:"instance"`
+	Timeout  time.Duration `mapstructure:"timeout"`
 }
 
 // TracingConfig holds tracing configuration
@@ -167,7 +484,37 @@ type TracingConfig struct {
 	Enabled     bool    `mapstructure:"enabled"`
 	ServiceName string  `mapstructure:"service_name"`
 	Endpoint    string  `mapstructure:"endpoint"`
-	SampleRate  float64 `mapstructure:"sample_rate"`
+	// SampleRate is the share of traces sampled when they start, by
+	// requests to routes without a rate of their own
+	SampleRate float64 `mapstructure:"sample_rate"`
+	// Routes sample the traces started by requests to them at rates of
+	// their own, the first matching a request applying
+	Routes       []RouteSamplingConfig `mapstructure:"routes"`
+	TailSampling TailSamplingConfig    `mapstructure:"tail_sampling"`
+}
+
+// RouteSamplingConfig holds the sample rate of the traces started by
+// requests to a route
+type RouteSamplingConfig struct {
+	// Route is a route as registered, e.g. /api/v1/orders/:id, or a prefix
+	// of routes ending with *, e.g. /api/v1/payments*
+	Route string `mapstructure:"route"`
+	// Method limits the rate to requests with a method, e.g. POST; it
+	// applies to all when empty
+	Method     string  `mapstructure:"method"`
+	SampleRate float64 `mapstructure:"sample_rate"`
+}
+
+// TailSamplingConfig holds tail-sampling configuration. When enabled,
+// every trace is sampled when it starts and exported to a collector that
+// decides which to keep once they end; spans carry hints for its policies:
+// the sample rate of their route, and a priority of 1 when they failed,
+// were slow or are of a route always sampled.
+type TailSamplingConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// LatencyThreshold is how slow a request must be for its trace to be
+	// marked as one to keep
+	LatencyThreshold time.Duration `mapstructure:"latency_threshold"`
 }
 
 // VaultConfig holds Vault configuration
@@ -180,35 +527,701 @@ type VaultConfig struct {
 	SecretPath string `mapstructure:"secret_path"`
 }
 
-// Load loads configuration from file and environment variables
-func Load() (*Config, error) {
+// StorageConfig holds object storage configuration
+type StorageConfig struct {
+	// Driver is "s3" for S3-compatible storage, or "local" to keep files on disk
+	Driver string             `mapstructure:"driver"`
+	S3     S3StorageConfig    `mapstructure:"s3"`
+	Local  LocalStorageConfig `mapstructure:"local"`
+}
+
+// S3StorageConfig holds settings for S3 or an S3-compatible service such as MinIO
+type S3StorageConfig struct {
+	// Endpoint defaults to AWS; set it for other providers
+	Endpoint        string        `mapstructure:"endpoint"`
+	Region          string        `mapstructure:"region"`
+	Bucket          string        `mapstructure:"bucket"`
+	AccessKeyID     string        `mapstructure:"access_key_id"`
+	SecretAccessKey string        `mapstructure:"secret_access_key"`
+	UsePathStyle    bool          `mapstructure:"use_path_style"`
+	Timeout         time.Duration `mapstructure:"timeout"`
+}
+
+// LocalStorageConfig holds settings for storing files on disk. Files are
+// downloaded from BaseURL with links signed by SigningKey.
+type LocalStorageConfig struct {
+	Path       string `mapstructure:"path"`
+	BaseURL    string `mapstructure:"base_url"`
+	SigningKey string `mapstructure:"signing_key"`
+}
+
+// TenancyConfig holds multi-tenancy settings. Each tenant's data lives in a
+// schema of its own. Requests name their tenant in the Header, or with a
+// subdomain of BaseDomain, e.g. acme.shop.example.com for BaseDomain
+// shop.example.com; Required rejects requests that don't. Requests of
+// signed-in users are made for the tenant of their access token.
+type TenancyConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Header     string `mapstructure:"header"`
+	BaseDomain string `mapstructure:"base_domain"`
+	Required   bool   `mapstructure:"required"`
+}
+
+// SeedConfig holds settings for seeding the database. OnStartup seeds the
+// database with the sets of its environment when the user service starts
+// in development; elsewhere the seed command is run instead.
+type SeedConfig struct {
+	OnStartup     bool   `mapstructure:"on_startup"`
+	AdminUsername string `mapstructure:"admin_username"`
+	AdminEmail    string `mapstructure:"admin_email"`
+	AdminPassword string `mapstructure:"admin_password"`
+}
+
+// SecretsConfig holds settings for resolving references to secrets kept
+// outside the configuration, see SecretsProvider
+type SecretsConfig struct {
+	AWS AWSSecretsConfig `mapstructure:"aws"`
+	Age AgeSecretsConfig `mapstructure:"age"`
+}
+
+// AgeSecretsConfig holds the age identity encrypted settings are decrypted
+// with: the keys of Identity, or of the file at IdentityFile
+type AgeSecretsConfig struct {
+	Identity     string `mapstructure:"identity"`
+	IdentityFile string `mapstructure:"identity_file"`
+}
+
+// AWSSecretsConfig holds settings for reading secrets from AWS Secrets
+// Manager and Parameter Store. Region defaults to AWS_REGION; credentials
+// left out are taken from the environment or the ECS task role.
+type AWSSecretsConfig struct {
+	Region          string        `mapstructure:"region"`
+	AccessKeyID     string        `mapstructure:"access_key_id"`
+	SecretAccessKey string        `mapstructure:"secret_access_key"`
+	SessionToken    string        `mapstructure:"session_token"`
+	Timeout         time.Duration `mapstructure:"timeout"`
+}
+
+// RemoteConfig holds settings for reading configuration from a key-value
+// store shared by all replicas, Consul or etcd. The YAML document at Key
+// is merged over the config files, and services watching it apply its
+// changes without restarting, see WatchRemote.
+type RemoteConfig struct {
+	// Provider is consul or etcd; remote configuration is off when empty
+	Provider string `mapstructure:"provider"`
+	// Endpoint is the HTTP address of the Consul agent or etcd gateway
+	Endpoint string `mapstructure:"endpoint"`
+	Key      string `mapstructure:"key"`
+	// Token is the Consul ACL token or etcd auth token
+	Token    string        `mapstructure:"token"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+	// WatchWait is how long a watch waits for a change before starting over
+	WatchWait time.Duration `mapstructure:"watch_wait"`
+}
+
+// ServicesConfig holds the addresses of internal services called over HTTP
+type ServicesConfig struct {
+	UserURL         string        `mapstructure:"user_url"`
+	PaymentURL      string        `mapstructure:"payment_url"`
+	InventoryURL    string        `mapstructure:"inventory_url"`
+	ShippingURL     string        `mapstructure:"shipping_url"`
+	ReviewURL       string        `mapstructure:"review_url"`
+	NotificationURL string        `mapstructure:"notification_url"`
+	CurrencyURL     string        `mapstructure:"currency_url"`
+	PricingURL      string        `mapstructure:"pricing_url"`
+	OrderURL        string        `mapstructure:"order_url"`
+	SubscriptionURL string        `mapstructure:"subscription_url"`
+	SellerURL       string        `mapstructure:"seller_url"`
+	AnalyticsURL    string        `mapstructure:"analytics_url"`
+	StockAlertURL   string        `mapstructure:"stock_alert_url"`
+	Timeout         time.Duration `mapstructure:"timeout"`
+	// Resilience holds how calls to services, carriers and payment
+	// providers are retried and cut off while they fail
+	Resilience ResilienceConfig `mapstructure:"resilience"`
+	// TaskQueue holds how services run the tasks they defer to the
+	// background through Redis
+	TaskQueue TaskQueueConfig `mapstructure:"task_queue"`
+
+	Gateway      APIGatewayConfig          `mapstructure:"api_gateway"`
+	User         UserServiceConfig         `mapstructure:"user_service"`
+	Order        OrderServiceConfig        `mapstructure:"order_service"`
+	Payment      PaymentServiceConfig      `mapstructure:"payment_service"`
+	Shipping     ShippingServiceConfig     `mapstructure:"shipping_service"`
+	Review       ReviewServiceConfig       `mapstructure:"review_service"`
+	Notification NotificationServiceConfig `mapstructure:"notification_service"`
+	Currency     CurrencyServiceConfig     `mapstructure:"currency_service"`
+	Pricing      PricingServiceConfig      `mapstructure:"pricing_service"`
+	Subscription SubscriptionServiceConfig `mapstructure:"subscription_service"`
+	Seller       SellerServiceConfig       `mapstructure:"seller_service"`
+	Analytics    AnalyticsServiceConfig    `mapstructure:"analytics_service"`
+	StockAlert   StockAlertServiceConfig   `mapstructure:"stock_alert_service"`
+}
+
+// ResilienceConfig holds the policy outbound calls are made with, and
+// those of dependencies, by name, whose settings override it
+type ResilienceConfig struct {
+	Default      ResiliencePolicyConfig            `mapstructure:"default"`
+	Dependencies map[string]ResiliencePolicyConfig `mapstructure:"dependencies"`
+}
+
+// ResiliencePolicyConfig holds how calls to a dependency are made. Each
+// attempt is bounded by Timeout. Calls that fail are attempted up to
+// MaxAttempts times with a backoff doubling from BackoffMin to BackoffMax,
+// of which the Jitter fraction is randomized, as long as retries stay
+// under RetryBudget, the fraction of calls that may be retried. After
+// FailureThreshold failures in a row the circuit opens: calls fail at once
+// for OpenTimeout, then one is let through to probe the dependency.
+type ResiliencePolicyConfig struct {
+	Timeout          time.Duration `mapstructure:"timeout"`
+	MaxAttempts      int           `mapstructure:"max_attempts"`
+	BackoffMin       time.Duration `mapstructure:"backoff_min"`
+	BackoffMax       time.Duration `mapstructure:"backoff_max"`
+	Jitter           float64       `mapstructure:"jitter"`
+	RetryBudget      float64       `mapstructure:"retry_budget"`
+	FailureThreshold int           `mapstructure:"failure_threshold"`
+	OpenTimeout      time.Duration `mapstructure:"open_timeout"`
+}
+
+// Policy returns the policy of the dependency named name: its settings,
+// and those of the default policy it doesn't configure. Attempts are
+// bounded by timeout when neither configures a timeout.
+func (r ResilienceConfig) Policy(name string, timeout time.Duration) ResiliencePolicyConfig {
+	policy := r.Dependencies[name]
+	if policy.Timeout == 0 {
+		policy.Timeout = r.Default.Timeout
+	}
+	if policy.Timeout == 0 {
+		policy.Timeout = timeout
+	}
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = r.Default.MaxAttempts
+	}
+	if policy.BackoffMin == 0 {
+		policy.BackoffMin = r.Default.BackoffMin
+	}
+	if policy.BackoffMax == 0 {
+		policy.BackoffMax = r.Default.BackoffMax
+	}
+	if policy.Jitter == 0 {
+		policy.Jitter = r.Default.Jitter
+	}
+	if policy.RetryBudget == 0 {
+		policy.RetryBudget = r.Default.RetryBudget
+	}
+	if policy.FailureThreshold == 0 {
+		policy.FailureThreshold = r.Default.FailureThreshold
+	}
+	if policy.OpenTimeout == 0 {
+		policy.OpenTimeout = r.Default.OpenTimeout
+	}
+	return policy
+}
+
+// TaskQueueConfig holds the settings of task queues. Concurrency tasks run
+// at once on each replica. Tasks that fail are retried with backoff up to
+// MaxRetry times, then kept as failed for inspection, and cancelled after
+// Timeout unless their type says otherwise. Tasks that succeeded are kept
+// for Retention.
+type TaskQueueConfig struct {
+	Concurrency int           `mapstructure:"concurrency"`
+	MaxRetry    int           `mapstructure:"max_retry"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+	Retention   time.Duration `mapstructure:"retention"`
+}
+
+// APIGatewayConfig holds API gateway configuration
+type APIGatewayConfig struct {
+	Clickstream ClickstreamConfig `mapstructure:"clickstream"`
+	DeadLetters DeadLetterConfig  `mapstructure:"dead_letters"`
+	// RateLimit limits the requests of each client, by IP address
+	RateLimit RateLimitConfig `mapstructure:"rate_limiting"`
+	// TrustedProxies are the IP addresses and CIDR ranges of the load
+	// balancers in front of the gateway, whose X-Forwarded-For header the
+	// client IP is taken from. Clients reach the gateway directly by
+	// default, so none are trusted.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+}
+
+// RateLimitConfig limits how many requests each client makes. The
+// sliding_window algorithm allows Limit requests in any Window; the
+// token_bucket one allows Limit requests per Window on average, in bursts of
+// up to Burst. Limits kept in memory are per instance, those kept in Redis
+// are shared by every instance.
+type RateLimitConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	Algorithm string        `mapstructure:"algorithm"`
+	Backend   string        `mapstructure:"backend"`
+	Limit     int           `mapstructure:"limit"`
+	Window    time.Duration `mapstructure:"window"`
+	// Burst defaults to Limit
+	Burst int `mapstructure:"burst"`
+}
+
+// DeadLetterConfig holds settings for the admin API inspecting and redriving
+// the messages consumer groups parked in dead-letter topics
+type DeadLetterConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// MaxListLimit bounds how many messages are listed at once
+	MaxListLimit int `mapstructure:"max_list_limit"`
+}
+
+// ClickstreamConfig holds settings for ingesting storefront events at the
+// gateway. Events are buffered and published to Kafka in batches; once
+// BufferSize events are waiting, new ones are refused until Kafka catches up.
+type ClickstreamConfig struct {
+	Enabled             bool          `mapstructure:"enabled"`
+	BufferSize          int           `mapstructure:"buffer_size"`
+	BatchSize           int           `mapstructure:"batch_size"`
+	FlushInterval       time.Duration `mapstructure:"flush_interval"`
+	MaxEventsPerRequest int           `mapstructure:"max_events_per_request"`
+	// SampleRates maps event types to the fraction of sessions whose events
+	// are kept; types not listed are always kept
+	SampleRates map[string]float64 `mapstructure:"sample_rates"`
+}
+
+// UserServiceConfig holds user service configuration
+type UserServiceConfig struct {
+	// GRPCPort is the port of the internal gRPC API other services fetch user data from
+	GRPCPort int `mapstructure:"grpc_port"`
+	// ProfileCache caches the profiles returned by GetProfile
+	ProfileCache CacheConfig `mapstructure:"profile_cache"`
+	// RateLimit limits the attempts of each client, by IP address or user,
+	// at registering, logging in and changing or resetting passwords
+	RateLimit RateLimitConfig `mapstructure:"rate_limiting"`
+	// Lockout locks accounts out of logging in after failed attempts,
+	// whichever client they come from
+	Lockout LockoutConfig `mapstructure:"lockout"`
+	// TokenCleanupSchedule is the cron expression of when password reset
+	// and email verification tokens that expired or were used are deleted
+	TokenCleanupSchedule string `mapstructure:"token_cleanup_schedule"`
+}
+
+// LockoutConfig holds settings for locking accounts out of logging in.
+// MaxAttempts failed logins, each within Window of the one before, lock an
+// account until Window after the last of them; logging in resets the count.
+type LockoutConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	MaxAttempts int           `mapstructure:"max_attempts"`
+	Window      time.Duration `mapstructure:"window"`
+}
+
+// CacheConfig holds settings for a read-through cache in Redis. Entries
+// expire after TTL; up to LocalSize of them are also kept in process for
+// LocalTTL, which a LocalSize of 0 turns off.
+type CacheConfig struct {
+	TTL       time.Duration `mapstructure:"ttl"`
+	LocalSize int           `mapstructure:"local_size"`
+	LocalTTL  time.Duration `mapstructure:"local_ttl"`
+}
+
+// OrderServiceConfig holds order service configuration
+type OrderServiceConfig struct {
+	Tax          TaxConfig         `mapstructure:"tax"`
+	Invoices     InvoiceConfig     `mapstructure:"invoices"`
+	Saga         SagaConfig        `mapstructure:"saga"`
+	Projection   ProjectionConfig  `mapstructure:"projection"`
+	Reservations ReservationConfig `mapstructure:"reservations"`
+}
+
+// ReservationConfig holds settings of the stock reserved by checkouts.
+// Reservations not purchased within TTL are considered abandoned; every
+// ExpiryInterval they are released in batches of BatchSize. TTL must exceed
+// the longest checkout. Conversion metrics cover the reservations resolved
+// in the last MetricsWindow.
+type ReservationConfig struct {
+	TTL            time.Duration `mapstructure:"ttl"`
+	ExpiryInterval time.Duration `mapstructure:"expiry_interval"`
+	BatchSize      int           `mapstructure:"batch_size"`
+	MetricsWindow  time.Duration `mapstructure:"metrics_window"`
+}
+
+// ProjectionConfig holds settings of the order read model. Rows are updated
+// from order and payment events; every Interval, rows whose events were
+// missed are caught up in batches of BatchSize.
+type ProjectionConfig struct {
+	ConsumerGroup string        `mapstructure:"consumer_group"`
+	Interval      time.Duration `mapstructure:"interval"`
+	BatchSize     int           `mapstructure:"batch_size"`
+	// Retry is the retry policy of the projection handlers
+	Retry RetryPolicyConfig `mapstructure:"retry"`
+}
+
+// SagaConfig holds settings of the checkout saga. A checkout that hasn't
+// moved for Timeout is considered stuck and rolled back by the recovery
+// sweep, which gives up after RetryAttempts failed rollbacks.
+type SagaConfig struct {
+	Timeout          time.Duration `mapstructure:"timeout"`
+	RetryAttempts    int           `mapstructure:"retry_attempts"`
+	RecoveryInterval time.Duration `mapstructure:"recovery_interval"`
+	BatchSize        int           `mapstructure:"batch_size"`
+}
+
+// InvoiceConfig holds invoice generation settings
+type InvoiceConfig struct {
+	// DefaultEntity is the code of the legal entity invoicing orders shipped to
+	// countries no entity lists
+	DefaultEntity string              `mapstructure:"default_entity"`
+	LegalEntities []LegalEntityConfig `mapstructure:"legal_entities"`
+	// URLTTL is how long invoice download links stay valid
+	URLTTL time.Duration `mapstructure:"url_ttl"`
+}
+
+// LegalEntityConfig describes a company issuing invoices. Each entity numbers
+// its invoices in its own sequence.
+type LegalEntityConfig struct {
+	Code         string   `mapstructure:"code"`
+	Name         string   `mapstructure:"name"`
+	AddressLines []string `mapstructure:"address_lines"`
+	TaxID        string   `mapstructure:"tax_id"`
+	// Countries are the shipping countries invoiced by this entity
+	Countries []string `mapstructure:"countries"`
+	// NumberPrefix is prepended to the sequence number, e.g. "DE-" gives DE-000001
+	NumberPrefix string `mapstructure:"number_prefix"`
+	Footer       string `mapstructure:"footer"`
+}
+
+// TaxConfig holds tax calculation settings
+type TaxConfig struct {
+	// Provider is "rules" to use the configured rate table, or "taxjar"
+	Provider string          `mapstructure:"provider"`
+	Rules    []TaxRuleConfig `mapstructure:"rules"`
+	TaxJar   TaxJarConfig    `mapstructure:"taxjar"`
+	Timeout  time.Duration   `mapstructure:"timeout"`
+}
+
+// TaxRuleConfig is the tax rate of a country, or of one of its states when
+// State is set. The most specific rule matching an address applies.
+type TaxRuleConfig struct {
+	Country string `mapstructure:"country"`
+	State   string `mapstructure:"state"`
+	// Rate is a percentage, e.g. 19 or 8.875
+	Rate float64 `mapstructure:"rate"`
+	// Inclusive is set where prices already include the tax (VAT)
+	Inclusive   bool `mapstructure:"inclusive"`
+	TaxShipping bool `mapstructure:"tax_shipping"`
+	// CategoryRates override Rate for product tax codes, e.g. reduced rates for books
+	CategoryRates map[string]float64 `mapstructure:"category_rates"`
+}
+
+// TaxJarConfig holds TaxJar configuration
+type TaxJarConfig struct {
+	APIURL string `mapstructure:"api_url"`
+	APIKey string `mapstructure:"api_key"`
+}
+
+// PaymentServiceConfig holds payment service configuration
+type PaymentServiceConfig struct {
+	DefaultProvider string                 `mapstructure:"default_provider"`
+	Providers       PaymentProvidersConfig `mapstructure:"providers"`
+	Timeout         time.Duration          `mapstructure:"timeout"`
+
+	// MethodProviders maps payment method types (card, paypal) to the provider handling them
+	MethodProviders map[string]string `mapstructure:"method_providers"`
+
+	Webhooks PaymentWebhooksConfig `mapstructure:"webhooks"`
+	Fraud    FraudConfig           `mapstructure:"fraud"`
+}
+
+// FraudConfig holds fraud screening settings for checkout.
+// Assessments scoring at least ReviewThreshold are held for manual review,
+// those scoring at least DeclineThreshold are declined.
+type FraudConfig struct {
+	Enabled          bool `mapstructure:"enabled"`
+	ReviewThreshold  int  `mapstructure:"review_threshold"`
+	DeclineThreshold int  `mapstructure:"decline_threshold"`
+
+	// Velocity limits per user, device and IP address within VelocityWindow
+	VelocityWindow       time.Duration `mapstructure:"velocity_window"`
+	MaxAttempts          int           `mapstructure:"max_attempts"`
+	MaxFailures          int           `mapstructure:"max_failures"`
+	MaxAccountsPerDevice int           `mapstructure:"max_accounts_per_device"`
+	MaxAccountsPerIP     int           `mapstructure:"max_accounts_per_ip"`
+}
+
+// ShippingServiceConfig holds shipping service configuration
+type ShippingServiceConfig struct {
+	// Origin is the warehouse address parcels are shipped from
+	Origin   ShippingOriginConfig   `mapstructure:"origin"`
+	Carriers ShippingCarriersConfig `mapstructure:"carriers"`
+	Tracking ShippingTrackingConfig `mapstructure:"tracking"`
+	// QuoteTTL is how long a rate quoted at checkout stays valid
+	QuoteTTL time.Duration `mapstructure:"quote_ttl"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+}
+
+// ShippingOriginConfig holds the ship-from address
+type ShippingOriginConfig struct {
+	Name         string `mapstructure:"name"`
+	Company      string `mapstructure:"company"`
+	AddressLine1 string `mapstructure:"address_line1"`
+	AddressLine2 string `mapstructure:"address_line2"`
+	City         string `mapstructure:"city"`
+	State        string `mapstructure:"state"`
+	PostalCode   string `mapstructure:"postal_code"`
+	Country      string `mapstructure:"country"`
+	Phone        string `mapstructure:"phone"`
+}
+
+// ShippingTrackingConfig holds settings for polling carriers for tracking updates
+type ShippingTrackingConfig struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	BatchSize    int           `mapstructure:"batch_size"`
+	// RefreshInterval is the minimum time between two polls of the same shipment
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+}
+
+// ShippingCarriersConfig holds the settings of each shipping carrier
+type ShippingCarriersConfig struct {
+	FlatRate FlatRateConfig `mapstructure:"flat_rate"`
+	EasyPost EasyPostConfig `mapstructure:"easypost"`
+}
+
+// FlatRateConfig holds flat-rate shipping prices in minor units of Currency
+type FlatRateConfig struct {
+	Enabled             bool   `mapstructure:"enabled"`
+	Currency            string `mapstructure:"currency"`
+	DomesticAmount      int64  `mapstructure:"domestic_amount"`
+	InternationalAmount int64  `mapstructure:"international_amount"`
+	// PerKgAmount is charged for every started kilogram
+	PerKgAmount       int64 `mapstructure:"per_kg_amount"`
+	DomesticDays      int   `mapstructure:"domestic_days"`
+	InternationalDays int   `mapstructure:"international_days"`
+}
+
+// EasyPostConfig holds EasyPost configuration
+type EasyPostConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	APIURL        string `mapstructure:"api_url"`
+	APIKey        string `mapstructure:"api_key"`
+	WebhookSecret string `mapstructure:"webhook_secret"`
+}
+
+// ReviewServiceConfig holds review service configuration
+type ReviewServiceConfig struct {
+	// AutoApproveVerified publishes reviews of verified purchases without waiting for moderation
+	AutoApproveVerified bool `mapstructure:"auto_approve_verified"`
+	// RatingCache caches the rating summaries of products
+	RatingCache CacheConfig `mapstructure:"rating_cache"`
+	// ConsumerGroup is the Kafka consumer group product events, which drop
+	// the cached ratings of their products, are read with
+	ConsumerGroup string `mapstructure:"consumer_group"`
+}
+
+// NotificationServiceConfig holds notification service configuration
+type NotificationServiceConfig struct {
+	// DefaultLocale is used when a template has no variant for the requested locale
+	DefaultLocale string      `mapstructure:"default_locale"`
+	Email         EmailConfig `mapstructure:"email"`
+	// EmailQueueRetry is the retry policy of the email queue handler
+	EmailQueueRetry RetryPolicyConfig `mapstructure:"email_queue_retry"`
+}
+
+// EmailConfig holds settings for sending email
+type EmailConfig struct {
+	// Driver is "smtp", or "log" to only log messages in development
+	Driver       string        `mapstructure:"driver"`
+	SMTPHost     string        `mapstructure:"smtp_host"`
+	SMTPPort     int           `mapstructure:"smtp_port"`
+	SMTPUser     string        `mapstructure:"smtp_user"`
+	SMTPPassword string        `mapstructure:"smtp_password"`
+	FromAddress  string        `mapstructure:"from_address"`
+	FromName     string        `mapstructure:"from_name"`
+	Timeout      time.Duration `mapstructure:"timeout"`
+}
+
+// CurrencyServiceConfig holds currency service configuration
+type CurrencyServiceConfig struct {
+	// BaseCurrency is the currency exchange rates are stored against
+	BaseCurrency string `mapstructure:"base_currency"`
+	// Supported lists the currencies customers can display prices in
+	Supported []string           `mapstructure:"supported"`
+	Rates     ExchangeRateConfig `mapstructure:"rates"`
+}
+
+// ExchangeRateConfig holds settings for fetching exchange rates
+type ExchangeRateConfig struct {
+	// Provider is "ecb" for the European Central Bank reference rates, or
+	// "static" to use StaticRates
+	Provider string `mapstructure:"provider"`
+	ECBURL   string `mapstructure:"ecb_url"`
+	// StaticRates maps a currency to its rate against the base currency.
+	// Rates are decimal strings, e.g. "1.0823".
+	StaticRates     map[string]string `mapstructure:"static_rates"`
+	RefreshInterval time.Duration     `mapstructure:"refresh_interval"`
+	// MaxAge is how old rates may get before conversions are refused
+	MaxAge  time.Duration `mapstructure:"max_age"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// PricingServiceConfig holds pricing service configuration
+type PricingServiceConfig struct {
+	// MaxBulkPrices limits the number of prices in one bulk update
+	MaxBulkPrices int `mapstructure:"max_bulk_prices"`
+}
+
+// SubscriptionServiceConfig holds subscription service configuration
+type SubscriptionServiceConfig struct {
+	// PollInterval is how often due renewals are processed
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	BatchSize    int           `mapstructure:"batch_size"`
+	Dunning      DunningConfig `mapstructure:"dunning"`
+}
+
+// DunningConfig holds settings for retrying failed renewal payments.
+// A failed renewal is retried after each delay of RetrySchedule in turn; the
+// subscription is canceled when the last retry fails too.
+type DunningConfig struct {
+	RetrySchedule []time.Duration `mapstructure:"retry_schedule"`
+}
+
+// SellerServiceConfig holds marketplace seller service configuration
+type SellerServiceConfig struct {
+	// DefaultCommissionBps is the commission kept on sellers' sales, in basis
+	// points, unless a seller has a rate of their own
+	DefaultCommissionBps int `mapstructure:"default_commission_bps"`
+}
+
+// AnalyticsServiceConfig holds admin analytics service configuration
+type AnalyticsServiceConfig struct {
+	// RefreshInterval is how often the reporting read model is rebuilt
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+	// ChangeData rebuilds the read model soon after the tables it is built
+	// from change, rather than on the next scheduled refresh
+	ChangeData AnalyticsChangeDataConfig `mapstructure:"change_data"`
+	// MaxRangeDays limits the date range of a report
+	MaxRangeDays int `mapstructure:"max_range_days"`
+}
+
+// AnalyticsChangeDataConfig holds settings for consuming the change events of
+// the tables the analytics read model is built from. A change triggers a
+// refresh, but refreshes are at least MinRefreshInterval apart.
+type AnalyticsChangeDataConfig struct {
+	Enabled            bool          `mapstructure:"enabled"`
+	ConsumerGroup      string        `mapstructure:"consumer_group"`
+	Tables             []string      `mapstructure:"tables"`
+	MinRefreshInterval time.Duration `mapstructure:"min_refresh_interval"`
+	// Retry is the retry policy of the change event handlers
+	Retry RetryPolicyConfig `mapstructure:"retry"`
+}
+
+// StockAlertServiceConfig holds back-in-stock alert service configuration
+type StockAlertServiceConfig struct {
+	// ConsumerGroup is the Kafka consumer group inventory events are read with
+	ConsumerGroup string `mapstructure:"consumer_group"`
+	// AlertTTL is how long an alert waits for a restock before it expires
+	AlertTTL         time.Duration `mapstructure:"alert_ttl"`
+	MaxAlertsPerUser int           `mapstructure:"max_alerts_per_user"`
+	// ExpiryInterval is how often stale alerts are expired
+	ExpiryInterval time.Duration `mapstructure:"expiry_interval"`
+	BatchSize      int           `mapstructure:"batch_size"`
+	// EmailPool is the pool back-in-stock emails of a restock are sent on
+	EmailPool WorkerPoolConfig `mapstructure:"email_pool"`
+	// Retry is the retry policy of the inventory event handler
+	Retry RetryPolicyConfig `mapstructure:"retry"`
+}
+
+// PaymentWebhooksConfig holds settings for asynchronous webhook processing
+type PaymentWebhooksConfig struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	BatchSize    int           `mapstructure:"batch_size"`
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+	// SignatureTolerance is the maximum age of a signed webhook (Stripe)
+	SignatureTolerance time.Duration `mapstructure:"signature_tolerance"`
+}
+
+// PaymentProvidersConfig holds the credentials of each payment provider
+type PaymentProvidersConfig struct {
+	Stripe StripeConfig `mapstructure:"stripe"`
+	PayPal PayPalConfig `mapstructure:"paypal"`
+}
+
+// StripeConfig holds Stripe configuration
+type StripeConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	APIURL        string `mapstructure:"api_url"`
+	PublicKey     string `mapstructure:"public_key"`
+	SecretKey     string `mapstructure:"secret_key"`
+	WebhookSecret string `mapstructure:"webhook_secret"`
+}
+
+// PayPalConfig holds PayPal configuration
+type PayPalConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	APIURL       string `mapstructure:"api_url"`
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	WebhookID    string `mapstructure:"webhook_id"`
+}
+
+// Load loads configuration from file and environment variables. Only the
+// sections of modules are loaded and validated, along with those every
+// service needs; all of them are when no modules are named.
+//
+// The base file is CONFIG_PATH, or config.yaml in ./configs or the working
+// directory. The overlay of the environment, e.g. config.production.yaml
+// next to config.yaml, is merged over it when there is one, and
+// environment variables override both.
+func Load(modules ...Module) (*Config, error) {
 	config := &Config{}
 
-	// Set configuration file name and paths
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("./configs")
-	viper.AddConfigPath(".")
+	v := viper.New()
+	if path := os.Getenv("CONFIG_PATH"); path != "" {
+		v.SetConfigFile(path)
+	} else {
+		v.SetConfigName("config")
+		v.AddConfigPath("./configs")
+		v.AddConfigPath(".")
+	}
+	v.SetConfigType("yaml")
 
 	// Enable reading from environment variables
-	viper.AutomaticEnv()
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
 	// Read configuration file
-	if err := viper.ReadInConfig(); err != nil {
+	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			return nil, fmt.Errorf("error reading config file: %w", err)
 		}
 	}
 
+	// Merge the overlay of the environment over it
+	if base := v.ConfigFileUsed(); base != "" {
+		environment := v.GetString("environment")
+		if environment == "" {
+			environment = "development"
+		}
+		if err := mergeOverlay(v, overlayPath(base, environment)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Merge the remote configuration over the files
+	if err := mergeRemote(v); err != nil {
+		return nil, err
+	}
+
 	// Unmarshal configuration
-	if err := viper.Unmarshal(config); err != nil {
+	if err := v.Unmarshal(config); err != nil {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
 
 	// Set default values if not provided
 	setDefaults(config)
 
+	// Leave out the modules the service doesn't need
+	if len(modules) == 0 {
+		modules = allModules
+	}
+	if err := config.keepModules(modules); err != nil {
+		return nil, err
+	}
+
+	// Replace references to secrets with the secrets
+	if err := resolveSecrets(context.Background(), config, secretsProviders(config.Secrets)); err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+
 	// Validate configuration
 	if err := validate(config); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
@@ -217,6 +1230,51 @@ func Load() (*Config, error) {
 	return config, nil
 }
 
+// setRateLimitDefaults sets the defaults of a rate limit, limit requests
+// per minute kept in backend
+func setRateLimitDefaults(rateLimit *RateLimitConfig, backend string, limit int) {
+	if rateLimit.Algorithm == "" {
+		rateLimit.Algorithm = "sliding_window"
+	}
+	if rateLimit.Backend == "" {
+		rateLimit.Backend = backend
+	}
+	if rateLimit.Limit == 0 {
+		rateLimit.Limit = limit
+	}
+	if rateLimit.Window == 0 {
+		rateLimit.Window = time.Minute
+	}
+	if rateLimit.Burst == 0 {
+		rateLimit.Burst = rateLimit.Limit
+	}
+}
+
+// overlayPath returns the path of the overlay of environment for the base
+// file at path: config.production.yaml for config.yaml
+func overlayPath(path, environment string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + environment + ext
+}
+
+// mergeOverlay merges the file at path over the configuration read, unless
+// there is no such file
+func mergeOverlay(v *viper.Viper, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("error reading config overlay: %w", err)
+	}
+	defer file.Close()
+
+	if err := v.MergeConfig(file); err != nil {
+		return fmt.Errorf("error reading config overlay %s: %w", path, err)
+	}
+	return nil
+}
+
 // setDefaults sets default values for configuration
 func setDefaults(config *Config) {
 	if config.Environment == "" {
@@ -238,6 +1296,18 @@ func setDefaults(config *Config) {
 	if config.Server.IdleTimeout == 0 {
 		config.Server.IdleTimeout = 60 * time.Second
 	}
+
+	if config.Server.ShutdownTimeout == 0 {
+		config.Server.ShutdownTimeout = 30 * time.Second
+	}
+
+	if config.Server.TrustedProxies == nil {
+		config.Server.TrustedProxies = append([]string(nil), DefaultTrustedProxies...)
+	}
+
+	if config.Server.Debug.Host == "" {
+		config.Server.Debug.Host = "127.0.0.1"
+	}
 	
 	if config.Logger.Level == "" {
 		config.Logger.Level = "info"
@@ -246,24 +1316,558 @@ func setDefaults(config *Config) {
 	if config.Logger.Format == "" {
 		config.Logger.Format = "json"
 	}
+
+	if config.Logger.Sampling.Tick == 0 {
+		config.Logger.Sampling.Tick = time.Second
+	}
+
+	if config.Logger.Sampling.Initial == 0 {
+		config.Logger.Sampling.Initial = 100
+	}
+
+	if config.Logger.Export.BatchSize == 0 {
+		config.Logger.Export.BatchSize = 500
+	}
+
+	if config.Logger.Export.FlushInterval == 0 {
+		config.Logger.Export.FlushInterval = 2 * time.Second
+	}
+
+	if config.Logger.Export.BufferSize == 0 {
+		config.Logger.Export.BufferSize = 10000
+	}
+
+	if config.Logger.Export.Timeout == 0 {
+		config.Logger.Export.Timeout = 10 * time.Second
+	}
+
+	if config.Logger.ErrorReporting.Environment == "" {
+		config.Logger.ErrorReporting.Environment = config.Environment
+	}
+
+	if config.Logger.ErrorReporting.Release == "" {
+		config.Logger.ErrorReporting.Release = config.Version
+	}
+
+	if config.Logger.ErrorReporting.BufferSize == 0 {
+		config.Logger.ErrorReporting.BufferSize = 100
+	}
+
+	if config.Logger.ErrorReporting.Timeout == 0 {
+		config.Logger.ErrorReporting.Timeout = 5 * time.Second
+	}
+
+	if config.Logger.Audit.Output == "" {
+		config.Logger.Audit.Output = "file"
+	}
+
+	if config.Logger.Audit.Filename == "" {
+		config.Logger.Audit.Filename = "logs/audit.log"
+	}
+
+	if config.Logger.Redaction.Fields == nil {
+		config.Logger.Redaction.Fields = []string{"email", "phone", "password", "token"}
+	}
+
+	if config.Logger.Sampling.Thereafter == nil {
+		config.Logger.Sampling.Thereafter = map[string]int{"debug": 100, "info": 100}
+	}
 	
 	if config.Metrics.Path == "" {
 		config.Metrics.Path = "/metrics"
 	}
+
+	if config.Metrics.Provider == "" {
+		config.Metrics.Provider = "prometheus"
+	}
+
+	if config.Metrics.Interval == 0 {
+		config.Metrics.Interval = 15 * time.Second
+	}
+
+	if config.Metrics.RuntimeInterval == 0 {
+		config.Metrics.RuntimeInterval = 15 * time.Second
+	}
+
+	if config.Metrics.MaxLabelValues == 0 {
+		config.Metrics.MaxLabelValues = 200
+	}
+
+	if config.Metrics.SLO.Availability == 0 {
+		config.Metrics.SLO.Availability = 0.999
+	}
+
+	if config.Metrics.SLO.Latency == 0 {
+		config.Metrics.SLO.Latency = 0.99
+	}
+
+	if config.Metrics.SLO.LatencyThreshold == 0 {
+		config.Metrics.SLO.LatencyThreshold = 300 * time.Millisecond
+	}
+
+	if len(config.Metrics.SLO.Windows) == 0 {
+		config.Metrics.SLO.Windows = []time.Duration{5 * time.Minute, 30 * time.Minute, time.Hour, 6 * time.Hour}
+	}
+
+	if config.Metrics.Pushgateway.Timeout == 0 {
+		config.Metrics.Pushgateway.Timeout = 10 * time.Second
+	}
 	
 	if config.Tracing.SampleRate == 0 {
 		config.Tracing.SampleRate = 0.1
 	}
-}
 
-// validate validates the configuration
-func validate(config *Config) error {
-	if config.Server.Port <= 0 || config.Server.Port > 65535 {
-		return fmt.Errorf("invalid server port: %d", config.Server.Port)
+	if config.Tracing.TailSampling.LatencyThreshold == 0 {
+		config.Tracing.TailSampling.LatencyThreshold = time.Second
 	}
 	
-	// Only validate required services if they are configured
-	// For Phase 1, we'll allow empty configurations for non-essential services
-	
-	return nil
+	if config.Services.Timeout == 0 {
+		config.Services.Timeout = 5 * time.Second
+	}
+
+	if config.Services.Payment.DefaultProvider == "" {
+		config.Services.Payment.DefaultProvider = "stripe"
+	}
+
+	if config.Services.Payment.Timeout == 0 {
+		config.Services.Payment.Timeout = 30 * time.Second
+	}
+
+	if config.Services.Payment.Providers.Stripe.APIURL == "" {
+		config.Services.Payment.Providers.Stripe.APIURL = "https://api.stripe.com"
+	}
+
+	if config.Services.Payment.Providers.PayPal.APIURL == "" {
+		config.Services.Payment.Providers.PayPal.APIURL = "https://api-m.sandbox.paypal.com"
+	}
+
+	if config.Services.Payment.Webhooks.PollInterval == 0 {
+		config.Services.Payment.Webhooks.PollInterval = 5 * time.Second
+	}
+
+	if config.Services.Payment.Webhooks.BatchSize == 0 {
+		config.Services.Payment.Webhooks.BatchSize = 50
+	}
+
+	if config.Services.Payment.Webhooks.MaxAttempts == 0 {
+		config.Services.Payment.Webhooks.MaxAttempts = 10
+	}
+
+	if config.Services.Payment.Webhooks.SignatureTolerance == 0 {
+		config.Services.Payment.Webhooks.SignatureTolerance = 5 * time.Minute
+	}
+
+	if config.Services.Order.Tax.Provider == "" {
+		config.Services.Order.Tax.Provider = "rules"
+	}
+
+	if config.Services.Order.Tax.TaxJar.APIURL == "" {
+		config.Services.Order.Tax.TaxJar.APIURL = "https://api.taxjar.com"
+	}
+
+	if config.Services.Order.Tax.Timeout == 0 {
+		config.Services.Order.Tax.Timeout = 10 * time.Second
+	}
+
+	if config.Services.Order.Invoices.URLTTL == 0 {
+		config.Services.Order.Invoices.URLTTL = 15 * time.Minute
+	}
+
+	if config.Services.User.GRPCPort == 0 {
+		config.Services.User.GRPCPort = 9081
+	}
+
+	if config.Services.User.ProfileCache.TTL == 0 {
+		config.Services.User.ProfileCache.TTL = 10 * time.Minute
+	}
+
+	if config.Services.User.ProfileCache.LocalTTL == 0 {
+		config.Services.User.ProfileCache.LocalTTL = 5 * time.Second
+	}
+
+	if config.Services.Review.RatingCache.TTL == 0 {
+		config.Services.Review.RatingCache.TTL = 10 * time.Minute
+	}
+
+	if config.Services.Review.ConsumerGroup == "" {
+		config.Services.Review.ConsumerGroup = "review-service"
+	}
+
+	if config.Services.Order.Saga.Timeout == 0 {
+		config.Services.Order.Saga.Timeout = 10 * time.Minute
+	}
+
+	if config.Services.Order.Saga.RetryAttempts == 0 {
+		config.Services.Order.Saga.RetryAttempts = 3
+	}
+
+	if config.Services.Order.Saga.RecoveryInterval == 0 {
+		config.Services.Order.Saga.RecoveryInterval = time.Minute
+	}
+
+	if config.Services.Order.Saga.BatchSize == 0 {
+		config.Services.Order.Saga.BatchSize = 50
+	}
+
+	if config.Services.Order.Projection.ConsumerGroup == "" {
+		config.Services.Order.Projection.ConsumerGroup = "order-service-projection"
+	}
+
+	if config.Services.Order.Projection.Interval == 0 {
+		config.Services.Order.Projection.Interval = 5 * time.Minute
+	}
+
+	if config.Services.Order.Projection.BatchSize == 0 {
+		config.Services.Order.Projection.BatchSize = 500
+	}
+
+	if config.Services.Order.Reservations.TTL == 0 {
+		config.Services.Order.Reservations.TTL = 15 * time.Minute
+	}
+
+	if config.Services.Order.Reservations.ExpiryInterval == 0 {
+		config.Services.Order.Reservations.ExpiryInterval = time.Minute
+	}
+
+	if config.Services.Order.Reservations.BatchSize == 0 {
+		config.Services.Order.Reservations.BatchSize = 100
+	}
+
+	if config.Services.Order.Reservations.MetricsWindow == 0 {
+		config.Services.Order.Reservations.MetricsWindow = 24 * time.Hour
+	}
+
+	setRemoteDefaults(&config.Remote)
+
+	resilience := &config.Services.Resilience.Default
+	if resilience.MaxAttempts == 0 {
+		resilience.MaxAttempts = 3
+	}
+	if resilience.BackoffMin == 0 {
+		resilience.BackoffMin = 100 * time.Millisecond
+	}
+	if resilience.BackoffMax == 0 {
+		resilience.BackoffMax = 2 * time.Second
+	}
+	if resilience.Jitter == 0 {
+		resilience.Jitter = 0.2
+	}
+	if resilience.RetryBudget == 0 {
+		resilience.RetryBudget = 0.2
+	}
+	if resilience.FailureThreshold == 0 {
+		resilience.FailureThreshold = 5
+	}
+	if resilience.OpenTimeout == 0 {
+		resilience.OpenTimeout = 30 * time.Second
+	}
+
+	tasks := &config.Services.TaskQueue
+	if tasks.Concurrency == 0 {
+		tasks.Concurrency = 10
+	}
+	if tasks.MaxRetry == 0 {
+		tasks.MaxRetry = 10
+	}
+	if tasks.Timeout == 0 {
+		tasks.Timeout = 5 * time.Minute
+	}
+
+	setRateLimitDefaults(&config.Services.Gateway.RateLimit, "memory", 1000)
+	setRateLimitDefaults(&config.Services.User.RateLimit, "redis", 10)
+	if config.Services.User.Lockout.MaxAttempts == 0 {
+		config.Services.User.Lockout.MaxAttempts = 5
+	}
+
+	if config.Services.User.Lockout.Window == 0 {
+		config.Services.User.Lockout.Window = 15 * time.Minute
+	}
+
+	if config.Services.User.TokenCleanupSchedule == "" {
+		config.Services.User.TokenCleanupSchedule = "@hourly"
+	}
+
+	if config.Secrets.AWS.Timeout == 0 {
+		config.Secrets.AWS.Timeout = 10 * time.Second
+	}
+
+	if config.Storage.Driver == "" {
+		config.Storage.Driver = "local"
+	}
+
+	if config.Storage.S3.Timeout == 0 {
+		config.Storage.S3.Timeout = 30 * time.Second
+	}
+
+	if config.Tenancy.Header == "" {
+		config.Tenancy.Header = "X-Tenant-ID"
+	}
+
+	if config.Seed.AdminUsername == "" {
+		config.Seed.AdminUsername = "admin"
+	}
+
+	if config.Services.Shipping.QuoteTTL == 0 {
+		config.Services.Shipping.QuoteTTL = 24 * time.Hour
+	}
+
+	if config.Services.Shipping.Timeout == 0 {
+		config.Services.Shipping.Timeout = 15 * time.Second
+	}
+
+	if config.Services.Shipping.Carriers.FlatRate.Currency == "" {
+		config.Services.Shipping.Carriers.FlatRate.Currency = "USD"
+	}
+
+	if config.Services.Shipping.Carriers.EasyPost.APIURL == "" {
+		config.Services.Shipping.Carriers.EasyPost.APIURL = "https://api.easypost.com"
+	}
+
+	if config.Services.Shipping.Tracking.PollInterval == 0 {
+		config.Services.Shipping.Tracking.PollInterval = 5 * time.Minute
+	}
+
+	if config.Services.Shipping.Tracking.BatchSize == 0 {
+		config.Services.Shipping.Tracking.BatchSize = 50
+	}
+
+	if config.Services.Shipping.Tracking.RefreshInterval == 0 {
+		config.Services.Shipping.Tracking.RefreshInterval = time.Hour
+	}
+
+	if config.Services.Payment.Fraud.ReviewThreshold == 0 {
+		config.Services.Payment.Fraud.ReviewThreshold = 50
+	}
+
+	if config.Services.Payment.Fraud.DeclineThreshold == 0 {
+		config.Services.Payment.Fraud.DeclineThreshold = 80
+	}
+
+	if config.Services.Payment.Fraud.VelocityWindow == 0 {
+		config.Services.Payment.Fraud.VelocityWindow = time.Hour
+	}
+
+	if config.Services.Payment.Fraud.MaxAttempts == 0 {
+		config.Services.Payment.Fraud.MaxAttempts = 5
+	}
+
+	if config.Services.Payment.Fraud.MaxFailures == 0 {
+		config.Services.Payment.Fraud.MaxFailures = 3
+	}
+
+	if config.Services.Payment.Fraud.MaxAccountsPerDevice == 0 {
+		config.Services.Payment.Fraud.MaxAccountsPerDevice = 3
+	}
+
+	if config.Services.Payment.Fraud.MaxAccountsPerIP == 0 {
+		config.Services.Payment.Fraud.MaxAccountsPerIP = 5
+	}
+
+	if config.Services.Currency.BaseCurrency == "" {
+		config.Services.Currency.BaseCurrency = "EUR"
+	}
+
+	if config.Services.Currency.Rates.RefreshInterval == 0 {
+		config.Services.Currency.Rates.RefreshInterval = time.Hour
+	}
+
+	if config.Services.Currency.Rates.MaxAge == 0 {
+		config.Services.Currency.Rates.MaxAge = 72 * time.Hour
+	}
+
+	if config.Services.Currency.Rates.Timeout == 0 {
+		config.Services.Currency.Rates.Timeout = 10 * time.Second
+	}
+
+	if config.Services.Pricing.MaxBulkPrices == 0 {
+		config.Services.Pricing.MaxBulkPrices = 1000
+	}
+
+	if config.Services.Subscription.PollInterval == 0 {
+		config.Services.Subscription.PollInterval = time.Minute
+	}
+
+	if config.Services.Subscription.BatchSize == 0 {
+		config.Services.Subscription.BatchSize = 50
+	}
+
+	if len(config.Services.Subscription.Dunning.RetrySchedule) == 0 {
+		config.Services.Subscription.Dunning.RetrySchedule = []time.Duration{24 * time.Hour, 72 * time.Hour, 120 * time.Hour}
+	}
+
+	if config.Services.Seller.DefaultCommissionBps == 0 {
+		config.Services.Seller.DefaultCommissionBps = 1000
+	}
+
+	if config.Services.Analytics.RefreshInterval == 0 {
+		config.Services.Analytics.RefreshInterval = 15 * time.Minute
+	}
+
+	if config.Services.Analytics.MaxRangeDays == 0 {
+		config.Services.Analytics.MaxRangeDays = 731
+	}
+
+	if config.Services.Analytics.ChangeData.ConsumerGroup == "" {
+		config.Services.Analytics.ChangeData.ConsumerGroup = "analytics-service"
+	}
+
+	if len(config.Services.Analytics.ChangeData.Tables) == 0 {
+		config.Services.Analytics.ChangeData.Tables = []string{"public.orders", "public.order_items", "public.users"}
+	}
+
+	if config.Services.Analytics.ChangeData.MinRefreshInterval == 0 {
+		config.Services.Analytics.ChangeData.MinRefreshInterval = time.Minute
+	}
+
+	if config.Services.StockAlert.ConsumerGroup == "" {
+		config.Services.StockAlert.ConsumerGroup = "stock-alert-service"
+	}
+
+	if config.Services.StockAlert.AlertTTL == 0 {
+		config.Services.StockAlert.AlertTTL = 90 * 24 * time.Hour
+	}
+
+	if config.Services.StockAlert.MaxAlertsPerUser == 0 {
+		config.Services.StockAlert.MaxAlertsPerUser = 50
+	}
+
+	if config.Services.StockAlert.ExpiryInterval == 0 {
+		config.Services.StockAlert.ExpiryInterval = time.Hour
+	}
+
+	if config.Services.StockAlert.BatchSize == 0 {
+		config.Services.StockAlert.BatchSize = 100
+	}
+
+	if config.Services.StockAlert.EmailPool.Size == 0 {
+		config.Services.StockAlert.EmailPool.Size = 10
+	}
+
+	if config.Services.StockAlert.EmailPool.TaskTimeout == 0 {
+		config.Services.StockAlert.EmailPool.TaskTimeout = 30 * time.Second
+	}
+
+	if config.Kafka.BatchSize == 0 {
+		config.Kafka.BatchSize = 100
+	}
+
+	if config.Kafka.BatchTimeout == 0 {
+		config.Kafka.BatchTimeout = time.Second
+	}
+
+	if config.Database.SlowQueryThreshold == 0 {
+		config.Database.SlowQueryThreshold = 500 * time.Millisecond
+	}
+
+	if config.Database.TenantPools.MaxPools == 0 {
+		config.Database.TenantPools.MaxPools = 50
+	}
+
+	if config.Database.TenantPools.MaxOpenConns == 0 {
+		config.Database.TenantPools.MaxOpenConns = 5
+	}
+
+	if config.Database.TenantPools.MaxIdleConns == 0 {
+		config.Database.TenantPools.MaxIdleConns = 2
+	}
+
+	if config.Database.Vault.Address == "" {
+		config.Database.Vault.Address = config.Vault.Address
+	}
+
+	if config.Database.Vault.Token == "" {
+		config.Database.Vault.Token = config.Vault.Token
+	}
+
+	if config.Database.Vault.Namespace == "" {
+		config.Database.Vault.Namespace = config.Vault.Namespace
+	}
+
+	if config.Database.Vault.Mount == "" {
+		config.Database.Vault.Mount = "database"
+	}
+
+	if config.Database.Vault.RenewBefore == 0 {
+		config.Database.Vault.RenewBefore = 5 * time.Minute
+	}
+
+	if config.Database.Vault.Timeout == 0 {
+		config.Database.Vault.Timeout = 10 * time.Second
+	}
+
+	if config.RabbitMQ.MaxPriority == 0 {
+		config.RabbitMQ.MaxPriority = 10
+	}
+
+	if config.RabbitMQ.Scheduler.Key == "" {
+		config.RabbitMQ.Scheduler.Key = "rabbitmq:scheduled"
+	}
+
+	if config.RabbitMQ.Scheduler.PollInterval == 0 {
+		config.RabbitMQ.Scheduler.PollInterval = time.Second
+	}
+
+	if config.RabbitMQ.Scheduler.BatchSize == 0 {
+		config.RabbitMQ.Scheduler.BatchSize = 100
+	}
+
+	if config.Kafka.RetryMax == 0 {
+		config.Kafka.RetryMax = 3
+	}
+
+	if config.Kafka.RetryBackoffMin == 0 {
+		config.Kafka.RetryBackoffMin = 100 * time.Millisecond
+	}
+
+	if config.Kafka.RetryBackoffMax == 0 {
+		config.Kafka.RetryBackoffMax = time.Second
+	}
+
+	if config.Kafka.DeadLetterSuffix == "" {
+		config.Kafka.DeadLetterSuffix = ".dlq"
+	}
+
+	if config.Kafka.SchemaRegistry.Timeout == 0 {
+		config.Kafka.SchemaRegistry.Timeout = 5 * time.Second
+	}
+
+	if config.Kafka.Inbox.TTL == 0 {
+		config.Kafka.Inbox.TTL = 7 * 24 * time.Hour
+	}
+
+	if config.Kafka.Inbox.Lease == 0 {
+		config.Kafka.Inbox.Lease = 5 * time.Minute
+	}
+
+	if config.Kafka.Inbox.PurgeInterval == 0 {
+		config.Kafka.Inbox.PurgeInterval = time.Hour
+	}
+
+	if config.Kafka.CDC.TopicPrefix == "" {
+		config.Kafka.CDC.TopicPrefix = "commercium"
+	}
+
+	if config.Kafka.Topics.ClickstreamEvents == "" {
+		config.Kafka.Topics.ClickstreamEvents = "clickstream.events"
+	}
+
+	if config.Services.Gateway.DeadLetters.MaxListLimit == 0 {
+		config.Services.Gateway.DeadLetters.MaxListLimit = 100
+	}
+
+	if config.Services.Gateway.Clickstream.BufferSize == 0 {
+		config.Services.Gateway.Clickstream.BufferSize = 10000
+	}
+
+	if config.Services.Gateway.Clickstream.BatchSize == 0 {
+		config.Services.Gateway.Clickstream.BatchSize = 500
+	}
+
+	if config.Services.Gateway.Clickstream.FlushInterval == 0 {
+		config.Services.Gateway.Clickstream.FlushInterval = time.Second
+	}
+
+	if config.Services.Gateway.Clickstream.MaxEventsPerRequest == 0 {
+		config.Services.Gateway.Clickstream.MaxEventsPerRequest = 50
+	}
 }
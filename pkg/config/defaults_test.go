@@ -0,0 +1,30 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTrustedProxiesDefault checks services trust the networks the gateway
+// reaches them from unless configured otherwise, and the gateway trusts no
+// proxy by default
+func TestTrustedProxiesDefault(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		configured []string
+		want       []string
+	}{
+		{"unset", nil, DefaultTrustedProxies},
+		{"none", []string{}, []string{}},
+		{"set", []string{"10.1.0.0/16"}, []string{"10.1.0.0/16"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &Config{}
+			config.Server.TrustedProxies = tc.configured
+			setDefaults(config)
+			assert.Equal(t, tc.want, config.Server.TrustedProxies)
+			assert.Empty(t, config.Services.Gateway.TrustedProxies)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"filippo.io/age"
+)
+
+// ageHeader starts every age ciphertext
+const ageHeader = "age-encryption.org/v1"
+
+// EncryptedValueProvider decrypts settings checked into config files
+// encrypted, so they are never kept in plaintext: "enc:<base64>" is
+// decrypted with the age identity configured when the ciphertext is age's,
+// and with AWS KMS otherwise. Values are encrypted with
+//
+//	age -r <recipient> | base64 -w0
+//	aws kms encrypt --key-id <key> --plaintext fileb://<(printf %s "$VALUE") --query CiphertextBlob --output text
+type EncryptedValueProvider struct {
+	config AgeSecretsConfig
+	kms    *awsClient
+
+	mu         sync.Mutex
+	identities []age.Identity
+}
+
+// NewEncryptedValueProvider creates a new provider of encrypted values
+func NewEncryptedValueProvider(cfg SecretsConfig) *EncryptedValueProvider {
+	return &EncryptedValueProvider{
+		config: cfg.Age,
+		kms:    newAWSClient("kms", cfg.AWS),
+	}
+}
+
+// Prefix returns the prefix of encrypted values
+func (p *EncryptedValueProvider) Prefix() string {
+	return "enc:"
+}
+
+// Secret decrypts an encrypted value
+func (p *EncryptedValueProvider) Secret(ctx context.Context, name string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimSpace(name))
+	if err != nil {
+		return "", fmt.Errorf("encrypted value is not base64: %w", err)
+	}
+
+	if bytes.HasPrefix(ciphertext, []byte(ageHeader)) {
+		return p.decryptAge(ciphertext)
+	}
+	return p.decryptKMS(ctx, ciphertext)
+}
+
+// decryptAge decrypts an age ciphertext
+func (p *EncryptedValueProvider) decryptAge(ciphertext []byte) (string, error) {
+	identities, err := p.ageIdentities()
+	if err != nil {
+		return "", err
+	}
+
+	reader, err := age.Decrypt(bytes.NewReader(ciphertext), identities...)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt age value: %w", err)
+	}
+	plaintext, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt age value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// ageIdentities returns the configured age identities, read once
+func (p *EncryptedValueProvider) ageIdentities() ([]age.Identity, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.identities != nil {
+		return p.identities, nil
+	}
+
+	keys := p.config.Identity
+	if keys == "" && p.config.IdentityFile != "" {
+		data, err := os.ReadFile(p.config.IdentityFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read age identity file: %w", err)
+		}
+		keys = string(data)
+	}
+	if keys == "" {
+		return nil, fmt.Errorf("no age identity to decrypt with, set secrets.age.identity_file")
+	}
+
+	identities, err := age.ParseIdentities(strings.NewReader(keys))
+	if err != nil {
+		return nil, fmt.Errorf("invalid age identity: %w", err)
+	}
+	p.identities = identities
+	return identities, nil
+}
+
+// decryptKMS decrypts a ciphertext blob encrypted with AWS KMS, which
+// records the key it was encrypted with
+func (p *EncryptedValueProvider) decryptKMS(ctx context.Context, ciphertext []byte) (string, error) {
+	var output struct {
+		Plaintext []byte `json:"Plaintext"`
+	}
+	input := map[string][]byte{"CiphertextBlob": ciphertext}
+	if err := p.kms.call(ctx, "TrentService.Decrypt", input, &output); err != nil {
+		return "", err
+	}
+	return string(output.Plaintext), nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+)
+
+// Module is a section of the configuration a service may depend on. Each
+// service loads the modules it needs; the sections of the others are left
+// empty and aren't validated, so a service doesn't fail to start over
+// settings of infrastructure it never connects to. The environment,
+// version, logger, metrics, tracing and services sections are always
+// loaded.
+type Module string
+
+// Modules of the configuration
+const (
+	ModuleServer   Module = "server"
+	ModuleDatabase Module = "database"
+	ModuleRedis    Module = "redis"
+	ModuleKafka    Module = "kafka"
+	ModuleRabbitMQ Module = "rabbitmq"
+	ModuleAuth     Module = "auth"
+	ModuleVault    Module = "vault"
+	ModuleStorage  Module = "storage"
+	ModuleTenancy  Module = "tenancy"
+	ModuleSeed     Module = "seed"
+)
+
+// allModules are the modules loaded when none are named
+var allModules = []Module{
+	ModuleServer,
+	ModuleDatabase,
+	ModuleRedis,
+	ModuleKafka,
+	ModuleRabbitMQ,
+	ModuleAuth,
+	ModuleVault,
+	ModuleStorage,
+	ModuleTenancy,
+	ModuleSeed,
+}
+
+// Has reports whether the configuration was loaded with module
+func (c *Config) Has(module Module) bool {
+	return c.modules[module]
+}
+
+// keepModules empties the sections of the modules not in modules
+func (c *Config) keepModules(modules []Module) error {
+	c.modules = make(map[Module]bool, len(modules))
+	for _, module := range modules {
+		if !isModule(module) {
+			return fmt.Errorf("unknown configuration module %q", module)
+		}
+		c.modules[module] = true
+	}
+
+	for _, module := range allModules {
+		if c.modules[module] {
+			continue
+		}
+		switch module {
+		case ModuleServer:
+			c.Server = ServerConfig{}
+		case ModuleDatabase:
+			c.Database = DatabaseConfig{}
+		case ModuleRedis:
+			c.Redis = RedisConfig{}
+		case ModuleKafka:
+			c.Kafka = KafkaConfig{}
+		case ModuleRabbitMQ:
+			c.RabbitMQ = RabbitMQConfig{}
+		case ModuleAuth:
+			c.Auth = AuthConfig{}
+		case ModuleVault:
+			c.Vault = VaultConfig{}
+		case ModuleStorage:
+			c.Storage = StorageConfig{}
+		case ModuleTenancy:
+			c.Tenancy = TenancyConfig{}
+		case ModuleSeed:
+			c.Seed = SeedConfig{}
+		}
+	}
+
+	return nil
+}
+
+// isModule reports whether module is a known module
+func isModule(module Module) bool {
+	for _, known := range allModules {
+		if module == known {
+			return true
+		}
+	}
+	return false
+}
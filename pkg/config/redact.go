@@ -0,0 +1,151 @@
+package config
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// redactedValue replaces the values of secret settings
+const redactedValue = "[REDACTED]"
+
+// secretNames are the names of settings holding secrets, on their own or
+// as the end of a name, e.g. smtp_password
+var secretNames = []string{
+	"password",
+	"secret",
+	"secret_key",
+	"secret_access_key",
+	"token",
+	"api_key",
+	"signing_key",
+	"identity",
+	"authorization",
+}
+
+// secretMaps are the names of settings mapping names to values that are
+// all masked, as their secrets can be under any name, e.g. the headers sent
+// to collectors, whether Authorization, Cookie or X-Api-Key
+var secretMaps = []string{
+	"headers",
+}
+
+// Redacted returns the effective settings, as loaded, keyed as in the
+// config files, for operators to check what a running service resolved.
+// Secrets are masked, as are passwords in URLs, and the sections of
+// modules the service didn't load are left out.
+func (c *Config) Redacted() map[string]interface{} {
+	settings := redact(reflect.ValueOf(c).Elem(), "").(map[string]interface{})
+	for _, module := range allModules {
+		if !c.modules[module] {
+			delete(settings, string(module))
+		}
+	}
+	return settings
+}
+
+// DebugHandler returns a handler responding with the redacted settings of
+// config. It must only be routed behind authentication.
+func DebugHandler(config *Config) gin.HandlerFunc {
+	settings := config.Redacted()
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, settings)
+	}
+}
+
+// redact returns the value of the setting name, with the settings under it
+// redacted
+func redact(v reflect.Value, name string) interface{} {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		return v.Interface().(time.Duration).String()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return redact(v.Elem(), name)
+
+	case reflect.Struct:
+		settings := make(map[string]interface{})
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldName, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+			if fieldName == "" {
+				fieldName = strings.ToLower(field.Name)
+			}
+			settings[fieldName] = redact(v.Field(i), fieldName)
+		}
+		return settings
+
+	case reflect.Slice:
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = redact(v.Index(i), name)
+		}
+		return values
+
+	case reflect.Map:
+		settings := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			if isSecretMap(name) {
+				settings[key.String()] = redactedValue
+				continue
+			}
+			settings[key.String()] = redact(v.MapIndex(key), key.String())
+		}
+		return settings
+
+	case reflect.String:
+		return redactString(name, v.String())
+
+	default:
+		return v.Interface()
+	}
+}
+
+// redactString masks the value of a string setting when it is a secret, or
+// the password of it when it is a URL
+func redactString(name, value string) string {
+	if value == "" {
+		return value
+	}
+	if isSecret(name) {
+		return redactedValue
+	}
+	if strings.Contains(value, "://") {
+		if u, err := url.Parse(value); err == nil {
+			return u.Redacted()
+		}
+	}
+	return value
+}
+
+// isSecret reports whether the setting name holds a secret
+func isSecret(name string) bool {
+	for _, secret := range secretNames {
+		if name == secret || strings.HasSuffix(name, "_"+secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecretMap reports whether every value of the setting name is a secret
+func isSecretMap(name string) bool {
+	for _, secret := range secretMaps {
+		if name == secret || strings.HasSuffix(name, "_"+secret) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRedacted checks secrets are masked in the settings shown to
+// operators, whatever the name of the headers holding them
+func TestRedacted(t *testing.T) {
+	config := &Config{
+		Logger: LoggerConfig{
+			Level: "info",
+			Export: LogExportConfig{
+				Endpoint: "http://loki:3100",
+				Headers:  map[string]string{"Authorization": "Basic bG9raTpzZWNyZXQ=", "X-Scope-OrgID": "tenant-1"},
+			},
+		},
+		Metrics: MetricsConfig{
+			Endpoint: "http://otel-collector:4318",
+			Headers:  map[string]string{"x-api-key": "metrics-key", "cookie": "session=abc"},
+		},
+		Database: DatabaseConfig{Host: "postgres", Password: "database-password"},
+		modules:  map[Module]bool{ModuleDatabase: true},
+	}
+
+	settings := config.Redacted()
+
+	logger := settings["logger"].(map[string]interface{})
+	assert.Equal(t, "info", logger["level"])
+	export := logger["export"].(map[string]interface{})
+	assert.Equal(t, "http://loki:3100", export["endpoint"])
+	assert.Equal(t, map[string]interface{}{"Authorization": redactedValue, "X-Scope-OrgID": redactedValue},
+		export["headers"])
+
+	metrics := settings["metrics"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"x-api-key": redactedValue, "cookie": redactedValue}, metrics["headers"])
+
+	database := settings["database"].(map[string]interface{})
+	assert.Equal(t, "postgres", database["host"])
+	assert.Equal(t, redactedValue, database["password"])
+
+	assert.NotContains(t, settings, "redis", "sections of modules not loaded are left out")
+}
@@ -0,0 +1,317 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// remoteRetryInterval is how long to wait before watching the remote
+// document again after failing to
+const remoteRetryInterval = 5 * time.Second
+
+// remoteSource reads a YAML document from a key-value store and watches it
+// for changes
+type remoteSource interface {
+	// get returns the document, empty when the key is missing, and the
+	// revision it was read at
+	get(ctx context.Context) ([]byte, uint64, error)
+	// watch waits for the document to change after revision and returns
+	// the revision it changed at, or revision when nothing changed before
+	// the source gave up waiting
+	watch(ctx context.Context, revision uint64) (uint64, error)
+}
+
+// newRemoteSource returns the source of the remote configuration
+func newRemoteSource(cfg RemoteConfig) (remoteSource, error) {
+	endpoint := strings.TrimRight(cfg.Endpoint, "/")
+	switch cfg.Provider {
+	case "consul":
+		return &consulSource{endpoint: endpoint, config: cfg, httpClient: &http.Client{Timeout: cfg.WatchWait + cfg.Timeout}}, nil
+	case "etcd":
+		return &etcdSource{endpoint: endpoint, config: cfg, httpClient: &http.Client{Timeout: cfg.WatchWait + cfg.Timeout}}, nil
+	default:
+		return nil, fmt.Errorf("remote config provider %q is not supported", cfg.Provider)
+	}
+}
+
+// mergeRemote merges the remote document over the configuration read,
+// when remote configuration is on. Services fail to start without it, as
+// replicas running without the shared settings would disagree.
+func mergeRemote(v *viper.Viper) error {
+	var remote RemoteConfig
+	if err := v.UnmarshalKey("remote", &remote); err != nil {
+		return fmt.Errorf("error unmarshaling remote config: %w", err)
+	}
+	if remote.Provider == "" {
+		return nil
+	}
+	setRemoteDefaults(&remote)
+
+	p := &problems{}
+	remote.validate(p)
+	if len(p.list) > 0 {
+		return fmt.Errorf("invalid configuration: %w", &ValidationError{Problems: p.list})
+	}
+
+	document, err := readRemote(remote)
+	if err != nil {
+		return err
+	}
+	if len(document) == 0 {
+		return nil
+	}
+	if err := v.MergeConfig(bytes.NewReader(document)); err != nil {
+		return fmt.Errorf("error reading remote config %s: %w", remote.Key, err)
+	}
+	return nil
+}
+
+// setRemoteDefaults sets the defaults of the remote settings, which are
+// needed before the rest of the configuration is read
+func setRemoteDefaults(remote *RemoteConfig) {
+	if remote.Timeout == 0 {
+		remote.Timeout = 10 * time.Second
+	}
+	if remote.WatchWait == 0 {
+		remote.WatchWait = 5 * time.Minute
+	}
+}
+
+// readRemote reads the remote document to merge over the config files
+func readRemote(cfg RemoteConfig) ([]byte, error) {
+	source, err := newRemoteSource(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	document, _, err := source.get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote config %s: %w", cfg.Key, err)
+	}
+	return document, nil
+}
+
+// WatchRemote loads the configuration again each time the remote document
+// of current changes, with the modules current was loaded with, and calls
+// onChange with it, until ctx is done. Changes leaving the configuration
+// invalid are passed to onError and skipped, as are failures to watch,
+// which are retried.
+func WatchRemote(ctx context.Context, current *Config, onChange func(*Config), onError func(error)) {
+	source, err := newRemoteSource(current.Remote)
+	if err != nil {
+		onError(err)
+		return
+	}
+
+	modules := make([]Module, 0, len(current.modules))
+	for _, module := range allModules {
+		if current.modules[module] {
+			modules = append(modules, module)
+		}
+	}
+
+	var revision uint64
+	for ctx.Err() == nil {
+		if revision == 0 {
+			_, revision, err = source.get(ctx)
+		} else {
+			var changed uint64
+			changed, err = source.watch(ctx, revision)
+			if err == nil && changed != revision {
+				revision = changed
+				config, loadErr := Load(modules...)
+				if loadErr != nil {
+					onError(fmt.Errorf("remote config change ignored: %w", loadErr))
+				} else {
+					onChange(config)
+				}
+			}
+		}
+
+		if err != nil && ctx.Err() == nil {
+			onError(fmt.Errorf("failed to watch remote config %s: %w", current.Remote.Key, err))
+			revision = 0
+			select {
+			case <-ctx.Done():
+			case <-time.After(remoteRetryInterval):
+			}
+		}
+	}
+}
+
+// consulSource reads the document from the Consul KV store, watching it
+// with blocking queries
+type consulSource struct {
+	endpoint   string
+	config     RemoteConfig
+	httpClient *http.Client
+}
+
+func (s *consulSource) get(ctx context.Context) ([]byte, uint64, error) {
+	return s.query(ctx, url.Values{"raw": {""}})
+}
+
+func (s *consulSource) watch(ctx context.Context, revision uint64) (uint64, error) {
+	query := url.Values{
+		"index": {strconv.FormatUint(revision, 10)},
+		"wait":  {fmt.Sprintf("%ds", int(s.config.WatchWait.Seconds()))},
+	}
+	_, index, err := s.query(ctx, query)
+	return index, err
+}
+
+// query reads the key, returning the index of the KV store it was read at
+func (s *consulSource) query(ctx context.Context, query url.Values) ([]byte, uint64, error) {
+	query.Set("raw", "")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		s.endpoint+"/v1/kv/"+strings.TrimPrefix(s.config.Key, "/")+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create consul request: %w", err)
+	}
+	if s.config.Token != "" {
+		req.Header.Set("X-Consul-Token", s.config.Token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("consul request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read consul response: %w", err)
+	}
+	index, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body, index, nil
+	case http.StatusNotFound:
+		return nil, index, nil
+	default:
+		return nil, 0, fmt.Errorf("consul returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+}
+
+// etcdSource reads the document from etcd through its JSON gateway,
+// watching it with a watch stream
+type etcdSource struct {
+	endpoint   string
+	config     RemoteConfig
+	httpClient *http.Client
+}
+
+// etcdHeader is the header of etcd responses. Revisions are encoded as
+// strings, being 64-bit integers.
+type etcdHeader struct {
+	Revision uint64 `json:"revision,string"`
+}
+
+func (s *etcdSource) get(ctx context.Context) ([]byte, uint64, error) {
+	var result struct {
+		Header etcdHeader `json:"header"`
+		KVs    []struct {
+			Value []byte `json:"value"`
+		} `json:"kvs"`
+	}
+	resp, err := s.post(ctx, "/v3/kv/range", map[string]interface{}{"key": s.key()})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode etcd response: %w", err)
+	}
+	if len(result.KVs) == 0 {
+		return nil, result.Header.Revision, nil
+	}
+	return result.KVs[0].Value, result.Header.Revision, nil
+}
+
+func (s *etcdSource) watch(ctx context.Context, revision uint64) (uint64, error) {
+	// The stream stays open until an event arrives or the wait is over
+	ctx, cancel := context.WithTimeout(ctx, s.config.WatchWait)
+	defer cancel()
+
+	resp, err := s.post(ctx, "/v3/watch", map[string]interface{}{
+		"create_request": map[string]interface{}{
+			"key":            s.key(),
+			"start_revision": strconv.FormatUint(revision+1, 10),
+		},
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return revision, nil
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var message struct {
+			Result struct {
+				Header etcdHeader        `json:"header"`
+				Events []json.RawMessage `json:"events"`
+			} `json:"result"`
+		}
+		if err := decoder.Decode(&message); err != nil {
+			if ctx.Err() != nil {
+				return revision, nil
+			}
+			return 0, fmt.Errorf("etcd watch failed: %w", err)
+		}
+		if len(message.Result.Events) > 0 {
+			return message.Result.Header.Revision, nil
+		}
+	}
+}
+
+// post calls the JSON gateway of etcd
+func (s *etcdSource) post(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode etcd request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create etcd request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.config.Token != "" {
+		req.Header.Set("Authorization", s.config.Token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("etcd request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("etcd returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return resp, nil
+}
+
+// key returns the key of the document, base64 encoded as the gateway takes
+// keys
+func (s *etcdSource) key() string {
+	return base64.StdEncoding.EncodeToString([]byte(s.config.Key))
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SecretsProvider resolves references to secrets kept outside the
+// configuration. A setting whose value is a reference, the provider's
+// prefix followed by the name of the secret, e.g.
+// "awssm:commercium/prod/db#password", is replaced with the secret when
+// the configuration is loaded.
+type SecretsProvider interface {
+	// Prefix is the prefix of the references the provider resolves,
+	// e.g. "awssm:"
+	Prefix() string
+	// Secret returns the secret named by a reference, without its prefix
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// secretsProviders returns the providers references to secrets are
+// resolved with
+func secretsProviders(cfg SecretsConfig) []SecretsProvider {
+	return []SecretsProvider{
+		NewSecretsManagerProvider(cfg.AWS),
+		NewParameterStoreProvider(cfg.AWS),
+		NewEncryptedValueProvider(cfg),
+	}
+}
+
+// resolveSecrets replaces the references to secrets among the settings of
+// config with the secrets, reporting every reference that can't be resolved
+func resolveSecrets(ctx context.Context, config *Config, providers []SecretsProvider) error {
+	resolved := make(map[string]string)
+	p := &problems{}
+
+	walkSettings(reflect.ValueOf(config).Elem(), "", func(key, value string) (string, bool) {
+		for _, provider := range providers {
+			name, ok := strings.CutPrefix(value, provider.Prefix())
+			if !ok {
+				continue
+			}
+
+			// Settings sharing a secret fetch it once
+			if secret, ok := resolved[value]; ok {
+				return secret, true
+			}
+			secret, err := provider.Secret(ctx, name)
+			if err != nil {
+				p.add(key, "failed to resolve %s: %v", value, err)
+				return "", false
+			}
+			resolved[value] = secret
+			return secret, true
+		}
+		return "", false
+	})
+
+	if len(p.list) > 0 {
+		return &ValidationError{Problems: p.list}
+	}
+	return nil
+}
+
+// walkSettings calls replace with every string setting under v, keyed by
+// its path, e.g. database.password, and sets those it returns a value for
+func walkSettings(v reflect.Value, key string, replace func(key, value string) (string, bool)) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			walkSettings(v.Elem(), key, replace)
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			walkSettings(v.Field(i), joinKey(key, name), replace)
+		}
+
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walkSettings(v.Index(i), fmt.Sprintf("%s[%d]", key, i), replace)
+		}
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		for _, mapKey := range v.MapKeys() {
+			if value, ok := replace(joinKey(key, mapKey.String()), v.MapIndex(mapKey).String()); ok {
+				v.SetMapIndex(mapKey, reflect.ValueOf(value).Convert(v.Type().Elem()))
+			}
+		}
+
+	case reflect.String:
+		if value, ok := replace(key, v.String()); ok && v.CanSet() {
+			v.SetString(value)
+		}
+	}
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
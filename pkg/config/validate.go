@@ -0,0 +1,507 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// minJWTSecretLength is the shortest JWT secret accepted outside
+// development, as HS256 keys shorter than its 256-bit output weaken it
+const minJWTSecretLength = 32
+
+// exampleJWTSecret is the JWT secret of the example configuration, which
+// must not make it to a deployment
+const exampleJWTSecret = "your-super-secret-jwt-key-change-this-in-production"
+
+// Problem is a setting that is missing or invalid
+type Problem struct {
+	// Key is the setting, e.g. database.host
+	Key     string
+	Message string
+}
+
+// ValidationError lists every problem found validating a configuration, so
+// they can all be fixed at once
+type ValidationError struct {
+	Problems []Problem
+}
+
+// Error lists the problems one per line
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d configuration problem(s):", len(e.Problems))
+	for _, problem := range e.Problems {
+		fmt.Fprintf(&b, "\n  - %s: %s", problem.Key, problem.Message)
+	}
+	b.WriteString("\nSettings are read from the config file, or from environment variables " +
+		"named after them, e.g. DATABASE_HOST for database.host")
+	return b.String()
+}
+
+// problems collects the problems of a configuration
+type problems struct {
+	list []Problem
+}
+
+// add records a problem with the setting key
+func (p *problems) add(key, format string, args ...interface{}) {
+	p.list = append(p.list, Problem{Key: key, Message: fmt.Sprintf(format, args...)})
+}
+
+// required records a problem when the setting key is blank
+func (p *problems) required(key, value string) {
+	if strings.TrimSpace(value) == "" {
+		p.add(key, "is required")
+	}
+}
+
+// port records a problem when the setting key isn't a port number
+func (p *problems) port(key string, value int) {
+	if value <= 0 || value > 65535 {
+		p.add(key, "must be a port between 1 and 65535, got %d", value)
+	}
+}
+
+// proxies records a problem when the setting key isn't a list of IP
+// addresses and CIDR ranges
+func (p *problems) proxies(key string, values []string) {
+	for _, value := range values {
+		if net.ParseIP(value) == nil {
+			if _, _, err := net.ParseCIDR(value); err != nil {
+				p.add(key, "must be IP addresses or CIDR ranges, got %q", value)
+			}
+		}
+	}
+}
+
+// oneOf records a problem when the setting key isn't one of values
+func (p *problems) oneOf(key, value string, values ...string) {
+	for _, allowed := range values {
+		if value == allowed {
+			return
+		}
+	}
+	p.add(key, "must be one of %s, got %q", strings.Join(values, ", "), value)
+}
+
+// ratio records a problem when the setting key isn't a share strictly
+// between 0 and 1
+func (p *problems) ratio(key string, value float64) {
+	if value <= 0 || value >= 1 {
+		p.add(key, "must be between 0 and 1 exclusive, got %v", value)
+	}
+}
+
+// validate validates the sections every service loads and those of the
+// loaded modules, reporting all their problems together
+func validate(config *Config) error {
+	p := &problems{}
+	strict := config.Environment != "development" && config.Environment != "test"
+
+	p.oneOf("environment", config.Environment, "development", "test", "staging", "production")
+	config.Logger.validate(p)
+	config.Metrics.validate(p)
+	config.Tracing.validate(p)
+	config.Services.Gateway.RateLimit.validate(p, "services.api_gateway.rate_limiting")
+	p.proxies("services.api_gateway.trusted_proxies", config.Services.Gateway.TrustedProxies)
+	config.Services.User.RateLimit.validate(p, "services.user_service.rate_limiting")
+	config.Services.User.Lockout.validate(p, "services.user_service.lockout")
+	config.Services.Resilience.validate(p)
+	config.Services.StockAlert.EmailPool.validate(p, "services.stock_alert_service.email_pool")
+	config.Services.TaskQueue.validate(p)
+
+	for _, module := range allModules {
+		if !config.modules[module] {
+			continue
+		}
+
+		switch module {
+		case ModuleServer:
+			config.Server.validate(p)
+		case ModuleDatabase:
+			config.Database.validate(p)
+		case ModuleRedis:
+			config.Redis.validate(p)
+		case ModuleKafka:
+			config.Kafka.validate(p)
+		case ModuleRabbitMQ:
+			config.RabbitMQ.validate(p)
+		case ModuleAuth:
+			config.Auth.validate(p, strict)
+		case ModuleStorage:
+			config.Storage.validate(p)
+		case ModuleTenancy:
+			config.Tenancy.validate(p)
+		case ModuleSeed:
+			config.Seed.validate(p)
+		}
+	}
+
+	if len(p.list) > 0 {
+		return &ValidationError{Problems: p.list}
+	}
+	return nil
+}
+
+func (r ResilienceConfig) validate(p *problems) {
+	r.Default.validate(p, "services.resilience.default")
+	for name, policy := range r.Dependencies {
+		policy.validate(p, "services.resilience.dependencies."+name)
+	}
+}
+
+func (r ResiliencePolicyConfig) validate(p *problems, key string) {
+	if r.Timeout < 0 {
+		p.add(key+".timeout", "must not be negative")
+	}
+	if r.MaxAttempts < 0 {
+		p.add(key+".max_attempts", "must not be negative")
+	}
+	if r.BackoffMin < 0 || r.BackoffMax < 0 {
+		p.add(key+".backoff_min", "must not be negative, nor must backoff_max")
+	}
+	if r.BackoffMax > 0 && r.BackoffMin > r.BackoffMax {
+		p.add(key+".backoff_min", "must not be longer than backoff_max (%s), got %s", r.BackoffMax, r.BackoffMin)
+	}
+	if r.Jitter < 0 || r.Jitter > 1 {
+		p.add(key+".jitter", "must be between 0 and 1, got %v", r.Jitter)
+	}
+	if r.RetryBudget < 0 || r.RetryBudget > 1 {
+		p.add(key+".retry_budget", "must be between 0 and 1, got %v", r.RetryBudget)
+	}
+	if r.FailureThreshold < 0 {
+		p.add(key+".failure_threshold", "must not be negative")
+	}
+	if r.OpenTimeout < 0 {
+		p.add(key+".open_timeout", "must not be negative")
+	}
+}
+
+func (t TaskQueueConfig) validate(p *problems) {
+	if t.Concurrency < 1 {
+		p.add("services.task_queue.concurrency", "must be at least 1, got %d", t.Concurrency)
+	}
+	if t.MaxRetry < 0 {
+		p.add("services.task_queue.max_retry", "must not be negative")
+	}
+	if t.Timeout < 0 || t.Retention < 0 {
+		p.add("services.task_queue.timeout", "must not be negative, nor must retention")
+	}
+}
+
+func (w WorkerPoolConfig) validate(p *problems, key string) {
+	if w.Size < 1 {
+		p.add(key+".size", "must be at least 1, got %d", w.Size)
+	}
+	if w.TaskTimeout < 0 {
+		p.add(key+".task_timeout", "must not be negative")
+	}
+}
+
+func (r RateLimitConfig) validate(p *problems, key string) {
+	if !r.Enabled {
+		return
+	}
+	p.oneOf(key+".algorithm", r.Algorithm, "token_bucket", "sliding_window")
+	p.oneOf(key+".backend", r.Backend, "memory", "redis")
+	if r.Limit < 1 {
+		p.add(key+".limit", "must be at least 1, got %d", r.Limit)
+	}
+	if r.Window <= 0 {
+		p.add(key+".window", "must be positive")
+	}
+	if r.Burst < 1 {
+		p.add(key+".burst", "must be at least 1, got %d", r.Burst)
+	}
+}
+
+func (l LoggerConfig) validate(p *problems) {
+	p.oneOf("logger.level", l.Level, "debug", "info", "warn", "error")
+	if l.Format != "" {
+		p.oneOf("logger.format", l.Format, "json", "console")
+	}
+	if l.Output != "" && l.Output != "stdout" {
+		p.required("logger.filename", l.Filename)
+	}
+
+	if l.Sampling.Tick < 0 {
+		p.add("logger.sampling.tick", "must not be negative")
+	}
+	if l.Sampling.Initial < 0 {
+		p.add("logger.sampling.initial", "must not be negative")
+	}
+	for level, thereafter := range l.Sampling.Thereafter {
+		key := "logger.sampling.thereafter." + level
+		p.oneOf(key, level, "debug", "info", "warn", "error")
+		if thereafter < 1 {
+			p.add(key, "must be at least 1, which keeps every line, got %d", thereafter)
+		}
+	}
+	if l.RateLimit.PerSecond < 0 {
+		p.add("logger.rate_limit.per_second", "must not be negative")
+	}
+
+	if l.Export.Provider != "" {
+		p.oneOf("logger.export.provider", l.Export.Provider, "otlp", "loki")
+		if u, err := url.Parse(l.Export.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			p.add("logger.export.endpoint", "must be an http(s) url, got %q", l.Export.Endpoint)
+		}
+		if l.Export.BatchSize > l.Export.BufferSize {
+			p.add("logger.export.batch_size", "must not be larger than buffer_size (%d), got %d", l.Export.BufferSize, l.Export.BatchSize)
+		}
+	}
+
+	if l.ErrorReporting.DSN != "" {
+		u, err := url.Parse(l.ErrorReporting.DSN)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.User == nil || strings.Trim(u.Path, "/") == "" {
+			p.add("logger.error_reporting.dsn", "must be a dsn such as https://key@host/project")
+		}
+		if l.ErrorReporting.BufferSize < 1 {
+			p.add("logger.error_reporting.buffer_size", "must be at least 1")
+		}
+	}
+
+	if l.Audit.Enabled {
+		p.oneOf("logger.audit.output", l.Audit.Output, "stdout", "stderr", "file")
+		if l.Audit.Output == "file" {
+			p.required("logger.audit.filename", l.Audit.Filename)
+			if l.Audit.Filename == l.Filename {
+				p.add("logger.audit.filename", "must not be the application log file")
+			}
+		}
+	}
+}
+
+func (m MetricsConfig) validate(p *problems) {
+	if !m.Enabled {
+		return
+	}
+	p.oneOf("metrics.provider", m.Provider, "prometheus", "otlp")
+	if m.RuntimeInterval < time.Second {
+		p.add("metrics.runtime_interval", "must be at least 1s")
+	}
+	if m.Provider == "otlp" {
+		if u, err := url.Parse(m.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			p.add("metrics.endpoint", "must be an http(s) url, got %q", m.Endpoint)
+		}
+	}
+	if m.Provider == "otlp" || m.Pushgateway.URL != "" {
+		if m.Interval < time.Second {
+			p.add("metrics.interval", "must be at least 1s")
+		}
+	}
+	if m.Pushgateway.URL != "" {
+		if u, err := url.Parse(m.Pushgateway.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			p.add("metrics.pushgateway.url", "must be an http(s) url, got %q", m.Pushgateway.URL)
+		}
+	}
+	if m.SLO.Enabled {
+		m.SLO.validate(p)
+	}
+}
+
+func (s SLOConfig) validate(p *problems) {
+	p.ratio("metrics.slo.availability", s.Availability)
+	p.ratio("metrics.slo.latency", s.Latency)
+	if s.LatencyThreshold <= 0 {
+		p.add("metrics.slo.latency_threshold", "must be positive")
+	}
+	for _, window := range s.Windows {
+		if window < time.Minute || window%time.Minute != 0 {
+			p.add("metrics.slo.windows", "must be whole minutes, got %s", window)
+		}
+	}
+}
+
+func (t TracingConfig) validate(p *problems) {
+	if !t.Enabled {
+		return
+	}
+	if t.SampleRate < 0 || t.SampleRate > 1 {
+		p.add("tracing.sample_rate", "must be between 0 and 1, got %v", t.SampleRate)
+	}
+	for i, route := range t.Routes {
+		key := fmt.Sprintf("tracing.routes[%d]", i)
+		p.required(key+".route", route.Route)
+		if route.SampleRate < 0 || route.SampleRate > 1 {
+			p.add(key+".sample_rate", "must be between 0 and 1, got %v", route.SampleRate)
+		}
+	}
+	if t.TailSampling.Enabled && t.TailSampling.LatencyThreshold <= 0 {
+		p.add("tracing.tail_sampling.latency_threshold", "must be positive")
+	}
+}
+
+func (r RemoteConfig) validate(p *problems) {
+	p.oneOf("remote.provider", r.Provider, "consul", "etcd")
+	if r.Endpoint == "" {
+		p.add("remote.endpoint", "is required")
+	} else if u, err := url.Parse(r.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		p.add("remote.endpoint", "must be an http(s) url, got %q", r.Endpoint)
+	}
+	p.required("remote.key", r.Key)
+	if r.WatchWait < time.Second {
+		p.add("remote.watch_wait", "must be at least 1s")
+	}
+}
+
+func (s ServerConfig) validate(p *problems) {
+	p.port("server.port", s.Port)
+	if s.ReadTimeout < 0 {
+		p.add("server.read_timeout", "must not be negative")
+	}
+	if s.WriteTimeout < 0 {
+		p.add("server.write_timeout", "must not be negative")
+	}
+	if s.ShutdownTimeout < 0 {
+		p.add("server.shutdown_timeout", "must not be negative")
+	}
+	p.proxies("server.trusted_proxies", s.TrustedProxies)
+	if s.Debug.Port != 0 {
+		p.port("server.debug.port", s.Debug.Port)
+		if s.Debug.Port == s.Port {
+			p.add("server.debug.port", "must differ from server.port")
+		}
+	}
+	if s.Debug.MutexProfileFraction < 0 {
+		p.add("server.debug.mutex_profile_fraction", "must not be negative")
+	}
+	if s.Debug.BlockProfileRate < 0 {
+		p.add("server.debug.block_profile_rate", "must not be negative")
+	}
+	if s.TLS.Enabled {
+		p.required("server.tls.cert_file", s.TLS.CertFile)
+		p.required("server.tls.key_file", s.TLS.KeyFile)
+	}
+}
+
+func (d DatabaseConfig) validate(p *problems) {
+	p.required("database.host", d.Host)
+	p.port("database.port", d.Port)
+	p.required("database.database", d.Database)
+	if d.SSLMode != "" {
+		p.oneOf("database.ssl_mode", d.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	}
+	if d.MaxOpenConns > 0 && d.MaxIdleConns > d.MaxOpenConns {
+		p.add("database.max_idle_conns", "must not exceed max_open_conns (%d), got %d", d.MaxOpenConns, d.MaxIdleConns)
+	}
+	if d.TenantPools.MaxPools < 0 {
+		p.add("database.tenant_pools.max_pools", "must not be negative")
+	}
+	if d.TenantPools.MaxOpenConns > 0 && d.TenantPools.MaxIdleConns > d.TenantPools.MaxOpenConns {
+		p.add("database.tenant_pools.max_idle_conns", "must not exceed max_open_conns (%d), got %d", d.TenantPools.MaxOpenConns, d.TenantPools.MaxIdleConns)
+	}
+
+	if !d.Vault.Enabled {
+		p.required("database.user", d.User)
+		return
+	}
+	p.required("database.vault.address", d.Vault.Address)
+	p.required("database.vault.token", d.Vault.Token)
+	p.required("database.vault.role", d.Vault.Role)
+	if d.Vault.RenewBefore <= 0 {
+		p.add("database.vault.renew_before", "must be positive")
+	}
+}
+
+func (r RedisConfig) validate(p *problems) {
+	p.required("redis.host", r.Host)
+	p.port("redis.port", r.Port)
+	if r.Database < 0 || r.Database > 15 {
+		p.add("redis.database", "must be between 0 and 15, got %d", r.Database)
+	}
+}
+
+func (k KafkaConfig) validate(p *problems) {
+	if len(k.Brokers) == 0 {
+		p.add("kafka.brokers", "must list at least one broker, e.g. localhost:9092")
+	}
+	for i, broker := range k.Brokers {
+		if !strings.Contains(broker, ":") {
+			p.add(fmt.Sprintf("kafka.brokers[%d]", i), "must be host:port, got %q", broker)
+		}
+	}
+	if k.RetryBackoffMax > 0 && k.RetryBackoffMin > k.RetryBackoffMax {
+		p.add("kafka.retry_backoff_min", "must not exceed retry_backoff_max (%s), got %s", k.RetryBackoffMax, k.RetryBackoffMin)
+	}
+}
+
+func (r RabbitMQConfig) validate(p *problems) {
+	p.required("rabbitmq.url", r.URL)
+	if r.URL != "" {
+		if u, err := url.Parse(r.URL); err != nil || (u.Scheme != "amqp" && u.Scheme != "amqps") {
+			p.add("rabbitmq.url", "must be an amqp:// or amqps:// URL")
+		}
+	}
+	p.required("rabbitmq.exchange", r.Exchange)
+}
+
+func (a AuthConfig) validate(p *problems, strict bool) {
+	secret := a.JWT.SecretKey
+	switch {
+	case strict && secret == exampleJWTSecret:
+		p.add("auth.jwt.secret_key", "is the example secret; generate one, e.g. with openssl rand -base64 48")
+	case strict && len(secret) < minJWTSecretLength:
+		p.add("auth.jwt.secret_key", "must be at least %d characters outside development, got %d", minJWTSecretLength, len(secret))
+	}
+	if a.JWT.Expiration < 0 {
+		p.add("auth.jwt.expiration", "must not be negative")
+	}
+	if a.JWT.RefreshExpiration > 0 && a.JWT.RefreshExpiration < a.JWT.Expiration {
+		p.add("auth.jwt.refresh_expiration", "must not be shorter than expiration (%s), got %s", a.JWT.Expiration, a.JWT.RefreshExpiration)
+	}
+
+	if a.OAuth2.Enabled && a.OAuth2.GoogleID == "" && a.OAuth2.GitHubID == "" {
+		p.add("auth.oauth2", "enabled without a Google or GitHub client")
+	}
+	if a.OAuth2.GoogleID != "" {
+		p.required("auth.oauth2.google_client_secret", a.OAuth2.GoogleSecret)
+	}
+	if a.OAuth2.GitHubID != "" {
+		p.required("auth.oauth2.github_client_secret", a.OAuth2.GitHubSecret)
+	}
+}
+
+func (s StorageConfig) validate(p *problems) {
+	p.oneOf("storage.driver", s.Driver, "local", "s3")
+	switch s.Driver {
+	case "local":
+		p.required("storage.local.path", s.Local.Path)
+	case "s3":
+		p.required("storage.s3.bucket", s.S3.Bucket)
+		p.required("storage.s3.region", s.S3.Region)
+		if (s.S3.AccessKeyID == "") != (s.S3.SecretAccessKey == "") {
+			p.add("storage.s3", "access_key_id and secret_access_key must be set together")
+		}
+	}
+}
+
+func (l LockoutConfig) validate(p *problems, key string) {
+	if !l.Enabled {
+		return
+	}
+	if l.MaxAttempts < 1 {
+		p.add(key+".max_attempts", "must be positive, got %d", l.MaxAttempts)
+	}
+	if l.Window <= 0 {
+		p.add(key+".window", "must be positive")
+	}
+}
+
+func (t TenancyConfig) validate(p *problems) {
+	if t.Enabled {
+		p.required("tenancy.header", t.Header)
+	}
+	if t.Required && !t.Enabled {
+		p.add("tenancy.required", "requires tenancy.enabled")
+	}
+}
+
+func (s SeedConfig) validate(p *problems) {
+	if s.AdminEmail != "" && s.AdminPassword == "" {
+		p.add("seed.admin_password", "is required to seed the admin user %s", s.AdminEmail)
+	}
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATE codes of the constraint violations repositories map to domain
+// errors
+const (
+	ForeignKeyViolation = "23503"
+	UniqueViolation     = "23505"
+)
+
+// SQLSTATE codes of transient failures, see IsTransient
+const (
+	SerializationFailure     = "40001"
+	DeadlockDetected         = "40P01"
+	connectionExceptionClass = "08"
+)
+
+// PgError returns the PostgreSQL error in the chain of err
+func PgError(err error) (*pgconn.PgError, bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr, true
+	}
+	return nil, false
+}
+
+// IsUniqueViolation reports whether err is a unique constraint violation
+func IsUniqueViolation(err error) bool {
+	pgErr, ok := PgError(err)
+	return ok && pgErr.Code == UniqueViolation
+}
+
+// IsForeignKeyViolation reports whether err is a foreign key violation
+func IsForeignKeyViolation(err error) bool {
+	pgErr, ok := PgError(err)
+	return ok && pgErr.Code == ForeignKeyViolation
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/retry"
+)
+
+// listenerBackoff is how long a listener waits before reconnecting
+var listenerBackoff = retry.Policy{
+	BackoffMin: time.Second,
+	BackoffMax: 30 * time.Second,
+	Jitter:     0.2,
+}
+
+// Notification is a notification received on a channel. Missed is set on
+// the notification a listener delivers to every handler after connecting:
+// notifications sent while it wasn't listening are lost, so handlers
+// should catch up, e.g. poll once, when it is set.
+type Notification struct {
+	Channel string
+	Payload string
+	Missed  bool
+}
+
+// NotificationHandler handles the notifications of a channel
+type NotificationHandler func(ctx context.Context, notification *Notification)
+
+// Listener receives Postgres notifications on a dedicated connection and
+// fans them out to the handlers registered for their channel. It
+// reconnects, with a backoff, when the connection is lost.
+type Listener struct {
+	db       *DB
+	mu       sync.RWMutex
+	handlers map[string][]NotificationHandler
+	logger   *logger.Logger
+}
+
+// NewListener creates a new listener on the database of db
+func NewListener(db *DB, log *logger.Logger) *Listener {
+	return &Listener{
+		db:       db,
+		handlers: make(map[string][]NotificationHandler),
+		logger:   log,
+	}
+}
+
+// Register adds a handler for the notifications of a channel. Handlers
+// must be registered before Run; they are called one at a time, so they
+// should return quickly.
+func (l *Listener) Register(channel string, handler NotificationHandler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.handlers[channel] = append(l.handlers[channel], handler)
+}
+
+// Run listens on the registered channels until ctx is cancelled
+func (l *Listener) Run(ctx context.Context) {
+	l.logger.Info("Database listener started", "channels", len(l.handlers))
+
+	for attempt := 1; ctx.Err() == nil; attempt++ {
+		listening, err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if listening {
+			attempt = 1
+		}
+
+		backoff := listenerBackoff.Backoff(attempt)
+		l.logger.Error("Database listener disconnected, reconnecting", "error", err, "backoff", backoff)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// listen connects, listens on the registered channels and dispatches
+// notifications until the connection fails. It reports whether it got as
+// far as listening.
+func (l *Listener) listen(ctx context.Context) (bool, error) {
+	conn, err := pgx.ConnectConfig(ctx, l.db.newConnConfig(ctx))
+	if err != nil {
+		return false, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	l.mu.RLock()
+	channels := make([]string, 0, len(l.handlers))
+	for channel := range l.handlers {
+		channels = append(channels, channel)
+	}
+	l.mu.RUnlock()
+
+	for _, channel := range channels {
+		if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return false, fmt.Errorf("failed to listen on %s: %w", channel, err)
+		}
+	}
+
+	for _, channel := range channels {
+		l.dispatch(ctx, &Notification{Channel: channel, Missed: true})
+	}
+
+	for {
+		received, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+		l.dispatch(ctx, &Notification{Channel: received.Channel, Payload: received.Payload})
+	}
+}
+
+// dispatch calls the handlers of the notification's channel
+func (l *Listener) dispatch(ctx context.Context, notification *Notification) {
+	l.mu.RLock()
+	handlers := l.handlers[notification.Channel]
+	l.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(ctx, notification)
+	}
+}
+
+// Notify sends a notification with a payload on a channel
+func (db *DB) Notify(ctx context.Context, channel, payload string) error {
+	if _, err := db.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, payload); err != nil {
+		return fmt.Errorf("failed to notify %s: %w", channel, err)
+	}
+	return nil
+}
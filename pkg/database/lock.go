@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrLockHeld is returned by WithLock when another holder has the lock
+var ErrLockHeld = errors.New("lock is held by another holder")
+
+// lockKeyPrefix namespaces lock keys
+const lockKeyPrefix = "lock:"
+
+// extendLock extends a lock only while the caller still holds it
+var extendLock = NewScript("extend_lock", `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseLock releases a lock only while the caller still holds it, so a
+// lock that expired and was taken by another holder is left alone
+var releaseLock = NewScript("release_lock", `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// WithLock runs fn while holding the lock key, so it runs on one replica at
+// a time. The lock expires after ttl unless extended, which it is every
+// third of ttl while fn runs, so a holder that dies doesn't keep it. When
+// the lock can't be extended, e.g. because Redis was unreachable for
+// longer than ttl, the context fn gets is cancelled, as another holder may
+// have taken the lock. WithLock returns ErrLockHeld without running fn when
+// the lock is held.
+func (r *Redis) WithLock(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) error) error {
+	key = lockKeyPrefix + key
+	token := uuid.NewString()
+
+	acquired, err := r.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return fmt.Errorf("failed to acquire lock %s: %w", key, err)
+	}
+	if !acquired {
+		return ErrLockHeld
+	}
+
+	lockCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	extended := make(chan struct{})
+	go func() {
+		defer close(extended)
+		r.extendLock(lockCtx, cancel, key, token, ttl)
+	}()
+
+	err = fn(lockCtx)
+
+	cancel()
+	<-extended
+
+	if releaseErr := r.RunScript(context.WithoutCancel(ctx), releaseLock, []string{key}, token).Err(); releaseErr != nil {
+		r.logger.Error("Failed to release lock, it expires on its own", "error", releaseErr, "key", key)
+	}
+
+	return err
+}
+
+// extendLock extends a lock every third of its ttl until ctx is done, and
+// calls lost once it no longer holds the lock
+func (r *Redis) extendLock(ctx context.Context, lost context.CancelFunc, key, token string, ttl time.Duration) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	extendedAt := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			held, err := r.RunScript(ctx, extendLock, []string{key}, token, ttl.Milliseconds()).Int()
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				// Try again on the next tick until the lock may have expired
+				r.logger.Warn("Failed to extend lock", "error", err, "key", key)
+				if time.Since(extendedAt) < ttl {
+					continue
+				}
+				held = 0
+			}
+			if held == 0 {
+				r.logger.Error("Lock lost, stopping its holder", "key", key)
+				lost()
+				return
+			}
+			extendedAt = time.Now()
+		}
+	}
+}
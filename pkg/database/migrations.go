@@ -3,43 +3,111 @@ package database
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 
 	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	pgxv5 "github.com/jackc/pgx/v5"
 	"github.com/jmoiron/sqlx"
 
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
 	"github.com/kaanevranportfolio/Commercium/pkg/logger"
 )
 
 // Migrator handles database migrations
 type Migrator struct {
 	migrate *migrate.Migrate
+	source  source.Driver
 	logger  *logger.Logger
 }
 
-// NewMigrator creates a new database migrator
+// MigrationStatus is whether a migration was applied
+type MigrationStatus struct {
+	Version uint   `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
+
+// MigrationSource is where migrations are read from: the directory Path
+// when it is set, the embedded FS otherwise. Which of them were applied is
+// recorded in Table of Schema, by default schema_migrations of the current
+// schema; services with migrations of their own sharing a database record
+// them in tables of their own.
+type MigrationSource struct {
+	Path   string
+	FS     fs.FS
+	Table  string
+	Schema string
+}
+
+// NewMigrationSource returns the source of the migrations of a database
+// configuration, reading those in fsys unless a migrations path is set
+func NewMigrationSource(cfg config.DatabaseConfig, fsys fs.FS) MigrationSource {
+	return MigrationSource{
+		Path:   cfg.MigrationsPath,
+		FS:     fsys,
+		Table:  cfg.MigrationsTable,
+		Schema: cfg.MigrationsSchema,
+	}
+}
+
+// NewMigrator creates a new database migrator reading migrations from a
+// directory
 func NewMigrator(db *sqlx.DB, migrationsPath string, log *logger.Logger) (*Migrator, error) {
-	driver, err := postgres.WithInstance(db.DB, &postgres.Config{})
+	return NewSourceMigrator(db, MigrationSource{Path: migrationsPath}, log)
+}
+
+// NewSourceMigrator creates a new database migrator reading migrations from
+// src
+func NewSourceMigrator(db *sqlx.DB, src MigrationSource, log *logger.Logger) (*Migrator, error) {
+	// The driver needs the schema of the version table to exist
+	if src.Schema != "" {
+		if _, err := db.Exec("CREATE SCHEMA IF NOT EXISTS " + pgxv5.Identifier{src.Schema}.Sanitize()); err != nil {
+			return nil, fmt.Errorf("failed to create migrations schema: %w", err)
+		}
+	}
+
+	driver, err := pgx.WithInstance(db.DB, &pgx.Config{MigrationsTable: src.Table, SchemaName: src.Schema})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create postgres driver: %w", err)
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", migrationsPath),
-		"postgres",
-		driver,
-	)
+	var sourceDriver source.Driver
+	if src.Path != "" {
+		sourceDriver, err = (&file.File{}).Open(fmt.Sprintf("file://%s", src.Path))
+	} else {
+		sourceDriver, err = iofs.New(src.FS, ".")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("migrations", sourceDriver, "pgx5", driver)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
 	}
 
 	return &Migrator{
 		migrate: m,
+		source:  sourceDriver,
 		logger:  log,
 	}, nil
 }
 
+// Migrate runs the pending migrations of src
+func (db *DB) Migrate(src MigrationSource) error {
+	migrator, err := NewSourceMigrator(db.DB, src, db.logger)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	return migrator.Up()
+}
+
 // Up runs all pending migrations
 func (m *Migrator) Up() error {
 	m.logger.Info("Running database migrations up")
@@ -104,6 +172,37 @@ func (m *Migrator) Version() (uint, bool, error) {
 	return version, dirty, nil
 }
 
+// Status returns every migration of the source and whether it was applied,
+// along with whether the last migration run failed halfway
+func (m *Migrator) Status() ([]MigrationStatus, bool, error) {
+	current, dirty, err := m.Version()
+	if err != nil {
+		return nil, false, err
+	}
+
+	statuses := []MigrationStatus{}
+	version, err := m.source.First()
+	for err == nil {
+		status := MigrationStatus{Version: version, Applied: version <= current && current > 0}
+		if body, name, readErr := m.source.ReadUp(version); readErr == nil {
+			body.Close()
+			status.Name = name
+		}
+		// A dirty version was started but not completed
+		if version == current && dirty {
+			status.Applied = false
+		}
+		statuses = append(statuses, status)
+
+		version, err = m.source.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, false, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	return statuses, dirty, nil
+}
+
 // Force sets the migration version without running migrations
 func (m *Migrator) Force(version int) error {
 	m.logger.Warn("Forcing migration version", "version", version)
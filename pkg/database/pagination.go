@@ -0,0 +1,65 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SortDirection is the direction a keyset-paginated listing is ordered in
+type SortDirection int
+
+const (
+	Ascending SortDirection = iota
+	Descending
+)
+
+// EncodeCursor serializes the position a listing continues after into an
+// opaque URL-safe token
+func EncodeCursor[T any](cursor T) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor parses a token produced by EncodeCursor. Checking the cursor
+// is complete is up to the caller.
+func DecodeCursor[T any](token string) (T, error) {
+	var cursor T
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, err
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, err
+	}
+	return cursor, nil
+}
+
+// KeysetCondition returns the condition selecting the rows after a cursor
+// in a listing ordered by columns, all in the same direction, e.g.
+// "(created_at, id) < ($3, $4)" for a listing ordered by created_at DESC,
+// id DESC. The last column must be unique so ties page deterministically.
+// values are the cursor's values of the columns; they are appended to args,
+// which is returned, and numbered after the arguments already in it.
+func KeysetCondition(args []interface{}, direction SortDirection, columns []string, values ...interface{}) (string, []interface{}) {
+	if len(columns) != len(values) {
+		panic(fmt.Sprintf("keyset of %d columns has %d values", len(columns), len(values)))
+	}
+
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		args = append(args, value)
+		placeholders[i] = fmt.Sprintf("$%d", len(args))
+	}
+
+	operator := ">"
+	if direction == Descending {
+		operator = "<"
+	}
+
+	return fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), operator, strings.Join(placeholders, ", ")), args
+}
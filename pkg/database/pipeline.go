@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// pipelineBatchSize bounds the commands sent in one round trip, so large
+// batches don't hold a connection, or Redis, for long
+const pipelineBatchSize = 500
+
+// Batch queues n commands, calling queue with the index of each, and sends
+// them in pipelines of up to pipelineBatchSize commands. Batches aren't
+// atomic: use a Script when the commands must not interleave with those of
+// other clients. Commands finding no value don't fail the batch; check each
+// command's error for redis.Nil.
+func (r *Redis) Batch(ctx context.Context, n int, queue func(pipe redis.Pipeliner, i int)) ([]redis.Cmder, error) {
+	cmds := make([]redis.Cmder, 0, n)
+	for start := 0; start < n; start += pipelineBatchSize {
+		end := min(start+pipelineBatchSize, n)
+
+		pipe := r.Pipeline()
+		for i := start; i < end; i++ {
+			queue(pipe, i)
+		}
+		batch, err := pipe.Exec(ctx)
+		cmds = append(cmds, batch...)
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return cmds, fmt.Errorf("failed to run pipeline: %w", err)
+		}
+	}
+
+	return cmds, nil
+}
+
+// GetMany gets the values of keys, leaving out the keys that have none
+func (r *Redis) GetMany(ctx context.Context, keys ...string) (map[string]string, error) {
+	values := make(map[string]string, len(keys))
+	for start := 0; start < len(keys); start += pipelineBatchSize {
+		batch := keys[start:min(start+pipelineBatchSize, len(keys))]
+
+		results, err := r.MGet(ctx, batch...).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get keys: %w", err)
+		}
+		for i, result := range results {
+			if value, ok := result.(string); ok {
+				values[batch[i]] = value
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// SetMany sets the values of keys with expiration, in pipelines
+func (r *Redis) SetMany(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	_, err := r.Batch(ctx, len(keys), func(pipe redis.Pipeliner, i int) {
+		pipe.Set(ctx, keys[i], values[keys[i]], expiration)
+	})
+	return err
+}
@@ -4,39 +4,83 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq" // PostgreSQL driver
 
 	"github.com/kaanevranportfolio/Commercium/pkg/config"
 	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+	"github.com/kaanevranportfolio/Commercium/pkg/retry"
 )
 
 // DB wraps sqlx.DB with additional functionality
 type DB struct {
 	*sqlx.DB
-	logger *logger.Logger
+	// connConfig opens connections outside the pool, e.g. for listeners
+	connConfig *pgx.ConnConfig
+	// credentials are issued by Vault when set, replacing those of
+	// connConfig
+	credentials *vaultCredentials
+	tracer      *queryTracer
+	// poolConfig sizes the connection pools of tenants like the shared one
+	poolConfig config.DatabaseConfig
+	tenants    tenantPools
+	retry      retry.Policy
+	done       chan struct{}
+	logger     *logger.Logger
 }
 
-// New creates a new database connection
+// New creates a new database connection. Queries go through pgx, which
+// prepares each statement once per connection and caches it. When Vault is
+// enabled for the database, connections log in with short-lived
+// credentials Vault issues, which are replaced before they expire.
 func New(cfg config.DatabaseConfig, log *logger.Logger) (*DB, error) {
-	dsn := cfg.DSN()
-	
-	db, err := sqlx.Connect("postgres", dsn)
+	connConfig, err := pgx.ParseConfig(cfg.DSN())
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, fmt.Errorf("invalid database configuration: %w", err)
+	}
+
+	// Every query is timed; slow ones are logged
+	tracer := &queryTracer{threshold: cfg.SlowQueryThreshold, logger: log}
+	connConfig.Tracer = tracer
+
+	var credentials *vaultCredentials
+	var options []stdlib.OptionOpenDB
+	maxLifetime := cfg.MaxLifetime
+	if cfg.Vault.Enabled {
+		credentials = newVaultCredentials(cfg.Vault, log)
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Vault.Timeout)
+		err := credentials.fetch(ctx)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, stdlib.OptionBeforeConnect(credentials.apply))
+
+		// Connections are retired before the credentials they logged in
+		// with expire
+		if lifetime := credentials.lead() / 2; maxLifetime <= 0 || maxLifetime > lifetime {
+			maxLifetime = lifetime
+		}
 	}
 
+	db := sqlx.NewDb(stdlib.OpenDB(*connConfig, options...), "pgx")
+
 	// Configure connection pool
 	db.SetMaxOpenConns(cfg.MaxOpenConns)
 	db.SetMaxIdleConns(cfg.MaxIdleConns)
-	db.SetConnMaxLifetime(cfg.MaxLifetime)
+	db.SetConnMaxLifetime(maxLifetime)
 	db.SetConnMaxIdleTime(cfg.MaxIdleTime)
 
 	// Test the connection
 	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	log.Info("Database connection established",
@@ -47,15 +91,52 @@ func New(cfg config.DatabaseConfig, log *logger.Logger) (*DB, error) {
 		"max_idle_conns", cfg.MaxIdleConns,
 	)
 
-	return &DB{
-		DB:     db,
-		logger: log,
-	}, nil
+	// Tenant pools retire their connections the same way
+	cfg.MaxLifetime = maxLifetime
+
+	result := &DB{
+		DB:          db,
+		connConfig:  connConfig,
+		credentials: credentials,
+		tracer:      tracer,
+		poolConfig:  cfg,
+		retry:       retry.FromConfig(cfg.Retry, defaultRetryPolicy),
+		done:        make(chan struct{}),
+		logger:      log,
+	}
+	if credentials != nil {
+		go credentials.run(result.done)
+	}
+
+	return result, nil
+}
+
+// newConnConfig returns the configuration of a connection opened outside
+// the pool, logging in with the current credentials
+func (db *DB) newConnConfig(ctx context.Context) *pgx.ConnConfig {
+	connConfig := db.connConfig.Copy()
+	if db.credentials != nil {
+		db.credentials.apply(ctx, connConfig)
+	}
+	return connConfig
+}
+
+// Instrument records the duration of every query in registry, labeled by
+// the operation name set with WithOperation, and exports the connection
+// pool stats to it until the database is closed. A nil registry is ignored.
+func (db *DB) Instrument(registry metrics.Registry, serviceName string) {
+	if registry == nil {
+		return
+	}
+	db.tracer.metrics.Store(&queryMetrics{registry: registry, serviceName: serviceName})
+	go exportPoolStats(registry, "postgres", serviceName, db.poolStats, db.done)
 }
 
 // Close closes the database connection
 func (db *DB) Close() error {
 	db.logger.Info("Closing database connection")
+	close(db.done)
+	db.closeTenantPools()
 	return db.DB.Close()
 }
 
@@ -72,32 +153,33 @@ func (db *DB) Stats() sql.DBStats {
 	return db.DB.Stats()
 }
 
-// Transaction executes a function within a database transaction
-func (db *DB) Transaction(fn func(*sqlx.Tx) error) error {
-	tx, err := db.Beginx()
+// CopyFrom bulk-inserts rows into the columns of a table, e.g.
+// "public.orders", with the COPY protocol, which is much faster than INSERTs
+// for large batches, and returns how many rows were copied
+func (db *DB) CopyFrom(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error) {
+	conn, err := db.Conn(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return 0, fmt.Errorf("failed to get connection: %w", err)
 	}
-	
-	defer func() {
-		if p := recover(); p != nil {
-			if rbErr := tx.Rollback(); rbErr != nil {
-				db.logger.Error("Failed to rollback transaction during panic", "error", rbErr)
-			}
-			panic(p) // re-throw panic after rollback
-		}
-	}()
+	defer conn.Close()
 
-	if err := fn(tx); err != nil {
-		if rbErr := tx.Rollback(); rbErr != nil {
-			db.logger.Error("Failed to rollback transaction", "error", rbErr)
-		}
+	var copied int64
+	err = conn.Raw(func(driverConn interface{}) error {
+		pgxConn := driverConn.(*stdlib.Conn).Conn()
+		copied, err = pgxConn.CopyFrom(ctx, pgx.Identifier(strings.Split(table, ".")), columns, pgx.CopyFromRows(rows))
 		return err
+	})
+	if err != nil {
+		return copied, fmt.Errorf("failed to copy rows into %s: %w", table, err)
 	}
+	return copied, nil
+}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
+// StringArray is a text[] column. pgx writes it as is; it only needs
+// help scanning it.
+type StringArray []string
 
-	return nil
+// Scan implements sql.Scanner
+func (a *StringArray) Scan(src interface{}) error {
+	return pgtype.NewMap().SQLScanner((*[]string)(a)).Scan(src)
 }
@@ -9,11 +9,13 @@ import (
 
 	"github.com/kaanevranportfolio/Commercium/pkg/config"
 	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
 )
 
 // Redis wraps redis.Client with additional functionality
 type Redis struct {
 	*redis.Client
+	done   chan struct{}
 	logger *logger.Logger
 }
 
@@ -29,6 +31,9 @@ func NewRedis(cfg config.RedisConfig, log *logger.Logger) (*Redis, error) {
 		WriteTimeout: cfg.WriteTimeout,
 	})
 
+	// Commands sent in a trace get spans of their own
+	client.AddHook(newRedisTracer(client.Options().Addr, cfg.Database))
+
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -44,15 +49,33 @@ func NewRedis(cfg config.RedisConfig, log *logger.Logger) (*Redis, error) {
 		"pool_size", cfg.PoolSize,
 	)
 
-	return &Redis{
+	r := &Redis{
 		Client: client,
+		done:   make(chan struct{}),
 		logger: log,
-	}, nil
+	}
+
+	// Scripts are sent on their first run when they can't be loaded now
+	if err := r.LoadScripts(ctx); err != nil {
+		log.Warn("Failed to load Redis scripts", "error", err)
+	}
+
+	return r, nil
+}
+
+// Instrument exports the connection pool stats to registry until the
+// client is closed. A nil registry is ignored.
+func (r *Redis) Instrument(registry metrics.Registry, serviceName string) {
+	if registry == nil {
+		return
+	}
+	go exportPoolStats(registry, "redis", serviceName, r.poolStats, r.done)
 }
 
 // Close closes the Redis connection
 func (r *Redis) Close() error {
 	r.logger.Info("Closing Redis connection")
+	close(r.done)
 	return r.Client.Close()
 }
 
@@ -74,6 +97,30 @@ func (r *Redis) GetString(ctx context.Context, key string) (string, error) {
 	return r.Get(ctx, key).Result()
 }
 
+// GetInt gets an integer value by key, 0 when the key doesn't exist
+func (r *Redis) GetInt(ctx context.Context, key string) (int64, error) {
+	value, err := r.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return value, err
+}
+
+// IncrWithExpiration increments an integer value by key, which then
+// expires after expiration, and returns the incremented value
+func (r *Redis) IncrWithExpiration(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := r.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		pipe.PExpire(ctx, key, expiration)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
 // Exists checks if a key exists
 func (r *Redis) Exists(ctx context.Context, key string) (bool, error) {
 	result := r.Client.Exists(ctx, key)
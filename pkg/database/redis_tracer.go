@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// redisTracerName is the name of the tracer of Redis command spans
+const redisTracerName = "redis"
+
+// redisTracer runs the commands sent to Redis in a trace in client spans of
+// their own. Their statements carry the command name only, as keys and
+// values may hold tokens or personal data.
+type redisTracer struct {
+	attributes []attribute.KeyValue
+}
+
+// newRedisTracer returns the tracer of the commands of a client of the
+// server at addr
+func newRedisTracer(addr string, database int) *redisTracer {
+	attributes := []attribute.KeyValue{
+		tracing.DBSystemKey.String(tracing.DBSystemRedis),
+		attribute.Int("db.redis.database_index", database),
+	}
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		port, _ := strconv.Atoi(port)
+		attributes = append(attributes, tracing.ServerAttributes(host, port)...)
+	}
+	return &redisTracer{attributes: attributes}
+}
+
+// DialHook implements redis.Hook
+func (t *redisTracer) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook implements redis.Hook
+func (t *redisTracer) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			return next(ctx, cmd)
+		}
+
+		name := strings.ToUpper(cmd.Name())
+		ctx, span := t.start(ctx, "redis "+name, name, sanitizeCommand(cmd))
+		defer span.End()
+
+		err := next(ctx, cmd)
+		t.end(span, err)
+		return err
+	}
+}
+
+// ProcessPipelineHook implements redis.Hook
+func (t *redisTracer) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			return next(ctx, cmds)
+		}
+
+		statements := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			statements[i] = sanitizeCommand(cmd)
+		}
+		ctx, span := t.start(ctx, "redis pipeline", "pipeline", strings.Join(statements, "\n"),
+			tracing.DBBatchSizeKey.Int(len(cmds)))
+		defer span.End()
+
+		err := next(ctx, cmds)
+		t.end(span, err)
+		return err
+	}
+}
+
+// start starts the span of a command or pipeline
+func (t *redisTracer) start(ctx context.Context, spanName, operation, statement string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	attributes = append(attributes,
+		tracing.DBOperationKey.String(operation),
+		tracing.DBStatementKey.String(statement),
+	)
+	return tracing.GetTracer(redisTracerName).Start(ctx, spanName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(t.attributes...),
+		trace.WithAttributes(attributes...))
+}
+
+// end records the error a command failed with on its span. A missing key
+// isn't one.
+func (t *redisTracer) end(span trace.Span, err error) {
+	if !errors.Is(err, redis.Nil) {
+		tracing.RecordError(span, err)
+	}
+}
+
+// sanitizeCommand returns the statement of cmd, its name followed by a ?
+// for each of its arguments, e.g. SET ? ? ? ?
+func sanitizeCommand(cmd redis.Cmder) string {
+	args := cmd.Args()
+	if len(args) == 0 {
+		return strings.ToUpper(cmd.Name())
+	}
+	return strings.ToUpper(cmd.Name()) + strings.Repeat(" ?", len(args)-1)
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/retry"
+)
+
+// defaultRetryPolicy retries transient errors a couple of times quickly,
+// with a lot of jitter so transactions that deadlocked don't collide again
+var defaultRetryPolicy = retry.Policy{
+	MaxAttempts: 3,
+	BackoffMin:  20 * time.Millisecond,
+	BackoffMax:  500 * time.Millisecond,
+	Jitter:      0.5,
+}
+
+// IsTransient reports whether err is a failure running the operation again
+// may get past: a serialization failure, a deadlock or a lost connection.
+// A connection lost while committing is not, as the transaction may have
+// been committed, see ErrCommitUnknown.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, ErrCommitUnknown) {
+		return false
+	}
+	if pgErr, ok := PgError(err); ok {
+		return pgErr.Code == SerializationFailure || pgErr.Code == DeadlockDetected ||
+			strings.HasPrefix(pgErr.Code, connectionExceptionClass)
+	}
+	return pgconn.SafeToRetry(err) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Retry runs fn, running it again after a backoff while it fails with a
+// transient error, as often as the retry policy of the database allows. fn
+// must be safe to run more than once: an idempotent operation, or a whole
+// transaction, which is rolled back when it fails.
+func (db *DB) Retry(ctx context.Context, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || !IsTransient(err) || !db.retry.Retryable(attempt) {
+			return err
+		}
+
+		db.logger.Warn("Transient database error, retrying", "error", err,
+			"operation", operation(ctx, ""), "attempt", attempt)
+
+		timer := time.NewTimer(db.retry.Backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// TransactionRetry runs fn in a transaction, running the whole transaction
+// again while it fails with a transient error. fn must not have effects
+// outside the transaction, e.g. appending to a result it doesn't reset.
+// Nested in another transaction, fn runs once: only the outer transaction
+// can be run again.
+func (db *DB) TransactionRetry(ctx context.Context, fn func(*sqlx.Tx) error) error {
+	run := func(ctx context.Context) error {
+		return db.TransactionContext(ctx, nil, func(_ context.Context, tx *sqlx.Tx) error {
+			return fn(tx)
+		})
+	}
+	if _, nested := TxFromContext(ctx); nested {
+		return run(ctx)
+	}
+	return db.Retry(ctx, run)
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTransient(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{"serialization failure", &pgconn.PgError{Code: SerializationFailure}, true},
+		{"deadlock", fmt.Errorf("failed to update order: %w", &pgconn.PgError{Code: DeadlockDetected}), true},
+		{"connection exception", &pgconn.PgError{Code: "08006"}, true},
+		{"connection reset", syscall.ECONNRESET, true},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"bad connection", driver.ErrBadConn, true},
+		{"unique violation", &pgconn.PgError{Code: UniqueViolation}, false},
+		{"no rows", sql.ErrNoRows, false},
+		{"cancelled", context.Canceled, false},
+		{"commit of unknown outcome", commitError(syscall.ECONNRESET), false},
+		{"commit lost on EOF", fmt.Errorf("failed to commit transaction: %w", commitError(io.ErrUnexpectedEOF)), false},
+		{"commit refused by the server", commitError(&pgconn.PgError{Code: SerializationFailure}), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.transient, IsTransient(tc.err))
+		})
+	}
+}
+
+func TestCommitError(t *testing.T) {
+	// PostgreSQL answered the COMMIT, so the transaction was rolled back
+	refused := &pgconn.PgError{Code: SerializationFailure}
+	assert.NotErrorIs(t, commitError(refused), ErrCommitUnknown)
+	assert.NotErrorIs(t, commitError(sql.ErrTxDone), ErrCommitUnknown)
+	assert.NotErrorIs(t, commitError(context.DeadlineExceeded), ErrCommitUnknown)
+
+	// The connection was lost waiting for the answer
+	lost := commitError(syscall.ECONNRESET)
+	assert.ErrorIs(t, lost, ErrCommitUnknown)
+	assert.ErrorIs(t, lost, syscall.ECONNRESET)
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Script is a Lua script run atomically by Redis, for operations on several
+// keys that must not interleave with other clients' commands. Scripts are
+// run by their SHA1 with EVALSHA, so their source is only sent when Redis
+// doesn't have them cached, e.g. after a restart or failover.
+type Script struct {
+	name string
+	src  string
+	hash string
+}
+
+var (
+	scriptsMu sync.Mutex
+	scripts   []*Script
+)
+
+// NewScript registers a script. Registered scripts are loaded into Redis
+// when a client connects; declare scripts as package variables so they are
+// registered by then.
+func NewScript(name, src string) *Script {
+	sum := sha1.Sum([]byte(src))
+	script := &Script{name: name, src: src, hash: hex.EncodeToString(sum[:])}
+
+	scriptsMu.Lock()
+	scripts = append(scripts, script)
+	scriptsMu.Unlock()
+
+	return script
+}
+
+// Name returns the name of the script
+func (s *Script) Name() string {
+	return s.name
+}
+
+// RunScript runs script with EVALSHA, falling back to EVAL when Redis
+// doesn't have it cached, which caches it again
+func (r *Redis) RunScript(ctx context.Context, script *Script, keys []string, args ...interface{}) *redis.Cmd {
+	cmd := r.EvalSha(ctx, script.hash, keys, args...)
+	if redis.HasErrorPrefix(cmd.Err(), "NOSCRIPT") {
+		r.logger.Debug("Script not cached by Redis, sending it", "script", script.name)
+		return r.Eval(ctx, script.src, keys, args...)
+	}
+	return cmd
+}
+
+// LoadScripts loads the registered scripts into Redis in one round trip, so
+// their first runs don't need to send them
+func (r *Redis) LoadScripts(ctx context.Context) error {
+	scriptsMu.Lock()
+	registered := append([]*Script(nil), scripts...)
+	scriptsMu.Unlock()
+
+	if len(registered) == 0 {
+		return nil
+	}
+
+	pipe := r.Pipeline()
+	cmds := make([]*redis.StringCmd, len(registered))
+	for i, script := range registered {
+		cmds[i] = pipe.ScriptLoad(ctx, script.src)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to load scripts: %w", err)
+	}
+
+	for i, script := range registered {
+		if hash := cmds[i].Val(); hash != script.hash {
+			return fmt.Errorf("script %s loaded as %s, expected %s", script.name, hash, script.hash)
+		}
+	}
+
+	r.logger.Debug("Scripts loaded", "count", len(registered))
+	return nil
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+)
+
+// poolStatsInterval is how often connection pool stats are exported
+const poolStatsInterval = 15 * time.Second
+
+// exportPoolStats sets the connections of a pool by state, as reported by
+// stats, in the database_connections gauge every poolStatsInterval until
+// done is closed
+func exportPoolStats(registry metrics.Registry, database, serviceName string, stats func() map[string]int, done <-chan struct{}) {
+	ticker := time.NewTicker(poolStatsInterval)
+	defer ticker.Stop()
+
+	for {
+		for state, count := range stats() {
+			registry.SetDBConnections(database, state, serviceName, float64(count))
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poolStats returns the connections of the pool by state
+func (db *DB) poolStats() map[string]int {
+	stats := db.DB.Stats()
+	return map[string]int{
+		"open":   stats.OpenConnections,
+		"in_use": stats.InUse,
+		"idle":   stats.Idle,
+	}
+}
+
+// poolStats returns the connections of the pool by state
+func (r *Redis) poolStats() map[string]int {
+	stats := r.PoolStats()
+	return map[string]int{
+		"open":   int(stats.TotalConns),
+		"in_use": int(stats.TotalConns) - int(stats.IdleConns),
+		"idle":   int(stats.IdleConns),
+	}
+}
@@ -0,0 +1,422 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/tenant"
+)
+
+// tenantSchemaPrefix prefixes the schema of each tenant
+const tenantSchemaPrefix = "tenant_"
+
+// TenantSchema returns the name of the schema holding a tenant's data
+func TenantSchema(id string) (string, error) {
+	if err := tenant.Validate(id); err != nil {
+		return "", err
+	}
+	return tenantSchemaPrefix + id, nil
+}
+
+// tenantSearchPath returns the search path of a tenant's connections: its
+// schema alone, so a table missing from it fails rather than reading the
+// shared one of the same name
+func tenantSearchPath(id string) (string, error) {
+	schema, err := TenantSchema(id)
+	if err != nil {
+		return "", err
+	}
+	return pgx.Identifier{schema}.Sanitize(), nil
+}
+
+// ErrUnknownTenant is returned by queries of a tenant that isn't
+// provisioned, i.e. has no schema
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+const (
+	// tenantsRefreshInterval is how long the provisioned tenants are known
+	// for before a tenant missing from them is looked up again
+	tenantsRefreshInterval = 30 * time.Second
+	// tenantPoolCloseDelay is how long the pool of a tenant is closed after
+	// it is dropped, for queries that already got it to finish
+	tenantPoolCloseDelay = time.Minute
+)
+
+// tenantPools are the connection pools of tenants, by tenant. Connections
+// of a tenant's pool are scoped to its schema as they are opened, so every
+// query, in a transaction or not, reads and writes the tenant's tables.
+// Pools are only opened for provisioned tenants, at most MaxPools of them
+// at once.
+type tenantPools struct {
+	mu    sync.Mutex
+	pools map[string]*tenantPool
+	// known are the provisioned tenants as of knownAt, looked up by one
+	// caller at a time under refresh
+	known   map[string]bool
+	knownAt time.Time
+	refresh sync.Mutex
+	// refused is the pool of tenants that aren't provisioned, whose
+	// connections fail to open
+	refused *sqlx.DB
+}
+
+// tenantPool is the connection pool of a tenant, with when it was last used
+type tenantPool struct {
+	*sqlx.DB
+	usedAt time.Time
+}
+
+// pool returns the connection pool of the tenant ctx carries, or the pool
+// shared by requests without a tenant
+func (db *DB) pool(ctx context.Context) *sqlx.DB {
+	id, ok := tenant.FromContext(ctx)
+	if !ok {
+		return db.DB
+	}
+
+	exists, err := db.TenantExists(ctx, id)
+	if err != nil {
+		db.logger.Error("Failed to look up tenant", "error", err, "tenant", id)
+	}
+	if !exists {
+		return db.refusedPool()
+	}
+
+	db.tenants.mu.Lock()
+	defer db.tenants.mu.Unlock()
+	if pool, ok := db.tenants.pools[id]; ok {
+		pool.usedAt = time.Now()
+		return pool.DB
+	}
+	if db.tenants.pools == nil {
+		db.tenants.pools = make(map[string]*tenantPool)
+	}
+	if len(db.tenants.pools) >= db.poolConfig.TenantPools.MaxPools {
+		db.dropLeastUsedTenantPool()
+	}
+	pool := &tenantPool{DB: db.openTenantPool(id), usedAt: time.Now()}
+	db.tenants.pools[id] = pool
+	return pool.DB
+}
+
+// TenantExists reports whether a tenant is provisioned. Tenants are looked
+// up again when one is missing from those known for longer than
+// tenantsRefreshInterval, so a tenant provisioned by another process is
+// found shortly after, while requests naming unknown tenants don't each
+// reach the database.
+func (db *DB) TenantExists(ctx context.Context, id string) (bool, error) {
+	if tenant.Validate(id) != nil {
+		return false, nil
+	}
+	if exists, fresh := db.knownTenant(id); exists || fresh {
+		return exists, nil
+	}
+
+	db.tenants.refresh.Lock()
+	defer db.tenants.refresh.Unlock()
+	// Another caller may have looked tenants up while this one waited
+	if exists, fresh := db.knownTenant(id); exists || fresh {
+		return exists, nil
+	}
+
+	tenants, err := db.Tenants(ctx)
+	if err != nil {
+		return false, err
+	}
+	known := make(map[string]bool, len(tenants))
+	for _, t := range tenants {
+		known[t] = true
+	}
+
+	db.tenants.mu.Lock()
+	db.tenants.known = known
+	db.tenants.knownAt = time.Now()
+	db.tenants.mu.Unlock()
+	return known[id], nil
+}
+
+// knownTenant reports whether a tenant is known to be provisioned, and
+// whether the known tenants are fresh enough to tell it isn't
+func (db *DB) knownTenant(id string) (exists, fresh bool) {
+	db.tenants.mu.Lock()
+	defer db.tenants.mu.Unlock()
+	return db.tenants.known[id], time.Since(db.tenants.knownAt) < tenantsRefreshInterval
+}
+
+// addKnownTenant records a tenant provisioned by this process
+func (db *DB) addKnownTenant(id string) {
+	db.tenants.mu.Lock()
+	defer db.tenants.mu.Unlock()
+	if db.tenants.known == nil {
+		db.tenants.known = make(map[string]bool)
+	}
+	db.tenants.known[id] = true
+}
+
+// dropLeastUsedTenantPool drops the pool of the tenant used the longest
+// ago, closing it once queries that got it had time to finish. It is
+// called with the lock of the pools held.
+func (db *DB) dropLeastUsedTenantPool() {
+	var leastID string
+	var least *tenantPool
+	for id, pool := range db.tenants.pools {
+		if least == nil || pool.usedAt.Before(least.usedAt) {
+			leastID, least = id, pool
+		}
+	}
+	if least == nil {
+		return
+	}
+
+	delete(db.tenants.pools, leastID)
+	time.AfterFunc(tenantPoolCloseDelay, func() {
+		if err := least.Close(); err != nil {
+			db.logger.Error("Failed to close tenant connection pool", "error", err, "tenant", leastID)
+		}
+	})
+}
+
+// openTenantPool opens the connection pool of a tenant, sized by the
+// tenant pool settings. Connections of an invalid tenant fail to open,
+// rather than fall back to the shared tables.
+func (db *DB) openTenantPool(id string) *sqlx.DB {
+	connConfig := db.connConfig.Copy()
+	searchPath, err := tenantSearchPath(id)
+	if err == nil {
+		connConfig.RuntimeParams["search_path"] = searchPath
+	}
+
+	options := []stdlib.OptionOpenDB{
+		stdlib.OptionBeforeConnect(func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+			if err != nil {
+				return err
+			}
+			if db.credentials != nil {
+				return db.credentials.apply(ctx, connConfig)
+			}
+			return nil
+		}),
+	}
+	pool := sqlx.NewDb(stdlib.OpenDB(*connConfig, options...), "pgx")
+	pool.SetMaxOpenConns(db.poolConfig.TenantPools.MaxOpenConns)
+	pool.SetMaxIdleConns(db.poolConfig.TenantPools.MaxIdleConns)
+	pool.SetConnMaxLifetime(db.poolConfig.MaxLifetime)
+	pool.SetConnMaxIdleTime(db.poolConfig.MaxIdleTime)
+	return pool
+}
+
+// refusedPool returns the pool shared by tenants that aren't provisioned,
+// whose connections fail to open with ErrUnknownTenant
+func (db *DB) refusedPool() *sqlx.DB {
+	db.tenants.mu.Lock()
+	defer db.tenants.mu.Unlock()
+	if db.tenants.refused == nil {
+		options := []stdlib.OptionOpenDB{
+			stdlib.OptionBeforeConnect(func(context.Context, *pgx.ConnConfig) error {
+				return ErrUnknownTenant
+			}),
+		}
+		db.tenants.refused = sqlx.NewDb(stdlib.OpenDB(*db.connConfig.Copy(), options...), "pgx")
+		db.tenants.refused.SetMaxIdleConns(0)
+	}
+	return db.tenants.refused
+}
+
+// closeTenantPools closes the connection pools of tenants
+func (db *DB) closeTenantPools() {
+	db.tenants.mu.Lock()
+	defer db.tenants.mu.Unlock()
+	for id, pool := range db.tenants.pools {
+		if err := pool.Close(); err != nil {
+			db.logger.Error("Failed to close tenant connection pool", "error", err, "tenant", id)
+		}
+	}
+	db.tenants.pools = nil
+	if db.tenants.refused != nil {
+		db.tenants.refused.Close()
+		db.tenants.refused = nil
+	}
+}
+
+// The methods below run on the connection pool of the tenant ctx carries,
+// so repositories are scoped to it without knowing about tenants
+
+// ExecContext executes a query without returning rows
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.pool(ctx).ExecContext(ctx, query, args...)
+}
+
+// GetContext scans the single row of a query into dest
+func (db *DB) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return db.pool(ctx).GetContext(ctx, dest, query, args...)
+}
+
+// SelectContext scans the rows of a query into dest
+func (db *DB) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return db.pool(ctx).SelectContext(ctx, dest, query, args...)
+}
+
+// QueryContext executes a query returning rows
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.pool(ctx).QueryContext(ctx, query, args...)
+}
+
+// QueryxContext executes a query returning sqlx rows
+func (db *DB) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	return db.pool(ctx).QueryxContext(ctx, query, args...)
+}
+
+// QueryRowContext executes a query returning at most one row
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return db.pool(ctx).QueryRowContext(ctx, query, args...)
+}
+
+// QueryRowxContext executes a query returning at most one sqlx row
+func (db *DB) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	return db.pool(ctx).QueryRowxContext(ctx, query, args...)
+}
+
+// NamedExecContext executes a query with named parameters
+func (db *DB) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	return db.pool(ctx).NamedExecContext(ctx, query, arg)
+}
+
+// NamedQueryContext executes a query with named parameters returning rows
+func (db *DB) NamedQueryContext(ctx context.Context, query string, arg interface{}) (*sqlx.Rows, error) {
+	return db.pool(ctx).NamedQueryContext(ctx, query, arg)
+}
+
+// PrepareNamedContext prepares a statement with named parameters
+func (db *DB) PrepareNamedContext(ctx context.Context, query string) (*sqlx.NamedStmt, error) {
+	return db.pool(ctx).PrepareNamedContext(ctx, query)
+}
+
+// PreparexContext prepares a statement
+func (db *DB) PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error) {
+	return db.pool(ctx).PreparexContext(ctx, query)
+}
+
+// PrepareContext prepares a statement
+func (db *DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return db.pool(ctx).PrepareContext(ctx, query)
+}
+
+// BeginTxx begins a transaction
+func (db *DB) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	return db.pool(ctx).BeginTxx(ctx, opts)
+}
+
+// BeginTx begins a transaction
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return db.pool(ctx).BeginTx(ctx, opts)
+}
+
+// Connx returns a single connection of the pool
+func (db *DB) Connx(ctx context.Context) (*sqlx.Conn, error) {
+	return db.pool(ctx).Connx(ctx)
+}
+
+// Conn returns a single connection of the pool
+func (db *DB) Conn(ctx context.Context) (*sql.Conn, error) {
+	return db.pool(ctx).Conn(ctx)
+}
+
+// CreateTenant creates the schema of a tenant, if it doesn't exist yet, and
+// migrates it with the migrations of src
+func (db *DB) CreateTenant(ctx context.Context, id string, src MigrationSource) error {
+	schema, err := TenantSchema(id)
+	if err != nil {
+		return err
+	}
+	if _, err := db.DB.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+pgx.Identifier{schema}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to create tenant schema: %w", err)
+	}
+	if err := db.migrateTenant(id, src); err != nil {
+		return err
+	}
+	db.addKnownTenant(id)
+	return nil
+}
+
+// Tenants returns the tenants that have a schema, in order
+func (db *DB) Tenants(ctx context.Context) ([]string, error) {
+	schemas := []string{}
+	err := db.DB.SelectContext(ctx, &schemas, `
+		SELECT schema_name FROM information_schema.schemata
+		WHERE starts_with(schema_name, $1)
+		ORDER BY schema_name`, tenantSchemaPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenant schemas: %w", err)
+	}
+
+	tenants := make([]string, 0, len(schemas))
+	for _, schema := range schemas {
+		tenants = append(tenants, strings.TrimPrefix(schema, tenantSchemaPrefix))
+	}
+	return tenants, nil
+}
+
+// MigrateTenants migrates the schema of every tenant with the migrations of
+// src. Each schema keeps its own migration version, so a tenant
+// that fails to migrate doesn't hold back the others; the first error is
+// returned once all were attempted.
+func (db *DB) MigrateTenants(ctx context.Context, src MigrationSource) error {
+	tenants, err := db.Tenants(ctx)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, id := range tenants {
+		if err := db.migrateTenant(id, src); err != nil {
+			db.logger.Error("Failed to migrate tenant schema", "error", err, "tenant", id)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("tenant %s: %w", id, err)
+			}
+		}
+	}
+
+	db.logger.Info("Tenant schemas migrated", "tenants", len(tenants))
+	return firstErr
+}
+
+// migrateTenant migrates the schema of a tenant over connections whose
+// search path is that schema, so the migrations and their version table
+// land in it. Shared objects, such as the functions of extensions, are
+// found in the public schema while migrating.
+func (db *DB) migrateTenant(id string, src MigrationSource) error {
+	searchPath, err := tenantSearchPath(id)
+	if err != nil {
+		return err
+	}
+	searchPath += ", public"
+
+	// Each tenant's version table is kept in its own schema
+	src.Schema = ""
+
+	connConfig := db.connConfig.Copy()
+	connConfig.RuntimeParams["search_path"] = searchPath
+	var options []stdlib.OptionOpenDB
+	if db.credentials != nil {
+		options = append(options, stdlib.OptionBeforeConnect(db.credentials.apply))
+	}
+	tenantDB := sqlx.NewDb(stdlib.OpenDB(*connConfig, options...), "pgx")
+	defer tenantDB.Close()
+
+	migrator, err := NewSourceMigrator(tenantDB, src, db.logger.WithFields("tenant", id))
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	return migrator.Up()
+}
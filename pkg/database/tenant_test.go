@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/tenant"
+)
+
+// newTenantTestDB returns a DB whose provisioned tenants are known, so
+// pools are handed out without reaching a database
+func newTenantTestDB(t *testing.T, maxPools int, tenants ...string) *DB {
+	connConfig, err := pgx.ParseConfig("postgres://commercium@localhost:1/commercium?connect_timeout=1")
+	require.NoError(t, err)
+
+	db := &DB{
+		connConfig: connConfig,
+		poolConfig: config.DatabaseConfig{
+			TenantPools: config.TenantPoolsConfig{MaxPools: maxPools, MaxOpenConns: 5, MaxIdleConns: 2},
+		},
+	}
+	for _, id := range tenants {
+		db.addKnownTenant(id)
+	}
+	db.tenants.knownAt = time.Now()
+	t.Cleanup(db.closeTenantPools)
+	return db
+}
+
+func TestTenantPoolsBounded(t *testing.T) {
+	db := newTenantTestDB(t, 2, "acme", "globex", "initech")
+	ctx := func(id string) context.Context { return tenant.WithTenant(context.Background(), id) }
+
+	acme := db.pool(ctx("acme"))
+	db.pool(ctx("globex"))
+	// acme is used again, leaving globex the least recently used
+	time.Sleep(time.Millisecond)
+	assert.Same(t, acme, db.pool(ctx("acme")))
+	db.pool(ctx("initech"))
+
+	assert.Len(t, db.tenants.pools, 2)
+	assert.Contains(t, db.tenants.pools, "acme")
+	assert.Contains(t, db.tenants.pools, "initech")
+	assert.Equal(t, 5, db.tenants.pools["acme"].Stats().MaxOpenConnections)
+}
+
+func TestTenantPoolUnknownTenant(t *testing.T) {
+	db := newTenantTestDB(t, 2, "acme")
+
+	exists, err := db.TenantExists(context.Background(), "globex")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	exists, err = db.TenantExists(context.Background(), "Not A Tenant")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	pool := db.pool(tenant.WithTenant(context.Background(), "globex"))
+	assert.Empty(t, db.tenants.pools)
+	err = pool.PingContext(context.Background())
+	assert.ErrorIs(t, err, ErrUnknownTenant)
+}
@@ -0,0 +1,255 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// slowQuerySQLLimit bounds how much of the SQL of a slow query is logged
+const slowQuerySQLLimit = 500
+
+// statementLimit bounds how much of the SQL of a query its span carries
+const statementLimit = 2000
+
+// postgresTracerName is the name of the tracer of query spans
+const postgresTracerName = "postgres"
+
+// operationKey is the context key of the operation name of queries
+type operationKey struct{}
+
+// WithOperation returns ctx naming the queries run with it, e.g.
+// "orders.get_by_id", for query metrics and slow-query logs. Queries without
+// a name are labeled with their SQL command, e.g. "select".
+func WithOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// operation returns the name of a query
+func operation(ctx context.Context, sql string) string {
+	if operation, ok := ctx.Value(operationKey{}).(string); ok && operation != "" {
+		return operation
+	}
+	command, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	return strings.ToLower(command)
+}
+
+// queryMetrics are where query durations are recorded
+type queryMetrics struct {
+	registry    metrics.Registry
+	serviceName string
+}
+
+// queryTracer times the queries pgx runs, recording their duration and
+// logging those slower than the threshold. Queries run in a trace get a
+// client span of their own, carrying their SQL with its literals replaced.
+type queryTracer struct {
+	threshold time.Duration
+	metrics   atomic.Pointer[queryMetrics]
+	logger    *logger.Logger
+}
+
+// queryStartKey is the context key of a query being traced
+type queryStartKey struct{}
+
+// queryStart is a query being traced
+type queryStart struct {
+	sql   string
+	start time.Time
+	span  trace.Span
+}
+
+// TraceQueryStart implements pgx.QueryTracer
+func (t *queryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return t.start(ctx, conn, data.SQL)
+}
+
+// TraceQueryEnd implements pgx.QueryTracer
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	t.end(ctx, data.CommandTag, data.Err)
+}
+
+// TraceCopyFromStart implements pgx.CopyFromTracer
+func (t *queryTracer) TraceCopyFromStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	sql := "copy " + data.TableName.Sanitize() + " (" + strings.Join(data.ColumnNames, ", ") + ")"
+	return t.start(ctx, conn, sql)
+}
+
+// TraceCopyFromEnd implements pgx.CopyFromTracer
+func (t *queryTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	t.end(ctx, data.CommandTag, data.Err)
+}
+
+// start starts timing a query, in a span of its own when ctx is traced.
+// Queries run outside of a trace, such as those of background workers,
+// don't start one each.
+func (t *queryTracer) start(ctx context.Context, conn *pgx.Conn, sql string) context.Context {
+	query := &queryStart{sql: sql, start: time.Now()}
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		statement := sanitizeSQL(sql)
+		if len(statement) > statementLimit {
+			statement = statement[:statementLimit] + "..."
+		}
+		name := operation(ctx, sql)
+		attributes := tracing.DBAttributes(tracing.DBSystemPostgreSQL, name, statement)
+		if conn != nil {
+			config := conn.Config()
+			attributes = append(attributes, tracing.DBNameKey.String(config.Database))
+			attributes = append(attributes, tracing.ServerAttributes(config.Host, int(config.Port))...)
+		}
+		ctx, query.span = tracing.GetTracer(postgresTracerName).Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attributes...))
+	}
+	return context.WithValue(ctx, queryStartKey{}, query)
+}
+
+// end records a query that completed with tag or failed with err, and
+// ends its span
+func (t *queryTracer) end(ctx context.Context, tag pgconn.CommandTag, err error) {
+	query, ok := ctx.Value(queryStartKey{}).(*queryStart)
+	if !ok {
+		return
+	}
+	t.record(ctx, query.sql, time.Since(query.start), err)
+
+	if query.span == nil {
+		return
+	}
+	if err != nil {
+		tracing.RecordError(query.span, err)
+	} else {
+		query.span.SetAttributes(tracing.DBRowsAffectedKey.Int64(tag.RowsAffected()))
+	}
+	query.span.End()
+}
+
+// record records the duration of a query and logs it when it was slow
+func (t *queryTracer) record(ctx context.Context, sql string, duration time.Duration, err error) {
+	name := operation(ctx, sql)
+
+	if m := t.metrics.Load(); m != nil {
+		outcome := "success"
+		if err != nil {
+			outcome = "error"
+		}
+		m.registry.ObserveDBQueryDuration(ctx, name, outcome, m.serviceName, duration.Seconds())
+	}
+
+	if t.threshold > 0 && duration >= t.threshold {
+		if len(sql) > slowQuerySQLLimit {
+			sql = sql[:slowQuerySQLLimit] + "..."
+		}
+		t.logger.Warn("Slow query", "operation", name, "duration", duration,
+			"sql", sql, "trace_id", tracing.TraceIDFromContext(ctx), "error", err)
+	}
+}
+
+// sanitizeSQL returns sql with its string and numeric literals replaced
+// with ?, so spans don't carry the values queries were written with.
+// Parameters, such as $1, quoted identifiers and comments are kept.
+func sanitizeSQL(sql string) string {
+	var b strings.Builder
+	b.Grow(len(sql))
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'':
+			// A string, in which '' is a quote
+			i++
+			for i < len(sql) {
+				if sql[i] == '\'' {
+					if i+1 < len(sql) && sql[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			i++
+			b.WriteByte('?')
+		case c == '"':
+			// A quoted identifier
+			end := strings.IndexByte(sql[i+1:], '"')
+			if end < 0 {
+				b.WriteString(sql[i:])
+				return b.String()
+			}
+			b.WriteString(sql[i : i+end+2])
+			i += end + 2
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				b.WriteString(sql[i:])
+				return b.String()
+			}
+			b.WriteString(sql[i : i+end])
+			i += end
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				b.WriteString(sql[i:])
+				return b.String()
+			}
+			b.WriteString(sql[i : i+end+4])
+			i += end + 4
+		case c == '$':
+			// A parameter, or a dollar-quoted string
+			j := i + 1
+			for j < len(sql) && isIdentByte(sql[j]) {
+				j++
+			}
+			if j < len(sql) && sql[j] == '$' && (j == i+1 || !isDigit(sql[i+1])) {
+				tag := sql[i : j+1]
+				end := strings.Index(sql[j+1:], tag)
+				if end < 0 {
+					b.WriteByte('?')
+					return b.String()
+				}
+				b.WriteByte('?')
+				i = j + 1 + end + len(tag)
+				continue
+			}
+			b.WriteString(sql[i:j])
+			i = j
+		case isDigit(c) && (i == 0 || !isIdentByte(sql[i-1])):
+			// A number, not part of an identifier
+			j := i
+			for j < len(sql) && (isDigit(sql[j]) || sql[j] == '.' || sql[j] == 'e' || sql[j] == 'E') {
+				j++
+			}
+			b.WriteByte('?')
+			i = j
+		case isIdentByte(c):
+			j := i
+			for j < len(sql) && isIdentByte(sql[j]) {
+				j++
+			}
+			b.WriteString(sql[i:j])
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
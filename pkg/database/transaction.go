@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+)
+
+// txContextKey is the context key of the transaction a context runs in
+type txContextKey struct{}
+
+// txState is the transaction a context runs in and how deeply transactions
+// are nested in it
+type txState struct {
+	tx    *sqlx.Tx
+	depth int
+}
+
+// TxFromContext returns the transaction ctx runs in, if any
+func TxFromContext(ctx context.Context) (*sqlx.Tx, bool) {
+	state, ok := ctx.Value(txContextKey{}).(*txState)
+	if !ok {
+		return nil, false
+	}
+	return state.tx, true
+}
+
+// TransactionContext executes fn within a database transaction begun with
+// opts, e.g. an isolation level or read-only mode; nil opts uses the
+// defaults of the database. The transaction is rolled back when fn fails or
+// panics, or when ctx is done before it commits. When ctx carries a tenant,
+// the transaction runs on a connection of the tenant's pool, scoped to its
+// schema.
+//
+// fn gets a context carrying the transaction. A TransactionContext called
+// with it runs in a savepoint of that transaction instead of a transaction
+// of its own: failing rolls back only the savepoint, and opts is ignored
+// since the outer transaction's options apply.
+func (db *DB) TransactionContext(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context, tx *sqlx.Tx) error) error {
+	if state, ok := ctx.Value(txContextKey{}).(*txState); ok {
+		return db.savepoint(ctx, state, fn)
+	}
+
+	tx, err := db.BeginTxx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				db.logger.Error("Failed to rollback transaction during panic", "error", rbErr)
+			}
+			panic(p) // re-throw panic after rollback
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txContextKey{}, &txState{tx: tx}), tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && rbErr != sql.ErrTxDone {
+			db.logger.Error("Failed to rollback transaction", "error", rbErr)
+		}
+		return err
+	}
+
+	// The driver rolls the transaction back once ctx is done, but may not
+	// have noticed yet
+	if err := ctx.Err(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("transaction abandoned: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		if err == sql.ErrTxDone && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return fmt.Errorf("failed to commit transaction: %w", commitError(err))
+	}
+
+	return nil
+}
+
+// ErrCommitUnknown is in the chain of the error of a transaction whose
+// COMMIT was sent but never answered, e.g. as the connection was lost: the
+// transaction may have been committed, so it must not be run again
+var ErrCommitUnknown = errors.New("transaction may have been committed")
+
+// commitError returns the error of a failed COMMIT, marked with
+// ErrCommitUnknown unless PostgreSQL answered it, which rolled the
+// transaction back, or it was never sent
+func commitError(err error) error {
+	if _, ok := PgError(err); ok || pgconn.SafeToRetry(err) || err == sql.ErrTxDone ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrCommitUnknown, err)
+}
+
+// savepoint executes fn within a savepoint of the transaction of state
+func (db *DB) savepoint(ctx context.Context, state *txState, fn func(ctx context.Context, tx *sqlx.Tx) error) error {
+	nested := &txState{tx: state.tx, depth: state.depth + 1}
+	name := fmt.Sprintf("sp_%d", nested.depth)
+
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	rollback := func() error {
+		// Outlive ctx: the outer transaction is still usable after this
+		_, err := state.tx.ExecContext(context.WithoutCancel(ctx), "ROLLBACK TO SAVEPOINT "+name)
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := rollback(); rbErr != nil {
+				db.logger.Error("Failed to rollback savepoint during panic", "error", rbErr, "savepoint", name)
+			}
+			panic(p) // re-throw panic after rollback
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txContextKey{}, nested), state.tx); err != nil {
+		if rbErr := rollback(); rbErr != nil {
+			db.logger.Error("Failed to rollback savepoint", "error", rbErr, "savepoint", name)
+		}
+		return err
+	}
+
+	if _, err := state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+
+	return nil
+}
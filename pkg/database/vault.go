@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// vaultRetryInterval is how long to wait before fetching credentials again
+// after failing to
+const vaultRetryInterval = 10 * time.Second
+
+// vaultCredentials are short-lived database credentials issued by the
+// database secrets engine of Vault. They are replaced before their lease
+// expires; connections opened from then on log in with the new ones, and
+// the pool retires connections before the old ones expire.
+type vaultCredentials struct {
+	config config.DatabaseVaultConfig
+	client *http.Client
+	logger *logger.Logger
+
+	mu        sync.RWMutex
+	username  string
+	password  string
+	leaseID   string
+	expiresAt time.Time
+}
+
+// vaultCredentialsResponse is the response of Vault to a credentials request
+type vaultCredentialsResponse struct {
+	LeaseID       string `json:"lease_id"`
+	LeaseDuration int    `json:"lease_duration"`
+	Data          struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+func newVaultCredentials(cfg config.DatabaseVaultConfig, log *logger.Logger) *vaultCredentials {
+	return &vaultCredentials{
+		config: cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		logger: log,
+	}
+}
+
+// fetch requests new credentials from Vault and makes them current
+func (v *vaultCredentials) fetch(ctx context.Context) error {
+	url := fmt.Sprintf("%s/v1/%s/creds/%s", strings.TrimRight(v.config.Address, "/"),
+		strings.Trim(v.config.Mount, "/"), v.config.Role)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.config.Token)
+	if v.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.config.Namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request database credentials from Vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read Vault response: %w", err)
+	}
+
+	var result vaultCredentialsResponse
+	if err := json.Unmarshal(body, &result); err != nil && resp.StatusCode == http.StatusOK {
+		return fmt.Errorf("failed to decode Vault response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault returned status %d: %s", resp.StatusCode, strings.Join(result.Errors, "; "))
+	}
+	if result.Data.Username == "" || result.LeaseDuration <= 0 {
+		return fmt.Errorf("vault returned no database credentials for role %s", v.config.Role)
+	}
+
+	v.mu.Lock()
+	v.username = result.Data.Username
+	v.password = result.Data.Password
+	v.leaseID = result.LeaseID
+	v.expiresAt = time.Now().Add(time.Duration(result.LeaseDuration) * time.Second)
+	v.mu.Unlock()
+
+	v.logger.Info("Database credentials issued by Vault",
+		"role", v.config.Role,
+		"username", result.Data.Username,
+		"lease_id", result.LeaseID,
+		"lease_duration", time.Duration(result.LeaseDuration)*time.Second,
+	)
+	return nil
+}
+
+// apply logs connections in with the current credentials. It is called
+// before each connection is opened.
+func (v *vaultCredentials) apply(ctx context.Context, connConfig *pgx.ConnConfig) error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	connConfig.User = v.username
+	connConfig.Password = v.password
+	return nil
+}
+
+// lead is how long before the current lease expires the credentials are
+// replaced: RenewBefore, or half the lease when it is shorter than twice
+// that
+func (v *vaultCredentials) lead() time.Duration {
+	v.mu.RLock()
+	lease := time.Until(v.expiresAt)
+	v.mu.RUnlock()
+
+	return min(v.config.RenewBefore, lease/2)
+}
+
+// run replaces the credentials before their lease expires until done is
+// closed
+func (v *vaultCredentials) run(done <-chan struct{}) {
+	for {
+		v.mu.RLock()
+		wait := time.Until(v.expiresAt.Add(-v.config.RenewBefore))
+		if lease := time.Until(v.expiresAt); wait < lease/2 {
+			wait = lease / 2
+		}
+		v.mu.RUnlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), v.config.Timeout)
+			err := v.fetch(ctx)
+			cancel()
+			if err == nil {
+				break
+			}
+
+			v.mu.RLock()
+			expiresAt := v.expiresAt
+			v.mu.RUnlock()
+			v.logger.Error("Failed to renew database credentials", "error", err,
+				"role", v.config.Role, "expires_at", expiresAt)
+
+			select {
+			case <-done:
+				return
+			case <-time.After(vaultRetryInterval):
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Package diagnostics serves the runtime diagnostics of a service, so
+// operators can profile one misbehaving in production: the pprof profiles,
+// a dump of its goroutines and the build it runs.
+//
+// They are served under /debug of the service's router, to admins only, or
+// on an internal port of their own, without authentication, when
+// server.debug.port is set:
+//
+//	go tool pprof http://localhost:6060/debug/pprof/heap
+//	go tool pprof 'http://localhost:6060/debug/pprof/profile?seconds=10'
+//	curl http://localhost:6060/debug/goroutines
+//	curl http://localhost:6060/debug/build
+//
+// CPU profiles and traces on the service's port must be shorter than its
+// write timeout, which the internal port doesn't have.
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	runtimepprof "runtime/pprof"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/lifecycle"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// profiles are the named profiles of the runtime served under /debug/pprof
+var profiles = []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"}
+
+// Setup serves the diagnostics of a service as cfg.Server.Debug says: on
+// their internal port when it is set, under /debug of router behind guard,
+// e.g. admin authentication, otherwise
+func Setup(cfg *config.Config, router gin.IRouter, shutdown *lifecycle.Manager, log *logger.Logger, guard ...gin.HandlerFunc) error {
+	if cfg.Server.Debug.Port == 0 {
+		Routes(router, cfg.Version, guard...)
+	}
+	return Serve(cfg, shutdown, log)
+}
+
+// Routes registers the diagnostics under /debug of router, behind handlers
+func Routes(router gin.IRouter, version string, handlers ...gin.HandlerFunc) {
+	group := router.Group("/debug", handlers...)
+
+	group.GET("/pprof/", gin.WrapF(pprof.Index))
+	group.GET("/pprof/cmdline", gin.WrapF(pprof.Cmdline))
+	group.GET("/pprof/profile", gin.WrapF(pprof.Profile))
+	group.GET("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	group.POST("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	group.GET("/pprof/trace", gin.WrapF(pprof.Trace))
+	for _, name := range profiles {
+		group.GET("/pprof/"+name, gin.WrapH(pprof.Handler(name)))
+	}
+
+	group.GET("/goroutines", goroutinesHandler)
+	group.GET("/build", buildHandler(version))
+}
+
+// Serve applies the profiling rates of cfg.Server.Debug and, when its port
+// is set, serves the diagnostics there until shutdown
+func Serve(cfg *config.Config, shutdown *lifecycle.Manager, log *logger.Logger) error {
+	debugConfig := cfg.Server.Debug
+	runtime.SetMutexProfileFraction(debugConfig.MutexProfileFraction)
+	runtime.SetBlockProfileRate(debugConfig.BlockProfileRate)
+
+	if debugConfig.Port == 0 {
+		return nil
+	}
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+	Routes(router, cfg.Version)
+
+	// No write timeout, so CPU profiles and traces can run as long as asked
+	srv := &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", debugConfig.Host, debugConfig.Port),
+		Handler:           router,
+		ReadHeaderTimeout: cfg.Server.ReadTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for diagnostics on %s: %w", srv.Addr, err)
+	}
+
+	go func() {
+		log.Info("Diagnostics server starting", "address", srv.Addr)
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Error("Diagnostics server failed", "error", err)
+		}
+	}()
+	shutdown.Register("diagnostics server", func(ctx context.Context) error {
+		return srv.Shutdown(ctx)
+	})
+	return nil
+}
+
+// goroutinesHandler dumps the stacks of all goroutines, as a panic would
+func goroutinesHandler(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := runtimepprof.Lookup("goroutine").WriteTo(c.Writer, 2); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// buildInfo describes the build a service runs
+type buildInfo struct {
+	Version    string            `json:"version"`
+	GoVersion  string            `json:"go_version"`
+	Path       string            `json:"path"`
+	Settings   map[string]string `json:"settings"`
+	Deps       map[string]string `json:"deps"`
+	Goroutines int               `json:"goroutines"`
+	GOMAXPROCS int               `json:"gomaxprocs"`
+	NumCPU     int               `json:"num_cpu"`
+}
+
+// buildHandler describes the build of the service, e.g. its VCS revision
+// and the versions of its dependencies
+func buildHandler(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		info := buildInfo{
+			Version:    version,
+			GoVersion:  runtime.Version(),
+			Settings:   map[string]string{},
+			Deps:       map[string]string{},
+			Goroutines: runtime.NumGoroutine(),
+			GOMAXPROCS: runtime.GOMAXPROCS(0),
+			NumCPU:     runtime.NumCPU(),
+		}
+		if build, ok := debug.ReadBuildInfo(); ok {
+			info.Path = build.Path
+			for _, setting := range build.Settings {
+				info.Settings[setting.Key] = setting.Value
+			}
+			for _, dep := range build.Deps {
+				if dep.Replace != nil {
+					dep = dep.Replace
+				}
+				info.Deps[dep.Path] = dep.Version
+			}
+		}
+		c.JSON(http.StatusOK, info)
+	}
+}
@@ -0,0 +1,63 @@
+package diagnostics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/diagnostics"
+)
+
+// TestRoutesAdminOnly checks the diagnostics served on a service's port are
+// only served to admins
+func TestRoutesAdminOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtService := auth.NewJWTService(&config.JWTConfig{
+		SecretKey:         "diagnostics-secret-key-of-32-byte",
+		Issuer:            "commercium",
+		Expiration:        15 * time.Minute,
+		RefreshExpiration: time.Hour,
+	})
+	router := gin.New()
+	diagnostics.Routes(router, "1.2.3", jwtService.Middleware(), auth.RequireRole("admin"))
+
+	token := func(role string) string {
+		pair, err := jwtService.GenerateTokenPair(uuid.New(), role+"@example.com", role, role, "")
+		require.NoError(t, err)
+		return pair.AccessToken
+	}
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/goroutines", "/debug/build"} {
+		for _, tc := range []struct {
+			token string
+			code  int
+		}{
+			{"", http.StatusUnauthorized},
+			{token("customer"), http.StatusForbidden},
+			{token("admin"), http.StatusOK},
+		} {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.code, rec.Code, path)
+
+			if tc.code == http.StatusOK && path == "/debug/build" {
+				assert.Contains(t, rec.Body.String(), `"version":"1.2.3"`)
+			}
+			if tc.code == http.StatusOK && path == "/debug/goroutines" {
+				assert.Contains(t, rec.Body.String(), "goroutine ")
+			}
+		}
+	}
+}
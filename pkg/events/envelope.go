@@ -0,0 +1,149 @@
+// Package events defines the envelope every event published to Kafka is
+// wrapped in. The envelope follows the CloudEvents 1.0 JSON format, with the
+// schema version of the payload and the publisher's trace context carried as
+// extension attributes, so consumers can route, deduplicate and trace events
+// without knowing the shape of their payload.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// SpecVersion is the CloudEvents version envelopes are written in
+const SpecVersion = "1.0"
+
+// ContentTypeJSON is the content type of envelope payloads
+const ContentTypeJSON = "application/json"
+
+// traceContext propagates trace context in the W3C Trace Context format, as
+// the CloudEvents distributed tracing extension requires, and baggage
+var traceContext = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// Envelope is an event as published to Kafka. Data holds the JSON payload,
+// in the shape of schema version SchemaVersion of the event type.
+type Envelope struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+	SchemaVersion   int       `json:"schemaversion"`
+	// TraceParent and TraceState carry the trace context of the publisher
+	TraceParent string `json:"traceparent,omitempty"`
+	TraceState  string `json:"tracestate,omitempty"`
+	// Baggage carries the baggage of the publisher, such as its tenant
+	Baggage string          `json:"baggage,omitempty"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// New wraps the payload of an event in a new envelope. Source names the
+// publishing service, subject the entity the event is about, and
+// schemaVersion the version of the payload's shape.
+func New(ctx context.Context, source, eventType, subject string, schemaVersion int, payload interface{}) (*Envelope, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event: %w", eventType, err)
+	}
+
+	carrier := propagation.MapCarrier{}
+	traceContext.Inject(ctx, carrier)
+
+	return &Envelope{
+		SpecVersion:     SpecVersion,
+		ID:              uuid.NewString(),
+		Source:          source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: ContentTypeJSON,
+		SchemaVersion:   schemaVersion,
+		TraceParent:     carrier.Get("traceparent"),
+		TraceState:      carrier.Get("tracestate"),
+		Baggage:         carrier.Get("baggage"),
+		Data:            data,
+	}, nil
+}
+
+// Marshal encodes the envelope as JSON
+func (e *Envelope) Marshal() ([]byte, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event: %w", e.Type, err)
+	}
+	return data, nil
+}
+
+// Parse decodes and validates a JSON envelope
+func Parse(data []byte) (*Envelope, error) {
+	envelope := &Envelope{}
+	if err := json.Unmarshal(data, envelope); err != nil {
+		return nil, fmt.Errorf("invalid event envelope: %w", err)
+	}
+	if err := envelope.Validate(); err != nil {
+		return nil, err
+	}
+	return envelope, nil
+}
+
+// UnmarshalJSON decodes a JSON envelope. Payloads published before schema
+// versions were recorded are version 1.
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	type envelope Envelope
+	if err := json.Unmarshal(data, (*envelope)(e)); err != nil {
+		return err
+	}
+	if e.SchemaVersion == 0 {
+		e.SchemaVersion = 1
+	}
+	return nil
+}
+
+// Validate checks that the envelope has the attributes CloudEvents requires,
+// in a spec version this package reads
+func (e *Envelope) Validate() error {
+	if !strings.HasPrefix(e.SpecVersion, "1.") {
+		return fmt.Errorf("invalid event envelope: unsupported spec version %q", e.SpecVersion)
+	}
+	if e.ID == "" || e.Source == "" || e.Type == "" {
+		return fmt.Errorf("invalid event envelope: id, source and type are required")
+	}
+	if e.DataContentType != "" && e.DataContentType != ContentTypeJSON {
+		return fmt.Errorf("invalid event envelope: unsupported data content type %q", e.DataContentType)
+	}
+	return nil
+}
+
+// Decode decodes the payload into v, upgraded to the latest schema version
+// of the event type first
+func (e *Envelope) Decode(v interface{}) error {
+	data, err := upgrade(e.Type, e.SchemaVersion, e.Data)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid %s event: %w", e.Type, err)
+	}
+	return nil
+}
+
+// Context returns ctx continuing the trace of the publisher, with its
+// baggage
+func (e *Envelope) Context(ctx context.Context) context.Context {
+	if e.TraceParent == "" && e.Baggage == "" {
+		return ctx
+	}
+	return traceContext.Extract(ctx, propagation.MapCarrier{
+		"traceparent": e.TraceParent,
+		"tracestate":  e.TraceState,
+		"baggage":     e.Baggage,
+	})
+}
@@ -0,0 +1,177 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// Inbox statuses of the events a consumer handles
+const (
+	inboxInProgress = "in_progress"
+	inboxCompleted  = "completed"
+)
+
+// ErrEventInProgress is returned when another instance of the consumer is
+// handling the event. The delivery should be retried later, when it will be
+// recognized as a duplicate or taken over.
+var ErrEventInProgress = errors.New("event is already being processed")
+
+// Inbox deduplicates the events a consumer handles. Events are delivered at
+// least once: after a rebalance, or a crash before the offset was committed,
+// they are delivered again. The inbox records the ID of every event handled
+// in PostgreSQL for a TTL, and skips events it has seen, so handlers with side
+// effects like notifications don't repeat them.
+type Inbox struct {
+	db       *database.DB
+	consumer string
+	config   config.InboxConfig
+	logger   *logger.Logger
+}
+
+// NewInbox creates the inbox of a consumer. Consumers that must each handle
+// every event, e.g. different consumer groups, need different names.
+func NewInbox(db *database.DB, consumer string, cfg config.InboxConfig, logger *logger.Logger) *Inbox {
+	return &Inbox{
+		db:       db,
+		consumer: consumer,
+		config:   cfg,
+		logger:   logger,
+	}
+}
+
+// Process handles an event unless the consumer handled it before. The event
+// is recorded as handled once handle succeeds; when it fails, the event is
+// forgotten so its next delivery is handled again. An event whose handling
+// stopped half-way, e.g. because the instance died, is handled again once
+// its lease ran out.
+func (i *Inbox) Process(ctx context.Context, envelope *Envelope, handle func(ctx context.Context) error) error {
+	claimed, err := i.claim(ctx, envelope)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		i.logger.Debug("Skipping event already processed", "consumer", i.consumer, "id", envelope.ID, "type", envelope.Type)
+		return nil
+	}
+
+	if err := handle(ctx); err != nil {
+		if releaseErr := i.release(ctx, envelope.ID); releaseErr != nil {
+			i.logger.Warn("Failed to release event, it is retried once its lease runs out",
+				"error", releaseErr, "consumer", i.consumer, "id", envelope.ID)
+		}
+		return err
+	}
+
+	return i.complete(ctx, envelope.ID)
+}
+
+// claim records that the consumer starts handling an event, and reports
+// whether it should. Expired records and records whose lease ran out are
+// taken over.
+func (i *Inbox) claim(ctx context.Context, envelope *Envelope) (bool, error) {
+	query := `
+		INSERT INTO processed_events (consumer, event_id, event_type, status, locked_at, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW(), NOW() + $5 * INTERVAL '1 second')
+		ON CONFLICT (consumer, event_id) DO UPDATE
+		SET status = EXCLUDED.status, locked_at = NOW(), created_at = NOW(), expires_at = EXCLUDED.expires_at
+		WHERE processed_events.expires_at < NOW()
+		   OR (processed_events.status = $4 AND processed_events.locked_at < NOW() - $6 * INTERVAL '1 second')
+		RETURNING consumer`
+
+	var claimed string
+	err := i.db.QueryRowxContext(ctx, query, i.consumer, envelope.ID, envelope.Type, inboxInProgress,
+		i.config.TTL.Seconds(), i.config.Lease.Seconds()).Scan(&claimed)
+	if err == nil {
+		return true, nil
+	}
+	if err != sql.ErrNoRows {
+		i.logger.Error("Failed to claim event", "error", err, "consumer", i.consumer, "id", envelope.ID)
+		return false, fmt.Errorf("failed to claim event: %w", err)
+	}
+
+	var status string
+	err = i.db.GetContext(ctx, &status, `SELECT status FROM processed_events WHERE consumer = $1 AND event_id = $2`,
+		i.consumer, envelope.ID)
+	if err != nil {
+		i.logger.Error("Failed to get processed event", "error", err, "consumer", i.consumer, "id", envelope.ID)
+		return false, fmt.Errorf("failed to get processed event: %w", err)
+	}
+	if status == inboxInProgress {
+		return false, ErrEventInProgress
+	}
+
+	return false, nil
+}
+
+// complete records that the consumer handled an event
+func (i *Inbox) complete(ctx context.Context, eventID string) error {
+	_, err := i.db.ExecContext(ctx, `UPDATE processed_events SET status = $3 WHERE consumer = $1 AND event_id = $2`,
+		i.consumer, eventID, inboxCompleted)
+	if err != nil {
+		i.logger.Error("Failed to complete processed event", "error", err, "consumer", i.consumer, "id", eventID)
+		return fmt.Errorf("failed to complete processed event: %w", err)
+	}
+
+	return nil
+}
+
+// release forgets an event the consumer failed to handle
+func (i *Inbox) release(ctx context.Context, eventID string) error {
+	_, err := i.db.ExecContext(ctx, `DELETE FROM processed_events WHERE consumer = $1 AND event_id = $2`,
+		i.consumer, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to release processed event: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeExpired deletes the expired records of every consumer and returns how
+// many were removed
+func (i *Inbox) PurgeExpired(ctx context.Context) (int64, error) {
+	result, err := i.db.ExecContext(ctx, `DELETE FROM processed_events WHERE expires_at < NOW()`)
+	if err != nil {
+		i.logger.Error("Failed to purge processed events", "error", err)
+		return 0, fmt.Errorf("failed to purge processed events: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// RunPurger deletes expired records every purge interval until ctx is cancelled
+func (i *Inbox) RunPurger(ctx context.Context) {
+	ticker := time.NewTicker(i.config.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := i.PurgeExpired(ctx)
+			if err != nil {
+				continue
+			}
+			if purged > 0 {
+				i.logger.Info("Purged expired processed events", "count", purged)
+			}
+		}
+	}
+}
+
+// Deduplicate wraps an event handler so the events it is given are handled
+// once, as recorded in the inbox
+func Deduplicate[T any](inbox *Inbox, handle func(ctx context.Context, envelope *Envelope, event *T) error) func(ctx context.Context, envelope *Envelope, event *T) error {
+	return func(ctx context.Context, envelope *Envelope, event *T) error {
+		return inbox.Process(ctx, envelope, func(ctx context.Context) error {
+			return handle(ctx, envelope, event)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// Schema is the Protobuf schema of the payloads of the event types of a
+// domain, e.g. of order.created and order.cancelled for the order domain
+type Schema struct {
+	// Domain is the part of the event types before the first dot
+	Domain string
+	// Subject is the schema registry subject the schema is registered under,
+	// the full name of its message
+	Subject string
+	// Definition is the source of the .proto file defining the message
+	Definition string
+	// Message is the message payloads are decoded into to validate them
+	Message proto.Message
+}
+
+// Registry manages the versions of schemas in a schema registry
+type Registry interface {
+	// Register registers a schema definition as the latest version of a
+	// subject and returns its ID, failing if it is incompatible with the
+	// versions registered
+	Register(ctx context.Context, subject, definition string) (int, error)
+	// CheckCompatibility fails if a schema definition is incompatible with
+	// the versions of a subject registered
+	CheckCompatibility(ctx context.Context, subject, definition string) error
+}
+
+// SchemaSet validates event payloads against the schemas of their event
+// types. Producers register their schemas on startup and validate what they
+// publish; consumers check on startup that the schemas they were built with
+// can read what producers registered, and validate what they consume.
+type SchemaSet struct {
+	schemas  map[string]*Schema
+	registry Registry
+	logger   *logger.Logger
+}
+
+// NewSchemaSet creates a new schema set of the schemas a service publishes
+// or consumes. Without a registry, payloads are still validated, but schemas
+// are neither registered nor checked.
+func NewSchemaSet(schemas []*Schema, registry Registry, logger *logger.Logger) *SchemaSet {
+	byDomain := make(map[string]*Schema, len(schemas))
+	for _, schema := range schemas {
+		byDomain[schema.Domain] = schema
+	}
+
+	return &SchemaSet{
+		schemas:  byDomain,
+		registry: registry,
+		logger:   logger,
+	}
+}
+
+// Register registers the schemas with the registry. It fails if a schema
+// changed in a way consumers of the registered versions can't read, so the
+// change is caught before anything is published.
+func (s *SchemaSet) Register(ctx context.Context) error {
+	if s.registry == nil {
+		return nil
+	}
+
+	for _, schema := range s.schemas {
+		id, err := s.registry.Register(ctx, schema.Subject, schema.Definition)
+		if err != nil {
+			return fmt.Errorf("failed to register schema %s: %w", schema.Subject, err)
+		}
+		s.logger.Info("Event schema registered", "subject", schema.Subject, "id", id)
+	}
+	return nil
+}
+
+// CheckCompatibility checks that the schemas can read the payloads of the
+// versions producers registered
+func (s *SchemaSet) CheckCompatibility(ctx context.Context) error {
+	if s.registry == nil {
+		return nil
+	}
+
+	for _, schema := range s.schemas {
+		if err := s.registry.CheckCompatibility(ctx, schema.Subject, schema.Definition); err != nil {
+			return fmt.Errorf("schema %s is incompatible with the registry: %w", schema.Subject, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the payload of an envelope matches the schema of its
+// event type: no unknown fields, and values of the declared types
+func (s *SchemaSet) Validate(envelope *Envelope) error {
+	domain, _, _ := strings.Cut(envelope.Type, ".")
+	schema, ok := s.schemas[domain]
+	if !ok {
+		return fmt.Errorf("invalid %s event: no schema for event type", envelope.Type)
+	}
+
+	message := schema.Message.ProtoReflect().New().Interface()
+	if err := protojson.Unmarshal(envelope.Data, message); err != nil {
+		return fmt.Errorf("invalid %s event: payload doesn't match schema %s: %w", envelope.Type, schema.Subject, err)
+	}
+	return nil
+}
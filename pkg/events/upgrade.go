@@ -0,0 +1,56 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Upgrade converts an event payload from one schema version to the next
+type Upgrade func(data json.RawMessage) (json.RawMessage, error)
+
+type upgradeKey struct {
+	eventType string
+	version   int
+}
+
+var (
+	upgradesMu sync.RWMutex
+	upgrades   = map[upgradeKey]Upgrade{}
+)
+
+// RegisterUpgrade registers the upgrade of the payloads of an event type from
+// schema version fromVersion to fromVersion+1. Consumers register the
+// upgrades of the events they read, typically in an init function, so
+// payloads published by older producers are decoded in the latest shape.
+func RegisterUpgrade(eventType string, fromVersion int, upgrade Upgrade) {
+	upgradesMu.Lock()
+	defer upgradesMu.Unlock()
+
+	key := upgradeKey{eventType: eventType, version: fromVersion}
+	if _, ok := upgrades[key]; ok {
+		panic(fmt.Sprintf("events: upgrade of %s from version %d registered twice", eventType, fromVersion))
+	}
+	upgrades[key] = upgrade
+}
+
+// upgrade applies the registered upgrades of an event type to a payload of
+// version, one version at a time, until no further upgrade is registered
+func upgrade(eventType string, version int, data json.RawMessage) (json.RawMessage, error) {
+	upgradesMu.RLock()
+	defer upgradesMu.RUnlock()
+
+	for {
+		next, ok := upgrades[upgradeKey{eventType: eventType, version: version}]
+		if !ok {
+			return data, nil
+		}
+
+		upgraded, err := next(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upgrade %s event from version %d: %w", eventType, version, err)
+		}
+		data = upgraded
+		version++
+	}
+}
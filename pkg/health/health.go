@@ -0,0 +1,270 @@
+// Package health reports whether a service can serve traffic, checking each
+// of the dependencies it needs: databases, caches, message brokers and the
+// services it calls. Services register a named checker per dependency and
+// serve the same /health, /readiness and /livez endpoints.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultTimeout bounds how long a check may take, unless it was
+// registered with a timeout of its own
+const DefaultTimeout = 5 * time.Second
+
+// Checker reports whether a dependency is reachable. It returns once ctx is
+// done at the latest.
+type Checker func(ctx context.Context) error
+
+// The statuses of a service
+const (
+	StatusReady    = "ready"
+	StatusDegraded = "degraded"
+	StatusNotReady = "not ready"
+)
+
+// The statuses of a check
+const (
+	CheckOK      = "ok"
+	CheckFailing = "failing"
+)
+
+// check is a registered check
+type check struct {
+	name     string
+	checker  Checker
+	timeout  time.Duration
+	critical bool
+}
+
+// Option configures a registered check
+type Option func(*check)
+
+// Timeout bounds how long the check may take
+func Timeout(timeout time.Duration) Option {
+	return func(c *check) {
+		c.timeout = timeout
+	}
+}
+
+// Optional marks the dependency as one the service can serve traffic
+// without: when it fails, the service is degraded but still ready
+func Optional() Option {
+	return func(c *check) {
+		c.critical = false
+	}
+}
+
+// Registry holds the checks of the dependencies of a service
+type Registry struct {
+	service string
+	version string
+	started time.Time
+
+	mu     sync.RWMutex
+	checks []*check
+}
+
+// NewRegistry creates a registry of the checks of a service
+func NewRegistry(serviceName, version string) *Registry {
+	return &Registry{
+		service: serviceName,
+		version: version,
+		started: time.Now(),
+	}
+}
+
+// Register registers the check of a dependency, named name. Dependencies
+// are critical unless registered as Optional: the service isn't ready
+// while one fails.
+func (r *Registry) Register(name string, checker Checker, opts ...Option) {
+	c := &check{name: name, checker: checker, timeout: DefaultTimeout, critical: true}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, registered := range r.checks {
+		if registered.name == name {
+			r.checks[i] = c
+			return
+		}
+	}
+	r.checks = append(r.checks, c)
+}
+
+// CheckResult is the outcome of a check
+type CheckResult struct {
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+	Duration int64  `json:"duration_ms"`
+}
+
+// Report is the outcome of the checks of a service
+type Report struct {
+	Status    string                 `json:"status"`
+	Service   string                 `json:"service"`
+	Version   string                 `json:"version,omitempty"`
+	Timestamp int64                  `json:"timestamp"`
+	Uptime    int64                  `json:"uptime_seconds"`
+	Checks    map[string]CheckResult `json:"checks"`
+}
+
+// Ready reports whether no critical check failed
+func (r *Report) Ready() bool {
+	return r.Status != StatusNotReady
+}
+
+// Run runs the checks concurrently, each within its timeout, and returns
+// their outcome
+func (r *Registry) Run(ctx context.Context) *Report {
+	r.mu.RLock()
+	checks := append([]*check(nil), r.checks...)
+	r.mu.RUnlock()
+
+	report := &Report{
+		Status:    StatusReady,
+		Service:   r.service,
+		Version:   r.version,
+		Timestamp: time.Now().Unix(),
+		Uptime:    int64(time.Since(r.started).Seconds()),
+		Checks:    make(map[string]CheckResult, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c *check) {
+			defer wg.Done()
+			result := c.run(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.name] = result
+			if result.Status == CheckOK {
+				return
+			}
+			if c.critical {
+				report.Status = StatusNotReady
+			} else if report.Status == StatusReady {
+				report.Status = StatusDegraded
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// run runs the check, giving up once its timeout passed even if the
+// checker doesn't
+func (c *check) run(ctx context.Context) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.checker(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", c.timeout)
+	}
+
+	result := CheckResult{Status: CheckOK, Critical: c.critical, Duration: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = CheckFailing
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Routes serves the health endpoints of the service on router: /readiness
+// and /health, kept for the monitors already polling it, report every
+// check, and /livez reports the process is up
+func (r *Registry) Routes(router gin.IRoutes) {
+	router.GET("/health", r.ReadinessHandler())
+	router.GET("/readiness", r.ReadinessHandler())
+	router.GET("/livez", r.LivenessHandler())
+}
+
+// ReadinessHandler returns a handler responding 200 while no critical
+// check fails, and 503 otherwise, so traffic is only routed to instances
+// that can serve it
+func (r *Registry) ReadinessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := r.Run(c.Request.Context())
+		if !report.Ready() {
+			c.JSON(http.StatusServiceUnavailable, report)
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// LivenessHandler returns a handler responding 200 as long as the service
+// serves requests. It checks no dependency, so an outage of one doesn't get
+// every instance restarted.
+func (r *Registry) LivenessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status":    "alive",
+			"service":   r.service,
+			"timestamp": time.Now().Unix(),
+		})
+	}
+}
+
+// Func returns a checker calling check, for clients whose checks bound
+// their own duration, such as database.DB.HealthCheck
+func Func(check func() error) Checker {
+	return func(context.Context) error {
+		return check()
+	}
+}
+
+// Unavailable returns a checker that always fails, for a dependency whose
+// client failed to initialize: the service runs without it until restarted.
+func Unavailable(reason string) Checker {
+	return func(context.Context) error {
+		return errors.New(reason)
+	}
+}
+
+// URL returns a checker of a service the service calls, requesting url,
+// typically its /health endpoint, and failing unless it responds 2xx
+func URL(client *http.Client, url string) Checker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return fmt.Errorf("invalid health check URL: %w", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("%s responded %d", url, resp.StatusCode)
+		}
+		return nil
+	}
+}
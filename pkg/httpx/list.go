@@ -0,0 +1,84 @@
+// Package httpx holds the shapes of the responses services share, so
+// clients read every list the same way
+package httpx
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// ListResponse is the body of every list endpoint: the items of the list,
+// or of a page of it, how to get the next page, and the request it
+// answered
+type ListResponse[T any] struct {
+	Data       []T        `json:"data"`
+	Pagination Pagination `json:"pagination"`
+	Meta       Meta       `json:"meta"`
+}
+
+// Pagination describes the page of a list a response holds
+type Pagination struct {
+	// NextCursor is passed as the cursor query parameter to get the next
+	// page; it is empty on the last one
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Total is the number of items of the whole list, when it is known
+	Total *int `json:"total,omitempty"`
+	// Limit is the most items a page holds, when the list is paged
+	Limit int `json:"limit,omitempty"`
+}
+
+// HasMore returns whether another page follows
+func (p Pagination) HasMore() bool {
+	return p.NextCursor != ""
+}
+
+// Meta describes the request a response answered
+type Meta struct {
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// NewPage returns a page of at most limit items of a list, followed by the
+// page nextCursor points to, if any
+func NewPage[T any](items []T, nextCursor string, limit int) *ListResponse[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return &ListResponse[T]{
+		Data:       items,
+		Pagination: Pagination{NextCursor: nextCursor, Limit: limit},
+	}
+}
+
+// NewList returns a whole list, not paged
+func NewList[T any](items []T) *ListResponse[T] {
+	if items == nil {
+		items = []T{}
+	}
+	total := len(items)
+	return &ListResponse[T]{
+		Data:       items,
+		Pagination: Pagination{Total: &total},
+	}
+}
+
+// List writes list as the response to the request of c, with its meta
+func List[T any](c *gin.Context, list *ListResponse[T]) {
+	list.Meta = Meta{RequestID: requestID(c)}
+	c.JSON(http.StatusOK, list)
+}
+
+// Items writes items, a whole list, as the response to the request of c
+func Items[T any](c *gin.Context, items []T) {
+	List(c, NewList(items))
+}
+
+// requestID returns the ID of the request of c, set by logger.Middleware
+func requestID(c *gin.Context) string {
+	if id := logger.RequestIDFromContext(c.Request.Context()); id != "" {
+		return id
+	}
+	return c.Writer.Header().Get(logger.HeaderRequestID)
+}
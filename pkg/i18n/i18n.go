@@ -0,0 +1,85 @@
+// Package i18n localizes what services tell clients: it negotiates the
+// language of each request from its Accept-Language header and looks up
+// messages in catalogs, falling back to the base language of the one
+// negotiated, then to English, for messages not translated yet.
+package i18n
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Fallback is the language of messages a client accepts none of the
+// supported languages of, and that of messages not translated yet
+var Fallback = language.English
+
+// Supported are the languages requests are negotiated to, the first being
+// Fallback
+var Supported = []language.Tag{Fallback, language.German, language.Turkish}
+
+// matcher picks the supported language a client accepts most
+var matcher = language.NewMatcher(Supported)
+
+// Negotiate returns the supported language best matching acceptLanguage,
+// the value of an Accept-Language header, or Fallback
+func Negotiate(acceptLanguage string) language.Tag {
+	accepted, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(accepted) == 0 {
+		return Fallback
+	}
+	_, index, _ := matcher.Match(accepted...)
+	return Supported[index]
+}
+
+// Locale returns lang as the locale of email templates, e.g. de or pt-BR
+func Locale(lang language.Tag) string {
+	return lang.String()
+}
+
+// Catalog holds messages, keyed alike in every language. Messages may hold
+// parameters, written {name}.
+type Catalog map[language.Tag]map[string]string
+
+// Message returns the message keyed key in lang, or in the first language
+// of its fallback chain that has one, with each {name} of params, given as
+// name and value pairs, replaced by its value. It returns "" when no
+// language has the message.
+func (c Catalog) Message(lang language.Tag, key string, params ...string) string {
+	text, ok := c.lookup(lang, key)
+	if !ok || len(params) < 2 {
+		return text
+	}
+
+	replacements := make([]string, 0, len(params))
+	for i := 0; i+1 < len(params); i += 2 {
+		replacements = append(replacements, "{"+params[i]+"}", params[i+1])
+	}
+	return strings.NewReplacer(replacements...).Replace(text)
+}
+
+// lookup returns the message keyed key in the first language of the
+// fallback chain of lang that has one
+func (c Catalog) lookup(lang language.Tag, key string) (string, bool) {
+	for _, tag := range chain(lang) {
+		if text, ok := c[tag][key]; ok {
+			return text, true
+		}
+	}
+	return "", false
+}
+
+// chain returns the languages to look messages in lang up in, most
+// specific first: pt-BR, then pt, then Fallback
+func chain(lang language.Tag) []language.Tag {
+	tags := []language.Tag{lang}
+	if base, confidence := lang.Base(); confidence != language.No {
+		if tag, err := language.Compose(base); err == nil && tag != lang {
+			tags = append(tags, tag)
+		}
+	}
+	if tags[len(tags)-1] != Fallback {
+		tags = append(tags, Fallback)
+	}
+	return tags
+}
@@ -0,0 +1,58 @@
+package i18n
+
+import (
+	"net/http"
+	"strconv"
+
+	"golang.org/x/text/language"
+)
+
+// statusTexts holds the titles of the problem details of error responses,
+// keyed by status code
+var statusTexts = Catalog{
+	language.German: {
+		"400": "Ungültige Anfrage",
+		"401": "Nicht authentifiziert",
+		"403": "Verboten",
+		"404": "Nicht gefunden",
+		"405": "Methode nicht erlaubt",
+		"409": "Konflikt",
+		"410": "Nicht mehr verfügbar",
+		"412": "Vorbedingung fehlgeschlagen",
+		"413": "Anfrage zu groß",
+		"415": "Nicht unterstützter Medientyp",
+		"422": "Nicht verarbeitbare Anfrage",
+		"429": "Zu viele Anfragen",
+		"500": "Interner Serverfehler",
+		"502": "Ungültiges Gateway",
+		"503": "Dienst nicht verfügbar",
+		"504": "Gateway-Zeitüberschreitung",
+	},
+	language.Turkish: {
+		"400": "Geçersiz istek",
+		"401": "Kimlik doğrulanmadı",
+		"403": "Yasak",
+		"404": "Bulunamadı",
+		"405": "İzin verilmeyen yöntem",
+		"409": "Çakışma",
+		"410": "Artık mevcut değil",
+		"412": "Ön koşul başarısız",
+		"413": "İstek çok büyük",
+		"415": "Desteklenmeyen ortam türü",
+		"422": "İşlenemeyen istek",
+		"429": "Çok fazla istek",
+		"500": "Sunucu hatası",
+		"502": "Hatalı ağ geçidi",
+		"503": "Hizmet kullanılamıyor",
+		"504": "Ağ geçidi zaman aşımı",
+	},
+}
+
+// StatusText returns the text of status in lang, or in English as
+// http.StatusText has it
+func StatusText(lang language.Tag, status int) string {
+	if text := statusTexts.Message(lang, strconv.Itoa(status)); text != "" {
+		return text
+	}
+	return http.StatusText(status)
+}
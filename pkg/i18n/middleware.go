@@ -0,0 +1,45 @@
+package i18n
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// languageKey is the context key of the language of a request
+type languageKey struct{}
+
+// WithLanguage returns ctx carrying lang, the language of its request
+func WithLanguage(ctx context.Context, lang language.Tag) context.Context {
+	return context.WithValue(ctx, languageKey{}, lang)
+}
+
+// FromContext returns the language ctx carries, or Fallback
+func FromContext(ctx context.Context) language.Tag {
+	if lang, ok := ctx.Value(languageKey{}).(language.Tag); ok {
+		return lang
+	}
+	return Fallback
+}
+
+// Middleware returns Gin middleware that negotiates the language of each
+// request from its Accept-Language header, carries it in the context of
+// the request, and tells clients in Content-Language
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := Negotiate(c.GetHeader("Accept-Language"))
+		c.Request = c.Request.WithContext(WithLanguage(c.Request.Context(), lang))
+		c.Header("Content-Language", lang.String())
+		c.Next()
+	}
+}
+
+// Language returns the language of the request of c, negotiating it when
+// Middleware didn't
+func Language(c *gin.Context) language.Tag {
+	if lang, ok := c.Request.Context().Value(languageKey{}).(language.Tag); ok {
+		return lang
+	}
+	return Negotiate(c.GetHeader("Accept-Language"))
+}
@@ -0,0 +1,155 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// HeaderKey is the request header carrying the client's idempotency key
+	HeaderKey = "Idempotency-Key"
+	// HeaderReplayed is set on responses replayed from a stored record
+	HeaderReplayed = "Idempotent-Replayed"
+
+	// MaxKeyLength bounds client keys so derived keys fit provider limits
+	MaxKeyLength = 200
+)
+
+// Options configures the idempotency middleware
+type Options struct {
+	// TTL is how long a response is kept for replay
+	TTL time.Duration
+	// Lease is how long an in-progress request may run before a retry may take over
+	Lease time.Duration
+	// Required rejects requests without an Idempotency-Key header
+	Required bool
+	// Scope returns the caller the key belongs to, e.g. the authenticated user.
+	// Keys are additionally scoped by route.
+	Scope func(c *gin.Context) string
+}
+
+// responseRecorder captures the response body while writing it to the client
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// Middleware makes a route safe to retry. The first request with a given key
+// is executed and its response stored; retries with the same key and body get
+// the stored response, concurrent retries are rejected until the first one
+// finishes, and reusing a key for a different request is an error. Server
+// errors are not stored so the request can be retried.
+func Middleware(store *Store, opts Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderKey)
+		if key == "" {
+			if opts.Required {
+				c.JSON(http.StatusBadRequest, gin.H{"error": HeaderKey + " header is required"})
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if len(key) > MaxKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": HeaderKey + " header is too long"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := c.FullPath()
+		if opts.Scope != nil {
+			scope = opts.Scope(c) + ":" + scope
+		}
+		hash := requestHash(c.Request.Method, c.Request.URL.Path, body)
+
+		ctx := c.Request.Context()
+		record, acquired, err := store.Begin(ctx, scope, key, hash, opts.TTL, opts.Lease)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process request"})
+			c.Abort()
+			return
+		}
+
+		if !acquired {
+			replay(c, record, hash, opts.Lease)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// The outcome is recorded even when the client went away meanwhile,
+		// or its retries would wait out the lease of a request already handled
+		done := context.WithoutCancel(ctx)
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := store.Release(done, scope, key); err != nil {
+				store.logger.Error("Failed to release idempotency key after server error", "error", err)
+			}
+			return
+		}
+
+		if err := store.Complete(done, scope, key, status, recorder.body.Bytes()); err != nil {
+			store.logger.Error("Failed to store idempotent response", "error", err)
+		}
+	}
+}
+
+// replay answers a retried request from an existing record
+func replay(c *gin.Context, record *Record, hash string, lease time.Duration) {
+	defer c.Abort()
+
+	if record.RequestHash != hash {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": HeaderKey + " was already used for a different request"})
+		return
+	}
+
+	if record.Status != StatusCompleted || record.ResponseCode == nil {
+		c.Header("Retry-After", strconv.Itoa(int(lease.Seconds())))
+		c.JSON(http.StatusConflict, gin.H{"error": "A request with this " + HeaderKey + " is still in progress"})
+		return
+	}
+
+	c.Header(HeaderReplayed, "true")
+	c.Data(*record.ResponseCode, "application/json; charset=utf-8", record.ResponseBody)
+}
+
+// requestHash fingerprints a request so a key can't be reused for a different one
+func requestHash(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
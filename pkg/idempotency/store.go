@@ -0,0 +1,142 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// Record statuses
+const (
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+)
+
+// Record is the persisted outcome of a request made with an idempotency key
+type Record struct {
+	Scope        string    `db:"scope"`
+	Key          string    `db:"key"`
+	RequestHash  string    `db:"request_hash"`
+	Status       string    `db:"status"`
+	ResponseCode *int      `db:"response_code"`
+	ResponseBody []byte    `db:"response_body"`
+	LockedAt     time.Time `db:"locked_at"`
+	CreatedAt    time.Time `db:"created_at"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+// Store persists idempotency records in PostgreSQL
+type Store struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewStore creates a new idempotency store
+func NewStore(db *database.DB, logger *logger.Logger) *Store {
+	return &Store{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Begin claims a key for a new request. It returns acquired=true if the caller
+// should execute the request, otherwise the existing record is returned.
+// Expired keys and keys whose in-progress lock is older than lease (the
+// original request died) are taken over, the latter only for the same request.
+func (s *Store) Begin(ctx context.Context, scope, key, requestHash string, ttl, lease time.Duration) (*Record, bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (scope, key, request_hash, status, locked_at, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW(), NOW() + $5 * INTERVAL '1 second')
+		ON CONFLICT (scope, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, status = EXCLUDED.status, response_code = NULL,
+		    response_body = NULL, locked_at = NOW(), created_at = NOW(), expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < NOW()
+		   OR (idempotency_keys.status = $4
+		       AND idempotency_keys.request_hash = EXCLUDED.request_hash
+		       AND idempotency_keys.locked_at < NOW() - $6 * INTERVAL '1 second')
+		RETURNING scope`
+
+	var claimed string
+	err := s.db.QueryRowxContext(ctx, query, scope, key, requestHash, StatusInProgress, ttl.Seconds(), lease.Seconds()).Scan(&claimed)
+	if err == nil {
+		return nil, true, nil
+	}
+	if err != sql.ErrNoRows {
+		s.logger.Error("Failed to claim idempotency key", "error", err, "scope", scope)
+		return nil, false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	record := &Record{}
+	err = s.db.GetContext(ctx, record, `
+		SELECT scope, key, request_hash, status, response_code, response_body, locked_at, created_at, expires_at
+		FROM idempotency_keys
+		WHERE scope = $1 AND key = $2`, scope, key)
+	if err != nil {
+		s.logger.Error("Failed to get idempotency record", "error", err, "scope", scope)
+		return nil, false, fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+
+	return record, false, nil
+}
+
+// Complete stores the response of a request so retries can replay it
+func (s *Store) Complete(ctx context.Context, scope, key string, responseCode int, responseBody []byte) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status = $3, response_code = $4, response_body = $5
+		WHERE scope = $1 AND key = $2`,
+		scope, key, StatusCompleted, responseCode, responseBody)
+	if err != nil {
+		s.logger.Error("Failed to complete idempotency record", "error", err, "scope", scope)
+		return fmt.Errorf("failed to complete idempotency record: %w", err)
+	}
+
+	return nil
+}
+
+// Release deletes a key so the request can be retried, e.g. after a server error
+func (s *Store) Release(ctx context.Context, scope, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2`, scope, key)
+	if err != nil {
+		s.logger.Error("Failed to release idempotency key", "error", err, "scope", scope)
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeExpired deletes expired records and returns how many were removed
+func (s *Store) PurgeExpired(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at < NOW()`)
+	if err != nil {
+		s.logger.Error("Failed to purge idempotency keys", "error", err)
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// RunPurger deletes expired records every interval until ctx is cancelled
+func (s *Store) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeExpired(ctx)
+			if err != nil {
+				continue
+			}
+			if purged > 0 {
+				s.logger.Info("Purged expired idempotency keys", "count", purged)
+			}
+		}
+	}
+}
@@ -0,0 +1,510 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/retry"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// Headers added to messages routed to a dead-letter topic
+const (
+	HeaderOriginalTopic     = "dlq-original-topic"
+	HeaderOriginalPartition = "dlq-original-partition"
+	HeaderOriginalOffset    = "dlq-original-offset"
+	HeaderConsumerGroup     = "dlq-consumer-group"
+	HeaderError             = "dlq-error"
+	HeaderAttempts          = "dlq-attempts"
+)
+
+// Headers added to messages parked on the retry topic of a delay tier
+const (
+	HeaderRetryOriginalTopic     = "retry-original-topic"
+	HeaderRetryOriginalPartition = "retry-original-partition"
+	HeaderRetryOriginalOffset    = "retry-original-offset"
+	HeaderRetryAttempts          = "retry-attempts"
+	// HeaderRetryNotBefore holds the Unix time in milliseconds the message
+	// is handled again at
+	HeaderRetryNotBefore = "retry-not-before"
+)
+
+// Handler processes the key and value of a consumed message
+type Handler func(ctx context.Context, key, value []byte) error
+
+// permanentError marks a handler error that retrying can't fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as one that retrying won't fix, e.g. a malformed
+// message. The message is dead-lettered without further attempts.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// ConsumerGroup consumes topics as a member of a consumer group, passing each
+// message to the handler registered for its topic. A message is committed
+// once handled. Failed messages are retried with backoff as the retry policy
+// of their handler allows. Messages that still fail are parked on a retry
+// topic for each delay tier of the policy in turn and handled again once the
+// delay passed. Messages failing after that, or failing permanently, are
+// published to the dead-letter topic of their topic and committed, so a
+// poison message can't stall its partition.
+type ConsumerGroup struct {
+	config      config.KafkaConfig
+	groupID     string
+	handlers    map[string]Handler
+	policies    map[string]retry.Policy
+	schemas     *events.SchemaSet
+	deadLetters *Producer
+	logger      *logger.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewConsumerGroup creates a new consumer group member. Services consuming
+// the same topic must use different groups, or each gets only part of the
+// messages. Parked and dead-lettered messages are published with
+// deadLetters; when it is nil, messages aren't parked and failed messages
+// are logged and skipped.
+func NewConsumerGroup(cfg config.KafkaConfig, groupID string, deadLetters *Producer, log *logger.Logger) (*ConsumerGroup, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("no kafka brokers configured")
+	}
+	if groupID == "" {
+		return nil, fmt.Errorf("no kafka consumer group configured")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ConsumerGroup{
+		config:      cfg,
+		groupID:     groupID,
+		handlers:    make(map[string]Handler),
+		policies:    make(map[string]retry.Policy),
+		deadLetters: deadLetters,
+		logger:      log,
+		ctx:         ctx,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}, nil
+}
+
+// Register registers the handler of a topic. Handlers must be registered
+// before Run is started.
+func (g *ConsumerGroup) Register(topic string, handler Handler) {
+	g.handlers[topic] = handler
+}
+
+// SetRetryPolicy sets the retry policy of the handler of a topic, which
+// otherwise retries as DefaultRetryPolicy. It must be set before Run is
+// started.
+func (g *ConsumerGroup) SetRetryPolicy(topic string, policy retry.Policy) {
+	g.policies[topic] = policy
+}
+
+// retryPolicy returns the retry policy of the handler of a topic
+func (g *ConsumerGroup) retryPolicy(topic string) retry.Policy {
+	if policy, ok := g.policies[topic]; ok {
+		return policy
+	}
+	return DefaultRetryPolicy(g.config)
+}
+
+// DefaultRetryPolicy returns the retry policy of the retry settings of the
+// brokers: RetryMax retries in place and no delay tiers
+func DefaultRetryPolicy(cfg config.KafkaConfig) retry.Policy {
+	return retry.Policy{
+		MaxAttempts: cfg.RetryMax + 1,
+		BackoffMin:  cfg.RetryBackoffMin,
+		BackoffMax:  cfg.RetryBackoffMax,
+	}
+}
+
+// ValidateWith makes the handlers registered with HandleEvent validate the
+// events they consume against schemas. Events that don't match their schema
+// are dead-lettered right away. It must be called before Run is started.
+func (g *ConsumerGroup) ValidateWith(schemas *events.SchemaSet) {
+	g.schemas = schemas
+}
+
+// Handle registers a handler for the JSON messages of a topic, decoded into
+// T. Messages that can't be decoded are dead-lettered right away.
+func Handle[T any](g *ConsumerGroup, topic string, handle func(ctx context.Context, key string, message *T) error) {
+	g.Register(topic, func(ctx context.Context, key, value []byte) error {
+		var message T
+		if err := json.Unmarshal(value, &message); err != nil {
+			return Permanent(fmt.Errorf("invalid message: %w", err))
+		}
+		return handle(ctx, string(key), &message)
+	})
+}
+
+// HandleEvent registers a handler for the event envelopes of a topic, with
+// the payload decoded into T in the latest schema version of its event type.
+// Messages that aren't valid events are dead-lettered right away.
+func HandleEvent[T any](g *ConsumerGroup, topic string, handle func(ctx context.Context, envelope *events.Envelope, event *T) error) {
+	g.Register(topic, func(ctx context.Context, key, value []byte) error {
+		envelope, err := events.Parse(value)
+		if err != nil {
+			return Permanent(err)
+		}
+		if g.schemas != nil {
+			if err := g.schemas.Validate(envelope); err != nil {
+				return Permanent(err)
+			}
+		}
+		var event T
+		if err := envelope.Decode(&event); err != nil {
+			return Permanent(err)
+		}
+		return handle(ctx, envelope, &event)
+	})
+}
+
+// Run consumes the registered topics, and the retry topics of their delay
+// tiers, until Close is called
+func (g *ConsumerGroup) Run() {
+	defer close(g.done)
+
+	topics := make([]string, 0, len(g.handlers))
+	for topic := range g.handlers {
+		if topic == "" {
+			g.logger.Error("Kafka handler registered without a topic", "group", g.groupID)
+			continue
+		}
+		topics = append(topics, topic)
+	}
+	if len(topics) == 0 {
+		g.logger.Error("No Kafka topics to consume", "group", g.groupID)
+		return
+	}
+	sort.Strings(topics)
+
+	// Each delay tier is consumed by a group of its own: waiting for the
+	// delay of a parked message mustn't hold up the messages of other tiers
+	var wg sync.WaitGroup
+	for i, retryTopics := range g.retryTopics(topics) {
+		tier := i + 1
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.consume(fmt.Sprintf("%s.retry-%d", g.groupID, tier), retryTopics, func(message kafka.Message) bool {
+				return g.processRetry(message, tier)
+			})
+		}()
+	}
+
+	g.consume(g.groupID, topics, g.process)
+	wg.Wait()
+}
+
+// retryTopics returns the retry topics of each delay tier of the topics.
+// Without a producer to park messages with, messages aren't retried later.
+func (g *ConsumerGroup) retryTopics(topics []string) [][]string {
+	if g.deadLetters == nil {
+		return nil
+	}
+
+	var tiers [][]string
+	for _, topic := range topics {
+		for i := range g.retryPolicy(topic).DelayTiers {
+			if i == len(tiers) {
+				tiers = append(tiers, nil)
+			}
+			tiers[i] = append(tiers[i], g.retryTopic(topic, i+1))
+		}
+	}
+	return tiers
+}
+
+// retryTopic returns the topic messages of a topic are parked on for a
+// delay tier. It is specific to the group, so other groups consuming the
+// topic don't handle the message again.
+func (g *ConsumerGroup) retryTopic(topic string, tier int) string {
+	return fmt.Sprintf("%s.%s.retry-%d", topic, g.groupID, tier)
+}
+
+// consume consumes topics as a member of a group until the group is closed,
+// committing the messages process reports can be
+func (g *ConsumerGroup) consume(groupID string, topics []string, process func(kafka.Message) bool) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     g.config.Brokers,
+		GroupID:     groupID,
+		GroupTopics: topics,
+		// Retry topics are only created once a message is parked
+		WatchPartitionChanges: true,
+	})
+	// Closing the reader leaves the group, so its partitions are reassigned
+	// right away rather than after the session times out
+	defer func() {
+		if err := reader.Close(); err != nil {
+			g.logger.Error("Failed to close Kafka consumer", "error", err, "group", groupID)
+		}
+	}()
+
+	g.logger.Info("Kafka consumer group started", "brokers", g.config.Brokers, "group", groupID, "topics", topics)
+
+	for {
+		message, err := reader.FetchMessage(g.ctx)
+		if err != nil {
+			if g.ctx.Err() != nil || errors.Is(err, io.EOF) {
+				return
+			}
+			g.logger.Error("Failed to fetch message", "error", err, "group", groupID)
+			continue
+		}
+
+		if !process(message) {
+			// Shutting down mid-retry: the message is left uncommitted for the
+			// member taking over the partition
+			return
+		}
+
+		// The offset of a handled message is committed even when shutting down
+		if err := reader.CommitMessages(context.WithoutCancel(g.ctx), message); err != nil {
+			g.logger.Error("Failed to commit message", "error", err, "topic", message.Topic, "offset", message.Offset)
+		}
+	}
+}
+
+// process handles a message of a registered topic and reports whether it
+// can be committed
+func (g *ConsumerGroup) process(message kafka.Message) bool {
+	return g.handle(message, message.Topic, 0, 0)
+}
+
+// processRetry handles a message parked on the retry topic of a delay tier
+// once its delay passed, and reports whether it can be committed
+func (g *ConsumerGroup) processRetry(message kafka.Message, tier int) bool {
+	topic := header(message.Headers, HeaderRetryOriginalTopic)
+	if _, ok := g.handlers[topic]; !ok {
+		g.logger.Error("Parked message has no handler, skipping it", "topic", message.Topic,
+			"partition", message.Partition, "offset", message.Offset, "original_topic", topic)
+		return true
+	}
+
+	notBefore, _ := strconv.ParseInt(header(message.Headers, HeaderRetryNotBefore), 10, 64)
+	if !g.sleep(time.Until(time.UnixMilli(notBefore))) {
+		return false
+	}
+
+	attempts, _ := strconv.Atoi(header(message.Headers, HeaderRetryAttempts))
+	return g.handle(message, topic, tier, attempts)
+}
+
+// handle passes a message to the handler of topic, retrying failures as
+// the retry policy of the handler allows, and parks messages that keep
+// failing on the retry topic of the next delay tier or dead-letters them.
+// It reports whether the message can be committed.
+func (g *ConsumerGroup) handle(message kafka.Message, topic string, tier, previousAttempts int) bool {
+	handle := g.handlers[topic]
+	policy := g.retryPolicy(topic)
+
+	// A message being handled is finished even when shutting down; handlers
+	// run in a consumer span continuing the trace of the request that
+	// published it
+	ctx, span := tracing.GetTracer(tracerName).Start(
+		extractTraceContext(context.WithoutCancel(g.ctx), message.Headers), message.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(tracing.MessagingAttributes(tracing.MessagingSystemKafka, tracing.MessagingOperationProcess, message.Topic)...),
+		trace.WithAttributes(
+			attribute.String("messaging.kafka.consumer.group", g.groupID),
+			attribute.Int("messaging.kafka.destination.partition", message.Partition),
+			attribute.Int64("messaging.kafka.message.offset", message.Offset),
+			attribute.String("messaging.kafka.message.key", string(message.Key)),
+			tracing.MessagingRetryTierKey.Int(tier),
+		))
+	defer span.End()
+
+	for attempt := 1; ; attempt++ {
+		err := handle(ctx, message.Key, message.Value)
+		if err == nil {
+			return true
+		}
+		attemptAttribute := tracing.MessagingAttemptKey.Int(previousAttempts + attempt)
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			tracing.RecordError(span, err, attemptAttribute)
+			return g.deadLetter(ctx, message, err, previousAttempts+attempt)
+		}
+		if !policy.Retryable(attempt) {
+			tracing.RecordError(span, err, attemptAttribute)
+			if delay, ok := policy.Delay(tier + 1); ok && g.deadLetters != nil {
+				return g.park(ctx, message, err, tier+1, delay, previousAttempts+attempt)
+			}
+			return g.deadLetter(ctx, message, err, previousAttempts+attempt)
+		}
+
+		// The message may yet be handled, so the span isn't failed by the
+		// attempt
+		span.RecordError(err, trace.WithAttributes(attemptAttribute))
+		g.logger.Warn("Failed to handle message, will retry", "error", err,
+			"topic", message.Topic, "partition", message.Partition, "offset", message.Offset, "attempt", attempt)
+		if !g.sleep(policy.Backoff(attempt)) {
+			return false
+		}
+	}
+}
+
+// park publishes a message that keeps failing to the retry topic of a delay
+// tier, to be handled again once the delay passed, and reports whether it
+// was published before the group was closed
+func (g *ConsumerGroup) park(ctx context.Context, message kafka.Message, cause error, tier int, delay time.Duration, attempts int) bool {
+	topic, partition, offset := origin(message)
+	parked := kafka.Message{
+		Topic:   g.retryTopic(topic, tier),
+		Key:     message.Key,
+		Value:   message.Value,
+		Headers: withoutHeaders(message.Headers, "retry-"),
+	}
+	for key, value := range map[string]string{
+		HeaderRetryOriginalTopic:     topic,
+		HeaderRetryOriginalPartition: strconv.Itoa(partition),
+		HeaderRetryOriginalOffset:    strconv.FormatInt(offset, 10),
+		HeaderRetryAttempts:          strconv.Itoa(attempts),
+		HeaderRetryNotBefore:         strconv.FormatInt(time.Now().Add(delay).UnixMilli(), 10),
+	} {
+		headerCarrier{headers: &parked.Headers}.Set(key, value)
+	}
+
+	if !g.publish(ctx, parked) {
+		return false
+	}
+	g.logger.Warn("Failed to handle message, parked it for a later retry", "error", cause,
+		"topic", topic, "partition", partition, "offset", offset,
+		"attempts", attempts, "retry_topic", parked.Topic, "delay", delay)
+	return true
+}
+
+// deadLetter publishes a message that failed to its topic's dead-letter
+// topic and reports whether it was published before the group was closed
+func (g *ConsumerGroup) deadLetter(ctx context.Context, message kafka.Message, cause error, attempts int) bool {
+	topic, partition, offset := origin(message)
+	if g.deadLetters == nil {
+		g.logger.Error("Failed to handle message, skipping it", "error", cause,
+			"topic", topic, "partition", partition, "offset", offset, "attempts", attempts)
+		return true
+	}
+
+	deadLetter := kafka.Message{
+		Topic:   topic + g.config.DeadLetterSuffix,
+		Key:     message.Key,
+		Value:   message.Value,
+		Headers: withoutHeaders(message.Headers, "retry-"),
+	}
+	for key, value := range map[string]string{
+		HeaderOriginalTopic:     topic,
+		HeaderOriginalPartition: strconv.Itoa(partition),
+		HeaderOriginalOffset:    strconv.FormatInt(offset, 10),
+		HeaderConsumerGroup:     g.groupID,
+		HeaderError:             cause.Error(),
+		HeaderAttempts:          strconv.Itoa(attempts),
+	} {
+		headerCarrier{headers: &deadLetter.Headers}.Set(key, value)
+	}
+
+	if !g.publish(ctx, deadLetter) {
+		return false
+	}
+	g.logger.Error("Failed to handle message, moved it to the dead-letter topic", "error", cause,
+		"topic", topic, "partition", partition, "offset", offset,
+		"attempts", attempts, "dead_letter_topic", deadLetter.Topic)
+	return true
+}
+
+// publish publishes a message to a retry or dead-letter topic, retrying
+// until it is published or the group is closed, and reports whether it was
+func (g *ConsumerGroup) publish(ctx context.Context, message kafka.Message) bool {
+	policy := DefaultRetryPolicy(g.config)
+	for attempt := 1; ; attempt++ {
+		err := g.deadLetters.write(ctx, message.Topic, message)
+		if err == nil {
+			return true
+		}
+
+		g.logger.Error("Failed to publish message, will retry", "error", err, "topic", message.Topic)
+		if !g.sleep(policy.Backoff(attempt)) {
+			return false
+		}
+	}
+}
+
+// origin returns the topic, partition and offset a message was consumed
+// from first, before it was parked on a retry topic
+func origin(message kafka.Message) (string, int, int64) {
+	topic := header(message.Headers, HeaderRetryOriginalTopic)
+	if topic == "" {
+		return message.Topic, message.Partition, message.Offset
+	}
+	partition, _ := strconv.Atoi(header(message.Headers, HeaderRetryOriginalPartition))
+	offset, _ := strconv.ParseInt(header(message.Headers, HeaderRetryOriginalOffset), 10, 64)
+	return topic, partition, offset
+}
+
+// withoutHeaders returns a copy of headers without those whose key has prefix
+func withoutHeaders(headers []kafka.Header, prefix string) []kafka.Header {
+	kept := make([]kafka.Header, 0, len(headers))
+	for _, h := range headers {
+		if !strings.HasPrefix(h.Key, prefix) {
+			kept = append(kept, h)
+		}
+	}
+	return kept
+}
+
+// sleep waits for d and reports whether the group is still running
+func (g *ConsumerGroup) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-g.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Close stops consuming. It waits for the message being handled, commits it
+// and leaves the group. Run must have been started.
+func (g *ConsumerGroup) Close() {
+	g.Shutdown(context.Background())
+}
+
+// Shutdown is Close, waiting for the message being handled until ctx is
+// done. A message whose handler didn't finish by then is left uncommitted,
+// for the member taking over its partition.
+func (g *ConsumerGroup) Shutdown(ctx context.Context) error {
+	g.cancel()
+	select {
+	case <-g.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
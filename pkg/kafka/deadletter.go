@@ -0,0 +1,360 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// deadLetterReadTimeout bounds how long reading a dead-letter partition may take
+const deadLetterReadTimeout = 10 * time.Second
+
+// deadLetterMaxBytes bounds the size of a batch read from a dead-letter partition
+const deadLetterMaxBytes = 10 << 20
+
+// ErrDeadLetterNotFound is returned for dead-letter topics, partitions or
+// offsets that don't exist
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetterTopic summarizes a dead-letter topic
+type DeadLetterTopic struct {
+	Topic      string                 `json:"topic"`
+	Messages   int64                  `json:"messages"`
+	Partitions []*DeadLetterPartition `json:"partitions"`
+}
+
+// DeadLetterPartition is the range of offsets of the messages a partition of a
+// dead-letter topic holds; LastOffset is the offset its next message gets
+type DeadLetterPartition struct {
+	Partition   int   `json:"partition"`
+	FirstOffset int64 `json:"first_offset"`
+	LastOffset  int64 `json:"last_offset"`
+}
+
+// DeadLetter is a message parked in a dead-letter topic, with the reason it
+// failed as the consumer group recorded it
+type DeadLetter struct {
+	Topic             string            `json:"topic"`
+	Partition         int               `json:"partition"`
+	Offset            int64             `json:"offset"`
+	Key               string            `json:"key"`
+	Value             string            `json:"value"`
+	OriginalTopic     string            `json:"original_topic"`
+	OriginalPartition int               `json:"original_partition"`
+	OriginalOffset    int64             `json:"original_offset"`
+	ConsumerGroup     string            `json:"consumer_group"`
+	Error             string            `json:"error"`
+	Attempts          int               `json:"attempts"`
+	Headers           map[string]string `json:"headers,omitempty"`
+	Time              time.Time         `json:"time"`
+}
+
+// DeadLetterQueue inspects the dead-letter topics consumer groups park failed
+// messages in, and redrives messages back to the topic they failed on.
+// Redriven messages are published to the original topic, so every group
+// consuming it sees them again; consumers with side effects deduplicate them
+// with an inbox. Redriving leaves the message in the dead-letter topic.
+type DeadLetterQueue struct {
+	config   config.KafkaConfig
+	producer *Producer
+	logger   *logger.Logger
+}
+
+// NewDeadLetterQueue creates a new dead-letter queue. Messages are redriven
+// with producer.
+func NewDeadLetterQueue(cfg config.KafkaConfig, producer *Producer, log *logger.Logger) (*DeadLetterQueue, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("no kafka brokers configured")
+	}
+
+	return &DeadLetterQueue{
+		config:   cfg,
+		producer: producer,
+		logger:   log,
+	}, nil
+}
+
+// IsDeadLetterTopic reports whether topic is a dead-letter topic
+func (q *DeadLetterQueue) IsDeadLetterTopic(topic string) bool {
+	return strings.HasSuffix(topic, q.config.DeadLetterSuffix) && topic != q.config.DeadLetterSuffix
+}
+
+// Topics lists the dead-letter topics and how many messages they hold
+func (q *DeadLetterQueue) Topics(ctx context.Context) ([]*DeadLetterTopic, error) {
+	conn, err := kafka.DialContext(ctx, "tcp", q.config.Brokers[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to kafka: %w", err)
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topics: %w", err)
+	}
+
+	byTopic := make(map[string]*DeadLetterTopic)
+	for _, partition := range partitions {
+		if !q.IsDeadLetterTopic(partition.Topic) {
+			continue
+		}
+		topic, ok := byTopic[partition.Topic]
+		if !ok {
+			topic = &DeadLetterTopic{Topic: partition.Topic, Partitions: []*DeadLetterPartition{}}
+			byTopic[partition.Topic] = topic
+		}
+
+		offsets, err := q.partitionOffsets(ctx, partition.Topic, partition.ID)
+		if err != nil {
+			return nil, err
+		}
+		topic.Partitions = append(topic.Partitions, offsets)
+		topic.Messages += offsets.LastOffset - offsets.FirstOffset
+	}
+
+	topics := make([]*DeadLetterTopic, 0, len(byTopic))
+	for _, topic := range byTopic {
+		sort.Slice(topic.Partitions, func(i, j int) bool {
+			return topic.Partitions[i].Partition < topic.Partitions[j].Partition
+		})
+		topics = append(topics, topic)
+	}
+	sort.Slice(topics, func(i, j int) bool { return topics[i].Topic < topics[j].Topic })
+
+	return topics, nil
+}
+
+// partitionOffsets returns the range of offsets of a partition
+func (q *DeadLetterQueue) partitionOffsets(ctx context.Context, topic string, partition int) (*DeadLetterPartition, error) {
+	conn, err := q.dialPartition(ctx, topic, partition)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read offsets of %s/%d: %w", topic, partition, err)
+	}
+
+	return &DeadLetterPartition{Partition: partition, FirstOffset: first, LastOffset: last}, nil
+}
+
+// List returns up to limit messages of a partition of a dead-letter topic,
+// starting at offset. Offsets before the first message still held start at
+// the first one.
+func (q *DeadLetterQueue) List(ctx context.Context, topic string, partition int, offset int64, limit int) ([]*DeadLetter, error) {
+	if !q.IsDeadLetterTopic(topic) {
+		return nil, ErrDeadLetterNotFound
+	}
+
+	conn, err := q.dialPartition(ctx, topic, partition)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read offsets of %s/%d: %w", topic, partition, err)
+	}
+	if offset < first {
+		offset = first
+	}
+
+	deadLetters := []*DeadLetter{}
+	if offset >= last || limit <= 0 {
+		return deadLetters, nil
+	}
+
+	messages, err := q.read(conn, offset, last, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s/%d: %w", topic, partition, err)
+	}
+	for _, message := range messages {
+		deadLetters = append(deadLetters, newDeadLetter(message))
+	}
+
+	return deadLetters, nil
+}
+
+// Get returns the message at an offset of a partition of a dead-letter topic
+func (q *DeadLetterQueue) Get(ctx context.Context, topic string, partition int, offset int64) (*DeadLetter, error) {
+	message, err := q.readOne(ctx, topic, partition, offset)
+	if err != nil {
+		return nil, err
+	}
+	return newDeadLetter(message), nil
+}
+
+// Redrive publishes the messages at the offsets of a partition of a
+// dead-letter topic back to the topics they failed on, without the headers
+// the dead-lettering added. It stops at the first message that can't be
+// redriven and returns how many were.
+func (q *DeadLetterQueue) Redrive(ctx context.Context, topic string, partition int, offsets []int64) (int, error) {
+	if q.producer == nil {
+		return 0, fmt.Errorf("redriving dead letters is disabled")
+	}
+
+	for i, offset := range offsets {
+		message, err := q.readOne(ctx, topic, partition, offset)
+		if err != nil {
+			return i, err
+		}
+
+		originalTopic := header(message.Headers, HeaderOriginalTopic)
+		if originalTopic == "" {
+			return i, fmt.Errorf("dead letter %s/%d/%d is invalid: it has no original topic", topic, partition, offset)
+		}
+
+		redriven := kafka.Message{
+			Topic: originalTopic,
+			Key:   message.Key,
+			Value: message.Value,
+		}
+		for _, h := range message.Headers {
+			if !strings.HasPrefix(h.Key, "dlq-") {
+				redriven.Headers = append(redriven.Headers, h)
+			}
+		}
+
+		if err := q.producer.write(extractTraceContext(ctx, message.Headers), originalTopic, redriven); err != nil {
+			return i, fmt.Errorf("failed to redrive dead letter %s/%d/%d: %w", topic, partition, offset, err)
+		}
+
+		q.logger.Info("Dead letter redriven", "topic", topic, "partition", partition, "offset", offset,
+			"original_topic", originalTopic, "consumer_group", header(message.Headers, HeaderConsumerGroup))
+	}
+
+	return len(offsets), nil
+}
+
+// readOne reads the message at an offset of a partition of a dead-letter topic
+func (q *DeadLetterQueue) readOne(ctx context.Context, topic string, partition int, offset int64) (kafka.Message, error) {
+	if !q.IsDeadLetterTopic(topic) {
+		return kafka.Message{}, ErrDeadLetterNotFound
+	}
+
+	conn, err := q.dialPartition(ctx, topic, partition)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	defer conn.Close()
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("failed to read offsets of %s/%d: %w", topic, partition, err)
+	}
+	if offset < first || offset >= last {
+		return kafka.Message{}, ErrDeadLetterNotFound
+	}
+
+	messages, err := q.read(conn, offset, last, 1)
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("failed to read %s/%d: %w", topic, partition, err)
+	}
+	// Compacted or transactional topics can skip offsets
+	if len(messages) == 0 || messages[0].Offset != offset {
+		return kafka.Message{}, ErrDeadLetterNotFound
+	}
+
+	return messages[0], nil
+}
+
+// read reads up to limit messages of the partition conn is connected to,
+// from offset up to last, excluded
+func (q *DeadLetterQueue) read(conn *kafka.Conn, offset, last int64, limit int) ([]kafka.Message, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(deadLetterReadTimeout)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Seek(offset, kafka.SeekAbsolute); err != nil {
+		return nil, err
+	}
+
+	messages := []kafka.Message{}
+	for len(messages) < limit && offset < last {
+		batch := conn.ReadBatch(1, deadLetterMaxBytes)
+		read := 0
+		for len(messages) < limit {
+			message, err := batch.ReadMessage()
+			if err != nil {
+				break
+			}
+			read++
+			offset = message.Offset + 1
+			messages = append(messages, message)
+			if offset >= last {
+				break
+			}
+		}
+		if err := batch.Close(); err != nil {
+			return nil, err
+		}
+		if read == 0 {
+			break
+		}
+	}
+
+	return messages, nil
+}
+
+// dialPartition connects to the leader of a partition
+func (q *DeadLetterQueue) dialPartition(ctx context.Context, topic string, partition int) (*kafka.Conn, error) {
+	conn, err := kafka.DialLeader(ctx, "tcp", q.config.Brokers[0], topic, partition)
+	if err != nil {
+		var kafkaErr kafka.Error
+		if errors.As(err, &kafkaErr) && (kafkaErr == kafka.UnknownTopicOrPartition || kafkaErr == kafka.LeaderNotAvailable) {
+			return nil, ErrDeadLetterNotFound
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			return nil, fmt.Errorf("failed to connect to kafka: %w", err)
+		}
+		return nil, fmt.Errorf("failed to connect to %s/%d: %w", topic, partition, err)
+	}
+	return conn, nil
+}
+
+// newDeadLetter decodes the dead-lettering headers of a message
+func newDeadLetter(message kafka.Message) *DeadLetter {
+	deadLetter := &DeadLetter{
+		Topic:         message.Topic,
+		Partition:     message.Partition,
+		Offset:        message.Offset,
+		Key:           string(message.Key),
+		Value:         string(message.Value),
+		OriginalTopic: header(message.Headers, HeaderOriginalTopic),
+		ConsumerGroup: header(message.Headers, HeaderConsumerGroup),
+		Error:         header(message.Headers, HeaderError),
+		Time:          message.Time,
+	}
+	deadLetter.OriginalPartition, _ = strconv.Atoi(header(message.Headers, HeaderOriginalPartition))
+	deadLetter.OriginalOffset, _ = strconv.ParseInt(header(message.Headers, HeaderOriginalOffset), 10, 64)
+	deadLetter.Attempts, _ = strconv.Atoi(header(message.Headers, HeaderAttempts))
+
+	for _, h := range message.Headers {
+		if strings.HasPrefix(h.Key, "dlq-") {
+			continue
+		}
+		if deadLetter.Headers == nil {
+			deadLetter.Headers = make(map[string]string)
+		}
+		deadLetter.Headers[h.Key] = string(h.Value)
+	}
+
+	return deadLetter
+}
+
+// header returns the value of a message header
+func header(headers []kafka.Header, key string) string {
+	return headerCarrier{headers: &headers}.Get(key)
+}
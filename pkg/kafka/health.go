@@ -0,0 +1,47 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// healthCheckTimeout bounds how long checking the brokers may take
+const healthCheckTimeout = 5 * time.Second
+
+// HealthCheck checks that a broker of the cluster answers a metadata request
+func HealthCheck(brokers []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	var err error
+	for _, broker := range brokers {
+		var conn *kafka.Conn
+		conn, err = kafka.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			continue
+		}
+		conn.SetDeadline(time.Now().Add(healthCheckTimeout))
+		_, err = conn.Brokers()
+		conn.Close()
+		if err == nil {
+			return nil
+		}
+	}
+	if err == nil {
+		return fmt.Errorf("no kafka brokers configured")
+	}
+	return fmt.Errorf("kafka unavailable: %w", err)
+}
+
+// HealthCheck checks that the producer's cluster is reachable
+func (p *Producer) HealthCheck() error {
+	return HealthCheck(p.brokers)
+}
+
+// HealthCheck checks that the group's cluster is reachable
+func (g *ConsumerGroup) HealthCheck() error {
+	return HealthCheck(g.config.Brokers)
+}
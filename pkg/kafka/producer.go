@@ -0,0 +1,188 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// Producer publishes JSON-encoded messages to Kafka topics. Messages are
+// sent in batches, failed writes are retried with backoff, and each message
+// carries the trace context of the publishing request in its headers.
+type Producer struct {
+	writer      *kafka.Writer
+	brokers     []string
+	schemas     *events.SchemaSet
+	metrics     metrics.Registry
+	serviceName string
+	logger      *logger.Logger
+}
+
+// NewProducer creates a new Kafka producer. Delivery metrics are recorded in
+// metricsRegistry, which may be nil.
+func NewProducer(cfg config.KafkaConfig, metricsRegistry metrics.Registry, serviceName string, log *logger.Logger) (*Producer, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("no kafka brokers configured")
+	}
+
+	writer := &kafka.Writer{
+		Addr: kafka.TCP(cfg.Brokers...),
+		// Messages with the same key go to the same partition, so they stay in order
+		Balancer:        &kafka.Hash{},
+		RequiredAcks:    kafka.RequireAll,
+		BatchSize:       cfg.BatchSize,
+		BatchTimeout:    cfg.BatchTimeout,
+		MaxAttempts:     cfg.RetryMax + 1,
+		WriteBackoffMin: cfg.RetryBackoffMin,
+		WriteBackoffMax: cfg.RetryBackoffMax,
+		// Retry and dead-letter topics are created when the first message is
+		// parked on them
+		AllowAutoTopicCreation: true,
+	}
+
+	log.Info("Kafka producer created", "brokers", cfg.Brokers, "batch_size", cfg.BatchSize, "retry_max", cfg.RetryMax)
+
+	return &Producer{
+		writer:      writer,
+		brokers:     cfg.Brokers,
+		metrics:     metricsRegistry,
+		serviceName: serviceName,
+		logger:      log,
+	}, nil
+}
+
+// ValidateWith makes the producer validate the event envelopes it publishes
+// against schemas. Events that don't match their schema aren't published.
+func (p *Producer) ValidateWith(schemas *events.SchemaSet) {
+	p.schemas = schemas
+}
+
+// Publish encodes value as JSON and writes it to the topic.
+// Messages with the same key are routed to the same partition.
+func (p *Producer) Publish(ctx context.Context, topic, key string, value interface{}) error {
+	payload, err := p.encode(value)
+	if err != nil {
+		return err
+	}
+
+	err = p.write(ctx, topic, kafka.Message{
+		Topic: topic,
+		Key:   []byte(key),
+		Value: payload,
+	})
+	if err != nil {
+		p.logger.Error("Failed to publish message", "error", err, "topic", topic, "key", key)
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	return nil
+}
+
+// Message is a message to publish in a batch
+type Message struct {
+	Key   string
+	Value interface{}
+}
+
+// PublishBatch encodes the messages as JSON and writes them to the topic in
+// one call, which is much cheaper than publishing them one by one.
+func (p *Producer) PublishBatch(ctx context.Context, topic string, messages []Message) error {
+	batch := make([]kafka.Message, 0, len(messages))
+	for _, message := range messages {
+		payload, err := p.encode(message.Value)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, kafka.Message{
+			Topic: topic,
+			Key:   []byte(message.Key),
+			Value: payload,
+		})
+	}
+
+	if err := p.write(ctx, topic, batch...); err != nil {
+		p.logger.Error("Failed to publish messages", "error", err, "topic", topic, "count", len(batch))
+		return fmt.Errorf("failed to publish messages: %w", err)
+	}
+
+	return nil
+}
+
+// encode encodes a message value as JSON, validating event envelopes first
+func (p *Producer) encode(value interface{}) ([]byte, error) {
+	if envelope, ok := value.(*events.Envelope); ok && p.schemas != nil {
+		if err := p.schemas.Validate(envelope); err != nil {
+			p.logger.Error("Refused to publish invalid event", "error", err, "type", envelope.Type, "id", envelope.ID)
+			return nil, err
+		}
+	}
+
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return payload, nil
+}
+
+// write writes messages of a topic in a producer span whose context the
+// messages carry, and records how their delivery went
+func (p *Producer) write(ctx context.Context, topic string, messages ...kafka.Message) error {
+	ctx, span := tracing.GetTracer(tracerName).Start(ctx, topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(tracing.MessagingAttributes(tracing.MessagingSystemKafka, tracing.MessagingOperationPublish, topic)...),
+		trace.WithAttributes(tracing.MessagingBatchCountKey.Int(len(messages))))
+	defer span.End()
+
+	for i := range messages {
+		injectTraceContext(ctx, &messages[i].Headers)
+	}
+
+	start := time.Now()
+	err := p.writer.WriteMessages(ctx, messages...)
+	p.recordDelivery(topic, len(messages), time.Since(start), err)
+
+	tracing.RecordError(span, err)
+	return err
+}
+
+// recordDelivery records the outcome of writing count messages to a topic.
+// A write can fail for some of its messages only.
+func (p *Producer) recordDelivery(topic string, count int, duration time.Duration, err error) {
+	if p.metrics == nil {
+		return
+	}
+
+	failed := 0
+	if err != nil {
+		failed = count
+		var writeErrs kafka.WriteErrors
+		if errors.As(err, &writeErrs) {
+			failed = writeErrs.Count()
+		}
+	}
+
+	p.metrics.ObserveKafkaPublishDuration(topic, p.serviceName, duration.Seconds())
+	if delivered := count - failed; delivered > 0 {
+		p.metrics.AddKafkaMessages(topic, "delivered", p.serviceName, delivered)
+	}
+	if failed > 0 {
+		p.metrics.AddKafkaMessages(topic, "failed", p.serviceName, failed)
+	}
+}
+
+// Close flushes pending messages and closes the producer
+func (p *Producer) Close() error {
+	p.logger.Info("Closing Kafka producer")
+	return p.writer.Close()
+}
@@ -0,0 +1,62 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// tracerName is the name of the tracer of Kafka spans
+const tracerName = "kafka"
+
+// traceContext propagates trace context and baggage in message headers in
+// the W3C formats, whatever propagator the service configured globally
+var traceContext = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// headerCarrier adapts message headers to a propagation.TextMapCarrier
+type headerCarrier struct {
+	headers *[]kafka.Header
+}
+
+// Get returns the value of the header key
+func (c headerCarrier) Get(key string) string {
+	for _, header := range *c.headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// Set sets the header key, replacing a header already set
+func (c headerCarrier) Set(key, value string) {
+	for i, header := range *c.headers {
+		if header.Key == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+// Keys returns the keys of the headers
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c.headers))
+	for _, header := range *c.headers {
+		keys = append(keys, header.Key)
+	}
+	return keys
+}
+
+// injectTraceContext adds the trace context and baggage of ctx to the
+// headers
+func injectTraceContext(ctx context.Context, headers *[]kafka.Header) {
+	traceContext.Inject(ctx, headerCarrier{headers: headers})
+}
+
+// extractTraceContext returns ctx continuing the trace the headers carry,
+// with their baggage
+func extractTraceContext(ctx context.Context, headers []kafka.Header) context.Context {
+	return traceContext.Extract(ctx, headerCarrier{headers: &headers})
+}
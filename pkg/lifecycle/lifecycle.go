@@ -0,0 +1,173 @@
+// Package lifecycle shuts services down gracefully: on SIGINT or SIGTERM
+// it stops background work and runs the shutdown hooks of the parts of a
+// service, such as its HTTP server, consumers, database and tracer, in
+// order, each within a timeout, so requests and messages in flight are
+// finished before what they use is closed.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// Hook stops a part of a service. It returns once the part stopped, or
+// once ctx is done, with the error of ctx.
+type Hook func(ctx context.Context) error
+
+// hook is a registered shutdown hook
+type hook struct {
+	name    string
+	timeout time.Duration
+	stop    Hook
+}
+
+// Manager runs the shutdown hooks of a service
+type Manager struct {
+	timeout time.Duration
+	logger  *logger.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu    sync.Mutex
+	hooks []hook
+}
+
+// New creates a manager that gives each shutdown hook timeout to return,
+// unless it was registered with a timeout of its own
+func New(timeout time.Duration, log *logger.Logger) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		timeout: timeout,
+		logger:  log,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Context returns a context that is cancelled when the service starts
+// shutting down, for background workers to stop with
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+// Register registers a shutdown hook. Hooks run in the reverse order of
+// registration, like deferred calls, so a part registered once what it
+// uses was set up stops before it.
+func (m *Manager) Register(name string, stop Hook) {
+	m.RegisterWithTimeout(name, 0, stop)
+}
+
+// RegisterWithTimeout registers a shutdown hook that gets timeout to
+// return instead of that of the manager
+func (m *Manager) RegisterWithTimeout(name string, timeout time.Duration, stop Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{name: name, timeout: timeout, stop: stop})
+}
+
+// Wait blocks until the service receives SIGINT or SIGTERM, then shuts it
+// down
+func (m *Manager) Wait() error {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	sig := <-quit
+	m.logger.Info("Shutting down", "signal", sig.String())
+	return m.Shutdown()
+}
+
+// Shutdown cancels the context of background workers and runs the
+// shutdown hooks. Hooks that fail or time out are logged and the next one
+// is run. It returns the errors of the hooks.
+func (m *Manager) Shutdown() error {
+	m.cancel()
+
+	m.mu.Lock()
+	hooks := m.hooks
+	m.hooks = nil
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := m.run(hooks[i]); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", hooks[i].name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// run runs a shutdown hook, returning when it did or when its time is up.
+// A hook ignoring its context is left running.
+func (m *Manager) run(h hook) error {
+	timeout := m.timeout
+	if h.timeout > 0 {
+		timeout = h.timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- h.stop(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		m.logger.Error("Failed to stop", "component", h.name, "error", err, "duration", time.Since(start))
+		return err
+	}
+	m.logger.Info("Stopped", "component", h.name, "duration", time.Since(start))
+	return nil
+}
+
+// Close returns a hook calling close, for parts stopped by closing them
+func Close(close func() error) Hook {
+	return func(context.Context) error {
+		return close()
+	}
+}
+
+// Graceful returns a hook calling stop, for parts whose stop waits for the
+// work in flight, and force once the time of the hook is up
+func Graceful(stop, force func()) Hook {
+	return func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			stop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			force()
+			return ctx.Err()
+		}
+	}
+}
+
+// Stop returns a hook calling stop, for parts whose stop can't fail
+func Stop(stop func()) Hook {
+	return func(context.Context) error {
+		stop()
+		return nil
+	}
+}
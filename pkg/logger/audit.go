@@ -0,0 +1,288 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// auditTailSize bounds the end of an audit file read to find its last
+// event, well over the size of one
+const auditTailSize = 64 * 1024
+
+// AuditLogger writes security-relevant events, such as logins and password
+// changes, as JSON lines to their own file or stream, apart from the
+// application's lines. Events are numbered and chained: each carries the
+// hash of the one before it, so lines removed, reordered or edited are
+// found by VerifyAuditLog.
+type AuditLogger struct {
+	serviceName string
+	key         []byte
+
+	mu       sync.Mutex
+	out      io.Writer
+	seq      uint64
+	prevHash string
+}
+
+// auditEvent is an event as written, without its hash, which is appended
+// to the line. Its fields are in the order written.
+type auditEvent struct {
+	Seq       uint64                 `json:"seq"`
+	Time      time.Time              `json:"time"`
+	Service   string                 `json:"service"`
+	Event     string                 `json:"event"`
+	RequestID string                 `json:"request_id,omitempty"`
+	TraceID   string                 `json:"trace_id,omitempty"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+	PrevHash  string                 `json:"prev_hash"`
+}
+
+// NewAuditLogger creates the audit logger of serviceName, or returns nil
+// when audit logging is off. Written to a file, the chain carries on from
+// the last event in it.
+func NewAuditLogger(cfg config.AuditLogConfig, serviceName string) (*AuditLogger, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	a := &AuditLogger{serviceName: serviceName}
+	if cfg.SigningKey != "" {
+		a.key = []byte(cfg.SigningKey)
+	}
+
+	switch cfg.Output {
+	case "stdout":
+		a.out = os.Stdout
+	case "stderr":
+		a.out = os.Stderr
+	default:
+		last, err := readLastLine(cfg.Filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit log: %w", err)
+		}
+		if last != nil {
+			event, _, err := parseAuditLine(last)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resume audit log %s: %w", cfg.Filename, err)
+			}
+			a.seq = event.Seq
+			_, a.prevHash, _ = splitAuditLine(last)
+		}
+		a.out = &lumberjack.Logger{
+			Filename:   cfg.Filename,
+			MaxSize:    cfg.MaxSize,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAge,
+		}
+	}
+
+	return a, nil
+}
+
+// Record writes event, e.g. user.login, with fields given as key value
+// pairs like the logger's. The request ID and trace ID of ctx are added.
+// A nil AuditLogger records nothing.
+func (a *AuditLogger) Record(ctx context.Context, event string, fields ...interface{}) error {
+	if a == nil {
+		return nil
+	}
+
+	e := auditEvent{
+		Time:      time.Now().UTC(),
+		Service:   a.serviceName,
+		Event:     event,
+		RequestID: RequestIDFromContext(ctx),
+		Fields:    auditFields(fields),
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		e.TraceID = spanContext.TraceID().String()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	e.Seq = a.seq + 1
+	e.PrevHash = a.prevHash
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit event: %w", err)
+	}
+	sum := auditHash(a.key, body)
+
+	line := make([]byte, 0, len(body)+len(sum)+12)
+	line = append(line, body[:len(body)-1]...)
+	line = append(line, `,"hash":"`...)
+	line = append(line, sum...)
+	line = append(line, "\"}\n"...)
+	if _, err := a.out.Write(line); err != nil {
+		return fmt.Errorf("failed to write audit event: %w", err)
+	}
+
+	a.seq = e.Seq
+	a.prevHash = sum
+	return nil
+}
+
+// Close closes the audit log file
+func (a *AuditLogger) Close() error {
+	if a == nil {
+		return nil
+	}
+	if c, ok := a.out.(io.Closer); ok && a.out != os.Stdout && a.out != os.Stderr {
+		return c.Close()
+	}
+	return nil
+}
+
+// VerifyAuditLog checks the events read from r are unaltered: each hashes
+// to the hash it carries, with key when the log is signed, follows on from
+// the event before it, and is numbered one after it. The first event may
+// carry on from a rotated file.
+func VerifyAuditLog(r io.Reader, key []byte) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, auditTailSize), auditTailSize)
+
+	var prev *auditEvent
+	var prevHash string
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		event, sum, err := parseAuditLine(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		body, _, _ := splitAuditLine(line)
+		if !hmac.Equal([]byte(auditHash(key, body)), []byte(sum)) {
+			return fmt.Errorf("line %d: hash mismatch, the event was altered", n)
+		}
+		if prev != nil {
+			if event.Seq != prev.Seq+1 {
+				return fmt.Errorf("line %d: sequence %d follows %d, events are missing or reordered", n, event.Seq, prev.Seq)
+			}
+			if event.PrevHash != prevHash {
+				return fmt.Errorf("line %d: previous hash mismatch, events are missing or reordered", n)
+			}
+		} else if event.Seq == 1 && event.PrevHash != "" {
+			return fmt.Errorf("line %d: first event carries a previous hash", n)
+		}
+		prev, prevHash = &event, sum
+	}
+	return scanner.Err()
+}
+
+// RequestIDFromContext returns the ID of the request ctx belongs to, set
+// by Middleware, or an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// auditHash returns the hash of the event body: an HMAC when the log is
+// signed, so the chain can't be recomputed without the key
+func auditHash(key, body []byte) string {
+	var h hash.Hash
+	if len(key) > 0 {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// parseAuditLine returns the event of an audit line, and its hash
+func parseAuditLine(line []byte) (auditEvent, string, error) {
+	var event auditEvent
+	if err := json.Unmarshal(line, &event); err != nil {
+		return event, "", fmt.Errorf("malformed audit event: %w", err)
+	}
+	_, sum, err := splitAuditLine(line)
+	return event, sum, err
+}
+
+// splitAuditLine splits an audit line into the body its hash is taken of
+// and the hash
+func splitAuditLine(line []byte) ([]byte, string, error) {
+	line = bytes.TrimSpace(line)
+	i := bytes.LastIndex(line, []byte(`,"hash":"`))
+	if i < 0 || !bytes.HasSuffix(line, []byte(`"}`)) {
+		return nil, "", errors.New("audit event has no hash")
+	}
+	body := append(line[:i:i], '}')
+	sum := string(line[i+len(`,"hash":"`) : len(line)-2])
+	return body, sum, nil
+}
+
+// auditFields returns key value pairs as the fields of an event. Strings
+// have emails and card numbers masked, as in application lines.
+func auditFields(pairs []interface{}) map[string]interface{} {
+	if len(pairs) == 0 {
+		return nil
+	}
+	fields := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		key := fmt.Sprint(pairs[i])
+		switch value := pairs[i+1].(type) {
+		case string:
+			fields[key] = redactString(value)
+		case error:
+			fields[key] = redactString(value.Error())
+		case fmt.Stringer:
+			fields[key] = value.String()
+		default:
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// readLastLine returns the last line of the file at path, or nil when it
+// doesn't exist or is empty
+func readLastLine(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset := info.Size() - auditTailSize
+	if offset < 0 {
+		offset = 0
+	}
+	tail := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(tail, offset); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	tail = bytes.TrimRight(tail, "\n")
+	if len(tail) == 0 {
+		return nil, nil
+	}
+	return tail[bytes.LastIndexByte(tail, '\n')+1:], nil
+}
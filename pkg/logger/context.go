@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// HeaderRequestID carries the ID of a request, and is passed on to the
+// services it is proxied to so their lines share it
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength bounds the request IDs taken from clients
+const maxRequestIDLength = 128
+
+// contextKey is the context key of the logger of a request
+type contextKey struct{}
+
+// requestIDKey is the context key of the ID of a request
+type requestIDKey struct{}
+
+// fallback is the logger FromContext returns for contexts without one: the
+// logger created last, which is the service's logger
+var fallback atomic.Pointer[Logger]
+
+// nop discards lines logged before any logger is created
+var nop = &Logger{SugaredLogger: zap.NewNop().Sugar()}
+
+// NewContext returns a copy of ctx carrying l
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger ctx carries, with the fields of the
+// request it was scoped to, or the service's logger. Lines logged within a
+// span carry its trace_id and span_id, correlating them with the trace.
+func FromContext(ctx context.Context) *Logger {
+	l := scoped(ctx)
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return l
+	}
+	return l.WithFields("trace_id", spanContext.TraceID().String(), "span_id", spanContext.SpanID().String())
+}
+
+// ContextWithFields returns a copy of ctx whose logger carries fields too,
+// e.g. the user of a request once authenticated
+func ContextWithFields(ctx context.Context, fields ...interface{}) context.Context {
+	return NewContext(ctx, scoped(ctx).WithFields(fields...))
+}
+
+// scoped returns the logger ctx carries, or the service's logger
+func scoped(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
+	}
+	if l := fallback.Load(); l != nil {
+		return l
+	}
+	return nop
+}
+
+// Middleware returns Gin middleware that scopes a logger to each request,
+// carrying its request ID and the baggage it came with, such as its tenant
+// and user, for handlers to take with FromContext. The
+// request ID is taken from the request ID header, or generated, and set
+// on the request, to be passed on, and on the response.
+func Middleware(l *Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(HeaderRequestID)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+			c.Request.Header.Set(HeaderRequestID, requestID)
+		}
+		c.Header(HeaderRequestID, requestID)
+
+		ctx := context.WithValue(c.Request.Context(), requestIDKey{}, requestID)
+		scoped := l.WithRequestID(requestID)
+		if fields := tracing.BaggageFields(ctx); len(fields) > 0 {
+			scoped = scoped.WithFields(fields...)
+		}
+		c.Request = c.Request.WithContext(NewContext(ctx, scoped))
+		c.Next()
+	}
+}
@@ -0,0 +1,407 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/retry"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// defaultExportRetryPolicy retries sending a batch for about half a
+// minute, long enough to ride out a collector restarting
+var defaultExportRetryPolicy = retry.Policy{
+	MaxAttempts: 6,
+	BackoffMin:  500 * time.Millisecond,
+	BackoffMax:  10 * time.Second,
+	Jitter:      0.2,
+}
+
+// logRecord is a log line waiting to be shipped
+type logRecord struct {
+	time    time.Time
+	level   zapcore.Level
+	message string
+	caller  string
+	fields  map[string]interface{}
+}
+
+// logBackend sends batches of log lines to a log store
+type logBackend interface {
+	push(ctx context.Context, records []logRecord) error
+}
+
+// newShipper returns the shipper of the log lines of serviceName, or nil
+// when shipping is off
+func newShipper(cfg config.LogExportConfig, serviceName string) (*shipper, error) {
+	resource := tracing.Resource(serviceName).Attributes()
+	client := &http.Client{Timeout: cfg.Timeout}
+	endpoint := strings.TrimRight(cfg.Endpoint, "/")
+
+	var backend logBackend
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "otlp":
+		backend = &otlpBackend{endpoint: endpoint, headers: cfg.Headers, resource: resource, httpClient: client}
+	case "loki":
+		backend = &lokiBackend{endpoint: endpoint, headers: cfg.Headers, resource: resource, httpClient: client}
+	default:
+		return nil, fmt.Errorf("log export provider %q is not supported", cfg.Provider)
+	}
+
+	s := &shipper{
+		backend: backend,
+		config:  cfg,
+		policy:  retry.FromConfig(cfg.Retry, defaultExportRetryPolicy),
+		full:    make(chan struct{}, 1),
+	}
+	go s.run()
+	return s, nil
+}
+
+// shipper buffers log lines and ships them in batches
+type shipper struct {
+	backend logBackend
+	config  config.LogExportConfig
+	policy  retry.Policy
+	// full wakes the shipper up once a batch is buffered
+	full chan struct{}
+
+	mu      sync.Mutex
+	buffer  []logRecord
+	dropped int
+
+	// sending serializes flushes
+	sending sync.Mutex
+}
+
+// enqueue buffers a line, dropping it when the buffer is full
+func (s *shipper) enqueue(record logRecord) {
+	s.mu.Lock()
+	if len(s.buffer) >= s.config.BufferSize {
+		s.dropped++
+		s.mu.Unlock()
+		return
+	}
+	s.buffer = append(s.buffer, record)
+	batched := len(s.buffer) >= s.config.BatchSize
+	s.mu.Unlock()
+
+	if batched {
+		select {
+		case s.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run flushes the buffer every flush interval, or sooner once a batch is
+// buffered
+func (s *shipper) run() {
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.full:
+		}
+		s.flush()
+	}
+}
+
+// flush ships the lines buffered. Batches that still fail after the
+// retries are given up on and reported on stderr, the logger being the
+// one failing.
+func (s *shipper) flush() error {
+	s.sending.Lock()
+	defer s.sending.Unlock()
+
+	s.mu.Lock()
+	records := s.buffer
+	dropped := s.dropped
+	s.buffer = nil
+	s.dropped = 0
+	s.mu.Unlock()
+
+	if dropped > 0 {
+		fmt.Fprintf(os.Stderr, "log export: dropped %d log lines, the buffer was full\n", dropped)
+	}
+
+	var lastErr error
+	for len(records) > 0 {
+		n := min(len(records), s.config.BatchSize)
+		if err := s.send(records[:n]); err != nil {
+			fmt.Fprintf(os.Stderr, "log export: failed to ship %d log lines: %v\n", n, err)
+			lastErr = err
+		}
+		records = records[n:]
+	}
+	return lastErr
+}
+
+// send sends a batch, retrying failures as the retry policy allows
+func (s *shipper) send(records []logRecord) error {
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+		err := s.backend.push(ctx, records)
+		cancel()
+		if err == nil || !s.policy.Retryable(attempt) {
+			return err
+		}
+		var statusErr *exportStatusError
+		if errors.As(err, &statusErr) && !statusErr.retryable() {
+			return err
+		}
+		time.Sleep(s.policy.Backoff(attempt))
+	}
+}
+
+// exportCore hands the lines it is given to a shipper
+type exportCore struct {
+	zapcore.LevelEnabler
+	fields  []zapcore.Field
+	shipper *shipper
+}
+
+func (c *exportCore) With(fields []zapcore.Field) zapcore.Core {
+	return &exportCore{
+		LevelEnabler: c.LevelEnabler,
+		fields:       append(c.fields[:len(c.fields):len(c.fields)], fields...),
+		shipper:      c.shipper,
+	}
+}
+
+func (c *exportCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *exportCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(encoder)
+	}
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+
+	record := logRecord{
+		time:    entry.Time,
+		level:   entry.Level,
+		message: entry.Message,
+		fields:  encoder.Fields,
+	}
+	if entry.Caller.Defined {
+		record.caller = entry.Caller.TrimmedPath()
+	}
+	c.shipper.enqueue(record)
+	return nil
+}
+
+// Sync ships the lines buffered, so none are lost when the service exits
+func (c *exportCore) Sync() error {
+	return c.shipper.flush()
+}
+
+// otlpBackend sends log lines to an OpenTelemetry collector over OTLP/HTTP
+// with JSON encoding
+type otlpBackend struct {
+	endpoint   string
+	headers    map[string]string
+	resource   []attribute.KeyValue
+	httpClient *http.Client
+}
+
+func (b *otlpBackend) push(ctx context.Context, records []logRecord) error {
+	resource := make([]map[string]interface{}, 0, len(b.resource))
+	for _, attr := range b.resource {
+		resource = append(resource, otlpAttribute(string(attr.Key), attr.Value.Emit()))
+	}
+
+	logRecords := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		attributes := make([]map[string]interface{}, 0, len(record.fields)+1)
+		for key, value := range record.fields {
+			attributes = append(attributes, otlpAttribute(key, value))
+		}
+		if record.caller != "" {
+			attributes = append(attributes, otlpAttribute("code.filepath", record.caller))
+		}
+
+		logRecord := map[string]interface{}{
+			"timeUnixNano":   strconv.FormatInt(record.time.UnixNano(), 10),
+			"severityNumber": otlpSeverity(record.level),
+			"severityText":   strings.ToUpper(record.level.String()),
+			"body":           map[string]interface{}{"stringValue": record.message},
+			"attributes":     attributes,
+		}
+		// Lines carrying the IDs of a span are linked to it
+		if traceID, ok := record.fields["trace_id"].(string); ok {
+			logRecord["traceId"] = traceID
+		}
+		if spanID, ok := record.fields["span_id"].(string); ok {
+			logRecord["spanId"] = spanID
+		}
+		logRecords = append(logRecords, logRecord)
+	}
+
+	body := map[string]interface{}{
+		"resourceLogs": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{"attributes": resource},
+			"scopeLogs": []interface{}{map[string]interface{}{
+				"scope":      map[string]interface{}{"name": "github.com/kaanevranportfolio/Commercium/pkg/logger"},
+				"logRecords": logRecords,
+			}},
+		}},
+	}
+	return postJSON(ctx, b.httpClient, b.endpoint+"/v1/logs", b.headers, body)
+}
+
+// otlpSeverity returns the OTLP severity number of a level
+func otlpSeverity(level zapcore.Level) int {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return 5
+	case level == zapcore.InfoLevel:
+		return 9
+	case level == zapcore.WarnLevel:
+		return 13
+	case level == zapcore.ErrorLevel:
+		return 17
+	default:
+		return 21
+	}
+}
+
+// otlpAttribute returns the OTLP attribute of a field
+func otlpAttribute(key string, value interface{}) map[string]interface{} {
+	var v map[string]interface{}
+	switch value := value.(type) {
+	case string:
+		v = map[string]interface{}{"stringValue": value}
+	case bool:
+		v = map[string]interface{}{"boolValue": value}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		v = map[string]interface{}{"intValue": fmt.Sprint(value)}
+	case float32, float64:
+		v = map[string]interface{}{"doubleValue": value}
+	default:
+		v = map[string]interface{}{"stringValue": fieldString(value)}
+	}
+	return map[string]interface{}{"key": key, "value": v}
+}
+
+// lokiBackend pushes log lines to Loki, as JSON lines labelled with the
+// resource of the service and their level
+type lokiBackend struct {
+	endpoint   string
+	headers    map[string]string
+	resource   []attribute.KeyValue
+	httpClient *http.Client
+}
+
+func (b *lokiBackend) push(ctx context.Context, records []logRecord) error {
+	streams := make(map[zapcore.Level][][2]string)
+	for _, record := range records {
+		line := make(map[string]interface{}, len(record.fields)+3)
+		for key, value := range record.fields {
+			line[key] = value
+		}
+		line["level"] = record.level.String()
+		line["msg"] = record.message
+		if record.caller != "" {
+			line["caller"] = record.caller
+		}
+		encoded, err := json.Marshal(line)
+		if err != nil {
+			encoded = []byte(fmt.Sprintf(`{"level":%q,"msg":%q}`, record.level.String(), record.message))
+		}
+
+		streams[record.level] = append(streams[record.level],
+			[2]string{strconv.FormatInt(record.time.UnixNano(), 10), string(encoded)})
+	}
+
+	body := struct {
+		Streams []interface{} `json:"streams"`
+	}{}
+	for level, values := range streams {
+		labels := map[string]string{"level": level.String()}
+		for _, attr := range b.resource {
+			// Label names can't hold dots
+			labels[strings.ReplaceAll(string(attr.Key), ".", "_")] = attr.Value.Emit()
+		}
+		body.Streams = append(body.Streams, map[string]interface{}{"stream": labels, "values": values})
+	}
+	return postJSON(ctx, b.httpClient, b.endpoint+"/loki/api/v1/push", b.headers, body)
+}
+
+// fieldString returns the text of a field value that isn't a scalar
+func fieldString(value interface{}) string {
+	if encoded, err := json.Marshal(value); err == nil {
+		return string(encoded)
+	}
+	return fmt.Sprint(value)
+}
+
+// postJSON posts the JSON encoding of body to url
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode log lines: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create log export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("log export request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &exportStatusError{status: resp.StatusCode, message: strings.TrimSpace(string(message))}
+	}
+	return nil
+}
+
+// exportStatusError is a batch refused by the log store
+type exportStatusError struct {
+	status  int
+	message string
+}
+
+func (e *exportStatusError) Error() string {
+	return fmt.Sprintf("log export returned status %d: %s", e.status, e.message)
+}
+
+// retryable reports whether the batch may be accepted when sent again: it
+// was refused for being sent too fast, or for a failure of the store
+func (e *exportStatusError) retryable() bool {
+	return e.status == http.StatusTooManyRequests || e.status >= 500
+}
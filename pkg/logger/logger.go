@@ -5,6 +5,7 @@ import (
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 
 	"github.com/kaanevranportfolio/Commercium/pkg/config"
 )
@@ -43,13 +44,23 @@ func New(cfg config.LoggerConfig, serviceName string) (*Logger, error) {
 	}
 	zapConfig.Level = zap.NewAtomicLevelAt(level)
 
-	// Configure output
+	// Configure output. Files are rotated by lumberjack rather than opened
+	// by zap, so zap is left without output paths and the core writing to
+	// the file is swapped in below.
+	var file zapcore.WriteSyncer
 	if cfg.Output == "stdout" || cfg.Output == "" {
 		zapConfig.OutputPaths = []string{"stdout"}
 		zapConfig.ErrorOutputPaths = []string{"stderr"}
 	} else if cfg.Filename != "" {
-		zapConfig.OutputPaths = []string{cfg.Filename}
-		zapConfig.ErrorOutputPaths = []string{cfg.Filename}
+		file = zapcore.AddSync(&lumberjack.Logger{
+			Filename:   cfg.Filename,
+			MaxSize:    cfg.MaxSize,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAge,
+			Compress:   cfg.Compress,
+		})
+		zapConfig.OutputPaths = nil
+		zapConfig.ErrorOutputPaths = []string{"stderr"}
 	}
 
 	// Configure encoding
@@ -72,21 +83,59 @@ func New(cfg config.LoggerConfig, serviceName string) (*Logger, error) {
 		}
 	}
 
-	// Add service name to initial fields
-	zapConfig.InitialFields = map[string]interface{}{
-		"service": serviceName,
-		"version": os.Getenv("APP_VERSION"),
+	// Add service name to initial fields. They are added once the core is
+	// wrapped below, as the core writing to a file replaces zap's.
+	initialFields := zap.Fields(
+		zap.String("service", serviceName),
+		zap.String("version", os.Getenv("APP_VERSION")),
+	)
+
+	// Lines are shipped to a log store too when export is configured
+	shipper, err := newShipper(cfg.Export, serviceName)
+	if err != nil {
+		return nil, err
 	}
 
-	logger, err := zapConfig.Build()
+	// Error lines are reported to Sentry too when error reporting is
+	// configured
+	reporter, err := newReporter(cfg.ErrorReporting, serviceName)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Logger{
+	// Repeated lines are sampled, then capped, as configured rather than
+	// by zap's production sampling, and personal data is masked
+	zapConfig.Sampling = nil
+	logger, err := zapConfig.Build(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		if file != nil {
+			core = zapcore.NewCore(newEncoder(zapConfig), file, zapConfig.Level)
+		}
+		if shipper != nil {
+			core = zapcore.NewTee(core, &exportCore{LevelEnabler: zapConfig.Level, shipper: shipper})
+		}
+		if reporter != nil {
+			core = zapcore.NewTee(core, &reportingCore{reporter: reporter})
+		}
+		return sample(rateLimit(redact(core, cfg.Redaction), cfg.RateLimit), cfg.Sampling)
+	}), initialFields)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Logger{
 		SugaredLogger: logger.Sugar(),
 		serviceName:   serviceName,
-	}, nil
+	}
+	fallback.Store(l)
+	return l, nil
+}
+
+// newEncoder returns the encoder zap builds for zapConfig
+func newEncoder(zapConfig zap.Config) zapcore.Encoder {
+	if zapConfig.Encoding == "console" {
+		return zapcore.NewConsoleEncoder(zapConfig.EncoderConfig)
+	}
+	return zapcore.NewJSONEncoder(zapConfig.EncoderConfig)
 }
 
 // WithFields creates a logger with additional fields
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Recovery returns Gin middleware, replacing gin.Recovery, that recovers
+// from panics in handlers and responds 500. Panics are logged at error
+// level with their stack trace and the request, so they are reported as
+// errors are, with the request ID and trace of the request: used ahead of
+// Middleware, it still logs with the logger Middleware scoped.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			l := FromContext(c.Request.Context()).Desugar().WithOptions(zap.AddStacktrace(zapcore.ErrorLevel))
+
+			// A client gone away isn't a failure of the handler, and can't
+			// be responded to
+			if brokenPipe(recovered) {
+				l.Warn("Client connection lost", zap.Any("error", recovered), zap.String(fieldRequestURL, c.Request.URL.Path))
+				c.Error(recovered.(error))
+				c.Abort()
+				return
+			}
+
+			l.Error("Panic recovered",
+				zap.Any("panic", recovered),
+				zap.String(fieldRequestMethod, c.Request.Method),
+				zap.String(fieldRequestURL, c.Request.URL.String()),
+				zap.String(fieldClientIP, c.ClientIP()),
+				zap.String(fieldUserAgent, c.Request.UserAgent()),
+			)
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}()
+		c.Next()
+	}
+}
+
+// brokenPipe reports whether recovered is a write to a connection the
+// client closed
+func brokenPipe(recovered interface{}) bool {
+	err, ok := recovered.(error)
+	if !ok {
+		return false
+	}
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	var syscallErr *os.SyscallError
+	if !errors.As(opErr, &syscallErr) {
+		return false
+	}
+	message := strings.ToLower(syscallErr.Error())
+	return strings.Contains(message, "broken pipe") || strings.Contains(message, "connection reset by peer")
+}
@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// redactedValue replaces the values of fields holding personal data
+const redactedValue = "[REDACTED]"
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// cardPattern matches 13 to 19 digits, optionally grouped by spaces or
+	// dashes; matches failing the Luhn check aren't card numbers
+	cardPattern = regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`)
+)
+
+// redact masks personal data before core writes it, see
+// config.LogRedactionConfig
+func redact(core zapcore.Core, cfg config.LogRedactionConfig) zapcore.Core {
+	fields := make(map[string]bool, len(cfg.Fields))
+	for _, field := range cfg.Fields {
+		fields[strings.ToLower(field)] = true
+	}
+	return &redactingCore{Core: core, fields: fields}
+}
+
+// redactingCore masks fields named as holding personal data, and emails and
+// card numbers in messages, strings and errors
+type redactingCore struct {
+	zapcore.Core
+	fields map[string]bool
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(c.redactFields(fields)), fields: c.fields}
+}
+
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = redactString(entry.Message)
+	return c.Core.Write(entry, c.redactFields(fields))
+}
+
+// redactFields returns fields with personal data masked, copied so the
+// caller's fields are left untouched
+func (c *redactingCore) redactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		redacted[i] = c.redactField(field)
+	}
+	return redacted
+}
+
+func (c *redactingCore) redactField(field zapcore.Field) zapcore.Field {
+	if c.isPersonal(field.Key) {
+		return zapcore.Field{Key: field.Key, Type: zapcore.StringType, String: redactedValue}
+	}
+
+	switch field.Type {
+	case zapcore.StringType:
+		field.String = redactString(field.String)
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok && err != nil {
+			if message := redactString(err.Error()); message != err.Error() {
+				field.Interface = errors.New(message)
+			}
+		}
+	}
+	return field
+}
+
+// isPersonal reports whether the field key holds personal data: a
+// configured name, on its own or as the end of the key, e.g. user_email
+func (c *redactingCore) isPersonal(key string) bool {
+	key = strings.ToLower(key)
+	if c.fields[key] {
+		return true
+	}
+	if i := strings.LastIndexAny(key, "_."); i >= 0 {
+		return c.fields[key[i+1:]]
+	}
+	return false
+}
+
+// redactString masks the emails and card numbers in s: emails keep the
+// first letter and the domain, card numbers their last four digits
+func redactString(s string) string {
+	if strings.Contains(s, "@") {
+		s = emailPattern.ReplaceAllStringFunc(s, func(email string) string {
+			at := strings.LastIndex(email, "@")
+			return email[:1] + "***" + email[at:]
+		})
+	}
+	return cardPattern.ReplaceAllStringFunc(s, func(match string) string {
+		digits := strings.NewReplacer(" ", "", "-", "").Replace(match)
+		if !luhnValid(digits) {
+			return match
+		}
+		return "****" + digits[len(digits)-4:]
+	})
+}
+
+// luhnValid reports whether digits pass the Luhn check card numbers carry
+func luhnValid(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,361 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// inAppPrefix marks the stack frames of this module's code, as opposed to
+// its dependencies'
+const inAppPrefix = "github.com/kaanevranportfolio/Commercium/"
+
+// reportClient names the client sending events to the error tracker
+const reportClient = "commercium-logger/1.0"
+
+// Fields of error lines given a place of their own in reported events,
+// rather than sent as extra data. The http.* fields are set by Recovery.
+const (
+	fieldRequestMethod = "http.method"
+	fieldRequestURL    = "http.url"
+	fieldClientIP      = "http.client_ip"
+	fieldUserAgent     = "http.user_agent"
+)
+
+// tagFields are the fields of error lines sent as tags, to search events by
+var tagFields = []string{"request_id", "trace_id", "user_id", "tenant_id"}
+
+// newReporter returns the reporter of the errors of serviceName, or nil
+// when error reporting is off
+func newReporter(cfg config.ErrorReportingConfig, serviceName string) (*reporter, error) {
+	if cfg.DSN == "" {
+		return nil, nil
+	}
+
+	dsn, err := url.Parse(cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse error reporting dsn: %w", err)
+	}
+	prefix, project := path.Split(strings.TrimRight(dsn.Path, "/"))
+	if dsn.User == nil || project == "" {
+		return nil, errors.New("error reporting dsn must carry a key and a project id")
+	}
+
+	hostname, _ := os.Hostname()
+	release := cfg.Release
+	if release == "" {
+		release = os.Getenv("APP_VERSION")
+	}
+
+	r := &reporter{
+		endpoint:    fmt.Sprintf("%s://%s%sapi/%s/envelope/", dsn.Scheme, dsn.Host, prefix, project),
+		auth:        fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", reportClient, dsn.User.Username()),
+		dsn:         cfg.DSN,
+		serviceName: serviceName,
+		serverName:  hostname,
+		environment: cfg.Environment,
+		release:     release,
+		timeout:     cfg.Timeout,
+		httpClient:  &http.Client{Timeout: cfg.Timeout},
+		events:      make(chan map[string]interface{}, cfg.BufferSize),
+	}
+	go r.run()
+	return r, nil
+}
+
+// reporter sends error lines and panics, with their stack traces, to
+// Sentry or a backend taking its envelopes, such as GlitchTip
+type reporter struct {
+	endpoint    string
+	auth        string
+	dsn         string
+	serviceName string
+	serverName  string
+	environment string
+	release     string
+	timeout     time.Duration
+	httpClient  *http.Client
+
+	events chan map[string]interface{}
+	// pending counts the events queued or being sent, for flush to wait on
+	pending sync.WaitGroup
+}
+
+// enqueue queues an event, dropping it when the queue is full so a burst
+// of errors can't hold up the service
+func (r *reporter) enqueue(event map[string]interface{}) {
+	r.pending.Add(1)
+	select {
+	case r.events <- event:
+	default:
+		r.pending.Done()
+		fmt.Fprintf(os.Stderr, "error reporting: dropped event %v, the queue was full\n", event["event_id"])
+	}
+}
+
+// run sends the events queued, one at a time. Failures are reported on
+// stderr, the logger being the one failing.
+func (r *reporter) run() {
+	for event := range r.events {
+		ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+		if err := r.send(ctx, event); err != nil {
+			fmt.Fprintf(os.Stderr, "error reporting: failed to send event %v: %v\n", event["event_id"], err)
+		}
+		cancel()
+		r.pending.Done()
+	}
+}
+
+// flush waits for the events queued to be sent, for up to the timeout of
+// one, so none are lost when the service exits
+func (r *reporter) flush() error {
+	done := make(chan struct{})
+	go func() {
+		r.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(r.timeout):
+		return errors.New("error reporting: timed out sending events")
+	}
+}
+
+// send posts event in an envelope
+func (r *reporter) send(ctx context.Context, event map[string]interface{}) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	header := map[string]interface{}{
+		"event_id": event["event_id"],
+		"dsn":      r.dsn,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	for _, item := range []interface{}{header, map[string]string{"type": "event"}, event} {
+		if err := encoder.Encode(item); err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create error reporting request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error reporting request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("error reporting returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// event returns the event reporting an error line
+func (r *reporter) event(entry zapcore.Entry, fields map[string]interface{}) map[string]interface{} {
+	event := map[string]interface{}{
+		"event_id":    strings.ReplaceAll(uuid.NewString(), "-", ""),
+		"timestamp":   entry.Time.UTC().Format(time.RFC3339Nano),
+		"platform":    "go",
+		"level":       reportLevel(entry.Level),
+		"logger":      r.serviceName,
+		"server_name": r.serverName,
+		"message":     entry.Message,
+	}
+	if r.environment != "" {
+		event["environment"] = r.environment
+	}
+	if r.release != "" {
+		event["release"] = r.release
+	}
+
+	tags := map[string]string{"service": r.serviceName}
+	for _, key := range tagFields {
+		if value, ok := fields[key]; ok {
+			tags[key] = fmt.Sprint(value)
+		}
+	}
+	event["tags"] = tags
+
+	if userID, ok := fields["user_id"]; ok {
+		event["user"] = map[string]interface{}{"id": fmt.Sprint(userID)}
+	}
+	if traceID, ok := fields["trace_id"]; ok {
+		event["contexts"] = map[string]interface{}{
+			"trace": map[string]interface{}{"trace_id": traceID, "span_id": fields["span_id"]},
+		}
+	}
+	if request := reportRequest(fields); request != nil {
+		event["request"] = request
+	}
+
+	// The error field, or the message when there is none, is the exception,
+	// raised where the stack trace of the line was taken
+	exception := map[string]interface{}{"type": entry.Message, "value": entry.Message}
+	if err, ok := fields["error"]; ok {
+		exception["type"] = "error"
+		exception["value"] = fmt.Sprint(err)
+	}
+	if value, ok := fields["panic"]; ok {
+		exception["type"] = "panic"
+		exception["value"] = fmt.Sprint(value)
+		exception["mechanism"] = map[string]interface{}{"type": "gin.recovery", "handled": false}
+	}
+	if frames := stackFrames(entry.Stack); len(frames) > 0 {
+		exception["stacktrace"] = map[string]interface{}{"frames": frames}
+	}
+	event["exception"] = map[string]interface{}{"values": []interface{}{exception}}
+
+	extra := make(map[string]interface{})
+	for key, value := range fields {
+		switch key {
+		case "error", "panic", "trace_id", "span_id", fieldRequestMethod, fieldRequestURL, fieldClientIP, fieldUserAgent:
+		default:
+			extra[key] = value
+		}
+	}
+	if entry.Caller.Defined {
+		extra["caller"] = entry.Caller.TrimmedPath()
+	}
+	event["extra"] = extra
+
+	return event
+}
+
+// reportRequest returns the request an error line was logged handling, from
+// the http.* fields Recovery sets, or nil
+func reportRequest(fields map[string]interface{}) map[string]interface{} {
+	method, ok := fields[fieldRequestMethod]
+	if !ok {
+		return nil
+	}
+	request := map[string]interface{}{
+		"method": method,
+		"url":    fields[fieldRequestURL],
+	}
+	if userAgent, ok := fields[fieldUserAgent]; ok {
+		request["headers"] = map[string]interface{}{"User-Agent": userAgent}
+	}
+	if clientIP, ok := fields[fieldClientIP]; ok {
+		request["env"] = map[string]interface{}{"REMOTE_ADDR": clientIP}
+	}
+	return request
+}
+
+// reportLevel returns the level of an event reporting a line of level
+func reportLevel(level zapcore.Level) string {
+	switch {
+	case level >= zapcore.DPanicLevel:
+		return "fatal"
+	case level >= zapcore.ErrorLevel:
+		return "error"
+	default:
+		return "warning"
+	}
+}
+
+// stackFrames parses a stack trace taken by zap, a function then its file
+// and line for each frame, innermost first, into the frames of an event,
+// outermost first
+func stackFrames(stack string) []map[string]interface{} {
+	lines := strings.Split(strings.TrimSpace(stack), "\n")
+	var frames []map[string]interface{}
+	for i := 0; i+1 < len(lines); i += 2 {
+		function := strings.TrimSpace(lines[i])
+		location := strings.TrimSpace(lines[i+1])
+		file, line := location, 0
+		if j := strings.LastIndexByte(location, ':'); j >= 0 {
+			file = location[:j]
+			line, _ = strconv.Atoi(location[j+1:])
+		}
+		frame := map[string]interface{}{
+			"function": function,
+			"abs_path": file,
+			"filename": path.Base(file),
+			"lineno":   line,
+			"in_app":   strings.HasPrefix(function, inAppPrefix),
+		}
+		if j := strings.LastIndexByte(function, '/'); j >= 0 {
+			if k := strings.IndexByte(function[j:], '.'); k >= 0 {
+				frame["module"] = function[:j+k]
+				frame["function"] = function[j+k+1:]
+			}
+		}
+		frames = append([]map[string]interface{}{frame}, frames...)
+	}
+	return frames
+}
+
+// reportingCore hands the error lines it is given to a reporter
+type reportingCore struct {
+	fields   []zapcore.Field
+	reporter *reporter
+}
+
+func (c *reportingCore) Enabled(level zapcore.Level) bool {
+	return level >= zapcore.ErrorLevel
+}
+
+func (c *reportingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &reportingCore{
+		fields:   append(c.fields[:len(c.fields):len(c.fields)], fields...),
+		reporter: c.reporter,
+	}
+}
+
+func (c *reportingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *reportingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	// The cores wrapping a tee write every line to each of its cores
+	if !c.Enabled(entry.Level) {
+		return nil
+	}
+
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(encoder)
+	}
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	c.reporter.enqueue(c.reporter.event(entry, encoder.Fields))
+
+	// Panics and fatal lines end the service, so they are sent right away
+	if entry.Level > zapcore.ErrorLevel {
+		return c.reporter.flush()
+	}
+	return nil
+}
+
+// Sync waits for the events queued to be sent
+func (c *reportingCore) Sync() error {
+	return c.reporter.flush()
+}
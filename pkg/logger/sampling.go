@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// levels are the levels lines are logged at
+var levels = []zapcore.Level{
+	zapcore.DebugLevel,
+	zapcore.InfoLevel,
+	zapcore.WarnLevel,
+	zapcore.ErrorLevel,
+	zapcore.DPanicLevel,
+	zapcore.PanicLevel,
+	zapcore.FatalLevel,
+}
+
+// sample samples the lines of the levels cfg has a Thereafter for, each
+// level on its own, and passes on those of other levels unsampled
+func sample(core zapcore.Core, cfg config.LogSamplingConfig) zapcore.Core {
+	if cfg.Tick <= 0 || len(cfg.Thereafter) == 0 {
+		return core
+	}
+
+	var cores []zapcore.Core
+	unsampled := make(map[zapcore.Level]bool)
+	for _, level := range levels {
+		thereafter := cfg.Thereafter[level.String()]
+		if thereafter <= 0 {
+			unsampled[level] = true
+			continue
+		}
+		only := &levelCore{Core: core, levels: map[zapcore.Level]bool{level: true}}
+		cores = append(cores, zapcore.NewSamplerWithOptions(only, cfg.Tick, cfg.Initial, thereafter))
+	}
+	cores = append(cores, &levelCore{Core: core, levels: unsampled})
+
+	return zapcore.NewTee(cores...)
+}
+
+// levelCore passes on the lines of some levels to its core only, so the
+// lines of each level can be sampled differently
+type levelCore struct {
+	zapcore.Core
+	levels map[zapcore.Level]bool
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.levels[level] && c.Core.Enabled(level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+func (c *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levels[entry.Level] {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
+
+// rateLimit caps the lines below error level core logs each second, see
+// config.LogRateLimitConfig
+func rateLimit(core zapcore.Core, cfg config.LogRateLimitConfig) zapcore.Core {
+	if cfg.PerSecond <= 0 {
+		return core
+	}
+	return &rateLimitedCore{Core: core, limiter: &lineLimiter{perSecond: cfg.PerSecond, core: core}}
+}
+
+// rateLimitedCore drops the lines its limiter doesn't allow. Errors are
+// never dropped.
+type rateLimitedCore struct {
+	zapcore.Core
+	limiter *lineLimiter
+}
+
+func (c *rateLimitedCore) With(fields []zapcore.Field) zapcore.Core {
+	return &rateLimitedCore{Core: c.Core.With(fields), limiter: c.limiter}
+}
+
+func (c *rateLimitedCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) {
+		return checked
+	}
+	if entry.Level < zapcore.ErrorLevel && !c.limiter.allow(entry.Time) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
+
+// lineLimiter counts the lines logged each second, shared by a core and
+// the cores derived from it
+type lineLimiter struct {
+	perSecond int
+	// core reports the lines dropped
+	core zapcore.Core
+
+	mu      sync.Mutex
+	second  time.Time
+	count   int
+	dropped int
+}
+
+// allow reports whether a line logged at now is within the limit. The
+// lines dropped within a second are reported by the first line allowed
+// after it.
+func (l *lineLimiter) allow(now time.Time) bool {
+	second := now.Truncate(time.Second)
+
+	l.mu.Lock()
+	var dropped int
+	if !second.Equal(l.second) {
+		dropped = l.dropped
+		l.second = second
+		l.count = 0
+		l.dropped = 0
+	}
+	l.count++
+	allowed := l.count <= l.perSecond
+	if !allowed {
+		l.dropped++
+	}
+	l.mu.Unlock()
+
+	if dropped > 0 {
+		l.core.Write(zapcore.Entry{
+			Level:   zapcore.WarnLevel,
+			Time:    now,
+			Message: "Log lines dropped over rate limit",
+		}, []zapcore.Field{zap.Int("dropped", dropped), zap.Int("per_second", l.perSecond)})
+	}
+	return allowed
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import "sync"
+
+// overflowLabelValue replaces the values of a label beyond its limit
+const overflowLabelValue = "overflow"
+
+// cardinalityGuard limits the distinct values each label of each metric
+// takes, so a label fed unbounded values, such as product IDs or raw
+// paths, can't add series without end. Once a label has taken as many
+// values as the limit, values it hasn't taken yet are recorded as overflow,
+// while those it has keep being recorded as they are.
+type cardinalityGuard struct {
+	limit int
+	// overflowed is called when a value of label of metric is replaced
+	overflowed func(metric, label string)
+
+	mu     sync.RWMutex
+	values map[string]map[string]struct{}
+}
+
+// newCardinalityGuard creates the guard of registry allowing limit values
+// per label, counting the values it replaces in registry. It returns nil,
+// leaving labels unlimited, when limit isn't positive.
+func newCardinalityGuard(limit int, registry Registry) *cardinalityGuard {
+	if limit <= 0 {
+		return nil
+	}
+
+	overflows := registry.NewCounter("metric_label_overflows", "Label values recorded as overflow once their label reached its limit, by metric and label", "metric", "label")
+	return &cardinalityGuard{
+		limit:      limit,
+		overflowed: func(metric, label string) { overflows.Inc(metric, label) },
+		values:     make(map[string]map[string]struct{}),
+	}
+}
+
+// labels returns the labels of metric, given as name value pairs, with
+// the values beyond the limit of their label replaced with overflow. A
+// nil guard returns them as they are.
+func (g *cardinalityGuard) labels(metric string, pairs ...string) []string {
+	if g == nil {
+		return pairs
+	}
+
+	limited := make([]string, len(pairs))
+	copy(limited, pairs)
+	for i := 0; i+1 < len(limited); i += 2 {
+		limited[i+1] = g.value(metric, limited[i], limited[i+1])
+	}
+	return limited
+}
+
+// value returns value, the value of label of metric, or overflow when it
+// is beyond the limit of the label. A nil guard returns it as it is.
+func (g *cardinalityGuard) value(metric, label, value string) string {
+	if g == nil || g.allow(metric+"/"+label, value) {
+		return value
+	}
+	if g.overflowed != nil {
+		g.overflowed(metric, label)
+	}
+	return overflowLabelValue
+}
+
+// allow returns whether value may be recorded for the label of key,
+// taking it when the label is below its limit
+func (g *cardinalityGuard) allow(key, value string) bool {
+	g.mu.RLock()
+	_, seen := g.values[key][value]
+	g.mu.RUnlock()
+	if seen {
+		return true
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	values, ok := g.values[key]
+	if !ok {
+		values = make(map[string]struct{})
+		g.values[key] = values
+	}
+	if _, seen := values[value]; seen {
+		return true
+	}
+	if len(values) >= g.limit {
+		return false
+	}
+	values[value] = struct{}{}
+	return true
+}
+
+// labelValues returns the values of labels given as name value pairs
+func labelValues(pairs []string) []string {
+	values := make([]string, 0, len(pairs)/2)
+	for i := 1; i < len(pairs); i += 2 {
+		values = append(values, pairs[i])
+	}
+	return values
+}
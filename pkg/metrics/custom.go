@@ -0,0 +1,253 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Counter is a metric a service registers for its own domain, such as
+// carts created, that only goes up. Label values are given in the order of
+// the labels it was registered with.
+type Counter interface {
+	Inc(labelValues ...string)
+	Add(value float64, labelValues ...string)
+}
+
+// Histogram is a metric a service registers for its own domain, such as
+// search latency, observing a distribution of values. Observations in a
+// sampled trace carry it as their exemplar.
+type Histogram interface {
+	Observe(ctx context.Context, value float64, labelValues ...string)
+}
+
+// Gauge is a metric a service registers for its own domain, such as carts
+// open, set to the current value
+type Gauge interface {
+	Set(value float64, labelValues ...string)
+}
+
+// NewCounter registers a counter named name, prefixed with the namespace
+// and subsystem as the registry's own metrics are, with the _total suffix
+// Prometheus expects. Registering a counter again returns the one
+// registered. It panics, as prometheus.MustRegister does, when name or
+// labels are invalid or name is taken by a metric of another kind.
+func (r *prometheusRegistry) NewCounter(name, help string, labels ...string) Counter {
+	if !r.config.Enabled {
+		return noopMetric{}
+	}
+	counter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: r.config.Namespace,
+			Subsystem: r.config.Subsystem,
+			Name:      name + "_total",
+			Help:      help,
+		},
+		labels,
+	)
+	return prometheusCounter{register(r.registry, counter), r.customLabels(name+"_total", labels)}
+}
+
+// NewHistogram registers a histogram named name, with the default
+// buckets, which suit latencies in seconds. It is registered as NewCounter
+// registers counters.
+func (r *prometheusRegistry) NewHistogram(name, help string, labels ...string) Histogram {
+	if !r.config.Enabled {
+		return noopMetric{}
+	}
+	histogram := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: r.config.Namespace,
+			Subsystem: r.config.Subsystem,
+			Name:      name,
+			Help:      help,
+			Buckets:   prometheus.DefBuckets,
+		},
+		labels,
+	)
+	return prometheusHistogram{register(r.registry, histogram), r.customLabels(name, labels)}
+}
+
+// NewGauge registers a gauge named name. It is registered as NewCounter
+// registers counters.
+func (r *prometheusRegistry) NewGauge(name, help string, labels ...string) Gauge {
+	if !r.config.Enabled {
+		return noopMetric{}
+	}
+	gauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: r.config.Namespace,
+			Subsystem: r.config.Subsystem,
+			Name:      name,
+			Help:      help,
+		},
+		labels,
+	)
+	return prometheusGauge{register(r.registry, gauge), r.customLabels(name, labels)}
+}
+
+// register registers collector, or returns the collector of the same kind
+// registered before it under its name, so services can register their
+// metrics wherever they use them
+func register[C prometheus.Collector](registry *prometheus.Registry, collector C) C {
+	err := registry.Register(collector)
+	if err == nil {
+		return collector
+	}
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		if existing, ok := registered.ExistingCollector.(C); ok {
+			return existing
+		}
+	}
+	panic(fmt.Errorf("failed to register metric: %w", err))
+}
+
+// customLabels returns the labels of the registered metric name
+func (r *prometheusRegistry) customLabels(name string, labels []string) customLabels {
+	return customLabels{metric: name, labels: labels, guard: r.guard}
+}
+
+// customLabels are the labels of a metric a service registered, whose
+// values are limited as those of the registry's own metrics are
+type customLabels struct {
+	metric string
+	labels []string
+	guard  *cardinalityGuard
+}
+
+// values returns label values given in the order of the labels, with
+// those beyond the limit of their label replaced with overflow. Values not
+// matching the labels are returned as they are, to fail when recorded.
+func (l customLabels) values(labelValues []string) []string {
+	if l.guard == nil || len(labelValues) != len(l.labels) {
+		return labelValues
+	}
+	limited := make([]string, len(labelValues))
+	for i, value := range labelValues {
+		limited[i] = l.guard.value(l.metric, l.labels[i], value)
+	}
+	return limited
+}
+
+type prometheusCounter struct {
+	vec *prometheus.CounterVec
+	customLabels
+}
+
+func (c prometheusCounter) Inc(labelValues ...string) {
+	c.vec.WithLabelValues(c.values(labelValues)...).Inc()
+}
+
+func (c prometheusCounter) Add(value float64, labelValues ...string) {
+	c.vec.WithLabelValues(c.values(labelValues)...).Add(value)
+}
+
+type prometheusHistogram struct {
+	vec *prometheus.HistogramVec
+	customLabels
+}
+
+func (h prometheusHistogram) Observe(ctx context.Context, value float64, labelValues ...string) {
+	observe(ctx, h.vec.WithLabelValues(h.values(labelValues)...), value)
+}
+
+type prometheusGauge struct {
+	vec *prometheus.GaugeVec
+	customLabels
+}
+
+func (g prometheusGauge) Set(value float64, labelValues ...string) {
+	g.vec.WithLabelValues(g.values(labelValues)...).Set(value)
+}
+
+// NewCounter creates a counter instrument named name, prefixed as the
+// registry's own instruments are. It panics when name is invalid.
+func (r *otelRegistry) NewCounter(name, help string, labels ...string) Counter {
+	m := &instruments{meter: r.meter, config: r.config}
+	counter := otelCounter{m.float64Counter(name, help), customLabels{metric: name, labels: labels, guard: r.guard}}
+	m.must()
+	return counter
+}
+
+// NewHistogram creates a histogram instrument named name. It panics when
+// name is invalid.
+func (r *otelRegistry) NewHistogram(name, help string, labels ...string) Histogram {
+	m := &instruments{meter: r.meter, config: r.config}
+	histogram := otelHistogram{m.float64Histogram(name, help, ""), customLabels{metric: name, labels: labels, guard: r.guard}}
+	m.must()
+	return histogram
+}
+
+// NewGauge creates a gauge instrument named name. It panics when name is
+// invalid.
+func (r *otelRegistry) NewGauge(name, help string, labels ...string) Gauge {
+	m := &instruments{meter: r.meter, config: r.config}
+	gauge := otelGauge{m.float64Gauge(name, help), customLabels{metric: name, labels: labels, guard: r.guard}}
+	m.must()
+	return gauge
+}
+
+// must panics with the error of creating an instrument, if any
+func (m *instruments) must() {
+	if m.err != nil {
+		panic(m.err)
+	}
+}
+
+type otelCounter struct {
+	counter metric.Float64Counter
+	customLabels
+}
+
+func (c otelCounter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c otelCounter) Add(value float64, labelValues ...string) {
+	c.counter.Add(context.Background(), value, c.attrs(labelValues))
+}
+
+type otelHistogram struct {
+	histogram metric.Float64Histogram
+	customLabels
+}
+
+func (h otelHistogram) Observe(ctx context.Context, value float64, labelValues ...string) {
+	h.histogram.Record(ctx, value, h.attrs(labelValues))
+}
+
+type otelGauge struct {
+	gauge metric.Float64Gauge
+	customLabels
+}
+
+func (g otelGauge) Set(value float64, labelValues ...string) {
+	g.gauge.Record(context.Background(), value, g.attrs(labelValues))
+}
+
+// attrs returns the attributes of label values given in the order of the
+// labels, limited as values returns them. It panics when their numbers
+// differ, as WithLabelValues does.
+func (l customLabels) attrs(labelValues []string) metric.MeasurementOption {
+	if len(l.labels) != len(labelValues) {
+		panic(fmt.Sprintf("metrics: %d label values given for labels %v", len(labelValues), l.labels))
+	}
+	kvs := make([]attribute.KeyValue, len(l.labels))
+	for i, value := range l.values(labelValues) {
+		kvs[i] = attribute.String(l.labels[i], value)
+	}
+	return metric.WithAttributes(kvs...)
+}
+
+// noopMetric is the metric registered when metrics are disabled
+type noopMetric struct{}
+
+func (noopMetric) Inc(...string)                               {}
+func (noopMetric) Add(float64, ...string)                      {}
+func (noopMetric) Observe(context.Context, float64, ...string) {}
+func (noopMetric) Set(float64, ...string)                      {}
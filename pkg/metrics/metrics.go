@@ -1,18 +1,78 @@
 package metrics
 
 import (
+	"context"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
 	"github.com/kaanevranportfolio/Commercium/pkg/config"
 )
 
-// Registry holds all metrics collectors
-type Registry struct {
+// unmatchedEndpoint is the endpoint label of requests matching no route, so
+// scanners probing random paths can't add a series each
+const unmatchedEndpoint = "unmatched"
+
+// Registry records the metrics of a service, scraped by Prometheus or
+// pushed to an OpenTelemetry collector as MetricsConfig.Provider selects
+type Registry interface {
+	// Handler returns the HTTP handler for metrics endpoint, which finds
+	// nothing when metrics are pushed
+	Handler() http.Handler
+	// HTTPMiddleware returns Gin middleware for HTTP metrics collection,
+	// measuring requests against the service level objectives too
+	HTTPMiddleware(serviceName string) gin.HandlerFunc
+	// Shutdown stops collecting system metrics, and pushes the metrics not
+	// pushed yet when they are pushed
+	Shutdown(ctx context.Context) error
+	// SLOHandler returns the HTTP handler of the SLO status endpoint, which
+	// finds nothing when SLOs are off
+	SLOHandler() http.Handler
+
+	// Metrics registered by services for their own domain
+	NewCounter(name, help string, labels ...string) Counter
+	NewHistogram(name, help string, labels ...string) Histogram
+	NewGauge(name, help string, labels ...string) Gauge
+
+	// Business metrics
+	IncActiveUsers()
+	DecActiveUsers()
+	IncOrdersTotal(status, serviceName string)
+	IncPaymentsTotal(status, method, serviceName string)
+	SetInventoryLevel(productID, warehouse, serviceName string, level float64)
+	SetReservations(outcome, serviceName string, count float64)
+	SetReservationConversionRate(serviceName string, ratio float64)
+
+	// Messaging metrics
+	AddKafkaMessages(topic, outcome, serviceName string, count int)
+	ObserveKafkaPublishDuration(topic, serviceName string, seconds float64)
+
+	// System metrics
+	SetGoRoutines(count float64)
+	SetMemoryUsage(bytes float64)
+	SetCPUUsage(percent float64)
+	SetDBConnections(database, state, serviceName string, count float64)
+	ObserveDBQueryDuration(ctx context.Context, operation, outcome, serviceName string, seconds float64)
+	IncCacheRequest(cache, result, serviceName string)
+}
+
+// NewRegistry creates a new metrics registry, recording nothing when
+// metrics are disabled
+func NewRegistry(cfg config.MetricsConfig, serviceName string) (Registry, error) {
+	if cfg.Enabled && cfg.Provider == "otlp" {
+		return newOTelRegistry(cfg, serviceName)
+	}
+	return newPrometheusRegistry(cfg, serviceName)
+}
+
+// prometheusRegistry holds the Prometheus collectors of the metrics
+type prometheusRegistry struct {
 	registry *prometheus.Registry
 	config   config.MetricsConfig
 
@@ -21,24 +81,40 @@ type Registry struct {
 	httpRequestDuration *prometheus.HistogramVec
 	httpRequestSize     *prometheus.HistogramVec
 	httpResponseSize    *prometheus.HistogramVec
+	httpInFlight        *prometheus.GaugeVec
 
 	// Business metrics
 	activeUsers     prometheus.Gauge
 	totalOrders     *prometheus.CounterVec
 	paymentStatus   *prometheus.CounterVec
 	inventoryLevels *prometheus.GaugeVec
+	reservations    *prometheus.GaugeVec
+	conversionRate  *prometheus.GaugeVec
+
+	// Messaging metrics
+	kafkaMessages        *prometheus.CounterVec
+	kafkaPublishDuration *prometheus.HistogramVec
 
 	// System metrics
-	goRoutines   prometheus.Gauge
-	memoryUsage  prometheus.Gauge
-	cpuUsage     prometheus.Gauge
+	goRoutines    prometheus.Gauge
+	memoryUsage   prometheus.Gauge
+	cpuUsage      prometheus.Gauge
 	dbConnections *prometheus.GaugeVec
+	dbQueries     *prometheus.HistogramVec
+	cacheRequests *prometheus.CounterVec
+
+	// runtime sets the system metrics
+	runtime *runtimeCollector
+	// slo measures requests against the service level objectives
+	slo *sloTracker
+	// guard limits the values of labels
+	guard *cardinalityGuard
 }
 
-// NewRegistry creates a new metrics registry
-func NewRegistry(cfg config.MetricsConfig, serviceName string) (*Registry, error) {
+// newPrometheusRegistry creates the registry Prometheus scrapes
+func newPrometheusRegistry(cfg config.MetricsConfig, serviceName string) (*prometheusRegistry, error) {
 	if !cfg.Enabled {
-		return &Registry{config: cfg}, nil
+		return &prometheusRegistry{config: cfg}, nil
 	}
 
 	registry := prometheus.NewRegistry()
@@ -87,6 +163,16 @@ func NewRegistry(cfg config.MetricsConfig, serviceName string) (*Registry, error
 		[]string{"method", "endpoint", "service"},
 	)
 
+	httpInFlight := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: cfg.Namespace,
+			Subsystem: cfg.Subsystem,
+			Name:      "http_requests_in_flight",
+			Help:      "Number of HTTP requests being served",
+		},
+		[]string{"service"},
+	)
+
 	// Business metrics
 	activeUsers := prometheus.NewGauge(
 		prometheus.GaugeOpts{
@@ -127,6 +213,48 @@ func NewRegistry(cfg config.MetricsConfig, serviceName string) (*Registry, error
 		[]string{"product_id", "warehouse", "service"},
 	)
 
+	reservations := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: cfg.Namespace,
+			Subsystem: cfg.Subsystem,
+			Name:      "stock_reservations",
+			Help:      "Stock reservations resolved recently by outcome",
+		},
+		[]string{"outcome", "service"},
+	)
+
+	conversionRate := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: cfg.Namespace,
+			Subsystem: cfg.Subsystem,
+			Name:      "stock_reservation_conversion_ratio",
+			Help:      "Share of recently resolved stock reservations that became an order",
+		},
+		[]string{"service"},
+	)
+
+	// Messaging metrics
+	kafkaMessages := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: cfg.Namespace,
+			Subsystem: cfg.Subsystem,
+			Name:      "kafka_messages_published_total",
+			Help:      "Total number of messages published to Kafka by outcome",
+		},
+		[]string{"topic", "outcome", "service"},
+	)
+
+	kafkaPublishDuration := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: cfg.Namespace,
+			Subsystem: cfg.Subsystem,
+			Name:      "kafka_publish_duration_seconds",
+			Help:      "Time to deliver messages to Kafka, including batching and retries",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"topic", "service"},
+	)
+
 	// System metrics
 	goRoutines := prometheus.NewGauge(
 		prometheus.GaugeOpts{
@@ -165,20 +293,48 @@ func NewRegistry(cfg config.MetricsConfig, serviceName string) (*Registry, error
 		[]string{"database", "state", "service"},
 	)
 
+	dbQueries := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: cfg.Namespace,
+			Subsystem: cfg.Subsystem,
+			Name:      "database_query_duration_seconds",
+			Help:      "Database query duration in seconds by operation and outcome",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		},
+		[]string{"operation", "outcome", "service"},
+	)
+
+	cacheRequests := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: cfg.Namespace,
+			Subsystem: cfg.Subsystem,
+			Name:      "cache_requests_total",
+			Help:      "Cache reads by cache and result (local_hit, hit, miss)",
+		},
+		[]string{"cache", "result", "service"},
+	)
+
 	// Register all metrics
 	collectors := []prometheus.Collector{
 		httpRequestsTotal,
 		httpRequestDuration,
 		httpRequestSize,
 		httpResponseSize,
+		httpInFlight,
 		activeUsers,
 		totalOrders,
 		paymentStatus,
 		inventoryLevels,
+		reservations,
+		conversionRate,
+		kafkaMessages,
+		kafkaPublishDuration,
 		goRoutines,
 		memoryUsage,
 		cpuUsage,
 		dbConnections,
+		dbQueries,
+		cacheRequests,
 	}
 
 	for _, collector := range collectors {
@@ -190,34 +346,48 @@ func NewRegistry(cfg config.MetricsConfig, serviceName string) (*Registry, error
 	// Add Go runtime metrics
 	registry.MustRegister(prometheus.NewGoCollector())
 
-	return &Registry{
-		registry:            registry,
-		config:              cfg,
-		httpRequestsTotal:   httpRequestsTotal,
-		httpRequestDuration: httpRequestDuration,
-		httpRequestSize:     httpRequestSize,
-		httpResponseSize:    httpResponseSize,
-		activeUsers:         activeUsers,
-		totalOrders:         totalOrders,
-		paymentStatus:       paymentStatus,
-		inventoryLevels:     inventoryLevels,
-		goRoutines:          goRoutines,
-		memoryUsage:         memoryUsage,
-		cpuUsage:            cpuUsage,
-		dbConnections:       dbConnections,
-	}, nil
+	r := &prometheusRegistry{
+		registry:             registry,
+		config:               cfg,
+		httpRequestsTotal:    httpRequestsTotal,
+		httpRequestDuration:  httpRequestDuration,
+		httpRequestSize:      httpRequestSize,
+		httpResponseSize:     httpResponseSize,
+		httpInFlight:         httpInFlight,
+		activeUsers:          activeUsers,
+		totalOrders:          totalOrders,
+		paymentStatus:        paymentStatus,
+		inventoryLevels:      inventoryLevels,
+		reservations:         reservations,
+		conversionRate:       conversionRate,
+		kafkaMessages:        kafkaMessages,
+		kafkaPublishDuration: kafkaPublishDuration,
+		goRoutines:           goRoutines,
+		memoryUsage:          memoryUsage,
+		cpuUsage:             cpuUsage,
+		dbConnections:        dbConnections,
+		dbQueries:            dbQueries,
+		cacheRequests:        cacheRequests,
+	}
+	r.guard = newCardinalityGuard(cfg.MaxLabelValues, r)
+	r.runtime = startRuntimeCollector(r, cfg.RuntimeInterval)
+	r.slo = newSLOTracker(cfg.SLO, r)
+	return r, nil
 }
 
-// Handler returns the HTTP handler for metrics endpoint
-func (r *Registry) Handler() http.Handler {
+// Handler returns the HTTP handler for metrics endpoint. Scrapers asking
+// for the OpenMetrics format get the exemplars of latency histograms too.
+func (r *prometheusRegistry) Handler() http.Handler {
 	if !r.config.Enabled {
 		return http.NotFoundHandler()
 	}
-	return promhttp.HandlerFor(r.registry, promhttp.HandlerOpts{})
+	return promhttp.HandlerFor(r.registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
 }
 
-// HTTPMiddleware returns Gin middleware for HTTP metrics collection
-func (r *Registry) HTTPMiddleware(serviceName string) gin.HandlerFunc {
+// HTTPMiddleware returns Gin middleware for HTTP metrics collection.
+// Requests are labelled with the route they matched rather than their
+// path, and requests matching no route share the unmatched label.
+func (r *prometheusRegistry) HTTPMiddleware(serviceName string) gin.HandlerFunc {
 	if !r.config.Enabled {
 		return func(c *gin.Context) {
 			c.Next()
@@ -226,95 +396,204 @@ func (r *Registry) HTTPMiddleware(serviceName string) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 		start := time.Now()
+		inFlight := r.httpInFlight.WithLabelValues(serviceName)
+		inFlight.Inc()
+		defer inFlight.Dec()
 
 		// Process request
 		c.Next()
 
 		// Record metrics
-		duration := time.Since(start).Seconds()
-		statusCode := string(rune(c.Writer.Status()))
+		elapsed := time.Since(start)
+		duration := elapsed.Seconds()
+		status := responseStatus(c)
+		statusCode := strconv.Itoa(status)
+		method, endpoint := requestLabels(c)
+		endpoint = r.guard.value("http_requests", "endpoint", endpoint)
+		if endpoint != unmatchedEndpoint && endpoint != overflowLabelValue {
+			r.slo.record(serviceName, endpoint, status, elapsed)
+		}
 
 		r.httpRequestsTotal.WithLabelValues(
-			c.Request.Method,
-			c.FullPath(),
+			method,
+			endpoint,
 			statusCode,
 			serviceName,
 		).Inc()
 
-		r.httpRequestDuration.WithLabelValues(
-			c.Request.Method,
-			c.FullPath(),
+		observe(c.Request.Context(), r.httpRequestDuration.WithLabelValues(
+			method,
+			endpoint,
 			serviceName,
-		).Observe(duration)
+		), duration)
 
 		if c.Request.ContentLength > 0 {
 			r.httpRequestSize.WithLabelValues(
-				c.Request.Method,
-				c.FullPath(),
+				method,
+				endpoint,
 				serviceName,
 			).Observe(float64(c.Request.ContentLength))
 		}
 
 		r.httpResponseSize.WithLabelValues(
-			c.Request.Method,
-			c.FullPath(),
+			method,
+			endpoint,
 			serviceName,
-		).Observe(float64(c.Writer.Size()))
+		).Observe(float64(max(c.Writer.Size(), 0)))
+	}
+}
+
+// observe observes value, with the trace of ctx as its exemplar when the
+// trace is sampled, so a latency spike leads to a trace recorded in it
+func observe(ctx context.Context, observer prometheus.Observer, value float64) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if exemplars, ok := observer.(prometheus.ExemplarObserver); ok && spanContext.IsSampled() {
+		exemplars.ObserveWithExemplar(value, prometheus.Labels{"trace_id": spanContext.TraceID().String()})
+		return
+	}
+	observer.Observe(value)
+}
+
+// responseStatus returns the status of the response to the request of c.
+// When a handler failed with c.Error and nothing was written yet, it is
+// the status apperrors.Middleware answers with, not the default 200.
+func responseStatus(c *gin.Context) int {
+	if !c.Writer.Written() && len(c.Errors) > 0 {
+		return apperrors.HTTPStatus(c.Errors.Last().Err)
 	}
+	return c.Writer.Status()
+}
+
+// requestLabels returns the method and endpoint labels of a request: the
+// route it matched, or unmatched
+func requestLabels(c *gin.Context) (string, string) {
+	endpoint := c.FullPath()
+	if endpoint == "" {
+		endpoint = unmatchedEndpoint
+	}
+	return methodLabel(c.Request.Method), endpoint
+}
+
+// methodLabel returns the method label of a request: its method, or other
+// for methods HTTP doesn't define, which clients can make up at will
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "other"
+	}
+}
+
+// labels returns the values of the labels of metric, given as name value
+// pairs, with those beyond the limit of their label replaced with overflow
+func (r *prometheusRegistry) labels(metric string, pairs ...string) []string {
+	return labelValues(r.guard.labels(metric, pairs...))
+}
+
+// Shutdown stops setting the system metrics; there is nothing to push, as
+// Prometheus scrapes the metrics
+func (r *prometheusRegistry) Shutdown(ctx context.Context) error {
+	r.runtime.close()
+	r.slo.close()
+	return nil
+}
+
+// SLOHandler returns the handler of the SLO status endpoint
+func (r *prometheusRegistry) SLOHandler() http.Handler {
+	return sloHandler(r.slo)
 }
 
 // Business metric methods
-func (r *Registry) IncActiveUsers() {
+func (r *prometheusRegistry) IncActiveUsers() {
 	if r.config.Enabled {
 		r.activeUsers.Inc()
 	}
 }
 
-func (r *Registry) DecActiveUsers() {
+func (r *prometheusRegistry) DecActiveUsers() {
 	if r.config.Enabled {
 		r.activeUsers.Dec()
 	}
 }
 
-func (r *Registry) IncOrdersTotal(status, serviceName string) {
+func (r *prometheusRegistry) IncOrdersTotal(status, serviceName string) {
 	if r.config.Enabled {
-		r.totalOrders.WithLabelValues(status, serviceName).Inc()
+		r.totalOrders.WithLabelValues(r.labels("orders_total", "status", status, "service", serviceName)...).Inc()
 	}
 }
 
-func (r *Registry) IncPaymentsTotal(status, method, serviceName string) {
+func (r *prometheusRegistry) IncPaymentsTotal(status, method, serviceName string) {
 	if r.config.Enabled {
-		r.paymentStatus.WithLabelValues(status, method, serviceName).Inc()
+		r.paymentStatus.WithLabelValues(r.labels("payments_total", "status", status, "method", method, "service", serviceName)...).Inc()
 	}
 }
 
-func (r *Registry) SetInventoryLevel(productID, warehouse, serviceName string, level float64) {
+func (r *prometheusRegistry) SetInventoryLevel(productID, warehouse, serviceName string, level float64) {
 	if r.config.Enabled {
-		r.inventoryLevels.WithLabelValues(productID, warehouse, serviceName).Set(level)
+		r.inventoryLevels.WithLabelValues(r.labels("inventory_level", "product_id", productID, "warehouse", warehouse, "service", serviceName)...).Set(level)
+	}
+}
+
+func (r *prometheusRegistry) SetReservations(outcome, serviceName string, count float64) {
+	if r.config.Enabled {
+		r.reservations.WithLabelValues(r.labels("stock_reservations", "outcome", outcome, "service", serviceName)...).Set(count)
+	}
+}
+
+func (r *prometheusRegistry) SetReservationConversionRate(serviceName string, ratio float64) {
+	if r.config.Enabled {
+		r.conversionRate.WithLabelValues(serviceName).Set(ratio)
+	}
+}
+
+// Messaging metric methods
+func (r *prometheusRegistry) AddKafkaMessages(topic, outcome, serviceName string, count int) {
+	if r.config.Enabled {
+		r.kafkaMessages.WithLabelValues(r.labels("kafka_messages_published_total", "topic", topic, "outcome", outcome, "service", serviceName)...).Add(float64(count))
+	}
+}
+
+func (r *prometheusRegistry) ObserveKafkaPublishDuration(topic, serviceName string, seconds float64) {
+	if r.config.Enabled {
+		r.kafkaPublishDuration.WithLabelValues(r.labels("kafka_publish_duration_seconds", "topic", topic, "service", serviceName)...).Observe(seconds)
 	}
 }
 
 // System metric methods
-func (r *Registry) SetGoRoutines(count float64) {
+func (r *prometheusRegistry) SetGoRoutines(count float64) {
 	if r.config.Enabled {
 		r.goRoutines.Set(count)
 	}
 }
 
-func (r *Registry) SetMemoryUsage(bytes float64) {
+func (r *prometheusRegistry) SetMemoryUsage(bytes float64) {
 	if r.config.Enabled {
 		r.memoryUsage.Set(bytes)
 	}
 }
 
-func (r *Registry) SetCPUUsage(percent float64) {
+func (r *prometheusRegistry) SetCPUUsage(percent float64) {
 	if r.config.Enabled {
 		r.cpuUsage.Set(percent)
 	}
 }
 
-func (r *Registry) SetDBConnections(database, state, serviceName string, count float64) {
+func (r *prometheusRegistry) SetDBConnections(database, state, serviceName string, count float64) {
+	if r.config.Enabled {
+		r.dbConnections.WithLabelValues(r.labels("database_connections", "database", database, "state", state, "service", serviceName)...).Set(count)
+	}
+}
+
+func (r *prometheusRegistry) ObserveDBQueryDuration(ctx context.Context, operation, outcome, serviceName string, seconds float64) {
+	if r.config.Enabled {
+		observe(ctx, r.dbQueries.WithLabelValues(r.labels("database_query_duration_seconds", "operation", operation, "outcome", outcome, "service", serviceName)...), seconds)
+	}
+}
+
+func (r *prometheusRegistry) IncCacheRequest(cache, result, serviceName string) {
 	if r.config.Enabled {
-		r.dbConnections.WithLabelValues(database, state, serviceName).Set(count)
+		r.cacheRequests.WithLabelValues(r.labels("cache_requests_total", "cache", cache, "result", result, "service", serviceName)...).Inc()
 	}
 }
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+)
+
+// TestResponseStatus checks requests whose handlers failed with c.Error
+// are counted with the status they are answered with, even when
+// apperrors.Middleware writes it after the metrics are recorded
+func TestResponseStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, tc := range []struct {
+		name    string
+		handler gin.HandlerFunc
+		status  int
+	}{
+		{"written", func(c *gin.Context) { c.Status(http.StatusCreated) }, http.StatusCreated},
+		{"failed", func(c *gin.Context) { c.Error(apperrors.NotFound("order not found")) }, http.StatusNotFound},
+		{"failed and written", func(c *gin.Context) {
+			c.Error(apperrors.NotFound("order not found"))
+			c.AbortWithStatus(http.StatusTeapot)
+		}, http.StatusTeapot},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var status int
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Next()
+				status = responseStatus(c)
+			})
+			router.Use(apperrors.Middleware())
+			router.GET("/", tc.handler)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, tc.status, status)
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// otelRegistry records metrics with OpenTelemetry instruments, pushed to
+// an OTLP collector, for deployments without Prometheus scraping. The
+// instruments are named as the Prometheus metrics are, without the _total
+// of counters, which the collector's Prometheus exporter adds back.
+type otelRegistry struct {
+	provider *sdkmetric.MeterProvider
+	meter    metric.Meter
+	config   config.MetricsConfig
+
+	// HTTP metrics
+	httpRequestsTotal   metric.Int64Counter
+	httpRequestDuration metric.Float64Histogram
+	httpRequestSize     metric.Int64Histogram
+	httpResponseSize    metric.Int64Histogram
+	httpInFlight        metric.Int64UpDownCounter
+
+	// Business metrics
+	activeUsers     metric.Int64UpDownCounter
+	totalOrders     metric.Int64Counter
+	paymentStatus   metric.Int64Counter
+	inventoryLevels metric.Float64Gauge
+	reservations    metric.Float64Gauge
+	conversionRate  metric.Float64Gauge
+
+	// Messaging metrics
+	kafkaMessages        metric.Int64Counter
+	kafkaPublishDuration metric.Float64Histogram
+
+	// System metrics
+	goRoutines    metric.Float64Gauge
+	memoryUsage   metric.Float64Gauge
+	cpuUsage      metric.Float64Gauge
+	dbConnections metric.Float64Gauge
+	dbQueries     metric.Float64Histogram
+	cacheRequests metric.Int64Counter
+
+	// runtime sets the system metrics
+	runtime *runtimeCollector
+	// slo measures requests against the service level objectives
+	slo *sloTracker
+	// guard limits the values of labels
+	guard *cardinalityGuard
+}
+
+// newOTelRegistry creates the registry pushing metrics to the collector
+// at cfg.Endpoint every cfg.Interval
+func newOTelRegistry(cfg config.MetricsConfig, serviceName string) (*otelRegistry, error) {
+	exporter, err := otlpmetrichttp.New(context.Background(),
+		otlpmetrichttp.WithEndpointURL(strings.TrimRight(cfg.Endpoint, "/")+"/v1/metrics"),
+		otlpmetrichttp.WithHeaders(cfg.Headers),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP metrics exporter: %w", err)
+	}
+
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(tracing.Resource(serviceName)),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(cfg.Interval))),
+	)
+	m := &instruments{meter: provider.Meter("github.com/kaanevranportfolio/Commercium/pkg/metrics"), config: cfg}
+
+	r := &otelRegistry{
+		provider: provider,
+		meter:    m.meter,
+		config:   cfg,
+
+		httpRequestsTotal:   m.int64Counter("http_requests", "Total number of HTTP requests"),
+		httpRequestDuration: m.float64Histogram("http_request_duration_seconds", "HTTP request duration in seconds", "s"),
+		httpRequestSize:     m.int64Histogram("http_request_size_bytes", "HTTP request size in bytes", "By"),
+		httpResponseSize:    m.int64Histogram("http_response_size_bytes", "HTTP response size in bytes", "By"),
+		httpInFlight:        m.int64UpDownCounter("http_requests_in_flight", "Number of HTTP requests being served"),
+
+		activeUsers:     m.int64UpDownCounter("active_users", "Number of currently active users"),
+		totalOrders:     m.int64Counter("orders", "Total number of orders"),
+		paymentStatus:   m.int64Counter("payments", "Total number of payments by status"),
+		inventoryLevels: m.float64Gauge("inventory_level", "Current inventory levels"),
+		reservations:    m.float64Gauge("stock_reservations", "Stock reservations resolved recently by outcome"),
+		conversionRate:  m.float64Gauge("stock_reservation_conversion_ratio", "Share of recently resolved stock reservations that became an order"),
+
+		kafkaMessages:        m.int64Counter("kafka_messages_published", "Total number of messages published to Kafka by outcome"),
+		kafkaPublishDuration: m.float64Histogram("kafka_publish_duration_seconds", "Time to deliver messages to Kafka, including batching and retries", "s"),
+
+		goRoutines:    m.float64Gauge("goroutines", "Number of goroutines"),
+		memoryUsage:   m.float64Gauge("memory_usage_bytes", "Memory usage in bytes"),
+		cpuUsage:      m.float64Gauge("cpu_usage_percent", "CPU usage percentage"),
+		dbConnections: m.float64Gauge("database_connections", "Number of database connections"),
+		dbQueries:     m.float64Histogram("database_query_duration_seconds", "Database query duration in seconds by operation and outcome", "s"),
+		cacheRequests: m.int64Counter("cache_requests", "Cache reads by cache and result (local_hit, hit, miss)"),
+	}
+	if m.err != nil {
+		provider.Shutdown(context.Background())
+		return nil, m.err
+	}
+	r.guard = newCardinalityGuard(cfg.MaxLabelValues, r)
+	r.runtime = startRuntimeCollector(r, cfg.RuntimeInterval)
+	r.slo = newSLOTracker(cfg.SLO, r)
+	return r, nil
+}
+
+// instruments creates the instruments of a registry, keeping the first
+// error so they can be created in one go
+type instruments struct {
+	meter  metric.Meter
+	config config.MetricsConfig
+	err    error
+}
+
+// name returns the full name of a metric, prefixed like Prometheus's
+func (m *instruments) name(name string) string {
+	for _, prefix := range []string{m.config.Subsystem, m.config.Namespace} {
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+	}
+	return name
+}
+
+func (m *instruments) keep(err error) {
+	if err != nil && m.err == nil {
+		m.err = fmt.Errorf("failed to create metric instrument: %w", err)
+	}
+}
+
+func (m *instruments) int64Counter(name, help string) metric.Int64Counter {
+	counter, err := m.meter.Int64Counter(m.name(name), metric.WithDescription(help))
+	m.keep(err)
+	return counter
+}
+
+func (m *instruments) float64Counter(name, help string) metric.Float64Counter {
+	counter, err := m.meter.Float64Counter(m.name(name), metric.WithDescription(help))
+	m.keep(err)
+	return counter
+}
+
+func (m *instruments) int64UpDownCounter(name, help string) metric.Int64UpDownCounter {
+	counter, err := m.meter.Int64UpDownCounter(m.name(name), metric.WithDescription(help))
+	m.keep(err)
+	return counter
+}
+
+func (m *instruments) int64Histogram(name, help, unit string) metric.Int64Histogram {
+	histogram, err := m.meter.Int64Histogram(m.name(name), metric.WithDescription(help), metric.WithUnit(unit))
+	m.keep(err)
+	return histogram
+}
+
+func (m *instruments) float64Histogram(name, help, unit string) metric.Float64Histogram {
+	histogram, err := m.meter.Float64Histogram(m.name(name), metric.WithDescription(help), metric.WithUnit(unit))
+	m.keep(err)
+	return histogram
+}
+
+func (m *instruments) float64Gauge(name, help string) metric.Float64Gauge {
+	gauge, err := m.meter.Float64Gauge(m.name(name), metric.WithDescription(help))
+	m.keep(err)
+	return gauge
+}
+
+// Handler finds nothing, as the metrics are pushed
+func (r *otelRegistry) Handler() http.Handler {
+	return http.NotFoundHandler()
+}
+
+// HTTPMiddleware returns Gin middleware for HTTP metrics collection,
+// labelled as the Prometheus registry's are
+func (r *otelRegistry) HTTPMiddleware(serviceName string) gin.HandlerFunc {
+	service := attribute.String("service", serviceName)
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		start := time.Now()
+		r.httpInFlight.Add(ctx, 1, metric.WithAttributes(service))
+		defer r.httpInFlight.Add(ctx, -1, metric.WithAttributes(service))
+
+		// Process request
+		c.Next()
+
+		// Record metrics, in the span of the request for exemplars
+		ctx = c.Request.Context()
+		method, endpoint := requestLabels(c)
+		endpoint = r.guard.value("http_requests", "endpoint", endpoint)
+		labels := metric.WithAttributes(attribute.String("method", method), attribute.String("endpoint", endpoint), service)
+		status := responseStatus(c)
+		if endpoint != unmatchedEndpoint && endpoint != overflowLabelValue {
+			r.slo.record(serviceName, endpoint, status, time.Since(start))
+		}
+
+		r.httpRequestsTotal.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("method", method),
+			attribute.String("endpoint", endpoint),
+			attribute.String("status_code", strconv.Itoa(status)),
+			service,
+		))
+		r.httpRequestDuration.Record(ctx, time.Since(start).Seconds(), labels)
+		if c.Request.ContentLength > 0 {
+			r.httpRequestSize.Record(ctx, c.Request.ContentLength, labels)
+		}
+		r.httpResponseSize.Record(ctx, int64(max(c.Writer.Size(), 0)), labels)
+	}
+}
+
+// Shutdown pushes the metrics not pushed yet and stops pushing
+func (r *otelRegistry) Shutdown(ctx context.Context) error {
+	r.runtime.close()
+	r.slo.close()
+	return r.provider.Shutdown(ctx)
+}
+
+// SLOHandler returns the handler of the SLO status endpoint
+func (r *otelRegistry) SLOHandler() http.Handler {
+	return sloHandler(r.slo)
+}
+
+// Business metric methods
+func (r *otelRegistry) IncActiveUsers() {
+	r.activeUsers.Add(context.Background(), 1)
+}
+
+func (r *otelRegistry) DecActiveUsers() {
+	r.activeUsers.Add(context.Background(), -1)
+}
+
+func (r *otelRegistry) IncOrdersTotal(status, serviceName string) {
+	r.totalOrders.Add(context.Background(), 1, r.attrs("orders", "status", status, "service", serviceName))
+}
+
+func (r *otelRegistry) IncPaymentsTotal(status, method, serviceName string) {
+	r.paymentStatus.Add(context.Background(), 1, r.attrs("payments", "status", status, "method", method, "service", serviceName))
+}
+
+func (r *otelRegistry) SetInventoryLevel(productID, warehouse, serviceName string, level float64) {
+	r.inventoryLevels.Record(context.Background(), level, r.attrs("inventory_level", "product_id", productID, "warehouse", warehouse, "service", serviceName))
+}
+
+func (r *otelRegistry) SetReservations(outcome, serviceName string, count float64) {
+	r.reservations.Record(context.Background(), count, r.attrs("stock_reservations", "outcome", outcome, "service", serviceName))
+}
+
+func (r *otelRegistry) SetReservationConversionRate(serviceName string, ratio float64) {
+	r.conversionRate.Record(context.Background(), ratio, attrs("service", serviceName))
+}
+
+// Messaging metric methods
+func (r *otelRegistry) AddKafkaMessages(topic, outcome, serviceName string, count int) {
+	r.kafkaMessages.Add(context.Background(), int64(count), r.attrs("kafka_messages_published", "topic", topic, "outcome", outcome, "service", serviceName))
+}
+
+func (r *otelRegistry) ObserveKafkaPublishDuration(topic, serviceName string, seconds float64) {
+	r.kafkaPublishDuration.Record(context.Background(), seconds, r.attrs("kafka_publish_duration_seconds", "topic", topic, "service", serviceName))
+}
+
+// System metric methods
+func (r *otelRegistry) SetGoRoutines(count float64) {
+	r.goRoutines.Record(context.Background(), count)
+}
+
+func (r *otelRegistry) SetMemoryUsage(bytes float64) {
+	r.memoryUsage.Record(context.Background(), bytes)
+}
+
+func (r *otelRegistry) SetCPUUsage(percent float64) {
+	r.cpuUsage.Record(context.Background(), percent)
+}
+
+func (r *otelRegistry) SetDBConnections(database, state, serviceName string, count float64) {
+	r.dbConnections.Record(context.Background(), count, r.attrs("database_connections", "database", database, "state", state, "service", serviceName))
+}
+
+func (r *otelRegistry) ObserveDBQueryDuration(ctx context.Context, operation, outcome, serviceName string, seconds float64) {
+	r.dbQueries.Record(ctx, seconds, r.attrs("database_query_duration_seconds", "operation", operation, "outcome", outcome, "service", serviceName))
+}
+
+func (r *otelRegistry) IncCacheRequest(cache, result, serviceName string) {
+	r.cacheRequests.Add(context.Background(), 1, r.attrs("cache_requests", "cache", cache, "result", result, "service", serviceName))
+}
+
+// attrs returns the attributes of the labels of the metric name, given as
+// name value pairs, with the values beyond the limit of their label replaced
+// with overflow
+func (r *otelRegistry) attrs(name string, labels ...string) metric.MeasurementOption {
+	return attrs(r.guard.labels(name, labels...)...)
+}
+
+// attrs returns the attributes of labels given as name value pairs
+func attrs(labels ...string) metric.MeasurementOption {
+	kvs := make([]attribute.KeyValue, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		kvs = append(kvs, attribute.String(labels[i], labels[i+1]))
+	}
+	return metric.WithAttributes(kvs...)
+}
@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// Job is a run of a batch job, such as a dead-letter redrive, whose
+// metrics are pushed to the Pushgateway as it may end before Prometheus
+// scrapes it. While it runs, the metrics of its registry are pushed every
+// interval, grouped by job and instance. Once it finishes, that group is
+// deleted, so runs on short-lived instances don't leave series behind, and
+// the outcome of the run is pushed to the group of the job, where it stays
+// until the next run. Metrics pushed to an OTLP collector instead are
+// flushed once the run finishes.
+type Job struct {
+	config   config.MetricsConfig
+	registry Registry
+	name     string
+	instance string
+	started  time.Time
+	running  *push.Pusher
+	client   *http.Client
+
+	stop     chan struct{}
+	stopped  sync.WaitGroup
+	finished sync.Once
+}
+
+// StartJob starts pushing the metrics of registry, which may be nil, for
+// a run of job. It returns nil when metrics aren't pushed.
+func StartJob(cfg config.MetricsConfig, registry Registry, job string) (*Job, error) {
+	_, pushed := registry.(*otelRegistry)
+	if !cfg.Enabled || (cfg.Pushgateway.URL == "" && !pushed) {
+		return nil, nil
+	}
+
+	instance := cfg.Pushgateway.Instance
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+
+	j := &Job{
+		config:   cfg,
+		registry: registry,
+		name:     job,
+		instance: instance,
+		started:  time.Now(),
+		client:   &http.Client{Timeout: cfg.Pushgateway.Timeout},
+		stop:     make(chan struct{}),
+	}
+
+	if r, ok := registry.(*prometheusRegistry); ok && r.registry != nil && cfg.Pushgateway.URL != "" {
+		j.running = push.New(cfg.Pushgateway.URL, job).
+			Grouping("instance", instance).
+			Gatherer(r.registry).
+			Client(j.client)
+		if err := j.running.Push(); err != nil {
+			return nil, fmt.Errorf("failed to push metrics of job %s: %w", job, err)
+		}
+		j.stopped.Add(1)
+		go j.run()
+	}
+
+	return j, nil
+}
+
+// run pushes the metrics of the run every interval until it finishes.
+// Failures are left to the next push.
+func (j *Job) run() {
+	defer j.stopped.Done()
+
+	ticker := time.NewTicker(j.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-j.stop:
+			return
+		case <-ticker.C:
+			j.running.Push()
+		}
+	}
+}
+
+// Finish stops pushing the metrics of the run and deletes them, and pushes
+// the outcome of the run: when it completed, how long it took, whether it
+// succeeded, and when it last succeeded. err is the error the run failed
+// with, or nil. A nil Job does nothing.
+func (j *Job) Finish(ctx context.Context, err error) error {
+	if j == nil {
+		return nil
+	}
+
+	var finishErr error
+	j.finished.Do(func() {
+		close(j.stop)
+		j.stopped.Wait()
+
+		if j.registry != nil {
+			if shutdownErr := j.registry.Shutdown(ctx); shutdownErr != nil {
+				finishErr = fmt.Errorf("failed to push metrics of job %s: %w", j.name, shutdownErr)
+			}
+		}
+		if j.config.Pushgateway.URL == "" {
+			return
+		}
+
+		if j.running != nil {
+			if deleteErr := j.running.Delete(); deleteErr != nil && finishErr == nil {
+				finishErr = fmt.Errorf("failed to delete metrics of job %s: %w", j.name, deleteErr)
+			}
+		}
+
+		outcome := push.New(j.config.Pushgateway.URL, j.name).Client(j.client)
+		now := time.Now()
+		succeeded := 0.0
+		if err == nil {
+			succeeded = 1
+			outcome.Collector(j.gauge("job_last_success_timestamp_seconds", "Time the job last succeeded", float64(now.Unix())))
+		}
+		outcome.
+			Collector(j.gauge("job_last_completion_timestamp_seconds", "Time the job last completed, successfully or not", float64(now.Unix()))).
+			Collector(j.gauge("job_duration_seconds", "Duration of the last run of the job", now.Sub(j.started).Seconds())).
+			Collector(j.gauge("job_succeeded", "Whether the last run of the job succeeded", succeeded))
+
+		// Added rather than pushed, so a failed run leaves the time the job
+		// last succeeded
+		if pushErr := outcome.AddContext(ctx); pushErr != nil && finishErr == nil {
+			finishErr = fmt.Errorf("failed to push outcome of job %s: %w", j.name, pushErr)
+		}
+	})
+	return finishErr
+}
+
+// gauge returns a gauge set to value
+func (j *Job) gauge(name, help string, value float64) prometheus.Gauge {
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: j.config.Namespace,
+		Subsystem: j.config.Subsystem,
+		Name:      name,
+		Help:      help,
+	})
+	gauge.Set(value)
+	return gauge
+}
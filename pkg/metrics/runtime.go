@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// runtimeCollector sets the system metrics of a registry every interval:
+// the goroutines running, the memory obtained from the OS, and the CPU the
+// process used since the last collection
+type runtimeCollector struct {
+	registry Registry
+	interval time.Duration
+	process  *process.Process
+	stop     chan struct{}
+	stopped  sync.Once
+}
+
+// startRuntimeCollector starts setting the system metrics of registry
+// every interval, until stopped. It returns nil, leaving them unset, when
+// interval isn't positive.
+func startRuntimeCollector(registry Registry, interval time.Duration) *runtimeCollector {
+	if interval <= 0 {
+		return nil
+	}
+
+	c := &runtimeCollector{
+		registry: registry,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+	// Without the process, CPU usage is left unset rather than failing
+	// the registry
+	if p, err := process.NewProcess(int32(os.Getpid())); err == nil {
+		c.process = p
+	}
+	go c.run()
+	return c
+}
+
+func (c *runtimeCollector) run() {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.collect()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.collect()
+		}
+	}
+}
+
+// collect sets the system metrics once
+func (c *runtimeCollector) collect() {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	c.registry.SetGoRoutines(float64(runtime.NumGoroutine()))
+	c.registry.SetMemoryUsage(float64(memStats.Sys))
+
+	if c.process != nil {
+		// Percent of one CPU used since the last call, so the first call,
+		// which has nothing to compare with, sets 0
+		if percent, err := c.process.Percent(0); err == nil {
+			c.registry.SetCPUUsage(percent)
+		}
+	}
+}
+
+// close stops the collector. A nil collector does nothing.
+func (c *runtimeCollector) close() {
+	if c != nil {
+		c.stopped.Do(func() { close(c.stop) })
+	}
+}
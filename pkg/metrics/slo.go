@@ -0,0 +1,280 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// sloBucketWidth is the span of time requests are counted by, and so how
+// often burn rates change
+const sloBucketWidth = time.Minute
+
+// The service level indicators requests are measured by
+const (
+	sliAvailability = "availability"
+	sliLatency      = "latency"
+)
+
+// sloTracker measures the requests to each route against the service
+// level objectives, counting them by minute over the longest window. Every
+// minute it sets the burn rate of each route over each window, how many
+// times faster than allowed it spends its error budget, and the budget
+// left over the longest window, so alerting rules are the same for every
+// service. The counters it keeps let Prometheus compute the same over
+// longer windows.
+type sloTracker struct {
+	config  config.SLOConfig
+	windows []time.Duration
+	size    int64
+
+	requests Counter
+	errors   Counter
+	slow     Counter
+	burnRate Gauge
+	budget   Gauge
+	target   Gauge
+
+	mu     sync.Mutex
+	routes map[sloRoute][]sloBucket
+
+	stop    chan struct{}
+	stopped sync.Once
+}
+
+// sloRoute is a route of a service
+type sloRoute struct {
+	service  string
+	endpoint string
+}
+
+// sloBucket counts the requests to a route in a minute
+type sloBucket struct {
+	minute   int64
+	requests uint64
+	errors   uint64
+	slow     uint64
+}
+
+// newSLOTracker starts measuring requests against the objectives of cfg,
+// recording the results in registry. It returns nil when SLOs are off.
+func newSLOTracker(cfg config.SLOConfig, registry Registry) *sloTracker {
+	if !cfg.Enabled || len(cfg.Windows) == 0 {
+		return nil
+	}
+
+	windows := append([]time.Duration(nil), cfg.Windows...)
+	sort.Slice(windows, func(i, j int) bool { return windows[i] < windows[j] })
+
+	t := &sloTracker{
+		config:  cfg,
+		windows: windows,
+		size:    int64(windows[len(windows)-1] / sloBucketWidth),
+
+		requests: registry.NewCounter("sli_requests", "Requests measured against the service level objectives by route", "service", "endpoint"),
+		errors:   registry.NewCounter("sli_errors", "Requests failing the availability objective, with a 5xx, by route", "service", "endpoint"),
+		slow:     registry.NewCounter("sli_slow_requests", "Requests failing the latency objective, served slower than the threshold, by route", "service", "endpoint"),
+		burnRate: registry.NewGauge("slo_burn_rate", "Rate the error budget of a route is spent at over a window, 1 spending it exactly", "service", "endpoint", "sli", "window"),
+		budget:   registry.NewGauge("slo_error_budget_remaining", "Share of the error budget of a route left over the longest window", "service", "endpoint", "sli"),
+		target:   registry.NewGauge("slo_objective", "Share of requests that must meet a service level indicator", "service", "sli"),
+
+		routes: make(map[sloRoute][]sloBucket),
+		stop:   make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// record counts a request to endpoint that was answered with status after
+// duration. A nil tracker records nothing.
+func (t *sloTracker) record(service, endpoint string, status int, duration time.Duration) {
+	if t == nil {
+		return
+	}
+
+	failed := status >= http.StatusInternalServerError
+	slow := duration > t.config.LatencyThreshold
+	t.requests.Inc(service, endpoint)
+	if failed {
+		t.errors.Inc(service, endpoint)
+	}
+	if slow {
+		t.slow.Inc(service, endpoint)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	route := sloRoute{service: service, endpoint: endpoint}
+	buckets, ok := t.routes[route]
+	if !ok {
+		buckets = make([]sloBucket, t.size)
+		t.routes[route] = buckets
+	}
+	minute := time.Now().Unix() / int64(sloBucketWidth/time.Second)
+	bucket := &buckets[minute%t.size]
+	if bucket.minute != minute {
+		*bucket = sloBucket{minute: minute}
+	}
+	bucket.requests++
+	if failed {
+		bucket.errors++
+	}
+	if slow {
+		bucket.slow++
+	}
+}
+
+func (t *sloTracker) run() {
+	ticker := time.NewTicker(sloBucketWidth)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			t.update()
+		}
+	}
+}
+
+// update sets the burn rate and error budget gauges of every route
+func (t *sloTracker) update() {
+	services := make(map[string]bool)
+	for _, route := range t.status() {
+		if !services[route.Service] {
+			services[route.Service] = true
+			t.target.Set(t.config.Availability, route.Service, sliAvailability)
+			t.target.Set(t.config.Latency, route.Service, sliLatency)
+		}
+		for _, sli := range route.SLIs {
+			for _, window := range sli.Windows {
+				t.burnRate.Set(window.BurnRate, route.Service, route.Endpoint, sli.SLI, window.Window)
+			}
+			t.budget.Set(sli.ErrorBudgetRemaining, route.Service, route.Endpoint, sli.SLI)
+		}
+	}
+}
+
+// sloStatus is the status of a route against the objectives
+type sloStatus struct {
+	Service  string      `json:"service"`
+	Endpoint string      `json:"endpoint"`
+	SLIs     []sliStatus `json:"slis"`
+}
+
+// sliStatus is the status of a route against the objective of an SLI
+type sliStatus struct {
+	SLI                  string      `json:"sli"`
+	Objective            float64     `json:"objective"`
+	Windows              []sliWindow `json:"windows"`
+	ErrorBudgetRemaining float64     `json:"error_budget_remaining"`
+}
+
+// sliWindow is an SLI of a route over a window: the share of its requests
+// that were good, 1 when there were none, and the rate its error budget
+// was spent at
+type sliWindow struct {
+	Window   string  `json:"window"`
+	Requests uint64  `json:"requests"`
+	Bad      uint64  `json:"bad"`
+	Ratio    float64 `json:"ratio"`
+	BurnRate float64 `json:"burn_rate"`
+}
+
+// status returns the status of every route requested, sorted by service
+// and endpoint
+func (t *sloTracker) status() []sloStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	minute := time.Now().Unix() / int64(sloBucketWidth/time.Second)
+	statuses := make([]sloStatus, 0, len(t.routes))
+	for route, buckets := range t.routes {
+		availability := sliStatus{SLI: sliAvailability, Objective: t.config.Availability}
+		latency := sliStatus{SLI: sliLatency, Objective: t.config.Latency}
+		for _, window := range t.windows {
+			var requests, errors, slow uint64
+			since := minute - int64(window/sloBucketWidth)
+			for _, bucket := range buckets {
+				if bucket.minute > since && bucket.minute <= minute {
+					requests += bucket.requests
+					errors += bucket.errors
+					slow += bucket.slow
+				}
+			}
+			availability.Windows = append(availability.Windows, t.window(window, requests, errors, t.config.Availability))
+			latency.Windows = append(latency.Windows, t.window(window, requests, slow, t.config.Latency))
+		}
+		// The budget spent over the longest window is its burn rate there
+		availability.ErrorBudgetRemaining = 1 - availability.Windows[len(t.windows)-1].BurnRate
+		latency.ErrorBudgetRemaining = 1 - latency.Windows[len(t.windows)-1].BurnRate
+
+		statuses = append(statuses, sloStatus{
+			Service:  route.service,
+			Endpoint: route.endpoint,
+			SLIs:     []sliStatus{availability, latency},
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Service != statuses[j].Service {
+			return statuses[i].Service < statuses[j].Service
+		}
+		return statuses[i].Endpoint < statuses[j].Endpoint
+	})
+	return statuses
+}
+
+// window returns an SLI over window, from the requests counted in it and
+// those that were bad
+func (t *sloTracker) window(window time.Duration, requests, bad uint64, objective float64) sliWindow {
+	w := sliWindow{Window: formatWindow(window), Requests: requests, Bad: bad, Ratio: 1}
+	if requests > 0 {
+		badRatio := float64(bad) / float64(requests)
+		w.Ratio = 1 - badRatio
+		w.BurnRate = badRatio / (1 - objective)
+	}
+	return w
+}
+
+// close stops updating the gauges. A nil tracker does nothing.
+func (t *sloTracker) close() {
+	if t != nil {
+		t.stopped.Do(func() { close(t.stop) })
+	}
+}
+
+// sloHandler returns the handler of the SLO status endpoint of tracker,
+// listing the objectives and the status of every route against them. It
+// finds nothing when SLOs are off.
+func sloHandler(tracker *sloTracker) http.Handler {
+	if tracker == nil {
+		return http.NotFoundHandler()
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"objectives": map[string]interface{}{
+				sliAvailability:     tracker.config.Availability,
+				sliLatency:          tracker.config.Latency,
+				"latency_threshold": tracker.config.LatencyThreshold.String(),
+			},
+			"routes": tracker.status(),
+		})
+	})
+}
+
+// formatWindow formats a window as alerting rules label it, e.g. 5m or 6h
+func formatWindow(window time.Duration) string {
+	if window%time.Hour == 0 {
+		return strconv.FormatInt(int64(window/time.Hour), 10) + "h"
+	}
+	return strconv.FormatInt(int64(window/time.Minute), 10) + "m"
+}
@@ -0,0 +1,130 @@
+// Package money implements currency arithmetic on amounts in minor units.
+// Amounts are int64 and exchange rates are exact rationals, so no value ever
+// passes through a float.
+package money
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// exponents holds the number of minor unit digits of currencies that don't
+// use two. Currencies not listed have two.
+var exponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// Exponent returns the number of minor unit digits of a currency, e.g. 2 for
+// USD and 0 for JPY
+func Exponent(currency string) int {
+	if exp, ok := exponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+// IsCurrencyCode reports whether code looks like an ISO 4217 currency code
+func IsCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseRate parses a decimal exchange rate such as "1.0823"
+func ParseRate(value string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid exchange rate: %q", value)
+	}
+	return rate, nil
+}
+
+// FormatRate formats an exchange rate as a decimal with up to 10 digits
+func FormatRate(rate *big.Rat) string {
+	s := rate.FloatString(10)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// CrossRate returns the rate from one currency to another given the rates of
+// both against a common base currency
+func CrossRate(baseToFrom, baseToTo *big.Rat) *big.Rat {
+	return new(big.Rat).Quo(baseToTo, baseToFrom)
+}
+
+// Convert converts an amount in minor units of one currency to minor units of
+// another. rate is the price of one unit of from in units of to. The result is
+// rounded half away from zero.
+func Convert(amount int64, from, to string, rate *big.Rat) int64 {
+	value := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), rate)
+
+	// Rescale between the minor units of the two currencies
+	shift := Exponent(to) - Exponent(from)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil))
+	if shift >= 0 {
+		value.Mul(value, scale)
+	} else {
+		value.Quo(value, scale)
+	}
+
+	return round(value)
+}
+
+// Format formats an amount in minor units with the currency code, e.g.
+// "12.34 USD" or "1234 JPY"
+func Format(amount int64, currency string) string {
+	return FormatAmount(amount, currency) + " " + strings.ToUpper(currency)
+}
+
+// FormatAmount formats an amount in minor units as a decimal with the number
+// of digits of its currency, e.g. "12.34" for USD
+func FormatAmount(amount int64, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	exp := Exponent(currency)
+	if exp == 0 {
+		return fmt.Sprintf("%s%d", sign, amount)
+	}
+
+	unit := int64(1)
+	for i := 0; i < exp; i++ {
+		unit *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/unit, exp, amount%unit)
+}
+
+// round rounds a rational half away from zero
+func round(value *big.Rat) int64 {
+	num := new(big.Int).Abs(value.Num())
+	den := value.Denom()
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(rem, big.NewInt(2)).Cmp(den) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+
+	if value.Sign() < 0 {
+		quo.Neg(quo)
+	}
+	return quo.Int64()
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,382 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/retry"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// Headers added to messages parked on the retry queue of a delay tier
+const (
+	HeaderRetryTier     = "retry-tier"
+	HeaderRetryAttempts = "retry-attempts"
+)
+
+// Handler processes the body of a consumed message
+type Handler func(ctx context.Context, body []byte) error
+
+// permanentError marks a handler error that retrying can't fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as one that retrying won't fix, e.g. a malformed
+// message. The message is dead-lettered without further attempts.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Consumer consumes a queue, passing each message to its handler. A message
+// is acknowledged once handled. Failed messages are retried with backoff as
+// the retry policy of the handler allows. Messages that still fail are
+// parked on a retry queue for each delay tier of the policy in turn and
+// handled again once the delay passed. Messages failing after that, or
+// failing permanently, are rejected to the dead-letter queue of the queue.
+// Instances consuming the same queue share its messages.
+type Consumer struct {
+	config  config.RabbitMQConfig
+	queue   string
+	handler Handler
+	policy  retry.Policy
+	logger  *logger.Logger
+
+	// conn is the connection being consumed on, nil while disconnected
+	mu   sync.Mutex
+	conn *amqp.Connection
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewConsumer creates a new consumer of a queue
+func NewConsumer(cfg config.RabbitMQConfig, queue string, log *logger.Logger) (*Consumer, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("no rabbitmq url configured")
+	}
+	if queue == "" {
+		return nil, fmt.Errorf("no queue configured")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Consumer{
+		config: cfg,
+		queue:  queue,
+		policy: DefaultRetryPolicy(cfg),
+		logger: log,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}, nil
+}
+
+// Register registers the handler of the queue. It must be registered before
+// Run is started.
+func (c *Consumer) Register(handler Handler) {
+	c.handler = handler
+}
+
+// SetRetryPolicy sets the retry policy of the handler, which otherwise
+// retries as DefaultRetryPolicy. It must be set before Run is started.
+func (c *Consumer) SetRetryPolicy(policy retry.Policy) {
+	c.policy = policy
+}
+
+// DefaultRetryPolicy returns the retry policy of the retry settings of the
+// broker: RetryMax retries RetryDelay apart and no delay tiers
+func DefaultRetryPolicy(cfg config.RabbitMQConfig) retry.Policy {
+	return retry.Policy{
+		MaxAttempts: cfg.RetryMax + 1,
+		BackoffMin:  cfg.RetryDelay,
+		BackoffMax:  cfg.RetryDelay,
+	}
+}
+
+// Handle registers a handler for the JSON messages of the queue, decoded
+// into T. Messages that can't be decoded are dead-lettered right away.
+func Handle[T any](c *Consumer, handle func(ctx context.Context, message *T) error) {
+	c.Register(func(ctx context.Context, body []byte) error {
+		var message T
+		if err := json.Unmarshal(body, &message); err != nil {
+			return Permanent(fmt.Errorf("invalid message: %w", err))
+		}
+		return handle(ctx, &message)
+	})
+}
+
+// Run consumes the queue until Close is called, reconnecting RetryDelay
+// after the connection is lost
+func (c *Consumer) Run() {
+	defer close(c.done)
+
+	if c.handler == nil {
+		c.logger.Error("No RabbitMQ handler registered", "queue", c.queue)
+		return
+	}
+
+	for {
+		err := c.consume()
+		if c.ctx.Err() != nil {
+			return
+		}
+		c.logger.Error("RabbitMQ consumer disconnected, reconnecting", "error", err, "queue", c.queue)
+		if !c.sleep(c.config.RetryDelay) {
+			return
+		}
+	}
+}
+
+// consume consumes the queue on a new connection until it is lost or the
+// consumer is closed
+func (c *Consumer) consume() error {
+	conn, err := dial(c.config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	c.setConn(conn)
+	defer c.setConn(nil)
+
+	channel, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open rabbitmq channel: %w", err)
+	}
+	if err := declareQueue(channel, c.config, c.queue); err != nil {
+		return err
+	}
+	for tier := range c.policy.DelayTiers {
+		if err := declareRetryQueue(channel, c.config, c.queue, tier+1); err != nil {
+			return err
+		}
+	}
+	// Messages are only acknowledged once parked on a retry queue for sure
+	if err := channel.Confirm(false); err != nil {
+		return fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+	// One message at a time: the others stay available to other instances
+	if err := channel.Qos(1, 0, false); err != nil {
+		return fmt.Errorf("failed to set prefetch: %w", err)
+	}
+
+	deliveries, err := channel.ConsumeWithContext(c.ctx, c.queue, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to consume queue %s: %w", c.queue, err)
+	}
+
+	c.logger.Info("RabbitMQ consumer started", "queue", c.queue)
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return nil
+		case delivery, ok := <-deliveries:
+			if !ok {
+				return errors.New("delivery channel closed")
+			}
+			c.process(channel, delivery)
+		}
+	}
+}
+
+// process handles a message, retrying failures, and acknowledges, parks or
+// rejects it
+func (c *Consumer) process(channel *amqp.Channel, delivery amqp.Delivery) {
+	// A message being handled is finished even when shutting down; the
+	// handler runs in a consumer span continuing the trace of the request
+	// that published it
+	ctx, span := tracing.GetTracer(tracerName).Start(
+		extractTraceContext(context.WithoutCancel(c.ctx), delivery.Headers), c.queue+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(tracing.MessagingAttributes(tracing.MessagingSystemRabbitMQ, tracing.MessagingOperationProcess, c.queue)...),
+		trace.WithAttributes(
+			attribute.String("messaging.rabbitmq.destination.routing_key", delivery.RoutingKey),
+			attribute.Bool("messaging.rabbitmq.redelivered", delivery.Redelivered),
+		))
+	defer span.End()
+
+	tier := headerInt(delivery.Headers, HeaderRetryTier)
+	previousAttempts := headerInt(delivery.Headers, HeaderRetryAttempts)
+	span.SetAttributes(tracing.MessagingRetryTierKey.Int(tier))
+
+	for attempt := 1; ; attempt++ {
+		err := c.handler(ctx, delivery.Body)
+		if err == nil {
+			if err := delivery.Ack(false); err != nil {
+				c.logger.Error("Failed to acknowledge message", "error", err, "queue", c.queue)
+			}
+			return
+		}
+		attempts := previousAttempts + attempt
+
+		var permanent *permanentError
+		isPermanent := errors.As(err, &permanent)
+		if isPermanent || !c.policy.Retryable(attempt) {
+			tracing.RecordError(span, err, tracing.MessagingAttemptKey.Int(attempts))
+			if delay, ok := c.policy.Delay(tier + 1); ok && !isPermanent {
+				c.park(ctx, channel, delivery, err, tier+1, delay, attempts)
+				return
+			}
+			c.logger.Error("Failed to handle message, moving it to the dead-letter queue", "error", err,
+				"queue", c.queue, "attempts", attempts, "dead_letter_queue", c.queue+DeadLetterSuffix)
+			if err := delivery.Nack(false, false); err != nil {
+				c.logger.Error("Failed to reject message", "error", err, "queue", c.queue)
+			}
+			return
+		}
+
+		// The message may yet be handled, so the span isn't failed by the
+		// attempt
+		span.RecordError(err, trace.WithAttributes(tracing.MessagingAttemptKey.Int(attempts)))
+		c.logger.Warn("Failed to handle message, will retry", "error", err, "queue", c.queue, "attempt", attempt)
+		if !c.sleep(c.policy.Backoff(attempt)) {
+			// Shutting down mid-retry: the message goes back to the queue for
+			// another instance
+			if err := delivery.Nack(false, true); err != nil {
+				c.logger.Error("Failed to requeue message", "error", err, "queue", c.queue)
+			}
+			return
+		}
+	}
+}
+
+// park publishes a message that keeps failing to the retry queue of a delay
+// tier, from which it expires back to the queue once the delay passed, and
+// acknowledges it. A message that can't be parked goes back to the queue.
+func (c *Consumer) park(ctx context.Context, channel *amqp.Channel, delivery amqp.Delivery, cause error, tier int, delay time.Duration, attempts int) {
+	headers := amqp.Table{}
+	for key, value := range delivery.Headers {
+		// The broker's record of the expiry isn't needed to route it back
+		if key != "x-death" {
+			headers[key] = value
+		}
+	}
+	headers[HeaderRetryTier] = int64(tier)
+	headers[HeaderRetryAttempts] = int64(attempts)
+
+	queue := retryQueue(c.queue, tier)
+	err := c.publish(ctx, channel, queue, amqp.Publishing{
+		ContentType:  delivery.ContentType,
+		DeliveryMode: amqp.Persistent,
+		Priority:     delivery.Priority,
+		Timestamp:    delivery.Timestamp,
+		Expiration:   strconv.FormatInt(delay.Milliseconds(), 10),
+		Headers:      headers,
+		Body:         delivery.Body,
+	})
+	if err != nil {
+		c.logger.Error("Failed to park message, requeueing it", "error", err, "queue", c.queue, "retry_queue", queue)
+		if err := delivery.Nack(false, true); err != nil {
+			c.logger.Error("Failed to requeue message", "error", err, "queue", c.queue)
+		}
+		return
+	}
+
+	c.logger.Warn("Failed to handle message, parked it for a later retry", "error", cause,
+		"queue", c.queue, "attempts", attempts, "retry_queue", queue, "delay", delay)
+	if err := delivery.Ack(false); err != nil {
+		c.logger.Error("Failed to acknowledge message", "error", err, "queue", c.queue)
+	}
+}
+
+// publish publishes a message straight to a queue and waits for the broker
+// to confirm it
+func (c *Consumer) publish(ctx context.Context, channel *amqp.Channel, queue string, message amqp.Publishing) error {
+	confirmation, err := channel.PublishWithDeferredConfirmWithContext(ctx, "", queue, false, false, message)
+	if err != nil {
+		return err
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return fmt.Errorf("message was not confirmed by the broker")
+	}
+	return nil
+}
+
+// headerInt returns the value of an integer message header, or 0
+func headerInt(headers amqp.Table, key string) int {
+	switch value := headers[key].(type) {
+	case int64:
+		return int(value)
+	case int32:
+		return int(value)
+	case int16:
+		return int(value)
+	case int8:
+		return int(value)
+	}
+	return 0
+}
+
+// setConn records the connection being consumed on
+func (c *Consumer) setConn(conn *amqp.Connection) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn = conn
+}
+
+// HealthCheck checks that the consumer is connected and its queue exists
+func (c *Consumer) HealthCheck() error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn == nil || conn.IsClosed() {
+		return fmt.Errorf("rabbitmq consumer disconnected")
+	}
+	return checkQueues(conn, []string{c.queue})
+}
+
+// sleep waits for d and reports whether the consumer is still running
+func (c *Consumer) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-c.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Close stops consuming. It waits for the message being handled. Run must
+// have been started.
+func (c *Consumer) Close() {
+	c.Shutdown(context.Background())
+}
+
+// Shutdown is Close, waiting for the message being handled until ctx is
+// done. A message whose handler didn't finish by then is left unacked, to
+// be redelivered.
+func (c *Consumer) Shutdown(ctx context.Context) error {
+	c.cancel()
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
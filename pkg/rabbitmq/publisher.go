@@ -0,0 +1,201 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// Publisher publishes JSON messages to queues. Messages are persistent and
+// a publish only succeeds once the broker confirmed it; failed publishes are
+// retried on a new connection. Each message carries the trace context of the
+// publishing request in its headers.
+type Publisher struct {
+	config config.RabbitMQConfig
+	queues []string
+	logger *logger.Logger
+
+	mu      sync.Mutex
+	conn    *amqp.Connection
+	channel *amqp.Channel
+}
+
+// NewPublisher connects a new publisher to RabbitMQ and declares the queues
+// it publishes to
+func NewPublisher(cfg config.RabbitMQConfig, log *logger.Logger, queues ...string) (*Publisher, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("no rabbitmq url configured")
+	}
+
+	p := &Publisher{
+		config: cfg,
+		queues: queues,
+		logger: log,
+	}
+	if err := p.connect(); err != nil {
+		return nil, err
+	}
+	for _, queue := range queues {
+		if err := declareQueue(p.channel, cfg, queue); err != nil {
+			p.Close()
+			return nil, err
+		}
+	}
+
+	log.Info("RabbitMQ publisher created", "exchange", cfg.Exchange, "queues", queues)
+	return p, nil
+}
+
+// connect opens the connection and a channel in confirm mode. It must be
+// called with mu held, or before the publisher is shared.
+func (p *Publisher) connect() error {
+	conn, err := dial(p.config)
+	if err != nil {
+		return err
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to open rabbitmq channel: %w", err)
+	}
+	if err := channel.Confirm(false); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+	if err := declareExchanges(channel, p.config); err != nil {
+		conn.Close()
+		return err
+	}
+
+	p.conn = conn
+	p.channel = channel
+	return nil
+}
+
+// Publish encodes value as JSON and publishes it to a queue with normal
+// priority, retrying up to RetryMax times RetryDelay apart
+func (p *Publisher) Publish(ctx context.Context, queue string, value interface{}) error {
+	return p.PublishPriority(ctx, queue, PriorityNormal, value)
+}
+
+// PublishPriority encodes value as JSON and publishes it to a queue with a
+// priority, retrying up to RetryMax times RetryDelay apart
+func (p *Publisher) PublishPriority(ctx context.Context, queue string, priority uint8, value interface{}) error {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return p.publishBody(ctx, queue, priority, body)
+}
+
+// publishBody publishes an encoded message in a producer span
+func (p *Publisher) publishBody(ctx context.Context, queue string, priority uint8, body []byte) error {
+	ctx, span := tracing.GetTracer(tracerName).Start(ctx, queue+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(tracing.MessagingAttributes(tracing.MessagingSystemRabbitMQ, tracing.MessagingOperationPublish, p.config.Exchange)...),
+		trace.WithAttributes(
+			attribute.String("messaging.rabbitmq.destination.routing_key", queue),
+			attribute.Int("messaging.rabbitmq.message.priority", int(priority)),
+		))
+	defer span.End()
+
+	message := amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Priority:     priority,
+		Timestamp:    time.Now().UTC(),
+		Headers:      amqp.Table{},
+		Body:         body,
+	}
+	injectTraceContext(ctx, message.Headers)
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = p.publish(ctx, queue, message)
+		if err == nil {
+			return nil
+		}
+		if attempt >= p.config.RetryMax || ctx.Err() != nil {
+			break
+		}
+
+		p.logger.Warn("Failed to publish message, retrying", "error", err, "queue", queue, "attempt", attempt+1)
+		select {
+		case <-ctx.Done():
+		case <-time.After(p.config.RetryDelay):
+		}
+	}
+
+	tracing.RecordError(span, err)
+	p.logger.Error("Failed to publish message", "error", err, "queue", queue)
+	return fmt.Errorf("failed to publish message: %w", err)
+}
+
+// publish publishes a message and waits for the broker to confirm it,
+// reconnecting first if the connection was lost
+func (p *Publisher) publish(ctx context.Context, queue string, message amqp.Publishing) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil || p.conn.IsClosed() || p.channel.IsClosed() {
+		if p.conn != nil {
+			p.conn.Close()
+		}
+		if err := p.connect(); err != nil {
+			return err
+		}
+	}
+
+	confirmation, err := p.channel.PublishWithDeferredConfirmWithContext(ctx, p.config.Exchange, queue, false, false, message)
+	if err != nil {
+		return err
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return fmt.Errorf("message was not confirmed by the broker")
+	}
+	return nil
+}
+
+// HealthCheck checks that the broker is reachable and the queues the
+// publisher publishes to exist, reconnecting if the connection was lost
+func (p *Publisher) HealthCheck() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil || p.conn.IsClosed() || p.channel.IsClosed() {
+		if p.conn != nil {
+			p.conn.Close()
+		}
+		if err := p.connect(); err != nil {
+			return err
+		}
+	}
+	return checkQueues(p.conn, p.queues)
+}
+
+// Close closes the connection
+func (p *Publisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.logger.Info("Closing RabbitMQ publisher")
+	if p.conn == nil {
+		return nil
+	}
+	return p.conn.Close()
+}
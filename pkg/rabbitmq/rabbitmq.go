@@ -0,0 +1,149 @@
+// Package rabbitmq publishes and consumes point-to-point work on RabbitMQ
+// queues, e.g. emails to send. Messages are JSON, published to the
+// configured exchange with the name of their queue as routing key. Each
+// queue has a dead-letter queue, named after it with DeadLetterSuffix
+// appended, that messages still failing after the configured retries end up in.
+// Handlers whose retry policy has delay tiers park messages that keep
+// failing on a retry queue per tier, named after the queue with
+// RetryQueueSuffix and the tier appended, until they expire back to the queue.
+//
+// Queues deliver messages of higher priority first, so time-sensitive work,
+// e.g. a password reset email, isn't stuck behind bulk work. Messages can be
+// delayed with a Scheduler, which holds them in Redis until due.
+package rabbitmq
+
+import (
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// DeadLetterSuffix is appended to the name of a queue to name its
+// dead-letter queue
+const DeadLetterSuffix = ".dlq"
+
+// RetryQueueSuffix is appended to the name of a queue, followed by the
+// delay tier, to name its retry queues
+const RetryQueueSuffix = ".retry-"
+
+// Message priorities, from 0 up to the configured MaxPriority
+const (
+	PriorityLow    uint8 = 1
+	PriorityNormal uint8 = 5
+	PriorityHigh   uint8 = 9
+)
+
+// healthCheckTimeout bounds how long checking the broker may take
+const healthCheckTimeout = 5 * time.Second
+
+// dialTimeout bounds how long connecting to the broker may take
+const dialTimeout = 5 * time.Second
+
+// dial connects to the broker
+func dial(cfg config.RabbitMQConfig) (*amqp.Connection, error) {
+	conn, err := amqp.DialConfig(cfg.URL, amqp.Config{Dial: amqp.DefaultDial(dialTimeout)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to rabbitmq: %w", err)
+	}
+	return conn, nil
+}
+
+// deadLetterExchange returns the exchange rejected messages are routed through
+func deadLetterExchange(cfg config.RabbitMQConfig) string {
+	return cfg.Exchange + ".dlx"
+}
+
+// declareExchanges declares the exchange messages are published to and the
+// one rejected messages are routed through
+func declareExchanges(ch *amqp.Channel, cfg config.RabbitMQConfig) error {
+	if err := ch.ExchangeDeclare(cfg.Exchange, cfg.ExchangeType, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare exchange %s: %w", cfg.Exchange, err)
+	}
+	if err := ch.ExchangeDeclare(deadLetterExchange(cfg), amqp.ExchangeDirect, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare exchange %s: %w", deadLetterExchange(cfg), err)
+	}
+	return nil
+}
+
+// declareQueue declares a durable priority queue bound to the exchange with
+// its name as routing key, and its dead-letter queue
+func declareQueue(ch *amqp.Channel, cfg config.RabbitMQConfig, queue string) error {
+	if err := declareExchanges(ch, cfg); err != nil {
+		return err
+	}
+
+	deadLetters := queue + DeadLetterSuffix
+	if _, err := ch.QueueDeclare(deadLetters, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", deadLetters, err)
+	}
+	if err := ch.QueueBind(deadLetters, queue, deadLetterExchange(cfg), false, nil); err != nil {
+		return fmt.Errorf("failed to bind queue %s: %w", deadLetters, err)
+	}
+
+	args := amqp.Table{
+		"x-dead-letter-exchange":    deadLetterExchange(cfg),
+		"x-dead-letter-routing-key": queue,
+	}
+	if cfg.MaxPriority > 0 {
+		args["x-max-priority"] = int32(cfg.MaxPriority)
+	}
+	_, err := ch.QueueDeclare(queue, true, false, false, false, args)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", queue, err)
+	}
+	if err := ch.QueueBind(queue, queue, cfg.Exchange, false, nil); err != nil {
+		return fmt.Errorf("failed to bind queue %s: %w", queue, err)
+	}
+	return nil
+}
+
+// retryQueue returns the retry queue of a queue for a delay tier
+func retryQueue(queue string, tier int) string {
+	return fmt.Sprintf("%s%s%d", queue, RetryQueueSuffix, tier)
+}
+
+// declareRetryQueue declares the retry queue of a queue for a delay tier.
+// Messages are parked on it with the delay as expiration, and expired
+// messages are routed back to the queue.
+func declareRetryQueue(ch *amqp.Channel, cfg config.RabbitMQConfig, queue string, tier int) error {
+	_, err := ch.QueueDeclare(retryQueue(queue, tier), true, false, false, false, amqp.Table{
+		"x-dead-letter-exchange":    cfg.Exchange,
+		"x-dead-letter-routing-key": queue,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", retryQueue(queue, tier), err)
+	}
+	return nil
+}
+
+// checkQueues checks that queues exist with a passive declare on a channel of
+// its own, as a failed declare closes the channel
+func checkQueues(conn *amqp.Connection, queues []string) error {
+	result := make(chan error, 1)
+	go func() {
+		channel, err := conn.Channel()
+		if err != nil {
+			result <- fmt.Errorf("failed to open rabbitmq channel: %w", err)
+			return
+		}
+		defer channel.Close()
+
+		for _, queue := range queues {
+			if _, err := channel.QueueDeclarePassive(queue, true, false, false, false, nil); err != nil {
+				result <- fmt.Errorf("queue %s unavailable: %w", queue, err)
+				return
+			}
+		}
+		result <- nil
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(healthCheckTimeout):
+		return fmt.Errorf("rabbitmq health check timed out")
+	}
+}
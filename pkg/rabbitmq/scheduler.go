@@ -0,0 +1,142 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// scheduledMessage is a message waiting in the sorted set until it is due
+type scheduledMessage struct {
+	// ID makes messages with the same content distinct members of the set
+	ID       uuid.UUID         `json:"id"`
+	Queue    string            `json:"queue"`
+	Priority uint8             `json:"priority"`
+	Body     json.RawMessage   `json:"body"`
+	Trace    map[string]string `json:"trace,omitempty"`
+}
+
+// Scheduler delays messages: they are held in a Redis sorted set, scored by
+// the time they are due at, and published once due. Instances sharing the
+// set share its messages; each message is published by one of them.
+type Scheduler struct {
+	redis     *redis.Client
+	publisher *Publisher
+	config    config.RabbitMQSchedulerConfig
+	logger    *logger.Logger
+}
+
+// NewScheduler creates a new scheduler publishing due messages with publisher
+func NewScheduler(client *redis.Client, publisher *Publisher, cfg config.RabbitMQConfig, log *logger.Logger) *Scheduler {
+	return &Scheduler{
+		redis:     client,
+		publisher: publisher,
+		config:    cfg.Scheduler,
+		logger:    log,
+	}
+}
+
+// Schedule encodes value as JSON and holds it to be published to a queue
+// with a priority at the given time. The message carries the trace context
+// of ctx.
+func (s *Scheduler) Schedule(ctx context.Context, queue string, priority uint8, value interface{}, at time.Time) error {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	message := scheduledMessage{
+		ID:       uuid.New(),
+		Queue:    queue,
+		Priority: priority,
+		Body:     body,
+		Trace:    map[string]string{},
+	}
+	traceContext.Inject(ctx, propagation.MapCarrier(message.Trace))
+
+	member, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scheduled message: %w", err)
+	}
+	if err := s.redis.ZAdd(ctx, s.config.Key, redis.Z{Score: float64(at.UnixMilli()), Member: member}).Err(); err != nil {
+		return fmt.Errorf("failed to schedule message: %w", err)
+	}
+	return nil
+}
+
+// Run publishes due messages every PollInterval until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	s.logger.Info("RabbitMQ scheduler started", "key", s.config.Key, "poll_interval", s.config.PollInterval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.PublishDue(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to publish scheduled messages", "error", err)
+			}
+		}
+	}
+}
+
+// PublishDue publishes up to BatchSize messages that are due and returns
+// how many it published
+func (s *Scheduler) PublishDue(ctx context.Context) (int, error) {
+	members, err := s.redis.ZRangeArgsWithScores(ctx, redis.ZRangeArgs{
+		Key:     s.config.Key,
+		Start:   "-inf",
+		Stop:    strconv.FormatInt(time.Now().UnixMilli(), 10),
+		ByScore: true,
+		Count:   int64(s.config.BatchSize),
+	}).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read scheduled messages: %w", err)
+	}
+
+	published := 0
+	for _, z := range members {
+		member, _ := z.Member.(string)
+
+		// Removing the message claims it; another instance got it first
+		// when there's nothing to remove
+		removed, err := s.redis.ZRem(ctx, s.config.Key, member).Result()
+		if err != nil {
+			return published, fmt.Errorf("failed to claim scheduled message: %w", err)
+		}
+		if removed == 0 {
+			continue
+		}
+
+		var message scheduledMessage
+		if err := json.Unmarshal([]byte(member), &message); err != nil {
+			s.logger.Error("Invalid scheduled message, dropping it", "error", err, "key", s.config.Key)
+			continue
+		}
+
+		publishCtx := traceContext.Extract(ctx, propagation.MapCarrier(message.Trace))
+		if err := s.publisher.publishBody(publishCtx, message.Queue, message.Priority, message.Body); err != nil {
+			// Put it back to be published on the next poll
+			if err := s.redis.ZAdd(context.WithoutCancel(ctx), s.config.Key, z).Err(); err != nil {
+				s.logger.Error("Failed to reschedule message, it is lost", "error", err,
+					"queue", message.Queue, "id", message.ID)
+			}
+			return published, err
+		}
+		published++
+	}
+
+	return published, nil
+}
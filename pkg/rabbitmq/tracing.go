@@ -0,0 +1,53 @@
+package rabbitmq
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// tracerName is the name of the tracer of RabbitMQ spans
+const tracerName = "rabbitmq"
+
+// traceContext propagates trace context and baggage in message headers in
+// the W3C formats, whatever propagator the service configured globally
+var traceContext = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// headerCarrier adapts message headers to a propagation.TextMapCarrier
+type headerCarrier amqp.Table
+
+// Get returns the value of the header key
+func (c headerCarrier) Get(key string) string {
+	value, _ := c[key].(string)
+	return value
+}
+
+// Set sets the header key
+func (c headerCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys returns the keys of the headers
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// injectTraceContext adds the trace context and baggage of ctx to the
+// headers
+func injectTraceContext(ctx context.Context, headers amqp.Table) {
+	traceContext.Inject(ctx, headerCarrier(headers))
+}
+
+// extractTraceContext returns ctx continuing the trace the headers carry,
+// with their baggage
+func extractTraceContext(ctx context.Context, headers amqp.Table) context.Context {
+	if headers == nil {
+		return ctx
+	}
+	return traceContext.Extract(ctx, headerCarrier(headers))
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// KeyFunc returns the key identifying the client of a request
+type KeyFunc func(c *gin.Context) string
+
+// ByClientIP identifies clients by their IP address
+func ByClientIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUser identifies clients by the authenticated user, or by their IP
+// address before they are authenticated
+func ByUser(c *gin.Context) string {
+	if userID := auth.UserIDFromContext(c); userID != uuid.Nil {
+		return "user:" + userID.String()
+	}
+	return ByClientIP(c)
+}
+
+// PerRoute limits the requests of clients, identified by key, to each
+// route apart
+func PerRoute(key KeyFunc) KeyFunc {
+	return func(c *gin.Context) string {
+		return c.Request.Method + " " + c.FullPath() + ":" + key(c)
+	}
+}
+
+// Middleware returns Gin middleware that answers requests of clients,
+// identified by key, over the limits of limiter with 429 Too Many
+// Requests. Responses tell clients their limit in X-RateLimit headers, and
+// when refused, when to retry in Retry-After. Requests are let through
+// while the limits can't be checked, so an outage of Redis doesn't refuse
+// every request.
+func Middleware(limiter Limiter, key KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := limiter.Allow(c.Request.Context(), key(c))
+		if err != nil {
+			logger.FromContext(c.Request.Context()).Error("Failed to check rate limit", "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", seconds(result.Reset))
+		if !result.Allowed {
+			c.Header("Retry-After", seconds(result.RetryAfter))
+			apperrors.Respond(c, apperrors.RateLimited("too many requests, retry in %s seconds", seconds(result.RetryAfter)))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// seconds returns d in whole seconds, rounded up, as HTTP headers have it
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
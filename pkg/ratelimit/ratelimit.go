@@ -0,0 +1,79 @@
+// Package ratelimit limits how many requests each client makes, with a
+// token bucket or a sliding window, kept in memory for limits of one
+// instance or in Redis for limits shared by every instance. Middleware
+// applies a limiter to Gin routes.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+)
+
+// The algorithms limits are kept with
+const (
+	AlgorithmTokenBucket   = "token_bucket"
+	AlgorithmSlidingWindow = "sliding_window"
+)
+
+// The backends limits are kept in
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// Result is whether a request was allowed, and how far its client is
+// from its limit
+type Result struct {
+	Allowed bool
+	// Limit is the number of requests allowed at once
+	Limit int
+	// Remaining is the number of requests allowed after this one
+	Remaining int
+	// RetryAfter is how long until a request is allowed again, when this
+	// one wasn't
+	RetryAfter time.Duration
+	// Reset is how long until the client is back to its full limit
+	Reset time.Duration
+}
+
+// Limiter decides whether to allow the requests of clients, each
+// identified by a key, such as its IP address
+type Limiter interface {
+	// Allow counts a request of the client identified by key, unless it is
+	// over its limit
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+// New creates the limiter cfg configures. Limits kept in Redis are
+// namespaced with name, so limiters of different routes don't share them.
+func New(cfg config.RateLimitConfig, redis *database.Redis, name string) (Limiter, error) {
+	burst := cfg.Burst
+	if burst == 0 {
+		burst = cfg.Limit
+	}
+	if cfg.Limit < 1 || cfg.Window <= 0 || burst < 1 {
+		return nil, fmt.Errorf("invalid rate limit of %d requests per %s", cfg.Limit, cfg.Window)
+	}
+	if cfg.Backend == BackendRedis && redis == nil {
+		return nil, errors.New("rate limits kept in Redis need a Redis client")
+	}
+
+	prefix := "ratelimit:" + name + ":"
+	switch {
+	case cfg.Algorithm == AlgorithmTokenBucket && cfg.Backend == BackendMemory:
+		return NewMemoryTokenBucket(cfg.Limit, cfg.Window, burst), nil
+	case cfg.Algorithm == AlgorithmTokenBucket && cfg.Backend == BackendRedis:
+		return NewRedisTokenBucket(redis, prefix, cfg.Limit, cfg.Window, burst), nil
+	case cfg.Algorithm == AlgorithmSlidingWindow && cfg.Backend == BackendMemory:
+		return NewMemorySlidingWindow(cfg.Limit, cfg.Window), nil
+	case cfg.Algorithm == AlgorithmSlidingWindow && cfg.Backend == BackendRedis:
+		return NewRedisSlidingWindow(redis, prefix, cfg.Limit, cfg.Window), nil
+	default:
+		return nil, fmt.Errorf("unsupported rate limit algorithm %q or backend %q", cfg.Algorithm, cfg.Backend)
+	}
+}
@@ -0,0 +1,199 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// clock is a fake clock, at the start of a minute so fixed windows start
+// with it. Advancing it advances that of Redis too, expiring keys.
+type clock struct {
+	now    time.Time
+	server *miniredis.Miniredis
+}
+
+func newClock(server *miniredis.Miniredis) *clock {
+	return &clock{now: time.Date(2026, time.March, 10, 10, 0, 0, 0, time.UTC), server: server}
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	c.server.FastForward(d)
+}
+
+// newRedis returns a client of a Redis server run in process
+func newRedis(t *testing.T) (*database.Redis, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	port, err := strconv.Atoi(server.Port())
+	require.NoError(t, err)
+	log, err := logger.New(config.LoggerConfig{Level: "error", Format: "json", Output: "stdout"}, "ratelimit-test")
+	require.NoError(t, err)
+
+	redis, err := database.NewRedis(config.RedisConfig{Host: server.Host(), Port: port}, log)
+	require.NoError(t, err)
+	t.Cleanup(func() { redis.Close() })
+	return redis, server
+}
+
+// step is a request of a client after advancing the clock by advance,
+// and the result it should get
+type step struct {
+	advance    time.Duration
+	allowed    bool
+	remaining  int
+	retryAfter time.Duration
+}
+
+// backends creates a limiter of each backend on the clock
+type backends map[string]func(redis *database.Redis, clock *clock) Limiter
+
+// run takes the steps with the limiter of each backend, on a fresh clock
+// and Redis each
+func (b backends) run(t *testing.T, steps []step) {
+	for name, newLimiter := range b {
+		t.Run(name, func(t *testing.T) {
+			redis, server := newRedis(t)
+			clock := newClock(server)
+			limiter := newLimiter(redis, clock)
+
+			for i, s := range steps {
+				clock.Advance(s.advance)
+				result, err := limiter.Allow(context.Background(), "ip:192.0.2.1")
+				require.NoError(t, err)
+
+				assert.Equal(t, s.allowed, result.Allowed, "step %d", i+1)
+				assert.Equal(t, s.remaining, result.Remaining, "step %d", i+1)
+				assert.InDelta(t, float64(s.retryAfter), float64(result.RetryAfter), float64(time.Millisecond), "step %d", i+1)
+			}
+
+			// Other clients have limits of their own
+			result, err := limiter.Allow(context.Background(), "ip:192.0.2.2")
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+		})
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	// A token a second, up to 3 at once
+	backends{
+		"memory": func(_ *database.Redis, clock *clock) Limiter {
+			l := NewMemoryTokenBucket(60, time.Minute, 3)
+			l.now, l.lastSweep = clock.Now, clock.Now()
+			return l
+		},
+		"redis": func(redis *database.Redis, clock *clock) Limiter {
+			l := NewRedisTokenBucket(redis, "ratelimit:test:", 60, time.Minute, 3)
+			l.now = clock.Now
+			return l
+		},
+	}.run(t, []step{
+		// A burst empties the bucket
+		{allowed: true, remaining: 2},
+		{allowed: true, remaining: 1},
+		{allowed: true, remaining: 0},
+		{allowed: false, remaining: 0, retryAfter: time.Second},
+		// Half a token was refilled
+		{advance: 500 * time.Millisecond, allowed: false, remaining: 0, retryAfter: 500 * time.Millisecond},
+		{advance: 600 * time.Millisecond, allowed: true, remaining: 0},
+		// Refills stop once the bucket is full
+		{advance: time.Minute, allowed: true, remaining: 2},
+		{allowed: true, remaining: 1},
+		{allowed: true, remaining: 0},
+		{allowed: false, remaining: 0, retryAfter: time.Second},
+	})
+}
+
+func TestSlidingWindow(t *testing.T) {
+	// 4 requests a minute
+	backends{
+		"memory": func(_ *database.Redis, clock *clock) Limiter {
+			l := NewMemorySlidingWindow(4, time.Minute)
+			l.now, l.lastSweep = clock.Now, clock.Now()
+			return l
+		},
+		"redis": func(redis *database.Redis, clock *clock) Limiter {
+			l := NewRedisSlidingWindow(redis, "ratelimit:test:", 4, time.Minute)
+			l.now = clock.Now
+			return l
+		},
+	}.run(t, []step{
+		{allowed: true, remaining: 3},
+		{allowed: true, remaining: 2},
+		{allowed: true, remaining: 1},
+		{allowed: true, remaining: 0},
+		// Allowed again once the 4 requests weigh 3, a quarter into the
+		// next fixed window
+		{allowed: false, remaining: 0, retryAfter: 75 * time.Second},
+		// The window rolled over, the previous one still counting in full
+		{advance: time.Minute, allowed: false, remaining: 0, retryAfter: 15 * time.Second},
+		{advance: 15 * time.Second, allowed: true, remaining: 0},
+		// Halfway, the previous window counts for half
+		{advance: 15 * time.Second, allowed: true, remaining: 0},
+		{allowed: false, remaining: 0, retryAfter: 15 * time.Second},
+		// Windows further back don't count
+		{advance: 2 * time.Minute, allowed: true, remaining: 3},
+	})
+}
+
+func TestPerRouteByUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userID := uuid.New()
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		// Stands in for the auth middleware
+		if c.GetHeader("X-User") != "" {
+			c.Set(auth.ContextKeyUserID, userID)
+		}
+	})
+	key := PerRoute(ByUser)
+	handler := func(c *gin.Context) { c.String(http.StatusOK, key(c)) }
+	router.GET("/orders/:id", handler)
+	router.POST("/orders", handler)
+
+	for _, tc := range []struct {
+		name          string
+		method, path  string
+		authenticated bool
+		want          string
+	}{
+		{"anonymous by IP", http.MethodGet, "/orders/1", false, "GET /orders/:id:ip:192.0.2.1"},
+		{"same route, other parameters", http.MethodGet, "/orders/2", false, "GET /orders/:id:ip:192.0.2.1"},
+		{"other route", http.MethodPost, "/orders", false, "POST /orders:ip:192.0.2.1"},
+		{"authenticated by user", http.MethodGet, "/orders/1", true, "GET /orders/:id:user:" + userID.String()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.RemoteAddr = "192.0.2.1:40000"
+			if tc.authenticated {
+				req.Header.Set("X-User", "1")
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.want, rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+)
+
+// slidingWindow allows limit requests of each client in any window. The
+// requests of the last window are estimated from the counts of the current
+// fixed window and of the previous one, weighted by how much of it the last
+// window overlaps, which takes two counters per client rather than the time
+// of each request.
+type slidingWindow struct {
+	limit  int
+	window time.Duration
+	// now tells the time, which tests fake
+	now func() time.Time
+}
+
+// weight returns how much of the previous fixed window the last window
+// overlaps, elapsed into the current one
+func (w slidingWindow) weight(elapsed time.Duration) float64 {
+	return 1 - float64(elapsed)/float64(w.window)
+}
+
+// allows reports whether a request is allowed given the counts of the
+// fixed windows, elapsed into the current one
+func (w slidingWindow) allows(previous, current int, elapsed time.Duration) bool {
+	return float64(previous)*w.weight(elapsed)+float64(current)+1 <= float64(w.limit)
+}
+
+// result returns the result of a request given the counts of the fixed
+// windows, counting it if allowed, elapsed into the current one
+func (w slidingWindow) result(allowed bool, previous, current int, elapsed time.Duration) Result {
+	used := int(math.Ceil(float64(previous)*w.weight(elapsed))) + current
+	result := Result{
+		Allowed:   allowed,
+		Limit:     w.limit,
+		Remaining: max(w.limit-used, 0),
+		Reset:     w.window - elapsed,
+	}
+	if current > 0 {
+		result.Reset += w.window
+	}
+	if !allowed {
+		result.RetryAfter = w.retryAfter(previous, current, elapsed)
+	}
+	return result
+}
+
+// retryAfter returns how long until a request is allowed, as the requests
+// of the previous fixed window weigh less and less
+func (w slidingWindow) retryAfter(previous, current int, elapsed time.Duration) time.Duration {
+	free := float64(w.limit - 1)
+	if current <= w.limit-1 && previous > 0 {
+		// Within the current fixed window, once previous*weight+current
+		// drops to limit-1
+		overlap := 1 - (free-float64(current))/float64(previous)
+		return max(time.Duration(overlap*float64(w.window))-elapsed, 0)
+	}
+	// Within the next fixed window, where current is the previous count
+	overlap := 1 - free/float64(current)
+	return w.window - elapsed + time.Duration(overlap*float64(w.window))
+}
+
+// counts are the counts of the fixed windows of a client
+type counts struct {
+	start    time.Time
+	previous int
+	current  int
+}
+
+// MemorySlidingWindow is a sliding window limiter keeping the counts of
+// clients in memory, so each instance limits them on its own
+type MemorySlidingWindow struct {
+	slidingWindow
+
+	mu        sync.Mutex
+	counts    map[string]*counts
+	lastSweep time.Time
+}
+
+// NewMemorySlidingWindow creates a limiter allowing limit requests in any
+// window
+func NewMemorySlidingWindow(limit int, window time.Duration) *MemorySlidingWindow {
+	return &MemorySlidingWindow{
+		slidingWindow: slidingWindow{limit: limit, window: window, now: time.Now},
+		counts:        make(map[string]*counts),
+		lastSweep:     time.Now(),
+	}
+}
+
+// Allow implements Limiter
+func (l *MemorySlidingWindow) Allow(_ context.Context, key string) (Result, error) {
+	now := l.now()
+	start := now.Truncate(l.window)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(start)
+
+	c, ok := l.counts[key]
+	if !ok {
+		c = &counts{start: start}
+		l.counts[key] = c
+	}
+	if !c.start.Equal(start) {
+		if c.start.Add(l.window).Equal(start) {
+			c.previous = c.current
+		} else {
+			c.previous = 0
+		}
+		c.current = 0
+		c.start = start
+	}
+
+	elapsed := now.Sub(start)
+	allowed := l.allows(c.previous, c.current, elapsed)
+	if allowed {
+		c.current++
+	}
+	return l.result(allowed, c.previous, c.current, elapsed), nil
+}
+
+// sweep forgets, once per window at most, the counts of clients that made
+// no request in the current or previous fixed window
+func (l *MemorySlidingWindow) sweep(start time.Time) {
+	if start.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = start
+	for key, c := range l.counts {
+		if start.Sub(c.start) > l.window {
+			delete(l.counts, key)
+		}
+	}
+}
+
+// countRequest counts a request of a client in the current fixed window,
+// unless the client is over its limit. KEYS: count of the current fixed
+// window, count of the previous one. ARGV: limit, weight of the previous
+// count, TTL in milliseconds. Returns whether the request was counted and
+// the counts.
+var countRequest = database.NewScript("ratelimit_count_request", `
+local limit = tonumber(ARGV[1])
+local previous = tonumber(redis.call('GET', KEYS[2]) or '0')
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+if previous * tonumber(ARGV[2]) + current + 1 > limit then
+	return {0, previous, current}
+end
+current = redis.call('INCR', KEYS[1])
+if current == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return {1, previous, current}`)
+
+// RedisSlidingWindow is a sliding window limiter keeping the counts of
+// clients in Redis, shared by every instance
+type RedisSlidingWindow struct {
+	slidingWindow
+	redis  *database.Redis
+	prefix string
+}
+
+// NewRedisSlidingWindow creates a limiter allowing limit requests in any
+// window. Counts are keyed with prefix.
+func NewRedisSlidingWindow(redis *database.Redis, prefix string, limit int, window time.Duration) *RedisSlidingWindow {
+	return &RedisSlidingWindow{
+		slidingWindow: slidingWindow{limit: limit, window: window, now: time.Now},
+		redis:         redis,
+		prefix:        prefix,
+	}
+}
+
+// Allow implements Limiter
+func (l *RedisSlidingWindow) Allow(ctx context.Context, key string) (Result, error) {
+	now := l.now()
+	index := now.UnixMilli() / l.window.Milliseconds()
+	elapsed := now.Sub(time.UnixMilli(index * l.window.Milliseconds()))
+	keys := []string{
+		l.prefix + key + ":" + strconv.FormatInt(index, 10),
+		l.prefix + key + ":" + strconv.FormatInt(index-1, 10),
+	}
+
+	// Counts are needed for the next fixed window too, as its previous one
+	ttl := 2 * l.window.Milliseconds()
+	reply, err := l.redis.RunScript(ctx, countRequest, keys,
+		l.limit, strconv.FormatFloat(l.weight(elapsed), 'f', -1, 64), ttl).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to count rate limited request: %w", err)
+	}
+	if len(reply) != 3 {
+		return Result{}, fmt.Errorf("unexpected rate limit reply %v", reply)
+	}
+	return l.result(reply[0] == 1, int(reply[1]), int(reply[2]), elapsed), nil
+}
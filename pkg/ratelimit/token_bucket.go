@@ -0,0 +1,185 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+)
+
+// tokenBucket holds up to burst tokens for each client, refilled at limit
+// tokens per window. Each request takes a token, and is refused when there
+// is none left.
+type tokenBucket struct {
+	limit  int
+	burst  int
+	window time.Duration
+	// now tells the time, which tests fake
+	now func() time.Time
+}
+
+// refillRate returns the tokens added each second
+func (b tokenBucket) refillRate() float64 {
+	return float64(b.limit) / b.window.Seconds()
+}
+
+// refill returns the tokens of a bucket that held tokens elapsed ago
+func (b tokenBucket) refill(tokens float64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return tokens
+	}
+	return math.Min(float64(b.burst), tokens+elapsed.Seconds()*b.refillRate())
+}
+
+// result returns the result of a request that left the bucket with tokens
+func (b tokenBucket) result(allowed bool, tokens float64) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     b.burst,
+		Remaining: int(tokens),
+		Reset:     b.durationOf(float64(b.burst) - tokens),
+	}
+	if !allowed {
+		result.RetryAfter = b.durationOf(1 - tokens)
+	}
+	return result
+}
+
+// durationOf returns how long refilling tokens takes
+func (b tokenBucket) durationOf(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(tokens / b.refillRate() * float64(time.Second)))
+}
+
+// bucket is the bucket of a client, as of the time it was last taken from
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// MemoryTokenBucket is a token bucket limiter keeping the buckets of
+// clients in memory, so each instance limits them on its own
+type MemoryTokenBucket struct {
+	tokenBucket
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewMemoryTokenBucket creates a limiter allowing limit requests per
+// window on average, in bursts of up to burst
+func NewMemoryTokenBucket(limit int, window time.Duration, burst int) *MemoryTokenBucket {
+	return &MemoryTokenBucket{
+		tokenBucket: tokenBucket{limit: limit, burst: burst, window: window, now: time.Now},
+		buckets:     make(map[string]*bucket),
+		lastSweep:   time.Now(),
+	}
+}
+
+// Allow implements Limiter
+func (l *MemoryTokenBucket) Allow(_ context.Context, key string) (Result, error) {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), at: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refill(b.tokens, now.Sub(b.at))
+	b.at = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return l.result(allowed, b.tokens), nil
+}
+
+// sweep forgets, once per window at most, the buckets refilled since, as
+// new ones are full too
+func (l *MemoryTokenBucket) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if l.refill(b.tokens, now.Sub(b.at)) >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// takeToken refills the bucket of a client and takes a token from it, if
+// any. KEYS: bucket. ARGV: time in milliseconds, tokens added each
+// millisecond, burst, TTL in milliseconds. Returns whether a token was
+// taken and the tokens left, as a string since Redis truncates numbers
+// returned by scripts.
+var takeToken = database.NewScript("ratelimit_take_token", `
+local now = tonumber(ARGV[1])
+local burst = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(state[1]) or burst
+local at = tonumber(state[2]) or now
+if now > at then
+	tokens = math.min(burst, tokens + (now - at) * tonumber(ARGV[2]))
+	at = now
+end
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', tostring(at))
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return {allowed, tostring(tokens)}`)
+
+// RedisTokenBucket is a token bucket limiter keeping the buckets of
+// clients in Redis, shared by every instance
+type RedisTokenBucket struct {
+	tokenBucket
+	redis  *database.Redis
+	prefix string
+}
+
+// NewRedisTokenBucket creates a limiter allowing limit requests per window
+// on average, in bursts of up to burst. Buckets are keyed with prefix.
+func NewRedisTokenBucket(redis *database.Redis, prefix string, limit int, window time.Duration, burst int) *RedisTokenBucket {
+	return &RedisTokenBucket{
+		tokenBucket: tokenBucket{limit: limit, burst: burst, window: window, now: time.Now},
+		redis:       redis,
+		prefix:      prefix,
+	}
+}
+
+// Allow implements Limiter
+func (l *RedisTokenBucket) Allow(ctx context.Context, key string) (Result, error) {
+	// Buckets expire once refilled, as new ones are full too
+	ttl := l.durationOf(float64(l.burst)).Milliseconds() + 1
+	reply, err := l.redis.RunScript(ctx, takeToken, []string{l.prefix + key},
+		l.now().UnixMilli(), l.refillRate()/1000, l.burst, ttl).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	if len(reply) != 2 {
+		return Result{}, fmt.Errorf("unexpected rate limit reply %v", reply)
+	}
+
+	allowed, _ := reply[0].(int64)
+	left, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(left, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("unexpected rate limit tokens %q: %w", left, err)
+	}
+	return l.result(allowed == 1, tokens), nil
+}
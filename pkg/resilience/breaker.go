@@ -0,0 +1,133 @@
+package resilience
+
+import (
+	"sync"
+	"time"
+)
+
+// The states of a circuit breaker, as exported in metrics
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half_open"
+)
+
+// breaker opens the circuit to a dependency after threshold failures in a
+// row, failing calls at once instead of waiting on a dependency that is
+// down. Once openTimeout passed, one call is let through to probe it: the
+// circuit closes again when it succeeds and stays open when it fails.
+type breaker struct {
+	threshold   int
+	openTimeout time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// newBreaker creates a closed breaker. A threshold of 0 never opens it.
+func newBreaker(threshold int, openTimeout time.Duration) *breaker {
+	return &breaker{threshold: threshold, openTimeout: openTimeout, state: StateClosed}
+}
+
+// allow reports whether a call may be made, and takes the probe when the
+// circuit is half open
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return false
+		}
+		b.state = StateHalfOpen
+		b.probing = true
+		return true
+	case StateHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record records the outcome of a call, returning the state of the circuit
+// and whether it changed
+func (b *breaker) record(failed bool) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	previous := b.state
+	if !failed {
+		b.failures = 0
+		b.state = StateClosed
+	} else {
+		b.failures++
+		if b.state == StateHalfOpen || (b.threshold > 0 && b.failures >= b.threshold) {
+			b.state = StateOpen
+			b.openedAt = time.Now()
+		}
+	}
+	b.probing = false
+	return b.state, b.state != previous
+}
+
+// release gives back the probe of a call whose outcome tells nothing of
+// the dependency, such as one its caller gave up on
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State returns the state of the circuit
+func (b *breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// budget caps retries at a fraction of calls, so retries don't multiply
+// the load on a dependency that is already failing. Each call deposits
+// ratio of a retry, up to a reserve that lets a few retries through while
+// calls are rare; each retry withdraws one.
+type budget struct {
+	ratio   float64
+	reserve float64
+
+	mu      sync.Mutex
+	balance float64
+}
+
+// minRetries is the reserve of retries of a budget, allowed whatever the
+// ratio of calls they make up
+const minRetries = 10
+
+// newBudget creates a budget allowing retries of ratio of calls
+func newBudget(ratio float64) *budget {
+	return &budget{ratio: ratio, reserve: minRetries, balance: minRetries}
+}
+
+// deposit records a call
+func (b *budget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.balance = min(b.balance+b.ratio, b.reserve)
+}
+
+// withdraw reports whether a retry is within the budget, recording it if so
+func (b *budget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.balance < 1 {
+		return false
+	}
+	b.balance--
+	return true
+}
@@ -0,0 +1,147 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// idempotencyKeyHeader is the header of requests that are safe to send
+// again whatever their method, as the dependency recognizes them
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// HTTPClient returns an HTTP client calling the dependency through
+// transport, or http.DefaultTransport when nil. Requests failing with a
+// network error, a 5xx or a 429 are sent again when that is safe: when their
+// method is idempotent, or they carry an Idempotency-Key or one of
+// idempotencyHeaders, and their body can be read again. The response of
+// the last attempt is returned.
+func (d *Dependency) HTTPClient(transport http.RoundTripper, idempotencyHeaders ...string) *http.Client {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &http.Client{Transport: &roundTripper{
+		dependency:         d,
+		base:               transport,
+		idempotencyHeaders: append([]string{idempotencyKeyHeader}, idempotencyHeaders...),
+	}}
+}
+
+// roundTripper sends requests to a dependency with its policy
+type roundTripper struct {
+	dependency         *Dependency
+	base               http.RoundTripper
+	idempotencyHeaders []string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	d := t.dependency
+	ctx := req.Context()
+	retryable := t.retryable(req)
+
+	d.budget.deposit()
+	for attempt := 1; ; attempt++ {
+		if !d.breaker.allow() {
+			d.calls.Inc(d.name, outcomeRejected)
+			closeBody(req)
+			return nil, fmt.Errorf("%s: %w", d.name, ErrCircuitOpen)
+		}
+
+		attemptReq := req
+		if attempt > 1 {
+			var err error
+			if attemptReq, err = rewind(req); err != nil {
+				return nil, err
+			}
+		}
+
+		attemptCtx, cancel := d.attemptContext(ctx)
+		start := time.Now()
+		resp, err := t.base.RoundTrip(attemptReq.WithContext(attemptCtx))
+
+		failed := err != nil || resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+		var refusal error
+		if resp != nil && resp.StatusCode >= http.StatusBadRequest {
+			refusal = fmt.Errorf("status %d", resp.StatusCode)
+		}
+		outcome := d.outcomeOf(ctx, refusal, failed)
+		d.record(ctx, outcome, start)
+
+		if outcome == outcomeFailure && retryable && d.mayRetry(ctx, attempt) {
+			if resp != nil {
+				// The connection is reused once the body was read
+				_, _ = io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+			cancel()
+			if !d.backoff(ctx, attempt) {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		// The attempt's context must outlive the response, whose body is
+		// read after RoundTrip returns
+		resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+		return resp, nil
+	}
+}
+
+// retryable reports whether req can be sent again
+func (t *roundTripper) retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	for _, header := range t.idempotencyHeaders {
+		if req.Header.Get(header) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// rewind returns a copy of req to send again, with its body read again
+func rewind(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body again: %w", err)
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// closeBody closes the body of a request that won't be sent, as the
+// transport would have
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+// cancelOnClose cancels the context of an attempt once the body of its
+// response is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,240 @@
+// Package resilience makes calls to the dependencies of a service, such
+// as other services, carriers and payment providers, within a timeout,
+// retries those that fail within a budget, and stops calling a dependency
+// that keeps failing until it recovers, recording the outcome of each call
+// in metrics labelled with the dependency.
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+	"github.com/kaanevranportfolio/Commercium/pkg/retry"
+)
+
+// ErrCircuitOpen is returned for calls to a dependency whose circuit is
+// open, without calling it
+var ErrCircuitOpen = errors.New("circuit open")
+
+// The outcomes of calls, as recorded in metrics
+const (
+	outcomeSuccess = "success"
+	// outcomeRefused is a call the dependency answered, refusing it, such as
+	// with a 4xx response; it doesn't count against the dependency
+	outcomeRefused   = "refused"
+	outcomeFailure   = "failure"
+	outcomeCancelled = "cancelled"
+	outcomeRejected  = "circuit_open"
+)
+
+// stateValues are the values of the states of circuits in metrics
+var stateValues = map[string]float64{StateClosed: 0, StateHalfOpen: 1, StateOpen: 2}
+
+// Dependency makes the calls to a dependency, with the policy configured
+// for it
+type Dependency struct {
+	name    string
+	timeout time.Duration
+	retry   retry.Policy
+	budget  *budget
+	breaker *breaker
+
+	calls    metrics.Counter
+	duration metrics.Histogram
+	retries  metrics.Counter
+	state    metrics.Gauge
+}
+
+// New creates the dependency named name, as metrics label it, called with
+// policy. A nil registry records nothing.
+func New(name string, policy config.ResiliencePolicyConfig, registry metrics.Registry) *Dependency {
+	d := &Dependency{
+		name:    name,
+		timeout: policy.Timeout,
+		retry: retry.Policy{
+			MaxAttempts: max(policy.MaxAttempts, 1),
+			BackoffMin:  policy.BackoffMin,
+			BackoffMax:  policy.BackoffMax,
+			Jitter:      policy.Jitter,
+		},
+		budget:   newBudget(policy.RetryBudget),
+		breaker:  newBreaker(policy.FailureThreshold, policy.OpenTimeout),
+		calls:    noop{},
+		duration: noop{},
+		retries:  noop{},
+		state:    noop{},
+	}
+	if registry != nil {
+		d.calls = registry.NewCounter("outbound_calls", "Calls to dependencies, by outcome", "dependency", "outcome")
+		d.duration = registry.NewHistogram("outbound_call_duration_seconds", "Duration of the attempts of calls to dependencies", "dependency")
+		d.retries = registry.NewCounter("outbound_retries", "Attempts of calls to dependencies after the first", "dependency")
+		d.state = registry.NewGauge("circuit_breaker_state", "State of the circuit to dependencies: 0 closed, 1 half open, 2 open", "dependency")
+		d.state.Set(stateValues[StateClosed], name)
+	}
+	return d
+}
+
+// Name returns the name of the dependency
+func (d *Dependency) Name() string {
+	return d.name
+}
+
+// State returns the state of the circuit to the dependency
+func (d *Dependency) State() string {
+	return d.breaker.State()
+}
+
+// Do calls the dependency with call, retrying it while it fails unless its
+// error is Permanent. Each attempt gets a context bounded by the timeout
+// of the policy.
+func (d *Dependency) Do(ctx context.Context, call func(ctx context.Context) error) error {
+	d.budget.deposit()
+	for attempt := 1; ; attempt++ {
+		if !d.breaker.allow() {
+			d.calls.Inc(d.name, outcomeRejected)
+			return fmt.Errorf("%s: %w", d.name, ErrCircuitOpen)
+		}
+
+		attemptCtx, cancel := d.attemptContext(ctx)
+		start := time.Now()
+		err := call(attemptCtx)
+		cancel()
+
+		outcome := d.outcomeOf(ctx, err, err != nil && !IsPermanent(err))
+		d.record(ctx, outcome, start)
+		if outcome != outcomeFailure || !d.retryAfter(ctx, attempt) {
+			return err
+		}
+	}
+}
+
+// Do calls dependency with call, as Dependency.Do does, returning the
+// result of the attempt that succeeded
+func Do[T any](ctx context.Context, dependency *Dependency, call func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := dependency.Do(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = call(ctx)
+		return err
+	})
+	return result, err
+}
+
+// attemptContext returns the context of an attempt, bounded by the
+// timeout of the policy
+func (d *Dependency) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if d.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d.timeout)
+}
+
+// outcomeOf returns the outcome of an attempt that failed with err, if
+// any, counting against the dependency when failed. An attempt its caller
+// gave up on tells nothing of the dependency.
+func (d *Dependency) outcomeOf(ctx context.Context, err error, failed bool) string {
+	switch {
+	case ctx.Err() != nil:
+		return outcomeCancelled
+	case failed:
+		return outcomeFailure
+	case err != nil:
+		return outcomeRefused
+	default:
+		return outcomeSuccess
+	}
+}
+
+// record records the outcome of an attempt started at start, opening or
+// closing the circuit
+func (d *Dependency) record(ctx context.Context, outcome string, start time.Time) {
+	d.calls.Inc(d.name, outcome)
+	d.duration.Observe(ctx, time.Since(start).Seconds(), d.name)
+
+	if outcome == outcomeCancelled {
+		d.breaker.release()
+		return
+	}
+	state, changed := d.breaker.record(outcome == outcomeFailure)
+	if !changed {
+		return
+	}
+	d.state.Set(stateValues[state], d.name)
+	if state == StateOpen {
+		logger.FromContext(ctx).Warn("Circuit opened, calls failing fast", "dependency", d.name, "open_timeout", d.breaker.openTimeout)
+	} else {
+		logger.FromContext(ctx).Info("Circuit closed", "dependency", d.name)
+	}
+}
+
+// retryAfter waits for the backoff after the attempt-th failed attempt and
+// reports whether to attempt the call again
+func (d *Dependency) retryAfter(ctx context.Context, attempt int) bool {
+	return d.mayRetry(ctx, attempt) && d.backoff(ctx, attempt)
+}
+
+// mayRetry reports whether to attempt the call again after the attempt-th
+// failed attempt: whether the policy allows another attempt, the budget
+// another retry, and ctx isn't done. The retry is withdrawn from the budget.
+func (d *Dependency) mayRetry(ctx context.Context, attempt int) bool {
+	if !d.retry.Retryable(attempt) || ctx.Err() != nil || !d.budget.withdraw() {
+		return false
+	}
+	d.retries.Inc(d.name)
+	return true
+}
+
+// backoff waits for the backoff after the attempt-th failed attempt,
+// reporting false when ctx is done first
+func (d *Dependency) backoff(ctx context.Context, attempt int) bool {
+	timer := time.NewTimer(d.retry.Backoff(attempt))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// permanentError is an error retrying won't fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks err as an error retrying won't fix, such as the
+// dependency refusing invalid data. Such errors don't count against the
+// dependency either.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// noop records nothing, for dependencies created without a registry
+type noop struct{}
+
+func (noop) Inc(...string)                               {}
+func (noop) Add(float64, ...string)                      {}
+func (noop) Observe(context.Context, float64, ...string) {}
+func (noop) Set(float64, ...string)                      {}
@@ -0,0 +1,93 @@
+// Package retry describes how the work a handler fails is retried: a few
+// attempts in place with a backoff growing exponentially, then, optionally,
+// further attempts after each delay tier in turn, e.g. by parking messages
+// on retry topics or queues, before the work is given up on.
+package retry
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// Policy is the retry policy of a handler
+type Policy struct {
+	// MaxAttempts is how often work is attempted in place, including the
+	// first attempt
+	MaxAttempts int
+	// The backoff before each further attempt doubles from BackoffMin up to
+	// BackoffMax
+	BackoffMin time.Duration
+	BackoffMax time.Duration
+	// Jitter is the fraction, between 0 and 1, of a backoff that is
+	// randomized, so failing consumers don't retry in lockstep
+	Jitter float64
+	// DelayTiers are the delays after which work that still fails is
+	// attempted again, MaxAttempts times each
+	DelayTiers []time.Duration
+}
+
+// FromConfig creates the policy of a handler from its configuration. Fields
+// that aren't configured are taken from fallback, usually the policy derived
+// from the retry settings of the broker.
+func FromConfig(cfg config.RetryPolicyConfig, fallback Policy) Policy {
+	policy := fallback
+	if cfg.MaxAttempts > 0 {
+		policy.MaxAttempts = cfg.MaxAttempts
+	}
+	if cfg.BackoffMin > 0 {
+		policy.BackoffMin = cfg.BackoffMin
+	}
+	if cfg.BackoffMax > 0 {
+		policy.BackoffMax = cfg.BackoffMax
+	}
+	if cfg.Jitter > 0 {
+		policy.Jitter = cfg.Jitter
+	}
+	if len(cfg.DelayTiers) > 0 {
+		policy.DelayTiers = cfg.DelayTiers
+	}
+	return policy
+}
+
+// Retryable reports whether work that failed its attempt-th attempt in
+// place, counting from 1, may be attempted again in place
+func (p Policy) Retryable(attempt int) bool {
+	return attempt < p.MaxAttempts
+}
+
+// Backoff returns how long to wait after the attempt-th failed attempt,
+// counting from 1, before the next one
+func (p Policy) Backoff(attempt int) time.Duration {
+	backoff := p.BackoffMin
+	for i := 1; i < attempt && backoff < p.BackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > p.BackoffMax {
+		backoff = p.BackoffMax
+	}
+	return p.jitter(backoff)
+}
+
+// Delay returns the delay of a tier, counting from 1, and whether the
+// policy has that tier
+func (p Policy) Delay(tier int) (time.Duration, bool) {
+	if tier < 1 || tier > len(p.DelayTiers) {
+		return 0, false
+	}
+	return p.DelayTiers[tier-1], true
+}
+
+// jitter randomizes the Jitter fraction of d
+func (p Policy) jitter(d time.Duration) time.Duration {
+	if p.Jitter <= 0 || d <= 0 {
+		return d
+	}
+	jitter := p.Jitter
+	if jitter > 1 {
+		jitter = 1
+	}
+	spread := time.Duration(float64(d) * jitter)
+	return d - spread + time.Duration(rand.Int63n(int64(spread)+1))
+}
@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// at returns a time of 2026, in UTC, or of another year given as an
+// offset, e.g. at(1, time.January, 1, 0, 0) for 2027
+func at(years int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(2026+years, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		// Minutes and hours
+		{"every minute", "* * * * *", at(0, time.March, 10, 10, 7).Add(30 * time.Second), at(0, time.March, 10, 10, 8)},
+		{"step", "*/15 * * * *", at(0, time.March, 10, 10, 7), at(0, time.March, 10, 10, 15)},
+		{"step from a value", "5/20 * * * *", at(0, time.March, 10, 10, 45), at(0, time.March, 10, 11, 5)},
+		{"stepped range", "0-30/10 9 * * *", at(0, time.March, 10, 9, 25), at(0, time.March, 10, 9, 30)},
+		{"stepped range ends", "0-30/10 9 * * *", at(0, time.March, 10, 9, 30), at(0, time.March, 11, 9, 0)},
+		{"list", "5,35 * * * *", at(0, time.March, 10, 10, 35), at(0, time.March, 10, 11, 5)},
+		{"list of ranges", "0 1-2,22-23 * * *", at(0, time.March, 10, 2, 0), at(0, time.March, 10, 22, 0)},
+		{"range of weekdays", "0 9-17 * * 1-5", at(0, time.March, 13, 17, 0), at(0, time.March, 16, 9, 0)},
+
+		// Days of month and of week: either matching when both are
+		// restricted, both otherwise
+		{"day of month or of week", "0 0 13 * 5", at(0, time.March, 1, 0, 0), at(0, time.March, 6, 0, 0)},
+		{"day of month or of week, by day of month", "0 0 13 * 5", at(0, time.March, 7, 0, 0), at(0, time.March, 13, 0, 0)},
+		{"day of month or of week, by day of week", "0 0 1 * 1", at(0, time.March, 24, 0, 0), at(0, time.March, 30, 0, 0)},
+		{"day of week only", "0 0 * * 1", at(0, time.March, 1, 0, 0), at(0, time.March, 2, 0, 0)},
+		{"day of month only", "0 0 13 * *", at(0, time.March, 1, 0, 0), at(0, time.March, 13, 0, 0)},
+		{"stepped day of month and day of week", "0 0 */2 * 1", at(0, time.March, 1, 0, 0), at(0, time.March, 9, 0, 0)},
+		{"day of month and stepped day of week", "0 0 1 * */2", at(0, time.March, 1, 0, 0), at(0, time.August, 1, 0, 0)},
+		{"Sunday as 7", "0 0 * * 7", at(0, time.March, 2, 0, 0), at(0, time.March, 8, 0, 0)},
+
+		// Months and years
+		{"day missing from months", "30 23 31 * *", at(0, time.March, 31, 23, 30), at(0, time.May, 31, 23, 30)},
+		{"stepped month", "0 0 1 */3 *", at(0, time.October, 1, 0, 0), at(1, time.January, 1, 0, 0)},
+		{"next year", "0 0 1 1 *", at(0, time.June, 15, 12, 0), at(1, time.January, 1, 0, 0)},
+		{"last minute of the year", "59 23 31 12 *", at(0, time.December, 31, 23, 59), at(1, time.December, 31, 23, 59)},
+		{"across new year", "*/30 * * * *", at(0, time.December, 31, 23, 45), at(1, time.January, 1, 0, 0)},
+		{"leap day", "0 12 29 2 *", at(0, time.March, 1, 0, 0), at(2, time.February, 29, 12, 0)},
+		{"never", "0 0 30 2 *", at(0, time.January, 1, 0, 0), time.Time{}},
+
+		// Descriptors
+		{"hourly", "@hourly", at(0, time.March, 10, 10, 7), at(0, time.March, 10, 11, 0)},
+		{"weekly", "@weekly", at(0, time.March, 7, 12, 0), at(0, time.March, 8, 0, 0)},
+		{"monthly", "@monthly", at(0, time.December, 15, 0, 0), at(1, time.January, 1, 0, 0)},
+		{"every", "@every 15m", at(0, time.March, 10, 10, 7), at(0, time.March, 10, 10, 15)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, schedule.Next(tt.after))
+		})
+	}
+}
+
+func TestCronParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-b * * * *",
+		"@every -1m",
+		"@every soon",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := Parse(expr)
+			assert.Error(t, err)
+		})
+	}
+}
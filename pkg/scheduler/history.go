@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// historyRetention is how long the runs of jobs are kept
+const historyRetention = 30 * 24 * time.Hour
+
+// Run is a run of a job
+type Run struct {
+	ID          uuid.UUID  `db:"id"`
+	Service     string     `db:"service"`
+	Job         string     `db:"job"`
+	ScheduledAt time.Time  `db:"scheduled_at"`
+	Status      string     `db:"status"`
+	StartedAt   time.Time  `db:"started_at"`
+	FinishedAt  *time.Time `db:"finished_at"`
+	Error       *string    `db:"error"`
+}
+
+// History records the runs of jobs
+type History interface {
+	// Start records a run starting, unless a run of the job for the same
+	// scheduled time was, returning whether it was recorded
+	Start(ctx context.Context, run *Run) (bool, error)
+	// Finish records the outcome of a run
+	Finish(ctx context.Context, run *Run) error
+}
+
+// postgresHistory records the runs of jobs in PostgreSQL
+type postgresHistory struct {
+	db     *database.DB
+	logger *logger.Logger
+}
+
+// NewHistory creates a history recording the runs of jobs in PostgreSQL,
+// for 30 days
+func NewHistory(db *database.DB, logger *logger.Logger) History {
+	return &postgresHistory{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Start implements History
+func (h *postgresHistory) Start(ctx context.Context, run *Run) (bool, error) {
+	result, err := h.db.NamedExecContext(ctx, `
+		INSERT INTO job_runs (id, service, job, scheduled_at, status, started_at)
+		VALUES (:id, :service, :job, :scheduled_at, :status, :started_at)
+		ON CONFLICT (service, job, scheduled_at) DO NOTHING`, run)
+	if err != nil {
+		return false, fmt.Errorf("failed to record job run: %w", err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record job run: %w", err)
+	}
+	return inserted == 1, nil
+}
+
+// Finish implements History. Runs of the job older than the retention are
+// deleted along.
+func (h *postgresHistory) Finish(ctx context.Context, run *Run) error {
+	_, err := h.db.NamedExecContext(ctx, `
+		UPDATE job_runs SET status = :status, finished_at = :finished_at, error = :error
+		WHERE id = :id`, run)
+	if err != nil {
+		return fmt.Errorf("failed to record job run: %w", err)
+	}
+
+	_, err = h.db.ExecContext(ctx, `
+		DELETE FROM job_runs
+		WHERE service = $1 AND job = $2 AND scheduled_at < $3`,
+		run.Service, run.Job, run.ScheduledAt.Add(-historyRetention))
+	if err != nil {
+		h.logger.Warn("Failed to delete old job runs", "error", err, "job", run.Job)
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+// Package scheduler runs the background jobs of a service on cron
+// schedules. Each run of a job happens on one replica: replicas take a lock
+// on the job in Redis for the run and claim its scheduled time in the run
+// history, so one whose clock lags doesn't run it again. Runs that panic are
+// recovered from, and recorded as failed, in the history and in metrics.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+)
+
+// The statuses of runs
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	// StatusSkipped is a run another replica made, in metrics only
+	StatusSkipped = "skipped"
+)
+
+// lockTTL is how long the lock on a job outlives a replica that died
+// running it; it is extended while the run goes on
+const lockTTL = time.Minute
+
+// Func is the work of a job
+type Func func(ctx context.Context) error
+
+// Locker runs work while holding a lock, so it runs on one replica at a time
+type Locker interface {
+	WithLock(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) error) error
+}
+
+// Option configures a job
+type Option func(*job)
+
+// Timeout cancels the runs of a job that take longer than timeout
+func Timeout(timeout time.Duration) Option {
+	return func(j *job) {
+		j.timeout = timeout
+	}
+}
+
+// AtStart runs a job when the scheduler starts too, on every replica, for
+// work a fresh deployment needs right away
+func AtStart() Option {
+	return func(j *job) {
+		j.atStart = true
+	}
+}
+
+// job is a job registered with a scheduler
+type job struct {
+	name     string
+	schedule Schedule
+	run      Func
+	timeout  time.Duration
+	atStart  bool
+}
+
+// Scheduler runs the jobs of a service on their schedules
+type Scheduler struct {
+	service string
+	locker  Locker
+	history History
+	logger  *logger.Logger
+	jobs    []*job
+
+	runs        metrics.Counter
+	duration    metrics.Histogram
+	lastSuccess metrics.Gauge
+}
+
+// New creates a scheduler for the jobs of service. Without a locker, runs
+// of a job on several replicas may overlap; without a history, replicas
+// run each job on their own. registry may be nil.
+func New(service string, locker Locker, history History, registry metrics.Registry, logger *logger.Logger) *Scheduler {
+	s := &Scheduler{
+		service:     service,
+		locker:      locker,
+		history:     history,
+		logger:      logger,
+		runs:        noop{},
+		duration:    noop{},
+		lastSuccess: noop{},
+	}
+	if registry != nil {
+		s.runs = registry.NewCounter("scheduled_job_runs", "Runs of scheduled jobs, by status", "job", "status")
+		s.duration = registry.NewHistogram("scheduled_job_duration_seconds", "Duration of the runs of scheduled jobs", "job")
+		s.lastSuccess = registry.NewGauge("scheduled_job_last_success_timestamp_seconds", "Time the last successful run of scheduled jobs finished", "job")
+	}
+	return s
+}
+
+// Register schedules run as the job name, on spec, a cron expression as
+// Parse reads it
+func (s *Scheduler) Register(name, spec string, run Func, opts ...Option) error {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule of job %s: %w", name, err)
+	}
+	s.Schedule(name, schedule, run, opts...)
+	return nil
+}
+
+// Schedule schedules run as the job name on schedule
+func (s *Scheduler) Schedule(name string, schedule Schedule, run Func, opts ...Option) {
+	j := &job{name: name, schedule: schedule, run: run}
+	for _, opt := range opts {
+		opt(j)
+	}
+	s.jobs = append(s.jobs, j)
+}
+
+// Run runs the jobs on their schedules, in UTC, until ctx is cancelled,
+// then waits for the runs in progress to stop
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, j)
+		}()
+	}
+	wg.Wait()
+}
+
+// loop runs j at each time of its schedule until ctx is cancelled. Times
+// missed while a run took longer than the schedule allows are skipped.
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	if j.atStart {
+		s.run(ctx, j, time.Now().UTC().Truncate(time.Second))
+	}
+
+	for {
+		next := j.schedule.Next(time.Now().UTC())
+		if next.IsZero() {
+			s.logger.Error("Scheduled job never runs again", "job", j.name)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.run(ctx, j, next)
+	}
+}
+
+// run runs j for its scheduled time, on one replica
+func (s *Scheduler) run(ctx context.Context, j *job, scheduledAt time.Time) {
+	if s.locker == nil {
+		s.claim(ctx, j, scheduledAt)
+		return
+	}
+
+	err := s.locker.WithLock(ctx, "scheduler:"+s.service+":"+j.name, lockTTL, func(ctx context.Context) error {
+		s.claim(ctx, j, scheduledAt)
+		return nil
+	})
+	if errors.Is(err, database.ErrLockHeld) {
+		s.runs.Inc(j.name, StatusSkipped)
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to lock scheduled job, run skipped", "error", err, "job", j.name)
+	}
+}
+
+// claim runs j for its scheduled time unless another replica already did,
+// recording the run in the history
+func (s *Scheduler) claim(ctx context.Context, j *job, scheduledAt time.Time) {
+	run := &Run{
+		ID:          uuid.New(),
+		Service:     s.service,
+		Job:         j.name,
+		ScheduledAt: scheduledAt,
+		Status:      StatusRunning,
+		StartedAt:   time.Now().UTC(),
+	}
+
+	if s.history != nil {
+		claimed, err := s.history.Start(ctx, run)
+		if err != nil {
+			// The history is no reason not to do the work
+			s.logger.Error("Failed to record scheduled job run", "error", err, "job", j.name)
+		} else if !claimed {
+			s.runs.Inc(j.name, StatusSkipped)
+			return
+		}
+	}
+
+	err := s.execute(ctx, j)
+
+	finishedAt := time.Now().UTC()
+	run.FinishedAt = &finishedAt
+	run.Status = StatusSucceeded
+	if err != nil {
+		run.Status = StatusFailed
+		message := err.Error()
+		run.Error = &message
+	}
+
+	s.runs.Inc(j.name, run.Status)
+	s.duration.Observe(ctx, finishedAt.Sub(run.StartedAt).Seconds(), j.name)
+	if err != nil {
+		s.logger.Error("Scheduled job failed", "error", err, "job", j.name)
+	} else {
+		s.lastSuccess.Set(float64(finishedAt.Unix()), j.name)
+	}
+
+	if s.history != nil {
+		if err := s.history.Finish(context.WithoutCancel(ctx), run); err != nil {
+			s.logger.Error("Failed to record scheduled job run", "error", err, "job", j.name)
+		}
+	}
+}
+
+// execute runs the work of j within its timeout, recovering from panics
+func (s *Scheduler) execute(ctx context.Context, j *job) (err error) {
+	if j.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.logger.Desugar().WithOptions(zap.AddStacktrace(zapcore.ErrorLevel)).
+				Error("Panic recovered", zap.Any("panic", recovered), zap.String("job", j.name))
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return j.run(ctx)
+}
+
+// noop records nothing, for schedulers created without a registry
+type noop struct{}
+
+func (noop) Inc(...string)                               {}
+func (noop) Add(float64, ...string)                      {}
+func (noop) Observe(context.Context, float64, ...string) {}
+func (noop) Set(float64, ...string)                      {}
@@ -0,0 +1,154 @@
+// Package schemaregistry is a client of the schema registry REST API, in
+// the dialect of the Confluent Schema Registry, for Protobuf schemas
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+)
+
+// contentType is the content type of schema registry requests
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+// errorSubjectNotFound is the registry's error code for unknown subjects
+const errorSubjectNotFound = 40401
+
+// ErrIncompatible is returned when a schema is incompatible with the
+// versions of its subject registered
+var ErrIncompatible = errors.New("schema is incompatible")
+
+// Client calls a schema registry
+type Client struct {
+	baseURL    string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+// NewClient creates a new schema registry client
+func NewClient(cfg config.SchemaRegistryConfig) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(cfg.URL, "/"),
+		username:   cfg.Username,
+		password:   cfg.Password,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// NewRegistry returns a client of the schema registry configured, or nil
+// when none is
+func NewRegistry(cfg config.SchemaRegistryConfig) events.Registry {
+	if cfg.URL == "" {
+		return nil
+	}
+	return NewClient(cfg)
+}
+
+// schemaRequest is a Protobuf schema sent to the registry
+type schemaRequest struct {
+	SchemaType string `json:"schemaType"`
+	Schema     string `json:"schema"`
+}
+
+// registryError is the body of the registry's error responses
+type registryError struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+// Register registers a Protobuf schema as the latest version of a subject
+// and returns its ID. Registering a schema already registered returns the ID
+// it was given. The registry refuses schemas incompatible with the versions
+// registered, as the compatibility level of the subject defines.
+func (c *Client) Register(ctx context.Context, subject, definition string) (int, error) {
+	var result struct {
+		ID int `json:"id"`
+	}
+
+	status, regErr, err := c.post(ctx, "/subjects/"+url.PathEscape(subject)+"/versions", definition, &result)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case status == http.StatusConflict:
+		return 0, fmt.Errorf("%w: %s", ErrIncompatible, regErr.Message)
+	case regErr != nil:
+		return 0, fmt.Errorf("schema registry returned status %d: %s", status, regErr.Message)
+	}
+
+	return result.ID, nil
+}
+
+// CheckCompatibility checks a Protobuf schema against the latest version of
+// a subject registered. A subject without versions is compatible with any
+// schema.
+func (c *Client) CheckCompatibility(ctx context.Context, subject, definition string) error {
+	var result struct {
+		IsCompatible bool     `json:"is_compatible"`
+		Messages     []string `json:"messages"`
+	}
+
+	path := "/compatibility/subjects/" + url.PathEscape(subject) + "/versions/latest?verbose=true"
+	status, regErr, err := c.post(ctx, path, definition, &result)
+	if err != nil {
+		return err
+	}
+	if regErr != nil {
+		if regErr.ErrorCode == errorSubjectNotFound {
+			return nil
+		}
+		return fmt.Errorf("schema registry returned status %d: %s", status, regErr.Message)
+	}
+
+	if !result.IsCompatible {
+		return fmt.Errorf("%w: %s", ErrIncompatible, strings.Join(result.Messages, "; "))
+	}
+	return nil
+}
+
+// post sends a schema to the registry and decodes the response into result.
+// Error responses are returned as a registryError along with their status.
+func (c *Client) post(ctx context.Context, path, definition string, result interface{}) (int, *registryError, error) {
+	body, err := json.Marshal(&schemaRequest{SchemaType: "PROTOBUF", Schema: definition})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create schema registry request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	httpReq.Header.Set("Accept", contentType)
+	if c.username != "" {
+		httpReq.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to call schema registry: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		regErr := &registryError{}
+		if err := json.NewDecoder(resp.Body).Decode(regErr); err != nil || regErr.Message == "" {
+			regErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return resp.StatusCode, regErr, nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("failed to decode schema registry response: %w", err)
+	}
+	return resp.StatusCode, nil, nil
+}
@@ -0,0 +1,89 @@
+// Package seed fills a database with the data an environment needs to be
+// usable: an admin user, a demo catalog to click through in development, or
+// the fixtures tests rely on. Seed sets are idempotent, so they are safe to
+// run on every start.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// Set is a named group of seed data. Run must be idempotent: rows that
+// already exist are left alone.
+type Set struct {
+	Name        string
+	Description string
+	// Environments are the environments the set may be run in
+	Environments []string
+	Run          func(ctx context.Context, tx *sqlx.Tx, cfg config.SeedConfig) error
+}
+
+// allowedIn reports whether the set may be run in an environment
+func (s Set) allowedIn(environment string) bool {
+	return slices.Contains(s.Environments, environment)
+}
+
+// Runner runs seed sets, each in a transaction of its own
+type Runner struct {
+	db     *database.DB
+	sets   []Set
+	config config.SeedConfig
+	logger *logger.Logger
+}
+
+// NewRunner creates a new runner of the built-in seed sets
+func NewRunner(db *database.DB, cfg config.SeedConfig, log *logger.Logger) *Runner {
+	return &Runner{
+		db:     db,
+		sets:   []Set{adminSet, demoCatalogSet, fixturesSet},
+		config: cfg,
+		logger: log,
+	}
+}
+
+// Sets returns the seed sets in the order they are run
+func (r *Runner) Sets() []Set {
+	return r.sets
+}
+
+// Run runs the named seed sets in an environment, or every set allowed in
+// it when no names are given. Naming a set that isn't allowed in the
+// environment is an error, so production is never seeded with demo data
+// by accident.
+func (r *Runner) Run(ctx context.Context, environment string, names ...string) error {
+	for _, name := range names {
+		if !slices.ContainsFunc(r.sets, func(set Set) bool { return set.Name == name }) {
+			return fmt.Errorf("seed set not found: %s", name)
+		}
+	}
+
+	for _, set := range r.sets {
+		if len(names) > 0 && !slices.Contains(names, set.Name) {
+			continue
+		}
+		if !set.allowedIn(environment) {
+			if len(names) > 0 {
+				return fmt.Errorf("seed set %s cannot be run in %s", set.Name, environment)
+			}
+			continue
+		}
+
+		err := r.db.TransactionContext(ctx, nil, func(ctx context.Context, tx *sqlx.Tx) error {
+			return set.Run(ctx, tx, r.config)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to run seed set %s: %w", set.Name, err)
+		}
+		r.logger.Info("Seed set applied", "set", set.Name, "environment", environment)
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// seedNamespace derives the IDs of seeded products, so every run and every
+// database agrees on them
+var seedNamespace = uuid.MustParse("6f1c7d0e-3b9a-4e52-9d7f-2a8c5e4b1f30")
+
+// demoProduct is a product of the demo catalog
+type demoProduct struct {
+	SKU    string
+	Amount int64
+}
+
+// demoProducts are the products of the demo catalog, priced in EUR cents
+var demoProducts = []demoProduct{
+	{SKU: "DEMO-TSHIRT-M", Amount: 1999},
+	{SKU: "DEMO-HOODIE-L", Amount: 4999},
+	{SKU: "DEMO-MUG", Amount: 1299},
+	{SKU: "DEMO-COFFEE-1KG", Amount: 2499},
+}
+
+// productID returns the ID of a seeded product
+func productID(sku string) uuid.UUID {
+	return uuid.NewSHA1(seedNamespace, []byte(sku))
+}
+
+var adminSet = Set{
+	Name:         "admin",
+	Description:  "the admin user from the seed configuration",
+	Environments: []string{"development", "test", "staging", "production"},
+	Run: func(ctx context.Context, tx *sqlx.Tx, cfg config.SeedConfig) error {
+		if cfg.AdminEmail == "" || cfg.AdminPassword == "" {
+			return fmt.Errorf("admin email and password are not configured")
+		}
+		_, err := createUser(ctx, tx, cfg.AdminUsername, cfg.AdminEmail, cfg.AdminPassword, "admin")
+		return err
+	},
+}
+
+var demoCatalogSet = Set{
+	Name:         "demo_catalog",
+	Description:  "a demo seller with products, their prices and a subscription plan",
+	Environments: []string{"development", "staging"},
+	Run: func(ctx context.Context, tx *sqlx.Tx, cfg config.SeedConfig) error {
+		var priceListID uuid.UUID
+		err := tx.QueryRowxContext(ctx, `
+			WITH inserted AS (
+				INSERT INTO price_lists (name, currency) VALUES ('Demo', 'EUR')
+				ON CONFLICT (name) DO NOTHING
+				RETURNING id
+			)
+			SELECT id FROM inserted
+			UNION ALL
+			SELECT id FROM price_lists WHERE name = 'Demo'`).Scan(&priceListID)
+		if err != nil {
+			return fmt.Errorf("failed to create demo price list: %w", err)
+		}
+
+		sellerUserID, err := createUser(ctx, tx, "demo-seller", "seller@demo.commercium.local", "demo-seller-password", "seller")
+		if err != nil {
+			return err
+		}
+
+		var sellerID uuid.UUID
+		err = tx.QueryRowxContext(ctx, `
+			INSERT INTO sellers (user_id, store_name, contact_email, country, status, approved_at)
+			VALUES ($1, 'Demo Store', 'seller@demo.commercium.local', 'DE', 'active', NOW())
+			ON CONFLICT (user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+			RETURNING id`, sellerUserID).Scan(&sellerID)
+		if err != nil {
+			return fmt.Errorf("failed to create demo seller: %w", err)
+		}
+
+		for _, product := range demoProducts {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO prices (price_list_id, sku, amount)
+				SELECT $1, $2, $3
+				WHERE NOT EXISTS (SELECT 1 FROM prices WHERE price_list_id = $1 AND sku = $2)`,
+				priceListID, product.SKU, product.Amount)
+			if err != nil {
+				return fmt.Errorf("failed to create demo price of %s: %w", product.SKU, err)
+			}
+
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO seller_products (product_id, seller_id, sku) VALUES ($1, $2, $3)
+				ON CONFLICT (product_id) DO NOTHING`,
+				productID(product.SKU), sellerID, product.SKU)
+			if err != nil {
+				return fmt.Errorf("failed to create demo product %s: %w", product.SKU, err)
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO subscription_plans (code, name, product_id, sku, currency, amount, interval_unit, trial_days)
+			VALUES ('demo-coffee-monthly', 'Monthly coffee', $1, 'DEMO-COFFEE-1KG', 'EUR', 2249, 'month', 14)
+			ON CONFLICT (code) DO NOTHING`, productID("DEMO-COFFEE-1KG"))
+		if err != nil {
+			return fmt.Errorf("failed to create demo subscription plan: %w", err)
+		}
+
+		return nil
+	},
+}
+
+var fixturesSet = Set{
+	Name:         "fixtures",
+	Description:  "verified customers for tests, one of them in the wholesale customer group",
+	Environments: []string{"test"},
+	Run: func(ctx context.Context, tx *sqlx.Tx, cfg config.SeedConfig) error {
+		if _, err := createUser(ctx, tx, "test-customer", "customer@test.commercium.local", "test-password", "customer"); err != nil {
+			return err
+		}
+
+		wholesaleID, err := createUser(ctx, tx, "test-wholesale", "wholesale@test.commercium.local", "test-password", "customer")
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO customer_groups (user_id, customer_group) VALUES ($1, 'wholesale')
+			ON CONFLICT (user_id) DO NOTHING`, wholesaleID)
+		if err != nil {
+			return fmt.Errorf("failed to add wholesale customer group: %w", err)
+		}
+
+		return nil
+	},
+}
+
+// createUser creates an active, verified user unless a user with the email
+// exists, and returns the user's ID. An existing user is left as it is.
+func createUser(ctx context.Context, tx *sqlx.Tx, username, email, password, role string) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := tx.GetContext(ctx, &id, `SELECT id FROM users WHERE email = $1`, email)
+	if err == nil {
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return uuid.Nil, fmt.Errorf("failed to get user %s: %w", email, err)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	err = tx.GetContext(ctx, &id, `
+		INSERT INTO users (username, email, password_hash, is_active, is_verified, role)
+		VALUES ($1, $2, $3, true, true, $4)
+		RETURNING id`, username, email, string(hash), role)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create user %s: %w", email, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// LocalStore keeps files on the local disk. It is meant for development; the
+// service that owns the store serves the files through Handler.
+type LocalStore struct {
+	root       string
+	baseURL    string
+	signingKey []byte
+	now        func() time.Time
+}
+
+// NewLocalStore creates a store rooted at the configured path
+func NewLocalStore(cfg config.LocalStorageConfig) (*LocalStore, error) {
+	if cfg.Path == "" || cfg.BaseURL == "" {
+		return nil, fmt.Errorf("local storage path and base url are required")
+	}
+	if cfg.SigningKey == "" {
+		return nil, fmt.Errorf("local storage signing key is required")
+	}
+
+	if err := os.MkdirAll(cfg.Path, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	return &LocalStore{
+		root:       cfg.Path,
+		baseURL:    strings.TrimSuffix(cfg.BaseURL, "/"),
+		signingKey: []byte(cfg.SigningKey),
+		now:        time.Now,
+	}, nil
+}
+
+// Put writes a file
+func (s *LocalStore) Put(ctx context.Context, key, contentType string, data []byte) error {
+	filePath, err := s.filePath(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	// Write to a temporary file first so readers never see a partial file
+	tmp := filePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp, filePath); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// SignedURL returns a link to the file that Handler accepts until it expires
+func (s *LocalStore) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if ttl <= 0 {
+		return "", fmt.Errorf("invalid signed url ttl: %s", ttl)
+	}
+	if _, err := s.filePath(key); err != nil {
+		return "", err
+	}
+
+	key = strings.TrimPrefix(key, "/")
+	expires := s.now().Add(ttl).Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", s.signature(key, expires))
+
+	return s.baseURL + "/" + escapePath(key) + "?" + query.Encode(), nil
+}
+
+// Handler serves files for signed URLs. The key is taken from the request path
+// with prefix stripped.
+func (s *LocalStore) Handler(prefix string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+
+		expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+		if err != nil || s.now().Unix() > expires {
+			http.Error(w, "link has expired", http.StatusForbidden)
+			return
+		}
+
+		expected := s.signature(key, expires)
+		if !hmac.Equal([]byte(expected), []byte(r.URL.Query().Get("signature"))) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+
+		filePath, err := s.filePath(key)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		contentType := mime.TypeByExtension(path.Ext(key))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Write(data)
+	})
+}
+
+// filePath maps a key to a path under the store root, rejecting keys that
+// would escape it
+func (s *LocalStore) filePath(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid storage key: %s", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
+
+func (s *LocalStore) signature(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,245 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+const (
+	s3Algorithm   = "AWS4-HMAC-SHA256"
+	s3Service     = "s3"
+	s3DateFormat  = "20060102T150405Z"
+	s3ScopeFormat = "20060102"
+	// maxPresignTTL is the longest validity S3 accepts for presigned URLs
+	maxPresignTTL = 7 * 24 * time.Hour
+)
+
+// S3Store stores files in an S3 bucket, signing requests with AWS Signature Version 4
+type S3Store struct {
+	endpoint     *url.URL
+	region       string
+	bucket       string
+	accessKeyID  string
+	secretKey    string
+	usePathStyle bool
+	httpClient   *http.Client
+	now          func() time.Time
+}
+
+// NewS3Store creates a store for the configured bucket
+func NewS3Store(cfg config.S3StorageConfig) (*S3Store, error) {
+	if cfg.Bucket == "" || cfg.Region == "" {
+		return nil, fmt.Errorf("s3 bucket and region are required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("s3 credentials are required")
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.Region)
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %s", endpoint)
+	}
+
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &S3Store{
+		endpoint:     parsed,
+		region:       cfg.Region,
+		bucket:       cfg.Bucket,
+		accessKeyID:  cfg.AccessKeyID,
+		secretKey:    cfg.SecretAccessKey,
+		usePathStyle: cfg.UsePathStyle,
+		httpClient:   &http.Client{Timeout: timeout},
+		now:          time.Now,
+	}, nil
+}
+
+// Put uploads a file
+func (s *S3Store) Put(ctx context.Context, key, contentType string, data []byte) error {
+	objectURL := s.objectURL(key)
+	payloadHash := sha256Hex(data)
+	now := s.now().UTC()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL.String(), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create s3 request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", now.Format(s3DateFormat))
+
+	headers := map[string]string{
+		"content-type":         contentType,
+		"host":                 objectURL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           now.Format(s3DateFormat),
+	}
+	signedHeaders, signature := s.sign(http.MethodPut, objectURL, "", headers, payloadHash, now)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.accessKeyID, s.scope(now), signedHeaders, signature))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("s3 upload failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 upload failed: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// SignedURL returns a presigned GET URL for a file
+func (s *S3Store) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if ttl <= 0 || ttl > maxPresignTTL {
+		return "", fmt.Errorf("invalid signed url ttl: %s", ttl)
+	}
+
+	objectURL := s.objectURL(key)
+	now := s.now().UTC()
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.accessKeyID+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(s3DateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalQuery := canonicalQueryString(query)
+	_, signature := s.sign(http.MethodGet, objectURL, canonicalQuery, map[string]string{"host": objectURL.Host}, "UNSIGNED-PAYLOAD", now)
+
+	objectURL.RawQuery = canonicalQuery + "&X-Amz-Signature=" + signature
+	return objectURL.String(), nil
+}
+
+// objectURL returns the URL of a key, in path style or virtual-hosted style
+func (s *S3Store) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	path := "/" + strings.TrimPrefix(key, "/")
+	if s.usePathStyle {
+		path = "/" + s.bucket + path
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+
+	u.Path = path
+	u.RawPath = escapePath(path)
+	return &u
+}
+
+// sign computes the request signature over the given headers
+func (s *S3Store) sign(method string, u *url.URL, canonicalQuery string, headers map[string]string, payloadHash string, now time.Time) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		canonicalQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format(s3DateFormat),
+		s.scope(now),
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format(s3ScopeFormat))
+	signingKey = hmacSHA256(signingKey, s.region)
+	signingKey = hmacSHA256(signingKey, s3Service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+
+	return signedHeaders, hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+}
+
+// scope returns the credential scope of a request made at the given time
+func (s *S3Store) scope(now time.Time) string {
+	return now.Format(s3ScopeFormat) + "/" + s.region + "/" + s3Service + "/aws4_request"
+}
+
+// canonicalQueryString sorts and encodes query parameters as SigV4 requires
+func canonicalQueryString(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range values[key] {
+			pairs = append(pairs, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// escapePath encodes every segment of an object key, keeping the slashes
+func escapePath(key string) string {
+	return uriEncode(key, false)
+}
+
+// uriEncode percent-encodes everything but unreserved characters. Slashes are
+// kept unless encodeSlash is set.
+func uriEncode(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(value) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
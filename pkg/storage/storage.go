@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// Store keeps files and hands out time-limited download links to them
+type Store interface {
+	// Put stores data under key, replacing any existing file
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// SignedURL returns a link to download the file that expires after ttl
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// New creates the store selected by the storage driver setting
+func New(cfg config.StorageConfig) (Store, error) {
+	switch cfg.Driver {
+	case "s3":
+		return NewS3Store(cfg.S3)
+	case "local":
+		return NewLocalStore(cfg.Local)
+	default:
+		return nil, fmt.Errorf("storage driver %q is not supported", cfg.Driver)
+	}
+}
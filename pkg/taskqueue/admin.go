@@ -0,0 +1,213 @@
+package taskqueue
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// The states of failed tasks
+const (
+	// StateRetry is a task that failed and waits for its next retry
+	StateRetry = "retry"
+	// StateArchived is a task that failed its retries, kept for operators
+	StateArchived = "archived"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// QueueSummary counts the tasks of a queue by state
+type QueueSummary struct {
+	Queue     string `json:"queue"`
+	Pending   int    `json:"pending"`
+	Active    int    `json:"active"`
+	Scheduled int    `json:"scheduled"`
+	Retry     int    `json:"retry"`
+	Archived  int    `json:"archived"`
+	Completed int    `json:"completed"`
+	// Processed and Failed count the tasks run today
+	Processed int  `json:"processed_today"`
+	Failed    int  `json:"failed_today"`
+	Paused    bool `json:"paused"`
+}
+
+// FailedTask is a task that failed
+type FailedTask struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	Payload       json.RawMessage `json:"payload"`
+	State         string          `json:"state"`
+	Retried       int             `json:"retried"`
+	MaxRetry      int             `json:"max_retry"`
+	LastError     string          `json:"last_error"`
+	LastFailedAt  *time.Time      `json:"last_failed_at,omitempty"`
+	NextProcessAt *time.Time      `json:"next_process_at,omitempty"`
+}
+
+// Admin serves the API operators inspect queues with, and run again or
+// delete tasks that failed
+type Admin struct {
+	inspector *asynq.Inspector
+	logger    *logger.Logger
+}
+
+// NewAdmin creates the admin API of the queues in redis
+func NewAdmin(redis *database.Redis, logger *logger.Logger) *Admin {
+	return &Admin{
+		inspector: asynq.NewInspectorFromRedisClient(redis.Client),
+		logger:    logger,
+	}
+}
+
+// Routes mounts the admin API under /tasks on r, which should require
+// the admin role
+func (a *Admin) Routes(r gin.IRouter) {
+	tasks := r.Group("/tasks")
+	{
+		tasks.GET("/queues", a.ListQueues)
+		tasks.GET("/queues/:queue/failed", a.ListFailed)
+		tasks.POST("/queues/:queue/tasks/:id/run", a.Run)
+		tasks.DELETE("/queues/:queue/tasks/:id", a.Delete)
+	}
+}
+
+// ListQueues counts the tasks of each queue by state
+func (a *Admin) ListQueues(c *gin.Context) {
+	queues, err := a.inspector.Queues()
+	if err != nil {
+		a.respondError(c, err, "Failed to list queues")
+		return
+	}
+
+	summaries := make([]*QueueSummary, 0, len(queues))
+	for _, queue := range queues {
+		info, err := a.inspector.GetQueueInfo(queue)
+		if err != nil {
+			a.respondError(c, err, "Failed to list queues")
+			return
+		}
+		summaries = append(summaries, &QueueSummary{
+			Queue:     info.Queue,
+			Pending:   info.Pending,
+			Active:    info.Active,
+			Scheduled: info.Scheduled,
+			Retry:     info.Retry,
+			Archived:  info.Archived,
+			Completed: info.Completed,
+			Processed: info.Processed,
+			Failed:    info.Failed,
+			Paused:    info.Paused,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"queues": summaries})
+}
+
+// ListFailed lists the failed tasks of a queue: those whose retries ran
+// out, or with state=retry those waiting for a retry. Pages are selected
+// with page and page_size.
+func (a *Admin) ListFailed(c *gin.Context) {
+	state := c.DefaultQuery("state", StateArchived)
+	if state != StateArchived && state != StateRetry {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "state must be archived or retry"})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+	if err != nil || pageSize < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page size"})
+		return
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	list := a.inspector.ListArchivedTasks
+	if state == StateRetry {
+		list = a.inspector.ListRetryTasks
+	}
+	infos, err := list(c.Param("queue"), asynq.Page(page), asynq.PageSize(pageSize))
+	if err != nil {
+		a.respondError(c, err, "Failed to list failed tasks")
+		return
+	}
+
+	tasks := make([]*FailedTask, 0, len(infos))
+	for _, info := range infos {
+		tasks = append(tasks, failedTask(info, state))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tasks": tasks, "page": page, "page_size": pageSize})
+}
+
+// Run runs a failed task again right away
+func (a *Admin) Run(c *gin.Context) {
+	if err := a.inspector.RunTask(c.Param("queue"), c.Param("id")); err != nil {
+		a.respondError(c, err, "Failed to run task")
+		return
+	}
+
+	a.logger.Info("Background task run again by operator", "queue", c.Param("queue"), "task_id", c.Param("id"))
+	c.Status(http.StatusNoContent)
+}
+
+// Delete deletes a task
+func (a *Admin) Delete(c *gin.Context) {
+	if err := a.inspector.DeleteTask(c.Param("queue"), c.Param("id")); err != nil {
+		a.respondError(c, err, "Failed to delete task")
+		return
+	}
+
+	a.logger.Info("Background task deleted by operator", "queue", c.Param("queue"), "task_id", c.Param("id"))
+	c.Status(http.StatusNoContent)
+}
+
+// respondError maps inspector errors to responses
+func (a *Admin) respondError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, asynq.ErrQueueNotFound), errors.Is(err, asynq.ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		a.logger.Error(fallback, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// failedTask describes a failed task in state
+func failedTask(info *asynq.TaskInfo, state string) *FailedTask {
+	task := &FailedTask{
+		ID:        info.ID,
+		Type:      info.Type,
+		Payload:   info.Payload,
+		State:     state,
+		Retried:   info.Retried,
+		MaxRetry:  info.MaxRetry,
+		LastError: info.LastErr,
+	}
+	if !json.Valid(task.Payload) {
+		task.Payload = nil
+	}
+	if !info.LastFailedAt.IsZero() {
+		task.LastFailedAt = &info.LastFailedAt
+	}
+	if !info.NextProcessAt.IsZero() {
+		task.NextProcessAt = &info.NextProcessAt
+	}
+	return task
+}
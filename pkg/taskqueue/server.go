@@ -0,0 +1,115 @@
+package taskqueue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+)
+
+// The outcomes of tasks run
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+)
+
+// Permanent marks err as one running the task again won't fix, so the
+// task is kept as failed without its retries
+func Permanent(err error) error {
+	return fmt.Errorf("%w: %w", err, asynq.SkipRetry)
+}
+
+// Server runs the tasks of the types handled with it
+type Server struct {
+	server *asynq.Server
+	mux    *asynq.ServeMux
+	logger *logger.Logger
+
+	processed metrics.Counter
+	duration  metrics.Histogram
+}
+
+// NewServer creates a server running tasks taken from redis, as many at a
+// time as cfg allows. registry may be nil.
+func NewServer(redis *database.Redis, cfg config.TaskQueueConfig, registry metrics.Registry, log *logger.Logger) *Server {
+	s := &Server{
+		mux:       asynq.NewServeMux(),
+		logger:    log,
+		processed: noop{},
+		duration:  noop{},
+	}
+	if registry != nil {
+		s.processed = registry.NewCounter("tasks_processed", "Background tasks run, by task and outcome", "task", "outcome")
+		s.duration = registry.NewHistogram("task_duration_seconds", "Duration of background tasks", "task")
+	}
+
+	s.server = asynq.NewServerFromRedisClient(redis.Client, asynq.Config{
+		Concurrency: cfg.Concurrency,
+		Logger:      log,
+		LogLevel:    asynq.WarnLevel,
+		ErrorHandler: asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
+			retried, _ := asynq.GetRetryCount(ctx)
+			maxRetry, _ := asynq.GetMaxRetry(ctx)
+			id, _ := asynq.GetTaskID(ctx)
+			log.Error("Background task failed", "error", err, "task", task.Type(), "task_id", id,
+				"retried", retried, "max_retry", maxRetry)
+		}),
+	})
+	return s
+}
+
+// Handle runs the tasks of type task with handler. A task whose handler
+// returns an error is retried, unless the error is Permanent.
+func Handle[P any](s *Server, task Task[P], handler func(ctx context.Context, payload P) error) {
+	s.mux.HandleFunc(task.name, func(ctx context.Context, t *asynq.Task) error {
+		started := time.Now()
+
+		var payload P
+		err := json.Unmarshal(t.Payload(), &payload)
+		if err != nil {
+			err = Permanent(fmt.Errorf("invalid task payload: %w", err))
+		} else {
+			err = handler(ctx, payload)
+		}
+
+		outcome := OutcomeSucceeded
+		if err != nil {
+			outcome = OutcomeFailed
+		}
+		s.processed.Inc(task.name, outcome)
+		s.duration.Observe(ctx, time.Since(started).Seconds(), task.name)
+		return err
+	})
+}
+
+// Start starts running tasks in the background
+func (s *Server) Start() error {
+	if err := s.server.Start(s.mux); err != nil {
+		return fmt.Errorf("failed to start task server: %w", err)
+	}
+	return nil
+}
+
+// Shutdown stops taking tasks and waits for the tasks running to finish.
+// Tasks still running when ctx ends go back to the queue, to run again.
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.server.Shutdown()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("task server not stopped: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,129 @@
+// Package taskqueue runs background tasks services defer, through Redis.
+// Unlike events, which any number of consumers may handle, a task is work
+// for the service that defined it, done once: a replica takes it from the
+// queue, and it is retried with backoff while it fails. Tasks still failing
+// after their retries are kept for operators to inspect and run again or
+// delete through the admin API.
+package taskqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+)
+
+// ErrDuplicate is returned enqueueing a task with the ID of a task still
+// queued
+var ErrDuplicate = errors.New("task already queued")
+
+// Option configures a task
+type Option struct {
+	option asynq.Option
+}
+
+// MaxRetry retries a failing task up to n times
+func MaxRetry(n int) Option {
+	return Option{asynq.MaxRetry(n)}
+}
+
+// Timeout cancels a task that runs longer than timeout
+func Timeout(timeout time.Duration) Option {
+	return Option{asynq.Timeout(timeout)}
+}
+
+// In runs a task after delay
+func In(delay time.Duration) Option {
+	return Option{asynq.ProcessIn(delay)}
+}
+
+// At runs a task at t
+func At(t time.Time) Option {
+	return Option{asynq.ProcessAt(t)}
+}
+
+// ID identifies a task, so it is enqueued once while it is queued
+func ID(id string) Option {
+	return Option{asynq.TaskID(id)}
+}
+
+// Task is a type of task, whose payloads are P
+type Task[P any] struct {
+	name    string
+	options []Option
+}
+
+// NewTask defines the type of task named name, for example
+// "seller:generate_statements". Its tasks are enqueued with options,
+// which the options of each Enqueue override.
+func NewTask[P any](name string, options ...Option) Task[P] {
+	return Task[P]{name: name, options: options}
+}
+
+// Name returns the name of the type of task
+func (t Task[P]) Name() string {
+	return t.name
+}
+
+// Enqueue enqueues a task of type t carrying payload
+func (t Task[P]) Enqueue(ctx context.Context, client *Client, payload P, options ...Option) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	opts := []asynq.Option{
+		asynq.MaxRetry(client.config.MaxRetry),
+		asynq.Timeout(client.config.Timeout),
+		asynq.Retention(client.config.Retention),
+	}
+	for _, option := range append(t.options, options...) {
+		opts = append(opts, option.option)
+	}
+
+	_, err = client.client.EnqueueContext(ctx, asynq.NewTask(t.name, data), opts...)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return ErrDuplicate
+	}
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task %s: %w", t.name, err)
+	}
+
+	client.enqueued.Inc(t.name)
+	return nil
+}
+
+// Client enqueues tasks
+type Client struct {
+	client   *asynq.Client
+	config   config.TaskQueueConfig
+	enqueued metrics.Counter
+}
+
+// NewClient creates a client enqueueing tasks in redis. registry may be nil.
+func NewClient(redis *database.Redis, cfg config.TaskQueueConfig, registry metrics.Registry) *Client {
+	c := &Client{
+		client:   asynq.NewClientFromRedisClient(redis.Client),
+		config:   cfg,
+		enqueued: noop{},
+	}
+	if registry != nil {
+		c.enqueued = registry.NewCounter("tasks_enqueued", "Background tasks enqueued, by task", "task")
+	}
+	return c
+}
+
+// noop records nothing, for task queues created without a registry
+type noop struct{}
+
+func (noop) Inc(...string)                               {}
+func (noop) Add(float64, ...string)                      {}
+func (noop) Observe(context.Context, float64, ...string) {}
+func (noop) Set(float64, ...string)                      {}
@@ -0,0 +1,110 @@
+package tenant
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/tracing"
+)
+
+// ContextKeyTenant is the Gin context key populated by Middleware
+const ContextKeyTenant = "tenant"
+
+// Registry tells which tenants are provisioned, e.g. database.DB
+type Registry interface {
+	TenantExists(ctx context.Context, id string) (bool, error)
+}
+
+// Middleware returns Gin middleware that resolves the tenant of a request
+// and stores it in the Gin and request contexts. Requests with a valid
+// access token are made for the tenant the token was issued for, and
+// rejected when they name another in the tenant header or a subdomain of
+// the base domain: clients only choose their tenant, e.g. to register or
+// log in, without one. The header is set to the resolved tenant so it is
+// passed on to services the request is proxied to, which check it against
+// the token again. Tenants missing from the registry are rejected as not
+// found; services without one, such as the gateway, leave that to those
+// they pass requests on to. It does nothing when tenancy is disabled.
+func Middleware(cfg config.TenancyConfig, tokens *auth.JWTService, registry Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfg.Enabled {
+			c.Next()
+			return
+		}
+
+		id := strings.ToLower(strings.TrimSpace(c.GetHeader(cfg.Header)))
+		if id == "" {
+			id = subdomain(c.Request.Host, cfg.BaseDomain)
+		}
+
+		if claims, ok := tokens.RequestClaims(c); ok {
+			if id != "" && id != claims.TenantID {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token isn't valid for this tenant"})
+				return
+			}
+			id = claims.TenantID
+		}
+
+		if id == "" {
+			if cfg.Required {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Tenant required"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if err := Validate(id); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant"})
+			return
+		}
+		if registry != nil {
+			exists, err := registry.TenantExists(c.Request.Context(), id)
+			if err != nil {
+				logger.FromContext(c.Request.Context()).Error("Failed to look up tenant", "error", err, "tenant", id)
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Tenant lookup failed"})
+				return
+			}
+			if !exists {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Unknown tenant"})
+				return
+			}
+		}
+
+		c.Request.Header.Set(cfg.Header, id)
+		ctx := WithTenant(c.Request.Context(), id)
+		// Lines logged for the request carry the tenant, as do the services
+		// it reaches, in its baggage, unless it came with it
+		if tracing.TenantID(ctx) != id {
+			ctx = tracing.WithTenantID(logger.ContextWithFields(ctx, "tenant_id", id), id)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Set(ContextKeyTenant, id)
+
+		c.Next()
+	}
+}
+
+// subdomain returns the label of host directly below baseDomain, e.g.
+// "acme" for acme.shop.example.com below shop.example.com
+func subdomain(host, baseDomain string) string {
+	if baseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	prefix, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(baseDomain))
+	if !ok || strings.Contains(prefix, ".") {
+		return ""
+	}
+	return prefix
+}
@@ -0,0 +1,126 @@
+package tenant_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/tenant"
+)
+
+// TestMiddlewareTokenTenant checks requests with an access token are made
+// for the token's tenant, whatever the client names
+func TestMiddlewareTokenTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtService := auth.NewJWTService(&config.JWTConfig{
+		SecretKey:         "tenant-test-secret-key-of-32-byte",
+		Issuer:            "commercium",
+		Expiration:        15 * time.Minute,
+		RefreshExpiration: time.Hour,
+	})
+	cfg := config.TenancyConfig{Enabled: true, Header: "X-Tenant-ID", BaseDomain: "shop.example.com"}
+
+	router := gin.New()
+	router.Use(tenant.Middleware(cfg, jwtService, nil))
+	router.GET("/", func(c *gin.Context) {
+		id, _ := tenant.FromContext(c.Request.Context())
+		c.String(http.StatusOK, id)
+	})
+
+	pair, err := jwtService.GenerateTokenPair(uuid.New(), "jane@example.com", "jane", "customer", "acme")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name   string
+		token  string
+		header string
+		host   string
+		code   int
+		tenant string
+	}{
+		{"anonymous header", "", "globex", "", http.StatusOK, "globex"},
+		{"anonymous subdomain", "", "", "globex.shop.example.com", http.StatusOK, "globex"},
+		{"token without header", pair.AccessToken, "", "", http.StatusOK, "acme"},
+		{"token with its tenant", pair.AccessToken, "acme", "", http.StatusOK, "acme"},
+		{"token with other header", pair.AccessToken, "globex", "", http.StatusForbidden, ""},
+		{"token with other subdomain", pair.AccessToken, "", "globex.shop.example.com", http.StatusForbidden, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.host != "" {
+				req.Host = tc.host
+			}
+			if tc.header != "" {
+				req.Header.Set(cfg.Header, tc.header)
+			}
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.code, rec.Code)
+			if tc.code == http.StatusOK {
+				assert.Equal(t, tc.tenant, rec.Body.String())
+			}
+		})
+	}
+}
+
+// registry is a Registry of fixed tenants, failing lookups with err
+type registry struct {
+	tenants map[string]bool
+	err     error
+}
+
+func (r registry) TenantExists(_ context.Context, id string) (bool, error) {
+	return r.tenants[id], r.err
+}
+
+// TestMiddlewareRegistry checks requests naming tenants that aren't
+// provisioned are rejected
+func TestMiddlewareRegistry(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtService := auth.NewJWTService(&config.JWTConfig{
+		SecretKey:         "tenant-test-secret-key-of-32-byte",
+		Issuer:            "commercium",
+		Expiration:        15 * time.Minute,
+		RefreshExpiration: time.Hour,
+	})
+	cfg := config.TenancyConfig{Enabled: true, Header: "X-Tenant-ID"}
+
+	for _, tc := range []struct {
+		name     string
+		registry registry
+		header   string
+		code     int
+	}{
+		{"provisioned", registry{tenants: map[string]bool{"acme": true}}, "acme", http.StatusOK},
+		{"unknown", registry{tenants: map[string]bool{"acme": true}}, "globex", http.StatusNotFound},
+		{"invalid", registry{tenants: map[string]bool{"acme": true}}, "Not A Tenant", http.StatusBadRequest},
+		{"lookup failed", registry{err: errors.New("connection refused")}, "acme", http.StatusServiceUnavailable},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(tenant.Middleware(cfg, jwtService, tc.registry))
+			router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(cfg.Header, tc.header)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.code, rec.Code)
+		})
+	}
+}
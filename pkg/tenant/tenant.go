@@ -0,0 +1,35 @@
+// Package tenant resolves the tenant a request is made for and carries it
+// through the request context
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// validID matches tenant IDs; they name database schemas, so they are kept
+// short and simple
+var validID = regexp.MustCompile(`^[a-z][a-z0-9_]{0,47}$`)
+
+// contextKey is the context key of the tenant of a request
+type contextKey struct{}
+
+// Validate checks a tenant ID is well formed
+func Validate(id string) error {
+	if !validID.MatchString(id) {
+		return fmt.Errorf("invalid tenant: %q", id)
+	}
+	return nil
+}
+
+// WithTenant returns a copy of ctx carrying a tenant
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant ctx carries, if any
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The names of the attributes spans carry, following the OpenTelemetry
+// semantic conventions, so spans of every service describe requests,
+// queries and messages alike and can be searched by the same keys
+const (
+	HTTPMethodKey     = attribute.Key("http.method")
+	HTTPRouteKey      = attribute.Key("http.route")
+	HTTPStatusCodeKey = attribute.Key("http.status_code")
+
+	DBSystemKey       = attribute.Key("db.system")
+	DBNameKey         = attribute.Key("db.name")
+	DBOperationKey    = attribute.Key("db.operation")
+	DBStatementKey    = attribute.Key("db.statement")
+	DBRowsAffectedKey = attribute.Key("db.rows_affected")
+	DBBatchSizeKey    = attribute.Key("db.operation.batch.size")
+
+	ServerAddressKey = attribute.Key("server.address")
+	ServerPortKey    = attribute.Key("server.port")
+
+	MessagingSystemKey      = attribute.Key("messaging.system")
+	MessagingOperationKey   = attribute.Key("messaging.operation")
+	MessagingDestinationKey = attribute.Key("messaging.destination.name")
+	MessagingBatchCountKey  = attribute.Key("messaging.batch.message_count")
+	MessagingAttemptKey     = attribute.Key("messaging.attempt")
+	MessagingRetryTierKey   = attribute.Key("messaging.retry.tier")
+
+	// Tail-sampling hints: the sample rate of the route of a trace, and a
+	// priority of 1 on spans whose trace must be kept
+	SamplingRateKey     = attribute.Key("sampling.rate")
+	SamplingPriorityKey = attribute.Key("sampling.priority")
+)
+
+// The values of the system and operation attributes
+const (
+	DBSystemPostgreSQL = "postgresql"
+	DBSystemRedis      = "redis"
+
+	MessagingSystemKafka    = "kafka"
+	MessagingSystemRabbitMQ = "rabbitmq"
+
+	MessagingOperationPublish = "publish"
+	MessagingOperationProcess = "process"
+)
+
+// HTTPServerAttributes returns the attributes of the server span of a
+// request with method to route, its path template, e.g. /orders/:id
+func HTTPServerAttributes(method, route string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		HTTPMethodKey.String(method),
+		HTTPRouteKey.String(route),
+	}
+}
+
+// DBAttributes returns the attributes of the client span of a query or
+// command run on system, e.g. postgresql, whose statement must have its
+// values removed
+func DBAttributes(system, operation, statement string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		DBSystemKey.String(system),
+		DBOperationKey.String(operation),
+		DBStatementKey.String(statement),
+	}
+}
+
+// ServerAttributes returns the attributes of the server a client span
+// calls. A port that isn't positive is left out.
+func ServerAttributes(host string, port int) []attribute.KeyValue {
+	attributes := []attribute.KeyValue{ServerAddressKey.String(host)}
+	if port > 0 {
+		attributes = append(attributes, ServerPortKey.Int(port))
+	}
+	return attributes
+}
+
+// MessagingAttributes returns the attributes of the span of a message sent
+// or received by system, e.g. kafka, for operation on destination, the
+// topic, exchange or queue
+func MessagingAttributes(system, operation, destination string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		MessagingSystemKey.String(system),
+		MessagingOperationKey.String(operation),
+		MessagingDestinationKey.String(destination),
+	}
+}
+
+// RecordError records err, with attributes describing it, on span and
+// marks the span failed, and as one to keep when tail sampling. A nil err
+// records nothing, so it can be called with the error of whatever the span
+// covers.
+func RecordError(span trace.Span, err error, attributes ...attribute.KeyValue) {
+	if err == nil || span == nil {
+		return
+	}
+	span.RecordError(err, trace.WithAttributes(attributes...))
+	span.SetStatus(codes.Error, err.Error())
+	markFailed(span)
+}
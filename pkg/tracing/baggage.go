@@ -0,0 +1,140 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Baggage members set by the gateway and services, carried with the trace
+// to every service a request reaches, over HTTP and messages alike
+const (
+	BaggageTenantID = "tenant_id"
+	BaggageUserID   = "user_id"
+	// baggageExperimentPrefix prefixes the members of experiment flags,
+	// e.g. experiment.checkout_v2=treatment
+	baggageExperimentPrefix = "experiment."
+)
+
+// SetBaggage returns a copy of ctx whose baggage carries key set to value,
+// replacing the value it had
+func SetBaggage(ctx context.Context, key, value string) (context.Context, error) {
+	member, err := baggage.NewMemberRaw(key, value)
+	if err != nil {
+		return ctx, fmt.Errorf("invalid baggage member %s: %w", key, err)
+	}
+	bag, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to set baggage member %s: %w", key, err)
+	}
+	return baggage.ContextWithBaggage(ctx, bag), nil
+}
+
+// BaggageValue returns the value of key in the baggage of ctx, or an empty
+// string
+func BaggageValue(ctx context.Context, key string) string {
+	return baggage.FromContext(ctx).Member(key).Value()
+}
+
+// WithTenantID returns a copy of ctx whose baggage carries the tenant of
+// the request. An invalid ID leaves ctx as it is.
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	ctx, _ = SetBaggage(ctx, BaggageTenantID, tenantID)
+	return ctx
+}
+
+// TenantID returns the tenant the baggage of ctx carries, or an empty
+// string
+func TenantID(ctx context.Context) string {
+	return BaggageValue(ctx, BaggageTenantID)
+}
+
+// WithUserID returns a copy of ctx whose baggage carries the user the
+// request is made by. An invalid ID leaves ctx as it is.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	ctx, _ = SetBaggage(ctx, BaggageUserID, userID)
+	return ctx
+}
+
+// UserID returns the user the baggage of ctx carries, or an empty string
+func UserID(ctx context.Context) string {
+	return BaggageValue(ctx, BaggageUserID)
+}
+
+// WithExperiment returns a copy of ctx whose baggage carries the variant
+// of the experiment name the request is in. An invalid name or variant
+// leaves ctx as it is.
+func WithExperiment(ctx context.Context, name, variant string) context.Context {
+	ctx, _ = SetBaggage(ctx, baggageExperimentPrefix+name, variant)
+	return ctx
+}
+
+// Experiment returns the variant of the experiment name the baggage of ctx
+// carries, or an empty string
+func Experiment(ctx context.Context, name string) string {
+	return BaggageValue(ctx, baggageExperimentPrefix+name)
+}
+
+// Experiments returns the variants of the experiments the baggage of ctx
+// carries, by experiment name
+func Experiments(ctx context.Context) map[string]string {
+	experiments := make(map[string]string)
+	for _, member := range baggage.FromContext(ctx).Members() {
+		if name, ok := strings.CutPrefix(member.Key(), baggageExperimentPrefix); ok {
+			experiments[name] = member.Value()
+		}
+	}
+	return experiments
+}
+
+// BaggageFields returns the members of the baggage of ctx as key value
+// pairs, for lines logged and spans recorded to carry the same dimensions
+// in every service
+func BaggageFields(ctx context.Context) []interface{} {
+	members := baggage.FromContext(ctx).Members()
+	fields := make([]interface{}, 0, 2*len(members))
+	for _, member := range members {
+		fields = append(fields, member.Key(), member.Value())
+	}
+	return fields
+}
+
+// DropBaggage returns Gin middleware that removes the baggage clients sent
+// with a request, for the edge of the system, where baggage isn't trusted:
+// a client could claim to be another user. It must run before Middleware.
+func DropBaggage() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Header.Del("baggage")
+		c.Next()
+	}
+}
+
+// NewTransport returns a transport adding the trace context and baggage of
+// each request's context to its headers, before sending it with base, or
+// http.DefaultTransport when base is nil. It is meant for clients of this
+// system's services; third parties shouldn't be sent the baggage.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+// transport propagates the trace of requests to the services they are sent to
+type transport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper mustn't modify the request it is given
+	req = req.Clone(req.Context())
+	otel.GetTextMapPropagator().Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,152 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+)
+
+// tailHints are the tail-sampling hints server spans are given, nil when
+// tail sampling is off
+var tailHints atomic.Pointer[tailSampling]
+
+// newSampler returns the sampler of cfg. Traces continued from another
+// service or a message keep the decision made where they started, so a
+// trace sampled by the gateway is sampled by every service it reaches.
+// With tail sampling, every trace is sampled, for the collector to decide.
+func newSampler(cfg config.TracingConfig) sdktrace.Sampler {
+	if cfg.TailSampling.Enabled {
+		return sdktrace.AlwaysSample()
+	}
+	return sdktrace.ParentBased(newRouteSampler(cfg))
+}
+
+// routeSampler samples the traces started by requests at the rate of
+// their route, found in the attributes the server span starts with, and
+// other traces at the default rate
+type routeSampler struct {
+	routes      []routeRate
+	defaultRate float64
+	fallback    sdktrace.Sampler
+}
+
+// routeRate is the sample rate of a route
+type routeRate struct {
+	method  string
+	route   string
+	prefix  bool
+	rate    float64
+	sampler sdktrace.Sampler
+}
+
+// newRouteSampler returns the sampler of the route rates of cfg
+func newRouteSampler(cfg config.TracingConfig) *routeSampler {
+	s := &routeSampler{
+		defaultRate: cfg.SampleRate,
+		fallback:    sdktrace.TraceIDRatioBased(cfg.SampleRate),
+	}
+	for _, route := range cfg.Routes {
+		pattern, prefix := strings.CutSuffix(route.Route, "*")
+		s.routes = append(s.routes, routeRate{
+			method:  strings.ToUpper(route.Method),
+			route:   pattern,
+			prefix:  prefix,
+			rate:    route.SampleRate,
+			sampler: sdktrace.TraceIDRatioBased(route.SampleRate),
+		})
+	}
+	return s
+}
+
+// ShouldSample implements sdktrace.Sampler
+func (s *routeSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	var method, route string
+	for _, attr := range p.Attributes {
+		switch attr.Key {
+		case HTTPMethodKey:
+			method = attr.Value.AsString()
+		case HTTPRouteKey:
+			route = attr.Value.AsString()
+		}
+	}
+	if r := s.match(method, route); r != nil {
+		return r.sampler.ShouldSample(p)
+	}
+	return s.fallback.ShouldSample(p)
+}
+
+// Description implements sdktrace.Sampler
+func (s *routeSampler) Description() string {
+	return fmt.Sprintf("RouteSampler{routes:%d,default:%g}", len(s.routes), s.defaultRate)
+}
+
+// match returns the rate of the first route matching a request with
+// method to route, or nil
+func (s *routeSampler) match(method, route string) *routeRate {
+	if route == "" {
+		return nil
+	}
+	for i := range s.routes {
+		r := &s.routes[i]
+		if r.method != "" && r.method != method {
+			continue
+		}
+		if route == r.route || (r.prefix && strings.HasPrefix(route, r.route)) {
+			return r
+		}
+	}
+	return nil
+}
+
+// rate returns the sample rate of requests with method to route
+func (s *routeSampler) rate(method, route string) float64 {
+	if r := s.match(method, route); r != nil {
+		return r.rate
+	}
+	return s.defaultRate
+}
+
+// tailSampling gives server spans the hints a collector sampling whole
+// traces decides by
+type tailSampling struct {
+	threshold time.Duration
+	routes    *routeSampler
+}
+
+// mark gives the server span of a request with method to route, answered
+// with status after duration, its hints: the sample rate of its route, and
+// a priority of 1 when the trace must be kept
+func (t *tailSampling) mark(span trace.Span, method, route string, status int, duration time.Duration) {
+	rate := t.routes.rate(method, route)
+	span.SetAttributes(SamplingRateKey.Float64(rate))
+	if rate >= 1 || status >= http.StatusInternalServerError || duration > t.threshold {
+		span.SetAttributes(SamplingPriorityKey.Int(1))
+	}
+}
+
+// markFailed gives a span that recorded an error a priority of 1, when tail
+// sampling is on
+func markFailed(span trace.Span) {
+	if tailHints.Load() != nil {
+		span.SetAttributes(SamplingPriorityKey.Int(1))
+	}
+}
+
+// setTailHints turns the hints of cfg on, or off when tail sampling is off
+func setTailHints(cfg config.TracingConfig) {
+	if !cfg.Enabled || !cfg.TailSampling.Enabled {
+		tailHints.Store(nil)
+		return
+	}
+	tailHints.Store(&tailSampling{
+		threshold: cfg.TailSampling.LatencyThreshold,
+		routes:    newRouteSampler(cfg),
+	})
+}
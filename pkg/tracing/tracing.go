@@ -2,10 +2,17 @@ package tracing
 
 import (
 	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
@@ -15,6 +22,10 @@ import (
 
 // NewTracerProvider creates a new tracer provider
 func NewTracerProvider(cfg config.TracingConfig, serviceName string) (*sdktrace.TracerProvider, error) {
+	// Traces are continued across services even when this one doesn't
+	// export them, so the lines it logs are correlated with them
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
 	if !cfg.Enabled {
 		return sdktrace.NewTracerProvider(), nil
 	}
@@ -25,19 +36,13 @@ func NewTracerProvider(cfg config.TracingConfig, serviceName string) (*sdktrace.
 		return nil, err
 	}
 
-	// Create resource with service information
-	res := resource.NewWithAttributes(
-		resource.Default().SchemaURL(),
-		attribute.String("service.name", serviceName),
-		attribute.String("service.version", "v1.0.0"),
-	)
-
 	// Create tracer provider
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithBatcher(exp),
-		sdktrace.WithResource(res),
-		sdktrace.WithSampler(sdktrace.TraceIDRatioBased(cfg.SampleRate)),
+		sdktrace.WithResource(Resource(serviceName)),
+		sdktrace.WithSampler(newSampler(cfg)),
 	)
+	setTailHints(cfg)
 
 	// Set global tracer provider
 	otel.SetTracerProvider(tp)
@@ -45,6 +50,16 @@ func NewTracerProvider(cfg config.TracingConfig, serviceName string) (*sdktrace.
 	return tp, nil
 }
 
+// Resource describes the service to telemetry backends. Logs shipped by
+// pkg/logger carry the same attributes, so they can be matched with traces.
+func Resource(serviceName string) *resource.Resource {
+	return resource.NewWithAttributes(
+		resource.Default().SchemaURL(),
+		attribute.String("service.name", serviceName),
+		attribute.String("service.version", "v1.0.0"),
+	)
+}
+
 // GetTracer returns a tracer for the given name
 func GetTracer(name string) trace.Tracer {
 	return otel.Tracer(name)
@@ -56,6 +71,48 @@ func StartSpan(ctx context.Context, tracerName, spanName string) (context.Contex
 	return tracer.Start(ctx, spanName)
 }
 
+// Middleware returns Gin middleware that runs each request in a server
+// span named after its route, continuing the trace propagated in its
+// headers, if any. The span carries the baggage of the request as it is
+// once handled, such as its tenant and user.
+func Middleware(serviceName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched route"
+		}
+		ctx, span := GetTracer(serviceName).Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(HTTPServerAttributes(c.Request.Method, route)...),
+		)
+		defer span.End()
+
+		start := time.Now()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(HTTPStatusCodeKey.Int(status))
+		if hints := tailHints.Load(); hints != nil {
+			hints.mark(span, c.Request.Method, route, status, time.Since(start))
+		}
+		for _, member := range baggage.FromContext(c.Request.Context()).Members() {
+			span.SetAttributes(attribute.String(member.Key(), member.Value()))
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// Inject writes the trace of ctx into the headers of an outgoing request,
+// for the service called to continue it
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
 // SpanFromContext returns the current span from context
 func SpanFromContext(ctx context.Context) trace.Span {
 	return trace.SpanFromContext(ctx)
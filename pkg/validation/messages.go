@@ -0,0 +1,111 @@
+package validation
+
+import (
+	"golang.org/x/text/language"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/i18n"
+)
+
+// The messages of the errors of whole requests, keyed like those of fields
+const (
+	messageRequest = "request"
+	messageQuery   = "query"
+	messageBody    = "body"
+)
+
+// messages holds, for each language, the messages of the rules a field can
+// break, keyed by rule and, for rules measuring strings, numbers and lists
+// alike, by rule and what was measured. {field} and {param} are replaced
+// by the field and the parameter of the rule.
+var messages = i18n.Catalog{
+	language.English: {
+		messageRequest: "invalid request data",
+		messageQuery:   "invalid query parameters",
+		messageBody:    "request body is missing or not valid JSON",
+
+		"required":         "{field} is required",
+		"required_without": "{field} is required",
+		"email":            "{field} must be a valid email address",
+		"min.string":       "{field} must be at least {param} characters long",
+		"min.number":       "{field} must be {param} or more",
+		"min.list":         "{field} must have at least {param} items",
+		"max.string":       "{field} must be at most {param} characters long",
+		"max.number":       "{field} must be {param} or less",
+		"max.list":         "{field} must have at most {param} items",
+		"len.string":       "{field} must be {param} characters long",
+		"len.number":       "{field} must be {param}",
+		"len.list":         "{field} must have {param} items",
+		"gt.string":        "{field} must be longer than {param} characters",
+		"gt.number":        "{field} must be greater than {param}",
+		"gt.list":          "{field} must have more than {param} items",
+		"oneof":            "{field} must be one of {param}",
+		"uuid":             "{field} must be a valid UUID",
+		TagPassword:        "{field} must contain upper and lower case letters and a digit",
+		TagPhone:           "{field} must be a valid phone number",
+		TagCountry:         "{field} must be an ISO 3166-1 alpha-2 country code",
+		codeType:           "{field} must be of type {param}",
+		codeInvalid:        "{field} is invalid",
+	},
+	language.German: {
+		messageRequest: "ungültige Anfragedaten",
+		messageQuery:   "ungültige Abfrageparameter",
+		messageBody:    "der Anfragetext fehlt oder ist kein gültiges JSON",
+
+		"required":         "{field} ist erforderlich",
+		"required_without": "{field} ist erforderlich",
+		"email":            "{field} muss eine gültige E-Mail-Adresse sein",
+		"min.string":       "{field} muss mindestens {param} Zeichen lang sein",
+		"min.number":       "{field} muss mindestens {param} sein",
+		"min.list":         "{field} muss mindestens {param} Einträge haben",
+		"max.string":       "{field} darf höchstens {param} Zeichen lang sein",
+		"max.number":       "{field} darf höchstens {param} sein",
+		"max.list":         "{field} darf höchstens {param} Einträge haben",
+		"len.string":       "{field} muss genau {param} Zeichen lang sein",
+		"len.number":       "{field} muss {param} sein",
+		"len.list":         "{field} muss genau {param} Einträge haben",
+		"gt.string":        "{field} muss länger als {param} Zeichen sein",
+		"gt.number":        "{field} muss größer als {param} sein",
+		"gt.list":          "{field} muss mehr als {param} Einträge haben",
+		"oneof":            "{field} muss einer der Werte {param} sein",
+		"uuid":             "{field} muss eine gültige UUID sein",
+		TagPassword:        "{field} muss Groß- und Kleinbuchstaben und eine Ziffer enthalten",
+		TagPhone:           "{field} muss eine gültige Telefonnummer sein",
+		TagCountry:         "{field} muss ein Ländercode nach ISO 3166-1 alpha-2 sein",
+		codeType:           "{field} muss vom Typ {param} sein",
+		codeInvalid:        "{field} ist ungültig",
+	},
+	language.Turkish: {
+		messageRequest: "geçersiz istek verisi",
+		messageQuery:   "geçersiz sorgu parametreleri",
+		messageBody:    "istek gövdesi eksik veya geçerli bir JSON değil",
+
+		"required":         "{field} zorunludur",
+		"required_without": "{field} zorunludur",
+		"email":            "{field} geçerli bir e-posta adresi olmalıdır",
+		"min.string":       "{field} en az {param} karakter uzunluğunda olmalıdır",
+		"min.number":       "{field} en az {param} olmalıdır",
+		"min.list":         "{field} en az {param} öğe içermelidir",
+		"max.string":       "{field} en fazla {param} karakter uzunluğunda olmalıdır",
+		"max.number":       "{field} en fazla {param} olmalıdır",
+		"max.list":         "{field} en fazla {param} öğe içermelidir",
+		"len.string":       "{field} tam olarak {param} karakter uzunluğunda olmalıdır",
+		"len.number":       "{field} {param} olmalıdır",
+		"len.list":         "{field} tam olarak {param} öğe içermelidir",
+		"gt.string":        "{field} {param} karakterden uzun olmalıdır",
+		"gt.number":        "{field} {param} değerinden büyük olmalıdır",
+		"gt.list":          "{field} {param} öğeden fazla içermelidir",
+		"oneof":            "{field} şunlardan biri olmalıdır: {param}",
+		"uuid":             "{field} geçerli bir UUID olmalıdır",
+		TagPassword:        "{field} büyük ve küçük harfler ile bir rakam içermelidir",
+		TagPhone:           "{field} geçerli bir telefon numarası olmalıdır",
+		TagCountry:         "{field} ISO 3166-1 alpha-2 ülke kodu olmalıdır",
+		codeType:           "{field} {param} türünde olmalıdır",
+		codeInvalid:        "{field} geçersiz",
+	},
+}
+
+// message returns the message keyed key in lang, or in the language it
+// falls back to, with {field} and {param} replaced
+func message(lang language.Tag, key, field, param string) string {
+	return messages.Message(lang, key, "field", field, "param", param)
+}
@@ -0,0 +1,160 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/text/language"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/i18n"
+)
+
+// The codes of field errors that don't come from a rule
+const (
+	// codeType is a field whose JSON value has the wrong type
+	codeType = "type"
+	// codeInvalid is a field breaking a rule with no message of its own
+	codeInvalid = "invalid"
+)
+
+// BindJSON binds the JSON body of the request of c to obj and validates
+// it. When either fails, it answers the request with the invalid fields
+// and returns false.
+func BindJSON(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		respond(c, err, messageRequest)
+		return false
+	}
+	return true
+}
+
+// BindOptionalJSON is BindJSON for requests whose body may be left out,
+// leaving obj as it is when there is none
+func BindOptionalJSON(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindJSON(obj); err != nil && c.Request.ContentLength > 0 {
+		respond(c, err, messageRequest)
+		return false
+	}
+	return true
+}
+
+// BindQuery binds the query parameters of the request of c to obj and
+// validates them. When either fails, it answers the request with the
+// invalid parameters and returns false.
+func BindQuery(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindQuery(obj); err != nil {
+		respond(c, err, messageQuery)
+		return false
+	}
+	return true
+}
+
+// respond answers the request of c, which failed to bind with err, with
+// the invalid fields, in the language the client accepts
+func respond(c *gin.Context, err error, summary string) {
+	apperrors.Respond(c, translate(err, summary, i18n.Language(c)))
+}
+
+// translate returns err as a validation error with the message keyed
+// summary, and the messages of its fields, in lang. The error is kept as
+// its cause, for logs only, as its message reveals the types of handlers.
+func translate(err error, summary string, lang language.Tag) *apperrors.Error {
+	var (
+		invalid   validator.ValidationErrors
+		typeErr   *json.UnmarshalTypeError
+		syntaxErr *json.SyntaxError
+	)
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		summary = messageBody
+	}
+	translated := apperrors.Validation("%s", message(lang, summary, "", "")).Wrap(err)
+
+	switch {
+	case errors.As(err, &invalid):
+		for _, fe := range invalid {
+			translated.WithFieldError(fieldError(fe, lang))
+		}
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		param := jsonType(typeErr.Type)
+		translated.WithFieldError(apperrors.FieldError{
+			Field:   typeErr.Field,
+			Code:    codeType,
+			Param:   param,
+			Message: message(lang, codeType, typeErr.Field, param),
+		})
+	}
+	return translated
+}
+
+// fieldError returns the field error of fe, a rule a field broke
+func fieldError(fe validator.FieldError, lang language.Tag) apperrors.FieldError {
+	field := fe.Namespace()
+	// The namespace starts with the type of the request, which clients
+	// don't know
+	if _, path, ok := strings.Cut(field, "."); ok {
+		field = path
+	}
+
+	param := fe.Param()
+	if fe.Tag() == "oneof" {
+		param = strings.Join(strings.Fields(param), ", ")
+	}
+
+	text := message(lang, fe.Tag()+"."+measureOf(fe.Kind()), field, param)
+	if text == "" {
+		text = message(lang, fe.Tag(), field, param)
+	}
+	if text == "" {
+		text = message(lang, codeInvalid, field, param)
+	}
+
+	return apperrors.FieldError{
+		Field:   field,
+		Code:    fe.Tag(),
+		Param:   param,
+		Message: text,
+	}
+}
+
+// measureOf returns what the rules measuring a field of kind measure: its
+// length, its value or its items
+func measureOf(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "list"
+	default:
+		return "string"
+	}
+}
+
+// jsonType returns the JSON type a value of t is decoded from
+func jsonType(t reflect.Type) string {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return "value"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.String:
+		return "string"
+	default:
+		return "number"
+	}
+}
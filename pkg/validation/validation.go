@@ -0,0 +1,108 @@
+// Package validation validates the requests handlers bind, with the rules
+// of their binding tags and the custom ones below, and turns what fails
+// into per-field errors clients can act on, in the language they accept,
+// instead of the messages of the validator
+package validation
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// The custom rules, used in binding tags like the built-in ones
+const (
+	// TagPassword requires upper and lower case letters and a digit; its
+	// length is checked with min
+	TagPassword = "password"
+	// TagPhone requires a phone number: an optional +, then 7 to 15 digits,
+	// which may be grouped with spaces, dots, dashes or parentheses
+	TagPhone = "phone"
+	// TagCountry requires an ISO 3166-1 alpha-2 country code, in either case
+	TagCountry = "country"
+)
+
+// phonePattern matches the characters a phone number is written with
+var phonePattern = regexp.MustCompile(`^\+?[0-9 ().-]+$`)
+
+// The digits a phone number has, those of E.164 numbers and of shorter
+// local ones
+const (
+	minPhoneDigits = 7
+	maxPhoneDigits = 15
+)
+
+// The rules are registered with the validator of Gin's binding when the
+// package is loaded, so every request bound after its handlers import it is
+// checked with them
+func init() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	register(validate)
+}
+
+// register adds the custom rules to validate and names fields after their
+// JSON keys, or query parameters, as clients know them
+func register(validate *validator.Validate) {
+	validate.RegisterTagNameFunc(fieldName)
+
+	validate.RegisterValidation(TagPassword, isStrongPassword)
+	validate.RegisterValidation(TagPhone, isPhone)
+	validate.RegisterValidation(TagCountry, func(fl validator.FieldLevel) bool {
+		return validate.Var(strings.ToUpper(fl.Field().String()), "iso3166_1_alpha2") == nil
+	})
+}
+
+// fieldName returns the name clients give field: its JSON key, else its
+// query parameter, else its Go name
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// isStrongPassword reports whether the field has upper and lower case
+// letters and a digit
+func isStrongPassword(fl validator.FieldLevel) bool {
+	var upper, lower, digit bool
+	for _, r := range fl.Field().String() {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return upper && lower && digit
+}
+
+// isPhone reports whether the field is written like a phone number
+func isPhone(fl validator.FieldLevel) bool {
+	phone := fl.Field().String()
+	if !phonePattern.MatchString(phone) {
+		return false
+	}
+
+	digits := 0
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	return digits >= minPhoneDigits && digits <= maxPhoneDigits
+}
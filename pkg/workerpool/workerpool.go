@@ -0,0 +1,247 @@
+// Package workerpool runs tasks concurrently on a bounded number of
+// workers. Submitting a task waits for a free worker, so producers slow down
+// to the pace tasks are done at instead of piling them up in memory. Tasks
+// run within a timeout, panics are recovered from, and the pool drains the
+// tasks in progress when the service stops.
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+)
+
+// The outcomes of tasks
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	OutcomeTimedOut  = "timed_out"
+	OutcomePanicked  = "panicked"
+)
+
+// ErrClosed is returned submitting tasks to a pool that was closed
+var ErrClosed = errors.New("worker pool closed")
+
+// Task is work run by a pool
+type Task func(ctx context.Context) error
+
+// Pool runs tasks on a bounded number of workers
+type Pool struct {
+	name    string
+	timeout time.Duration
+	logger  *logger.Logger
+
+	// workers holds a slot per task in progress
+	workers chan struct{}
+	// stop is cancelled when draining the pool took too long, to cancel the
+	// tasks still in progress
+	stop   context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	closed  bool
+	running sync.WaitGroup
+	waiting int
+
+	tasks    metrics.Counter
+	duration metrics.Histogram
+	busy     metrics.Gauge
+	queued   metrics.Gauge
+}
+
+// New creates a pool of size workers named name. Tasks taking longer than
+// timeout are cancelled; a zero timeout lets them run as long as the
+// context they were submitted with. registry may be nil.
+func New(name string, size int, timeout time.Duration, registry metrics.Registry, logger *logger.Logger) *Pool {
+	if size < 1 {
+		size = 1
+	}
+
+	stop, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		name:     name,
+		timeout:  timeout,
+		logger:   logger,
+		workers:  make(chan struct{}, size),
+		stop:     stop,
+		cancel:   cancel,
+		tasks:    noop{},
+		duration: noop{},
+		busy:     noop{},
+		queued:   noop{},
+	}
+	if registry != nil {
+		p.tasks = registry.NewCounter("worker_pool_tasks", "Tasks run by worker pools, by outcome", "pool", "outcome")
+		p.duration = registry.NewHistogram("worker_pool_task_duration_seconds", "Duration of the tasks run by worker pools", "pool")
+		p.busy = registry.NewGauge("worker_pool_busy_workers", "Workers of worker pools running a task", "pool")
+		p.queued = registry.NewGauge("worker_pool_queued_tasks", "Tasks waiting for a worker of worker pools", "pool")
+	}
+	return p
+}
+
+// Submit runs task on a worker, waiting for one to be free. The task runs
+// with ctx, and may outlive the call. It returns ctx's error if ctx ends
+// before a worker is free, and ErrClosed once the pool is closed.
+func (p *Pool) Submit(ctx context.Context, task Task) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrClosed
+	}
+	// Tasks waiting for a worker are drained along with those in progress
+	p.running.Add(1)
+	p.waiting++
+	p.queued.Set(float64(p.waiting), p.name)
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		p.waiting--
+		p.queued.Set(float64(p.waiting), p.name)
+		p.mu.Unlock()
+	}()
+
+	select {
+	case p.workers <- struct{}{}:
+	case <-ctx.Done():
+		p.running.Done()
+		return ctx.Err()
+	case <-p.stop.Done():
+		p.running.Done()
+		return ErrClosed
+	}
+
+	p.busy.Set(float64(len(p.workers)), p.name)
+	go func() {
+		defer func() {
+			<-p.workers
+			p.busy.Set(float64(len(p.workers)), p.name)
+			p.running.Done()
+		}()
+		p.run(ctx, task)
+	}()
+	return nil
+}
+
+// run runs task within the timeout of the pool, recording its outcome
+func (p *Pool) run(ctx context.Context, task Task) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer context.AfterFunc(p.stop, cancel)()
+
+	started := time.Now()
+	err := p.execute(ctx, task)
+
+	outcome := OutcomeSucceeded
+	var panicked *panicError
+	switch {
+	case errors.As(err, &panicked):
+		outcome = OutcomePanicked
+	case err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		outcome = OutcomeTimedOut
+	case err != nil:
+		outcome = OutcomeFailed
+	}
+
+	p.tasks.Inc(p.name, outcome)
+	p.duration.Observe(ctx, time.Since(started).Seconds(), p.name)
+	if err != nil {
+		p.logger.Error("Worker pool task failed", "error", err, "pool", p.name, "outcome", outcome)
+	}
+}
+
+// execute runs task, recovering from panics
+func (p *Pool) execute(ctx context.Context, task Task) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			p.logger.Desugar().WithOptions(zap.AddStacktrace(zapcore.ErrorLevel)).
+				Error("Panic recovered", zap.Any("panic", recovered), zap.String("pool", p.name))
+			err = &panicError{value: recovered}
+		}
+	}()
+
+	return task(ctx)
+}
+
+// Close stops the pool taking tasks and waits for the tasks submitted to
+// finish. Tasks still in progress when ctx ends are cancelled, and ctx's
+// error returned. Close fits lifecycle.Shutdown.Register.
+func (p *Pool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		p.running.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return fmt.Errorf("worker pool %s not drained: %w", p.name, ctx.Err())
+	}
+}
+
+// Group runs a batch of tasks on a pool and waits for them
+type Group struct {
+	pool *Pool
+	wg   sync.WaitGroup
+}
+
+// Group returns a group of tasks run on p
+func (p *Pool) Group() *Group {
+	return &Group{pool: p}
+}
+
+// Go submits task to the pool of g, as Pool.Submit does
+func (g *Group) Go(ctx context.Context, task Task) error {
+	g.wg.Add(1)
+	err := g.pool.Submit(ctx, func(ctx context.Context) error {
+		defer g.wg.Done()
+		return task(ctx)
+	})
+	if err != nil {
+		g.wg.Done()
+	}
+	return err
+}
+
+// Wait waits for the tasks submitted with g to finish
+func (g *Group) Wait() {
+	g.wg.Wait()
+}
+
+// panicError is the error of a task that panicked
+type panicError struct {
+	value interface{}
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+// noop records nothing, for pools created without a registry
+type noop struct{}
+
+func (noop) Inc(...string)                               {}
+func (noop) Add(float64, ...string)                      {}
+func (noop) Observe(context.Context, float64, ...string) {}
+func (noop) Set(float64, ...string)                      {}
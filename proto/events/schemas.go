@@ -0,0 +1,45 @@
+package eventspb
+
+import (
+	"embed"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+)
+
+// definitions holds the .proto files of the event payloads, registered with
+// the schema registry as they are
+//
+//go:embed *.proto
+var definitions embed.FS
+
+// Schemas of the event payloads of each domain. Subjects are the full names
+// of the messages; the generated descriptors aren't built yet when package
+// variables are initialized, so they are spelled out.
+var (
+	OrderEventSchema        = schema("order", "order_event.proto", "commercium.events.v1.OrderEvent", &OrderEvent{})
+	PaymentEventSchema      = schema("payment", "payment_event.proto", "commercium.events.v1.PaymentEvent", &PaymentEvent{})
+	ShipmentEventSchema     = schema("shipment", "shipment_event.proto", "commercium.events.v1.ShipmentEvent", &ShipmentEvent{})
+	ReviewEventSchema       = schema("review", "review_event.proto", "commercium.events.v1.ReviewEvent", &ReviewEvent{})
+	SubscriptionEventSchema = schema("subscription", "subscription_event.proto", "commercium.events.v1.SubscriptionEvent", &SubscriptionEvent{})
+	InventoryEventSchema    = schema("inventory", "inventory_event.proto", "commercium.events.v1.InventoryEvent", &InventoryEvent{})
+	ClickstreamEventSchema  = schema("clickstream", "clickstream_event.proto", "commercium.events.v1.ClickstreamEvent", &ClickstreamEvent{})
+)
+
+// schema builds the schema of the payloads of a domain from the file defining
+// its message
+func schema(domain, file, subject string, message proto.Message) *events.Schema {
+	definition, err := definitions.ReadFile(file)
+	if err != nil {
+		panic(fmt.Sprintf("eventspb: missing schema definition %s: %v", file, err))
+	}
+
+	return &events.Schema{
+		Domain:     domain,
+		Subject:    subject,
+		Definition: string(definition),
+		Message:    message,
+	}
+}
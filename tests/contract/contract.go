@@ -0,0 +1,125 @@
+// Package contract holds the contracts between the API gateway and the
+// services it proxies to: the requests the gateway forwards to each, with
+// the schemas of their JSON bodies and of those of the responses it relies
+// on them answering with. The gateway's tests record them, and fail when
+// they change without the contract files being updated; the services'
+// tests verify they still serve every request of their contract, reading
+// the bodies sent and answering with every property expected, so a route
+// or a field renamed or removed on either side fails the build instead of
+// requests at runtime.
+//
+// Contracts are kept in contracts/, one file per service, and rewritten
+// from the gateway with
+//
+//	go test ./tests/contract/ -run TestGatewayContracts -update
+//
+// The schemas of interactions already in a contract are kept as recorded;
+// those of new ones are recorded from the types the provider binds and
+// writes. A contract changed on purpose is edited, or the interaction
+// removed from its file and recorded again.
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Consumer is the service whose expectations the contracts hold
+const Consumer = "api-gateway"
+
+// Dir is the directory of the contract files, relative to this package
+const Dir = "contracts"
+
+// Contract is what a consumer expects of a provider
+type Contract struct {
+	Consumer     string        `json:"consumer"`
+	Provider     string        `json:"provider"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a request the consumer sends the provider. Path is a
+// route pattern: path parameters are named after a colon, e.g. /orders/:id.
+// Request and Response are the schemas of the JSON bodies of the request
+// and of its response, when they have one; query parameters aren't part of
+// the contract.
+type Interaction struct {
+	Method   string  `json:"method"`
+	Path     string  `json:"path"`
+	Request  *Schema `json:"request,omitempty"`
+	Response *Schema `json:"response,omitempty"`
+}
+
+// String returns the method and path of i, e.g. GET /orders/:id
+func (i Interaction) String() string {
+	return i.Method + " " + i.Path
+}
+
+// Route returns the method and path of i with its parameters unnamed, so
+// routes naming them differently on either side match
+func (i Interaction) Route() string {
+	return Route(i.Method, i.Path)
+}
+
+// Route returns the method and path of a route with its parameters
+// unnamed, e.g. GET /orders/: for GET /orders/:id
+func Route(method, path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = segment[:1]
+		}
+	}
+	return method + " " + strings.Join(segments, "/")
+}
+
+// Sort sorts the interactions of c by path, then method, so contract files
+// only change when the contract does
+func (c *Contract) Sort() {
+	sort.Slice(c.Interactions, func(i, j int) bool {
+		a, b := c.Interactions[i], c.Interactions[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	})
+}
+
+// File returns the path of the contract file of provider in dir
+func File(dir, provider string) string {
+	return filepath.Join(dir, Consumer+"-"+provider+".json")
+}
+
+// Load reads the contracts of dir
+func Load(dir string) ([]*Contract, error) {
+	files, err := filepath.Glob(filepath.Join(dir, Consumer+"-*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contracts: %w", err)
+	}
+
+	contracts := make([]*Contract, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read contract %s: %w", file, err)
+		}
+		var c Contract
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("failed to parse contract %s: %w", file, err)
+		}
+		contracts = append(contracts, &c)
+	}
+	return contracts, nil
+}
+
+// Encode returns c as written to its contract file
+func (c *Contract) Encode() ([]byte, error) {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode contract with %s: %w", c.Provider, err)
+	}
+	return append(data, '\n'), nil
+}
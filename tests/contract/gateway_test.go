@@ -0,0 +1,202 @@
+package contract_test
+
+import (
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/internal/api-gateway/server"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
+	"github.com/kaanevranportfolio/Commercium/tests/contract"
+)
+
+var update = flag.Bool("update", false, "rewrite the contract files from the gateway")
+
+// sampleID fills the ID parameters of routes
+const sampleID = "00000000-0000-4000-8000-000000000001"
+
+// recorder stands in for a provider, recording the requests the gateway
+// forwards it
+type recorder struct {
+	provider string
+	server   *httptest.Server
+
+	mu       sync.Mutex
+	requests []contract.Interaction
+}
+
+func newRecorder(t *testing.T, provider string) *recorder {
+	r := &recorder{provider: provider}
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		r.requests = append(r.requests, contract.Interaction{Method: req.Method, Path: req.URL.Path})
+		r.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+// take returns the requests recorded since it was last called
+func (r *recorder) take() []contract.Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	requests := r.requests
+	r.requests = nil
+	return requests
+}
+
+// TestGatewayContracts records the requests the gateway forwards to each
+// service, through every route it proxies, and checks they are those of
+// the contract files, each with the schemas of its bodies
+func TestGatewayContracts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	log := quietLogger(t)
+
+	// Every service the gateway proxies to is a recorder
+	cfg := &config.Config{Environment: "test"}
+	cfg.Auth.JWT.SecretKey = "contract-test-secret"
+	urls := map[string]*string{
+		"user-service":         &cfg.Services.UserURL,
+		"order-service":        &cfg.Services.OrderURL,
+		"payment-service":      &cfg.Services.PaymentURL,
+		"shipping-service":     &cfg.Services.ShippingURL,
+		"review-service":       &cfg.Services.ReviewURL,
+		"notification-service": &cfg.Services.NotificationURL,
+		"currency-service":     &cfg.Services.CurrencyURL,
+		"pricing-service":      &cfg.Services.PricingURL,
+		"subscription-service": &cfg.Services.SubscriptionURL,
+		"seller-service":       &cfg.Services.SellerURL,
+		"analytics-service":    &cfg.Services.AnalyticsURL,
+		"stock-alert-service":  &cfg.Services.StockAlertURL,
+	}
+	recorders := make(map[string]*recorder, len(urls))
+	for provider, url := range urls {
+		recorders[provider] = newRecorder(t, provider)
+		*url = recorders[provider].server.URL
+	}
+
+	registry, err := metrics.NewRegistry(config.MetricsConfig{}, "api-gateway")
+	require.NoError(t, err)
+	gateway, err := server.New(cfg, log, registry)
+	require.NoError(t, err)
+	defer gateway.Close()
+	// The gateway is served over HTTP, as its proxies stream responses
+	frontend := httptest.NewServer(gateway.Handler())
+	defer frontend.Close()
+
+	contracts := make(map[string]*contract.Contract, len(recorders))
+	for provider := range recorders {
+		contracts[provider] = &contract.Contract{Consumer: contract.Consumer, Provider: provider}
+	}
+
+	// The gateway checks the health of every service it proxies to
+	send(t, frontend, http.MethodGet, "/health")
+	for provider, r := range recorders {
+		for _, request := range r.take() {
+			contracts[provider].Interactions = append(contracts[provider].Interactions, request)
+		}
+	}
+
+	for _, route := range gateway.Handler().(*gin.Engine).Routes() {
+		if !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+
+		path := samplePath(route.Path)
+		send(t, frontend, route.Method, path)
+
+		// Routes reaching no service are the gateway's own
+		for provider, r := range recorders {
+			for _, request := range r.take() {
+				require.Equal(t, path, request.Path, "%s %s reached %s under another path", route.Method, route.Path, provider)
+				contracts[provider].Interactions = append(contracts[provider].Interactions,
+					contract.Interaction{Method: request.Method, Path: route.Path})
+			}
+		}
+	}
+
+	// The bodies of interactions already in the contracts are kept as
+	// recorded, as the gateway passes them through; those of new ones are
+	// recorded from the provider
+	recorded, err := contract.Load(contract.Dir)
+	require.NoError(t, err)
+	schemas := make(map[string]contract.Interaction)
+	for _, c := range recorded {
+		for _, interaction := range c.Interactions {
+			schemas[c.Provider+" "+interaction.String()] = interaction
+		}
+	}
+
+	for provider, c := range contracts {
+		for i, interaction := range c.Interactions {
+			if existing, ok := schemas[provider+" "+interaction.String()]; ok {
+				c.Interactions[i].Request, c.Interactions[i].Response = existing.Request, existing.Response
+				continue
+			}
+			b, ok := bodiesOf(interaction)
+			require.True(t, ok, "the bodies of %s of %s aren't known, add them to routeBodies", interaction, provider)
+			c.Interactions[i].Request, c.Interactions[i].Response = contract.SchemaOf(b.request), contract.SchemaOf(b.response)
+		}
+
+		c.Sort()
+		encoded, err := c.Encode()
+		require.NoError(t, err)
+
+		file := contract.File(contract.Dir, provider)
+		if *update {
+			require.NoError(t, os.MkdirAll(contract.Dir, 0o755))
+			require.NoError(t, os.WriteFile(file, encoded, 0o644))
+			continue
+		}
+
+		existing, err := os.ReadFile(file)
+		require.NoError(t, err, "no contract with %s, run the test with -update to write it", provider)
+		assert.Equal(t, string(existing), string(encoded),
+			"the gateway's contract with %s changed, run the test with -update to rewrite it", provider)
+	}
+}
+
+// send sends a request through the gateway, with what any route needs
+func send(t *testing.T, gateway *httptest.Server, method, path string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, gateway.URL+path, strings.NewReader("{}"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer contract-test")
+	req.Header.Set("Idempotency-Key", "contract-test")
+
+	resp, err := gateway.Client().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+// samplePath fills the parameters of a route pattern with sample values
+func samplePath(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		name, ok := strings.CutPrefix(segment, ":")
+		if !ok {
+			continue
+		}
+		if name == "id" || strings.HasSuffix(name, "Id") {
+			segments[i] = sampleID
+		} else {
+			segments[i] = "sample-" + strings.ToLower(name)
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,129 @@
+package contract_test
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	analytics "github.com/kaanevranportfolio/Commercium/internal/analytics/handlers"
+	currency "github.com/kaanevranportfolio/Commercium/internal/currency/handlers"
+	notification "github.com/kaanevranportfolio/Commercium/internal/notification/handlers"
+	order "github.com/kaanevranportfolio/Commercium/internal/order/handlers"
+	payment "github.com/kaanevranportfolio/Commercium/internal/payment/handlers"
+	pricing "github.com/kaanevranportfolio/Commercium/internal/pricing/handlers"
+	review "github.com/kaanevranportfolio/Commercium/internal/review/handlers"
+	seller "github.com/kaanevranportfolio/Commercium/internal/seller/handlers"
+	shipping "github.com/kaanevranportfolio/Commercium/internal/shipping/handlers"
+	stockalert "github.com/kaanevranportfolio/Commercium/internal/stockalert/handlers"
+	subscription "github.com/kaanevranportfolio/Commercium/internal/subscription/handlers"
+	user "github.com/kaanevranportfolio/Commercium/internal/user/handlers"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/health"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/taskqueue"
+	"github.com/kaanevranportfolio/Commercium/tests/contract"
+)
+
+// provider sets up the routes of a service on router, as its main does
+type provider func(router *gin.Engine, jwtService *auth.JWTService, log *logger.Logger)
+
+// providers are the services the gateway proxies to, by name
+var providers = map[string]provider{
+	"user-service": func(router *gin.Engine, jwtService *auth.JWTService, log *logger.Logger) {
+		user.NewUserHandler(nil, jwtService).SetupRoutes(router)
+	},
+	"order-service": func(router *gin.Engine, jwtService *auth.JWTService, log *logger.Logger) {
+		order.NewOrderHandler(nil, jwtService, log).SetupRoutes(router)
+	},
+	"payment-service": func(router *gin.Engine, jwtService *auth.JWTService, log *logger.Logger) {
+		payment.NewPaymentHandler(nil, jwtService, nil, log).SetupRoutes(router)
+	},
+	"shipping-service": func(router *gin.Engine, jwtService *auth.JWTService, log *logger.Logger) {
+		shipping.NewShippingHandler(nil, jwtService, log).SetupRoutes(router)
+	},
+	"review-service": func(router *gin.Engine, jwtService *auth.JWTService, log *logger.Logger) {
+		review.NewReviewHandler(nil, jwtService, log).SetupRoutes(router)
+	},
+	"notification-service": func(router *gin.Engine, jwtService *auth.JWTService, log *logger.Logger) {
+		notification.NewNotificationHandler(nil, jwtService, log).SetupRoutes(router)
+	},
+	"currency-service": func(router *gin.Engine, jwtService *auth.JWTService, log *logger.Logger) {
+		currency.NewCurrencyHandler(nil, jwtService, log).SetupRoutes(router)
+	},
+	"pricing-service": func(router *gin.Engine, jwtService *auth.JWTService, log *logger.Logger) {
+		pricing.NewPricingHandler(nil, jwtService, log).SetupRoutes(router)
+	},
+	"subscription-service": func(router *gin.Engine, jwtService *auth.JWTService, log *logger.Logger) {
+		subscription.NewSubscriptionHandler(nil, jwtService, log).SetupRoutes(router)
+	},
+	"seller-service": func(router *gin.Engine, jwtService *auth.JWTService, log *logger.Logger) {
+		seller.NewSellerHandler(nil, jwtService, log).SetupRoutes(router)
+
+		// The routes of background tasks, mounted when Redis is available
+		redis := &database.Redis{Client: goredis.NewClient(&goredis.Options{})}
+		admin := router.Group("/api/v1/admin", jwtService.Middleware(), auth.RequireRole("admin"))
+		taskqueue.NewAdmin(redis, log).Routes(admin)
+	},
+	"analytics-service": func(router *gin.Engine, jwtService *auth.JWTService, log *logger.Logger) {
+		analytics.NewAnalyticsHandler(nil, jwtService, log).SetupRoutes(router)
+	},
+	"stock-alert-service": func(router *gin.Engine, jwtService *auth.JWTService, log *logger.Logger) {
+		stockalert.NewStockAlertHandler(nil, jwtService, log).SetupRoutes(router)
+	},
+}
+
+// TestProviderContracts checks every service serves the requests of the
+// gateway's contract with it, reading the request bodies the gateway
+// forwards and answering with the bodies it expects
+func TestProviderContracts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := quietLogger(t)
+	jwtService := auth.NewJWTService(&config.JWTConfig{SecretKey: "contract-test-secret"})
+
+	contracts, err := contract.Load(contract.Dir)
+	require.NoError(t, err)
+	require.NotEmpty(t, contracts, "no contracts in %s", contract.Dir)
+
+	for _, c := range contracts {
+		t.Run(c.Provider, func(t *testing.T) {
+			setup, ok := providers[c.Provider]
+			require.True(t, ok, "the gateway has a contract with %s, which isn't known", c.Provider)
+
+			router := gin.New()
+			health.NewRegistry(c.Provider, "").Routes(router)
+			setup(router, jwtService, log)
+
+			served := make(map[string]bool)
+			for _, route := range router.Routes() {
+				served[contract.Route(route.Method, route.Path)] = true
+			}
+			for _, interaction := range c.Interactions {
+				assert.True(t, served[interaction.Route()], "%s doesn't serve %s", c.Provider, interaction)
+
+				b, ok := bodiesOf(interaction)
+				if !assert.True(t, ok, "the bodies of %s of %s aren't known, add them to routeBodies", interaction, c.Provider) {
+					continue
+				}
+				problems := append(contract.CheckRequest(interaction.Request, contract.SchemaOf(b.request)),
+					contract.CheckResponse(interaction.Response, contract.SchemaOf(b.response))...)
+				assert.Empty(t, problems, "%s breaks the contract of %s", c.Provider, interaction)
+			}
+		})
+	}
+}
+
+// quietLogger logs errors only
+func quietLogger(t *testing.T) *logger.Logger {
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "error",
+		Format: "json",
+		Output: "stdout",
+	}, "contract-test")
+	require.NoError(t, err)
+	return log
+}
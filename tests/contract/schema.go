@@ -0,0 +1,241 @@
+package contract
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Types of schemas, those of JSON Schema
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	// TypeAny is the type of values whose shape isn't known, such as raw
+	// JSON passed through
+	TypeAny = "any"
+)
+
+// Schema is the shape of a JSON value, a subset of JSON Schema
+type Schema struct {
+	Type string `json:"type"`
+	// Properties are the properties of an object, by name
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	// Required are the properties a request is refused without
+	Required []string `json:"required,omitempty"`
+	// AdditionalProperties is the schema of the values of an object used as
+	// a map
+	AdditionalProperties *Schema `json:"additionalProperties,omitempty"`
+	// Items is the schema of the items of an array
+	Items *Schema `json:"items,omitempty"`
+}
+
+var (
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// SchemaOf returns the schema of v encoded as JSON, nil for a nil v. The
+// properties of structs are named by their json tags, and those bound with
+// binding:"required" are required.
+func SchemaOf(v interface{}) *Schema {
+	if v == nil {
+		return nil
+	}
+	return schemaOf(reflect.TypeOf(v), make(map[reflect.Type]bool))
+}
+
+// schemaOf returns the schema of values of t. seen holds the structs being
+// described, so recursive ones end.
+func schemaOf(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	// Types encoding themselves are strings when they encode as text, such
+	// as times and UUIDs, and unknown otherwise
+	switch {
+	case implements(t, textMarshaler):
+		return &Schema{Type: TypeString}
+	case implements(t, jsonMarshaler):
+		return &Schema{Type: TypeAny}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: TypeBoolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: TypeInteger}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: TypeNumber}
+	case reflect.String:
+		return &Schema{Type: TypeString}
+	case reflect.Slice, reflect.Array:
+		// Bytes are encoded as base64
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: TypeString}
+		}
+		return &Schema{Type: TypeArray, Items: schemaOf(t.Elem(), seen)}
+	case reflect.Map:
+		return &Schema{Type: TypeObject, AdditionalProperties: schemaOf(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return &Schema{Type: TypeObject}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		schema := &Schema{Type: TypeObject, Properties: make(map[string]*Schema)}
+		addFields(schema, t, seen)
+		sort.Strings(schema.Required)
+		return schema
+	default:
+		return &Schema{Type: TypeAny}
+	}
+}
+
+// addFields adds the fields of struct t to the properties of schema, those
+// of embedded structs without a name of their own included
+func addFields(schema *Schema, t reflect.Type, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && !implements(embedded, textMarshaler) && !implements(embedded, jsonMarshaler) {
+				addFields(schema, embedded, seen)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = schemaOf(field.Type, seen)
+		for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+			if rule == "required" {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+	}
+}
+
+// implements reports whether values of t, or pointers to them, implement
+// iface
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// CheckRequest returns how a provider taking request bodies of schema
+// accepted falls short of a contract whose consumer sends sent: properties
+// sent it doesn't take, or takes as another type, and properties it
+// requires that aren't sent
+func CheckRequest(sent, accepted *Schema) []string {
+	var problems []string
+	switch {
+	case sent == nil && accepted == nil:
+	case sent == nil:
+		for _, name := range accepted.Required {
+			problems = append(problems, fmt.Sprintf("request.%s is required but the request has no body", name))
+		}
+	case accepted == nil:
+		problems = append(problems, "the request has a body, which isn't read")
+	default:
+		check("request", sent, accepted, true, &problems)
+	}
+	return problems
+}
+
+// CheckResponse returns how a provider answering with response bodies of
+// schema provided falls short of a contract whose consumer expects
+// expected: properties expected it leaves out, or answers with as another
+// type
+func CheckResponse(expected, provided *Schema) []string {
+	var problems []string
+	switch {
+	case expected == nil:
+	case provided == nil:
+		problems = append(problems, "the response has no body")
+	default:
+		check("response", expected, provided, false, &problems)
+	}
+	return problems
+}
+
+// check adds to problems how provider's schema of the value at path falls
+// short of the consumer's
+func check(path string, consumer, provider *Schema, request bool, problems *[]string) {
+	if !compatible(consumer.Type, provider.Type, request) {
+		*problems = append(*problems, fmt.Sprintf("%s is a %s, not a %s", path, provider.Type, consumer.Type))
+		return
+	}
+
+	if consumer.Items != nil && provider.Items != nil {
+		check(path+"[]", consumer.Items, provider.Items, request, problems)
+	}
+	if consumer.AdditionalProperties != nil && provider.AdditionalProperties != nil {
+		check(path+"{}", consumer.AdditionalProperties, provider.AdditionalProperties, request, problems)
+	}
+
+	for _, name := range sortedKeys(consumer.Properties) {
+		property, ok := provider.Properties[name]
+		if !ok {
+			if request {
+				*problems = append(*problems, fmt.Sprintf("%s.%s is sent but not read", path, name))
+			} else {
+				*problems = append(*problems, fmt.Sprintf("%s.%s is expected but left out", path, name))
+			}
+			continue
+		}
+		check(path+"."+name, consumer.Properties[name], property, request, problems)
+	}
+	if request {
+		for _, name := range provider.Required {
+			if _, ok := consumer.Properties[name]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s.%s is required but not sent", path, name))
+			}
+		}
+	}
+}
+
+// compatible reports whether values of the consumer's type are read as
+// the provider's, or the other way around for responses: integers are
+// numbers, and values of unknown type are anything
+func compatible(consumer, provider string, request bool) bool {
+	if consumer == provider || consumer == TypeAny || provider == TypeAny {
+		return true
+	}
+	if request {
+		return consumer == TypeInteger && provider == TypeNumber
+	}
+	return consumer == TypeNumber && provider == TypeInteger
+}
+
+// sortedKeys returns the names of properties in order, so problems are
+// reported in the same order on every run
+func sortedKeys(properties map[string]*Schema) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,232 @@
+package contract_test
+
+import (
+	analytics "github.com/kaanevranportfolio/Commercium/internal/analytics/models"
+	currency "github.com/kaanevranportfolio/Commercium/internal/currency/models"
+	notification "github.com/kaanevranportfolio/Commercium/internal/notification/models"
+	order "github.com/kaanevranportfolio/Commercium/internal/order/models"
+	payment "github.com/kaanevranportfolio/Commercium/internal/payment/models"
+	pricing "github.com/kaanevranportfolio/Commercium/internal/pricing/models"
+	review "github.com/kaanevranportfolio/Commercium/internal/review/models"
+	seller "github.com/kaanevranportfolio/Commercium/internal/seller/models"
+	shipping "github.com/kaanevranportfolio/Commercium/internal/shipping/models"
+	stockalert "github.com/kaanevranportfolio/Commercium/internal/stockalert/models"
+	subscription "github.com/kaanevranportfolio/Commercium/internal/subscription/models"
+	user "github.com/kaanevranportfolio/Commercium/internal/user/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/health"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/taskqueue"
+	"github.com/kaanevranportfolio/Commercium/tests/contract"
+
+	"strings"
+)
+
+// bodies are the JSON bodies a route of a provider reads and answers with,
+// nil for none: values of the types its handler binds and writes
+type bodies struct {
+	request  interface{}
+	response interface{}
+}
+
+// The bodies of the responses handlers write as gin.H
+type (
+	message struct {
+		Message string `json:"message"`
+	}
+	userMessage struct {
+		Message string             `json:"message"`
+		User    *user.UserResponse `json:"user"`
+	}
+	addressMessage struct {
+		Message string            `json:"message"`
+		Address *user.UserAddress `json:"address"`
+	}
+	orderMessage struct {
+		Message string       `json:"message"`
+		Order   *order.Order `json:"order"`
+	}
+	refundMessage struct {
+		Message string        `json:"message"`
+		Refund  *order.Refund `json:"refund"`
+	}
+	paymentMessage struct {
+		Message string           `json:"message"`
+		Payment *payment.Payment `json:"payment"`
+	}
+	assessmentMessage struct {
+		Message    string                   `json:"message"`
+		Assessment *payment.FraudAssessment `json:"assessment"`
+	}
+	refreshedRates struct {
+		Rates int `json:"rates"`
+	}
+	queues struct {
+		Queues []*taskqueue.QueueSummary `json:"queues"`
+	}
+	failedTasks struct {
+		Tasks    []*taskqueue.FailedTask `json:"tasks"`
+		Page     int                     `json:"page"`
+		PageSize int                     `json:"page_size"`
+	}
+)
+
+// refreshRequest is the body the user service binds to refresh tokens
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// routeBodies are the bodies of the routes the gateway proxies to, by
+// method and path as the providers serve them
+var routeBodies = map[string]bodies{
+	"GET /health": {response: health.Report{}},
+
+	// analytics-service
+	"GET /api/v1/admin/analytics/orders":       {response: analytics.OrdersReport{}},
+	"GET /api/v1/admin/analytics/revenue":      {response: analytics.RevenueReport{}},
+	"GET /api/v1/admin/analytics/conversion":   {response: analytics.ConversionReport{}},
+	"GET /api/v1/admin/analytics/new-users":    {response: analytics.NewUsersReport{}},
+	"GET /api/v1/admin/analytics/top-products": {response: analytics.TopProductsReport{}},
+	"POST /api/v1/admin/analytics/refresh":     {response: analytics.RefreshResponse{}},
+
+	// currency-service
+	"GET /api/v1/currencies":                    {response: currency.CurrenciesResponse{}},
+	"GET /api/v1/currencies/preference":         {response: currency.CurrencyPreference{}},
+	"PUT /api/v1/currencies/preference":         {currency.SetPreferenceRequest{}, currency.CurrencyPreference{}},
+	"DELETE /api/v1/currencies/preference":      {response: message{}},
+	"GET /api/v1/exchange-rates":                {response: currency.RatesResponse{}},
+	"GET /api/v1/exchange-rates/convert":        {response: currency.Conversion{}},
+	"POST /api/v1/admin/exchange-rates/refresh": {response: refreshedRates{}},
+
+	// notification-service
+	"GET /api/v1/admin/email-templates":                                                  {response: httpx.ListResponse[*notification.TemplateSummary]{}},
+	"POST /api/v1/admin/email-templates":                                                 {notification.CreateTemplateRequest{}, notification.EmailTemplate{}},
+	"GET /api/v1/admin/email-templates/:key/locales/:locale/versions":                    {response: httpx.ListResponse[*notification.EmailTemplate]{}},
+	"POST /api/v1/admin/email-templates/:key/locales/:locale/versions/:version/activate": {response: notification.EmailTemplate{}},
+	"POST /api/v1/admin/email-templates/:key/preview":                                    {notification.PreviewRequest{}, notification.RenderedEmail{}},
+	"POST /api/v1/admin/email-templates/:key/test-send":                                  {notification.TestSendRequest{}, notification.RenderedEmail{}},
+
+	// order-service
+	"GET /api/v1/orders":                           {response: order.OrderListResponse{}},
+	"GET /api/v1/orders/:id":                       {response: order.Order{}},
+	"POST /api/v1/orders/:id/cancel":               {order.CancelOrderRequest{}, orderMessage{}},
+	"POST /api/v1/orders/:id/refunds":              {order.CreateRefundRequest{}, refundMessage{}},
+	"GET /api/v1/orders/:id/refunds":               {response: httpx.ListResponse[*order.Refund]{}},
+	"GET /api/v1/orders/:id/invoice":               {response: order.InvoiceResponse{}},
+	"POST /api/v1/checkout":                        {order.PlaceOrderRequest{}, order.Order{}},
+	"POST /api/v1/checkout/totals":                 {order.CheckoutTotalsRequest{}, order.CheckoutTotals{}},
+	"GET /api/v1/admin/orders":                     {response: order.AdminOrderListResponse{}},
+	"GET /api/v1/admin/tax-exemptions/:user_id":    {response: order.TaxExemption{}},
+	"PUT /api/v1/admin/tax-exemptions/:user_id":    {order.TaxExemptionRequest{}, order.TaxExemption{}},
+	"DELETE /api/v1/admin/tax-exemptions/:user_id": {response: message{}},
+
+	// payment-service
+	"POST /api/v1/payments":                     {payment.AuthorizePaymentRequest{}, paymentMessage{}},
+	"GET /api/v1/payments/:id":                  {response: payment.Payment{}},
+	"POST /api/v1/gift-cards/balance":           {payment.GiftCardBalanceRequest{}, payment.GiftCardBalance{}},
+	"GET /api/v1/admin/fraud/reviews":           {response: httpx.ListResponse[*payment.FraudAssessment]{}},
+	"POST /api/v1/admin/fraud/reviews/:id":      {payment.FraudReviewRequest{}, assessmentMessage{}},
+	"POST /api/v1/admin/gift-cards":             {payment.IssueGiftCardRequest{}, payment.IssuedGiftCard{}},
+	"GET /api/v1/admin/gift-cards/:id":          {response: payment.GiftCard{}},
+	"POST /api/v1/admin/gift-cards/:id/disable": {response: payment.GiftCard{}},
+
+	// pricing-service
+	"GET /api/v1/prices":                                   {response: pricing.ResolvePricesResponse{}},
+	"GET /api/v1/admin/price-lists":                        {response: httpx.ListResponse[*pricing.PriceList]{}},
+	"POST /api/v1/admin/price-lists":                       {pricing.CreatePriceListRequest{}, pricing.PriceList{}},
+	"GET /api/v1/admin/price-lists/:id":                    {response: pricing.PriceList{}},
+	"PUT /api/v1/admin/price-lists/:id":                    {pricing.UpdatePriceListRequest{}, pricing.PriceList{}},
+	"GET /api/v1/admin/price-lists/:id/prices":             {response: httpx.ListResponse[*pricing.Price]{}},
+	"POST /api/v1/admin/price-lists/:id/prices":            {pricing.BulkPricesRequest{}, pricing.BulkPricesResponse{}},
+	"DELETE /api/v1/admin/price-lists/:id/prices/:priceId": {response: message{}},
+	"GET /api/v1/admin/customer-groups/:userId":            {response: pricing.CustomerGroup{}},
+	"PUT /api/v1/admin/customer-groups/:userId":            {pricing.SetCustomerGroupRequest{}, pricing.CustomerGroup{}},
+	"DELETE /api/v1/admin/customer-groups/:userId":         {response: message{}},
+
+	// review-service
+	"GET /api/v1/products/:id/reviews":          {response: review.ReviewListResponse{}},
+	"GET /api/v1/products/:id/rating":           {response: review.ProductRating{}},
+	"POST /api/v1/products/:id/reviews":         {review.SubmitReviewRequest{}, review.Review{}},
+	"PUT /api/v1/reviews/:id":                   {review.SubmitReviewRequest{}, review.Review{}},
+	"DELETE /api/v1/reviews/:id":                {},
+	"POST /api/v1/reviews/:id/votes":            {review.VoteRequest{}, review.PublicReview{}},
+	"DELETE /api/v1/reviews/:id/votes":          {response: review.PublicReview{}},
+	"GET /api/v1/admin/reviews":                 {response: httpx.ListResponse[*review.Review]{}},
+	"POST /api/v1/admin/reviews/:id/moderation": {review.ModerationRequest{}, review.Review{}},
+
+	// seller-service
+	"POST /api/v1/sellers":                                 {seller.ApplyRequest{}, seller.Seller{}},
+	"GET /api/v1/sellers/me":                               {response: seller.Seller{}},
+	"PUT /api/v1/seller/profile":                           {seller.UpdateProfileRequest{}, seller.Seller{}},
+	"GET /api/v1/seller/products":                          {response: httpx.ListResponse[*seller.SellerProduct]{}},
+	"POST /api/v1/seller/products":                         {seller.RegisterProductRequest{}, seller.SellerProduct{}},
+	"GET /api/v1/seller/orders":                            {response: seller.SellerOrderListResponse{}},
+	"GET /api/v1/seller/orders/:id":                        {response: seller.SellerOrder{}},
+	"GET /api/v1/seller/statements":                        {response: httpx.ListResponse[*seller.Statement]{}},
+	"GET /api/v1/seller/statements/:id":                    {response: seller.Statement{}},
+	"GET /api/v1/admin/sellers":                            {response: httpx.ListResponse[*seller.Seller]{}},
+	"GET /api/v1/admin/sellers/:id":                        {response: seller.Seller{}},
+	"POST /api/v1/admin/sellers/:id/review":                {seller.ReviewSellerRequest{}, seller.Seller{}},
+	"PUT /api/v1/admin/sellers/:id/commission":             {seller.SetCommissionRequest{}, seller.Seller{}},
+	"GET /api/v1/admin/sellers/:id/statements":             {response: httpx.ListResponse[*seller.Statement]{}},
+	"POST /api/v1/admin/sellers/:id/statements":            {seller.GenerateStatementsRequest{}, seller.GenerateStatementsResponse{}},
+	"POST /api/v1/admin/seller-statements":                 {seller.GenerateStatementsRequest{}, seller.GenerateStatementsResponse{}},
+	"GET /api/v1/admin/seller-statements/:id":              {response: seller.Statement{}},
+	"POST /api/v1/admin/seller-statements/:id/paid":        {seller.MarkPaidRequest{}, seller.Statement{}},
+	"GET /api/v1/admin/tasks/queues":                       {response: queues{}},
+	"GET /api/v1/admin/tasks/queues/:queue/failed":         {response: failedTasks{}},
+	"POST /api/v1/admin/tasks/queues/:queue/tasks/:id/run": {},
+	"DELETE /api/v1/admin/tasks/queues/:queue/tasks/:id":   {},
+
+	// shipping-service
+	"POST /api/v1/shipping/rates":     {shipping.RateRequest{}, shipping.RateResponse{}},
+	"GET /api/v1/orders/:id/tracking": {response: shipping.TrackingResponse{}},
+
+	// stock-alert-service
+	"POST /api/v1/stock-alerts":       {stockalert.CreateAlertRequest{}, stockalert.StockAlert{}},
+	"GET /api/v1/stock-alerts":        {response: httpx.ListResponse[*stockalert.StockAlert]{}},
+	"DELETE /api/v1/stock-alerts/:id": {response: stockalert.StockAlert{}},
+
+	// subscription-service
+	"GET /api/v1/subscription-plans":               {response: httpx.ListResponse[*subscription.Plan]{}},
+	"GET /api/v1/subscription-plans/:id":           {response: subscription.Plan{}},
+	"GET /api/v1/subscriptions":                    {response: httpx.ListResponse[*subscription.Subscription]{}},
+	"POST /api/v1/subscriptions":                   {subscription.SubscribeRequest{}, subscription.Subscription{}},
+	"GET /api/v1/subscriptions/:id":                {response: subscription.Subscription{}},
+	"GET /api/v1/subscriptions/:id/renewals":       {response: httpx.ListResponse[*subscription.Renewal]{}},
+	"PUT /api/v1/subscriptions/:id/plan":           {subscription.ChangePlanRequest{}, subscription.Subscription{}},
+	"POST /api/v1/subscriptions/:id/pause":         {subscription.PauseRequest{}, subscription.Subscription{}},
+	"POST /api/v1/subscriptions/:id/resume":        {response: subscription.Subscription{}},
+	"POST /api/v1/subscriptions/:id/cancel":        {subscription.CancelRequest{}, subscription.Subscription{}},
+	"PUT /api/v1/subscriptions/:id/payment-method": {subscription.UpdatePaymentMethodRequest{}, subscription.Subscription{}},
+	"GET /api/v1/admin/subscription-plans":         {response: httpx.ListResponse[*subscription.Plan]{}},
+	"POST /api/v1/admin/subscription-plans":        {subscription.CreatePlanRequest{}, subscription.Plan{}},
+	"PUT /api/v1/admin/subscription-plans/:id":     {subscription.UpdatePlanRequest{}, subscription.Plan{}},
+
+	// user-service
+	"POST /api/v1/auth/register":             {user.CreateUserRequest{}, userMessage{}},
+	"POST /api/v1/auth/login":                {user.LoginRequest{}, user.AuthTokens{}},
+	"POST /api/v1/auth/refresh":              {refreshRequest{}, user.AuthTokens{}},
+	"POST /api/v1/auth/forgot-password":      {user.ForgotPasswordRequest{}, message{}},
+	"POST /api/v1/auth/reset-password":       {user.ResetPasswordRequest{}, message{}},
+	"GET /api/v1/auth/verify-email":          {response: message{}},
+	"GET /api/v1/users/profile":              {response: user.UserResponse{}},
+	"PUT /api/v1/users/profile":              {user.UpdateUserRequest{}, userMessage{}},
+	"POST /api/v1/users/change-password":     {user.ChangePasswordRequest{}, message{}},
+	"POST /api/v1/users/resend-verification": {response: message{}},
+	"GET /api/v1/users/addresses":            {response: httpx.ListResponse[*user.UserAddress]{}},
+	"POST /api/v1/users/addresses":           {user.UserAddress{}, addressMessage{}},
+	"PUT /api/v1/users/addresses/:id":        {user.UserAddress{}, addressMessage{}},
+	"DELETE /api/v1/users/addresses/:id":     {response: message{}},
+}
+
+// bodiesOf returns the bodies of the route of interaction, and whether
+// they are known
+func bodiesOf(interaction contract.Interaction) (bodies, bool) {
+	for route, b := range routeBodies {
+		method, path, _ := strings.Cut(route, " ")
+		if contract.Route(method, path) == interaction.Route() {
+			return b, true
+		}
+	}
+	return bodies{}, false
+}
@@ -0,0 +1,257 @@
+package analytics_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/internal/analytics/handlers"
+	"github.com/kaanevranportfolio/Commercium/internal/analytics/models"
+	"github.com/kaanevranportfolio/Commercium/internal/analytics/repository"
+	"github.com/kaanevranportfolio/Commercium/internal/analytics/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/tests/integration/testenv"
+)
+
+// TestSuite holds the test dependencies
+type TestSuite struct {
+	db         *database.DB
+	router     *gin.Engine
+	jwtService *auth.JWTService
+	userIDs    []uuid.UUID
+	orderIDs   []uuid.UUID
+}
+
+func setupTestSuite(t *testing.T) *TestSuite {
+	cfg := &config.Config{
+		Auth: config.AuthConfig{
+			JWT: config.JWTConfig{
+				SecretKey:         "test-secret-key-for-testing-only",
+				Issuer:            "commercium-test",
+				Expiration:        15 * time.Minute,
+				RefreshExpiration: 24 * time.Hour,
+			},
+		},
+		Services: config.ServicesConfig{
+			Analytics: config.AnalyticsServiceConfig{
+				RefreshInterval: 15 * time.Minute,
+				MaxRangeDays:    731,
+			},
+		},
+	}
+
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "info",
+		Format: "json",
+		Output: "stdout",
+	}, "analytics-service-test")
+	require.NoError(t, err)
+
+	// Each test gets a database of its own (skipped if not available)
+	db := testenv.Database(t, log)
+
+	jwtService := auth.NewJWTService(&cfg.Auth.JWT)
+
+	analyticsRepo := repository.NewAnalyticsRepository(db, log)
+	analyticsService := service.NewAnalyticsService(analyticsRepo, cfg, log)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService, jwtService, log)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apperrors.Middleware())
+	analyticsHandler.SetupRoutes(router)
+
+	return &TestSuite{
+		db:         db,
+		router:     router,
+		jwtService: jwtService,
+	}
+}
+
+func (ts *TestSuite) cleanup() {
+	for _, orderID := range ts.orderIDs {
+		ts.db.Exec(`DELETE FROM orders WHERE id = $1`, orderID)
+	}
+	for _, userID := range ts.userIDs {
+		ts.db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	}
+}
+
+// seedUser creates a user signed up at the given time and returns an access token for it
+func (ts *TestSuite) seedUser(t *testing.T, role string, createdAt time.Time) (uuid.UUID, string) {
+	userID := uuid.New()
+	_, err := ts.db.Exec(`INSERT INTO users (id, username, email, password_hash, role, created_at) VALUES ($1, $2, $3, 'x', $4, $5)`,
+		userID, "analytics_"+userID.String()[:8], userID.String()[:8]+"@example.com", role, createdAt)
+	require.NoError(t, err)
+	ts.userIDs = append(ts.userIDs, userID)
+
+	tokens, err := ts.jwtService.GenerateTokenPair(userID, userID.String()[:8]+"@example.com", "analytics_"+userID.String()[:8], role, "")
+	require.NoError(t, err)
+	return userID, tokens.AccessToken
+}
+
+// seedOrder places an order of one item at the given time
+func (ts *TestSuite) seedOrder(t *testing.T, userID uuid.UUID, status, currency string, placedAt time.Time, productID uuid.UUID, quantity int, unitPrice, refunded int64) {
+	orderID := uuid.New()
+	total := unitPrice * int64(quantity)
+	_, err := ts.db.Exec(`
+		INSERT INTO orders (id, order_number, user_id, status, currency, subtotal_amount, total_amount, refunded_amount, placed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6, $7, $8)`,
+		orderID, "ORD-"+orderID.String()[:8], userID, status, currency, total, refunded, placedAt)
+	require.NoError(t, err)
+	ts.orderIDs = append(ts.orderIDs, orderID)
+
+	_, err = ts.db.Exec(`
+		INSERT INTO order_items (order_id, product_id, sku, name, quantity, unit_price, total_price)
+		VALUES ($1, $2, $3, $3, $4, $5, $6)`,
+		orderID, productID, "SKU-"+productID.String()[:8], quantity, unitPrice, total)
+	require.NoError(t, err)
+}
+
+func (ts *TestSuite) get(path, token string, out interface{}) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, req)
+	if out != nil && w.Code == http.StatusOK {
+		json.Unmarshal(w.Body.Bytes(), out)
+	}
+	return w
+}
+
+func TestAnalyticsReportsIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	// Seed a quiet week long ago so other data doesn't show up in the reports
+	monday := time.Date(2001, time.January, 1, 12, 0, 0, 0, time.UTC)
+	tuesday := monday.AddDate(0, 0, 1)
+	nextMonday := monday.AddDate(0, 0, 7)
+
+	_, admin := ts.seedUser(t, "admin", time.Now())
+	customerID, customer := ts.seedUser(t, "customer", monday)
+	otherID, _ := ts.seedUser(t, "customer", tuesday)
+
+	widget := uuid.New()
+	gadget := uuid.New()
+	ts.seedOrder(t, customerID, "delivered", "USD", monday, widget, 2, 1000, 500)
+	ts.seedOrder(t, customerID, "pending", "USD", monday, widget, 1, 1000, 0)
+	ts.seedOrder(t, otherID, "confirmed", "USD", tuesday, gadget, 1, 5000, 0)
+	ts.seedOrder(t, otherID, "cancelled", "USD", tuesday, gadget, 3, 5000, 0)
+	ts.seedOrder(t, otherID, "shipped", "EUR", nextMonday, widget, 5, 900, 0)
+
+	query := "?from=2001-01-01&to=2001-01-08"
+
+	t.Run("Reports are admin only", func(t *testing.T) {
+		w := ts.get("/api/v1/admin/analytics/orders"+query, customer, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Refresh picks up new data", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/analytics/refresh", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", admin))
+		w := httptest.NewRecorder()
+		ts.router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response models.RefreshResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.WithinDuration(t, time.Now(), response.RefreshedAt, time.Minute)
+	})
+
+	t.Run("Orders per day are gap-filled", func(t *testing.T) {
+		var report models.OrdersReport
+		w := ts.get("/api/v1/admin/analytics/orders"+query, admin, &report)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		assert.Equal(t, models.GranularityDay, report.Granularity)
+		require.Len(t, report.Series, 8)
+		assert.Equal(t, "2001-01-01", report.Series[0].Period)
+		assert.Equal(t, int64(2), report.Series[0].Orders)
+		assert.Equal(t, int64(1), report.Series[0].PaidOrders)
+		assert.Equal(t, int64(0), report.Series[3].Orders)
+		assert.Equal(t, int64(5), report.TotalOrders)
+	})
+
+	t.Run("Orders roll up to weeks", func(t *testing.T) {
+		var report models.OrdersReport
+		w := ts.get("/api/v1/admin/analytics/orders"+query+"&granularity=week", admin, &report)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		require.Len(t, report.Series, 2)
+		assert.Equal(t, "2001-01-01", report.Series[0].Period)
+		assert.Equal(t, int64(4), report.Series[0].Orders)
+		assert.Equal(t, "2001-01-08", report.Series[1].Period)
+		assert.Equal(t, int64(1), report.Series[1].Orders)
+	})
+
+	t.Run("Revenue is reported per currency", func(t *testing.T) {
+		var report models.RevenueReport
+		w := ts.get("/api/v1/admin/analytics/revenue"+query+"&granularity=month", admin, &report)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		require.Len(t, report.Series["USD"], 1)
+		usd := report.Series["USD"][0]
+		assert.Equal(t, int64(2), usd.PaidOrders)
+		assert.Equal(t, int64(7000), usd.GrossRevenue)
+		assert.Equal(t, int64(500), usd.RefundedAmount)
+		assert.Equal(t, int64(6500), usd.NetRevenue)
+		assert.Equal(t, int64(3500), usd.AverageOrderValue)
+
+		require.Len(t, report.Series["EUR"], 1)
+		assert.Equal(t, int64(4500), report.Series["EUR"][0].GrossRevenue)
+	})
+
+	t.Run("Conversion is paid over placed orders", func(t *testing.T) {
+		var report models.ConversionReport
+		w := ts.get("/api/v1/admin/analytics/conversion"+query, admin, &report)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		assert.Equal(t, 0.6, report.ConversionRate)
+		assert.Equal(t, 0.5, report.Series[0].ConversionRate)
+	})
+
+	t.Run("New users per day", func(t *testing.T) {
+		var report models.NewUsersReport
+		w := ts.get("/api/v1/admin/analytics/new-users"+query, admin, &report)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		assert.Equal(t, int64(2), report.TotalNewUsers)
+		assert.Equal(t, int64(1), report.Series[1].NewUsers)
+	})
+
+	t.Run("Top products", func(t *testing.T) {
+		var report models.TopProductsReport
+		w := ts.get("/api/v1/admin/analytics/top-products"+query+"&currency=usd&sort=revenue", admin, &report)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		require.Len(t, report.Products, 2)
+		assert.Equal(t, gadget.String(), report.Products[0].ProductID)
+		assert.Equal(t, int64(5000), report.Products[0].Revenue)
+		assert.Equal(t, widget.String(), report.Products[1].ProductID)
+		assert.Equal(t, int64(2), report.Products[1].Units)
+	})
+
+	t.Run("Invalid ranges are rejected", func(t *testing.T) {
+		w := ts.get("/api/v1/admin/analytics/orders?from=2001-02-01&to=2001-01-01", admin, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = ts.get("/api/v1/admin/analytics/orders?from=2001-01-01&to=2010-01-01", admin, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = ts.get("/api/v1/admin/analytics/orders?from=01/01/2001", admin, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
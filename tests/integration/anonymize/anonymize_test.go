@@ -0,0 +1,136 @@
+package anonymize_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/anonymize"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/tests/integration/testenv"
+)
+
+// TestAnonymize copies a schema standing in for production into another,
+// and checks personal data is scrambled while references between rows hold
+func TestAnonymize(t *testing.T) {
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "error",
+		Format: "json",
+		Output: "stdout",
+	}, "anonymize-test")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	sourceConfig := testenv.DatabaseConfig(t)
+	production, err := database.New(sourceConfig, log)
+	require.NoError(t, err)
+	defer production.Close()
+	target := testenv.Database(t, log)
+
+	// A customer with an address, an order, its summary and a pending
+	// password reset
+	userID, orderID := uuid.New(), uuid.New()
+	_, err = production.ExecContext(ctx, `
+		INSERT INTO users (id, username, email, password_hash, first_name, last_name, phone, role)
+		VALUES ($1, 'jane.doe', 'Jane.Doe@mail.test', 'bcrypt-hash', 'Jane', 'Doe', '+49 151 2345678', 'customer')`, userID)
+	require.NoError(t, err)
+	_, err = production.ExecContext(ctx, `
+		INSERT INTO user_addresses (user_id, first_name, last_name, address_line1, city, state, postal_code, country)
+		VALUES ($1, 'Jane', 'Doe', '12 Baker Street', 'London', 'LDN', 'NW1 6XE', 'GB')`, userID)
+	require.NoError(t, err)
+	_, err = production.ExecContext(ctx, `
+		INSERT INTO orders (id, order_number, user_id, total_amount, shipping_address)
+		VALUES ($1, 'ORD-1', $2, 1999, '{"first_name": "Jane", "last_name": "Doe", "address_line1": "12 Baker Street", "city": "London", "postal_code": "NW1 6XE", "country": "GB"}')`,
+		orderID, userID)
+	require.NoError(t, err)
+	_, err = production.ExecContext(ctx, `
+		INSERT INTO order_summaries (order_id, order_number, user_id, customer_email, customer_name, status, currency,
+			total_amount, refunded_amount, item_count, items, placed_at, source_updated_at)
+		VALUES ($1, 'ORD-1', $2, 'jane.doe@mail.test', 'Jane Doe', 'pending', 'EUR', 1999, 0, 1, '[]', NOW(), NOW())`,
+		orderID, userID)
+	require.NoError(t, err)
+	_, err = production.ExecContext(ctx, `
+		INSERT INTO password_reset_tokens (user_id, token, expires_at) VALUES ($1, 'reset-token', NOW() + INTERVAL '1 hour')`, userID)
+	require.NoError(t, err)
+
+	// The target's own rows are replaced
+	_, err = target.ExecContext(ctx, `
+		INSERT INTO users (username, email, password_hash) VALUES ('staging', 'staging@example.com', 'hash')`)
+	require.NoError(t, err)
+
+	conn, err := pgx.Connect(ctx, sourceConfig.DSN())
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+	source, err := conn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	require.NoError(t, err)
+	defer source.Rollback(ctx)
+
+	anonymizer, err := anonymize.New(target, anonymize.DefaultRules, anonymize.Options{
+		Key:          []byte("test-key"),
+		PasswordHash: "staging-hash",
+		Excluded:     []string{"schema_migrations"},
+	}, log)
+	require.NoError(t, err)
+	tables, err := anonymizer.Run(ctx, source)
+	require.NoError(t, err)
+	assert.Contains(t, tables, anonymize.Table{Name: "users", Rows: 1})
+	assert.Contains(t, tables, anonymize.Table{Name: "password_reset_tokens", Skipped: true})
+
+	var user struct {
+		ID           uuid.UUID `db:"id"`
+		Username     string    `db:"username"`
+		Email        string    `db:"email"`
+		PasswordHash string    `db:"password_hash"`
+		FirstName    string    `db:"first_name"`
+		Phone        string    `db:"phone"`
+		Role         string    `db:"role"`
+	}
+	require.NoError(t, target.GetContext(ctx, &user,
+		`SELECT id, username, email, password_hash, first_name, phone, role FROM users`))
+	assert.Equal(t, userID, user.ID)
+	assert.NotEqual(t, "jane.doe", user.Username)
+	assert.True(t, strings.HasSuffix(user.Email, "@example.com"), user.Email)
+	assert.Equal(t, "staging-hash", user.PasswordHash)
+	assert.NotEqual(t, "Jane", user.FirstName)
+	assert.Len(t, user.Phone, len("+49 151 2345678"))
+	assert.True(t, strings.HasPrefix(user.Phone, "+"), user.Phone)
+	assert.Equal(t, "customer", user.Role)
+
+	// The same email scrambles the same in every table
+	var summary struct {
+		UserID        uuid.UUID `db:"user_id"`
+		CustomerEmail string    `db:"customer_email"`
+		CustomerName  string    `db:"customer_name"`
+	}
+	require.NoError(t, target.GetContext(ctx, &summary,
+		`SELECT user_id, customer_email, customer_name FROM order_summaries WHERE order_id = $1`, orderID))
+	assert.Equal(t, userID, summary.UserID)
+	assert.Equal(t, user.Email, summary.CustomerEmail)
+	assert.True(t, strings.HasPrefix(summary.CustomerName, user.FirstName+" "), summary.CustomerName)
+
+	// Addresses are scrambled, but for what taxes and shipping need
+	var address struct {
+		City    string `db:"city"`
+		Country string `db:"country"`
+	}
+	require.NoError(t, target.GetContext(ctx, &address, `
+		SELECT shipping_address->>'city' AS city, shipping_address->>'country' AS country
+		FROM orders WHERE id = $1 AND user_id = $2`, orderID, userID))
+	assert.NotEqual(t, "London", address.City)
+	assert.Equal(t, "GB", address.Country)
+
+	var street string
+	require.NoError(t, target.GetContext(ctx, &street, `SELECT address_line1 FROM user_addresses WHERE user_id = $1`, userID))
+	assert.NotContains(t, street, "Baker")
+
+	var tokens int
+	require.NoError(t, target.GetContext(ctx, &tokens, `SELECT COUNT(*) FROM password_reset_tokens`))
+	assert.Zero(t, tokens)
+}
@@ -1,15 +1,20 @@
 package integration
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/kaanevranportfolio/Commercium/internal/api-gateway/clickstream"
 	"github.com/kaanevranportfolio/Commercium/internal/api-gateway/config"
 	"github.com/kaanevranportfolio/Commercium/internal/api-gateway/server"
+	pkgconfig "github.com/kaanevranportfolio/Commercium/pkg/config"
 	"github.com/kaanevranportfolio/Commercium/pkg/logger"
 	"github.com/kaanevranportfolio/Commercium/pkg/metrics"
 )
@@ -51,6 +56,12 @@ func TestAPIGatewayEndpoints(t *testing.T) {
 			path:           "/readiness",
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:           "Liveness check",
+			method:         "GET",
+			path:           "/livez",
+			expectedStatus: http.StatusOK,
+		},
 		{
 			name:           "Status endpoint",
 			method:         "GET",
@@ -115,3 +126,137 @@ func TestMetricsEndpoint(t *testing.T) {
 	assert.Contains(t, body, "# HELP")
 	assert.Contains(t, body, "# TYPE")
 }
+
+func TestClickstreamEvents(t *testing.T) {
+	// Create test configuration
+	cfg, err := config.Load()
+	require.NoError(t, err)
+
+	// Buffer a handful of events and never flush them, so no broker is needed
+	cfg.Kafka.Brokers = []string{"localhost:9092"}
+	cfg.Services.Gateway.Clickstream.Enabled = true
+	cfg.Services.Gateway.Clickstream.BufferSize = 3
+	cfg.Services.Gateway.Clickstream.BatchSize = 100
+	cfg.Services.Gateway.Clickstream.FlushInterval = time.Hour
+	cfg.Services.Gateway.Clickstream.MaxEventsPerRequest = 5
+	cfg.Services.Gateway.Clickstream.SampleRates = map[string]float64{"product_view": 0}
+
+	// Create logger
+	log, err := logger.New(cfg.Logger, "api-gateway-test")
+	require.NoError(t, err)
+
+	// Create metrics registry
+	metricsRegistry, err := metrics.NewRegistry(cfg.Metrics, "api-gateway-test")
+	require.NoError(t, err)
+
+	// Create server
+	srv, err := server.New(cfg, log, metricsRegistry)
+	require.NoError(t, err)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/events", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Events are accepted and sampled", func(t *testing.T) {
+		w := post(`{"events": [
+			{"type": "add_to_cart", "session_id": "s1", "product_id": "p1", "quantity": 2},
+			{"type": "product_view", "session_id": "s1", "product_id": "p1"}
+		]}`)
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+		var response clickstream.IngestResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Accepted)
+		assert.Equal(t, 1, response.Sampled)
+	})
+
+	t.Run("Invalid events are rejected", func(t *testing.T) {
+		w := post(`{"events": [{"type": "checkout", "session_id": "s1", "product_id": "p1"}]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = post(`{"events": [{"type": "add_to_cart", "product_id": "p1"}]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = post(`{"events": []}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		event := `{"type": "add_to_cart", "session_id": "s1", "product_id": "p1"}`
+		w = post(`{"events": [` + strings.Repeat(event+",", 5) + event + `]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Batches are refused while the buffer is full", func(t *testing.T) {
+		event := `{"type": "add_to_cart", "session_id": "s2", "product_id": "p2"}`
+		w := post(`{"events": [` + event + `,` + event + `,` + event + `]}`)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+		w = post(`{"events": [` + event + `,` + event + `]}`)
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	})
+}
+
+func TestRateLimiting(t *testing.T) {
+	// Create test configuration
+	cfg, err := config.Load()
+	require.NoError(t, err)
+
+	// Allow two API requests a minute per client, kept in memory
+	cfg.Services.Gateway.RateLimit = pkgconfig.RateLimitConfig{
+		Enabled:   true,
+		Algorithm: "sliding_window",
+		Backend:   "memory",
+		Limit:     2,
+		Window:    time.Minute,
+	}
+
+	// Create logger
+	log, err := logger.New(cfg.Logger, "api-gateway-test")
+	require.NoError(t, err)
+
+	// Create metrics registry
+	metricsRegistry, err := metrics.NewRegistry(cfg.Metrics, "api-gateway-test")
+	require.NoError(t, err)
+
+	// Create server
+	srv, err := server.New(cfg, log, metricsRegistry)
+	require.NoError(t, err)
+
+	get := func(path, clientIP string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = clientIP + ":12345"
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Requests over the limit are refused", func(t *testing.T) {
+		w := get("/api/v1/status", "192.0.2.1")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+
+		w = get("/api/v1/status", "192.0.2.1")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+		w = get("/api/v1/status", "192.0.2.1")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("Clients are limited apart", func(t *testing.T) {
+		w := get("/api/v1/status", "192.0.2.2")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Health checks aren't limited", func(t *testing.T) {
+		w := get("/livez", "192.0.2.1")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -0,0 +1,234 @@
+package currency_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/internal/currency/handlers"
+	"github.com/kaanevranportfolio/Commercium/internal/currency/models"
+	"github.com/kaanevranportfolio/Commercium/internal/currency/rates"
+	"github.com/kaanevranportfolio/Commercium/internal/currency/repository"
+	"github.com/kaanevranportfolio/Commercium/internal/currency/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/tests/integration/testenv"
+)
+
+// TestSuite holds the test dependencies
+type TestSuite struct {
+	db         *database.DB
+	router     *gin.Engine
+	jwtService *auth.JWTService
+	userIDs    []uuid.UUID
+}
+
+func setupTestSuite(t *testing.T) *TestSuite {
+	cfg := &config.Config{
+		Auth: config.AuthConfig{
+			JWT: config.JWTConfig{
+				SecretKey:         "test-secret-key-for-testing-only",
+				Issuer:            "commercium-test",
+				Expiration:        15 * time.Minute,
+				RefreshExpiration: 24 * time.Hour,
+			},
+		},
+		Services: config.ServicesConfig{
+			Currency: config.CurrencyServiceConfig{
+				BaseCurrency: "EUR",
+				Supported:    []string{"EUR", "USD", "JPY"},
+				Rates: config.ExchangeRateConfig{
+					Provider: "static",
+					StaticRates: map[string]string{
+						"USD": "1.25",
+						"JPY": "160",
+						"GBP": "0.85",
+					},
+					MaxAge: time.Hour,
+				},
+			},
+		},
+	}
+
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "info",
+		Format: "json",
+		Output: "stdout",
+	}, "currency-service-test")
+	require.NoError(t, err)
+
+	// Each test gets a database of its own (skipped if not available)
+	db := testenv.Database(t, log)
+
+	provider, err := rates.NewProvider(cfg.Services.Currency)
+	require.NoError(t, err)
+
+	jwtService := auth.NewJWTService(&cfg.Auth.JWT)
+
+	currencyRepo := repository.NewCurrencyRepository(db, log)
+	currencyService := service.NewCurrencyService(currencyRepo, provider, cfg, log)
+	currencyHandler := handlers.NewCurrencyHandler(currencyService, jwtService, log)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apperrors.Middleware())
+	currencyHandler.SetupRoutes(router)
+
+	return &TestSuite{
+		db:         db,
+		router:     router,
+		jwtService: jwtService,
+	}
+}
+
+func (ts *TestSuite) cleanup() {
+	for _, userID := range ts.userIDs {
+		ts.db.Exec(`DELETE FROM currency_preferences WHERE user_id = $1`, userID)
+		ts.db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	}
+	ts.db.Exec(`DELETE FROM exchange_rates WHERE source = 'static'`)
+}
+
+// seedUser creates a user and returns an access token for it
+func (ts *TestSuite) seedUser(t *testing.T, role string) (uuid.UUID, string) {
+	userID := uuid.New()
+	_, err := ts.db.Exec(`INSERT INTO users (id, username, email, password_hash, role) VALUES ($1, $2, $3, 'x', $4)`,
+		userID, "currency_"+userID.String()[:8], userID.String()[:8]+"@example.com", role)
+	require.NoError(t, err)
+	ts.userIDs = append(ts.userIDs, userID)
+
+	tokens, err := ts.jwtService.GenerateTokenPair(userID, userID.String()[:8]+"@example.com", "currency_"+userID.String()[:8], role, "")
+	require.NoError(t, err)
+	return userID, tokens.AccessToken
+}
+
+func (ts *TestSuite) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	if token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, req)
+	return w
+}
+
+func (ts *TestSuite) convert(t *testing.T, query string) *models.Conversion {
+	w := ts.do(http.MethodGet, "/api/v1/exchange-rates/convert?"+query, "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var conversion models.Conversion
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &conversion))
+	return &conversion
+}
+
+func TestCurrencyIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	userID, customer := ts.seedUser(t, "customer")
+	_, admin := ts.seedUser(t, "admin")
+
+	t.Run("Only admins refresh rates", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, ts.do(http.MethodPost, "/api/v1/admin/exchange-rates/refresh", customer, nil).Code)
+
+		w := ts.do(http.MethodPost, "/api/v1/admin/exchange-rates/refresh", admin, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("Rates list only supported currencies", func(t *testing.T) {
+		w := ts.do(http.MethodGet, "/api/v1/exchange-rates", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp models.RatesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "EUR", resp.BaseCurrency)
+		assert.Contains(t, resp.Rates, "USD")
+		assert.NotContains(t, resp.Rates, "GBP")
+	})
+
+	t.Run("Conversion respects currency exponents", func(t *testing.T) {
+		conversion := ts.convert(t, "amount=1000&from=EUR&to=USD")
+		assert.Equal(t, int64(1250), conversion.Converted)
+		assert.Equal(t, "12.50 USD", conversion.Formatted)
+
+		// 10.00 USD is 8.00 EUR is 1280 JPY, which has no minor unit
+		conversion = ts.convert(t, "amount=1000&from=usd&to=jpy")
+		assert.Equal(t, int64(1280), conversion.Converted)
+		assert.Equal(t, "1280 JPY", conversion.Formatted)
+
+		w := ts.do(http.MethodGet, "/api/v1/exchange-rates/convert?amount=100&from=EUR&to=XYZ", "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Display currency preference", func(t *testing.T) {
+		w := ts.do(http.MethodGet, "/api/v1/currencies/preference", customer, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = ts.do(http.MethodPut, "/api/v1/currencies/preference", customer, models.SetPreferenceRequest{Currency: "GBP"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = ts.do(http.MethodPut, "/api/v1/currencies/preference", customer, models.SetPreferenceRequest{Currency: "usd"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var preference models.CurrencyPreference
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &preference))
+		assert.Equal(t, "USD", preference.Currency)
+	})
+
+	t.Run("Display amounts use the preference unless overridden", func(t *testing.T) {
+		req := models.DisplayAmountsRequest{
+			UserID:  userID,
+			Amounts: []models.Money{{Currency: "EUR", Amount: 2000}, {Currency: "USD", Amount: 500}},
+		}
+		w := ts.do(http.MethodPost, "/internal/v1/display-amounts", "", req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp models.DisplayAmountsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "USD", resp.Currency)
+		require.Len(t, resp.Amounts, 2)
+		assert.Equal(t, int64(2500), resp.Amounts[0].Converted)
+		assert.Equal(t, int64(500), resp.Amounts[1].Converted)
+		assert.NotNil(t, resp.RatesAsOf)
+
+		req.Currency = "JPY"
+		w = ts.do(http.MethodPost, "/internal/v1/display-amounts", "", req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "JPY", resp.Currency)
+		assert.Equal(t, int64(3200), resp.Amounts[0].Converted)
+
+		req.Currency = "GBP"
+		w = ts.do(http.MethodPost, "/internal/v1/display-amounts", "", req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Removing the preference shows original amounts", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, ts.do(http.MethodDelete, "/api/v1/currencies/preference", customer, nil).Code)
+
+		w := ts.do(http.MethodPost, "/internal/v1/display-amounts", "", models.DisplayAmountsRequest{
+			UserID:  userID,
+			Amounts: []models.Money{{Currency: "EUR", Amount: 2000}},
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp models.DisplayAmountsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Empty(t, resp.Currency)
+		assert.Empty(t, resp.Amounts)
+	})
+}
@@ -0,0 +1,125 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/internal/stockalert/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/tests/integration/testenv"
+)
+
+func TestInboxIntegration(t *testing.T) {
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "info",
+		Format: "json",
+		Output: "stdout",
+	}, "events-test")
+	require.NoError(t, err)
+
+	// The test gets a database of its own (skipped if not available)
+	db := testenv.Database(t, log)
+
+	ctx := context.Background()
+	consumer := "inbox-test-" + uuid.NewString()[:8]
+	defer db.Exec(`DELETE FROM processed_events WHERE consumer = $1`, consumer)
+
+	inbox := events.NewInbox(db, consumer, config.InboxConfig{TTL: time.Hour, Lease: time.Minute}, log)
+
+	newEvent := func(t *testing.T) *events.Envelope {
+		envelope, err := events.New(ctx, "inventory-service", models.EventStockChanged, uuid.NewString(), 1,
+			&models.InventoryEvent{Type: models.EventStockChanged, Available: 1})
+		require.NoError(t, err)
+		return envelope
+	}
+
+	t.Run("Redelivered events are handled once", func(t *testing.T) {
+		handled := 0
+		handle := events.Deduplicate(inbox, func(ctx context.Context, envelope *events.Envelope, event *models.InventoryEvent) error {
+			handled++
+			return nil
+		})
+
+		envelope := newEvent(t)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, handle(ctx, envelope, &models.InventoryEvent{}))
+		}
+		assert.Equal(t, 1, handled)
+
+		// Another consumer handles the event on its own
+		other := events.NewInbox(db, consumer+"-other", config.InboxConfig{TTL: time.Hour, Lease: time.Minute}, log)
+		defer db.Exec(`DELETE FROM processed_events WHERE consumer = $1`, consumer+"-other")
+		require.NoError(t, other.Process(ctx, envelope, func(ctx context.Context) error {
+			handled++
+			return nil
+		}))
+		assert.Equal(t, 2, handled)
+	})
+
+	t.Run("Failed events are handled again", func(t *testing.T) {
+		envelope := newEvent(t)
+		failure := errors.New("notification service unavailable")
+
+		err := inbox.Process(ctx, envelope, func(ctx context.Context) error { return failure })
+		assert.ErrorIs(t, err, failure)
+
+		handled := false
+		require.NoError(t, inbox.Process(ctx, envelope, func(ctx context.Context) error {
+			handled = true
+			return nil
+		}))
+		assert.True(t, handled)
+	})
+
+	t.Run("Events being handled are retried later", func(t *testing.T) {
+		envelope := newEvent(t)
+
+		err := inbox.Process(ctx, envelope, func(ctx context.Context) error {
+			return inbox.Process(ctx, envelope, func(ctx context.Context) error {
+				t.Fatal("event handled twice concurrently")
+				return nil
+			})
+		})
+		assert.ErrorIs(t, err, events.ErrEventInProgress)
+	})
+
+	t.Run("Abandoned events are taken over once their lease runs out", func(t *testing.T) {
+		envelope := newEvent(t)
+		_, err := db.Exec(`INSERT INTO processed_events (consumer, event_id, event_type, status, locked_at, expires_at)
+			VALUES ($1, $2, $3, 'in_progress', NOW() - INTERVAL '2 minutes', NOW() + INTERVAL '1 hour')`,
+			consumer, envelope.ID, envelope.Type)
+		require.NoError(t, err)
+
+		handled := false
+		require.NoError(t, inbox.Process(ctx, envelope, func(ctx context.Context) error {
+			handled = true
+			return nil
+		}))
+		assert.True(t, handled)
+	})
+
+	t.Run("Expired records are purged", func(t *testing.T) {
+		envelope := newEvent(t)
+		require.NoError(t, inbox.Process(ctx, envelope, func(ctx context.Context) error { return nil }))
+		_, err := db.Exec(`UPDATE processed_events SET expires_at = NOW() - INTERVAL '1 minute' WHERE consumer = $1 AND event_id = $2`,
+			consumer, envelope.ID)
+		require.NoError(t, err)
+
+		purged, err := inbox.PurgeExpired(ctx)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, purged, int64(1))
+
+		var count int
+		require.NoError(t, db.Get(&count, `SELECT COUNT(*) FROM processed_events WHERE consumer = $1 AND event_id = $2`,
+			consumer, envelope.ID))
+		assert.Zero(t, count)
+	})
+}
@@ -0,0 +1,84 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/kafka"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/tests/integration/testenv"
+)
+
+// testMessage is the message of the topic under test
+type testMessage struct {
+	Name string `json:"name"`
+}
+
+func TestConsumerGroupIntegration(t *testing.T) {
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "info",
+		Format: "json",
+		Output: "stdout",
+	}, "events-test")
+	require.NoError(t, err)
+
+	// The test gets a Kafka topic of its own (skipped if not available)
+	cfg := testenv.Kafka(t)
+	topic := testenv.Topic(t, cfg, "events-test")
+
+	producer, err := kafka.NewProducer(cfg, nil, "events-test", log)
+	require.NoError(t, err)
+	defer producer.Close()
+
+	group, err := kafka.NewConsumerGroup(cfg, "events-test-"+uuid.NewString()[:8], producer, log)
+	require.NoError(t, err)
+
+	received := make(chan string, 10)
+	kafka.Handle(group, topic, func(ctx context.Context, key string, message *testMessage) error {
+		if message.Name == "poison" {
+			return kafka.Permanent(errors.New("poison message"))
+		}
+		received <- message.Name
+		return nil
+	})
+	go group.Run()
+	defer group.Shutdown(context.Background())
+
+	ctx := context.Background()
+
+	t.Run("Published messages are handled", func(t *testing.T) {
+		require.NoError(t, producer.Publish(ctx, topic, "key-1", &testMessage{Name: "first"}))
+
+		select {
+		case name := <-received:
+			assert.Equal(t, "first", name)
+		case <-time.After(time.Minute):
+			t.Fatal("message was not handled")
+		}
+	})
+
+	t.Run("Messages failing permanently are dead-lettered", func(t *testing.T) {
+		require.NoError(t, producer.Publish(ctx, topic, "key-2", &testMessage{Name: "poison"}))
+
+		reader := kafkago.NewReader(kafkago.ReaderConfig{
+			Brokers: cfg.Brokers,
+			Topic:   topic + cfg.DeadLetterSuffix,
+		})
+		defer reader.Close()
+
+		readCtx, cancel := context.WithTimeout(ctx, time.Minute)
+		defer cancel()
+		message, err := reader.ReadMessage(readCtx)
+		require.NoError(t, err)
+		assert.Equal(t, "key-2", string(message.Key))
+		assert.JSONEq(t, `{"name":"poison"}`, string(message.Value))
+	})
+}
@@ -0,0 +1,150 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+	"github.com/kaanevranportfolio/Commercium/pkg/kafka"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/schemaregistry"
+	eventspb "github.com/kaanevranportfolio/Commercium/proto/events"
+)
+
+// fakeRegistry mimics the schema registry REST API. Definitions containing
+// "incompatible" are refused, as a registry would refuse breaking changes.
+type fakeRegistry struct {
+	mu       sync.Mutex
+	subjects map[string][]string
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var body struct {
+		SchemaType string `json:"schemaType"`
+		Schema     string `json:"schema"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil || body.SchemaType != "PROTOBUF" {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error_code": 42201, "message": "Invalid schema"})
+		return
+	}
+	incompatible := strings.Contains(body.Schema, "incompatible")
+
+	switch {
+	case strings.HasPrefix(req.URL.Path, "/subjects/"):
+		subject := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/subjects/"), "/versions")
+		if incompatible && len(r.subjects[subject]) > 0 {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error_code": 409, "message": "Schema being registered is incompatible"})
+			return
+		}
+		r.subjects[subject] = append(r.subjects[subject], body.Schema)
+		json.NewEncoder(w).Encode(map[string]int{"id": len(r.subjects[subject])})
+	case strings.HasPrefix(req.URL.Path, "/compatibility/subjects/"):
+		subject := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/compatibility/subjects/"), "/versions/latest")
+		if len(r.subjects[subject]) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error_code": 40401, "message": "Subject not found"})
+			return
+		}
+		response := map[string]interface{}{"is_compatible": !incompatible}
+		if incompatible {
+			response["messages"] = []string{"Field removed"}
+		}
+		json.NewEncoder(w).Encode(response)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestSchemaRegistryIntegration(t *testing.T) {
+	registry := &fakeRegistry{subjects: make(map[string][]string)}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "info",
+		Format: "json",
+		Output: "stdout",
+	}, "events-test")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	client := schemaregistry.NewClient(config.SchemaRegistryConfig{URL: server.URL, Timeout: 5 * time.Second})
+	schemas := events.NewSchemaSet([]*events.Schema{eventspb.OrderEventSchema}, client, log)
+
+	orderEvent := func(t *testing.T, payload interface{}) *events.Envelope {
+		envelope, err := events.New(ctx, "order-service", models.EventOrderCreated, uuid.NewString(),
+			models.OrderEventSchemaVersion, payload)
+		require.NoError(t, err)
+		return envelope
+	}
+
+	t.Run("Consumers of unregistered subjects are compatible", func(t *testing.T) {
+		assert.NoError(t, schemas.CheckCompatibility(ctx))
+	})
+
+	t.Run("Producers register their schemas", func(t *testing.T) {
+		require.NoError(t, schemas.Register(ctx))
+		require.Len(t, registry.subjects[eventspb.OrderEventSchema.Subject], 1)
+		assert.Equal(t, eventspb.OrderEventSchema.Definition, registry.subjects[eventspb.OrderEventSchema.Subject][0])
+
+		assert.NoError(t, schemas.CheckCompatibility(ctx))
+	})
+
+	t.Run("Breaking schema changes are refused", func(t *testing.T) {
+		changed := *eventspb.OrderEventSchema
+		changed.Definition = "// incompatible\n" + changed.Definition
+		breaking := events.NewSchemaSet([]*events.Schema{&changed}, client, log)
+
+		assert.ErrorIs(t, breaking.Register(ctx), schemaregistry.ErrIncompatible)
+		assert.ErrorIs(t, breaking.CheckCompatibility(ctx), schemaregistry.ErrIncompatible)
+	})
+
+	t.Run("Payloads are validated against their schema", func(t *testing.T) {
+		assert.NoError(t, schemas.Validate(orderEvent(t, &models.OrderEvent{
+			Type:       models.EventOrderCreated,
+			OrderID:    uuid.New(),
+			UserID:     uuid.New(),
+			Status:     string(models.OrderStatusPending),
+			Amount:     2500,
+			Currency:   "USD",
+			OccurredAt: time.Now().UTC(),
+		})))
+
+		assert.Error(t, schemas.Validate(orderEvent(t, map[string]interface{}{"order_id": uuid.New(), "discount": 10})))
+		assert.Error(t, schemas.Validate(orderEvent(t, map[string]interface{}{"amount": "ten"})))
+
+		envelope := orderEvent(t, &models.OrderEvent{})
+		envelope.Type = "review.submitted"
+		assert.Error(t, schemas.Validate(envelope))
+	})
+
+	t.Run("Producers refuse invalid events", func(t *testing.T) {
+		// Nothing listens on the broker: the event must be refused before
+		// the producer tries to reach it
+		producer, err := kafka.NewProducer(config.KafkaConfig{Brokers: []string{"127.0.0.1:1"}}, nil, "events-test", log)
+		require.NoError(t, err)
+		defer producer.Close()
+		producer.ValidateWith(schemas)
+
+		err = producer.Publish(ctx, "order.events", "key", orderEvent(t, map[string]interface{}{"discount": 10}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "doesn't match schema")
+	})
+}
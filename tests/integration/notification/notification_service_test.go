@@ -0,0 +1,270 @@
+package notification_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/internal/notification/handlers"
+	"github.com/kaanevranportfolio/Commercium/internal/notification/mailer"
+	"github.com/kaanevranportfolio/Commercium/internal/notification/models"
+	"github.com/kaanevranportfolio/Commercium/internal/notification/repository"
+	"github.com/kaanevranportfolio/Commercium/internal/notification/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/tests/integration/testenv"
+)
+
+// recordingMailer keeps sent messages instead of delivering them
+type recordingMailer struct {
+	mu   sync.Mutex
+	sent []*mailer.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg *mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func (m *recordingMailer) last() *mailer.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.sent) == 0 {
+		return nil
+	}
+	return m.sent[len(m.sent)-1]
+}
+
+// TestSuite holds the test dependencies
+type TestSuite struct {
+	db     *database.DB
+	router *gin.Engine
+	mailer *recordingMailer
+	userID uuid.UUID
+	token  string
+	// key is unique per test run so templates don't collide
+	key string
+}
+
+func setupTestSuite(t *testing.T) *TestSuite {
+	cfg := &config.Config{
+		Auth: config.AuthConfig{
+			JWT: config.JWTConfig{
+				SecretKey:         "test-secret-key-for-testing-only",
+				Issuer:            "commercium-test",
+				Expiration:        15 * time.Minute,
+				RefreshExpiration: 24 * time.Hour,
+			},
+		},
+		Services: config.ServicesConfig{
+			Notification: config.NotificationServiceConfig{
+				DefaultLocale: "en",
+			},
+		},
+	}
+
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "info",
+		Format: "json",
+		Output: "stdout",
+	}, "notification-service-test")
+	require.NoError(t, err)
+
+	// Each test gets a database of its own (skipped if not available)
+	db := testenv.Database(t, log)
+
+	jwtService := auth.NewJWTService(&cfg.Auth.JWT)
+	recorder := &recordingMailer{}
+
+	templateRepo := repository.NewTemplateRepository(db, log)
+	notificationService := service.NewNotificationService(templateRepo, recorder, cfg, log)
+	notificationHandler := handlers.NewNotificationHandler(notificationService, jwtService, log)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apperrors.Middleware())
+	notificationHandler.SetupRoutes(router)
+
+	userID := uuid.New()
+	_, err = db.Exec(`INSERT INTO users (id, username, email, password_hash, role) VALUES ($1, $2, $3, 'x', 'admin')`,
+		userID, "notify_"+userID.String()[:8], userID.String()[:8]+"@example.com")
+	require.NoError(t, err)
+
+	tokens, err := jwtService.GenerateTokenPair(userID, "notify@example.com", "notify", "admin", "")
+	require.NoError(t, err)
+
+	return &TestSuite{
+		db:     db,
+		router: router,
+		mailer: recorder,
+		userID: userID,
+		token:  tokens.AccessToken,
+		key:    "test_" + userID.String()[:8],
+	}
+}
+
+func (ts *TestSuite) cleanup() {
+	ts.db.Exec(`DELETE FROM email_templates WHERE key = $1`, ts.key)
+	ts.db.Exec(`DELETE FROM users WHERE id = $1`, ts.userID)
+}
+
+func (ts *TestSuite) do(method, path string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", ts.token))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, req)
+	return w
+}
+
+func (ts *TestSuite) createVersion(t *testing.T, locale, greeting string, activate bool) *models.EmailTemplate {
+	w := ts.do(http.MethodPost, "/api/v1/admin/email-templates", models.CreateTemplateRequest{
+		Key:      ts.key,
+		Locale:   locale,
+		Subject:  greeting + " {{.name}}, order {{.order_number}}",
+		HTMLBody: "<p>" + greeting + " {{.name}}</p><p>Total: {{money .total .currency}}</p>",
+		TextBody: greeting + " {{.name}}\nTotal: {{money .total .currency}}",
+		Activate: activate,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var template models.EmailTemplate
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &template))
+	return &template
+}
+
+func sampleData() map[string]interface{} {
+	return map[string]interface{}{
+		"name":         "<Jane>",
+		"order_number": "ORD-1",
+		"total":        1234,
+		"currency":     "usd",
+	}
+}
+
+func TestEmailTemplateVersionsIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	t.Run("Versions are numbered per locale", func(t *testing.T) {
+		v1 := ts.createVersion(t, "en", "Hello", true)
+		assert.Equal(t, 1, v1.Version)
+		assert.True(t, v1.Active)
+
+		v2 := ts.createVersion(t, "en", "Hi", false)
+		assert.Equal(t, 2, v2.Version)
+		assert.False(t, v2.Active)
+
+		de := ts.createVersion(t, "de_de", "Hallo", true)
+		assert.Equal(t, "de-DE", de.Locale)
+		assert.Equal(t, 1, de.Version)
+	})
+
+	t.Run("Templates that don't parse are rejected", func(t *testing.T) {
+		w := ts.do(http.MethodPost, "/api/v1/admin/email-templates", models.CreateTemplateRequest{
+			Key: ts.key, Locale: "en", Subject: "{{.name", HTMLBody: "x", TextBody: "x",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Activation switches the active version", func(t *testing.T) {
+		w := ts.do(http.MethodPost, "/api/v1/admin/email-templates/"+ts.key+"/locales/en/versions/2/activate", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = ts.do(http.MethodGet, "/api/v1/admin/email-templates/"+ts.key+"/locales/en/versions", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp httpx.ListResponse[*models.EmailTemplate]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Data, 2)
+		assert.True(t, resp.Data[0].Active)
+		assert.False(t, resp.Data[1].Active)
+	})
+
+	t.Run("Preview renders with escaping", func(t *testing.T) {
+		w := ts.do(http.MethodPost, "/api/v1/admin/email-templates/"+ts.key+"/preview", models.PreviewRequest{
+			Locale: "en", Version: 1, Data: sampleData(),
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var rendered models.RenderedEmail
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rendered))
+		assert.Equal(t, 1, rendered.Version)
+		assert.Equal(t, "Hello <Jane>, order ORD-1", rendered.Subject)
+		assert.Contains(t, rendered.HTMLBody, "&lt;Jane&gt;")
+		assert.Contains(t, rendered.TextBody, "Total: 12.34 USD")
+	})
+
+	t.Run("Missing data is rejected", func(t *testing.T) {
+		w := ts.do(http.MethodPost, "/api/v1/admin/email-templates/"+ts.key+"/preview", models.PreviewRequest{
+			Locale: "en", Data: map[string]interface{}{"name": "Jane"},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Test sends are marked", func(t *testing.T) {
+		w := ts.do(http.MethodPost, "/api/v1/admin/email-templates/"+ts.key+"/test-send", models.TestSendRequest{
+			PreviewRequest: models.PreviewRequest{Locale: "de-DE", Data: sampleData()},
+			To:             "qa@example.com",
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		sent := ts.mailer.last()
+		require.NotNil(t, sent)
+		assert.Equal(t, "qa@example.com", sent.To)
+		assert.Equal(t, "[TEST] Hallo <Jane>, order ORD-1", sent.Subject)
+	})
+}
+
+func TestSendEmailIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	ts.createVersion(t, "en", "Hello", true)
+	ts.createVersion(t, "pt", "Olá", true)
+
+	send := func(locale string) *httptest.ResponseRecorder {
+		return ts.do(http.MethodPost, "/internal/v1/emails", models.SendEmailRequest{
+			Template: ts.key,
+			Locale:   locale,
+			To:       "jane@example.com",
+			Data:     sampleData(),
+		})
+	}
+
+	t.Run("Regional locales fall back to the language", func(t *testing.T) {
+		require.Equal(t, http.StatusAccepted, send("pt-BR").Code)
+		assert.Equal(t, "Olá <Jane>, order ORD-1", ts.mailer.last().Subject)
+	})
+
+	t.Run("Unknown locales fall back to the default", func(t *testing.T) {
+		require.Equal(t, http.StatusAccepted, send("fr").Code)
+		assert.Equal(t, "Hello <Jane>, order ORD-1", ts.mailer.last().Subject)
+	})
+
+	t.Run("Unknown template", func(t *testing.T) {
+		w := ts.do(http.MethodPost, "/internal/v1/emails", models.SendEmailRequest{
+			Template: "missing_" + ts.key,
+			To:       "jane@example.com",
+		})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,812 @@
+package order_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/internal/order/clients"
+	"github.com/kaanevranportfolio/Commercium/internal/order/handlers"
+	"github.com/kaanevranportfolio/Commercium/internal/order/models"
+	"github.com/kaanevranportfolio/Commercium/internal/order/repository"
+	"github.com/kaanevranportfolio/Commercium/internal/order/service"
+	"github.com/kaanevranportfolio/Commercium/internal/order/tax"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/events"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/storage"
+	"github.com/kaanevranportfolio/Commercium/tests/integration/testenv"
+)
+
+// fakePaymentClient authorizes payments unless declining is set, and
+// accepts every refund. Refunds of orders without a payment fail like the
+// payment service's do.
+type fakePaymentClient struct {
+	declining  bool
+	authorized map[uuid.UUID]bool
+	voided     []uuid.UUID
+}
+
+func (f *fakePaymentClient) Authorize(ctx context.Context, req *clients.AuthorizePaymentRequest) (*clients.Payment, error) {
+	if f.declining {
+		return nil, &clients.DeclinedError{StatusCode: http.StatusPaymentRequired, Message: "card declined"}
+	}
+	f.authorized[req.OrderID] = true
+	return &clients.Payment{ID: uuid.New(), Status: "authorized"}, nil
+}
+
+func (f *fakePaymentClient) Refund(ctx context.Context, req *clients.RefundPaymentRequest) (*clients.RefundPaymentResponse, error) {
+	if f.authorized[req.OrderID] {
+		f.voided = append(f.voided, req.OrderID)
+	}
+	return &clients.RefundPaymentResponse{ProviderRefundID: "re_" + req.RefundID.String(), Status: "succeeded"}, nil
+}
+
+// fakeInventoryClient records reserved and released orders, and refuses
+// reservations while outOfStock is set
+type fakeInventoryClient struct {
+	outOfStock bool
+	reserved   []uuid.UUID
+	released   []uuid.UUID
+	// onReserve runs after stock is reserved
+	onReserve func(orderID uuid.UUID)
+}
+
+func (f *fakeInventoryClient) Reserve(ctx context.Context, req *clients.ReserveStockRequest) error {
+	if f.outOfStock {
+		return clients.ErrInsufficientStock
+	}
+	f.reserved = append(f.reserved, req.OrderID)
+	if f.onReserve != nil {
+		f.onReserve(req.OrderID)
+	}
+	return nil
+}
+
+func (f *fakeInventoryClient) Release(ctx context.Context, req *clients.ReleaseStockRequest) error {
+	f.released = append(f.released, req.OrderID)
+	return nil
+}
+
+type TestSuite struct {
+	db           *database.DB
+	jwtService   *auth.JWTService
+	router       *gin.Engine
+	orderService service.OrderService
+	payments     *fakePaymentClient
+	inventory    *fakeInventoryClient
+	userID       uuid.UUID
+	token        string
+	adminToken   string
+	// entity is the invoicing legal entity, unique per run so numbering starts at 1
+	entity string
+}
+
+func setupTestSuite(t *testing.T) *TestSuite {
+	cfg := &config.Config{
+		Auth: config.AuthConfig{
+			JWT: config.JWTConfig{
+				SecretKey:         "test-secret-key-for-testing-only",
+				Issuer:            "commercium-test",
+				Expiration:        15 * time.Minute,
+				RefreshExpiration: 24 * time.Hour,
+			},
+		},
+	}
+
+	entity := "T" + strings.ToUpper(uuid.New().String()[:8])
+	cfg.Services.Order.Invoices = config.InvoiceConfig{
+		DefaultEntity: entity,
+		URLTTL:        time.Minute,
+		LegalEntities: []config.LegalEntityConfig{{
+			Code:         entity,
+			Name:         "Commercium Test Ltd.",
+			AddressLines: []string{"1 Test Street", "Testville"},
+			NumberPrefix: entity + "-",
+			Footer:       "Test invoice",
+		}},
+	}
+
+	cfg.Services.Order.Saga = config.SagaConfig{
+		Timeout:          10 * time.Minute,
+		RetryAttempts:    3,
+		RecoveryInterval: time.Minute,
+		BatchSize:        50,
+	}
+	cfg.Services.Order.Reservations = config.ReservationConfig{
+		TTL:            15 * time.Minute,
+		ExpiryInterval: time.Minute,
+		BatchSize:      100,
+		MetricsWindow:  24 * time.Hour,
+	}
+
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "info",
+		Format: "json",
+		Output: "stdout",
+	}, "order-service-test")
+	require.NoError(t, err)
+
+	// Each test gets a database of its own (skipped if not available)
+	db := testenv.Database(t, log)
+
+	jwtService := auth.NewJWTService(&cfg.Auth.JWT)
+
+	taxProvider, err := tax.NewRuleProvider([]config.TaxRuleConfig{
+		{Country: "US", State: "NY", Rate: 8.875, TaxShipping: true},
+		{Country: "DE", Rate: 19, Inclusive: true, TaxShipping: true, CategoryRates: map[string]float64{"books": 7}},
+	})
+	require.NoError(t, err)
+
+	store, err := storage.NewLocalStore(config.LocalStorageConfig{
+		Path:       t.TempDir(),
+		BaseURL:    "http://localhost/files",
+		SigningKey: "test-signing-key",
+	})
+	require.NoError(t, err)
+
+	payments := &fakePaymentClient{authorized: map[uuid.UUID]bool{}}
+	inventory := &fakeInventoryClient{}
+
+	orderRepo := repository.NewOrderRepository(db, log)
+	orderService := service.NewOrderService(orderRepo, payments, inventory, nil, nil, taxProvider, store, nil, cfg, log)
+	orderHandler := handlers.NewOrderHandler(orderService, jwtService, log)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apperrors.Middleware())
+	orderHandler.SetupRoutes(router)
+	router.GET("/files/*key", gin.WrapH(store.Handler("/files")))
+
+	userID := uuid.New()
+	_, err = db.Exec(`INSERT INTO users (id, username, email, password_hash) VALUES ($1, $2, $3, 'x')`,
+		userID, "order_"+userID.String()[:8], userID.String()[:8]+"@example.com")
+	require.NoError(t, err)
+
+	tokens, err := jwtService.GenerateTokenPair(userID, "orders@example.com", "orders", "customer", "")
+	require.NoError(t, err)
+
+	adminTokens, err := jwtService.GenerateTokenPair(userID, "orders@example.com", "orders", "admin", "")
+	require.NoError(t, err)
+
+	return &TestSuite{
+		db:           db,
+		jwtService:   jwtService,
+		router:       router,
+		orderService: orderService,
+		payments:     payments,
+		inventory:    inventory,
+		userID:       userID,
+		token:        tokens.AccessToken,
+		adminToken:   adminTokens.AccessToken,
+		entity:       entity,
+	}
+}
+
+func (ts *TestSuite) cleanup() {
+	ts.db.Exec(`DELETE FROM tax_exemptions WHERE user_id = $1`, ts.userID)
+	ts.db.Exec(`DELETE FROM orders WHERE user_id = $1`, ts.userID)
+	ts.db.Exec(`DELETE FROM invoice_sequences WHERE legal_entity = $1`, ts.entity)
+	ts.db.Exec(`DELETE FROM users WHERE id = $1`, ts.userID)
+}
+
+func (ts *TestSuite) seedOrder(t *testing.T, status models.OrderStatus, placedAt time.Time) uuid.UUID {
+	orderID := uuid.New()
+	_, err := ts.db.ExecContext(context.Background(), `
+		INSERT INTO orders (id, order_number, user_id, status, total_amount, placed_at)
+		VALUES ($1, $2, $3, $4, 2500, $5)`,
+		orderID, "ORD-"+orderID.String()[:8], ts.userID, status, placedAt)
+	require.NoError(t, err)
+
+	_, err = ts.db.ExecContext(context.Background(), `
+		INSERT INTO order_items (order_id, product_id, sku, name, quantity, unit_price, total_price)
+		VALUES ($1, $2, 'SKU-1', 'Test Product', 2, 1250, 2500)`,
+		orderID, uuid.New())
+	require.NoError(t, err)
+
+	// Order lists are read from the read model, which events keep up to date
+	require.NoError(t, ts.orderService.ProjectOrder(context.Background(), orderID))
+
+	return orderID
+}
+
+func (ts *TestSuite) get(path string) *httptest.ResponseRecorder {
+	return ts.do(http.MethodGet, path, nil)
+}
+
+func (ts *TestSuite) do(method, path string, body interface{}) *httptest.ResponseRecorder {
+	return ts.doAs(ts.token, method, path, body)
+}
+
+func (ts *TestSuite) doAs(token, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestOrderHistoryIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	now := time.Now().UTC()
+	delivered := ts.seedOrder(t, models.OrderStatusDelivered, now.Add(-48*time.Hour))
+	ts.seedOrder(t, models.OrderStatusShipped, now.Add(-24*time.Hour))
+	ts.seedOrder(t, models.OrderStatusPending, now)
+
+	t.Run("Cursor pagination", func(t *testing.T) {
+		w := ts.get("/api/v1/orders?limit=2")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var page models.OrderListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.Len(t, page.Data, 2)
+		assert.True(t, page.Pagination.HasMore())
+		assert.NotEmpty(t, page.Pagination.NextCursor)
+		assert.Equal(t, 2, page.Data[0].ItemCount)
+
+		w = ts.get("/api/v1/orders?limit=2&cursor=" + page.Pagination.NextCursor)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var next models.OrderListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &next))
+		assert.Len(t, next.Data, 1)
+		assert.False(t, next.Pagination.HasMore())
+		assert.Equal(t, delivered, next.Data[0].ID)
+	})
+
+	t.Run("Status filter", func(t *testing.T) {
+		w := ts.get("/api/v1/orders?status=shipped,delivered")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var page models.OrderListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.Len(t, page.Data, 2)
+	})
+
+	t.Run("Invalid filter", func(t *testing.T) {
+		w := ts.get("/api/v1/orders?status=unknown")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Order detail", func(t *testing.T) {
+		w := ts.get("/api/v1/orders/" + delivered.String())
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var order models.Order
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+		assert.Equal(t, models.OrderStatusDelivered, order.Status)
+		assert.Len(t, order.Items, 1)
+
+		w = ts.get("/api/v1/orders/" + uuid.New().String())
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestOrderCancellationAndRefundIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	now := time.Now().UTC()
+
+	t.Run("Cancel before shipment", func(t *testing.T) {
+		orderID := ts.seedOrder(t, models.OrderStatusConfirmed, now)
+
+		w := ts.do(http.MethodPost, "/api/v1/orders/"+orderID.String()+"/cancel", models.CancelOrderRequest{Reason: "changed my mind"})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = ts.get("/api/v1/orders/" + orderID.String())
+		var order models.Order
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+		assert.Equal(t, models.OrderStatusCancelled, order.Status)
+		assert.Equal(t, int64(2500), order.RefundedAmount)
+
+		w = ts.do(http.MethodPost, "/api/v1/orders/"+orderID.String()+"/cancel", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Partial refund after shipment", func(t *testing.T) {
+		orderID := ts.seedOrder(t, models.OrderStatusShipped, now)
+
+		w := ts.do(http.MethodPost, "/api/v1/orders/"+orderID.String()+"/cancel", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = ts.get("/api/v1/orders/" + orderID.String())
+		var order models.Order
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+		itemID := order.Items[0].ID
+
+		refundReq := models.CreateRefundRequest{Items: []*models.RefundItemRequest{{OrderItemID: itemID, Quantity: 1}}}
+		w = ts.do(http.MethodPost, "/api/v1/orders/"+orderID.String()+"/refunds", refundReq)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		w = ts.do(http.MethodPost, "/api/v1/orders/"+orderID.String()+"/refunds", refundReq)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		// Both units are refunded now
+		w = ts.do(http.MethodPost, "/api/v1/orders/"+orderID.String()+"/refunds", refundReq)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = ts.get("/api/v1/orders/" + orderID.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+		assert.Equal(t, models.OrderStatusRefunded, order.Status)
+		assert.Equal(t, int64(2500), order.RefundedAmount)
+	})
+}
+
+func TestInvoiceIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	now := time.Now().UTC()
+	first := ts.seedOrder(t, models.OrderStatusDelivered, now.Add(-time.Hour))
+	second := ts.seedOrder(t, models.OrderStatusDelivered, now)
+
+	getInvoice := func(t *testing.T, orderID uuid.UUID) *models.InvoiceResponse {
+		w := ts.get("/api/v1/orders/" + orderID.String() + "/invoice")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var invoice models.InvoiceResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &invoice))
+		return &invoice
+	}
+
+	t.Run("Numbers are sequential per legal entity", func(t *testing.T) {
+		assert.Equal(t, ts.entity+"-000001", getInvoice(t, first).InvoiceNumber)
+		assert.Equal(t, ts.entity+"-000002", getInvoice(t, second).InvoiceNumber)
+	})
+
+	t.Run("Invoices are issued once", func(t *testing.T) {
+		assert.Equal(t, ts.entity+"-000001", getInvoice(t, first).InvoiceNumber)
+	})
+
+	t.Run("Signed link downloads the PDF", func(t *testing.T) {
+		link, err := url.Parse(getInvoice(t, second).URL)
+		require.NoError(t, err)
+
+		w := ts.get(link.RequestURI())
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+		assert.True(t, strings.HasPrefix(w.Body.String(), "%PDF-"))
+
+		w = ts.get(strings.Replace(link.RequestURI(), "signature=", "signature=0", 1))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Orders that aren't completed have no invoice", func(t *testing.T) {
+		pending := ts.seedOrder(t, models.OrderStatusShipped, now)
+		w := ts.get("/api/v1/orders/" + pending.String() + "/invoice")
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestCheckoutTotalsIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	state := "NY"
+	newYork := &models.Address{FirstName: "Jane", LastName: "Doe", AddressLine1: "1 Broadway", City: "New York", State: &state, PostalCode: "10004", Country: "US"}
+	berlin := &models.Address{FirstName: "Erika", LastName: "Muster", AddressLine1: "Unter den Linden 1", City: "Berlin", PostalCode: "10117", Country: "DE"}
+
+	totals := func(t *testing.T, address *models.Address, discount int64) *models.CheckoutTotals {
+		w := ts.do(http.MethodPost, "/api/v1/checkout/totals", models.CheckoutTotalsRequest{
+			Currency: "USD",
+			Items: []models.CheckoutItem{
+				{ProductID: uuid.New(), SKU: "SKU-1", Quantity: 2, UnitPrice: 1000},
+				{ProductID: uuid.New(), SKU: "BOOK-1", Quantity: 1, UnitPrice: 2000, TaxCode: "books"},
+			},
+			ShippingAddress: address,
+			ShippingAmount:  500,
+			DiscountAmount:  discount,
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp models.CheckoutTotals
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return &resp
+	}
+
+	t.Run("Tax added on top of prices", func(t *testing.T) {
+		resp := totals(t, newYork, 400)
+		assert.Equal(t, int64(4000), resp.SubtotalAmount)
+		assert.False(t, resp.PricesIncludeTax)
+		// (4000 - 400 + 500) * 8.875%
+		assert.Equal(t, int64(364), resp.TaxAmount)
+		assert.Equal(t, int64(4000-400+500+364), resp.TotalAmount)
+		assert.Equal(t, int64(200), resp.Lines[0].DiscountAmount)
+		assert.Equal(t, int64(200), resp.Lines[1].DiscountAmount)
+	})
+
+	t.Run("VAT included in prices", func(t *testing.T) {
+		resp := totals(t, berlin, 0)
+		assert.True(t, resp.PricesIncludeTax)
+		// 2000 at 19%, 2000 at the reduced 7% and shipping at 19%
+		assert.Equal(t, int64(319), resp.Lines[0].TaxAmount)
+		assert.Equal(t, int64(131), resp.Lines[1].TaxAmount)
+		assert.Equal(t, int64(319+131+80), resp.TaxAmount)
+		assert.Equal(t, int64(4500), resp.TotalAmount)
+	})
+
+	t.Run("Unknown destinations are not taxed", func(t *testing.T) {
+		resp := totals(t, &models.Address{City: "Toronto", PostalCode: "M5H", Country: "CA"}, 0)
+		assert.Equal(t, int64(0), resp.TaxAmount)
+		assert.Equal(t, int64(4500), resp.TotalAmount)
+	})
+
+	t.Run("Tax-exempt customers", func(t *testing.T) {
+		path := "/api/v1/admin/tax-exemptions/" + ts.userID.String()
+
+		w := ts.do(http.MethodPut, path, models.TaxExemptionRequest{CertificateNumber: "EX-1"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = ts.doAs(ts.adminToken, http.MethodPut, path, models.TaxExemptionRequest{CertificateNumber: "EX-1"})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		resp := totals(t, newYork, 0)
+		assert.True(t, resp.TaxExempt)
+		assert.Equal(t, int64(0), resp.TaxAmount)
+		assert.Equal(t, int64(4500), resp.TotalAmount)
+
+		// Exempt customers pay net prices where VAT is included
+		resp = totals(t, berlin, 0)
+		assert.Equal(t, int64(0), resp.TaxAmount)
+		assert.Equal(t, int64(4500-530), resp.TotalAmount)
+
+		w = ts.doAs(ts.adminToken, http.MethodDelete, path, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		resp = totals(t, newYork, 0)
+		assert.False(t, resp.TaxExempt)
+		assert.NotZero(t, resp.TaxAmount)
+	})
+
+	t.Run("Invalid discount", func(t *testing.T) {
+		w := ts.do(http.MethodPost, "/api/v1/checkout/totals", models.CheckoutTotalsRequest{
+			Currency:        "USD",
+			Items:           []models.CheckoutItem{{ProductID: uuid.New(), SKU: "SKU-1", Quantity: 1, UnitPrice: 1000}},
+			ShippingAddress: newYork,
+			DiscountAmount:  2000,
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func (ts *TestSuite) sagaStatus(t *testing.T, id uuid.UUID) models.SagaStatus {
+	var status models.SagaStatus
+	require.NoError(t, ts.db.Get(&status, `SELECT status FROM checkout_sagas WHERE id = $1`, id))
+	return status
+}
+
+func TestCheckoutSagaIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	state := "NY"
+	checkout := func() models.PlaceOrderRequest {
+		return models.PlaceOrderRequest{
+			CheckoutID: uuid.New(),
+			Currency:   "USD",
+			Items: []models.CreateOrderItem{{
+				CheckoutItem: models.CheckoutItem{ProductID: uuid.New(), SKU: "SKU-1", Quantity: 2, UnitPrice: 1000},
+				Name:         "Test Product",
+			}},
+			ShippingAddress: &models.Address{FirstName: "Jane", LastName: "Doe", AddressLine1: "1 Broadway", City: "New York", State: &state, PostalCode: "10004", Country: "US"},
+			ShippingAmount:  500,
+			PaymentMethod:   "pm_card_visa",
+		}
+	}
+
+	t.Run("Checkout places the order", func(t *testing.T) {
+		req := checkout()
+		w := ts.do(http.MethodPost, "/api/v1/checkout", req)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var order models.Order
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+		assert.Equal(t, req.CheckoutID, order.ID)
+		assert.Equal(t, models.OrderStatusPending, order.Status)
+		assert.Contains(t, ts.inventory.reserved, order.ID)
+		assert.True(t, ts.payments.authorized[order.ID])
+		assert.Equal(t, models.SagaStatusCompleted, ts.sagaStatus(t, order.ID))
+
+		// Retries return the order placed
+		w = ts.do(http.MethodPost, "/api/v1/checkout", req)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var retried models.Order
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &retried))
+		assert.Equal(t, order.OrderNumber, retried.OrderNumber)
+	})
+
+	t.Run("Out of stock checkouts place no order", func(t *testing.T) {
+		ts.inventory.outOfStock = true
+		defer func() { ts.inventory.outOfStock = false }()
+
+		req := checkout()
+		w := ts.do(http.MethodPost, "/api/v1/checkout", req)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = ts.get("/api/v1/orders/" + req.CheckoutID.String())
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, ts.inventory.released, req.CheckoutID)
+		assert.Equal(t, models.SagaStatusCompensated, ts.sagaStatus(t, req.CheckoutID))
+	})
+
+	t.Run("Declined payments roll the checkout back", func(t *testing.T) {
+		ts.payments.declining = true
+		defer func() { ts.payments.declining = false }()
+
+		req := checkout()
+		w := ts.do(http.MethodPost, "/api/v1/checkout", req)
+		assert.Equal(t, http.StatusPaymentRequired, w.Code)
+
+		w = ts.get("/api/v1/orders/" + req.CheckoutID.String())
+		require.Equal(t, http.StatusOK, w.Code)
+		var order models.Order
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+		assert.Equal(t, models.OrderStatusCancelled, order.Status)
+		assert.Contains(t, ts.inventory.released, req.CheckoutID)
+		assert.Equal(t, models.SagaStatusCompensated, ts.sagaStatus(t, req.CheckoutID))
+
+		// A failed checkout isn't retried with the same ID
+		ts.payments.declining = false
+		w = ts.do(http.MethodPost, "/api/v1/checkout", req)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Stuck checkouts are rolled back", func(t *testing.T) {
+		req := checkout()
+		w := ts.do(http.MethodPost, "/api/v1/checkout", req)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		// Pretend the instance died while authorizing the payment
+		_, err := ts.db.Exec(`UPDATE checkout_sagas SET status = 'running', updated_at = NOW() - INTERVAL '1 hour' WHERE id = $1`,
+			req.CheckoutID)
+		require.NoError(t, err)
+
+		for {
+			claimed, err := ts.orderService.RecoverCheckouts(context.Background())
+			require.NoError(t, err)
+			if claimed == 0 {
+				break
+			}
+		}
+
+		assert.Equal(t, models.SagaStatusCompensated, ts.sagaStatus(t, req.CheckoutID))
+		assert.Contains(t, ts.payments.voided, req.CheckoutID)
+		assert.Contains(t, ts.inventory.released, req.CheckoutID)
+
+		w = ts.get("/api/v1/orders/" + req.CheckoutID.String())
+		var order models.Order
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+		assert.Equal(t, models.OrderStatusCancelled, order.Status)
+	})
+}
+
+func (ts *TestSuite) reservationStatus(t *testing.T, id uuid.UUID) models.ReservationStatus {
+	var status models.ReservationStatus
+	require.NoError(t, ts.db.Get(&status, `SELECT status FROM stock_reservations WHERE id = $1`, id))
+	return status
+}
+
+func TestStockReservationIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	ctx := context.Background()
+	state := "NY"
+	req := models.PlaceOrderRequest{
+		CheckoutID: uuid.New(),
+		Currency:   "USD",
+		Items: []models.CreateOrderItem{{
+			CheckoutItem: models.CheckoutItem{ProductID: uuid.New(), SKU: "SKU-1", Quantity: 1, UnitPrice: 1000},
+			Name:         "Test Product",
+		}},
+		ShippingAddress: &models.Address{FirstName: "Jane", LastName: "Doe", AddressLine1: "1 Broadway", City: "New York", State: &state, PostalCode: "10004", Country: "US"},
+		PaymentMethod:   "pm_card_visa",
+	}
+
+	expireAll := func(t *testing.T) {
+		for {
+			claimed, err := ts.orderService.ExpireReservations(ctx)
+			require.NoError(t, err)
+			if claimed == 0 {
+				break
+			}
+		}
+	}
+
+	t.Run("Placed orders purchase their reservation", func(t *testing.T) {
+		w := ts.do(http.MethodPost, "/api/v1/checkout", req)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Equal(t, models.ReservationStatusPurchased, ts.reservationStatus(t, req.CheckoutID))
+
+		// Purchased stock never expires
+		_, err := ts.db.Exec(`UPDATE stock_reservations SET expires_at = NOW() - INTERVAL '1 hour' WHERE id = $1`, req.CheckoutID)
+		require.NoError(t, err)
+		expireAll(t)
+		assert.Equal(t, models.ReservationStatusPurchased, ts.reservationStatus(t, req.CheckoutID))
+		assert.NotContains(t, ts.inventory.released, req.CheckoutID)
+	})
+
+	t.Run("Abandoned reservations are released", func(t *testing.T) {
+		abandonedID := uuid.New()
+		_, err := ts.db.Exec(`INSERT INTO stock_reservations (id, user_id, items, expires_at)
+			VALUES ($1, $2, '[]', NOW() - INTERVAL '1 minute')`, abandonedID, ts.userID)
+		require.NoError(t, err)
+
+		expireAll(t)
+		assert.Equal(t, models.ReservationStatusExpired, ts.reservationStatus(t, abandonedID))
+		assert.Contains(t, ts.inventory.released, abandonedID)
+
+		stats, err := ts.orderService.GetReservationStats(ctx)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, stats.Purchased, 1)
+		assert.GreaterOrEqual(t, stats.Expired, 1)
+		assert.Greater(t, stats.ConversionRate(), 0.0)
+		assert.Less(t, stats.ConversionRate(), 1.0)
+	})
+
+	t.Run("Checkouts outliving their reservation are rolled back", func(t *testing.T) {
+		// The reservation expires before the checkout gets to complete
+		ts.inventory.onReserve = func(orderID uuid.UUID) {
+			_, err := ts.db.Exec(`UPDATE stock_reservations SET expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1`, orderID)
+			require.NoError(t, err)
+			expireAll(t)
+		}
+		defer func() { ts.inventory.onReserve = nil }()
+
+		late := req
+		late.CheckoutID = uuid.New()
+		w := ts.do(http.MethodPost, "/api/v1/checkout", late)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		assert.Equal(t, models.ReservationStatusExpired, ts.reservationStatus(t, late.CheckoutID))
+		assert.Equal(t, models.SagaStatusCompensated, ts.sagaStatus(t, late.CheckoutID))
+		assert.Contains(t, ts.payments.voided, late.CheckoutID)
+	})
+}
+
+func TestOrderReadModelIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	ctx := context.Background()
+	orderID := ts.seedOrder(t, models.OrderStatusConfirmed, time.Now().UTC())
+
+	var orderNumber string
+	require.NoError(t, ts.db.Get(&orderNumber, `SELECT order_number FROM orders WHERE id = $1`, orderID))
+
+	search := func(t *testing.T, query string) *models.AdminOrderListResponse {
+		w := ts.doAs(ts.adminToken, http.MethodGet, "/api/v1/admin/orders?"+query, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp models.AdminOrderListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return &resp
+	}
+
+	t.Run("Admins search by order number and customer", func(t *testing.T) {
+		resp := search(t, "q="+url.QueryEscape(strings.ToLower(orderNumber)))
+		require.Len(t, resp.Data, 1)
+		assert.Equal(t, orderID, resp.Data[0].ID)
+		assert.Equal(t, ts.userID, resp.Data[0].UserID)
+		assert.Equal(t, ts.userID.String()[:8]+"@example.com", resp.Data[0].CustomerEmail)
+		assert.Equal(t, 2, resp.Data[0].ItemCount)
+
+		resp = search(t, "q="+ts.userID.String()[:8]+"&user_id="+ts.userID.String())
+		assert.Len(t, resp.Data, 1)
+
+		w := ts.get("/api/v1/admin/orders")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Payments show up once projected", func(t *testing.T) {
+		_, err := ts.db.Exec(`INSERT INTO payments (order_id, user_id, provider, status, currency, amount)
+			VALUES ($1, $2, 'stripe', 'authorized', 'USD', 2500)`, orderID, ts.userID)
+		require.NoError(t, err)
+		defer ts.db.Exec(`DELETE FROM payments WHERE order_id = $1`, orderID)
+
+		require.NoError(t, ts.orderService.ProjectOrder(ctx, orderID))
+
+		resp := search(t, "user_id="+ts.userID.String())
+		require.Len(t, resp.Data, 1)
+		require.NotNil(t, resp.Data[0].PaymentStatus)
+		assert.Equal(t, "authorized", *resp.Data[0].PaymentStatus)
+	})
+
+	t.Run("Missed events are caught up", func(t *testing.T) {
+		w := ts.do(http.MethodPost, "/api/v1/orders/"+orderID.String()+"/cancel", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		// No event was delivered, so the history still shows the old status
+		w = ts.get("/api/v1/orders")
+		var page models.OrderListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		require.Len(t, page.Data, 1)
+		assert.Equal(t, models.OrderStatusConfirmed, page.Data[0].Status)
+
+		for {
+			projected, err := ts.orderService.ProjectStaleOrders(ctx)
+			require.NoError(t, err)
+			if projected == 0 {
+				break
+			}
+		}
+
+		w = ts.get("/api/v1/orders")
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		require.Len(t, page.Data, 1)
+		assert.Equal(t, models.OrderStatusCancelled, page.Data[0].Status)
+	})
+
+	t.Run("Event envelopes are projected", func(t *testing.T) {
+		handle := service.ProjectionHandler(ts.orderService)
+		status := func(t *testing.T) models.OrderStatus {
+			resp := search(t, "user_id="+ts.userID.String())
+			require.Len(t, resp.Data, 1)
+			return resp.Data[0].Status
+		}
+
+		_, err := ts.db.Exec(`UPDATE orders SET status = $2 WHERE id = $1`, orderID, models.OrderStatusRefunded)
+		require.NoError(t, err)
+		envelope, err := events.New(ctx, "order-service", models.EventOrderRefunded, orderID.String(),
+			models.OrderEventSchemaVersion, &models.OrderEvent{Type: models.EventOrderRefunded, OrderID: orderID})
+		require.NoError(t, err)
+		value, err := envelope.Marshal()
+		require.NoError(t, err)
+
+		require.NoError(t, handle(ctx, []byte(orderID.String()), value))
+		assert.Equal(t, models.OrderStatusRefunded, status(t))
+
+		// Payloads of older schema versions are upgraded before they are read
+		const legacyType = "order.legacy_test"
+		events.RegisterUpgrade(legacyType, 1, func(data json.RawMessage) (json.RawMessage, error) {
+			var legacy struct {
+				Order uuid.UUID `json:"order"`
+			}
+			if err := json.Unmarshal(data, &legacy); err != nil {
+				return nil, err
+			}
+			return json.Marshal(map[string]uuid.UUID{"order_id": legacy.Order})
+		})
+
+		_, err = ts.db.Exec(`UPDATE orders SET status = $2 WHERE id = $1`, orderID, models.OrderStatusDelivered)
+		require.NoError(t, err)
+		envelope, err = events.New(ctx, "order-service", legacyType, orderID.String(), 1,
+			map[string]uuid.UUID{"order": orderID})
+		require.NoError(t, err)
+		value, err = envelope.Marshal()
+		require.NoError(t, err)
+
+		require.NoError(t, handle(ctx, []byte(orderID.String()), value))
+		assert.Equal(t, models.OrderStatusDelivered, status(t))
+
+		// Messages that aren't event envelopes can't be projected
+		assert.Error(t, handle(ctx, nil, []byte(`{"order_id":"`+orderID.String()+`"}`)))
+	})
+}
@@ -0,0 +1,707 @@
+package payment_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/internal/payment/handlers"
+	"github.com/kaanevranportfolio/Commercium/internal/payment/models"
+	"github.com/kaanevranportfolio/Commercium/internal/payment/providers"
+	"github.com/kaanevranportfolio/Commercium/internal/payment/repository"
+	"github.com/kaanevranportfolio/Commercium/internal/payment/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/idempotency"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/tests/integration/testenv"
+)
+
+// fakeProvider approves every operation and counts authorization and refund calls
+type fakeProvider struct {
+	authorizations int
+	refunds        int
+}
+
+func (f *fakeProvider) Name() string { return "fake" }
+
+func (f *fakeProvider) Authorize(ctx context.Context, req *providers.AuthorizeRequest) (*providers.Result, error) {
+	f.authorizations++
+	if req.PaymentMethod == "pm_card_declined" {
+		return &providers.Result{ID: "pi_" + uuid.NewString(), Status: providers.StatusFailed},
+			&providers.ProviderError{Provider: "fake", Message: "Your card was declined.", Declined: true}
+	}
+	return &providers.Result{ID: "pi_" + uuid.NewString(), Status: providers.StatusAuthorized, Amount: req.Amount}, nil
+}
+
+func (f *fakeProvider) Capture(ctx context.Context, paymentID string, amount int64, currency string, idempotencyKey string) (*providers.Result, error) {
+	return &providers.Result{ID: paymentID, Status: providers.StatusCaptured, Amount: amount}, nil
+}
+
+func (f *fakeProvider) Refund(ctx context.Context, paymentID string, amount int64, currency string, idempotencyKey string) (*providers.Result, error) {
+	f.refunds++
+	return &providers.Result{ID: "re_" + uuid.NewString(), Status: providers.StatusRefunded, Amount: amount}, nil
+}
+
+func (f *fakeProvider) Void(ctx context.Context, paymentID string, idempotencyKey string) (*providers.Result, error) {
+	return &providers.Result{ID: paymentID, Status: providers.StatusVoided}, nil
+}
+
+// ParseWebhook accepts payloads carrying a fixed signature header
+func (f *fakeProvider) ParseWebhook(ctx context.Context, header http.Header, body []byte) (*providers.WebhookEvent, error) {
+	if header.Get("X-Fake-Signature") != "valid" {
+		return nil, fmt.Errorf("invalid webhook signature")
+	}
+
+	event := &providers.WebhookEvent{}
+	if err := json.Unmarshal(body, event); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	return event, nil
+}
+
+type TestSuite struct {
+	db         *database.DB
+	router     *gin.Engine
+	service    service.PaymentService
+	provider   *fakeProvider
+	userID     uuid.UUID
+	token      string
+	adminToken string
+}
+
+func setupTestSuite(t *testing.T) *TestSuite {
+	cfg := &config.Config{
+		Auth: config.AuthConfig{
+			JWT: config.JWTConfig{
+				SecretKey:         "test-secret-key-for-testing-only",
+				Issuer:            "commercium-test",
+				Expiration:        15 * time.Minute,
+				RefreshExpiration: 24 * time.Hour,
+			},
+		},
+		Services: config.ServicesConfig{
+			Payment: config.PaymentServiceConfig{
+				Webhooks: config.PaymentWebhooksConfig{BatchSize: 50, MaxAttempts: 3},
+				Fraud: config.FraudConfig{
+					Enabled:              true,
+					ReviewThreshold:      30,
+					DeclineThreshold:     60,
+					VelocityWindow:       time.Hour,
+					MaxAttempts:          20,
+					MaxFailures:          3,
+					MaxAccountsPerDevice: 3,
+					MaxAccountsPerIP:     100,
+				},
+			},
+		},
+	}
+
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "info",
+		Format: "json",
+		Output: "stdout",
+	}, "payment-service-test")
+	require.NoError(t, err)
+
+	// Each test gets a database of its own (skipped if not available)
+	db := testenv.Database(t, log)
+
+	jwtService := auth.NewJWTService(&cfg.Auth.JWT)
+
+	provider := &fakeProvider{}
+	registry := providers.NewRegistry("fake", map[string]string{"card": "fake"})
+	registry.Register(provider)
+
+	paymentRepo := repository.NewPaymentRepository(db, log)
+	paymentService := service.NewPaymentService(paymentRepo, registry, nil, cfg, log)
+	paymentHandler := handlers.NewPaymentHandler(paymentService, jwtService, idempotency.NewStore(db, log), log)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apperrors.Middleware())
+	paymentHandler.SetupRoutes(router)
+
+	userID := uuid.New()
+	_, err = db.Exec(`INSERT INTO users (id, username, email, password_hash) VALUES ($1, $2, $3, 'x')`,
+		userID, "payment_"+userID.String()[:8], userID.String()[:8]+"@example.com")
+	require.NoError(t, err)
+
+	tokens, err := jwtService.GenerateTokenPair(userID, "payments@example.com", "payments", "customer", "")
+	require.NoError(t, err)
+
+	adminTokens, err := jwtService.GenerateTokenPair(userID, "payments@example.com", "payments", "admin", "")
+	require.NoError(t, err)
+
+	return &TestSuite{
+		db:         db,
+		router:     router,
+		service:    paymentService,
+		provider:   provider,
+		userID:     userID,
+		token:      tokens.AccessToken,
+		adminToken: adminTokens.AccessToken,
+	}
+}
+
+func (ts *TestSuite) cleanup() {
+	ts.db.Exec(`DELETE FROM idempotency_keys WHERE scope LIKE $1`, ts.userID.String()+":%")
+	ts.db.Exec(`DELETE FROM payment_webhook_events WHERE provider_payment_id IN (SELECT provider_payment_id FROM payments WHERE user_id = $1)`, ts.userID)
+	ts.db.Exec(`DELETE FROM gift_card_ledger WHERE gift_card_id IN (SELECT id FROM gift_cards WHERE issued_by = $1)`, ts.userID)
+	ts.db.Exec(`DELETE FROM gift_cards WHERE issued_by = $1`, ts.userID)
+	ts.db.Exec(`DELETE FROM payments WHERE user_id = $1`, ts.userID)
+	ts.db.Exec(`DELETE FROM orders WHERE user_id = $1`, ts.userID)
+	ts.db.Exec(`DELETE FROM users WHERE id = $1`, ts.userID)
+}
+
+func (ts *TestSuite) seedOrder(t *testing.T) uuid.UUID {
+	orderID := uuid.New()
+	_, err := ts.db.ExecContext(context.Background(), `
+		INSERT INTO orders (id, order_number, user_id, status, currency, total_amount)
+		VALUES ($1, $2, $3, 'pending', 'USD', 5000)`,
+		orderID, "ORD-"+orderID.String()[:8], ts.userID)
+	require.NoError(t, err)
+	return orderID
+}
+
+func (ts *TestSuite) seedOrderWithAddresses(t *testing.T, billingCountry, shippingCountry string) uuid.UUID {
+	orderID := uuid.New()
+	_, err := ts.db.ExecContext(context.Background(), `
+		INSERT INTO orders (id, order_number, user_id, status, currency, total_amount, billing_address, shipping_address)
+		VALUES ($1, $2, $3, 'pending', 'USD', 5000, jsonb_build_object('country', $4::text), jsonb_build_object('country', $5::text))`,
+		orderID, "ORD-"+orderID.String()[:8], ts.userID, billingCountry, shippingCountry)
+	require.NoError(t, err)
+	return orderID
+}
+
+func (ts *TestSuite) do(method, path string, body interface{}, headers map[string]string) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", ts.token))
+	req.Header.Set("Content-Type", "application/json")
+	// Checkouts require a key; each call is a new attempt unless the test passes one
+	if method == http.MethodPost && path == "/api/v1/payments" {
+		req.Header.Set(idempotency.HeaderKey, uuid.NewString())
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, req)
+	return w
+}
+
+func (ts *TestSuite) authorize(t *testing.T, orderID uuid.UUID) *models.Payment {
+	w := ts.do(http.MethodPost, "/api/v1/payments", models.AuthorizePaymentRequest{OrderID: orderID, PaymentMethod: "pm_card_visa"}, nil)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var resp struct {
+		Payment *models.Payment `json:"payment"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Payment
+}
+
+func TestPaymentLifecycleIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	t.Run("Declined card", func(t *testing.T) {
+		orderID := ts.seedOrder(t)
+
+		w := ts.do(http.MethodPost, "/api/v1/payments", models.AuthorizePaymentRequest{OrderID: orderID, PaymentMethod: "pm_card_declined"}, nil)
+		assert.Equal(t, http.StatusPaymentRequired, w.Code)
+
+		// A failed attempt doesn't block a retry
+		payment := ts.authorize(t, orderID)
+		assert.Equal(t, models.PaymentStatusAuthorized, payment.Status)
+	})
+
+	t.Run("Provider selection by payment method", func(t *testing.T) {
+		orderID := ts.seedOrder(t)
+
+		w := ts.do(http.MethodPost, "/api/v1/payments", models.AuthorizePaymentRequest{
+			OrderID: orderID, PaymentMethod: "pm_card_visa", PaymentMethodType: "bank_transfer",
+		}, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = ts.do(http.MethodPost, "/api/v1/payments", models.AuthorizePaymentRequest{
+			OrderID: orderID, PaymentMethod: "pm_card_visa", PaymentMethodType: "card",
+		}, nil)
+		require.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("Capture and partial refunds", func(t *testing.T) {
+		orderID := ts.seedOrder(t)
+		payment := ts.authorize(t, orderID)
+		assert.Equal(t, int64(5000), payment.Amount)
+
+		w := ts.do(http.MethodPost, "/api/v1/payments", models.AuthorizePaymentRequest{OrderID: orderID, PaymentMethod: "pm_card_visa"}, nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = ts.do(http.MethodPost, "/internal/v1/payments/"+payment.ID.String()+"/capture", nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		refund := models.RefundRequest{OrderID: orderID, RefundID: uuid.New(), Amount: 2000, Currency: "USD"}
+		headers := map[string]string{"Idempotency-Key": refund.RefundID.String()}
+
+		w = ts.do(http.MethodPost, "/internal/v1/refunds", refund, headers)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		// Retrying the same refund doesn't call the provider again
+		w = ts.do(http.MethodPost, "/internal/v1/refunds", refund, headers)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 1, ts.provider.refunds)
+
+		refund.RefundID = uuid.New()
+		refund.Amount = 4000
+		w = ts.do(http.MethodPost, "/internal/v1/refunds", refund, map[string]string{"Idempotency-Key": refund.RefundID.String()})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = ts.do(http.MethodGet, "/api/v1/payments/"+payment.ID.String(), nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var got models.Payment
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		assert.Equal(t, models.PaymentStatusPartiallyRefunded, got.Status)
+		assert.Equal(t, int64(2000), got.RefundedAmount)
+		assert.Len(t, got.Transactions, 3)
+	})
+
+	t.Run("Refund before capture voids", func(t *testing.T) {
+		orderID := ts.seedOrder(t)
+		ts.authorize(t, orderID)
+
+		refund := models.RefundRequest{OrderID: orderID, RefundID: uuid.New(), Amount: 5000, Currency: "USD"}
+		w := ts.do(http.MethodPost, "/internal/v1/refunds", refund, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp models.RefundResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, providers.StatusVoided, resp.Status)
+	})
+}
+
+func TestPaymentIdempotencyIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	t.Run("Missing key is rejected", func(t *testing.T) {
+		orderID := ts.seedOrder(t)
+
+		w := ts.do(http.MethodPost, "/api/v1/payments", models.AuthorizePaymentRequest{OrderID: orderID, PaymentMethod: "pm_card_visa"},
+			map[string]string{idempotency.HeaderKey: ""})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Retried checkout is replayed", func(t *testing.T) {
+		orderID := ts.seedOrder(t)
+		req := models.AuthorizePaymentRequest{OrderID: orderID, PaymentMethod: "pm_card_visa"}
+		headers := map[string]string{idempotency.HeaderKey: uuid.NewString()}
+		authorizations := ts.provider.authorizations
+
+		first := ts.do(http.MethodPost, "/api/v1/payments", req, headers)
+		require.Equal(t, http.StatusCreated, first.Code)
+
+		second := ts.do(http.MethodPost, "/api/v1/payments", req, headers)
+		require.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, "true", second.Header().Get(idempotency.HeaderReplayed))
+		assert.JSONEq(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, authorizations+1, ts.provider.authorizations)
+	})
+
+	t.Run("Retried declined checkout is replayed with its status", func(t *testing.T) {
+		req := models.AuthorizePaymentRequest{OrderID: ts.seedOrder(t), PaymentMethod: "pm_card_declined"}
+		headers := map[string]string{idempotency.HeaderKey: uuid.NewString()}
+		authorizations := ts.provider.authorizations
+
+		first := ts.do(http.MethodPost, "/api/v1/payments", req, headers)
+		require.Equal(t, http.StatusPaymentRequired, first.Code)
+
+		second := ts.do(http.MethodPost, "/api/v1/payments", req, headers)
+		require.Equal(t, http.StatusPaymentRequired, second.Code)
+		assert.Equal(t, "true", second.Header().Get(idempotency.HeaderReplayed))
+		assert.JSONEq(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, authorizations+1, ts.provider.authorizations)
+	})
+
+	t.Run("Key reused for a different request", func(t *testing.T) {
+		headers := map[string]string{idempotency.HeaderKey: uuid.NewString()}
+
+		w := ts.do(http.MethodPost, "/api/v1/payments", models.AuthorizePaymentRequest{OrderID: ts.seedOrder(t), PaymentMethod: "pm_card_visa"}, headers)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		w = ts.do(http.MethodPost, "/api/v1/payments", models.AuthorizePaymentRequest{OrderID: ts.seedOrder(t), PaymentMethod: "pm_card_visa"}, headers)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("Interrupted checkout resumes with the same payment", func(t *testing.T) {
+		orderID := ts.seedOrder(t)
+		req := &models.AuthorizePaymentRequest{OrderID: orderID, PaymentMethod: "pm_card_visa"}
+		key := uuid.NewString()
+
+		first, err := ts.service.Authorize(context.Background(), ts.userID, req, key)
+		require.NoError(t, err)
+
+		// Simulate a crash between creating the payment and recording the provider's answer
+		_, err = ts.db.Exec(`UPDATE payments SET status = 'pending' WHERE id = $1`, first.ID)
+		require.NoError(t, err)
+
+		second, err := ts.service.Authorize(context.Background(), ts.userID, req, key)
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, second.ID)
+		assert.Equal(t, models.PaymentStatusAuthorized, second.Status)
+
+		_, err = ts.service.Authorize(context.Background(), ts.userID, &models.AuthorizePaymentRequest{OrderID: ts.seedOrder(t)}, key)
+		assert.Error(t, err)
+	})
+}
+
+func (ts *TestSuite) webhook(event *providers.WebhookEvent, signature string) *httptest.ResponseRecorder {
+	return ts.do(http.MethodPost, "/webhooks/fake", event, map[string]string{"X-Fake-Signature": signature})
+}
+
+func (ts *TestSuite) getPayment(t *testing.T, paymentID uuid.UUID) *models.Payment {
+	w := ts.do(http.MethodGet, "/api/v1/payments/"+paymentID.String(), nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	payment := &models.Payment{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), payment))
+	return payment
+}
+
+func TestPaymentWebhookIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	ctx := context.Background()
+
+	t.Run("Verification and deduplication", func(t *testing.T) {
+		orderID := ts.seedOrder(t)
+		payment := ts.authorize(t, orderID)
+
+		event := &providers.WebhookEvent{
+			ID:        "evt_" + uuid.NewString(),
+			Type:      providers.WebhookPaymentCaptured,
+			PaymentID: *payment.ProviderPaymentID,
+			Amount:    5000,
+		}
+
+		w := ts.webhook(event, "forged")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		// Redeliveries are acknowledged but stored once
+		for i := 0; i < 2; i++ {
+			w = ts.webhook(event, "valid")
+			require.Equal(t, http.StatusOK, w.Code)
+		}
+
+		var stored int
+		require.NoError(t, ts.db.Get(&stored, `SELECT COUNT(*) FROM payment_webhook_events WHERE event_id = $1`, event.ID))
+		assert.Equal(t, 1, stored)
+
+		// Processing is asynchronous
+		assert.Equal(t, models.PaymentStatusAuthorized, ts.getPayment(t, payment.ID).Status)
+
+		_, err := ts.service.ProcessWebhookEvents(ctx)
+		require.NoError(t, err)
+
+		got := ts.getPayment(t, payment.ID)
+		assert.Equal(t, models.PaymentStatusCaptured, got.Status)
+		assert.Equal(t, int64(5000), got.CapturedAmount)
+	})
+
+	t.Run("Capture of cancelled order is refunded", func(t *testing.T) {
+		orderID := ts.seedOrder(t)
+		payment := ts.authorize(t, orderID)
+
+		_, err := ts.db.Exec(`UPDATE orders SET status = 'cancelled' WHERE id = $1`, orderID)
+		require.NoError(t, err)
+
+		w := ts.webhook(&providers.WebhookEvent{
+			ID:        "evt_" + uuid.NewString(),
+			Type:      providers.WebhookPaymentCaptured,
+			PaymentID: *payment.ProviderPaymentID,
+			Amount:    5000,
+		}, "valid")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		_, err = ts.service.ProcessWebhookEvents(ctx)
+		require.NoError(t, err)
+
+		got := ts.getPayment(t, payment.ID)
+		assert.Equal(t, models.PaymentStatusRefunded, got.Status)
+		assert.Equal(t, int64(5000), got.RefundedAmount)
+	})
+
+	t.Run("Refund issued at provider", func(t *testing.T) {
+		orderID := ts.seedOrder(t)
+		payment := ts.authorize(t, orderID)
+
+		w := ts.do(http.MethodPost, "/internal/v1/payments/"+payment.ID.String()+"/capture", nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = ts.webhook(&providers.WebhookEvent{
+			ID:        "evt_" + uuid.NewString(),
+			Type:      providers.WebhookPaymentRefunded,
+			PaymentID: *payment.ProviderPaymentID,
+			Amount:    1500,
+		}, "valid")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		_, err := ts.service.ProcessWebhookEvents(ctx)
+		require.NoError(t, err)
+
+		got := ts.getPayment(t, payment.ID)
+		assert.Equal(t, models.PaymentStatusPartiallyRefunded, got.Status)
+		assert.Equal(t, int64(1500), got.RefundedAmount)
+	})
+}
+
+func TestFraudScreeningIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	admin := map[string]string{"Authorization": "Bearer " + ts.adminToken}
+
+	pendingReview := func(t *testing.T, paymentID uuid.UUID) *models.FraudAssessment {
+		w := ts.do(http.MethodGet, "/api/v1/admin/fraud/reviews", nil, admin)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp httpx.ListResponse[*models.FraudAssessment]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		for _, review := range resp.Data {
+			if review.PaymentID == paymentID {
+				return review
+			}
+		}
+		t.Fatalf("payment %s not in review queue", paymentID)
+		return nil
+	}
+
+	t.Run("Matching countries pass", func(t *testing.T) {
+		payment := ts.authorize(t, ts.seedOrderWithAddresses(t, "US", "US"))
+
+		w := ts.do(http.MethodPost, "/internal/v1/payments/"+payment.ID.String()+"/capture", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Held for review until approved", func(t *testing.T) {
+		payment := ts.authorize(t, ts.seedOrderWithAddresses(t, "US", "DE"))
+		assert.Equal(t, models.PaymentStatusAuthorized, payment.Status)
+
+		w := ts.do(http.MethodPost, "/internal/v1/payments/"+payment.ID.String()+"/capture", nil, nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		review := pendingReview(t, payment.ID)
+		assert.Equal(t, "review", review.Decision)
+		assert.NotEmpty(t, review.Reasons)
+
+		// Customers can't review their own checkouts
+		w = ts.do(http.MethodPost, "/api/v1/admin/fraud/reviews/"+review.ID.String(), models.FraudReviewRequest{Decision: "approve"}, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = ts.do(http.MethodPost, "/api/v1/admin/fraud/reviews/"+review.ID.String(), models.FraudReviewRequest{Decision: "approve"}, admin)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = ts.do(http.MethodPost, "/api/v1/admin/fraud/reviews/"+review.ID.String(), models.FraudReviewRequest{Decision: "reject"}, admin)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = ts.do(http.MethodPost, "/internal/v1/payments/"+payment.ID.String()+"/capture", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Rejected review voids the payment", func(t *testing.T) {
+		payment := ts.authorize(t, ts.seedOrderWithAddresses(t, "US", "DE"))
+		review := pendingReview(t, payment.ID)
+
+		w := ts.do(http.MethodPost, "/api/v1/admin/fraud/reviews/"+review.ID.String(), models.FraudReviewRequest{Decision: "reject", Notes: "stolen card"}, admin)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, models.PaymentStatusVoided, ts.getPayment(t, payment.ID).Status)
+	})
+
+	t.Run("Repeated declines are declined without calling the provider", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			w := ts.do(http.MethodPost, "/api/v1/payments", models.AuthorizePaymentRequest{OrderID: ts.seedOrder(t), PaymentMethod: "pm_card_declined"}, nil)
+			require.Equal(t, http.StatusPaymentRequired, w.Code)
+		}
+
+		authorizations := ts.provider.authorizations
+		w := ts.do(http.MethodPost, "/api/v1/payments", models.AuthorizePaymentRequest{OrderID: ts.seedOrderWithAddresses(t, "US", "DE"), PaymentMethod: "pm_card_visa"}, nil)
+		assert.Equal(t, http.StatusPaymentRequired, w.Code)
+		assert.Equal(t, authorizations, ts.provider.authorizations)
+	})
+}
+
+func TestGiftCardIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	admin := map[string]string{"Authorization": "Bearer " + ts.adminToken}
+
+	issue := func(t *testing.T, amount int64) *models.IssuedGiftCard {
+		w := ts.do(http.MethodPost, "/api/v1/admin/gift-cards", models.IssueGiftCardRequest{Amount: amount, Currency: "USD"}, admin)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		issued := &models.IssuedGiftCard{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), issued))
+		return issued
+	}
+
+	getCard := func(t *testing.T, id uuid.UUID) *models.GiftCard {
+		w := ts.do(http.MethodGet, "/api/v1/admin/gift-cards/"+id.String(), nil, admin)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		card := &models.GiftCard{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), card))
+
+		// The ledger always accounts for the balance
+		var sum int64
+		for _, entry := range card.Ledger {
+			sum += entry.Amount
+		}
+		assert.Equal(t, card.Balance, sum)
+		return card
+	}
+
+	authorize := func(t *testing.T, req models.AuthorizePaymentRequest) *models.Payment {
+		w := ts.do(http.MethodPost, "/api/v1/payments", req, nil)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var resp struct {
+			Payment *models.Payment `json:"payment"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Payment
+	}
+
+	t.Run("Issuance and balance check", func(t *testing.T) {
+		w := ts.do(http.MethodPost, "/api/v1/admin/gift-cards", models.IssueGiftCardRequest{Amount: 3000, Currency: "USD"}, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		issued := issue(t, 3000)
+		assert.Len(t, issued.Code, 19)
+		assert.Equal(t, issued.Code[len(issued.Code)-4:], issued.GiftCard.LastFour)
+
+		// Codes are accepted without separators and in lowercase
+		code := strings.ToLower(strings.ReplaceAll(issued.Code, "-", ""))
+		w = ts.do(http.MethodPost, "/api/v1/gift-cards/balance", models.GiftCardBalanceRequest{Code: code}, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var balance models.GiftCardBalance
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &balance))
+		assert.Equal(t, int64(3000), balance.Balance)
+		assert.False(t, balance.Expired)
+
+		w = ts.do(http.MethodPost, "/api/v1/gift-cards/balance", models.GiftCardBalanceRequest{Code: "AAAA-BBBB-CCCC-DDDD"}, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Partial redemption and refund", func(t *testing.T) {
+		issued := issue(t, 3000)
+
+		payment := authorize(t, models.AuthorizePaymentRequest{
+			OrderID: ts.seedOrder(t), PaymentMethod: "pm_card_visa", GiftCardCodes: []string{issued.Code},
+		})
+		assert.Equal(t, int64(5000), payment.Amount)
+		assert.Equal(t, int64(3000), payment.GiftCardAmount)
+		assert.Equal(t, int64(0), getCard(t, issued.GiftCard.ID).Balance)
+
+		w := ts.do(http.MethodPost, "/internal/v1/payments/"+payment.ID.String()+"/capture", nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int64(2000), ts.getPayment(t, payment.ID).CapturedAmount)
+
+		// The provider is refunded first, the rest goes back to the gift card
+		refund := models.RefundRequest{OrderID: payment.OrderID, RefundID: uuid.New(), Amount: 3500, Currency: "USD"}
+		headers := map[string]string{"Idempotency-Key": refund.RefundID.String()}
+		for i := 0; i < 2; i++ {
+			w = ts.do(http.MethodPost, "/internal/v1/refunds", refund, headers)
+			require.Equal(t, http.StatusOK, w.Code)
+		}
+
+		got := ts.getPayment(t, payment.ID)
+		assert.Equal(t, models.PaymentStatusPartiallyRefunded, got.Status)
+		assert.Equal(t, int64(2000), got.RefundedAmount)
+		assert.Equal(t, int64(1500), got.GiftCardRefundedAmount)
+		assert.Equal(t, int64(1500), getCard(t, issued.GiftCard.ID).Balance)
+
+		refund = models.RefundRequest{OrderID: payment.OrderID, RefundID: uuid.New(), Amount: 1500, Currency: "USD"}
+		w = ts.do(http.MethodPost, "/internal/v1/refunds", refund, map[string]string{"Idempotency-Key": refund.RefundID.String()})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, models.PaymentStatusRefunded, ts.getPayment(t, payment.ID).Status)
+		assert.Equal(t, int64(3000), getCard(t, issued.GiftCard.ID).Balance)
+	})
+
+	t.Run("Order covered by gift cards", func(t *testing.T) {
+		first := issue(t, 2000)
+		second := issue(t, 10000)
+		authorizations := ts.provider.authorizations
+
+		payment := authorize(t, models.AuthorizePaymentRequest{
+			OrderID: ts.seedOrder(t), GiftCardCodes: []string{first.Code, second.Code},
+		})
+		assert.Equal(t, models.PaymentStatusAuthorized, payment.Status)
+		assert.Equal(t, models.GiftCardProvider, payment.Provider)
+		assert.Equal(t, int64(5000), payment.GiftCardAmount)
+		assert.Equal(t, authorizations, ts.provider.authorizations)
+		assert.Equal(t, int64(0), getCard(t, first.GiftCard.ID).Balance)
+		assert.Equal(t, int64(7000), getCard(t, second.GiftCard.ID).Balance)
+
+		// Refunding before capture releases the redemptions
+		refund := models.RefundRequest{OrderID: payment.OrderID, RefundID: uuid.New(), Amount: 5000, Currency: "USD"}
+		w := ts.do(http.MethodPost, "/internal/v1/refunds", refund, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, models.PaymentStatusVoided, ts.getPayment(t, payment.ID).Status)
+		assert.Equal(t, int64(2000), getCard(t, first.GiftCard.ID).Balance)
+		assert.Equal(t, int64(10000), getCard(t, second.GiftCard.ID).Balance)
+
+		w = ts.do(http.MethodPost, "/api/v1/payments", models.AuthorizePaymentRequest{
+			OrderID: ts.seedOrder(t), GiftCardCodes: []string{first.Code},
+		}, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Declined card reverses the redemption", func(t *testing.T) {
+		issued := issue(t, 1000)
+
+		w := ts.do(http.MethodPost, "/api/v1/payments", models.AuthorizePaymentRequest{
+			OrderID: ts.seedOrder(t), PaymentMethod: "pm_card_declined", GiftCardCodes: []string{issued.Code},
+		}, nil)
+		assert.Equal(t, http.StatusPaymentRequired, w.Code)
+
+		card := getCard(t, issued.GiftCard.ID)
+		assert.Equal(t, int64(1000), card.Balance)
+		assert.Len(t, card.Ledger, 3)
+	})
+
+	t.Run("Disabled and expired cards can't be redeemed", func(t *testing.T) {
+		disabled := issue(t, 1000)
+		w := ts.do(http.MethodPost, "/api/v1/admin/gift-cards/"+disabled.GiftCard.ID.String()+"/disable", nil, admin)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		expired := issue(t, 1000)
+		_, err := ts.db.Exec(`UPDATE gift_cards SET expires_at = NOW() - INTERVAL '1 day' WHERE id = $1`, expired.GiftCard.ID)
+		require.NoError(t, err)
+
+		for _, code := range []string{disabled.Code, expired.Code} {
+			w = ts.do(http.MethodPost, "/api/v1/payments", models.AuthorizePaymentRequest{
+				OrderID: ts.seedOrder(t), PaymentMethod: "pm_card_visa", GiftCardCodes: []string{code},
+			}, nil)
+			assert.Equal(t, http.StatusConflict, w.Code)
+		}
+	})
+}
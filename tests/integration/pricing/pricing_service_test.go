@@ -0,0 +1,289 @@
+package pricing_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/internal/pricing/handlers"
+	"github.com/kaanevranportfolio/Commercium/internal/pricing/models"
+	"github.com/kaanevranportfolio/Commercium/internal/pricing/repository"
+	"github.com/kaanevranportfolio/Commercium/internal/pricing/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/tests/integration/testenv"
+)
+
+// TestSuite holds the test dependencies
+type TestSuite struct {
+	db           *database.DB
+	router       *gin.Engine
+	jwtService   *auth.JWTService
+	userIDs      []uuid.UUID
+	priceListIDs []uuid.UUID
+	suffix       string
+}
+
+func setupTestSuite(t *testing.T) *TestSuite {
+	cfg := &config.Config{
+		Auth: config.AuthConfig{
+			JWT: config.JWTConfig{
+				SecretKey:         "test-secret-key-for-testing-only",
+				Issuer:            "commercium-test",
+				Expiration:        15 * time.Minute,
+				RefreshExpiration: 24 * time.Hour,
+			},
+		},
+		Services: config.ServicesConfig{
+			Pricing: config.PricingServiceConfig{
+				MaxBulkPrices: 10,
+			},
+		},
+	}
+
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "info",
+		Format: "json",
+		Output: "stdout",
+	}, "pricing-service-test")
+	require.NoError(t, err)
+
+	// Each test gets a database of its own (skipped if not available)
+	db := testenv.Database(t, log)
+
+	jwtService := auth.NewJWTService(&cfg.Auth.JWT)
+
+	pricingRepo := repository.NewPricingRepository(db, log)
+	pricingService := service.NewPricingService(pricingRepo, cfg, log)
+	pricingHandler := handlers.NewPricingHandler(pricingService, jwtService, log)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apperrors.Middleware())
+	pricingHandler.SetupRoutes(router)
+
+	return &TestSuite{
+		db:         db,
+		router:     router,
+		jwtService: jwtService,
+		suffix:     uuid.New().String()[:8],
+	}
+}
+
+func (ts *TestSuite) cleanup() {
+	for _, listID := range ts.priceListIDs {
+		ts.db.Exec(`DELETE FROM price_lists WHERE id = $1`, listID)
+	}
+	for _, userID := range ts.userIDs {
+		ts.db.Exec(`DELETE FROM customer_groups WHERE user_id = $1`, userID)
+		ts.db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	}
+}
+
+// seedUser creates a user and returns an access token for it
+func (ts *TestSuite) seedUser(t *testing.T, role string) (uuid.UUID, string) {
+	userID := uuid.New()
+	_, err := ts.db.Exec(`INSERT INTO users (id, username, email, password_hash, role) VALUES ($1, $2, $3, 'x', $4)`,
+		userID, "pricing_"+userID.String()[:8], userID.String()[:8]+"@example.com", role)
+	require.NoError(t, err)
+	ts.userIDs = append(ts.userIDs, userID)
+
+	tokens, err := ts.jwtService.GenerateTokenPair(userID, userID.String()[:8]+"@example.com", "pricing_"+userID.String()[:8], role, "")
+	require.NoError(t, err)
+	return userID, tokens.AccessToken
+}
+
+func (ts *TestSuite) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	if token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, req)
+	return w
+}
+
+func (ts *TestSuite) createList(t *testing.T, token, name string, group *string, priority int) *models.PriceList {
+	w := ts.do(http.MethodPost, "/api/v1/admin/price-lists", token, models.CreatePriceListRequest{
+		Name:          name + " " + ts.suffix,
+		Currency:      "xts",
+		CustomerGroup: group,
+		Priority:      priority,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var list models.PriceList
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	ts.priceListIDs = append(ts.priceListIDs, list.ID)
+	return &list
+}
+
+func (ts *TestSuite) addPrices(t *testing.T, token string, listID uuid.UUID, entries ...models.PriceEntry) *models.BulkPricesResponse {
+	w := ts.do(http.MethodPost, "/api/v1/admin/price-lists/"+listID.String()+"/prices", token, models.BulkPricesRequest{Prices: entries})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp models.BulkPricesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return &resp
+}
+
+func (ts *TestSuite) resolve(t *testing.T, userID uuid.UUID, at *time.Time, skus ...string) map[string]*models.ResolvedPrice {
+	w := ts.do(http.MethodPost, "/internal/v1/prices/resolve", "", models.ResolvePricesRequest{
+		UserID:   userID,
+		Currency: "XTS",
+		SKUs:     skus,
+		At:       at,
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp models.ResolvePricesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	prices := make(map[string]*models.ResolvedPrice)
+	for _, price := range resp.Prices {
+		prices[price.SKU] = price
+	}
+	return prices
+}
+
+func TestPriceResolutionIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	_, admin := ts.seedUser(t, "admin")
+	customerID, customer := ts.seedUser(t, "customer")
+	wholesalerID, _ := ts.seedUser(t, "customer")
+
+	skuA := "PRC-A-" + ts.suffix
+	skuB := "PRC-B-" + ts.suffix
+	wholesale := "wholesale"
+
+	retail := ts.createList(t, admin, "Retail", nil, 0)
+	trade := ts.createList(t, admin, "Wholesale", &wholesale, 0)
+
+	t.Run("Only admins manage price lists", func(t *testing.T) {
+		w := ts.do(http.MethodPost, "/api/v1/admin/price-lists", customer, models.CreatePriceListRequest{Name: "Nope", Currency: "XTS"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Bulk update is validated as a whole", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour)
+		w := ts.do(http.MethodPost, "/api/v1/admin/price-lists/"+retail.ID.String()+"/prices", admin, models.BulkPricesRequest{
+			Prices: []models.PriceEntry{
+				{SKU: skuA, Amount: 1000},
+				{SKU: skuB, Amount: 500, StartsAt: &past},
+			},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = ts.do(http.MethodPost, "/api/v1/admin/price-lists/"+retail.ID.String()+"/prices", admin, models.BulkPricesRequest{
+			Prices: []models.PriceEntry{{SKU: skuA, Kind: models.PriceKindSale, Amount: 800}},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		assert.Empty(t, ts.resolve(t, uuid.Nil, nil, skuA))
+	})
+
+	ts.addPrices(t, admin, retail.ID,
+		models.PriceEntry{SKU: skuA, Amount: 1000},
+		models.PriceEntry{SKU: skuB, Amount: 500},
+	)
+	ts.addPrices(t, admin, trade.ID, models.PriceEntry{SKU: skuA, Amount: 700})
+
+	t.Run("Customer groups get their own list", func(t *testing.T) {
+		assert.Equal(t, int64(1000), ts.resolve(t, customerID, nil, skuA)[skuA].UnitPrice)
+
+		w := ts.do(http.MethodPut, "/api/v1/admin/customer-groups/"+wholesalerID.String(), admin, models.SetCustomerGroupRequest{CustomerGroup: "Wholesale"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		prices := ts.resolve(t, wholesalerID, nil, skuA, skuB)
+		assert.Equal(t, int64(700), prices[skuA].UnitPrice)
+		assert.Equal(t, trade.ID, prices[skuA].PriceListID)
+		// SKUs missing from the group list fall back to the list for everyone
+		assert.Equal(t, int64(500), prices[skuB].UnitPrice)
+	})
+
+	t.Run("Sale prices apply while the sale runs", func(t *testing.T) {
+		start := time.Now().Add(time.Hour)
+		end := start.Add(24 * time.Hour)
+		ts.addPrices(t, admin, retail.ID, models.PriceEntry{SKU: skuA, Kind: models.PriceKindSale, Amount: 800, StartsAt: &start, EndsAt: &end})
+
+		assert.False(t, ts.resolve(t, customerID, nil, skuA)[skuA].OnSale)
+
+		during := start.Add(time.Hour)
+		price := ts.resolve(t, customerID, &during, skuA)[skuA]
+		assert.True(t, price.OnSale)
+		assert.Equal(t, int64(800), price.UnitPrice)
+		assert.Equal(t, int64(1000), price.ListPrice)
+
+		// The wholesale price is already below the sale price
+		assert.False(t, ts.resolve(t, wholesalerID, &during, skuA)[skuA].OnSale)
+
+		after := end.Add(time.Minute)
+		assert.Equal(t, int64(1000), ts.resolve(t, customerID, &after, skuA)[skuA].UnitPrice)
+	})
+
+	t.Run("Scheduled price changes", func(t *testing.T) {
+		start := time.Now().Add(48 * time.Hour)
+		scheduled := ts.addPrices(t, admin, retail.ID, models.PriceEntry{SKU: skuB, Amount: 550, StartsAt: &start})
+
+		assert.Equal(t, int64(500), ts.resolve(t, customerID, nil, skuB)[skuB].UnitPrice)
+
+		later := start.Add(time.Minute)
+		assert.Equal(t, int64(550), ts.resolve(t, customerID, &later, skuB)[skuB].UnitPrice)
+
+		w := ts.do(http.MethodGet, "/api/v1/admin/price-lists/"+retail.ID.String()+"/prices?upcoming=true&sku="+skuB, admin, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var listed httpx.ListResponse[*models.Price]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+		require.Len(t, listed.Data, 1)
+
+		// Cancelling the scheduled change keeps the current price
+		w = ts.do(http.MethodDelete, "/api/v1/admin/price-lists/"+retail.ID.String()+"/prices/"+scheduled.Prices[0].ID.String(), admin, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int64(500), ts.resolve(t, customerID, &later, skuB)[skuB].UnitPrice)
+	})
+
+	t.Run("Prices in effect cannot be deleted", func(t *testing.T) {
+		w := ts.do(http.MethodGet, "/api/v1/admin/price-lists/"+retail.ID.String()+"/prices?sku="+skuB, admin, nil)
+		var listed httpx.ListResponse[*models.Price]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+		require.NotEmpty(t, listed.Data)
+
+		w = ts.do(http.MethodDelete, "/api/v1/admin/price-lists/"+retail.ID.String()+"/prices/"+listed.Data[0].ID.String(), admin, nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Inactive lists are ignored", func(t *testing.T) {
+		inactive := false
+		w := ts.do(http.MethodPut, "/api/v1/admin/price-lists/"+trade.ID.String(), admin, models.UpdatePriceListRequest{IsActive: &inactive})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		assert.Equal(t, int64(1000), ts.resolve(t, wholesalerID, nil, skuA)[skuA].UnitPrice)
+	})
+
+	t.Run("Public prices", func(t *testing.T) {
+		w := ts.do(http.MethodGet, "/api/v1/prices?currency=XTS&sku="+skuA+"&sku=UNKNOWN-"+ts.suffix, "", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp models.ResolvePricesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Prices, 1)
+		assert.Equal(t, int64(1000), resp.Prices[0].UnitPrice)
+		assert.Equal(t, []string{"UNKNOWN-" + ts.suffix}, resp.Missing)
+	})
+}
@@ -0,0 +1,320 @@
+package review_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/internal/review/handlers"
+	"github.com/kaanevranportfolio/Commercium/internal/review/models"
+	"github.com/kaanevranportfolio/Commercium/internal/review/repository"
+	"github.com/kaanevranportfolio/Commercium/internal/review/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/tests/integration/testenv"
+)
+
+// TestSuite holds the test dependencies
+type TestSuite struct {
+	db         *database.DB
+	router     *gin.Engine
+	jwtService *auth.JWTService
+	userIDs    []uuid.UUID
+	productID  uuid.UUID
+}
+
+func setupTestSuite(t *testing.T) *TestSuite {
+	cfg := &config.Config{
+		Auth: config.AuthConfig{
+			JWT: config.JWTConfig{
+				SecretKey:         "test-secret-key-for-testing-only",
+				Issuer:            "commercium-test",
+				Expiration:        15 * time.Minute,
+				RefreshExpiration: 24 * time.Hour,
+			},
+		},
+	}
+
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "info",
+		Format: "json",
+		Output: "stdout",
+	}, "review-service-test")
+	require.NoError(t, err)
+
+	// Each test gets a database of its own (skipped if not available)
+	db := testenv.Database(t, log)
+
+	jwtService := auth.NewJWTService(&cfg.Auth.JWT)
+
+	reviewRepo := repository.NewReviewRepository(db, log)
+	reviewService := service.NewReviewService(reviewRepo, nil, nil, cfg, log)
+	reviewHandler := handlers.NewReviewHandler(reviewService, jwtService, log)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apperrors.Middleware())
+	reviewHandler.SetupRoutes(router)
+
+	return &TestSuite{
+		db:         db,
+		router:     router,
+		jwtService: jwtService,
+		productID:  uuid.New(),
+	}
+}
+
+func (ts *TestSuite) cleanup() {
+	ts.db.Exec(`DELETE FROM product_reviews WHERE product_id = $1`, ts.productID)
+	ts.db.Exec(`DELETE FROM product_ratings WHERE product_id = $1`, ts.productID)
+	for _, userID := range ts.userIDs {
+		ts.db.Exec(`DELETE FROM orders WHERE user_id = $1`, userID)
+		ts.db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	}
+}
+
+// seedUser creates a user and returns an access token for it
+func (ts *TestSuite) seedUser(t *testing.T, role string) (uuid.UUID, string) {
+	userID := uuid.New()
+	_, err := ts.db.Exec(`INSERT INTO users (id, username, email, password_hash, role) VALUES ($1, $2, $3, 'x', $4)`,
+		userID, "review_"+userID.String()[:8], userID.String()[:8]+"@example.com", role)
+	require.NoError(t, err)
+	ts.userIDs = append(ts.userIDs, userID)
+
+	tokens, err := ts.jwtService.GenerateTokenPair(userID, userID.String()[:8]+"@example.com", "review_"+userID.String()[:8], role, "")
+	require.NoError(t, err)
+	return userID, tokens.AccessToken
+}
+
+// seedPurchase creates an order in which the user bought the test product
+func (ts *TestSuite) seedPurchase(t *testing.T, userID uuid.UUID, status string) {
+	orderID := uuid.New()
+	_, err := ts.db.ExecContext(context.Background(), `
+		INSERT INTO orders (id, order_number, user_id, status, currency, total_amount)
+		VALUES ($1, $2, $3, $4, 'USD', 2500)`,
+		orderID, "ORD-"+orderID.String()[:8], userID, status)
+	require.NoError(t, err)
+
+	_, err = ts.db.ExecContext(context.Background(), `
+		INSERT INTO order_items (order_id, product_id, sku, name, quantity, unit_price, total_price)
+		VALUES ($1, $2, 'SKU-1', 'Test Product', 1, 2500, 2500)`,
+		orderID, ts.productID)
+	require.NoError(t, err)
+}
+
+func (ts *TestSuite) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	if token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, req)
+	return w
+}
+
+func (ts *TestSuite) submit(t *testing.T, token string, rating int) *models.Review {
+	w := ts.do(http.MethodPost, "/api/v1/products/"+ts.productID.String()+"/reviews", token, models.SubmitReviewRequest{
+		Rating: rating,
+		Title:  fmt.Sprintf("%d stars", rating),
+		Body:   "Review body",
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var review models.Review
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &review))
+	return &review
+}
+
+func (ts *TestSuite) moderate(t *testing.T, token string, reviewID uuid.UUID, decision string) int {
+	w := ts.do(http.MethodPost, "/api/v1/admin/reviews/"+reviewID.String()+"/moderation", token, models.ModerationRequest{Decision: decision})
+	return w.Code
+}
+
+func (ts *TestSuite) rating(t *testing.T) *models.ProductRating {
+	w := ts.do(http.MethodGet, "/api/v1/products/"+ts.productID.String()+"/rating", "", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var rating models.ProductRating
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rating))
+	return &rating
+}
+
+func (ts *TestSuite) list(t *testing.T, query string) *models.ReviewListResponse {
+	w := ts.do(http.MethodGet, "/api/v1/products/"+ts.productID.String()+"/reviews?"+query, "", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp models.ReviewListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return &resp
+}
+
+func TestReviewModerationIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	buyerID, buyer := ts.seedUser(t, "customer")
+	_, browser := ts.seedUser(t, "customer")
+	_, admin := ts.seedUser(t, "admin")
+	ts.seedPurchase(t, buyerID, "delivered")
+
+	var verified, unverified *models.Review
+
+	t.Run("Submitted reviews wait for moderation", func(t *testing.T) {
+		verified = ts.submit(t, buyer, 5)
+		assert.True(t, verified.VerifiedPurchase)
+		assert.Equal(t, models.ReviewStatusPending, verified.Status)
+
+		unverified = ts.submit(t, browser, 2)
+		assert.False(t, unverified.VerifiedPurchase)
+
+		assert.Empty(t, ts.list(t, "").Data)
+		assert.Equal(t, 0, ts.rating(t).ReviewCount)
+	})
+
+	t.Run("One review per customer", func(t *testing.T) {
+		w := ts.do(http.MethodPost, "/api/v1/products/"+ts.productID.String()+"/reviews", buyer, models.SubmitReviewRequest{
+			Rating: 4, Title: "Again", Body: "Second review",
+		})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Only admins moderate", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, ts.moderate(t, buyer, verified.ID, "approve"))
+	})
+
+	t.Run("Approval updates the rating", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, ts.moderate(t, admin, verified.ID, "approve"))
+		require.Equal(t, http.StatusOK, ts.moderate(t, admin, unverified.ID, "approve"))
+		assert.Equal(t, http.StatusConflict, ts.moderate(t, admin, verified.ID, "approve"))
+
+		rating := ts.rating(t)
+		assert.Equal(t, 2, rating.ReviewCount)
+		assert.Equal(t, 3.5, rating.AverageRating)
+		assert.Equal(t, 1, rating.Distribution[5])
+		assert.Equal(t, 1, rating.Distribution[2])
+	})
+
+	t.Run("Rejection removes a review from the rating", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, ts.moderate(t, admin, unverified.ID, "reject"))
+
+		rating := ts.rating(t)
+		assert.Equal(t, 1, rating.ReviewCount)
+		assert.Equal(t, 5.0, rating.AverageRating)
+		assert.Equal(t, 0, rating.Distribution[2])
+	})
+
+	t.Run("Edits go back to moderation", func(t *testing.T) {
+		w := ts.do(http.MethodPut, "/api/v1/reviews/"+verified.ID.String(), buyer, models.SubmitReviewRequest{
+			Rating: 4, Title: "Still good", Body: "Changed my mind a little",
+		})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 0, ts.rating(t).ReviewCount)
+
+		w = ts.do(http.MethodPut, "/api/v1/reviews/"+verified.ID.String(), browser, models.SubmitReviewRequest{
+			Rating: 1, Title: "Hijacked", Body: "Not my review",
+		})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestReviewListingIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	_, admin := ts.seedUser(t, "admin")
+
+	tokens := make([]string, 4)
+	reviews := make([]*models.Review, 4)
+	for i := range reviews {
+		userID, token := ts.seedUser(t, "customer")
+		if i%2 == 0 {
+			ts.seedPurchase(t, userID, "confirmed")
+		}
+		tokens[i] = token
+		reviews[i] = ts.submit(t, token, i+1)
+		require.Equal(t, http.StatusOK, ts.moderate(t, admin, reviews[i].ID, "approve"))
+	}
+
+	t.Run("Sort orders", func(t *testing.T) {
+		assert.Equal(t, reviews[3].ID, ts.list(t, "sort=newest").Data[0].ID)
+		assert.Equal(t, reviews[0].ID, ts.list(t, "sort=oldest").Data[0].ID)
+		assert.Equal(t, 4, ts.list(t, "sort=highest_rated").Data[0].Rating)
+		assert.Equal(t, 1, ts.list(t, "sort=lowest_rated").Data[0].Rating)
+	})
+
+	t.Run("Pages cover every review once", func(t *testing.T) {
+		for _, sort := range []string{"newest", "oldest", "highest_rated", "lowest_rated", "most_helpful"} {
+			seen := map[uuid.UUID]bool{}
+			query := "limit=3&sort=" + sort
+			for {
+				page := ts.list(t, query)
+				for _, review := range page.Data {
+					assert.False(t, seen[review.ID], sort)
+					seen[review.ID] = true
+				}
+				if !page.Pagination.HasMore() {
+					break
+				}
+				query = "limit=3&sort=" + sort + "&cursor=" + page.Pagination.NextCursor
+			}
+			assert.Len(t, seen, 4, sort)
+		}
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		assert.Len(t, ts.list(t, "verified=true").Data, 2)
+		assert.Len(t, ts.list(t, "rating=3").Data, 1)
+	})
+
+	t.Run("Cursor only continues its own sort order", func(t *testing.T) {
+		page := ts.list(t, "limit=1&sort=newest")
+		w := ts.do(http.MethodGet, "/api/v1/products/"+ts.productID.String()+"/reviews?sort=oldest&cursor="+page.Pagination.NextCursor, "", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Helpfulness votes", func(t *testing.T) {
+		target := reviews[0].ID
+		votesPath := "/api/v1/reviews/" + target.String() + "/votes"
+		helpful, unhelpful := true, false
+
+		w := ts.do(http.MethodPost, votesPath, tokens[0], models.VoteRequest{Helpful: &helpful})
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		require.Equal(t, http.StatusOK, ts.do(http.MethodPost, votesPath, tokens[1], models.VoteRequest{Helpful: &helpful}).Code)
+		require.Equal(t, http.StatusOK, ts.do(http.MethodPost, votesPath, tokens[2], models.VoteRequest{Helpful: &helpful}).Code)
+		w = ts.do(http.MethodPost, votesPath, tokens[3], models.VoteRequest{Helpful: &unhelpful})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var review models.PublicReview
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &review))
+		assert.Equal(t, 2, review.HelpfulCount)
+		assert.Equal(t, 1, review.UnhelpfulCount)
+
+		// Changing a vote replaces it
+		w = ts.do(http.MethodPost, votesPath, tokens[3], models.VoteRequest{Helpful: &helpful})
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &review))
+		assert.Equal(t, 3, review.HelpfulCount)
+		assert.Equal(t, 0, review.UnhelpfulCount)
+
+		w = ts.do(http.MethodDelete, votesPath, tokens[3], nil)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &review))
+		assert.Equal(t, 2, review.HelpfulCount)
+
+		assert.Equal(t, target, ts.list(t, "sort=most_helpful").Data[0].ID)
+	})
+}
@@ -0,0 +1,363 @@
+package seller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/internal/seller/handlers"
+	"github.com/kaanevranportfolio/Commercium/internal/seller/models"
+	"github.com/kaanevranportfolio/Commercium/internal/seller/repository"
+	"github.com/kaanevranportfolio/Commercium/internal/seller/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/tests/integration/testenv"
+)
+
+// TestSuite holds the test dependencies
+type TestSuite struct {
+	db         *database.DB
+	router     *gin.Engine
+	jwtService *auth.JWTService
+	userIDs    []uuid.UUID
+	orderIDs   []uuid.UUID
+	suffix     string
+}
+
+func setupTestSuite(t *testing.T) *TestSuite {
+	cfg := &config.Config{
+		Auth: config.AuthConfig{
+			JWT: config.JWTConfig{
+				SecretKey:         "test-secret-key-for-testing-only",
+				Issuer:            "commercium-test",
+				Expiration:        15 * time.Minute,
+				RefreshExpiration: 24 * time.Hour,
+			},
+		},
+		Services: config.ServicesConfig{
+			Seller: config.SellerServiceConfig{
+				DefaultCommissionBps: 1000,
+			},
+		},
+	}
+
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "info",
+		Format: "json",
+		Output: "stdout",
+	}, "seller-service-test")
+	require.NoError(t, err)
+
+	// Each test gets a database of its own (skipped if not available)
+	db := testenv.Database(t, log)
+
+	jwtService := auth.NewJWTService(&cfg.Auth.JWT)
+
+	sellerRepo := repository.NewSellerRepository(db, log)
+	sellerService := service.NewSellerService(sellerRepo, nil, cfg, log)
+	sellerHandler := handlers.NewSellerHandler(sellerService, jwtService, log)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apperrors.Middleware())
+	sellerHandler.SetupRoutes(router)
+
+	return &TestSuite{
+		db:         db,
+		router:     router,
+		jwtService: jwtService,
+		suffix:     uuid.New().String()[:8],
+	}
+}
+
+func (ts *TestSuite) cleanup() {
+	for _, userID := range ts.userIDs {
+		ts.db.Exec(`DELETE FROM sellers WHERE user_id = $1`, userID)
+	}
+	for _, orderID := range ts.orderIDs {
+		ts.db.Exec(`DELETE FROM orders WHERE id = $1`, orderID)
+	}
+	for _, userID := range ts.userIDs {
+		ts.db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	}
+}
+
+// seedUser creates a user and returns an access token for it
+func (ts *TestSuite) seedUser(t *testing.T, role string) (uuid.UUID, string) {
+	userID := uuid.New()
+	_, err := ts.db.Exec(`INSERT INTO users (id, username, email, password_hash, role) VALUES ($1, $2, $3, 'x', $4)`,
+		userID, "seller_"+userID.String()[:8], userID.String()[:8]+"@example.com", role)
+	require.NoError(t, err)
+	ts.userIDs = append(ts.userIDs, userID)
+
+	return userID, ts.token(t, userID, role)
+}
+
+// token issues an access token, e.g. after the user's role changed
+func (ts *TestSuite) token(t *testing.T, userID uuid.UUID, role string) string {
+	tokens, err := ts.jwtService.GenerateTokenPair(userID, userID.String()[:8]+"@example.com", "seller_"+userID.String()[:8], role, "")
+	require.NoError(t, err)
+	return tokens.AccessToken
+}
+
+// seedOrder places an order with the given status and items
+func (ts *TestSuite) seedOrder(t *testing.T, userID uuid.UUID, status string, items ...seedItem) uuid.UUID {
+	orderID := uuid.New()
+	_, err := ts.db.Exec(`
+		INSERT INTO orders (id, order_number, user_id, status, currency, shipping_address)
+		VALUES ($1, $2, $3, $4, 'USD', '{"city": "Springfield", "country": "US"}')`,
+		orderID, "ORD-"+orderID.String()[:8], userID, status)
+	require.NoError(t, err)
+	ts.orderIDs = append(ts.orderIDs, orderID)
+
+	for _, item := range items {
+		_, err := ts.db.Exec(`
+			INSERT INTO order_items (order_id, product_id, sku, name, quantity, refunded_quantity, unit_price, total_price)
+			VALUES ($1, $2, $3, $3, $4, $5, $6, $7)`,
+			orderID, item.productID, item.sku, item.quantity, item.refunded, item.unitPrice, item.unitPrice*int64(item.quantity))
+		require.NoError(t, err)
+	}
+
+	return orderID
+}
+
+type seedItem struct {
+	productID uuid.UUID
+	sku       string
+	quantity  int
+	refunded  int
+	unitPrice int64
+}
+
+func (ts *TestSuite) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	if token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSellerOnboardingIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	_, admin := ts.seedUser(t, "admin")
+	userID, customer := ts.seedUser(t, "customer")
+
+	var seller models.Seller
+
+	t.Run("Users apply to sell", func(t *testing.T) {
+		w := ts.do(http.MethodPost, "/api/v1/sellers", customer, models.ApplyRequest{
+			StoreName:    "Store " + ts.suffix,
+			ContactEmail: "Store@Example.com",
+			Country:      "us",
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &seller))
+		assert.Equal(t, models.SellerStatusPending, seller.Status)
+		assert.Equal(t, "US", seller.Country)
+		assert.Equal(t, "store@example.com", seller.ContactEmail)
+
+		w = ts.do(http.MethodPost, "/api/v1/sellers", customer, models.ApplyRequest{
+			StoreName:    "Other " + ts.suffix,
+			ContactEmail: "store@example.com",
+			Country:      "US",
+		})
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = ts.do(http.MethodGet, "/api/v1/sellers/me", customer, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Seller routes need the seller role", func(t *testing.T) {
+		w := ts.do(http.MethodGet, "/api/v1/seller/orders", customer, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = ts.do(http.MethodGet, "/api/v1/admin/sellers", customer, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Approval grants the seller role", func(t *testing.T) {
+		w := ts.do(http.MethodPost, "/api/v1/admin/sellers/"+seller.ID.String()+"/review", admin,
+			models.ReviewSellerRequest{Decision: "approve"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &seller))
+		assert.Equal(t, models.SellerStatusActive, seller.Status)
+		assert.NotNil(t, seller.ApprovedAt)
+
+		var role string
+		require.NoError(t, ts.db.Get(&role, `SELECT role FROM users WHERE id = $1`, userID))
+		assert.Equal(t, "seller", role)
+
+		// Approved sellers can't be rejected, only suspended
+		w = ts.do(http.MethodPost, "/api/v1/admin/sellers/"+seller.ID.String()+"/review", admin,
+			models.ReviewSellerRequest{Decision: "reject"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Suspended sellers can't register products", func(t *testing.T) {
+		sellerToken := ts.token(t, userID, "seller")
+
+		w := ts.do(http.MethodPost, "/api/v1/admin/sellers/"+seller.ID.String()+"/review", admin,
+			models.ReviewSellerRequest{Decision: "suspend", Reason: "policy violation"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = ts.do(http.MethodPost, "/api/v1/seller/products", sellerToken,
+			models.RegisterProductRequest{ProductID: uuid.New(), SKU: "SKU-1"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = ts.do(http.MethodPost, "/api/v1/admin/sellers/"+seller.ID.String()+"/review", admin,
+			models.ReviewSellerRequest{Decision: "approve"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+}
+
+func TestSellerOrdersAndStatementsIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	_, admin := ts.seedUser(t, "admin")
+	buyerID, _ := ts.seedUser(t, "customer")
+	userID, customer := ts.seedUser(t, "customer")
+
+	w := ts.do(http.MethodPost, "/api/v1/sellers", customer, models.ApplyRequest{
+		StoreName:    "Store " + ts.suffix,
+		ContactEmail: "store@example.com",
+		Country:      "US",
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var seller models.Seller
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &seller))
+
+	w = ts.do(http.MethodPost, "/api/v1/admin/sellers/"+seller.ID.String()+"/review", admin,
+		models.ReviewSellerRequest{Decision: "approve"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	sellerToken := ts.token(t, userID, "seller")
+
+	ownProduct := uuid.New()
+	otherProduct := uuid.New()
+
+	// Sales before the product was registered belong to no seller
+	ts.seedOrder(t, buyerID, "delivered", seedItem{ownProduct, "OWN-1", 1, 0, 1500})
+
+	w = ts.do(http.MethodPost, "/api/v1/seller/products", sellerToken,
+		models.RegisterProductRequest{ProductID: ownProduct, SKU: "OWN-1"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = ts.do(http.MethodPost, "/api/v1/seller/products", sellerToken,
+		models.RegisterProductRequest{ProductID: ownProduct, SKU: "OWN-1"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	delivered := ts.seedOrder(t, buyerID, "delivered",
+		seedItem{ownProduct, "OWN-1", 3, 1, 1500},
+		seedItem{otherProduct, "OTHER-1", 1, 0, 999})
+	pending := ts.seedOrder(t, buyerID, "pending", seedItem{ownProduct, "OWN-1", 1, 0, 1500})
+
+	t.Run("Sellers see their own items only", func(t *testing.T) {
+		w := ts.do(http.MethodGet, "/api/v1/seller/orders", sellerToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var page models.SellerOrderListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		require.Len(t, page.Data, 2)
+		assert.Equal(t, pending, page.Data[0].ID)
+		assert.Equal(t, delivered, page.Data[1].ID)
+		require.Len(t, page.Data[1].Items, 1)
+		assert.Equal(t, ownProduct, page.Data[1].Items[0].ProductID)
+		assert.Equal(t, int64(4500), page.Data[1].Subtotal)
+		assert.Empty(t, page.Data[1].ShippingAddress)
+
+		w = ts.do(http.MethodGet, "/api/v1/seller/orders?status=delivered&limit=1", sellerToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		require.Len(t, page.Data, 1)
+		assert.False(t, page.Pagination.HasMore())
+
+		w = ts.do(http.MethodGet, "/api/v1/seller/orders/"+delivered.String(), sellerToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var order models.SellerOrder
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+		assert.NotEmpty(t, order.ShippingAddress)
+	})
+
+	t.Run("Statements settle delivered sales with commission", func(t *testing.T) {
+		rate := 1250
+		w := ts.do(http.MethodPut, "/api/v1/admin/sellers/"+seller.ID.String()+"/commission", admin,
+			models.SetCommissionRequest{CommissionRateBps: &rate})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = ts.do(http.MethodPost, "/api/v1/admin/sellers/"+seller.ID.String()+"/statements", admin, nil)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var generated models.GenerateStatementsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &generated))
+		require.Equal(t, 1, generated.Created)
+		statement := generated.Statements[0]
+		// Two units remain after the refund
+		assert.Equal(t, int64(3000), statement.GrossAmount)
+		assert.Equal(t, int64(375), statement.CommissionAmount)
+		assert.Equal(t, int64(2625), statement.PayoutAmount)
+		assert.Equal(t, models.StatementStatusPending, statement.Status)
+
+		// Sales are settled once
+		w = ts.do(http.MethodPost, "/api/v1/admin/sellers/"+seller.ID.String()+"/statements", admin, nil)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &generated))
+		assert.Equal(t, 0, generated.Created)
+
+		w = ts.do(http.MethodGet, "/api/v1/seller/statements/"+statement.ID.String(), sellerToken, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var own models.Statement
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &own))
+		require.Len(t, own.Lines, 1)
+		assert.Equal(t, 2, own.Lines[0].Quantity)
+		assert.Equal(t, 1250, own.Lines[0].CommissionRateBps)
+
+		w = ts.do(http.MethodPost, "/api/v1/admin/seller-statements/"+statement.ID.String()+"/paid", admin,
+			models.MarkPaidRequest{PayoutReference: "TRF-" + ts.suffix})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var paid models.Statement
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &paid))
+		assert.Equal(t, models.StatementStatusPaid, paid.Status)
+
+		w = ts.do(http.MethodPost, "/api/v1/admin/seller-statements/"+statement.ID.String()+"/paid", admin,
+			models.MarkPaidRequest{PayoutReference: "TRF-" + ts.suffix})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Other sellers can't see statements", func(t *testing.T) {
+		otherID, otherCustomer := ts.seedUser(t, "customer")
+		w := ts.do(http.MethodPost, "/api/v1/sellers", otherCustomer, models.ApplyRequest{
+			StoreName:    "Other " + ts.suffix,
+			ContactEmail: "other@example.com",
+			Country:      "US",
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		w = ts.do(http.MethodGet, "/api/v1/seller/statements", ts.token(t, otherID, "seller"), nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp httpx.ListResponse[*models.Statement]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Empty(t, resp.Data)
+
+		w = ts.do(http.MethodGet, "/api/v1/seller/orders/"+delivered.String(), ts.token(t, otherID, "seller"), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,406 @@
+package shipping_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/carriers"
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/handlers"
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/models"
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/repository"
+	"github.com/kaanevranportfolio/Commercium/internal/shipping/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/tests/integration/testenv"
+)
+
+// failingCarrier is unavailable for every request
+type failingCarrier struct{}
+
+func (f *failingCarrier) Name() string { return "failing" }
+
+func (f *failingCarrier) Rates(ctx context.Context, req *carriers.RateRequest) ([]*carriers.Rate, error) {
+	return nil, &carriers.CarrierError{Carrier: "failing", Message: "service unavailable"}
+}
+
+func (f *failingCarrier) PurchaseLabel(ctx context.Context, req *carriers.LabelRequest) (*carriers.Label, error) {
+	return nil, &carriers.CarrierError{Carrier: "failing", Message: "service unavailable"}
+}
+
+// webhookCarrier buys labels with fixed tracking numbers and pushes tracking
+// updates as plain JSON, without signatures
+type webhookCarrier struct{}
+
+func (w *webhookCarrier) Name() string { return "pushing" }
+
+func (w *webhookCarrier) Rates(ctx context.Context, req *carriers.RateRequest) ([]*carriers.Rate, error) {
+	return nil, &carriers.CarrierError{Carrier: "pushing", Message: "quotes not available"}
+}
+
+func (w *webhookCarrier) PurchaseLabel(ctx context.Context, req *carriers.LabelRequest) (*carriers.Label, error) {
+	return &carriers.Label{TrackingNumber: "PUSH-" + req.IdempotencyKey, Amount: 800, Currency: "USD"}, nil
+}
+
+func (w *webhookCarrier) ParseTrackingWebhook(ctx context.Context, header http.Header, body []byte) (*carriers.TrackingUpdate, error) {
+	update := &carriers.TrackingUpdate{}
+	if err := json.Unmarshal(body, update); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	return update, nil
+}
+
+// TestSuite holds the test dependencies
+type TestSuite struct {
+	db     *database.DB
+	router *gin.Engine
+	userID uuid.UUID
+	token  string
+}
+
+func setupTestSuite(t *testing.T) *TestSuite {
+	cfg := &config.Config{
+		Auth: config.AuthConfig{
+			JWT: config.JWTConfig{
+				SecretKey:         "test-secret-key-for-testing-only",
+				Issuer:            "commercium-test",
+				Expiration:        15 * time.Minute,
+				RefreshExpiration: 24 * time.Hour,
+			},
+		},
+		Services: config.ServicesConfig{
+			Shipping: config.ShippingServiceConfig{
+				Origin: config.ShippingOriginConfig{
+					Name:         "Warehouse",
+					AddressLine1: "1 Warehouse Way",
+					City:         "Columbus",
+					PostalCode:   "43004",
+					Country:      "US",
+				},
+				QuoteTTL: time.Hour,
+			},
+		},
+	}
+
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "info",
+		Format: "json",
+		Output: "stdout",
+	}, "shipping-service-test")
+	require.NoError(t, err)
+
+	// Each test gets a database of its own (skipped if not available)
+	db := testenv.Database(t, log)
+
+	jwtService := auth.NewJWTService(&cfg.Auth.JWT)
+
+	flatRate, err := carriers.NewFlatRateCarrier(config.FlatRateConfig{
+		Currency:            "USD",
+		DomesticAmount:      500,
+		InternationalAmount: 2000,
+		PerKgAmount:         100,
+		DomesticDays:        3,
+		InternationalDays:   10,
+	})
+	require.NoError(t, err)
+
+	registry := carriers.NewRegistry()
+	registry.Register(flatRate)
+	registry.Register(&failingCarrier{})
+	registry.Register(&webhookCarrier{})
+
+	shippingRepo := repository.NewShippingRepository(db, log)
+	shippingService := service.NewShippingService(shippingRepo, registry, nil, cfg, log)
+	shippingHandler := handlers.NewShippingHandler(shippingService, jwtService, log)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apperrors.Middleware())
+	shippingHandler.SetupRoutes(router)
+
+	userID := uuid.New()
+	_, err = db.Exec(`INSERT INTO users (id, username, email, password_hash) VALUES ($1, $2, $3, 'x')`,
+		userID, "shipping_"+userID.String()[:8], userID.String()[:8]+"@example.com")
+	require.NoError(t, err)
+
+	tokens, err := jwtService.GenerateTokenPair(userID, "shipping@example.com", "shipping", "customer", "")
+	require.NoError(t, err)
+
+	return &TestSuite{
+		db:     db,
+		router: router,
+		userID: userID,
+		token:  tokens.AccessToken,
+	}
+}
+
+func (ts *TestSuite) cleanup() {
+	ts.db.Exec(`DELETE FROM shipments WHERE order_id IN (SELECT id FROM orders WHERE user_id = $1)`, ts.userID)
+	ts.db.Exec(`DELETE FROM shipping_quotes WHERE user_id = $1`, ts.userID)
+	ts.db.Exec(`DELETE FROM orders WHERE user_id = $1`, ts.userID)
+	ts.db.Exec(`DELETE FROM users WHERE id = $1`, ts.userID)
+}
+
+func (ts *TestSuite) seedOrder(t *testing.T, status string) uuid.UUID {
+	orderID := uuid.New()
+	_, err := ts.db.ExecContext(context.Background(), `
+		INSERT INTO orders (id, order_number, user_id, status, currency, total_amount, shipping_address)
+		VALUES ($1, $2, $3, $4, 'USD', 5000, $5)`,
+		orderID, "ORD-"+orderID.String()[:8], ts.userID, status, destination("US"))
+	require.NoError(t, err)
+	return orderID
+}
+
+func (ts *TestSuite) do(method, path string, body interface{}, headers map[string]string) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", ts.token))
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, req)
+	return w
+}
+
+func destination(country string) models.Address {
+	return models.Address{
+		FirstName:    "Jane",
+		LastName:     "Doe",
+		AddressLine1: "10 Main Street",
+		City:         "Springfield",
+		PostalCode:   "12345",
+		Country:      country,
+	}
+}
+
+func TestShippingRatesIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	quote := func(t *testing.T, country string, weightGrams int) *models.Quote {
+		w := ts.do(http.MethodPost, "/api/v1/shipping/rates", models.RateRequest{
+			Destination: destination(country),
+			Parcel:      models.Parcel{WeightGrams: weightGrams},
+		}, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp models.RateResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		// The failing carriers are left out
+		require.Len(t, resp.Quotes, 1)
+		return resp.Quotes[0]
+	}
+
+	t.Run("Domestic and international rates", func(t *testing.T) {
+		domestic := quote(t, "US", 1500)
+		assert.Equal(t, "flat_rate", domestic.Carrier)
+		assert.Equal(t, int64(700), domestic.Amount)
+		require.NotNil(t, domestic.EstimatedDays)
+		assert.Equal(t, 3, *domestic.EstimatedDays)
+
+		international := quote(t, "DE", 500)
+		assert.Equal(t, int64(2100), international.Amount)
+	})
+
+	t.Run("Quotes can be verified", func(t *testing.T) {
+		q := quote(t, "US", 200)
+
+		w := ts.do(http.MethodGet, "/internal/v1/shipping/quotes/"+q.ID.String(), nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var got models.Quote
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		assert.Equal(t, q.Amount, got.Amount)
+	})
+
+	t.Run("Invalid parcel", func(t *testing.T) {
+		w := ts.do(http.MethodPost, "/api/v1/shipping/rates", models.RateRequest{Destination: destination("US")}, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestShippingLabelIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	purchase := func(orderID uuid.UUID, carrier, key string) *httptest.ResponseRecorder {
+		return ts.do(http.MethodPost, "/internal/v1/shipments", models.PurchaseLabelRequest{
+			OrderID: orderID,
+			Carrier: carrier,
+			Service: "standard",
+			Parcel:  models.Parcel{WeightGrams: 800},
+		}, map[string]string{"Idempotency-Key": key})
+	}
+
+	t.Run("Unpaid orders can't be shipped", func(t *testing.T) {
+		w := purchase(ts.seedOrder(t, "pending"), "flat_rate", uuid.NewString())
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Label purchase is idempotent", func(t *testing.T) {
+		orderID := ts.seedOrder(t, "confirmed")
+		key := uuid.NewString()
+
+		w := purchase(orderID, "flat_rate", key)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var first models.Shipment
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+		assert.NotEmpty(t, first.TrackingNumber)
+		assert.Equal(t, int64(600), first.RateAmount)
+
+		w = purchase(orderID, "flat_rate", key)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var second models.Shipment
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &second))
+		assert.Equal(t, first.ID, second.ID)
+
+		w = ts.do(http.MethodGet, "/internal/v1/orders/"+orderID.String()+"/shipments", nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp httpx.ListResponse[*models.Shipment]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Data, 1)
+	})
+
+	t.Run("Carrier errors", func(t *testing.T) {
+		orderID := ts.seedOrder(t, "confirmed")
+
+		w := purchase(orderID, "unknown", uuid.NewString())
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = purchase(orderID, "failing", uuid.NewString())
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+	})
+}
+
+func TestShippingTrackingIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	purchase := func(t *testing.T, orderID uuid.UUID, carrier string) *models.Shipment {
+		w := ts.do(http.MethodPost, "/internal/v1/shipments", models.PurchaseLabelRequest{
+			OrderID: orderID,
+			Carrier: carrier,
+			Service: "standard",
+			Parcel:  models.Parcel{WeightGrams: 500},
+		}, map[string]string{"Idempotency-Key": uuid.NewString()})
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var shipment models.Shipment
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &shipment))
+		return &shipment
+	}
+
+	tracking := func(t *testing.T, orderID uuid.UUID) *models.TrackingResponse {
+		w := ts.do(http.MethodGet, "/api/v1/orders/"+orderID.String()+"/tracking", nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp models.TrackingResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return &resp
+	}
+
+	t.Run("Order is delivered once all shipments are", func(t *testing.T) {
+		orderID := ts.seedOrder(t, "shipped")
+		first := purchase(t, orderID, "flat_rate")
+		second := purchase(t, orderID, "flat_rate")
+
+		shippedAt := time.Now().Add(-48 * time.Hour).UTC().Truncate(time.Second)
+		record := func(shipmentID uuid.UUID, events ...models.TrackingEventRequest) {
+			w := ts.do(http.MethodPost, "/internal/v1/shipments/"+shipmentID.String()+"/events",
+				models.RecordTrackingEventsRequest{Events: events}, nil)
+			require.Equal(t, http.StatusOK, w.Code)
+		}
+
+		record(first.ID,
+			models.TrackingEventRequest{Status: models.ShipmentStatusInTransit, Description: "Picked up", OccurredAt: shippedAt},
+			models.TrackingEventRequest{Status: models.ShipmentStatusDelivered, Description: "Delivered", OccurredAt: shippedAt.Add(24 * time.Hour)},
+		)
+
+		resp := tracking(t, orderID)
+		assert.Equal(t, "shipped", resp.OrderStatus)
+		require.Len(t, resp.Shipments, 2)
+		assert.Equal(t, models.ShipmentStatusDelivered, resp.Shipments[0].Status)
+		require.NotNil(t, resp.Shipments[0].DeliveredAt)
+		require.Len(t, resp.Shipments[0].Events, 2)
+		assert.Equal(t, "Picked up", resp.Shipments[0].Events[0].Description)
+
+		// Stale events don't move a delivered shipment back, and duplicates are ignored
+		record(first.ID, models.TrackingEventRequest{Status: models.ShipmentStatusInTransit, Description: "Picked up", OccurredAt: shippedAt})
+		resp = tracking(t, orderID)
+		assert.Equal(t, models.ShipmentStatusDelivered, resp.Shipments[0].Status)
+		assert.Len(t, resp.Shipments[0].Events, 2)
+
+		record(second.ID, models.TrackingEventRequest{Status: models.ShipmentStatusDelivered, OccurredAt: shippedAt.Add(30 * time.Hour)})
+
+		resp = tracking(t, orderID)
+		assert.Equal(t, "delivered", resp.OrderStatus)
+	})
+
+	t.Run("Carrier webhooks", func(t *testing.T) {
+		orderID := ts.seedOrder(t, "confirmed")
+		shipment := purchase(t, orderID, "pushing")
+
+		update := carriers.TrackingUpdate{
+			TrackingNumber: shipment.TrackingNumber,
+			Status:         models.ShipmentStatusOutForDelivery,
+			Events: []*carriers.TrackingEvent{
+				{Status: models.ShipmentStatusOutForDelivery, Description: "Out for delivery", Location: "Springfield", OccurredAt: time.Now().UTC()},
+			},
+		}
+		w := ts.do(http.MethodPost, "/webhooks/carriers/pushing", update, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		resp := tracking(t, orderID)
+		require.Len(t, resp.Shipments, 1)
+		assert.Equal(t, models.ShipmentStatusOutForDelivery, resp.Shipments[0].Status)
+		require.Len(t, resp.Shipments[0].Events, 1)
+		require.NotNil(t, resp.Shipments[0].Events[0].Location)
+		assert.Equal(t, "Springfield", *resp.Shipments[0].Events[0].Location)
+
+		// Updates for unknown shipments are acknowledged
+		update.TrackingNumber = "UNKNOWN"
+		w = ts.do(http.MethodPost, "/webhooks/carriers/pushing", update, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = ts.do(http.MethodPost, "/webhooks/carriers/flat_rate", update, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Other customers' orders", func(t *testing.T) {
+		orderID := uuid.New()
+		otherUser := uuid.New()
+		_, err := ts.db.Exec(`INSERT INTO users (id, username, email, password_hash) VALUES ($1, $2, $3, 'x')`,
+			otherUser, "shipping_"+otherUser.String()[:8], otherUser.String()[:8]+"@example.com")
+		require.NoError(t, err)
+		defer ts.db.Exec(`DELETE FROM users WHERE id = $1`, otherUser)
+
+		_, err = ts.db.Exec(`
+			INSERT INTO orders (id, order_number, user_id, status, currency, total_amount)
+			VALUES ($1, $2, $3, 'confirmed', 'USD', 5000)`,
+			orderID, "ORD-"+orderID.String()[:8], otherUser)
+		require.NoError(t, err)
+		defer ts.db.Exec(`DELETE FROM orders WHERE id = $1`, orderID)
+
+		w := ts.do(http.MethodGet, "/api/v1/orders/"+orderID.String()+"/tracking", nil, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,266 @@
+package stockalert_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/internal/stockalert/clients"
+	"github.com/kaanevranportfolio/Commercium/internal/stockalert/handlers"
+	"github.com/kaanevranportfolio/Commercium/internal/stockalert/models"
+	"github.com/kaanevranportfolio/Commercium/internal/stockalert/repository"
+	"github.com/kaanevranportfolio/Commercium/internal/stockalert/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/pkg/workerpool"
+	"github.com/kaanevranportfolio/Commercium/tests/integration/testenv"
+)
+
+// fakeNotifications records the templates emailed to each address, and
+// fails to email addresses listed in failing
+type fakeNotifications struct {
+	mu        sync.Mutex
+	templates map[string][]string
+	failing   map[string]bool
+}
+
+func (f *fakeNotifications) SendEmail(ctx context.Context, req *clients.SendEmailRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failing[req.To] {
+		return fmt.Errorf("notification service returned status 503")
+	}
+	f.templates[req.To] = append(f.templates[req.To], req.Template)
+	return nil
+}
+
+func (f *fakeNotifications) sent(to string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.templates[to]...)
+}
+
+// TestSuite holds the test dependencies
+type TestSuite struct {
+	db                *database.DB
+	router            *gin.Engine
+	jwtService        *auth.JWTService
+	stockAlertService service.StockAlertService
+	notifications     *fakeNotifications
+	userIDs           []uuid.UUID
+}
+
+func setupTestSuite(t *testing.T) *TestSuite {
+	cfg := &config.Config{
+		Auth: config.AuthConfig{
+			JWT: config.JWTConfig{
+				SecretKey:         "test-secret-key-for-testing-only",
+				Issuer:            "commercium-test",
+				Expiration:        15 * time.Minute,
+				RefreshExpiration: 24 * time.Hour,
+			},
+		},
+		Services: config.ServicesConfig{
+			StockAlert: config.StockAlertServiceConfig{
+				AlertTTL:         90 * 24 * time.Hour,
+				MaxAlertsPerUser: 2,
+				ExpiryInterval:   time.Hour,
+				BatchSize:        1,
+			},
+		},
+	}
+
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "info",
+		Format: "json",
+		Output: "stdout",
+	}, "stock-alert-service-test")
+	require.NoError(t, err)
+
+	// Each test gets a database of its own (skipped if not available)
+	db := testenv.Database(t, log)
+
+	jwtService := auth.NewJWTService(&cfg.Auth.JWT)
+	notifications := &fakeNotifications{templates: map[string][]string{}, failing: map[string]bool{}}
+
+	stockAlertRepo := repository.NewStockAlertRepository(db, log)
+	emails := workerpool.New("back_in_stock_emails", 4, 10*time.Second, nil, log)
+	t.Cleanup(func() { emails.Close(context.Background()) })
+	stockAlertService := service.NewStockAlertService(stockAlertRepo, notifications, emails, cfg, log)
+	stockAlertHandler := handlers.NewStockAlertHandler(stockAlertService, jwtService, log)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apperrors.Middleware())
+	stockAlertHandler.SetupRoutes(router)
+
+	return &TestSuite{
+		db:                db,
+		router:            router,
+		jwtService:        jwtService,
+		stockAlertService: stockAlertService,
+		notifications:     notifications,
+	}
+}
+
+func (ts *TestSuite) cleanup() {
+	for _, userID := range ts.userIDs {
+		ts.db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	}
+}
+
+// seedUser creates a customer and returns their email address and an access token
+func (ts *TestSuite) seedUser(t *testing.T) (string, string) {
+	userID := uuid.New()
+	email := userID.String()[:8] + "@example.com"
+	_, err := ts.db.Exec(`INSERT INTO users (id, username, email, password_hash, role) VALUES ($1, $2, $3, 'x', 'customer')`,
+		userID, "alert_"+userID.String()[:8], email)
+	require.NoError(t, err)
+	ts.userIDs = append(ts.userIDs, userID)
+
+	tokens, err := ts.jwtService.GenerateTokenPair(userID, email, "alert_"+userID.String()[:8], "customer", "")
+	require.NoError(t, err)
+	return email, tokens.AccessToken
+}
+
+func (ts *TestSuite) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, req)
+	return w
+}
+
+func (ts *TestSuite) createAlert(t *testing.T, token string, productID uuid.UUID, sku string) *models.StockAlert {
+	w := ts.do(http.MethodPost, "/api/v1/stock-alerts", token, models.CreateAlertRequest{ProductID: productID, SKU: sku})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var alert models.StockAlert
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &alert))
+	return &alert
+}
+
+func (ts *TestSuite) alertStatus(t *testing.T, id uuid.UUID) models.AlertStatus {
+	var status models.AlertStatus
+	require.NoError(t, ts.db.Get(&status, `SELECT status FROM stock_alerts WHERE id = $1`, id))
+	return status
+}
+
+func TestStockAlertsIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	ctx := context.Background()
+	productID := uuid.New()
+	sku := "SKU-" + uuid.New().String()[:8]
+
+	firstEmail, first := ts.seedUser(t)
+	secondEmail, second := ts.seedUser(t)
+	failingEmail, failing := ts.seedUser(t)
+	ts.notifications.failing[failingEmail] = true
+
+	var firstAlert, secondAlert, failingAlert *models.StockAlert
+
+	t.Run("Customers subscribe to SKUs", func(t *testing.T) {
+		firstAlert = ts.createAlert(t, first, productID, sku)
+		assert.Equal(t, models.AlertStatusActive, firstAlert.Status)
+		assert.WithinDuration(t, time.Now().Add(90*24*time.Hour), firstAlert.ExpiresAt, time.Minute)
+
+		// One active alert per SKU
+		w := ts.do(http.MethodPost, "/api/v1/stock-alerts", first, models.CreateAlertRequest{ProductID: productID, SKU: sku})
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		secondAlert = ts.createAlert(t, second, productID, sku)
+		failingAlert = ts.createAlert(t, failing, productID, sku)
+	})
+
+	t.Run("Active alerts per customer are limited", func(t *testing.T) {
+		ts.createAlert(t, first, uuid.New(), "SKU-"+uuid.New().String()[:8])
+
+		w := ts.do(http.MethodPost, "/api/v1/stock-alerts", first, models.CreateAlertRequest{ProductID: uuid.New(), SKU: "OTHER"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Customers cancel only their own alerts", func(t *testing.T) {
+		w := ts.do(http.MethodDelete, "/api/v1/stock-alerts/"+secondAlert.ID.String(), first, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = ts.do(http.MethodDelete, "/api/v1/stock-alerts/"+secondAlert.ID.String(), second, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, models.AlertStatusCanceled, ts.alertStatus(t, secondAlert.ID))
+
+		w = ts.do(http.MethodDelete, "/api/v1/stock-alerts/"+secondAlert.ID.String(), second, nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Stock changes that aren't restocks are ignored", func(t *testing.T) {
+		err := ts.stockAlertService.HandleInventoryEvent(ctx, &models.InventoryEvent{
+			Type: models.EventStockChanged, ProductID: productID, SKU: sku, Available: 5, PreviousAvailable: 3,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, ts.notifications.sent(firstEmail))
+	})
+
+	t.Run("Restocks notify waiting customers once", func(t *testing.T) {
+		event := &models.InventoryEvent{
+			Type: models.EventStockChanged, ProductID: productID, SKU: sku, Available: 10, PreviousAvailable: 0,
+		}
+		require.NoError(t, ts.stockAlertService.HandleInventoryEvent(ctx, event))
+
+		assert.Equal(t, []string{"back_in_stock"}, ts.notifications.sent(firstEmail))
+		assert.Empty(t, ts.notifications.sent(secondEmail))
+		assert.Equal(t, models.AlertStatusNotified, ts.alertStatus(t, firstAlert.ID))
+
+		// Alerts whose email failed wait for the next restock
+		assert.Equal(t, models.AlertStatusActive, ts.alertStatus(t, failingAlert.ID))
+
+		// Redelivered events don't notify again
+		require.NoError(t, ts.stockAlertService.HandleInventoryEvent(ctx, event))
+		assert.Len(t, ts.notifications.sent(firstEmail), 1)
+
+		var alerts httpx.ListResponse[*models.StockAlert]
+		w := ts.do(http.MethodGet, "/api/v1/stock-alerts?status=notified", first, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &alerts))
+		require.Len(t, alerts.Data, 1)
+		assert.NotNil(t, alerts.Data[0].NotifiedAt)
+	})
+
+	t.Run("Stale alerts expire", func(t *testing.T) {
+		_, err := ts.db.Exec(`UPDATE stock_alerts SET expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1`, failingAlert.ID)
+		require.NoError(t, err)
+
+		for {
+			expired, err := ts.stockAlertService.ExpireAlerts(ctx)
+			require.NoError(t, err)
+			if expired == 0 {
+				break
+			}
+		}
+		assert.Equal(t, models.AlertStatusExpired, ts.alertStatus(t, failingAlert.ID))
+
+		// Expired alerts don't fire
+		ts.notifications.failing[failingEmail] = false
+		require.NoError(t, ts.stockAlertService.HandleInventoryEvent(ctx, &models.InventoryEvent{
+			Type: models.EventStockChanged, ProductID: productID, SKU: sku, Available: 1, PreviousAvailable: 0,
+		}))
+		assert.Empty(t, ts.notifications.sent(failingEmail))
+	})
+}
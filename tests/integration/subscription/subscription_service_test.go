@@ -0,0 +1,527 @@
+package subscription_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kaanevranportfolio/Commercium/internal/subscription/clients"
+	"github.com/kaanevranportfolio/Commercium/internal/subscription/handlers"
+	"github.com/kaanevranportfolio/Commercium/internal/subscription/models"
+	"github.com/kaanevranportfolio/Commercium/internal/subscription/repository"
+	"github.com/kaanevranportfolio/Commercium/internal/subscription/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
+	"github.com/kaanevranportfolio/Commercium/pkg/auth"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/httpx"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	"github.com/kaanevranportfolio/Commercium/tests/integration/testenv"
+)
+
+// fakeOrders records the renewal orders placed
+type fakeOrders struct {
+	mu     sync.Mutex
+	orders map[uuid.UUID]*clients.CreateOrderRequest
+}
+
+func (f *fakeOrders) CreateOrder(ctx context.Context, req *clients.CreateOrderRequest) (*clients.Order, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.orders[req.ID] = req
+
+	var total int64
+	for _, item := range req.Items {
+		total += item.UnitPrice * int64(item.Quantity)
+	}
+	return &clients.Order{ID: req.ID, Currency: req.Currency, TotalAmount: total - req.DiscountAmount}, nil
+}
+
+func (f *fakeOrders) get(id uuid.UUID) *clients.CreateOrderRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.orders[id]
+}
+
+// fakePayments declines charges to payment methods listed in declined
+type fakePayments struct {
+	mu       sync.Mutex
+	declined map[string]bool
+	keys     []string
+}
+
+func (f *fakePayments) Charge(ctx context.Context, req *clients.ChargeRequest) (*clients.Payment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys = append(f.keys, req.IdempotencyKey)
+	if f.declined[req.PaymentMethod] {
+		return nil, &clients.DeclinedError{StatusCode: http.StatusPaymentRequired, Message: "card declined"}
+	}
+	return &clients.Payment{ID: uuid.New(), Status: "captured"}, nil
+}
+
+// fakeNotifications records the templates emailed to each address
+type fakeNotifications struct {
+	mu        sync.Mutex
+	templates map[string][]string
+}
+
+func (f *fakeNotifications) SendEmail(ctx context.Context, req *clients.SendEmailRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.templates[req.To] = append(f.templates[req.To], req.Template)
+	return nil
+}
+
+func (f *fakeNotifications) sent(to string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.templates[to]...)
+}
+
+// TestSuite holds the test dependencies
+type TestSuite struct {
+	db                  *database.DB
+	router              *gin.Engine
+	jwtService          *auth.JWTService
+	subscriptionService service.SubscriptionService
+	orders              *fakeOrders
+	payments            *fakePayments
+	notifications       *fakeNotifications
+	userIDs             []uuid.UUID
+	planIDs             []uuid.UUID
+	suffix              string
+}
+
+func setupTestSuite(t *testing.T) *TestSuite {
+	cfg := &config.Config{
+		Auth: config.AuthConfig{
+			JWT: config.JWTConfig{
+				SecretKey:         "test-secret-key-for-testing-only",
+				Issuer:            "commercium-test",
+				Expiration:        15 * time.Minute,
+				RefreshExpiration: 24 * time.Hour,
+			},
+		},
+		Services: config.ServicesConfig{
+			Subscription: config.SubscriptionServiceConfig{
+				BatchSize: 100,
+				Dunning: config.DunningConfig{
+					RetrySchedule: []time.Duration{24 * time.Hour, 72 * time.Hour, 120 * time.Hour},
+				},
+			},
+		},
+	}
+
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "info",
+		Format: "json",
+		Output: "stdout",
+	}, "subscription-service-test")
+	require.NoError(t, err)
+
+	// Each test gets a database of its own (skipped if not available)
+	db := testenv.Database(t, log)
+
+	jwtService := auth.NewJWTService(&cfg.Auth.JWT)
+
+	orders := &fakeOrders{orders: make(map[uuid.UUID]*clients.CreateOrderRequest)}
+	payments := &fakePayments{declined: make(map[string]bool)}
+	notifications := &fakeNotifications{templates: make(map[string][]string)}
+
+	subscriptionRepo := repository.NewSubscriptionRepository(db, log)
+	subscriptionService := service.NewSubscriptionService(subscriptionRepo, orders, payments, notifications, nil, cfg, log)
+	subscriptionHandler := handlers.NewSubscriptionHandler(subscriptionService, jwtService, log)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(apperrors.Middleware())
+	subscriptionHandler.SetupRoutes(router)
+
+	return &TestSuite{
+		db:                  db,
+		router:              router,
+		jwtService:          jwtService,
+		subscriptionService: subscriptionService,
+		orders:              orders,
+		payments:            payments,
+		notifications:       notifications,
+		suffix:              uuid.New().String()[:8],
+	}
+}
+
+func (ts *TestSuite) cleanup() {
+	for _, userID := range ts.userIDs {
+		ts.db.Exec(`DELETE FROM subscriptions WHERE user_id = $1`, userID)
+		ts.db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	}
+	for _, planID := range ts.planIDs {
+		ts.db.Exec(`DELETE FROM subscription_plans WHERE id = $1`, planID)
+	}
+}
+
+// seedUser creates a user and returns its email and an access token for it
+func (ts *TestSuite) seedUser(t *testing.T, role string) (string, string) {
+	userID := uuid.New()
+	email := userID.String()[:8] + "@example.com"
+	_, err := ts.db.Exec(`INSERT INTO users (id, username, email, password_hash, role) VALUES ($1, $2, $3, 'x', $4)`,
+		userID, "subscription_"+userID.String()[:8], email, role)
+	require.NoError(t, err)
+	ts.userIDs = append(ts.userIDs, userID)
+
+	tokens, err := ts.jwtService.GenerateTokenPair(userID, email, "subscription_"+userID.String()[:8], role, "")
+	require.NoError(t, err)
+	return email, tokens.AccessToken
+}
+
+func (ts *TestSuite) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	if token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, req)
+	return w
+}
+
+func (ts *TestSuite) createPlan(t *testing.T, token, code string, amount int64, trialDays int) *models.Plan {
+	w := ts.do(http.MethodPost, "/api/v1/admin/subscription-plans", token, models.CreatePlanRequest{
+		Code:         code + "-" + ts.suffix,
+		Name:         code,
+		ProductID:    uuid.New(),
+		SKU:          "SUB-" + code,
+		Currency:     "xts",
+		Amount:       amount,
+		IntervalUnit: models.IntervalMonth,
+		TrialDays:    trialDays,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var plan models.Plan
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &plan))
+	ts.planIDs = append(ts.planIDs, plan.ID)
+	return &plan
+}
+
+func (ts *TestSuite) subscribe(token string, planID uuid.UUID, paymentMethod string) *httptest.ResponseRecorder {
+	return ts.do(http.MethodPost, "/api/v1/subscriptions", token, models.SubscribeRequest{
+		PlanID:        planID,
+		PaymentMethod: paymentMethod,
+		ShippingAddress: models.Address{
+			FirstName:    "Test",
+			LastName:     "User",
+			AddressLine1: "1 Main St",
+			City:         "Springfield",
+			PostalCode:   "12345",
+			Country:      "US",
+		},
+	})
+}
+
+func (ts *TestSuite) get(t *testing.T, token string, id uuid.UUID) *models.Subscription {
+	w := ts.do(http.MethodGet, "/api/v1/subscriptions/"+id.String(), token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var sub models.Subscription
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sub))
+	return &sub
+}
+
+func (ts *TestSuite) renewals(t *testing.T, token string, id uuid.UUID) []*models.Renewal {
+	w := ts.do(http.MethodGet, "/api/v1/subscriptions/"+id.String()+"/renewals", token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp httpx.ListResponse[*models.Renewal]
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp.Data
+}
+
+// makeDue moves a subscription's renewal or retry into the past
+func (ts *TestSuite) makeDue(t *testing.T, id uuid.UUID) {
+	_, err := ts.db.Exec(`
+		UPDATE subscriptions
+		SET current_period_start = current_period_start - INTERVAL '40 days',
+		    current_period_end = NOW() - INTERVAL '1 minute',
+		    next_attempt_at = CASE WHEN next_attempt_at IS NULL THEN NULL ELSE NOW() - INTERVAL '1 minute' END,
+		    claimed_until = NULL
+		WHERE id = $1 AND status <> 'past_due'`, id)
+	require.NoError(t, err)
+	_, err = ts.db.Exec(`UPDATE subscriptions SET next_attempt_at = NOW() - INTERVAL '1 minute', claimed_until = NULL
+		WHERE id = $1 AND status = 'past_due'`, id)
+	require.NoError(t, err)
+}
+
+func (ts *TestSuite) processRenewals(t *testing.T) {
+	_, err := ts.subscriptionService.ProcessRenewals(context.Background())
+	require.NoError(t, err)
+}
+
+func TestSubscriptionLifecycleIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	_, admin := ts.seedUser(t, "admin")
+	_, customer := ts.seedUser(t, "customer")
+
+	basic := ts.createPlan(t, admin, "basic", 1000, 0)
+	premium := ts.createPlan(t, admin, "premium", 3000, 0)
+	trial := ts.createPlan(t, admin, "trial", 1000, 14)
+
+	t.Run("Only admins manage plans", func(t *testing.T) {
+		w := ts.do(http.MethodPost, "/api/v1/admin/subscription-plans", customer, models.CreatePlanRequest{})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = ts.do(http.MethodGet, "/api/v1/subscription-plans", "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Subscribing charges the first period", func(t *testing.T) {
+		w := ts.subscribe(customer, basic.ID, "pm_ok")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var sub models.Subscription
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sub))
+		assert.Equal(t, models.SubscriptionStatusActive, sub.Status)
+		assert.Equal(t, sub.CurrentPeriodStart.AddDate(0, 1, 0), sub.CurrentPeriodEnd)
+
+		renewals := ts.renewals(t, customer, sub.ID)
+		require.Len(t, renewals, 1)
+		assert.Equal(t, models.RenewalStatusPaid, renewals[0].Status)
+		order := ts.orders.get(renewals[0].OrderID)
+		require.NotNil(t, order)
+		assert.Equal(t, int64(1000), order.Items[0].UnitPrice)
+	})
+
+	t.Run("Declined first payment cancels the subscription", func(t *testing.T) {
+		ts.payments.declined["pm_declined"] = true
+		w := ts.subscribe(customer, basic.ID, "pm_declined")
+		assert.Equal(t, http.StatusPaymentRequired, w.Code, w.Body.String())
+	})
+
+	t.Run("Trials are not charged", func(t *testing.T) {
+		w := ts.subscribe(customer, trial.ID, "pm_ok")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var sub models.Subscription
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sub))
+		assert.Equal(t, models.SubscriptionStatusTrialing, sub.Status)
+		assert.Empty(t, ts.renewals(t, customer, sub.ID))
+
+		// Plans can be changed during the trial without proration
+		w = ts.do(http.MethodPut, "/api/v1/subscriptions/"+sub.ID.String()+"/plan", customer, models.ChangePlanRequest{PlanID: basic.ID})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, int64(0), ts.get(t, customer, sub.ID).ProrationBalance)
+	})
+
+	t.Run("Upgrades are prorated into the next renewal", func(t *testing.T) {
+		w := ts.subscribe(customer, basic.ID, "pm_ok")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var sub models.Subscription
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sub))
+
+		// Halfway through the period
+		period := sub.CurrentPeriodEnd.Sub(sub.CurrentPeriodStart)
+		_, err := ts.db.Exec(`UPDATE subscriptions SET current_period_start = $2, current_period_end = $3 WHERE id = $1`,
+			sub.ID, time.Now().Add(-period/2), time.Now().Add(period/2))
+		require.NoError(t, err)
+
+		w = ts.do(http.MethodPut, "/api/v1/subscriptions/"+sub.ID.String()+"/plan", customer, models.ChangePlanRequest{PlanID: premium.ID})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		balance := ts.get(t, customer, sub.ID).ProrationBalance
+		assert.InDelta(t, 1000, balance, 5)
+
+		ts.makeDue(t, sub.ID)
+		ts.processRenewals(t)
+
+		renewed := ts.get(t, customer, sub.ID)
+		assert.Equal(t, models.SubscriptionStatusActive, renewed.Status)
+		assert.True(t, renewed.CurrentPeriodEnd.After(time.Now()))
+		assert.Equal(t, int64(0), renewed.ProrationBalance)
+
+		renewals := ts.renewals(t, customer, sub.ID)
+		require.Len(t, renewals, 2)
+		assert.Equal(t, int64(3000), renewals[0].Amount)
+		assert.Equal(t, balance, renewals[0].ProrationAmount)
+		order := ts.orders.get(renewals[0].OrderID)
+		require.NotNil(t, order)
+		require.Len(t, order.Items, 2)
+		assert.Equal(t, balance, order.Items[1].UnitPrice)
+	})
+
+	t.Run("Plans must share currency and interval", func(t *testing.T) {
+		w := ts.subscribe(customer, basic.ID, "pm_ok")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var sub models.Subscription
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sub))
+
+		w = ts.do(http.MethodPost, "/api/v1/admin/subscription-plans", admin, models.CreatePlanRequest{
+			Code: "yearly-" + ts.suffix, Name: "yearly", ProductID: uuid.New(), SKU: "SUB-yearly",
+			Currency: "XTS", Amount: 10000, IntervalUnit: models.IntervalYear,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var yearly models.Plan
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &yearly))
+		ts.planIDs = append(ts.planIDs, yearly.ID)
+
+		w = ts.do(http.MethodPut, "/api/v1/subscriptions/"+sub.ID.String()+"/plan", customer, models.ChangePlanRequest{PlanID: yearly.ID})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Pausing credits the unused period", func(t *testing.T) {
+		w := ts.subscribe(customer, basic.ID, "pm_ok")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var sub models.Subscription
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sub))
+
+		w = ts.do(http.MethodPost, "/api/v1/subscriptions/"+sub.ID.String()+"/pause", customer, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		paused := ts.get(t, customer, sub.ID)
+		assert.Equal(t, models.SubscriptionStatusPaused, paused.Status)
+		assert.InDelta(t, -1000, paused.ProrationBalance, 5)
+
+		// Paused subscriptions aren't renewed
+		ts.makeDue(t, sub.ID)
+		ts.processRenewals(t)
+		assert.Equal(t, models.SubscriptionStatusPaused, ts.get(t, customer, sub.ID).Status)
+
+		w = ts.do(http.MethodPost, "/api/v1/subscriptions/"+sub.ID.String()+"/resume", customer, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		ts.processRenewals(t)
+
+		resumed := ts.get(t, customer, sub.ID)
+		assert.Equal(t, models.SubscriptionStatusActive, resumed.Status)
+		renewals := ts.renewals(t, customer, sub.ID)
+		require.Len(t, renewals, 2)
+		// The credit covers most of the first period after resuming
+		order := ts.orders.get(renewals[0].OrderID)
+		require.NotNil(t, order)
+		assert.Equal(t, -renewals[0].ProrationAmount, order.DiscountAmount)
+		assert.InDelta(t, 1000, order.DiscountAmount, 5)
+	})
+
+	t.Run("Cancel at period end", func(t *testing.T) {
+		w := ts.subscribe(customer, basic.ID, "pm_ok")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var sub models.Subscription
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sub))
+
+		w = ts.do(http.MethodPost, "/api/v1/subscriptions/"+sub.ID.String()+"/cancel", customer, models.CancelRequest{AtPeriodEnd: true})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, models.SubscriptionStatusActive, ts.get(t, customer, sub.ID).Status)
+
+		ts.makeDue(t, sub.ID)
+		ts.processRenewals(t)
+		assert.Equal(t, models.SubscriptionStatusCanceled, ts.get(t, customer, sub.ID).Status)
+		assert.Len(t, ts.renewals(t, customer, sub.ID), 1)
+
+		w = ts.do(http.MethodPost, "/api/v1/subscriptions/"+sub.ID.String()+"/cancel", customer, nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Other customers can't see subscriptions", func(t *testing.T) {
+		_, other := ts.seedUser(t, "customer")
+		w := ts.subscribe(customer, basic.ID, "pm_ok")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var sub models.Subscription
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sub))
+
+		w = ts.do(http.MethodGet, "/api/v1/subscriptions/"+sub.ID.String(), other, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestDunningIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	_, admin := ts.seedUser(t, "admin")
+	email, customer := ts.seedUser(t, "customer")
+
+	plan := ts.createPlan(t, admin, "dunning", 1500, 0)
+
+	w := ts.subscribe(customer, plan.ID, "pm_expiring")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var sub models.Subscription
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sub))
+
+	ts.payments.declined["pm_expiring"] = true
+
+	t.Run("Failed renewals are retried on schedule", func(t *testing.T) {
+		ts.makeDue(t, sub.ID)
+		ts.processRenewals(t)
+
+		pastDue := ts.get(t, customer, sub.ID)
+		assert.Equal(t, models.SubscriptionStatusPastDue, pastDue.Status)
+		assert.Equal(t, 1, pastDue.FailedAttempts)
+		require.NotNil(t, pastDue.NextAttemptAt)
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), *pastDue.NextAttemptAt, time.Minute)
+
+		// Retries aren't made before they're due
+		ts.processRenewals(t)
+		assert.Equal(t, 1, ts.get(t, customer, sub.ID).FailedAttempts)
+
+		ts.makeDue(t, sub.ID)
+		ts.processRenewals(t)
+		pastDue = ts.get(t, customer, sub.ID)
+		assert.Equal(t, 2, pastDue.FailedAttempts)
+		assert.WithinDuration(t, time.Now().Add(72*time.Hour), *pastDue.NextAttemptAt, time.Minute)
+
+		// All attempts charge the same renewal order
+		renewals := ts.renewals(t, customer, sub.ID)
+		require.Len(t, renewals, 2)
+		assert.Equal(t, 2, renewals[0].Attempts)
+		assert.Equal(t, models.RenewalStatusFailed, renewals[0].Status)
+	})
+
+	t.Run("Emails escalate until the subscription is canceled", func(t *testing.T) {
+		ts.makeDue(t, sub.ID)
+		ts.processRenewals(t)
+		ts.makeDue(t, sub.ID)
+		ts.processRenewals(t)
+
+		canceled := ts.get(t, customer, sub.ID)
+		assert.Equal(t, models.SubscriptionStatusCanceled, canceled.Status)
+		assert.Equal(t, []string{
+			"subscription_payment_failed",
+			"subscription_payment_reminder",
+			"subscription_payment_final_notice",
+			"subscription_canceled_unpaid",
+		}, ts.notifications.sent(email))
+	})
+
+	t.Run("A new payment method retries right away", func(t *testing.T) {
+		w := ts.subscribe(customer, plan.ID, "pm_ok")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var sub models.Subscription
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sub))
+
+		ts.payments.declined["pm_ok"] = true
+		ts.makeDue(t, sub.ID)
+		ts.processRenewals(t)
+		require.Equal(t, models.SubscriptionStatusPastDue, ts.get(t, customer, sub.ID).Status)
+
+		w = ts.do(http.MethodPut, "/api/v1/subscriptions/"+sub.ID.String()+"/payment-method", customer,
+			models.UpdatePaymentMethodRequest{PaymentMethod: "pm_new"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		ts.processRenewals(t)
+
+		recovered := ts.get(t, customer, sub.ID)
+		assert.Equal(t, models.SubscriptionStatusActive, recovered.Status)
+		assert.Equal(t, 0, recovered.FailedAttempts)
+		assert.Nil(t, recovered.NextAttemptAt)
+	})
+}
@@ -0,0 +1,96 @@
+package testenv
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	tckafka "github.com/testcontainers/testcontainers-go/modules/kafka"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	pkgkafka "github.com/kaanevranportfolio/Commercium/pkg/kafka"
+)
+
+// kafkaImage runs a single broker in KRaft mode, without ZooKeeper
+const kafkaImage = "confluentinc/confluent-local:7.5.0"
+
+var kafkaServer container[[]string]
+
+// startKafka starts a Kafka broker in a container, or returns the local one
+func startKafka(ctx context.Context) ([]string, error) {
+	if !useContainers() {
+		return []string{"localhost:9092"}, nil
+	}
+
+	server, err := tckafka.Run(ctx, kafkaImage, tckafka.WithClusterID("commercium-test"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start kafka container: %w", err)
+	}
+
+	brokers, err := server.Brokers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kafka container brokers: %w", err)
+	}
+	return brokers, nil
+}
+
+// Kafka returns the configuration of the Kafka cluster, writing messages
+// at once rather than in batches. Tests keep apart with topics of their
+// own, from Topic. t is skipped when Kafka isn't available.
+func Kafka(t testing.TB) config.KafkaConfig {
+	t.Helper()
+
+	brokers, err := kafkaServer.get(startKafka)
+	if err == nil {
+		err = pkgkafka.HealthCheck(brokers)
+	}
+	if err != nil {
+		t.Skipf("Kafka not available for integration tests: %v", err)
+	}
+
+	return config.KafkaConfig{
+		Brokers:          brokers,
+		BatchSize:        1,
+		BatchTimeout:     10 * time.Millisecond,
+		RetryMax:         3,
+		RetryBackoffMin:  100 * time.Millisecond,
+		RetryBackoffMax:  time.Second,
+		DeadLetterSuffix: ".dlq",
+	}
+}
+
+// Topic creates a topic of t's own on the cluster of cfg, named after name,
+// and returns its name
+func Topic(t testing.TB, cfg config.KafkaConfig, name string) string {
+	t.Helper()
+
+	topic := name + "." + strings.ReplaceAll(uuid.NewString(), "-", "")
+	conn, err := kafka.Dial("tcp", cfg.Brokers[0])
+	if err != nil {
+		t.Fatalf("Failed to connect to kafka: %v", err)
+	}
+	defer conn.Close()
+
+	// Topics are created on the controller
+	controller, err := conn.Controller()
+	if err != nil {
+		t.Fatalf("Failed to find kafka controller: %v", err)
+	}
+	controllerConn, err := kafka.Dial("tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	if err != nil {
+		t.Fatalf("Failed to connect to kafka controller: %v", err)
+	}
+	defer controllerConn.Close()
+
+	err = controllerConn.CreateTopics(kafka.TopicConfig{Topic: topic, NumPartitions: 1, ReplicationFactor: 1})
+	if err != nil {
+		t.Fatalf("Failed to create topic %s: %v", topic, err)
+	}
+	return topic
+}
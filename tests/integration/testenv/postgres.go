@@ -0,0 +1,140 @@
+package testenv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+
+	"github.com/kaanevranportfolio/Commercium/migrations"
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// postgresImage is the image of docker-compose
+const postgresImage = "postgres:15-alpine"
+
+var postgresServer container[config.DatabaseConfig]
+
+// localPostgres is the test database of docker-compose
+var localPostgres = config.DatabaseConfig{
+	Host:     "localhost",
+	Port:     5432,
+	User:     "commercium_user",
+	Password: "commercium_password",
+	Database: "commercium_test_db",
+	SSLMode:  "disable",
+}
+
+// startPostgres starts Postgres in a container, or returns the local one
+func startPostgres(ctx context.Context) (config.DatabaseConfig, error) {
+	cfg := localPostgres
+	cfg.MaxOpenConns = 10
+	cfg.MaxIdleConns = 5
+	cfg.MaxLifetime = 30 * time.Minute
+	cfg.MaxIdleTime = 15 * time.Minute
+	if !useContainers() {
+		return cfg, nil
+	}
+
+	server, err := postgres.Run(ctx, postgresImage,
+		postgres.WithDatabase(cfg.Database),
+		postgres.WithUsername(cfg.User),
+		postgres.WithPassword(cfg.Password),
+		postgres.BasicWaitStrategies(),
+	)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to start postgres container: %w", err)
+	}
+
+	host, err := server.Host(ctx)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to get postgres container host: %w", err)
+	}
+	port, err := server.MappedPort(ctx, "5432/tcp")
+	if err != nil {
+		return cfg, fmt.Errorf("failed to get postgres container port: %w", err)
+	}
+
+	cfg.Host = host
+	cfg.Port = port.Int()
+	return cfg, nil
+}
+
+// DatabaseConfig returns the configuration of a database for t: a schema
+// of its own, migrated, which is dropped when t ends. t is skipped when
+// Postgres isn't available.
+func DatabaseConfig(t testing.TB) config.DatabaseConfig {
+	t.Helper()
+
+	base, err := postgresServer.get(startPostgres)
+	if err != nil {
+		t.Skipf("Database not available for integration tests: %v", err)
+	}
+
+	log := quietLogger(t)
+	admin, err := database.New(base, log)
+	if err != nil {
+		t.Skipf("Database not available for integration tests: %v", err)
+	}
+
+	ctx := context.Background()
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	identifier := pgx.Identifier{schema}.Sanitize()
+
+	// Extensions are created once in public, which every schema sees
+	if _, err := admin.ExecContext(ctx, `CREATE EXTENSION IF NOT EXISTS "uuid-ossp" SCHEMA public`); err != nil {
+		admin.Close()
+		t.Fatalf("Failed to create extensions: %v", err)
+	}
+	if _, err := admin.ExecContext(ctx, "CREATE SCHEMA "+identifier); err != nil {
+		admin.Close()
+		t.Fatalf("Failed to create test schema: %v", err)
+	}
+	t.Cleanup(func() {
+		defer admin.Close()
+		if _, err := admin.ExecContext(context.Background(), "DROP SCHEMA "+identifier+" CASCADE"); err != nil {
+			t.Errorf("Failed to drop test schema %s: %v", schema, err)
+		}
+	})
+
+	cfg := base
+	cfg.SearchPath = schema + ", public"
+	migrate(t, cfg, log)
+	return cfg
+}
+
+// Database returns a connection to a database of t's own, as
+// DatabaseConfig describes it, which is closed when t ends
+func Database(t testing.TB, log *logger.Logger) *database.DB {
+	t.Helper()
+
+	cfg := DatabaseConfig(t)
+	db, err := database.New(cfg, log)
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// migrate runs the migrations of the services in the schema of cfg
+func migrate(t testing.TB, cfg config.DatabaseConfig, log *logger.Logger) {
+	t.Helper()
+
+	db, err := database.New(cfg, log)
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Migrate(database.NewMigrationSource(cfg, migrations.FS)); err != nil {
+		t.Fatalf("Failed to migrate test schema: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package testenv
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go/modules/redis"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/database"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// redisImage is the image of docker-compose
+const redisImage = "redis:7-alpine"
+
+var redisServer container[config.RedisConfig]
+
+// redisDatabases hands out the databases of the Redis server to tests, one
+// at a time each. Database 0 is left to development.
+var redisDatabases = func() chan int {
+	databases := make(chan int, 15)
+	for i := 1; i <= 15; i++ {
+		databases <- i
+	}
+	return databases
+}()
+
+// startRedis starts Redis in a container, or returns the local one
+func startRedis(ctx context.Context) (config.RedisConfig, error) {
+	cfg := config.RedisConfig{
+		Host:         "localhost",
+		Port:         6379,
+		PoolSize:     5,
+		PoolTimeout:  30 * time.Second,
+		ReadTimeout:  3 * time.Second,
+		WriteTimeout: 3 * time.Second,
+	}
+	if !useContainers() {
+		return cfg, nil
+	}
+
+	server, err := redis.Run(ctx, redisImage)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to start redis container: %w", err)
+	}
+
+	host, err := server.Host(ctx)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to get redis container host: %w", err)
+	}
+	port, err := server.MappedPort(ctx, "6379/tcp")
+	if err != nil {
+		return cfg, fmt.Errorf("failed to get redis container port: %w", err)
+	}
+
+	cfg.Host = host
+	cfg.Port = port.Int()
+	return cfg, nil
+}
+
+// Redis returns a connection to a Redis database of t's own, empty, which
+// is emptied and closed when t ends. t is skipped when Redis isn't
+// available.
+func Redis(t testing.TB, log *logger.Logger) *database.Redis {
+	t.Helper()
+
+	cfg, err := redisServer.get(startRedis)
+	if err != nil {
+		t.Skipf("Redis not available for integration tests: %v", err)
+	}
+
+	cfg.Database = <-redisDatabases
+	client, err := database.NewRedis(cfg, log)
+	if err != nil {
+		redisDatabases <- cfg.Database
+		t.Skipf("Redis not available for integration tests: %v", err)
+	}
+
+	t.Cleanup(func() {
+		defer func() { redisDatabases <- cfg.Database }()
+		if err := client.FlushDB(context.Background()).Err(); err != nil {
+			t.Errorf("Failed to empty test Redis database: %v", err)
+		}
+		client.Close()
+	})
+	if err := client.FlushDB(context.Background()).Err(); err != nil {
+		t.Fatalf("Failed to empty test Redis database: %v", err)
+	}
+	return client
+}
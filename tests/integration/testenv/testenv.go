@@ -0,0 +1,88 @@
+// Package testenv provides the dependencies of integration tests: Postgres,
+// Redis and Kafka. They run in containers started with testcontainers the
+// first time a test of the package needs them, and are shared by the tests
+// of the package. Each test gets a schema of its own, migrated, and a Redis
+// database of its own, both dropped when it ends.
+//
+// Without Docker, or with COMMERCIUM_TEST_SERVICES=local, tests use the
+// services of docker-compose on localhost instead, and are skipped when
+// those aren't running either.
+package testenv
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+
+	"github.com/kaanevranportfolio/Commercium/pkg/config"
+	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+)
+
+// startTimeout bounds starting a container, images pulled included
+const startTimeout = 3 * time.Minute
+
+var (
+	dockerOnce sync.Once
+	docker     bool
+)
+
+// useContainers reports whether dependencies run in containers: Docker is
+// available and tests weren't told to use local services
+func useContainers() bool {
+	dockerOnce.Do(func() {
+		if os.Getenv("COMMERCIUM_TEST_SERVICES") == "local" {
+			return
+		}
+
+		// testcontainers panics when it finds no Docker host at all
+		defer func() {
+			if recover() != nil {
+				docker = false
+			}
+		}()
+		provider, err := testcontainers.NewDockerProvider()
+		if err != nil {
+			return
+		}
+		defer provider.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		docker = provider.Health(ctx) == nil
+	})
+	return docker
+}
+
+// container starts a dependency once for the tests of the package, and
+// returns what it started, or why it couldn't
+type container[T any] struct {
+	once  sync.Once
+	value T
+	err   error
+}
+
+func (c *container[T]) get(start func(ctx context.Context) (T, error)) (T, error) {
+	c.once.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+		defer cancel()
+		c.value, c.err = start(ctx)
+	})
+	return c.value, c.err
+}
+
+// quietLogger logs errors only, for the harness's own connections
+func quietLogger(t testing.TB) *logger.Logger {
+	log, err := logger.New(config.LoggerConfig{
+		Level:  "error",
+		Format: "json",
+		Output: "stdout",
+	}, "testenv")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	return log
+}
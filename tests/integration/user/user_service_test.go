@@ -5,23 +5,35 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 
+	"github.com/kaanevranportfolio/Commercium/api/openapi"
 	"github.com/kaanevranportfolio/Commercium/internal/user/handlers"
 	"github.com/kaanevranportfolio/Commercium/internal/user/models"
 	"github.com/kaanevranportfolio/Commercium/internal/user/repository"
 	"github.com/kaanevranportfolio/Commercium/internal/user/service"
+	"github.com/kaanevranportfolio/Commercium/pkg/apperrors"
 	"github.com/kaanevranportfolio/Commercium/pkg/auth"
 	"github.com/kaanevranportfolio/Commercium/pkg/config"
 	"github.com/kaanevranportfolio/Commercium/pkg/database"
 	"github.com/kaanevranportfolio/Commercium/pkg/logger"
+	userpb "github.com/kaanevranportfolio/Commercium/proto/user"
+	"github.com/kaanevranportfolio/Commercium/tests/integration/testenv"
 )
 
 type TestSuite struct {
@@ -38,28 +50,6 @@ type TestSuite struct {
 func setupTestSuite(t *testing.T) *TestSuite {
 	// Load test configuration
 	cfg := &config.Config{
-		Database: config.DatabaseConfig{
-			Host:         "localhost",
-			Port:         5432,
-			User:         "commercium_user", 
-			Password:     "commercium_password",
-			Database:     "commercium_test_db",
-			SSLMode:      "disable",
-			MaxOpenConns: 10,
-			MaxIdleConns: 5,
-			MaxLifetime:  30 * time.Minute,
-			MaxIdleTime:  15 * time.Minute,
-		},
-		Redis: config.RedisConfig{
-			Host:         "localhost",
-			Port:         6379,
-			Password:     "",
-			Database:     1, // Use different DB for tests
-			PoolSize:     5,
-			PoolTimeout:  30 * time.Second,
-			ReadTimeout:  3 * time.Second,
-			WriteTimeout: 3 * time.Second,
-		},
 		Auth: config.AuthConfig{
 			JWT: config.JWTConfig{
 				SecretKey:         "test-secret-key-for-testing-only",
@@ -78,31 +68,26 @@ func setupTestSuite(t *testing.T) *TestSuite {
 	}, "user-service-test")
 	require.NoError(t, err)
 
-	// Initialize database (skip if not available)
-	db, err := database.New(cfg.Database, log)
-	if err != nil {
-		t.Skipf("Database not available for integration tests: %v", err)
-	}
+	// Each test gets a database of its own (skipped if not available)
+	db := testenv.Database(t, log)
 
-	// Initialize Redis (skip if not available)
-	redis, err := database.NewRedis(cfg.Redis, log)
-	if err != nil {
-		t.Skipf("Redis not available for integration tests: %v", err)
-	}
+	// And a Redis database of its own
+	redis := testenv.Redis(t, log)
 
 	// Initialize JWT service
 	jwtService := auth.NewJWTService(&cfg.Auth.JWT)
 
 	// Initialize repository and service
 	userRepo := repository.NewUserRepository(db, log)
-	userService := service.NewUserService(userRepo, jwtService, redis, cfg, log)
+	userService := service.NewUserService(userRepo, jwtService, redis, redis, nil, nil, nil, cfg, log)
 
 	// Initialize handler
-	userHandler := handlers.NewUserHandler(userService, jwtService, log)
+	userHandler := handlers.NewUserHandler(userService, jwtService)
 
 	// Setup Gin router
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
+	router.Use(apperrors.Middleware())
 	userHandler.SetupRoutes(router)
 
 	return &TestSuite{
@@ -123,10 +108,6 @@ func (ts *TestSuite) cleanup() {
 	
 	// Clean up Redis test data
 	ts.redis.Client.FlushDB(ctx)
-	
-	// Close connections
-	ts.db.Close()
-	ts.redis.Close()
 }
 
 func TestUserServiceIntegration(t *testing.T) {
@@ -355,7 +336,7 @@ func TestUserServiceIntegration(t *testing.T) {
 		err = json.Unmarshal(w.Body.Bytes(), &getResp)
 		require.NoError(t, err)
 
-		addresses := getResp["addresses"].([]interface{})
+		addresses := getResp["data"].([]interface{})
 		assert.Len(t, addresses, 1)
 
 		firstAddress := addresses[0].(map[string]interface{})
@@ -387,6 +368,36 @@ func TestUserServiceErrors(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 
+	t.Run("Validation Errors Name the Invalid Fields", func(t *testing.T) {
+		registerReq := models.CreateUserRequest{
+			Username: "fielderrors",
+			Email:    "invalid-email",
+			Password: "alllowercase",
+		}
+
+		registerBody, _ := json.Marshal(registerReq)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", bytes.NewReader(registerBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", "de-DE,de;q=0.9")
+		w := httptest.NewRecorder()
+
+		ts.router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, apperrors.ProblemContentType, w.Header().Get("Content-Type"))
+
+		var problem apperrors.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "Ungültige Anfrage", problem.Title)
+		assert.Equal(t, "Ungültige Anfragedaten", problem.Detail)
+		require.Len(t, problem.FieldErrors, 2)
+		assert.Equal(t, "email", problem.FieldErrors[0].Field)
+		assert.Equal(t, "email", problem.FieldErrors[0].Code)
+		assert.Equal(t, "password", problem.FieldErrors[1].Field)
+		assert.Equal(t, "password", problem.FieldErrors[1].Code)
+		assert.Equal(t, "password muss Groß- und Kleinbuchstaben und eine Ziffer enthalten", problem.Errors["password"])
+	})
+
 	t.Run("Login with Invalid Credentials", func(t *testing.T) {
 		loginReq := models.LoginRequest{
 			Username: "nonexistentuser",
@@ -439,3 +450,139 @@ func TestUserServiceErrors(t *testing.T) {
 		assert.Equal(t, "Invalid token", errorResp["error"])
 	})
 }
+
+func TestUserGRPCIntegration(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.cleanup()
+
+	ctx := context.Background()
+
+	// Serve the gRPC API over an in-memory connection
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	handlers.NewGRPCHandler(ts.userService, ts.logger).Register(server)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := userpb.NewUserServiceClient(conn)
+
+	suffix := uuid.New().String()[:8]
+	user, err := ts.userService.Register(ctx, &models.CreateUserRequest{
+		Username: "grpcuser" + suffix,
+		Email:    "grpc" + suffix + "@example.com",
+		Password: "SecurePassword123!",
+	})
+	require.NoError(t, err)
+	defer ts.db.Exec(`DELETE FROM users WHERE id = $1`, user.ID)
+
+	t.Run("GetUser", func(t *testing.T) {
+		resp, err := client.GetUser(ctx, &userpb.GetUserRequest{UserId: user.ID.String()})
+		require.NoError(t, err)
+		assert.Equal(t, user.ID.String(), resp.User.Id)
+		assert.Equal(t, user.Email, resp.User.Email)
+		assert.True(t, resp.User.IsActive)
+		assert.Nil(t, resp.User.LastLoginAt)
+
+		_, err = client.GetUser(ctx, &userpb.GetUserRequest{UserId: uuid.New().String()})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		_, err = client.GetUser(ctx, &userpb.GetUserRequest{UserId: "not-a-uuid"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("ValidateCredentials", func(t *testing.T) {
+		resp, err := client.ValidateCredentials(ctx, &userpb.ValidateCredentialsRequest{
+			Username: user.Email,
+			Password: "SecurePassword123!",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, user.ID.String(), resp.User.Id)
+
+		_, err = client.ValidateCredentials(ctx, &userpb.ValidateCredentialsRequest{
+			Username: user.Username,
+			Password: "WrongPassword123!",
+		})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		_, err = ts.db.Exec(`UPDATE users SET is_active = false WHERE id = $1`, user.ID)
+		require.NoError(t, err)
+		_, err = client.ValidateCredentials(ctx, &userpb.ValidateCredentialsRequest{
+			Username: user.Username,
+			Password: "SecurePassword123!",
+		})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		_, err = ts.db.Exec(`UPDATE users SET is_active = true WHERE id = $1`, user.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("GetAddresses", func(t *testing.T) {
+		for _, addressType := range []string{"shipping", "billing"} {
+			_, err := ts.userService.CreateAddress(ctx, user.ID, &models.UserAddress{
+				Type:         addressType,
+				FirstName:    "Jane",
+				LastName:     "Doe",
+				AddressLine1: "1 Broadway",
+				City:         "New York",
+				PostalCode:   "10004",
+				Country:      "US",
+			})
+			require.NoError(t, err)
+		}
+
+		resp, err := client.GetAddresses(ctx, &userpb.GetAddressesRequest{UserId: user.ID.String()})
+		require.NoError(t, err)
+		assert.Len(t, resp.Addresses, 2)
+
+		shipping := "shipping"
+		resp, err = client.GetAddresses(ctx, &userpb.GetAddressesRequest{UserId: user.ID.String(), Type: &shipping})
+		require.NoError(t, err)
+		require.Len(t, resp.Addresses, 1)
+		assert.Equal(t, "shipping", resp.Addresses[0].Type)
+		assert.Equal(t, "US", resp.Addresses[0].Country)
+	})
+}
+
+// TestOpenAPISpec checks the spec the user service serves documents every
+// route it has, and no other
+func TestOpenAPISpec(t *testing.T) {
+	spec, err := openapi.Spec("user-service")
+	require.NoError(t, err)
+
+	var document struct {
+		OpenAPI string                            `json:"openapi"`
+		Paths   map[string]map[string]interface{} `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(spec, &document))
+	assert.Equal(t, "3.0.3", document.OpenAPI)
+
+	documented := make(map[string]bool)
+	for path, operations := range document.Paths {
+		for method := range operations {
+			if method != "parameters" {
+				documented[strings.ToUpper(method)+" "+path] = true
+			}
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.NewUserHandler(nil, nil).SetupRoutes(router)
+	routes := make(map[string]bool)
+	for _, route := range router.Routes() {
+		// Gin's :id is OpenAPI's {id}
+		segments := strings.Split(route.Path, "/")
+		for i, segment := range segments {
+			if name, ok := strings.CutPrefix(segment, ":"); ok {
+				segments[i] = "{" + name + "}"
+			}
+		}
+		routes[route.Method+" "+strings.Join(segments, "/")] = true
+	}
+
+	assert.Equal(t, routes, documented)
+}
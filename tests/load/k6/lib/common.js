@@ -0,0 +1,48 @@
+// Helpers shared by the k6 scenarios. Scenarios are configured with
+// environment variables, passed to k6 with -e NAME=value.
+import http from 'k6/http';
+import { check, fail } from 'k6';
+
+export const JSON_PARAMS = { headers: { 'Content-Type': 'application/json' } };
+
+// DURATION is how long each scenario runs at its rate
+export const DURATION = __ENV.DURATION || '1m';
+
+// env returns the environment variable name, or fallback when it isn't set
+export function env(name, fallback) {
+  return __ENV[name] || fallback;
+}
+
+// rate returns the environment variable name as requests per second, or
+// fallback when it isn't set
+export function rate(name, fallback) {
+  return parseInt(env(name, String(fallback)), 10);
+}
+
+// throughput returns the threshold holding a scenario to at least 90% of
+// the rate it was run at: requests it couldn't start in time, because the
+// service fell behind, count against it. The rest is left to setup, which
+// counts towards the duration of the test.
+export function throughput(requestsPerSecond) {
+  return [`rate>=${requestsPerSecond * 0.9}`];
+}
+
+// login logs in to the user service at baseURL and returns the access token.
+// The iteration fails when it can't.
+export function login(baseURL, username, password) {
+  const res = http.post(
+    `${baseURL}/api/v1/auth/login`,
+    JSON.stringify({ username, password }),
+    Object.assign({ tags: { name: 'login' } }, JSON_PARAMS),
+  );
+  if (!check(res, { 'logged in': (r) => r.status === 200 })) {
+    fail(`login as ${username} failed with status ${res.status}: ${res.body}`);
+  }
+  return res.json('access_token');
+}
+
+// bearer returns the params of a request authenticated with token, tagged
+// with name
+export function bearer(token, name) {
+  return { headers: { Authorization: `Bearer ${token}` }, tags: { name } };
+}
@@ -0,0 +1,48 @@
+// Load test of product listing: the demo seller, from the development seed
+// sets, listing the products of their store. Run against a seller service
+// on a seeded database, with the user service to log in to.
+//
+//	k6 run -e USER_URL=http://localhost:8080 -e SELLER_URL=http://localhost:8081 \
+//	  tests/load/k6/product-load-test.js
+import http from 'k6/http';
+import { check } from 'k6';
+import { DURATION, bearer, env, login, rate, throughput } from './lib/common.js';
+
+const USER_URL = env('USER_URL', 'http://localhost:8080');
+const SELLER_URL = env('SELLER_URL', 'http://localhost:8080');
+const SELLER_USERNAME = env('SELLER_USERNAME', 'seller@demo.commercium.local');
+const SELLER_PASSWORD = env('SELLER_PASSWORD', 'demo-seller-password');
+const LIST_RATE = rate('LIST_RATE', 200);
+
+export const options = {
+  scenarios: {
+    list_products: {
+      executor: 'constant-arrival-rate',
+      exec: 'listProducts',
+      rate: LIST_RATE,
+      timeUnit: '1s',
+      duration: DURATION,
+      preAllocatedVUs: 50,
+      maxVUs: 200,
+    },
+  },
+  // The baselines: a run failing them is a regression
+  thresholds: {
+    http_req_failed: ['rate<0.01'],
+    checks: ['rate>0.99'],
+    'http_req_duration{name:list_products}': ['p(95)<150', 'p(99)<300'],
+    'http_reqs{name:list_products}': throughput(LIST_RATE),
+  },
+};
+
+export function setup() {
+  return { token: login(USER_URL, SELLER_USERNAME, SELLER_PASSWORD) };
+}
+
+export function listProducts(data) {
+  const res = http.get(`${SELLER_URL}/api/v1/seller/products`, bearer(data.token, 'list_products'));
+  check(res, {
+    'products listed': (r) => r.status === 200,
+    'products returned': (r) => r.status === 200 && Array.isArray(r.json('data')),
+  });
+}
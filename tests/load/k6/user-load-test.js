@@ -0,0 +1,84 @@
+// Load test of the user service's hot paths: logging in, and requests
+// authenticated with the access token, which every service validates the
+// same way. Run against a user service with the sensitive endpoints' rate
+// limit off (SERVICES_USER_SERVICE_RATE_LIMITING_ENABLED=false), or logins
+// are limited long before the service is; make load-test runs one.
+//
+//	k6 run -e BASE_URL=http://localhost:8080 tests/load/k6/user-load-test.js
+import http from 'k6/http';
+import { check } from 'k6';
+import { DURATION, JSON_PARAMS, bearer, env, login, rate, throughput } from './lib/common.js';
+
+const BASE_URL = env('BASE_URL', 'http://localhost:8080');
+const USERS = rate('USERS', 20);
+const LOGIN_RATE = rate('LOGIN_RATE', 10);
+const PROFILE_RATE = rate('PROFILE_RATE', 200);
+const PASSWORD = 'LoadTest123!';
+
+export const options = {
+  scenarios: {
+    login: {
+      executor: 'constant-arrival-rate',
+      exec: 'loginUser',
+      rate: LOGIN_RATE,
+      timeUnit: '1s',
+      duration: DURATION,
+      preAllocatedVUs: 20,
+      maxVUs: 100,
+    },
+    profile: {
+      executor: 'constant-arrival-rate',
+      exec: 'getProfile',
+      rate: PROFILE_RATE,
+      timeUnit: '1s',
+      duration: DURATION,
+      preAllocatedVUs: 50,
+      maxVUs: 200,
+    },
+  },
+  // The baselines: a run failing them is a regression
+  thresholds: {
+    http_req_failed: ['rate<0.01'],
+    checks: ['rate>0.99'],
+    // Logins are bound by bcrypt, at its default cost
+    'http_req_duration{name:login}': ['p(95)<500', 'p(99)<1000'],
+    'http_req_duration{name:profile}': ['p(95)<100', 'p(99)<250'],
+    'http_reqs{name:login}': throughput(LOGIN_RATE),
+    'http_reqs{name:profile}': throughput(PROFILE_RATE),
+  },
+};
+
+// setup registers the users of the test, and logs them in for the
+// authenticated requests
+export function setup() {
+  const run = Date.now().toString(36);
+  const users = [];
+  for (let i = 0; i < USERS; i++) {
+    const user = { username: `load-${run}-${i}`, email: `load-${run}-${i}@load.commercium.local` };
+    const res = http.post(
+      `${BASE_URL}/api/v1/auth/register`,
+      JSON.stringify(Object.assign({ password: PASSWORD }, user)),
+      Object.assign({ tags: { name: 'register' } }, JSON_PARAMS),
+    );
+    check(res, { registered: (r) => r.status === 201 });
+    user.token = login(BASE_URL, user.username, PASSWORD);
+    users.push(user);
+  }
+  return { users };
+}
+
+function pick(users) {
+  return users[Math.floor(Math.random() * users.length)];
+}
+
+export function loginUser(data) {
+  login(BASE_URL, pick(data.users).username, PASSWORD);
+}
+
+export function getProfile(data) {
+  const user = pick(data.users);
+  const res = http.get(`${BASE_URL}/api/v1/users/profile`, bearer(user.token, 'profile'));
+  check(res, {
+    'profile returned': (r) => r.status === 200,
+  });
+}